@@ -0,0 +1,89 @@
+// Command openapi writes the OpenAPI document of the server and a typed
+// TypeScript client generated from it.
+//
+// The router is built against an empty temporary database, so no data is
+// needed. Run it through go generate ./internal/api after changing routes or
+// models.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/api"
+	"github.com/jengzang/records-backend-go/internal/config"
+	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/openapi"
+)
+
+func main() {
+	out := flag.String("out", "docs/openapi.json", "path of the OpenAPI document")
+	ts := flag.String("ts", "", "path of the TypeScript client, empty to skip it")
+	strict := flag.Bool("strict", false, "fail when routes are undocumented")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+
+	dir, err := os.MkdirTemp("", "records-openapi")
+	if err != nil {
+		log.Fatal("Failed to create temporary directory:", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := database.Init(database.Config{Path: filepath.Join(dir, "schema.db")}); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer database.Close()
+
+	// Routes only; the analysis schedules, the watch directory, the MQTT
+	// subscriber and the notification channels are not started
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	cfg.ScheduleIncremental, cfg.ScheduleFull = "", ""
+	cfg.ImportWatchDir, cfg.MQTTURL = "", ""
+	cfg.WebhookURLs, cfg.TelegramBotToken, cfg.BarkURL = nil, "", ""
+	router := api.SetupRouter(cfg)
+
+	if missing := api.UndocumentedRoutes(router); len(missing) > 0 {
+		for _, route := range missing {
+			log.Printf("Undocumented route: %s", route)
+		}
+		if *strict {
+			log.Fatalf("%d undocumented routes", len(missing))
+		}
+	}
+
+	doc := api.Spec(router)
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatal("Failed to encode OpenAPI document:", err)
+	}
+	if err := writeFile(*out, append(body, '\n')); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %s (%d paths)", *out, len(doc.Paths))
+
+	if *ts != "" {
+		var buf bytes.Buffer
+		if err := openapi.WriteTypeScript(&buf, doc); err != nil {
+			log.Fatal("Failed to generate TypeScript client:", err)
+		}
+		if err := writeFile(*ts, buf.Bytes()); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Wrote %s", *ts)
+	}
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -1,17 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/jengzang/records-backend-go/internal/analysis"
 	"github.com/jengzang/records-backend-go/internal/api"
 	"github.com/jengzang/records-backend-go/internal/config"
 	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/elevation"
+	"github.com/jengzang/records-backend-go/internal/geocode"
+	"github.com/jengzang/records-backend-go/internal/mapmatch"
+	"github.com/jengzang/records-backend-go/internal/poi"
+	"github.com/jengzang/records-backend-go/internal/weather"
 
 	// Import analyzer packages to register them
 	_ "github.com/jengzang/records-backend-go/internal/analysis/advanced"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/annotation"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/behavior"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/foundation"
+	_ "github.com/jengzang/records-backend-go/internal/analysis/insights"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/spatial"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/stats"
 	_ "github.com/jengzang/records-backend-go/internal/analysis/temporal"
@@ -20,8 +33,27 @@ import (
 )
 
 func main() {
-	// 加载配置
-	cfg := config.Load()
+	// 加载配置（配置文件 + 环境变量）
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration: ", err)
+	}
+	if cfg.File != "" {
+		log.Printf("Loaded configuration from %s", cfg.File)
+	}
+	config.SetCurrent(cfg)
+
+	// 从备份恢复数据库后退出（需先停止服务）
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := restore(cfg, os.Args[2:]); err != nil {
+			log.Fatal("Restore failed: ", err)
+		}
+		return
+	}
+
+	// 禁用配置中指定的分析器（可热加载）
+	disableAnalyzers(cfg)
+	config.OnReload(disableAnalyzers)
 
 	// 初始化数据库
 	dbConfig := database.Config{
@@ -32,12 +64,203 @@ func main() {
 	}
 	defer database.Close()
 
+	// 分析器的写操作统一经由单一写入队列
+	analysis.SetWriter(database.GetWriter())
+
+	// 加载行政区边界（缺少文件时 geocode_backfill 不可用）
+	if _, err := os.Stat(cfg.GeocodeBoundaryPath); err == nil {
+		provider, err := geocode.LoadBoundaryProvider(cfg.GeocodeBoundaryPath, geocode.DefaultBoundaryOptions)
+		if err != nil {
+			log.Printf("Warning: failed to load geocoding boundaries: %v", err)
+		} else {
+			geocode.SetDefaultProvider(geocode.NewCachedProvider(provider, 7))
+			geocode.DefaultDivisions().Add(provider.Divisions()...)
+			log.Printf("Loaded %d geocoding boundaries from %s", provider.FeatureCount(), cfg.GeocodeBoundaryPath)
+		}
+	} else {
+		log.Printf("Geocoding boundaries not found at %s, geocode_backfill disabled", cfg.GeocodeBoundaryPath)
+	}
+
+	// 加载行政区划目录（覆盖率统计的分母，内置目录只到地级）
+	if cfg.AdminDivisionsPath != "" {
+		if err := geocode.DefaultDivisions().LoadFile(cfg.AdminDivisionsPath); err != nil {
+			log.Printf("Warning: failed to load admin divisions: %v", err)
+		} else {
+			log.Printf("Loaded admin divisions from %s", cfg.AdminDivisionsPath)
+		}
+	}
+
+	// 配置地图匹配（未配置时 map_matching 不可用）
+	switch cfg.MapMatchBackend {
+	case "osrm", "valhalla":
+		if cfg.MapMatchURL == "" {
+			log.Printf("Warning: MAP_MATCH_URL is required for the %s map matcher, map_matching disabled", cfg.MapMatchBackend)
+			break
+		}
+		if cfg.MapMatchBackend == "osrm" {
+			mapmatch.SetDefaultMatcher(mapmatch.NewOSRMMatcher(cfg.MapMatchURL, cfg.MapMatchProfile))
+		} else {
+			mapmatch.SetDefaultMatcher(mapmatch.NewValhallaMatcher(cfg.MapMatchURL, cfg.MapMatchProfile))
+		}
+		log.Printf("Using %s map matcher at %s", cfg.MapMatchBackend, cfg.MapMatchURL)
+	case "hmm", "":
+		if _, err := os.Stat(cfg.RoadNetworkPath); err != nil {
+			if cfg.MapMatchBackend == "hmm" {
+				log.Printf("Warning: road network not found at %s, map_matching disabled", cfg.RoadNetworkPath)
+			}
+			break
+		}
+		network, err := mapmatch.LoadRoadNetwork(cfg.RoadNetworkPath)
+		if err != nil {
+			log.Printf("Warning: failed to load road network: %v", err)
+			break
+		}
+		mapmatch.SetDefaultMatcher(mapmatch.NewHMMMatcher(network, mapmatch.DefaultHMMOptions))
+		log.Printf("Loaded %d road edges from %s for offline map matching", network.EdgeCount(), cfg.RoadNetworkPath)
+	default:
+		log.Printf("Warning: unknown MAP_MATCH_BACKEND %q, map_matching disabled", cfg.MapMatchBackend)
+	}
+
+	// 配置高程数据源（未配置时 elevation_backfill 不可用）
+	switch cfg.ElevationBackend {
+	case "opentopodata":
+		if cfg.ElevationURL == "" {
+			log.Printf("Warning: ELEVATION_URL is required for the opentopodata elevation provider, elevation_backfill disabled")
+			break
+		}
+		elevation.SetDefaultProvider(elevation.NewOpenTopoDataProvider(cfg.ElevationURL, cfg.ElevationDataset))
+		log.Printf("Using opentopodata elevation provider at %s", cfg.ElevationURL)
+	case "srtm", "":
+		if _, err := os.Stat(cfg.SRTMDir); err != nil {
+			if cfg.ElevationBackend == "srtm" {
+				log.Printf("Warning: SRTM tiles not found at %s, elevation_backfill disabled", cfg.SRTMDir)
+			}
+			break
+		}
+		elevation.SetDefaultProvider(elevation.NewSRTMProvider(cfg.SRTMDir))
+		log.Printf("Using SRTM tiles from %s for elevation", cfg.SRTMDir)
+	default:
+		log.Printf("Warning: unknown ELEVATION_BACKEND %q, elevation_backfill disabled", cfg.ElevationBackend)
+	}
+
+	// 配置POI数据源（未配置时 poi_enrichment 不可用）
+	switch cfg.POIBackend {
+	case "amap", "overpass":
+		var provider poi.Provider
+		if cfg.POIBackend == "amap" {
+			if cfg.POIAPIKey == "" {
+				log.Printf("Warning: POI_API_KEY is required for the amap POI provider, poi_enrichment disabled")
+				break
+			}
+			provider = poi.NewAmapProvider(cfg.POIURL, cfg.POIAPIKey)
+		} else {
+			provider = poi.NewOverpassProvider(cfg.POIURL)
+		}
+		poi.SetDefaultProvider(poi.NewCachedProvider(poi.NewRateLimitedProvider(provider, cfg.POIRateLimit), 7, cfg.POICacheTTL))
+		log.Printf("Using %s POI provider (%.1f requests/s)", cfg.POIBackend, cfg.POIRateLimit)
+	case "snapshot", "":
+		if _, err := os.Stat(cfg.POISnapshotPath); err != nil {
+			if cfg.POIBackend == "snapshot" {
+				log.Printf("Warning: POI snapshot not found at %s, poi_enrichment disabled", cfg.POISnapshotPath)
+			}
+			break
+		}
+		provider, err := poi.LoadSnapshotProvider(cfg.POISnapshotPath)
+		if err != nil {
+			log.Printf("Warning: failed to load POI snapshot: %v", err)
+			break
+		}
+		poi.SetDefaultProvider(provider)
+		log.Printf("Loaded %d POIs from %s", provider.Count(), cfg.POISnapshotPath)
+	default:
+		log.Printf("Warning: unknown POI_BACKEND %q, poi_enrichment disabled", cfg.POIBackend)
+	}
+
+	// 配置天气数据源（未配置时 weather_enrichment 不可用）
+	switch cfg.WeatherBackend {
+	case "openmeteo":
+		weather.SetDefaultProvider(weather.NewOpenMeteoProvider(cfg.WeatherURL))
+		log.Printf("Using Open-Meteo weather provider")
+	case "era5", "":
+		if _, err := os.Stat(cfg.WeatherERA5Path); err != nil {
+			if cfg.WeatherBackend == "era5" {
+				log.Printf("Warning: ERA5 extract not found at %s, weather_enrichment disabled", cfg.WeatherERA5Path)
+			}
+			break
+		}
+		provider, err := weather.LoadERA5Provider(cfg.WeatherERA5Path)
+		if err != nil {
+			log.Printf("Warning: failed to load ERA5 extract: %v", err)
+			break
+		}
+		weather.SetDefaultProvider(provider)
+		log.Printf("Loaded ERA5 weather for %d grid points from %s", provider.Cells(), cfg.WeatherERA5Path)
+	default:
+		log.Printf("Warning: unknown WEATHER_BACKEND %q, weather_enrichment disabled", cfg.WeatherBackend)
+	}
+
 	// 初始化路由
 	router := api.SetupRouter(cfg)
 
+	// SIGHUP 热加载配置
+	reloadOnSIGHUP()
+
 	// 启动服务器
-	log.Printf("Server starting on port %s", cfg.Port)
-	if err := router.Run(cfg.Port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := &http.Server{Addr: cfg.Port, Handler: router}
+	go func() {
+		log.Printf("Server starting on port %s", cfg.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// SIGINT / SIGTERM 优雅退出：停止接收请求，等待分析任务完成（超过 SHUTDOWN_TIMEOUT 时取消并标记为 interrupted），
+	// 再关闭数据库；再次收到信号时立即退出
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	sig := <-signals
+	timeout := config.Current().ShutdownTimeout
+	log.Printf("Received %s, shutting down (timeout %s)", sig, timeout)
+	go func() {
+		<-signals
+		log.Fatal("Forced shutdown")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Warning: HTTP server did not shut down cleanly: %v", err)
 	}
+	api.Shutdown(ctx)
+	log.Printf("Server stopped")
+}
+
+// disableAnalyzers disables the analyzers listed in DISABLED_ANALYZERS,
+// enabling the others
+func disableAnalyzers(cfg *config.Config) {
+	if unknown := analysis.SetDisabledAnalyzers(cfg.DisabledAnalyzers...); len(unknown) > 0 {
+		log.Printf("Warning: unknown analyzers in DISABLED_ANALYZERS: %v", unknown)
+	}
+	if len(cfg.DisabledAnalyzers) > 0 {
+		log.Printf("Disabled analyzers: %v", cfg.DisabledAnalyzers)
+	}
+}
+
+// reloadOnSIGHUP reloads the configuration whenever the process receives SIGHUP
+func reloadOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			result, err := config.Reload()
+			if err != nil {
+				log.Printf("Warning: failed to reload configuration: %v", err)
+				continue
+			}
+			log.Printf("Configuration reloaded: applied %v", result.Applied)
+			if len(result.RestartRequired) > 0 {
+				log.Printf("Warning: configuration changes need a restart: %v", result.RestartRequired)
+			}
+		}
+	}()
 }
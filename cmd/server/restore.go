@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/jengzang/records-backend-go/internal/api"
+	"github.com/jengzang/records-backend-go/internal/backup"
+	"github.com/jengzang/records-backend-go/internal/config"
+)
+
+// restore implements `server restore [-name NAME | -file PATH]`: it replaces
+// the database at DB_PATH with a backup from the configured backup store
+// (the newest by default) or a local file, then exits
+// Stop the server first; the replaced database is kept with a
+// .before-restore suffix.
+func restore(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	name := fs.String("name", "", "backup in the backup store to restore, default the newest")
+	file := fs.String("file", "", "local backup file (.db.gz or .db) to restore instead")
+	list := fs.Bool("list", false, "list the backups in the backup store and exit")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	store := api.BackupStore(cfg)
+
+	if *list {
+		objects, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			fmt.Printf("%s\t%d\n", obj.Name, obj.Size)
+		}
+		return nil
+	}
+
+	var r io.ReadCloser
+	var source string
+	switch {
+	case *file != "":
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		r, source = f, *file
+	default:
+		if *name == "" {
+			latest, err := backup.Latest(ctx, store)
+			if err != nil {
+				return fmt.Errorf("%s: %w", store.Location(), err)
+			}
+			*name = latest.Name
+		}
+		var err error
+		if r, err = store.Get(ctx, *name); err != nil {
+			return err
+		}
+		source = store.Location() + " " + *name
+	}
+	defer r.Close()
+
+	log.Printf("Restoring %s from %s", cfg.DBPath, source)
+	if err := backup.Restore(r, cfg.DBPath); err != nil {
+		return err
+	}
+	log.Printf("Restored %s; the previous database was kept as %s.before-restore", cfg.DBPath, cfg.DBPath)
+	return nil
+}
@@ -0,0 +1,3817 @@
+// Code generated from the OpenAPI document of Records Backend API 1.0.0. DO NOT EDIT.
+
+export interface AdminCoverage {
+  ratio: number;
+  total_children: number;
+  visited_children: number;
+}
+
+export interface AdminCrossing {
+  algo_version?: string;
+  created_at: string;
+  crossing_ts: number;
+  crossing_type: string;
+  distance_from_prev_m: number;
+  from_city?: string;
+  from_country?: string;
+  from_county?: string;
+  from_province?: string;
+  from_town?: string;
+  from_ts?: number;
+  id: number;
+  latitude: number;
+  longitude: number;
+  to_city?: string;
+  to_country?: string;
+  to_county?: string;
+  to_province?: string;
+  to_town?: string;
+}
+
+export interface AdminStats {
+  admin_level: string;
+  admin_name: string;
+  algo_version?: string;
+  created_at: string;
+  first_visit_ts?: number;
+  id: number;
+  last_visit_ts?: number;
+  parent_name?: string;
+  total_distance_m: number;
+  total_duration_s: number;
+  unique_days: number;
+  updated_at: string;
+  visit_count: number;
+}
+
+export interface AdminTreeNode {
+  children?: (AdminTreeNode | null)[] | null;
+  coverage?: AdminCoverage | null;
+  first_visit_ts?: number;
+  last_visit_ts?: number;
+  level: string;
+  name: string;
+  point_count: number;
+  total_duration_s: number;
+  unique_days: number;
+  visit_count: number;
+  visited: boolean;
+}
+
+export interface AdminVisit {
+  admin_level: string;
+  admin_name: string;
+  algo_version?: string;
+  arrival_lat: number;
+  arrival_lon: number;
+  arrival_ts: number;
+  created_at: string;
+  departure_ts: number;
+  duration_s: number;
+  entered_from?: string;
+  exited_to?: string;
+  id: number;
+  parent_name?: string;
+}
+
+export interface AdminVisitSummary {
+  admin_level: string;
+  admin_name: string;
+  first_arrival_ts: number;
+  last_departure_ts: number;
+  longest_duration_s: number;
+  parent_name?: string;
+  total_duration_s: number;
+  visit_count: number;
+}
+
+export interface AltitudeStats {
+  algo_version: string;
+  altitude_span: number;
+  area_key?: string;
+  area_type: string;
+  avg_altitude: number;
+  bucket_key?: string;
+  bucket_type: string;
+  created_at: number;
+  id: number;
+  max_altitude: number;
+  min_altitude: number;
+  p25_altitude: number;
+  p50_altitude: number;
+  p75_altitude: number;
+  p90_altitude: number;
+  point_count: number;
+  segment_count: number;
+  source: string;
+  total_ascent: number;
+  total_descent: number;
+  total_distance: number;
+  updated_at: number;
+  vertical_intensity: number;
+}
+
+export interface AnalysisPreview {
+  analyzer: string;
+  count: number;
+  duration_ms: number;
+  end_time: number;
+  inputs: number;
+  results: unknown;
+  start_time: number;
+  thresholds: unknown;
+}
+
+export interface AnalysisPreviewRequest {
+  analyzer: string;
+  end_time: number;
+  start_time: number;
+  thresholds: Record<string, unknown> | null;
+}
+
+export interface AnalysisQueue {
+  pending: AnalysisQueueEntry[] | null;
+  running: AnalysisQueueEntry[] | null;
+  workers: number;
+}
+
+export interface AnalysisQueueEntry {
+  position?: number;
+  priority: number;
+  skill_name: string;
+  task_id: number;
+}
+
+export interface AnalysisStatus {
+  analyzers: AnalyzerStatus[] | null;
+  stale: string[] | null;
+}
+
+export interface AnalysisTask {
+  blocks_task_ids?: string | null;
+  created_at: string;
+  created_by?: string;
+  depends_on_task_ids?: string | null;
+  end_time?: number | null;
+  error_message?: string | null;
+  eta_seconds?: number | null;
+  failed_points: number;
+  id: number;
+  params_json?: string | null;
+  priority: number;
+  processed_points: number;
+  progress_percent: number;
+  queue_position?: number | null;
+  result_summary?: string | null;
+  resume_count: number;
+  skill_name: string;
+  start_time?: number | null;
+  status: string;
+  task_type: string;
+  threshold_profile_id?: number | null;
+  total_points?: number;
+  updated_at: string;
+}
+
+export interface AnalyzerOutput {
+  rows_by_version?: Record<string, number> | null;
+  table: string;
+}
+
+export interface AnalyzerStatus {
+  analyzer: string;
+  computed_at?: number;
+  computed_version?: string;
+  depends_on: string[] | null;
+  in_chain: boolean;
+  outputs: AnalyzerOutput[] | null;
+  reasons: string[] | null;
+  stale: boolean;
+  version: string;
+}
+
+export interface AnnualReport {
+  active_days: number;
+  busiest_day?: DailySummary | null;
+  distance_by_mode: ModeDistance[] | null;
+  end_time: number;
+  extreme_events: ExtremeEvent[] | null;
+  footprint?: FootprintStatistics | null;
+  generated_at: number;
+  longest_trip?: Trip | null;
+  new_cities: NewAdminArea[] | null;
+  new_counties: NewAdminArea[] | null;
+  start_time: number;
+  top_revisit_locations: RevisitPattern[] | null;
+  total_distance_m: number;
+  total_steps: number;
+  trip_count: number;
+  year: number;
+}
+
+export interface AnomalousDay {
+  algo_version: string;
+  baseline_days: number;
+  category: string;
+  created_at: number;
+  date: string;
+  distance_m: number;
+  distance_z: number;
+  id: number;
+  novel_grid_count: number;
+  novel_z: number;
+  radius_of_gyration_m: number;
+  radius_z: number;
+  reasons: string[] | null;
+  score: number;
+  stay_count: number;
+  stay_z: number;
+}
+
+export interface AppUsage {
+  app_id?: string;
+  app_name: string;
+  category: string;
+  days: number;
+  duration_s: number;
+  launches: number;
+  share: number;
+}
+
+export interface AreaFirstVisit {
+  first_visit: number;
+  last_visit: number;
+  name: string;
+  point_count: number;
+}
+
+export interface AreaVisit {
+  city?: string;
+  first_visit: number;
+  last_visit: number;
+  name: string;
+  point_count: number;
+  province?: string;
+}
+
+export interface Backup {
+  created_at: number;
+  name: string;
+  size: number;
+}
+
+export interface BackupList {
+  backups: Backup[] | null;
+  keep: number;
+  location: string;
+  max_age_seconds: number;
+}
+
+export interface BackupResult {
+  created_at: number;
+  deleted: string[] | null;
+  duration_ms: number;
+  error?: string;
+  location: string;
+  name: string;
+  size: number;
+}
+
+export interface BatchQuery {
+  name: string;
+  params: Record<string, unknown> | null;
+  stat: string;
+}
+
+export interface BatchRequest {
+  queries: BatchQuery[] | null;
+}
+
+export interface BatchResult {
+  data?: unknown;
+  details?: unknown;
+  error?: string;
+  status: number;
+}
+
+export interface BucketComparison {
+  altitude: MetricComparison[] | null;
+  bucket_a: string;
+  bucket_b: string;
+  bucket_type: string;
+  compression: MetricComparison[] | null;
+  footprint: MetricComparison[] | null;
+  speed_space: MetricComparison[] | null;
+}
+
+export interface CarbonPeriod {
+  bucket_key: string;
+  bucket_type: string;
+  change_pct?: number | null;
+  co2_kg: number;
+  created_at: number;
+  distance_m: number;
+  mode_co2_kg: Record<string, number> | null;
+  prev_year_co2_kg?: number | null;
+  trip_count: number;
+}
+
+export interface CarbonSummary {
+  factors: EmissionFactor[] | null;
+  months: CarbonPeriod[] | null;
+  total_co2_kg: number;
+  total_distance_m: number;
+  years: CarbonPeriod[] | null;
+}
+
+export interface CategoryUsage {
+  app_count: number;
+  category: string;
+  duration_s: number;
+  share: number;
+}
+
+export interface CommuteDirectionSummary {
+  avg_distance_m: number;
+  avg_duration_s: number;
+  route_variability: number;
+  trip_count: number;
+}
+
+export interface CommutePattern {
+  avg_distance_m: number;
+  avg_duration_s: number;
+  direction: string;
+  first_trip_ts: number;
+  id: number;
+  last_trip_ts: number;
+  median_departure: string;
+  median_departure_min: number;
+  median_duration_s: number;
+  mode_split: Record<string, number> | null;
+  p25_departure: string;
+  p75_departure: string;
+  p90_duration_s: number;
+  route_variability: number;
+  trip_count: number;
+  weekday: number;
+}
+
+export interface CommuteStats {
+  directions: Record<string, CommuteDirectionSummary> | null;
+  patterns: CommutePattern[] | null;
+}
+
+export interface CountryVisit {
+  code: string;
+  days: number;
+  first_visit: number;
+  last_visit: number;
+  name: string;
+  name_en: string;
+  point_count: number;
+  region: string;
+}
+
+export interface CreateTaskRequest {
+  params: Record<string, unknown> | null;
+  skill_name: string;
+  task_type: string;
+}
+
+export interface DailyInputActivity {
+  active_hours: number;
+  clicks: number;
+  date: string;
+  keystrokes: number;
+  scrolls: number;
+}
+
+export interface DailySummary {
+  active_time_s: number;
+  cities: string[] | null;
+  city_count: number;
+  county_count: number;
+  date: string;
+  day_type: string;
+  distance_by_mode: Record<string, number> | null;
+  first_movement_ts?: number | null;
+  has_data: boolean;
+  last_movement_ts?: number | null;
+  max_speed_kmh: number;
+  point_count: number;
+  primary_mode?: string | null;
+  provinces: string[] | null;
+  stay_count: number;
+  step_equivalent: number;
+  total_distance_m: number;
+  trip_count: number;
+  weekday: number;
+}
+
+export interface DailyTimeline {
+  active_days: number;
+  days: DailySummary[] | null;
+  from: string;
+  max_distance_m: number;
+  to: string;
+  total_distance_m: number;
+  total_steps: number;
+}
+
+export interface Dashboard {
+  date: string;
+  end_time: number;
+  errors?: Record<string, string> | null;
+  health?: HealthDailyStats | null;
+  input_activity?: InputActivityStats | null;
+  movement?: DailySummary | null;
+  screen_time?: ScreenTimeSummary | null;
+  start_time: number;
+  top_stays: DashboardStay[] | null;
+}
+
+export interface DashboardStay {
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  confirmed: boolean;
+  county?: string;
+  duration_seconds: number;
+  end_time: number;
+  id: number;
+  label?: string | null;
+  province?: string;
+  start_time: number;
+  stay_type: string;
+  sub_label?: string | null;
+}
+
+export interface DayTypeComparison {
+  bucket_key: string;
+  bucket_type: string;
+  holiday?: DayTypeStats | null;
+  holiday_vs_workday?: DayTypeRatios | null;
+  weekend?: DayTypeStats | null;
+  weekend_vs_workday?: DayTypeRatios | null;
+  workday?: DayTypeStats | null;
+}
+
+export interface DayTypeRatios {
+  avg_active_time?: number | null;
+  avg_cities?: number | null;
+  avg_distance?: number | null;
+  avg_trips?: number | null;
+}
+
+export interface DayTypeStats {
+  algo_version: string;
+  avg_active_time_s: number;
+  avg_city_count: number;
+  avg_distance_m: number;
+  avg_trip_count: number;
+  bucket_key: string;
+  bucket_type: string;
+  city_count: number;
+  created_at: number;
+  day_count: number;
+  day_type: string;
+  distance_by_mode: Record<string, number> | null;
+  id: number;
+  stay_count: number;
+  stay_duration_s: number;
+  stay_types: Record<string, StayTypeTotal> | null;
+  total_distance_m: number;
+  trip_count: number;
+}
+
+export interface DaypartStats {
+  active_s: number;
+  active_share: number;
+  algo_version: string;
+  bucket_key: string;
+  bucket_type: string;
+  city_count: number;
+  created_at: number;
+  day_count: number;
+  daypart: string;
+  distance_m: number;
+  distance_share: number;
+  grid_count: number;
+  id: number;
+  point_count: number;
+  source: string;
+  tracked_s: number;
+}
+
+export interface DetailPoint {
+  altitude?: number;
+  data_time: number;
+  id: number;
+  latitude: number;
+  longitude: number;
+  segment_id?: number;
+  speed?: number;
+}
+
+export interface DirectionalBiasStats {
+  algo_version: number;
+  anisotropy_index: number;
+  area_key: string;
+  area_type: string;
+  bidirectional_score: number;
+  bucket_key: string;
+  bucket_type: string;
+  created_at: string;
+  direction_histogram_json: string;
+  directional_concentration: number;
+  directional_entropy: number;
+  dominant_axis_deg: number;
+  dominant_direction_deg: number;
+  id: number;
+  mode_filter: string;
+  num_bins: number;
+  segment_count: number;
+  source: string;
+  total_distance: number;
+  total_duration: number;
+}
+
+export interface DirectionalRose {
+  anisotropy_index: number;
+  area_key: string;
+  area_type: string;
+  bin_width_deg: number;
+  bins: RoseBin[] | null;
+  bucket_key: string;
+  bucket_type: string;
+  dominant_axis_deg: number;
+  dominant_direction_deg: number;
+  mode_filter: string;
+  segment_count: number;
+  source: string;
+  total_distance: number;
+}
+
+export interface DiscoveryStreak {
+  algo_version: string;
+  created_at: number;
+  days_count: number;
+  end_date: string;
+  id: number;
+  new_cell_count: number;
+  start_date: string;
+}
+
+export interface EffectiveThresholds {
+  params: Record<string, unknown> | null;
+  profile_id: number;
+  profile_name: string;
+}
+
+export interface EmissionFactor {
+  default_g_co2_per_km: number;
+  description?: string;
+  g_co2_per_km: number;
+  mode: string;
+  updated_at: string;
+}
+
+export interface EmissionFactorRequest {
+  description: string;
+  g_co2_per_km?: number | null;
+}
+
+export interface EntityRef {
+  end_time: number;
+  id: number;
+  label?: string;
+  start_time: number;
+  type: string;
+}
+
+export interface ExplorationCurve {
+  longest_streak?: DiscoveryStreak | null;
+  months: ExplorationMonth[] | null;
+  total_cells: number;
+}
+
+export interface ExplorationMonth {
+  active_days: number;
+  algo_version: string;
+  created_at: number;
+  cumulative_cell_count: number;
+  discovery_days: number;
+  id: number;
+  month: string;
+  new_cell_count: number;
+  novel_point_count: number;
+  novel_point_ratio: number;
+  novel_time_ratio: number;
+  novel_time_s: number;
+  point_count: number;
+  tracked_s: number;
+}
+
+export interface ExtremeEvent {
+  algo_version?: string;
+  city?: string;
+  county?: string;
+  created_at: string;
+  event_category: string;
+  event_time: number;
+  event_type: string;
+  event_value: number;
+  id: number;
+  latitude: number;
+  longitude: number;
+  mode?: string;
+  point_id: number;
+  province?: string;
+  rank?: number;
+  segment_id?: number;
+  updated_at: string;
+}
+
+export interface FirstVisit {
+  area: string;
+  city?: string;
+  crossing_id?: number | null;
+  date: string;
+  entry_latitude?: number | null;
+  entry_longitude?: number | null;
+  entry_type: string;
+  first_visit: number;
+  from_area?: string;
+  id: number;
+  level: string;
+  point_count: number;
+  province?: string;
+  trip_id?: number | null;
+  visit_count: number;
+}
+
+export interface FootprintCoverage {
+  levels: LevelCoverage[] | null;
+  year: number;
+}
+
+export interface FootprintStatistics {
+  algo_version?: string;
+  cities?: AreaVisit[] | null;
+  city?: string;
+  city_count: number;
+  counties?: AreaVisit[] | null;
+  county?: string;
+  county_count: number;
+  created_at: string;
+  end_time?: number;
+  first_visit_time?: number;
+  generated_at?: string;
+  id: number;
+  last_visit_time?: number;
+  point_count: number;
+  prev_rank_by_duration?: number;
+  prev_rank_by_points?: number;
+  prev_rank_by_visits?: number;
+  province?: string;
+  province_count: number;
+  provinces?: AreaVisit[] | null;
+  rank_by_duration?: number;
+  rank_by_points?: number;
+  rank_by_visits?: number;
+  rank_movement?: number | null;
+  start_time?: number;
+  stat_key: string;
+  stat_type: string;
+  time_range?: string;
+  total_distance_meters: number;
+  total_duration_seconds: number;
+  total_points: number;
+  town?: string;
+  town_count: number;
+  updated_at: string;
+  village_count: number;
+  visit_count: number;
+}
+
+export interface GeocodingTask {
+  created_at: string;
+  created_by: string;
+  end_time?: string | null;
+  error_message?: string | null;
+  eta_seconds?: number | null;
+  failed_points: number;
+  id: number;
+  processed_points: number;
+  start_time?: string | null;
+  status: string;
+  total_points: number;
+  updated_at: string;
+}
+
+export interface GridCell {
+  algo_version?: string;
+  avg_speed_kmh?: number;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  county?: string;
+  created_at: string;
+  density_score?: number;
+  dominant_mode?: string;
+  first_visit?: number;
+  first_visit_time?: number;
+  grid_id: string;
+  id: number;
+  last_visit?: number;
+  last_visit_time?: number;
+  level: number;
+  max_lat: number;
+  max_lon: number;
+  max_speed_kmh?: number;
+  min_lat: number;
+  min_lon: number;
+  modes_json?: string;
+  point_count: number;
+  province?: string;
+  revisit_score?: number;
+  total_duration_seconds: number;
+  updated_at: string;
+  visit_count: number;
+  x: number;
+  y: number;
+}
+
+export interface HealthCorrelationStats {
+  avg_meters_per_step?: number | null;
+  avg_step_ratio?: number | null;
+  daily: HealthMovementCorrelation[] | null;
+  days: number;
+  from: string;
+  high_altitude_avg_hr?: number | null;
+  high_altitude_hr_samples: number;
+  low_altitude_avg_hr?: number | null;
+  low_altitude_hr_samples: number;
+  rest_day_avg_distance_m: number;
+  step_distance_correlation?: number | null;
+  to: string;
+  workout_day_avg_distance_m: number;
+  workout_days: number;
+}
+
+export interface HealthDailyStats {
+  avg_heart_rate?: number | null;
+  date: string;
+  max_heart_rate?: number | null;
+  min_heart_rate?: number | null;
+  sleep_hours?: number | null;
+  steps: number;
+  workout_minutes: number;
+  workouts: number;
+}
+
+export interface HealthImportTask {
+  completed_at?: number | null;
+  created_at: number;
+  created_by: string;
+  duplicates: number;
+  error_message?: string | null;
+  file_name: string;
+  id: number;
+  ignored_records: number;
+  processed_bytes: number;
+  progress_percent: number;
+  records_inserted: number;
+  records_parsed: number;
+  started_at?: number | null;
+  status: string;
+  total_bytes: number;
+  updated_at: number;
+}
+
+export interface HealthMovementCorrelation {
+  avg_heart_rate?: number | null;
+  date: string;
+  expected_steps: number;
+  high_altitude_avg_hr?: number | null;
+  high_altitude_hr_samples: number;
+  low_altitude_avg_hr?: number | null;
+  low_altitude_hr_samples: number;
+  max_altitude_m?: number | null;
+  meters_per_step?: number | null;
+  moved_distance_m: number;
+  recorded_steps: number;
+  step_ratio?: number | null;
+  walk_distance_m: number;
+  workout_count: number;
+  workout_distance_m: number;
+  workout_minutes: number;
+}
+
+export interface HealthStats {
+  avg_daily_steps: number;
+  avg_heart_rate?: number | null;
+  avg_sleep_hours?: number | null;
+  daily: HealthDailyStats[] | null;
+  days: number;
+  from: string;
+  to: string;
+  total_steps: number;
+  workout_count: number;
+  workout_minutes: number;
+}
+
+export interface HeatmapPoint {
+  intensity: number;
+  lat: number;
+  lng: number;
+  metric: string;
+  value: number;
+}
+
+export interface HeatmapResponse {
+  count: number;
+  grid_level: number;
+  max_value: number;
+  metric: string;
+  min_value: number;
+  points: HeatmapPoint[] | null;
+}
+
+export interface ImportFileResultInputActivityImportResult {
+  error?: string;
+  file_name: string;
+  result?: InputActivityImportResult | null;
+}
+
+export interface ImportFileResultJourneyImportResult {
+  error?: string;
+  file_name: string;
+  result?: JourneyImportResult | null;
+}
+
+export interface ImportFileResultScreenTimeImportResult {
+  error?: string;
+  file_name: string;
+  result?: ScreenTimeImportResult | null;
+}
+
+export interface ImportResponse {
+  analysis_task_ids?: number[] | null;
+  files: ImportResult[] | null;
+  total_duplicate: number;
+  total_inserted: number;
+  total_replaced: number;
+}
+
+export interface ImportResult {
+  duplicate_points: number;
+  end_time?: number;
+  error?: string;
+  file_name: string;
+  format: string;
+  inserted_points: number;
+  parsed_points: number;
+  replaced_points: number;
+  source: string;
+  source_device: string;
+  start_time?: number;
+}
+
+export interface IngestResult {
+  duplicates: number;
+  inserted: number;
+  received: number;
+  rejected: number;
+  replaced: number;
+}
+
+export interface IngestStatus {
+  analysis_interval_seconds: number;
+  last_analysis_at?: number;
+  last_analysis_task_ids: number[] | null;
+  last_device?: string;
+  last_point_time?: number;
+  last_received_at?: number;
+  mqtt_broker?: string;
+  mqtt_connected: boolean;
+  mqtt_error?: string;
+  pending_points: number;
+}
+
+export interface InputActivityImportResult {
+  end_date?: string;
+  file_name: string;
+  format: string;
+  hours: number;
+  parsed_rows: number;
+  start_date?: string;
+  stored: number;
+  warnings?: string[] | null;
+}
+
+export interface InputActivityShare {
+  active_hours: number;
+  clicks: number;
+  keystrokes: number;
+  keystrokes_per_hour: number;
+}
+
+export interface InputActivityStats {
+  active_days: number;
+  active_hours: number;
+  busiest_day?: DailyInputActivity | null;
+  daily: DailyInputActivity[] | null;
+  daily_average_clicks: number;
+  daily_average_keystrokes: number;
+  from: string;
+  peak_hour?: number | null;
+  to: string;
+  total_clicks: number;
+  total_keystrokes: number;
+  total_scrolls: number;
+}
+
+export interface InputHeatmap {
+  cells: InputHeatmapCell[] | null;
+  from: string;
+  max: number;
+  metric: string;
+  to: string;
+}
+
+export interface InputHeatmapCell {
+  average: number;
+  hour: number;
+  total: number;
+  weekday: number;
+}
+
+export interface InputWorkCorrelation {
+  at_work: InputActivityShare;
+  correlation?: number | null;
+  days: WorkInputDay[] | null;
+  elsewhere: InputActivityShare;
+  from: string;
+  keystroke_share: number;
+  to: string;
+  work_hours: number;
+  work_stay_count: number;
+}
+
+export interface Insight {
+  algo_version: string;
+  bucket_key: string;
+  bucket_type: string;
+  category: string;
+  change_pct?: number | null;
+  created_at: number;
+  details?: Record<string, unknown> | null;
+  id: number;
+  message: string;
+  rule: string;
+  value?: number | null;
+}
+
+export interface Journey {
+  arrival_time?: number;
+  booking_class?: string;
+  carrier?: string;
+  created_at: number;
+  departure_time: number;
+  dest_city?: string;
+  dest_code?: string;
+  dest_lat?: number;
+  dest_lon?: number;
+  dest_name: string;
+  distance_m?: number;
+  duration_s?: number;
+  id: number;
+  journey_type: string;
+  notes?: string;
+  number: string;
+  origin_city?: string;
+  origin_code?: string;
+  origin_lat?: number;
+  origin_lon?: number;
+  origin_name: string;
+  path?: number[][] | null;
+  seat?: string;
+  segment_id?: number;
+  source: string;
+  updated_at: number;
+}
+
+export interface JourneyCount {
+  count: number;
+  distance_m: number;
+  key: string;
+}
+
+export interface JourneyImportResult {
+  duplicates: number;
+  file_name: string;
+  format: string;
+  inserted: number;
+  linked: number;
+  parsed: number;
+  unlocated: number;
+  warnings?: string[] | null;
+}
+
+export interface JourneyRoute {
+  count: number;
+  dest: string;
+  distance_m: number;
+  origin: string;
+}
+
+export interface JourneyStats {
+  by_year: JourneyCount[] | null;
+  count: number;
+  journey_type: string;
+  linked_count: number;
+  top_carriers: JourneyCount[] | null;
+  top_routes: JourneyRoute[] | null;
+  total_distance_m: number;
+  total_duration_s: number;
+}
+
+export interface LevelCoverage {
+  areas: AreaFirstVisit[] | null;
+  level: string;
+  newly_unlocked: AreaFirstVisit[] | null;
+  ratio?: number | null;
+  total?: number | null;
+  visited: number;
+}
+
+export interface MetricComparison {
+  a?: number | null;
+  b?: number | null;
+  change_pct?: number | null;
+  delta?: number | null;
+  metric: string;
+}
+
+export interface ModeCorrection {
+  avg_speed_kmh: number;
+  created_at: number;
+  end_time: number;
+  id: number;
+  mode: string;
+  note?: string;
+  original_mode: string;
+  start_time: number;
+}
+
+export interface ModeDistance {
+  distance_m: number;
+  mode: string;
+  share: number;
+}
+
+export interface ModeStats {
+  algo_version: string;
+  bucket_key: string;
+  bucket_type: string;
+  co2_kg: number;
+  created_at: number;
+  distance_m: number;
+  duration_s: number;
+  id: number;
+  mode: string;
+  segment_count: number;
+  source: string;
+  trip_count: number;
+}
+
+export interface NewAdminArea {
+  city: string;
+  county?: string;
+  first_visit: number;
+  province: string;
+}
+
+export interface NotificationDelivery {
+  attempts: number;
+  channel: string;
+  delivered_at: number;
+  error?: string;
+  event_id: string;
+  event_time: number;
+  event_type: string;
+  id: number;
+  status: string;
+  title: string;
+}
+
+export interface NotificationStatus {
+  channels: string[] | null;
+  enabled: boolean;
+  events: string[] | null;
+}
+
+export interface ODArea {
+  city: string;
+  county?: string;
+  lat: number;
+  lon: number;
+  province: string;
+}
+
+export interface ODFlow {
+  avg_duration_s: number;
+  dest: number;
+  first_time: number;
+  last_time: number;
+  origin: number;
+  total_distance_m: number;
+  trip_count: number;
+}
+
+export interface ODMatrix {
+  areas: ODArea[] | null;
+  flows: ODFlow[] | null;
+  level: string;
+  total_trips: number;
+}
+
+export interface OutlierPoint {
+  accuracy: number;
+  city?: string;
+  dataTime: number;
+  id: number;
+  latitude: number;
+  longitude: number;
+  outlier_flag: boolean;
+  province?: string;
+  qa_status: string;
+  reason_codes: string[] | null;
+  speed: number;
+}
+
+export interface OutlierReviewRequest {
+  ids: number[] | null;
+}
+
+export interface OutlierReviewResult {
+  qa_status?: string;
+  updated: number;
+}
+
+export interface OwnTracksMessage {
+  _type: string;
+  acc?: number | null;
+  alt?: number | null;
+  batt?: number | null;
+  bs: number;
+  cog?: number | null;
+  lat?: number | null;
+  lon?: number | null;
+  tid: string;
+  topic: string;
+  tst: number;
+  vel?: number | null;
+}
+
+export interface PersonalRecord {
+  algo_version: string;
+  created_at: number;
+  date?: string;
+  details?: Record<string, unknown> | null;
+  end_date?: string;
+  end_time?: number | null;
+  id: number;
+  latitude?: number | null;
+  longitude?: number | null;
+  point_id?: number | null;
+  record_type: string;
+  segment_id?: number | null;
+  start_time?: number | null;
+  stay_id?: number | null;
+  trip_id?: number | null;
+  unit: string;
+  value: number;
+}
+
+export interface Photo {
+  altitude?: number;
+  camera_make?: string;
+  camera_model?: string;
+  external_id: string;
+  file_name: string;
+  file_size?: number;
+  height?: number;
+  id: number;
+  imported_at: number;
+  lat?: number;
+  location_source?: string;
+  lon?: number;
+  source: string;
+  taken_at: number;
+  url: string;
+  width?: number;
+}
+
+export interface PhotoImmichRequest {
+  since: number;
+}
+
+export interface PhotoImportResult {
+  failed: number;
+  geotagged: number;
+  imported: number;
+  located: number;
+  no_time: number;
+  scanned: number;
+  source: string;
+  unchanged: number;
+  warnings?: string[] | null;
+}
+
+export interface PhotoScanRequest {
+  path: string;
+}
+
+export interface PhotoSet {
+  end_time: number;
+  photos: Photo[] | null;
+  start_time: number;
+}
+
+export interface Place {
+  algo_version?: string;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  county?: string;
+  created_at: number;
+  first_visit_ts?: number;
+  geohash: string;
+  id: number;
+  label?: string;
+  last_visit_ts?: number;
+  name?: string;
+  poi_checked_at?: number;
+  pois?: PlacePOI[] | null;
+  province?: string;
+  radius_m: number;
+  total_duration_s: number;
+  total_hours: number;
+  updated_at: number;
+  visit_count: number;
+}
+
+export interface PlaceNameRequest {
+  name: string;
+}
+
+export interface PlacePOI {
+  category: string;
+  distance_m: number;
+  kind?: string;
+  lat: number;
+  lon: number;
+  name: string;
+  provider: string;
+  rank: number;
+}
+
+export interface PlaybackFrame {
+  heading: number;
+  latitude: number;
+  longitude: number;
+  mode?: string;
+  segment_id?: number;
+  speed_kmh: number;
+  time: number;
+}
+
+export interface PlaybackGap {
+  end: number;
+  start: number;
+}
+
+export interface PlaybackResponse {
+  frames: PlaybackFrame[] | null;
+  from: number;
+  gaps: PlaybackGap[] | null;
+  max_gap: number;
+  source_point_count: number;
+  step: number;
+  to: number;
+}
+
+export interface PolylineResponse {
+  count: number;
+  lod: number;
+  polylines: SegmentPolyline[] | null;
+  zoom: number;
+}
+
+export interface PrivacyZone {
+  action: string;
+  center_lat?: number;
+  center_lon?: number;
+  created_at: string;
+  deleted_at?: string | null;
+  enabled: boolean;
+  fuzz_m?: number;
+  id: number;
+  name: string;
+  polygon?: number[][] | null;
+  radius_m?: number;
+  shape: string;
+  updated_at: string;
+}
+
+export interface PrivacyZoneRequest {
+  action: string;
+  center_lat: number;
+  center_lon: number;
+  enabled?: boolean | null;
+  fuzz_m: number;
+  name: string;
+  polygon: number[][] | null;
+  radius_m: number;
+  shape: string;
+}
+
+export interface QueryPlan {
+  description: string;
+  name: string;
+  scans: string[] | null;
+  sql: string;
+  steps: QueryPlanStep[] | null;
+}
+
+export interface QueryPlanStep {
+  detail: string;
+  id: number;
+  parent: number;
+}
+
+export interface RailLineMileage {
+  distance_km: number;
+  first_time: number;
+  last_time: number;
+  line: string;
+  segment_count: number;
+}
+
+export interface RailLineStats {
+  lines: RailLineMileage[] | null;
+  total_distance_km: number;
+  year?: number;
+}
+
+export interface RecomputeOutput {
+  action: string;
+  rows: number;
+  table: string;
+}
+
+export interface RecomputePlan {
+  dry_run: boolean;
+  end_time?: number;
+  points: number;
+  start_time?: number;
+  steps: RecomputeStep[] | null;
+  total_rows: number;
+  warnings: string[] | null;
+}
+
+export interface RecomputeRequest {
+  analyzers: string[] | null;
+  confirm: boolean;
+  end_time: number;
+  start_time: number;
+}
+
+export interface RecomputeStep {
+  analyzer: string;
+  outputs: RecomputeOutput[] | null;
+  windowed: boolean;
+}
+
+export interface ReloadResult {
+  applied: string[] | null;
+  file?: string;
+  restart_required: string[] | null;
+}
+
+export interface RenderHints {
+  alpha?: number | null;
+  line_weight?: number | null;
+  max_lat: number;
+  max_lon: number;
+  min_lat: number;
+  min_lon: number;
+  overlap_rank?: number | null;
+  speed_bucket?: number | null;
+}
+
+export interface RevisitPattern {
+  algo_version: string;
+  avg_interval_days: number;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  county?: string;
+  created_at: number;
+  first_visit: number;
+  geohash6: string;
+  id: number;
+  is_habitual: boolean;
+  is_periodic: boolean;
+  last_visit: number;
+  max_interval_days: number;
+  min_interval_days: number;
+  province?: string;
+  regularity_score: number;
+  revisit_strength: number;
+  std_interval_days: number;
+  total_duration_seconds: number;
+  updated_at: number;
+  visit_count: number;
+}
+
+export interface RoadOverlapSummary {
+  by_road_type: Record<string, RoadTypeStats> | null;
+  off_road_distance_km: number;
+  on_road_distance_km: number;
+  overlap_ratio: number;
+  total_segments: number;
+}
+
+export interface RoadTypeStats {
+  avg_ratio: number;
+  distance_km: number;
+  segment_count: number;
+}
+
+export interface RoseBin {
+  bin: number;
+  center_deg: number;
+  count: number;
+  distance: number;
+  from_deg: number;
+  to_deg: number;
+  weight: number;
+}
+
+export interface RouteCluster {
+  avg_distance_m: number;
+  avg_duration_s: number;
+  avg_speed_kmh: number;
+  end_lat: number;
+  end_lon: number;
+  first_used_ts: number;
+  id: number;
+  last_used_ts: number;
+  max_duration_s: number;
+  max_speed_kmh: number;
+  mean_dtw_m: number;
+  median_speed_kmh: number;
+  min_duration_s: number;
+  polyline?: number[][] | null;
+  primary_mode: string;
+  representative_trip_id: number;
+  start_lat: number;
+  start_lon: number;
+  trip_count: number;
+  trip_ids?: number[] | null;
+}
+
+export interface Schedule {
+  cron: string;
+  last_run?: ScheduleRun | null;
+  name: string;
+  next_run: number;
+  task_type: string;
+}
+
+export interface ScheduleRun {
+  cron: string;
+  finished_at?: number | null;
+  id: number;
+  max_point_id: number;
+  point_count: number;
+  reason?: string;
+  schedule: string;
+  started_at: number;
+  status: string;
+  task_ids: number[] | null;
+  task_type: string;
+}
+
+export interface ScreenTimeImportResult {
+  end_date?: string;
+  file_name: string;
+  format: string;
+  parsed_rows: number;
+  records: number;
+  start_date?: string;
+  stored: number;
+  warnings?: string[] | null;
+}
+
+export interface ScreenTimeSummary {
+  categories: CategoryUsage[] | null;
+  daily_average_s: number;
+  days: number;
+  from: string;
+  to: string;
+  top_apps: AppUsage[] | null;
+  total_duration_s: number;
+  total_launches: number;
+}
+
+export interface SearchResult {
+  date?: string;
+  id: number;
+  score: number;
+  snippet?: string;
+  start_time?: number;
+  subtype?: string;
+  title: string;
+  type: string;
+}
+
+export interface Segment {
+  algo_version?: string;
+  avg_heading?: number;
+  avg_speed_kmh?: number;
+  city?: string;
+  confidence: number;
+  county?: string;
+  created_at: string;
+  distance_meters?: number;
+  duration_seconds: number;
+  end_lat?: number;
+  end_lon?: number;
+  end_point_id: number;
+  end_time: number;
+  heading_variance?: number;
+  id: number;
+  max_speed_kmh?: number;
+  mode: string;
+  mode_probs?: Record<string, number> | null;
+  province?: string;
+  rail_line?: string;
+  reason_codes: string;
+  source?: string;
+  start_lat?: number;
+  start_lon?: number;
+  start_point_id: number;
+  start_time: number;
+  updated_at: string;
+}
+
+export interface SegmentDetail {
+  algo_version?: string;
+  avg_heading?: number;
+  avg_speed_kmh?: number;
+  city?: string;
+  confidence: number;
+  county?: string;
+  created_at: string;
+  distance_meters?: number;
+  duration_seconds: number;
+  end_lat?: number;
+  end_lon?: number;
+  end_point_id: number;
+  end_time: number;
+  heading_variance?: number;
+  id: number;
+  max_speed_kmh?: number;
+  mode: string;
+  mode_probs?: Record<string, number> | null;
+  next?: EntityRef | null;
+  points: DetailPoint[] | null;
+  previous?: EntityRef | null;
+  province?: string;
+  rail_line?: string;
+  reason_codes: string;
+  render?: RenderHints | null;
+  source?: string;
+  source_point_count: number;
+  start_lat?: number;
+  start_lon?: number;
+  start_point_id: number;
+  start_time: number;
+  trip?: EntityRef | null;
+  updated_at: string;
+}
+
+export interface SegmentModeRequest {
+  mode: string;
+  note: string;
+}
+
+export interface SegmentModeResult {
+  correction: ModeCorrection;
+  segment: Segment;
+  task_ids: number[] | null;
+}
+
+export interface SegmentPolyline {
+  algorithm: string;
+  coordinates?: number[][] | null;
+  end_time: number;
+  lod: number;
+  max_lat: number;
+  max_lon: number;
+  min_lat: number;
+  min_lon: number;
+  mode: string;
+  point_count: number;
+  polyline?: string;
+  segment_id: number;
+  source_point_count: number;
+  start_time: number;
+  tolerance_m: number;
+}
+
+export interface SpatialComplexity {
+  algo_version: string;
+  avg_turn_angle: number;
+  created_at: string;
+  direction_changes: number;
+  id: number;
+  metric_date?: string;
+  path_efficiency: number;
+  period_type: string;
+  spatial_entropy: number;
+  tortuosity: number;
+  trajectory_complexity: number;
+}
+
+export interface SpatialDensityGrid {
+  algo_version: string;
+  bucket_key?: string;
+  bucket_type: string;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  cluster_area_km2?: number | null;
+  cluster_id?: number | null;
+  county?: string;
+  created_at: number;
+  density_level: string;
+  density_score: number;
+  grid_id: string;
+  id: number;
+  province?: string;
+  source: string;
+  stay_count: number;
+  stay_duration_s: number;
+  updated_at: number;
+  visit_days: number;
+}
+
+export interface SpatialUtilization {
+  algo_version: string;
+  area_depth: number;
+  area_key: string;
+  area_type: string;
+  bucket_key?: string;
+  bucket_type: string;
+  coverage_efficiency: number;
+  created_at: number;
+  distinct_grids: number;
+  distinct_visit_days: number;
+  first_visit?: number;
+  id: number;
+  last_visit?: number;
+  source: string;
+  stay_duration_s: number;
+  total_grids: number;
+  transit_dominance: number;
+  transit_intensity: number;
+  updated_at: number;
+  utilization_efficiency: number;
+}
+
+export interface SpeedDistribution {
+  count: number;
+  percentage: number;
+  speed_range: string;
+}
+
+export interface SpeedSpaceStats {
+  algo_version: number;
+  area_key: string;
+  area_type: string;
+  avg_speed: number;
+  bucket_key: string;
+  bucket_type: string;
+  created_at: string;
+  id: number;
+  is_high_speed_zone: boolean;
+  is_slow_life_zone: boolean;
+  segment_count: number;
+  source: string;
+  speed_entropy: number;
+  speed_variance: number;
+  stay_intensity: number;
+  total_distance: number;
+}
+
+export interface StayAnnotation {
+  confirmed: boolean;
+  label?: string;
+  note?: string;
+  place_id?: number;
+  sub_label?: string;
+  suggestions?: unknown[] | null;
+}
+
+export interface StayDetail {
+  algo_version?: string;
+  annotation?: StayAnnotation | null;
+  arriving_trip?: EntityRef | null;
+  avg_accuracy?: number;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  confidence?: number;
+  county?: string;
+  created_at: string;
+  duration_seconds: number;
+  end_time: number;
+  first_point_id: number;
+  id: number;
+  last_point_id: number;
+  leaving_trip?: EntityRef | null;
+  max_distance_from_center?: number;
+  metadata?: string;
+  next?: EntityRef | null;
+  place_id?: number;
+  place_name?: string;
+  point_count?: number;
+  points: DetailPoint[] | null;
+  previous?: EntityRef | null;
+  province?: string;
+  radius_meters?: number;
+  render?: RenderHints | null;
+  source?: string;
+  source_point_count: number;
+  start_time: number;
+  stay_category?: string;
+  stay_label?: string;
+  stay_type: string;
+  town?: string;
+  updated_at: string;
+  village?: string;
+}
+
+export interface StaySegment {
+  algo_version?: string;
+  avg_accuracy?: number;
+  center_lat: number;
+  center_lon: number;
+  city?: string;
+  confidence?: number;
+  county?: string;
+  created_at: string;
+  duration_seconds: number;
+  end_time: number;
+  first_point_id: number;
+  id: number;
+  last_point_id: number;
+  max_distance_from_center?: number;
+  metadata?: string;
+  place_id?: number;
+  place_name?: string;
+  point_count?: number;
+  province?: string;
+  radius_meters?: number;
+  source?: string;
+  start_time: number;
+  stay_category?: string;
+  stay_label?: string;
+  stay_type: string;
+  town?: string;
+  updated_at: string;
+  village?: string;
+}
+
+export interface StayStatistics {
+  algo_version?: string;
+  avg_duration_seconds?: number;
+  city?: string;
+  county?: string;
+  created_at: string;
+  id: number;
+  max_duration_seconds?: number;
+  prev_rank_by_count?: number;
+  prev_rank_by_duration?: number;
+  province?: string;
+  rank_by_count?: number;
+  rank_by_duration?: number;
+  rank_movement?: number | null;
+  stat_key: string;
+  stat_type: string;
+  stay_category?: string;
+  stay_count: number;
+  time_range?: string;
+  total_duration_seconds: number;
+  updated_at: string;
+}
+
+export interface StayTypeTotal {
+  count: number;
+  duration_s: number;
+}
+
+export interface ThresholdProfile {
+  created_at: string;
+  description?: string;
+  id: number;
+  is_default: boolean;
+  name: string;
+  params_json: string;
+  updated_at: string;
+}
+
+export interface ThresholdProfileRequest {
+  description?: string | null;
+  name: string;
+  params: Record<string, unknown> | null;
+  replace: boolean;
+}
+
+export interface TimeDistribution {
+  count: number;
+  duration: number;
+  hour: number;
+  weekday: number;
+}
+
+export interface TimeSpaceCompression {
+  active_time_s: number;
+  activity_ratio: number;
+  algo_version: string;
+  area_key?: string;
+  area_type: string;
+  avg_speed_kmh: number;
+  bucket_key?: string;
+  bucket_type: string;
+  burst_count: number;
+  burst_duration_s: number;
+  burst_intensity: number;
+  created_at: number;
+  distance_per_day: number;
+  distinct_days: number;
+  effective_movement_ratio: number;
+  id: number;
+  inactive_time_s: number;
+  max_speed_kmh: number;
+  movement_intensity: number;
+  source: string;
+  time_compression_index: number;
+  total_distance_m: number;
+  total_duration_s: number;
+  trip_count: number;
+  updated_at: number;
+}
+
+export interface TimeSpaceSlice {
+  admin_level?: string;
+  admin_name?: string;
+  algo_version: string;
+  created_at: string;
+  distance_m: number;
+  duration_s: number;
+  grid_id?: string;
+  id: number;
+  point_count: number;
+  slice_key: string;
+  slice_type: string;
+  unique_locations: number;
+}
+
+export interface TrackPoint {
+  accuracy: number;
+  algoVersion?: string | null;
+  altitude: number;
+  batteryLevel?: number | null;
+  batteryState?: string;
+  city?: string;
+  country?: string;
+  county?: string;
+  createdAt?: string | null;
+  dataTime: number;
+  distance: number;
+  heading: number;
+  id: number;
+  latitude: number;
+  longitude: number;
+  motion?: string;
+  province?: string;
+  region?: string;
+  source?: string;
+  sourceDevice?: string;
+  speed: number;
+  time: string;
+  timeVisually: string;
+  town?: string;
+  updatedAt?: string | null;
+  village?: string;
+}
+
+export interface TrackPointsResponse {
+  data: TrackPoint[] | null;
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+}
+
+export interface TriggerAnalysisChainRequest {
+  task_type: string;
+}
+
+export interface Trip {
+  algo_version?: string;
+  created_at: string;
+  date: string;
+  day_type?: string;
+  dest_city?: string;
+  dest_county?: string;
+  dest_lat?: number;
+  dest_lon?: number;
+  dest_place_id?: number;
+  dest_province?: string;
+  dest_stay_id?: number;
+  distance_meters?: number;
+  duration_seconds: number;
+  end_time: number;
+  id: number;
+  modes_json?: string;
+  origin_city?: string;
+  origin_county?: string;
+  origin_lat?: number;
+  origin_lon?: number;
+  origin_place_id?: number;
+  origin_province?: string;
+  origin_stay_id?: number;
+  primary_mode?: string;
+  purpose?: string;
+  purpose_confidence?: number;
+  purpose_probs_json?: string;
+  rail_lines_json?: string;
+  segment_count: number;
+  start_time: number;
+  trip_number: number;
+  updated_at: string;
+  weather_precip_mm?: number | null;
+  weather_temp_c?: number | null;
+  weather_wet?: boolean | null;
+}
+
+export interface TripCarbon {
+  co2_kg: number;
+  created_at: number;
+  distance_m: number;
+  mode_co2_kg: Record<string, number> | null;
+  start_time: number;
+  trip_id: number;
+}
+
+export interface TripDetail {
+  algo_version?: string;
+  created_at: string;
+  date: string;
+  day_type?: string;
+  dest_city?: string;
+  dest_county?: string;
+  dest_lat?: number;
+  dest_lon?: number;
+  dest_place_id?: number;
+  dest_province?: string;
+  dest_stay?: EntityRef | null;
+  dest_stay_id?: number;
+  distance_meters?: number;
+  duration_seconds: number;
+  end_time: number;
+  id: number;
+  modes_json?: string;
+  next?: EntityRef | null;
+  origin_city?: string;
+  origin_county?: string;
+  origin_lat?: number;
+  origin_lon?: number;
+  origin_place_id?: number;
+  origin_province?: string;
+  origin_stay?: EntityRef | null;
+  origin_stay_id?: number;
+  points: DetailPoint[] | null;
+  previous?: EntityRef | null;
+  primary_mode?: string;
+  purpose?: string;
+  purpose_confidence?: number;
+  purpose_probs_json?: string;
+  rail_lines_json?: string;
+  render?: RenderHints | null;
+  segment_count: number;
+  segments: EntityRef[] | null;
+  source_point_count: number;
+  start_time: number;
+  trip_number: number;
+  updated_at: string;
+  weather_precip_mm?: number | null;
+  weather_temp_c?: number | null;
+  weather_wet?: boolean | null;
+}
+
+export interface TripEdit {
+  action: string;
+  created_at: number;
+  from_time?: number;
+  id: number;
+  note?: string;
+  split_time?: number;
+  to_time?: number;
+}
+
+export interface TripEditResult {
+  edit: TripEdit;
+  task_ids: number[] | null;
+}
+
+export interface TripMergeRequest {
+  note: string;
+  trip_ids: number[] | null;
+}
+
+export interface TripSplitRequest {
+  note: string;
+  time: number;
+}
+
+export interface WatchImport {
+  analysis_task_ids: number[] | null;
+  archived_path?: string;
+  duplicate_points: number;
+  end_time?: number;
+  error?: string;
+  file_name: string;
+  format?: string;
+  id: number;
+  imported_at: number;
+  inserted_points: number;
+  parsed_points: number;
+  replaced_points: number;
+  sha256: string;
+  size: number;
+  start_time?: number;
+  status: string;
+}
+
+export interface WatchImportStatus {
+  archive_dir?: string;
+  dir?: string;
+  enabled: boolean;
+  error?: string;
+  failed_dir?: string;
+  interval_seconds?: number;
+  running: boolean;
+}
+
+export interface WeatherDay {
+  cell: string;
+  date: string;
+  hour_count: number;
+  id: number;
+  is_primary: boolean;
+  lat: number;
+  lon: number;
+  point_count: number;
+  precip_mm?: number | null;
+  provider: string;
+  rain_hours?: number | null;
+  temp_max_c?: number | null;
+  temp_mean_c?: number | null;
+  temp_min_c?: number | null;
+}
+
+export interface WeatherModeDistance {
+  dry_distance_km: number;
+  mode: string;
+  wet_distance_km: number;
+  wet_share: number;
+}
+
+export interface WeatherSummary {
+  avg_temp_c: number;
+  coldest?: WeatherDay | null;
+  days: number;
+  hottest?: WeatherDay | null;
+  modes: WeatherModeDistance[] | null;
+  precip_mm: number;
+  rain_days: number;
+  trips: number;
+  wet_trips: number;
+  wettest?: WeatherDay | null;
+  year?: number;
+}
+
+export interface WeeklyUsage {
+  by_category: Record<string, number> | null;
+  daily_average_s: number;
+  days: number;
+  total_duration_s: number;
+  week_start: string;
+}
+
+export interface WorkInputDay {
+  clicks: number;
+  date: string;
+  keystrokes: number;
+  work_hours: number;
+}
+
+export interface Workout {
+  activity_type: string;
+  date: string;
+  distance_m?: number | null;
+  duration_s: number;
+  end_time: number;
+  energy_kcal?: number | null;
+  id: number;
+  source_name: string;
+  start_time: number;
+}
+
+export interface YearAbroad {
+  countries: Record<string, number> | null;
+  days_abroad: number;
+  year: string;
+}
+
+export type AnalysisTaskListTasksResult = {
+  limit: number;
+  offset: number;
+  tasks: AnalysisTask[] | null;
+};
+
+export type AnalysisTaskCancelTaskResult = {
+  message: string;
+};
+
+export type AnalysisTaskTriggerAnalysisChainResult = {
+  message: string;
+  task_ids: number[] | null;
+};
+
+export type CarbonListFactorsResult = {
+  count: number;
+  data: EmissionFactor[];
+};
+
+export type CarbonSetFactorResult = {
+  factor: EmissionFactor;
+  task_ids: number[] | null;
+};
+
+export type CarbonResetFactorResult = {
+  factor: EmissionFactor;
+  task_ids: number[] | null;
+};
+
+export type GeocodingListTasksResult = {
+  limit: number;
+  offset: number;
+  tasks: GeocodingTask[] | null;
+};
+
+export type GeocodingCancelTaskResult = {
+  message: string;
+};
+
+export type WatchImportListFilesResult = {
+  count: number;
+  data: WatchImport[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type NotificationListDeliveriesResult = {
+  count: number;
+  data: NotificationDelivery[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type NotificationSendTestResult = {
+  event_id: string;
+};
+
+export type PrivacyListZonesResult = {
+  count: number;
+  data: PrivacyZone[];
+};
+
+export type ScheduleListSchedulesResult = {
+  count: number;
+  data: Schedule[];
+};
+
+export type ScheduleListRunsResult = {
+  count: number;
+  data: ScheduleRun[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type ThresholdListProfilesResult = {
+  count: number;
+  data: ThresholdProfile[];
+};
+
+export type JourneyGetFlightsResult = {
+  data: Journey[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type HealthListImportTasksResult = {
+  count: number;
+  data: HealthImportTask[];
+};
+
+export type HealthGetWorkoutsResult = {
+  count: number;
+  data: Workout[];
+};
+
+export type IngestIngestOverlandResult = {
+  received: number;
+  rejected: number;
+  result: string;
+};
+
+export type InsightGetInsightsResult = {
+  count: number;
+  data: Insight[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type JourneyGetJourneysResult = {
+  data: Journey[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type JourneyImportJourneysResult = {
+  files: ImportFileResultJourneyImportResult[] | null;
+};
+
+export type JourneyLinkSegmentsResult = {
+  linked: number;
+};
+
+export type InputActivityImportInputActivityResult = {
+  files: ImportFileResultInputActivityImportResult[] | null;
+};
+
+export type PhotoListPhotosResult = {
+  count: number;
+  data: Photo[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type PlaceListPlacesResult = {
+  count: number;
+  data: Place[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type QAGetOutliersResult = {
+  count: number;
+  data: OutlierPoint[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type ScreenTimeGetCategoriesResult = {
+  count: number;
+  data: CategoryUsage[];
+};
+
+export type ScreenTimeImportScreenTimeResult = {
+  files: ImportFileResultScreenTimeImportResult[] | null;
+};
+
+export type ScreenTimeGetTopAppsResult = {
+  count: number;
+  data: AppUsage[];
+};
+
+export type ScreenTimeGetWeeklyTrendsResult = {
+  count: number;
+  data: WeeklyUsage[];
+};
+
+export type SearchSearchResult = {
+  count: number;
+  data: SearchResult[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetAdminCrossingsResult = {
+  count: number;
+  data: AdminCrossing[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetAdminViewResult = {
+  count: number;
+  data: AdminStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetAdminVisitsResult = {
+  count: number;
+  data: AdminVisit[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetAltitudeStatsResult = {
+  count: number;
+  data: AltitudeStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetHighestVerticalIntensityResult = {
+  count: number;
+  data: AltitudeStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetHighestAltitudeSpansResult = {
+  count: number;
+  data: AltitudeStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetAnomalousDaysResult = {
+  count: number;
+  data: AnomalousDay[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type BatchBatchResult = {
+  results: Record<string, BatchResult> | null;
+};
+
+export type CarbonGetTripCarbonResult = {
+  count: number;
+  data: TripCarbon[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDayTypeStatsResult = {
+  count: number;
+  data: DayTypeStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDaypartStatsResult = {
+  count: number;
+  data: DaypartStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDensityGridsResult = {
+  count: number;
+  data: SpatialDensityGrid[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDensityClustersResult = {
+  count: number;
+  data: SpatialDensityGrid[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetCoreAreasResult = {
+  count: number;
+  data: SpatialDensityGrid[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetRareVisitsResult = {
+  count: number;
+  data: SpatialDensityGrid[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDirectionalBiasStatsResult = {
+  count: number;
+  data: DirectionalBiasStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetBidirectionalPatternsResult = {
+  count: number;
+  data: DirectionalBiasStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetTopDirectionalAreasResult = {
+  count: number;
+  data: DirectionalBiasStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDiscoveryStreaksResult = {
+  count: number;
+  data: DiscoveryStreak[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetExtremeEventsResult = {
+  count: number;
+  data: ExtremeEvent[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetFirstVisitsResult = {
+  count: number;
+  data: FirstVisit[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetFootprintRankingsResult = {
+  count: number;
+  data: FootprintStatistics[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetModeBreakdownResult = {
+  count: number;
+  data: ModeStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetRevisitPatternsResult = {
+  count: number;
+  data: RevisitPattern[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetHabitualLocationsResult = {
+  count: number;
+  data: RevisitPattern[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetPeriodicLocationsResult = {
+  count: number;
+  data: RevisitPattern[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetTopRevisitLocationsResult = {
+  count: number;
+  data: RevisitPattern[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetRouteClustersResult = {
+  count: number;
+  data: RouteCluster[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetSpatialComplexityResult = {
+  count: number;
+  data: SpatialComplexity[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetSpatialUtilizationResult = {
+  count: number;
+  data: SpatialUtilization[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetTransitCorridorsResult = {
+  count: number;
+  data: SpatialUtilization[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDeepEngagementAreasResult = {
+  count: number;
+  data: SpatialUtilization[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetDestinationAreasResult = {
+  count: number;
+  data: SpatialUtilization[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetSpeedSpaceStatsResult = {
+  count: number;
+  data: SpeedSpaceStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetHighSpeedZonesResult = {
+  count: number;
+  data: SpeedSpaceStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetSlowLifeZonesResult = {
+  count: number;
+  data: SpeedSpaceStats[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetStayRankingsResult = {
+  count: number;
+  data: StayStatistics[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetTimeSpaceCompressionResult = {
+  count: number;
+  data: TimeSpaceCompression[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetBurstPeriodsResult = {
+  count: number;
+  data: TimeSpaceCompression[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetHighestMovementIntensityResult = {
+  count: number;
+  data: TimeSpaceCompression[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StatsGetTimeSpaceSlicesResult = {
+  count: number;
+  data: TimeSpaceSlice[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type WeatherListWeatherDaysResult = {
+  count: number;
+  data: WeatherDay[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type SegmentGetSegmentsResult = {
+  data: Segment[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type StatsGetVisitedAreasResult = {
+  count: number;
+  data: AreaVisit[];
+  limit: number;
+  next_cursor?: string;
+  offset: number;
+  page?: number;
+  total: number;
+};
+
+export type StayGetStaysResult = {
+  data: StaySegment[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type TripGetTripsResult = {
+  data: Trip[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type TrackGetUngeocodedPointsResult = {
+  count: number;
+  data: TrackPoint[];
+};
+
+export type JourneyGetTrainsResult = {
+  data: Journey[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type TripGetTrips2Result = {
+  data: Trip[];
+  page: number;
+  pageSize: number;
+  total: number;
+  totalPages: number;
+};
+
+export type GridGetGridCellsResult = {
+  count: number;
+  data: GridCell[];
+};
+
+export type VisualizationGetRenderingMetadataResult = {
+  count: number;
+  data: TrackPoint[];
+};
+
+export type GetHealthResult = {
+  message: string;
+  status: string;
+};
+
+export interface Envelope<T> {
+  code: number;
+  message: string;
+  data: T;
+}
+
+export class ApiError extends Error {
+  constructor(public status: number, public code: number, message: string, public body?: unknown) {
+    super(message);
+  }
+}
+
+type Query = Record<string, string | number | boolean | undefined | null>;
+
+export class RecordsClient {
+  constructor(
+    private baseUrl: string = "",
+    private fetchImpl: typeof fetch = (input, init) => fetch(input, init),
+    private headers: Record<string, string> = {},
+  ) {}
+
+  private async send(method: string, path: string, query?: Query, body?: unknown): Promise<Response> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined && value !== null) params.set(key, String(value));
+    }
+    const qs = params.toString();
+    const init: RequestInit = { method, headers: { ...this.headers } };
+    if (body instanceof FormData) {
+      init.body = body;
+    } else if (body !== undefined) {
+      init.body = JSON.stringify(body);
+      (init.headers as Record<string, string>)["Content-Type"] = "application/json";
+    }
+    const res = await this.fetchImpl(this.baseUrl + path + (qs ? "?" + qs : ""), init);
+    if (!res.ok) {
+      const err = await res.json().catch(() => undefined);
+      throw new ApiError(res.status, err?.code ?? res.status, err?.message ?? res.statusText, err);
+    }
+    return res;
+  }
+
+  private async json<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const res = await this.send(method, path, query, body);
+    return (await res.json()) as T;
+  }
+
+  private async data<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    return (await this.json<Envelope<T>>(method, path, query, body)).data;
+  }
+
+  /** List the running and queued analysis tasks */
+  analysisTaskGetQueue(): Promise<AnalysisQueue> {
+    return this.data<AnalysisQueue>("GET", `/api/v1/admin/analysis/queue`, undefined, undefined);
+  }
+
+  /** List analysis tasks */
+  analysisTaskListTasks(query: { skill_name?: string; status?: string; limit?: number; offset?: number } = {}): Promise<AnalysisTaskListTasksResult> {
+    return this.data<AnalysisTaskListTasksResult>("GET", `/api/v1/admin/analysis/tasks`, query, undefined);
+  }
+
+  /** Create an analysis task */
+  analysisTaskCreateTask(body: CreateTaskRequest): Promise<AnalysisTask> {
+    return this.data<AnalysisTask>("POST", `/api/v1/admin/analysis/tasks`, undefined, body);
+  }
+
+  /** Cancel an analysis task */
+  analysisTaskCancelTask(id: number): Promise<AnalysisTaskCancelTaskResult> {
+    return this.data<AnalysisTaskCancelTaskResult>("DELETE", `/api/v1/admin/analysis/tasks/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Get an analysis task */
+  analysisTaskGetTask(id: number): Promise<AnalysisTask> {
+    return this.data<AnalysisTask>("GET", `/api/v1/admin/analysis/tasks/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Resume an interrupted analysis task */
+  analysisTaskResumeTask(id: number): Promise<AnalysisTask> {
+    return this.data<AnalysisTask>("POST", `/api/v1/admin/analysis/tasks/${encodeURIComponent(String(id))}/resume`, undefined, undefined);
+  }
+
+  /** Queue every analyzer in dependency order */
+  analysisTaskTriggerAnalysisChain(body: TriggerAnalysisChainRequest): Promise<AnalysisTaskTriggerAnalysisChainResult> {
+    return this.data<AnalysisTaskTriggerAnalysisChainResult>("POST", `/api/v1/admin/analysis/trigger-chain`, undefined, body);
+  }
+
+  /** Back up the database */
+  backupCreateBackup(): Promise<BackupResult> {
+    return this.data<BackupResult>("POST", `/api/v1/admin/backup`, undefined, undefined);
+  }
+
+  /** List the backups in the backup store, newest first */
+  backupListBackups(): Promise<BackupList> {
+    return this.data<BackupList>("GET", `/api/v1/admin/backups`, undefined, undefined);
+  }
+
+  /** Show the running configuration */
+  configGetConfig(): Promise<Record<string, unknown> | null> {
+    return this.data<Record<string, unknown> | null>("GET", `/api/v1/admin/config`, undefined, undefined);
+  }
+
+  /** Reload the configuration file */
+  configReloadConfig(): Promise<ReloadResult> {
+    return this.data<ReloadResult>("POST", `/api/v1/admin/config/reload`, undefined, undefined);
+  }
+
+  /** List emission factors (g CO2e per km per mode) */
+  carbonListFactors(): Promise<CarbonListFactorsResult> {
+    return this.data<CarbonListFactorsResult>("GET", `/api/v1/admin/emission-factors`, undefined, undefined);
+  }
+
+  /** Override the emission factor of a mode */
+  carbonSetFactor(mode: string, body: EmissionFactorRequest): Promise<CarbonSetFactorResult> {
+    return this.data<CarbonSetFactorResult>("PUT", `/api/v1/admin/emission-factors/${encodeURIComponent(String(mode))}`, undefined, body);
+  }
+
+  /** Restore the built-in emission factor of a mode */
+  carbonResetFactor(mode: string): Promise<CarbonResetFactorResult> {
+    return this.data<CarbonResetFactorResult>("POST", `/api/v1/admin/emission-factors/${encodeURIComponent(String(mode))}/reset`, undefined, undefined);
+  }
+
+  /** Show the query plans of the hot queries */
+  explainExplain(query: { name?: string } = {}): Promise<QueryPlan[] | null> {
+    return this.data<QueryPlan[] | null>("GET", `/api/v1/admin/explain`, query, undefined);
+  }
+
+  /** List geocoding tasks */
+  geocodingListTasks(query: { status?: string; limit?: number; offset?: number } = {}): Promise<GeocodingListTasksResult> {
+    return this.data<GeocodingListTasksResult>("GET", `/api/v1/admin/geocoding/tasks`, query, undefined);
+  }
+
+  /** Start a geocoding task */
+  geocodingCreateTask(): Promise<GeocodingTask> {
+    return this.data<GeocodingTask>("POST", `/api/v1/admin/geocoding/tasks`, undefined, undefined);
+  }
+
+  /** Cancel a geocoding task */
+  geocodingCancelTask(id: number): Promise<GeocodingCancelTaskResult> {
+    return this.data<GeocodingCancelTaskResult>("DELETE", `/api/v1/admin/geocoding/tasks/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Get a geocoding task */
+  geocodingGetTask(id: number): Promise<GeocodingTask> {
+    return this.data<GeocodingTask>("GET", `/api/v1/admin/geocoding/tasks/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Describe the import watch directory */
+  watchImportGetStatus(): Promise<WatchImportStatus> {
+    return this.data<WatchImportStatus>("GET", `/api/v1/admin/import-watch`, undefined, undefined);
+  }
+
+  /** Files processed from the import watch directory, latest first */
+  watchImportListFiles(query: { status?: "imported" | "duplicate" | "failed"; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<WatchImportListFilesResult> {
+    return this.data<WatchImportListFilesResult>("GET", `/api/v1/admin/import-watch/files`, query, undefined);
+  }
+
+  /** Describe the notification channels */
+  notificationGetStatus(): Promise<NotificationStatus> {
+    return this.data<NotificationStatus>("GET", `/api/v1/admin/notifications`, undefined, undefined);
+  }
+
+  /** Notification deliveries, latest first; failed ones were retried with backoff up to NOTIFY_MAX_ATTEMPTS times */
+  notificationListDeliveries(query: { event_type?: "analysis.completed" | "analysis.failed" | "record.new" | "import.completed" | "import.failed" | "test"; status?: "delivered" | "failed"; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<NotificationListDeliveriesResult> {
+    return this.data<NotificationListDeliveriesResult>("GET", `/api/v1/admin/notifications/deliveries`, query, undefined);
+  }
+
+  /** Send a test notification to every channel */
+  notificationSendTest(): Promise<NotificationSendTestResult> {
+    return this.data<NotificationSendTestResult>("POST", `/api/v1/admin/notifications/test`, undefined, undefined);
+  }
+
+  /** List privacy zones */
+  privacyListZones(query: { include_deleted?: boolean } = {}): Promise<PrivacyListZonesResult> {
+    return this.data<PrivacyListZonesResult>("GET", `/api/v1/admin/privacy-zones`, query, undefined);
+  }
+
+  /** Create a privacy zone */
+  privacyCreateZone(body: PrivacyZoneRequest): Promise<PrivacyZone> {
+    return this.data<PrivacyZone>("POST", `/api/v1/admin/privacy-zones`, undefined, body);
+  }
+
+  /** Soft-delete a privacy zone */
+  privacyDeleteZone(id: number): Promise<PrivacyZone> {
+    return this.data<PrivacyZone>("DELETE", `/api/v1/admin/privacy-zones/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Get a privacy zone */
+  privacyGetZone(id: number): Promise<PrivacyZone> {
+    return this.data<PrivacyZone>("GET", `/api/v1/admin/privacy-zones/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Update a privacy zone */
+  privacyUpdateZone(id: number, body: PrivacyZoneRequest): Promise<PrivacyZone> {
+    return this.data<PrivacyZone>("PUT", `/api/v1/admin/privacy-zones/${encodeURIComponent(String(id))}`, undefined, body);
+  }
+
+  /** Restore a soft-deleted privacy zone */
+  privacyRestoreZone(id: number): Promise<PrivacyZone> {
+    return this.data<PrivacyZone>("POST", `/api/v1/admin/privacy-zones/${encodeURIComponent(String(id))}/restore`, undefined, undefined);
+  }
+
+  /** Plan or run a full recompute of analyzers */
+  recomputeRecompute(body: RecomputeRequest): Promise<RecomputePlan> {
+    return this.data<RecomputePlan>("POST", `/api/v1/admin/recompute`, undefined, body);
+  }
+
+  /** List the analysis schedules */
+  scheduleListSchedules(): Promise<ScheduleListSchedulesResult> {
+    return this.data<ScheduleListSchedulesResult>("GET", `/api/v1/admin/schedules`, undefined, undefined);
+  }
+
+  /** Run history of the analysis schedules, latest first */
+  scheduleListRuns(query: { schedule?: string; status?: "running" | "completed" | "failed" | "skipped"; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<ScheduleListRunsResult> {
+    return this.data<ScheduleListRunsResult>("GET", `/api/v1/admin/schedules/runs`, query, undefined);
+  }
+
+  /** List threshold profiles */
+  thresholdListProfiles(): Promise<ThresholdListProfilesResult> {
+    return this.data<ThresholdListProfilesResult>("GET", `/api/v1/admin/thresholds`, undefined, undefined);
+  }
+
+  /** Create a threshold profile */
+  thresholdCreateProfile(body: ThresholdProfileRequest): Promise<ThresholdProfile> {
+    return this.data<ThresholdProfile>("POST", `/api/v1/admin/thresholds`, undefined, body);
+  }
+
+  /** Get a threshold profile */
+  thresholdGetProfile(id: number): Promise<ThresholdProfile> {
+    return this.data<ThresholdProfile>("GET", `/api/v1/admin/thresholds/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Update a threshold profile */
+  thresholdUpdateProfile(id: number, body: ThresholdProfileRequest): Promise<ThresholdProfile> {
+    return this.data<ThresholdProfile>("PUT", `/api/v1/admin/thresholds/${encodeURIComponent(String(id))}`, undefined, body);
+  }
+
+  /** Make a threshold profile the default */
+  thresholdSetDefaultProfile(id: number): Promise<ThresholdProfile> {
+    return this.data<ThresholdProfile>("POST", `/api/v1/admin/thresholds/${encodeURIComponent(String(id))}/default`, undefined, undefined);
+  }
+
+  /** Thresholds of a profile merged with the defaults */
+  thresholdGetEffectiveThresholds(id: number): Promise<EffectiveThresholds> {
+    return this.data<EffectiveThresholds>("GET", `/api/v1/admin/thresholds/${encodeURIComponent(String(id))}/effective`, undefined, undefined);
+  }
+
+  /** Preview an analyzer on a time window without writing */
+  analysisTaskPreview(body: AnalysisPreviewRequest): Promise<AnalysisPreview> {
+    return this.data<AnalysisPreview>("POST", `/api/v1/analysis/preview`, undefined, body);
+  }
+
+  /** Algorithm versions and staleness of the analyzers */
+  analysisTaskGetStatus(): Promise<AnalysisStatus> {
+    return this.data<AnalysisStatus>("GET", `/api/v1/analysis/status`, undefined, undefined);
+  }
+
+  /** Dashboard of one day */
+  dashboardGetDashboard(query: { date?: string } = {}): Promise<Dashboard> {
+    return this.data<Dashboard>("GET", `/api/v1/dashboard`, query, undefined);
+  }
+
+  /** Swagger UI */
+  getApiV1Docs(): Promise<Response> {
+    return this.send("GET", `/api/v1/docs`, undefined, undefined);
+  }
+
+  /** Stream an anonymized, shareable track dataset */
+  exportExportAnonymized(query: { startTime?: number; endTime?: number; format?: string; precision?: number; maxShiftDays?: number } = {}): Promise<Response> {
+    return this.send("GET", `/api/v1/export/anonymized`, query, undefined);
+  }
+
+  /** List flights */
+  journeyGetFlights(query: { type?: string; startTime?: number; endTime?: number; carrier?: string; city?: string; source?: string; page?: number; pageSize?: number } = {}): Promise<JourneyGetFlightsResult> {
+    return this.data<JourneyGetFlightsResult>("GET", `/api/v1/flights`, query, undefined);
+  }
+
+  /** Import an Apple Health export */
+  healthImportAppleHealth(form: FormData): Promise<HealthImportTask> {
+    return this.data<HealthImportTask>("POST", `/api/v1/health-data/import`, undefined, form);
+  }
+
+  /** List health import tasks */
+  healthListImportTasks(query: { limit?: number } = {}): Promise<HealthListImportTasksResult> {
+    return this.data<HealthListImportTasksResult>("GET", `/api/v1/health-data/import/tasks`, query, undefined);
+  }
+
+  /** Get a health import task */
+  healthGetImportTask(id: number): Promise<HealthImportTask> {
+    return this.data<HealthImportTask>("GET", `/api/v1/health-data/import/tasks/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Health overview */
+  healthGetStats(query: { from?: string; to?: string; type?: string; limit?: number } = {}): Promise<HealthStats> {
+    return this.data<HealthStats>("GET", `/api/v1/health-data/stats`, query, undefined);
+  }
+
+  /** List workouts */
+  healthGetWorkouts(query: { from?: string; to?: string; type?: string; limit?: number } = {}): Promise<HealthGetWorkoutsResult> {
+    return this.data<HealthGetWorkoutsResult>("GET", `/api/v1/health-data/workouts`, query, undefined);
+  }
+
+  /** Ingest a live GPSLogger location */
+  ingestIngestGPSLogger(query: { lat?: number; lon?: number; time?: string; alt?: number; acc?: number; spd?: number; dir?: number; batt?: number; ischarging?: boolean; act?: string; aid?: string } = {}): Promise<IngestResult> {
+    return this.data<IngestResult>("GET", `/api/v1/ingest/gpslogger`, query, undefined);
+  }
+
+  /** Ingest a live GPSLogger location from a form */
+  ingestIngestGPSLogger2(query: { lat?: number; lon?: number; time?: string; alt?: number; acc?: number; spd?: number; dir?: number; batt?: number; ischarging?: boolean; act?: string; aid?: string } = {}): Promise<IngestResult> {
+    return this.data<IngestResult>("POST", `/api/v1/ingest/gpslogger`, query, undefined);
+  }
+
+  /** Ingest live Overland locations */
+  ingestIngestOverland(body: Record<string, unknown> | null, query: { device?: string } = {}): Promise<IngestIngestOverlandResult> {
+    return this.json<IngestIngestOverlandResult>("POST", `/api/v1/ingest/overland`, query, body);
+  }
+
+  /** Ingest live OwnTracks locations */
+  ingestIngestOwnTracks(body: OwnTracksMessage, query: { u?: string; d?: string } = {}): Promise<unknown[] | null> {
+    return this.json<unknown[] | null>("POST", `/api/v1/ingest/owntracks`, query, body);
+  }
+
+  /** Describe live ingestion */
+  ingestGetStatus(): Promise<IngestStatus> {
+    return this.data<IngestStatus>("GET", `/api/v1/ingest/status`, undefined, undefined);
+  }
+
+  /** Generated findings per month and year, newest first */
+  insightGetInsights(query: { bucket_type?: "month" | "year"; bucket_key?: string; category?: "FOOTPRINT" | "DISTANCE" | "COMMUTE" | "EXPLORATION" | "RECORD"; rule?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<InsightGetInsightsResult> {
+    return this.data<InsightGetInsightsResult>("GET", `/api/v1/insights`, query, undefined);
+  }
+
+  /** List flights and train journeys */
+  journeyGetJourneys(query: { type?: string; startTime?: number; endTime?: number; carrier?: string; city?: string; source?: string; page?: number; pageSize?: number } = {}): Promise<JourneyGetJourneysResult> {
+    return this.data<JourneyGetJourneysResult>("GET", `/api/v1/journeys`, query, undefined);
+  }
+
+  /** Import journey records */
+  journeyImportJourneys(form: FormData, query: { type?: "FLIGHT" | "TRAIN" } = {}): Promise<JourneyImportJourneysResult> {
+    return this.data<JourneyImportJourneysResult>("POST", `/api/v1/journeys/import`, query, form);
+  }
+
+  /** Link journeys to trajectory segments */
+  journeyLinkSegments(): Promise<JourneyLinkSegmentsResult> {
+    return this.data<JourneyLinkSegmentsResult>("POST", `/api/v1/journeys/link`, undefined, undefined);
+  }
+
+  /** Journey statistics */
+  journeyGetJourneyStats(query: { type?: "FLIGHT" | "TRAIN"; limit?: number } = {}): Promise<JourneyStats> {
+    return this.data<JourneyStats>("GET", `/api/v1/journeys/stats`, query, undefined);
+  }
+
+  /** Get a journey */
+  journeyGetJourneyByID(id: number): Promise<Journey> {
+    return this.data<Journey>("GET", `/api/v1/journeys/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Input activity by weekday and hour */
+  inputActivityGetHeatmap(query: { from?: string; to?: string; device?: string; metric?: string } = {}): Promise<InputHeatmap> {
+    return this.data<InputHeatmap>("GET", `/api/v1/keyboard/heatmap`, query, undefined);
+  }
+
+  /** Import keyboard and mouse logs */
+  inputActivityImportInputActivity(form: FormData, query: { device?: string } = {}): Promise<InputActivityImportInputActivityResult> {
+    return this.data<InputActivityImportInputActivityResult>("POST", `/api/v1/keyboard/import`, query, form);
+  }
+
+  /** Keyboard and mouse overview */
+  inputActivityGetStats(query: { from?: string; to?: string; device?: string; metric?: string } = {}): Promise<InputActivityStats> {
+    return this.data<InputActivityStats>("GET", `/api/v1/keyboard/stats`, query, undefined);
+  }
+
+  /** Input activity against time at work */
+  inputActivityGetWorkCorrelation(query: { from?: string; to?: string; device?: string; metric?: string } = {}): Promise<InputWorkCorrelation> {
+    return this.data<InputWorkCorrelation>("GET", `/api/v1/keyboard/work-correlation`, query, undefined);
+  }
+
+  /** This OpenAPI document */
+  getApiV1OpenapiJson(): Promise<Record<string, unknown>> {
+    return this.json<Record<string, unknown>>("GET", `/api/v1/openapi.json`, undefined, undefined);
+  }
+
+  /** List photos */
+  photoListPhotos(query: { start_time?: number; end_time?: number; source?: string; located?: boolean; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<PhotoListPhotosResult> {
+    return this.data<PhotoListPhotosResult>("GET", `/api/v1/photos`, query, undefined);
+  }
+
+  /** Import photo geotags from Immich */
+  photoImportImmichPhotos(body: PhotoImmichRequest): Promise<PhotoImportResult> {
+    return this.data<PhotoImportResult>("POST", `/api/v1/photos/import/immich`, undefined, body);
+  }
+
+  /** Import photo geotags from PHOTO_DIR */
+  photoScanPhotos(body: PhotoScanRequest): Promise<PhotoImportResult> {
+    return this.data<PhotoImportResult>("POST", `/api/v1/photos/import/scan`, undefined, body);
+  }
+
+  /** Get a photo */
+  photoGetPhoto(id: number): Promise<Photo> {
+    return this.data<Photo>("GET", `/api/v1/photos/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Image of a photo */
+  photoGetPhotoFile(id: number): Promise<Response> {
+    return this.send("GET", `/api/v1/photos/${encodeURIComponent(String(id))}/file`, undefined, undefined);
+  }
+
+  /** List places */
+  placeListPlaces(query: { name?: string; label?: string; city?: string; named?: boolean; min_visits?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<PlaceListPlacesResult> {
+    return this.data<PlaceListPlacesResult>("GET", `/api/v1/places`, query, undefined);
+  }
+
+  /** Get a place with its nearby venues */
+  placeGetPlace(id: number): Promise<Place> {
+    return this.data<Place>("GET", `/api/v1/places/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Name a place */
+  placeRenamePlace(id: number, body: PlaceNameRequest): Promise<Place> {
+    return this.data<Place>("PUT", `/api/v1/places/${encodeURIComponent(String(id))}`, undefined, body);
+  }
+
+  /** Points flagged by outlier detection or manually reviewed */
+  qAGetOutliers(query: { reason?: string; qa_status?: string; start_time?: number; end_time?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<QAGetOutliersResult> {
+    return this.data<QAGetOutliersResult>("GET", `/api/v1/qa/outliers`, query, undefined);
+  }
+
+  /** Confirm points as outliers */
+  qAConfirmOutliers(body: OutlierReviewRequest): Promise<OutlierReviewResult> {
+    return this.data<OutlierReviewResult>("POST", `/api/v1/qa/outliers/confirm`, undefined, body);
+  }
+
+  /** Drop the manual review of points */
+  qAResetReviews(body: OutlierReviewRequest): Promise<OutlierReviewResult> {
+    return this.data<OutlierReviewResult>("POST", `/api/v1/qa/outliers/reset`, undefined, body);
+  }
+
+  /** Clear the outlier flag of points */
+  qAUnflagOutliers(body: OutlierReviewRequest): Promise<OutlierReviewResult> {
+    return this.data<OutlierReviewResult>("POST", `/api/v1/qa/outliers/unflag`, undefined, body);
+  }
+
+  /** Annual report */
+  reportGetAnnualReport(year: number, query: { format?: "json" | "html" } = {}): Promise<AnnualReport> {
+    return this.data<AnnualReport>("GET", `/api/v1/reports/annual/${encodeURIComponent(String(year))}`, query, undefined);
+  }
+
+  /** Usage by category */
+  screenTimeGetCategories(query: { from?: string; to?: string; device?: string; category?: string; limit?: number } = {}): Promise<ScreenTimeGetCategoriesResult> {
+    return this.data<ScreenTimeGetCategoriesResult>("GET", `/api/v1/screentime/categories`, query, undefined);
+  }
+
+  /** Import Screen Time or Digital Wellbeing CSV exports */
+  screenTimeImportScreenTime(form: FormData, query: { device?: string } = {}): Promise<ScreenTimeImportScreenTimeResult> {
+    return this.data<ScreenTimeImportScreenTimeResult>("POST", `/api/v1/screentime/import`, query, form);
+  }
+
+  /** Screen time overview */
+  screenTimeGetStats(query: { from?: string; to?: string; device?: string; category?: string; limit?: number } = {}): Promise<ScreenTimeSummary> {
+    return this.data<ScreenTimeSummary>("GET", `/api/v1/screentime/stats`, query, undefined);
+  }
+
+  /** Most used apps */
+  screenTimeGetTopApps(query: { from?: string; to?: string; device?: string; category?: string; limit?: number } = {}): Promise<ScreenTimeGetTopAppsResult> {
+    return this.data<ScreenTimeGetTopAppsResult>("GET", `/api/v1/screentime/top-apps`, query, undefined);
+  }
+
+  /** Weekly usage */
+  screenTimeGetWeeklyTrends(query: { from?: string; to?: string; device?: string; category?: string; limit?: number } = {}): Promise<ScreenTimeGetWeeklyTrendsResult> {
+    return this.data<ScreenTimeGetWeeklyTrendsResult>("GET", `/api/v1/screentime/trends/weekly`, query, undefined);
+  }
+
+  /** Search stays, trips, places and events */
+  searchSearch(query: { q?: string; type?: "stay" | "trip" | "place" | "event"; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<SearchSearchResult> {
+    return this.data<SearchSearchResult>("GET", `/api/v1/search`, query, undefined);
+  }
+
+  /** List segment mode corrections, newest first */
+  segmentGetModeCorrections(): Promise<ModeCorrection[] | null> {
+    return this.data<ModeCorrection[] | null>("GET", `/api/v1/segments/mode-corrections`, undefined, undefined);
+  }
+
+  /** Get a segment with its points, neighbouring segments and trip */
+  detailGetSegmentDetail(id: number, query: { max_points?: number } = {}): Promise<SegmentDetail> {
+    return this.data<SegmentDetail>("GET", `/api/v1/segments/${encodeURIComponent(String(id))}`, query, undefined);
+  }
+
+  /** Correct the transport mode of a segment */
+  segmentCorrectSegmentMode(id: number, body: SegmentModeRequest): Promise<SegmentModeResult> {
+    return this.data<SegmentModeResult>("PATCH", `/api/v1/segments/${encodeURIComponent(String(id))}/mode`, undefined, body);
+  }
+
+  /** Share card of a day */
+  shareGetDayCard(date: string): Promise<Response> {
+    return this.send("GET", `/api/v1/share/day/${encodeURIComponent(String(date))}.png`, undefined, undefined);
+  }
+
+  /** Share card of a trip */
+  shareGetTripCard(id: number): Promise<Response> {
+    return this.send("GET", `/api/v1/share/trip/${encodeURIComponent(String(id))}.png`, undefined, undefined);
+  }
+
+  /** Administrative boundary crossings */
+  statsGetAdminCrossings(query: { crossing_type?: string; from?: string; to?: string; start_time?: number; end_time?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetAdminCrossingsResult> {
+    return this.data<StatsGetAdminCrossingsResult>("GET", `/api/v1/stats/admin-crossings`, query, undefined);
+  }
+
+  /** Administrative hierarchy with coverage */
+  statsGetAdminTree(query: { province?: string; city?: string; county?: string; depth?: number; include_unvisited?: boolean } = {}): Promise<AdminTreeNode> {
+    return this.data<AdminTreeNode>("GET", `/api/v1/stats/admin-tree`, query, undefined);
+  }
+
+  /** Statistics per administrative area */
+  statsGetAdminView(query: { admin_level?: string; admin_name?: string; parent_name?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetAdminViewResult> {
+    return this.data<StatsGetAdminViewResult>("GET", `/api/v1/stats/admin-view`, query, undefined);
+  }
+
+  /** Visits to countries, provinces and cities */
+  statsGetAdminVisits(query: { admin_level?: string; admin_name?: string; parent_name?: string; start_time?: number; end_time?: number; min_duration_s?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetAdminVisitsResult> {
+    return this.data<StatsGetAdminVisitsResult>("GET", `/api/v1/stats/admin-visits`, query, undefined);
+  }
+
+  /** Visit count and time spent per region */
+  statsGetAdminVisitSummaries(query: { admin_level?: "COUNTRY" | "PROVINCE" | "CITY" } = {}): Promise<AdminVisitSummary[] | null> {
+    return this.data<AdminVisitSummary[] | null>("GET", `/api/v1/stats/admin-visits/summary`, query, undefined);
+  }
+
+  /** Altitude statistics per area */
+  statsGetAltitudeStats(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; area_key?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetAltitudeStatsResult> {
+    return this.data<StatsGetAltitudeStatsResult>("GET", `/api/v1/stats/altitude`, query, undefined);
+  }
+
+  /** Areas with the highest vertical intensity */
+  statsGetHighestVerticalIntensity(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetHighestVerticalIntensityResult> {
+    return this.data<StatsGetHighestVerticalIntensityResult>("GET", `/api/v1/stats/altitude/highest-intensity`, query, undefined);
+  }
+
+  /** Areas with the largest altitude span */
+  statsGetHighestAltitudeSpans(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetHighestAltitudeSpansResult> {
+    return this.data<StatsGetHighestAltitudeSpansResult>("GET", `/api/v1/stats/altitude/highest-spans`, query, undefined);
+  }
+
+  /** Days whose distance, range, stays or new places stand out from the preceding days */
+  statsGetAnomalousDays(query: { from?: string; to?: string; category?: "TRAVEL_DAY" | "UNUSUAL_ROUTINE" | "QUIET_DAY"; reason?: string; min_score?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetAnomalousDaysResult> {
+    return this.data<StatsGetAnomalousDaysResult>("GET", `/api/v1/stats/anomalies`, query, undefined);
+  }
+
+  /** Run several stats queries in one request */
+  batchBatch(body: BatchRequest): Promise<BatchBatchResult> {
+    return this.data<BatchBatchResult>("POST", `/api/v1/stats/batch`, undefined, body);
+  }
+
+  /** Carbon footprint per year and month */
+  carbonGetCarbonSummary(query: { year?: number } = {}): Promise<CarbonSummary> {
+    return this.data<CarbonSummary>("GET", `/api/v1/stats/carbon`, query, undefined);
+  }
+
+  /** Carbon footprint per trip */
+  carbonGetTripCarbon(query: { start_time?: number; end_time?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<CarbonGetTripCarbonResult> {
+    return this.data<CarbonGetTripCarbonResult>("GET", `/api/v1/stats/carbon/trips`, query, undefined);
+  }
+
+  /** Commute patterns */
+  statsGetCommuteStats(query: { direction?: "HOME_TO_WORK" | "WORK_TO_HOME" } = {}): Promise<CommuteStats> {
+    return this.data<CommuteStats>("GET", `/api/v1/stats/commute`, query, undefined);
+  }
+
+  /** Compare two months or two years */
+  statsCompareBuckets(query: { bucketA?: string; bucketB?: string } = {}): Promise<BucketComparison> {
+    return this.data<BucketComparison>("GET", `/api/v1/stats/compare`, query, undefined);
+  }
+
+  /** Visited countries and regions */
+  statsGetCountryVisits(): Promise<CountryVisit[] | null> {
+    return this.data<CountryVisit[] | null>("GET", `/api/v1/stats/countries`, undefined, undefined);
+  }
+
+  /** Days spent outside China per year */
+  statsGetDaysAbroad(): Promise<YearAbroad[] | null> {
+    return this.data<YearAbroad[] | null>("GET", `/api/v1/stats/countries/days-abroad`, undefined, undefined);
+  }
+
+  /** Distance, trips, cities and stays on workdays, weekends and public holidays */
+  statsGetDayTypeStats(query: { bucket?: "all" | "year"; bucket_key?: string; day_type?: "WORKDAY" | "WEEKEND" | "HOLIDAY"; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDayTypeStatsResult> {
+    return this.data<StatsGetDayTypeStatsResult>("GET", `/api/v1/stats/day-types`, query, undefined);
+  }
+
+  /** Compare weekends and public holidays with workdays */
+  statsCompareDayTypes(query: { year?: number } = {}): Promise<DayTypeComparison> {
+    return this.data<DayTypeComparison>("GET", `/api/v1/stats/day-types/compare`, query, undefined);
+  }
+
+  /** Distance, active time and visited areas per part of the day */
+  statsGetDaypartStats(query: { bucket?: "all" | "year" | "month"; bucket_key?: string; daypart?: "MORNING" | "AFTERNOON" | "EVENING" | "NIGHT"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDaypartStatsResult> {
+    return this.data<StatsGetDaypartStatsResult>("GET", `/api/v1/stats/dayparts`, query, undefined);
+  }
+
+  /** Density grid cells */
+  statsGetDensityGrids(query: { bucket?: "all" | "year" | "month"; source?: string; level?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDensityGridsResult> {
+    return this.data<StatsGetDensityGridsResult>("GET", `/api/v1/stats/density`, query, undefined);
+  }
+
+  /** Density clusters */
+  statsGetDensityClusters(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDensityClustersResult> {
+    return this.data<StatsGetDensityClustersResult>("GET", `/api/v1/stats/density/clusters`, query, undefined);
+  }
+
+  /** Core activity areas */
+  statsGetCoreAreas(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetCoreAreasResult> {
+    return this.data<StatsGetCoreAreasResult>("GET", `/api/v1/stats/density/core`, query, undefined);
+  }
+
+  /** Rarely visited cells */
+  statsGetRareVisits(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetRareVisitsResult> {
+    return this.data<StatsGetRareVisitsResult>("GET", `/api/v1/stats/density/rare`, query, undefined);
+  }
+
+  /** Directional bias per area */
+  statsGetDirectionalBiasStats(query: { bucket?: "all" | "year" | "month" | "season"; source?: string; area_type?: string; area_key?: string; mode?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDirectionalBiasStatsResult> {
+    return this.data<StatsGetDirectionalBiasStatsResult>("GET", `/api/v1/stats/directional-bias`, query, undefined);
+  }
+
+  /** Areas travelled back and forth */
+  statsGetBidirectionalPatterns(query: { bucket?: "all" | "year" | "month" | "season"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetBidirectionalPatternsResult> {
+    return this.data<StatsGetBidirectionalPatternsResult>("GET", `/api/v1/stats/directional-bias/bidirectional`, query, undefined);
+  }
+
+  /** Wind-rose bins of an area's travel directions */
+  statsGetDirectionalRose(query: { area_type?: string; area_key?: string; mode?: string; bucket?: "all" | "year" | "month" | "season"; bucket_key?: string; source?: string } = {}): Promise<DirectionalRose> {
+    return this.data<DirectionalRose>("GET", `/api/v1/stats/directional-bias/rose`, query, undefined);
+  }
+
+  /** Areas with the strongest directional bias */
+  statsGetTopDirectionalAreas(query: { bucket?: "all" | "year" | "month" | "season"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetTopDirectionalAreasResult> {
+    return this.data<StatsGetTopDirectionalAreasResult>("GET", `/api/v1/stats/directional-bias/top-areas`, query, undefined);
+  }
+
+  /** Monthly exploration curve */
+  statsGetExplorationCurve(query: { from?: string; to?: string } = {}): Promise<ExplorationCurve> {
+    return this.data<ExplorationCurve>("GET", `/api/v1/stats/exploration`, query, undefined);
+  }
+
+  /** Runs of consecutive days that each visited a new grid cell */
+  statsGetDiscoveryStreaks(query: { from?: string; to?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDiscoveryStreaksResult> {
+    return this.data<StatsGetDiscoveryStreaksResult>("GET", `/api/v1/stats/exploration/streaks`, query, undefined);
+  }
+
+  /** Extreme events */
+  statsGetExtremeEvents(query: { eventType?: string; eventCategory?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetExtremeEventsResult> {
+    return this.data<StatsGetExtremeEventsResult>("GET", `/api/v1/stats/extreme-events`, query, undefined);
+  }
+
+  /** First visit of each city or county, oldest first */
+  statsGetFirstVisits(query: { level?: "city" | "county"; from?: string; to?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetFirstVisitsResult> {
+    return this.data<StatsGetFirstVisitsResult>("GET", `/api/v1/stats/first-visits`, query, undefined);
+  }
+
+  /** Share of Chinese provinces, cities and counties visited */
+  statsGetFootprintCoverage(query: { year?: number } = {}): Promise<FootprintCoverage> {
+    return this.data<FootprintCoverage>("GET", `/api/v1/stats/footprint/coverage`, query, undefined);
+  }
+
+  /** Footprint rankings */
+  statsGetFootprintRankings(query: { statType?: string; timeRange?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetFootprintRankingsResult> {
+    return this.data<StatsGetFootprintRankingsResult>("GET", `/api/v1/stats/footprint/rankings`, query, undefined);
+  }
+
+  /** Correlation of health data with movement */
+  statsGetHealthCorrelation(query: { from?: string; to?: string } = {}): Promise<HealthCorrelationStats> {
+    return this.data<HealthCorrelationStats>("GET", `/api/v1/stats/health-correlation`, query, undefined);
+  }
+
+  /** Distance, duration, trips and CO2 estimate per transport mode */
+  statsGetModeBreakdown(query: { bucket?: "year" | "month" | "all"; bucket_key?: string; mode?: string; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetModeBreakdownResult> {
+    return this.data<StatsGetModeBreakdownResult>("GET", `/api/v1/stats/mode-breakdown`, query, undefined);
+  }
+
+  /** Mileage on each high-speed rail line */
+  statsGetRailLineStats(query: { year?: number } = {}): Promise<RailLineStats> {
+    return this.data<RailLineStats>("GET", `/api/v1/stats/rail-lines`, query, undefined);
+  }
+
+  /** Personal records */
+  statsGetPersonalRecords(): Promise<PersonalRecord[] | null> {
+    return this.data<PersonalRecord[] | null>("GET", `/api/v1/stats/records`, undefined, undefined);
+  }
+
+  /** Revisit patterns of places */
+  statsGetRevisitPatterns(query: { min_visits?: number; habitual_only?: boolean; periodic_only?: boolean; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetRevisitPatternsResult> {
+    return this.data<StatsGetRevisitPatternsResult>("GET", `/api/v1/stats/revisit-patterns`, query, undefined);
+  }
+
+  /** Habitual places */
+  statsGetHabitualLocations(query: { limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetHabitualLocationsResult> {
+    return this.data<StatsGetHabitualLocationsResult>("GET", `/api/v1/stats/revisit-patterns/habitual`, query, undefined);
+  }
+
+  /** Periodically visited places */
+  statsGetPeriodicLocations(query: { limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetPeriodicLocationsResult> {
+    return this.data<StatsGetPeriodicLocationsResult>("GET", `/api/v1/stats/revisit-patterns/periodic`, query, undefined);
+  }
+
+  /** Most revisited places */
+  statsGetTopRevisitLocations(query: { limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetTopRevisitLocationsResult> {
+    return this.data<StatsGetTopRevisitLocationsResult>("GET", `/api/v1/stats/revisit-patterns/top-locations`, query, undefined);
+  }
+
+  /** Overlap of the trajectory with the road network */
+  statsGetRoadOverlapSummary(): Promise<RoadOverlapSummary> {
+    return this.data<RoadOverlapSummary>("GET", `/api/v1/stats/road-overlap`, undefined, undefined);
+  }
+
+  /** Frequently travelled routes */
+  statsGetRouteClusters(query: { mode?: string; minTrips?: number; includePolyline?: boolean; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetRouteClustersResult> {
+    return this.data<StatsGetRouteClustersResult>("GET", `/api/v1/stats/routes`, query, undefined);
+  }
+
+  /** Spatial complexity of the trajectory per day or month */
+  statsGetSpatialComplexity(query: { period?: "day" | "month" | "all"; from?: string; to?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetSpatialComplexityResult> {
+    return this.data<StatsGetSpatialComplexityResult>("GET", `/api/v1/stats/spatial-complexity`, query, undefined);
+  }
+
+  /** Spatial utilization per area */
+  statsGetSpatialUtilization(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; area_key?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetSpatialUtilizationResult> {
+    return this.data<StatsGetSpatialUtilizationResult>("GET", `/api/v1/stats/spatial-utilization`, query, undefined);
+  }
+
+  /** Transit corridors */
+  statsGetTransitCorridors(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetTransitCorridorsResult> {
+    return this.data<StatsGetTransitCorridorsResult>("GET", `/api/v1/stats/spatial-utilization/corridors`, query, undefined);
+  }
+
+  /** Areas of deep engagement */
+  statsGetDeepEngagementAreas(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDeepEngagementAreasResult> {
+    return this.data<StatsGetDeepEngagementAreasResult>("GET", `/api/v1/stats/spatial-utilization/deep-engagement`, query, undefined);
+  }
+
+  /** Destination areas */
+  statsGetDestinationAreas(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetDestinationAreasResult> {
+    return this.data<StatsGetDestinationAreasResult>("GET", `/api/v1/stats/spatial-utilization/destinations`, query, undefined);
+  }
+
+  /** Speed-space coupling per area */
+  statsGetSpeedSpaceStats(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; area_name?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetSpeedSpaceStatsResult> {
+    return this.data<StatsGetSpeedSpaceStatsResult>("GET", `/api/v1/stats/speed-space`, query, undefined);
+  }
+
+  /** Areas crossed at high speed */
+  statsGetHighSpeedZones(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetHighSpeedZonesResult> {
+    return this.data<StatsGetHighSpeedZonesResult>("GET", `/api/v1/stats/speed-space/high-speed-zones`, query, undefined);
+  }
+
+  /** Areas where movement is slow and stays are long */
+  statsGetSlowLifeZones(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetSlowLifeZonesResult> {
+    return this.data<StatsGetSlowLifeZonesResult>("GET", `/api/v1/stats/speed-space/slow-life-zones`, query, undefined);
+  }
+
+  /** Stay rankings */
+  statsGetStayRankings(query: { statType?: string; timeRange?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetStayRankingsResult> {
+    return this.data<StatsGetStayRankingsResult>("GET", `/api/v1/stats/stay/rankings`, query, undefined);
+  }
+
+  /** Time-space compression per area */
+  statsGetTimeSpaceCompression(query: { bucket?: "all" | "year" | "month"; source?: string; area_type?: string; area_key?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetTimeSpaceCompressionResult> {
+    return this.data<StatsGetTimeSpaceCompressionResult>("GET", `/api/v1/stats/time-space-compression`, query, undefined);
+  }
+
+  /** Burst periods of movement */
+  statsGetBurstPeriods(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetBurstPeriodsResult> {
+    return this.data<StatsGetBurstPeriodsResult>("GET", `/api/v1/stats/time-space-compression/burst-periods`, query, undefined);
+  }
+
+  /** Areas with the highest movement intensity */
+  statsGetHighestMovementIntensity(query: { bucket?: "all" | "year" | "month"; source?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetHighestMovementIntensityResult> {
+    return this.data<StatsGetHighestMovementIntensityResult>("GET", `/api/v1/stats/time-space-compression/highest-intensity`, query, undefined);
+  }
+
+  /** Time-space slices */
+  statsGetTimeSpaceSlices(query: { slice_type?: string; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetTimeSpaceSlicesResult> {
+    return this.data<StatsGetTimeSpaceSlicesResult>("GET", `/api/v1/stats/time-space-slices`, query, undefined);
+  }
+
+  /** Activity by hour of day (24 slices) */
+  statsGetHourlyPattern(): Promise<TimeSpaceSlice[] | null> {
+    return this.data<TimeSpaceSlice[] | null>("GET", `/api/v1/stats/time-space-slices/hourly-pattern`, undefined, undefined);
+  }
+
+  /** Activity by hour of the week (168 slices) */
+  statsGetWeeklyPattern(): Promise<TimeSpaceSlice[] | null> {
+    return this.data<TimeSpaceSlice[] | null>("GET", `/api/v1/stats/time-space-slices/weekly-pattern`, undefined, undefined);
+  }
+
+  /** Weather of the visited days and distance travelled in the rain */
+  weatherGetWeatherSummary(query: { year?: number } = {}): Promise<WeatherSummary> {
+    return this.data<WeatherSummary>("GET", `/api/v1/stats/weather`, query, undefined);
+  }
+
+  /** Daily weather at the visited locations */
+  weatherListWeatherDays(query: { start_date?: string; end_date?: string; rainy?: boolean; all_cells?: boolean; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<WeatherListWeatherDaysResult> {
+    return this.data<WeatherListWeatherDaysResult>("GET", `/api/v1/stats/weather/days`, query, undefined);
+  }
+
+  /** Get a stay with its points, annotation, neighbouring stays and trips */
+  detailGetStayDetail(id: number, query: { max_points?: number } = {}): Promise<StayDetail> {
+    return this.data<StayDetail>("GET", `/api/v1/stays/${encodeURIComponent(String(id))}`, query, undefined);
+  }
+
+  /** Photos taken during a stay, in time order */
+  photoGetStayPhotos(id: number): Promise<PhotoSet> {
+    return this.data<PhotoSet>("GET", `/api/v1/stays/${encodeURIComponent(String(id))}/photos`, undefined, undefined);
+  }
+
+  /** Daily summaries of a date range */
+  summaryGetDailySummaries(query: { from?: string; to?: string } = {}): Promise<DailyTimeline> {
+    return this.data<DailyTimeline>("GET", `/api/v1/summary/daily`, query, undefined);
+  }
+
+  /** Import GPX, KML, CSV or JSON track files */
+  importImportTracks(form: FormData, query: { source?: string; device?: string; analyze?: boolean } = {}): Promise<ImportResponse> {
+    return this.data<ImportResponse>("POST", `/api/v1/tracks/import`, query, form);
+  }
+
+  /** List track points */
+  trackGetTrackPoints(query: { startTime?: number; endTime?: number; province?: string; city?: string; county?: string; country?: string; source?: string; sourceDevice?: string; minSpeed?: number; maxSpeed?: number; page?: number; pageSize?: number; after_id?: number; limit?: number; fields?: string; minLat?: number; maxLat?: number; minLon?: number; maxLon?: number } = {}): Promise<TrackPointsResponse> {
+    return this.data<TrackPointsResponse>("GET", `/api/v1/tracks/points`, query, undefined);
+  }
+
+  /** Get a track point */
+  trackGetTrackPointByID(id: number): Promise<TrackPoint> {
+    return this.data<TrackPoint>("GET", `/api/v1/tracks/points/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** List movement segments */
+  segmentGetSegments(query: { mode?: string; startTime?: number; endTime?: number; province?: string; city?: string; county?: string; source?: string; minDistance?: number; minDuration?: number; minConfidence?: number; page?: number; pageSize?: number } = {}): Promise<SegmentGetSegmentsResult> {
+    return this.data<SegmentGetSegmentsResult>("GET", `/api/v1/tracks/segments`, query, undefined);
+  }
+
+  /** Get a movement segment */
+  segmentGetSegmentByID(id: number): Promise<Segment> {
+    return this.data<Segment>("GET", `/api/v1/tracks/segments/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Footprint statistics of a time range */
+  statsGetFootprintStatistics(query: { startTime?: number; endTime?: number } = {}): Promise<FootprintStatistics> {
+    return this.data<FootprintStatistics>("GET", `/api/v1/tracks/statistics/footprint`, query, undefined);
+  }
+
+  /** Provinces, cities or counties visited within a time range */
+  statsGetVisitedAreas(query: { level?: "province" | "city" | "county"; startTime?: number; endTime?: number; limit?: number; page?: number; cursor?: string; sort?: string; order?: "asc" | "desc"; fields?: string } = {}): Promise<StatsGetVisitedAreasResult> {
+    return this.data<StatsGetVisitedAreasResult>("GET", `/api/v1/tracks/statistics/footprint/areas`, query, undefined);
+  }
+
+  /** Point counts by speed range */
+  statsGetSpeedDistribution(query: { startTime?: number; endTime?: number } = {}): Promise<SpeedDistribution[] | null> {
+    return this.data<SpeedDistribution[] | null>("GET", `/api/v1/tracks/statistics/speed-distribution`, query, undefined);
+  }
+
+  /** Point counts by hour of day */
+  statsGetTimeDistribution(query: { startTime?: number; endTime?: number } = {}): Promise<TimeDistribution[] | null> {
+    return this.data<TimeDistribution[] | null>("GET", `/api/v1/tracks/statistics/time-distribution`, query, undefined);
+  }
+
+  /** List stays */
+  stayGetStays(query: { stayType?: string; stayCategory?: string; minDuration?: number; province?: string; city?: string; county?: string; source?: string; startTime?: number; endTime?: number; minConfidence?: number; placeId?: number; page?: number; pageSize?: number } = {}): Promise<StayGetStaysResult> {
+    return this.data<StayGetStaysResult>("GET", `/api/v1/tracks/stays`, query, undefined);
+  }
+
+  /** Get a stay */
+  stayGetStayByID(id: number): Promise<StaySegment> {
+    return this.data<StaySegment>("GET", `/api/v1/tracks/stays/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** List trips */
+  tripGetTrips(query: { startTime?: number; endTime?: number; originProvince?: string; originCity?: string; originCounty?: string; destProvince?: string; destCity?: string; destCounty?: string; minDistance?: number; primaryMode?: string; purpose?: string; dayType?: string; placeId?: number; wet?: boolean; minTemp?: number; maxTemp?: number; page?: number; pageSize?: number } = {}): Promise<TripGetTripsResult> {
+    return this.data<TripGetTripsResult>("GET", `/api/v1/tracks/trips`, query, undefined);
+  }
+
+  /** Get a trip */
+  tripGetTripByID(id: number): Promise<Trip> {
+    return this.data<Trip>("GET", `/api/v1/tracks/trips/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** List points without administrative areas */
+  trackGetUngeocodedPoints(query: { limit?: number } = {}): Promise<TrackGetUngeocodedPointsResult> {
+    return this.data<TrackGetUngeocodedPointsResult>("GET", `/api/v1/tracks/ungeocoded`, query, undefined);
+  }
+
+  /** List train journeys */
+  journeyGetTrains(query: { type?: string; startTime?: number; endTime?: number; carrier?: string; city?: string; source?: string; page?: number; pageSize?: number } = {}): Promise<JourneyGetTrainsResult> {
+    return this.data<JourneyGetTrainsResult>("GET", `/api/v1/trains`, query, undefined);
+  }
+
+  /** List trips */
+  tripGetTrips2(query: { startTime?: number; endTime?: number; originProvince?: string; originCity?: string; originCounty?: string; destProvince?: string; destCity?: string; destCounty?: string; minDistance?: number; primaryMode?: string; purpose?: string; dayType?: string; placeId?: number; wet?: boolean; minTemp?: number; maxTemp?: number; page?: number; pageSize?: number } = {}): Promise<TripGetTrips2Result> {
+    return this.data<TripGetTrips2Result>("GET", `/api/v1/trips`, query, undefined);
+  }
+
+  /** List manual trip splits and merges */
+  tripGetTripEdits(): Promise<TripEdit[] | null> {
+    return this.data<TripEdit[] | null>("GET", `/api/v1/trips/edits`, undefined, undefined);
+  }
+
+  /** Remove a trip edit */
+  tripDeleteTripEdit(id: number): Promise<TripEditResult> {
+    return this.data<TripEditResult>("DELETE", `/api/v1/trips/edits/${encodeURIComponent(String(id))}`, undefined, undefined);
+  }
+
+  /** Merge consecutive trips */
+  tripMergeTrips(body: TripMergeRequest): Promise<TripEditResult> {
+    return this.data<TripEditResult>("POST", `/api/v1/trips/merge`, undefined, body);
+  }
+
+  /** Origin-destination matrix of trips */
+  tripGetODMatrix(query: { startTime?: number; endTime?: number; level?: string; purpose?: string; primaryMode?: string; includeIntra?: boolean; minCount?: number } = {}): Promise<ODMatrix> {
+    return this.data<ODMatrix>("GET", `/api/v1/trips/od-matrix`, query, undefined);
+  }
+
+  /** Get a trip with its segments, points, end stays and neighbouring trips */
+  detailGetTripDetail(id: number, query: { max_points?: number } = {}): Promise<TripDetail> {
+    return this.data<TripDetail>("GET", `/api/v1/trips/${encodeURIComponent(String(id))}`, query, undefined);
+  }
+
+  /** Export a trip as GPX */
+  tripExportTripGPX(id: number): Promise<Response> {
+    return this.send("GET", `/api/v1/trips/${encodeURIComponent(String(id))}/export.gpx`, undefined, undefined);
+  }
+
+  /** Photos taken during a trip, in time order */
+  photoGetTripPhotos(id: number): Promise<PhotoSet> {
+    return this.data<PhotoSet>("GET", `/api/v1/trips/${encodeURIComponent(String(id))}/photos`, undefined, undefined);
+  }
+
+  /** Split a trip */
+  tripSplitTrip(id: number, body: TripSplitRequest): Promise<TripEditResult> {
+    return this.data<TripEditResult>("POST", `/api/v1/trips/${encodeURIComponent(String(id))}/split`, undefined, body);
+  }
+
+  /** Grid cells in a bounding box */
+  gridGetGridCells(query: { level?: number; minLat?: number; maxLat?: number; minLon?: number; maxLon?: number; minDensity?: number } = {}): Promise<GridGetGridCellsResult> {
+    return this.data<GridGetGridCellsResult>("GET", `/api/v1/viz/grid-cells`, query, undefined);
+  }
+
+  /** Heatmap data */
+  gridGetHeatmapData(query: { level?: number; minLat?: number; maxLat?: number; minLon?: number; maxLon?: number; minDensity?: number; bbox?: string; zoom?: number; bucket?: string; source?: string; metric?: string } = {}): Promise<HeatmapResponse> {
+    return this.data<HeatmapResponse>("GET", `/api/v1/viz/heatmap`, query, undefined);
+  }
+
+  /** Positions at fixed time steps for animated replay */
+  playbackGetPlayback(query: { from?: number; to?: number; step?: number; max_gap?: number } = {}): Promise<PlaybackResponse> {
+    return this.data<PlaybackResponse>("GET", `/api/v1/viz/playback`, query, undefined);
+  }
+
+  /** Simplified segment geometry for a zoom level */
+  visualizationGetSegmentPolylines(query: { zoom?: number; bbox?: string; startTime?: number; endTime?: number; mode?: string; format?: string; limit?: number } = {}): Promise<PolylineResponse> {
+    return this.data<PolylineResponse>("GET", `/api/v1/viz/polylines`, query, undefined);
+  }
+
+  /** Points to render at a level of detail */
+  visualizationGetRenderingMetadata(query: { minLat?: number; maxLat?: number; minLon?: number; maxLon?: number; lodLevel?: number; startTime?: number; endTime?: number; mode?: string; limit?: number } = {}): Promise<VisualizationGetRenderingMetadataResult> {
+    return this.data<VisualizationGetRenderingMetadataResult>("GET", `/api/v1/viz/rendering`, query, undefined);
+  }
+
+  /** Point counts by time slice */
+  visualizationGetTimeSliceData(query: { startTime?: number; endTime?: number; granularity?: "day" | "month" | "year" } = {}): Promise<Record<string, unknown> | null> {
+    return this.data<Record<string, unknown> | null>("GET", `/api/v1/viz/time-slices`, query, undefined);
+  }
+
+  /** Liveness check */
+  getHealth(): Promise<GetHealthResult> {
+    return this.json<GetHealthResult>("GET", `/health`, undefined, undefined);
+  }
+
+  /** Prometheus metrics */
+  getMetrics(): Promise<Response> {
+    return this.send("GET", `/metrics`, undefined, undefined);
+  }
+}
@@ -41,47 +41,56 @@ func (a *AltitudeStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing stats (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM altitude_stats_bucketed"); err != nil {
-			return fmt.Errorf("failed to clear altitude_stats_bucketed: %w", err)
-		}
-		log.Printf("[AltitudeStatsAnalyzer] Cleared existing altitude stats")
-	}
-
-	// Process different aggregation levels
-	totalRecords := 0
-
-	// 1. Global stats (ALL)
-	if err := a.processAltitudeStats(ctx, "ALL", ""); err != nil {
-		return fmt.Errorf("failed to process global stats: %w", err)
+	sources, err := a.LoadSources(ctx)
+	if err != nil {
+		return err
 	}
-	totalRecords++
 
-	// 2. Province-level stats
-	provinces, err := a.getDistinctValues(ctx, "province")
+	// Global stats are also kept per year and month, so periods can be compared
+	buckets, err := loadCalendarBuckets(ctx, a.DB, `
+		SELECT MIN(dataTime), MAX(dataTime) FROM "一生足迹" WHERE altitude IS NOT NULL AND altitude > 0
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to get provinces: %w", err)
+		return err
 	}
-	for _, province := range provinces {
-		if err := a.processAltitudeStats(ctx, "PROVINCE", province); err != nil {
-			log.Printf("[AltitudeAnalyzer] Warning: failed to process province %s: %v", province, err)
-			continue
+
+	// Process different aggregation levels, for all sources and each source
+	totalRecords := 0
+	var provinces, cities []string
+	for _, source := range sources {
+		// 1. Global stats (ALL), all time and per year and month
+		for _, bucket := range buckets {
+			if err := a.processAltitudeStats(ctx, source, bucket, "ALL", ""); err != nil {
+				return fmt.Errorf("failed to process global stats: %w", err)
+			}
+			totalRecords++
 		}
-		totalRecords++
-	}
 
-	// 3. City-level stats
-	cities, err := a.getDistinctValues(ctx, "city")
-	if err != nil {
-		return fmt.Errorf("failed to get cities: %w", err)
-	}
-	for _, city := range cities {
-		if err := a.processAltitudeStats(ctx, "CITY", city); err != nil {
-			log.Printf("[AltitudeAnalyzer] Warning: failed to process city %s: %v", city, err)
-			continue
+		// 2. Province-level stats
+		provinces, err = a.getDistinctValues(ctx, "province", source)
+		if err != nil {
+			return fmt.Errorf("failed to get provinces: %w", err)
+		}
+		for _, province := range provinces {
+			if err := a.processAltitudeStats(ctx, source, allTimeBucket, "PROVINCE", province); err != nil {
+				log.Printf("[AltitudeAnalyzer] Warning: failed to process province %s: %v", province, err)
+				continue
+			}
+			totalRecords++
+		}
+
+		// 3. City-level stats
+		cities, err = a.getDistinctValues(ctx, "city", source)
+		if err != nil {
+			return fmt.Errorf("failed to get cities: %w", err)
+		}
+		for _, city := range cities {
+			if err := a.processAltitudeStats(ctx, source, allTimeBucket, "CITY", city); err != nil {
+				log.Printf("[AltitudeAnalyzer] Warning: failed to process city %s: %v", city, err)
+				continue
+			}
+			totalRecords++
 		}
-		totalRecords++
 	}
 
 	// Mark task as completed
@@ -89,6 +98,7 @@ func (a *AltitudeStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		"total_records": totalRecords,
 		"provinces":     len(provinces),
 		"cities":        len(cities),
+		"sources":       sources,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -100,8 +110,8 @@ func (a *AltitudeStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 	return nil
 }
 
-// processAltitudeStats processes altitude statistics for a specific area
-func (a *AltitudeStatsAnalyzer) processAltitudeStats(ctx context.Context, areaType, areaKey string) error {
+// processAltitudeStats processes altitude statistics of a source for a specific area and time bucket
+func (a *AltitudeStatsAnalyzer) processAltitudeStats(ctx context.Context, source string, bucket timeBucket, areaType, areaKey string) error {
 	// Query track points with altitude data
 	query := `
 		SELECT
@@ -118,6 +128,12 @@ func (a *AltitudeStatsAnalyzer) processAltitudeStats(ctx context.Context, areaTy
 		query += " AND " + areaType + " = ?"
 		args = append(args, areaKey)
 	}
+	sourceCond, sourceArgs := analysis.SourceCondition("source", source)
+	query += sourceCond
+	args = append(args, sourceArgs...)
+	bucketCond, bucketArgs := bucket.condition("dataTime")
+	query += bucketCond
+	args = append(args, bucketArgs...)
 
 	query += " ORDER BY dataTime"
 
@@ -162,7 +178,7 @@ func (a *AltitudeStatsAnalyzer) processAltitudeStats(ctx context.Context, areaTy
 	}
 
 	if len(altitudes) == 0 {
-		log.Printf("[AltitudeStatsAnalyzer] No altitude data for %s/%s", areaType, areaKey)
+		log.Printf("[AltitudeStatsAnalyzer] No altitude data for %s/%s %s %s (source=%s)", areaType, areaKey, bucket.Type, bucket.Key, source)
 		return nil
 	}
 
@@ -170,7 +186,7 @@ func (a *AltitudeStatsAnalyzer) processAltitudeStats(ctx context.Context, areaTy
 	stats := calculateAltitudeStats(altitudes, totalAscent, totalDescent, totalDistance, pointCount)
 
 	// Insert into database
-	if err := a.insertAltitudeStats(ctx, "all", "", areaType, areaKey, stats); err != nil {
+	if err := a.insertAltitudeStats(ctx, bucket.Type, bucket.Key, source, areaType, areaKey, stats); err != nil {
 		return fmt.Errorf("failed to insert altitude stats: %w", err)
 	}
 
@@ -273,19 +289,19 @@ func percentile(sorted []float64, p float64) float64 {
 // insertAltitudeStats inserts altitude statistics into the database
 func (a *AltitudeStatsAnalyzer) insertAltitudeStats(
 	ctx context.Context,
-	bucketType, bucketKey, areaType, areaKey string,
+	bucketType, bucketKey, source, areaType, areaKey string,
 	stats AltitudeStats,
 ) error {
 	query := `
 		INSERT INTO altitude_stats_bucketed (
-			bucket_type, bucket_key, area_type, area_key,
+			bucket_type, bucket_key, source, area_type, area_key,
 			min_altitude, max_altitude, avg_altitude, altitude_span,
 			p25_altitude, p50_altitude, p75_altitude, p90_altitude,
 			total_ascent, total_descent, vertical_intensity,
 			point_count, segment_count, total_distance,
 			algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1')
-		ON CONFLICT(bucket_type, bucket_key, area_type, area_key) DO UPDATE SET
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("altitude_stats") + `')
+		ON CONFLICT(bucket_type, bucket_key, area_type, area_key, source) DO UPDATE SET
 			min_altitude = excluded.min_altitude,
 			max_altitude = excluded.max_altitude,
 			avg_altitude = excluded.avg_altitude,
@@ -303,8 +319,8 @@ func (a *AltitudeStatsAnalyzer) insertAltitudeStats(
 			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
 	`
 
-	_, err := a.DB.ExecContext(ctx, query,
-		bucketType, bucketKey, areaType, areaKey,
+	_, err := a.ExecWrite(ctx, query,
+		bucketType, bucketKey, source, areaType, areaKey,
 		stats.MinAltitude, stats.MaxAltitude, stats.AvgAltitude, stats.AltitudeSpan,
 		stats.P25Altitude, stats.P50Altitude, stats.P75Altitude, stats.P90Altitude,
 		stats.TotalAscent, stats.TotalDescent, stats.VerticalIntensity,
@@ -314,16 +330,17 @@ func (a *AltitudeStatsAnalyzer) insertAltitudeStats(
 	return err
 }
 
-// getDistinctValues gets distinct values for a column from track points
-func (a *AltitudeStatsAnalyzer) getDistinctValues(ctx context.Context, column string) ([]string, error) {
+// getDistinctValues gets distinct values for a column from track points of a source
+func (a *AltitudeStatsAnalyzer) getDistinctValues(ctx context.Context, column, source string) ([]string, error) {
+	sourceCond, args := analysis.SourceCondition("source", source)
 	query := fmt.Sprintf(`
 		SELECT DISTINCT %s
 		FROM "一生足迹"
-		WHERE %s IS NOT NULL AND %s != ''
+		WHERE %s IS NOT NULL AND %s != ''%s
 		ORDER BY %s
-	`, column, column, column, column)
+	`, column, column, column, sourceCond, column)
 
-	rows, err := a.DB.QueryContext(ctx, query)
+	rows, err := a.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -345,5 +362,7 @@ func (a *AltitudeStatsAnalyzer) getDistinctValues(ctx context.Context, column st
 func init() {
 	log.Println("[advanced] Registering altitude_stats analyzer")
 	analysis.RegisterAnalyzer("altitude_stats", NewAltitudeStatsAnalyzer)
+	analysis.RegisterDependencies("altitude_stats", "elevation_backfill")
+	analysis.RegisterOutputs("altitude_stats", analysis.Output{Table: "altitude_stats_bucketed"})
 	log.Println("[advanced] altitude_stats analyzer registered")
 }
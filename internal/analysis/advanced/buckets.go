@@ -0,0 +1,64 @@
+package advanced
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// timeBucket is a calendar period statistics are computed for
+// The all-time bucket has no bounds and an empty key.
+type timeBucket struct {
+	Type  string // all, year, month
+	Key   string // "", 2025, 2025-01
+	Start int64
+	End   int64 // Exclusive
+}
+
+// allTimeBucket covers the whole history
+var allTimeBucket = timeBucket{Type: "all"}
+
+// condition returns the SQL condition restricting a Unix time column to the bucket
+func (b timeBucket) condition(column string) (string, []interface{}) {
+	if b.Type == "all" {
+		return "", nil
+	}
+	return " AND " + column + " >= ? AND " + column + " < ?", []interface{}{b.Start, b.End}
+}
+
+// calendarBuckets returns the all-time bucket followed by the local years and
+// months from the one holding minTS to the one holding maxTS
+func calendarBuckets(minTS, maxTS int64) []timeBucket {
+	buckets := []timeBucket{allTimeBucket}
+	first, last := time.Unix(minTS, 0), time.Unix(maxTS, 0)
+
+	for y := first.Year(); y <= last.Year(); y++ {
+		start := time.Date(y, time.January, 1, 0, 0, 0, 0, time.Local)
+		buckets = append(buckets, timeBucket{
+			Type: "year", Key: start.Format("2006"),
+			Start: start.Unix(), End: start.AddDate(1, 0, 0).Unix(),
+		})
+	}
+	for m := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.Local); !m.After(last); m = m.AddDate(0, 1, 0) {
+		buckets = append(buckets, timeBucket{
+			Type: "month", Key: m.Format("2006-01"),
+			Start: m.Unix(), End: m.AddDate(0, 1, 0).Unix(),
+		})
+	}
+	return buckets
+}
+
+// loadCalendarBuckets returns the buckets spanning the timestamps selected by
+// query, which yields their minimum and maximum; only the all-time bucket when
+// there are none
+func loadCalendarBuckets(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]timeBucket, error) {
+	var minTS, maxTS sql.NullInt64
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&minTS, &maxTS); err != nil {
+		return nil, fmt.Errorf("failed to query time range: %w", err)
+	}
+	if !minTS.Valid || !maxTS.Valid {
+		return []timeBucket{allTimeBucket}, nil
+	}
+	return calendarBuckets(minTS.Int64, maxTS.Int64), nil
+}
@@ -7,13 +7,16 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
 )
 
 // MovementIntensityAnalyzer implements time-space compression analysis
 // Skill: 27_movement_intensity (Time-Space Compression)
-// Analyzes movement intensity and time-space efficiency patterns
+// Analyzes movement intensity and time-space efficiency patterns, everywhere
+// and per province and city (the area a segment starts in), each for all
+// time and per year and month
 type MovementIntensityAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -34,26 +37,44 @@ func (a *MovementIntensityAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing stats (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM time_space_compression_bucketed"); err != nil {
-			return fmt.Errorf("failed to clear time_space_compression_bucketed: %w", err)
-		}
-		log.Printf("[MovementIntensityAnalyzer] Cleared existing time-space compression stats")
+	sources, err := a.LoadSources(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Process global stats only (segments table doesn't have admin columns)
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultMovementIntensityThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// Each source's segments are read once and grouped by area and bucket
 	totalRecords := 0
+	areas := map[string]map[string]bool{"PROVINCE": {}, "CITY": {}}
+	for _, source := range sources {
+		segments, err := a.loadSegments(ctx, source)
+		if err != nil {
+			return err
+		}
 
-	// Global stats (ALL)
-	if err := a.processCompressionStats(ctx, "ALL", ""); err != nil {
-		return fmt.Errorf("failed to process global stats: %w", err)
+		groups := groupSegments(segments)
+		if err := a.insertCompressionStats(ctx, source, groups, thresholds); err != nil {
+			return fmt.Errorf("failed to insert compression stats: %w", err)
+		}
+		for key := range groups {
+			if areas[key.AreaType] != nil {
+				areas[key.AreaType][key.AreaKey] = true
+			}
+		}
+		totalRecords += len(groups)
 	}
-	totalRecords++
 
 	// Mark task as completed
 	summary := map[string]interface{}{
 		"total_records": totalRecords,
+		"provinces":     len(areas["PROVINCE"]),
+		"cities":        len(areas["CITY"]),
+		"sources":       sources,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -65,34 +86,32 @@ func (a *MovementIntensityAnalyzer) Analyze(ctx context.Context, taskID int64, m
 	return nil
 }
 
-// processCompressionStats processes time-space compression statistics for a specific area
-func (a *MovementIntensityAnalyzer) processCompressionStats(ctx context.Context, areaType, areaKey string) error {
-	// Query segments with movement data
+// loadSegments returns the moving segments of a source in time order
+func (a *MovementIntensityAnalyzer) loadSegments(ctx context.Context, source string) ([]SegmentData, error) {
+	// Segments built before their points were geocoded have no area until
+	// geocode_backfill runs; they fall back to the area of their start point
+	sourceCond, args := analysis.SourceCondition("s.source", source)
 	query := `
 		SELECT
-			start_time,
-			end_time,
-			duration_s,
-			distance_m,
-			avg_speed_kmh,
-			max_speed_kmh,
-			mode
-		FROM segments
-		WHERE duration_s > 0
-		  AND distance_m > 0
+			s.start_time,
+			s.end_time,
+			s.duration_s,
+			s.distance_m,
+			s.avg_speed_kmh,
+			s.max_speed_kmh,
+			s.mode,
+			COALESCE(CASE WHEN s.province IS NULL THEN sp.province ELSE s.province END, ''),
+			COALESCE(CASE WHEN s.province IS NULL THEN sp.city ELSE s.city END, '')
+		FROM segments s
+		LEFT JOIN "一生足迹" sp ON sp.id = s.start_point_id
+		WHERE s.duration_s > 0
+		  AND s.distance_m > 0` + sourceCond + `
+		ORDER BY s.start_time
 	`
-	args := []interface{}{}
-
-	if areaType != "ALL" {
-		query += " AND " + areaType + " = ?"
-		args = append(args, areaKey)
-	}
-
-	query += " ORDER BY start_time"
 
 	rows, err := a.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to query segments: %w", err)
+		return nil, fmt.Errorf("failed to query segments: %w", err)
 	}
 	defer rows.Close()
 
@@ -104,8 +123,9 @@ func (a *MovementIntensityAnalyzer) processCompressionStats(ctx context.Context,
 		if err := rows.Scan(
 			&seg.StartTime, &seg.EndTime, &seg.Duration,
 			&seg.Distance, &seg.AvgSpeed, &seg.MaxSpeed, &mode,
+			&seg.Province, &seg.City,
 		); err != nil {
-			return fmt.Errorf("failed to scan segment: %w", err)
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
 		}
 
 		if mode.Valid {
@@ -116,23 +136,44 @@ func (a *MovementIntensityAnalyzer) processCompressionStats(ctx context.Context,
 	}
 
 	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
+		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
+	return segments, nil
+}
 
-	if len(segments) == 0 {
-		log.Printf("[MovementIntensityAnalyzer] No segment data for %s/%s", areaType, areaKey)
-		return nil
-	}
+// compressionKey identifies a time-space compression stats row of a source
+type compressionKey struct {
+	BucketType string // all, year, month
+	BucketKey  string // "", 2025, 2025-01
+	AreaType   string // ALL, PROVINCE, CITY
+	AreaKey    string // "" for ALL
+}
 
-	// Calculate compression statistics
-	stats := calculateCompressionStats(segments)
+// groupSegments groups time-ordered segments by the area and time buckets
+// they count towards, keeping each group in time order
+// A segment counts towards everywhere (ALL) and, when geocoded, its province
+// and city, each for all time and its local year and month.
+func groupSegments(segments []SegmentData) map[compressionKey][]SegmentData {
+	groups := make(map[compressionKey][]SegmentData)
+	for _, seg := range segments {
+		start := time.Unix(seg.StartTime, 0)
+		buckets := [][2]string{{"all", ""}, {"year", start.Format("2006")}, {"month", start.Format("2006-01")}}
+		areas := [][2]string{{"ALL", ""}}
+		if seg.Province != "" {
+			areas = append(areas, [2]string{"PROVINCE", seg.Province})
+		}
+		if seg.City != "" {
+			areas = append(areas, [2]string{"CITY", seg.City})
+		}
 
-	// Insert into database
-	if err := a.insertCompressionStats(ctx, "all", "", areaType, areaKey, stats); err != nil {
-		return fmt.Errorf("failed to insert compression stats: %w", err)
+		for _, area := range areas {
+			for _, bucket := range buckets {
+				key := compressionKey{BucketType: bucket[0], BucketKey: bucket[1], AreaType: area[0], AreaKey: area[1]}
+				groups[key] = append(groups[key], seg)
+			}
+		}
 	}
-
-	return nil
+	return groups
 }
 
 // SegmentData holds segment information
@@ -144,44 +185,58 @@ type SegmentData struct {
 	AvgSpeed  float64
 	MaxSpeed  float64
 	Mode      string
+	Province  string // Area the segment starts in, "" when not geocoded
+	City      string
 }
 
 // CompressionStats holds time-space compression statistics
 type CompressionStats struct {
-	MovementIntensity       float64
-	BurstIntensity          float64
-	BurstCount              int
-	BurstDuration           int64
-	ActiveTime              int64
-	InactiveTime            int64
-	ActivityRatio           float64
-	EffectiveMovementRatio  float64
-	AvgSpeedKmh             float64
-	MaxSpeedKmh             float64
-	DistancePerDay          float64
-	TimeCompressionIndex    float64
-	TotalDistance           float64
-	TotalDuration           int64
-	TripCount               int
-	DistinctDays            int
+	MovementIntensity      float64
+	BurstIntensity         float64
+	BurstCount             int
+	BurstDuration          int64
+	ActiveTime             int64
+	InactiveTime           int64
+	ActivityRatio          float64
+	EffectiveMovementRatio float64
+	AvgSpeedKmh            float64
+	MaxSpeedKmh            float64
+	DistancePerDay         float64
+	TimeCompressionIndex   float64
+	TotalDistance          float64
+	TotalDuration          int64
+	TripCount              int
+	DistinctDays           int
 }
 
-// calculateCompressionStats calculates time-space compression statistics
-func calculateCompressionStats(segments []SegmentData) CompressionStats {
+// MovementIntensityThresholds defines configurable thresholds for movement intensity
+// Loaded from the "movement_intensity" section of the active threshold profile
+type MovementIntensityThresholds struct {
+	ActiveSpeedKmh float64 `json:"active_speed_kmh"` // Segments faster on average count as active time
+	BurstSpeedKmh  float64 `json:"burst_speed_kmh"`  // Segments faster on average are high-speed bursts
+}
+
+// DefaultMovementIntensityThresholds provides default movement intensity thresholds
+var DefaultMovementIntensityThresholds = MovementIntensityThresholds{
+	ActiveSpeedKmh: 5.0,
+	BurstSpeedKmh:  50.0,
+}
+
+// calculateCompressionStats calculates time-space compression statistics of
+// time-ordered segments
+// Consecutive burst segments count as one burst.
+func calculateCompressionStats(segments []SegmentData, thresholds MovementIntensityThresholds) CompressionStats {
 	if len(segments) == 0 {
 		return CompressionStats{}
 	}
 
-	// Speed threshold for "active" movement (km/h)
-	const activeSpeedThreshold = 5.0
-	const burstSpeedThreshold = 50.0 // High-speed burst threshold
-
 	var totalDistance float64
 	var totalDuration int64
 	var activeTime int64
 	var maxSpeed float64
 	var burstCount int
 	var burstDuration int64
+	var burstDistance float64
 	var inBurst bool
 
 	// Track distinct days
@@ -196,7 +251,7 @@ func calculateCompressionStats(segments []SegmentData) CompressionStats {
 		daySet[dayKey] = true
 
 		// Count active time (speed > threshold)
-		if seg.AvgSpeed > activeSpeedThreshold {
+		if seg.AvgSpeed > thresholds.ActiveSpeedKmh {
 			activeTime += seg.Duration
 		}
 
@@ -206,12 +261,13 @@ func calculateCompressionStats(segments []SegmentData) CompressionStats {
 		}
 
 		// Detect burst periods (high-speed movement)
-		if seg.AvgSpeed > burstSpeedThreshold {
+		if seg.AvgSpeed > thresholds.BurstSpeedKmh {
 			if !inBurst {
 				burstCount++
 				inBurst = true
 			}
 			burstDuration += seg.Duration
+			burstDistance += seg.Distance
 		} else {
 			inBurst = false
 		}
@@ -243,11 +299,10 @@ func calculateCompressionStats(segments []SegmentData) CompressionStats {
 		distancePerDay = (totalDistance / 1000.0) / float64(distinctDays)
 	}
 
-	// Calculate burst intensity (average speed during burst periods)
+	// Burst intensity: average speed during burst periods (km/h)
 	burstIntensity := 0.0
 	if burstDuration > 0 {
-		// Estimate distance during bursts (simplified)
-		burstIntensity = burstSpeedThreshold * 1.5 // Approximate
+		burstIntensity = (burstDistance / 1000.0) / (float64(burstDuration) / 3600.0)
 	}
 
 	// Time compression index: composite metric
@@ -274,82 +329,64 @@ func calculateCompressionStats(segments []SegmentData) CompressionStats {
 	}
 }
 
-// insertCompressionStats inserts compression statistics into the database
+// insertCompressionStats replaces the rows of a source with the statistics of
+// each group of its segments, in one transaction, so areas and buckets without
+// segments any more lose their rows
 func (a *MovementIntensityAnalyzer) insertCompressionStats(
 	ctx context.Context,
-	bucketType, bucketKey, areaType, areaKey string,
-	stats CompressionStats,
+	source string,
+	groups map[compressionKey][]SegmentData,
+	thresholds MovementIntensityThresholds,
 ) error {
 	query := `
 		INSERT INTO time_space_compression_bucketed (
-			bucket_type, bucket_key, area_type, area_key,
+			bucket_type, bucket_key, source, area_type, area_key,
 			movement_intensity, burst_intensity, burst_count, burst_duration_s,
 			active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio,
 			avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index,
 			total_distance_m, total_duration_s, trip_count, distinct_days,
 			algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1')
-		ON CONFLICT(bucket_type, bucket_key, area_type, area_key) DO UPDATE SET
-			movement_intensity = excluded.movement_intensity,
-			burst_intensity = excluded.burst_intensity,
-			burst_count = excluded.burst_count,
-			burst_duration_s = excluded.burst_duration_s,
-			active_time_s = excluded.active_time_s,
-			inactive_time_s = excluded.inactive_time_s,
-			activity_ratio = excluded.activity_ratio,
-			effective_movement_ratio = excluded.effective_movement_ratio,
-			avg_speed_kmh = excluded.avg_speed_kmh,
-			max_speed_kmh = excluded.max_speed_kmh,
-			distance_per_day = excluded.distance_per_day,
-			time_compression_index = excluded.time_compression_index,
-			total_distance_m = excluded.total_distance_m,
-			total_duration_s = excluded.total_duration_s,
-			trip_count = excluded.trip_count,
-			distinct_days = excluded.distinct_days,
-			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("movement_intensity") + `')
 	`
 
-	_, err := a.DB.ExecContext(ctx, query,
-		bucketType, bucketKey, areaType, areaKey,
-		stats.MovementIntensity, stats.BurstIntensity, stats.BurstCount, stats.BurstDuration,
-		stats.ActiveTime, stats.InactiveTime, stats.ActivityRatio, stats.EffectiveMovementRatio,
-		stats.AvgSpeedKmh, stats.MaxSpeedKmh, stats.DistancePerDay, stats.TimeCompressionIndex,
-		stats.TotalDistance, stats.TotalDuration, stats.TripCount, stats.DistinctDays,
-	)
-
-	return err
-}
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-// getDistinctValues gets distinct values for a column from segments
-func (a *MovementIntensityAnalyzer) getDistinctValues(ctx context.Context, column string) ([]string, error) {
-	query := fmt.Sprintf(`
-		SELECT DISTINCT %s
-		FROM segments
-		WHERE %s IS NOT NULL AND %s != ''
-		ORDER BY %s
-	`, column, column, column, column)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM time_space_compression_bucketed WHERE source = ?", source); err != nil {
+		return fmt.Errorf("failed to clear compression stats: %w", err)
+	}
 
-	rows, err := a.DB.QueryContext(ctx, query)
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
-	defer rows.Close()
-
-	var values []string
-	for rows.Next() {
-		var value string
-		if err := rows.Scan(&value); err != nil {
-			return nil, err
+	defer stmt.Close()
+
+	for key, segments := range groups {
+		stats := calculateCompressionStats(segments, thresholds)
+		if _, err := stmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, source, key.AreaType, key.AreaKey,
+			stats.MovementIntensity, stats.BurstIntensity, stats.BurstCount, stats.BurstDuration,
+			stats.ActiveTime, stats.InactiveTime, stats.ActivityRatio, stats.EffectiveMovementRatio,
+			stats.AvgSpeedKmh, stats.MaxSpeedKmh, stats.DistancePerDay, stats.TimeCompressionIndex,
+			stats.TotalDistance, stats.TotalDuration, stats.TripCount, stats.DistinctDays,
+		); err != nil {
+			return fmt.Errorf("failed to insert %s/%s %s %s: %w", key.AreaType, key.AreaKey, key.BucketType, key.BucketKey, err)
 		}
-		values = append(values, value)
 	}
 
-	return values, rows.Err()
+	return tx.Commit()
 }
 
 // Register the analyzer
 func init() {
 	log.Println("[advanced] Registering movement_intensity analyzer")
 	analysis.RegisterAnalyzer("movement_intensity", NewMovementIntensityAnalyzer)
+	analysis.RegisterVersion("movement_intensity", "v2")
+	analysis.RegisterDependencies("movement_intensity", "transport_mode")
+	analysis.RegisterOutputs("movement_intensity", analysis.Output{Table: "time_space_compression_bucketed"})
 	log.Println("[advanced] movement_intensity analyzer registered")
 }
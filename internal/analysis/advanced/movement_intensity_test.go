@@ -0,0 +1,124 @@
+package advanced
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateCompressionStats(t *testing.T) {
+	const day = 86400
+	fast := SegmentData{StartTime: 0, Duration: 3600, Distance: 60000, AvgSpeed: 60, MaxSpeed: 90}
+	slow := SegmentData{StartTime: 4000, Duration: 3600, Distance: 3000, AvgSpeed: 3, MaxSpeed: 6}
+	faster := SegmentData{StartTime: day, Duration: 1800, Distance: 50000, AvgSpeed: 100, MaxSpeed: 120}
+
+	tests := []struct {
+		name           string
+		segments       []SegmentData
+		thresholds     MovementIntensityThresholds
+		burstCount     int
+		burstIntensity float64
+		activeTime     int64
+		distinctDays   int
+	}{
+		{
+			name:       "no segments",
+			thresholds: DefaultMovementIntensityThresholds,
+		},
+		{
+			name:           "bursts separated by slow movement",
+			segments:       []SegmentData{fast, slow, faster},
+			thresholds:     DefaultMovementIntensityThresholds,
+			burstCount:     2,
+			burstIntensity: 110 / 1.5,
+			activeTime:     5400,
+			distinctDays:   2,
+		},
+		{
+			name:           "consecutive burst segments are one burst",
+			segments:       []SegmentData{fast, faster, slow},
+			thresholds:     DefaultMovementIntensityThresholds,
+			burstCount:     1,
+			burstIntensity: 110 / 1.5,
+			activeTime:     5400,
+			distinctDays:   2,
+		},
+		{
+			name:         "higher thresholds",
+			segments:     []SegmentData{fast, slow, faster},
+			thresholds:   MovementIntensityThresholds{ActiveSpeedKmh: 80, BurstSpeedKmh: 200},
+			activeTime:   1800,
+			distinctDays: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := calculateCompressionStats(tt.segments, tt.thresholds)
+			if stats.BurstCount != tt.burstCount || math.Abs(stats.BurstIntensity-tt.burstIntensity) > 1e-9 {
+				t.Errorf("bursts = %d at %.2f km/h, want %d at %.2f km/h", stats.BurstCount, stats.BurstIntensity, tt.burstCount, tt.burstIntensity)
+			}
+			if stats.ActiveTime != tt.activeTime || stats.InactiveTime != stats.TotalDuration-tt.activeTime {
+				t.Errorf("active/inactive time = %d/%d s, want %d active", stats.ActiveTime, stats.InactiveTime, tt.activeTime)
+			}
+			if stats.DistinctDays != tt.distinctDays || stats.TripCount != len(tt.segments) {
+				t.Errorf("days/trips = %d/%d, want %d/%d", stats.DistinctDays, stats.TripCount, tt.distinctDays, len(tt.segments))
+			}
+		})
+	}
+
+	t.Run("intensity and speeds", func(t *testing.T) {
+		stats := calculateCompressionStats([]SegmentData{fast, slow, faster}, DefaultMovementIntensityThresholds)
+		if math.Abs(stats.MovementIntensity-113/2.5) > 1e-9 {
+			t.Errorf("movement intensity = %.3f km/h, want %.3f", stats.MovementIntensity, 113/2.5)
+		}
+		if math.Abs(stats.AvgSpeedKmh-113/1.5) > 1e-9 || stats.MaxSpeedKmh != 120 {
+			t.Errorf("avg/max speed = %.3f/%.1f km/h, want %.3f/120", stats.AvgSpeedKmh, stats.MaxSpeedKmh, 113/1.5)
+		}
+		if math.Abs(stats.DistancePerDay-56.5) > 1e-9 {
+			t.Errorf("distance per day = %.3f km, want 56.5", stats.DistancePerDay)
+		}
+	})
+}
+
+func TestGroupSegments(t *testing.T) {
+	jan := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.Local).Unix()
+	dec := time.Date(2023, time.December, 31, 23, 0, 0, 0, time.Local).Unix()
+	segments := []SegmentData{
+		{StartTime: dec, Province: "广东省", City: "广州市"},
+		{StartTime: jan, Province: "广东省", City: "深圳市"},
+		{StartTime: jan + 3600}, // Not geocoded
+	}
+
+	groups := groupSegments(segments)
+	counts := map[compressionKey]int{
+		{BucketType: "all", AreaType: "ALL"}:                                              3,
+		{BucketType: "year", BucketKey: "2023", AreaType: "ALL"}:                          1,
+		{BucketType: "year", BucketKey: "2024", AreaType: "ALL"}:                          2,
+		{BucketType: "month", BucketKey: "2023-12", AreaType: "ALL"}:                      1,
+		{BucketType: "month", BucketKey: "2024-01", AreaType: "ALL"}:                      2,
+		{BucketType: "all", AreaType: "PROVINCE", AreaKey: "广东省"}:                         2,
+		{BucketType: "year", BucketKey: "2023", AreaType: "PROVINCE", AreaKey: "广东省"}:     1,
+		{BucketType: "year", BucketKey: "2024", AreaType: "PROVINCE", AreaKey: "广东省"}:     1,
+		{BucketType: "month", BucketKey: "2023-12", AreaType: "PROVINCE", AreaKey: "广东省"}: 1,
+		{BucketType: "month", BucketKey: "2024-01", AreaType: "PROVINCE", AreaKey: "广东省"}: 1,
+		{BucketType: "all", AreaType: "CITY", AreaKey: "广州市"}:                             1,
+		{BucketType: "year", BucketKey: "2023", AreaType: "CITY", AreaKey: "广州市"}:         1,
+		{BucketType: "month", BucketKey: "2023-12", AreaType: "CITY", AreaKey: "广州市"}:     1,
+		{BucketType: "all", AreaType: "CITY", AreaKey: "深圳市"}:                             1,
+		{BucketType: "year", BucketKey: "2024", AreaType: "CITY", AreaKey: "深圳市"}:         1,
+		{BucketType: "month", BucketKey: "2024-01", AreaType: "CITY", AreaKey: "深圳市"}:     1,
+	}
+
+	if len(groups) != len(counts) {
+		t.Errorf("got %d groups, want %d: %v", len(groups), len(counts), groups)
+	}
+	for key, want := range counts {
+		if got := len(groups[key]); got != want {
+			t.Errorf("%+v has %d segments, want %d", key, got, want)
+		}
+	}
+	if all := groups[compressionKey{BucketType: "all", AreaType: "ALL"}]; len(all) == 3 && all[0].StartTime != dec {
+		t.Errorf("groups are not kept in time order: %v", all)
+	}
+}
@@ -0,0 +1,419 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Anchor inference settings
+const (
+	anchorGeohashPrecision = 6    // ~1.2km x 0.6km cells
+	anchorRadiusM          = 300  // Radius written to place_anchors
+	anchorMinDays          = 4    // Minimum distinct days per month for a recurring cell
+	anchorMinNightHours    = 20.0 // Minimum night hours per month for a HOME candidate
+	anchorMinWorkHours     = 20.0 // Minimum weekday daytime hours per month for a WORK candidate
+	anchorNightStartHour   = 22   // Night window 22:00-06:00
+	anchorNightEndHour     = 6
+	anchorWorkStartHour    = 9 // Weekday daytime window 09:00-18:00
+	anchorWorkEndHour      = 18
+)
+
+// PlaceAnchorAnalyzer infers HOME and WORK anchors from stay segments
+// Skill: 锚点推断 (Place Anchor Inference)
+// Night-dominant recurring cells become HOME, weekday-daytime recurring cells become WORK.
+// Candidates are evaluated per month so that moving house or changing jobs starts a new anchor.
+type PlaceAnchorAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewPlaceAnchorAnalyzer creates a new place anchor analyzer
+func NewPlaceAnchorAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &PlaceAnchorAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "place_anchor", 1000),
+	}
+}
+
+// anchorStay holds the stay fields needed for anchor inference
+type anchorStay struct {
+	StartTS   int64
+	EndTS     int64
+	CenterLat float64
+	CenterLon float64
+	Cell      string
+}
+
+// anchorCellStats accumulates time spent in a cell during one month
+type anchorCellStats struct {
+	NightSeconds int64
+	WorkSeconds  int64
+	NightDays    map[string]bool
+	WorkDays     map[string]bool
+	WeightedLat  float64
+	WeightedLon  float64
+	TotalSeconds int64
+}
+
+// anchorCandidate is the winning cell of an anchor type in one month
+type anchorCandidate struct {
+	Month string
+	Cell  string
+	Hours float64
+	Days  int
+	Lat   float64
+	Lon   float64
+}
+
+// InferredAnchor holds an inferred anchor period
+type InferredAnchor struct {
+	Type       string
+	GridID     string
+	CenterLat  float64
+	CenterLon  float64
+	ActiveFrom int64
+	ActiveTo   *int64 // nil = still active
+	Confidence float64
+	Months     int
+}
+
+// Analyze infers place anchors from all stays
+// Anchors depend on the full stay history, so both modes recompute all inferred anchors
+func (a *PlaceAnchorAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[PlaceAnchorAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	stays, err := a.loadStays(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[PlaceAnchorAnalyzer] Processing %d stays", len(stays))
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(stays)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Aggregate stay time per month and cell
+	monthly := a.aggregateMonthly(stays)
+
+	// Pick the dominant HOME and WORK cell for each month
+	var homeCandidates, workCandidates []anchorCandidate
+	months := make([]string, 0, len(monthly))
+	for month := range monthly {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	for _, month := range months {
+		home := pickCandidate(month, monthly[month], func(s *anchorCellStats) (int64, int) {
+			return s.NightSeconds, len(s.NightDays)
+		}, anchorMinNightHours, "")
+		if home != nil {
+			homeCandidates = append(homeCandidates, *home)
+		}
+
+		homeCell := ""
+		if home != nil {
+			homeCell = home.Cell
+		}
+		work := pickCandidate(month, monthly[month], func(s *anchorCellStats) (int64, int) {
+			return s.WorkSeconds, len(s.WorkDays)
+		}, anchorMinWorkHours, homeCell)
+		if work != nil {
+			workCandidates = append(workCandidates, *work)
+		}
+	}
+
+	anchors := append(buildAnchorPeriods("HOME", homeCandidates), buildAnchorPeriods("WORK", workCandidates)...)
+
+	if err := a.replaceInferredAnchors(ctx, anchors); err != nil {
+		return fmt.Errorf("failed to write place anchors: %w", err)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(stays)), int64(len(stays)), 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Mark task as completed
+	homeCount, workCount := 0, 0
+	for _, anchor := range anchors {
+		if anchor.Type == "HOME" {
+			homeCount++
+		} else {
+			workCount++
+		}
+	}
+	summary := map[string]interface{}{
+		"total_stays":  len(stays),
+		"months":       len(months),
+		"home_anchors": homeCount,
+		"work_anchors": workCount,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[PlaceAnchorAnalyzer] Analysis completed: %d HOME, %d WORK anchors", homeCount, workCount)
+	return nil
+}
+
+// loadStays loads spatial stays with their grid cell
+func (a *PlaceAnchorAnalyzer) loadStays(ctx context.Context) ([]anchorStay, error) {
+	query := `
+		SELECT start_time, end_time, center_lat, center_lon, geohash6
+		FROM stay_segments
+		WHERE center_lat IS NOT NULL
+			AND center_lon IS NOT NULL
+			AND stay_type = 'SPATIAL'
+		ORDER BY start_time
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer rows.Close()
+
+	var stays []anchorStay
+	for rows.Next() {
+		var stay anchorStay
+		var geohash sql.NullString
+		if err := rows.Scan(&stay.StartTS, &stay.EndTS, &stay.CenterLat, &stay.CenterLon, &geohash); err != nil {
+			return nil, fmt.Errorf("failed to scan stay: %w", err)
+		}
+		if geohash.Valid && len(geohash.String) >= anchorGeohashPrecision {
+			stay.Cell = geohash.String[:anchorGeohashPrecision]
+		} else {
+			stay.Cell = spatial.EncodeGeohash(stay.CenterLat, stay.CenterLon, anchorGeohashPrecision)
+		}
+		stays = append(stays, stay)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stays: %w", err)
+	}
+
+	return stays, nil
+}
+
+// aggregateMonthly accumulates night and weekday-daytime seconds per month and cell
+// Stays are split hour by hour so long stays crossing windows or months are attributed correctly
+func (a *PlaceAnchorAnalyzer) aggregateMonthly(stays []anchorStay) map[string]map[string]*anchorCellStats {
+	monthly := make(map[string]map[string]*anchorCellStats)
+
+	for _, stay := range stays {
+		for ts := stay.StartTS; ts < stay.EndTS; {
+			t := time.Unix(ts, 0)
+			nextHour := t.Truncate(time.Hour).Add(time.Hour).Unix()
+			end := nextHour
+			if end > stay.EndTS {
+				end = stay.EndTS
+			}
+			seconds := end - ts
+
+			month := t.Format("2006-01")
+			cells, ok := monthly[month]
+			if !ok {
+				cells = make(map[string]*anchorCellStats)
+				monthly[month] = cells
+			}
+			stats, ok := cells[stay.Cell]
+			if !ok {
+				stats = &anchorCellStats{NightDays: map[string]bool{}, WorkDays: map[string]bool{}}
+				cells[stay.Cell] = stats
+			}
+
+			hour := t.Hour()
+			weekday := t.Weekday()
+			if hour >= anchorNightStartHour || hour < anchorNightEndHour {
+				stats.NightSeconds += seconds
+				// Attribute early-morning hours to the previous evening's night
+				nightDay := t
+				if hour < anchorNightEndHour {
+					nightDay = t.AddDate(0, 0, -1)
+				}
+				stats.NightDays[nightDay.Format("2006-01-02")] = true
+			} else if weekday != time.Saturday && weekday != time.Sunday && hour >= anchorWorkStartHour && hour < anchorWorkEndHour {
+				stats.WorkSeconds += seconds
+				stats.WorkDays[t.Format("2006-01-02")] = true
+			}
+
+			stats.WeightedLat += stay.CenterLat * float64(seconds)
+			stats.WeightedLon += stay.CenterLon * float64(seconds)
+			stats.TotalSeconds += seconds
+
+			ts = end
+		}
+	}
+
+	return monthly
+}
+
+// pickCandidate selects the cell with the most time in the window for a month
+// A cell qualifies only if it recurs on enough days and reaches the minimum hours
+func pickCandidate(month string, cells map[string]*anchorCellStats, metric func(*anchorCellStats) (int64, int), minHours float64, excludeCell string) *anchorCandidate {
+	var best *anchorCandidate
+	var bestSeconds int64
+
+	for cell, stats := range cells {
+		if cell == excludeCell {
+			continue
+		}
+		seconds, days := metric(stats)
+		if days < anchorMinDays || float64(seconds)/3600 < minHours {
+			continue
+		}
+		if best == nil || seconds > bestSeconds || (seconds == bestSeconds && cell < best.Cell) {
+			bestSeconds = seconds
+			best = &anchorCandidate{
+				Month: month,
+				Cell:  cell,
+				Hours: float64(seconds) / 3600,
+				Days:  days,
+				Lat:   stats.WeightedLat / float64(stats.TotalSeconds),
+				Lon:   stats.WeightedLon / float64(stats.TotalSeconds),
+			}
+		}
+	}
+
+	return best
+}
+
+// buildAnchorPeriods merges monthly candidates into anchor periods
+// A change of cell is only accepted once the new cell wins two candidate months in a row,
+// so a single month away (travel, holidays) does not end an anchor
+func buildAnchorPeriods(anchorType string, candidates []anchorCandidate) []InferredAnchor {
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	type period struct {
+		cell       string
+		startMonth string
+		months     []anchorCandidate
+	}
+
+	var periods []period
+	current := period{cell: candidates[0].Cell, startMonth: candidates[0].Month, months: []anchorCandidate{candidates[0]}}
+	var pending []anchorCandidate
+
+	for _, c := range candidates[1:] {
+		if c.Cell == current.cell {
+			current.months = append(current.months, c)
+			pending = nil
+			continue
+		}
+		if len(pending) > 0 && pending[0].Cell == c.Cell {
+			// Change confirmed: the new anchor starts at the first month it won
+			periods = append(periods, current)
+			current = period{cell: c.Cell, startMonth: pending[0].Month, months: append(pending, c)}
+			pending = nil
+			continue
+		}
+		pending = []anchorCandidate{c}
+	}
+	periods = append(periods, current)
+
+	anchors := make([]InferredAnchor, 0, len(periods))
+	for i, p := range periods {
+		var lat, lon, hours float64
+		for _, m := range p.months {
+			lat += m.Lat * m.Hours
+			lon += m.Lon * m.Hours
+			hours += m.Hours
+		}
+
+		anchor := InferredAnchor{
+			Type:       anchorType,
+			GridID:     p.cell,
+			CenterLat:  lat / hours,
+			CenterLon:  lon / hours,
+			ActiveFrom: monthStart(p.startMonth),
+			Months:     len(p.months),
+			// More supporting months means a more reliable anchor
+			Confidence: minFloat(0.6+0.05*float64(len(p.months)), 0.95),
+		}
+		if i < len(periods)-1 {
+			activeTo := monthStart(periods[i+1].startMonth)
+			anchor.ActiveTo = &activeTo
+		}
+		anchors = append(anchors, anchor)
+	}
+
+	return anchors
+}
+
+// replaceInferredAnchors replaces all inferred anchors, leaving manual anchors untouched
+func (a *PlaceAnchorAnalyzer) replaceInferredAnchors(ctx context.Context, anchors []InferredAnchor) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM place_anchors WHERE source = 'inferred'"); err != nil {
+		return fmt.Errorf("failed to clear inferred anchors: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO place_anchors (
+			type, grid_id, center_lat, center_lon, radius_m,
+			active_from_ts, active_to_ts, source, confidence, metadata
+		) VALUES (?, ?, ?, ?, ?, ?, ?, 'inferred', ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, anchor := range anchors {
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"algorithm":         "monthly_dominant_cell",
+			"supporting_months": anchor.Months,
+		})
+		if _, err := stmt.ExecContext(ctx,
+			anchor.Type, anchor.GridID, anchor.CenterLat, anchor.CenterLon, anchorRadiusM,
+			anchor.ActiveFrom, anchor.ActiveTo, anchor.Confidence, string(metadata),
+		); err != nil {
+			return fmt.Errorf("failed to insert anchor: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// monthStart returns the unix timestamp of the first second of a YYYY-MM month in local time
+func monthStart(month string) int64 {
+	t, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+// minFloat returns the smaller of two floats
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("place_anchor", NewPlaceAnchorAnalyzer)
+	analysis.RegisterOutputs("place_anchor", analysis.Output{Table: "place_anchors", Replaced: true})
+}
@@ -0,0 +1,396 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// placeGeohashPrecision sets the cells places grow from: ~150m x 150m, so
+// a place with its 8 neighbours spans about 450m
+const placeGeohashPrecision = 7
+
+// PlaceClusteringAnalyzer clusters stays across time into persistent places
+// Skill: 地点聚类 (Place Clustering)
+// Each place is seeded by a geohash7 cell and claims the neighbouring cells
+// with stays. Existing places seed first, named ones before the others, so a
+// place keeps its id and name as new stays arrive; the remaining cells seed
+// new places, the most visited first. Places without stays are dropped
+// unless they were named.
+type PlaceClusteringAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewPlaceClusteringAnalyzer creates a new place clustering analyzer
+func NewPlaceClusteringAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &PlaceClusteringAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "place_clustering", 1000),
+	}
+}
+
+// placeStay holds the stay fields needed for place clustering
+type placeStay struct {
+	ID        int64
+	StartTS   int64
+	EndTS     int64
+	DurationS int64
+	CenterLat float64
+	CenterLon float64
+	Province  string
+	City      string
+	County    string
+	Label     string // Confirmed annotation label
+	Cell      string
+}
+
+// existingPlace is a place already stored
+type existingPlace struct {
+	ID      int64
+	Geohash string
+	Named   bool
+}
+
+// Place is a cluster of stays
+type Place struct {
+	ID             int64 // 0 for a new place
+	Geohash        string
+	Named          bool
+	Label          string
+	CenterLat      float64
+	CenterLon      float64
+	RadiusM        float64
+	Province       string
+	City           string
+	County         string
+	VisitCount     int
+	TotalDurationS int64
+	FirstVisitTS   int64
+	LastVisitTS    int64
+	StayIDs        []int64
+}
+
+// Analyze clusters all stays into places
+// Places depend on the full stay history, so both modes re-cluster every stay.
+func (a *PlaceClusteringAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[PlaceClusteringAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	stays, err := a.loadStays(ctx)
+	if err != nil {
+		return err
+	}
+	existing, err := a.loadPlaces(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[PlaceClusteringAnalyzer] Clustering %d stays around %d existing places", len(stays), len(existing))
+	if err := a.UpdateTaskProgress(taskID, int64(len(stays)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	places := clusterPlaces(stays, existing)
+
+	if err := a.savePlaces(ctx, places, existing); err != nil {
+		return fmt.Errorf("failed to save places: %w", err)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(stays)), int64(len(stays)), 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	newPlaces := 0
+	for _, p := range places {
+		if p.ID == 0 {
+			newPlaces++
+		}
+	}
+	summary := map[string]interface{}{
+		"total_stays": len(stays),
+		"places":      len(places),
+		"new_places":  newPlaces,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[PlaceClusteringAnalyzer] Analysis completed: %d places, %d new", len(places), newPlaces)
+	return nil
+}
+
+// loadStays loads spatial stays with their confirmed label
+func (a *PlaceClusteringAnalyzer) loadStays(ctx context.Context) ([]placeStay, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT s.id, s.start_time, s.end_time, s.duration_s, s.center_lat, s.center_lon,
+			COALESCE(s.province, ''), COALESCE(s.city, ''), COALESCE(s.county, ''),
+			CASE WHEN a.confirmed = 1 THEN COALESCE(a.label, '') ELSE '' END
+		FROM stay_segments s
+		LEFT JOIN stay_annotations a ON a.stay_id = s.id
+		WHERE s.center_lat IS NOT NULL
+			AND s.center_lon IS NOT NULL
+			AND s.stay_type = 'SPATIAL'
+		ORDER BY s.start_time
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer rows.Close()
+
+	var stays []placeStay
+	for rows.Next() {
+		var stay placeStay
+		if err := rows.Scan(&stay.ID, &stay.StartTS, &stay.EndTS, &stay.DurationS, &stay.CenterLat, &stay.CenterLon,
+			&stay.Province, &stay.City, &stay.County, &stay.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan stay: %w", err)
+		}
+		stay.Cell = spatial.EncodeGeohash(stay.CenterLat, stay.CenterLon, placeGeohashPrecision)
+		stays = append(stays, stay)
+	}
+	return stays, rows.Err()
+}
+
+// loadPlaces loads the stored places
+func (a *PlaceClusteringAnalyzer) loadPlaces(ctx context.Context) ([]existingPlace, error) {
+	rows, err := a.DB.QueryContext(ctx, `SELECT id, geohash, name IS NOT NULL FROM places ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer rows.Close()
+
+	var places []existingPlace
+	for rows.Next() {
+		var p existingPlace
+		if err := rows.Scan(&p.ID, &p.Geohash, &p.Named); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		places = append(places, p)
+	}
+	return places, rows.Err()
+}
+
+// clusterPlaces groups stays into places seeded by geohash cells
+// Named places keep their seed even without stays; unnamed existing places
+// are kept only while their own cell has stays.
+func clusterPlaces(stays []placeStay, existing []existingPlace) []Place {
+	cellStays := make(map[string][]placeStay)
+	cellDuration := make(map[string]int64)
+	for _, stay := range stays {
+		cellStays[stay.Cell] = append(cellStays[stay.Cell], stay)
+		cellDuration[stay.Cell] += stay.DurationS
+	}
+
+	// Seeds in claiming order: named places, other existing places, then new cells by time spent
+	var seeds []Place
+	seeded := make(map[string]bool)
+	for _, named := range []bool{true, false} {
+		for _, p := range existing {
+			if p.Named != named || seeded[p.Geohash] || (!named && len(cellStays[p.Geohash]) == 0) {
+				continue
+			}
+			seeds = append(seeds, Place{ID: p.ID, Geohash: p.Geohash, Named: p.Named})
+			seeded[p.Geohash] = true
+		}
+	}
+	cells := make([]string, 0, len(cellStays))
+	for cell := range cellStays {
+		if !seeded[cell] {
+			cells = append(cells, cell)
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cellDuration[cells[i]] != cellDuration[cells[j]] {
+			return cellDuration[cells[i]] > cellDuration[cells[j]]
+		}
+		return cells[i] < cells[j]
+	})
+
+	claimed := make(map[string]bool)
+	var places []Place
+	claim := func(place Place) {
+		var members []placeStay
+		for _, cell := range append([]string{place.Geohash}, spatial.GeohashNeighbors(place.Geohash)...) {
+			if claimed[cell] {
+				continue
+			}
+			claimed[cell] = true
+			members = append(members, cellStays[cell]...)
+		}
+		if len(members) == 0 && !place.Named {
+			return
+		}
+		places = append(places, summarizePlace(place, members))
+	}
+	for _, seed := range seeds {
+		claim(seed)
+	}
+	for _, cell := range cells {
+		if !claimed[cell] {
+			claim(Place{Geohash: cell})
+		}
+	}
+	return places
+}
+
+// summarizePlace fills the center, extent and visit statistics of a place from its stays
+// A place without stays stays at the center of its seed cell.
+func summarizePlace(place Place, stays []placeStay) Place {
+	if len(stays) == 0 {
+		place.CenterLat, place.CenterLon = spatial.DecodeGeohash(place.Geohash)
+		return place
+	}
+	sort.Slice(stays, func(i, j int) bool { return stays[i].StartTS < stays[j].StartTS })
+
+	points := make([]spatial.Point, len(stays))
+	weights := make([]float64, len(stays))
+	labels := make(map[string]int)
+	var longest placeStay
+	for i, stay := range stays {
+		points[i] = spatial.Point{Lat: stay.CenterLat, Lon: stay.CenterLon}
+		weights[i] = math.Max(float64(stay.DurationS), 1)
+		place.StayIDs = append(place.StayIDs, stay.ID)
+		place.TotalDurationS += stay.DurationS
+		if stay.Label != "" {
+			labels[stay.Label]++
+		}
+		if stay.DurationS > longest.DurationS || longest.ID == 0 {
+			longest = stay
+		}
+	}
+	center := spatial.WeightedCentroid(points, weights)
+	place.CenterLat, place.CenterLon = center.Lat, center.Lon
+	for _, p := range points {
+		place.RadiusM = math.Max(place.RadiusM, spatial.HaversineDistance(center.Lat, center.Lon, p.Lat, p.Lon))
+	}
+	place.Province, place.City, place.County = longest.Province, longest.City, longest.County
+	place.VisitCount = len(stays)
+	place.FirstVisitTS = stays[0].StartTS
+	place.LastVisitTS = stays[len(stays)-1].EndTS
+	for label, n := range labels {
+		if n > labels[place.Label] || (n == labels[place.Label] && label < place.Label) {
+			place.Label = label
+		}
+	}
+	return place
+}
+
+// savePlaces writes the clustered places and points stays, their annotations
+// and the trip endpoints at them to their place
+// Existing places missing from places are deleted.
+func (a *PlaceClusteringAnalyzer) savePlaces(ctx context.Context, places []Place, existing []existingPlace) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	kept := make(map[int64]bool)
+	for _, p := range places {
+		kept[p.ID] = true
+	}
+	for _, p := range existing {
+		if kept[p.ID] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM places WHERE id = ?`, p.ID); err != nil {
+			return fmt.Errorf("failed to delete place %d: %w", p.ID, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE stay_segments SET place_id = NULL WHERE place_id IS NOT NULL`); err != nil {
+		return fmt.Errorf("failed to clear stay places: %w", err)
+	}
+
+	version := analysis.Version("place_clustering")
+	for _, p := range places {
+		args := []interface{}{
+			nullIfEmpty(p.Label), p.CenterLat, p.CenterLon, p.RadiusM,
+			nullIfEmpty(p.Province), nullIfEmpty(p.City), nullIfEmpty(p.County),
+			p.VisitCount, p.TotalDurationS, nullIfZero(p.FirstVisitTS), nullIfZero(p.LastVisitTS), version,
+		}
+		id := p.ID
+		if id == 0 {
+			result, err := tx.ExecContext(ctx, `
+				INSERT INTO places (
+					label, center_lat, center_lon, radius_m, province, city, county,
+					visit_count, total_duration_s, first_visit_ts, last_visit_ts, algo_version, geohash
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`, append(args, p.Geohash)...)
+			if err != nil {
+				return fmt.Errorf("failed to insert place %s: %w", p.Geohash, err)
+			}
+			if id, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get place id: %w", err)
+			}
+		} else if _, err := tx.ExecContext(ctx, `
+			UPDATE places SET
+				label = ?, center_lat = ?, center_lon = ?, radius_m = ?, province = ?, city = ?, county = ?,
+				visit_count = ?, total_duration_s = ?, first_visit_ts = ?, last_visit_ts = ?, algo_version = ?,
+				updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+			WHERE id = ?
+		`, append(args, id)...); err != nil {
+			return fmt.Errorf("failed to update place %d: %w", id, err)
+		}
+
+		for _, stayID := range p.StayIDs {
+			if _, err := tx.ExecContext(ctx, `UPDATE stay_segments SET place_id = ? WHERE id = ?`, id, stayID); err != nil {
+				return fmt.Errorf("failed to set place of stay %d: %w", stayID, err)
+			}
+		}
+	}
+
+	// Annotations and trips follow the place of their stay
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE stay_annotations SET place_id = (SELECT place_id FROM stay_segments WHERE id = stay_annotations.stay_id)
+	`); err != nil {
+		return fmt.Errorf("failed to set annotation places: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE trips SET
+			origin_place_id = (SELECT place_id FROM stay_segments WHERE id = trips.origin_stay_id),
+			dest_place_id = (SELECT place_id FROM stay_segments WHERE id = trips.dest_stay_id)
+	`); err != nil {
+		return fmt.Errorf("failed to set trip places: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// nullIfEmpty returns nil for an empty string, so it is stored as NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// nullIfZero returns nil for 0, so it is stored as NULL
+func nullIfZero(n int64) interface{} {
+	if n == 0 {
+		return nil
+	}
+	return n
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("place_clustering", NewPlaceClusteringAnalyzer)
+	analysis.RegisterVersion("place_clustering", "v1")
+	analysis.RegisterDependencies("place_clustering", "stay_detection")
+	// Places are matched to the stored ones by seed cell, so names survive a recompute
+	analysis.RegisterOutputs("place_clustering",
+		analysis.Output{Table: "places", Replaced: true},
+		analysis.Output{Table: "stay_segments", Set: "place_id = NULL", Replaced: true},
+	)
+}
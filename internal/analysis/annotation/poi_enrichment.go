@@ -0,0 +1,218 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/poi"
+)
+
+// poiSaveBatch is the number of places looked up between writes, so progress
+// survives a provider failure part way
+const poiSaveBatch = 50
+
+// POIEnrichmentAnalyzer looks up the named venues near places
+// Skill: 地点POI补全 (POI Enrichment)
+// Each place is looked up once with the configured POI provider, most visited
+// places first and at most MaxLookups per run to respect the provider's quota;
+// later runs continue with the places not looked up yet. The venues feed the
+// NEARBY_VENUE rule of stay label suggestions.
+type POIEnrichmentAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// POIEnrichmentThresholds defines configurable parameters for POI enrichment
+// Loaded from the "poi_enrichment" section of the active threshold profile
+type POIEnrichmentThresholds struct {
+	SearchRadiusM float64 `json:"search_radius_m"` // Smallest search radius around the place center
+	MaxRadiusM    float64 `json:"max_radius_m"`    // Largest search radius, for spread-out places
+	MaxPOIs       int     `json:"max_pois"`        // Venues kept per place
+	MinVisits     int     `json:"min_visits"`      // Places visited less often are not looked up
+	MaxLookups    int     `json:"max_lookups"`     // Provider calls per run
+}
+
+// DefaultPOIEnrichmentThresholds provides default POI enrichment parameters
+var DefaultPOIEnrichmentThresholds = POIEnrichmentThresholds{
+	SearchRadiusM: 150,
+	MaxRadiusM:    500,
+	MaxPOIs:       5,
+	MinVisits:     2,
+	MaxLookups:    500,
+}
+
+// NewPOIEnrichmentAnalyzer creates a new POI enrichment analyzer
+func NewPOIEnrichmentAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &POIEnrichmentAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "poi_enrichment", poiSaveBatch),
+	}
+}
+
+// poiPlace is a place to look venues up for
+type poiPlace struct {
+	ID        int64
+	CenterLat float64
+	CenterLon float64
+	RadiusM   float64
+}
+
+// Analyze looks up the venues of the places not looked up yet
+// A full recompute starts with no place looked up (see the outputs registered in init).
+func (a *POIEnrichmentAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[POIEnrichmentAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	provider := poi.DefaultProvider()
+	if provider == nil {
+		return poi.ErrNoProvider
+	}
+
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultPOIEnrichmentThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	places, err := a.loadPlaces(ctx, thresholds)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[POIEnrichmentAnalyzer] Looking up %d places with %s", len(places), provider.Name())
+	if err := a.UpdateTaskProgress(taskID, int64(len(places)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	found := make(map[int64][]poi.POI)
+	var batch []poiPlace
+	withVenues := 0
+	for i, place := range places {
+		radius := math.Min(math.Max(thresholds.SearchRadiusM, place.RadiusM), thresholds.MaxRadiusM)
+		venues, err := provider.Nearby(ctx, place.CenterLat, place.CenterLon, radius)
+		if err != nil {
+			// Keep the places looked up so far; the next run continues after them
+			if saveErr := a.savePOIs(ctx, batch, found, provider.Name()); saveErr != nil {
+				return fmt.Errorf("failed to save venues: %w", saveErr)
+			}
+			return fmt.Errorf("failed to look up venues of place %d: %w", place.ID, err)
+		}
+		if len(venues) > thresholds.MaxPOIs {
+			venues = venues[:thresholds.MaxPOIs]
+		}
+		if len(venues) > 0 {
+			withVenues++
+		}
+		found[place.ID] = venues
+		batch = append(batch, place)
+
+		if len(batch) == poiSaveBatch || i == len(places)-1 {
+			if err := a.savePOIs(ctx, batch, found, provider.Name()); err != nil {
+				return fmt.Errorf("failed to save venues: %w", err)
+			}
+			batch, found = nil, make(map[int64][]poi.POI)
+			if err := a.UpdateTaskProgress(taskID, int64(len(places)), int64(i+1), 0); err != nil {
+				return fmt.Errorf("failed to update task progress: %w", err)
+			}
+		}
+	}
+
+	summary := map[string]interface{}{
+		"mode":        mode,
+		"provider":    provider.Name(),
+		"places":      len(places),
+		"with_venues": withVenues,
+		"thresholds":  thresholds,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[POIEnrichmentAnalyzer] Analysis completed: %d places looked up, %d with venues", len(places), withVenues)
+	return nil
+}
+
+// loadPlaces loads the places not looked up yet, most visited first
+func (a *POIEnrichmentAnalyzer) loadPlaces(ctx context.Context, thresholds POIEnrichmentThresholds) ([]poiPlace, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, center_lat, center_lon, COALESCE(radius_m, 0)
+		FROM places
+		WHERE poi_checked_at IS NULL AND visit_count >= ?
+		ORDER BY visit_count DESC, id
+		LIMIT ?
+	`, thresholds.MinVisits, thresholds.MaxLookups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query places: %w", err)
+	}
+	defer rows.Close()
+
+	var places []poiPlace
+	for rows.Next() {
+		var p poiPlace
+		if err := rows.Scan(&p.ID, &p.CenterLat, &p.CenterLon, &p.RadiusM); err != nil {
+			return nil, fmt.Errorf("failed to scan place: %w", err)
+		}
+		places = append(places, p)
+	}
+	return places, rows.Err()
+}
+
+// savePOIs replaces the venues of the places and marks them looked up
+func (a *POIEnrichmentAnalyzer) savePOIs(ctx context.Context, places []poiPlace, venues map[int64][]poi.POI, provider string) error {
+	if len(places) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT INTO place_pois (place_id, rank, name, category, kind, lat, lon, distance_m, provider, algo_version)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("poi_enrichment")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close()
+
+	for _, place := range places {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM place_pois WHERE place_id = ?`, place.ID); err != nil {
+			return fmt.Errorf("failed to clear venues of place %d: %w", place.ID, err)
+		}
+		for i, v := range venues[place.ID] {
+			if _, err := insert.ExecContext(ctx, place.ID, i+1, v.Name, v.Category, nullIfEmpty(v.Kind),
+				v.Lat, v.Lon, v.DistanceM, provider); err != nil {
+				return fmt.Errorf("failed to insert venue of place %d: %w", place.ID, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE places SET poi_checked_at = CAST(strftime('%s', 'now') AS INTEGER) WHERE id = ?
+		`, place.ID); err != nil {
+			return fmt.Errorf("failed to mark place %d: %w", place.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("poi_enrichment", NewPOIEnrichmentAnalyzer)
+	analysis.RegisterVersion("poi_enrichment", "v1")
+	analysis.RegisterDependencies("poi_enrichment", "place_clustering")
+	analysis.RegisterOutputs("poi_enrichment",
+		analysis.Output{Table: "place_pois"},
+		analysis.Output{Table: "places", Where: "poi_checked_at IS NOT NULL", Set: "poi_checked_at = NULL"},
+	)
+}
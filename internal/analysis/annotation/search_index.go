@@ -0,0 +1,299 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// Search document kinds
+const (
+	SearchKindStay  = "stay"
+	SearchKindTrip  = "trip"
+	SearchKindPlace = "place"
+	SearchKindEvent = "event"
+)
+
+// SearchIndexAnalyzer builds the full-text search index
+// Skill: 全文检索索引 (Search Index)
+// Indexes stays with their labels, trips, visited admin areas, flights,
+// trains and anomalous days. Run it after the analyzers that fill those
+// tables; stay labels edited later show up on the next run.
+type SearchIndexAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewSearchIndexAnalyzer creates a new search index analyzer
+func NewSearchIndexAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &SearchIndexAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "search_index", 1000),
+	}
+}
+
+// searchDoc is one row of search_index
+type searchDoc struct {
+	Kind      string
+	Subtype   string
+	RefID     int64
+	Title     string
+	Body      string
+	StartTime int64
+}
+
+// searchSource loads the documents of one kind
+type searchSource struct {
+	Name  string
+	Query string
+	Build func(rows *sql.Rows) (searchDoc, error)
+}
+
+// searchSources lists what is indexed; every query yields one document per row
+var searchSources = []searchSource{
+	{
+		Name: "stays",
+		Query: `
+			SELECT s.id, s.stay_type, s.start_time, COALESCE(a.label, ''), COALESCE(a.sub_label, ''), COALESCE(a.note, ''),
+				COALESCE(s.province, ''), COALESCE(s.city, ''), COALESCE(s.county, ''), COALESCE(s.town, ''), COALESCE(s.village, '')
+			FROM stay_segments s
+			LEFT JOIN stay_annotations a ON a.stay_id = s.id`,
+		Build: func(rows *sql.Rows) (searchDoc, error) {
+			d := searchDoc{Kind: SearchKindStay}
+			var label, subLabel, note, province, city, county, town, village string
+			if err := rows.Scan(&d.RefID, &d.Subtype, &d.StartTime, &label, &subLabel, &note,
+				&province, &city, &county, &town, &village); err != nil {
+				return d, err
+			}
+			place := firstNonEmpty(village, town, county, city, province)
+			if label != "" {
+				d.Subtype = label
+				d.Title = joinNonEmpty(" · ", label, place)
+			} else {
+				d.Title = joinNonEmpty(" ", "Stay in", firstNonEmpty(place, "unknown place"))
+			}
+			d.Body = joinNonEmpty(" ", subLabel, note, province, city, county, town, village)
+			return d, nil
+		},
+	},
+	{
+		Name: "trips",
+		Query: `
+			SELECT id, start_time, COALESCE(primary_mode, ''), COALESCE(purpose_ml, ''),
+				COALESCE(origin_province, ''), COALESCE(origin_city, ''), COALESCE(origin_county, ''),
+				COALESCE(dest_province, ''), COALESCE(dest_city, ''), COALESCE(dest_county, '')
+			FROM trips`,
+		Build: func(rows *sql.Rows) (searchDoc, error) {
+			d := searchDoc{Kind: SearchKindTrip}
+			var purpose, oProvince, oCity, oCounty, dProvince, dCity, dCounty string
+			if err := rows.Scan(&d.RefID, &d.StartTime, &d.Subtype, &purpose,
+				&oProvince, &oCity, &oCounty, &dProvince, &dCity, &dCounty); err != nil {
+				return d, err
+			}
+			origin := firstNonEmpty(oCounty, oCity, oProvince, "?")
+			dest := firstNonEmpty(dCounty, dCity, dProvince, "?")
+			d.Title = origin + " → " + dest
+			d.Body = joinNonEmpty(" ", d.Subtype, purpose, oProvince, oCity, oCounty, dProvince, dCity, dCounty)
+			return d, nil
+		},
+	},
+	{
+		Name: "places",
+		Query: `
+			SELECT id, stat_type, stat_key, COALESCE(first_visit, 0)
+			FROM footprint_statistics
+			WHERE time_range = 'all' AND stat_type IN ('PROVINCE', 'CITY', 'COUNTY', 'TOWN')`,
+		Build: func(rows *sql.Rows) (searchDoc, error) {
+			d := searchDoc{Kind: SearchKindPlace}
+			if err := rows.Scan(&d.RefID, &d.Subtype, &d.Title, &d.StartTime); err != nil {
+				return d, err
+			}
+			d.Body = strings.ToLower(d.Subtype)
+			return d, nil
+		},
+	},
+	{
+		Name: "journeys",
+		Query: `
+			SELECT id, journey_type, departure_time, COALESCE(number, ''), COALESCE(carrier, ''),
+				COALESCE(origin_name, ''), COALESCE(origin_code, ''), COALESCE(origin_city, ''),
+				COALESCE(dest_name, ''), COALESCE(dest_code, ''), COALESCE(dest_city, ''), COALESCE(notes, '')
+			FROM journeys`,
+		Build: func(rows *sql.Rows) (searchDoc, error) {
+			d := searchDoc{Kind: SearchKindEvent}
+			var number, carrier, oName, oCode, oCity, dName, dCode, dCity, notes string
+			if err := rows.Scan(&d.RefID, &d.Subtype, &d.StartTime, &number, &carrier,
+				&oName, &oCode, &oCity, &dName, &dCode, &dCity, &notes); err != nil {
+				return d, err
+			}
+			d.Title = joinNonEmpty(" ", number, firstNonEmpty(oName, oCity, oCode, "?")+" → "+firstNonEmpty(dName, dCity, dCode, "?"))
+			d.Body = joinNonEmpty(" ", strings.ToLower(d.Subtype), carrier, oName, oCode, oCity, dName, dCode, dCity, notes)
+			return d, nil
+		},
+	},
+	{
+		Name: "anomalous days",
+		Query: `
+			SELECT id, date, category, COALESCE(reasons, '[]')
+			FROM anomalous_days`,
+		Build: func(rows *sql.Rows) (searchDoc, error) {
+			d := searchDoc{Kind: SearchKindEvent, Subtype: "ANOMALOUS_DAY"}
+			var date, category, reasonsJSON string
+			if err := rows.Scan(&d.RefID, &date, &category, &reasonsJSON); err != nil {
+				return d, err
+			}
+			t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+			if err != nil {
+				return d, fmt.Errorf("invalid anomalous day %q: %w", date, err)
+			}
+			var reasons []string
+			if err := json.Unmarshal([]byte(reasonsJSON), &reasons); err != nil {
+				return d, fmt.Errorf("invalid reasons of %s: %w", date, err)
+			}
+			d.StartTime = t.Unix()
+			d.Title = "Unusual day: " + strings.ToLower(strings.ReplaceAll(category, "_", " "))
+			d.Body = joinNonEmpty(" ", append([]string{category}, reasons...)...)
+			return d, nil
+		},
+	},
+}
+
+// Analyze rebuilds the search index
+func (a *SearchIndexAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[SearchIndexAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	var docs []searchDoc
+	counts := make(map[string]int)
+	for i, src := range searchSources {
+		before := len(docs)
+		var err error
+		docs, err = a.loadDocs(ctx, src, docs)
+		if err != nil {
+			return err
+		}
+		counts[src.Name] = len(docs) - before
+
+		if err := a.UpdateTaskProgress(taskID, int64(len(searchSources)), int64(i+1), 0); err != nil {
+			log.Printf("[SearchIndexAnalyzer] Warning: failed to update progress: %v", err)
+		}
+	}
+
+	// Labels and names change in place, so the index is rebuilt whatever the mode
+	if err := a.replaceIndex(ctx, docs); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"documents": len(docs),
+		"sources":   counts,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[SearchIndexAnalyzer] Analysis completed: %d documents", len(docs))
+	return nil
+}
+
+// loadDocs appends the documents of one source to docs
+func (a *SearchIndexAnalyzer) loadDocs(ctx context.Context, src searchSource, docs []searchDoc) ([]searchDoc, error) {
+	rows, err := a.DB.QueryContext(ctx, src.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", src.Name, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d, err := src.Build(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", src.Name, err)
+		}
+		docs = append(docs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", src.Name, err)
+	}
+	return docs, nil
+}
+
+// replaceIndex replaces all documents of the search index in one transaction
+func (a *SearchIndexAnalyzer) replaceIndex(ctx context.Context, docs []searchDoc) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM search_index"); err != nil {
+		return fmt.Errorf("failed to clear search index: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO search_index (kind, subtype, ref_id, title, body, date, start_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, d := range docs {
+		var date interface{}
+		if d.StartTime > 0 {
+			date = time.Unix(d.StartTime, 0).Format("2006-01-02")
+		}
+		if _, err := stmt.ExecContext(ctx, d.Kind, d.Subtype, d.RefID, d.Title, d.Body, date, d.StartTime); err != nil {
+			return fmt.Errorf("failed to insert %s %d: %w", d.Kind, d.RefID, err)
+		}
+	}
+
+	// Merge the index segments written above
+	if _, err := tx.ExecContext(ctx, "INSERT INTO search_index(search_index) VALUES ('optimize')"); err != nil {
+		return fmt.Errorf("failed to optimize search index: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// joinNonEmpty joins the non-empty values with sep
+func joinNonEmpty(sep string, values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("search_index", NewSearchIndexAnalyzer)
+	analysis.RegisterDependencies("search_index", "trip_construction", "anomalous_days", "footprint_statistics", "stay_annotation")
+	analysis.RegisterOutputs("search_index", analysis.Output{Table: "search_index", Replaced: true})
+}
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/poi"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // StayAnnotationAnalyzer implements stay annotation and label suggestion
@@ -34,20 +38,20 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing context cache (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM stay_context_cache"); err != nil {
-			return fmt.Errorf("failed to clear context cache: %w", err)
-		}
-		log.Printf("[StayAnnotationAnalyzer] Cleared existing context cache")
+	// Learn rule weights from annotation feedback before the cached suggestions are replaced
+	ruleWeights, err := a.refreshSuggestionFeedback(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to refresh suggestion feedback: %w", err)
 	}
 
+	log.Printf("[StayAnnotationAnalyzer] Loaded feedback weights for %d rules", len(ruleWeights))
+
 	// Get all stay segments
 	staysQuery := `
 		SELECT
 			id,
-			start_ts,
-			end_ts,
+			start_time,
+			end_time,
 			duration_s,
 			center_lat,
 			center_lon,
@@ -55,9 +59,9 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 			city,
 			county,
 			town,
-			grid_id
+			geohash6,
+			place_id
 		FROM stay_segments
-		WHERE outlier_flag = 0
 		ORDER BY id
 	`
 
@@ -71,7 +75,7 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		var stay StayInfo
 		if err := rows.Scan(&stay.ID, &stay.StartTS, &stay.EndTS, &stay.DurationS,
 			&stay.CenterLat, &stay.CenterLon, &stay.Province, &stay.City,
-			&stay.County, &stay.Town, &stay.GridID); err != nil {
+			&stay.County, &stay.Town, &stay.GridID, &stay.PlaceID); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to scan stay: %w", err)
 		}
@@ -94,6 +98,12 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 
 	log.Printf("[StayAnnotationAnalyzer] Loaded %d place anchors", len(anchors))
 
+	// Load the venues near places (POI enrichment)
+	venues, err := a.loadPlaceVenues(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load place venues: %w", err)
+	}
+
 	// Process each stay
 	processed := 0
 	batchSize := 100
@@ -107,13 +117,14 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		arrivalContext, departureContext := a.extractMovementContext(ctx, stay)
 
 		// Extract location features
-		locationFeatures := a.extractLocationFeatures(stay)
+		stayVenues := venues[stay.PlaceID.Int64]
+		locationFeatures := a.extractLocationFeatures(stay, stayVenues)
 
 		// Query historical annotations for this location
 		historicalLabel := a.queryHistoricalLabel(ctx, stay)
 
 		// Generate label suggestions using rule engine
-		suggestions := a.generateLabelSuggestions(stay, timeFeatures, arrivalContext, departureContext, locationFeatures, historicalLabel, anchors)
+		suggestions := a.generateLabelSuggestions(stay, timeFeatures, arrivalContext, departureContext, locationFeatures, historicalLabel, anchors, stayVenues, ruleWeights)
 
 		// Create context card
 		contextCard := ContextCard{
@@ -160,6 +171,7 @@ func (a *StayAnnotationAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 	summary := map[string]interface{}{
 		"total_stays":     len(stays),
 		"processed_stays": processed,
+		"rule_weights":    ruleWeights,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -184,6 +196,7 @@ type StayInfo struct {
 	County    sql.NullString
 	Town      sql.NullString
 	GridID    sql.NullString
+	PlaceID   sql.NullInt64
 }
 
 // TimeFeatures holds time-related features
@@ -198,11 +211,13 @@ type TimeFeatures struct {
 
 // LocationFeatures holds location-related features
 type LocationFeatures struct {
-	Province string
-	City     string
-	County   string
-	Town     string
-	GridID   string
+	Province      string
+	City          string
+	County        string
+	Town          string
+	GridID        string
+	Venue         string // Nearest venue of the place
+	VenueCategory string
 }
 
 // MovementContext holds arrival/departure context
@@ -223,16 +238,101 @@ type ContextCard struct {
 }
 
 // LabelSuggestion holds a label suggestion with confidence
+// Rule identifies the rule that produced the suggestion, used to attribute feedback
 type LabelSuggestion struct {
 	Label      string
 	Confidence float64
 	Reasons    []string
+	Rule       string
+	Venue      string `json:",omitempty"` // Venue behind a NEARBY_VENUE suggestion
+}
+
+// PlaceVenue is a venue near the place of a stay, nearest first
+type PlaceVenue struct {
+	Name      string
+	Category  string
+	DistanceM float64
+}
+
+// Suggestion rule IDs
+const (
+	RuleKnownAnchor     = "KNOWN_ANCHOR"
+	RuleHistoricalLabel = "HISTORICAL_LABEL"
+	RuleHomeOvernight   = "HOME_OVERNIGHT"
+	RuleWorkDaytime     = "WORK_WEEKDAY_DAYTIME"
+	RuleEatMealHours    = "EAT_MEAL_HOURS"
+	RuleSleepNight      = "SLEEP_NIGHT"
+	RuleTransit         = "TRANSIT_BETWEEN_MOVEMENTS"
+	RuleNearbyVenue     = "NEARBY_VENUE"
+)
+
+// maxVenueDistanceM is how far from the place center a venue may be to suggest a label
+const maxVenueDistanceM = 100.0
+
+// venueLabels maps POI categories to the stay label their venues suggest
+var venueLabels = map[string]string{
+	poi.CategoryFood:      "EAT",
+	poi.CategoryShopping:  "SHOPPING",
+	poi.CategoryLeisure:   "LEISURE",
+	poi.CategoryLodging:   "SLEEP",
+	poi.CategoryTransport: "TRANSIT",
+	poi.CategoryOffice:    "WORK",
+}
+
+const (
+	feedbackPriorStrength = 10.0 // Pseudo-observations backing each rule's base confidence
+	minSuggestionConf     = 0.05
+	maxSuggestionConf     = 0.99
+)
+
+// ruleBaseConfidence holds the static confidence of each rule before feedback
+var ruleBaseConfidence = map[string]float64{
+	RuleKnownAnchor:     0.95,
+	RuleHistoricalLabel: 0.85,
+	RuleHomeOvernight:   0.8,
+	RuleWorkDaytime:     0.7,
+	RuleEatMealHours:    0.6,
+	RuleSleepNight:      0.65,
+	RuleTransit:         0.5,
+	RuleNearbyVenue:     0.6,
 }
 
-// PlaceAnchor holds a known place anchor
+// feedbackWeight computes the confidence multiplier of a rule from its feedback counts
+// The base confidence acts as a prior worth feedbackPriorStrength observations
+func feedbackWeight(base float64, confirmed, rejected int64) float64 {
+	if base <= 0 {
+		return 1.0
+	}
+	posterior := (base*feedbackPriorStrength + float64(confirmed)) / (feedbackPriorStrength + float64(confirmed+rejected))
+	return posterior / base
+}
+
+// PlaceAnchor holds a known place anchor (manual or inferred by PlaceAnchorAnalyzer)
 type PlaceAnchor struct {
-	Type   string
-	GridID string
+	Type       string
+	GridID     string
+	CenterLat  sql.NullFloat64
+	CenterLon  sql.NullFloat64
+	RadiusM    float64
+	ActiveFrom sql.NullInt64
+	ActiveTo   sql.NullInt64
+}
+
+// matches checks if the anchor was active at the stay start and covers the stay location
+func (p PlaceAnchor) matches(stay StayInfo) bool {
+	if p.ActiveFrom.Valid && stay.StartTS < p.ActiveFrom.Int64 {
+		return false
+	}
+	if p.ActiveTo.Valid && stay.StartTS >= p.ActiveTo.Int64 {
+		return false
+	}
+	if stay.GridID.Valid && p.GridID == stay.GridID.String {
+		return true
+	}
+	if p.CenterLat.Valid && p.CenterLon.Valid {
+		return spatial.HaversineDistance(p.CenterLat.Float64, p.CenterLon.Float64, stay.CenterLat, stay.CenterLon) <= p.RadiusM
+	}
+	return false
 }
 
 // StayContext holds stay context cache entry
@@ -265,8 +365,11 @@ func (a *StayAnnotationAnalyzer) extractTimeFeatures(stay StayInfo) TimeFeatures
 }
 
 // extractLocationFeatures extracts location-related features
-func (a *StayAnnotationAnalyzer) extractLocationFeatures(stay StayInfo) LocationFeatures {
+func (a *StayAnnotationAnalyzer) extractLocationFeatures(stay StayInfo, venues []PlaceVenue) LocationFeatures {
 	features := LocationFeatures{}
+	if len(venues) > 0 {
+		features.Venue, features.VenueCategory = venues[0].Name, venues[0].Category
+	}
 
 	if stay.Province.Valid {
 		features.Province = stay.Province.String
@@ -366,15 +469,16 @@ func (a *StayAnnotationAnalyzer) queryHistoricalLabel(ctx context.Context, stay
 	return label
 }
 
-// loadPlaceAnchors loads known place anchors
+// loadPlaceAnchors loads known place anchors, including past ones
+// Manual anchors are ordered first so they take precedence over inferred ones
 func (a *StayAnnotationAnalyzer) loadPlaceAnchors(ctx context.Context) ([]PlaceAnchor, error) {
 	query := `
-		SELECT type, grid_id
+		SELECT type, grid_id, center_lat, center_lon, COALESCE(radius_m, 500), active_from_ts, active_to_ts
 		FROM place_anchors
-		WHERE active_to_ts IS NULL OR active_to_ts > ?
+		ORDER BY CASE WHEN source = 'inferred' THEN 1 ELSE 0 END, id
 	`
 
-	rows, err := a.DB.QueryContext(ctx, query, time.Now().Unix())
+	rows, err := a.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query place anchors: %w", err)
 	}
@@ -383,7 +487,8 @@ func (a *StayAnnotationAnalyzer) loadPlaceAnchors(ctx context.Context) ([]PlaceA
 	var anchors []PlaceAnchor
 	for rows.Next() {
 		var anchor PlaceAnchor
-		if err := rows.Scan(&anchor.Type, &anchor.GridID); err != nil {
+		if err := rows.Scan(&anchor.Type, &anchor.GridID, &anchor.CenterLat, &anchor.CenterLon,
+			&anchor.RadiusM, &anchor.ActiveFrom, &anchor.ActiveTo); err != nil {
 			return nil, fmt.Errorf("failed to scan anchor: %w", err)
 		}
 		anchors = append(anchors, anchor)
@@ -392,7 +497,32 @@ func (a *StayAnnotationAnalyzer) loadPlaceAnchors(ctx context.Context) ([]PlaceA
 	return anchors, nil
 }
 
+// loadPlaceVenues loads the venues near each place, nearest first
+func (a *StayAnnotationAnalyzer) loadPlaceVenues(ctx context.Context) (map[int64][]PlaceVenue, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT place_id, name, category, distance_m
+		FROM place_pois
+		ORDER BY place_id, rank
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query place venues: %w", err)
+	}
+	defer rows.Close()
+
+	venues := make(map[int64][]PlaceVenue)
+	for rows.Next() {
+		var placeID int64
+		var venue PlaceVenue
+		if err := rows.Scan(&placeID, &venue.Name, &venue.Category, &venue.DistanceM); err != nil {
+			return nil, fmt.Errorf("failed to scan place venue: %w", err)
+		}
+		venues[placeID] = append(venues[placeID], venue)
+	}
+	return venues, rows.Err()
+}
+
 // generateLabelSuggestions generates label suggestions using rule engine
+// Confidences are scaled by the per-rule feedback weights and sorted in descending order
 func (a *StayAnnotationAnalyzer) generateLabelSuggestions(
 	stay StayInfo,
 	timeFeatures TimeFeatures,
@@ -401,110 +531,203 @@ func (a *StayAnnotationAnalyzer) generateLabelSuggestions(
 	locationFeatures LocationFeatures,
 	historicalLabel string,
 	anchors []PlaceAnchor,
+	venues []PlaceVenue,
+	ruleWeights map[string]float64,
 ) []LabelSuggestion {
 	var suggestions []LabelSuggestion
 
+	suggest := func(rule, label string, reasons ...string) {
+		confidence := ruleBaseConfidence[rule]
+		if weight, ok := ruleWeights[rule]; ok {
+			confidence *= weight
+		}
+		if confidence < minSuggestionConf {
+			confidence = minSuggestionConf
+		} else if confidence > maxSuggestionConf {
+			confidence = maxSuggestionConf
+		}
+		suggestions = append(suggestions, LabelSuggestion{
+			Label:      label,
+			Confidence: confidence,
+			Reasons:    reasons,
+			Rule:       rule,
+		})
+	}
+
 	// Check if this location matches a known anchor
-	if stay.GridID.Valid {
-		for _, anchor := range anchors {
-			if anchor.GridID == stay.GridID.String {
-				suggestions = append(suggestions, LabelSuggestion{
-					Label:      anchor.Type,
-					Confidence: 0.95,
-					Reasons:    []string{"KNOWN_ANCHOR"},
-				})
-				return suggestions
-			}
+	for _, anchor := range anchors {
+		if anchor.matches(stay) {
+			suggest(RuleKnownAnchor, anchor.Type, "KNOWN_ANCHOR")
+			return suggestions
 		}
 	}
 
 	// Use historical label if available
 	if historicalLabel != "" {
-		suggestions = append(suggestions, LabelSuggestion{
-			Label:      historicalLabel,
-			Confidence: 0.85,
-			Reasons:    []string{"HISTORICAL_LABEL"},
-		})
+		suggest(RuleHistoricalLabel, historicalLabel, "HISTORICAL_LABEL")
 	}
 
 	// Rule-based suggestions
 
 	// HOME: overnight stay, night hours, long duration
 	if timeFeatures.IsOvernight && timeFeatures.IsNight && timeFeatures.DurationHours >= 6 {
-		suggestions = append(suggestions, LabelSuggestion{
-			Label:      "HOME",
-			Confidence: 0.8,
-			Reasons:    []string{"OVERNIGHT", "NIGHT_HOURS", "LONG_DURATION"},
-		})
+		suggest(RuleHomeOvernight, "HOME", "OVERNIGHT", "NIGHT_HOURS", "LONG_DURATION")
 	}
 
 	// WORK: weekday daytime, long duration
 	if !timeFeatures.IsWeekend && timeFeatures.HourOfDay >= 8 && timeFeatures.HourOfDay <= 18 && timeFeatures.DurationHours >= 4 {
-		suggestions = append(suggestions, LabelSuggestion{
-			Label:      "WORK",
-			Confidence: 0.7,
-			Reasons:    []string{"WEEKDAY", "DAYTIME", "LONG_DURATION"},
-		})
+		suggest(RuleWorkDaytime, "WORK", "WEEKDAY", "DAYTIME", "LONG_DURATION")
 	}
 
 	// EAT: meal hours, short duration
 	if (timeFeatures.HourOfDay >= 11 && timeFeatures.HourOfDay <= 14) || (timeFeatures.HourOfDay >= 17 && timeFeatures.HourOfDay <= 20) {
 		if timeFeatures.DurationHours >= 0.5 && timeFeatures.DurationHours <= 2 {
-			suggestions = append(suggestions, LabelSuggestion{
-				Label:      "EAT",
-				Confidence: 0.6,
-				Reasons:    []string{"MEAL_HOURS", "SHORT_DURATION"},
-			})
+			suggest(RuleEatMealHours, "EAT", "MEAL_HOURS", "SHORT_DURATION")
 		}
 	}
 
 	// SLEEP: night hours, medium duration
 	if timeFeatures.IsNight && timeFeatures.DurationHours >= 4 && timeFeatures.DurationHours <= 10 {
-		suggestions = append(suggestions, LabelSuggestion{
-			Label:      "SLEEP",
-			Confidence: 0.65,
-			Reasons:    []string{"NIGHT_HOURS", "MEDIUM_DURATION"},
-		})
+		suggest(RuleSleepNight, "SLEEP", "NIGHT_HOURS", "MEDIUM_DURATION")
 	}
 
 	// TRANSIT: short duration, between movements
 	if timeFeatures.DurationHours < 1 && arrivalContext.Mode != "" && departureContext.Mode != "" {
-		suggestions = append(suggestions, LabelSuggestion{
-			Label:      "TRANSIT",
-			Confidence: 0.5,
-			Reasons:    []string{"SHORT_DURATION", "BETWEEN_MOVEMENTS"},
-		})
+		suggest(RuleTransit, "TRANSIT", "SHORT_DURATION", "BETWEEN_MOVEMENTS")
 	}
 
+	// Venue: the nearest venue at the place whose category suggests a label
+	for _, venue := range venues {
+		if venue.DistanceM > maxVenueDistanceM {
+			break
+		}
+		if label, ok := venueLabels[venue.Category]; ok {
+			suggest(RuleNearbyVenue, label, "NEARBY_VENUE", "POI_"+venue.Category)
+			suggestions[len(suggestions)-1].Venue = venue.Name
+			break
+		}
+	}
+
+	// Re-rank by feedback-adjusted confidence
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Confidence > suggestions[j].Confidence
+	})
+
 	return suggestions
 }
 
+// refreshSuggestionFeedback recounts confirmed/rejected feedback per rule and stores the weights
+// A suggestion is confirmed when its label matches a confirmed annotation, and rejected when it
+// contradicts a confirmed annotation or repeats a rejected one
+func (a *StayAnnotationAnalyzer) refreshSuggestionFeedback(ctx context.Context) (map[string]float64, error) {
+	query := `
+		SELECT sa.label, sa.confirmed, scc.suggestions_json
+		FROM stay_annotations sa
+		JOIN stay_context_cache scc ON scc.stay_id = sa.stay_id
+		WHERE sa.confirmed IN (1, -1)
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query annotation feedback: %w", err)
+	}
+
+	confirmedCounts := make(map[string]int64)
+	rejectedCounts := make(map[string]int64)
+	for rows.Next() {
+		var label, suggestionsJSON string
+		var confirmed int
+		if err := rows.Scan(&label, &confirmed, &suggestionsJSON); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan annotation feedback: %w", err)
+		}
+
+		var suggestions []LabelSuggestion
+		if err := json.Unmarshal([]byte(suggestionsJSON), &suggestions); err != nil {
+			continue
+		}
+
+		for _, suggestion := range suggestions {
+			// Suggestions cached before rule IDs existed cannot be attributed
+			if suggestion.Rule == "" {
+				continue
+			}
+			switch {
+			case confirmed == 1 && suggestion.Label == label:
+				confirmedCounts[suggestion.Rule]++
+			case confirmed == 1:
+				rejectedCounts[suggestion.Rule]++
+			case suggestion.Label == label:
+				rejectedCounts[suggestion.Rule]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("failed to iterate annotation feedback: %w", err)
+	}
+	rows.Close()
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM suggestion_feedback"); err != nil {
+		return nil, fmt.Errorf("failed to clear suggestion feedback: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO suggestion_feedback (rule, confirmed_count, rejected_count, weight, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	weights := make(map[string]float64)
+	for rule, base := range ruleBaseConfidence {
+		confirmed, rejected := confirmedCounts[rule], rejectedCounts[rule]
+		if confirmed == 0 && rejected == 0 {
+			continue
+		}
+		weight := feedbackWeight(base, confirmed, rejected)
+		if _, err := stmt.ExecContext(ctx, rule, confirmed, rejected, weight); err != nil {
+			return nil, fmt.Errorf("failed to insert suggestion feedback: %w", err)
+		}
+		weights[rule] = weight
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return weights, nil
+}
+
 // insertContextCache inserts context cache into the database
 func (a *StayAnnotationAnalyzer) insertContextCache(ctx context.Context, cache []StayContext) error {
 	if len(cache) == 0 {
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	insertQuery := `
+	batch := database.NewBatchInserter(tx, `
 		INSERT OR REPLACE INTO stay_context_cache (
 			stay_id, context_json, suggestions_json, computed_at, algo_version
-		) VALUES (?, ?, ?, CURRENT_TIMESTAMP, 'v1')
-	`
-
-	stmt, err := tx.PrepareContext(ctx, insertQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
+		)`, `(?, ?, ?, CURRENT_TIMESTAMP, '`+analysis.Version("stay_annotation")+`')`,
+		0)
+	defer batch.Close()
 
 	for _, c := range cache {
-		_, err := stmt.ExecContext(ctx,
+		err := batch.Add(ctx,
 			c.StayID,
 			c.ContextJSON,
 			c.SuggestionsJSON,
@@ -513,6 +736,9 @@ func (a *StayAnnotationAnalyzer) insertContextCache(ctx context.Context, cache [
 			return fmt.Errorf("failed to insert context cache: %w", err)
 		}
 	}
+	if err := batch.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to insert context cache: %w", err)
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -524,4 +750,7 @@ func (a *StayAnnotationAnalyzer) insertContextCache(ctx context.Context, cache [
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("stay_annotation", NewStayAnnotationAnalyzer)
+	analysis.RegisterVersion("stay_annotation", "v2")
+	analysis.RegisterDependencies("stay_annotation", "place_anchor", "transport_mode", "poi_enrichment")
+	analysis.RegisterOutputs("stay_annotation", analysis.Output{Table: "stay_context_cache"})
 }
@@ -0,0 +1,507 @@
+package annotation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/weather"
+)
+
+// weatherTripBatch is the number of trips annotated per write
+const weatherTripBatch = 500
+
+// WeatherEnrichmentAnalyzer looks up the historical weather of visited days and trips
+// Skill: 天气补全 (Weather Enrichment)
+// Track points are grouped into local days and 0.25° grid cells. The hourly
+// weather of each day and cell not looked up yet is fetched from the
+// configured weather provider, one request per cell and date range, and
+// aggregated into weather_daily; the cell with most points is the primary
+// cell of the day. Trips then get the temperature and precipitation of the
+// hours they span, at the cell the trip was in during each hour.
+type WeatherEnrichmentAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// WeatherEnrichmentThresholds defines configurable parameters for weather enrichment
+// Loaded from the "weather_enrichment" section of the active threshold profile
+type WeatherEnrichmentThresholds struct {
+	WetPrecipMM float64 `json:"wet_precip_mm"` // Hourly precipitation counted as rain
+	RecentDays  int     `json:"recent_days"`   // The last days are left for later runs, as reanalysis lags a few days
+	MaxSpanDays int     `json:"max_span_days"` // Longest date range fetched per request
+	MaxRequests int     `json:"max_requests"`  // Provider calls per run
+}
+
+// DefaultWeatherEnrichmentThresholds provides default weather enrichment parameters
+var DefaultWeatherEnrichmentThresholds = WeatherEnrichmentThresholds{
+	WetPrecipMM: 0.1,
+	RecentDays:  7,
+	MaxSpanDays: 366,
+	MaxRequests: 500,
+}
+
+// NewWeatherEnrichmentAnalyzer creates a new weather enrichment analyzer
+func NewWeatherEnrichmentAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &WeatherEnrichmentAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "weather_enrichment", weatherTripBatch),
+	}
+}
+
+// weatherDay is a local day with track points in a grid cell
+type weatherDay struct {
+	Date   string
+	Points int
+}
+
+// weatherSpan is a date range of one cell fetched with a single request
+type weatherSpan struct {
+	Cell     string
+	Lat, Lon float64
+	Days     []weatherDay // in date order
+}
+
+// weatherTrip is a trip to annotate
+type weatherTrip struct {
+	ID        int64
+	StartTime int64
+	EndTime   int64
+	TempC     float64
+	PrecipMM  float64
+	Wet       bool
+}
+
+// Analyze looks up the weather of the days and trips not annotated yet
+// A full recompute starts with no day looked up (see the outputs registered in init).
+func (a *WeatherEnrichmentAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[WeatherEnrichmentAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	provider := weather.DefaultProvider()
+	if provider == nil {
+		return weather.ErrNoProvider
+	}
+
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultWeatherEnrichmentThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	spans, err := a.loadSpans(ctx, thresholds)
+	if err != nil {
+		return err
+	}
+	if len(spans) > thresholds.MaxRequests {
+		spans = spans[:thresholds.MaxRequests]
+	}
+	trips, err := a.loadTrips(ctx)
+	if err != nil {
+		return err
+	}
+
+	total := int64(len(spans) + len(trips))
+	log.Printf("[WeatherEnrichmentAnalyzer] Fetching %d date ranges with %s, then %d trips", len(spans), provider.Name(), len(trips))
+	if err := a.UpdateTaskProgress(taskID, total, 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	days := 0
+	for i, span := range spans {
+		first, _ := localDayBounds(span.Days[0].Date)
+		_, last := localDayBounds(span.Days[len(span.Days)-1].Date)
+		hours, err := provider.Hourly(ctx, span.Lat, span.Lon, time.Unix(first, 0), time.Unix(last, 0))
+		if err != nil {
+			// The ranges fetched so far are saved; the next run continues after them
+			return fmt.Errorf("failed to fetch weather of cell %s: %w", span.Cell, err)
+		}
+		if err := a.saveSpan(ctx, span, hours, provider.Name(), thresholds); err != nil {
+			return fmt.Errorf("failed to save weather: %w", err)
+		}
+		days += len(span.Days)
+		if err := a.UpdateTaskProgress(taskID, total, int64(i+1), 0); err != nil {
+			return fmt.Errorf("failed to update task progress: %w", err)
+		}
+	}
+	if err := a.markPrimaryCells(ctx); err != nil {
+		return err
+	}
+
+	annotated, wet, err := a.annotateTrips(ctx, taskID, trips, int64(len(spans)), total, thresholds)
+	if err != nil {
+		return err
+	}
+
+	summary := map[string]interface{}{
+		"mode":            mode,
+		"provider":        provider.Name(),
+		"requests":        len(spans),
+		"days":            days,
+		"trips":           len(trips),
+		"annotated_trips": annotated,
+		"wet_trips":       wet,
+		"thresholds":      thresholds,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[WeatherEnrichmentAnalyzer] Analysis completed: %d days, %d trips annotated (%d wet)", days, annotated, wet)
+	return nil
+}
+
+// loadSpans groups the visited days and cells without weather into date
+// ranges per cell, most recent first
+func (a *WeatherEnrichmentAnalyzer) loadSpans(ctx context.Context, thresholds WeatherEnrichmentThresholds) ([]weatherSpan, error) {
+	done := make(map[string]bool)
+	rows, err := a.DB.QueryContext(ctx, `SELECT date, cell FROM weather_daily`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query weather days: %w", err)
+	}
+	for rows.Next() {
+		var date, cell string
+		if err := rows.Scan(&date, &cell); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan weather day: %w", err)
+		}
+		done[date+"|"+cell] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day()-thresholds.RecentDays, 0, 0, 0, 0, time.Local).Unix()
+	rows, err = a.DB.QueryContext(ctx, `
+		SELECT date(dataTime, 'unixepoch', 'localtime') AS day,
+			ROUND(latitude / ?) AS lat_idx, ROUND(longitude / ?) AS lon_idx, COUNT(*)
+		FROM "一生足迹"
+		WHERE outlier_flag = 0 AND dataTime < ?
+		GROUP BY day, lat_idx, lon_idx
+		ORDER BY lat_idx, lon_idx, day
+	`, weather.CellDeg, weather.CellDeg, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visited days: %w", err)
+	}
+	defer rows.Close()
+
+	var spans []weatherSpan
+	var spanStart time.Time
+	for rows.Next() {
+		var day weatherDay
+		var latIdx, lonIdx float64
+		if err := rows.Scan(&day.Date, &latIdx, &lonIdx, &day.Points); err != nil {
+			return nil, fmt.Errorf("failed to scan visited day: %w", err)
+		}
+		lat, lon := latIdx*weather.CellDeg, lonIdx*weather.CellDeg
+		cell := weather.Cell(lat, lon)
+		if done[day.Date+"|"+cell] {
+			continue
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", day.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid track date %q: %w", day.Date, err)
+		}
+		n := len(spans)
+		if n == 0 || spans[n-1].Cell != cell || date.Sub(spanStart) >= time.Duration(thresholds.MaxSpanDays)*24*time.Hour {
+			spans = append(spans, weatherSpan{Cell: cell, Lat: lat, Lon: lon})
+			spanStart = date
+			n++
+		}
+		spans[n-1].Days = append(spans[n-1].Days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Days[len(spans[i].Days)-1].Date > spans[j].Days[len(spans[j].Days)-1].Date
+	})
+	return spans, nil
+}
+
+// localDayBounds returns the Unix times of the start of a local date and of the next day
+func localDayBounds(date string) (int64, int64) {
+	d, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+	return d.Unix(), d.AddDate(0, 0, 1).Unix()
+}
+
+// saveSpan stores the hours of the days of a span and their daily aggregates
+// Days the provider has no hours for are stored without values, so they are
+// not fetched again.
+func (a *WeatherEnrichmentAnalyzer) saveSpan(ctx context.Context, span weatherSpan, hours []weather.Hour,
+	provider string, thresholds WeatherEnrichmentThresholds) error {
+	byTime := make(map[int64]weather.Hour, len(hours))
+	for _, h := range hours {
+		byTime[h.Time] = h
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertHour, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO weather_hourly (cell, ts, temp_c, precip_mm, provider) VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insertHour.Close()
+	insertDay, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO weather_daily (
+			date, cell, lat, lon, point_count, hour_count,
+			temp_min_c, temp_max_c, temp_mean_c, precip_mm, rain_hours, provider, algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("weather_enrichment")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insertDay.Close()
+
+	for _, day := range span.Days {
+		start, end := localDayBounds(day.Date)
+		var hourCount, rainHours int
+		var sumTemp, precip float64
+		minTemp, maxTemp := math.Inf(1), math.Inf(-1)
+		// Temperatures are taken from the hours of the day and precipitation
+		// from the hours ending in it, so both cover [start, end)
+		for ts := start; ts <= end; ts += 3600 {
+			h, ok := byTime[ts]
+			if !ok {
+				continue
+			}
+			if _, err := insertHour.ExecContext(ctx, span.Cell, h.Time, h.TempC, h.PrecipMM, provider); err != nil {
+				return fmt.Errorf("failed to insert weather hour: %w", err)
+			}
+			if ts < end {
+				hourCount++
+				sumTemp += h.TempC
+				minTemp, maxTemp = math.Min(minTemp, h.TempC), math.Max(maxTemp, h.TempC)
+			}
+			if ts > start {
+				precip += h.PrecipMM
+				if h.PrecipMM >= thresholds.WetPrecipMM {
+					rainHours++
+				}
+			}
+		}
+
+		var tempMin, tempMax, tempMean, precipMM, rain interface{}
+		if hourCount > 0 {
+			tempMin, tempMax = minTemp, maxTemp
+			tempMean = math.Round(sumTemp/float64(hourCount)*10) / 10
+			precipMM, rain = math.Round(precip*10)/10, rainHours
+		}
+		if _, err := insertDay.ExecContext(ctx, day.Date, span.Cell, span.Lat, span.Lon, day.Points, hourCount,
+			tempMin, tempMax, tempMean, precipMM, rain, provider); err != nil {
+			return fmt.Errorf("failed to insert weather of %s: %w", day.Date, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// markPrimaryCells flags the cell with most track points of each day
+func (a *WeatherEnrichmentAnalyzer) markPrimaryCells(ctx context.Context) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE weather_daily SET is_primary = (id = (
+			SELECT w.id FROM weather_daily w WHERE w.date = weather_daily.date
+			ORDER BY w.point_count DESC, w.id LIMIT 1
+		))
+	`); err != nil {
+		return fmt.Errorf("failed to mark primary weather cells: %w", err)
+	}
+	return tx.Commit()
+}
+
+// loadTrips loads the trips without weather
+func (a *WeatherEnrichmentAnalyzer) loadTrips(ctx context.Context) ([]weatherTrip, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, start_time, end_time FROM trips WHERE weather_wet IS NULL ORDER BY start_time
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []weatherTrip
+	for rows.Next() {
+		var t weatherTrip
+		if err := rows.Scan(&t.ID, &t.StartTime, &t.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		trips = append(trips, t)
+	}
+	return trips, rows.Err()
+}
+
+// annotateTrips computes the weather of the trips from the stored hours and
+// saves it; trips in hours without weather are left for a later run
+func (a *WeatherEnrichmentAnalyzer) annotateTrips(ctx context.Context, taskID int64, trips []weatherTrip,
+	processed, total int64, thresholds WeatherEnrichmentThresholds) (int, int, error) {
+	hourStmt, err := a.DB.PrepareContext(ctx, `SELECT temp_c, precip_mm FROM weather_hourly WHERE cell = ? AND ts = ?`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer hourStmt.Close()
+
+	annotated, wet := 0, 0
+	var batch []weatherTrip
+	for i, trip := range trips {
+		ok, err := a.tripWeather(ctx, hourStmt, &trip, thresholds)
+		if err != nil {
+			return annotated, wet, err
+		}
+		if ok {
+			batch = append(batch, trip)
+			annotated++
+			if trip.Wet {
+				wet++
+			}
+		}
+
+		if len(batch) == weatherTripBatch || i == len(trips)-1 {
+			if err := a.saveTrips(ctx, batch); err != nil {
+				return annotated, wet, fmt.Errorf("failed to save trip weather: %w", err)
+			}
+			batch = nil
+			if err := a.UpdateTaskProgress(taskID, total, processed+int64(i+1), 0); err != nil {
+				return annotated, wet, fmt.Errorf("failed to update task progress: %w", err)
+			}
+		}
+	}
+	return annotated, wet, nil
+}
+
+// tripWeather averages the temperature and sums the precipitation of the
+// hours a trip spans, each at the trip's mean position in the hour
+// Reports false when none of the hours has weather.
+func (a *WeatherEnrichmentAnalyzer) tripWeather(ctx context.Context, hourStmt *sql.Stmt, trip *weatherTrip,
+	thresholds WeatherEnrichmentThresholds) (bool, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT (dataTime / 3600) * 3600 AS hour, AVG(latitude), AVG(longitude)
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ? AND outlier_flag = 0
+		GROUP BY hour
+	`, trip.StartTime, trip.EndTime)
+	if err != nil {
+		return false, fmt.Errorf("failed to query points of trip %d: %w", trip.ID, err)
+	}
+	type tripHour struct {
+		Start    int64
+		Lat, Lon float64
+	}
+	var tripHours []tripHour
+	for rows.Next() {
+		var h tripHour
+		if err := rows.Scan(&h.Start, &h.Lat, &h.Lon); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan points of trip %d: %w", trip.ID, err)
+		}
+		tripHours = append(tripHours, h)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	lookup := func(cell string, ts int64) (float64, float64, bool, error) {
+		var temp, precip float64
+		err := hourStmt.QueryRowContext(ctx, cell, ts).Scan(&temp, &precip)
+		if err == sql.ErrNoRows {
+			return 0, 0, false, nil
+		}
+		if err != nil {
+			return 0, 0, false, fmt.Errorf("failed to query weather hour: %w", err)
+		}
+		return temp, precip, true, nil
+	}
+
+	var sumTemp float64
+	var temps, precipHours int
+	for _, h := range tripHours {
+		cell := weather.Cell(h.Lat, h.Lon)
+		temp, _, ok, err := lookup(cell, h.Start)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			sumTemp += temp
+			temps++
+		}
+		_, precip, ok, err := lookup(cell, h.Start+3600)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			trip.PrecipMM += precip
+			precipHours++
+			if precip >= thresholds.WetPrecipMM {
+				trip.Wet = true
+			}
+		}
+	}
+	if temps == 0 || precipHours == 0 {
+		return false, nil
+	}
+
+	trip.TempC = math.Round(sumTemp/float64(temps)*10) / 10
+	trip.PrecipMM = math.Round(trip.PrecipMM*10) / 10
+	return true, nil
+}
+
+// saveTrips stores the weather of annotated trips
+func (a *WeatherEnrichmentAnalyzer) saveTrips(ctx context.Context, trips []weatherTrip) error {
+	if len(trips) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, t := range trips {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE trips SET weather_temp_c = ?, weather_precip_mm = ?, weather_wet = ? WHERE id = ?
+		`, t.TempC, t.PrecipMM, t.Wet, t.ID); err != nil {
+			return fmt.Errorf("failed to update trip %d: %w", t.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("weather_enrichment", NewWeatherEnrichmentAnalyzer)
+	analysis.RegisterVersion("weather_enrichment", "v1")
+	analysis.RegisterDependencies("weather_enrichment", "trip_construction")
+	analysis.RegisterOutputs("weather_enrichment",
+		analysis.Output{Table: "weather_daily"},
+		analysis.Output{Table: "weather_hourly"},
+		analysis.Output{Table: "trips", Where: "weather_wet IS NOT NULL",
+			Set: "weather_temp_c = NULL, weather_precip_mm = NULL, weather_wet = NULL"},
+	)
+}
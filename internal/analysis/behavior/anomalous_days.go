@@ -0,0 +1,380 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+// Categories of anomalous days
+const (
+	AnomalyTravelDay      = "TRAVEL_DAY"
+	AnomalyUnusualRoutine = "UNUSUAL_ROUTINE"
+	AnomalyQuietDay       = "QUIET_DAY"
+)
+
+// Minimum baseline spread per metric, so a very regular baseline (MAD of 0)
+// does not turn a small deviation into a huge z-score. Distance, radius and
+// novel cells are compared as log1p values, stays as counts.
+const (
+	minDistanceScale = 0.5 // log1p(km)
+	minRadiusScale   = 0.5 // log1p(km)
+	minStayScale     = 1.5 // stays
+	minNovelScale    = 1.0 // log1p(cells): about 30 new cells on a day without any usually
+)
+
+// madToSigma scales the median absolute deviation to the standard deviation of a normal distribution
+const madToSigma = 1.4826
+
+// AnomalousDaysThresholds defines configurable parameters for anomalous day detection
+// Loaded from the "anomalous_days" section of the active threshold profile
+type AnomalousDaysThresholds struct {
+	BaselineDays    int     `json:"baseline_days"`     // 90: calendar days before a day that form its baseline
+	MinBaselineDays int     `json:"min_baseline_days"` // 14: days with data needed before a day is scored
+	ZThreshold      float64 `json:"z_threshold"`       // 3.5: robust z-score a metric must reach to count
+}
+
+// DefaultAnomalousDaysThresholds provides default anomalous day parameters
+var DefaultAnomalousDaysThresholds = AnomalousDaysThresholds{
+	BaselineDays:    90,
+	MinBaselineDays: 14,
+	ZThreshold:      3.5,
+}
+
+// AnomalousDaysAnalyzer implements anomalous day detection
+// Skill: 异常日检测 (Anomalous Day Detection)
+// Scores each day's distance, radius of gyration, number of stays and newly
+// visited grid cells against the preceding days, and keeps the days that stand
+// out. Distances and stays come from daily_summaries and grid cells from
+// grid_system, so run it after both.
+type AnomalousDaysAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds AnomalousDaysThresholds
+}
+
+// NewAnomalousDaysAnalyzer creates a new anomalous day analyzer
+func NewAnomalousDaysAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &AnomalousDaysAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "anomalous_days", 10000),
+		Thresholds:          DefaultAnomalousDaysThresholds,
+	}
+}
+
+// dayBehaviour holds the metrics of one local day
+type dayBehaviour struct {
+	Date       string
+	Day        time.Time
+	DistanceM  float64
+	RadiusM    float64
+	StayCount  int
+	NovelGrids int
+}
+
+// anomalousDay is a day that stands out from its baseline
+type anomalousDay struct {
+	dayBehaviour
+	BaselineDays int
+	DistanceZ    float64
+	RadiusZ      float64
+	StayZ        float64
+	NovelZ       float64
+	Score        float64
+	Category     string
+	Reasons      []string
+}
+
+// Analyze performs anomalous day detection
+func (a *AnomalousDaysAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[AnomalousDaysAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultAnomalousDaysThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	if a.Thresholds.BaselineDays < 1 || a.Thresholds.MinBaselineDays < 1 || a.Thresholds.ZThreshold <= 0 {
+		return fmt.Errorf("anomalous_days thresholds must be positive, got %+v", a.Thresholds)
+	}
+
+	days, err := a.loadDays(ctx)
+	if err != nil {
+		return err
+	}
+	totalPoints, err := a.addPointMetrics(ctx, days)
+	if err != nil {
+		return err
+	}
+
+	anomalies := a.scoreDays(days)
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(days)), int64(len(days)), 0); err != nil {
+		log.Printf("[AnomalousDaysAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	// Every new day shifts the baselines and novel cells depend on the whole
+	// history, so the table is rebuilt whatever the mode
+	if err := a.insertAnomalousDays(ctx, anomalies); err != nil {
+		return fmt.Errorf("failed to insert anomalous days: %w", err)
+	}
+
+	// Mark task as completed
+	categories := make(map[string]int)
+	for _, d := range anomalies {
+		categories[d.Category]++
+	}
+	summary := map[string]interface{}{
+		"days":           len(days),
+		"total_points":   totalPoints,
+		"anomalous_days": len(anomalies),
+		"categories":     categories,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[AnomalousDaysAnalyzer] Analysis completed: %d days, %d anomalous", len(days), len(anomalies))
+	return nil
+}
+
+// loadDays reads the summarized days in date order
+func (a *AnomalousDaysAnalyzer) loadDays(ctx context.Context) ([]*dayBehaviour, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT date, COALESCE(total_distance_m, 0), COALESCE(stay_count, 0)
+		FROM daily_summaries
+		ORDER BY date
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var days []*dayBehaviour
+	for rows.Next() {
+		d := &dayBehaviour{}
+		if err := rows.Scan(&d.Date, &d.DistanceM, &d.StayCount); err != nil {
+			return nil, fmt.Errorf("failed to scan daily summary: %w", err)
+		}
+		if d.Day, err = time.ParseInLocation("2006-01-02", d.Date, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid summarized day %q: %w", d.Date, err)
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// addPointMetrics sets the radius of gyration and the number of first-visited
+// grid cells of each day from the track points, and returns the number of points
+func (a *AnomalousDaysAnalyzer) addPointMetrics(ctx context.Context, days []*dayBehaviour) (int, error) {
+	byDate := make(map[string]*dayBehaviour, len(days))
+	for _, d := range days {
+		byDate[d.Date] = d
+	}
+
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude, COALESCE(grid_id, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY dataTime, id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	seenGrids := make(map[string]bool)
+	var current string
+	var points []spatial.Point
+	flush := func() {
+		if d := byDate[current]; d != nil {
+			d.RadiusM = spatial.RadiusOfGyration(points)
+		}
+		points = points[:0]
+	}
+
+	totalPoints := 0
+	for rows.Next() {
+		var timestamp int64
+		var lat, lon float64
+		var gridID string
+		if err := rows.Scan(&timestamp, &lat, &lon, &gridID); err != nil {
+			return 0, fmt.Errorf("failed to scan point: %w", err)
+		}
+		totalPoints++
+
+		date := time.Unix(timestamp, 0).Format("2006-01-02")
+		if date != current {
+			flush()
+			current = date
+		}
+		points = append(points, spatial.Point{Lat: lat, Lon: lon})
+
+		// Cells count as novel on the first day they are seen, even before the
+		// first summarized day, so the history before it is not novel later
+		if gridID != "" && !seenGrids[gridID] {
+			seenGrids[gridID] = true
+			if d := byDate[date]; d != nil {
+				d.NovelGrids++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+	flush()
+	return totalPoints, nil
+}
+
+// scoreDays compares each day with the days of data in the BaselineDays
+// calendar days before it and returns those reaching the z-score threshold
+func (a *AnomalousDaysAnalyzer) scoreDays(days []*dayBehaviour) []anomalousDay {
+	var anomalies []anomalousDay
+	start := 0
+	for i, d := range days {
+		from := d.Day.AddDate(0, 0, -a.Thresholds.BaselineDays)
+		for start < i && days[start].Day.Before(from) {
+			start++
+		}
+		baseline := days[start:i]
+		if len(baseline) < a.Thresholds.MinBaselineDays {
+			continue
+		}
+
+		distances := make([]float64, len(baseline))
+		radii := make([]float64, len(baseline))
+		stays := make([]float64, len(baseline))
+		novel := make([]float64, len(baseline))
+		for j, b := range baseline {
+			distances[j] = math.Log1p(b.DistanceM / 1000)
+			radii[j] = math.Log1p(b.RadiusM / 1000)
+			stays[j] = float64(b.StayCount)
+			novel[j] = math.Log1p(float64(b.NovelGrids))
+		}
+
+		day := anomalousDay{
+			dayBehaviour: *d,
+			BaselineDays: len(baseline),
+			DistanceZ:    robustZ(math.Log1p(d.DistanceM/1000), distances, minDistanceScale),
+			RadiusZ:      robustZ(math.Log1p(d.RadiusM/1000), radii, minRadiusScale),
+			StayZ:        robustZ(float64(d.StayCount), stays, minStayScale),
+			NovelZ:       robustZ(math.Log1p(float64(d.NovelGrids)), novel, minNovelScale),
+		}
+		if day.classify(a.Thresholds.ZThreshold) {
+			anomalies = append(anomalies, day)
+		}
+	}
+	return anomalies
+}
+
+// classify sets the score, reasons and category of a scored day and reports
+// whether it is anomalous. Fewer new places than usual is not a reason.
+func (d *anomalousDay) classify(threshold float64) bool {
+	d.Score = math.Max(math.Max(math.Abs(d.DistanceZ), math.Abs(d.RadiusZ)), math.Max(math.Abs(d.StayZ), d.NovelZ))
+
+	reason := func(name string, z float64, lowToo bool) {
+		switch {
+		case z >= threshold:
+			d.Reasons = append(d.Reasons, name+"_HIGH")
+		case lowToo && z <= -threshold:
+			d.Reasons = append(d.Reasons, name+"_LOW")
+		}
+	}
+	reason("DISTANCE", d.DistanceZ, true)
+	reason("RADIUS", d.RadiusZ, true)
+	reason("STAYS", d.StayZ, true)
+	reason("NOVEL_CELLS", d.NovelZ, false)
+	if len(d.Reasons) == 0 {
+		return false
+	}
+
+	has := func(r string) bool {
+		for _, got := range d.Reasons {
+			if got == r {
+				return true
+			}
+		}
+		return false
+	}
+	allLow := true
+	for _, r := range d.Reasons {
+		allLow = allLow && strings.HasSuffix(r, "_LOW")
+	}
+	switch {
+	case has("RADIUS_HIGH") || (has("DISTANCE_HIGH") && has("NOVEL_CELLS_HIGH")):
+		d.Category = AnomalyTravelDay
+	case allLow:
+		d.Category = AnomalyQuietDay
+	default:
+		d.Category = AnomalyUnusualRoutine
+	}
+	return true
+}
+
+// robustZ returns how many robust standard deviations x lies from the median of values
+func robustZ(x float64, values []float64, minScale float64) float64 {
+	scale := math.Max(madToSigma*stats.MAD(values), minScale)
+	return (x - stats.Median(values)) / scale
+}
+
+// insertAnomalousDays replaces the stored anomalous days in one transaction
+func (a *AnomalousDaysAnalyzer) insertAnomalousDays(ctx context.Context, days []anomalousDay) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM anomalous_days"); err != nil {
+		return fmt.Errorf("failed to clear anomalous_days: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO anomalous_days (
+			date, distance_m, radius_of_gyration_m, stay_count, novel_grid_count,
+			baseline_days, distance_z, radius_z, stay_z, novel_z, score,
+			category, reasons, algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("anomalous_days")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, d := range days {
+		reasonsJSON, _ := json.Marshal(d.Reasons)
+		if _, err := stmt.ExecContext(ctx,
+			d.Date, d.DistanceM, d.RadiusM, d.StayCount, d.NovelGrids,
+			d.BaselineDays, d.DistanceZ, d.RadiusZ, d.StayZ, d.NovelZ, d.Score,
+			d.Category, string(reasonsJSON),
+		); err != nil {
+			return fmt.Errorf("failed to insert anomalous day %s: %w", d.Date, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("anomalous_days", NewAnomalousDaysAnalyzer)
+	analysis.RegisterDependencies("anomalous_days", "daily_summary")
+	analysis.RegisterOutputs("anomalous_days", analysis.Output{Table: "anomalous_days", Replaced: true})
+}
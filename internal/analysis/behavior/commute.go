@@ -0,0 +1,412 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Commute directions
+const (
+	CommuteHomeToWork = "HOME_TO_WORK"
+	CommuteWorkToHome = "WORK_TO_HOME"
+)
+
+// Commute detection settings
+const (
+	commuteMinTrips       = 3 // Minimum trips per direction and weekday to count as recurring
+	commuteRoutePrecision = 7 // Geohash precision of route cells (~150m)
+)
+
+// CommuteAnalyzer detects recurring HOME<->WORK trips
+// Skill: 通勤模式 (Commute Pattern Detection)
+// Uses the origin/destination anchors resolved by trip construction and summarises
+// departure times, durations, route variability and mode split per weekday
+type CommuteAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewCommuteAnalyzer creates a new commute analyzer
+func NewCommuteAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &CommuteAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "commute", 1000),
+	}
+}
+
+// commuteTrip holds a single HOME<->WORK trip
+type commuteTrip struct {
+	ID          int64
+	StartTime   int64
+	EndTime     int64
+	Duration    int64
+	Distance    float64
+	PrimaryMode string
+	Direction   string
+	Cells       map[string]bool
+}
+
+// commutePattern holds the aggregated pattern for one direction and weekday
+type commutePattern struct {
+	Direction          string
+	Weekday            int
+	TripCount          int
+	MedianDepartureMin int
+	P25DepartureMin    int
+	P75DepartureMin    int
+	MedianDurationS    int64
+	AvgDurationS       float64
+	P90DurationS       int64
+	AvgDistanceM       float64
+	RouteVariability   float64
+	ModeSplit          string
+	FirstTripTS        int64
+	LastTripTS         int64
+}
+
+// Analyze performs commute pattern detection
+// Patterns are aggregates over all commute trips, so both modes recompute the table
+func (a *CommuteAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[CommuteAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	trips, err := a.loadCommuteTrips(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load commute trips: %w", err)
+	}
+
+	log.Printf("[CommuteAnalyzer] Processing %d commute trips", len(trips))
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(trips)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Collect the route cells of each trip
+	if err := a.loadRouteCells(ctx, trips); err != nil {
+		return fmt.Errorf("failed to load route cells: %w", err)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(trips)), int64(len(trips)), 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	patterns := a.buildPatterns(trips)
+
+	if err := a.replacePatterns(ctx, patterns); err != nil {
+		return fmt.Errorf("failed to write commute patterns: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"commute_trips": len(trips),
+		"patterns":      len(patterns),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[CommuteAnalyzer] Analysis completed: %d patterns from %d trips", len(patterns), len(trips))
+	return nil
+}
+
+// loadCommuteTrips loads trips whose origin and destination anchors are HOME and WORK
+func (a *CommuteAnalyzer) loadCommuteTrips(ctx context.Context) ([]commuteTrip, error) {
+	query := `
+		SELECT id, start_time, end_time, duration_s, COALESCE(distance_m, 0), COALESCE(primary_mode, ''),
+			json_extract(features_json, '$.origin_anchor') AS origin_anchor
+		FROM trips
+		WHERE features_json IS NOT NULL
+			AND (
+				(json_extract(features_json, '$.origin_anchor') = 'HOME' AND json_extract(features_json, '$.dest_anchor') = 'WORK')
+				OR (json_extract(features_json, '$.origin_anchor') = 'WORK' AND json_extract(features_json, '$.dest_anchor') = 'HOME')
+			)
+		ORDER BY start_time
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []commuteTrip
+	for rows.Next() {
+		var trip commuteTrip
+		var originAnchor string
+		if err := rows.Scan(&trip.ID, &trip.StartTime, &trip.EndTime, &trip.Duration,
+			&trip.Distance, &trip.PrimaryMode, &originAnchor); err != nil {
+			return nil, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		if originAnchor == "HOME" {
+			trip.Direction = CommuteHomeToWork
+		} else {
+			trip.Direction = CommuteWorkToHome
+		}
+		trips = append(trips, trip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return trips, nil
+}
+
+// loadRouteCells collects the set of geohash cells visited by each trip
+func (a *CommuteAnalyzer) loadRouteCells(ctx context.Context, trips []commuteTrip) error {
+	stmt, err := a.DB.PrepareContext(ctx, `
+		SELECT latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND outlier_flag = 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range trips {
+		rows, err := stmt.QueryContext(ctx, trips[i].StartTime, trips[i].EndTime)
+		if err != nil {
+			return fmt.Errorf("failed to query trip points: %w", err)
+		}
+
+		trips[i].Cells = make(map[string]bool)
+		for rows.Next() {
+			var lat, lon float64
+			if err := rows.Scan(&lat, &lon); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan point: %w", err)
+			}
+			trips[i].Cells[spatial.EncodeGeohash(lat, lon, commuteRoutePrecision)] = true
+		}
+		rows.Close()
+	}
+
+	return nil
+}
+
+// buildPatterns aggregates commute trips per direction and weekday
+func (a *CommuteAnalyzer) buildPatterns(trips []commuteTrip) []commutePattern {
+	byDirection := make(map[string][]commuteTrip)
+	for _, trip := range trips {
+		byDirection[trip.Direction] = append(byDirection[trip.Direction], trip)
+	}
+
+	var patterns []commutePattern
+	for _, direction := range []string{CommuteHomeToWork, CommuteWorkToHome} {
+		directionTrips := byDirection[direction]
+		if len(directionTrips) == 0 {
+			continue
+		}
+
+		// The typical route is the trip most similar to all others in this direction
+		typical := typicalRoute(directionTrips)
+
+		byWeekday := make(map[int][]commuteTrip)
+		for _, trip := range directionTrips {
+			weekday := int(time.Unix(trip.StartTime, 0).Weekday())
+			byWeekday[weekday] = append(byWeekday[weekday], trip)
+		}
+
+		for weekday := 0; weekday < 7; weekday++ {
+			dayTrips := byWeekday[weekday]
+			if len(dayTrips) < commuteMinTrips {
+				continue
+			}
+			patterns = append(patterns, summarizeCommute(direction, weekday, dayTrips, typical))
+		}
+	}
+
+	return patterns
+}
+
+// typicalRoute returns the route cells of the medoid trip (highest total Jaccard similarity)
+func typicalRoute(trips []commuteTrip) map[string]bool {
+	bestIdx := -1
+	bestScore := -1.0
+	for i := range trips {
+		if len(trips[i].Cells) == 0 {
+			continue
+		}
+		score := 0.0
+		for j := range trips {
+			if i != j {
+				score += jaccard(trips[i].Cells, trips[j].Cells)
+			}
+		}
+		if score > bestScore {
+			bestIdx, bestScore = i, score
+		}
+	}
+	if bestIdx < 0 {
+		return nil
+	}
+	return trips[bestIdx].Cells
+}
+
+// jaccard computes the Jaccard similarity of two cell sets
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	intersection := 0
+	for cell := range a {
+		if b[cell] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// summarizeCommute computes the pattern statistics for one group of trips
+func summarizeCommute(direction string, weekday int, trips []commuteTrip, typical map[string]bool) commutePattern {
+	departures := make([]int, 0, len(trips))
+	durations := make([]int64, 0, len(trips))
+	modeCounts := make(map[string]int)
+	totalDistance := 0.0
+	totalDuration := int64(0)
+	similaritySum := 0.0
+	similarityCount := 0
+
+	pattern := commutePattern{
+		Direction:   direction,
+		Weekday:     weekday,
+		TripCount:   len(trips),
+		FirstTripTS: trips[0].StartTime,
+		LastTripTS:  trips[0].StartTime,
+	}
+
+	for _, trip := range trips {
+		start := time.Unix(trip.StartTime, 0)
+		departures = append(departures, start.Hour()*60+start.Minute())
+		durations = append(durations, trip.Duration)
+		totalDistance += trip.Distance
+		totalDuration += trip.Duration
+
+		mode := trip.PrimaryMode
+		if mode == "" {
+			mode = "UNKNOWN"
+		}
+		modeCounts[mode]++
+
+		if typical != nil && len(trip.Cells) > 0 {
+			similaritySum += jaccard(trip.Cells, typical)
+			similarityCount++
+		}
+
+		if trip.StartTime < pattern.FirstTripTS {
+			pattern.FirstTripTS = trip.StartTime
+		}
+		if trip.StartTime > pattern.LastTripTS {
+			pattern.LastTripTS = trip.StartTime
+		}
+	}
+
+	sort.Ints(departures)
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	pattern.MedianDepartureMin = departures[percentileIndex(len(departures), 0.5)]
+	pattern.P25DepartureMin = departures[percentileIndex(len(departures), 0.25)]
+	pattern.P75DepartureMin = departures[percentileIndex(len(departures), 0.75)]
+	pattern.MedianDurationS = durations[percentileIndex(len(durations), 0.5)]
+	pattern.P90DurationS = durations[percentileIndex(len(durations), 0.9)]
+	pattern.AvgDurationS = float64(totalDuration) / float64(len(trips))
+	pattern.AvgDistanceM = totalDistance / float64(len(trips))
+
+	if similarityCount > 0 {
+		pattern.RouteVariability = math.Round((1-similaritySum/float64(similarityCount))*1000) / 1000
+	}
+
+	modeSplit := make(map[string]float64, len(modeCounts))
+	for mode, count := range modeCounts {
+		modeSplit[mode] = math.Round(float64(count)/float64(len(trips))*1000) / 1000
+	}
+	modeSplitJSON, _ := json.Marshal(modeSplit)
+	pattern.ModeSplit = string(modeSplitJSON)
+
+	return pattern
+}
+
+// percentileIndex returns the nearest-rank index of percentile p in a sorted slice of length n
+func percentileIndex(n int, p float64) int {
+	idx := int(math.Ceil(p*float64(n))) - 1
+	if idx < 0 {
+		return 0
+	}
+	if idx >= n {
+		return n - 1
+	}
+	return idx
+}
+
+// replacePatterns replaces all commute patterns
+func (a *CommuteAnalyzer) replacePatterns(ctx context.Context, patterns []commutePattern) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM commute_patterns"); err != nil {
+		return fmt.Errorf("failed to clear commute patterns: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO commute_patterns (
+			direction, weekday, trip_count,
+			median_departure_min, p25_departure_min, p75_departure_min,
+			median_duration_s, avg_duration_s, p90_duration_s, avg_distance_m,
+			route_variability, mode_split, first_trip_ts, last_trip_ts,
+			algo_version, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("commute") + `', CAST(strftime('%s', 'now') AS INTEGER))
+	`
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range patterns {
+		_, err := stmt.ExecContext(ctx,
+			p.Direction, p.Weekday, p.TripCount,
+			p.MedianDepartureMin, p.P25DepartureMin, p.P75DepartureMin,
+			p.MedianDurationS, p.AvgDurationS, p.P90DurationS, p.AvgDistanceM,
+			p.RouteVariability, p.ModeSplit, p.FirstTripTS, p.LastTripTS,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert commute pattern: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("commute", NewCommuteAnalyzer)
+	analysis.RegisterDependencies("commute", "trip_construction")
+	analysis.RegisterOutputs("commute", analysis.Output{Table: "commute_patterns", Replaced: true})
+}
@@ -0,0 +1,302 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// ExplorationThresholds defines configurable parameters for the exploration tracker
+// Loaded from the "exploration" section of the active threshold profile
+type ExplorationThresholds struct {
+	MaxGapS       int64 `json:"max_gap_s"`       // 300 s: longer gaps between points count as untracked
+	MinStreakDays int   `json:"min_streak_days"` // 2: shortest discovery streak kept
+}
+
+// DefaultExplorationThresholds provides default exploration parameters
+var DefaultExplorationThresholds = ExplorationThresholds{
+	MaxGapS:       300,
+	MinStreakDays: 2,
+}
+
+// ExplorationAnalyzer implements the exploration vs exploitation tracker
+// Skill: 探索与回访 (Exploration vs Exploitation)
+// A point explores when it lies in a grid cell on the day that cell was first
+// visited; every later visit exploits a familiar cell. Grid cells come from
+// grid_system, so run it after that.
+type ExplorationAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds ExplorationThresholds
+}
+
+// NewExplorationAnalyzer creates a new exploration analyzer
+func NewExplorationAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &ExplorationAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "exploration", 10000),
+		Thresholds:          DefaultExplorationThresholds,
+	}
+}
+
+// explorationMonth accumulates the totals of a month
+type explorationMonth struct {
+	PointCount      int
+	NovelPointCount int
+	TrackedS        int64
+	NovelTimeS      int64
+	NewCells        int
+	CumulativeCells int
+	Days            map[string]bool
+	DiscoveryDays   map[string]bool
+}
+
+// discoveryStreak is a run of consecutive days that each found a new cell
+type discoveryStreak struct {
+	StartDate string
+	EndDate   string
+	DaysCount int
+	NewCells  int
+}
+
+// explorationPrev is the last point seen of a source
+type explorationPrev struct {
+	Time  int64
+	Month string
+	Novel bool
+}
+
+// Analyze performs the exploration analysis
+func (a *ExplorationAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[ExplorationAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultExplorationThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// Points are read in time order so the first visit of a cell comes first.
+	// Intervals are measured between consecutive points of one source and
+	// count towards the cell of the point they start at.
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, COALESCE(source, ''), grid_id
+		FROM "一生足迹"
+		WHERE outlier_flag = 0 AND grid_id IS NOT NULL AND grid_id != ''
+		ORDER BY dataTime, id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	months := make(map[string]*explorationMonth)
+	firstDay := make(map[string]string) // Grid cell -> day of its first visit
+	newCellsByDay := make(map[string]int)
+	prevBySource := make(map[string]explorationPrev)
+	totalPoints := 0
+	for rows.Next() {
+		var timestamp int64
+		var source, gridID string
+		if err := rows.Scan(&timestamp, &source, &gridID); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		totalPoints++
+
+		t := time.Unix(timestamp, 0)
+		day, month := t.Format("2006-01-02"), t.Format("2006-01")
+		m := months[month]
+		if m == nil {
+			m = &explorationMonth{Days: make(map[string]bool), DiscoveryDays: make(map[string]bool)}
+			months[month] = m
+		}
+		m.Days[day] = true
+
+		first, seen := firstDay[gridID]
+		if !seen {
+			first = day
+			firstDay[gridID] = day
+			newCellsByDay[day]++
+			m.NewCells++
+			m.DiscoveryDays[day] = true
+		}
+		novel := first == day
+		m.PointCount++
+		if novel {
+			m.NovelPointCount++
+		}
+
+		if prev, ok := prevBySource[source]; ok {
+			if dt := timestamp - prev.Time; dt > 0 && dt <= a.Thresholds.MaxGapS {
+				pm := months[prev.Month]
+				pm.TrackedS += dt
+				if prev.Novel {
+					pm.NovelTimeS += dt
+				}
+			}
+		}
+		prevBySource[source] = explorationPrev{Time: timestamp, Month: month, Novel: novel}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	rows.Close()
+
+	// Cumulative cell counts in month order
+	keys := make([]string, 0, len(months))
+	for month := range months {
+		keys = append(keys, month)
+	}
+	sort.Strings(keys)
+	cumulative := 0
+	for _, month := range keys {
+		cumulative += months[month].NewCells
+		months[month].CumulativeCells = cumulative
+	}
+
+	streaks := a.detectDiscoveryStreaks(newCellsByDay)
+
+	if err := a.UpdateTaskProgress(taskID, int64(totalPoints), int64(totalPoints), 0); err != nil {
+		log.Printf("[ExplorationAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	// Novelty depends on the whole history, so both tables are rebuilt whatever the mode
+	if err := a.insertExploration(ctx, months, streaks); err != nil {
+		return fmt.Errorf("failed to insert exploration stats: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_points":      totalPoints,
+		"months":            len(months),
+		"cells":             len(firstDay),
+		"discovery_streaks": len(streaks),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[ExplorationAnalyzer] Analysis completed: %d points, %d months, %d cells, %d streaks",
+		totalPoints, len(months), len(firstDay), len(streaks))
+	return nil
+}
+
+// detectDiscoveryStreaks returns the runs of consecutive days with new cells
+// that last at least MinStreakDays
+func (a *ExplorationAnalyzer) detectDiscoveryStreaks(newCellsByDay map[string]int) []discoveryStreak {
+	days := make([]string, 0, len(newCellsByDay))
+	for day := range newCellsByDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	var streaks []discoveryStreak
+	var current *discoveryStreak
+	var prevDay time.Time
+	for _, day := range days {
+		t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+		if err != nil {
+			continue
+		}
+		if current != nil && t.Equal(prevDay.AddDate(0, 0, 1)) {
+			current.EndDate = day
+			current.DaysCount++
+			current.NewCells += newCellsByDay[day]
+		} else {
+			if current != nil && current.DaysCount >= a.Thresholds.MinStreakDays {
+				streaks = append(streaks, *current)
+			}
+			current = &discoveryStreak{StartDate: day, EndDate: day, DaysCount: 1, NewCells: newCellsByDay[day]}
+		}
+		prevDay = t
+	}
+	if current != nil && current.DaysCount >= a.Thresholds.MinStreakDays {
+		streaks = append(streaks, *current)
+	}
+	return streaks
+}
+
+// insertExploration replaces the monthly stats and discovery streaks in one transaction
+func (a *ExplorationAnalyzer) insertExploration(ctx context.Context, months map[string]*explorationMonth, streaks []discoveryStreak) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"exploration_monthly", "discovery_streaks"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	monthStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO exploration_monthly (
+			month, point_count, novel_point_count, tracked_s, novel_time_s,
+			novel_point_ratio, novel_time_ratio, new_cell_count, cumulative_cell_count,
+			active_days, discovery_days, algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("exploration")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer monthStmt.Close()
+
+	for month, m := range months {
+		var pointRatio, timeRatio float64
+		if m.PointCount > 0 {
+			pointRatio = float64(m.NovelPointCount) / float64(m.PointCount)
+		}
+		if m.TrackedS > 0 {
+			timeRatio = float64(m.NovelTimeS) / float64(m.TrackedS)
+		}
+		if _, err := monthStmt.ExecContext(ctx,
+			month, m.PointCount, m.NovelPointCount, m.TrackedS, m.NovelTimeS,
+			pointRatio, timeRatio, m.NewCells, m.CumulativeCells,
+			len(m.Days), len(m.DiscoveryDays),
+		); err != nil {
+			return fmt.Errorf("failed to insert exploration month %s: %w", month, err)
+		}
+	}
+
+	streakStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO discovery_streaks (start_date, end_date, days_count, new_cell_count, algo_version)
+		VALUES (?, ?, ?, ?, '`+analysis.Version("exploration")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer streakStmt.Close()
+
+	for _, s := range streaks {
+		if _, err := streakStmt.ExecContext(ctx, s.StartDate, s.EndDate, s.DaysCount, s.NewCells); err != nil {
+			return fmt.Errorf("failed to insert discovery streak: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("exploration", NewExplorationAnalyzer)
+	analysis.RegisterDependencies("exploration", "outlier_detection")
+	analysis.RegisterOutputs("exploration",
+		analysis.Output{Table: "exploration_monthly", Replaced: true},
+		analysis.Output{Table: "discovery_streaks", Replaced: true},
+	)
+}
@@ -0,0 +1,483 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/transit"
+)
+
+// FlightDetectionAnalyzer implements flight detection
+// Skill: 航班识别 (Flight Detection)
+// Finds stretches of the trajectory flown between two airports: long gaps
+// crossed at flight speed and runs of airborne-speed points. Each flight
+// replaces the segments it covers with a FLIGHT segment, so trip
+// construction builds FLIGHT trips, and is recorded as a detected flight
+// journey unless a booked flight covers it.
+type FlightDetectionAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// FlightDetectionThresholds defines configurable thresholds for flight detection
+// Loaded from the "flight_detection" section of the active threshold profile
+type FlightDetectionThresholds struct {
+	MinGapS        int64   `json:"min_gap_s"`         // Gaps at least this long may hide a flight
+	MinGapSpeedMPS float64 `json:"min_gap_speed_mps"` // Slowest straight-line speed across a flown gap, ground time included
+	BurstSpeedMPS  float64 `json:"burst_speed_mps"`   // Airborne speed, above high-speed rail
+	MaxSpeedMPS    float64 `json:"max_speed_mps"`     // Faster steps are GPS jumps
+	MinDistanceM   float64 `json:"min_distance_m"`    // Shortest flight between airports
+	AirportRadiusM float64 `json:"airport_radius_m"`  // A flight end must come this close to an airport
+	SnapWindowS    int64   `json:"snap_window_s"`     // Points this long before and after a flight are searched for its airports
+}
+
+// DefaultFlightDetectionThresholds provides default flight detection thresholds
+var DefaultFlightDetectionThresholds = FlightDetectionThresholds{
+	MinGapS:        1200,
+	MinGapSpeedMPS: 40,  // 144 km/h
+	BurstSpeedMPS:  100, // 360 km/h
+	MaxSpeedMPS:    300, // 1080 km/h
+	MinDistanceM:   100000,
+	AirportRadiusM: 10000,
+	SnapWindowS:    900,
+}
+
+// bookedFlightSlackS widens the schedule of booked flights when matching
+// detected ones, absorbing delays
+const bookedFlightSlackS = 2 * 3600
+
+// NewFlightDetectionAnalyzer creates a new flight detection analyzer
+func NewFlightDetectionAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &FlightDetectionAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "flight_detection", 5000),
+	}
+}
+
+// Analyze performs flight detection
+func (a *FlightDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[FlightDetectionAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultFlightDetectionThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// A full recompute starts without FLIGHT segments (see the outputs registered in init)
+	// In incremental mode, detection resumes after the latest flight
+	var sinceTS int64
+	if mode != "full" {
+		if err := a.DB.QueryRowContext(ctx, `
+			SELECT COALESCE(MAX(end_time), 0) FROM segments WHERE mode = 'FLIGHT'
+		`).Scan(&sinceTS); err != nil {
+			return fmt.Errorf("failed to query latest flight: %w", err)
+		}
+	}
+
+	var totalPoints int64
+	if err := a.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM "一生足迹" WHERE outlier_flag = 0 AND dataTime > ?
+	`, sinceTS).Scan(&totalPoints); err != nil {
+		return fmt.Errorf("failed to count points: %w", err)
+	}
+	if err := a.UpdateTaskProgress(taskID, totalPoints, 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Stream points in time-ordered batches; the detector carries an open flight across batch boundaries
+	detector := newFlightDetector(thresholds)
+	var flights []Flight
+	processed := int64(0)
+	cursorTS, cursorID := sinceTS, int64(math.MaxInt64)
+	for {
+		points, err := a.loadPointBatch(ctx, cursorTS, cursorID, a.BatchSize)
+		if err != nil {
+			return err
+		}
+		for _, point := range points {
+			flights = append(flights, detector.add(point)...)
+		}
+		if len(points) == 0 {
+			break
+		}
+
+		last := points[len(points)-1]
+		cursorTS, cursorID = last.Timestamp, last.ID
+		processed += int64(len(points))
+		if err := a.UpdateTaskProgress(taskID, totalPoints, processed, 0); err != nil {
+			return fmt.Errorf("failed to update task progress: %w", err)
+		}
+		if len(points) < a.BatchSize {
+			break
+		}
+	}
+	flights = append(flights, detector.flush()...)
+
+	journeys, err := a.saveFlights(ctx, flights)
+	if err != nil {
+		return fmt.Errorf("failed to save flights: %w", err)
+	}
+
+	summary := map[string]interface{}{
+		"mode":         mode,
+		"total_points": processed,
+		"flights":      len(flights),
+		"journeys":     journeys,
+		"thresholds":   thresholds,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[FlightDetectionAnalyzer] Analysis completed: %d points processed, %d flights detected", processed, len(flights))
+	return nil
+}
+
+// loadPointBatch loads the next batch of non-outlier track points ordered by (dataTime, id)
+// The first batch starts after afterTS when afterID is math.MaxInt64.
+func (a *FlightDetectionAnalyzer) loadPointBatch(ctx context.Context, afterTS, afterID int64, limit int) ([]types.Point, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, dataTime, latitude, longitude, `+analysis.EffectiveSpeedExpr+`
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+			AND (dataTime > ? OR (dataTime = ? AND id > ?))
+		ORDER BY dataTime, id
+		LIMIT ?
+	`, afterTS, afterTS, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]types.Point, 0, limit)
+	for rows.Next() {
+		var point types.Point
+		var speed sql.NullFloat64
+		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &speed); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		point.Speed = speed.Float64
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating points: %w", err)
+	}
+	return points, nil
+}
+
+// Flight is a flight detected in the trajectory
+type Flight struct {
+	Origin       transit.Place `json:"origin"`
+	Dest         transit.Place `json:"dest"`
+	StartTime    int64         `json:"start_time"` // Last point on the ground before the flight
+	EndTime      int64         `json:"end_time"`   // First point on the ground after it
+	StartPointID int64         `json:"start_point_id"`
+	EndPointID   int64         `json:"end_point_id"`
+	PointCount   int           `json:"point_count"`
+	DistanceM    float64       `json:"distance_m"` // Great-circle distance between the airports
+	MaxSpeedKmh  float64       `json:"max_speed_kmh"`
+	Gaps         int           `json:"gaps"`       // Gaps crossed at flight speed
+	Bursts       int           `json:"bursts"`     // Ends flown at airborne speed, 0 to 2
+	Confidence   float64       `json:"confidence"` // Higher when airborne bursts bracket the flight
+}
+
+// flightDetector finds flights in a time-ordered point stream
+// A flight is a chain of steps that are airborne (a point at BurstSpeedMPS
+// or faster) or long gaps crossed at flight speed. Once a chain ends, the
+// points of the next SnapWindowS are collected to locate the destination
+// airport; airborne steps among them continue the flight instead.
+type flightDetector struct {
+	thresholds FlightDetectionThresholds
+	recent     []types.Point // Points of the last SnapWindowS, while no flight is open
+	flight     *flightSpan
+	last       types.Point
+	hasLast    bool
+}
+
+// flightSpan is an open flight
+type flightSpan struct {
+	before     []types.Point // Points up to the takeoff, searched for the origin airport
+	start      types.Point
+	end        types.Point
+	points     int
+	maxSpeed   float64
+	gaps       int
+	firstBurst bool
+	lastBurst  bool
+	after      []types.Point // Points since the landing, searched for the destination airport
+}
+
+// newFlightDetector creates a detector using thresholds
+func newFlightDetector(thresholds FlightDetectionThresholds) *flightDetector {
+	return &flightDetector{thresholds: thresholds}
+}
+
+// add appends a point and returns the flights it completes
+func (d *flightDetector) add(point types.Point) []Flight {
+	prev, hasPrev := d.last, d.hasLast
+	d.last, d.hasLast = point, true
+
+	flown, gap := false, false
+	if hasPrev {
+		flown, gap = d.step(prev, point)
+	}
+
+	f := d.flight
+	switch {
+	case f == nil && flown:
+		d.flight = &flightSpan{before: d.recent, start: prev, end: point, points: 2, maxSpeed: prev.Speed, firstBurst: !gap, lastBurst: !gap}
+		d.recent = nil
+		d.extend(point, gap)
+	case f == nil:
+		d.recent = append(d.recent, point)
+		cut := 0
+		for cut < len(d.recent) && point.Timestamp-d.recent[cut].Timestamp > d.thresholds.SnapWindowS {
+			cut++
+		}
+		d.recent = d.recent[cut:]
+	case flown:
+		// Points since the last airborne step were a dip in speed, not a landing
+		f.points += len(f.after) + 1
+		f.after = nil
+		f.end = point
+		f.lastBurst = !gap
+		d.extend(point, gap)
+	default:
+		f.after = append(f.after, point)
+		if point.Timestamp-f.end.Timestamp > d.thresholds.SnapWindowS {
+			return d.close()
+		}
+	}
+	return nil
+}
+
+// extend adds the step to point to the open flight
+func (d *flightDetector) extend(point types.Point, gap bool) {
+	f := d.flight
+	if gap {
+		f.gaps++
+	}
+	f.maxSpeed = math.Max(f.maxSpeed, point.Speed)
+}
+
+// step reports whether the step from p to q was flown and whether it was a gap
+func (d *flightDetector) step(p, q types.Point) (bool, bool) {
+	t := d.thresholds
+	dt := q.Timestamp - p.Timestamp
+	if dt <= 0 {
+		return false, false
+	}
+	speed := spatial.HaversineDistance(p.Lat, p.Lon, q.Lat, q.Lon) / float64(dt)
+	if speed > t.MaxSpeedMPS {
+		return false, false
+	}
+	if dt >= t.MinGapS {
+		return speed >= t.MinGapSpeedMPS, true
+	}
+	return math.Max(p.Speed, q.Speed) >= t.BurstSpeedMPS || speed >= t.BurstSpeedMPS, false
+}
+
+// flush returns the flight still open at the end of the stream, if any
+func (d *flightDetector) flush() []Flight {
+	if d.flight == nil {
+		return nil
+	}
+	return d.close()
+}
+
+// close completes the open flight, returning it if it connects two airports
+func (d *flightDetector) close() []Flight {
+	f := d.flight
+	d.flight = nil
+	d.recent = f.after
+
+	origin, ok := d.snap(append(f.before, f.start))
+	if !ok {
+		return nil
+	}
+	dest, ok := d.snap(append([]types.Point{f.end}, f.after...))
+	if !ok || dest.Code == origin.Code {
+		return nil
+	}
+	distance := spatial.HaversineDistance(origin.Lat, origin.Lon, dest.Lat, dest.Lon)
+	if distance < d.thresholds.MinDistanceM {
+		return nil
+	}
+
+	flight := Flight{
+		Origin:       origin,
+		Dest:         dest,
+		StartTime:    f.start.Timestamp,
+		EndTime:      f.end.Timestamp,
+		StartPointID: f.start.ID,
+		EndPointID:   f.end.ID,
+		PointCount:   f.points,
+		DistanceM:    distance,
+		MaxSpeedKmh:  f.maxSpeed * 3.6,
+		Gaps:         f.gaps,
+		Confidence:   0.6,
+	}
+	for _, burst := range []bool{f.firstBurst, f.lastBurst} {
+		if burst {
+			flight.Bursts++
+			flight.Confidence += 0.15
+		}
+	}
+	return []Flight{flight}
+}
+
+// snap returns the airport closest to any of points, if within AirportRadiusM
+func (d *flightDetector) snap(points []types.Point) (transit.Place, bool) {
+	var best transit.Place
+	bestDist := math.Inf(1)
+	for _, p := range points {
+		if airport, dist := transit.NearestAirport(p.Lat, p.Lon); dist < bestDist {
+			best, bestDist = airport, dist
+		}
+	}
+	return best, bestDist <= d.thresholds.AirportRadiusM
+}
+
+// detectFlights finds the flights in time-ordered points, as the analyzer
+// does while streaming them in batches
+func detectFlights(points []types.Point, thresholds FlightDetectionThresholds) []Flight {
+	detector := newFlightDetector(thresholds)
+	var flights []Flight
+	for _, point := range points {
+		flights = append(flights, detector.add(point)...)
+	}
+	return append(flights, detector.flush()...)
+}
+
+// saveFlights replaces the segments each flight covers with a FLIGHT
+// segment and records the flights no booked flight covers as detected
+// journeys, linking booked flights to the segment instead
+// Returns the number of journeys recorded.
+func (a *FlightDetectionAnalyzer) saveFlights(ctx context.Context, flights []Flight) (int, error) {
+	if len(flights) == 0 {
+		return 0, nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	journeys := 0
+	for _, f := range flights {
+		// Segments inside the flight lose their derived rows with them, as when transport_mode re-opens a segment
+		covered := `SELECT id FROM segments WHERE start_time >= ? AND end_time <= ? AND mode != 'FLIGHT'`
+		for _, table := range []string{"speed_events", "render_segments_cache", "segment_polylines", "matched_segments", "road_overlap_stats"} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE segment_id IN ("+covered+")", f.StartTime, f.EndTime); err != nil {
+				// Table may not exist yet
+				log.Printf("[FlightDetectionAnalyzer] Warning: failed to clear %s for flight at %d: %v", table, f.StartTime, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM segments WHERE id IN ("+covered+")", f.StartTime, f.EndTime); err != nil {
+			return 0, fmt.Errorf("failed to delete segments covered by flight at %d: %w", f.StartTime, err)
+		}
+
+		reasons, _ := json.Marshal(flightReasons(f))
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"origin": f.Origin.Code,
+			"dest":   f.Dest.Code,
+			"gaps":   f.Gaps,
+			"bursts": f.Bursts,
+		})
+		duration := f.EndTime - f.StartTime
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO segments (
+				mode, start_time, end_time, start_point_id, end_point_id,
+				point_count, distance_m, duration_s, avg_speed_kmh, max_speed_kmh,
+				confidence, reason_codes, metadata, source, `+analysis.SegmentAreaColumns+`, algo_version, created_at, updated_at
+			) VALUES ('FLIGHT', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+analysis.DominantSourceExpr+`, `+analysis.SegmentAreaValues+`, '`+analysis.Version("flight_detection")+`', CAST(strftime('%s', 'now') AS INTEGER), CAST(strftime('%s', 'now') AS INTEGER))
+		`, append([]interface{}{f.StartTime, f.EndTime, f.StartPointID, f.EndPointID,
+			f.PointCount, f.DistanceM, duration, f.DistanceM/float64(max(duration, 1))*3.6, f.MaxSpeedKmh,
+			f.Confidence, string(reasons), string(metadata), f.StartTime, f.EndTime},
+			analysis.SegmentAreaArgs(f.StartPointID, f.EndPointID)...)...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert flight segment: %w", err)
+		}
+		segmentID, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get flight segment id: %w", err)
+		}
+
+		// A booked flight scheduled around the detected one is the same flight
+		booked, err := tx.ExecContext(ctx, `
+			UPDATE journeys SET segment_id = COALESCE(segment_id, ?)
+			WHERE journey_type = 'FLIGHT' AND source != 'detected'
+			  AND departure_time <= ? AND COALESCE(arrival_time, departure_time) >= ?
+		`, segmentID, f.EndTime+bookedFlightSlackS, f.StartTime-bookedFlightSlackS)
+		if err != nil {
+			return 0, fmt.Errorf("failed to link booked flights: %w", err)
+		}
+		if n, err := booked.RowsAffected(); err != nil {
+			return 0, fmt.Errorf("failed to get affected rows: %w", err)
+		} else if n > 0 {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO journeys (
+				journey_type, number, departure_time, arrival_time, duration_s,
+				origin_code, origin_name, origin_city, origin_lat, origin_lon,
+				dest_code, dest_name, dest_city, dest_lat, dest_lon,
+				distance_m, segment_id, source
+			) VALUES ('FLIGHT', '', ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'detected')
+			ON CONFLICT (journey_type, number, departure_time) DO UPDATE SET
+				segment_id = excluded.segment_id,
+				updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		`, f.StartTime, f.EndTime, duration,
+			f.Origin.Code, f.Origin.Name, f.Origin.City, f.Origin.Lat, f.Origin.Lon,
+			f.Dest.Code, f.Dest.Name, f.Dest.City, f.Dest.Lat, f.Dest.Lon,
+			f.DistanceM, segmentID); err != nil {
+			return 0, fmt.Errorf("failed to insert detected flight: %w", err)
+		}
+		journeys++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return journeys, nil
+}
+
+// flightReasons returns the reason codes of a flight segment
+func flightReasons(f Flight) []string {
+	reasons := []string{"AIRPORTS"}
+	if f.Gaps > 0 {
+		reasons = append(reasons, "FLOWN_GAP")
+	}
+	if f.Bursts > 0 {
+		reasons = append(reasons, "AIRBORNE_SPEED")
+	}
+	return reasons
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("flight_detection", NewFlightDetectionAnalyzer)
+	analysis.RegisterVersion("flight_detection", "v1")
+	analysis.RegisterDependencies("flight_detection", "transport_mode")
+	// The segments a flight covers are deleted for good; recompute
+	// transport_mode to restore them when fewer flights are detected
+	analysis.RegisterOutputs("flight_detection",
+		analysis.Output{Table: "journeys", Where: "source = 'detected'"},
+		analysis.Output{Table: "segments", Where: "mode = 'FLIGHT'"},
+	)
+}
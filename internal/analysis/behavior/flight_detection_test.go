@@ -0,0 +1,128 @@
+package behavior
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Terminals of airports in the transit tables, and a place far from any airport
+var (
+	atPEK     = [2]float64{40.0801, 116.5846}
+	atPKX     = [2]float64{39.5098, 116.4105}
+	atSHA     = [2]float64{31.1979, 121.3363}
+	noAirport = [2]float64{35.0, 100.0}
+)
+
+// groundPoints returns points at a place from start to end, one every 60 s
+func groundPoints(at [2]float64, start, end int64) []types.Point {
+	var points []types.Point
+	for ts := start; ts <= end; ts += 60 {
+		points = append(points, types.Point{ID: ts, Timestamp: ts, Lat: at[0], Lon: at[1], Speed: 1})
+	}
+	return points
+}
+
+// flyPoints returns points moving straight from one place to another at
+// speed, one every 60 s, starting at start
+func flyPoints(from, to [2]float64, start int64, speed float64) []types.Point {
+	distance := spatial.HaversineDistance(from[0], from[1], to[0], to[1])
+	steps := int(math.Ceil(distance / speed / 60))
+	var points []types.Point
+	for i := 1; i < steps; i++ {
+		f := float64(i) / float64(steps)
+		ts := start + int64(i)*60
+		points = append(points, types.Point{
+			ID:        ts,
+			Timestamp: ts,
+			Lat:       from[0] + f*(to[0]-from[0]),
+			Lon:       from[1] + f*(to[1]-from[1]),
+			Speed:     speed,
+		})
+	}
+	return points
+}
+
+func TestDetectFlights(t *testing.T) {
+	type want struct {
+		origin, dest string
+		start, end   int64
+		gaps, bursts int
+	}
+	tests := []struct {
+		name   string
+		points []types.Point
+		want   []want
+	}{
+		{
+			name:   "gap between airports",
+			points: joinPoints(groundPoints(atPEK, 0, 600), groundPoints(atSHA, 9600, 10200)),
+			want:   []want{{"PEK", "SHA", 600, 9600, 1, 0}},
+		},
+		{
+			name:   "tracked flight",
+			points: joinPoints(groundPoints(atPEK, 0, 600), flyPoints(atPEK, atSHA, 600, 200), groundPoints(atSHA, 6060, 6600)),
+			want:   []want{{"PEK", "SHA", 600, 6060, 0, 2}},
+		},
+		{
+			name:   "airborne bursts around a gap",
+			points: joinPoints(groundPoints(atPEK, 0, 600), flyPoints(atPEK, atSHA, 600, 200)[:3], flyPoints(atPEK, atSHA, 600, 200)[86:], groundPoints(atSHA, 6060, 6600)),
+			want:   []want{{"PEK", "SHA", 600, 6060, 1, 2}},
+		},
+		{
+			name:   "connecting flights",
+			points: joinPoints(groundPoints(atSHA, 0, 600), groundPoints(atPEK, 9600, 13200), groundPoints(atSHA, 22200, 22800)),
+			want:   []want{{"SHA", "PEK", 600, 9600, 1, 0}, {"PEK", "SHA", 13200, 22200, 1, 0}},
+		},
+		{
+			name:   "high-speed rail is not airborne",
+			points: joinPoints(groundPoints(atPEK, 0, 600), flyPoints(atPEK, atSHA, 600, 85), groundPoints(atSHA, 13380, 13980)),
+			want:   nil,
+		},
+		{
+			name:   "gap too slow for a flight",
+			points: joinPoints(groundPoints(atPEK, 0, 600), groundPoints(atSHA, 86400, 87000)),
+			want:   nil,
+		},
+		{
+			name:   "gap not ending at an airport",
+			points: joinPoints(groundPoints(atPEK, 0, 600), groundPoints(noAirport, 20000, 20600)),
+			want:   nil,
+		},
+		{
+			name:   "airports too close",
+			points: joinPoints(groundPoints(atPEK, 0, 600), groundPoints(atPKX, 1800, 2400)),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectFlights(tt.points, DefaultFlightDetectionThresholds)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d flights, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, f := range got {
+				w := tt.want[i]
+				if f.Origin.Code != w.origin || f.Dest.Code != w.dest || f.StartTime != w.start || f.EndTime != w.end || f.Gaps != w.gaps || f.Bursts != w.bursts {
+					t.Errorf("flight %d = %s-%s %d-%d (%d gaps, %d bursts), want %s-%s %d-%d (%d gaps, %d bursts)",
+						i, f.Origin.Code, f.Dest.Code, f.StartTime, f.EndTime, f.Gaps, f.Bursts,
+						w.origin, w.dest, w.start, w.end, w.gaps, w.bursts)
+				}
+			}
+		})
+	}
+
+	t.Run("confidence grows with bursts", func(t *testing.T) {
+		gap := detectFlights(tests[0].points, DefaultFlightDetectionThresholds)[0]
+		tracked := detectFlights(tests[1].points, DefaultFlightDetectionThresholds)[0]
+		if gap.Confidence >= tracked.Confidence {
+			t.Errorf("confidence of a gap = %.2f, of a tracked flight = %.2f, want it lower", gap.Confidence, tracked.Confidence)
+		}
+		if math.Abs(tracked.DistanceM-1080000) > 20000 {
+			t.Errorf("distance = %.0f m, want about 1080 km", tracked.DistanceM)
+		}
+	})
+}
@@ -0,0 +1,264 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/transit"
+)
+
+// RailLineAnalyzer implements high-speed rail line recognition
+// Skill: 高铁线路识别 (Rail Line Recognition)
+// Matches the points of TRAIN segments against the embedded high-speed rail
+// line traces. Every step of a segment is credited to the line nearest its
+// midpoint, so a segment changing lines at a junction records the distance
+// travelled on each, and the line covering most of it labels the segment.
+type RailLineAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// RailLineThresholds defines configurable thresholds for rail line recognition
+// Loaded from the "rail_lines" section of the active threshold profile
+type RailLineThresholds struct {
+	CorridorM        float64 `json:"corridor_m"`          // Steps farther than this from every line are off the network
+	MinLineDistanceM float64 `json:"min_line_distance_m"` // Shortest distance on a line worth recording
+	MinShare         float64 `json:"min_share"`           // Share of the segment a line needs to label it
+	MaxStepS         int64   `json:"max_step_s"`          // Longer steps are gaps and credit no line
+}
+
+// DefaultRailLineThresholds provides default rail line recognition thresholds
+var DefaultRailLineThresholds = RailLineThresholds{
+	CorridorM:        5000, // Line traces join stations with straight lines
+	MinLineDistanceM: 5000,
+	MinShare:         0.5,
+	MaxStepS:         300,
+}
+
+// RailLineMatch is the distance a segment travelled along one rail line
+type RailLineMatch struct {
+	Line      string  `json:"line"`
+	DistanceM float64 `json:"distance_m"`
+	Share     float64 `json:"share"` // Fraction of the segment's distance
+}
+
+// NewRailLineAnalyzer creates a new rail line analyzer
+func NewRailLineAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &RailLineAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "rail_lines", 1000),
+	}
+}
+
+// Analyze performs rail line recognition
+func (a *RailLineAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[RailLineAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultRailLineThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// A full recompute starts without matches (see the outputs registered in init),
+	// so loading the unmatched segments covers both modes
+	segments, err := a.loadUnmatchedTrainSegments(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[RailLineAnalyzer] Processing %d TRAIN segments", len(segments))
+	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	matches := make(map[int64][]RailLineMatch, len(segments))
+	labelled := 0
+	for i, seg := range segments {
+		points, err := a.loadSegmentPoints(ctx, seg)
+		if err != nil {
+			return err
+		}
+		lines, _ := matchRailLines(points, thresholds)
+		matches[seg.ID] = lines
+		if railLineLabel(lines, thresholds) != "" {
+			labelled++
+		}
+
+		if (i+1)%100 == 0 {
+			if err := a.UpdateTaskProgress(taskID, int64(len(segments)), int64(i+1), 0); err != nil {
+				return fmt.Errorf("failed to update task progress: %w", err)
+			}
+		}
+	}
+
+	if err := a.saveMatches(ctx, segments, matches, thresholds); err != nil {
+		return fmt.Errorf("failed to save rail line matches: %w", err)
+	}
+
+	summary := map[string]interface{}{
+		"mode":       mode,
+		"segments":   len(segments),
+		"labelled":   labelled,
+		"thresholds": thresholds,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[RailLineAnalyzer] Analysis completed: %d segments processed, %d labelled", len(segments), labelled)
+	return nil
+}
+
+// loadUnmatchedTrainSegments loads the TRAIN segments without rail line matches
+func (a *RailLineAnalyzer) loadUnmatchedTrainSegments(ctx context.Context) ([]types.SegmentInfo, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, start_time, end_time
+		FROM segments s
+		WHERE mode = 'TRAIN' AND rail_line IS NULL
+			AND NOT EXISTS (SELECT 1 FROM segment_rail_lines r WHERE r.segment_id = s.id)
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []types.SegmentInfo
+	for rows.Next() {
+		var seg types.SegmentInfo
+		if err := rows.Scan(&seg.ID, &seg.StartTS, &seg.EndTS); err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments = append(segments, seg)
+	}
+	return segments, rows.Err()
+}
+
+// loadSegmentPoints loads the non-outlier points of a segment in time order
+func (a *RailLineAnalyzer) loadSegmentPoints(ctx context.Context, seg types.SegmentInfo) ([]types.Point, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, dataTime, latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND outlier_flag = 0
+		ORDER BY dataTime
+	`, seg.StartTS, seg.EndTS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points for segment %d: %w", seg.ID, err)
+	}
+	defer rows.Close()
+
+	var points []types.Point
+	for rows.Next() {
+		var point types.Point
+		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// matchRailLines credits each step of time-ordered points to the rail line
+// nearest its midpoint and returns the lines by distance, longest first,
+// with the total distance of the steps
+// Steps off the network count toward the total but credit no line.
+func matchRailLines(points []types.Point, thresholds RailLineThresholds) ([]RailLineMatch, float64) {
+	distances := make(map[string]float64)
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		p, q := points[i-1], points[i]
+		if q.Timestamp-p.Timestamp > thresholds.MaxStepS {
+			continue
+		}
+		step := spatial.HaversineDistance(p.Lat, p.Lon, q.Lat, q.Lon)
+		total += step
+		if line, _, ok := transit.NearestRailLine((p.Lat+q.Lat)/2, (p.Lon+q.Lon)/2, thresholds.CorridorM); ok {
+			distances[line.Name] += step
+		}
+	}
+
+	var matches []RailLineMatch
+	for line, distance := range distances {
+		if distance < thresholds.MinLineDistanceM {
+			continue
+		}
+		matches = append(matches, RailLineMatch{Line: line, DistanceM: distance, Share: distance / total})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].DistanceM != matches[j].DistanceM {
+			return matches[i].DistanceM > matches[j].DistanceM
+		}
+		return matches[i].Line < matches[j].Line
+	})
+	return matches, total
+}
+
+// railLineLabel returns the line labelling a segment with matches, or "" if
+// no line covers MinShare of it
+func railLineLabel(matches []RailLineMatch, thresholds RailLineThresholds) string {
+	if len(matches) == 0 || matches[0].Share < thresholds.MinShare {
+		return ""
+	}
+	return matches[0].Line
+}
+
+// saveMatches records the rail line matches of segments and labels them
+func (a *RailLineAnalyzer) saveMatches(ctx context.Context, segments []types.SegmentInfo, matches map[int64][]RailLineMatch, thresholds RailLineThresholds) error {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO segment_rail_lines (segment_id, line, distance_m, share, start_time, algo_version)
+		VALUES (?, ?, ?, ?, ?, '`+analysis.Version("rail_lines")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insert.Close()
+
+	for _, seg := range segments {
+		for _, m := range matches[seg.ID] {
+			if _, err := insert.ExecContext(ctx, seg.ID, m.Line, m.DistanceM, m.Share, seg.StartTS); err != nil {
+				return fmt.Errorf("failed to insert rail line of segment %d: %w", seg.ID, err)
+			}
+		}
+		if label := railLineLabel(matches[seg.ID], thresholds); label != "" {
+			if _, err := tx.ExecContext(ctx, `UPDATE segments SET rail_line = ? WHERE id = ?`, label, seg.ID); err != nil {
+				return fmt.Errorf("failed to label segment %d: %w", seg.ID, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("rail_lines", NewRailLineAnalyzer)
+	analysis.RegisterVersion("rail_lines", "v1")
+	analysis.RegisterDependencies("rail_lines", "transport_mode", "flight_detection")
+	analysis.RegisterOutputs("rail_lines",
+		analysis.Output{Table: "segment_rail_lines"},
+		analysis.Output{Table: "segments", Where: "rail_line IS NOT NULL", Set: "rail_line = NULL"},
+	)
+}
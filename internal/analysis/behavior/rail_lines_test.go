@@ -0,0 +1,85 @@
+package behavior
+
+import (
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+)
+
+// Stations on the rail line traces
+var (
+	atBeijingSouth = [2]float64{39.8652, 116.3786}
+	atLangfang     = [2]float64{39.5058, 116.6921}
+	atTianjinWest  = [2]float64{39.1592, 117.1637}
+	atDezhouEast   = [2]float64{37.4466, 116.3604}
+	atJinanWest    = [2]float64{36.6697, 116.8905}
+	atJinanEast    = [2]float64{36.7400, 117.2000}
+	atZiboNorth    = [2]float64{36.8700, 118.0000}
+	atWeifangNorth = [2]float64{36.8000, 119.1000}
+	atQingdaoNorth = [2]float64{36.1676, 120.3736}
+)
+
+// ridePoints returns points moving along stations at speed, one every 60 s
+func ridePoints(speed float64, stations ...[2]float64) []types.Point {
+	var points []types.Point
+	start := int64(0)
+	for i := 1; i < len(stations); i++ {
+		leg := flyPoints(stations[i-1], stations[i], start, speed)
+		points = append(points, leg...)
+		if len(leg) > 0 {
+			start = leg[len(leg)-1].Timestamp
+		}
+	}
+	return points
+}
+
+func TestMatchRailLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []types.Point
+		label  string
+		lines  []string
+	}{
+		{
+			name:   "along one line",
+			points: ridePoints(80, atBeijingSouth, atLangfang, atTianjinWest),
+			label:  "京沪高铁",
+			lines:  []string{"京沪高铁"},
+		},
+		{
+			name:   "changing lines at a junction",
+			points: ridePoints(80, atDezhouEast, atJinanWest, atJinanEast, atZiboNorth, atWeifangNorth, atQingdaoNorth),
+			label:  "济青高铁",
+			lines:  []string{"济青高铁", "京沪高铁"},
+		},
+		{
+			name:   "off the network",
+			points: ridePoints(80, noAirport, [2]float64{35.5, 101.0}),
+			label:  "",
+			lines:  nil,
+		},
+		{
+			name:   "gaps credit no line",
+			points: joinPoints(groundPoints(atBeijingSouth, 0, 0), groundPoints(atJinanWest, 3600, 3600)),
+			label:  "",
+			lines:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, _ := matchRailLines(tt.points, DefaultRailLineThresholds)
+			if label := railLineLabel(matches, DefaultRailLineThresholds); label != tt.label {
+				t.Errorf("label = %q, want %q (matches %+v)", label, tt.label, matches)
+			}
+			if len(matches) != len(tt.lines) {
+				t.Fatalf("got %d lines, want %d: %+v", len(matches), len(tt.lines), matches)
+			}
+			for i, m := range matches {
+				if m.Line != tt.lines[i] {
+					t.Errorf("line %d = %s, want %s", i, m.Line, tt.lines[i])
+				}
+			}
+		})
+	}
+}
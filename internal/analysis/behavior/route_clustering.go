@@ -0,0 +1,470 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Route clustering settings
+const (
+	routeResamplePoints   = 64     // Points per resampled polyline
+	routeMinDistanceM     = 500.0  // Shorter trips are ignored
+	routeEndpointRadiusM  = 1000.0 // Start/end must be this close to a cluster representative
+	routeMaxDTWM          = 300.0  // Maximum mean DTW distance to join a cluster
+	routeMinTrips         = 2      // Minimum trips for a frequent route
+	routeMedoidSampleSize = 50     // Members considered when choosing the medoid
+)
+
+// RouteClusteringAnalyzer clusters trips with similar geometry into frequent routes
+// Skill: 常走路线 (Route Clustering / Frequent Path Mining)
+// Trips are resampled to fixed-length polylines and grouped by leader clustering on
+// DTW distance, with start/end proximity as a cheap pre-filter
+type RouteClusteringAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewRouteClusteringAnalyzer creates a new route clustering analyzer
+func NewRouteClusteringAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &RouteClusteringAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "route_clustering", 1000),
+	}
+}
+
+// routePoint is a point of a resampled polyline in local planar coordinates (meters)
+type routePoint struct {
+	Lat, Lon float64
+	X, Y     float64
+}
+
+// routeTrip holds a trip and its resampled polyline
+type routeTrip struct {
+	ID          int64
+	StartTime   int64
+	EndTime     int64
+	Duration    int64
+	Distance    float64
+	PrimaryMode string
+	Polyline    []routePoint
+}
+
+// routeCluster holds the trips assigned to one route
+type routeCluster struct {
+	Leader  *routeTrip
+	Members []*routeTrip
+}
+
+// Analyze performs route clustering
+// Clusters depend on all trips, so both modes recompute the table
+func (a *RouteClusteringAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[RouteClusteringAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	trips, err := a.loadTrips(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trips: %w", err)
+	}
+
+	log.Printf("[RouteClusteringAnalyzer] Processing %d trips", len(trips))
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(trips)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Resample each trip polyline
+	var routeTrips []*routeTrip
+	failed := 0
+	for i, trip := range trips {
+		polyline, err := a.loadPolyline(ctx, trip)
+		if err != nil {
+			return fmt.Errorf("failed to load trip polyline: %w", err)
+		}
+		if polyline == nil {
+			failed++
+		} else {
+			trips[i].Polyline = polyline
+			routeTrips = append(routeTrips, &trips[i])
+		}
+
+		if (i+1)%a.BatchSize == 0 {
+			if err := a.UpdateTaskProgress(taskID, int64(len(trips)), int64(i+1), int64(failed)); err != nil {
+				return fmt.Errorf("failed to update progress: %w", err)
+			}
+		}
+	}
+
+	clusters := clusterRoutes(routeTrips)
+
+	var frequent []*routeCluster
+	for _, cluster := range clusters {
+		if len(cluster.Members) >= routeMinTrips {
+			frequent = append(frequent, cluster)
+		}
+	}
+
+	if err := a.replaceRoutes(ctx, frequent); err != nil {
+		return fmt.Errorf("failed to write route clusters: %w", err)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(trips)), int64(len(trips)), int64(failed)); err != nil {
+		return fmt.Errorf("failed to update progress: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_trips":     len(trips),
+		"clustered_trips": len(routeTrips),
+		"clusters":        len(clusters),
+		"frequent_routes": len(frequent),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[RouteClusteringAnalyzer] Analysis completed: %d frequent routes from %d trips", len(frequent), len(routeTrips))
+	return nil
+}
+
+// loadTrips loads trips long enough to form a route
+func (a *RouteClusteringAnalyzer) loadTrips(ctx context.Context) ([]routeTrip, error) {
+	query := `
+		SELECT id, start_time, end_time, duration_s, COALESCE(distance_m, 0), COALESCE(primary_mode, '')
+		FROM trips
+		WHERE distance_m >= ?
+		ORDER BY start_time
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query, routeMinDistanceM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []routeTrip
+	for rows.Next() {
+		var trip routeTrip
+		if err := rows.Scan(&trip.ID, &trip.StartTime, &trip.EndTime, &trip.Duration, &trip.Distance, &trip.PrimaryMode); err != nil {
+			return nil, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		trips = append(trips, trip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return trips, nil
+}
+
+// loadPolyline loads the track points of a trip and resamples them
+// Returns nil if the trip has too few points
+func (a *RouteClusteringAnalyzer) loadPolyline(ctx context.Context, trip routeTrip) ([]routePoint, error) {
+	query := `
+		SELECT latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND outlier_flag = 0
+		ORDER BY dataTime
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query, trip.StartTime, trip.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip points: %w", err)
+	}
+	defer rows.Close()
+
+	var lats, lons []float64
+	for rows.Next() {
+		var lat, lon float64
+		if err := rows.Scan(&lat, &lon); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		lats = append(lats, lat)
+		lons = append(lons, lon)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return resamplePolyline(lats, lons, routeResamplePoints), nil
+}
+
+// resamplePolyline resamples a polyline to n points equally spaced by distance
+func resamplePolyline(lats, lons []float64, n int) []routePoint {
+	if len(lats) < 2 || n < 2 {
+		return nil
+	}
+
+	cumulative := make([]float64, len(lats))
+	for i := 1; i < len(lats); i++ {
+		cumulative[i] = cumulative[i-1] + spatial.HaversineDistance(lats[i-1], lons[i-1], lats[i], lons[i])
+	}
+	total := cumulative[len(cumulative)-1]
+	if total <= 0 {
+		return nil
+	}
+
+	result := make([]routePoint, 0, n)
+	j := 0
+	for k := 0; k < n; k++ {
+		target := total * float64(k) / float64(n-1)
+		for j < len(cumulative)-2 && cumulative[j+1] < target {
+			j++
+		}
+		span := cumulative[j+1] - cumulative[j]
+		t := 0.0
+		if span > 0 {
+			t = (target - cumulative[j]) / span
+		}
+		if t > 1 {
+			t = 1
+		}
+		lat := lats[j] + (lats[j+1]-lats[j])*t
+		lon := lons[j] + (lons[j+1]-lons[j])*t
+		result = append(result, routePoint{Lat: lat, Lon: lon})
+	}
+
+	// Equirectangular projection, so polylines of nearby trips share a coordinate system
+	for i := range result {
+		cosLat := math.Cos(result[i].Lat * math.Pi / 180)
+		result[i].X = result[i].Lon * cosLat * spatial.EarthRadiusMeters * math.Pi / 180
+		result[i].Y = result[i].Lat * spatial.EarthRadiusMeters * math.Pi / 180
+	}
+
+	return result
+}
+
+// clusterRoutes groups trips by leader clustering: each trip joins the closest
+// existing cluster within the DTW threshold, otherwise it starts a new cluster
+func clusterRoutes(trips []*routeTrip) []*routeCluster {
+	var clusters []*routeCluster
+	for _, trip := range trips {
+		var best *routeCluster
+		bestDist := routeMaxDTWM
+		for _, cluster := range clusters {
+			leader := cluster.Leader.Polyline
+			if !endpointsNear(trip.Polyline, leader) {
+				continue
+			}
+			if dist := meanDTW(trip.Polyline, leader); dist <= bestDist {
+				best, bestDist = cluster, dist
+			}
+		}
+		if best == nil {
+			best = &routeCluster{Leader: trip}
+			clusters = append(clusters, best)
+		}
+		best.Members = append(best.Members, trip)
+	}
+	return clusters
+}
+
+// endpointsNear checks that two polylines start and end close to each other
+func endpointsNear(a, b []routePoint) bool {
+	first, last := 0, len(a)-1
+	return spatial.HaversineDistance(a[first].Lat, a[first].Lon, b[first].Lat, b[first].Lon) <= routeEndpointRadiusM &&
+		spatial.HaversineDistance(a[last].Lat, a[last].Lon, b[len(b)-1].Lat, b[len(b)-1].Lon) <= routeEndpointRadiusM
+}
+
+// meanDTW computes the dynamic time warping distance of two polylines,
+// normalised by the warping path length (meters)
+func meanDTW(a, b []routePoint) float64 {
+	n, m := len(a), len(b)
+	cost := make([]float64, (n+1)*(m+1))
+	steps := make([]int, (n+1)*(m+1))
+	idx := func(i, j int) int { return i*(m+1) + j }
+
+	for i := range cost {
+		cost[i] = math.Inf(1)
+	}
+	cost[idx(0, 0)] = 0
+
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			d := math.Hypot(a[i-1].X-b[j-1].X, a[i-1].Y-b[j-1].Y)
+			prev := idx(i-1, j-1)
+			if cost[idx(i-1, j)] < cost[prev] {
+				prev = idx(i-1, j)
+			}
+			if cost[idx(i, j-1)] < cost[prev] {
+				prev = idx(i, j-1)
+			}
+			cost[idx(i, j)] = cost[prev] + d
+			steps[idx(i, j)] = steps[prev] + 1
+		}
+	}
+
+	return cost[idx(n, m)] / float64(steps[idx(n, m)])
+}
+
+// medoid returns the member with the lowest total DTW distance to the other members
+// (among a bounded sample) and its mean distance to all members
+func (c *routeCluster) medoid() (*routeTrip, float64) {
+	sample := c.Members
+	if len(sample) > routeMedoidSampleSize {
+		sample = sample[:routeMedoidSampleSize]
+	}
+
+	best := sample[0]
+	bestTotal := math.Inf(1)
+	for _, candidate := range sample {
+		total := 0.0
+		for _, other := range sample {
+			if other != candidate {
+				total += meanDTW(candidate.Polyline, other.Polyline)
+			}
+		}
+		if total < bestTotal {
+			best, bestTotal = candidate, total
+		}
+	}
+
+	meanDist := 0.0
+	for _, member := range c.Members {
+		if member != best {
+			meanDist += meanDTW(best.Polyline, member.Polyline)
+		}
+	}
+	if len(c.Members) > 1 {
+		meanDist /= float64(len(c.Members) - 1)
+	}
+
+	return best, meanDist
+}
+
+// replaceRoutes replaces all route clusters
+func (a *RouteClusteringAnalyzer) replaceRoutes(ctx context.Context, clusters []*routeCluster) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM route_clusters"); err != nil {
+		return fmt.Errorf("failed to clear route clusters: %w", err)
+	}
+
+	insertQuery := `
+		INSERT INTO route_clusters (
+			trip_count, representative_trip_id, polyline,
+			start_lat, start_lon, end_lat, end_lon, primary_mode,
+			avg_distance_m, avg_duration_s, min_duration_s, max_duration_s,
+			avg_speed_kmh, median_speed_kmh, max_speed_kmh, mean_dtw_m,
+			first_used_ts, last_used_ts, trip_ids,
+			algo_version, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("route_clustering") + `', CAST(strftime('%s', 'now') AS INTEGER))
+	`
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	// Most used routes first
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Members) > len(clusters[j].Members)
+	})
+
+	for _, cluster := range clusters {
+		representative, meanDist := cluster.medoid()
+
+		polyline := make([][2]float64, len(representative.Polyline))
+		for i, p := range representative.Polyline {
+			polyline[i] = [2]float64{math.Round(p.Lat*1e6) / 1e6, math.Round(p.Lon*1e6) / 1e6}
+		}
+		polylineJSON, _ := json.Marshal(polyline)
+
+		var totalDistance float64
+		var totalDuration int64
+		minDuration, maxDuration := representative.Duration, representative.Duration
+		firstUsed, lastUsed := representative.StartTime, representative.StartTime
+		modeCounts := make(map[string]int)
+		tripIDs := make([]int64, 0, len(cluster.Members))
+		var speeds []float64
+
+		for _, member := range cluster.Members {
+			totalDistance += member.Distance
+			totalDuration += member.Duration
+			if member.Duration < minDuration {
+				minDuration = member.Duration
+			}
+			if member.Duration > maxDuration {
+				maxDuration = member.Duration
+			}
+			if member.StartTime < firstUsed {
+				firstUsed = member.StartTime
+			}
+			if member.StartTime > lastUsed {
+				lastUsed = member.StartTime
+			}
+			if member.PrimaryMode != "" {
+				modeCounts[member.PrimaryMode]++
+			}
+			if member.Duration > 0 {
+				speeds = append(speeds, member.Distance/float64(member.Duration)*3.6)
+			}
+			tripIDs = append(tripIDs, member.ID)
+		}
+		tripIDsJSON, _ := json.Marshal(tripIDs)
+
+		primaryMode := ""
+		for mode, count := range modeCounts {
+			if primaryMode == "" || count > modeCounts[primaryMode] || (count == modeCounts[primaryMode] && mode < primaryMode) {
+				primaryMode = mode
+			}
+		}
+
+		var avgSpeed, medianSpeed, maxSpeed float64
+		if len(speeds) > 0 {
+			sort.Float64s(speeds)
+			medianSpeed = speeds[percentileIndex(len(speeds), 0.5)]
+			maxSpeed = speeds[len(speeds)-1]
+		}
+		if totalDuration > 0 {
+			avgSpeed = totalDistance / float64(totalDuration) * 3.6
+		}
+
+		n := float64(len(cluster.Members))
+		start := representative.Polyline[0]
+		end := representative.Polyline[len(representative.Polyline)-1]
+
+		_, err := stmt.ExecContext(ctx,
+			len(cluster.Members), representative.ID, string(polylineJSON),
+			start.Lat, start.Lon, end.Lat, end.Lon, primaryMode,
+			totalDistance/n, float64(totalDuration)/n, minDuration, maxDuration,
+			avgSpeed, medianSpeed, maxSpeed, meanDist,
+			firstUsed, lastUsed, string(tripIDsJSON),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert route cluster: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("route_clustering", NewRouteClusteringAnalyzer)
+	analysis.RegisterDependencies("route_clustering", "trip_construction")
+	analysis.RegisterOutputs("route_clustering", analysis.Output{Table: "route_clusters", Replaced: true})
+}
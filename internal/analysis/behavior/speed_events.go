@@ -8,6 +8,8 @@ import (
 	"log"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/database"
 )
 
 // SpeedEventsAnalyzer implements speed event detection
@@ -33,51 +35,16 @@ func (a *SpeedEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing speed events (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM speed_events"); err != nil {
-			return fmt.Errorf("failed to clear speed events: %w", err)
-		}
-		log.Printf("[SpeedEventsAnalyzer] Cleared existing speed events")
-	}
-
-	// Get all CAR segments
-	segmentsQuery := `
-		SELECT
-			id,
-			start_ts,
-			end_ts,
-			province,
-			city,
-			county,
-			town,
-			grid_id
-		FROM segments
-		WHERE mode = 'CAR'
-			AND outlier_flag = 0
-		ORDER BY id
-	`
-
-	rows, err := a.DB.QueryContext(ctx, segmentsQuery)
+	// Count the CAR segments
+	total, err := a.countCarSegments(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to query segments: %w", err)
+		return err
 	}
 
-	var segments []SegmentInfo
-	for rows.Next() {
-		var seg SegmentInfo
-		if err := rows.Scan(&seg.ID, &seg.StartTS, &seg.EndTS, &seg.Province, &seg.City, &seg.County, &seg.Town, &seg.GridID); err != nil {
-			rows.Close()
-			return fmt.Errorf("failed to scan segment: %w", err)
-		}
-		segments = append(segments, seg)
-	}
-	rows.Close()
-
-	log.Printf("[SpeedEventsAnalyzer] Processing %d CAR segments", len(segments))
+	log.Printf("[SpeedEventsAnalyzer] Processing %d CAR segments", total)
 
 	// Update task with total count
-	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), 0, 0); err != nil {
+	if err := a.UpdateTaskProgress(taskID, total, 0, 0); err != nil {
 		return fmt.Errorf("failed to update task progress: %w", err)
 	}
 
@@ -85,61 +52,28 @@ func (a *SpeedEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 	var speedEvents []SpeedEvent
 	processed := 0
 
-	// Default thresholds (should come from threshold_profiles)
-	minEventSpeed := 33.33  // 120 km/h = 33.33 m/s
-	minEventDuration := 60  // 60 seconds
-	allowedGap := 10        // 10 seconds
-
-	for _, seg := range segments {
-		// Get points for this segment
-		pointsQuery := `
-			SELECT
-				id,
-				dataTime,
-				latitude,
-				longitude,
-				speed
-			FROM "一生足迹"
-			WHERE dataTime BETWEEN ? AND ?
-				AND outlier_flag = 0
-			ORDER BY dataTime
-		`
-
-		pointRows, err := a.DB.QueryContext(ctx, pointsQuery, seg.StartTS, seg.EndTS)
-		if err != nil {
-			return fmt.Errorf("failed to query points for segment %d: %w", seg.ID, err)
-		}
-
-		var points []PointData
-		for pointRows.Next() {
-			var point PointData
-			var speed sql.NullFloat64
-			if err := pointRows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &speed); err != nil {
-				pointRows.Close()
-				return fmt.Errorf("failed to scan point: %w", err)
-			}
-			if speed.Valid {
-				point.Speed = speed.Float64
-			}
-			points = append(points, point)
-		}
-		pointRows.Close()
-
-		if len(points) == 0 {
-			continue
-		}
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultSpeedEventThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
 
+	err = a.eachSegmentPoints(ctx, nil, func(seg types.SegmentInfo, points []types.Point) error {
 		// Detect speed events using state machine
-		events := a.detectSpeedEvents(seg, points, minEventSpeed, float64(minEventDuration), float64(allowedGap))
+		events := detectSpeedEvents(seg, points, thresholds)
 		speedEvents = append(speedEvents, events...)
 
 		processed++
 		if processed%100 == 0 {
-			if err := a.UpdateTaskProgress(taskID, int64(len(segments)), int64(processed), 0); err != nil {
+			if err := a.UpdateTaskProgress(taskID, total, int64(processed), 0); err != nil {
 				return fmt.Errorf("failed to update progress: %w", err)
 			}
-			log.Printf("[SpeedEventsAnalyzer] Processed %d/%d segments", processed, len(segments))
+			log.Printf("[SpeedEventsAnalyzer] Processed %d/%d segments", processed, total)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Insert speed events
@@ -149,7 +83,7 @@ func (a *SpeedEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 
 	// Mark task as completed
 	summary := map[string]interface{}{
-		"total_segments": len(segments),
+		"total_segments": total,
 		"processed_segments": processed,
 		"speed_events": len(speedEvents),
 	}
@@ -163,61 +97,181 @@ func (a *SpeedEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 	return nil
 }
 
-// SegmentInfo holds segment information
-type SegmentInfo struct {
-	ID       int64
-	StartTS  int64
-	EndTS    int64
-	Province sql.NullString
-	City     sql.NullString
-	County   sql.NullString
-	Town     sql.NullString
-	GridID   sql.NullString
+// Preview detects the speed events of the CAR segments starting in window
+// without writing them
+func (a *SpeedEventsAnalyzer) Preview(ctx context.Context, window analysis.Window, override json.RawMessage) (*analysis.Preview, error) {
+	thresholds := DefaultSpeedEventThresholds
+	if err := a.PreviewThresholds(ctx, override, &thresholds); err != nil {
+		return nil, err
+	}
+
+	events := []SpeedEvent{}
+	inputs := 0
+	err := a.eachSegmentPoints(ctx, &window, func(seg types.SegmentInfo, points []types.Point) error {
+		inputs += len(points)
+		events = append(events, detectSpeedEvents(seg, points, thresholds)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &analysis.Preview{Thresholds: thresholds, Inputs: inputs, Count: len(events), Results: events}, nil
+}
+
+// countCarSegments counts the CAR segments
+func (a *SpeedEventsAnalyzer) countCarSegments(ctx context.Context) (int64, error) {
+	var count int64
+	if err := a.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM segments WHERE mode = 'CAR'").Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count segments: %w", err)
+	}
+	return count, nil
+}
+
+// eachSegmentPoints calls fn with every CAR segment having points, only
+// those starting in window when not nil, and its non-outlier points in time
+// order
+// The points of all segments come from a single query joining them to the
+// segment boundaries rather than a query per segment. The unary plus keeps
+// SQLite from picking the outlier_flag index, which matches nearly every
+// point, over the dataTime range.
+func (a *SpeedEventsAnalyzer) eachSegmentPoints(ctx context.Context, window *analysis.Window, fn func(seg types.SegmentInfo, points []types.Point) error) error {
+	query := `
+		SELECT
+			s.id,
+			s.start_time,
+			s.end_time,
+			p.id,
+			p.dataTime,
+			p.latitude,
+			p.longitude,
+			` + analysis.EffectiveSpeedExpr + `
+		FROM segments s
+		JOIN "一生足迹" p ON p.dataTime BETWEEN s.start_time AND s.end_time
+			AND +p.outlier_flag = 0
+		WHERE s.mode = 'CAR'
+	`
+	var args []interface{}
+	if window != nil {
+		query += " AND s.start_time >= ? AND s.start_time < ?"
+		args = append(args, window.Start, window.End)
+	}
+	query += " ORDER BY s.id, p.dataTime"
+
+	rows, err := a.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query segment points: %w", err)
+	}
+	defer rows.Close()
+
+	var seg types.SegmentInfo
+	var points []types.Point
+	for rows.Next() {
+		var next types.SegmentInfo
+		var point types.Point
+		var speed sql.NullFloat64
+		if err := rows.Scan(&next.ID, &next.StartTS, &next.EndTS, &point.ID, &point.Timestamp, &point.Lat, &point.Lon, &speed); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		if speed.Valid {
+			point.Speed = speed.Float64
+		}
+		if next.ID != seg.ID && len(points) > 0 {
+			if err := fn(seg, points); err != nil {
+				return err
+			}
+			points = nil
+		}
+		seg = next
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read segment points: %w", err)
+	}
+	if len(points) > 0 {
+		return fn(seg, points)
+	}
+	return nil
 }
 
-// PointData holds point data
-type PointData struct {
-	ID        int64
-	Timestamp int64
-	Lat       float64
-	Lon       float64
-	Speed     float64
+// SpeedEventThresholds defines configurable thresholds for speed event detection
+// Loaded from the "speed_events" section of the active threshold profile
+type SpeedEventThresholds struct {
+	MinEventSpeedMPS  float64 `json:"min_event_speed_mps"`
+	MinEventDurationS float64 `json:"min_event_duration_s"`
+	AllowedGapS       float64 `json:"allowed_gap_s"`
+}
+
+// DefaultSpeedEventThresholds provides default speed event thresholds
+var DefaultSpeedEventThresholds = SpeedEventThresholds{
+	MinEventSpeedMPS:  33.33, // 120 km/h
+	MinEventDurationS: 60,    // 60 seconds
+	AllowedGapS:       10,    // 10 seconds
 }
 
 // SpeedEvent holds speed event data
 type SpeedEvent struct {
-	SegmentID  int64
-	StartTS    int64
-	EndTS      int64
-	DurationS  int64
-	MaxSpeed   float64
-	AvgSpeed   float64
-	PeakTS     int64
-	PeakLat    float64
-	PeakLon    float64
-	Province   string
-	City       string
-	County     string
-	Town       string
-	GridID     string
-	Confidence float64
-	Reasons    []string
+	SegmentID  int64    `json:"segment_id"`
+	StartTS    int64    `json:"start_ts"`
+	EndTS      int64    `json:"end_ts"`
+	DurationS  int64    `json:"duration_s"`
+	MaxSpeed   float64  `json:"max_speed_mps"`
+	AvgSpeed   float64  `json:"avg_speed_mps"`
+	PeakTS     int64    `json:"peak_ts"`
+	PeakLat    float64  `json:"peak_lat"`
+	PeakLon    float64  `json:"peak_lon"`
+	Province   string   `json:"province,omitempty"`
+	City       string   `json:"city,omitempty"`
+	County     string   `json:"county,omitempty"`
+	Town       string   `json:"town,omitempty"`
+	GridID     string   `json:"grid_id,omitempty"`
+	Confidence float64  `json:"confidence"`
+	Reasons    []string `json:"reason_codes"`
 }
 
-// detectSpeedEvents detects speed events using state machine
-func (a *SpeedEventsAnalyzer) detectSpeedEvents(seg SegmentInfo, points []PointData, minSpeed, minDuration, allowedGap float64) []SpeedEvent {
+// detectSpeedEvents detects the speed events of a segment with a state
+// machine over its time-ordered points
+// An event runs while points stay at or above MinEventSpeedMPS, tolerating
+// slower points for up to AllowedGapS, and is kept when it lasts at least
+// MinEventDurationS.
+func detectSpeedEvents(seg types.SegmentInfo, points []types.Point, thresholds SpeedEventThresholds) []SpeedEvent {
 	var events []SpeedEvent
-
-	if len(points) == 0 {
-		return events
-	}
-
 	var currentEvent *SpeedEvent
-	var eventPoints []PointData
+	var eventPoints []types.Point
 	lastHighSpeedTS := int64(0)
 
+	// closeEvent ends the current event at the last high-speed point
+	closeEvent := func() {
+		currentEvent.EndTS = lastHighSpeedTS
+		currentEvent.DurationS = currentEvent.EndTS - currentEvent.StartTS
+
+		if float64(currentEvent.DurationS) >= thresholds.MinEventDurationS {
+			// Calculate average speed
+			totalSpeed := 0.0
+			for _, p := range eventPoints {
+				totalSpeed += p.Speed
+			}
+			currentEvent.AvgSpeed = totalSpeed / float64(len(eventPoints))
+
+			// Set location info
+			currentEvent.Province = seg.Province
+			currentEvent.City = seg.City
+			currentEvent.County = seg.County
+			currentEvent.Town = seg.Town
+			currentEvent.GridID = seg.GridID
+
+			currentEvent.Confidence = speedEventConfidence(currentEvent, eventPoints)
+			currentEvent.Reasons = speedEventReasons(currentEvent, eventPoints)
+
+			events = append(events, *currentEvent)
+		}
+
+		currentEvent = nil
+		eventPoints = nil
+	}
+
 	for _, point := range points {
-		if point.Speed >= minSpeed {
+		if point.Speed >= thresholds.MinEventSpeedMPS {
 			// High speed point
 			if currentEvent == nil {
 				// Start new event
@@ -229,7 +283,7 @@ func (a *SpeedEventsAnalyzer) detectSpeedEvents(seg SegmentInfo, points []PointD
 					PeakLat:   point.Lat,
 					PeakLon:   point.Lon,
 				}
-				eventPoints = []PointData{point}
+				eventPoints = []types.Point{point}
 			} else {
 				// Continue event
 				eventPoints = append(eventPoints, point)
@@ -241,95 +295,22 @@ func (a *SpeedEventsAnalyzer) detectSpeedEvents(seg SegmentInfo, points []PointD
 				}
 			}
 			lastHighSpeedTS = point.Timestamp
-		} else {
-			// Low speed point
-			if currentEvent != nil {
-				gap := point.Timestamp - lastHighSpeedTS
-				if float64(gap) > allowedGap {
-					// End current event
-					currentEvent.EndTS = lastHighSpeedTS
-					currentEvent.DurationS = currentEvent.EndTS - currentEvent.StartTS
-
-					if float64(currentEvent.DurationS) >= minDuration {
-						// Calculate average speed
-						totalSpeed := 0.0
-						for _, p := range eventPoints {
-							totalSpeed += p.Speed
-						}
-						currentEvent.AvgSpeed = totalSpeed / float64(len(eventPoints))
-
-						// Set location info
-						if seg.Province.Valid {
-							currentEvent.Province = seg.Province.String
-						}
-						if seg.City.Valid {
-							currentEvent.City = seg.City.String
-						}
-						if seg.County.Valid {
-							currentEvent.County = seg.County.String
-						}
-						if seg.Town.Valid {
-							currentEvent.Town = seg.Town.String
-						}
-						if seg.GridID.Valid {
-							currentEvent.GridID = seg.GridID.String
-						}
-
-						// Calculate confidence
-						currentEvent.Confidence = a.calculateConfidence(currentEvent, eventPoints)
-						currentEvent.Reasons = a.generateReasons(currentEvent, eventPoints)
-
-						events = append(events, *currentEvent)
-					}
-
-					// Reset
-					currentEvent = nil
-					eventPoints = nil
-				}
-			}
+		} else if currentEvent != nil && float64(point.Timestamp-lastHighSpeedTS) > thresholds.AllowedGapS {
+			// Low speed for longer than the allowed gap
+			closeEvent()
 		}
 	}
 
 	// Handle event at end of segment
 	if currentEvent != nil {
-		currentEvent.EndTS = lastHighSpeedTS
-		currentEvent.DurationS = currentEvent.EndTS - currentEvent.StartTS
-
-		if float64(currentEvent.DurationS) >= minDuration {
-			totalSpeed := 0.0
-			for _, p := range eventPoints {
-				totalSpeed += p.Speed
-			}
-			currentEvent.AvgSpeed = totalSpeed / float64(len(eventPoints))
-
-			if seg.Province.Valid {
-				currentEvent.Province = seg.Province.String
-			}
-			if seg.City.Valid {
-				currentEvent.City = seg.City.String
-			}
-			if seg.County.Valid {
-				currentEvent.County = seg.County.String
-			}
-			if seg.Town.Valid {
-				currentEvent.Town = seg.Town.String
-			}
-			if seg.GridID.Valid {
-				currentEvent.GridID = seg.GridID.String
-			}
-
-			currentEvent.Confidence = a.calculateConfidence(currentEvent, eventPoints)
-			currentEvent.Reasons = a.generateReasons(currentEvent, eventPoints)
-
-			events = append(events, *currentEvent)
-		}
+		closeEvent()
 	}
 
 	return events
 }
 
-// calculateConfidence calculates confidence score for speed event
-func (a *SpeedEventsAnalyzer) calculateConfidence(event *SpeedEvent, points []PointData) float64 {
+// speedEventConfidence calculates confidence score for speed event
+func speedEventConfidence(event *SpeedEvent, points []types.Point) float64 {
 	confidence := 1.0
 
 	// Reduce confidence if duration is short
@@ -350,8 +331,8 @@ func (a *SpeedEventsAnalyzer) calculateConfidence(event *SpeedEvent, points []Po
 	return confidence
 }
 
-// generateReasons generates reason codes for speed event
-func (a *SpeedEventsAnalyzer) generateReasons(event *SpeedEvent, points []PointData) []string {
+// speedEventReasons generates reason codes for speed event
+func speedEventReasons(event *SpeedEvent, points []types.Point) []string {
 	var reasons []string
 
 	if event.MaxSpeed >= 50 { // 180 km/h
@@ -387,30 +368,25 @@ func (a *SpeedEventsAnalyzer) insertSpeedEvents(ctx context.Context, events []Sp
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	insertQuery := `
+	batch := database.NewBatchInserter(tx, `
 		INSERT INTO speed_events (
 			segment_id, start_ts, end_ts, duration_s, max_speed_mps, avg_speed_mps,
 			peak_ts, peak_lat, peak_lon, province, city, county, town, grid_id,
 			confidence, reason_codes, algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
-	`
-
-	stmt, err := tx.PrepareContext(ctx, insertQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
+		)`, `(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("speed_events")+`', CURRENT_TIMESTAMP)`,
+		0)
+	defer batch.Close()
 
 	for _, event := range events {
 		reasonsJSON, _ := json.Marshal(event.Reasons)
 
-		_, err := stmt.ExecContext(ctx,
+		err := batch.Add(ctx,
 			event.SegmentID,
 			event.StartTS,
 			event.EndTS,
@@ -432,6 +408,9 @@ func (a *SpeedEventsAnalyzer) insertSpeedEvents(ctx context.Context, events []Sp
 			return fmt.Errorf("failed to insert speed event: %w", err)
 		}
 	}
+	if err := batch.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to insert speed events: %w", err)
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -444,4 +423,6 @@ func (a *SpeedEventsAnalyzer) insertSpeedEvents(ctx context.Context, events []Sp
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("speed_events", NewSpeedEventsAnalyzer)
+	analysis.RegisterDependencies("speed_events", "transport_mode", "computed_speed")
+	analysis.RegisterOutputs("speed_events", analysis.Output{Table: "speed_events"})
 }
@@ -0,0 +1,110 @@
+package behavior
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+)
+
+// speedRun returns points from start to end, one every step seconds, all at speed
+func speedRun(start, end, step int64, speed float64) []types.Point {
+	var points []types.Point
+	for ts := start; ts <= end; ts += step {
+		points = append(points, types.Point{ID: ts, Timestamp: ts, Speed: speed})
+	}
+	return points
+}
+
+func joinPoints(runs ...[]types.Point) []types.Point {
+	var points []types.Point
+	for _, run := range runs {
+		points = append(points, run...)
+	}
+	return points
+}
+
+func TestDetectSpeedEvents(t *testing.T) {
+	thresholds := SpeedEventThresholds{MinEventSpeedMPS: 30, MinEventDurationS: 60, AllowedGapS: 10}
+	type span struct{ start, end int64 }
+	tests := []struct {
+		name   string
+		points []types.Point
+		want   []span
+	}{
+		{
+			name:   "sustained high speed",
+			points: speedRun(0, 120, 10, 35),
+			want:   []span{{0, 120}},
+		},
+		{
+			name:   "too short",
+			points: speedRun(0, 50, 10, 35),
+			want:   nil,
+		},
+		{
+			name:   "never fast enough",
+			points: speedRun(0, 300, 10, 29.9),
+			want:   nil,
+		},
+		{
+			name:   "dip within the allowed gap",
+			points: joinPoints(speedRun(0, 60, 10, 35), speedRun(65, 65, 1, 20), speedRun(70, 130, 10, 35)),
+			want:   []span{{0, 130}},
+		},
+		{
+			name:   "dip longer than the allowed gap splits the event",
+			points: joinPoints(speedRun(0, 70, 10, 35), speedRun(80, 90, 10, 20), speedRun(100, 170, 10, 35)),
+			want:   []span{{0, 70}, {100, 170}},
+		},
+		{
+			name:   "event ends at the last fast point",
+			points: joinPoints(speedRun(0, 40, 10, 10), speedRun(50, 120, 10, 35), speedRun(130, 200, 10, 10)),
+			want:   []span{{50, 120}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []span
+			for _, event := range detectSpeedEvents(types.SegmentInfo{ID: 7}, tt.points, thresholds) {
+				got = append(got, span{event.StartTS, event.EndTS})
+				if event.SegmentID != 7 || event.DurationS != event.EndTS-event.StartTS {
+					t.Errorf("event %+v: wrong segment or duration", event)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectSpeedEventsPeak(t *testing.T) {
+	thresholds := SpeedEventThresholds{MinEventSpeedMPS: 30, MinEventDurationS: 60, AllowedGapS: 10}
+	points := speedRun(0, 300, 10, 42)
+	points[12].Speed, points[12].Lat, points[12].Lon = 55, 31.2, 121.5
+	seg := types.SegmentInfo{ID: 1, Province: "上海市", City: "上海市"}
+
+	events := detectSpeedEvents(seg, points, thresholds)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.MaxSpeed != 55 || event.PeakTS != 120 || event.PeakLat != 31.2 || event.PeakLon != 121.5 {
+		t.Errorf("peak = %.1f m/s at %d (%.1f, %.1f), want 55 m/s at 120 (31.2, 121.5)", event.MaxSpeed, event.PeakTS, event.PeakLat, event.PeakLon)
+	}
+	if want := (42*30 + 55) / 31.0; math.Abs(event.AvgSpeed-want) > 1e-9 {
+		t.Errorf("avg speed = %.3f, want %.3f", event.AvgSpeed, want)
+	}
+	if event.Province != "上海市" || event.City != "上海市" {
+		t.Errorf("location = %s %s, want the segment's", event.Province, event.City)
+	}
+	if want := []string{"VERY_HIGH_SPEED", "LONG_DURATION", "MANY_POINTS"}; !reflect.DeepEqual(event.Reasons, want) {
+		t.Errorf("reasons = %v, want %v", event.Reasons, want)
+	}
+	if event.Confidence != 1 {
+		t.Errorf("confidence = %.2f, want 1", event.Confidence)
+	}
+}
@@ -34,14 +34,6 @@ func (a *StreakDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing streaks (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM streaks"); err != nil {
-			return fmt.Errorf("failed to clear streaks: %w", err)
-		}
-		log.Printf("[StreakDetectionAnalyzer] Cleared existing streaks")
-	}
-
 	// Get daily activity statistics
 	dailyStatsQuery := `
 		SELECT
@@ -223,7 +215,7 @@ func (a *StreakDetectionAnalyzer) insertStreaks(ctx context.Context, streaks []S
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -233,7 +225,7 @@ func (a *StreakDetectionAnalyzer) insertStreaks(ctx context.Context, streaks []S
 		INSERT INTO streaks (
 			start_date, end_date, days_count, total_distance_m, total_duration_s,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, '` + analysis.Version("streak_detection") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -266,4 +258,6 @@ func (a *StreakDetectionAnalyzer) insertStreaks(ctx context.Context, streaks []S
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("streak_detection", NewStreakDetectionAnalyzer)
+	analysis.RegisterDependencies("streak_detection", "outlier_detection")
+	analysis.RegisterOutputs("streak_detection", analysis.Output{Table: "streaks"})
 }
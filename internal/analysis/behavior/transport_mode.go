@@ -9,19 +9,69 @@ import (
 	"math"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // TransportModeAnalyzer implements transport mode classification
 // Skill: 交通方式识别 (Transport Mode Classification)
-// Classifies trajectory segments by transport mode based on speed
+// Splits the trajectory into runs by speed, joins them into segments and
+// scores each segment's mode from its speed, acceleration, stops, heading
+// changes, nearby stations and airports and the previous segment's mode
 type TransportModeAnalyzer struct {
 	*analysis.IncrementalAnalyzer
+	Thresholds TransportModeThresholds
+}
+
+// TransportModeThresholds defines configurable speed cutoffs for transport mode classification
+// Loaded from the "transport_mode" section of the active threshold profile
+type TransportModeThresholds struct {
+	WalkMaxSpeedMPS     float64 `json:"walk_max_speed_mps"`
+	BikeMaxSpeedMPS     float64 `json:"bike_max_speed_mps"`
+	CarMaxSpeedMPS      float64 `json:"car_max_speed_mps"`
+	TrainMaxSpeedMPS    float64 `json:"train_max_speed_mps"`
+	MinSegmentDurationS int64   `json:"min_segment_duration_s"`
+	// CalibrationMaxFactor bounds how far mode corrections move each speed
+	// cutoff, as a factor of the profile value; 1 or less turns calibration off
+	CalibrationMaxFactor float64 `json:"calibration_max_factor"`
+
+	// Signals weighing in besides speed
+	MaxStopDurationS     int64   `json:"max_stop_duration_s"`     // Slow runs up to this long inside a vehicle segment are stops
+	SmoothAccelMPS2      float64 `json:"smooth_accel_mps2"`       // Mean acceleration below it is smooth (rail, air)
+	JerkyAccelMPS2       float64 `json:"jerky_accel_mps2"`        // Mean acceleration above it is jerky (road traffic)
+	FrequentStopsPer10Km float64 `json:"frequent_stops_per_10km"` // Stop rate of road traffic
+	WindingDegPerKm      float64 `json:"winding_deg_per_km"`      // Heading change rate of streets
+	StraightDegPerKm     float64 `json:"straight_deg_per_km"`     // Heading change rate of rail lines and flights
+	StationRadiusM       float64 `json:"station_radius_m"`        // Segment ends this close to a railway station favour trains
+	AirportRadiusM       float64 `json:"airport_radius_m"`        // Segment ends this close to an airport favour flights
+	ContinuityGapS       int64   `json:"continuity_gap_s"`        // Segments closer than this favour the previous segment's mode
+}
+
+// DefaultTransportModeThresholds provides default transport mode thresholds
+var DefaultTransportModeThresholds = TransportModeThresholds{
+	WalkMaxSpeedMPS:      2.0,  // 7.2 km/h
+	BikeMaxSpeedMPS:      8.0,  // 28.8 km/h
+	CarMaxSpeedMPS:       40.0, // 144 km/h
+	TrainMaxSpeedMPS:     60.0, // 216 km/h
+	MinSegmentDurationS:  10,
+	CalibrationMaxFactor: 1.5,
+	MaxStopDurationS:     120,
+	SmoothAccelMPS2:      0.15,
+	JerkyAccelMPS2:       0.5,
+	FrequentStopsPer10Km: 2,
+	WindingDegPerKm:      90,
+	StraightDegPerKm:     15,
+	StationRadiusM:       1500,
+	AirportRadiusM:       5000,
+	ContinuityGapS:       600,
 }
 
 // NewTransportModeAnalyzer creates a new transport mode analyzer
 func NewTransportModeAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &TransportModeAnalyzer{
-		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "transport_mode", 1000),
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "transport_mode", 5000),
+		Thresholds:          DefaultTransportModeThresholds,
 	}
 }
 
@@ -34,34 +84,210 @@ func (a *TransportModeAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing segments (full recompute)
-	if mode == "full" {
-		// Delete dependent rows first to avoid foreign key constraint violations
-		// Order matters: delete child tables before parent tables
-		// Ignore errors for non-existent tables (they may not be created yet)
-		tablesToClear := []string{"speed_events", "render_segments_cache", "road_overlap_stats"}
-		for _, table := range tablesToClear {
-			if _, err := a.DB.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", table)); err != nil {
-				// Log warning but continue if table doesn't exist
-				log.Printf("[TransportModeAnalyzer] Warning: failed to clear %s: %v (table may not exist yet)", table, err)
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultTransportModeThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// Corrected segments calibrate the speed cutoffs and keep their mode
+	corrections, err := a.loadModeCorrections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load mode corrections: %w", err)
+	}
+	a.Thresholds = calibrateThresholds(a.Thresholds, corrections)
+
+	// A full recompute starts from an empty segments table (see the outputs registered in init)
+	// In incremental mode, only the trailing segment is re-opened so it can absorb new points
+	var sinceTS int64
+	var reopenedSegmentID int64
+	if mode != "full" {
+		reopenedSegmentID, sinceTS, err = a.reopenTrailingSegment(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to re-open trailing segment: %w", err)
+		}
+		if reopenedSegmentID > 0 {
+			log.Printf("[TransportModeAnalyzer] Re-opened trailing segment %d, processing points from %d", reopenedSegmentID, sinceTS)
+		}
+	}
+
+	// Count points so progress can be reported while streaming
+	var totalPoints int64
+	if err := a.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM "一生足迹" WHERE outlier_flag = 0 AND dataTime >= ?
+	`, sinceTS).Scan(&totalPoints); err != nil {
+		return fmt.Errorf("failed to count points: %w", err)
+	}
+
+	if totalPoints == 0 {
+		log.Printf("[TransportModeAnalyzer] No points to process")
+		return a.MarkTaskAsCompleted(taskID, `{"segments": 0}`)
+	}
+
+	log.Printf("[TransportModeAnalyzer] Processing %d points in batches of %d", totalPoints, a.BatchSize)
+
+	// Update task with total count
+	if err := a.UpdateTaskProgress(taskID, totalPoints, 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	// Stream points in time-ordered batches; the classifier carries the open segment across batch boundaries
+	classifier := newModeClassifier(a.Thresholds)
+	if reopenedSegmentID > 0 {
+		if err := a.seedContinuity(ctx, classifier); err != nil {
+			return fmt.Errorf("failed to load previous segment: %w", err)
+		}
+	}
+	processed := int64(0)
+	segmentCount := 0
+	cursorTS, cursorID := sinceTS, int64(0)
+	for {
+		points, err := a.loadPointBatch(ctx, cursorTS, cursorID, a.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(points) == 0 {
+			break
+		}
+
+		var segments []TransportSegment
+		for _, point := range points {
+			segments = append(segments, classifier.add(point)...)
+		}
+
+		// Insert segments finalized in this batch
+		applyModeCorrections(segments, corrections)
+		if err := a.insertSegments(ctx, segments); err != nil {
+			return fmt.Errorf("failed to insert segments: %w", err)
+		}
+		segmentCount += len(segments)
+
+		last := points[len(points)-1]
+		cursorTS, cursorID = last.Timestamp, last.ID
+		processed += int64(len(points))
+		if err := a.UpdateTaskProgress(taskID, totalPoints, processed, 0); err != nil {
+			return fmt.Errorf("failed to update task progress: %w", err)
+		}
+
+		if len(points) < a.BatchSize {
+			break
+		}
+	}
+
+	// Finalize the trailing open segments
+	segments := classifier.flush()
+	applyModeCorrections(segments, corrections)
+	if err := a.insertSegments(ctx, segments); err != nil {
+		return fmt.Errorf("failed to insert segments: %w", err)
+	}
+	segmentCount += len(segments)
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"mode":                mode,
+		"total_points":        processed,
+		"segments":            segmentCount,
+		"reopened_segment_id": reopenedSegmentID,
+		"corrections":         len(corrections),
+		"thresholds":          a.Thresholds,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[TransportModeAnalyzer] Analysis completed: %d points processed, %d segments created", processed, segmentCount)
+	return nil
+}
+
+// Preview classifies the points of window into segments without writing them
+func (a *TransportModeAnalyzer) Preview(ctx context.Context, window analysis.Window, override json.RawMessage) (*analysis.Preview, error) {
+	thresholds := DefaultTransportModeThresholds
+	if err := a.PreviewThresholds(ctx, override, &thresholds); err != nil {
+		return nil, err
+	}
+	corrections, err := a.loadModeCorrections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load mode corrections: %w", err)
+	}
+	thresholds = calibrateThresholds(thresholds, corrections)
+
+	classifier := newModeClassifier(thresholds)
+	segments := []TransportSegment{}
+	inputs := 0
+	cursorTS, cursorID := window.Start, int64(0)
+	for done := false; !done; {
+		points, err := a.loadPointBatch(ctx, cursorTS, cursorID, a.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+		done = len(points) < a.BatchSize
+		for _, point := range points {
+			if point.Timestamp >= window.End {
+				done = true
+				break
 			}
+			segments = append(segments, classifier.add(point)...)
+			inputs++
 		}
+		if len(points) > 0 {
+			last := points[len(points)-1]
+			cursorTS, cursorID = last.Timestamp, last.ID
+		}
+	}
+	segments = append(segments, classifier.flush()...)
+	applyModeCorrections(segments, corrections)
 
-		// Delete segments table (this one must succeed)
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM segments"); err != nil {
-			return fmt.Errorf("failed to clear segments: %w", err)
+	return &analysis.Preview{Thresholds: thresholds, Inputs: inputs, Count: len(segments), Results: segments}, nil
+}
+
+// reopenTrailingSegment deletes the most recent segment (and only its dependent rows)
+// so that it can be re-classified together with newly arrived points
+// Returns the deleted segment ID and its start time, or zeros if there are no segments
+func (a *TransportModeAnalyzer) reopenTrailingSegment(ctx context.Context) (int64, int64, error) {
+	var segmentID, startTime int64
+	err := a.DB.QueryRowContext(ctx, `
+		SELECT id, start_time FROM segments
+		ORDER BY end_time DESC, id DESC
+		LIMIT 1
+	`).Scan(&segmentID, &startTime)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query trailing segment: %w", err)
+	}
+
+	err = a.Transaction(func(tx *sql.Tx) error {
+		for _, table := range []string{"speed_events", "render_segments_cache", "segment_polylines", "matched_segments", "road_overlap_stats"} {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE segment_id = ?", table), segmentID); err != nil {
+				// Table may not exist yet
+				log.Printf("[TransportModeAnalyzer] Warning: failed to clear %s for segment %d: %v", table, segmentID, err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM segments WHERE id = ?", segmentID); err != nil {
+			return fmt.Errorf("failed to delete segment %d: %w", segmentID, err)
 		}
-		log.Printf("[TransportModeAnalyzer] Cleared existing segments and dependent tables")
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
 	}
 
-	// Get all track points ordered by time
+	return segmentID, startTime, nil
+}
+
+// loadPointBatch loads the next batch of non-outlier track points ordered by (dataTime, id)
+// Keyset pagination after (afterTS, afterID) keeps each query bounded regardless of table size
+func (a *TransportModeAnalyzer) loadPointBatch(ctx context.Context, afterTS, afterID int64, limit int) ([]types.Point, error) {
 	pointsQuery := `
 		SELECT
 			id,
 			dataTime,
 			latitude,
 			longitude,
-			speed,
+			`+analysis.EffectiveSpeedExpr+`,
 			province,
 			city,
 			county,
@@ -69,248 +295,355 @@ func (a *TransportModeAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 			grid_id
 		FROM "一生足迹"
 		WHERE outlier_flag = 0
-		ORDER BY dataTime
+			AND (dataTime > ? OR (dataTime = ? AND id > ?))
+		ORDER BY dataTime, id
+		LIMIT ?
 	`
 
-	rows, err := a.DB.QueryContext(ctx, pointsQuery)
+	// IDs are positive, so afterID = 0 includes all points at exactly afterTS
+	rows, err := a.DB.QueryContext(ctx, pointsQuery, afterTS, afterTS, afterID, limit)
 	if err != nil {
-		return fmt.Errorf("failed to query points: %w", err)
+		return nil, fmt.Errorf("failed to query points: %w", err)
 	}
 	defer rows.Close()
 
-	var points []TrackPoint
+	points := make([]types.Point, 0, limit)
 	for rows.Next() {
-		var point TrackPoint
+		var point types.Point
 		var speed sql.NullFloat64
 		var province, city, county, town, gridID sql.NullString
 
 		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon,
 			&speed, &province, &city, &county, &town, &gridID); err != nil {
-			return fmt.Errorf("failed to scan point: %w", err)
+			return nil, fmt.Errorf("failed to scan point: %w", err)
 		}
 
-		if speed.Valid {
-			point.Speed = speed.Float64
-		}
-		if province.Valid {
-			point.Province = province.String
-		}
-		if city.Valid {
-			point.City = city.String
-		}
-		if county.Valid {
-			point.County = county.String
-		}
-		if town.Valid {
-			point.Town = town.String
-		}
-		if gridID.Valid {
-			point.GridID = gridID.String
-		}
+		point.Speed = speed.Float64
+		point.Province = province.String
+		point.City = city.String
+		point.County = county.String
+		point.Town = town.String
+		point.GridID = gridID.String
 
 		points = append(points, point)
 	}
 
-	if len(points) == 0 {
-		log.Printf("[TransportModeAnalyzer] No points to process")
-		return a.MarkTaskAsCompleted(taskID, `{"segments": 0}`)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating points: %w", err)
 	}
 
-	log.Printf("[TransportModeAnalyzer] Processing %d points", len(points))
+	return points, nil
+}
 
-	// Update task with total count
-	if err := a.UpdateTaskProgress(taskID, int64(len(points)), 0, 0); err != nil {
-		return fmt.Errorf("failed to update task progress: %w", err)
-	}
+// TransportSegment holds segment data for transport mode classification
+type TransportSegment struct {
+	Mode         string             `json:"mode"`
+	StartTime    int64              `json:"start_time"`
+	EndTime      int64              `json:"end_time"`
+	StartPointID int64              `json:"start_point_id"`
+	EndPointID   int64              `json:"end_point_id"`
+	PointCount   int                `json:"point_count"`
+	DistanceM    float64            `json:"distance_m"`
+	DurationS    int64              `json:"duration_s"`
+	AvgSpeedKmh  float64            `json:"avg_speed_kmh"`
+	MaxSpeedKmh  float64            `json:"max_speed_kmh"`
+	Confidence   float64            `json:"confidence"` // Probability of the mode
+	ModeProbs    map[string]float64 `json:"mode_probs"` // Probability of each classified mode
+	Signals      []string           `json:"signals"`    // Signals besides speed that weighed in
+	ReasonCodes  string             `json:"-"`          // JSON array
+	Metadata     string             `json:"-"`          // JSON object
+	features     legFeatures
+}
 
-	// Classify segments by transport mode
-	segments := a.classifySegments(points)
+// segmentBuilder groups a time-ordered point stream into runs of points of
+// the same speed class, split at gaps longer than maxGapS
+// Only running aggregates of the open run are kept, so memory use is constant.
+type segmentBuilder struct {
+	maxGapS     int64
+	current     *TransportSegment
+	lastPoint   types.Point
+	hasLast     bool
+	lastBearing float64
+	hasBearing  bool
+}
 
-	// Insert segments
-	if err := a.insertSegments(ctx, segments); err != nil {
-		return fmt.Errorf("failed to insert segments: %w", err)
+// add appends a point classified as mode and returns the run finalized by a
+// mode change or gap, if any
+// Runs never span a gap, so a flight flown without fixes does not become
+// one long walk between the points around it.
+func (b *segmentBuilder) add(point types.Point, mode string) *TransportSegment {
+	var finalized *TransportSegment
+	if b.current != nil && (mode != b.current.Mode || b.maxGapS > 0 && point.Timestamp-b.lastPoint.Timestamp > b.maxGapS) {
+		finalized = b.finalize()
 	}
 
-	// Mark task as completed
-	summary := map[string]interface{}{
-		"total_points": len(points),
-		"segments":     len(segments),
+	speedKmh := point.Speed * 3.6 // Convert m/s to km/h
+	if b.current == nil {
+		// Start new run
+		b.current = &TransportSegment{
+			Mode:         mode,
+			StartTime:    point.Timestamp,
+			StartPointID: point.ID,
+			MaxSpeedKmh:  speedKmh,
+		}
+		b.current.features.startLat, b.current.features.startLon = point.Lat, point.Lon
+	} else {
+		// Distance between consecutive points within the run
+		b.current.DistanceM += haversineDistance(b.lastPoint.Lat, b.lastPoint.Lon, point.Lat, point.Lon)
 	}
-	summaryJSON, _ := json.Marshal(summary)
 
-	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
-		return fmt.Errorf("failed to mark task as completed: %w", err)
+	b.current.PointCount++
+	b.current.EndTime = point.Timestamp
+	b.current.EndPointID = point.ID
+	if speedKmh > b.current.MaxSpeedKmh {
+		b.current.MaxSpeedKmh = speedKmh
 	}
+	b.addSignals(point)
+	b.lastPoint, b.hasLast = point, true
 
-	log.Printf("[TransportModeAnalyzer] Analysis completed: %d points processed, %d segments created", len(points), len(segments))
-	return nil
+	return finalized
 }
 
-// TrackPoint holds track point data
-type TrackPoint struct {
-	ID        int64
-	Timestamp int64
-	Lat       float64
-	Lon       float64
-	Speed     float64
-	Province  string
-	City      string
-	County    string
-	Town      string
-	GridID    string
-}
+// addSignals accumulates the motion signals of a point into the open run
+// Acceleration and heading change come from the previous point, even one of
+// the previous run, unless too long ago to be related.
+func (b *segmentBuilder) addSignals(point types.Point) {
+	f := &b.current.features
+	f.speedSumKmh += point.Speed * 3.6
+	if point.Speed >= stopSpeedMPS {
+		f.movingSpeedSum += point.Speed
+		f.movingPoints++
+	}
+	f.endLat, f.endLon = point.Lat, point.Lon
 
-// TransportSegment holds segment data for transport mode classification
-type TransportSegment struct {
-	Mode          string
-	StartTime     int64
-	EndTime       int64
-	StartPointID  int64
-	EndPointID    int64
-	PointCount    int
-	DistanceM     float64
-	DurationS     int64
-	AvgSpeedKmh   float64
-	MaxSpeedKmh   float64
-	Confidence    float64
-	ReasonCodes   string // JSON array
-	Metadata      string // JSON object
+	if !b.hasLast {
+		return
+	}
+	dt := point.Timestamp - b.lastPoint.Timestamp
+	if dt <= 0 || dt > maxSignalGapS {
+		b.hasBearing = false
+		return
+	}
+	f.accelSum += math.Abs(point.Speed-b.lastPoint.Speed) / float64(dt)
+	f.accelCount++
+
+	// Bearings between points closer than a few meters are mostly GPS noise
+	if haversineDistance(b.lastPoint.Lat, b.lastPoint.Lon, point.Lat, point.Lon) < headingMinStepM {
+		return
+	}
+	bearing := spatial.Bearing(b.lastPoint.Lat, b.lastPoint.Lon, point.Lat, point.Lon)
+	if b.hasBearing {
+		f.headingChangeSum += math.Abs(spatial.AngularDifferenceDegrees(b.lastBearing, bearing))
+	}
+	b.lastBearing, b.hasBearing = bearing, true
 }
 
-// classifySegments classifies points into transport mode segments
-func (a *TransportModeAnalyzer) classifySegments(points []TrackPoint) []TransportSegment {
-	if len(points) == 0 {
+// flush finalizes the open run at the end of the stream
+func (b *segmentBuilder) flush() *TransportSegment {
+	if b.current == nil {
 		return nil
 	}
+	return b.finalize()
+}
 
-	var segments []TransportSegment
-	var currentSegment *TransportSegment
-	var segmentPoints []TrackPoint
-
-	for i, point := range points {
-		mode := a.classifyMode(point.Speed)
-
-		if currentSegment == nil {
-			// Start new segment
-			currentSegment = &TransportSegment{
-				Mode:         mode,
-				StartTime:    point.Timestamp,
-				StartPointID: point.ID,
-				MaxSpeedKmh:  point.Speed * 3.6, // Convert m/s to km/h
-				Confidence:   0.8,                // Default confidence
-			}
-			segmentPoints = []TrackPoint{point}
-		} else if mode != currentSegment.Mode || i == len(points)-1 {
-			// Mode changed or last point - end current segment
-			if i == len(points)-1 && mode == currentSegment.Mode {
-				segmentPoints = append(segmentPoints, point)
-			}
-
-			// Finalize segment
-			lastPoint := segmentPoints[len(segmentPoints)-1]
-			currentSegment.EndTime = lastPoint.Timestamp
-			currentSegment.EndPointID = lastPoint.ID
-			currentSegment.PointCount = len(segmentPoints)
-			currentSegment.DurationS = currentSegment.EndTime - currentSegment.StartTime
-
-			// Calculate distance and speeds
-			totalSpeed := 0.0
-			totalDistance := 0.0
-			for j, p := range segmentPoints {
-				speedKmh := p.Speed * 3.6
-				totalSpeed += speedKmh
-				if speedKmh > currentSegment.MaxSpeedKmh {
-					currentSegment.MaxSpeedKmh = speedKmh
-				}
-				// Calculate distance between consecutive points
-				if j > 0 {
-					prev := segmentPoints[j-1]
-					dist := haversineDistance(prev.Lat, prev.Lon, p.Lat, p.Lon)
-					totalDistance += dist
-				}
-			}
-			currentSegment.AvgSpeedKmh = totalSpeed / float64(len(segmentPoints))
-			currentSegment.DistanceM = totalDistance
-
-			// Set reason codes and metadata
-			currentSegment.ReasonCodes = "[]" // Empty JSON array for now
-			currentSegment.Metadata = "{}"    // Empty JSON object for now
+// finalize closes the open run, however short; joining runs into segments
+// and dropping short ones is left to the mode classifier
+func (b *segmentBuilder) finalize() *TransportSegment {
+	seg := b.current
+	b.current = nil
 
-			// Only add segments with duration > 10 seconds
-			if currentSegment.DurationS >= 10 {
-				segments = append(segments, *currentSegment)
-			}
+	seg.DurationS = seg.EndTime - seg.StartTime
+	seg.AvgSpeedKmh = seg.features.speedSumKmh / float64(seg.PointCount)
+	return seg
+}
 
-			// Start new segment if not last point
-			if i < len(points)-1 {
-				currentSegment = &TransportSegment{
-					Mode:         mode,
-					StartTime:    point.Timestamp,
-					StartPointID: point.ID,
-					MaxSpeedKmh:  point.Speed * 3.6,
-					Confidence:   0.8,
-				}
-				segmentPoints = []TrackPoint{point}
-			}
-		} else {
-			// Continue current segment
-			segmentPoints = append(segmentPoints, point)
-		}
+// classifySegments groups time-ordered points into transport mode segments,
+// as the analyzer does while streaming them in batches
+func classifySegments(points []types.Point, thresholds TransportModeThresholds) []TransportSegment {
+	classifier := newModeClassifier(thresholds)
+	var segments []TransportSegment
+	for _, point := range points {
+		segments = append(segments, classifier.add(point)...)
 	}
-
-	return segments
+	return append(segments, classifier.flush()...)
 }
 
-// classifyMode classifies transport mode based on speed
-func (a *TransportModeAnalyzer) classifyMode(speed float64) string {
-	// Speed thresholds (m/s):
-	// WALK: 0-2 m/s (0-7.2 km/h)
-	// BIKE: 2-8 m/s (7.2-28.8 km/h)
-	// CAR: 8-40 m/s (28.8-144 km/h)
-	// TRAIN: 40-60 m/s (144-216 km/h)
-	// PLANE: >60 m/s (>216 km/h)
-
-	if speed < 2.0 {
+// classifyMode classifies transport mode based on speed (m/s)
+// Cutoffs come from the threshold profile, defaults:
+// WALK: 0-2 m/s (0-7.2 km/h)
+// BIKE: 2-8 m/s (7.2-28.8 km/h)
+// CAR: 8-40 m/s (28.8-144 km/h)
+// TRAIN: 40-60 m/s (144-216 km/h)
+// PLANE: >60 m/s (>216 km/h)
+func classifyMode(speed float64, thresholds TransportModeThresholds) string {
+	if speed < thresholds.WalkMaxSpeedMPS {
 		return "WALK"
-	} else if speed < 8.0 {
+	} else if speed < thresholds.BikeMaxSpeedMPS {
 		return "BIKE"
-	} else if speed < 40.0 {
+	} else if speed < thresholds.CarMaxSpeedMPS {
 		return "CAR"
-	} else if speed < 60.0 {
+	} else if speed < thresholds.TrainMaxSpeedMPS {
 		return "TRAIN"
 	} else {
 		return "PLANE"
 	}
 }
 
+// classifiedModes lists the modes classifyMode returns, slowest first
+var classifiedModes = []string{"WALK", "BIKE", "CAR", "TRAIN", "PLANE"}
+
+// modeRank returns the position of mode in classifiedModes, -1 for other modes
+func modeRank(mode string) int {
+	for i, m := range classifiedModes {
+		if m == mode {
+			return i
+		}
+	}
+	return -1
+}
+
+// ModeCorrection is a manual correction of the mode of a segment's time span
+type ModeCorrection struct {
+	StartTime   int64
+	EndTime     int64
+	Mode        string
+	AvgSpeedMPS float64 // Average speed of the corrected segment
+}
+
+// reasonUserCorrected marks segments whose mode was corrected by hand
+const reasonUserCorrected = `["USER_CORRECTED"]`
+
+// calibrationMargin is how far past a corrected segment's speed a cutoff
+// moves when only one side of the cutoff has corrections
+const calibrationMargin = 1.1
+
+// loadModeCorrections loads the mode corrections, oldest first
+func (a *TransportModeAnalyzer) loadModeCorrections(ctx context.Context) ([]ModeCorrection, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT start_time, end_time, mode, avg_speed_kmh / 3.6
+		FROM segment_mode_corrections
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var corrections []ModeCorrection
+	for rows.Next() {
+		var c ModeCorrection
+		if err := rows.Scan(&c.StartTime, &c.EndTime, &c.Mode, &c.AvgSpeedMPS); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections, rows.Err()
+}
+
+// applyModeCorrections sets the mode of segments mostly covered by a
+// correction, the latest one winning
+func applyModeCorrections(segments []TransportSegment, corrections []ModeCorrection) {
+	for i := range segments {
+		seg := &segments[i]
+		duration := seg.EndTime - seg.StartTime
+		for _, c := range corrections {
+			overlap := min(seg.EndTime, c.EndTime) - max(seg.StartTime, c.StartTime)
+			if 2*overlap > duration || duration == 0 && overlap == 0 {
+				seg.Mode = c.Mode
+				seg.Confidence = 1
+				seg.ReasonCodes = reasonUserCorrected
+			}
+		}
+	}
+}
+
+// calibrateThresholds moves the speed cutoffs so they separate the average
+// speeds of corrected segments
+// A cutoff moves only when corrections put a slower mode above it or a faster
+// mode below it: to midway between the fastest corrected segment below and
+// the slowest above, or just past the offending segments when the other side
+// has none. It never moves by more than CalibrationMaxFactor, is left alone
+// when the corrections contradict each other, and cutoffs stay in order.
+func calibrateThresholds(t TransportModeThresholds, corrections []ModeCorrection) TransportModeThresholds {
+	if t.CalibrationMaxFactor <= 1 || len(corrections) == 0 {
+		return t
+	}
+
+	original := []float64{t.WalkMaxSpeedMPS, t.BikeMaxSpeedMPS, t.CarMaxSpeedMPS, t.TrainMaxSpeedMPS}
+	cutoffs := append([]float64(nil), original...)
+	for k, cutoff := range original {
+		// Fastest corrected segment of the modes below the cutoff, slowest above
+		below, above := math.Inf(-1), math.Inf(1)
+		for _, c := range corrections {
+			rank := modeRank(c.Mode)
+			if rank < 0 {
+				continue
+			}
+			if rank <= k {
+				below = math.Max(below, c.AvgSpeedMPS)
+			} else {
+				above = math.Min(above, c.AvgSpeedMPS)
+			}
+		}
+
+		switch {
+		case below < cutoff && above >= cutoff, below >= above:
+			continue
+		case math.IsInf(above, 1):
+			cutoff = below * calibrationMargin
+		case math.IsInf(below, -1):
+			cutoff = above / calibrationMargin
+		default:
+			cutoff = (below + above) / 2
+		}
+		cutoffs[k] = math.Min(math.Max(cutoff, original[k]/t.CalibrationMaxFactor), original[k]*t.CalibrationMaxFactor)
+	}
+
+	// Revert moved cutoffs that overtake their neighbours until all are in order
+	for k := 1; k < len(cutoffs); k++ {
+		if cutoffs[k] > cutoffs[k-1] {
+			continue
+		}
+		switch {
+		case cutoffs[k] != original[k]:
+			cutoffs[k] = original[k]
+		case cutoffs[k-1] != original[k-1]:
+			cutoffs[k-1] = original[k-1]
+		default:
+			continue
+		}
+		k = 0
+	}
+
+	t.WalkMaxSpeedMPS, t.BikeMaxSpeedMPS, t.CarMaxSpeedMPS, t.TrainMaxSpeedMPS = cutoffs[0], cutoffs[1], cutoffs[2], cutoffs[3]
+	return t
+}
+
 // insertSegments inserts segments into the database
 func (a *TransportModeAnalyzer) insertSegments(ctx context.Context, segments []TransportSegment) error {
 	if len(segments) == 0 {
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	insertQuery := `
+	// A segment belongs to the source that recorded most of its points, and
+	// to the area of its start point
+	batch := database.NewBatchInserter(tx, `
 		INSERT INTO segments (
 			mode, start_time, end_time, start_point_id, end_point_id,
 			point_count, distance_m, duration_s, avg_speed_kmh, max_speed_kmh,
-			confidence, reason_codes, metadata, algo_version, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1.0', CAST(strftime('%s', 'now') AS INTEGER), CAST(strftime('%s', 'now') AS INTEGER))
-	`
-
-	stmt, err := tx.PrepareContext(ctx, insertQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
+			confidence, reason_codes, metadata, source, `+analysis.SegmentAreaColumns+`, algo_version, created_at, updated_at
+		)`, `(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+analysis.DominantSourceExpr+`, `+analysis.SegmentAreaValues+`, '`+analysis.Version("transport_mode")+`', CAST(strftime('%s', 'now') AS INTEGER), CAST(strftime('%s', 'now') AS INTEGER))`,
+		0)
+	defer batch.Close()
 
 	for _, seg := range segments {
-		_, err := stmt.ExecContext(ctx,
+		args := []interface{}{
 			seg.Mode,
 			seg.StartTime,
 			seg.EndTime,
@@ -324,11 +657,17 @@ func (a *TransportModeAnalyzer) insertSegments(ctx context.Context, segments []T
 			seg.Confidence,
 			seg.ReasonCodes,
 			seg.Metadata,
-		)
-		if err != nil {
+			seg.StartTime,
+			seg.EndTime,
+		}
+		args = append(args, analysis.SegmentAreaArgs(seg.StartPointID, seg.EndPointID)...)
+		if err := batch.Add(ctx, args...); err != nil {
 			return fmt.Errorf("failed to insert segment: %w", err)
 		}
 	}
+	if err := batch.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to insert segments: %w", err)
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -357,4 +696,15 @@ func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("transport_mode", NewTransportModeAnalyzer)
+	analysis.RegisterVersion("transport_mode", "v2.1")
+	analysis.RegisterDependencies("transport_mode", "outlier_detection", "computed_speed")
+	analysis.RegisterOutputs("transport_mode",
+		// Rows derived from segments go first, in the same transaction
+		analysis.Output{Table: "speed_events"},
+		analysis.Output{Table: "render_segments_cache"},
+		analysis.Output{Table: "segment_polylines"},
+		analysis.Output{Table: "matched_segments"},
+		analysis.Output{Table: "road_overlap_stats"},
+		analysis.Output{Table: "segments"},
+	)
 }
@@ -0,0 +1,380 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+	"github.com/jengzang/records-backend-go/internal/transit"
+)
+
+const (
+	stopSpeedMPS         = 0.5   // Points slower than this are standing still
+	maxSignalGapS        = 60    // Points further apart give no acceleration or heading change
+	headingMinStepM      = 10.0  // Steps shorter than this give no heading
+	minSignalSamples     = 5     // Acceleration samples needed to judge smoothness
+	windingMinDistanceM  = 1000  // Segments shorter than this are not judged winding
+	straightMinDistanceM = 5000  // Segments shorter than this are not judged straight
+	nonStopMinDistanceM  = 20000 // Segments without stops over this distance are non-stop
+	speedSoftness        = 0.15  // Width of the speed cutoffs' fall-off, as a fraction of the cutoff
+)
+
+// legFeatures are running aggregates of a segment's motion signals, summed
+// when runs are joined
+type legFeatures struct {
+	speedSumKmh      float64
+	movingSpeedSum   float64 // m/s, of points at or above stopSpeedMPS
+	movingPoints     int
+	accelSum         float64 // m/s², absolute speed change per second
+	accelCount       int
+	headingChangeSum float64 // degrees
+	stops            int
+	startLat         float64
+	startLon         float64
+	endLat           float64
+	endLon           float64
+}
+
+// merge adds the aggregates of the run following f
+func (f *legFeatures) merge(o legFeatures) {
+	f.speedSumKmh += o.speedSumKmh
+	f.movingSpeedSum += o.movingSpeedSum
+	f.movingPoints += o.movingPoints
+	f.accelSum += o.accelSum
+	f.accelCount += o.accelCount
+	f.headingChangeSum += o.headingChangeSum
+	f.stops += o.stops
+	f.endLat, f.endLon = o.endLat, o.endLon
+}
+
+// modeSignal is evidence about a segment's mode, as likelihood factors of the
+// modes it favours (above 1) or disfavours (below 1)
+type modeSignal struct {
+	code    string
+	factors map[string]float64
+}
+
+var (
+	signalSmoothAccel   = modeSignal{"SMOOTH_ACCEL", map[string]float64{"BIKE": 1.3, "CAR": 0.6, "TRAIN": 2, "PLANE": 1.5}}
+	signalJerkyAccel    = modeSignal{"JERKY_ACCEL", map[string]float64{"CAR": 1.5, "TRAIN": 0.5, "PLANE": 0.5}}
+	signalFrequentStops = modeSignal{"FREQUENT_STOPS", map[string]float64{"BIKE": 1.5, "CAR": 1.5, "TRAIN": 0.4, "PLANE": 0.1}}
+	signalNonStop       = modeSignal{"NON_STOP", map[string]float64{"CAR": 0.8, "TRAIN": 1.5, "PLANE": 1.5}}
+	signalWinding       = modeSignal{"WINDING", map[string]float64{"WALK": 1.2, "BIKE": 1.3, "CAR": 1.3, "TRAIN": 0.5, "PLANE": 0.7}}
+	signalStraight      = modeSignal{"STRAIGHT", map[string]float64{"TRAIN": 1.5, "PLANE": 1.3}}
+	signalLowPeakSpeed  = modeSignal{"LOW_PEAK_SPEED", map[string]float64{"BIKE": 1.5, "CAR": 0.7}}
+	signalNearStation   = modeSignal{"NEAR_STATION", map[string]float64{"TRAIN": 1.5}} // Per segment end
+	signalNearAirport   = modeSignal{"NEAR_AIRPORT", map[string]float64{"PLANE": 2}}   // Per segment end
+	continuityFactor    = 1.5                                                          // For the previous segment's mode
+)
+
+// modeClassifier turns a time-ordered point stream into transport mode
+// segments with per-mode probabilities
+// Points are grouped into runs by speed class. Consecutive vehicle runs form
+// one segment, counting slow runs of up to MaxStopDurationS between them as
+// stops; runs shorter than MinSegmentDurationS join their neighbours. One
+// run is held back to tell a stop from the start of a walk.
+type modeClassifier struct {
+	thresholds      TransportModeThresholds
+	runs            segmentBuilder
+	leg             *TransportSegment // Open segment
+	legVehicle      bool
+	pause           *TransportSegment // Slow run after a vehicle segment, a stop if another vehicle run follows
+	prevVehicleMode string
+	prevVehicleEnd  int64
+}
+
+// newModeClassifier creates a classifier using thresholds
+func newModeClassifier(thresholds TransportModeThresholds) *modeClassifier {
+	return &modeClassifier{thresholds: thresholds, runs: segmentBuilder{maxGapS: thresholds.ContinuityGapS}}
+}
+
+// add appends a point and returns the segments it completes
+func (c *modeClassifier) add(point types.Point) []TransportSegment {
+	run := c.runs.add(point, classifyMode(point.Speed, c.thresholds))
+	if run == nil {
+		return nil
+	}
+	return c.join(*run)
+}
+
+// flush returns the segments still open at the end of the stream
+func (c *modeClassifier) flush() []TransportSegment {
+	var done []TransportSegment
+	if run := c.runs.flush(); run != nil {
+		done = c.join(*run)
+	}
+	return append(done, c.close()...)
+}
+
+// join adds a finished run to the open segment or starts a new one, and
+// returns the segments completed
+func (c *modeClassifier) join(run TransportSegment) []TransportSegment {
+	var done []TransportSegment
+	last := c.leg
+	if c.pause != nil {
+		last = c.pause
+	}
+	if last != nil && run.StartTime-last.EndTime > c.thresholds.ContinuityGapS {
+		done = c.close()
+	}
+
+	short := run.DurationS < c.thresholds.MinSegmentDurationS
+	walk := run.Mode == "WALK"
+	switch {
+	case c.leg == nil:
+		c.open(run)
+	case c.pause != nil && (short || walk):
+		mergeSegments(c.pause, run)
+		if c.pause.DurationS > c.thresholds.MaxStopDurationS {
+			// Too long for a stop: a walk of its own
+			done = append(done, c.emit(c.leg)...)
+			c.leg, c.legVehicle, c.pause = c.pause, false, nil
+		}
+	case c.pause != nil:
+		mergeSegments(c.leg, *c.pause)
+		c.leg.features.stops++
+		c.pause = nil
+		mergeSegments(c.leg, run)
+	case short:
+		mergeSegments(c.leg, run)
+	case walk && c.legVehicle && run.DurationS <= c.thresholds.MaxStopDurationS:
+		c.pause = &run
+	case walk != c.legVehicle:
+		mergeSegments(c.leg, run)
+	default:
+		done = append(done, c.emit(c.leg)...)
+		c.open(run)
+	}
+	return done
+}
+
+// open starts a segment with run
+func (c *modeClassifier) open(run TransportSegment) {
+	c.leg = &run
+	c.legVehicle = run.Mode != "WALK"
+}
+
+// close completes the open segment and the held back run
+func (c *modeClassifier) close() []TransportSegment {
+	done := c.emit(c.leg)
+	done = append(done, c.emit(c.pause)...)
+	c.leg, c.pause = nil, nil
+	return done
+}
+
+// emit scores a completed segment, dropping it if shorter than MinSegmentDurationS
+func (c *modeClassifier) emit(seg *TransportSegment) []TransportSegment {
+	if seg == nil || seg.DurationS < c.thresholds.MinSegmentDurationS {
+		return nil
+	}
+	prev := ""
+	if c.prevVehicleMode != "" && seg.StartTime-c.prevVehicleEnd <= c.thresholds.ContinuityGapS {
+		prev = c.prevVehicleMode
+	}
+	scoreSegment(seg, c.thresholds, prev)
+	if seg.Mode != "WALK" {
+		c.prevVehicleMode, c.prevVehicleEnd = seg.Mode, seg.EndTime
+	}
+	return []TransportSegment{*seg}
+}
+
+// seedContinuity lets the classifier continue from the latest stored segment,
+// after the trailing one was re-opened
+func (a *TransportModeAnalyzer) seedContinuity(ctx context.Context, c *modeClassifier) error {
+	var mode string
+	var endTime int64
+	err := a.DB.QueryRowContext(ctx, `
+		SELECT mode, end_time FROM segments
+		ORDER BY end_time DESC, id DESC
+		LIMIT 1
+	`).Scan(&mode, &endTime)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if mode != "WALK" {
+		c.prevVehicleMode, c.prevVehicleEnd = mode, endTime
+	}
+	return nil
+}
+
+// mergeSegments appends the run src to the segment dst
+func mergeSegments(dst *TransportSegment, src TransportSegment) {
+	dst.DistanceM += haversineDistance(dst.features.endLat, dst.features.endLon, src.features.startLat, src.features.startLon) + src.DistanceM
+	dst.EndTime, dst.EndPointID = src.EndTime, src.EndPointID
+	dst.PointCount += src.PointCount
+	dst.DurationS = dst.EndTime - dst.StartTime
+	dst.MaxSpeedKmh = math.Max(dst.MaxSpeedKmh, src.MaxSpeedKmh)
+	dst.features.merge(src.features)
+	dst.AvgSpeedKmh = dst.features.speedSumKmh / float64(dst.PointCount)
+}
+
+// scoreSegment sets the mode of a segment to the most probable one, with its
+// probabilities, confidence and the signals behind them
+// prevMode is the mode of a vehicle segment shortly before, if any.
+func scoreSegment(seg *TransportSegment, t TransportModeThresholds, prevMode string) {
+	probs, signals := scoreModes(seg, t, prevMode)
+
+	best := classifiedModes[0]
+	for _, m := range classifiedModes[1:] {
+		if probs[m] > probs[best] {
+			best = m
+		}
+	}
+	seg.Mode = best
+	seg.Confidence = probs[best]
+	seg.ModeProbs = probs
+	seg.Signals = signals
+
+	f := seg.features
+	reasons, _ := json.Marshal(append([]string{"SPEED"}, signals...))
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"mode_probs":         probs,
+		"stops":              f.stops,
+		"moving_speed_mps":   round3(movingSpeed(seg)),
+		"accel_mps2":         round3(meanAccel(f)),
+		"heading_deg_per_km": round3(headingRate(seg)),
+	})
+	seg.ReasonCodes = string(reasons)
+	seg.Metadata = string(metadata)
+}
+
+// scoreModes returns the probability of each classified mode for a segment
+// and the signals besides speed that weighed in
+// Speed gives each mode a likelihood, which the other signals scale up or
+// down before normalizing. Signals only judge segments moving faster than a
+// walk.
+func scoreModes(seg *TransportSegment, t TransportModeThresholds, prevMode string) (map[string]float64, []string) {
+	speed := movingSpeed(seg)
+	cutoffs := []float64{t.WalkMaxSpeedMPS, t.BikeMaxSpeedMPS, t.CarMaxSpeedMPS, t.TrainMaxSpeedMPS}
+	scores := make([]float64, len(classifiedModes))
+	for i := range classifiedModes {
+		scores[i] = speedLikelihood(speed, cutoffs, i)
+	}
+
+	var signals []string
+	apply := func(s modeSignal) {
+		for i, m := range classifiedModes {
+			if x, ok := s.factors[m]; ok {
+				scores[i] *= x
+			}
+		}
+		for _, code := range signals {
+			if code == s.code {
+				return
+			}
+		}
+		signals = append(signals, s.code)
+	}
+
+	if speed >= t.WalkMaxSpeedMPS {
+		f := seg.features
+		if f.accelCount >= minSignalSamples {
+			if accel := meanAccel(f); accel < t.SmoothAccelMPS2 {
+				apply(signalSmoothAccel)
+			} else if accel > t.JerkyAccelMPS2 {
+				apply(signalJerkyAccel)
+			}
+		}
+
+		if km := seg.DistanceM / 1000; f.stops >= 2 && float64(f.stops)/km*10 >= t.FrequentStopsPer10Km {
+			apply(signalFrequentStops)
+		} else if f.stops == 0 && seg.DistanceM >= nonStopMinDistanceM {
+			apply(signalNonStop)
+		}
+
+		if rate := headingRate(seg); seg.DistanceM >= windingMinDistanceM && rate >= t.WindingDegPerKm {
+			apply(signalWinding)
+		} else if seg.DistanceM >= straightMinDistanceM && rate <= t.StraightDegPerKm {
+			apply(signalStraight)
+		}
+
+		if seg.MaxSpeedKmh/3.6 < 1.5*t.BikeMaxSpeedMPS {
+			apply(signalLowPeakSpeed)
+		}
+
+		for _, end := range [][2]float64{{f.startLat, f.startLon}, {f.endLat, f.endLon}} {
+			if _, d := transit.NearestStation(end[0], end[1]); d <= t.StationRadiusM {
+				apply(signalNearStation)
+			}
+			if _, d := transit.NearestAirport(end[0], end[1]); d <= t.AirportRadiusM {
+				apply(signalNearAirport)
+			}
+		}
+
+		if rank := modeRank(prevMode); rank >= 0 {
+			scores[rank] *= continuityFactor
+			signals = append(signals, "CONTINUITY")
+		}
+	}
+
+	total := 0.0
+	for _, s := range scores {
+		total += s
+	}
+	probs := make(map[string]float64, len(classifiedModes))
+	for i, m := range classifiedModes {
+		if total > 0 {
+			probs[m] = round3(scores[i] / total)
+		}
+	}
+	if total == 0 {
+		probs[classifyMode(speed, t)] = 1
+	}
+	return probs, signals
+}
+
+// speedLikelihood is a soft version of the speed cutoffs for the mode at rank:
+// about 1 inside its speed range, falling off outside it over speedSoftness
+// of the cutoff
+func speedLikelihood(speed float64, cutoffs []float64, rank int) float64 {
+	l := 1.0
+	if rank > 0 {
+		lo := cutoffs[rank-1]
+		l *= sigmoid((speed - lo) / math.Max(speedSoftness*lo, 0.01))
+	}
+	if rank < len(cutoffs) {
+		hi := cutoffs[rank]
+		l *= sigmoid((hi - speed) / math.Max(speedSoftness*hi, 0.01))
+	}
+	return l
+}
+
+// sigmoid is the logistic function
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// movingSpeed returns the mean speed of a segment's moving points in m/s,
+// leaving out stops
+func movingSpeed(seg *TransportSegment) float64 {
+	if seg.features.movingPoints == 0 {
+		return seg.AvgSpeedKmh / 3.6
+	}
+	return seg.features.movingSpeedSum / float64(seg.features.movingPoints)
+}
+
+// meanAccel returns the mean absolute acceleration in m/s²
+func meanAccel(f legFeatures) float64 {
+	if f.accelCount == 0 {
+		return 0
+	}
+	return f.accelSum / float64(f.accelCount)
+}
+
+// headingRate returns the heading change of a segment in degrees per km
+func headingRate(seg *TransportSegment) float64 {
+	if seg.DistanceM <= 0 {
+		return 0
+	}
+	return seg.features.headingChangeSum / (seg.DistanceM / 1000)
+}
+
+// round3 rounds to three decimals
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
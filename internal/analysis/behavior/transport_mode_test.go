@@ -0,0 +1,330 @@
+package behavior
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
+)
+
+func TestClassifyMode(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  string
+	}{
+		{0, "WALK"},
+		{1.9, "WALK"},
+		{2, "BIKE"},
+		{7.9, "BIKE"},
+		{8, "CAR"},
+		{39.9, "CAR"},
+		{40, "TRAIN"},
+		{60, "PLANE"},
+		{250, "PLANE"},
+	}
+	for _, tt := range tests {
+		if got := classifyMode(tt.speed, DefaultTransportModeThresholds); got != tt.want {
+			t.Errorf("classifyMode(%.1f) = %s, want %s", tt.speed, got, tt.want)
+		}
+	}
+
+	custom := DefaultTransportModeThresholds
+	custom.WalkMaxSpeedMPS = 3
+	if got := classifyMode(2.5, custom); got != "WALK" {
+		t.Errorf("classifyMode(2.5) with a 3 m/s walk cutoff = %s, want WALK", got)
+	}
+}
+
+// movePoints returns points from start to end, one every 10 s, moving north at speed
+func movePoints(start, end int64, speed float64) []types.Point {
+	var points []types.Point
+	for ts := start; ts <= end; ts += 10 {
+		// One degree of latitude is about 111.2 km
+		points = append(points, types.Point{ID: ts, Timestamp: ts, Lat: float64(ts) * speed / 111195, Speed: speed})
+	}
+	return points
+}
+
+func TestClassifySegments(t *testing.T) {
+	type want struct {
+		mode       string
+		start, end int64
+		points     int
+	}
+	tests := []struct {
+		name   string
+		points []types.Point
+		want   []want
+	}{
+		{
+			name:   "no points",
+			points: nil,
+			want:   nil,
+		},
+		{
+			name:   "walk then drive",
+			points: joinPoints(movePoints(0, 60, 1), movePoints(70, 200, 20)),
+			want:   []want{{"WALK", 0, 60, 7}, {"CAR", 70, 200, 14}},
+		},
+		{
+			name:   "runs shorter than the minimum duration join their neighbours",
+			points: joinPoints(movePoints(0, 60, 1), movePoints(70, 70, 5), movePoints(80, 200, 1)),
+			want:   []want{{"WALK", 0, 200, 21}},
+		},
+		{
+			name:   "short stops stay within a vehicle segment",
+			points: joinPoints(movePoints(0, 300, 20), movePoints(310, 370, 0), movePoints(380, 700, 20)),
+			want:   []want{{"CAR", 0, 700, 71}},
+		},
+		{
+			name:   "walks longer than a stop split vehicle segments",
+			points: joinPoints(movePoints(0, 300, 20), movePoints(310, 600, 1), movePoints(610, 900, 20)),
+			want:   []want{{"CAR", 0, 300, 31}, {"WALK", 310, 600, 30}, {"CAR", 610, 900, 30}},
+		},
+		{
+			name:   "gaps split runs of the same mode",
+			points: joinPoints(movePoints(0, 300, 1), movePoints(1000, 1300, 1)),
+			want:   []want{{"WALK", 0, 300, 31}, {"WALK", 1000, 1300, 31}},
+		},
+		{
+			name:   "segments too short even with their neighbours are dropped",
+			points: movePoints(0, 5, 1),
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifySegments(tt.points, DefaultTransportModeThresholds)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d segments, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, seg := range got {
+				w := tt.want[i]
+				if seg.Mode != w.mode || seg.StartTime != w.start || seg.EndTime != w.end || seg.PointCount != w.points {
+					t.Errorf("segment %d = %s %d-%d (%d points), want %s %d-%d (%d points)",
+						i, seg.Mode, seg.StartTime, seg.EndTime, seg.PointCount, w.mode, w.start, w.end, w.points)
+				}
+			}
+		})
+	}
+
+	t.Run("distance and speeds", func(t *testing.T) {
+		seg := classifySegments(movePoints(0, 100, 20), DefaultTransportModeThresholds)[0]
+		if math.Abs(seg.DistanceM-2000) > 5 {
+			t.Errorf("distance = %.1f m, want about 2000", seg.DistanceM)
+		}
+		if math.Abs(seg.AvgSpeedKmh-72) > 1e-9 || math.Abs(seg.MaxSpeedKmh-72) > 1e-9 {
+			t.Errorf("speeds = %.1f/%.1f km/h, want 72", seg.AvgSpeedKmh, seg.MaxSpeedKmh)
+		}
+		if seg.DurationS != 100 {
+			t.Errorf("duration = %d s, want 100", seg.DurationS)
+		}
+	})
+}
+
+// shiftPoints moves points to start at lat, lon
+func shiftPoints(points []types.Point, lat, lon float64) []types.Point {
+	for i := range points {
+		points[i].Lat += lat
+		points[i].Lon += lon
+	}
+	return points
+}
+
+// varySpeed alternates the speeds of points between speed-delta and speed+delta
+func varySpeed(points []types.Point, delta float64) []types.Point {
+	for i := range points {
+		if i%2 == 0 {
+			points[i].Speed -= delta
+		} else {
+			points[i].Speed += delta
+		}
+	}
+	return points
+}
+
+func TestModeScoring(t *testing.T) {
+	tests := []struct {
+		name        string
+		points      []types.Point
+		want        string
+		wantSignals []string
+	}{
+		{
+			name:        "slow train leaving a station",
+			points:      shiftPoints(movePoints(0, 1000, 30), 39.8652, 116.3786),
+			want:        "TRAIN",
+			wantSignals: []string{"SMOOTH_ACCEL", "NON_STOP", "STRAIGHT", "NEAR_STATION"},
+		},
+		{
+			name:        "car in traffic at the same speed",
+			points:      varySpeed(movePoints(0, 1000, 30), 5),
+			want:        "CAR",
+			wantSignals: []string{"JERKY_ACCEL", "NON_STOP", "STRAIGHT"},
+		},
+		{
+			name:        "fast cycling",
+			points:      varySpeed(movePoints(0, 600, 9), 0.5),
+			want:        "BIKE",
+			wantSignals: []string{"SMOOTH_ACCEL", "STRAIGHT", "LOW_PEAK_SPEED"},
+		},
+		{
+			name:        "driving at cycling speed",
+			points:      varySpeed(movePoints(0, 600, 10), 6),
+			want:        "CAR",
+			wantSignals: []string{"JERKY_ACCEL", "STRAIGHT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := classifySegments(tt.points, DefaultTransportModeThresholds)
+			if len(segments) != 1 {
+				t.Fatalf("got %d segments, want 1: %+v", len(segments), segments)
+			}
+			seg := segments[0]
+			if seg.Mode != tt.want {
+				t.Errorf("mode = %s, want %s (probabilities %v)", seg.Mode, tt.want, seg.ModeProbs)
+			}
+			if seg.Confidence != seg.ModeProbs[seg.Mode] {
+				t.Errorf("confidence = %.3f, want the probability of %s", seg.Confidence, seg.Mode)
+			}
+			total := 0.0
+			for _, p := range seg.ModeProbs {
+				total += p
+			}
+			if math.Abs(total-1) > 0.01 {
+				t.Errorf("probabilities sum to %.3f: %v", total, seg.ModeProbs)
+			}
+			if len(seg.Signals) != len(tt.wantSignals) {
+				t.Fatalf("signals = %v, want %v", seg.Signals, tt.wantSignals)
+			}
+			for i := range seg.Signals {
+				if seg.Signals[i] != tt.wantSignals[i] {
+					t.Fatalf("signals = %v, want %v", seg.Signals, tt.wantSignals)
+				}
+			}
+		})
+	}
+
+	t.Run("continuity favours the previous mode", func(t *testing.T) {
+		seg := classifySegments(movePoints(0, 600, 35), DefaultTransportModeThresholds)[0]
+		without, _ := scoreModes(&seg, DefaultTransportModeThresholds, "")
+		with, signals := scoreModes(&seg, DefaultTransportModeThresholds, "TRAIN")
+		if with["TRAIN"] <= without["TRAIN"] {
+			t.Errorf("TRAIN probability %.3f after a train, %.3f without", with["TRAIN"], without["TRAIN"])
+		}
+		if signals[len(signals)-1] != "CONTINUITY" {
+			t.Errorf("signals = %v, want CONTINUITY last", signals)
+		}
+	})
+
+	t.Run("walks are judged by speed alone", func(t *testing.T) {
+		seg := classifySegments(shiftPoints(movePoints(0, 600, 1), 39.8652, 116.3786), DefaultTransportModeThresholds)[0]
+		if seg.Mode != "WALK" || len(seg.Signals) != 0 {
+			t.Errorf("mode = %s with signals %v, want WALK without signals", seg.Mode, seg.Signals)
+		}
+	})
+}
+
+func TestApplyModeCorrections(t *testing.T) {
+	corrections := []ModeCorrection{
+		{StartTime: 0, EndTime: 100, Mode: "BIKE"},
+		{StartTime: 50, EndTime: 100, Mode: "CAR"},
+		{StartTime: 500, EndTime: 600, Mode: "TRAIN"},
+	}
+	tests := []struct {
+		name       string
+		start, end int64
+		want       string
+	}{
+		{"covered", 10, 40, "BIKE"},
+		{"latest correction wins", 60, 100, "CAR"},
+		{"mostly covered", 450, 560, "TRAIN"},
+		{"half covered", 400, 600, "WALK"},
+		{"not covered", 200, 300, "WALK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments := []TransportSegment{{Mode: "WALK", StartTime: tt.start, EndTime: tt.end, Confidence: 0.8}}
+			applyModeCorrections(segments, corrections)
+			if segments[0].Mode != tt.want {
+				t.Errorf("mode = %s, want %s", segments[0].Mode, tt.want)
+			}
+			corrected := segments[0].ReasonCodes == reasonUserCorrected && segments[0].Confidence == 1
+			if corrected != (tt.want != "WALK") {
+				t.Errorf("reason codes = %q, confidence = %.1f", segments[0].ReasonCodes, segments[0].Confidence)
+			}
+		})
+	}
+}
+
+func TestCalibrateThresholds(t *testing.T) {
+	d := DefaultTransportModeThresholds
+	tests := []struct {
+		name        string
+		corrections []ModeCorrection
+		want        [4]float64 // walk, bike, car and train cutoffs
+	}{
+		{
+			name: "no corrections",
+			want: [4]float64{2, 8, 40, 60},
+		},
+		{
+			name:        "corrections agreeing with the cutoffs",
+			corrections: []ModeCorrection{{Mode: "WALK", AvgSpeedMPS: 1.5}, {Mode: "BIKE", AvgSpeedMPS: 5}},
+			want:        [4]float64{2, 8, 40, 60},
+		},
+		{
+			name:        "fast walks raise the walk cutoff",
+			corrections: []ModeCorrection{{Mode: "WALK", AvgSpeedMPS: 2.2}},
+			want:        [4]float64{2.42, 8, 40, 60},
+		},
+		{
+			name:        "cutoff midway between both sides",
+			corrections: []ModeCorrection{{Mode: "CAR", AvgSpeedMPS: 7}, {Mode: "BIKE", AvgSpeedMPS: 5}},
+			want:        [4]float64{2, 6, 40, 60},
+		},
+		{
+			name:        "moves are bounded",
+			corrections: []ModeCorrection{{Mode: "TRAIN", AvgSpeedMPS: 5}},
+			want:        [4]float64{2, 5.33, 26.67, 60},
+		},
+		{
+			name:        "contradicting corrections leave the cutoff alone",
+			corrections: []ModeCorrection{{Mode: "WALK", AvgSpeedMPS: 3}, {Mode: "BIKE", AvgSpeedMPS: 2.5}},
+			want:        [4]float64{2, 8, 40, 60},
+		},
+		{
+			name:        "cutoffs stay in order",
+			corrections: []ModeCorrection{{Mode: "CAR", AvgSpeedMPS: 55}},
+			want:        [4]float64{2, 8, 40, 60},
+		},
+		{
+			name:        "other modes are ignored",
+			corrections: []ModeCorrection{{Mode: "STAY", AvgSpeedMPS: 30}},
+			want:        [4]float64{2, 8, 40, 60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calibrateThresholds(d, tt.corrections)
+			cutoffs := [4]float64{got.WalkMaxSpeedMPS, got.BikeMaxSpeedMPS, got.CarMaxSpeedMPS, got.TrainMaxSpeedMPS}
+			for i := range cutoffs {
+				if math.Abs(cutoffs[i]-tt.want[i]) > 0.01 {
+					t.Fatalf("cutoffs = %.2f, want %.2f", cutoffs, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("calibration turned off", func(t *testing.T) {
+		off := d
+		off.CalibrationMaxFactor = 0
+		if got := calibrateThresholds(off, []ModeCorrection{{Mode: "WALK", AvgSpeedMPS: 2.2}}); got != off {
+			t.Errorf("thresholds changed with calibration off: %+v", got)
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
@@ -34,30 +35,28 @@ func (a *TripConstructionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing trips (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM trips"); err != nil {
-			return fmt.Errorf("failed to clear trips: %w", err)
-		}
-		log.Printf("[TripConstructionAnalyzer] Cleared existing trips")
-	}
-
 	// Query segments and stays ordered by time
 	segmentsQuery := `
 		SELECT
-			id, start_time, end_time, mode, distance_m, duration_s
+			id, start_time, end_time, mode, distance_m, duration_s, start_point_id, end_point_id, rail_line
 		FROM segments
 		ORDER BY start_time
 	`
 
 	staysQuery := `
 		SELECT
-			id, start_time, end_time, duration_s
+			id, start_time, end_time, duration_s, center_lat, center_lon, province, city, county, place_id
 		FROM stay_segments
-		WHERE duration_s >= 1800
+		WHERE duration_s >= ?
 		ORDER BY start_time
 	`
 
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultTripConstructionThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
 	// Load segments
 	segments, err := a.loadSegments(ctx, segmentsQuery)
 	if err != nil {
@@ -65,15 +64,35 @@ func (a *TripConstructionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 	}
 
 	// Load stays
-	stays, err := a.loadStays(ctx, staysQuery)
+	stays, err := a.loadStays(ctx, staysQuery, thresholds.MinStayDurationS)
 	if err != nil {
 		return fmt.Errorf("failed to load stays: %w", err)
 	}
 
-	log.Printf("[TripConstructionAnalyzer] Loaded %d segments and %d stays", len(segments), len(stays))
+	// Load HOME/WORK anchors for purpose inference
+	anchors, err := a.loadPlaceAnchors(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load place anchors: %w", err)
+	}
+
+	log.Printf("[TripConstructionAnalyzer] Loaded %d segments, %d stays and %d anchors", len(segments), len(stays), len(anchors))
+
+	// Load manual splits and merges, replayed over the gap rule
+	edits, err := a.loadTripEdits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trip edits: %w", err)
+	}
 
 	// Construct trips
-	trips := a.constructTrips(segments, stays)
+	trips := constructTrips(segments, stays, thresholds, edits)
+
+	// Resolve origin/destination locations
+	if err := a.resolveEndpoints(ctx, trips, stays); err != nil {
+		return fmt.Errorf("failed to resolve trip endpoints: %w", err)
+	}
+
+	// Infer trip purposes
+	a.inferPurposes(trips, stays, anchors)
 
 	log.Printf("[TripConstructionAnalyzer] Constructed %d trips", len(trips))
 
@@ -83,10 +102,16 @@ func (a *TripConstructionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 	}
 
 	// Mark task as completed
+	purposeCounts := make(map[string]int)
+	for _, trip := range trips {
+		purposeCounts[trip.Purpose]++
+	}
+
 	summary := map[string]interface{}{
 		"total_trips":    len(trips),
 		"total_segments": len(segments),
 		"total_stays":    len(stays),
+		"purposes":       purposeCounts,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -98,6 +123,105 @@ func (a *TripConstructionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 	return nil
 }
 
+// PreviewTrip is a trip constructed by a preview
+type PreviewTrip struct {
+	Date              string   `json:"date"`
+	TripNumber        int      `json:"trip_number"` // Counted within the preview window
+	StartTime         int64    `json:"start_time"`
+	EndTime           int64    `json:"end_time"`
+	DurationS         int64    `json:"duration_s"`
+	DistanceM         float64  `json:"distance_m"`
+	SegmentCount      int      `json:"segment_count"`
+	Modes             []string `json:"modes"`
+	RailLines         []string `json:"rail_lines,omitempty"`
+	PrimaryMode       string   `json:"primary_mode"`
+	OriginStayID      *int64   `json:"origin_stay_id,omitempty"`
+	DestStayID        *int64   `json:"dest_stay_id,omitempty"`
+	OriginCity        string   `json:"origin_city,omitempty"`
+	DestCity          string   `json:"dest_city,omitempty"`
+	Purpose           string   `json:"purpose"`
+	PurposeConfidence float64  `json:"purpose_confidence"`
+}
+
+// Preview constructs the trips of the segments starting in window without
+// writing them
+func (a *TripConstructionAnalyzer) Preview(ctx context.Context, window analysis.Window, override json.RawMessage) (*analysis.Preview, error) {
+	thresholds := DefaultTripConstructionThresholds
+	if err := a.PreviewThresholds(ctx, override, &thresholds); err != nil {
+		return nil, err
+	}
+
+	segments, err := a.loadSegments(ctx, `
+		SELECT
+			id, start_time, end_time, mode, distance_m, duration_s, start_point_id, end_point_id, rail_line
+		FROM segments
+		WHERE start_time >= ? AND start_time < ?
+		ORDER BY start_time
+	`, window.Start, window.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load segments: %w", err)
+	}
+	preview := &analysis.Preview{Thresholds: thresholds, Inputs: len(segments), Results: []PreviewTrip{}}
+	if len(segments) == 0 {
+		return preview, nil
+	}
+
+	// Only stays close enough to a trip to become its origin or destination
+	lastEnd := segments[len(segments)-1].EndTime
+	stays, err := a.loadStays(ctx, `
+		SELECT
+			id, start_time, end_time, duration_s, center_lat, center_lon, province, city, county, place_id
+		FROM stay_segments
+		WHERE duration_s >= ? AND end_time >= ? AND start_time <= ?
+		ORDER BY start_time
+	`, thresholds.MinStayDurationS, window.Start-thresholds.StayLinkS, lastEnd+thresholds.StayLinkS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stays: %w", err)
+	}
+	anchors, err := a.loadPlaceAnchors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load place anchors: %w", err)
+	}
+	edits, err := a.loadTripEdits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trip edits: %w", err)
+	}
+
+	trips := constructTrips(segments, stays, thresholds, edits)
+	if err := a.resolveEndpoints(ctx, trips, stays); err != nil {
+		return nil, fmt.Errorf("failed to resolve trip endpoints: %w", err)
+	}
+	a.inferPurposes(trips, stays, anchors)
+
+	results := make([]PreviewTrip, 0, len(trips))
+	for _, trip := range trips {
+		var modes []string
+		_ = json.Unmarshal([]byte(trip.Modes), &modes)
+		var railLines []string
+		_ = json.Unmarshal([]byte(trip.RailLines.String), &railLines)
+		results = append(results, PreviewTrip{
+			Date:              trip.Date,
+			TripNumber:        trip.TripNumber,
+			StartTime:         trip.StartTime,
+			EndTime:           trip.EndTime,
+			DurationS:         trip.Duration,
+			DistanceM:         trip.Distance,
+			SegmentCount:      trip.SegmentCount,
+			Modes:             modes,
+			RailLines:         railLines,
+			PrimaryMode:       trip.PrimaryMode,
+			OriginStayID:      trip.OriginStayID,
+			DestStayID:        trip.DestStayID,
+			OriginCity:        trip.Origin.City.String,
+			DestCity:          trip.Dest.City.String,
+			Purpose:           trip.Purpose,
+			PurposeConfidence: trip.PurposeConfidence,
+		})
+	}
+	preview.Count, preview.Results = len(results), results
+	return preview, nil
+}
+
 // Segment holds segment data
 type Segment struct {
 	ID       int64
@@ -106,6 +230,9 @@ type Segment struct {
 	Mode     string
 	Distance float64
 	Duration int64
+	StartPointID sql.NullInt64
+	EndPointID   sql.NullInt64
+	RailLine     sql.NullString // High-speed rail line of a TRAIN segment
 }
 
 // Stay holds stay data
@@ -114,6 +241,21 @@ type Stay struct {
 	StartTime int64
 	EndTime   int64
 	Duration int64
+	CenterLat sql.NullFloat64
+	CenterLon sql.NullFloat64
+	Province  sql.NullString
+	City      sql.NullString
+	County    sql.NullString
+	PlaceID   sql.NullInt64 // Place the stay belongs to
+}
+
+// TripEndpoint holds the location of a trip origin or destination
+type TripEndpoint struct {
+	Lat      sql.NullFloat64
+	Lon      sql.NullFloat64
+	Province sql.NullString
+	City     sql.NullString
+	County   sql.NullString
 }
 
 // Trip holds trip data
@@ -122,18 +264,33 @@ type Trip struct {
 	TripNumber    int     // 1, 2, 3... for the day
 	OriginStayID  *int64  // Foreign key to stay_segments
 	DestStayID    *int64  // Foreign key to stay_segments
+	OriginPlaceID sql.NullInt64 // Place of the origin stay
+	DestPlaceID   sql.NullInt64 // Place of the destination stay
 	StartTime     int64
 	EndTime       int64
 	Duration      int64
 	Distance      float64
 	SegmentCount  int
 	Modes         string  // JSON array of modes
+	RailLines     sql.NullString // JSON array of rail lines, longest first; NULL when none
 	Metadata      string  // JSON object
+	PrimaryMode   string  // Mode covering the longest distance
+	StartPointID  sql.NullInt64
+	EndPointID    sql.NullInt64
+	Origin        TripEndpoint
+	Dest          TripEndpoint
+
+	// Purpose inference
+	DayType           string  // WORKDAY, WEEKEND, HOLIDAY
+	Purpose           string  // Most likely purpose
+	PurposeConfidence float64 // Probability of the most likely purpose
+	PurposeProbs      string  // JSON object of purpose probabilities
+	Features          string  // JSON object of purpose features
 }
 
 // loadSegments loads segments from database
-func (a *TripConstructionAnalyzer) loadSegments(ctx context.Context, query string) ([]Segment, error) {
-	rows, err := a.DB.QueryContext(ctx, query)
+func (a *TripConstructionAnalyzer) loadSegments(ctx context.Context, query string, args ...interface{}) ([]Segment, error) {
+	rows, err := a.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query segments: %w", err)
 	}
@@ -145,6 +302,7 @@ func (a *TripConstructionAnalyzer) loadSegments(ctx context.Context, query strin
 
 		if err := rows.Scan(
 			&seg.ID, &seg.StartTime, &seg.EndTime, &seg.Mode, &seg.Distance, &seg.Duration,
+			&seg.StartPointID, &seg.EndPointID, &seg.RailLine,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan segment: %w", err)
 		}
@@ -160,8 +318,8 @@ func (a *TripConstructionAnalyzer) loadSegments(ctx context.Context, query strin
 }
 
 // loadStays loads stays from database
-func (a *TripConstructionAnalyzer) loadStays(ctx context.Context, query string) ([]Stay, error) {
-	rows, err := a.DB.QueryContext(ctx, query)
+func (a *TripConstructionAnalyzer) loadStays(ctx context.Context, query string, args ...interface{}) ([]Stay, error) {
+	rows, err := a.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query stays: %w", err)
 	}
@@ -172,7 +330,8 @@ func (a *TripConstructionAnalyzer) loadStays(ctx context.Context, query string)
 		var stay Stay
 
 		if err := rows.Scan(
-			&stay.ID, &stay.StartTime, &stay.EndTime, &stay.Duration,
+			&stay.ID, &stay.StartTime, &stay.EndTime, &stay.Duration, &stay.CenterLat, &stay.CenterLon,
+			&stay.Province, &stay.City, &stay.County, &stay.PlaceID,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan stay: %w", err)
 		}
@@ -187,8 +346,83 @@ func (a *TripConstructionAnalyzer) loadStays(ctx context.Context, query string)
 	return stays, nil
 }
 
-// constructTrips constructs trips from segments and stays
-func (a *TripConstructionAnalyzer) constructTrips(segments []Segment, stays []Stay) []Trip {
+// TripConstructionThresholds defines configurable thresholds for trip construction
+// Loaded from the "trip_construction" section of the active threshold profile
+type TripConstructionThresholds struct {
+	MaxGapS          int64 `json:"max_gap_s"`           // Longest gap between segments of one trip
+	StayLinkS        int64 `json:"stay_link_s"`         // Longest gap between a trip and its origin or destination stay
+	MinStayDurationS int64 `json:"min_stay_duration_s"` // Shortest stay a trip can start or end at
+}
+
+// DefaultTripConstructionThresholds provides default trip construction thresholds
+var DefaultTripConstructionThresholds = TripConstructionThresholds{
+	MaxGapS:          7200, // 2 hours
+	StayLinkS:        3600, // 1 hour
+	MinStayDurationS: 1800, // 30 minutes
+}
+
+// Trip edit actions
+const (
+	TripEditSplit = "split"
+	TripEditMerge = "merge"
+)
+
+// TripEdit is a manual split or merge of trips, replayed on every run
+// Edits are keyed by time, as trip IDs change with every run.
+type TripEdit struct {
+	Action  string
+	SplitTS int64 // split: segments starting at or after it begin a new trip
+	FromTS  int64 // merge: segments starting within [FromTS, ToTS] stay in one trip
+	ToTS    int64
+}
+
+// loadTripEdits loads the manual trip edits, oldest first
+func (a *TripConstructionAnalyzer) loadTripEdits(ctx context.Context) ([]TripEdit, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT action, COALESCE(split_ts, 0), COALESCE(from_ts, 0), COALESCE(to_ts, 0)
+		FROM trip_edits
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edits []TripEdit
+	for rows.Next() {
+		var edit TripEdit
+		if err := rows.Scan(&edit.Action, &edit.SplitTS, &edit.FromTS, &edit.ToTS); err != nil {
+			return nil, err
+		}
+		edits = append(edits, edit)
+	}
+	return edits, rows.Err()
+}
+
+// tripBoundary reports whether the trip ending at tripEnd stops between
+// segments prev and next
+// A gap longer than MaxGapS is a boundary unless a merge covers both
+// segments; a split between them always is. Later edits override earlier ones.
+func tripBoundary(prev, next Segment, tripEnd int64, thresholds TripConstructionThresholds, edits []TripEdit) bool {
+	boundary := next.StartTime-tripEnd > thresholds.MaxGapS
+	for _, edit := range edits {
+		switch edit.Action {
+		case TripEditSplit:
+			if prev.StartTime < edit.SplitTS && edit.SplitTS <= next.StartTime {
+				boundary = true
+			}
+		case TripEditMerge:
+			if edit.FromTS <= prev.StartTime && next.StartTime <= edit.ToTS {
+				boundary = false
+			}
+		}
+	}
+	return boundary
+}
+
+// constructTrips constructs trips from time-ordered segments and stays,
+// replaying the manual edits
+func constructTrips(segments []Segment, stays []Stay, thresholds TripConstructionThresholds, edits []TripEdit) []Trip {
 	if len(segments) == 0 {
 		return nil
 	}
@@ -202,7 +436,7 @@ func (a *TripConstructionAnalyzer) constructTrips(segments []Segment, stays []St
 	for _, seg := range segments {
 		if currentTrip == nil {
 			// Start new trip
-			date := a.timestampToDate(seg.StartTime)
+			date := timestampToDate(seg.StartTime)
 			tripsByDate[date]++
 
 			currentTrip = &Trip{
@@ -213,18 +447,16 @@ func (a *TripConstructionAnalyzer) constructTrips(segments []Segment, stays []St
 			tripSegments = []Segment{seg}
 		} else {
 			// Check if this segment continues the current trip
-			// Gap threshold: 2 hours
-			gap := seg.StartTime - currentTrip.EndTime
-			if gap <= 7200 {
+			if !tripBoundary(tripSegments[len(tripSegments)-1], seg, currentTrip.EndTime, thresholds, edits) {
 				// Continue current trip
 				tripSegments = append(tripSegments, seg)
 			} else {
 				// End current trip and start new one
-				a.finalizeTrip(currentTrip, tripSegments, stays)
+				finalizeTrip(currentTrip, tripSegments, stays, thresholds)
 				trips = append(trips, *currentTrip)
 
 				// Start new trip
-				date := a.timestampToDate(seg.StartTime)
+				date := timestampToDate(seg.StartTime)
 				tripsByDate[date]++
 
 				currentTrip = &Trip{
@@ -242,7 +474,7 @@ func (a *TripConstructionAnalyzer) constructTrips(segments []Segment, stays []St
 
 	// Finalize last trip
 	if currentTrip != nil {
-		a.finalizeTrip(currentTrip, tripSegments, stays)
+		finalizeTrip(currentTrip, tripSegments, stays, thresholds)
 		trips = append(trips, *currentTrip)
 	}
 
@@ -250,13 +482,13 @@ func (a *TripConstructionAnalyzer) constructTrips(segments []Segment, stays []St
 }
 
 // timestampToDate converts Unix timestamp to YYYY-MM-DD format
-func (a *TripConstructionAnalyzer) timestampToDate(timestamp int64) string {
+func timestampToDate(timestamp int64) string {
 	t := time.Unix(timestamp, 0)
 	return t.Format("2006-01-02")
 }
 
 // finalizeTrip calculates trip statistics
-func (a *TripConstructionAnalyzer) finalizeTrip(trip *Trip, segments []Segment, stays []Stay) {
+func finalizeTrip(trip *Trip, segments []Segment, stays []Stay, thresholds TripConstructionThresholds) {
 	// Calculate total distance
 	totalDistance := 0.0
 	for _, seg := range segments {
@@ -279,24 +511,63 @@ func (a *TripConstructionAnalyzer) finalizeTrip(trip *Trip, segments []Segment,
 	for mode := range modeSet {
 		modes = append(modes, mode)
 	}
+	sort.Strings(modes)
 	modesJSON, _ := json.Marshal(modes)
 	trip.Modes = string(modesJSON)
 
+	// Primary mode: the mode covering the longest distance
+	modeDistance := make(map[string]float64)
+	for _, seg := range segments {
+		modeDistance[seg.Mode] += seg.Distance
+	}
+	trip.PrimaryMode = ""
+	for _, mode := range modes {
+		if trip.PrimaryMode == "" || modeDistance[mode] > modeDistance[trip.PrimaryMode] ||
+			(modeDistance[mode] == modeDistance[trip.PrimaryMode] && mode < trip.PrimaryMode) {
+			trip.PrimaryMode = mode
+		}
+	}
+
+	// Rail lines travelled, longest first
+	lineDistance := make(map[string]float64)
+	var lines []string
+	for _, seg := range segments {
+		if !seg.RailLine.Valid {
+			continue
+		}
+		if _, ok := lineDistance[seg.RailLine.String]; !ok {
+			lines = append(lines, seg.RailLine.String)
+		}
+		lineDistance[seg.RailLine.String] += seg.Distance
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lineDistance[lines[i]] > lineDistance[lines[j]] })
+	trip.RailLines = sql.NullString{}
+	if len(lines) > 0 {
+		linesJSON, _ := json.Marshal(lines)
+		trip.RailLines = sql.NullString{String: string(linesJSON), Valid: true}
+	}
+
+	// Track point IDs bounding the trip, used when no stay is linked
+	trip.StartPointID = segments[0].StartPointID
+	trip.EndPointID = segments[len(segments)-1].EndPointID
+
 	// Try to link to origin and destination stays
 	// Find stay that ends just before trip starts
 	for _, stay := range stays {
-		if stay.EndTime <= trip.StartTime && trip.StartTime-stay.EndTime < 3600 {
+		if stay.EndTime <= trip.StartTime && trip.StartTime-stay.EndTime < thresholds.StayLinkS {
 			trip.OriginStayID = &stay.ID
+			trip.OriginPlaceID = stay.PlaceID
 		}
-		if stay.StartTime >= trip.EndTime && stay.StartTime-trip.EndTime < 3600 {
+		if stay.StartTime >= trip.EndTime && stay.StartTime-trip.EndTime < thresholds.StayLinkS {
 			trip.DestStayID = &stay.ID
+			trip.DestPlaceID = stay.PlaceID
 		}
 	}
 
 	// Create metadata JSON object
 	metadata := map[string]interface{}{
 		"algorithm":      "simple_gap_based",
-		"gap_threshold_s": 7200,
+		"gap_threshold_s": thresholds.MaxGapS,
 		"segment_ids":    []int64{},
 	}
 	for _, seg := range segments {
@@ -306,13 +577,64 @@ func (a *TripConstructionAnalyzer) finalizeTrip(trip *Trip, segments []Segment,
 	trip.Metadata = string(metadataJSON)
 }
 
+// resolveEndpoints fills trip origin/destination from the linked stays,
+// falling back to the first/last track point of the trip
+func (a *TripConstructionAnalyzer) resolveEndpoints(ctx context.Context, trips []Trip, stays []Stay) error {
+	stayByID := make(map[int64]*Stay, len(stays))
+	for i := range stays {
+		stayByID[stays[i].ID] = &stays[i]
+	}
+
+	stmt, err := a.DB.PrepareContext(ctx, `
+		SELECT latitude, longitude, province, city, county
+		FROM "一生足迹"
+		WHERE id = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	resolve := func(stayID *int64, pointID sql.NullInt64) (TripEndpoint, error) {
+		var endpoint TripEndpoint
+		if stayID != nil {
+			if stay, ok := stayByID[*stayID]; ok && stay.CenterLat.Valid && stay.CenterLon.Valid {
+				endpoint.Lat, endpoint.Lon = stay.CenterLat, stay.CenterLon
+				endpoint.Province, endpoint.City, endpoint.County = stay.Province, stay.City, stay.County
+				return endpoint, nil
+			}
+		}
+		if !pointID.Valid {
+			return endpoint, nil
+		}
+		err := stmt.QueryRowContext(ctx, pointID.Int64).Scan(
+			&endpoint.Lat, &endpoint.Lon, &endpoint.Province, &endpoint.City, &endpoint.County,
+		)
+		if err != nil && err != sql.ErrNoRows {
+			return endpoint, fmt.Errorf("failed to query track point: %w", err)
+		}
+		return endpoint, nil
+	}
+
+	for i := range trips {
+		if trips[i].Origin, err = resolve(trips[i].OriginStayID, trips[i].StartPointID); err != nil {
+			return err
+		}
+		if trips[i].Dest, err = resolve(trips[i].DestStayID, trips[i].EndPointID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // insertTrips inserts trips into the database
 func (a *TripConstructionAnalyzer) insertTrips(ctx context.Context, trips []Trip) error {
 	if len(trips) == 0 {
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -321,11 +643,15 @@ func (a *TripConstructionAnalyzer) insertTrips(ctx context.Context, trips []Trip
 	insertQuery := `
 		INSERT INTO trips (
 			date, trip_number,
-			origin_stay_id, dest_stay_id,
+			origin_stay_id, dest_stay_id, origin_place_id, dest_place_id,
 			start_time, end_time, duration_s,
-			distance_m, segment_count, modes, metadata,
+			distance_m, segment_count, modes, rail_lines, metadata,
+			day_type, purpose_ml, confidence_ml, purpose_probs, features_json,
+			primary_mode,
+			origin_lat, origin_lon, origin_province, origin_city, origin_county,
+			dest_lat, dest_lon, dest_province, dest_city, dest_county,
 			algo_version, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1',
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("trip_construction") + `',
 		          CAST(strftime('%s', 'now') AS INTEGER),
 		          CAST(strftime('%s', 'now') AS INTEGER))
 	`
@@ -339,9 +665,13 @@ func (a *TripConstructionAnalyzer) insertTrips(ctx context.Context, trips []Trip
 	for _, trip := range trips {
 		_, err := stmt.ExecContext(ctx,
 			trip.Date, trip.TripNumber,
-			trip.OriginStayID, trip.DestStayID,
+			trip.OriginStayID, trip.DestStayID, trip.OriginPlaceID, trip.DestPlaceID,
 			trip.StartTime, trip.EndTime, trip.Duration,
-			trip.Distance, trip.SegmentCount, trip.Modes, trip.Metadata,
+			trip.Distance, trip.SegmentCount, trip.Modes, trip.RailLines, trip.Metadata,
+			trip.DayType, trip.Purpose, trip.PurposeConfidence, trip.PurposeProbs, trip.Features,
+			trip.PrimaryMode,
+			trip.Origin.Lat, trip.Origin.Lon, trip.Origin.Province, trip.Origin.City, trip.Origin.County,
+			trip.Dest.Lat, trip.Dest.Lon, trip.Dest.Province, trip.Dest.City, trip.Dest.County,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert trip: %w", err)
@@ -359,4 +689,7 @@ func (a *TripConstructionAnalyzer) insertTrips(ctx context.Context, trips []Trip
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("trip_construction", NewTripConstructionAnalyzer)
+	analysis.RegisterVersion("trip_construction", "v4")
+	analysis.RegisterDependencies("trip_construction", "transport_mode", "place_anchor", "rail_lines", "place_clustering")
+	analysis.RegisterOutputs("trip_construction", analysis.Output{Table: "trips"})
 }
\ No newline at end of file
@@ -0,0 +1,133 @@
+package behavior
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConstructTrips(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local).Unix()
+	seg := func(id, start, end int64, mode string, distance float64) Segment {
+		return Segment{
+			ID: id, StartTime: base + start, EndTime: base + end, Mode: mode, Distance: distance, Duration: end - start,
+			StartPointID: sql.NullInt64{Int64: id * 10, Valid: true}, EndPointID: sql.NullInt64{Int64: id*10 + 9, Valid: true},
+		}
+	}
+	segments := []Segment{
+		seg(1, 0, 600, "WALK", 500),
+		seg(2, 900, 1800, "CAR", 10000),
+		seg(3, 9100, 9700, "WALK", 300), // 7300 s after the second segment
+	}
+
+	type want struct {
+		number   int
+		segments int
+		distance float64
+		primary  string
+		modes    string
+	}
+	tests := []struct {
+		name       string
+		segments   []Segment
+		thresholds TripConstructionThresholds
+		edits      []TripEdit
+		want       []want
+	}{
+		{
+			name:       "no segments",
+			thresholds: DefaultTripConstructionThresholds,
+			want:       nil,
+		},
+		{
+			name:       "gap longer than two hours splits trips",
+			segments:   segments,
+			thresholds: DefaultTripConstructionThresholds,
+			want:       []want{{1, 2, 10500, "CAR", `["CAR","WALK"]`}, {2, 1, 300, "WALK", `["WALK"]`}},
+		},
+		{
+			name:       "shorter maximum gap",
+			segments:   segments,
+			thresholds: TripConstructionThresholds{MaxGapS: 120, StayLinkS: 3600},
+			want:       []want{{1, 1, 500, "WALK", `["WALK"]`}, {2, 1, 10000, "CAR", `["CAR"]`}, {3, 1, 300, "WALK", `["WALK"]`}},
+		},
+		{
+			name:       "longer maximum gap",
+			segments:   segments,
+			thresholds: TripConstructionThresholds{MaxGapS: 8000, StayLinkS: 3600},
+			want:       []want{{1, 3, 10800, "CAR", `["CAR","WALK"]`}},
+		},
+		{
+			name:       "split between segments",
+			segments:   segments,
+			thresholds: DefaultTripConstructionThresholds,
+			edits:      []TripEdit{{Action: TripEditSplit, SplitTS: base + 700}},
+			want:       []want{{1, 1, 500, "WALK", `["WALK"]`}, {2, 1, 10000, "CAR", `["CAR"]`}, {3, 1, 300, "WALK", `["WALK"]`}},
+		},
+		{
+			name:       "merge across a long gap",
+			segments:   segments,
+			thresholds: DefaultTripConstructionThresholds,
+			edits:      []TripEdit{{Action: TripEditMerge, FromTS: base + 900, ToTS: base + 9100}},
+			want:       []want{{1, 3, 10800, "CAR", `["CAR","WALK"]`}},
+		},
+		{
+			name:       "later edits override earlier ones",
+			segments:   segments,
+			thresholds: DefaultTripConstructionThresholds,
+			edits: []TripEdit{
+				{Action: TripEditSplit, SplitTS: base + 900},
+				{Action: TripEditMerge, FromTS: base, ToTS: base + 9100},
+			},
+			want: []want{{1, 3, 10800, "CAR", `["CAR","WALK"]`}},
+		},
+		{
+			name:       "edits outside the segments change nothing",
+			segments:   segments,
+			thresholds: DefaultTripConstructionThresholds,
+			edits: []TripEdit{
+				{Action: TripEditSplit, SplitTS: base - 100},
+				{Action: TripEditMerge, FromTS: base + 20000, ToTS: base + 30000},
+			},
+			want: []want{{1, 2, 10500, "CAR", `["CAR","WALK"]`}, {2, 1, 300, "WALK", `["WALK"]`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trips := constructTrips(tt.segments, nil, tt.thresholds, tt.edits)
+			var got []want
+			for _, trip := range trips {
+				got = append(got, want{trip.TripNumber, trip.SegmentCount, trip.Distance, trip.PrimaryMode, trip.Modes})
+				if trip.Date != "2024-03-01" || trip.Duration != trip.EndTime-trip.StartTime {
+					t.Errorf("trip %d: date %s, duration %d", trip.TripNumber, trip.Date, trip.Duration)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("trips = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stays within the link gap become endpoints", func(t *testing.T) {
+		stays := []Stay{
+			{ID: 1, StartTime: base - 7200, EndTime: base - 1000},
+			{ID: 2, StartTime: base + 1800 + 600, EndTime: base + 5000},
+			{ID: 3, StartTime: base + 9700 + 3600, EndTime: base + 20000}, // one hour after the last trip
+		}
+		trips := constructTrips(segments, stays, DefaultTripConstructionThresholds, nil)
+		if len(trips) != 2 {
+			t.Fatalf("got %d trips, want 2", len(trips))
+		}
+		if trips[0].OriginStayID == nil || *trips[0].OriginStayID != 1 || trips[0].DestStayID == nil || *trips[0].DestStayID != 2 {
+			t.Errorf("first trip stays = %v -> %v, want 1 -> 2", trips[0].OriginStayID, trips[0].DestStayID)
+		}
+		if trips[1].DestStayID != nil {
+			t.Errorf("second trip destination = %d, want none", *trips[1].DestStayID)
+		}
+		if trips[1].StartPointID.Int64 != 30 || trips[1].EndPointID.Int64 != 39 {
+			t.Errorf("second trip points = %d-%d, want 30-39", trips[1].StartPointID.Int64, trips[1].EndPointID.Int64)
+		}
+	})
+}
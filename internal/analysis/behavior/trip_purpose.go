@@ -0,0 +1,305 @@
+package behavior
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/calendar"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Trip purposes (same vocabulary as trips.purpose_ml)
+const (
+	PurposeCommute  = "COMMUTE"
+	PurposeWork     = "WORK"
+	PurposeLeisure  = "LEISURE"
+	PurposeShopping = "SHOPPING"
+	PurposeTravel   = "TRAVEL"
+	PurposeOther    = "OTHER"
+)
+
+// tripPurposes fixes the output order of purpose scores
+var tripPurposes = []string{PurposeCommute, PurposeWork, PurposeLeisure, PurposeShopping, PurposeTravel, PurposeOther}
+
+// Purpose inference settings
+const (
+	travelDistanceM      = 100000.0 // Trips longer than this lean towards TRAVEL
+	travelAwayFromHomeM  = 100000.0 // Destinations this far from HOME lean towards TRAVEL
+	chainReturnRadiusM   = 500.0    // Destination within this distance of the previous origin counts as a return leg
+	purposeProbPrecision = 1000.0
+)
+
+// tripAnchor holds a HOME/WORK place anchor used for purpose inference
+type tripAnchor struct {
+	Type       string
+	CenterLat  float64
+	CenterLon  float64
+	RadiusM    float64
+	ActiveFrom sql.NullInt64
+	ActiveTo   sql.NullInt64
+}
+
+// TripPurposeFeatures holds the features used to infer a trip purpose
+type TripPurposeFeatures struct {
+	DayType       string  `json:"day_type"`
+	HolidayName   string  `json:"holiday_name,omitempty"`
+	StartHour     int     `json:"start_hour"`
+	OriginAnchor  string  `json:"origin_anchor,omitempty"`
+	DestAnchor    string  `json:"dest_anchor,omitempty"`
+	DestStayHours float64 `json:"dest_stay_hours,omitempty"`
+	DistanceM     float64 `json:"distance_m"`
+	DestFromHomeM float64 `json:"dest_from_home_m,omitempty"`
+	LongHaulMode  bool    `json:"long_haul_mode"`
+	ReturnLeg     bool    `json:"return_leg"`
+	ChainPurpose  string  `json:"chain_purpose,omitempty"`
+}
+
+// loadPlaceAnchors loads HOME and WORK anchors with known centers
+func (a *TripConstructionAnalyzer) loadPlaceAnchors(ctx context.Context) ([]tripAnchor, error) {
+	query := `
+		SELECT type, center_lat, center_lon, COALESCE(radius_m, 500), active_from_ts, active_to_ts
+		FROM place_anchors
+		WHERE type IN ('HOME', 'WORK')
+			AND center_lat IS NOT NULL AND center_lon IS NOT NULL
+		ORDER BY CASE WHEN source = 'inferred' THEN 1 ELSE 0 END, id
+	`
+
+	rows, err := a.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query place anchors: %w", err)
+	}
+	defer rows.Close()
+
+	var anchors []tripAnchor
+	for rows.Next() {
+		var anchor tripAnchor
+		if err := rows.Scan(&anchor.Type, &anchor.CenterLat, &anchor.CenterLon, &anchor.RadiusM,
+			&anchor.ActiveFrom, &anchor.ActiveTo); err != nil {
+			return nil, fmt.Errorf("failed to scan anchor: %w", err)
+		}
+		anchors = append(anchors, anchor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return anchors, nil
+}
+
+// activeAt checks if the anchor was active at the given timestamp
+func (p tripAnchor) activeAt(ts int64) bool {
+	if p.ActiveFrom.Valid && ts < p.ActiveFrom.Int64 {
+		return false
+	}
+	if p.ActiveTo.Valid && ts >= p.ActiveTo.Int64 {
+		return false
+	}
+	return true
+}
+
+// matchAnchor returns the type of the first anchor covering the stay at the given time
+func matchAnchor(anchors []tripAnchor, stay *Stay, ts int64) string {
+	if stay == nil || !stay.CenterLat.Valid || !stay.CenterLon.Valid {
+		return ""
+	}
+	for _, anchor := range anchors {
+		if !anchor.activeAt(ts) {
+			continue
+		}
+		if spatial.HaversineDistance(anchor.CenterLat, anchor.CenterLon, stay.CenterLat.Float64, stay.CenterLon.Float64) <= anchor.RadiusM {
+			return anchor.Type
+		}
+	}
+	return ""
+}
+
+// homeAnchorAt returns the HOME anchor active at the given time
+func homeAnchorAt(anchors []tripAnchor, ts int64) *tripAnchor {
+	for i := range anchors {
+		if anchors[i].Type == "HOME" && anchors[i].activeAt(ts) {
+			return &anchors[i]
+		}
+	}
+	return nil
+}
+
+// inferPurposes assigns purpose probabilities to trips in chronological order
+// so that each trip can take the previous trip of the same day into account
+func (a *TripConstructionAnalyzer) inferPurposes(trips []Trip, stays []Stay, anchors []tripAnchor) {
+	stayByID := make(map[int64]*Stay, len(stays))
+	for i := range stays {
+		stayByID[stays[i].ID] = &stays[i]
+	}
+
+	lookupStay := func(id *int64) *Stay {
+		if id == nil {
+			return nil
+		}
+		return stayByID[*id]
+	}
+
+	for i := range trips {
+		var prev *Trip
+		if i > 0 && trips[i-1].Date == trips[i].Date {
+			prev = &trips[i-1]
+		}
+
+		features := a.extractPurposeFeatures(&trips[i], prev, lookupStay, anchors)
+		probs := scorePurposes(features)
+
+		best := PurposeOther
+		for _, purpose := range tripPurposes {
+			if probs[purpose] > probs[best] {
+				best = purpose
+			}
+		}
+
+		featuresJSON, _ := json.Marshal(features)
+		probsJSON, _ := json.Marshal(probs)
+
+		trips[i].DayType = features.DayType
+		trips[i].Purpose = best
+		trips[i].PurposeConfidence = probs[best]
+		trips[i].PurposeProbs = string(probsJSON)
+		trips[i].Features = string(featuresJSON)
+	}
+}
+
+// extractPurposeFeatures derives calendar, anchor and chaining features for a trip
+func (a *TripConstructionAnalyzer) extractPurposeFeatures(trip *Trip, prev *Trip, lookupStay func(*int64) *Stay, anchors []tripAnchor) TripPurposeFeatures {
+	start := time.Unix(trip.StartTime, 0)
+
+	features := TripPurposeFeatures{
+		DayType:     calendar.DayType(start),
+		HolidayName: calendar.HolidayName(start),
+		StartHour:   start.Hour(),
+		DistanceM:   trip.Distance,
+	}
+
+	var modes []string
+	json.Unmarshal([]byte(trip.Modes), &modes)
+	for _, mode := range modes {
+		if mode == "TRAIN" || mode == "FLIGHT" {
+			features.LongHaulMode = true
+		}
+	}
+
+	origin := lookupStay(trip.OriginStayID)
+	dest := lookupStay(trip.DestStayID)
+
+	features.OriginAnchor = matchAnchor(anchors, origin, trip.StartTime)
+	features.DestAnchor = matchAnchor(anchors, dest, trip.EndTime)
+
+	if dest != nil {
+		features.DestStayHours = float64(dest.Duration) / 3600.0
+		if home := homeAnchorAt(anchors, trip.EndTime); home != nil && dest.CenterLat.Valid && dest.CenterLon.Valid {
+			features.DestFromHomeM = math.Round(spatial.HaversineDistance(home.CenterLat, home.CenterLon, dest.CenterLat.Float64, dest.CenterLon.Float64))
+		}
+	}
+
+	// Trip chaining: the previous trip ended where this one starts
+	if prev != nil && prev.DestStayID != nil && trip.OriginStayID != nil && *prev.DestStayID == *trip.OriginStayID {
+		features.ChainPurpose = prev.Purpose
+		prevOrigin := lookupStay(prev.OriginStayID)
+		if prevOrigin != nil && dest != nil && prevOrigin.CenterLat.Valid && dest.CenterLat.Valid &&
+			spatial.HaversineDistance(prevOrigin.CenterLat.Float64, prevOrigin.CenterLon.Float64, dest.CenterLat.Float64, dest.CenterLon.Float64) <= chainReturnRadiusM {
+			features.ReturnLeg = true
+		}
+	}
+
+	return features
+}
+
+// scorePurposes turns trip features into a probability distribution over purposes
+// Each rule adds evidence to purpose scores, which are normalised with softmax
+func scorePurposes(f TripPurposeFeatures) map[string]float64 {
+	scores := map[string]float64{
+		PurposeCommute:  0,
+		PurposeWork:     0,
+		PurposeLeisure:  0,
+		PurposeShopping: 0,
+		PurposeTravel:   0,
+		PurposeOther:    0.5,
+	}
+
+	workday := f.DayType == calendar.DayTypeWorkday
+	peakHour := (f.StartHour >= 7 && f.StartHour < 10) || (f.StartHour >= 17 && f.StartHour < 20)
+
+	// Anchor proximity
+	homeWork := (f.OriginAnchor == "HOME" && f.DestAnchor == "WORK") || (f.OriginAnchor == "WORK" && f.DestAnchor == "HOME")
+	switch {
+	case homeWork && workday:
+		scores[PurposeCommute] += 3
+	case homeWork:
+		scores[PurposeCommute] += 1.5
+		scores[PurposeWork] += 0.5
+	case f.DestAnchor == "WORK":
+		if workday {
+			scores[PurposeWork] += 2
+		} else {
+			scores[PurposeWork] += 1
+		}
+	case f.OriginAnchor == "WORK":
+		scores[PurposeWork] += 1
+		scores[PurposeLeisure] += 0.5
+	case f.DestAnchor == "HOME":
+		scores[PurposeLeisure] += 0.5
+	}
+
+	if workday && peakHour {
+		scores[PurposeCommute] += 1
+	}
+
+	// Calendar
+	switch f.DayType {
+	case calendar.DayTypeWeekend:
+		scores[PurposeLeisure] += 1.5
+		scores[PurposeShopping] += 0.5
+	case calendar.DayTypeHoliday:
+		scores[PurposeLeisure] += 1.5
+		scores[PurposeTravel] += 1
+	}
+
+	// Short daytime stop at a non-anchor destination
+	if f.DestAnchor == "" && f.DestStayHours >= 0.5 && f.DestStayHours <= 3 && f.StartHour >= 10 && f.StartHour < 20 {
+		scores[PurposeShopping] += 0.8
+		scores[PurposeLeisure] += 0.3
+	}
+
+	// Long distance
+	if f.DistanceM >= travelDistanceM {
+		scores[PurposeTravel] += 2.5
+	}
+	if f.DestFromHomeM >= travelAwayFromHomeM {
+		scores[PurposeTravel] += 1.5
+	}
+	if f.LongHaulMode {
+		scores[PurposeTravel] += 1.5
+	}
+
+	// Trip chaining: the return leg of an outing shares its purpose
+	if f.ReturnLeg && f.ChainPurpose != "" && f.ChainPurpose != PurposeOther {
+		scores[f.ChainPurpose] += 1
+	}
+
+	return softmax(scores)
+}
+
+// softmax normalises scores into probabilities rounded to 3 decimals
+func softmax(scores map[string]float64) map[string]float64 {
+	total := 0.0
+	for _, score := range scores {
+		total += math.Exp(score)
+	}
+
+	probs := make(map[string]float64, len(scores))
+	for purpose, score := range scores {
+		probs[purpose] = math.Round(math.Exp(score)/total*purposeProbPrecision) / purposeProbPrecision
+	}
+	return probs
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+)
+
+// EmissionFactors maps transport modes to their emission factor (g CO2e per km)
+type EmissionFactors map[string]float64
+
+// Factor returns the emission factor of a mode; modes without one emit nothing
+func (f EmissionFactors) Factor(mode string) float64 {
+	return f[mode]
+}
+
+// CO2Kg returns the CO2-equivalent (kg) of travelling distanceM meters by mode
+func (f EmissionFactors) CO2Kg(mode string, distanceM float64) float64 {
+	return distanceM / 1000 * f.Factor(mode) / 1000
+}
+
+// LoadEmissionFactors loads the emissions model from the emission_factors table
+// The factors are edited through the emission factors API, so analyzers
+// estimating CO2 share one model.
+func (a *BaseAnalyzer) LoadEmissionFactors(ctx context.Context) (EmissionFactors, error) {
+	rows, err := a.DB.QueryContext(ctx, `SELECT mode, g_co2_per_km FROM emission_factors`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emission factors: %w", err)
+	}
+	defer rows.Close()
+
+	factors := make(EmissionFactors)
+	for rows.Next() {
+		var mode string
+		var factor float64
+		if err := rows.Scan(&mode, &factor); err != nil {
+			return nil, fmt.Errorf("failed to scan emission factor: %w", err)
+		}
+		factors[mode] = factor
+	}
+	return factors, rows.Err()
+}
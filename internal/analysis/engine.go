@@ -3,6 +3,9 @@ package analysis
 import (
 	"context"
 	"database/sql"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/database"
 )
 
 // Analyzer is the interface that all analysis skills must implement
@@ -29,20 +32,52 @@ type Progress struct {
 	Message    string  // Optional progress message
 }
 
+// sharedWriter serializes the writes of all analyzers (see SetWriter)
+var sharedWriter *database.Writer
+
+// SetWriter routes the writes of analyzers created afterwards through w.
+// Without a writer, analyzers write directly to their DB handle.
+func SetWriter(w *database.Writer) {
+	sharedWriter = w
+}
+
 // BaseAnalyzer provides common functionality for all analyzers
+// DB is used for reads; writes go through BeginWriteTx and ExecWrite
 type BaseAnalyzer struct {
-	DB   *sql.DB
-	Name string
+	DB     *sql.DB
+	Writer *database.Writer
+	Name   string
 }
 
 // NewBaseAnalyzer creates a new base analyzer
 func NewBaseAnalyzer(db *sql.DB, name string) *BaseAnalyzer {
 	return &BaseAnalyzer{
-		DB:   db,
-		Name: name,
+		DB:     db,
+		Writer: sharedWriter,
+		Name:   name,
 	}
 }
 
+// BeginWriteTx starts a write transaction, waiting for its turn in the writer queue
+func (a *BaseAnalyzer) BeginWriteTx(ctx context.Context) (*database.Tx, error) {
+	if a.Writer != nil {
+		return a.Writer.BeginTx(ctx)
+	}
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return database.WrapTx(tx), nil
+}
+
+// ExecWrite executes a single write statement through the writer queue
+func (a *BaseAnalyzer) ExecWrite(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if a.Writer != nil {
+		return a.Writer.ExecContext(ctx, query, args...)
+	}
+	return a.DB.ExecContext(ctx, query, args...)
+}
+
 // GetName returns the analyzer name
 func (a *BaseAnalyzer) GetName() string {
 	return a.Name
@@ -65,7 +100,8 @@ func (a *BaseAnalyzer) UpdateTaskProgress(taskID int64, processed, total, failed
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, processed, total, failed, percent, taskID)
+	_, err := a.ExecWrite(context.Background(), query, processed, total, failed, percent, taskID)
+	recordProgress(taskID, int64(processed))
 	return err
 }
 
@@ -79,7 +115,8 @@ func (a *BaseAnalyzer) MarkTaskAsRunning(taskID int64) error {
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, taskID)
+	_, err := a.ExecWrite(context.Background(), query, taskID)
+	StartRun(taskID)
 	return err
 }
 
@@ -94,7 +131,8 @@ func (a *BaseAnalyzer) MarkTaskAsCompleted(taskID int64) error {
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, taskID)
+	_, err := a.ExecWrite(context.Background(), query, taskID)
+	FinishRun(taskID, a.Name, "completed")
 	return err
 }
 
@@ -109,7 +147,8 @@ func (a *BaseAnalyzer) MarkTaskAsFailed(taskID int64, errorMsg string) error {
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, errorMsg, taskID)
+	_, err := a.ExecWrite(context.Background(), query, errorMsg, taskID)
+	FinishRun(taskID, a.Name, "failed")
 	return err
 }
 
@@ -176,10 +215,44 @@ func RegisterAnalyzer(skillName string, factory AnalyzerFactory) {
 	AnalyzerRegistry[skillName] = factory
 }
 
+var (
+	// disabledAnalyzers holds the disabled skill names
+	disabledAnalyzers   = make(map[string]bool)
+	disabledAnalyzersMu sync.RWMutex
+)
+
+// SetDisabledAnalyzers replaces the disabled skills, so no tasks are run for
+// them; tasks already running finish
+// Names that are not registered are ignored and returned
+func SetDisabledAnalyzers(skillNames ...string) []string {
+	disabled := make(map[string]bool)
+	var unknown []string
+	for _, name := range skillNames {
+		if _, ok := AnalyzerRegistry[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		disabled[name] = true
+	}
+
+	disabledAnalyzersMu.Lock()
+	disabledAnalyzers = disabled
+	disabledAnalyzersMu.Unlock()
+	return unknown
+}
+
+// IsAnalyzerEnabled checks if a skill has not been disabled
+func IsAnalyzerEnabled(skillName string) bool {
+	disabledAnalyzersMu.RLock()
+	defer disabledAnalyzersMu.RUnlock()
+	return !disabledAnalyzers[skillName]
+}
+
 // GetAnalyzer retrieves an analyzer instance for a skill name
+// Returns nil for unknown or disabled skills
 func GetAnalyzer(skillName string, db *sql.DB) Analyzer {
 	factory, ok := AnalyzerRegistry[skillName]
-	if !ok {
+	if !ok || !IsAnalyzerEnabled(skillName) {
 		return nil
 	}
 	return factory(db)
@@ -0,0 +1,234 @@
+package foundation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+// SpeedPoint is a track point position used to compute speed
+type SpeedPoint struct {
+	ID        int64
+	Timestamp int64
+	Lat       float64
+	Lon       float64
+}
+
+// ComputedSpeedThresholds defines configurable parameters for speed computation
+// Loaded from the "computed_speed" section of the active threshold profile
+type ComputedSpeedThresholds struct {
+	SmoothingWindow int     `json:"smoothing_window"` // 2 points on each side of the rolling median
+	MaxGapS         int64   `json:"max_gap_s"`        // 300 s: no speed is computed across longer gaps
+	MaxSpeedMPS     float64 `json:"max_speed_mps"`    // 277.78 m/s: faster implied speeds are discarded
+}
+
+// DefaultComputedSpeedThresholds provides default speed computation parameters
+var DefaultComputedSpeedThresholds = ComputedSpeedThresholds{
+	SmoothingWindow: 2,
+	MaxGapS:         300,
+	MaxSpeedMPS:     277.78, // same ceiling as outlier detection
+}
+
+// ComputedSpeedAnalyzer implements speed recomputation from positions
+// Skill: 速度补算 (Computed Speed)
+// Backfills computed_speed for datasets whose devices report no speed
+type ComputedSpeedAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds ComputedSpeedThresholds
+}
+
+// NewComputedSpeedAnalyzer creates a new computed speed analyzer
+func NewComputedSpeedAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &ComputedSpeedAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "computed_speed", 10000),
+		Thresholds:          DefaultComputedSpeedThresholds,
+	}
+}
+
+// Analyze computes the speed of every non-outlier point from its neighbours
+// Run after outlier detection: bad fixes would otherwise produce speed spikes.
+func (a *ComputedSpeedAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[ComputedSpeedAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultComputedSpeedThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, dataTime, latitude, longitude
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY dataTime, id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	var points []SpeedPoint
+	for rows.Next() {
+		var point SpeedPoint
+		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate points: %w", err)
+	}
+	rows.Close()
+
+	if len(points) < 2 {
+		log.Printf("[ComputedSpeedAnalyzer] Not enough points to process")
+		return a.MarkTaskAsCompleted(taskID, `{"computed_points": 0}`)
+	}
+
+	log.Printf("[ComputedSpeedAnalyzer] Processing %d points", len(points))
+
+	// Update task with total count
+	if err := a.UpdateTaskProgress(taskID, int64(len(points)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	speeds := computeSpeeds(points, a.Thresholds)
+
+	computed, err := a.writeComputedSpeeds(ctx, points, speeds)
+	if err != nil {
+		return fmt.Errorf("failed to write computed speeds: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_points":    len(points),
+		"computed_points": computed,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[ComputedSpeedAnalyzer] Analysis completed: %d points processed, %d speeds computed", len(points), computed)
+	return nil
+}
+
+// computeSpeeds returns the smoothed implied speed (m/s) of each point; NaN where none can be computed
+// The raw speed of a point is implied by the distance and time from its
+// predecessor, or from its successor when the predecessor is missing, more than
+// MaxGapS away or at the same timestamp. Raw speeds above MaxSpeedMPS are
+// discarded. Each point then takes the median of the raw speeds within
+// SmoothingWindow points on each side, never across a gap: a noisy fix spikes
+// the raw speed into and out of it, which the median of five outvotes.
+func computeSpeeds(points []SpeedPoint, t ComputedSpeedThresholds) []float64 {
+	n := len(points)
+
+	// connected[i] reports whether points i and i+1 belong to the same run
+	connected := make([]bool, n)
+	for i := 0; i+1 < n; i++ {
+		connected[i] = points[i+1].Timestamp-points[i].Timestamp <= t.MaxGapS
+	}
+
+	raw := make([]float64, n)
+	for i, p := range points {
+		raw[i] = math.NaN()
+
+		// From the predecessor, or from the successor at the start of a run
+		var other SpeedPoint
+		switch {
+		case i > 0 && connected[i-1] && p.Timestamp > points[i-1].Timestamp:
+			other = points[i-1]
+		case i+1 < n && connected[i] && points[i+1].Timestamp > p.Timestamp:
+			other = points[i+1]
+		default:
+			continue
+		}
+
+		dt := math.Abs(float64(p.Timestamp - other.Timestamp))
+		if speed := haversineDistance(other.Lat, other.Lon, p.Lat, p.Lon) / dt; speed <= t.MaxSpeedMPS {
+			raw[i] = speed
+		}
+	}
+
+	half := max(t.SmoothingWindow, 0)
+	speeds := make([]float64, n)
+	window := make([]float64, 0, 2*half+1)
+	for i := range points {
+		window = window[:0]
+		if !math.IsNaN(raw[i]) {
+			window = append(window, raw[i])
+		}
+		for j := i - 1; j >= 0 && j >= i-half && connected[j]; j-- {
+			if !math.IsNaN(raw[j]) {
+				window = append(window, raw[j])
+			}
+		}
+		for j := i + 1; j < n && j <= i+half && connected[j-1]; j++ {
+			if !math.IsNaN(raw[j]) {
+				window = append(window, raw[j])
+			}
+		}
+
+		speeds[i] = math.NaN()
+		if len(window) > 0 {
+			speeds[i] = stats.Median(window)
+		}
+	}
+	return speeds
+}
+
+// writeComputedSpeeds stores the computed speeds and returns how many were set
+// Points without a computed speed are set to NULL.
+func (a *ComputedSpeedAnalyzer) writeComputedSpeeds(ctx context.Context, points []SpeedPoint, speeds []float64) (int, error) {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE "一生足迹" SET computed_speed = ? WHERE id = ?`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	computed := 0
+	for i, p := range points {
+		var speed sql.NullFloat64
+		if !math.IsNaN(speeds[i]) {
+			speed = sql.NullFloat64{Float64: speeds[i], Valid: true}
+			computed++
+		}
+		if _, err := stmt.ExecContext(ctx, speed, p.ID); err != nil {
+			return 0, fmt.Errorf("failed to update computed speed for id %d: %w", p.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return computed, nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("computed_speed", NewComputedSpeedAnalyzer)
+	analysis.RegisterDependencies("computed_speed", "outlier_detection")
+	analysis.RegisterOutputs("computed_speed", analysis.Output{
+		Table: "一生足迹",
+		Set:   "computed_speed = NULL",
+		Where: "computed_speed IS NOT NULL",
+	})
+}
@@ -0,0 +1,83 @@
+package foundation
+
+import (
+	"math"
+	"testing"
+)
+
+// speedPoints turns points built with at into speed points
+func speedPoints(points ...OutlierPoint) []SpeedPoint {
+	out := make([]SpeedPoint, len(points))
+	for i, p := range points {
+		out[i] = SpeedPoint{ID: int64(i + 1), Timestamp: p.Timestamp, Lat: p.Lat, Lon: p.Lon}
+	}
+	return out
+}
+
+func TestComputeSpeeds(t *testing.T) {
+	thresholds := DefaultComputedSpeedThresholds
+
+	t.Run("constant walk", func(t *testing.T) {
+		// 1.5 m/s north, one fix every 10 s
+		var points []OutlierPoint
+		for i := 0; i < 6; i++ {
+			points = append(points, at(int64(i*10), float64(i*15), 0, 0, 0))
+		}
+		for i, got := range computeSpeeds(speedPoints(points...), thresholds) {
+			if math.Abs(got-1.5) > 0.05 {
+				t.Errorf("point %d: speed = %.3f, want 1.5", i, got)
+			}
+		}
+	})
+
+	t.Run("noisy fix is smoothed", func(t *testing.T) {
+		points := speedPoints(
+			at(0, 0, 0, 0, 0),
+			at(10, 10, 0, 0, 0),
+			at(20, 20, 0, 0, 0),
+			at(30, 30, 300, 0, 0), // 300 m off to the east
+			at(40, 40, 0, 0, 0),
+			at(50, 50, 0, 0, 0),
+			at(60, 60, 0, 0, 0),
+			at(70, 70, 0, 0, 0),
+			at(80, 80, 0, 0, 0),
+		)
+		speeds := computeSpeeds(points, thresholds)
+		for i, got := range speeds {
+			if got > 5 {
+				t.Errorf("point %d: speed = %.3f, want the spike smoothed out", i, got)
+			}
+		}
+	})
+
+	t.Run("no speed across gaps", func(t *testing.T) {
+		points := speedPoints(
+			at(0, 0, 0, 0, 0),
+			at(10, 10, 0, 0, 0),
+			at(5000, 5000, 0, 0, 0), // isolated by gaps on both sides
+			at(10000, 10000, 0, 0, 0),
+			at(10010, 10020, 0, 0, 0),
+		)
+		speeds := computeSpeeds(points, thresholds)
+		if !math.IsNaN(speeds[2]) {
+			t.Errorf("isolated point: speed = %.3f, want NaN", speeds[2])
+		}
+		if math.Abs(speeds[0]-1) > 0.05 || math.Abs(speeds[4]-2) > 0.05 {
+			t.Errorf("runs: speeds = %v, want 1 and 2 m/s", speeds)
+		}
+	})
+
+	t.Run("duplicate timestamps and implausible jumps", func(t *testing.T) {
+		points := speedPoints(
+			at(0, 0, 0, 0, 0),
+			at(0, 0, 0, 0, 0),
+			at(1, 500000, 0, 0, 0), // 500 km in 1 s
+		)
+		speeds := computeSpeeds(points, thresholds)
+		for i, got := range speeds {
+			if !math.IsNaN(got) {
+				t.Errorf("point %d: speed = %.3f, want NaN", i, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package foundation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/geocode"
+)
+
+// CountryBackfillAnalyzer fills in the country and world region of track points
+// Skill: 国家与地区回填 (Country Lookup)
+// Points with a province take the country of their division; the others are
+// resolved by the coarse offline country lookup. Incremental mode only touches
+// points without a country; full mode resolves everything again.
+type CountryBackfillAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewCountryBackfillAnalyzer creates a new country backfill analyzer
+func NewCountryBackfillAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &CountryBackfillAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "country_backfill", 5000),
+	}
+}
+
+// countryUpdate is a resolved point waiting to be written
+type countryUpdate struct {
+	ID      int64
+	Country geocode.Country
+}
+
+// Analyze performs the country backfill
+func (a *CountryBackfillAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[CountryBackfillAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	condition := "latitude IS NOT NULL AND longitude IS NOT NULL"
+	if mode != "full" {
+		condition += " AND (country IS NULL OR country = '')"
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "一生足迹" WHERE %s`, condition)
+	if err := a.DB.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return fmt.Errorf("failed to count points: %w", err)
+	}
+	log.Printf("[CountryBackfillAnalyzer] %d points to resolve", total)
+
+	batchQuery := fmt.Sprintf(`
+		SELECT id, latitude, longitude, COALESCE(province, '')
+		FROM "一生足迹"
+		WHERE %s AND id > ?
+		ORDER BY id
+		LIMIT ?
+	`, condition)
+
+	var lastID, processed, resolved, failed int64
+	countryCounts := make(map[string]int64)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rows, err := a.DB.QueryContext(ctx, batchQuery, lastID, a.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to query points: %w", err)
+		}
+
+		var updates []countryUpdate
+		batchSize := 0
+		for rows.Next() {
+			var id int64
+			var lat, lon float64
+			var province string
+			if err := rows.Scan(&id, &lat, &lon, &province); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan point: %w", err)
+			}
+			lastID = id
+			batchSize++
+
+			country, ok := geocode.CountryOfDivision(geocode.AdminDivision{Province: province})
+			if !ok {
+				country, ok = geocode.LookupCountry(lat, lon)
+			}
+			if !ok {
+				failed++
+				continue
+			}
+			countryCounts[country.Code]++
+			updates = append(updates, countryUpdate{ID: id, Country: country})
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("error iterating points: %w", err)
+		}
+		rows.Close()
+
+		if batchSize == 0 {
+			break
+		}
+
+		if err := a.writeUpdates(ctx, updates); err != nil {
+			return err
+		}
+		resolved += int64(len(updates))
+		processed += int64(batchSize)
+
+		if err := a.UpdateTaskProgress(taskID, total, processed, failed); err != nil {
+			log.Printf("[CountryBackfillAnalyzer] Warning: failed to update progress: %v", err)
+		}
+	}
+
+	summary := map[string]interface{}{
+		"resolved_points":   resolved,
+		"unresolved_points": failed,
+		"countries":         countryCounts,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[CountryBackfillAnalyzer] Analysis completed: %d points resolved (%d unresolved) in %d countries",
+		resolved, failed, len(countryCounts))
+	return nil
+}
+
+// writeUpdates stores resolved countries in one transaction
+func (a *CountryBackfillAnalyzer) writeUpdates(ctx context.Context, updates []countryUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `UPDATE "一生足迹" SET country = ?, region = ? WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, u := range updates {
+		if _, err := stmt.ExecContext(ctx, u.Country.Code, nullIfEmpty(u.Country.Region), u.ID); err != nil {
+			return fmt.Errorf("failed to update point %d: %w", u.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("country_backfill", NewCountryBackfillAnalyzer)
+}
@@ -0,0 +1,360 @@
+package foundation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/elevation"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+// Altitude sources recorded in altitude_source; NULL means the device's reading is kept
+const (
+	AltitudeSourceDEMFilled    = "DEM_FILLED"
+	AltitudeSourceDEMCorrected = "DEM_CORRECTED"
+)
+
+// elevationLookupBatch is the number of points looked up per provider call
+const elevationLookupBatch = 1000
+
+// ElevationThresholds defines configurable parameters for the elevation backfill
+// Loaded from the "elevation_backfill" section of the active threshold profile
+type ElevationThresholds struct {
+	MaxDeviationM   float64 `json:"max_deviation_m"`   // 50 m: readings farther from the terrain are replaced
+	MaxOffsetM      float64 `json:"max_offset_m"`      // 100 m: segments biased by more are replaced entirely
+	MinOffsetPoints int     `json:"min_offset_points"` // 5 readings needed to estimate a segment's offset
+}
+
+// DefaultElevationThresholds provides default elevation backfill parameters
+var DefaultElevationThresholds = ElevationThresholds{
+	MaxDeviationM:   50,
+	MaxOffsetM:      100,
+	MinOffsetPoints: 5,
+}
+
+// ElevationPoint is a track point whose altitude is checked against the terrain
+type ElevationPoint struct {
+	ID        int64
+	Timestamp int64
+	Lat       float64
+	Lon       float64
+	Altitude  float64 // NaN when the device reported none
+	DEM       float64 // Terrain elevation, NaN outside the provider's coverage
+	Segment   int     // Index of the covering segment, -1 outside segments
+}
+
+// altitudeFix is the corrected altitude of a point; Source is empty when the reading is kept
+type altitudeFix struct {
+	Altitude float64
+	Source   string
+}
+
+// ElevationBackfillAnalyzer implements elevation enrichment from a DEM
+// Skill: 高程补全 (Elevation Backfill)
+// Fills missing altitudes and replaces implausible ones with the terrain
+// elevation, so altitude statistics and altitude events get usable ascent and
+// descent figures. Replaced readings are kept in altitude_raw.
+// Incremental mode only touches points not checked yet; full mode restores the
+// device readings and checks everything again.
+type ElevationBackfillAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds ElevationThresholds
+}
+
+// NewElevationBackfillAnalyzer creates a new elevation backfill analyzer
+func NewElevationBackfillAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &ElevationBackfillAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "elevation_backfill", elevationLookupBatch),
+		Thresholds:          DefaultElevationThresholds,
+	}
+}
+
+// Analyze performs the elevation backfill
+func (a *ElevationBackfillAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[ElevationBackfillAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	provider := elevation.DefaultProvider()
+	if provider == nil {
+		return elevation.ErrNoProvider
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultElevationThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	points, err := a.loadUncheckedPoints(ctx)
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		log.Printf("[ElevationBackfillAnalyzer] No points to process")
+		return a.MarkTaskAsCompleted(taskID, `{"checked_points": 0}`)
+	}
+
+	log.Printf("[ElevationBackfillAnalyzer] Processing %d points with %s", len(points), provider.Name())
+
+	// Update task with total count
+	if err := a.UpdateTaskProgress(taskID, int64(len(points)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	unresolved, err := a.lookupElevations(ctx, taskID, provider, points)
+	if err != nil {
+		return err
+	}
+
+	if err := a.loadPointSegments(ctx, points); err != nil {
+		return fmt.Errorf("failed to load point segments: %w", err)
+	}
+	fixes := fixAltitudes(points, a.Thresholds)
+
+	filled, corrected, err := a.writeAltitudes(ctx, points, fixes)
+	if err != nil {
+		return fmt.Errorf("failed to write altitudes: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"provider":          provider.Name(),
+		"checked_points":    len(points) - unresolved,
+		"unresolved_points": unresolved,
+		"filled_points":     filled,
+		"corrected_points":  corrected,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[ElevationBackfillAnalyzer] Analysis completed: %d points checked (%d unresolved), %d filled, %d corrected",
+		len(points)-unresolved, unresolved, filled, corrected)
+	return nil
+}
+
+// loadUncheckedPoints loads the non-outlier points without a terrain elevation, in time order
+func (a *ElevationBackfillAnalyzer) loadUncheckedPoints(ctx context.Context) ([]ElevationPoint, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT id, dataTime, latitude, longitude, altitude
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+			AND dem_elevation IS NULL
+		ORDER BY dataTime, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	var points []ElevationPoint
+	for rows.Next() {
+		var point ElevationPoint
+		var altitude sql.NullFloat64
+		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &altitude); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		point.Altitude = math.NaN()
+		if altitude.Valid {
+			point.Altitude = altitude.Float64
+		}
+		point.DEM = math.NaN()
+		point.Segment = -1
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
+
+// lookupElevations sets the terrain elevation of the points and returns how many have none
+func (a *ElevationBackfillAnalyzer) lookupElevations(ctx context.Context, taskID int64, provider elevation.Provider, points []ElevationPoint) (int, error) {
+	unresolved := 0
+	coords := make([]spatial.Point, 0, elevationLookupBatch)
+	for start := 0; start < len(points); start += elevationLookupBatch {
+		batch := points[start:min(start+elevationLookupBatch, len(points))]
+
+		coords = coords[:0]
+		for _, p := range batch {
+			coords = append(coords, spatial.Point{Lat: p.Lat, Lon: p.Lon})
+		}
+		elevations, err := provider.Elevations(ctx, coords)
+		if err != nil {
+			return 0, fmt.Errorf("failed to look up elevations: %w", err)
+		}
+
+		for i, e := range elevations {
+			batch[i].DEM = e
+			if math.IsNaN(e) {
+				unresolved++
+			}
+		}
+
+		if err := a.UpdateTaskProgress(taskID, int64(len(points)), int64(start+len(batch)), int64(unresolved)); err != nil {
+			log.Printf("[ElevationBackfillAnalyzer] Warning: failed to update progress: %v", err)
+		}
+	}
+	return unresolved, nil
+}
+
+// loadPointSegments sets the index of the segment covering each point
+// points must be in time order; points outside any segment keep -1.
+func (a *ElevationBackfillAnalyzer) loadPointSegments(ctx context.Context, points []ElevationPoint) error {
+	rows, err := a.DB.QueryContext(ctx, `SELECT start_time, end_time FROM segments ORDER BY start_time`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	i, segment := 0, 0
+	for rows.Next() {
+		var start, end int64
+		if err := rows.Scan(&start, &end); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		for i < len(points) && points[i].Timestamp < start {
+			i++
+		}
+		for j := i; j < len(points) && points[j].Timestamp <= end; j++ {
+			points[j].Segment = segment
+		}
+		segment++
+	}
+	return rows.Err()
+}
+
+// fixAltitudes decides the altitude of each point, segment by segment
+// A barometric altimeter is often offset by a roughly constant amount over a
+// segment, so the median difference between readings and terrain is the
+// segment's offset. Missing readings are filled with terrain plus offset, and
+// readings farther than MaxDeviationM from it are corrected to it. Without
+// MinOffsetPoints readings to estimate it, or when it exceeds MaxOffsetM and
+// the altimeter is no usable baseline, the offset is 0 and readings are
+// compared with the terrain itself. Points outside segments are checked one
+// by one with no offset.
+func fixAltitudes(points []ElevationPoint, t ElevationThresholds) []altitudeFix {
+	fixes := make([]altitudeFix, len(points))
+
+	for start := 0; start < len(points); {
+		end := start + 1
+		if points[start].Segment >= 0 {
+			for end < len(points) && points[end].Segment == points[start].Segment {
+				end++
+			}
+		}
+		group := points[start:end]
+
+		offset := 0.0
+		if points[start].Segment >= 0 {
+			var diffs []float64
+			for _, p := range group {
+				if !math.IsNaN(p.Altitude) && !math.IsNaN(p.DEM) {
+					diffs = append(diffs, p.Altitude-p.DEM)
+				}
+			}
+			if len(diffs) >= t.MinOffsetPoints {
+				offset = stats.Median(diffs)
+			}
+			if math.Abs(offset) > t.MaxOffsetM {
+				offset = 0
+			}
+		}
+
+		for i, p := range group {
+			fixes[start+i] = fixAltitude(p, offset, t)
+		}
+		start = end
+	}
+	return fixes
+}
+
+// fixAltitude decides the altitude of one point given its segment's offset from the terrain
+func fixAltitude(p ElevationPoint, offset float64, t ElevationThresholds) altitudeFix {
+	if math.IsNaN(p.DEM) {
+		return altitudeFix{Altitude: p.Altitude}
+	}
+	expected := p.DEM + offset
+	switch {
+	case math.IsNaN(p.Altitude):
+		return altitudeFix{Altitude: expected, Source: AltitudeSourceDEMFilled}
+	case math.Abs(p.Altitude-expected) > t.MaxDeviationM:
+		return altitudeFix{Altitude: expected, Source: AltitudeSourceDEMCorrected}
+	default:
+		return altitudeFix{Altitude: p.Altitude}
+	}
+}
+
+// writeAltitudes stores the terrain elevations and fixed altitudes, and returns
+// the number of filled and corrected points
+// Points without a terrain elevation are left unchecked so a later run retries them.
+func (a *ElevationBackfillAnalyzer) writeAltitudes(ctx context.Context, points []ElevationPoint, fixes []altitudeFix) (filled, corrected int, err error) {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	checkStmt, err := tx.PrepareContext(ctx, `UPDATE "一生足迹" SET dem_elevation = ? WHERE id = ?`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer checkStmt.Close()
+
+	fixStmt, err := tx.PrepareContext(ctx, `
+		UPDATE "一生足迹"
+		SET dem_elevation = ?, altitude_raw = altitude, altitude = ?, altitude_source = ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer fixStmt.Close()
+
+	for i, p := range points {
+		if math.IsNaN(p.DEM) {
+			continue
+		}
+
+		fix := fixes[i]
+		switch fix.Source {
+		case "":
+			_, err = checkStmt.ExecContext(ctx, p.DEM, p.ID)
+		case AltitudeSourceDEMFilled:
+			filled++
+			_, err = fixStmt.ExecContext(ctx, p.DEM, fix.Altitude, fix.Source, p.ID)
+		default:
+			corrected++
+			_, err = fixStmt.ExecContext(ctx, p.DEM, fix.Altitude, fix.Source, p.ID)
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to update altitude for id %d: %w", p.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return filled, corrected, nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("elevation_backfill", NewElevationBackfillAnalyzer)
+	analysis.RegisterDependencies("elevation_backfill", "outlier_detection")
+	analysis.RegisterOutputs("elevation_backfill", analysis.Output{
+		// Restores the device readings
+		Table: "一生足迹",
+		Set:   "altitude = CASE WHEN altitude_source IS NOT NULL THEN altitude_raw ELSE altitude END, altitude_raw = NULL, altitude_source = NULL, dem_elevation = NULL",
+		Where: "dem_elevation IS NOT NULL OR altitude_source IS NOT NULL",
+	})
+}
@@ -0,0 +1,73 @@
+package foundation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixAltitudes(t *testing.T) {
+	nan := math.NaN()
+	thresholds := DefaultElevationThresholds
+
+	// elevationPoints builds a segment of points from device altitudes and terrain elevations
+	elevationPoints := func(segment int, altitudes, dem []float64) []ElevationPoint {
+		points := make([]ElevationPoint, len(altitudes))
+		for i := range altitudes {
+			points[i] = ElevationPoint{ID: int64(i + 1), Altitude: altitudes[i], DEM: dem[i], Segment: segment}
+		}
+		return points
+	}
+
+	tests := []struct {
+		name    string
+		points  []ElevationPoint
+		want    []float64
+		sources []string
+	}{
+		{
+			name:    "plausible readings are kept",
+			points:  elevationPoints(0, []float64{105, 112, 118, 125, 131}, []float64{100, 110, 120, 130, 140}),
+			want:    []float64{105, 112, 118, 125, 131},
+			sources: []string{"", "", "", "", ""},
+		},
+		{
+			name:    "missing reading is filled with terrain plus the segment offset",
+			points:  elevationPoints(0, []float64{120, 130, nan, 150, 160, 170}, []float64{100, 110, 120, 130, 140, 150}),
+			want:    []float64{120, 130, 140, 150, 160, 170},
+			sources: []string{"", "", AltitudeSourceDEMFilled, "", "", ""},
+		},
+		{
+			name:    "spike is corrected",
+			points:  elevationPoints(0, []float64{100, 110, 900, 130, 140}, []float64{100, 110, 120, 130, 140}),
+			want:    []float64{100, 110, 120, 130, 140},
+			sources: []string{"", "", AltitudeSourceDEMCorrected, "", ""},
+		},
+		{
+			name:    "heavily biased segment falls back to terrain",
+			points:  elevationPoints(0, []float64{600, 610, 620, 630, 640}, []float64{100, 110, 120, 130, 140}),
+			want:    []float64{100, 110, 120, 130, 140},
+			sources: []string{AltitudeSourceDEMCorrected, AltitudeSourceDEMCorrected, AltitudeSourceDEMCorrected, AltitudeSourceDEMCorrected, AltitudeSourceDEMCorrected},
+		},
+		{
+			name:    "outside coverage nothing changes",
+			points:  elevationPoints(-1, []float64{nan, 900}, []float64{nan, nan}),
+			want:    []float64{nan, 900},
+			sources: []string{"", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixes := fixAltitudes(tt.points, thresholds)
+			for i, fix := range fixes {
+				sameNaN := math.IsNaN(fix.Altitude) && math.IsNaN(tt.want[i])
+				if !sameNaN && math.Abs(fix.Altitude-tt.want[i]) > 1e-9 {
+					t.Errorf("point %d: altitude = %v, want %v", i, fix.Altitude, tt.want[i])
+				}
+				if fix.Source != tt.sources[i] {
+					t.Errorf("point %d: source = %q, want %q", i, fix.Source, tt.sources[i])
+				}
+			}
+		})
+	}
+}
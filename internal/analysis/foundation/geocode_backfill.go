@@ -0,0 +1,294 @@
+package foundation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/geocode"
+)
+
+// GeocodeBackfillAnalyzer fills in missing admin fields by reverse geocoding
+// Skill: 行政区回填 (Reverse Geocoding Backfill)
+// Incremental mode only touches points and stays without a province;
+// full mode re-geocodes everything. Points outside the provider's coverage
+// keep their current values and are counted as failed. Segments take their
+// areas from their newly geocoded start and end points.
+type GeocodeBackfillAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewGeocodeBackfillAnalyzer creates a new geocode backfill analyzer
+func NewGeocodeBackfillAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &GeocodeBackfillAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "geocode_backfill", 5000),
+	}
+}
+
+// geocodeUpdate is a resolved row waiting to be written
+type geocodeUpdate struct {
+	ID       int64
+	Division geocode.AdminDivision
+}
+
+// Analyze performs the reverse geocoding backfill
+func (a *GeocodeBackfillAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[GeocodeBackfillAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	provider := geocode.DefaultProvider()
+	if provider == nil {
+		return geocode.ErrNoProvider
+	}
+
+	missingOnly := mode != "full"
+
+	pointsDone, pointsFailed, err := a.backfillPoints(ctx, taskID, provider, missingOnly)
+	if err != nil {
+		return err
+	}
+
+	segmentsDone, err := a.backfillSegments(ctx, missingOnly)
+	if err != nil {
+		return err
+	}
+
+	staysDone, staysFailed, err := a.backfillStays(ctx, provider, missingOnly)
+	if err != nil {
+		return err
+	}
+
+	summary := map[string]interface{}{
+		"provider":          provider.Name(),
+		"geocoded_points":   pointsDone,
+		"unresolved_points": pointsFailed,
+		"updated_segments":  segmentsDone,
+		"geocoded_stays":    staysDone,
+		"unresolved_stays":  staysFailed,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[GeocodeBackfillAnalyzer] Analysis completed: %d points geocoded (%d unresolved), %d stays geocoded (%d unresolved)",
+		pointsDone, pointsFailed, staysDone, staysFailed)
+	return nil
+}
+
+// backfillPoints geocodes track points in id order, one write transaction per batch
+func (a *GeocodeBackfillAnalyzer) backfillPoints(ctx context.Context, taskID int64, provider geocode.Provider, missingOnly bool) (int64, int64, error) {
+	condition := "latitude IS NOT NULL AND longitude IS NOT NULL"
+	if missingOnly {
+		condition += " AND (province IS NULL OR province = '')"
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "一生足迹" WHERE %s`, condition)
+	if err := a.DB.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
+		return 0, 0, fmt.Errorf("failed to count points: %w", err)
+	}
+	log.Printf("[GeocodeBackfillAnalyzer] %d points to geocode", total)
+
+	batchQuery := fmt.Sprintf(`
+		SELECT id, latitude, longitude
+		FROM "一生足迹"
+		WHERE %s AND id > ?
+		ORDER BY id
+		LIMIT ?
+	`, condition)
+
+	var lastID, processed, geocoded, failed int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return geocoded, failed, err
+		}
+
+		rows, err := a.DB.QueryContext(ctx, batchQuery, lastID, a.BatchSize)
+		if err != nil {
+			return geocoded, failed, fmt.Errorf("failed to query points: %w", err)
+		}
+
+		var updates []geocodeUpdate
+		batchSize := 0
+		for rows.Next() {
+			var id int64
+			var lat, lon float64
+			if err := rows.Scan(&id, &lat, &lon); err != nil {
+				rows.Close()
+				return geocoded, failed, fmt.Errorf("failed to scan point: %w", err)
+			}
+			lastID = id
+			batchSize++
+
+			division, ok, err := provider.ReverseGeocode(lat, lon)
+			if err != nil {
+				rows.Close()
+				return geocoded, failed, fmt.Errorf("failed to geocode point %d: %w", id, err)
+			}
+			if !ok {
+				failed++
+				continue
+			}
+			updates = append(updates, geocodeUpdate{ID: id, Division: division})
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return geocoded, failed, fmt.Errorf("error iterating points: %w", err)
+		}
+		rows.Close()
+
+		if batchSize == 0 {
+			break
+		}
+
+		if err := a.writeUpdates(ctx, `"一生足迹"`, updates); err != nil {
+			return geocoded, failed, err
+		}
+		geocoded += int64(len(updates))
+		processed += int64(batchSize)
+
+		if err := a.UpdateTaskProgress(taskID, total, processed, failed); err != nil {
+			log.Printf("[GeocodeBackfillAnalyzer] Warning: failed to update progress: %v", err)
+		}
+	}
+
+	return geocoded, failed, nil
+}
+
+// backfillSegments sets the areas of segments from their start and end
+// points, which segments only take when they are built
+func (a *GeocodeBackfillAnalyzer) backfillSegments(ctx context.Context, missingOnly bool) (int64, error) {
+	query := "UPDATE segments SET " + analysis.SegmentAreaSet
+	if missingOnly {
+		query += " WHERE province IS NULL OR province = ''"
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update segment areas: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+	return n, nil
+}
+
+// backfillStays geocodes stay centers
+func (a *GeocodeBackfillAnalyzer) backfillStays(ctx context.Context, provider geocode.Provider, missingOnly bool) (int64, int64, error) {
+	query := `
+		SELECT id, center_lat, center_lon
+		FROM stay_segments
+		WHERE center_lat IS NOT NULL AND center_lon IS NOT NULL
+	`
+	if missingOnly {
+		query += " AND (province IS NULL OR province = '')"
+	}
+
+	rows, err := a.DB.QueryContext(ctx, query)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query stays: %w", err)
+	}
+
+	var updates []geocodeUpdate
+	var failed int64
+	for rows.Next() {
+		var id int64
+		var lat, lon float64
+		if err := rows.Scan(&id, &lat, &lon); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan stay: %w", err)
+		}
+
+		division, ok, err := provider.ReverseGeocode(lat, lon)
+		if err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to geocode stay %d: %w", id, err)
+		}
+		if !ok {
+			failed++
+			continue
+		}
+		updates = append(updates, geocodeUpdate{ID: id, Division: division})
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, 0, fmt.Errorf("error iterating stays: %w", err)
+	}
+	rows.Close()
+
+	if err := a.writeUpdates(ctx, "stay_segments", updates); err != nil {
+		return 0, failed, err
+	}
+
+	return int64(len(updates)), failed, nil
+}
+
+// writeUpdates stores resolved admin fields in one transaction
+func (a *GeocodeBackfillAnalyzer) writeUpdates(ctx context.Context, table string, updates []geocodeUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		UPDATE %s
+		SET province = ?, city = ?, county = ?, town = ?
+		WHERE id = ?
+	`, table))
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, u := range updates {
+		if _, err := stmt.ExecContext(ctx,
+			nullIfEmpty(u.Division.Province),
+			nullIfEmpty(u.Division.City),
+			nullIfEmpty(u.Division.County),
+			nullIfEmpty(u.Division.Town),
+			u.ID,
+		); err != nil {
+			return fmt.Errorf("failed to update %s %d: %w", table, u.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("geocode_backfill", NewGeocodeBackfillAnalyzer)
+}
@@ -0,0 +1,113 @@
+package foundation
+
+import (
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+// minDriftNeighbours is the number of stationary neighbours a drift window
+// needs before its center is trusted
+const minDriftNeighbours = 4
+
+// deviceAccuracyBaselines returns the median reported accuracy of each device
+// Points without an accuracy reading are ignored, so devices that never report
+// one have no baseline.
+func deviceAccuracyBaselines(points []OutlierPoint) map[string]float64 {
+	byDevice := make(map[string][]float64)
+	for _, p := range points {
+		if p.Accuracy > 0 {
+			byDevice[p.Device] = append(byDevice[p.Device], p.Accuracy)
+		}
+	}
+
+	baselines := make(map[string]float64, len(byDevice))
+	for device, values := range byDevice {
+		baselines[device] = stats.Median(values)
+	}
+	return baselines
+}
+
+// adaptiveRadius widens a rule's radius for devices whose typical accuracy is worse
+func adaptiveRadius(radius, baseline, factor float64) float64 {
+	return math.Max(radius, baseline*factor)
+}
+
+// isStationary reports whether the device reported the point as not moving
+func isStationary(p OutlierPoint, t OutlierThresholds) bool {
+	return p.Speed <= t.StationarySpeedMPS
+}
+
+// isImprecise reports whether a point's accuracy is clearly worse than its device's baseline
+// Without a baseline nothing is known to be imprecise.
+func isImprecise(p OutlierPoint, baselines map[string]float64, t OutlierThresholds) bool {
+	baseline, ok := baselines[p.Device]
+	return ok && p.Accuracy > baseline*t.AccuracyFactor
+}
+
+// detectBacktracks flags points that jump away and come straight back
+// For consecutive points a, b, c within BacktrackWindowS, b is a backtrack when
+// c returns to within the radius of a while b lies more than twice the radius
+// from both. The radius adapts to the device's accuracy baseline, and b must be
+// corroborated as a GPS error: reported as stationary, or imprecise.
+func detectBacktracks(points []OutlierPoint, baselines map[string]float64, t OutlierThresholds) []bool {
+	flags := make([]bool, len(points))
+	for i := 1; i+1 < len(points); i++ {
+		a, b, c := points[i-1], points[i], points[i+1]
+		if c.Timestamp-a.Timestamp > t.BacktrackWindowS {
+			continue
+		}
+		if !isStationary(b, t) && !isImprecise(b, baselines, t) {
+			continue
+		}
+
+		radius := adaptiveRadius(t.BacktrackRadiusM, baselines[b.Device], t.AccuracyFactor)
+		if haversineDistance(a.Lat, a.Lon, c.Lat, c.Lon) > radius {
+			continue
+		}
+		if haversineDistance(a.Lat, a.Lon, b.Lat, b.Lon) > 2*radius &&
+			haversineDistance(b.Lat, b.Lon, c.Lat, c.Lon) > 2*radius {
+			flags[i] = true
+		}
+	}
+	return flags
+}
+
+// detectStaticDrift flags imprecise fixes that wander off a stationary cluster
+// A candidate is a point reported as stationary and imprecise. Its window is the
+// run of stationary points around it, at most DriftWindowSize wide; the median
+// position of the window's other points is where the device really was, and the
+// candidate is drift when it lies farther from it than the adaptive radius.
+func detectStaticDrift(points []OutlierPoint, baselines map[string]float64, t OutlierThresholds) []bool {
+	flags := make([]bool, len(points))
+	half := t.DriftWindowSize / 2
+	if half < 1 {
+		return flags
+	}
+
+	for i, p := range points {
+		if !isStationary(p, t) || !isImprecise(p, baselines, t) {
+			continue
+		}
+
+		// Window: stationary neighbours of the same device, stopping at the first moving point
+		var lats, lons []float64
+		for j := i - 1; j >= 0 && j >= i-half && isStationary(points[j], t) && points[j].Device == p.Device; j-- {
+			lats = append(lats, points[j].Lat)
+			lons = append(lons, points[j].Lon)
+		}
+		for j := i + 1; j < len(points) && j <= i+half && isStationary(points[j], t) && points[j].Device == p.Device; j++ {
+			lats = append(lats, points[j].Lat)
+			lons = append(lons, points[j].Lon)
+		}
+		if len(lats) < minDriftNeighbours {
+			continue
+		}
+
+		radius := adaptiveRadius(t.StaticDriftRadiusM, baselines[p.Device], t.AccuracyFactor)
+		if haversineDistance(stats.Median(lats), stats.Median(lons), p.Lat, p.Lon) > radius {
+			flags[i] = true
+		}
+	}
+	return flags
+}
@@ -0,0 +1,220 @@
+package foundation
+
+import "testing"
+
+// at returns a point offset north and east of a fixed origin by the given meters
+func at(ts int64, northM, eastM, speed, accuracy float64) OutlierPoint {
+	const originLat, originLon = 23.1, 113.3
+	return OutlierPoint{
+		Timestamp: ts,
+		Lat:       originLat + northM/111320,
+		Lon:       originLon + eastM/(111320*0.9198), // cos(23.1°)
+		Speed:     speed,
+		Accuracy:  accuracy,
+	}
+}
+
+func testThresholds() OutlierThresholds {
+	t := DefaultThresholds
+	t.EnableBacktrack = true
+	t.EnableStaticDrift = true
+	return t
+}
+
+func TestDeviceAccuracyBaselines(t *testing.T) {
+	points := []OutlierPoint{
+		{Device: "phone", Accuracy: 5},
+		{Device: "phone", Accuracy: 10},
+		{Device: "phone", Accuracy: 50},
+		{Device: "watch", Accuracy: 0}, // no reading
+		{Device: "watch", Accuracy: 30},
+		{Device: "logger"},
+	}
+
+	baselines := deviceAccuracyBaselines(points)
+	want := map[string]float64{"phone": 10, "watch": 30}
+	if len(baselines) != len(want) {
+		t.Fatalf("baselines = %v, want %v", baselines, want)
+	}
+	for device, v := range want {
+		if baselines[device] != v {
+			t.Errorf("baseline of %s = %v, want %v", device, baselines[device], v)
+		}
+	}
+}
+
+func TestAdaptiveRadius(t *testing.T) {
+	tests := []struct {
+		radius, baseline, factor, want float64
+	}{
+		{20, 0, 2, 20},    // no baseline: configured radius
+		{20, 5, 2, 20},    // precise device: configured radius
+		{20, 25, 2, 50},   // imprecise device: widened
+		{50, 30, 1.5, 50}, // exactly at the radius
+	}
+	for _, tt := range tests {
+		if got := adaptiveRadius(tt.radius, tt.baseline, tt.factor); got != tt.want {
+			t.Errorf("adaptiveRadius(%v, %v, %v) = %v, want %v", tt.radius, tt.baseline, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestDetectBacktracks(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []OutlierPoint
+		baselines map[string]float64
+		want      []bool
+	}{
+		{
+			name: "stationary spike is flagged",
+			points: []OutlierPoint{
+				at(0, 0, 0, 0, 10),
+				at(10, 200, 0, 0, 10),
+				at(20, 5, 0, 0, 10),
+			},
+			want: []bool{false, true, false},
+		},
+		{
+			name: "imprecise spike while moving is flagged",
+			points: []OutlierPoint{
+				at(0, 0, 0, 5, 10),
+				at(10, 200, 0, 5, 80),
+				at(20, 5, 0, 5, 10),
+			},
+			baselines: map[string]float64{"": 10},
+			want:      []bool{false, true, false},
+		},
+		{
+			name: "precise detour while moving is kept",
+			points: []OutlierPoint{
+				at(0, 0, 0, 5, 10),
+				at(10, 200, 0, 5, 10),
+				at(20, 5, 0, 5, 10),
+			},
+			baselines: map[string]float64{"": 10},
+			want:      []bool{false, false, false},
+		},
+		{
+			name: "return outside the window is kept",
+			points: []OutlierPoint{
+				at(0, 0, 0, 0, 10),
+				at(100, 200, 0, 0, 10),
+				at(200, 5, 0, 0, 10),
+			},
+			want: []bool{false, false, false},
+		},
+		{
+			name: "no return is kept",
+			points: []OutlierPoint{
+				at(0, 0, 0, 0, 10),
+				at(10, 200, 0, 0, 10),
+				at(20, 400, 0, 0, 10),
+			},
+			want: []bool{false, false, false},
+		},
+		{
+			name: "spike within the device's noise is kept",
+			points: []OutlierPoint{
+				at(0, 0, 0, 0, 60),
+				at(10, 200, 0, 0, 60),
+				at(20, 5, 0, 0, 60),
+			},
+			// Radius widens to 2 × 60 m, so the 200 m spike is not beyond twice the radius
+			baselines: map[string]float64{"": 60},
+			want:      []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectBacktracks(tt.points, tt.baselines, testThresholds())
+			assertFlags(t, got, tt.want)
+		})
+	}
+}
+
+func TestDetectStaticDrift(t *testing.T) {
+	cluster := func(drift OutlierPoint) []OutlierPoint {
+		return []OutlierPoint{
+			at(0, 0, 0, 0, 10),
+			at(10, 3, 0, 0, 10),
+			at(20, 0, 3, 0, 10),
+			drift,
+			at(40, -3, 0, 0, 10),
+			at(50, 0, -3, 0, 10),
+		}
+	}
+	baselines := map[string]float64{"": 10}
+
+	tests := []struct {
+		name   string
+		points []OutlierPoint
+		want   []bool
+	}{
+		{
+			name:   "imprecise stationary fix off the cluster is flagged",
+			points: cluster(at(30, 150, 0, 0, 80)),
+			want:   []bool{false, false, false, true, false, false},
+		},
+		{
+			name:   "precise fix off the cluster is kept",
+			points: cluster(at(30, 150, 0, 0, 10)),
+			want:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "moving fix off the cluster is kept",
+			points: cluster(at(30, 150, 0, 3, 80)),
+			want:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name:   "imprecise fix within the radius is kept",
+			points: cluster(at(30, 30, 0, 0, 80)),
+			want:   []bool{false, false, false, false, false, false},
+		},
+		{
+			name: "too few stationary neighbours is kept",
+			points: []OutlierPoint{
+				at(0, 0, 0, 5, 10),
+				at(10, 0, 0, 0, 10),
+				at(20, 150, 0, 0, 80),
+				at(30, 0, 0, 0, 10),
+				at(40, 0, 0, 5, 10),
+			},
+			want: []bool{false, false, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectStaticDrift(tt.points, baselines, testThresholds())
+			assertFlags(t, got, tt.want)
+		})
+	}
+}
+
+func TestDetectStaticDriftStopsAtOtherDevices(t *testing.T) {
+	points := []OutlierPoint{
+		at(0, 0, 0, 0, 10),
+		at(10, 3, 0, 0, 10),
+		at(20, 150, 0, 0, 80),
+		at(30, -3, 0, 0, 10),
+		at(40, 0, 3, 0, 10),
+	}
+	points[0].Device, points[1].Device = "watch", "watch"
+
+	got := detectStaticDrift(points, map[string]float64{"": 10, "watch": 10}, testThresholds())
+	assertFlags(t, got, []bool{false, false, false, false, false})
+}
+
+func assertFlags(t *testing.T, got, want []bool) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d flags, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("flag %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
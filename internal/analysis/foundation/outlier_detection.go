@@ -19,6 +19,8 @@ type OutlierPoint struct {
 	Lon       float64
 	Speed     float64
 	Accuracy  float64
+	Device    string // source_device, empty when unknown
+	Mode      string // Transport mode of the covering segment, loaded for the Hampel pass
 }
 
 // OutlierResult represents the result of outlier detection for a point
@@ -30,13 +32,34 @@ type OutlierResult struct {
 }
 
 // OutlierThresholds defines configurable thresholds for outlier detection
+// Loaded from the "outlier_detection" section of the active threshold profile
 type OutlierThresholds struct {
-	MaxSpeedMPS        float64 // 277.78 m/s (1000 km/h)
-	MaxAccuracyM       float64 // 100 m
-	JumpDistanceM      float64 // 1000 m
-	JumpTimeS          int64   // 10 s
-	BacktrackRadiusM   float64 // 50 m
-	StaticDriftRadiusM float64 // 30 m
+	MaxSpeedMPS        float64 `json:"max_speed_mps"`         // 277.78 m/s (1000 km/h)
+	MaxAccuracyM       float64 `json:"max_accuracy_m"`        // 100 m
+	JumpDistanceM      float64 `json:"jump_distance_m"`       // 1000 m
+	JumpTimeS          int64   `json:"jump_time_s"`           // 10 s
+	BacktrackRadiusM   float64 `json:"backtrack_radius_m"`    // 50 m
+	StaticDriftRadiusM float64 `json:"static_drift_radius_m"` // 30 m
+
+	// Adaptive BACKTRACK and STATIC_DRIFT rules, off unless enabled in the profile
+	EnableBacktrack    bool    `json:"enable_backtrack"`
+	EnableStaticDrift  bool    `json:"enable_static_drift"`
+	BacktrackWindowS   int64   `json:"backtrack_window_s"`   // 120 s: excursion and return within this time
+	StationarySpeedMPS float64 `json:"stationary_speed_mps"` // 0.5 m/s: reported speeds up to this count as not moving
+	AccuracyFactor     float64 `json:"accuracy_factor"`      // 2: radii grow to this multiple of the device's accuracy baseline
+	DriftWindowSize    int     `json:"drift_window_size"`    // 10 points around each candidate
+
+	// Statistical HAMPEL_SPEED and HAMPEL_IMPLIED_SPEED pass, off unless enabled in the profile
+	// Points deviating from their rolling median by more than k scaled MADs;
+	// k depends on the transport mode of the point's segment.
+	EnableHampel    bool    `json:"enable_hampel"`
+	HampelWindow    int     `json:"hampel_window"`      // 7 points on each side
+	HampelMinMADMPS float64 `json:"hampel_min_mad_mps"` // 0.5 m/s floor on the scaled MAD
+	HampelK         float64 `json:"hampel_k"`           // 3.5, points outside segments
+	HampelKWalk     float64 `json:"hampel_k_walk"`      // 3, WALK and BIKE
+	HampelKCar      float64 `json:"hampel_k_car"`       // 4
+	HampelKTrain    float64 `json:"hampel_k_train"`     // 5
+	HampelKFlight   float64 `json:"hampel_k_flight"`    // 6, climbs and descents vary a lot
 }
 
 // DefaultThresholds provides default outlier detection thresholds
@@ -47,8 +70,23 @@ var DefaultThresholds = OutlierThresholds{
 	JumpTimeS:          10,     // 10 seconds
 	BacktrackRadiusM:   20.0,   // 20 meters (tightened from 50m)
 	StaticDriftRadiusM: 50.0,   // 50 meters (increased from 30m)
+	BacktrackWindowS:   120,
+	StationarySpeedMPS: 0.5,
+	AccuracyFactor:     2.0,
+	DriftWindowSize:    10,
+	HampelWindow:       7,
+	HampelMinMADMPS:    0.5,
+	HampelK:            3.5,
+	HampelKWalk:        3.0,
+	HampelKCar:         4.0,
+	HampelKTrain:       5.0,
+	HampelKFlight:      6.0,
 }
 
+// notManuallyReviewed is a SQL condition excluding points whose QA status was
+// set through the outlier review API (MANUAL_PASS or MANUAL_FAIL)
+const notManuallyReviewed = "(qa_status IS NULL OR qa_status NOT IN ('MANUAL_PASS', 'MANUAL_FAIL'))"
+
 // TrajectoryPoint represents a GPS point for trajectory completion
 type TrajectoryPoint struct {
 	ID        int64
@@ -57,6 +95,7 @@ type TrajectoryPoint struct {
 	Lon       float64
 	Altitude  float64
 	Speed     float64
+	Source    string
 }
 
 // OutlierDetectionAnalyzer implements outlier detection
@@ -84,12 +123,10 @@ func (a *OutlierDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Reset outlier flags and reason codes (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "UPDATE \"一生足迹\" SET outlier_flag = 0, outlier_reason_codes = NULL, qa_status = NULL"); err != nil {
-			return fmt.Errorf("failed to reset outlier flags: %w", err)
-		}
-		log.Printf("[OutlierDetectionAnalyzer] Reset outlier flags and reason codes")
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
 	}
 
 	// Get all track points with necessary fields for rule-based detection
@@ -100,7 +137,8 @@ func (a *OutlierDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 			latitude,
 			longitude,
 			speed,
-			accuracy
+			accuracy,
+			COALESCE(source_device, '')
 		FROM "一生足迹"
 		ORDER BY dataTime, id
 	`
@@ -117,7 +155,7 @@ func (a *OutlierDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		var timestamp sql.NullInt64
 		var lat, lon, speed, accuracy sql.NullFloat64
 
-		if err := rows.Scan(&point.ID, &timestamp, &lat, &lon, &speed, &accuracy); err != nil {
+		if err := rows.Scan(&point.ID, &timestamp, &lat, &lon, &speed, &accuracy, &point.Device); err != nil {
 			return fmt.Errorf("failed to scan point: %w", err)
 		}
 
@@ -152,6 +190,13 @@ func (a *OutlierDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		return fmt.Errorf("failed to update task progress: %w", err)
 	}
 
+	// The Hampel pass picks its threshold by the transport mode of each point
+	if a.Thresholds.EnableHampel {
+		if err := a.loadPointModes(ctx, points); err != nil {
+			return fmt.Errorf("failed to load point modes: %w", err)
+		}
+	}
+
 	// Detect outliers with rule-based methods
 	outlierResults := a.detectOutliers(points)
 
@@ -187,6 +232,24 @@ func (a *OutlierDetectionAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 func (a *OutlierDetectionAnalyzer) detectOutliers(points []OutlierPoint) []OutlierResult {
 	results := make([]OutlierResult, len(points))
 
+	// Adaptive rules, measured against each device's typical accuracy
+	var backtracks, drifts []bool
+	if a.Thresholds.EnableBacktrack || a.Thresholds.EnableStaticDrift {
+		baselines := deviceAccuracyBaselines(points)
+		if a.Thresholds.EnableBacktrack {
+			backtracks = detectBacktracks(points, baselines, a.Thresholds)
+		}
+		if a.Thresholds.EnableStaticDrift {
+			drifts = detectStaticDrift(points, baselines, a.Thresholds)
+		}
+	}
+
+	// Statistical pass
+	var speedSpikes, impliedSpikes []bool
+	if a.Thresholds.EnableHampel {
+		speedSpikes, impliedSpikes = detectHampel(points, a.Thresholds)
+	}
+
 	for i, point := range points {
 		var reasons []string
 		qaStatus := "PASS"
@@ -213,8 +276,23 @@ func (a *OutlierDetectionAnalyzer) detectOutliers(points []OutlierPoint) []Outli
 			}
 		}
 
-		// Rule 4: BACKTRACK Detection - DISABLED (too many false positives)
-		// Rule 5: STATIC_DRIFT Detection - DISABLED (too many false positives)
+		// Rule 4: BACKTRACK - excursion that returns to where it left (see detectBacktracks)
+		if backtracks != nil && backtracks[i] {
+			reasons = append(reasons, "BACKTRACK")
+		}
+
+		// Rule 5: STATIC_DRIFT - imprecise fix wandering off a stationary cluster (see detectStaticDrift)
+		if drifts != nil && drifts[i] {
+			reasons = append(reasons, "STATIC_DRIFT")
+		}
+
+		// Rule 6: HAMPEL_SPEED / HAMPEL_IMPLIED_SPEED - statistical outliers (see detectHampel)
+		if speedSpikes != nil && speedSpikes[i] {
+			reasons = append(reasons, "HAMPEL_SPEED")
+		}
+		if impliedSpikes != nil && impliedSpikes[i] {
+			reasons = append(reasons, "HAMPEL_IMPLIED_SPEED")
+		}
 
 		// Determine QA status
 		if len(reasons) > 0 {
@@ -253,63 +331,20 @@ func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	return earthRadius * c
 }
 
-// isStaticDrift detects GPS drift in a window of points
-// Returns true if points cluster but have high variance (indicating drift while stationary)
-func isStaticDrift(window []OutlierPoint, radiusM float64) bool {
-	if len(window) < 5 {
-		return false
-	}
-
-	// Calculate centroid
-	var sumLat, sumLon float64
-	for _, p := range window {
-		sumLat += p.Lat
-		sumLon += p.Lon
-	}
-	centroidLat := sumLat / float64(len(window))
-	centroidLon := sumLon / float64(len(window))
-
-	// Check if all points are within radius of centroid
-	allWithinRadius := true
-	for _, p := range window {
-		dist := haversineDistance(centroidLat, centroidLon, p.Lat, p.Lon)
-		if dist > radiusM {
-			allWithinRadius = false
-			break
-		}
-	}
-
-	// If all points cluster within radius, check for variance
-	if allWithinRadius {
-		// Calculate variance
-		var sumSqDist float64
-		for _, p := range window {
-			dist := haversineDistance(centroidLat, centroidLon, p.Lat, p.Lon)
-			sumSqDist += dist * dist
-		}
-		variance := sumSqDist / float64(len(window))
-
-		// If variance is high (>400m²), it's likely drift
-		// This means standard deviation > 20m, which is significant for stationary points
-		return variance > 400 // 20m * 20m
-	}
-
-	return false
-}
-
 // updateOutlierResults updates outlier flags, reason codes, and QA status in the database
 func (a *OutlierDetectionAnalyzer) updateOutlierResults(ctx context.Context, results []OutlierResult) error {
 	if len(results) == 0 {
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	updateQuery := `UPDATE "一生足迹" SET outlier_flag = ?, outlier_reason_codes = ?, qa_status = ? WHERE id = ?`
+	// Manual overrides from the outlier review API are not overwritten
+	updateQuery := `UPDATE "一生足迹" SET outlier_flag = ?, outlier_reason_codes = ?, qa_status = ? WHERE id = ? AND ` + notManuallyReviewed
 
 	stmt, err := tx.PrepareContext(ctx, updateQuery)
 	if err != nil {
@@ -352,4 +387,9 @@ func (a *OutlierDetectionAnalyzer) updateOutlierResults(ctx context.Context, res
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("outlier_detection", NewOutlierDetectionAnalyzer)
+	analysis.RegisterOutputs("outlier_detection", analysis.Output{
+		Table: "一生足迹",
+		Set:   "outlier_flag = 0, outlier_reason_codes = NULL, qa_status = NULL",
+		Where: notManuallyReviewed, // Manually reviewed points keep their verdict
+	})
 }
@@ -0,0 +1,135 @@
+package foundation
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+// hampelScale turns a median absolute deviation into an estimate of the
+// standard deviation of normally distributed values
+const hampelScale = 1.4826
+
+// hampelFlags runs a Hampel filter over values: a value is flagged when it
+// deviates from the median of its rolling window by more than k[i] scaled
+// median absolute deviations
+// The window holds up to half values on each side. NaN values are skipped and
+// never flagged. minMAD floors the scaled MAD, so long runs of near-identical
+// values do not turn small deviations into outliers.
+func hampelFlags(values []float64, half int, k []float64, minMAD float64) []bool {
+	flags := make([]bool, len(values))
+	window := make([]float64, 0, 2*half+1)
+	deviations := make([]float64, 0, 2*half+1)
+
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+
+		window = window[:0]
+		for j := max(0, i-half); j <= min(len(values)-1, i+half); j++ {
+			if !math.IsNaN(values[j]) {
+				window = append(window, values[j])
+			}
+		}
+		if len(window) < 3 {
+			continue
+		}
+
+		median := stats.Median(window)
+		deviations = deviations[:0]
+		for _, w := range window {
+			deviations = append(deviations, math.Abs(w-median))
+		}
+		mad := math.Max(hampelScale*stats.Median(deviations), minMAD)
+
+		flags[i] = math.Abs(v-median) > k[i]*mad
+	}
+	return flags
+}
+
+// impliedSpeeds returns the speed (m/s) implied by the distance and time from
+// each point's predecessor; NaN for the first point and for duplicate timestamps
+func impliedSpeeds(points []OutlierPoint) []float64 {
+	speeds := make([]float64, len(points))
+	for i := range points {
+		speeds[i] = math.NaN()
+		if i == 0 {
+			continue
+		}
+		prev, p := points[i-1], points[i]
+		if dt := p.Timestamp - prev.Timestamp; dt > 0 {
+			speeds[i] = haversineDistance(prev.Lat, prev.Lon, p.Lat, p.Lon) / float64(dt)
+		}
+	}
+	return speeds
+}
+
+// hampelK returns the Hampel threshold for a transport mode
+func (t OutlierThresholds) hampelK(mode string) float64 {
+	switch mode {
+	case "WALK", "BIKE":
+		return t.HampelKWalk
+	case "CAR":
+		return t.HampelKCar
+	case "TRAIN":
+		return t.HampelKTrain
+	case "PLANE", "FLIGHT":
+		return t.HampelKFlight
+	default:
+		return t.HampelK
+	}
+}
+
+// detectHampel runs the Hampel filter on reported speed and on implied speed
+// A bad fix makes the implied speed spike twice, into and out of it, so a
+// point is flagged on implied speed only when both spikes are.
+func detectHampel(points []OutlierPoint, t OutlierThresholds) (speedFlags, impliedFlags []bool) {
+	k := make([]float64, len(points))
+	speeds := make([]float64, len(points))
+	for i, p := range points {
+		k[i] = t.hampelK(p.Mode)
+		speeds[i] = p.Speed
+	}
+
+	speedFlags = hampelFlags(speeds, t.HampelWindow, k, t.HampelMinMADMPS)
+
+	spikes := hampelFlags(impliedSpeeds(points), t.HampelWindow, k, t.HampelMinMADMPS)
+	impliedFlags = make([]bool, len(points))
+	for i := range points {
+		impliedFlags[i] = spikes[i] && (i+1 == len(points) || spikes[i+1])
+	}
+	return speedFlags, impliedFlags
+}
+
+// loadPointModes sets the transport mode of each point from the segment covering it
+// points must be in time order; points outside any segment keep an empty mode.
+func (a *OutlierDetectionAnalyzer) loadPointModes(ctx context.Context, points []OutlierPoint) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT start_time, end_time, COALESCE(mode, '')
+		FROM segments
+		ORDER BY start_time
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	i := 0
+	for rows.Next() {
+		var start, end int64
+		var mode string
+		if err := rows.Scan(&start, &end, &mode); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		for i < len(points) && points[i].Timestamp < start {
+			i++
+		}
+		for j := i; j < len(points) && points[j].Timestamp <= end; j++ {
+			points[j].Mode = mode
+		}
+	}
+	return rows.Err()
+}
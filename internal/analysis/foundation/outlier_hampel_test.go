@@ -0,0 +1,106 @@
+package foundation
+
+import (
+	"math"
+	"testing"
+)
+
+func constK(n int, k float64) []float64 {
+	ks := make([]float64, n)
+	for i := range ks {
+		ks[i] = k
+	}
+	return ks
+}
+
+func TestHampelFlags(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name   string
+		values []float64
+		k      float64
+		minMAD float64
+		want   []bool
+	}{
+		{
+			name:   "single spike",
+			values: []float64{10, 11, 10, 12, 60, 11, 10, 12, 11},
+			k:      3,
+			minMAD: 0.5,
+			want:   []bool{false, false, false, false, true, false, false, false, false},
+		},
+		{
+			name:   "steady acceleration is kept",
+			values: []float64{2, 4, 6, 8, 10, 12, 14, 16, 18},
+			k:      3,
+			minMAD: 0.5,
+			want:   make([]bool, 9),
+		},
+		{
+			name:   "minimum MAD keeps small deviations of a constant series",
+			values: []float64{5, 5, 5, 5, 6, 5, 5, 5, 5},
+			k:      3,
+			minMAD: 0.5,
+			want:   make([]bool, 9),
+		},
+		{
+			name:   "larger k keeps the spike",
+			values: []float64{10, 11, 10, 12, 60, 11, 10, 12, 11},
+			k:      100,
+			minMAD: 0.5,
+			want:   make([]bool, 9),
+		},
+		{
+			name:   "NaN values are skipped",
+			values: []float64{nan, 10, 11, nan, 60, 11, 10, 12, nan},
+			k:      3,
+			minMAD: 0.5,
+			want:   []bool{false, false, false, false, true, false, false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hampelFlags(tt.values, 4, constK(len(tt.values), tt.k), tt.minMAD)
+			assertFlags(t, got, tt.want)
+		})
+	}
+}
+
+func TestDetectHampelImpliedSpeed(t *testing.T) {
+	// Walking east at ~1.5 m/s with one fix thrown 300 m north
+	var points []OutlierPoint
+	for i := 0; i < 11; i++ {
+		north := 0.0
+		if i == 5 {
+			north = 300
+		}
+		points = append(points, at(int64(i*10), north, float64(i*15), 1.5, 10))
+	}
+
+	th := DefaultThresholds
+	speedFlags, impliedFlags := detectHampel(points, th)
+
+	want := make([]bool, len(points))
+	want[5] = true
+	assertFlags(t, impliedFlags, want)
+	assertFlags(t, speedFlags, make([]bool, len(points)))
+}
+
+func TestHampelKByMode(t *testing.T) {
+	th := DefaultThresholds
+	tests := map[string]float64{
+		"WALK":   th.HampelKWalk,
+		"BIKE":   th.HampelKWalk,
+		"CAR":    th.HampelKCar,
+		"TRAIN":  th.HampelKTrain,
+		"PLANE":  th.HampelKFlight,
+		"FLIGHT": th.HampelKFlight,
+		"":       th.HampelK,
+	}
+	for mode, want := range tests {
+		if got := th.hampelK(mode); got != want {
+			t.Errorf("hampelK(%q) = %v, want %v", mode, got, want)
+		}
+	}
+}
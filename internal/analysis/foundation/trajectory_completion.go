@@ -33,14 +33,6 @@ func (a *TrajectoryCompletionAnalyzer) Analyze(ctx context.Context, taskID int64
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Remove existing interpolated points (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM \"一生足迹\" WHERE qa_status = 'interpolated'"); err != nil {
-			return fmt.Errorf("failed to remove interpolated points: %w", err)
-		}
-		log.Printf("[TrajectoryCompletionAnalyzer] Removed existing interpolated points")
-	}
-
 	// Get all track points ordered by time
 	pointsQuery := `
 		SELECT
@@ -49,7 +41,8 @@ func (a *TrajectoryCompletionAnalyzer) Analyze(ctx context.Context, taskID int64
 			latitude,
 			longitude,
 			altitude,
-			speed
+			speed,
+			source
 		FROM "一生足迹"
 		WHERE outlier_flag = 0
 			AND (qa_status IS NULL OR qa_status != 'interpolated')
@@ -67,7 +60,7 @@ func (a *TrajectoryCompletionAnalyzer) Analyze(ctx context.Context, taskID int64
 		var point TrajectoryPoint
 		var altitude, speed sql.NullFloat64
 
-		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &altitude, &speed); err != nil {
+		if err := rows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &altitude, &speed, &point.Source); err != nil {
 			return fmt.Errorf("failed to scan point: %w", err)
 		}
 
@@ -125,6 +118,7 @@ type InterpolatedPoint struct {
 	Lon       float64
 	Altitude  float64
 	Speed     float64
+	Source    string // Source of the point before the gap
 }
 
 // detectAndInterpolate detects gaps and creates interpolated points
@@ -156,6 +150,7 @@ func (a *TrajectoryCompletionAnalyzer) detectAndInterpolate(points []TrajectoryP
 					Lon:       p1.Lon + (p2.Lon-p1.Lon)*ratio,
 					Altitude:  p1.Altitude + (p2.Altitude-p1.Altitude)*ratio,
 					Speed:     p1.Speed + (p2.Speed-p1.Speed)*ratio,
+					Source:    p1.Source,
 				})
 			}
 		}
@@ -170,7 +165,7 @@ func (a *TrajectoryCompletionAnalyzer) insertInterpolatedPoints(ctx context.Cont
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -178,9 +173,9 @@ func (a *TrajectoryCompletionAnalyzer) insertInterpolatedPoints(ctx context.Cont
 
 	insertQuery := `
 		INSERT INTO "一生足迹" (
-			dataTime, latitude, longitude, altitude, speed,
+			dataTime, latitude, longitude, altitude, speed, source,
 			qa_status, outlier_flag
-		) VALUES (?, ?, ?, ?, ?, 'interpolated', 0)
+		) VALUES (?, ?, ?, ?, ?, ?, 'interpolated', 0)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -196,6 +191,7 @@ func (a *TrajectoryCompletionAnalyzer) insertInterpolatedPoints(ctx context.Cont
 			point.Lon,
 			point.Altitude,
 			point.Speed,
+			point.Source,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert interpolated point: %w", err)
@@ -213,4 +209,6 @@ func (a *TrajectoryCompletionAnalyzer) insertInterpolatedPoints(ctx context.Cont
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("trajectory_completion", NewTrajectoryCompletionAnalyzer)
+	analysis.RegisterDependencies("trajectory_completion", "outlier_detection")
+	analysis.RegisterOutputs("trajectory_completion", analysis.Output{Table: "一生足迹", Where: "qa_status = 'interpolated'"})
 }
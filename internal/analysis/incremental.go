@@ -179,7 +179,7 @@ func (a *IncrementalAnalyzer) MarkRecordsAsAnalyzed(
 		WHERE id IN (%s)
 	`, tableName, placeholders)
 
-	_, err := a.DB.Exec(query, args...)
+	_, err := a.ExecWrite(context.Background(), query, args...)
 	return err
 }
 
@@ -220,7 +220,7 @@ func (a *IncrementalAnalyzer) BatchInsert(
 		query += ")"
 	}
 
-	_, err := a.DB.Exec(query, args...)
+	_, err := a.ExecWrite(context.Background(), query, args...)
 	return err
 }
 
@@ -232,13 +232,13 @@ func (a *IncrementalAnalyzer) BatchUpdate(
 	args []interface{},
 ) error {
 	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", tableName, setClause, whereClause)
-	_, err := a.DB.Exec(query, args...)
+	_, err := a.ExecWrite(context.Background(), query, args...)
 	return err
 }
 
 // Transaction executes a function within a database transaction
 func (a *IncrementalAnalyzer) Transaction(fn func(tx *sql.Tx) error) error {
-	tx, err := a.DB.Begin()
+	tx, err := a.BeginWriteTx(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -250,7 +250,7 @@ func (a *IncrementalAnalyzer) Transaction(fn func(tx *sql.Tx) error) error {
 		}
 	}()
 
-	if err := fn(tx); err != nil {
+	if err := fn(tx.Tx); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -308,7 +308,8 @@ func (a *IncrementalAnalyzer) MarkTaskAsCompleted(taskID int64, resultSummary ..
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, summary, taskID)
+	_, err := a.ExecWrite(context.Background(), query, summary, taskID)
+	FinishRun(taskID, a.Name, "completed")
 	return err
 }
 
@@ -323,7 +324,8 @@ func (a *IncrementalAnalyzer) UpdateTaskProgress(taskID int64, total, processed,
 		WHERE id = ?
 	`
 
-	_, err := a.DB.Exec(query, processed, total, failed, taskID)
+	_, err := a.ExecWrite(context.Background(), query, processed, total, failed, taskID)
+	recordProgress(taskID, processed)
 	return err
 }
 
@@ -0,0 +1,255 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// The built-in rule catalogue, in the order findings are listed within a period
+func init() {
+	RegisterRule(newPlacesRule("new_cities", "CITY", "city", "cities"))
+	RegisterRule(newPlacesRule("new_counties", "COUNTY", "county", "counties"))
+	RegisterRule(Rule{
+		Name:     "top_city",
+		Category: CategoryFootprint,
+		Template: `You spent the most time in {{.city}} in {{.period}}, out of {{.city_count}} {{plural .city_count "city" "cities"}}`,
+		Evaluate: evaluateTopCity,
+	})
+	RegisterRule(Rule{
+		Name:     "distance_change",
+		Category: CategoryDistance,
+		Template: `You travelled {{km .distance_m}} in {{.period}}, {{pct .change_pct}} {{if gt .change_pct 0.0}}more{{else}}less{{end}} than in {{.prev}}`,
+		Evaluate: evaluateDistanceChange,
+	})
+	RegisterRule(Rule{
+		Name:     "longest_day",
+		Category: CategoryRecord,
+		Template: `Your longest day in {{.period}} was {{.date}}, covering {{km .distance_m}}`,
+		Evaluate: evaluateLongestDay,
+	})
+	RegisterRule(Rule{
+		Name:     "commute_duration",
+		Category: CategoryCommute,
+		Template: `Your commute was {{pct .change_pct}} {{if gt .change_pct 0.0}}slower{{else}}faster{{end}} in {{.period}} than in {{.prev}}, {{minutes .avg_duration_s}} on average`,
+		Evaluate: evaluateCommuteDuration,
+	})
+	RegisterRule(Rule{
+		Name:     "exploration_share",
+		Category: CategoryExploration,
+		Template: `You spent {{pct .novel_pct}} of your tracked time in {{.period}} in places you had never been before`,
+		Evaluate: evaluateExplorationShare,
+	})
+}
+
+// newPlacesRule reports the admin areas of a level first visited in the period
+func newPlacesRule(name, statType, one, many string) Rule {
+	return Rule{
+		Name:     name,
+		Category: CategoryFootprint,
+		Template: `You visited {{.count}} new {{plural .count "` + one + `" "` + many + `"}} in {{.period}}` +
+			`{{with .examples}}{{if gt $.count (len .)}}, including {{list .}}{{else}}: {{list .}}{{end}}{{end}}`,
+		Evaluate: func(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+			rows, err := env.DB.QueryContext(ctx, `
+				SELECT stat_key
+				FROM footprint_statistics
+				WHERE stat_type = ? AND time_range = 'all' AND first_visit >= ? AND first_visit < ?
+				ORDER BY first_visit, stat_key
+			`, statType, p.Start, p.End)
+			if err != nil {
+				return nil, fmt.Errorf("failed to query new areas: %w", err)
+			}
+			defer rows.Close()
+
+			var names []string
+			for rows.Next() {
+				var name string
+				if err := rows.Scan(&name); err != nil {
+					return nil, fmt.Errorf("failed to scan area: %w", err)
+				}
+				names = append(names, name)
+			}
+			if err := rows.Err(); err != nil {
+				return nil, err
+			}
+			if len(names) == 0 {
+				return nil, nil
+			}
+
+			examples := names
+			if len(examples) > env.Thresholds.MaxExamples {
+				examples = examples[:env.Thresholds.MaxExamples]
+			}
+			return []Finding{{
+				Value: float64(len(names)),
+				Data:  map[string]interface{}{"count": len(names), "examples": examples},
+			}}, nil
+		},
+	}
+}
+
+// evaluateTopCity reports the city with the most time spent in the period,
+// when more than one city was visited
+func evaluateTopCity(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+	var city string
+	var durationS float64
+	var cityCount int
+	err := env.DB.QueryRowContext(ctx, `
+		SELECT stat_key, total_duration_s, (SELECT COUNT(*) FROM footprint_statistics WHERE stat_type = 'CITY' AND time_range = ?)
+		FROM footprint_statistics
+		WHERE stat_type = 'CITY' AND time_range = ?
+		ORDER BY total_duration_s DESC, stat_key
+		LIMIT 1
+	`, p.Key, p.Key).Scan(&city, &durationS, &cityCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top city: %w", err)
+	}
+	if cityCount < 2 {
+		return nil, nil
+	}
+	return []Finding{{
+		Value: durationS,
+		Data:  map[string]interface{}{"city": city, "city_count": cityCount, "duration_s": durationS},
+	}}, nil
+}
+
+// periodDistance sums the daily distances of a period
+func periodDistance(ctx context.Context, db *sql.DB, p Period) (float64, int, error) {
+	var distance float64
+	var days int
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(total_distance_m), 0), COUNT(*)
+		FROM daily_summaries
+		WHERE date >= ? AND date < ?
+	`, p.StartDate, p.EndDate).Scan(&distance, &days)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query period distance: %w", err)
+	}
+	return distance, days, nil
+}
+
+// evaluateDistanceChange reports a change in distance travelled from the
+// previous period of at least MinChangePct
+func evaluateDistanceChange(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+	if p.Prev == nil {
+		return nil, nil
+	}
+	cur, days, err := periodDistance(ctx, env.DB, p)
+	if err != nil || days == 0 {
+		return nil, err
+	}
+	prev, _, err := periodDistance(ctx, env.DB, *p.Prev)
+	if err != nil {
+		return nil, err
+	}
+	change := changePct(cur, prev)
+	if change == nil || math.Abs(*change) < env.Thresholds.MinChangePct {
+		return nil, nil
+	}
+	return []Finding{{
+		Value:     cur,
+		ChangePct: change,
+		Data:      map[string]interface{}{"distance_m": cur, "prev_distance_m": prev, "change_pct": *change},
+	}}, nil
+}
+
+// evaluateLongestDay reports the day with the longest distance in the period
+func evaluateLongestDay(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+	var date string
+	var distance float64
+	err := env.DB.QueryRowContext(ctx, `
+		SELECT date, total_distance_m
+		FROM daily_summaries
+		WHERE date >= ? AND date < ? AND total_distance_m > 0
+		ORDER BY total_distance_m DESC, date
+		LIMIT 1
+	`, p.StartDate, p.EndDate).Scan(&date, &distance)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query longest day: %w", err)
+	}
+	return []Finding{{
+		Value: distance,
+		Data:  map[string]interface{}{"date": date, "distance_m": distance},
+	}}, nil
+}
+
+// commuteDuration averages the duration of the HOME<->WORK trips of a period
+func commuteDuration(ctx context.Context, db *sql.DB, p Period) (float64, int, error) {
+	var avg sql.NullFloat64
+	var trips int
+	err := db.QueryRowContext(ctx, `
+		SELECT AVG(duration_s), COUNT(*)
+		FROM trips
+		WHERE start_time >= ? AND start_time < ?
+			AND features_json IS NOT NULL
+			AND (
+				(json_extract(features_json, '$.origin_anchor') = 'HOME' AND json_extract(features_json, '$.dest_anchor') = 'WORK')
+				OR (json_extract(features_json, '$.origin_anchor') = 'WORK' AND json_extract(features_json, '$.dest_anchor') = 'HOME')
+			)
+	`, p.Start, p.End).Scan(&avg, &trips)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query commute duration: %w", err)
+	}
+	return avg.Float64, trips, nil
+}
+
+// evaluateCommuteDuration reports a change in average commute duration from
+// the previous period of at least MinChangePct; both periods need
+// MinCommuteTrips commutes
+func evaluateCommuteDuration(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+	if p.Prev == nil {
+		return nil, nil
+	}
+	cur, curTrips, err := commuteDuration(ctx, env.DB, p)
+	if err != nil || curTrips < env.Thresholds.MinCommuteTrips {
+		return nil, err
+	}
+	prev, prevTrips, err := commuteDuration(ctx, env.DB, *p.Prev)
+	if err != nil || prevTrips < env.Thresholds.MinCommuteTrips {
+		return nil, err
+	}
+	change := changePct(cur, prev)
+	if change == nil || math.Abs(*change) < env.Thresholds.MinChangePct {
+		return nil, nil
+	}
+	return []Finding{{
+		Value:     cur,
+		ChangePct: change,
+		Data: map[string]interface{}{
+			"avg_duration_s": cur, "prev_avg_duration_s": prev, "change_pct": *change,
+			"trip_count": curTrips, "prev_trip_count": prevTrips,
+		},
+	}}, nil
+}
+
+// evaluateExplorationShare reports the share of tracked time spent in grid
+// cells on the day of their first visit, when it reaches MinNovelRatio
+func evaluateExplorationShare(ctx context.Context, env *Env, p Period) ([]Finding, error) {
+	var trackedS, novelS int64
+	err := env.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(tracked_s), 0), COALESCE(SUM(novel_time_s), 0)
+		FROM exploration_monthly
+		WHERE month >= ? AND month < ?
+	`, p.StartDate[:7], p.EndDate[:7]).Scan(&trackedS, &novelS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exploration: %w", err)
+	}
+	if trackedS == 0 {
+		return nil, nil
+	}
+	ratio := float64(novelS) / float64(trackedS)
+	if ratio < env.Thresholds.MinNovelRatio {
+		return nil, nil
+	}
+	return []Finding{{
+		Value: ratio,
+		Data:  map[string]interface{}{"novel_pct": ratio * 100, "novel_time_s": novelS, "tracked_s": trackedS},
+	}}, nil
+}
@@ -0,0 +1,181 @@
+// Package insights turns computed statistics into short human-readable
+// findings per month and year. Findings come from a catalogue of rules, each a
+// query over the stat tables and a text template; RegisterRule adds to it.
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// Thresholds defines configurable parameters for the insight rules
+// Loaded from the "insights" section of the active threshold profile
+type Thresholds struct {
+	MinChangePct    float64 `json:"min_change_pct"`    // 10: smallest change from the previous period worth reporting
+	MinCommuteTrips int     `json:"min_commute_trips"` // 3: commutes both periods need before they are compared
+	MinNovelRatio   float64 `json:"min_novel_ratio"`   // 0.2: share of time in new places worth reporting
+	MaxExamples     int     `json:"max_examples"`      // 3: names listed in a finding
+}
+
+// DefaultThresholds provides default insight parameters
+var DefaultThresholds = Thresholds{
+	MinChangePct:    10,
+	MinCommuteTrips: 3,
+	MinNovelRatio:   0.2,
+	MaxExamples:     3,
+}
+
+// InsightsAnalyzer implements the insight generator
+// Skill: 洞察生成 (Insights)
+// Reads footprint_statistics, daily_summaries, trips and exploration_monthly,
+// so run it after footprint_statistics, daily_summary, place_anchor and
+// exploration.
+type InsightsAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds Thresholds
+}
+
+// NewInsightsAnalyzer creates a new insights analyzer
+func NewInsightsAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &InsightsAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "insights", 1000),
+		Thresholds:          DefaultThresholds,
+	}
+}
+
+// insight is one rendered finding
+type insight struct {
+	Period   Period
+	Rule     *Rule
+	Position int
+	Message  string
+	Finding  Finding
+}
+
+// Analyze performs the insight generation
+func (a *InsightsAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[InsightsAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	var minTS, maxTS sql.NullInt64
+	if err := a.DB.QueryRowContext(ctx, `
+		SELECT MIN(dataTime), MAX(dataTime) FROM "一生足迹" WHERE outlier_flag = 0
+	`).Scan(&minTS, &maxTS); err != nil {
+		return fmt.Errorf("failed to query time range: %w", err)
+	}
+	var all []Period
+	if minTS.Valid && maxTS.Valid {
+		all = periods(minTS.Int64, maxTS.Int64)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(all)), 0, 0); err != nil {
+		log.Printf("[InsightsAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	env := &Env{DB: a.DB, Thresholds: a.Thresholds}
+	var insights []insight
+	for i, p := range all {
+		for pos, rule := range catalogue {
+			findings, err := rule.Evaluate(ctx, env, p)
+			if err != nil {
+				return fmt.Errorf("rule %s failed for %s: %w", rule.Name, p.Key, err)
+			}
+			for _, f := range findings {
+				message, err := rule.render(p, f)
+				if err != nil {
+					return err
+				}
+				insights = append(insights, insight{Period: p, Rule: rule, Position: pos, Message: message, Finding: f})
+			}
+		}
+
+		if (i+1)%12 == 0 {
+			if err := a.UpdateTaskProgress(taskID, int64(len(all)), int64(i+1), 0); err != nil {
+				log.Printf("[InsightsAnalyzer] Warning: failed to update progress: %v", err)
+			}
+		}
+	}
+
+	// Findings depend on neighbouring periods, so the table is rebuilt whatever the mode
+	if err := a.replaceInsights(ctx, insights); err != nil {
+		return fmt.Errorf("failed to insert insights: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"periods":  len(all),
+		"rules":    len(catalogue),
+		"insights": len(insights),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[InsightsAnalyzer] Analysis completed: %d insights over %d periods", len(insights), len(all))
+	return nil
+}
+
+// replaceInsights replaces all insights in one transaction
+func (a *InsightsAnalyzer) replaceInsights(ctx context.Context, insights []insight) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM insights"); err != nil {
+		return fmt.Errorf("failed to clear insights: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO insights (
+			bucket_type, bucket_key, rule, category, position, message, value, change_pct, details, algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("insights")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, in := range insights {
+		details, err := json.Marshal(in.Finding.Data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal details: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx,
+			in.Period.Type, in.Period.Key, in.Rule.Name, in.Rule.Category, in.Position,
+			in.Message, in.Finding.Value, in.Finding.ChangePct, string(details),
+		); err != nil {
+			return fmt.Errorf("failed to insert insight: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("insights", NewInsightsAnalyzer)
+	analysis.RegisterDependencies("insights", "outlier_detection")
+	analysis.RegisterOutputs("insights", analysis.Output{Table: "insights", Replaced: true})
+}
@@ -0,0 +1,180 @@
+package insights
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Insight categories
+const (
+	CategoryFootprint   = "FOOTPRINT"
+	CategoryDistance    = "DISTANCE"
+	CategoryCommute     = "COMMUTE"
+	CategoryExploration = "EXPLORATION"
+	CategoryRecord      = "RECORD"
+)
+
+// Period is a month or a year that rules are evaluated for
+type Period struct {
+	Type      string // month, year
+	Key       string // 2025-05, 2025
+	Label     string // May 2025, 2025
+	Start     int64  // Unix time of the first second
+	End       int64  // Exclusive
+	StartDate string // YYYY-MM-DD of the first day
+	EndDate   string // YYYY-MM-DD of the day after the last
+	Prev      *Period
+}
+
+// Env is what rules evaluate against
+type Env struct {
+	DB         *sql.DB
+	Thresholds Thresholds
+}
+
+// Finding is one result of a rule; Data fills the rule's template
+type Finding struct {
+	Value     float64
+	ChangePct *float64
+	Data      map[string]interface{}
+}
+
+// Rule turns the statistics of a period into findings
+// Evaluate returns no findings when there is nothing worth saying. The
+// template is rendered with the finding's Data plus "period" and "prev", the
+// labels of the period and the one before it.
+type Rule struct {
+	Name     string
+	Category string
+	Template string
+	Evaluate func(ctx context.Context, env *Env, p Period) ([]Finding, error)
+
+	tmpl *template.Template
+}
+
+// templateFuncs are available to every rule template
+var templateFuncs = template.FuncMap{
+	"plural": func(n interface{}, one, many string) string {
+		if fmt.Sprint(n) == "1" {
+			return one
+		}
+		return many
+	},
+	"km": func(m float64) string {
+		if m < 10000 {
+			return fmt.Sprintf("%.1f km", m/1000)
+		}
+		return fmt.Sprintf("%.0f km", m/1000)
+	},
+	"pct": func(v float64) string {
+		return fmt.Sprintf("%.0f%%", math.Abs(v))
+	},
+	"minutes": func(s float64) string {
+		return fmt.Sprintf("%.0f min", s/60)
+	},
+	"list": func(items []string) string {
+		switch len(items) {
+		case 0:
+			return ""
+		case 1:
+			return items[0]
+		}
+		return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	},
+}
+
+// catalogue holds the registered rules in registration order
+var catalogue []*Rule
+
+// RegisterRule adds a rule to the catalogue
+// Panics on a duplicate name or a template that does not parse, so mistakes
+// surface at startup.
+func RegisterRule(rule Rule) {
+	for _, r := range catalogue {
+		if r.Name == rule.Name {
+			panic(fmt.Sprintf("insights: duplicate rule %q", rule.Name))
+		}
+	}
+	rule.tmpl = template.Must(template.New(rule.Name).Funcs(templateFuncs).Option("missingkey=error").Parse(rule.Template))
+	catalogue = append(catalogue, &rule)
+}
+
+// Rules returns the names of the registered rules in catalogue order
+func Rules() []string {
+	names := make([]string, len(catalogue))
+	for i, r := range catalogue {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// render fills the rule template for a finding of period p
+func (r *Rule) render(p Period, f Finding) (string, error) {
+	data := make(map[string]interface{}, len(f.Data)+2)
+	for k, v := range f.Data {
+		data[k] = v
+	}
+	data["period"] = p.Label
+	data["prev"] = ""
+	if p.Prev != nil {
+		data["prev"] = p.Prev.Label
+	}
+
+	var sb strings.Builder
+	if err := r.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render rule %s: %w", r.Name, err)
+	}
+	return sb.String(), nil
+}
+
+// changePct returns the change from prev to cur in percent; nil when prev is zero
+func changePct(cur, prev float64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	pct := (cur - prev) / math.Abs(prev) * 100
+	return &pct
+}
+
+// periods returns every local month and year from the one holding minTS to the
+// one holding maxTS, each linked to the period before it
+func periods(minTS, maxTS int64) []Period {
+	first, last := time.Unix(minTS, 0), time.Unix(maxTS, 0)
+	newPeriod := func(typ string, start, end time.Time, prev *Period) Period {
+		p := Period{
+			Type:      typ,
+			Start:     start.Unix(),
+			End:       end.Unix(),
+			StartDate: start.Format("2006-01-02"),
+			EndDate:   end.Format("2006-01-02"),
+			Prev:      prev,
+		}
+		if typ == "year" {
+			p.Key, p.Label = start.Format("2006"), start.Format("2006")
+		} else {
+			p.Key, p.Label = start.Format("2006-01"), start.Format("January 2006")
+		}
+		return p
+	}
+
+	var result []Period
+	var prev *Period
+	for m := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.Local); !m.After(last); m = m.AddDate(0, 1, 0) {
+		p := newPeriod("month", m, m.AddDate(0, 1, 0), prev)
+		result = append(result, p)
+		prev = &p
+	}
+	prev = nil
+	for y := first.Year(); y <= last.Year(); y++ {
+		start := time.Date(y, time.January, 1, 0, 0, 0, 0, time.Local)
+		p := newPeriod("year", start, start.AddDate(1, 0, 0), prev)
+		result = append(result, p)
+		prev = &p
+	}
+	return result
+}
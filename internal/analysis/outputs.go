@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// Output is a table, or part of one, that an analyzer derives from the
+// tracks; it is cleared before a full recompute of the analyzer
+type Output struct {
+	Table string
+	// Where limits the output to the rows matching a condition, for analyzers
+	// sharing a table with others; empty for the whole table
+	Where string
+	// Set clears these columns instead of deleting the rows, for outputs kept
+	// on the track point table, e.g. "computed_speed = NULL"
+	Set string
+	// Window is a condition selecting the rows derived from a time window,
+	// with the window start and end as its parameters; empty when the
+	// analyzer cannot recompute a window
+	Window string
+	// Replaced outputs are rebuilt by the analyzer itself in one transaction,
+	// so nothing is cleared beforehand
+	Replaced bool
+}
+
+// Window is a time range to recompute, in Unix seconds with an exclusive end
+type Window struct {
+	Start int64
+	End   int64
+}
+
+// Output actions, as reported by a recompute plan
+const (
+	OutputTruncate = "truncate" // Every row is deleted
+	OutputDelete   = "delete"   // The matching rows are deleted
+	OutputUpdate   = "update"   // Columns of the matching rows are cleared
+	OutputReplace  = "replace"  // Rebuilt by the analyzer on completion
+)
+
+var (
+	outputsMu sync.RWMutex
+	outputs   = make(map[string][]Output)
+)
+
+// RegisterOutputs records the outputs of a skill
+func RegisterOutputs(skillName string, skillOutputs ...Output) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	outputs[skillName] = append(outputs[skillName], skillOutputs...)
+}
+
+// GetOutputs returns the outputs registered for a skill
+func GetOutputs(skillName string) []Output {
+	outputsMu.RLock()
+	defer outputsMu.RUnlock()
+	return outputs[skillName]
+}
+
+// SupportsWindow reports whether every output a skill clears can be limited
+// to a time window, that is whether the skill can recompute a window
+func SupportsWindow(skillName string) bool {
+	cleared := 0
+	for _, o := range GetOutputs(skillName) {
+		if o.Replaced {
+			continue
+		}
+		if o.Window == "" {
+			return false
+		}
+		cleared++
+	}
+	return cleared > 0
+}
+
+// Action returns how the output is cleared, within window when not nil
+func (o Output) Action(window *Window) string {
+	switch {
+	case o.Replaced:
+		return OutputReplace
+	case o.Set != "":
+		return OutputUpdate
+	case o.Where != "" || window != nil && o.Window != "":
+		return OutputDelete
+	}
+	return OutputTruncate
+}
+
+// condition returns the WHERE clause and arguments selecting the rows of the
+// output, within window when not nil and the output supports windows
+func (o Output) condition(window *Window) (string, []interface{}) {
+	var where string
+	var args []interface{}
+	if o.Where != "" {
+		where = "(" + o.Where + ")"
+	}
+	if window != nil && o.Window != "" {
+		if where != "" {
+			where += " AND "
+		}
+		where += "(" + o.Window + ")"
+		args = append(args, window.Start, window.End)
+	}
+	if where == "" {
+		return "", nil
+	}
+	return " WHERE " + where, args
+}
+
+// CountRows counts the rows a recompute would clear, within window when not nil
+// A table that does not exist yet has no rows.
+func (o Output) CountRows(ctx context.Context, db *sql.DB, window *Window) (int64, error) {
+	exists, err := tableExists(ctx, db, o.Table)
+	if err != nil || !exists {
+		return 0, err
+	}
+	where, args := o.condition(window)
+	var count int64
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+o.Table+`"`+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rows of %s: %w", o.Table, err)
+	}
+	return count, nil
+}
+
+// ResetOutputs clears the outputs of a skill before it recomputes them, within
+// window when not nil, and returns the cleared rows by table
+// All outputs are cleared in one write transaction so no dependent row can
+// sneak in between; replaced outputs and tables that do not exist yet are
+// skipped.
+func ResetOutputs(ctx context.Context, db *sql.DB, skillName string, window *Window) (map[string]int64, error) {
+	skillOutputs := GetOutputs(skillName)
+	cleared := make(map[string]int64)
+	if len(skillOutputs) == 0 {
+		return cleared, nil
+	}
+
+	tx, err := NewBaseAnalyzer(db, skillName).BeginWriteTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, o := range skillOutputs {
+		if o.Replaced {
+			continue
+		}
+		exists, err := tableExists(ctx, db, o.Table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		where, args := o.condition(window)
+		query := `DELETE FROM "` + o.Table + `"` + where
+		if o.Set != "" {
+			query = `UPDATE "` + o.Table + `" SET ` + o.Set + where
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", o.Table, err)
+		}
+		n, _ := result.RowsAffected()
+		cleared[o.Table] += n
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return cleared, nil
+}
+
+// tableExists reports whether a table exists
+func tableExists(ctx context.Context, db *sql.DB, table string) (bool, error) {
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to look up table %s: %w", table, err)
+	}
+	return n > 0, nil
+}
+
+type windowKey struct{}
+
+// WithWindow returns a context telling analyzers to recompute only window
+func WithWindow(ctx context.Context, window Window) context.Context {
+	return context.WithValue(ctx, windowKey{}, window)
+}
+
+// WindowFrom returns the window an analyzer should recompute, if any
+func WindowFrom(ctx context.Context) (Window, bool) {
+	window, ok := ctx.Value(windowKey{}).(Window)
+	return window, ok
+}
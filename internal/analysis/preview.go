@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidThresholds is returned for preview thresholds that do not fit the
+// analyzer's threshold section
+var ErrInvalidThresholds = errors.New("invalid thresholds")
+
+// Previewer is implemented by analyzers that can run their algorithm on a
+// time window without writing anything, so thresholds can be tuned before a
+// full recompute
+type Previewer interface {
+	// Preview runs the analysis on the tracks of window, with thresholds
+	// overriding the analyzer's section of the default threshold profile
+	Preview(ctx context.Context, window Window, thresholds json.RawMessage) (*Preview, error)
+}
+
+// Preview is what an analyzer would derive from a time window
+type Preview struct {
+	Thresholds interface{} // Thresholds the preview ran with
+	Inputs     int         // Track points or rows read
+	Count      int         // Results found
+	Results    interface{} // The results, in the analyzer's own shape
+}
+
+// PreviewThresholds fills dst with the analyzer's section of the default
+// threshold profile, overridden by the keys of override
+// dst must be pre-filled with built-in defaults; keys unknown to dst are
+// rejected so typos do not silently preview the defaults.
+func (a *BaseAnalyzer) PreviewThresholds(ctx context.Context, override json.RawMessage, dst interface{}) error {
+	if err := a.LoadThresholds(ctx, 0, dst); err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(override)) == 0 || string(bytes.TrimSpace(override)) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(override))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("%w for %s: %v", ErrInvalidThresholds, a.Name, err)
+	}
+	return nil
+}
+
+// PreviewSkills returns the registered skills whose analyzers support previews
+func PreviewSkills() []string {
+	var skills []string
+	for skill, factory := range AnalyzerRegistry {
+		if _, ok := factory(nil).(Previewer); ok {
+			skills = append(skills, skill)
+		}
+	}
+	return skills
+}
@@ -47,7 +47,7 @@ func (a *PythonWorkerAnalyzer) Analyze(ctx context.Context, taskID int64, mode s
 			    completed_at = CURRENT_TIMESTAMP
 			WHERE id = ?
 		`
-		a.DB.Exec(failQuery, string(output), taskID)
+		a.ExecWrite(context.Background(), failQuery, string(output), taskID)
 		return fmt.Errorf("python worker failed: %w, output: %s", err, string(output))
 	}
 
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/metrics"
+)
+
+// completionHooks are called with the skill name of every completed task run
+var (
+	completionHooksMu sync.Mutex
+	completionHooks   []func(skill string)
+)
+
+// OnTaskCompleted registers fn to be called after an analyzer completes a task,
+// for example to drop cached results computed from the analyzer's tables
+func OnTaskCompleted(fn func(skill string)) {
+	completionHooksMu.Lock()
+	defer completionHooksMu.Unlock()
+	completionHooks = append(completionHooks, fn)
+}
+
+// taskRun tracks a running task for the analyzer metrics
+type taskRun struct {
+	start     time.Time
+	processed int64
+}
+
+// runs holds the tasks between MarkTaskAsRunning and their completion
+var runs sync.Map // taskID -> *taskRun
+
+// StartRun records the start of a task run
+// It is called by the task runner and again by MarkTaskAsRunning; the first
+// call wins.
+func StartRun(taskID int64) {
+	runs.LoadOrStore(taskID, &taskRun{start: time.Now()})
+}
+
+// recordProgress remembers the processed count last reported by a task
+func recordProgress(taskID int64, processed int64) {
+	if v, ok := runs.Load(taskID); ok {
+		run := v.(*taskRun)
+		if processed > run.processed {
+			run.processed = processed
+		}
+	}
+}
+
+// FinishRun reports the duration and processed rows of a task run and, for
+// completed runs, calls the completion hooks
+// It is called when an analyzer marks its task completed or failed, and by the
+// task runner for analyzers that return an error without marking the task;
+// later calls for the same task do nothing.
+func FinishRun(taskID int64, skill, status string) {
+	v, ok := runs.LoadAndDelete(taskID)
+	if !ok {
+		return
+	}
+	run := v.(*taskRun)
+	metrics.AnalyzerRunDuration.Observe(time.Since(run.start).Seconds(), skill, status)
+	metrics.AnalyzerRowsProcessed.Add(float64(run.processed), skill)
+
+	if status == "completed" {
+		completionHooksMu.Lock()
+		hooks := append([]func(string){}, completionHooks...)
+		completionHooksMu.Unlock()
+		for _, hook := range hooks {
+			hook(skill)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package analysis
+
+import "fmt"
+
+// SegmentAreaColumns are the administrative area columns of segments, in the
+// order SegmentAreaValues selects them
+const SegmentAreaColumns = "province, city, county"
+
+// segmentAreaExpr selects a column of the earlier geocoded of the start and
+// end points of a segment, so a segment belongs to the area it starts in
+// unless its start point has no area; the second verb is both point ids
+const segmentAreaExpr = `(
+	SELECT %s FROM "一生足迹"
+	WHERE id IN (%s) AND province != ''
+	ORDER BY dataTime
+	LIMIT 1
+)`
+
+// SegmentAreaValues is the SQL value list of SegmentAreaColumns, bound by SegmentAreaArgs
+var SegmentAreaValues = fmt.Sprintf(segmentAreaExpr+", "+segmentAreaExpr+", "+segmentAreaExpr,
+	"province", "?, ?", "city", "?, ?", "county", "?, ?")
+
+// SegmentAreaSet is the SET clause of an UPDATE of segments that gives every
+// segment the areas SegmentAreaValues would, for segments built before their
+// points were geocoded
+var SegmentAreaSet = fmt.Sprintf("province = "+segmentAreaExpr+", city = "+segmentAreaExpr+", county = "+segmentAreaExpr,
+	"province", segmentAreaPoints, "city", segmentAreaPoints, "county", segmentAreaPoints)
+
+// segmentAreaPoints are the point ids of the segment SegmentAreaSet updates
+const segmentAreaPoints = "segments.start_point_id, segments.end_point_id"
+
+// SegmentAreaArgs returns the parameters of SegmentAreaValues for a segment
+func SegmentAreaArgs(startPointID, endPointID int64) []interface{} {
+	return []interface{}{startPointID, endPointID, startPointID, endPointID, startPointID, endPointID}
+}
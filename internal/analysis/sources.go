@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+)
+
+// SourceAll is the source of bucketed stat rows that aggregate every track point source
+const SourceAll = "all"
+
+// DominantSourceExpr is a SQL expression selecting the source that contributes
+// the most track points to a time range; it takes the range start and end as
+// parameters and yields NULL when the range has no points
+const DominantSourceExpr = `(
+	SELECT source FROM "一生足迹"
+	WHERE dataTime BETWEEN ? AND ?
+	GROUP BY source
+	ORDER BY COUNT(*) DESC, source
+	LIMIT 1
+)`
+
+// LoadSources returns the source scopes bucketed stats are computed for:
+// SourceAll followed by every source that has track points
+func (a *BaseAnalyzer) LoadSources(ctx context.Context) ([]string, error) {
+	rows, err := a.DB.QueryContext(ctx, `SELECT DISTINCT source FROM "一生足迹" ORDER BY source`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []string{SourceAll}
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			return nil, fmt.Errorf("failed to scan source: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	return sources, rows.Err()
+}
+
+// SourceScopes returns the source scopes a record of source counts towards:
+// SourceAll and, when known, its own source
+func SourceScopes(source string) []string {
+	if source == "" {
+		return []string{SourceAll}
+	}
+	return []string{SourceAll, source}
+}
+
+// SourceCondition returns an " AND column = ?" condition and its argument
+// restricting a query to source; both are empty for SourceAll
+func SourceCondition(column, source string) (string, []interface{}) {
+	if source == "" || source == SourceAll {
+		return "", nil
+	}
+	return " AND " + column + " = ?", []interface{}{source}
+}
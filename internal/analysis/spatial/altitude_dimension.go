@@ -34,14 +34,6 @@ func (a *AltitudeDimensionAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing events (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM altitude_events"); err != nil {
-			return fmt.Errorf("failed to clear altitude_events: %w", err)
-		}
-		log.Printf("[AltitudeDimensionAnalyzer] Cleared existing altitude events")
-	}
-
 	// Query track points with altitude data
 	query := `
 		SELECT
@@ -264,7 +256,7 @@ func (a *AltitudeDimensionAnalyzer) insertAltitudeEvents(ctx context.Context, ev
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -277,7 +269,7 @@ func (a *AltitudeDimensionAnalyzer) insertAltitudeEvents(ctx context.Context, ev
 			duration_s, avg_grade, distance_m,
 			province, city, county,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("altitude_dimension") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -320,4 +312,6 @@ func (a *AltitudeDimensionAnalyzer) countEventsByType(events []AltitudeEvent, ev
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("altitude_dimension", NewAltitudeDimensionAnalyzer)
+	analysis.RegisterDependencies("altitude_dimension", "elevation_backfill")
+	analysis.RegisterOutputs("altitude_dimension", analysis.Output{Table: "altitude_events"})
 }
\ No newline at end of file
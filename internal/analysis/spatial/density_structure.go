@@ -9,15 +9,57 @@ import (
 	"math"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
-// DensityStructureAnalyzer implements spatial density analysis (simplified)
+// DensityStructureAnalyzer implements spatial density analysis
 // Skill: 密度结构分析 (Density Structure)
-// Analyzes spatial density patterns using grid-based approach
+// Scores the grid cells of every zoom level from stays, visit days and points,
+// classifies them by score percentile and clusters the high-density cells
+// with DBSCAN.
 type DensityStructureAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
 
+// DensityThresholds defines configurable parameters for density structure
+// Loaded from the "density_structure" section of the active threshold profile
+type DensityThresholds struct {
+	CorePercentile       float64 `json:"core_percentile"`       // 90: cells scoring at least this percentile of their level are core
+	FrequentPercentile   float64 `json:"frequent_percentile"`   // 70
+	OccasionalPercentile float64 `json:"occasional_percentile"` // 30: cells below are rare
+	ClusterEpsCells      int     `json:"cluster_eps_cells"`     // 1: DBSCAN neighbourhood, in cells of the same level
+	ClusterMinCells      int     `json:"cluster_min_cells"`     // 3: high-density cells, itself included, within reach of a cluster core
+}
+
+// DefaultDensityThresholds provides default density structure parameters
+var DefaultDensityThresholds = DensityThresholds{
+	CorePercentile:       90,
+	FrequentPercentile:   70,
+	OccasionalPercentile: 30,
+	ClusterEpsCells:      1,
+	ClusterMinCells:      3,
+}
+
+// validate checks that the percentiles are ordered and the DBSCAN parameters positive
+func (t DensityThresholds) validate() error {
+	if !(t.OccasionalPercentile <= t.FrequentPercentile && t.FrequentPercentile <= t.CorePercentile) {
+		return fmt.Errorf("density percentiles must be ordered occasional <= frequent <= core, got %v, %v, %v",
+			t.OccasionalPercentile, t.FrequentPercentile, t.CorePercentile)
+	}
+	if t.ClusterEpsCells < 1 || t.ClusterMinCells < 1 {
+		return fmt.Errorf("cluster eps and min cells must be positive, got %d and %d", t.ClusterEpsCells, t.ClusterMinCells)
+	}
+	return nil
+}
+
+// Density levels, from the highest scores down
+const (
+	densityCore       = "core"
+	densityFrequent   = "frequent"
+	densityOccasional = "occasional"
+	densityRare       = "rare"
+)
+
 // NewDensityStructureAnalyzer creates a new density structure analyzer
 func NewDensityStructureAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &DensityStructureAnalyzer{
@@ -34,100 +76,65 @@ func (a *DensityStructureAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing zones (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM spatial_density_grid_stats"); err != nil {
-			return fmt.Errorf("failed to clear spatial_density_grid_stats: %w", err)
-		}
-		log.Printf("[DensityStructureAnalyzer] Cleared existing density zones")
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultDensityThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	if err := thresholds.validate(); err != nil {
+		return err
 	}
 
-	// Query grid cells with statistics
-	// Note: grid_cells doesn't have admin columns, we'll leave them empty
-	query := `
-		SELECT
-			grid_id, visit_count, point_count, total_duration_s,
-			center_lat, center_lon
-		FROM grid_cells
-		WHERE visit_count > 0
-		ORDER BY visit_count DESC
-	`
-
-	rows, err := a.DB.QueryContext(ctx, query)
+	sources, err := a.LoadSources(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to query grid cells: %w", err)
+		return err
 	}
-	defer rows.Close()
-
-	var zones []DensityZone
-	var allVisitCounts []int64
-
-	for rows.Next() {
-		var zone DensityZone
 
-		if err := rows.Scan(
-			&zone.GridID, &zone.VisitCount, &zone.PointCount, &zone.TotalDuration,
-			&zone.CenterLat, &zone.CenterLon,
-		); err != nil {
-			return fmt.Errorf("failed to scan grid cell: %w", err)
+	// Classify and cluster the grid cells of all sources and of each source
+	// separately; cluster ids are numbered per source across levels
+	zonesBySource := make(map[string][]DensityZone, len(sources))
+	for _, source := range sources {
+		levels, err := a.loadZones(ctx, source)
+		if err != nil {
+			return err
 		}
 
-		// Calculate visit_days from grid metadata if available
-		// For now, estimate as visit_count (simplified)
-		zone.VisitDays = int(zone.VisitCount)
-
-		zones = append(zones, zone)
-		allVisitCounts = append(allVisitCounts, zone.VisitCount)
-	}
-
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	if len(zones) == 0 {
-		log.Printf("[DensityStructureAnalyzer] No grid cells to process")
-		return a.MarkTaskAsCompleted(taskID, `{"zones": 0}`)
+		var sourceZones []DensityZone
+		clusters := 0
+		for _, zones := range levels {
+			classifyDensityLevels(zones, thresholds)
+			clusters += clusterZones(zones, thresholds, clusters+1)
+			sourceZones = append(sourceZones, zones...)
+		}
+		log.Printf("[DensityStructureAnalyzer] Classified %d grid cells into %d clusters (source=%s)",
+			len(sourceZones), clusters, source)
+		zonesBySource[source] = sourceZones
 	}
 
-	log.Printf("[DensityStructureAnalyzer] Processing %d grid cells", len(zones))
-
-	// Calculate density scores and classify zones
-	a.calculateDensityScores(zones, allVisitCounts)
-
-	// Insert density zones
-	if err := a.insertDensityZones(ctx, zones); err != nil {
+	if err := a.insertDensityZones(ctx, sources, zonesBySource); err != nil {
 		return fmt.Errorf("failed to insert density zones: %w", err)
 	}
 
-	// Count zones by density level
-	coreCount := 0
-	secondaryCount := 0
-	activeCount := 0
-	peripheralCount := 0
-	rareCount := 0
+	// Count zones of all sources by density level
+	zones := zonesBySource[analysis.SourceAll]
+	levelCounts := make(map[string]int)
+	clusterIDs := make(map[int]bool)
 	for _, zone := range zones {
-		switch zone.DensityLevel {
-		case "core":
-			coreCount++
-		case "secondary":
-			secondaryCount++
-		case "active":
-			activeCount++
-		case "peripheral":
-			peripheralCount++
-		case "rare":
-			rareCount++
+		levelCounts[zone.DensityLevel]++
+		if zone.ClusterID > 0 {
+			clusterIDs[zone.ClusterID] = true
 		}
 	}
 
 	// Mark task as completed
 	summary := map[string]interface{}{
 		"total_zones":      len(zones),
-		"core_zones":       coreCount,
-		"secondary_zones":  secondaryCount,
-		"active_zones":     activeCount,
-		"peripheral_zones": peripheralCount,
-		"rare_zones":       rareCount,
+		"core_zones":       levelCounts[densityCore],
+		"frequent_zones":   levelCounts[densityFrequent],
+		"occasional_zones": levelCounts[densityOccasional],
+		"rare_zones":       levelCounts[densityRare],
+		"clusters":         len(clusterIDs),
+		"sources":          sources,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -139,89 +146,264 @@ func (a *DensityStructureAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 	return nil
 }
 
-// DensityZone holds density zone data
+// DensityZone holds the density of a grid cell
 type DensityZone struct {
-	GridID        string
-	DensityScore  float64
-	PointCount    int64
-	VisitCount    int64
-	TotalDuration int64
-	VisitDays     int
-	DensityLevel  string // 'core', 'secondary', 'active', 'peripheral', 'rare'
-	CenterLat     float64
-	CenterLon     float64
-	Province      string
-	City          string
-	County        string
+	GridID         string
+	Level          int
+	X              int
+	Y              int
+	CenterLat      float64
+	CenterLon      float64
+	AreaKm2        float64
+	PointCount     int64
+	StayCount      int64
+	StayDurationS  int64
+	VisitDays      int
+	DensityScore   float64
+	DensityLevel   string // 'core', 'frequent', 'occasional', 'rare'
+	ClusterID      int    // 0 when the cell is in no cluster
+	ClusterAreaKm2 float64
+	Province       string
+	City           string
+	County         string
+
+	lastDay     int64 // Local day of the latest point counted in VisitDays
+	longestStay int64 // Duration of the stay the admin context was taken from
 }
 
-// calculateDensityScores calculates density scores using weighted formula and classifies zones
-func (a *DensityStructureAnalyzer) calculateDensityScores(zones []DensityZone, allVisitCounts []int64) {
-	if len(zones) == 0 {
-		return
+// loadZones aggregates the points and spatial stays of a source into the grid
+// cells of every zoom level, returned level by level in gridLevels order
+func (a *DensityStructureAnalyzer) loadZones(ctx context.Context, source string) ([][]DensityZone, error) {
+	cells := make([]map[string]*DensityZone, len(gridLevels))
+	for i := range cells {
+		cells[i] = make(map[string]*DensityZone)
+	}
+	cellAt := func(i int, lat, lon float64) *DensityZone {
+		level := gridLevels[i]
+		x, y := latLonToTile(lat, lon, level)
+		gridID := fmt.Sprintf("L%d_%d_%d", level, x, y)
+		zone, ok := cells[i][gridID]
+		if !ok {
+			minLat, minLon, maxLat, maxLon := tileToBounds(x, y, level)
+			zone = &DensityZone{
+				GridID:    gridID,
+				Level:     level,
+				X:         x,
+				Y:         y,
+				CenterLat: (minLat + maxLat) / 2,
+				CenterLon: (minLon + maxLon) / 2,
+				AreaKm2:   boundsAreaKm2(minLat, minLon, maxLat, maxLon),
+			}
+			cells[i][gridID] = zone
+		}
+		return zone
 	}
 
-	// Calculate weighted density scores for all zones
-	scores := make([]float64, len(zones))
-	for i := range zones {
-		scores[i] = calculateWeightedDensityScore(
-			zones[i].TotalDuration,
-			zones[i].VisitDays,
-			int(zones[i].VisitCount),
-		)
-		zones[i].DensityScore = scores[i]
-	}
-
-	// Sort scores to find percentiles
-	sortedScores := make([]float64, len(scores))
-	copy(sortedScores, scores)
-
-	// Simple bubble sort (sufficient for this use case)
-	for i := 0; i < len(sortedScores); i++ {
-		for j := i + 1; j < len(sortedScores); j++ {
-			if sortedScores[i] < sortedScores[j] {
-				sortedScores[i], sortedScores[j] = sortedScores[j], sortedScores[i]
+	// Points are read in time order, so a cell's visit days grow whenever a
+	// point falls on a later day than the cell's previous point
+	sourceCond, args := analysis.SourceCondition("source", source)
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude
+		FROM "一生足迹"
+		WHERE outlier_flag = 0`+sourceCond+`
+		ORDER BY dataTime
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts int64
+		var lat, lon float64
+		if err := rows.Scan(&ts, &lat, &lon); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		day := localDay(ts).Unix()
+		for i := range gridLevels {
+			zone := cellAt(i, lat, lon)
+			zone.PointCount++
+			if zone.VisitDays == 0 || day != zone.lastDay {
+				zone.VisitDays++
+				zone.lastDay = day
 			}
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating points: %w", err)
+	}
+	rows.Close()
 
-	// Calculate percentile thresholds
-	// p90 (top 10%), p70 (top 30%), p30 (top 70%), p10 (top 90%)
-	p90 := sortedScores[len(sortedScores)/10]
-	p70 := sortedScores[len(sortedScores)*3/10]
-	p30 := sortedScores[len(sortedScores)*7/10]
-	p10 := sortedScores[len(sortedScores)*9/10]
+	// Stays add their duration to the cell of their center, and the longest
+	// stay of a cell gives its admin context
+	stayRows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			duration_s, center_lat, center_lon,
+			COALESCE(province, ''), COALESCE(city, ''), COALESCE(county, '')
+		FROM stay_segments
+		WHERE stay_type = 'SPATIAL'
+			AND center_lat IS NOT NULL AND center_lon IS NOT NULL`+sourceCond,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer stayRows.Close()
+
+	for stayRows.Next() {
+		var duration int64
+		var lat, lon float64
+		var province, city, county string
+		if err := stayRows.Scan(&duration, &lat, &lon, &province, &city, &county); err != nil {
+			return nil, fmt.Errorf("failed to scan stay: %w", err)
+		}
+		for i := range gridLevels {
+			zone := cellAt(i, lat, lon)
+			zone.StayCount++
+			zone.StayDurationS += duration
+			if province != "" && duration > zone.longestStay {
+				zone.Province, zone.City, zone.County = province, city, county
+				zone.longestStay = duration
+			}
+		}
+	}
+	if err := stayRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stays: %w", err)
+	}
 
-	// Classify density levels
-	for i := range zones {
-		zones[i].DensityLevel = classifyDensityLevel(zones[i].DensityScore, p90, p70, p30, p10)
+	levels := make([][]DensityZone, len(gridLevels))
+	for i, levelCells := range cells {
+		for _, zone := range levelCells {
+			zone.DensityScore = calculateWeightedDensityScore(zone.StayDurationS, zone.VisitDays, zone.PointCount)
+			levels[i] = append(levels[i], *zone)
+		}
 	}
+	return levels, nil
 }
 
 // calculateWeightedDensityScore calculates weighted density score
-// Formula: a*log(1+duration_hours) + b*log(1+days) + c*log(1+count)
-func calculateWeightedDensityScore(stayDuration int64, visitDays int, stayCount int) float64 {
+// Formula: a*log(1+duration_hours) + b*log(1+days) + c*log(1+points)
+func calculateWeightedDensityScore(stayDuration int64, visitDays int, pointCount int64) float64 {
 	a := 0.5 // duration weight
 	b := 0.3 // days weight
-	c := 0.2 // count weight
+	c := 0.2 // point weight
 
 	durationHours := float64(stayDuration) / 3600.0
-	score := a*log1p(durationHours) + b*log1p(float64(visitDays)) + c*log1p(float64(stayCount))
+	score := a*log1p(durationHours) + b*log1p(float64(visitDays)) + c*log1p(float64(pointCount))
 	return score
 }
 
-// classifyDensityLevel classifies density level based on percentiles
-func classifyDensityLevel(score, p90, p70, p30, p10 float64) string {
-	if score >= p90 {
-		return "core" // Top 10%
-	} else if score >= p70 {
-		return "secondary" // 10-30%
-	} else if score >= p30 {
-		return "active" // 30-70%
-	} else if score >= p10 {
-		return "peripheral" // 70-90%
-	}
-	return "rare" // Bottom 10%
+// classifyDensityLevels classifies the cells of one zoom level by the
+// percentiles of their scores
+func classifyDensityLevels(zones []DensityZone, th DensityThresholds) {
+	if len(zones) == 0 {
+		return
+	}
+
+	scores := make([]float64, len(zones))
+	for i, zone := range zones {
+		scores[i] = zone.DensityScore
+	}
+	cuts := stats.Percentiles(scores, []float64{th.CorePercentile, th.FrequentPercentile, th.OccasionalPercentile})
+
+	for i := range zones {
+		zones[i].DensityLevel = classifyDensityLevel(zones[i].DensityScore, cuts[0], cuts[1], cuts[2])
+	}
+}
+
+// classifyDensityLevel classifies a score against the core, frequent and
+// occasional percentile scores
+func classifyDensityLevel(score, core, frequent, occasional float64) string {
+	switch {
+	case score >= core:
+		return densityCore
+	case score >= frequent:
+		return densityFrequent
+	case score >= occasional:
+		return densityOccasional
+	}
+	return densityRare
+}
+
+// isHighDensity reports whether a cell takes part in clustering
+func isHighDensity(zone DensityZone) bool {
+	return zone.DensityLevel == densityCore || zone.DensityLevel == densityFrequent
+}
+
+// clusterZones runs DBSCAN over the core and frequent cells of one zoom level
+// Cells are neighbours within ClusterEpsCells tiles of each other in both
+// directions, and a cell with at least ClusterMinCells high-density cells in
+// its neighbourhood is a cluster core. Clusters are numbered from firstID and
+// each member gets the summed area of the cluster; the number of clusters
+// found is returned.
+func clusterZones(zones []DensityZone, th DensityThresholds, firstID int) int {
+	type tile struct{ x, y int }
+	index := make(map[tile]int)
+	for i, zone := range zones {
+		if isHighDensity(zone) {
+			index[tile{zone.X, zone.Y}] = i
+		}
+	}
+
+	neighbours := func(i int) []int {
+		var result []int
+		for dx := -th.ClusterEpsCells; dx <= th.ClusterEpsCells; dx++ {
+			for dy := -th.ClusterEpsCells; dy <= th.ClusterEpsCells; dy++ {
+				if j, ok := index[tile{zones[i].X + dx, zones[i].Y + dy}]; ok {
+					result = append(result, j)
+				}
+			}
+		}
+		return result
+	}
+
+	visited := make(map[int]bool)
+	clusters := 0
+	for i := range zones {
+		if !isHighDensity(zones[i]) || visited[i] {
+			continue
+		}
+		visited[i] = true
+		seeds := neighbours(i)
+		if len(seeds) < th.ClusterMinCells {
+			continue // Noise, unless a later core reaches it
+		}
+
+		id := firstID + clusters
+		clusters++
+		members := []int{i}
+		zones[i].ClusterID = id
+		for k := 0; k < len(seeds); k++ {
+			j := seeds[k]
+			if zones[j].ClusterID == 0 {
+				zones[j].ClusterID = id
+				members = append(members, j)
+			}
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if next := neighbours(j); len(next) >= th.ClusterMinCells {
+				seeds = append(seeds, next...)
+			}
+		}
+
+		area := 0.0
+		for _, j := range members {
+			area += zones[j].AreaKm2
+		}
+		for _, j := range members {
+			zones[j].ClusterAreaKm2 = area
+		}
+	}
+	return clusters
+}
+
+// boundsAreaKm2 returns the area of a latitude/longitude box on a spherical earth
+func boundsAreaKm2(minLat, minLon, maxLat, maxLon float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := math.Pi / 180
+	return earthRadiusKm * earthRadiusKm * (maxLon - minLon) * toRad *
+		math.Abs(math.Sin(maxLat*toRad)-math.Sin(minLat*toRad))
 }
 
 // log1p calculates log(1 + x) safely
@@ -232,33 +414,27 @@ func log1p(x float64) float64 {
 	return math.Log(1 + x)
 }
 
-// insertDensityZones inserts density zones into the database
-func (a *DensityStructureAnalyzer) insertDensityZones(ctx context.Context, zones []DensityZone) error {
-	if len(zones) == 0 {
-		return nil
-	}
-
-	tx, err := a.DB.BeginTx(ctx, nil)
+// insertDensityZones replaces the density grid with the zones of every source
+func (a *DensityStructureAnalyzer) insertDensityZones(ctx context.Context, sources []string, zonesBySource map[string][]DensityZone) error {
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if _, err := tx.ExecContext(ctx, "DELETE FROM spatial_density_grid_stats"); err != nil {
+		return fmt.Errorf("failed to clear density grid: %w", err)
+	}
+
 	insertQuery := `
 		INSERT INTO spatial_density_grid_stats (
-			bucket_type, bucket_key, grid_id,
+			bucket_type, bucket_key, source, grid_id,
 			center_lat, center_lon, province, city, county,
 			density_score, density_level,
 			stay_duration_s, stay_count, visit_days,
+			cluster_id, cluster_area_km2,
 			algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1')
-		ON CONFLICT(bucket_type, bucket_key, grid_id) DO UPDATE SET
-			density_score = excluded.density_score,
-			density_level = excluded.density_level,
-			stay_duration_s = excluded.stay_duration_s,
-			stay_count = excluded.stay_count,
-			visit_days = excluded.visit_days,
-			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("density_structure") + `')
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -267,15 +443,24 @@ func (a *DensityStructureAnalyzer) insertDensityZones(ctx context.Context, zones
 	}
 	defer stmt.Close()
 
-	for _, zone := range zones {
-		_, err := stmt.ExecContext(ctx,
-			"all", nil, zone.GridID,
-			zone.CenterLat, zone.CenterLon, zone.Province, zone.City, zone.County,
-			zone.DensityScore, zone.DensityLevel,
-			zone.TotalDuration, zone.VisitCount, zone.VisitDays,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert density zone: %w", err)
+	total := 0
+	for _, source := range sources {
+		for _, zone := range zonesBySource[source] {
+			var clusterID, clusterArea interface{}
+			if zone.ClusterID > 0 {
+				clusterID, clusterArea = zone.ClusterID, zone.ClusterAreaKm2
+			}
+			_, err := stmt.ExecContext(ctx,
+				"all", nil, source, zone.GridID,
+				zone.CenterLat, zone.CenterLon, zone.Province, zone.City, zone.County,
+				zone.DensityScore, zone.DensityLevel,
+				zone.StayDurationS, zone.StayCount, zone.VisitDays,
+				clusterID, clusterArea,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert density zone: %w", err)
+			}
+			total++
 		}
 	}
 
@@ -283,11 +468,14 @@ func (a *DensityStructureAnalyzer) insertDensityZones(ctx context.Context, zones
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("[DensityStructureAnalyzer] Inserted %d density zones", len(zones))
+	log.Printf("[DensityStructureAnalyzer] Inserted %d density zones", total)
 	return nil
 }
 
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("density_structure", NewDensityStructureAnalyzer)
-}
\ No newline at end of file
+	analysis.RegisterVersion("density_structure", "v2")
+	analysis.RegisterDependencies("density_structure", "outlier_detection", "stay_detection")
+	analysis.RegisterOutputs("density_structure", analysis.Output{Table: "spatial_density_grid_stats", Replaced: true})
+}
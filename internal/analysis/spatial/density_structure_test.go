@@ -0,0 +1,66 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifyDensityLevels(t *testing.T) {
+	zones := make([]DensityZone, 10)
+	for i := range zones {
+		zones[i].DensityScore = float64(i + 1)
+	}
+
+	classifyDensityLevels(zones, DefaultDensityThresholds)
+
+	want := []string{"rare", "rare", "rare", "occasional", "occasional", "occasional", "occasional", "frequent", "frequent", "core"}
+	for i, zone := range zones {
+		if zone.DensityLevel != want[i] {
+			t.Errorf("score %v: level = %q, want %q", zone.DensityScore, zone.DensityLevel, want[i])
+		}
+	}
+}
+
+func TestClusterZones(t *testing.T) {
+	zone := func(x, y int, level string) DensityZone {
+		return DensityZone{X: x, Y: y, DensityLevel: level, AreaKm2: 2}
+	}
+	zones := []DensityZone{
+		// A block of three high-density cells with a frequent cell touching it
+		zone(0, 0, "core"),
+		zone(1, 0, "core"),
+		zone(0, 1, "frequent"),
+		zone(2, 1, "frequent"),
+		// Occasional cells never join a cluster
+		zone(3, 1, "occasional"),
+		// Two adjacent core cells are too few for a cluster
+		zone(10, 10, "core"),
+		zone(11, 10, "core"),
+	}
+
+	clusters := clusterZones(zones, DefaultDensityThresholds, 5)
+	if clusters != 1 {
+		t.Fatalf("clusters = %d, want 1", clusters)
+	}
+
+	wantIDs := []int{5, 5, 5, 5, 0, 0, 0}
+	for i, z := range zones {
+		if z.ClusterID != wantIDs[i] {
+			t.Errorf("cell (%d,%d): cluster = %d, want %d", z.X, z.Y, z.ClusterID, wantIDs[i])
+		}
+		wantArea := 0.0
+		if wantIDs[i] > 0 {
+			wantArea = 8
+		}
+		if z.ClusterAreaKm2 != wantArea {
+			t.Errorf("cell (%d,%d): cluster area = %v, want %v", z.X, z.Y, z.ClusterAreaKm2, wantArea)
+		}
+	}
+}
+
+func TestBoundsAreaKm2(t *testing.T) {
+	// One degree square at the equator is about 111.2 km on a side
+	if got := boundsAreaKm2(0, 0, 1, 1); math.Abs(got-12364) > 10 {
+		t.Errorf("boundsAreaKm2 = %v, want about 12364", got)
+	}
+}
@@ -10,15 +10,51 @@ import (
 	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	geo "github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // DirectionalBiasAnalyzer implements directional movement pattern analysis
 // Skill: 方向偏好分析 (Directional Bias)
-// Analyzes heading distribution and identifies preferred directions
+// Analyzes heading distribution and identifies preferred directions, per
+// admin area and per geohash grid cell a segment starts in
 type DirectionalBiasAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
 
+// DirectionalBiasThresholds defines configurable parameters for directional bias
+// Loaded from the "directional_bias" section of the active threshold profile
+type DirectionalBiasThresholds struct {
+	NumBins     int            `json:"num_bins"`      // 8: direction bins of the histograms, 8, 16 or 36
+	ModeNumBins map[string]int `json:"mode_num_bins"` // Bin counts of single-mode histograms, e.g. {"WALK": 36}; others use NumBins
+}
+
+// DefaultDirectionalBiasThresholds provides default directional bias parameters
+var DefaultDirectionalBiasThresholds = DirectionalBiasThresholds{
+	NumBins: 8,
+}
+
+// validate checks that every bin count is supported
+// Bin counts are multiples of 4, so every axis has a perpendicular one.
+func (t DirectionalBiasThresholds) validate() error {
+	for mode, n := range t.ModeNumBins {
+		if n != 8 && n != 16 && n != 36 {
+			return fmt.Errorf("%s bin count must be 8, 16 or 36, got %d", mode, n)
+		}
+	}
+	if t.NumBins != 8 && t.NumBins != 16 && t.NumBins != 36 {
+		return fmt.Errorf("bin count must be 8, 16 or 36, got %d", t.NumBins)
+	}
+	return nil
+}
+
+// numBins returns the bin count of the histograms of a mode filter
+func (t DirectionalBiasThresholds) numBins(modeFilter string) int {
+	if n, ok := t.ModeNumBins[modeFilter]; ok {
+		return n
+	}
+	return t.NumBins
+}
+
 // NewDirectionalBiasAnalyzer creates a new directional bias analyzer
 func NewDirectionalBiasAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &DirectionalBiasAnalyzer{
@@ -35,12 +71,13 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing stats (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM directional_stats_bucketed"); err != nil {
-			return fmt.Errorf("failed to clear directional_stats_bucketed: %w", err)
-		}
-		log.Printf("[DirectionalBiasAnalyzer] Cleared existing directional stats")
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultDirectionalBiasThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	if err := thresholds.validate(); err != nil {
+		return err
 	}
 
 	// Query segments with coordinates and transport mode
@@ -52,6 +89,7 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 			s.distance_m,
 			s.duration_s,
 			s.mode,
+			COALESCE(s.source, ''),
 			p1.latitude AS start_lat,
 			p1.longitude AS start_lon,
 			p2.latitude AS end_lat,
@@ -76,13 +114,14 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 	}
 	defer rows.Close()
 
-	// Aggregation map: (bucket_type, bucket_key, area_type, area_key, mode_filter) -> stats
+	// Aggregation map: (bucket_type, bucket_key, area_type, area_key, mode_filter, source) -> stats
 	type AggKey struct {
 		BucketType string
 		BucketKey  string
 		AreaType   string
 		AreaKey    string
 		ModeFilter string
+		Source     string
 	}
 	aggMap := make(map[AggKey]*DirectionalAggregation)
 
@@ -90,7 +129,7 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 	for rows.Next() {
 		var seg Segment
 		if err := rows.Scan(
-			&seg.ID, &seg.StartTime, &seg.EndTime, &seg.Distance, &seg.Duration, &seg.Mode,
+			&seg.ID, &seg.StartTime, &seg.EndTime, &seg.Distance, &seg.Duration, &seg.Mode, &seg.Source,
 			&seg.StartLat, &seg.StartLon, &seg.EndLat, &seg.EndLon,
 			&seg.Province, &seg.City, &seg.County,
 		); err != nil {
@@ -101,12 +140,12 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 
 		// Calculate bearing
 		bearing := calculateBearing(seg.StartLat, seg.StartLon, seg.EndLat, seg.EndLon)
-		bucket := bearingToBucket(bearing, 8)
 
 		// Extract time dimensions
 		startTime := time.Unix(seg.StartTime, 0)
 		year := startTime.Format("2006")
 		month := startTime.Format("2006-01")
+		season := seasonOf(startTime)
 
 		// Define aggregation keys
 		areas := []struct {
@@ -116,6 +155,8 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 			{"PROVINCE", seg.Province.String},
 			{"CITY", seg.City.String},
 			{"COUNTY", seg.County.String},
+			{"GEOHASH5", geo.EncodeGeohash(seg.StartLat, seg.StartLon, 5)},
+			{"GEOHASH6", geo.EncodeGeohash(seg.StartLat, seg.StartLon, 6)},
 		}
 
 		bucketTypes := []struct {
@@ -125,9 +166,11 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 			{"all", "all"},
 			{"year", year},
 			{"month", month},
+			{"season", season},
 		}
 
 		modeFilters := []string{"ALL", seg.Mode.String}
+		sources := analysis.SourceScopes(seg.Source)
 
 		// Aggregate across all dimensions
 		for _, area := range areas {
@@ -135,32 +178,41 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 				continue
 			}
 			for _, bt := range bucketTypes {
+				// Grid cells are only kept for all time and per year, months
+				// and seasons would multiply the number of sparse histograms
+				if bt.bucketType != "all" && bt.bucketType != "year" && isGridArea(area.areaType) {
+					continue
+				}
 				for _, mode := range modeFilters {
 					if mode == "" {
 						continue
 					}
 
-					key := AggKey{
-						BucketType: bt.bucketType,
-						BucketKey:  bt.bucketKey,
-						AreaType:   area.areaType,
-						AreaKey:    area.areaKey,
-						ModeFilter: mode,
-					}
+					for _, source := range sources {
+						key := AggKey{
+							BucketType: bt.bucketType,
+							BucketKey:  bt.bucketKey,
+							AreaType:   area.areaType,
+							AreaKey:    area.areaKey,
+							ModeFilter: mode,
+							Source:     source,
+						}
 
-					if aggMap[key] == nil {
-						aggMap[key] = &DirectionalAggregation{
-							Buckets: make([]float64, 8),
-							Counts:  make([]int, 8),
+						if aggMap[key] == nil {
+							aggMap[key] = &DirectionalAggregation{
+								Buckets: make([]float64, thresholds.numBins(mode)),
+								Counts:  make([]int, thresholds.numBins(mode)),
+							}
 						}
-					}
 
-					agg := aggMap[key]
-					agg.Buckets[bucket] += seg.Distance
-					agg.Counts[bucket]++
-					agg.TotalDistance += seg.Distance
-					agg.TotalDuration += seg.Duration
-					agg.SegmentCount++
+						agg := aggMap[key]
+						bucket := bearingToBucket(bearing, len(agg.Buckets))
+						agg.Buckets[bucket] += seg.Distance
+						agg.Counts[bucket]++
+						agg.TotalDistance += seg.Distance
+						agg.TotalDuration += seg.Duration
+						agg.SegmentCount++
+					}
 				}
 			}
 		}
@@ -180,14 +232,50 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 	log.Printf("[DirectionalBiasAnalyzer] Processed %d segments, generated %d aggregations", totalSegments, len(aggMap))
 
 	// Calculate metrics and insert results
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO directional_stats_bucketed (
+			bucket_type, bucket_key, area_type, area_key, mode_filter, source,
+			direction_histogram_json, num_bins,
+			dominant_direction_deg, directional_concentration,
+			bidirectional_score, directional_entropy,
+			dominant_axis_deg, anisotropy_index,
+			total_distance, total_duration, segment_count,
+			algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(bucket_type, bucket_key, area_type, area_key, mode_filter, source)
+		DO UPDATE SET
+			direction_histogram_json = excluded.direction_histogram_json,
+			num_bins = excluded.num_bins,
+			dominant_direction_deg = excluded.dominant_direction_deg,
+			directional_concentration = excluded.directional_concentration,
+			bidirectional_score = excluded.bidirectional_score,
+			directional_entropy = excluded.directional_entropy,
+			dominant_axis_deg = excluded.dominant_axis_deg,
+			anisotropy_index = excluded.anisotropy_index,
+			total_distance = excluded.total_distance,
+			total_duration = excluded.total_duration,
+			segment_count = excluded.segment_count,
+			created_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
 	insertedCount := 0
 	for key, agg := range aggMap {
 		// Calculate advanced metrics
 		metrics := calculateDirectionalMetrics(agg.Buckets, agg.Counts)
 
 		// Prepare histogram JSON
-		histogram := make([]map[string]interface{}, 8)
-		for i := 0; i < 8; i++ {
+		histogram := make([]map[string]interface{}, len(agg.Buckets))
+		for i := range agg.Buckets {
 			histogram[i] = map[string]interface{}{
 				"bin":      i,
 				"distance": agg.Buckets[i],
@@ -196,35 +284,12 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 		}
 		histogramJSON, _ := json.Marshal(histogram)
 
-		// Insert into database
-		insertQuery := `
-			INSERT INTO directional_stats_bucketed (
-				bucket_type, bucket_key, area_type, area_key, mode_filter,
-				direction_histogram_json, num_bins,
-				dominant_direction_deg, directional_concentration,
-				bidirectional_score, directional_entropy,
-				total_distance, total_duration, segment_count,
-				algo_version
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
-			ON CONFLICT(bucket_type, bucket_key, area_type, area_key, mode_filter)
-			DO UPDATE SET
-				direction_histogram_json = excluded.direction_histogram_json,
-				num_bins = excluded.num_bins,
-				dominant_direction_deg = excluded.dominant_direction_deg,
-				directional_concentration = excluded.directional_concentration,
-				bidirectional_score = excluded.bidirectional_score,
-				directional_entropy = excluded.directional_entropy,
-				total_distance = excluded.total_distance,
-				total_duration = excluded.total_duration,
-				segment_count = excluded.segment_count,
-				created_at = CURRENT_TIMESTAMP
-		`
-
-		_, err := a.DB.ExecContext(ctx, insertQuery,
-			key.BucketType, key.BucketKey, key.AreaType, key.AreaKey, key.ModeFilter,
-			string(histogramJSON), 8,
+		_, err := stmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, key.AreaType, key.AreaKey, key.ModeFilter, key.Source,
+			string(histogramJSON), len(agg.Buckets),
 			metrics.DominantDirection, metrics.Concentration,
 			metrics.BidirectionalScore, metrics.Entropy,
+			metrics.DominantAxis, metrics.Anisotropy,
 			agg.TotalDistance, agg.TotalDuration, agg.SegmentCount,
 		)
 		if err != nil {
@@ -232,11 +297,15 @@ func (a *DirectionalBiasAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 		}
 
 		insertedCount++
-		if insertedCount%100 == 0 {
+		if insertedCount%10000 == 0 {
 			log.Printf("[DirectionalBiasAnalyzer] Inserted %d/%d records", insertedCount, len(aggMap))
 		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	log.Printf("[DirectionalBiasAnalyzer] Inserted %d directional stats records", insertedCount)
 
 	// Mark task as completed
@@ -263,6 +332,7 @@ type Segment struct {
 	Distance  float64
 	Duration  int64
 	Mode      sql.NullString
+	Source    string
 	StartLat  float64
 	StartLon  float64
 	EndLat    float64
@@ -283,10 +353,12 @@ type DirectionalAggregation struct {
 
 // DirectionalMetrics holds calculated directional metrics
 type DirectionalMetrics struct {
-	DominantDirection   float64
-	Concentration       float64
-	BidirectionalScore  float64
-	Entropy             float64
+	DominantDirection  float64
+	Concentration      float64
+	BidirectionalScore float64
+	Entropy            float64
+	DominantAxis       float64 // 0-180 degrees, direction of the axis travelled most either way
+	Anisotropy         float64 // 0-1, (dominant - perpendicular) / (dominant + perpendicular) axis distance
 }
 
 // calculateBearing calculates the initial bearing from point 1 to point 2
@@ -304,7 +376,31 @@ func calculateBearing(lat1, lon1, lat2, lon2 float64) float64 {
 	return math.Mod(bearing+360, 360) // Normalize to [0, 360)
 }
 
+// seasonOf returns the meteorological season of local time t, e.g. 2024-spring
+// Winter runs from December to February and belongs to the year of its December.
+func seasonOf(t time.Time) string {
+	year := t.Year()
+	switch t.Month() {
+	case time.March, time.April, time.May:
+		return fmt.Sprintf("%d-spring", year)
+	case time.June, time.July, time.August:
+		return fmt.Sprintf("%d-summer", year)
+	case time.September, time.October, time.November:
+		return fmt.Sprintf("%d-autumn", year)
+	case time.December:
+		return fmt.Sprintf("%d-winter", year)
+	default:
+		return fmt.Sprintf("%d-winter", year-1)
+	}
+}
+
+// isGridArea reports whether an area type is a geohash grid cell
+func isGridArea(areaType string) bool {
+	return areaType == "GEOHASH5" || areaType == "GEOHASH6"
+}
+
 // bearingToBucket converts bearing (0-360) to bucket index
+// Bins are centered on their direction, bin 0 on north (see binCenter).
 func bearingToBucket(bearing float64, numBins int) int {
 	binSize := 360.0 / float64(numBins)
 	bucket := int((bearing + binSize/2) / binSize)
@@ -314,6 +410,11 @@ func bearingToBucket(bearing float64, numBins int) int {
 	return bucket
 }
 
+// binCenter returns the direction in degrees a bin is centered on
+func binCenter(bin, numBins int) float64 {
+	return float64(bin) * 360.0 / float64(numBins)
+}
+
 // calculateDirectionalMetrics calculates advanced directional metrics
 func calculateDirectionalMetrics(buckets []float64, counts []int) DirectionalMetrics {
 	numBins := len(buckets)
@@ -326,7 +427,7 @@ func calculateDirectionalMetrics(buckets []float64, counts []int) DirectionalMet
 		return DirectionalMetrics{}
 	}
 
-	// 1. Dominant direction (weighted average of dominant bin)
+	// 1. Dominant direction (center of the dominant bin)
 	dominantBin := 0
 	maxDistance := 0.0
 	for i, d := range buckets {
@@ -335,13 +436,12 @@ func calculateDirectionalMetrics(buckets []float64, counts []int) DirectionalMet
 			dominantBin = i
 		}
 	}
-	binSize := 360.0 / float64(numBins)
-	dominantDirection := float64(dominantBin)*binSize + binSize/2
+	dominantDirection := binCenter(dominantBin, numBins)
 
 	// 2. Directional concentration (vector synthesis)
 	var sumX, sumY float64
 	for i, d := range buckets {
-		angle := (float64(i)*binSize + binSize/2) * math.Pi / 180
+		angle := binCenter(i, numBins) * math.Pi / 180
 		weight := d / totalDistance
 		sumX += weight * math.Cos(angle)
 		sumY += weight * math.Sin(angle)
@@ -373,15 +473,51 @@ func calculateDirectionalMetrics(buckets []float64, counts []int) DirectionalMet
 		entropy /= maxEntropy // Normalize to [0, 1]
 	}
 
+	// 5. Anisotropy (dominant axis against its perpendicular)
+	dominantAxis, anisotropy := calculateAnisotropy(buckets)
+
 	return DirectionalMetrics{
 		DominantDirection:  dominantDirection,
 		Concentration:      concentration,
 		BidirectionalScore: bidirectionalScore,
 		Entropy:            entropy,
+		DominantAxis:       dominantAxis,
+		Anisotropy:         anisotropy,
+	}
+}
+
+// calculateAnisotropy folds opposite bins into axes and compares the axis with
+// the most distance to the axis perpendicular to it
+// It returns the dominant axis in degrees (0 north-south, 90 east-west) and
+// the anisotropy index: 0 when both axes carry the same distance, 1 when
+// nothing moves across the dominant axis. The bin count must be a multiple of 4.
+func calculateAnisotropy(buckets []float64) (float64, float64) {
+	numAxes := len(buckets) / 2
+	if numAxes == 0 || numAxes%2 != 0 {
+		return 0, 0
+	}
+
+	axes := make([]float64, numAxes)
+	for i, d := range buckets {
+		axes[i%numAxes] += d
+	}
+
+	dominant := 0
+	for i, d := range axes {
+		if d > axes[dominant] {
+			dominant = i
+		}
+	}
+	along, across := axes[dominant], axes[(dominant+numAxes/2)%numAxes]
+	if along+across == 0 {
+		return 0, 0
 	}
+	return binCenter(dominant, len(buckets)), (along - across) / (along + across)
 }
 
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("directional_bias", NewDirectionalBiasAnalyzer)
+	analysis.RegisterDependencies("directional_bias", "transport_mode")
+	analysis.RegisterOutputs("directional_bias", analysis.Output{Table: "directional_stats_bucketed"})
 }
@@ -0,0 +1,89 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDirectionBinsAreCenteredOnTheirBearing(t *testing.T) {
+	for bin := 0; bin < 8; bin++ {
+		center := binCenter(bin, 8)
+		for _, bearing := range []float64{center, center + 22, center - 22 + 360} {
+			if bearing >= 360 {
+				bearing -= 360
+			}
+			if got := bearingToBucket(bearing, 8); got != bin {
+				t.Errorf("bearing %.0f is in bin %d, want %d (centered on %.0f)", bearing, got, bin, center)
+			}
+		}
+	}
+
+	// Mostly east with some north-east: the dominant direction is east
+	buckets := []float64{0, 1000, 5000, 0, 0, 0, 0, 0}
+	metrics := calculateDirectionalMetrics(buckets, []int{0, 1, 3, 0, 0, 0, 0, 0})
+	if metrics.DominantDirection != 90 {
+		t.Errorf("dominant direction = %.1f, want 90", metrics.DominantDirection)
+	}
+}
+
+func TestCalculateAnisotropy(t *testing.T) {
+	tests := []struct {
+		name       string
+		buckets    []float64
+		axis       float64
+		anisotropy float64
+	}{
+		{name: "empty", buckets: make([]float64, 8)},
+		{name: "north and back", buckets: []float64{100, 0, 0, 0, 100, 0, 0, 0}, axis: 0, anisotropy: 1},
+		{name: "east-west with some north", buckets: []float64{10, 0, 60, 0, 10, 0, 20, 0}, axis: 90, anisotropy: 0.6},
+		{name: "all directions alike", buckets: []float64{5, 5, 5, 5, 5, 5, 5, 5}, axis: 0, anisotropy: 0},
+		{
+			name:       "16 bins, north-east axis",
+			buckets:    []float64{0, 0, 30, 0, 0, 0, 0, 0, 0, 0, 10, 0, 0, 0, 10, 0},
+			axis:       45,
+			anisotropy: 0.6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			axis, anisotropy := calculateAnisotropy(tt.buckets)
+			if axis != tt.axis || math.Abs(anisotropy-tt.anisotropy) > 1e-9 {
+				t.Errorf("axis/anisotropy = %.1f/%.3f, want %.1f/%.3f", axis, anisotropy, tt.axis, tt.anisotropy)
+			}
+		})
+	}
+}
+
+func TestSeasonOf(t *testing.T) {
+	tests := map[time.Month]string{
+		time.January:  "2023-winter",
+		time.February: "2023-winter",
+		time.March:    "2024-spring",
+		time.July:     "2024-summer",
+		time.November: "2024-autumn",
+		time.December: "2024-winter",
+	}
+	for month, want := range tests {
+		if got := seasonOf(time.Date(2024, month, 10, 12, 0, 0, 0, time.Local)); got != want {
+			t.Errorf("%s 2024 is in %s, want %s", month, got, want)
+		}
+	}
+}
+
+func TestDirectionalBiasThresholds(t *testing.T) {
+	th := DefaultDirectionalBiasThresholds
+	th.ModeNumBins = map[string]int{"WALK": 36}
+	if err := th.validate(); err != nil {
+		t.Fatalf("validate() = %v", err)
+	}
+	if th.numBins("WALK") != 36 || th.numBins("ALL") != 8 {
+		t.Errorf("bins = %d/%d, want 36 for WALK and 8 otherwise", th.numBins("WALK"), th.numBins("ALL"))
+	}
+
+	th.ModeNumBins["CAR"] = 12
+	if err := th.validate(); err == nil {
+		t.Error("validate() accepted 12 bins")
+	}
+}
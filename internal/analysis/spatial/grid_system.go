@@ -34,20 +34,12 @@ func (a *GridSystemAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing grid cells (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM grid_cells"); err != nil {
-			return fmt.Errorf("failed to clear grid cells: %w", err)
-		}
-		log.Printf("[GridSystemAnalyzer] Cleared existing grid cells")
-	}
-
 	// Process multiple zoom levels (8-15)
 	// Level 8: ~150km cells (country/province level)
 	// Level 10: ~40km cells (city level)
 	// Level 12: ~10km cells (district level)
 	// Level 15: ~1km cells (neighborhood level)
-	levels := []int{8, 10, 12, 15}
+	levels := gridLevels
 	totalCells := 0
 
 	for _, level := range levels {
@@ -74,9 +66,28 @@ func (a *GridSystemAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 	return nil
 }
 
+// gridLevels are the zoom levels of the grid system
+var gridLevels = []int{8, 10, 12, 15}
+
 // processGridLevel processes a single zoom level
 func (a *GridSystemAnalyzer) processGridLevel(ctx context.Context, level int) ([]GridCell, error) {
-	// Get all track points
+	cells, err := aggregateGridCells(ctx, a.DB, level, analysis.SourceAll)
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert cells
+	if err := a.insertGridCells(ctx, cells); err != nil {
+		return nil, fmt.Errorf("failed to insert grid cells: %w", err)
+	}
+
+	return cells, nil
+}
+
+// aggregateGridCells aggregates the track points of a source into the grid
+// cells of a zoom level
+func aggregateGridCells(ctx context.Context, db *sql.DB, level int, source string) ([]GridCell, error) {
+	sourceCond, args := analysis.SourceCondition("source", source)
 	pointsQuery := `
 		SELECT
 			id,
@@ -84,11 +95,11 @@ func (a *GridSystemAnalyzer) processGridLevel(ctx context.Context, level int) ([
 			latitude,
 			longitude
 		FROM "一生足迹"
-		WHERE outlier_flag = 0
+		WHERE outlier_flag = 0` + sourceCond + `
 		ORDER BY dataTime
 	`
 
-	rows, err := a.DB.QueryContext(ctx, pointsQuery)
+	rows, err := db.QueryContext(ctx, pointsQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query points: %w", err)
 	}
@@ -149,12 +160,7 @@ func (a *GridSystemAnalyzer) processGridLevel(ctx context.Context, level int) ([
 		cells = append(cells, *cell)
 	}
 
-	// Insert cells
-	if err := a.insertGridCells(ctx, cells); err != nil {
-		return nil, fmt.Errorf("failed to insert grid cells: %w", err)
-	}
-
-	return cells, nil
+	return cells, rows.Err()
 }
 
 // GridCell holds grid cell data
@@ -180,7 +186,7 @@ func (a *GridSystemAnalyzer) insertGridCells(ctx context.Context, cells []GridCe
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -268,4 +274,6 @@ func tileYToLat(y, zoom int) float64 {
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("grid_system", NewGridSystemAnalyzer)
+	analysis.RegisterDependencies("grid_system", "outlier_detection")
+	analysis.RegisterOutputs("grid_system", analysis.Output{Table: "grid_cells"})
 }
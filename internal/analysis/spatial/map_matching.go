@@ -0,0 +1,280 @@
+package spatial
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/mapmatch"
+	geo "github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// mapMatchAlgoVersion marks road_overlap_stats rows measured by map matching,
+// as opposed to the speed heuristics of road_overlap ('v1')
+const mapMatchAlgoVersion = "map_match_v1"
+
+// MapMatchingAnalyzer snaps CAR segments to the road network
+// Skill: 地图匹配 (Map Matching)
+// Uses the configured matcher (OSRM, Valhalla or the offline HMM matcher) to
+// store road-snapped geometry in matched_segments and measured on/off-road
+// distances per road type in road_overlap_stats. Incremental mode only
+// matches segments that have not been matched yet.
+type MapMatchingAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewMapMatchingAnalyzer creates a new map matching analyzer
+func NewMapMatchingAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &MapMatchingAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "map_matching", 50),
+	}
+}
+
+// MatchedSegment holds the map matching result of a segment
+type MatchedSegment struct {
+	SegmentID         int64
+	Polyline          string
+	PointCount        int
+	MatchedPointCount int
+	OnRoadDistance    float64
+	OffRoadDistance   float64
+	RoadType          string
+	Confidence        float64
+}
+
+// Analyze performs map matching
+func (a *MapMatchingAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[MapMatchingAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	matcher := mapmatch.DefaultMatcher()
+	if matcher == nil {
+		return mapmatch.ErrNoMatcher
+	}
+
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT s.id, s.start_time, s.end_time
+		FROM segments s
+		WHERE s.mode = 'CAR'
+			AND NOT EXISTS (SELECT 1 FROM matched_segments m WHERE m.segment_id = s.id)
+		ORDER BY s.id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+
+	type segmentRange struct {
+		id, startTS, endTS int64
+	}
+	var segments []segmentRange
+	for rows.Next() {
+		var seg segmentRange
+		if err := rows.Scan(&seg.id, &seg.startTS, &seg.endTS); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments = append(segments, seg)
+	}
+	rows.Close()
+
+	log.Printf("[MapMatchingAnalyzer] Matching %d CAR segments with %s", len(segments), matcher.Name())
+
+	// Update task with total count
+	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	var matched []MatchedSegment
+	var processed, failed int64
+	var totalOnRoad, totalOffRoad float64
+	for _, seg := range segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		trace, err := a.loadTrace(ctx, seg.startTS, seg.endTS)
+		if err != nil {
+			return fmt.Errorf("failed to load points for segment %d: %w", seg.id, err)
+		}
+
+		processed++
+		if len(trace) >= 2 {
+			result, err := matcher.Match(ctx, trace)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// A failed request only loses this segment; it is retried by the next incremental run
+				log.Printf("[MapMatchingAnalyzer] Warning: failed to match segment %d: %v", seg.id, err)
+				failed++
+			} else {
+				m := summarizeMatch(seg.id, trace, result)
+				totalOnRoad += m.OnRoadDistance
+				totalOffRoad += m.OffRoadDistance
+				matched = append(matched, m)
+			}
+		}
+
+		if processed%int64(a.BatchSize) == 0 {
+			if err := a.saveMatches(ctx, matcher.Name(), matched); err != nil {
+				return fmt.Errorf("failed to save matched segments: %w", err)
+			}
+			matched = nil
+
+			if err := a.UpdateTaskProgress(taskID, int64(len(segments)), processed, failed); err != nil {
+				return fmt.Errorf("failed to update progress: %w", err)
+			}
+			log.Printf("[MapMatchingAnalyzer] Processed %d/%d segments", processed, len(segments))
+		}
+	}
+
+	// Save remaining matches
+	if err := a.saveMatches(ctx, matcher.Name(), matched); err != nil {
+		return fmt.Errorf("failed to save matched segments: %w", err)
+	}
+	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), processed, failed); err != nil {
+		return fmt.Errorf("failed to update progress: %w", err)
+	}
+
+	overallRatio := 0.0
+	if totalOnRoad+totalOffRoad > 0 {
+		overallRatio = totalOnRoad / (totalOnRoad + totalOffRoad)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"matcher":           matcher.Name(),
+		"total_segments":    len(segments),
+		"failed_segments":   failed,
+		"on_road_distance":  totalOnRoad,
+		"off_road_distance": totalOffRoad,
+		"overlap_ratio":     overallRatio,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[MapMatchingAnalyzer] Analysis completed: %d segments processed (%d failed)", processed, failed)
+	return nil
+}
+
+// loadTrace loads the non-outlier points of a segment in time order
+func (a *MapMatchingAnalyzer) loadTrace(ctx context.Context, startTS, endTS int64) ([]mapmatch.TracePoint, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND outlier_flag = 0
+		ORDER BY dataTime
+	`, startTS, endTS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trace []mapmatch.TracePoint
+	for rows.Next() {
+		var p mapmatch.TracePoint
+		if err := rows.Scan(&p.Timestamp, &p.Lat, &p.Lon); err != nil {
+			return nil, err
+		}
+		trace = append(trace, p)
+	}
+	return trace, rows.Err()
+}
+
+// summarizeMatch measures the on/off-road distances of a matched trace
+func summarizeMatch(segmentID int64, trace []mapmatch.TracePoint, result *mapmatch.Result) MatchedSegment {
+	onRoad, offRoad := result.Distances(trace)
+	m := MatchedSegment{
+		SegmentID:         segmentID,
+		PointCount:        len(trace),
+		MatchedPointCount: result.MatchedCount(),
+		OffRoadDistance:   offRoad,
+		RoadType:          mapmatch.DominantRoadType(onRoad),
+		Confidence:        result.Confidence,
+	}
+	for _, distance := range onRoad {
+		m.OnRoadDistance += distance
+	}
+	if m.MatchedPointCount >= 2 {
+		m.Polyline = geo.EncodePolyline(result.Geometry)
+	}
+	return m
+}
+
+// saveMatches stores matched segments and replaces their road overlap stats
+// Simplified polylines of the segments are dropped so trajectory_simplification
+// rebuilds them from the matched geometry.
+func (a *MapMatchingAnalyzer) saveMatches(ctx context.Context, matcher string, matches []MatchedSegment) error {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, m := range matches {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO matched_segments (
+				segment_id, matcher, polyline, point_count, matched_point_count,
+				on_road_distance_m, off_road_distance_m, road_type, confidence, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CAST(strftime('%s', 'now') AS INTEGER))
+		`, m.SegmentID, matcher, m.Polyline, m.PointCount, m.MatchedPointCount,
+			m.OnRoadDistance, m.OffRoadDistance, m.RoadType, m.Confidence)
+		if err != nil {
+			return fmt.Errorf("failed to insert matched segment %d: %w", m.SegmentID, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM road_overlap_stats WHERE segment_id = ?", m.SegmentID); err != nil {
+			return fmt.Errorf("failed to clear road overlap stats of segment %d: %w", m.SegmentID, err)
+		}
+		ratio := 0.0
+		if total := m.OnRoadDistance + m.OffRoadDistance; total > 0 {
+			ratio = m.OnRoadDistance / total
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO road_overlap_stats (
+				segment_id, on_road_distance_m, off_road_distance_m,
+				overlap_ratio, road_type, confidence,
+				algo_version, created_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, m.SegmentID, m.OnRoadDistance, m.OffRoadDistance, ratio, m.RoadType, m.Confidence, mapMatchAlgoVersion)
+		if err != nil {
+			return fmt.Errorf("failed to insert road overlap stat of segment %d: %w", m.SegmentID, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM segment_polylines WHERE segment_id = ?", m.SegmentID); err != nil {
+			return fmt.Errorf("failed to clear polylines of segment %d: %w", m.SegmentID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("map_matching", NewMapMatchingAnalyzer)
+	analysis.RegisterVersion("map_matching", mapMatchAlgoVersion)
+	analysis.RegisterDependencies("map_matching", "transport_mode")
+	analysis.RegisterOutputs("map_matching",
+		analysis.Output{Table: "road_overlap_stats", Where: "algo_version = '" + mapMatchAlgoVersion + "'"},
+		analysis.Output{Table: "matched_segments"},
+	)
+}
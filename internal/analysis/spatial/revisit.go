@@ -3,19 +3,47 @@ package spatial
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
 	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
 // RevisitAnalyzer implements the Revisit Patterns analysis skill
+// Spatial stays are grouped by geohash6 cell into visits, and each cell with
+// repeated visits gets interval statistics, a regularity score and periodic
+// and habitual flags.
 type RevisitAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
 
+// RevisitThresholds defines configurable parameters for revisit patterns
+// Loaded from the "revisit_pattern" section of the active threshold profile
+type RevisitThresholds struct {
+	MinVisits             int     `json:"min_visits"`              // 2: cells visited fewer times are not kept
+	VisitMergeGapS        int64   `json:"visit_merge_gap_s"`       // 3600 s: stays in a cell closer together are one visit
+	MaxPeriodDays         int     `json:"max_period_days"`         // 35: longest visit period looked for
+	PeriodicMinACF        float64 `json:"periodic_min_acf"`        // 0.3: autocorrelation of daily visits at the period
+	PeriodicMinVisits     int     `json:"periodic_min_visits"`     // 3
+	HabitualMinVisits     int     `json:"habitual_min_visits"`     // 5
+	HabitualMinRegularity float64 `json:"habitual_min_regularity"` // 0.7: or periodic
+}
+
+// DefaultRevisitThresholds provides default revisit parameters
+var DefaultRevisitThresholds = RevisitThresholds{
+	MinVisits:             2,
+	VisitMergeGapS:        3600,
+	MaxPeriodDays:         35,
+	PeriodicMinACF:        0.3,
+	PeriodicMinVisits:     3,
+	HabitualMinVisits:     5,
+	HabitualMinRegularity: 0.7,
+}
+
 // RevisitLocation represents a location with revisit statistics
 type RevisitLocation struct {
 	Geohash         string
@@ -27,17 +55,30 @@ type RevisitLocation struct {
 	VisitCount      int
 	FirstVisit      int64
 	LastVisit       int64
-	TotalDuration   int
+	TotalDuration   int64
 	AvgInterval     float64
 	StdInterval     float64
 	MinInterval     float64
 	MaxInterval     float64
 	RegularityScore float64
+	PeriodDays      int // 0 when no period was found
 	IsPeriodic      bool
 	IsHabitual      bool
 	RevisitStrength float64
 }
 
+// revisitStay is a spatial stay in a geohash6 cell
+type revisitStay struct {
+	StartTime int64
+	EndTime   int64
+	Duration  int64
+	Lat       float64
+	Lon       float64
+	Province  string
+	City      string
+	County    string
+}
+
 // NewRevisitAnalyzer creates a new revisit patterns analyzer
 func NewRevisitAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &RevisitAnalyzer{
@@ -47,187 +88,236 @@ func NewRevisitAnalyzer(db *sql.DB) analysis.Analyzer {
 
 // Analyze performs the revisit patterns analysis
 func (a *RevisitAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
-	log.Printf("Starting revisit patterns analysis (task: %d, mode: %s)", taskID, mode)
+	log.Printf("[RevisitAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
 
 	// Mark task as running
 	if err := a.MarkTaskAsRunning(taskID); err != nil {
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Query stay_segments grouped by geohash6
-	query := `
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultRevisitThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+
+	// Stays are read once, cell by cell in time order
+	rows, err := a.DB.QueryContext(ctx, `
 		SELECT
 			geohash6,
-			AVG(center_lat) as lat,
-			AVG(center_lon) as lon,
-			COUNT(*) as visit_count,
-			MIN(start_time) as first_visit,
-			MAX(end_time) as last_visit,
-			SUM(duration_s) as total_duration
+			start_time,
+			end_time,
+			duration_s,
+			COALESCE(center_lat, 0),
+			COALESCE(center_lon, 0),
+			COALESCE(province, ''),
+			COALESCE(city, ''),
+			COALESCE(county, '')
 		FROM stay_segments
-		WHERE geohash6 IS NOT NULL AND geohash6 != ''
-		GROUP BY geohash6
-		HAVING visit_count >= 2
-		ORDER BY visit_count DESC
-	`
-
-	rows, err := a.DB.QueryContext(ctx, query)
+		WHERE stay_type = 'SPATIAL'
+			AND geohash6 IS NOT NULL AND geohash6 != ''
+		ORDER BY geohash6, start_time
+	`)
 	if err != nil {
-		a.MarkTaskAsFailed(taskID, fmt.Sprintf("Query failed: %v", err))
-		return fmt.Errorf("failed to query stay_segments: %w", err)
+		return fmt.Errorf("failed to query stays: %w", err)
 	}
 	defer rows.Close()
 
-	locations := []RevisitLocation{}
-	processed := 0
-
-	for rows.Next() {
-		var loc RevisitLocation
-		err := rows.Scan(&loc.Geohash, &loc.Lat, &loc.Lon, &loc.VisitCount,
-			&loc.FirstVisit, &loc.LastVisit, &loc.TotalDuration)
-		if err != nil {
-			log.Printf("Failed to scan row: %v", err)
-			continue
-		}
-
-		// Get admin info from first stay in this location
-		adminQuery := `
-			SELECT province, city, county
-			FROM stay_segments
-			WHERE geohash6 = ?
-			LIMIT 1
-		`
-		err = a.DB.QueryRow(adminQuery, loc.Geohash).Scan(&loc.Province, &loc.City, &loc.County)
-		if err != nil {
-			// Admin info is optional, just log and continue
-			log.Printf("Failed to get admin info for %s: %v", loc.Geohash, err)
-		}
-
-		// Calculate revisit intervals
-		intervals, minInterval, maxInterval, err := a.getIntervals(loc.Geohash)
-		if err != nil {
-			log.Printf("Failed to get intervals for %s: %v", loc.Geohash, err)
-			continue
-		}
-
-		if len(intervals) > 0 {
-			loc.AvgInterval = stats.Mean(intervals)
-			loc.StdInterval = stats.StdDev(intervals)
-			loc.MinInterval = minInterval
-			loc.MaxInterval = maxInterval
-
-			// Calculate regularity score (0-1, higher = more regular)
-			if loc.AvgInterval > 0 {
-				cv := loc.StdInterval / loc.AvgInterval
-				loc.RegularityScore = 1.0 / (1.0 + cv)
+	var locations []RevisitLocation
+	var cell string
+	var stays []revisitStay
+	flush := func() {
+		if len(stays) > 0 {
+			if loc, ok := analyzeRevisits(cell, stays, thresholds); ok {
+				locations = append(locations, loc)
 			}
-
-			// Mark as periodic if highly regular (low coefficient of variation)
-			loc.IsPeriodic = loc.RegularityScore > 0.8 && loc.VisitCount >= 3
-
-			// Mark as habitual if visited frequently and regularly
-			loc.IsHabitual = loc.VisitCount >= 5 && loc.RegularityScore > 0.7
 		}
+		stays = stays[:0]
+	}
 
-		// Calculate revisit strength: log(1 + visits) × log(1 + duration)
-		loc.RevisitStrength = math.Log(1+float64(loc.VisitCount)) * math.Log(1+float64(loc.TotalDuration))
-
-		locations = append(locations, loc)
-		processed++
-
-		// Update progress periodically
-		if processed%100 == 0 {
-			a.UpdateTaskProgress(taskID, int64(processed), int64(len(locations)), 0)
+	for rows.Next() {
+		var geohash string
+		var stay revisitStay
+		if err := rows.Scan(&geohash, &stay.StartTime, &stay.EndTime, &stay.Duration,
+			&stay.Lat, &stay.Lon, &stay.Province, &stay.City, &stay.County); err != nil {
+			return fmt.Errorf("failed to scan stay: %w", err)
+		}
+		if geohash != cell {
+			flush()
+			cell = geohash
 		}
+		stays = append(stays, stay)
 	}
-
 	if err := rows.Err(); err != nil {
-		a.MarkTaskAsFailed(taskID, fmt.Sprintf("Row iteration failed: %v", err))
-		return fmt.Errorf("row iteration failed: %w", err)
+		return fmt.Errorf("error iterating stays: %w", err)
 	}
+	flush()
 
-	log.Printf("Processed %d revisit locations", len(locations))
-
-	// Convert locations to []interface{} for insertResults
-	locationsInterface := make([]interface{}, len(locations))
-	for i, loc := range locations {
-		locationsInterface[i] = loc
-	}
+	log.Printf("[RevisitAnalyzer] Found %d revisited locations", len(locations))
 
 	// Insert results into database
-	if err := a.insertResults(locationsInterface); err != nil {
-		a.MarkTaskAsFailed(taskID, fmt.Sprintf("Insert failed: %v", err))
+	if err := a.insertResults(ctx, locations); err != nil {
 		return fmt.Errorf("failed to insert results: %w", err)
 	}
 
 	// Mark task as completed
-	if err := a.MarkTaskAsCompleted(taskID); err != nil {
+	periodic, habitual := 0, 0
+	for _, loc := range locations {
+		if loc.IsPeriodic {
+			periodic++
+		}
+		if loc.IsHabitual {
+			habitual++
+		}
+	}
+	summary := map[string]interface{}{
+		"locations":          len(locations),
+		"periodic_locations": periodic,
+		"habitual_locations": habitual,
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
 		return fmt.Errorf("failed to mark task as completed: %w", err)
 	}
 
-	log.Printf("Revisit patterns analysis completed (task: %d)", taskID)
+	log.Printf("[RevisitAnalyzer] Analysis completed: %d locations, %d periodic, %d habitual", len(locations), periodic, habitual)
 	return nil
 }
 
-// getIntervals calculates the time intervals between visits to a location
-func (a *RevisitAnalyzer) getIntervals(geohash string) ([]float64, float64, float64, error) {
-	query := `
-		SELECT start_time
-		FROM stay_segments
-		WHERE geohash6 = ?
-		ORDER BY start_time
-	`
+// analyzeRevisits computes the revisit statistics of a cell from its stays in
+// time order; ok is false when the cell has fewer than MinVisits visits
+func analyzeRevisits(geohash string, stays []revisitStay, th RevisitThresholds) (RevisitLocation, bool) {
+	visits := clusterVisits(stays, th.VisitMergeGapS)
+	if len(visits) < th.MinVisits || len(visits) < 2 {
+		return RevisitLocation{}, false
+	}
 
-	rows, err := a.DB.Query(query, geohash)
-	if err != nil {
-		return nil, 0, 0, err
+	loc := RevisitLocation{
+		Geohash:    geohash,
+		VisitCount: len(visits),
+		FirstVisit: stays[0].StartTime,
 	}
-	defer rows.Close()
 
-	var times []int64
-	for rows.Next() {
-		var t int64
-		if err := rows.Scan(&t); err != nil {
-			return nil, 0, 0, err
+	// The center is weighted by stay duration, the area is the one of the longest stay
+	var weight float64
+	var longest int64 = -1
+	for _, stay := range stays {
+		w := float64(max(stay.Duration, 1))
+		loc.Lat += stay.Lat * w
+		loc.Lon += stay.Lon * w
+		weight += w
+		loc.TotalDuration += stay.Duration
+		loc.LastVisit = max(loc.LastVisit, stay.EndTime)
+		if stay.Province != "" && stay.Duration > longest {
+			loc.Province, loc.City, loc.County = stay.Province, stay.City, stay.County
+			longest = stay.Duration
 		}
-		times = append(times, t)
 	}
+	loc.Lat /= weight
+	loc.Lon /= weight
 
-	if len(times) < 2 {
-		return nil, 0, 0, nil
+	// Intervals between the starts of consecutive visits, in days
+	intervals := make([]float64, len(visits)-1)
+	for i := 1; i < len(visits); i++ {
+		intervals[i-1] = float64(visits[i]-visits[i-1]) / 86400
 	}
+	loc.AvgInterval = stats.Mean(intervals)
+	loc.StdInterval = stats.StdDev(intervals)
+	loc.MinInterval = stats.Min(intervals)
+	loc.MaxInterval = stats.Max(intervals)
+
+	// Regularity: 1 when visits are evenly spaced, falling with the
+	// coefficient of variation of the intervals
+	if loc.AvgInterval > 0 {
+		loc.RegularityScore = 1 / (1 + loc.StdInterval/loc.AvgInterval)
+	}
+
+	// Periodic: the daily visit series repeats itself, e.g. every 7 days
+	var acf float64
+	loc.PeriodDays, acf = detectVisitPeriod(visits, th.MaxPeriodDays)
+	loc.IsPeriodic = loc.PeriodDays > 0 && acf >= th.PeriodicMinACF && loc.VisitCount >= th.PeriodicMinVisits
 
-	// Calculate intervals in days
-	intervals := make([]float64, len(times)-1)
-	minInterval := math.MaxFloat64
-	maxInterval := 0.0
+	// Habitual: visited often, and regularly or periodically
+	loc.IsHabitual = loc.VisitCount >= th.HabitualMinVisits &&
+		(loc.RegularityScore >= th.HabitualMinRegularity || loc.IsPeriodic)
 
-	for i := 1; i < len(times); i++ {
-		intervalSeconds := times[i] - times[i-1]
-		intervalDays := float64(intervalSeconds) / 86400.0
-		intervals[i-1] = intervalDays
+	// Revisit strength: log(1 + visits) × log(1 + duration)
+	loc.RevisitStrength = math.Log(1+float64(loc.VisitCount)) * math.Log(1+float64(loc.TotalDuration))
 
-		if intervalDays < minInterval {
-			minInterval = intervalDays
+	return loc, true
+}
+
+// clusterVisits returns the start times of the visits made up by stays in
+// time order, merging a stay into the previous visit when it starts within
+// mergeGapS of that visit's end
+func clusterVisits(stays []revisitStay, mergeGapS int64) []int64 {
+	var visits []int64
+	var visitEnd int64
+	for i, stay := range stays {
+		if i == 0 || stay.StartTime-visitEnd > mergeGapS {
+			visits = append(visits, stay.StartTime)
 		}
-		if intervalDays > maxInterval {
-			maxInterval = intervalDays
+		visitEnd = max(visitEnd, stay.EndTime)
+	}
+	return visits
+}
+
+// detectVisitPeriod looks for a period in the local days visits start on
+// It returns the lag of the highest autocorrelation peak of the daily visit
+// series between 2 and maxPeriod days, and its autocorrelation; 0 when the
+// series is constant or too short to hold two periods. Daily visits have no
+// variance and so no period here; their regularity score covers them.
+func detectVisitPeriod(visits []int64, maxPeriod int) (int, float64) {
+	if len(visits) < 2 {
+		return 0, 0
+	}
+
+	first := localDay(visits[0])
+	days := int(localDay(visits[len(visits)-1]).Sub(first).Hours()/24+0.5) + 1
+	series := make([]float64, days)
+	for _, v := range visits {
+		series[int(localDay(v).Sub(first).Hours()/24+0.5)] = 1
+	}
+
+	maxLag := min(maxPeriod, days/2)
+	if maxLag < 2 {
+		return 0, 0
+	}
+	acf := stats.ACF(series, maxLag+1)
+	if acf == nil {
+		return 0, 0
+	}
+
+	period, best := 0, 0.0
+	for lag := 2; lag <= maxLag; lag++ {
+		if r := acf[lag]; r > best && r >= acf[lag-1] && r >= acf[lag+1] {
+			period, best = lag, r
 		}
 	}
+	return period, best
+}
 
-	return intervals, minInterval, maxInterval, nil
+// localDay returns local midnight of the day of a Unix time
+func localDay(ts int64) time.Time {
+	t := time.Unix(ts, 0)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
-// insertResults inserts the analysis results into the database
-func (a *RevisitAnalyzer) insertResults(locations []interface{}) error {
-	// Clear existing results (for full recompute)
-	_, err := a.DB.Exec("DELETE FROM revisit_patterns")
+// insertResults replaces the revisit patterns with locations
+func (a *RevisitAnalyzer) insertResults(ctx context.Context, locations []RevisitLocation) error {
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Every run recomputes all locations
+	if _, err := tx.ExecContext(ctx, "DELETE FROM revisit_patterns"); err != nil {
 		return fmt.Errorf("failed to clear existing results: %w", err)
 	}
 
-	// Prepare insert statement
-	stmt, err := a.DB.Prepare(`
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO revisit_patterns (
 			geohash6, center_lat, center_lon,
 			province, city, county,
@@ -235,73 +325,32 @@ func (a *RevisitAnalyzer) insertResults(locations []interface{}) error {
 			avg_interval_days, std_interval_days, min_interval_days, max_interval_days,
 			regularity_score, is_periodic, is_habitual, revisit_strength,
 			algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1')
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("revisit_pattern")+`')
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	// Insert each location
-	for _, locInterface := range locations {
-		loc, ok := locInterface.(RevisitLocation)
-		if !ok {
-			log.Printf("Failed to cast location to RevisitLocation")
-			continue
-		}
-
-		isPeriodic := 0
-		if loc.IsPeriodic {
-			isPeriodic = 1
-		}
-		isHabitual := 0
-		if loc.IsHabitual {
-			isHabitual = 1
-		}
-
-		_, err := stmt.Exec(
+	for _, loc := range locations {
+		if _, err := stmt.ExecContext(ctx,
 			loc.Geohash, loc.Lat, loc.Lon,
 			loc.Province, loc.City, loc.County,
 			loc.VisitCount, loc.FirstVisit, loc.LastVisit, loc.TotalDuration,
 			loc.AvgInterval, loc.StdInterval, loc.MinInterval, loc.MaxInterval,
-			loc.RegularityScore, isPeriodic, isHabitual, loc.RevisitStrength,
-		)
-		if err != nil {
-			log.Printf("Failed to insert location %s: %v", loc.Geohash, err)
-			continue
+			loc.RegularityScore, loc.IsPeriodic, loc.IsHabitual, loc.RevisitStrength,
+		); err != nil {
+			return fmt.Errorf("failed to insert location %s: %w", loc.Geohash, err)
 		}
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("revisit_pattern", NewRevisitAnalyzer)
-}
-
-// calculateIntervals calculates intervals between visit times
-func calculateIntervals(times []int64) ([]float64, float64, float64) {
-	if len(times) < 2 {
-		return nil, 0, 0
-	}
-
-	intervals := make([]float64, len(times)-1)
-	minInterval := math.MaxFloat64
-	maxInterval := 0.0
-
-	for i := 1; i < len(times); i++ {
-		intervalSeconds := times[i] - times[i-1]
-		intervalDays := float64(intervalSeconds) / 86400.0
-		intervals[i-1] = intervalDays
-
-		if intervalDays < minInterval {
-			minInterval = intervalDays
-		}
-		if intervalDays > maxInterval {
-			maxInterval = intervalDays
-		}
-	}
-
-	return intervals, minInterval, maxInterval
+	analysis.RegisterVersion("revisit_pattern", "v2")
+	analysis.RegisterDependencies("revisit_pattern", "stay_detection")
+	analysis.RegisterOutputs("revisit_pattern", analysis.Output{Table: "revisit_patterns", Replaced: true})
 }
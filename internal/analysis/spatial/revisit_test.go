@@ -0,0 +1,108 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// stayAt returns an hour-long stay starting at hour of day day of January 2024
+func stayAt(day, hour int) revisitStay {
+	start := time.Date(2024, time.January, day, hour, 0, 0, 0, time.Local).Unix()
+	return revisitStay{StartTime: start, EndTime: start + 3600, Duration: 3600, Lat: 23.1, Lon: 113.3, Province: "广东省", City: "广州市"}
+}
+
+func TestClusterVisits(t *testing.T) {
+	stays := []revisitStay{stayAt(1, 9), stayAt(1, 10), stayAt(1, 15), stayAt(2, 9)}
+	// 9-10 and 10-11 merge, 15:00 is 4 hours after the visit ended
+	if visits := clusterVisits(stays, 3600); len(visits) != 3 || visits[0] != stays[0].StartTime || visits[1] != stays[2].StartTime {
+		t.Errorf("visits = %v, want starts of stays 0, 2 and 3", visits)
+	}
+	// Back-to-back stays are always one visit
+	if visits := clusterVisits(stays, 0); len(visits) != 3 {
+		t.Errorf("without a merge gap got %d visits, want 3", len(visits))
+	}
+	if visits := clusterVisits(stays, 6*3600); len(visits) != 2 {
+		t.Errorf("with a 6 h merge gap got %d visits, want 2", len(visits))
+	}
+}
+
+func TestDetectVisitPeriod(t *testing.T) {
+	var weekly []int64
+	for day := 1; day <= 29; day += 7 {
+		weekly = append(weekly, stayAt(day, 19).StartTime)
+	}
+	// Two visits a week, on the 1st and 3rd day of each week
+	var twiceWeekly []int64
+	for week := 0; week < 4; week++ {
+		twiceWeekly = append(twiceWeekly, stayAt(1+7*week, 9).StartTime, stayAt(3+7*week, 9).StartTime)
+	}
+	var daily []int64
+	for day := 1; day <= 20; day++ {
+		daily = append(daily, stayAt(day, 8).StartTime)
+	}
+
+	tests := []struct {
+		name   string
+		visits []int64
+		period int
+	}{
+		{name: "weekly", visits: weekly, period: 7},
+		{name: "twice weekly", visits: twiceWeekly, period: 7},
+		{name: "daily has no variance", visits: daily, period: 0},
+		{name: "too short", visits: []int64{stayAt(1, 9).StartTime, stayAt(2, 9).StartTime}, period: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period, acf := detectVisitPeriod(tt.visits, DefaultRevisitThresholds.MaxPeriodDays)
+			if period != tt.period {
+				t.Errorf("period = %d days (acf %.2f), want %d", period, acf, tt.period)
+			}
+			if period > 0 && acf < DefaultRevisitThresholds.PeriodicMinACF {
+				t.Errorf("acf = %.2f at the period, want at least %.2f", acf, DefaultRevisitThresholds.PeriodicMinACF)
+			}
+		})
+	}
+}
+
+func TestAnalyzeRevisits(t *testing.T) {
+	th := DefaultRevisitThresholds
+
+	t.Run("weekly visits are periodic and habitual", func(t *testing.T) {
+		var stays []revisitStay
+		for day := 1; day <= 29; day += 7 {
+			stays = append(stays, stayAt(day, 19))
+		}
+		loc, ok := analyzeRevisits("ws0e6j", stays, th)
+		if !ok {
+			t.Fatal("location not kept")
+		}
+		if loc.VisitCount != 5 || loc.TotalDuration != 5*3600 || loc.FirstVisit != stays[0].StartTime || loc.LastVisit != stays[4].EndTime {
+			t.Errorf("visits/duration/first/last = %d/%d/%d/%d", loc.VisitCount, loc.TotalDuration, loc.FirstVisit, loc.LastVisit)
+		}
+		if loc.AvgInterval != 7 || loc.StdInterval != 0 || loc.MinInterval != 7 || loc.MaxInterval != 7 || loc.RegularityScore != 1 {
+			t.Errorf("intervals = %.1f±%.1f [%.1f, %.1f], regularity %.2f, want 7±0 [7, 7], 1",
+				loc.AvgInterval, loc.StdInterval, loc.MinInterval, loc.MaxInterval, loc.RegularityScore)
+		}
+		if loc.PeriodDays != 7 || !loc.IsPeriodic || !loc.IsHabitual {
+			t.Errorf("period/periodic/habitual = %d/%v/%v, want 7/true/true", loc.PeriodDays, loc.IsPeriodic, loc.IsHabitual)
+		}
+		if loc.Province != "广东省" || math.Abs(loc.Lat-23.1) > 1e-9 {
+			t.Errorf("area/lat = %s/%.4f, want 广东省/23.1", loc.Province, loc.Lat)
+		}
+	})
+
+	t.Run("irregular visits are neither", func(t *testing.T) {
+		stays := []revisitStay{stayAt(1, 9), stayAt(2, 9), stayAt(20, 9)}
+		loc, ok := analyzeRevisits("ws0e6j", stays, th)
+		if !ok || loc.IsPeriodic || loc.IsHabitual || loc.RegularityScore > 0.6 {
+			t.Errorf("kept/periodic/habitual/regularity = %v/%v/%v/%.2f", ok, loc.IsPeriodic, loc.IsHabitual, loc.RegularityScore)
+		}
+	})
+
+	t.Run("one visit is not a revisit", func(t *testing.T) {
+		if _, ok := analyzeRevisits("ws0e6j", []revisitStay{stayAt(1, 9), stayAt(1, 10)}, th); ok {
+			t.Error("a single merged visit was kept")
+		}
+	})
+}
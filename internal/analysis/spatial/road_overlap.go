@@ -13,6 +13,7 @@ import (
 // RoadOverlapAnalyzer implements road network overlap analysis (simplified)
 // Skill: 道路重叠分析 (Road Overlap)
 // Analyzes trajectory overlap with road networks using speed-based heuristics
+// Segments already measured by map_matching keep their measured stats.
 type RoadOverlapAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -33,14 +34,6 @@ func (a *RoadOverlapAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing stats (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM road_overlap_stats"); err != nil {
-			return fmt.Errorf("failed to clear road_overlap_stats: %w", err)
-		}
-		log.Printf("[RoadOverlapAnalyzer] Cleared existing road overlap stats")
-	}
-
 	// Query segments
 	query := `
 		SELECT
@@ -48,6 +41,7 @@ func (a *RoadOverlapAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 		FROM segments
 		WHERE mode IN ('CAR', 'BIKE', 'WALK')
 			AND distance_m > 0
+			AND NOT EXISTS (SELECT 1 FROM road_overlap_stats r WHERE r.segment_id = segments.id)
 	`
 
 	rows, err := a.DB.QueryContext(ctx, query)
@@ -212,7 +206,7 @@ func (a *RoadOverlapAnalyzer) insertRoadOverlapStats(ctx context.Context, stats
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -223,7 +217,7 @@ func (a *RoadOverlapAnalyzer) insertRoadOverlapStats(ctx context.Context, stats
 			segment_id, on_road_distance_m, off_road_distance_m,
 			overlap_ratio, road_type, confidence,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, '` + analysis.Version("road_overlap") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -253,4 +247,6 @@ func (a *RoadOverlapAnalyzer) insertRoadOverlapStats(ctx context.Context, stats
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("road_overlap", NewRoadOverlapAnalyzer)
+	analysis.RegisterDependencies("road_overlap", "transport_mode")
+	analysis.RegisterOutputs("road_overlap", analysis.Output{Table: "road_overlap_stats", Where: "algo_version <> '" + mapMatchAlgoVersion + "'"})
 }
\ No newline at end of file
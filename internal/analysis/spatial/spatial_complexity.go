@@ -7,17 +7,58 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	geo "github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
 // SpatialComplexityAnalyzer implements spatial complexity metrics
 // Skill: 空间复杂度分析 (Spatial Complexity)
-// Calculates trajectory complexity, entropy, and tortuosity
+// Calculates turns, path efficiency, tortuosity and entropy over visited grids
+// per day, per month and for all time
 type SpatialComplexityAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
 
+// SpatialComplexityThresholds defines configurable parameters for spatial complexity
+// Loaded from the "spatial_complexity" section of the active threshold profile
+type SpatialComplexityThresholds struct {
+	Timezone         string  `json:"timezone"`           // IANA zone days are cut in, e.g. Asia/Shanghai; empty for the server's zone
+	MaxGapS          int64   `json:"max_gap_s"`          // 300 s: longer gaps between points end a track
+	MinStepM         float64 `json:"min_step_m"`         // 20 m: movement shorter than this is GPS jitter, not a step
+	TurnThresholdDeg float64 `json:"turn_threshold_deg"` // 15: sharper turns are direction changes
+	GeohashPrecision int     `json:"geohash_precision"`  // 6: grid cells of the spatial entropy
+}
+
+// DefaultSpatialComplexityThresholds provides default spatial complexity parameters
+var DefaultSpatialComplexityThresholds = SpatialComplexityThresholds{
+	MaxGapS:          300,
+	MinStepM:         20,
+	TurnThresholdDeg: 15,
+	GeohashPrecision: 6,
+}
+
+// location returns the timezone days are cut in
+func (t SpatialComplexityThresholds) location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", t.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Period types of complexity_metrics
+const (
+	ComplexityPeriodDay   = "day"   // metric_date is YYYY-MM-DD
+	ComplexityPeriodMonth = "month" // metric_date is YYYY-MM
+	ComplexityPeriodAll   = "all"   // metric_date is NULL
+)
+
 // NewSpatialComplexityAnalyzer creates a new spatial complexity analyzer
 func NewSpatialComplexityAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &SpatialComplexityAnalyzer{
@@ -34,19 +75,47 @@ func (a *SpatialComplexityAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing metrics (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM complexity_metrics"); err != nil {
-			return fmt.Errorf("failed to clear complexity_metrics: %w", err)
-		}
-		log.Printf("[SpatialComplexityAnalyzer] Cleared existing complexity metrics")
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultSpatialComplexityThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	loc, err := thresholds.location()
+	if err != nil {
+		return err
 	}
 
-	// Compute all-time complexity metrics
-	metrics, err := a.computeComplexityMetrics(ctx, "")
+	// Points of different sources are interleaved in time, so tracks only
+	// follow consecutive points of one source
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude, COALESCE(source, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY source, dataTime, id
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to compute complexity metrics: %w", err)
+		return fmt.Errorf("failed to query track points: %w", err)
+	}
+	defer rows.Close()
+
+	tracker := newComplexityTracker(thresholds)
+	totalPoints := 0
+	for rows.Next() {
+		var timestamp int64
+		var lat, lon float64
+		var source string
+		if err := rows.Scan(&timestamp, &lat, &lon, &source); err != nil {
+			return fmt.Errorf("failed to scan track point: %w", err)
+		}
+		totalPoints++
+		tracker.add(source, time.Unix(timestamp, 0).In(loc), lat, lon)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
 	}
+	rows.Close()
+
+	metrics := tracker.metrics()
 
 	// Insert metrics
 	if err := a.insertComplexityMetrics(ctx, metrics); err != nil {
@@ -55,11 +124,18 @@ func (a *SpatialComplexityAnalyzer) Analyze(ctx context.Context, taskID int64, m
 
 	// Mark task as completed
 	summary := map[string]interface{}{
-		"trajectory_complexity": metrics.TrajectoryComplexity,
-		"direction_changes":     metrics.DirectionChanges,
-		"spatial_entropy":       metrics.SpatialEntropy,
-		"path_efficiency":       metrics.PathEfficiency,
-		"tortuosity":            metrics.Tortuosity,
+		"total_points": totalPoints,
+		"timezone":     loc.String(),
+		"periods":      len(metrics),
+	}
+	for _, m := range metrics {
+		if m.PeriodType == ComplexityPeriodAll {
+			summary["trajectory_complexity"] = m.TrajectoryComplexity
+			summary["direction_changes"] = m.DirectionChanges
+			summary["spatial_entropy"] = m.SpatialEntropy
+			summary["path_efficiency"] = m.PathEfficiency
+			summary["tortuosity"] = m.Tortuosity
+		}
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -67,12 +143,13 @@ func (a *SpatialComplexityAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		return fmt.Errorf("failed to mark task as completed: %w", err)
 	}
 
-	log.Printf("[SpatialComplexityAnalyzer] Analysis completed")
+	log.Printf("[SpatialComplexityAnalyzer] Analysis completed: %d periods from %d points", len(metrics), totalPoints)
 	return nil
 }
 
 // ComplexityMetrics holds spatial complexity metrics
 type ComplexityMetrics struct {
+	PeriodType           string
 	MetricDate           string
 	TrajectoryComplexity float64
 	DirectionChanges     int64
@@ -82,219 +159,201 @@ type ComplexityMetrics struct {
 	Tortuosity           float64
 }
 
-// computeComplexityMetrics computes complexity metrics
-func (a *SpatialComplexityAnalyzer) computeComplexityMetrics(ctx context.Context, date string) (*ComplexityMetrics, error) {
-	// Query track points with heading and distance
-	query := `
-		SELECT
-			latitude, longitude, heading, distance
-		FROM "一生足迹"
-		WHERE outlier_flag = 0
-			AND heading IS NOT NULL
-			AND distance IS NOT NULL
-		ORDER BY dataTime
-	`
+// complexityAcc accumulates the steps and tracks of a period
+type complexityAcc struct {
+	Turns         int64
+	TurnSum       float64
+	Changes       int64
+	PathM         float64
+	DisplacementM float64
+	Cells         map[string]float64 // Point count per grid cell
+}
 
-	rows, err := a.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query track points: %w", err)
+// finish calculates the metrics of the period
+func (c *complexityAcc) finish(periodType, date string) ComplexityMetrics {
+	m := ComplexityMetrics{PeriodType: periodType, MetricDate: date, DirectionChanges: c.Changes}
+	if c.Turns > 0 {
+		m.AvgTurnAngle = c.TurnSum / float64(c.Turns)
 	}
-	defer rows.Close()
 
-	var points []ComplexityPoint
-	for rows.Next() {
-		var point ComplexityPoint
-		if err := rows.Scan(&point.Latitude, &point.Longitude, &point.Heading, &point.Distance); err != nil {
-			return nil, fmt.Errorf("failed to scan track point: %w", err)
-		}
-		points = append(points, point)
+	// Path efficiency is net displacement over path length, tortuosity its inverse
+	if c.PathM > 0 {
+		m.PathEfficiency = c.DisplacementM / c.PathM
+	}
+	if c.DisplacementM > 0 {
+		m.Tortuosity = c.PathM / c.DisplacementM
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	counts := make([]float64, 0, len(c.Cells))
+	for _, n := range c.Cells {
+		counts = append(counts, n)
 	}
+	m.SpatialEntropy = stats.ShannonEntropy(counts)
 
-	if len(points) < 2 {
-		return &ComplexityMetrics{MetricDate: date}, nil
+	// Trajectory complexity score (0-1), combining direction changes per km
+	// (10 per km scores 1), tortuosity (5 scores 1) and entropy (10 bits score 1)
+	directionScore := 0.0
+	if c.PathM > 0 {
+		directionScore = math.Min(float64(c.Changes)/(c.PathM/1000)/10.0, 1.0)
 	}
+	tortuosityScore := math.Min(m.Tortuosity/5.0, 1.0)
+	entropyScore := math.Min(m.SpatialEntropy/10.0, 1.0)
+	m.TrajectoryComplexity = directionScore*0.4 + tortuosityScore*0.3 + entropyScore*0.3
 
-	// Calculate metrics
-	metrics := &ComplexityMetrics{MetricDate: date}
+	return m
+}
 
-	// 1. Direction changes and turn angles
-	var turnAngles []float64
-	for i := 1; i < len(points); i++ {
-		prevHeading := points[i-1].Heading
-		currHeading := points[i].Heading
+// complexityTracker follows points, ordered by source and time, in tracks
+// A track is a run of points of one source within a local day with no gap
+// over MaxGapS. Steps join points at least MinStepM apart, and each step
+// turns from the previous one of its track.
+type complexityTracker struct {
+	th   SpatialComplexityThresholds
+	accs map[[2]string]*complexityAcc
+
+	active     bool
+	source     string
+	day        string
+	keys       [3][2]string
+	lastTime   time.Time
+	startLat   float64
+	startLon   float64
+	anchorLat  float64
+	anchorLon  float64
+	bearing    float64
+	hasBearing bool
+}
 
-		// Calculate turn angle
-		turnAngle := math.Abs(currHeading - prevHeading)
-		if turnAngle > 180 {
-			turnAngle = 360 - turnAngle
-		}
+func newComplexityTracker(th SpatialComplexityThresholds) *complexityTracker {
+	return &complexityTracker{th: th, accs: make(map[[2]string]*complexityAcc)}
+}
 
-		// Count as direction change if turn > 15 degrees
-		if turnAngle > 15 {
-			metrics.DirectionChanges++
-			turnAngles = append(turnAngles, turnAngle)
+// add follows a point at local time t
+func (tr *complexityTracker) add(source string, t time.Time, lat, lon float64) {
+	day := t.Format("2006-01-02")
+	if !tr.active || source != tr.source || day != tr.day || t.Sub(tr.lastTime).Seconds() > float64(tr.th.MaxGapS) {
+		tr.closeTrack()
+		tr.active, tr.source, tr.day = true, source, day
+		tr.keys = [3][2]string{
+			{ComplexityPeriodDay, day},
+			{ComplexityPeriodMonth, t.Format("2006-01")},
+			{ComplexityPeriodAll, ""},
 		}
-	}
-
-	// Average turn angle
-	if len(turnAngles) > 0 {
-		sum := 0.0
-		for _, angle := range turnAngles {
-			sum += angle
+		tr.startLat, tr.startLon = lat, lon
+		tr.anchorLat, tr.anchorLon = lat, lon
+		tr.hasBearing = false
+	} else if d := geo.HaversineDistance(tr.anchorLat, tr.anchorLon, lat, lon); d >= tr.th.MinStepM {
+		bearing := geo.Bearing(tr.anchorLat, tr.anchorLon, lat, lon)
+		turn := 0.0
+		if tr.hasBearing {
+			turn = math.Abs(bearing - tr.bearing)
+			if turn > 180 {
+				turn = 360 - turn
+			}
+		}
+		for _, key := range tr.keys {
+			acc := tr.acc(key)
+			acc.PathM += d
+			if tr.hasBearing {
+				acc.Turns++
+				acc.TurnSum += turn
+				if turn > tr.th.TurnThresholdDeg {
+					acc.Changes++
+				}
+			}
 		}
-		metrics.AvgTurnAngle = sum / float64(len(turnAngles))
+		tr.bearing, tr.hasBearing = bearing, true
+		tr.anchorLat, tr.anchorLon = lat, lon
 	}
 
-	// 2. Path efficiency (actual distance / straight-line distance)
-	actualDistance := 0.0
-	for _, point := range points {
-		actualDistance += point.Distance
+	cell := geo.EncodeGeohash(lat, lon, tr.th.GeohashPrecision)
+	for _, key := range tr.keys {
+		tr.acc(key).Cells[cell]++
 	}
+	tr.lastTime = t
+}
 
-	firstPoint := points[0]
-	lastPoint := points[len(points)-1]
-	straightLineDistance := haversineDistance(
-		firstPoint.Latitude, firstPoint.Longitude,
-		lastPoint.Latitude, lastPoint.Longitude,
-	)
-
-	if straightLineDistance > 0 {
-		metrics.PathEfficiency = straightLineDistance / actualDistance
+// acc returns the accumulator of a period, creating it when missing
+func (tr *complexityTracker) acc(key [2]string) *complexityAcc {
+	acc, ok := tr.accs[key]
+	if !ok {
+		acc = &complexityAcc{Cells: make(map[string]float64)}
+		tr.accs[key] = acc
 	}
+	return acc
+}
 
-	// 3. Tortuosity (inverse of path efficiency)
-	if metrics.PathEfficiency > 0 {
-		metrics.Tortuosity = 1.0 / metrics.PathEfficiency
+// closeTrack adds the net displacement of the current track to its periods
+func (tr *complexityTracker) closeTrack() {
+	if !tr.active {
+		return
 	}
-
-	// 4. Spatial entropy (based on grid cell distribution)
-	entropy, err := a.calculateSpatialEntropy(ctx)
-	if err != nil {
-		log.Printf("[SpatialComplexityAnalyzer] Warning: failed to calculate spatial entropy: %v", err)
-	} else {
-		metrics.SpatialEntropy = entropy
+	displacement := geo.HaversineDistance(tr.startLat, tr.startLon, tr.anchorLat, tr.anchorLon)
+	for _, key := range tr.keys {
+		tr.acc(key).DisplacementM += displacement
 	}
-
-	// 5. Trajectory complexity score (0-1, normalized)
-	// Combines direction changes, tortuosity, and entropy
-	complexityScore := 0.0
-
-	// Normalize direction changes (assume max 1000 changes)
-	directionScore := math.Min(float64(metrics.DirectionChanges)/1000.0, 1.0)
-
-	// Normalize tortuosity (assume max 5.0)
-	tortuosityScore := math.Min(metrics.Tortuosity/5.0, 1.0)
-
-	// Normalize entropy (assume max 10.0)
-	entropyScore := math.Min(metrics.SpatialEntropy/10.0, 1.0)
-
-	// Weighted average
-	complexityScore = (directionScore*0.4 + tortuosityScore*0.3 + entropyScore*0.3)
-	metrics.TrajectoryComplexity = complexityScore
-
-	return metrics, nil
+	tr.active = false
 }
 
-// ComplexityPoint holds point data for complexity analysis
-type ComplexityPoint struct {
-	Latitude  float64
-	Longitude float64
-	Heading   float64
-	Distance  float64
+// metrics closes the last track and returns the metrics of every period
+func (tr *complexityTracker) metrics() []ComplexityMetrics {
+	tr.closeTrack()
+	result := make([]ComplexityMetrics, 0, len(tr.accs))
+	for key, acc := range tr.accs {
+		result = append(result, acc.finish(key[0], key[1]))
+	}
+	return result
 }
 
-// calculateSpatialEntropy calculates spatial entropy based on grid cell distribution
-func (a *SpatialComplexityAnalyzer) calculateSpatialEntropy(ctx context.Context) (float64, error) {
-	query := `
-		SELECT visit_count
-		FROM grid_cells
-		WHERE visit_count > 0
-	`
-
-	rows, err := a.DB.QueryContext(ctx, query)
+// insertComplexityMetrics replaces the complexity metrics in one transaction
+func (a *SpatialComplexityAnalyzer) insertComplexityMetrics(ctx context.Context, metrics []ComplexityMetrics) error {
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to query grid cells: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer rows.Close()
-
-	var visitCounts []int64
-	totalVisits := int64(0)
+	defer tx.Rollback()
 
-	for rows.Next() {
-		var count int64
-		if err := rows.Scan(&count); err != nil {
-			return 0, fmt.Errorf("failed to scan visit count: %w", err)
-		}
-		visitCounts = append(visitCounts, count)
-		totalVisits += count
+	if _, err := tx.ExecContext(ctx, "DELETE FROM complexity_metrics"); err != nil {
+		return fmt.Errorf("failed to clear complexity metrics: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return 0, fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	if totalVisits == 0 {
-		return 0, nil
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO complexity_metrics (
+			period_type, metric_date, trajectory_complexity, direction_changes,
+			avg_turn_angle, spatial_entropy, path_efficiency, tortuosity,
+			algo_version, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("spatial_complexity")+`', CURRENT_TIMESTAMP)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	defer stmt.Close()
 
-	// Calculate Shannon entropy
-	entropy := 0.0
-	for _, count := range visitCounts {
-		if count > 0 {
-			p := float64(count) / float64(totalVisits)
-			entropy -= p * math.Log2(p)
+	for _, m := range metrics {
+		var metricDate interface{}
+		if m.MetricDate != "" {
+			metricDate = m.MetricDate
+		}
+		if _, err := stmt.ExecContext(ctx,
+			m.PeriodType, metricDate, m.TrajectoryComplexity, m.DirectionChanges,
+			m.AvgTurnAngle, m.SpatialEntropy, m.PathEfficiency, m.Tortuosity,
+		); err != nil {
+			return fmt.Errorf("failed to insert complexity metrics: %w", err)
 		}
 	}
 
-	return entropy, nil
-}
-
-// insertComplexityMetrics inserts complexity metrics into the database
-func (a *SpatialComplexityAnalyzer) insertComplexityMetrics(ctx context.Context, metrics *ComplexityMetrics) error {
-	insertQuery := `
-		INSERT INTO complexity_metrics (
-			metric_date, trajectory_complexity, direction_changes,
-			avg_turn_angle, spatial_entropy, path_efficiency, tortuosity,
-			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
-	`
-
-	_, err := a.DB.ExecContext(ctx, insertQuery,
-		metrics.MetricDate, metrics.TrajectoryComplexity, metrics.DirectionChanges,
-		metrics.AvgTurnAngle, metrics.SpatialEntropy, metrics.PathEfficiency, metrics.Tortuosity,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to insert complexity metrics: %w", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	log.Printf("[SpatialComplexityAnalyzer] Inserted complexity metrics")
+	log.Printf("[SpatialComplexityAnalyzer] Inserted %d complexity metrics", len(metrics))
 	return nil
 }
 
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("spatial_complexity", NewSpatialComplexityAnalyzer)
+	analysis.RegisterVersion("spatial_complexity", "v2")
+	analysis.RegisterDependencies("spatial_complexity", "outlier_detection")
+	analysis.RegisterOutputs("spatial_complexity", analysis.Output{Table: "complexity_metrics", Replaced: true})
 }
-
-// haversineDistance calculates the great-circle distance between two points
-func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadius = 6371000.0 // meters
-
-	// Convert to radians
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	// Haversine formula
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadius * c
-}
\ No newline at end of file
@@ -0,0 +1,62 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComplexityTracker(t *testing.T) {
+	tr := newComplexityTracker(DefaultSpatialComplexityThresholds)
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	const deg = 0.001 // ~111 m of latitude
+
+	// North, north, then east: one direction change out of two turns, on an
+	// L-shaped track of 3 steps with a net displacement of sqrt(5) steps
+	for i, p := range [][2]float64{
+		{0, 0},
+		{0.00005, 0}, // Jitter under MinStepM is not a step
+		{deg, 0},
+		{2 * deg, 0},
+		{2 * deg, deg}, // East at the equator is ~111 m too
+	} {
+		tr.add("phone", start.Add(time.Duration(i)*10*time.Second), p[0], p[1])
+	}
+	// A later track of the same day after a gap only moves one step
+	tr.add("phone", start.Add(time.Hour), 1, 1)
+	tr.add("phone", start.Add(time.Hour+10*time.Second), 1+deg, 1)
+
+	byPeriod := make(map[string]ComplexityMetrics)
+	for _, m := range tr.metrics() {
+		byPeriod[m.PeriodType+"/"+m.MetricDate] = m
+	}
+	if len(byPeriod) != 3 {
+		t.Fatalf("periods = %v, want a day, a month and all time", byPeriod)
+	}
+
+	day := byPeriod["day/2024-03-01"]
+	if day.DirectionChanges != 1 {
+		t.Errorf("direction changes = %d, want 1", day.DirectionChanges)
+	}
+	if math.Abs(day.AvgTurnAngle-45) > 1 {
+		t.Errorf("average turn angle = %v, want ~45", day.AvgTurnAngle)
+	}
+	// Both tracks: 4 steps in all, and net displacements of sqrt(5) and 1 steps
+	wantEfficiency := (math.Sqrt(5) + 1) / 4
+	if math.Abs(day.PathEfficiency-wantEfficiency) > 0.01 {
+		t.Errorf("path efficiency = %v, want %v", day.PathEfficiency, wantEfficiency)
+	}
+	if math.Abs(day.Tortuosity*day.PathEfficiency-1) > 1e-9 {
+		t.Errorf("tortuosity = %v, want the inverse of path efficiency", day.Tortuosity)
+	}
+	if day.SpatialEntropy <= 0 {
+		t.Errorf("spatial entropy = %v, want > 0", day.SpatialEntropy)
+	}
+	if day.TrajectoryComplexity <= 0 || day.TrajectoryComplexity > 1 {
+		t.Errorf("trajectory complexity = %v, want in (0, 1]", day.TrajectoryComplexity)
+	}
+
+	if all := byPeriod["all/"]; all.DirectionChanges != 1 || all.PathEfficiency != day.PathEfficiency {
+		t.Errorf("all time = %+v, want the same as the only day", all)
+	}
+}
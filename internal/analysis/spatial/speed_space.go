@@ -44,6 +44,7 @@ func (a *SpeedSpaceAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 			s.distance_m,
 			s.avg_speed_kmh,
 			s.mode,
+			COALESCE(s.source, ''),
 			p.province,
 			p.city,
 			p.county,
@@ -68,15 +69,15 @@ func (a *SpeedSpaceAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 
 	for rows.Next() {
 		var (
-			id                                      int64
-			startTS, endTS                          int64
-			distance, avgSpeed                      float64
-			mode                                    string
-			province, city, county                  sql.NullString
-			year, month                             string
+			id                     int64
+			startTS, endTS         int64
+			distance, avgSpeed     float64
+			mode, source           string
+			province, city, county sql.NullString
+			year, month            string
 		)
 
-		if err := rows.Scan(&id, &startTS, &endTS, &distance, &avgSpeed, &mode, &province, &city, &county, &year, &month); err != nil {
+		if err := rows.Scan(&id, &startTS, &endTS, &distance, &avgSpeed, &mode, &source, &province, &city, &county, &year, &month); err != nil {
 			rows.Close()
 			return fmt.Errorf("failed to scan segment: %w", err)
 		}
@@ -84,57 +85,58 @@ func (a *SpeedSpaceAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 		totalSegments++
 		allSpeeds = append(allSpeeds, avgSpeed)
 
-		// Aggregate by province
-		if province.Valid && province.String != "" {
-			a.aggregateAreaSpeed(areaStats, "PROVINCE", province.String, year, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "PROVINCE", province.String, month, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "PROVINCE", province.String, "all", avgSpeed, distance)
-		}
-
-		// Aggregate by city
-		if city.Valid && city.String != "" {
-			a.aggregateAreaSpeed(areaStats, "CITY", city.String, year, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "CITY", city.String, month, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "CITY", city.String, "all", avgSpeed, distance)
-		}
-
-		// Aggregate by county
-		if county.Valid && county.String != "" {
-			a.aggregateAreaSpeed(areaStats, "COUNTY", county.String, year, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "COUNTY", county.String, month, avgSpeed, distance)
-			a.aggregateAreaSpeed(areaStats, "COUNTY", county.String, "all", avgSpeed, distance)
+		// Each segment counts towards all sources and its own source
+		for _, scope := range analysis.SourceScopes(source) {
+			// Aggregate by province
+			if province.Valid && province.String != "" {
+				a.aggregateAreaSpeed(areaStats, scope, "PROVINCE", province.String, year, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "PROVINCE", province.String, month, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "PROVINCE", province.String, "all", avgSpeed, distance)
+			}
+
+			// Aggregate by city
+			if city.Valid && city.String != "" {
+				a.aggregateAreaSpeed(areaStats, scope, "CITY", city.String, year, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "CITY", city.String, month, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "CITY", city.String, "all", avgSpeed, distance)
+			}
+
+			// Aggregate by county
+			if county.Valid && county.String != "" {
+				a.aggregateAreaSpeed(areaStats, scope, "COUNTY", county.String, year, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "COUNTY", county.String, month, avgSpeed, distance)
+				a.aggregateAreaSpeed(areaStats, scope, "COUNTY", county.String, "all", avgSpeed, distance)
+			}
 		}
 	}
 	rows.Close()
 
 	log.Printf("[SpeedSpaceAnalyzer] Processed %d segments", totalSegments)
 
+	// Stay hours in the same areas and buckets
+	if err := a.aggregateStayIntensity(ctx, areaStats); err != nil {
+		return err
+	}
+
 	// Calculate global speed thresholds
 	highSpeedThreshold := stats.Percentile(allSpeeds, 90)
 	lowSpeedThreshold := stats.Percentile(allSpeeds, 25)
 
 	log.Printf("[SpeedSpaceAnalyzer] Speed thresholds: high=%.2f km/h, low=%.2f km/h", highSpeedThreshold, lowSpeedThreshold)
 
-	// Calculate final statistics and classify zones
+	// Calculate final statistics
 	for _, stat := range areaStats {
-		// Calculate weighted average speed
-		stat.AvgSpeed = stat.TotalWeightedSpeed / stat.TotalDistance
-
-		// Calculate weighted variance
-		stat.SpeedVariance = stat.TotalWeightedVariance / stat.TotalDistance
-
-		// Classify zones
-		if stat.AvgSpeed > highSpeedThreshold {
-			stat.IsHighSpeedZone = true
-		}
-		if stat.AvgSpeed < lowSpeedThreshold {
-			stat.IsSlowLifeZone = true
-		}
+		// Distance-weighted mean and variance of segment speeds
+		stat.AvgSpeed = stat.Speed.Mean()
+		stat.SpeedVariance = stat.Speed.Variance()
 
 		// Calculate speed entropy
 		stat.SpeedEntropy = a.calculateSpeedEntropy(stat.SpeedBins)
 	}
 
+	// Classify zones
+	a.classifyZones(areaStats, highSpeedThreshold, lowSpeedThreshold)
+
 	// Insert results into spatial_analysis table
 	if err := a.insertSpeedSpaceResults(ctx, areaStats); err != nil {
 		return fmt.Errorf("failed to insert results: %w", err)
@@ -142,11 +144,11 @@ func (a *SpeedSpaceAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 
 	// Mark task as completed
 	summary := map[string]interface{}{
-		"total_segments":     totalSegments,
-		"areas_analyzed":     len(areaStats),
-		"high_speed_zones":   a.countHighSpeedZones(areaStats),
-		"slow_life_zones":    a.countSlowLifeZones(areaStats),
-		"global_avg_speed":   stats.Mean(allSpeeds),
+		"total_segments":       totalSegments,
+		"areas_analyzed":       len(areaStats),
+		"high_speed_zones":     a.countHighSpeedZones(areaStats),
+		"slow_life_zones":      a.countSlowLifeZones(areaStats),
+		"global_avg_speed":     stats.Mean(allSpeeds),
 		"high_speed_threshold": highSpeedThreshold,
 		"low_speed_threshold":  lowSpeedThreshold,
 	}
@@ -162,28 +164,119 @@ func (a *SpeedSpaceAnalyzer) Analyze(ctx context.Context, taskID int64, mode str
 
 // AreaSpeedStat holds speed statistics for an area
 type AreaSpeedStat struct {
-	AreaType             string
-	AreaKey              string
-	TimeRange            string
-	TotalDistance        float64
-	TotalWeightedSpeed   float64
-	TotalWeightedVariance float64
-	AvgSpeed             float64
-	SpeedVariance        float64
-	SpeedEntropy         float64
-	SegmentCount         int
-	IsHighSpeedZone      bool
-	IsSlowLifeZone       bool
-	SpeedBins            map[int]float64 // Speed bins for entropy calculation
+	Source          string
+	AreaType        string
+	AreaKey         string
+	TimeRange       string
+	TotalDistance   float64
+	Speed           stats.WeightedRunning // Segment speeds weighted by distance
+	AvgSpeed        float64
+	SpeedVariance   float64
+	SpeedEntropy    float64
+	SegmentCount    int
+	StayIntensity   float64 // Hours of stays in the area during the bucket
+	IsHighSpeedZone bool
+	IsSlowLifeZone  bool
+	SpeedBins       map[int]float64 // Speed bins for entropy calculation
+}
+
+// aggregateStayIntensity adds the hours of spatial stays to the stats of the
+// area and bucket they fall in, bucketed like segments by their start time
+// Areas only passed through at speed have no stats and are not added.
+func (a *SpeedSpaceAnalyzer) aggregateStayIntensity(ctx context.Context, areaStats map[string]*AreaSpeedStat) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			duration_s,
+			COALESCE(source, ''),
+			COALESCE(province, ''),
+			COALESCE(city, ''),
+			COALESCE(county, ''),
+			strftime('%Y', datetime(start_time, 'unixepoch')) as year,
+			strftime('%Y-%m', datetime(start_time, 'unixepoch')) as month
+		FROM stay_segments
+		WHERE stay_type = 'SPATIAL'
+			AND duration_s > 0
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			duration               int64
+			source                 string
+			province, city, county string
+			year, month            string
+		)
+		if err := rows.Scan(&duration, &source, &province, &city, &county, &year, &month); err != nil {
+			return fmt.Errorf("failed to scan stay: %w", err)
+		}
+
+		hours := float64(duration) / 3600
+		for _, scope := range analysis.SourceScopes(source) {
+			for _, area := range [][2]string{{"PROVINCE", province}, {"CITY", city}, {"COUNTY", county}} {
+				if area[1] == "" {
+					continue
+				}
+				for _, timeRange := range []string{year, month, "all"} {
+					if stat, ok := areaStats[fmt.Sprintf("%s|%s|%s|%s", scope, area[0], area[1], timeRange)]; ok {
+						stat.StayIntensity += hours
+					}
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating stays: %w", err)
+	}
+	return nil
+}
+
+// slowLifeStayPercentile is the percentile of stay intensity, among areas of
+// the same source, area type and bucket type, a slow-life zone reaches
+const slowLifeStayPercentile = 50
+
+// classifyZones marks areas faster on average than highSpeed as high-speed
+// zones, and areas slower than lowSpeed where time is also spent staying as
+// slow-life zones
+// Stay hours grow with the length of a bucket and the size of an area, so an
+// area's stay intensity is compared with the median of comparable areas that
+// have stays; areas without stays are never slow-life zones.
+func (a *SpeedSpaceAnalyzer) classifyZones(areaStats map[string]*AreaSpeedStat, highSpeed, lowSpeed float64) {
+	intensities := make(map[string][]float64)
+	for _, stat := range areaStats {
+		if stat.StayIntensity > 0 {
+			group := a.stayGroup(stat)
+			intensities[group] = append(intensities[group], stat.StayIntensity)
+		}
+	}
+	stayThresholds := make(map[string]float64, len(intensities))
+	for group, values := range intensities {
+		stayThresholds[group] = stats.Percentile(values, slowLifeStayPercentile)
+	}
+
+	for _, stat := range areaStats {
+		stat.IsHighSpeedZone = stat.AvgSpeed > highSpeed
+		stat.IsSlowLifeZone = stat.AvgSpeed < lowSpeed &&
+			stat.StayIntensity > 0 && stat.StayIntensity >= stayThresholds[a.stayGroup(stat)]
+	}
+}
+
+// stayGroup returns the key of the areas an area's stay intensity is compared with
+func (a *SpeedSpaceAnalyzer) stayGroup(stat *AreaSpeedStat) string {
+	bucketType, _ := a.parseBucketInfo(stat.TimeRange)
+	return stat.Source + "|" + stat.AreaType + "|" + bucketType
 }
 
 // aggregateAreaSpeed aggregates speed data for an area
-func (a *SpeedSpaceAnalyzer) aggregateAreaSpeed(stats map[string]*AreaSpeedStat, areaType, areaKey, timeRange string, speed, distance float64) {
-	key := fmt.Sprintf("%s|%s|%s", areaType, areaKey, timeRange)
+func (a *SpeedSpaceAnalyzer) aggregateAreaSpeed(stats map[string]*AreaSpeedStat, source, areaType, areaKey, timeRange string, speed, distance float64) {
+	key := fmt.Sprintf("%s|%s|%s|%s", source, areaType, areaKey, timeRange)
 
 	stat, exists := stats[key]
 	if !exists {
 		stat = &AreaSpeedStat{
+			Source:    source,
 			AreaType:  areaType,
 			AreaKey:   areaKey,
 			TimeRange: timeRange,
@@ -194,14 +287,12 @@ func (a *SpeedSpaceAnalyzer) aggregateAreaSpeed(stats map[string]*AreaSpeedStat,
 
 	// Update weighted statistics
 	stat.TotalDistance += distance
-	stat.TotalWeightedSpeed += speed * distance
+	stat.Speed.Add(speed, distance)
 	stat.SegmentCount++
 
 	// Update speed bins for entropy calculation (10 km/h bins)
 	bin := int(speed / 10)
 	stat.SpeedBins[bin] += distance
-
-	// Note: Variance will be calculated in a second pass
 }
 
 // calculateSpeedEntropy calculates Shannon entropy of speed distribution
@@ -230,7 +321,7 @@ func (a *SpeedSpaceAnalyzer) calculateSpeedEntropy(speedBins map[int]float64) fl
 
 // insertSpeedSpaceResults inserts speed-space coupling results
 func (a *SpeedSpaceAnalyzer) insertSpeedSpaceResults(ctx context.Context, stats map[string]*AreaSpeedStat) error {
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -238,13 +329,13 @@ func (a *SpeedSpaceAnalyzer) insertSpeedSpaceResults(ctx context.Context, stats
 
 	insertQuery := `
 		INSERT INTO speed_space_stats_bucketed (
-			bucket_type, bucket_key, area_type, area_key,
+			bucket_type, bucket_key, source, area_type, area_key,
 			avg_speed, speed_variance, speed_entropy,
 			total_distance, segment_count,
 			is_high_speed_zone, is_slow_life_zone,
 			stay_intensity, algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
-		ON CONFLICT(bucket_type, bucket_key, area_type, area_key)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+		ON CONFLICT(bucket_type, bucket_key, area_type, area_key, source)
 		DO UPDATE SET
 			avg_speed = excluded.avg_speed,
 			speed_variance = excluded.speed_variance,
@@ -270,6 +361,7 @@ func (a *SpeedSpaceAnalyzer) insertSpeedSpaceResults(ctx context.Context, stats
 		_, err := stmt.ExecContext(ctx,
 			bucketType,
 			bucketKey,
+			stat.Source,
 			stat.AreaType,
 			stat.AreaKey,
 			stat.AvgSpeed,
@@ -279,7 +371,7 @@ func (a *SpeedSpaceAnalyzer) insertSpeedSpaceResults(ctx context.Context, stats
 			stat.SegmentCount,
 			stat.IsHighSpeedZone,
 			stat.IsSlowLifeZone,
-			0.0, // stay_intensity - will be calculated later if needed
+			stat.StayIntensity,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert result: %w", err)
@@ -332,4 +424,5 @@ func (a *SpeedSpaceAnalyzer) countSlowLifeZones(stats map[string]*AreaSpeedStat)
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("speed_space_coupling", NewSpeedSpaceAnalyzer)
+	analysis.RegisterDependencies("speed_space_coupling", "transport_mode")
 }
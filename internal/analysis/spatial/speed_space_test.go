@@ -0,0 +1,63 @@
+package spatial
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClassifyZones(t *testing.T) {
+	newStat := func(areaKey, timeRange string, avgSpeed, stayHours float64) *AreaSpeedStat {
+		return &AreaSpeedStat{Source: "all", AreaType: "CITY", AreaKey: areaKey, TimeRange: timeRange, AvgSpeed: avgSpeed, StayIntensity: stayHours}
+	}
+	areaStats := map[string]*AreaSpeedStat{
+		"fast":         newStat("fast", "all", 90, 200),
+		"slow-home":    newStat("slow-home", "all", 5, 300),
+		"slow-transit": newStat("slow-transit", "all", 5, 10),
+		"slow-nostay":  newStat("slow-nostay", "all", 5, 0),
+		"average":      newStat("average", "all", 30, 100),
+		// Month buckets are only compared with each other
+		"slow-month": newStat("slow-home", "2024-01", 5, 20),
+		"busy-month": newStat("average", "2024-01", 30, 10),
+	}
+
+	(&SpeedSpaceAnalyzer{}).classifyZones(areaStats, 60, 10)
+
+	want := map[string][2]bool{ // high-speed, slow-life
+		"fast":         {true, false},
+		"slow-home":    {false, true},
+		"slow-transit": {false, false},
+		"slow-nostay":  {false, false},
+		"average":      {false, false},
+		"slow-month":   {false, true},
+		"busy-month":   {false, false},
+	}
+	for key, w := range want {
+		stat := areaStats[key]
+		if stat.IsHighSpeedZone != w[0] || stat.IsSlowLifeZone != w[1] {
+			t.Errorf("%s: high-speed/slow-life = %v/%v, want %v/%v", key, stat.IsHighSpeedZone, stat.IsSlowLifeZone, w[0], w[1])
+		}
+	}
+}
+
+func TestAggregateAreaSpeedVariance(t *testing.T) {
+	a := &SpeedSpaceAnalyzer{}
+	areaStats := make(map[string]*AreaSpeedStat)
+
+	// 30 km at 40 km/h and 10 km at 80 km/h: mean 50, variance 0.75*100 + 0.25*900
+	a.aggregateAreaSpeed(areaStats, "all", "CITY", "广州市", "all", 40, 10000)
+	a.aggregateAreaSpeed(areaStats, "all", "CITY", "广州市", "all", 80, 10000)
+	a.aggregateAreaSpeed(areaStats, "all", "CITY", "广州市", "all", 40, 20000)
+	// Another bucket is kept apart
+	a.aggregateAreaSpeed(areaStats, "all", "CITY", "广州市", "2024", 60, 5000)
+
+	stat := areaStats["all|CITY|广州市|all"]
+	if stat == nil || stat.SegmentCount != 3 || stat.TotalDistance != 40000 {
+		t.Fatalf("stat = %+v, want 3 segments over 40 km", stat)
+	}
+	if mean, variance := stat.Speed.Mean(), stat.Speed.Variance(); math.Abs(mean-50) > 1e-9 || math.Abs(variance-300) > 1e-9 {
+		t.Errorf("mean/variance = %v/%v, want 50/300", mean, variance)
+	}
+	if variance := areaStats["all|CITY|广州市|2024"].Speed.Variance(); variance != 0 {
+		t.Errorf("single segment variance = %v, want 0", variance)
+	}
+}
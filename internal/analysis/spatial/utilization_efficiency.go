@@ -7,13 +7,17 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	geo "github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // UtilizationEfficiencyAnalyzer implements spatial utilization efficiency analysis
 // Skill: 空间利用效率 (Utilization Efficiency)
 // Distinguishes destinations (high stay) from transit corridors (high pass-through)
+// per administrative area and all-time, year and month bucket.
 type UtilizationEfficiencyAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -25,6 +29,14 @@ func NewUtilizationEfficiencyAnalyzer(db *sql.DB) analysis.Analyzer {
 	}
 }
 
+// utilizationAreaTypes are the area types of spatial_utilization_bucketed, in
+// the order of the point and stay area columns
+var utilizationAreaTypes = [4]string{"PROVINCE", "CITY", "COUNTY", "TOWN"}
+
+// utilizationGridPrecision is the geohash precision of the grids counted for
+// coverage efficiency, about 1.2 x 0.6 km
+const utilizationGridPrecision = 6
+
 // Analyze performs utilization efficiency analysis
 func (a *UtilizationEfficiencyAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
 	log.Printf("[UtilizationEfficiencyAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
@@ -34,29 +46,30 @@ func (a *UtilizationEfficiencyAnalyzer) Analyze(ctx context.Context, taskID int6
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing metrics (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM spatial_utilization_bucketed"); err != nil {
-			return fmt.Errorf("failed to clear spatial_utilization_bucketed: %w", err)
-		}
-		log.Printf("[UtilizationEfficiencyAnalyzer] Cleared existing metrics")
+	segments, err := a.loadSegments(ctx)
+	if err != nil {
+		return err
 	}
 
-	// Process all-time bucket for all area types
-	totalRecords := 0
-	for _, areaType := range []string{"province", "city", "county", "town"} {
-		count, err := a.processAreaType(ctx, "all", "", areaType)
-		if err != nil {
-			return fmt.Errorf("failed to process %s: %w", areaType, err)
-		}
-		totalRecords += count
-		log.Printf("[UtilizationEfficiencyAnalyzer] Processed %s: %d records", areaType, count)
+	stats := make(map[utilizationKey]*AreaMetrics)
+	if err := a.aggregatePoints(ctx, segments, stats); err != nil {
+		return err
+	}
+	if err := a.aggregateStays(ctx, stats); err != nil {
+		return err
+	}
+	for _, metrics := range stats {
+		metrics.finish()
+	}
+
+	if err := a.insertUtilizationRecords(ctx, stats); err != nil {
+		return fmt.Errorf("failed to insert utilization records: %w", err)
 	}
 
 	// Mark task as completed
 	summary := map[string]interface{}{
-		"total_records": totalRecords,
-		"area_types":    []string{"province", "city", "county", "town"},
+		"total_records": len(stats),
+		"area_types":    utilizationAreaTypes,
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -64,208 +77,351 @@ func (a *UtilizationEfficiencyAnalyzer) Analyze(ctx context.Context, taskID int6
 		return fmt.Errorf("failed to mark task as completed: %w", err)
 	}
 
-	log.Printf("[UtilizationEfficiencyAnalyzer] Analysis completed: %d total records", totalRecords)
+	log.Printf("[UtilizationEfficiencyAnalyzer] Analysis completed: %d total records", len(stats))
 	return nil
 }
 
-// processAreaType processes all areas of a given type
-func (a *UtilizationEfficiencyAnalyzer) processAreaType(ctx context.Context, bucketType, bucketKey, areaType string) (int, error) {
-	// Get distinct areas
-	areas, err := a.getDistinctAreas(ctx, areaType)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get distinct areas: %w", err)
+// utilizationKey identifies a row of spatial_utilization_bucketed
+type utilizationKey struct {
+	Source     string
+	BucketType string
+	BucketKey  string
+	AreaType   string
+	AreaKey    string
+}
+
+// AreaMetrics holds calculated metrics for an area
+type AreaMetrics struct {
+	TransitIntensity   int
+	StayDurationS      int64
+	DistinctVisitDays  int
+	DistinctGrids      int
+	TotalGrids         int
+	FirstVisit         int64
+	LastVisit          int64
+	UtilizationEff     float64
+	TransitDominance   float64
+	AreaDepth          float64
+	CoverageEfficiency float64
+
+	lastDay int64               // Local day of the latest point counted in DistinctVisitDays
+	grids   map[string]struct{} // Geohashes of the points in the area
+	minLat  float64             // Bounding box of the points in the area
+	maxLat  float64
+	minLon  float64
+	maxLon  float64
+}
+
+// addPoint counts a point of the area; points are added in time order
+func (m *AreaMetrics) addPoint(ts int64, day int64, lat, lon float64, grid string) {
+	if m.grids == nil {
+		m.grids = make(map[string]struct{})
+		m.minLat, m.maxLat, m.minLon, m.maxLon = lat, lat, lon, lon
+		m.FirstVisit = ts
+	}
+	if m.DistinctVisitDays == 0 || day != m.lastDay {
+		m.DistinctVisitDays++
+		m.lastDay = day
 	}
+	m.LastVisit = ts
+	m.grids[grid] = struct{}{}
+	m.minLat = math.Min(m.minLat, lat)
+	m.maxLat = math.Max(m.maxLat, lat)
+	m.minLon = math.Min(m.minLon, lon)
+	m.maxLon = math.Max(m.maxLon, lon)
+}
 
-	count := 0
-	for _, area := range areas {
-		if area == "" {
-			continue // Skip empty areas
-		}
+// finish calculates the derived metrics
+func (m *AreaMetrics) finish() {
+	epsilon := 1.0
+	transit := float64(m.TransitIntensity)
+	stayHours := float64(m.StayDurationS) / 3600.0
 
-		// Calculate metrics for this area
-		metrics, err := a.calculateAreaMetrics(ctx, areaType, area)
-		if err != nil {
-			log.Printf("[UtilizationEfficiencyAnalyzer] Warning: failed to calculate metrics for %s/%s: %v", areaType, area, err)
-			continue
-		}
+	// Utilization efficiency: stay hours per pass-through segment
+	m.UtilizationEff = stayHours / (transit + epsilon)
 
-		// Insert record
-		if err := a.insertUtilizationRecord(ctx, bucketType, bucketKey, areaType, area, metrics); err != nil {
-			log.Printf("[UtilizationEfficiencyAnalyzer] Warning: failed to insert record for %s/%s: %v", areaType, area, err)
-			continue
-		}
+	// Transit dominance: transit / (transit + stay_hours)
+	m.TransitDominance = transit / (transit + stayHours + epsilon)
 
-		count++
+	// Area depth: log(1 + stay) × log(1 + days)
+	m.AreaDepth = math.Log(1+float64(m.StayDurationS)) * math.Log(1+float64(m.DistinctVisitDays))
+
+	// Coverage efficiency: grids visited out of the grids of the visited
+	// bounding box, as the area's own extent is not known
+	m.DistinctGrids = len(m.grids)
+	if m.grids != nil {
+		m.TotalGrids = geohashCellsInBounds(m.minLat, m.minLon, m.maxLat, m.maxLon, utilizationGridPrecision)
 	}
+	if m.TotalGrids > 0 {
+		m.CoverageEfficiency = float64(m.DistinctGrids) / float64(m.TotalGrids)
+	}
+}
 
-	return count, nil
+// geohashCellsInBounds counts the geohash cells of a precision intersecting a
+// latitude/longitude box
+func geohashCellsInBounds(minLat, minLon, maxLat, maxLon float64, precision int) int {
+	bits := 5 * precision
+	lonBits := (bits + 1) / 2
+	latBits := bits / 2
+	cellLat := 180 / math.Pow(2, float64(latBits))
+	cellLon := 360 / math.Pow(2, float64(lonBits))
+	rows := int(math.Floor((maxLat+90)/cellLat)-math.Floor((minLat+90)/cellLat)) + 1
+	cols := int(math.Floor((maxLon+180)/cellLon)-math.Floor((minLon+180)/cellLon)) + 1
+	return rows * cols
 }
 
-// getDistinctAreas retrieves distinct areas of a given type
-func (a *UtilizationEfficiencyAnalyzer) getDistinctAreas(ctx context.Context, areaType string) ([]string, error) {
-	query := fmt.Sprintf(`
-		SELECT DISTINCT %s
-		FROM "一生足迹"
-		WHERE %s IS NOT NULL AND %s != ''
-		ORDER BY %s
-	`, areaType, areaType, areaType, areaType)
+// utilizationBuckets returns the all-time, year and month buckets of a time
+func utilizationBuckets(ts int64) [3][2]string {
+	t := time.Unix(ts, 0)
+	return [3][2]string{{"all", ""}, {"year", t.Format("2006")}, {"month", t.Format("2006-01")}}
+}
 
-	rows, err := a.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query distinct areas: %w", err)
+// metricsFor returns the metrics of a row, creating them when missing
+func metricsFor(stats map[utilizationKey]*AreaMetrics, key utilizationKey) *AreaMetrics {
+	m, ok := stats[key]
+	if !ok {
+		m = &AreaMetrics{}
+		stats[key] = m
 	}
-	defer rows.Close()
+	return m
+}
 
-	var areas []string
-	for rows.Next() {
-		var area string
-		if err := rows.Scan(&area); err != nil {
-			return nil, fmt.Errorf("failed to scan area: %w", err)
+// utilizationSegment is a movement segment and the areas its points pass
+type utilizationSegment struct {
+	Source    string
+	StartTime int64
+	EndTime   int64
+
+	first   [4]string // Area of each type of the first geocoded point
+	last    [4]string // Area of each type of the last geocoded point
+	touched [4]map[string]bool
+}
+
+// addAreas records the areas of a point of the segment
+func (s *utilizationSegment) addAreas(areas [4]string) {
+	for i, area := range areas {
+		if area == "" {
+			continue
+		}
+		if s.touched[i] == nil {
+			s.touched[i] = make(map[string]bool)
+			s.first[i] = area
 		}
-		areas = append(areas, area)
+		s.last[i] = area
+		s.touched[i][area] = true
 	}
-
-	return areas, nil
 }
 
-// AreaMetrics holds calculated metrics for an area
-type AreaMetrics struct {
-	TransitIntensity   int
-	StayDurationS      int64
-	DistinctVisitDays  int
-	DistinctGrids      int
-	FirstVisit         int64
-	LastVisit          int64
-	UtilizationEff     float64
-	TransitDominance   float64
-	AreaDepth          float64
-	CoverageEfficiency float64
+// passThrough returns the areas of each type the segment passes without
+// starting or ending in them
+func (s *utilizationSegment) passThrough() [4][]string {
+	var result [4][]string
+	for i, touched := range s.touched {
+		for area := range touched {
+			if area != s.first[i] && area != s.last[i] {
+				result[i] = append(result[i], area)
+			}
+		}
+		sort.Strings(result[i])
+	}
+	return result
 }
 
-// calculateAreaMetrics calculates all metrics for a given area
-func (a *UtilizationEfficiencyAnalyzer) calculateAreaMetrics(ctx context.Context, areaType, areaKey string) (*AreaMetrics, error) {
-	metrics := &AreaMetrics{}
-
-	// 1. Calculate transit intensity (count of segments passing through)
-	// Since segments table doesn't have trip_id, we count segments instead
-	// Join segments with track points to get administrative region info
-	transitQuery := fmt.Sprintf(`
-		SELECT COUNT(DISTINCT s.id)
-		FROM segments s
-		JOIN "一生足迹" p ON p.id = s.start_point_id
-		WHERE p.%s = ?
-	`, areaType)
-
-	err := a.DB.QueryRowContext(ctx, transitQuery, areaKey).Scan(&metrics.TransitIntensity)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to calculate transit intensity: %w", err)
+// loadSegments loads the movement segments of every source in time order
+func (a *UtilizationEfficiencyAnalyzer) loadSegments(ctx context.Context) (map[string][]*utilizationSegment, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT COALESCE(source, ''), start_time, end_time
+		FROM segments
+		ORDER BY start_time
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query segments: %w", err)
 	}
+	defer rows.Close()
 
-	// 2. Calculate stay intensity (total stay duration and distinct days)
-	stayQuery := fmt.Sprintf(`
-		SELECT
-			COALESCE(SUM(duration_s), 0) as total_duration,
-			COUNT(DISTINCT DATE(start_time, 'unixepoch')) as distinct_days,
-			MIN(start_time) as first_visit,
-			MAX(end_time) as last_visit
-		FROM stay_segments
-		WHERE %s = ?
-	`, areaType)
-
-	var firstVisit, lastVisit sql.NullInt64
-	err = a.DB.QueryRowContext(ctx, stayQuery, areaKey).Scan(
-		&metrics.StayDurationS,
-		&metrics.DistinctVisitDays,
-		&firstVisit,
-		&lastVisit,
-	)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to calculate stay intensity: %w", err)
+	segments := make(map[string][]*utilizationSegment)
+	for rows.Next() {
+		seg := &utilizationSegment{}
+		if err := rows.Scan(&seg.Source, &seg.StartTime, &seg.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments[seg.Source] = append(segments[seg.Source], seg)
 	}
+	return segments, rows.Err()
+}
 
-	if firstVisit.Valid {
-		metrics.FirstVisit = firstVisit.Int64
+// aggregatePoints counts the visit days, grids and visit span of every area
+// from the track points, and the pass-through segments of every area from the
+// areas the points of each segment fall in
+func (a *UtilizationEfficiencyAnalyzer) aggregatePoints(ctx context.Context, segments map[string][]*utilizationSegment, stats map[utilizationKey]*AreaMetrics) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			dataTime, latitude, longitude, COALESCE(source, ''),
+			COALESCE(province, ''), COALESCE(city, ''), COALESCE(county, ''), COALESCE(town, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY dataTime
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
 	}
-	if lastVisit.Valid {
-		metrics.LastVisit = lastVisit.Int64
+	defer rows.Close()
+
+	// A segment is finished once a point of its source is past its end
+	cursors := make(map[string]int)
+	finish := func(seg *utilizationSegment) {
+		areas := seg.passThrough()
+		for _, bucket := range utilizationBuckets(seg.StartTime) {
+			for _, source := range analysis.SourceScopes(seg.Source) {
+				for i, areaType := range utilizationAreaTypes {
+					for _, area := range areas[i] {
+						metricsFor(stats, utilizationKey{source, bucket[0], bucket[1], areaType, area}).TransitIntensity++
+					}
+				}
+			}
+		}
 	}
 
-	// 3. Calculate grid coverage (distinct grids visited in this area)
-	gridQuery := fmt.Sprintf(`
-		SELECT COUNT(DISTINCT geohash6)
-		FROM stay_segments
-		WHERE %s = ?
-	`, areaType)
+	points := 0
+	for rows.Next() {
+		var ts int64
+		var lat, lon float64
+		var source string
+		var areas [4]string
+		if err := rows.Scan(&ts, &lat, &lon, &source, &areas[0], &areas[1], &areas[2], &areas[3]); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		points++
+
+		sourceSegments := segments[source]
+		c := cursors[source]
+		for c < len(sourceSegments) && sourceSegments[c].EndTime < ts {
+			finish(sourceSegments[c])
+			c++
+		}
+		cursors[source] = c
+		if c < len(sourceSegments) && sourceSegments[c].StartTime <= ts {
+			sourceSegments[c].addAreas(areas)
+		}
 
-	err = a.DB.QueryRowContext(ctx, gridQuery, areaKey).Scan(&metrics.DistinctGrids)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to calculate grid coverage: %w", err)
+		day := localDay(ts).Unix()
+		grid := geo.EncodeGeohash(lat, lon, utilizationGridPrecision)
+		for _, bucket := range utilizationBuckets(ts) {
+			for _, scope := range analysis.SourceScopes(source) {
+				for i, areaType := range utilizationAreaTypes {
+					if areas[i] == "" {
+						continue
+					}
+					metricsFor(stats, utilizationKey{scope, bucket[0], bucket[1], areaType, areas[i]}).addPoint(ts, day, lat, lon, grid)
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating points: %w", err)
 	}
 
-	// 4. Calculate derived metrics
-	epsilon := 1.0
+	for source, sourceSegments := range segments {
+		for _, seg := range sourceSegments[cursors[source]:] {
+			finish(seg)
+		}
+	}
 
-	// Utilization efficiency: stay / (transit + ε)
-	metrics.UtilizationEff = float64(metrics.StayDurationS) / (float64(metrics.TransitIntensity) + epsilon)
+	log.Printf("[UtilizationEfficiencyAnalyzer] Aggregated %d points into %d area buckets", points, len(stats))
+	return nil
+}
 
-	// Transit dominance: transit / (transit + stay_hours)
-	stayHours := float64(metrics.StayDurationS) / 3600.0
-	metrics.TransitDominance = float64(metrics.TransitIntensity) / (float64(metrics.TransitIntensity) + stayHours + epsilon)
+// aggregateStays adds the duration of the spatial stays to the area of each stay
+func (a *UtilizationEfficiencyAnalyzer) aggregateStays(ctx context.Context, stats map[utilizationKey]*AreaMetrics) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			start_time, duration_s, COALESCE(source, ''),
+			COALESCE(province, ''), COALESCE(city, ''), COALESCE(county, ''), COALESCE(town, '')
+		FROM stay_segments
+		WHERE stay_type = 'SPATIAL'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer rows.Close()
 
-	// Area depth: log(1 + stay) × log(1 + days)
-	metrics.AreaDepth = math.Log(1+float64(metrics.StayDurationS)) * math.Log(1+float64(metrics.DistinctVisitDays))
+	for rows.Next() {
+		var start, duration int64
+		var source string
+		var areas [4]string
+		if err := rows.Scan(&start, &duration, &source, &areas[0], &areas[1], &areas[2], &areas[3]); err != nil {
+			return fmt.Errorf("failed to scan stay: %w", err)
+		}
+		for _, bucket := range utilizationBuckets(start) {
+			for _, scope := range analysis.SourceScopes(source) {
+				for i, areaType := range utilizationAreaTypes {
+					if areas[i] == "" {
+						continue
+					}
+					metricsFor(stats, utilizationKey{scope, bucket[0], bucket[1], areaType, areas[i]}).StayDurationS += duration
+				}
+			}
+		}
+	}
+	return rows.Err()
+}
 
-	// Coverage efficiency: (distinct grids / total grids in area)
-	// For now, set to 0 as we don't have total grid count per area
-	metrics.CoverageEfficiency = 0
+// insertUtilizationRecords replaces the utilization table with the metrics of every area bucket
+func (a *UtilizationEfficiencyAnalyzer) insertUtilizationRecords(ctx context.Context, stats map[utilizationKey]*AreaMetrics) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	return metrics, nil
-}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM spatial_utilization_bucketed"); err != nil {
+		return fmt.Errorf("failed to clear utilization stats: %w", err)
+	}
 
-// insertUtilizationRecord inserts a utilization record into the database
-func (a *UtilizationEfficiencyAnalyzer) insertUtilizationRecord(
-	ctx context.Context,
-	bucketType, bucketKey, areaType, areaKey string,
-	metrics *AreaMetrics,
-) error {
-	insertQuery := `
+	stmt, err := tx.PrepareContext(ctx, `
 		INSERT INTO spatial_utilization_bucketed (
-			bucket_type, bucket_key, area_type, area_key,
+			bucket_type, bucket_key, source, area_type, area_key,
 			transit_intensity, stay_duration_s,
 			utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
 			distinct_visit_days, distinct_grids, total_grids,
 			first_visit, last_visit,
 			algo_version
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1')
-		ON CONFLICT(bucket_type, bucket_key, area_type, area_key) DO UPDATE SET
-			transit_intensity = excluded.transit_intensity,
-			stay_duration_s = excluded.stay_duration_s,
-			utilization_efficiency = excluded.utilization_efficiency,
-			transit_dominance = excluded.transit_dominance,
-			area_depth = excluded.area_depth,
-			coverage_efficiency = excluded.coverage_efficiency,
-			distinct_visit_days = excluded.distinct_visit_days,
-			distinct_grids = excluded.distinct_grids,
-			first_visit = excluded.first_visit,
-			last_visit = excluded.last_visit,
-			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
-	`
-
-	_, err := a.DB.ExecContext(ctx, insertQuery,
-		bucketType, bucketKey, areaType, areaKey,
-		metrics.TransitIntensity, metrics.StayDurationS,
-		metrics.UtilizationEff, metrics.TransitDominance, metrics.AreaDepth, metrics.CoverageEfficiency,
-		metrics.DistinctVisitDays, metrics.DistinctGrids, 0, // total_grids = 0 for now
-		metrics.FirstVisit, metrics.LastVisit,
-	)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("utilization_efficiency")+`')
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to insert utilization record: %w", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	defer stmt.Close()
 
+	for key, m := range stats {
+		var bucketKey, firstVisit, lastVisit interface{}
+		if key.BucketKey != "" {
+			bucketKey = key.BucketKey
+		}
+		if m.grids != nil {
+			firstVisit, lastVisit = m.FirstVisit, m.LastVisit
+		}
+		if _, err := stmt.ExecContext(ctx,
+			key.BucketType, bucketKey, key.Source, key.AreaType, key.AreaKey,
+			m.TransitIntensity, m.StayDurationS,
+			m.UtilizationEff, m.TransitDominance, m.AreaDepth, m.CoverageEfficiency,
+			m.DistinctVisitDays, m.DistinctGrids, m.TotalGrids,
+			firstVisit, lastVisit,
+		); err != nil {
+			return fmt.Errorf("failed to insert utilization record: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("utilization_efficiency", NewUtilizationEfficiencyAnalyzer)
+	analysis.RegisterVersion("utilization_efficiency", "v2")
+	analysis.RegisterDependencies("utilization_efficiency", "transport_mode", "stay_detection")
+	analysis.RegisterOutputs("utilization_efficiency", analysis.Output{Table: "spatial_utilization_bucketed", Replaced: true})
 }
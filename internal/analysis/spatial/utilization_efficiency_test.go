@@ -0,0 +1,61 @@
+package spatial
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUtilizationSegmentPassThrough(t *testing.T) {
+	seg := &utilizationSegment{}
+	for _, areas := range [][4]string{
+		{"", "", "", ""}, // Not geocoded
+		{"广东省", "广州市", "天河区", ""},
+		{"广东省", "东莞市", "", ""},
+		{"广东省", "深圳市", "福田区", ""},
+		{"广东省", "东莞市", "", ""},
+		{"广东省", "广州市", "越秀区", ""},
+	} {
+		seg.addAreas(areas)
+	}
+
+	got := seg.passThrough()
+	want := [4][]string{nil, {"东莞市", "深圳市"}, {"福田区"}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("passThrough() = %v, want %v", got, want)
+	}
+}
+
+func TestGeohashCellsInBounds(t *testing.T) {
+	// Geohash6 cells are 360/2^15 degrees wide and 180/2^15 degrees high
+	cellLat, cellLon := 180/math.Pow(2, 15), 360/math.Pow(2, 15)
+	lat, lon := 10.5*cellLat, 20.5*cellLon
+
+	if got := geohashCellsInBounds(lat, lon, lat, lon, 6); got != 1 {
+		t.Errorf("single point: got %d cells, want 1", got)
+	}
+	if got := geohashCellsInBounds(lat, lon, lat+2*cellLat, lon+cellLon, 6); got != 6 {
+		t.Errorf("3x2 cells: got %d, want 6", got)
+	}
+}
+
+func TestAreaMetricsFinish(t *testing.T) {
+	m := &AreaMetrics{TransitIntensity: 3, StayDurationS: 7200}
+	m.addPoint(1000, 1, 23.1, 113.3, "ws0e6j")
+	m.addPoint(2000, 1, 23.1, 113.3, "ws0e6j")
+	m.addPoint(90000, 2, 23.1, 113.3, "ws0e6j")
+	m.finish()
+
+	if m.DistinctVisitDays != 2 || m.FirstVisit != 1000 || m.LastVisit != 90000 {
+		t.Errorf("days, first, last = %d, %d, %d, want 2, 1000, 90000", m.DistinctVisitDays, m.FirstVisit, m.LastVisit)
+	}
+	if m.UtilizationEff != 0.5 {
+		t.Errorf("utilization efficiency = %v, want 0.5", m.UtilizationEff)
+	}
+	if m.TransitDominance != 0.5 {
+		t.Errorf("transit dominance = %v, want 0.5", m.TransitDominance)
+	}
+	if m.DistinctGrids != 1 || m.TotalGrids != 1 || m.CoverageEfficiency != 1 {
+		t.Errorf("grids = %d/%d (%v), want 1/1", m.DistinctGrids, m.TotalGrids, m.CoverageEfficiency)
+	}
+}
@@ -0,0 +1,6 @@
+package analysis
+
+// EffectiveSpeedExpr is a SQL expression for the speed (m/s) of a track point:
+// the speed reported by the device, or the computed_speed backfilled by the
+// computed_speed analyzer when the device reported none or 0
+const EffectiveSpeedExpr = "COALESCE(NULLIF(speed, 0), computed_speed)"
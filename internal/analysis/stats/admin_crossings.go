@@ -7,13 +7,17 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
 )
 
 // AdminCrossingsAnalyzer implements administrative boundary crossing detection
 // Skill: 行政区划穿越检测 (Admin Crossings Detection)
-// Detects when trajectory crosses administrative boundaries
+// Detects when trajectory crosses administrative boundaries, then pairs the
+// crossings into visits: the stays in each country, province and city from
+// the crossing into it to the crossing out of it
 type AdminCrossingsAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -34,26 +38,30 @@ func (a *AdminCrossingsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing crossings (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM admin_crossings"); err != nil {
-			return fmt.Errorf("failed to clear admin_crossings: %w", err)
-		}
-		log.Printf("[AdminCrossingsAnalyzer] Cleared existing crossings")
-	}
-
 	// Query track points ordered by time
+	// When recomputing a window, the last point before it is read too so a
+	// crossing into the window is detected
 	query := `
 		SELECT
 			id, dataTime, latitude, longitude,
-			province, city, county, town
+			country, province, city, county, town
 		FROM "一生足迹"
 		WHERE outlier_flag = 0
-			AND province IS NOT NULL
+			AND ` + locatedPointCondition + `
+			AND dataTime >= COALESCE((
+				SELECT MAX(dataTime) FROM "一生足迹"
+				WHERE outlier_flag = 0 AND ` + locatedPointCondition + ` AND dataTime < ?
+			), ?)
+			AND dataTime < ?
 		ORDER BY dataTime
 	`
+	var windowStart, windowEnd int64 = 0, math.MaxInt64
+	if window, ok := analysis.WindowFrom(ctx); ok {
+		windowStart, windowEnd = window.Start, window.End
+		log.Printf("[AdminCrossingsAnalyzer] Recomputing crossings in [%d, %d)", windowStart, windowEnd)
+	}
 
-	rows, err := a.DB.QueryContext(ctx, query)
+	rows, err := a.DB.QueryContext(ctx, query, windowStart, windowStart, windowEnd)
 	if err != nil {
 		return fmt.Errorf("failed to query track points: %w", err)
 	}
@@ -61,20 +69,23 @@ func (a *AdminCrossingsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 
 	// Process points and detect crossings
 	var crossings []Crossing
-	var prevPoint *TrackPoint
+	var prevPoint *types.Point
 	totalPoints := 0
 
 	for rows.Next() {
-		var point TrackPoint
-		var province, city, county, town sql.NullString
+		var point types.Point
+		var country, province, city, county, town sql.NullString
 
 		if err := rows.Scan(
-			&point.ID, &point.DataTime, &point.Latitude, &point.Longitude,
-			&province, &city, &county, &town,
+			&point.ID, &point.Timestamp, &point.Lat, &point.Lon,
+			&country, &province, &city, &county, &town,
 		); err != nil {
 			return fmt.Errorf("failed to scan track point: %w", err)
 		}
 
+		if country.Valid {
+			point.Country = country.String
+		}
 		if province.Valid {
 			point.Province = province.String
 		}
@@ -117,10 +128,18 @@ func (a *AdminCrossingsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to insert crossings: %w", err)
 	}
 
+	// Pair all crossings, not only those of a recomputed window, into visits
+	visits, err := a.rebuildVisits(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild visits: %w", err)
+	}
+
 	// Mark task as completed
 	summary := map[string]interface{}{
 		"total_points": totalPoints,
 		"crossings":    len(crossings),
+		"visits":       visits,
+		"country":      a.countCrossingsByType(crossings, "COUNTRY"),
 		"province":     a.countCrossingsByType(crossings, "PROVINCE"),
 		"city":         a.countCrossingsByType(crossings, "CITY"),
 		"county":       a.countCrossingsByType(crossings, "COUNTY"),
@@ -136,25 +155,22 @@ func (a *AdminCrossingsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 	return nil
 }
 
-// TrackPoint holds track point data
-type TrackPoint struct {
-	ID        int64
-	DataTime  int64
-	Latitude  float64
-	Longitude float64
-	Province  string
-	City      string
-	County    string
-	Town      string
-}
+// locatedPointCondition selects the points placed in a region: points
+// with a province, and points abroad, which only have a country
+// Points in China still waiting for their province are left out so they
+// do not break a visit.
+const locatedPointCondition = `(province IS NOT NULL OR (country IS NOT NULL AND country NOT IN ('', 'CN')))`
 
 // Crossing holds crossing event data
 type Crossing struct {
 	CrossingTS   int64
+	FromTS       int64 // Last point before the crossing
+	FromCountry  string
 	FromProvince string
 	FromCity     string
 	FromCounty   string
 	FromTown     string
+	ToCountry    string
 	ToProvince   string
 	ToCity       string
 	ToCounty     string
@@ -166,90 +182,51 @@ type Crossing struct {
 }
 
 // detectCrossing detects if there's an admin boundary crossing between two points
-func (a *AdminCrossingsAnalyzer) detectCrossing(prev, curr *TrackPoint) *Crossing {
-	// Check for province crossing (highest priority)
-	if prev.Province != curr.Province && curr.Province != "" {
-		distance := haversineDistance(prev.Latitude, prev.Longitude, curr.Latitude, curr.Longitude)
-		return &Crossing{
-			CrossingTS:   curr.DataTime,
-			FromProvince: prev.Province,
-			FromCity:     prev.City,
-			FromCounty:   prev.County,
-			FromTown:     prev.Town,
-			ToProvince:   curr.Province,
-			ToCity:       curr.City,
-			ToCounty:     curr.County,
-			ToTown:       curr.Town,
-			CrossingType: "PROVINCE",
-			Latitude:     curr.Latitude,
-			Longitude:    curr.Longitude,
-			Distance:     distance,
-		}
-	}
-
+// Only the highest level crossed is reported; the crossing records every level
+// on both sides.
+func (a *AdminCrossingsAnalyzer) detectCrossing(prev, curr *types.Point) *Crossing {
+	switch {
+	// Check for country crossing (highest priority)
+	case prev.Country != curr.Country && curr.Country != "" && prev.Country != "":
+		return newCrossing(prev, curr, "COUNTRY")
+	// Check for province crossing
+	case prev.Province != curr.Province && curr.Province != "":
+		return newCrossing(prev, curr, "PROVINCE")
 	// Check for city crossing
-	if prev.City != curr.City && curr.City != "" && prev.Province == curr.Province {
-		distance := haversineDistance(prev.Latitude, prev.Longitude, curr.Latitude, curr.Longitude)
-		return &Crossing{
-			CrossingTS:   curr.DataTime,
-			FromProvince: prev.Province,
-			FromCity:     prev.City,
-			FromCounty:   prev.County,
-			FromTown:     prev.Town,
-			ToProvince:   curr.Province,
-			ToCity:       curr.City,
-			ToCounty:     curr.County,
-			ToTown:       curr.Town,
-			CrossingType: "CITY",
-			Latitude:     curr.Latitude,
-			Longitude:    curr.Longitude,
-			Distance:     distance,
-		}
-	}
-
+	case prev.City != curr.City && curr.City != "" && prev.Province == curr.Province:
+		return newCrossing(prev, curr, "CITY")
 	// Check for county crossing
-	if prev.County != curr.County && curr.County != "" && prev.City == curr.City {
-		distance := haversineDistance(prev.Latitude, prev.Longitude, curr.Latitude, curr.Longitude)
-		return &Crossing{
-			CrossingTS:   curr.DataTime,
-			FromProvince: prev.Province,
-			FromCity:     prev.City,
-			FromCounty:   prev.County,
-			FromTown:     prev.Town,
-			ToProvince:   curr.Province,
-			ToCity:       curr.City,
-			ToCounty:     curr.County,
-			ToTown:       curr.Town,
-			CrossingType: "COUNTY",
-			Latitude:     curr.Latitude,
-			Longitude:    curr.Longitude,
-			Distance:     distance,
-		}
-	}
-
+	case prev.County != curr.County && curr.County != "" && prev.City == curr.City:
+		return newCrossing(prev, curr, "COUNTY")
 	// Check for town crossing
-	if prev.Town != curr.Town && curr.Town != "" && prev.County == curr.County {
-		distance := haversineDistance(prev.Latitude, prev.Longitude, curr.Latitude, curr.Longitude)
-		return &Crossing{
-			CrossingTS:   curr.DataTime,
-			FromProvince: prev.Province,
-			FromCity:     prev.City,
-			FromCounty:   prev.County,
-			FromTown:     prev.Town,
-			ToProvince:   curr.Province,
-			ToCity:       curr.City,
-			ToCounty:     curr.County,
-			ToTown:       curr.Town,
-			CrossingType: "TOWN",
-			Latitude:     curr.Latitude,
-			Longitude:    curr.Longitude,
-			Distance:     distance,
-		}
+	case prev.Town != curr.Town && curr.Town != "" && prev.County == curr.County:
+		return newCrossing(prev, curr, "TOWN")
 	}
-
 	return nil
 }
 
+// newCrossing creates a crossing of type from prev to curr
+func newCrossing(prev, curr *types.Point, crossingType string) *Crossing {
+	return &Crossing{
+		CrossingTS:   curr.Timestamp,
+		FromTS:       prev.Timestamp,
+		FromCountry:  prev.Country,
+		FromProvince: prev.Province,
+		FromCity:     prev.City,
+		FromCounty:   prev.County,
+		FromTown:     prev.Town,
+		ToCountry:    curr.Country,
+		ToProvince:   curr.Province,
+		ToCity:       curr.City,
+		ToCounty:     curr.County,
+		ToTown:       curr.Town,
+		CrossingType: crossingType,
+		Latitude:     curr.Lat,
+		Longitude:    curr.Lon,
+		Distance:     haversineDistance(prev.Lat, prev.Lon, curr.Lat, curr.Lon),
+	}
+}
+
 // haversineDistance calculates the distance between two points in meters
 func haversineDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	const earthRadius = 6371000 // meters
@@ -273,7 +250,7 @@ func (a *AdminCrossingsAnalyzer) insertCrossings(ctx context.Context, crossings
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -281,11 +258,11 @@ func (a *AdminCrossingsAnalyzer) insertCrossings(ctx context.Context, crossings
 
 	insertQuery := `
 		INSERT INTO admin_crossings (
-			crossing_ts, from_province, from_city, from_county, from_town,
-			to_province, to_city, to_county, to_town,
+			crossing_ts, from_ts, from_country, from_province, from_city, from_county, from_town,
+			to_country, to_province, to_city, to_county, to_town,
 			crossing_type, latitude, longitude, distance_from_prev_m,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("admin_crossings") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -296,9 +273,9 @@ func (a *AdminCrossingsAnalyzer) insertCrossings(ctx context.Context, crossings
 
 	for _, crossing := range crossings {
 		_, err := stmt.ExecContext(ctx,
-			crossing.CrossingTS,
-			crossing.FromProvince, crossing.FromCity, crossing.FromCounty, crossing.FromTown,
-			crossing.ToProvince, crossing.ToCity, crossing.ToCounty, crossing.ToTown,
+			crossing.CrossingTS, crossing.FromTS,
+			crossing.FromCountry, crossing.FromProvince, crossing.FromCity, crossing.FromCounty, crossing.FromTown,
+			crossing.ToCountry, crossing.ToProvince, crossing.ToCity, crossing.ToCounty, crossing.ToTown,
 			crossing.CrossingType,
 			crossing.Latitude, crossing.Longitude, crossing.Distance,
 		)
@@ -315,6 +292,235 @@ func (a *AdminCrossingsAnalyzer) insertCrossings(ctx context.Context, crossings
 	return nil
 }
 
+// visitMergeGapS is how soon a region re-entered after leaving it continues
+// the same visit, absorbing GPS flicker along a boundary
+const visitMergeGapS = 600
+
+// Visit holds a stay in a country, province or city
+type Visit struct {
+	Level       string // COUNTRY/PROVINCE/CITY
+	Name        string
+	Parent      string // Province of a city, country of a province
+	ArrivalTS   int64
+	DepartureTS int64
+	EnteredFrom string // "" for the first visit
+	ExitedTo    string // "" while still there
+	ArrivalLat  float64
+	ArrivalLon  float64
+}
+
+// visitLevel is an administrative level visits are paired at
+type visitLevel struct {
+	name   string
+	region func(p *types.Point) string // Region of a point at this level
+	parent func(p *types.Point) string
+}
+
+// visitLevels are the levels visits are paired at, highest first
+var visitLevels = []visitLevel{
+	{"COUNTRY", func(p *types.Point) string { return p.Country }, func(p *types.Point) string { return "" }},
+	{"PROVINCE", func(p *types.Point) string { return p.Province }, func(p *types.Point) string { return p.Country }},
+	{"CITY", func(p *types.Point) string { return p.City }, func(p *types.Point) string { return p.Province }},
+}
+
+// buildVisits pairs time-ordered crossings into visits at each level
+// first and last are the first and last located points, opening the first
+// visits and closing the last ones. A visit ends when the region changes,
+// or when a higher level changes into a place without that level (going
+// abroad ends a province visit).
+func buildVisits(first, last types.Point, crossings []Crossing) []Visit {
+	var visits []Visit
+	for li, level := range visitLevels {
+		var levelVisits []Visit
+		var open *Visit
+		start := func(p *types.Point, ts int64, from string, lat, lon float64) {
+			if name := level.region(p); name != "" {
+				open = &Visit{Level: level.name, Name: name, Parent: level.parent(p), ArrivalTS: ts, EnteredFrom: from, ArrivalLat: lat, ArrivalLon: lon}
+			}
+		}
+		start(&first, first.Timestamp, "", first.Lat, first.Lon)
+
+		for _, c := range crossings {
+			from, to := c.points()
+			target, current := level.region(&to), ""
+			if open != nil {
+				current = open.Name
+			}
+			if target == current {
+				continue
+			}
+			if target == "" && !higherLevelChanged(li, &from, &to) {
+				// Missing division, not a departure
+				continue
+			}
+			if open != nil {
+				open.DepartureTS = c.FromTS
+				if open.DepartureTS == 0 {
+					open.DepartureTS = c.CrossingTS
+				}
+				open.ExitedTo = placeName(li, &to)
+				levelVisits = append(levelVisits, *open)
+				open = nil
+			}
+			start(&to, c.CrossingTS, placeName(li, &from), c.Latitude, c.Longitude)
+		}
+		if open != nil {
+			open.DepartureTS = last.Timestamp
+			levelVisits = append(levelVisits, *open)
+		}
+		visits = append(visits, mergeVisits(levelVisits)...)
+	}
+	return visits
+}
+
+// higherLevelChanged reports whether a level above visitLevels[li] changed
+// between two points
+func higherLevelChanged(li int, from, to *types.Point) bool {
+	for _, level := range visitLevels[:li] {
+		if level.region(from) != level.region(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// placeName returns the region of a point at visitLevels[li], or at the
+// closest level above where it has none (the country of a place abroad)
+func placeName(li int, p *types.Point) string {
+	for i := li; i >= 0; i-- {
+		if name := visitLevels[i].region(p); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// mergeVisits joins visits of a region interrupted by an excursion shorter
+// than visitMergeGapS, dropping the excursion
+func mergeVisits(visits []Visit) []Visit {
+	var merged []Visit
+	for _, v := range visits {
+		n := len(merged)
+		if n >= 2 && merged[n-2].Name == v.Name && v.ArrivalTS-merged[n-2].DepartureTS <= visitMergeGapS {
+			merged[n-2].DepartureTS = v.DepartureTS
+			merged[n-2].ExitedTo = v.ExitedTo
+			merged = merged[:n-1]
+			continue
+		}
+		merged = append(merged, v)
+	}
+	return merged
+}
+
+// points returns the regions on both sides of a crossing
+func (c Crossing) points() (types.Point, types.Point) {
+	return types.Point{Country: c.FromCountry, Province: c.FromProvince, City: c.FromCity},
+		types.Point{Country: c.ToCountry, Province: c.ToProvince, City: c.ToCity}
+}
+
+// rebuildVisits replaces the visits with those paired from all crossings
+// Returns the number of visits.
+func (a *AdminCrossingsAnalyzer) rebuildVisits(ctx context.Context) (int, error) {
+	first, ok, err := a.loadBoundaryPoint(ctx, "ASC")
+	if err != nil || !ok {
+		return 0, err
+	}
+	last, _, err := a.loadBoundaryPoint(ctx, "DESC")
+	if err != nil {
+		return 0, err
+	}
+
+	// Town and county crossings stay within a city
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			crossing_ts, COALESCE(from_ts, 0),
+			COALESCE(from_country, ''), COALESCE(from_province, ''), COALESCE(from_city, ''),
+			COALESCE(to_country, ''), COALESCE(to_province, ''), COALESCE(to_city, ''),
+			crossing_type, COALESCE(latitude, 0), COALESCE(longitude, 0)
+		FROM admin_crossings
+		WHERE crossing_type IN ('COUNTRY', 'PROVINCE', 'CITY')
+		ORDER BY crossing_ts, id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query crossings: %w", err)
+	}
+	defer rows.Close()
+
+	var crossings []Crossing
+	for rows.Next() {
+		var c Crossing
+		if err := rows.Scan(
+			&c.CrossingTS, &c.FromTS,
+			&c.FromCountry, &c.FromProvince, &c.FromCity,
+			&c.ToCountry, &c.ToProvince, &c.ToCity,
+			&c.CrossingType, &c.Latitude, &c.Longitude,
+		); err != nil {
+			return 0, fmt.Errorf("failed to scan crossing: %w", err)
+		}
+		crossings = append(crossings, c)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating crossings: %w", err)
+	}
+
+	visits := buildVisits(first, last, crossings)
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].ArrivalTS < visits[j].ArrivalTS })
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM admin_visits`); err != nil {
+		return 0, fmt.Errorf("failed to clear visits: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO admin_visits (
+			admin_level, admin_name, parent_name, arrival_ts, departure_ts, duration_s,
+			entered_from, exited_to, arrival_lat, arrival_lon, algo_version
+		) VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, '`+analysis.Version("admin_crossings")+`')
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, v := range visits {
+		if _, err := stmt.ExecContext(ctx,
+			v.Level, v.Name, v.Parent, v.ArrivalTS, v.DepartureTS, v.DepartureTS-v.ArrivalTS,
+			v.EnteredFrom, v.ExitedTo, v.ArrivalLat, v.ArrivalLon,
+		); err != nil {
+			return 0, fmt.Errorf("failed to insert visit: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	log.Printf("[AdminCrossingsAnalyzer] Rebuilt %d visits", len(visits))
+	return len(visits), nil
+}
+
+// loadBoundaryPoint loads the first (order ASC) or last (order DESC) located point
+func (a *AdminCrossingsAnalyzer) loadBoundaryPoint(ctx context.Context, order string) (types.Point, bool, error) {
+	var p types.Point
+	err := a.DB.QueryRowContext(ctx, `
+		SELECT dataTime, latitude, longitude, COALESCE(country, ''), COALESCE(province, ''), COALESCE(city, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0 AND `+locatedPointCondition+`
+		ORDER BY dataTime `+order+`
+		LIMIT 1
+	`).Scan(&p.Timestamp, &p.Lat, &p.Lon, &p.Country, &p.Province, &p.City)
+	if err == sql.ErrNoRows {
+		return p, false, nil
+	}
+	if err != nil {
+		return p, false, fmt.Errorf("failed to query boundary point: %w", err)
+	}
+	return p, true, nil
+}
+
 // countCrossingsByType counts crossings by type
 func (a *AdminCrossingsAnalyzer) countCrossingsByType(crossings []Crossing, crossingType string) int {
 	count := 0
@@ -329,4 +535,14 @@ func (a *AdminCrossingsAnalyzer) countCrossingsByType(crossings []Crossing, cros
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("admin_crossings", NewAdminCrossingsAnalyzer)
+	analysis.RegisterVersion("admin_crossings", "v2")
+	analysis.RegisterDependencies("admin_crossings", "outlier_detection", "country_backfill")
+	analysis.RegisterOutputs("admin_crossings",
+		analysis.Output{
+			Table:  "admin_crossings",
+			Window: "crossing_ts >= ? AND crossing_ts < ?",
+		},
+		// Visits are paired from all crossings once they are saved
+		analysis.Output{Table: "admin_visits", Replaced: true},
+	)
 }
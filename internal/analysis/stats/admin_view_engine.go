@@ -33,14 +33,6 @@ func (a *AdminViewEngineAnalyzer) Analyze(ctx context.Context, taskID int64, mod
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing stats (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM admin_stats"); err != nil {
-			return fmt.Errorf("failed to clear admin_stats: %w", err)
-		}
-		log.Printf("[AdminViewEngineAnalyzer] Cleared existing admin stats")
-	}
-
 	// Process each admin level
 	levels := []string{"PROVINCE", "CITY", "COUNTY", "TOWN"}
 	totalStats := 0
@@ -177,7 +169,7 @@ func (a *AdminViewEngineAnalyzer) insertAdminStats(ctx context.Context, stats []
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -189,7 +181,7 @@ func (a *AdminViewEngineAnalyzer) insertAdminStats(ctx context.Context, stats []
 			visit_count, total_duration_s, unique_days,
 			first_visit_ts, last_visit_ts, total_distance_m,
 			algo_version, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("admin_view_engine") + `', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 		ON CONFLICT(admin_level, admin_name) DO UPDATE SET
 			parent_name = excluded.parent_name,
 			visit_count = excluded.visit_count,
@@ -229,4 +221,6 @@ func (a *AdminViewEngineAnalyzer) insertAdminStats(ctx context.Context, stats []
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("admin_view_engine", NewAdminViewEngineAnalyzer)
+	analysis.RegisterDependencies("admin_view_engine", "outlier_detection")
+	analysis.RegisterOutputs("admin_view_engine", analysis.Output{Table: "admin_stats"})
 }
@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// CarbonFootprintAnalyzer implements carbon footprint estimation
+// Skill: 碳足迹 (Carbon Footprint)
+// Estimates the CO2-equivalent of each trip and of each month and year from
+// segment distances and the emissions model in emission_factors. Segments
+// outside trips count towards the monthly and yearly totals only.
+type CarbonFootprintAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewCarbonFootprintAnalyzer creates a new carbon footprint analyzer
+func NewCarbonFootprintAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &CarbonFootprintAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "carbon_footprint", 10000),
+	}
+}
+
+// carbonAgg accumulates the footprint of a trip or a time bucket
+type carbonAgg struct {
+	StartTime int64
+	DistanceM float64
+	CO2Kg     float64
+	ModeCO2   map[string]float64
+	Trips     map[int64]bool
+}
+
+func newCarbonAgg(startTime int64) *carbonAgg {
+	return &carbonAgg{StartTime: startTime, ModeCO2: make(map[string]float64), Trips: make(map[int64]bool)}
+}
+
+func (c *carbonAgg) add(mode string, distanceM, co2Kg float64) {
+	c.DistanceM += distanceM
+	c.CO2Kg += co2Kg
+	c.ModeCO2[mode] += co2Kg
+}
+
+// carbonBucketKey identifies a row of carbon_stats_bucketed
+type carbonBucketKey struct {
+	BucketType string
+	BucketKey  string
+}
+
+// Analyze performs the carbon footprint estimation
+func (a *CarbonFootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[CarbonFootprintAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	factors, err := a.LoadEmissionFactors(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Each segment counts towards the trip whose time range contains its start
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			s.start_time,
+			s.mode,
+			COALESCE(s.distance_m, 0),
+			(
+				SELECT t.id FROM trips t
+				WHERE t.start_time <= s.start_time AND t.end_time >= s.start_time
+				ORDER BY t.start_time DESC
+				LIMIT 1
+			)
+		FROM segments s
+		WHERE s.mode IS NOT NULL AND s.mode != 'STAY'
+		ORDER BY s.start_time
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	trips := make(map[int64]*carbonAgg)
+	buckets := make(map[carbonBucketKey]*carbonAgg)
+	totalSegments := 0
+	for rows.Next() {
+		var startTime int64
+		var segMode string
+		var distanceM float64
+		var tripID sql.NullInt64
+		if err := rows.Scan(&startTime, &segMode, &distanceM, &tripID); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		totalSegments++
+
+		co2Kg := factors.CO2Kg(segMode, distanceM)
+
+		if tripID.Valid {
+			trip := trips[tripID.Int64]
+			if trip == nil {
+				trip = newCarbonAgg(startTime)
+				trips[tripID.Int64] = trip
+			}
+			trip.add(segMode, distanceM, co2Kg)
+		}
+
+		start := time.Unix(startTime, 0)
+		for _, key := range []carbonBucketKey{
+			{"year", start.Format("2006")},
+			{"month", start.Format("2006-01")},
+		} {
+			bucket := buckets[key]
+			if bucket == nil {
+				bucket = newCarbonAgg(startTime)
+				buckets[key] = bucket
+			}
+			bucket.add(segMode, distanceM, co2Kg)
+			if tripID.Valid {
+				bucket.Trips[tripID.Int64] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	rows.Close()
+
+	if err := a.UpdateTaskProgress(taskID, int64(totalSegments), int64(totalSegments), 0); err != nil {
+		log.Printf("[CarbonFootprintAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	if err := a.insertFootprints(ctx, trips, buckets); err != nil {
+		return fmt.Errorf("failed to insert carbon footprints: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_segments": totalSegments,
+		"trips":          len(trips),
+		"buckets":        len(buckets),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[CarbonFootprintAnalyzer] Analysis completed: %d segments, %d trips, %d buckets", totalSegments, len(trips), len(buckets))
+	return nil
+}
+
+// insertFootprints upserts the trip and bucket footprints in one transaction
+func (a *CarbonFootprintAnalyzer) insertFootprints(ctx context.Context, trips map[int64]*carbonAgg, buckets map[carbonBucketKey]*carbonAgg) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tripStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO trip_carbon (trip_id, start_time, distance_m, co2_kg, mode_co2_json)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(trip_id) DO UPDATE SET
+			start_time = excluded.start_time,
+			distance_m = excluded.distance_m,
+			co2_kg = excluded.co2_kg,
+			mode_co2_json = excluded.mode_co2_json,
+			created_at = CAST(strftime('%s', 'now') AS INTEGER)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer tripStmt.Close()
+
+	for tripID, trip := range trips {
+		modeJSON, _ := json.Marshal(trip.ModeCO2)
+		if _, err := tripStmt.ExecContext(ctx, tripID, trip.StartTime, trip.DistanceM, trip.CO2Kg, string(modeJSON)); err != nil {
+			return fmt.Errorf("failed to insert footprint of trip %d: %w", tripID, err)
+		}
+	}
+
+	bucketStmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO carbon_stats_bucketed (bucket_type, bucket_key, distance_m, co2_kg, trip_count, mode_co2_json)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(bucket_type, bucket_key) DO UPDATE SET
+			distance_m = excluded.distance_m,
+			co2_kg = excluded.co2_kg,
+			trip_count = excluded.trip_count,
+			mode_co2_json = excluded.mode_co2_json,
+			created_at = CAST(strftime('%s', 'now') AS INTEGER)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer bucketStmt.Close()
+
+	for key, bucket := range buckets {
+		modeJSON, _ := json.Marshal(bucket.ModeCO2)
+		if _, err := bucketStmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, bucket.DistanceM, bucket.CO2Kg, len(bucket.Trips), string(modeJSON),
+		); err != nil {
+			return fmt.Errorf("failed to insert carbon footprint of %s %s: %w", key.BucketType, key.BucketKey, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("carbon_footprint", NewCarbonFootprintAnalyzer)
+	analysis.RegisterDependencies("carbon_footprint", "transport_mode", "trip_construction")
+	analysis.RegisterOutputs("carbon_footprint",
+		analysis.Output{Table: "trip_carbon"},
+		analysis.Output{Table: "carbon_stats_bucketed"},
+	)
+}
@@ -0,0 +1,397 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/calendar"
+)
+
+// strideLengthM is the average walking stride used for step equivalents
+const strideLengthM = 0.75
+
+// DailySummaryAnalyzer rolls movement data up into one row per local day
+// Skill: 每日摘要 (Daily Summary Rollup)
+// Full mode rebuilds every day; incremental mode rebuilds from the last
+// summarized day onward, since that day may have been incomplete.
+type DailySummaryAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewDailySummaryAnalyzer creates a new daily summary analyzer
+func NewDailySummaryAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &DailySummaryAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "daily_summary", 1000),
+	}
+}
+
+// DailySummary holds the rollup of a single day
+type DailySummary struct {
+	Date            string
+	Weekday         int
+	DayType         string
+	PointCount      int64
+	TotalDistance   float64
+	DistanceByMode  map[string]float64
+	ActiveTime      float64
+	MaxSpeedKmh     float64
+	TripCount       int
+	StayCount       int
+	FirstMovementTS int64
+	LastMovementTS  int64
+	Provinces       map[string]bool
+	Cities          map[string]bool
+	Counties        map[string]bool
+}
+
+// Analyze builds the daily summaries
+func (a *DailySummaryAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[DailySummaryAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Incremental mode resumes at local midnight of the last summarized day
+	var sinceTS int64
+	var sinceDate string
+	if mode != "full" {
+		var lastDate sql.NullString
+		if err := a.DB.QueryRowContext(ctx, "SELECT MAX(date) FROM daily_summaries").Scan(&lastDate); err != nil {
+			return fmt.Errorf("failed to query last summarized day: %w", err)
+		}
+		if lastDate.Valid {
+			day, err := time.ParseInLocation("2006-01-02", lastDate.String, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid summarized day %q: %w", lastDate.String, err)
+			}
+			sinceTS = day.Unix()
+			sinceDate = lastDate.String
+			log.Printf("[DailySummaryAnalyzer] Incremental mode: rebuilding from %s", sinceDate)
+		}
+	}
+
+	days := make(map[string]*DailySummary)
+
+	if err := a.aggregateSegments(ctx, days, sinceTS); err != nil {
+		return err
+	}
+	if err := a.aggregatePoints(ctx, days, sinceTS); err != nil {
+		return err
+	}
+	if err := a.aggregateStaysAndTrips(ctx, days, sinceTS, sinceDate); err != nil {
+		return err
+	}
+
+	if err := a.replaceSummaries(ctx, days, sinceDate); err != nil {
+		return fmt.Errorf("failed to store daily summaries: %w", err)
+	}
+
+	total := int64(len(days))
+	if err := a.UpdateTaskProgress(taskID, total, total, 0); err != nil {
+		log.Printf("[DailySummaryAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	summary := map[string]interface{}{
+		"days": len(days),
+	}
+	if sinceDate != "" {
+		summary["since"] = sinceDate
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[DailySummaryAnalyzer] Analysis completed: %d days summarized", len(days))
+	return nil
+}
+
+// summaryDay returns the summary for the local day containing ts, creating it if needed
+func summaryDay(days map[string]*DailySummary, ts int64) *DailySummary {
+	return summaryDayAt(days, time.Unix(ts, 0))
+}
+
+func summaryDayAt(days map[string]*DailySummary, t time.Time) *DailySummary {
+	key := t.Format("2006-01-02")
+	if d, ok := days[key]; ok {
+		return d
+	}
+	d := &DailySummary{
+		Date:           key,
+		Weekday:        int(t.Weekday()),
+		DayType:        calendar.DayType(t),
+		DistanceByMode: make(map[string]float64),
+		Provinces:      make(map[string]bool),
+		Cities:         make(map[string]bool),
+		Counties:       make(map[string]bool),
+	}
+	days[key] = d
+	return d
+}
+
+// aggregateSegments adds movement segments, splitting those that cross
+// midnight proportionally to the time spent on each day
+func (a *DailySummaryAnalyzer) aggregateSegments(ctx context.Context, days map[string]*DailySummary, sinceTS int64) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT mode, start_time, end_time, COALESCE(distance_m, 0), COALESCE(max_speed_kmh, 0)
+		FROM segments
+		WHERE end_time >= ?
+		ORDER BY start_time
+	`, sinceTS)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mode string
+		var start, end int64
+		var distance, maxSpeed float64
+		if err := rows.Scan(&mode, &start, &end, &distance, &maxSpeed); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		if start < sinceTS {
+			start = sinceTS
+		}
+		if end < start {
+			continue
+		}
+
+		duration := float64(end - start)
+		for cur := start; cur <= end; {
+			d := summaryDay(days, cur)
+			next := nextMidnight(cur)
+			sliceEnd := end
+			if next <= end {
+				sliceEnd = next
+			}
+
+			share := 1.0
+			if duration > 0 {
+				share = float64(sliceEnd-cur) / duration
+			}
+			d.DistanceByMode[mode] += distance * share
+			d.TotalDistance += distance * share
+			d.ActiveTime += float64(sliceEnd - cur)
+			if maxSpeed > d.MaxSpeedKmh {
+				d.MaxSpeedKmh = maxSpeed
+			}
+			if d.FirstMovementTS == 0 || cur < d.FirstMovementTS {
+				d.FirstMovementTS = cur
+			}
+			if sliceEnd > d.LastMovementTS {
+				d.LastMovementTS = sliceEnd
+			}
+
+			if next > end {
+				break
+			}
+			cur = next
+		}
+	}
+
+	return rows.Err()
+}
+
+// aggregatePoints counts points and collects the admin areas visited
+func (a *DailySummaryAnalyzer) aggregatePoints(ctx context.Context, days map[string]*DailySummary, sinceTS int64) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, COALESCE(province, ''), COALESCE(city, ''), COALESCE(county, '')
+		FROM "一生足迹"
+		WHERE dataTime >= ?
+			AND (outlier_flag = 0 OR outlier_flag IS NULL)
+	`, sinceTS)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts int64
+		var province, city, county string
+		if err := rows.Scan(&ts, &province, &city, &county); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+
+		d := summaryDay(days, ts)
+		d.PointCount++
+		if province != "" {
+			d.Provinces[province] = true
+		}
+		if city != "" {
+			d.Cities[city] = true
+		}
+		if county != "" {
+			d.Counties[province+"/"+city+"/"+county] = true
+		}
+	}
+
+	return rows.Err()
+}
+
+// aggregateStaysAndTrips counts stays (by start day) and trips (by trip date)
+func (a *DailySummaryAnalyzer) aggregateStaysAndTrips(ctx context.Context, days map[string]*DailySummary, sinceTS int64, sinceDate string) error {
+	stayRows, err := a.DB.QueryContext(ctx, "SELECT start_time FROM stay_segments WHERE start_time >= ?", sinceTS)
+	if err != nil {
+		return fmt.Errorf("failed to query stays: %w", err)
+	}
+	for stayRows.Next() {
+		var ts int64
+		if err := stayRows.Scan(&ts); err != nil {
+			stayRows.Close()
+			return fmt.Errorf("failed to scan stay: %w", err)
+		}
+		summaryDay(days, ts).StayCount++
+	}
+	if err := stayRows.Err(); err != nil {
+		stayRows.Close()
+		return fmt.Errorf("error iterating stays: %w", err)
+	}
+	stayRows.Close()
+
+	tripRows, err := a.DB.QueryContext(ctx, "SELECT date, COUNT(*) FROM trips WHERE date >= ? GROUP BY date", sinceDate)
+	if err != nil {
+		return fmt.Errorf("failed to query trips: %w", err)
+	}
+	defer tripRows.Close()
+
+	for tripRows.Next() {
+		var date string
+		var count int
+		if err := tripRows.Scan(&date, &count); err != nil {
+			return fmt.Errorf("failed to scan trips: %w", err)
+		}
+		t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			continue
+		}
+		summaryDayAt(days, t).TripCount += count
+	}
+
+	return tripRows.Err()
+}
+
+// replaceSummaries deletes the rebuilt range and inserts the new rows in one transaction
+func (a *DailySummaryAnalyzer) replaceSummaries(ctx context.Context, days map[string]*DailySummary, sinceDate string) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM daily_summaries WHERE date >= ?", sinceDate); err != nil {
+		return fmt.Errorf("failed to clear daily summaries: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO daily_summaries (
+			date, weekday, day_type,
+			point_count, total_distance_m, distance_by_mode, primary_mode,
+			active_time_s, max_speed_kmh, step_equivalent,
+			trip_count, stay_count, first_movement_ts, last_movement_ts,
+			provinces, cities, city_count, county_count,
+			algo_version, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("daily_summary")+`', ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	keys := make([]string, 0, len(days))
+	for k := range days {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	now := time.Now().Unix()
+	for _, k := range keys {
+		d := days[k]
+
+		byMode := make(map[string]float64, len(d.DistanceByMode))
+		primaryMode := ""
+		for mode, dist := range d.DistanceByMode {
+			byMode[mode] = roundTo(dist, 1)
+			if primaryMode == "" || dist > d.DistanceByMode[primaryMode] ||
+				(dist == d.DistanceByMode[primaryMode] && mode < primaryMode) {
+				primaryMode = mode
+			}
+		}
+		byModeJSON, _ := json.Marshal(byMode)
+		provincesJSON, _ := json.Marshal(sortedKeys(d.Provinces))
+		citiesJSON, _ := json.Marshal(sortedKeys(d.Cities))
+
+		var firstTS, lastTS interface{}
+		if d.FirstMovementTS > 0 {
+			firstTS = d.FirstMovementTS
+			lastTS = d.LastMovementTS
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			d.Date, d.Weekday, d.DayType,
+			d.PointCount, roundTo(d.TotalDistance, 1), string(byModeJSON), nullString(primaryMode),
+			int64(d.ActiveTime), d.MaxSpeedKmh, int64(d.DistanceByMode["WALK"]/strideLengthM),
+			d.TripCount, d.StayCount, firstTS, lastTS,
+			string(provincesJSON), string(citiesJSON), len(d.Cities), len(d.Counties),
+			now,
+		); err != nil {
+			return fmt.Errorf("failed to insert summary for %s: %w", d.Date, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// nextMidnight returns the start of the local day after ts
+func nextMidnight(ts int64) int64 {
+	t := time.Unix(ts, 0)
+	y, m, d := t.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.Local).Unix()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func roundTo(v float64, decimals int) float64 {
+	p := 1.0
+	for i := 0; i < decimals; i++ {
+		p *= 10
+	}
+	if v < 0 {
+		return float64(int64(v*p-0.5)) / p
+	}
+	return float64(int64(v*p+0.5)) / p
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("daily_summary", NewDailySummaryAnalyzer)
+	analysis.RegisterDependencies("daily_summary", "transport_mode", "trip_construction")
+	analysis.RegisterOutputs("daily_summary", analysis.Output{Table: "daily_summaries", Replaced: true})
+}
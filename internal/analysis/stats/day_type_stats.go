@@ -0,0 +1,293 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/calendar"
+)
+
+// DayTypeStatsAnalyzer implements the workday / weekend / holiday comparison
+// Skill: 节假日对比 (Day Type Comparison)
+// Splits distance, trips, cities and stays into workdays, weekends and public
+// holidays using the embedded Chinese holiday calendar. Movement comes from
+// daily_summaries, so run it after daily_summary.
+type DayTypeStatsAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewDayTypeStatsAnalyzer creates a new day type stats analyzer
+func NewDayTypeStatsAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &DayTypeStatsAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "day_type_stats", 1000),
+	}
+}
+
+// dayTypeKey identifies a row of day_type_stats_bucketed
+type dayTypeKey struct {
+	BucketType string
+	BucketKey  string
+	DayType    string
+}
+
+// stayTypeTotals are the stays of one label
+type stayTypeTotals struct {
+	Count     int   `json:"count"`
+	DurationS int64 `json:"duration_s"`
+}
+
+// dayTypeAgg accumulates the totals of a row
+type dayTypeAgg struct {
+	DayCount       int
+	DistanceM      float64
+	ActiveTimeS    int64
+	TripCount      int
+	DistanceByMode map[string]float64
+	Cities         map[string]bool
+	CityDays       int // Sum of cities visited per day
+	StayCount      int
+	StayDurationS  int64
+	StayTypes      map[string]*stayTypeTotals
+}
+
+// dayTypeBuckets returns the rows a local day counts towards
+func dayTypeBuckets(day time.Time) []dayTypeKey {
+	dayType := calendar.DayType(day)
+	return []dayTypeKey{
+		{BucketType: "all", BucketKey: "all", DayType: dayType},
+		{BucketType: "year", BucketKey: day.Format("2006"), DayType: dayType},
+	}
+}
+
+// Analyze performs the day type comparison
+func (a *DayTypeStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[DayTypeStatsAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	aggMap := make(map[dayTypeKey]*dayTypeAgg)
+	agg := func(key dayTypeKey) *dayTypeAgg {
+		if d := aggMap[key]; d != nil {
+			return d
+		}
+		d := &dayTypeAgg{
+			DistanceByMode: make(map[string]float64),
+			Cities:         make(map[string]bool),
+			StayTypes:      make(map[string]*stayTypeTotals),
+		}
+		aggMap[key] = d
+		return d
+	}
+
+	days, err := a.aggregateDays(ctx, agg)
+	if err != nil {
+		return err
+	}
+	stays, err := a.aggregateStays(ctx, agg)
+	if err != nil {
+		return err
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(days+stays), int64(days+stays), 0); err != nil {
+		log.Printf("[DayTypeStatsAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	if err := a.insertDayTypeStats(ctx, aggMap); err != nil {
+		return fmt.Errorf("failed to insert day type stats: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"days":             days,
+		"stays":            stays,
+		"inserted_records": len(aggMap),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[DayTypeStatsAnalyzer] Analysis completed: %d days, %d stays, %d records", days, stays, len(aggMap))
+	return nil
+}
+
+// aggregateDays adds the daily summaries and returns the number of days
+// The day type is taken from the calendar rather than the summary, so calendar
+// updates apply without rebuilding the summaries.
+func (a *DayTypeStatsAnalyzer) aggregateDays(ctx context.Context, agg func(dayTypeKey) *dayTypeAgg) (int, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT date, COALESCE(total_distance_m, 0), COALESCE(active_time_s, 0), COALESCE(trip_count, 0),
+			COALESCE(distance_by_mode, ''), COALESCE(cities, '')
+		FROM daily_summaries
+		ORDER BY date
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query daily summaries: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var date, modeJSON, citiesJSON string
+		var distance float64
+		var activeTime int64
+		var trips int
+		if err := rows.Scan(&date, &distance, &activeTime, &trips, &modeJSON, &citiesJSON); err != nil {
+			return 0, fmt.Errorf("failed to scan daily summary: %w", err)
+		}
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return 0, fmt.Errorf("invalid summarized day %q: %w", date, err)
+		}
+
+		distanceByMode := map[string]float64{}
+		if modeJSON != "" {
+			if err := json.Unmarshal([]byte(modeJSON), &distanceByMode); err != nil {
+				return 0, fmt.Errorf("invalid distance_by_mode of %s: %w", date, err)
+			}
+		}
+		var cities []string
+		if citiesJSON != "" {
+			if err := json.Unmarshal([]byte(citiesJSON), &cities); err != nil {
+				return 0, fmt.Errorf("invalid cities of %s: %w", date, err)
+			}
+		}
+		count++
+
+		for _, key := range dayTypeBuckets(day) {
+			d := agg(key)
+			d.DayCount++
+			d.DistanceM += distance
+			d.ActiveTimeS += activeTime
+			d.TripCount += trips
+			for mode, m := range distanceByMode {
+				d.DistanceByMode[mode] += m
+			}
+			for _, city := range cities {
+				d.Cities[city] = true
+			}
+			d.CityDays += len(cities)
+		}
+	}
+	return count, rows.Err()
+}
+
+// aggregateStays adds the spatial stays by the day they start, and returns their number
+// Stays are labelled by their annotation, else the type of their place cluster.
+func (a *DayTypeStatsAnalyzer) aggregateStays(ctx context.Context, agg func(dayTypeKey) *dayTypeAgg) (int, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT s.start_time, s.duration_s, COALESCE(sa.label, s.cluster_type, 'UNLABELED')
+		FROM stay_segments s
+		LEFT JOIN stay_annotations sa ON sa.stay_id = s.id
+		WHERE s.stay_type = 'SPATIAL'
+		ORDER BY s.start_time
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query stays: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var start, duration int64
+		var label string
+		if err := rows.Scan(&start, &duration, &label); err != nil {
+			return 0, fmt.Errorf("failed to scan stay: %w", err)
+		}
+		count++
+
+		for _, key := range dayTypeBuckets(time.Unix(start, 0)) {
+			d := agg(key)
+			d.StayCount++
+			d.StayDurationS += duration
+			totals := d.StayTypes[label]
+			if totals == nil {
+				totals = &stayTypeTotals{}
+				d.StayTypes[label] = totals
+			}
+			totals.Count++
+			totals.DurationS += duration
+		}
+	}
+	return count, rows.Err()
+}
+
+// insertDayTypeStats upserts the aggregated rows in one transaction
+func (a *DayTypeStatsAnalyzer) insertDayTypeStats(ctx context.Context, aggMap map[dayTypeKey]*dayTypeAgg) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO day_type_stats_bucketed (
+			bucket_type, bucket_key, day_type, day_count,
+			total_distance_m, avg_distance_m, avg_active_time_s, trip_count, avg_trip_count, distance_by_mode,
+			city_count, avg_city_count, stay_count, stay_duration_s, stay_types,
+			algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("day_type_stats")+`')
+		ON CONFLICT(bucket_type, bucket_key, day_type)
+		DO UPDATE SET
+			day_count = excluded.day_count,
+			total_distance_m = excluded.total_distance_m,
+			avg_distance_m = excluded.avg_distance_m,
+			avg_active_time_s = excluded.avg_active_time_s,
+			trip_count = excluded.trip_count,
+			avg_trip_count = excluded.avg_trip_count,
+			distance_by_mode = excluded.distance_by_mode,
+			city_count = excluded.city_count,
+			avg_city_count = excluded.avg_city_count,
+			stay_count = excluded.stay_count,
+			stay_duration_s = excluded.stay_duration_s,
+			stay_types = excluded.stay_types,
+			created_at = CAST(strftime('%s', 'now') AS INTEGER)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, d := range aggMap {
+		// Averages are per day with a summary; stays alone do not make a day
+		var avgDistance, avgActive, avgTrips, avgCities float64
+		if d.DayCount > 0 {
+			n := float64(d.DayCount)
+			avgDistance = d.DistanceM / n
+			avgActive = float64(d.ActiveTimeS) / n
+			avgTrips = float64(d.TripCount) / n
+			avgCities = float64(d.CityDays) / n
+		}
+		modeJSON, _ := json.Marshal(d.DistanceByMode)
+		stayTypesJSON, _ := json.Marshal(d.StayTypes)
+
+		if _, err := stmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, key.DayType, d.DayCount,
+			d.DistanceM, avgDistance, avgActive, d.TripCount, avgTrips, string(modeJSON),
+			len(d.Cities), avgCities, d.StayCount, d.StayDurationS, string(stayTypesJSON),
+		); err != nil {
+			return fmt.Errorf("failed to insert day type stats: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("day_type_stats", NewDayTypeStatsAnalyzer)
+	analysis.RegisterDependencies("day_type_stats", "daily_summary", "stay_annotation")
+	analysis.RegisterOutputs("day_type_stats", analysis.Output{Table: "day_type_stats_bucketed"})
+}
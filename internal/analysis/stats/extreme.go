@@ -9,6 +9,7 @@ import (
 	"sort"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/analysis/types"
 	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
@@ -35,14 +36,6 @@ func (a *ExtremeEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing extreme events (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM extreme_events"); err != nil {
-			return fmt.Errorf("failed to clear extreme events: %w", err)
-		}
-		log.Printf("[ExtremeEventsAnalyzer] Cleared existing extreme events")
-	}
-
 	// Get all trips
 	tripsQuery := `
 		SELECT
@@ -103,9 +96,9 @@ func (a *ExtremeEventsAnalyzer) Analyze(ctx context.Context, taskID int64, mode
 			return fmt.Errorf("failed to query points for trip %d: %w", trip.ID, err)
 		}
 
-		var points []PointData
+		var points []types.Point
 		for pointRows.Next() {
-			var point PointData
+			var point types.Point
 			var altitude sql.NullFloat64
 			var province, city, county sql.NullString
 			if err := pointRows.Scan(&point.ID, &point.Timestamp, &point.Lat, &point.Lon, &altitude, &province, &city, &county); err != nil {
@@ -172,18 +165,6 @@ type TripInfo struct {
 	EndTS   int64
 }
 
-// PointData holds point data
-type PointData struct {
-	ID        int64
-	Timestamp int64
-	Lat       float64
-	Lon       float64
-	Alt       float64
-	Province  string
-	City      string
-	County    string
-}
-
 // ExtremeEvent holds extreme event data
 type ExtremeEvent struct {
 	EventType string
@@ -199,7 +180,7 @@ type ExtremeEvent struct {
 }
 
 // calculateTripExtremes calculates extreme events for a trip
-func (a *ExtremeEventsAnalyzer) calculateTripExtremes(extremes map[string]*ExtremeEvent, trip TripInfo, points []PointData) {
+func (a *ExtremeEventsAnalyzer) calculateTripExtremes(extremes map[string]*ExtremeEvent, trip TripInfo, points []types.Point) {
 	if len(points) == 0 {
 		return
 	}
@@ -346,7 +327,7 @@ func (a *ExtremeEventsAnalyzer) calculateTripExtremes(extremes map[string]*Extre
 
 // insertExtremeEvents inserts extreme events into the database
 func (a *ExtremeEventsAnalyzer) insertExtremeEvents(ctx context.Context, extremes map[string]*ExtremeEvent) error {
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -397,4 +378,6 @@ func (a *ExtremeEventsAnalyzer) insertExtremeEvents(ctx context.Context, extreme
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("extreme_events", NewExtremeEventsAnalyzer)
+	analysis.RegisterDependencies("extreme_events", "outlier_detection", "trip_construction")
+	analysis.RegisterOutputs("extreme_events", analysis.Output{Table: "extreme_events"})
 }
@@ -6,14 +6,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
 )
 
+// footprintStatTypes are the stat types FootprintAnalyzer produces
+var footprintStatTypes = []string{"PROVINCE", "CITY", "COUNTY", "TOWN", "COUNTRY", "REGION", "GRID"}
+
 // FootprintAnalyzer implements footprint statistics aggregation
 // Skill: 足迹层统计与排行 (Footprint Analytics)
-// Aggregates track points by administrative areas, time ranges, and grids
+// Aggregates track points by administrative areas, time ranges, and grids.
+//
+// Points are added to one row per area and day (time_range YYYY-MM-DD); the
+// month, year and "all" rows of the areas a batch touched are then rolled up
+// from those day rows, so the statistics do not depend on how points were
+// batched. Each batch is written together with the range of point ids it
+// counted, in one transaction.
+//
+// Full mode rebuilds: the statistics and the recorded ranges are truncated
+// first. Incremental mode counts only the points after the last recorded
+// range, so re-running it never counts a point twice; without recorded ranges
+// (statistics from before they were kept) it rebuilds. Points that change
+// after being counted, e.g. geocoded, re-imported or reviewed as outliers,
+// are only picked up by a rebuild. Every run ends by ranking all statistics.
 type FootprintAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -21,7 +36,7 @@ type FootprintAnalyzer struct {
 // NewFootprintAnalyzer creates a new footprint statistics analyzer
 func NewFootprintAnalyzer(db *sql.DB) analysis.Analyzer {
 	return &FootprintAnalyzer{
-		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "footprint_statistics", 1000),
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "footprint_statistics", 10000),
 	}
 }
 
@@ -34,11 +49,25 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Get last processed point ID for incremental mode
-	var lastProcessedID int64
-	if mode == "incremental" {
-		lastProcessedID = a.GetLastProcessedID(taskID)
-		log.Printf("[FootprintAnalyzer] Incremental mode: starting from point_id=%d", lastProcessedID)
+	lastProcessedID, recorded, err := a.lastProcessedID(ctx)
+	if err != nil {
+		return err
+	}
+	rebuild := mode != "incremental" || !recorded
+	if rebuild {
+		if err := a.truncate(ctx); err != nil {
+			return fmt.Errorf("failed to truncate statistics: %w", err)
+		}
+		lastProcessedID = 0
+		log.Printf("[FootprintAnalyzer] Rebuilding statistics")
+	} else {
+		log.Printf("[FootprintAnalyzer] Incremental mode: starting after point_id=%d", lastProcessedID)
+	}
+
+	// Points imported while the analyzer runs are left for the next run
+	var maxPointID int64
+	if err := a.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM "一生足迹"`).Scan(&maxPointID); err != nil {
+		return fmt.Errorf("failed to get last point id: %w", err)
 	}
 
 	// Count total points to process
@@ -46,10 +75,10 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 		SELECT COUNT(*)
 		FROM "一生足迹"
 		WHERE outlier_flag = 0
-			AND id > ?
+			AND id > ? AND id <= ?
 	`
 	var totalPoints int64
-	if err := a.DB.QueryRowContext(ctx, countQuery, lastProcessedID).Scan(&totalPoints); err != nil {
+	if err := a.DB.QueryRowContext(ctx, countQuery, lastProcessedID, maxPointID).Scan(&totalPoints); err != nil {
 		return fmt.Errorf("failed to count points: %w", err)
 	}
 
@@ -60,10 +89,7 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 		return fmt.Errorf("failed to update task progress: %w", err)
 	}
 
-	// Process in batches
-	batchSize := 10000
 	processed := int64(0)
-
 	for {
 		// Query batch of points
 		query := `
@@ -74,19 +100,19 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 				city,
 				county,
 				town,
+				country,
+				region,
 				grid_id,
 				distance,
-				strftime('%Y', datetime(dataTime, 'unixepoch')) as year,
-				strftime('%Y-%m', datetime(dataTime, 'unixepoch')) as month,
 				strftime('%Y-%m-%d', datetime(dataTime, 'unixepoch')) as day
 			FROM "一生足迹"
 			WHERE outlier_flag = 0
-				AND id > ?
+				AND id > ? AND id <= ?
 			ORDER BY id
 			LIMIT ?
 		`
 
-		rows, err := a.DB.QueryContext(ctx, query, lastProcessedID, batchSize)
+		rows, err := a.DB.QueryContext(ctx, query, lastProcessedID, maxPointID, a.BatchSize)
 		if err != nil {
 			return fmt.Errorf("failed to query points: %w", err)
 		}
@@ -98,14 +124,15 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 
 		for rows.Next() {
 			var (
-				id                                     int64
-				dataTime                               int64
-				province, city, county, town, grid_id  sql.NullString
-				distance                               sql.NullFloat64
-				year, month, day                       string
+				id                                    int64
+				dataTime                              int64
+				province, city, county, town, grid_id sql.NullString
+				country, region                       sql.NullString
+				distance                              sql.NullFloat64
+				day                                   string
 			)
 
-			if err := rows.Scan(&id, &dataTime, &province, &city, &county, &town, &grid_id, &distance, &year, &month, &day); err != nil {
+			if err := rows.Scan(&id, &dataTime, &province, &city, &county, &town, &country, &region, &grid_id, &distance, &day); err != nil {
 				rows.Close()
 				return fmt.Errorf("failed to scan row: %w", err)
 			}
@@ -115,46 +142,28 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 				maxID = id
 			}
 
-			// Aggregate by province
-			if province.Valid && province.String != "" {
-				a.aggregatePoint(stats, "PROVINCE", province.String, year, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "PROVINCE", province.String, month, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "PROVINCE", province.String, day, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "PROVINCE", province.String, "all", dataTime, distance.Float64)
+			areas := []struct {
+				statType string
+				key      sql.NullString
+			}{
+				{"PROVINCE", province},
+				{"CITY", city},
+				{"COUNTY", county},
+				{"TOWN", town},
+				{"COUNTRY", country},
+				{"REGION", region},
+				{"GRID", grid_id},
 			}
-
-			// Aggregate by city
-			if city.Valid && city.String != "" {
-				a.aggregatePoint(stats, "CITY", city.String, year, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "CITY", city.String, month, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "CITY", city.String, day, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "CITY", city.String, "all", dataTime, distance.Float64)
-			}
-
-			// Aggregate by county
-			if county.Valid && county.String != "" {
-				a.aggregatePoint(stats, "COUNTY", county.String, year, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "COUNTY", county.String, month, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "COUNTY", county.String, day, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "COUNTY", county.String, "all", dataTime, distance.Float64)
-			}
-
-			// Aggregate by town
-			if town.Valid && town.String != "" {
-				a.aggregatePoint(stats, "TOWN", town.String, year, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "TOWN", town.String, month, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "TOWN", town.String, day, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "TOWN", town.String, "all", dataTime, distance.Float64)
-			}
-
-			// Aggregate by grid
-			if grid_id.Valid && grid_id.String != "" {
-				a.aggregatePoint(stats, "GRID", grid_id.String, year, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "GRID", grid_id.String, month, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "GRID", grid_id.String, day, dataTime, distance.Float64)
-				a.aggregatePoint(stats, "GRID", grid_id.String, "all", dataTime, distance.Float64)
+			for _, area := range areas {
+				if area.key.Valid && area.key.String != "" {
+					a.aggregatePoint(stats, area.statType, area.key.String, day, dataTime, distance.Float64)
+				}
 			}
 		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("error iterating points: %w", err)
+		}
 		rows.Close()
 
 		// No more data
@@ -163,7 +172,7 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 		}
 
 		// Insert/update statistics
-		if err := a.upsertStatistics(ctx, stats); err != nil {
+		if err := a.upsertStatistics(ctx, taskID, stats, lastProcessedID+1, maxID, int64(batchCount)); err != nil {
 			return fmt.Errorf("failed to upsert statistics: %w", err)
 		}
 
@@ -177,16 +186,22 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 		log.Printf("[FootprintAnalyzer] Processed %d/%d points (%.1f%%)", processed, totalPoints, float64(processed)/float64(totalPoints)*100)
 
 		// Check if we've processed all points
-		if batchCount < batchSize {
+		if batchCount < a.BatchSize {
 			break
 		}
 	}
 
+	if err := rankStatistics(ctx, a.IncrementalAnalyzer, "footprint_statistics", footprintRanks); err != nil {
+		return err
+	}
+
 	// Mark task as completed
 	summary := map[string]interface{}{
+		"rebuild":          rebuild,
 		"total_points":     totalPoints,
 		"processed_points": processed,
-		"statistics_count": len(a.getStatisticsCount(ctx)),
+		"last_point_id":    lastProcessedID,
+		"statistics_count": a.getStatisticsCount(ctx),
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -198,30 +213,27 @@ func (a *FootprintAnalyzer) Analyze(ctx context.Context, taskID int64, mode stri
 	return nil
 }
 
-// FootprintStat holds aggregated statistics for a specific stat_type + stat_key + time_range
+// FootprintStat holds the statistics of one area on one day
 type FootprintStat struct {
-	StatType       string
-	StatKey        string
-	TimeRange      string
-	PointCount     int64
-	VisitDays      map[string]bool // Track unique days
-	FirstVisit     int64
-	LastVisit      int64
-	TotalDistance  float64
-	TotalDuration  int64
+	StatType      string
+	StatKey       string
+	TimeRange     string // YYYY-MM-DD
+	PointCount    int64
+	FirstVisit    int64
+	LastVisit     int64
+	TotalDistance float64
 }
 
-// aggregatePoint adds a point to the statistics
-func (a *FootprintAnalyzer) aggregatePoint(stats map[string]*FootprintStat, statType, statKey, timeRange string, timestamp int64, distance float64) {
-	key := fmt.Sprintf("%s|%s|%s", statType, statKey, timeRange)
+// aggregatePoint adds a point to the statistics of its area and day
+func (a *FootprintAnalyzer) aggregatePoint(stats map[string]*FootprintStat, statType, statKey, day string, timestamp int64, distance float64) {
+	key := fmt.Sprintf("%s|%s|%s", statType, statKey, day)
 
 	stat, exists := stats[key]
 	if !exists {
 		stat = &FootprintStat{
 			StatType:   statType,
 			StatKey:    statKey,
-			TimeRange:  timeRange,
-			VisitDays:  make(map[string]bool),
+			TimeRange:  day,
 			FirstVisit: timestamp,
 			LastVisit:  timestamp,
 		}
@@ -232,10 +244,6 @@ func (a *FootprintAnalyzer) aggregatePoint(stats map[string]*FootprintStat, stat
 	stat.PointCount++
 	stat.TotalDistance += distance
 
-	// Track unique days
-	day := time.Unix(timestamp, 0).Format("2006-01-02")
-	stat.VisitDays[day] = true
-
 	// Update first/last visit
 	if timestamp < stat.FirstVisit {
 		stat.FirstVisit = timestamp
@@ -245,9 +253,76 @@ func (a *FootprintAnalyzer) aggregatePoint(stats map[string]*FootprintStat, stat
 	}
 }
 
-// upsertStatistics inserts or updates statistics in the database
-func (a *FootprintAnalyzer) upsertStatistics(ctx context.Context, stats map[string]*FootprintStat) error {
-	tx, err := a.DB.BeginTx(ctx, nil)
+// lastProcessedID returns the last point id counted into the statistics, and
+// whether any range has been recorded
+func (a *FootprintAnalyzer) lastProcessedID(ctx context.Context) (int64, bool, error) {
+	var lastID sql.NullInt64
+	if err := a.DB.QueryRowContext(ctx, "SELECT MAX(end_id) FROM footprint_processed_ranges").Scan(&lastID); err != nil {
+		return 0, false, fmt.Errorf("failed to get processed ranges: %w", err)
+	}
+	return lastID.Int64, lastID.Valid, nil
+}
+
+// truncate deletes the statistics and the recorded ranges before a rebuild
+func (a *FootprintAnalyzer) truncate(ctx context.Context) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	args := make([]interface{}, len(footprintStatTypes))
+	for i, statType := range footprintStatTypes {
+		args[i] = statType
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM footprint_statistics
+		WHERE stat_type IN (?, ?, ?, ?, ?, ?, ?)`, args...); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM footprint_processed_ranges"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// footprintRollupQuery recomputes month, year and "all" rows from day rows
+// Its parameter is a JSON array of [stat_type, stat_key, time_range, prefix]
+// targets, where the day rows of the target are those starting with prefix.
+const footprintRollupQuery = `
+	INSERT INTO footprint_statistics (
+		stat_type, stat_key, time_range,
+		point_count, visit_count, first_visit, last_visit,
+		total_distance_m, total_duration_s, metadata, updated_at
+	)
+	SELECT
+		d.stat_type, d.stat_key, json_extract(r.value, '$[2]'),
+		SUM(d.point_count), COUNT(*), MIN(d.first_visit), MAX(d.last_visit),
+		SUM(d.total_distance_m), MAX(d.last_visit) - MIN(d.first_visit),
+		json_object('visit_days', COUNT(*)), CURRENT_TIMESTAMP
+	FROM json_each(?) r
+	JOIN footprint_statistics d
+		ON d.stat_type = json_extract(r.value, '$[0]')
+		AND d.stat_key = json_extract(r.value, '$[1]')
+		AND d.time_range >= json_extract(r.value, '$[3]')
+		AND d.time_range < json_extract(r.value, '$[3]') || '~'
+	WHERE length(d.time_range) = 10
+	GROUP BY r.key
+	ON CONFLICT(stat_type, stat_key, time_range) DO UPDATE SET
+		point_count = excluded.point_count,
+		visit_count = excluded.visit_count,
+		first_visit = excluded.first_visit,
+		last_visit = excluded.last_visit,
+		total_distance_m = excluded.total_distance_m,
+		total_duration_s = excluded.total_duration_s,
+		metadata = excluded.metadata,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+// upsertStatistics adds a batch of day statistics, rolls up the month, year
+// and "all" rows they belong to and records the point range [startID, endID]
+// they were counted from, in one transaction
+func (a *FootprintAnalyzer) upsertStatistics(ctx context.Context, taskID int64, stats map[string]*FootprintStat, startID, endID, pointCount int64) error {
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -258,15 +333,13 @@ func (a *FootprintAnalyzer) upsertStatistics(ctx context.Context, stats map[stri
 			stat_type, stat_key, time_range,
 			point_count, visit_count, first_visit, last_visit,
 			total_distance_m, total_duration_s, metadata, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, 1, ?, ?, ?, ?, '{"visit_days":1}', CURRENT_TIMESTAMP)
 		ON CONFLICT(stat_type, stat_key, time_range) DO UPDATE SET
 			point_count = point_count + excluded.point_count,
-			visit_count = visit_count + excluded.visit_count,
 			first_visit = MIN(first_visit, excluded.first_visit),
 			last_visit = MAX(last_visit, excluded.last_visit),
 			total_distance_m = total_distance_m + excluded.total_distance_m,
-			total_duration_s = total_duration_s + excluded.total_duration_s,
-			metadata = excluded.metadata,
+			total_duration_s = MAX(last_visit, excluded.last_visit) - MIN(first_visit, excluded.first_visit),
 			updated_at = CURRENT_TIMESTAMP
 	`
 
@@ -276,28 +349,48 @@ func (a *FootprintAnalyzer) upsertStatistics(ctx context.Context, stats map[stri
 	}
 	defer stmt.Close()
 
+	// Month, year and "all" rows to roll up, with the prefix of their day rows
+	var targets [][4]string
+	seen := make(map[[4]string]bool)
 	for _, stat := range stats {
-		visitCount := int64(len(stat.VisitDays))
-		duration := stat.LastVisit - stat.FirstVisit
-
-		// Create metadata JSON
-		metadata := fmt.Sprintf(`{"visit_days":%d}`, visitCount)
-
 		_, err := stmt.ExecContext(ctx,
 			stat.StatType,
 			stat.StatKey,
 			stat.TimeRange,
 			stat.PointCount,
-			visitCount,
 			stat.FirstVisit,
 			stat.LastVisit,
 			stat.TotalDistance,
-			duration,
-			metadata,
+			stat.LastVisit-stat.FirstVisit,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to upsert statistic: %w", err)
 		}
+
+		month, year := stat.TimeRange[:7], stat.TimeRange[:4]
+		for _, target := range [][4]string{
+			{stat.StatType, stat.StatKey, month, month},
+			{stat.StatType, stat.StatKey, year, year},
+			{stat.StatType, stat.StatKey, "all", ""},
+		} {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	targetsJSON, err := json.Marshal(targets)
+	if err != nil {
+		return fmt.Errorf("failed to encode rollup targets: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, footprintRollupQuery, string(targetsJSON)); err != nil {
+		return fmt.Errorf("failed to roll up statistics: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO footprint_processed_ranges (start_id, end_id, point_count, task_id)
+		VALUES (?, ?, ?, ?)`, startID, endID, pointCount, taskID); err != nil {
+		return fmt.Errorf("failed to record processed range: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -337,4 +430,9 @@ func (a *FootprintAnalyzer) getStatisticsCount(ctx context.Context) map[string]i
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("footprint_statistics", NewFootprintAnalyzer)
+	analysis.RegisterDependencies("footprint_statistics", "outlier_detection")
+	analysis.RegisterOutputs("footprint_statistics",
+		analysis.Output{Table: "footprint_statistics"},
+		analysis.Output{Table: "footprint_processed_ranges"},
+	)
 }
@@ -0,0 +1,216 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/database"
+)
+
+// footprintDB opens a migrated database in a temporary directory holding n
+// points spread over areas, days and a month boundary
+func footprintDB(t *testing.T, n int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "footprint.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+	addFootprintPoints(t, db, 0, n)
+	return db
+}
+
+// addFootprintPoints inserts points from..to-1; every seventh is an outlier
+// and every fifth has no town
+func addFootprintPoints(t *testing.T, db *sql.DB, from, to int) {
+	t.Helper()
+	const start = 1706659200 // 2024-01-31 00:00:00 UTC
+	for i := from; i < to; i++ {
+		town := sql.NullString{String: fmt.Sprintf("town-%d", i%4), Valid: i%5 != 0}
+		if _, err := db.Exec(`INSERT INTO "一生足迹" (
+			dataTime, longitude, latitude, province, city, county, town, country, region, grid_id, distance, outlier_flag
+		) VALUES (?, 113.3, 23.1, ?, ?, ?, ?, '中国', '华南', ?, ?, ?)`,
+			start+int64(i)*3*3600, fmt.Sprintf("province-%d", i%2), fmt.Sprintf("city-%d", i%3),
+			fmt.Sprintf("county-%d", i%6), town, fmt.Sprintf("grid-%d", i%10), float64(i%7)*12.5,
+			boolInt(i%7 == 3)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// footprintRow is the part of a footprint_statistics row the analyzer computes
+type footprintRow struct {
+	PointCount, VisitCount, FirstVisit, LastVisit, DurationS int64
+	DistanceM                                                float64
+	Metadata                                                 string
+}
+
+// footprintSnapshot returns all footprint statistics by type, key and range
+func footprintSnapshot(t *testing.T, db *sql.DB) map[string]footprintRow {
+	t.Helper()
+	rows, err := db.Query(`SELECT stat_type, stat_key, time_range, point_count, visit_count,
+		first_visit, last_visit, total_duration_s, total_distance_m, metadata
+		FROM footprint_statistics`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	snapshot := make(map[string]footprintRow)
+	for rows.Next() {
+		var statType, statKey, timeRange string
+		var row footprintRow
+		if err := rows.Scan(&statType, &statKey, &timeRange, &row.PointCount, &row.VisitCount,
+			&row.FirstVisit, &row.LastVisit, &row.DurationS, &row.DistanceM, &row.Metadata); err != nil {
+			t.Fatal(err)
+		}
+		snapshot[statType+"|"+statKey+"|"+timeRange] = row
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+func runFootprint(t *testing.T, db *sql.DB, mode string, batchSize int) {
+	t.Helper()
+	a := NewFootprintAnalyzer(db).(*FootprintAnalyzer)
+	a.BatchSize = batchSize
+	if err := a.Analyze(context.Background(), 0, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFootprintFullRunIsIdempotent(t *testing.T) {
+	const n = 100 // 3-hourly from 2024-01-31, so 8 points a day over 13 days
+	db := footprintDB(t, n)
+
+	runFootprint(t, db, "full", 10000)
+	first := footprintSnapshot(t, db)
+	runFootprint(t, db, "full", 10000)
+	if second := footprintSnapshot(t, db); !reflect.DeepEqual(first, second) {
+		t.Fatalf("second full run changed the statistics")
+	}
+
+	var valid int64
+	if err := db.QueryRow(`SELECT COUNT(*) FROM "一生足迹" WHERE outlier_flag = 0`).Scan(&valid); err != nil {
+		t.Fatal(err)
+	}
+	all := first["COUNTRY|中国|all"]
+	if all.PointCount != valid {
+		t.Errorf("COUNTRY all point_count = %d, want %d", all.PointCount, valid)
+	}
+	if all.VisitCount != 13 || all.Metadata != `{"visit_days":13}` {
+		t.Errorf("COUNTRY all visit_count = %d, metadata %s, want 13 days", all.VisitCount, all.Metadata)
+	}
+	if all.DurationS != all.LastVisit-all.FirstVisit {
+		t.Errorf("COUNTRY all duration = %d, want span %d", all.DurationS, all.LastVisit-all.FirstVisit)
+	}
+	// Points 0..7 fall on 2024-01-31; point 3 is an outlier
+	if got := first["COUNTRY|中国|2024-01"].PointCount; got != 7 {
+		t.Errorf("COUNTRY 2024-01 point_count = %d, want 7", got)
+	}
+	if got := first["COUNTRY|中国|2024-01-31"].PointCount; got != 7 {
+		t.Errorf("COUNTRY 2024-01-31 point_count = %d, want 7", got)
+	}
+	if got, want := first["COUNTRY|中国|2024-02"].PointCount, valid-7; got != want {
+		t.Errorf("COUNTRY 2024-02 point_count = %d, want %d", got, want)
+	}
+	if got := first["REGION|华南|2024-01-31"].PointCount; got != 7 {
+		t.Errorf("REGION 2024-01-31 point_count = %d, want 7", got)
+	}
+}
+
+func TestFootprintIncrementalMatchesFull(t *testing.T) {
+	const n = 100
+	full := footprintDB(t, n)
+	runFootprint(t, full, "full", 10000)
+	want := footprintSnapshot(t, full)
+
+	db := footprintDB(t, n/2)
+	runFootprint(t, db, "incremental", 7) // No recorded ranges yet: rebuilds
+	addFootprintPoints(t, db, n/2, n)
+	runFootprint(t, db, "incremental", 7)
+	if got := footprintSnapshot(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("incremental runs differ from a full run:\n got %v\nwant %v", got, want)
+	}
+
+	// Nothing new: re-running counts nothing twice
+	runFootprint(t, db, "incremental", 7)
+	if got := footprintSnapshot(t, db); !reflect.DeepEqual(got, want) {
+		t.Fatalf("re-running incremental changed the statistics")
+	}
+
+	var counted int64
+	if err := db.QueryRow("SELECT SUM(point_count) FROM footprint_processed_ranges").Scan(&counted); err != nil {
+		t.Fatal(err)
+	}
+	if got := want["COUNTRY|中国|all"].PointCount; counted != got {
+		t.Errorf("processed ranges counted %d points, want %d", counted, got)
+	}
+}
+
+func TestFootprintBatchSizeDoesNotMatter(t *testing.T) {
+	db := footprintDB(t, 100)
+	runFootprint(t, db, "full", 10000)
+	want := footprintSnapshot(t, db)
+	for _, batchSize := range []int{1, 3, 16} {
+		runFootprint(t, db, "full", batchSize)
+		if got := footprintSnapshot(t, db); !reflect.DeepEqual(got, want) {
+			t.Errorf("batch size %d: statistics differ from a single batch", batchSize)
+		}
+	}
+}
+
+func TestFootprintRanks(t *testing.T) {
+	db := footprintDB(t, 100)
+	runFootprint(t, db, "full", 10000)
+
+	// Ranks follow point counts within each stat_type and time_range
+	var misordered int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM footprint_statistics a
+		JOIN footprint_statistics b ON a.stat_type = b.stat_type AND a.time_range = b.time_range
+		WHERE a.point_count > b.point_count AND a.rank_by_points >= b.rank_by_points`).Scan(&misordered); err != nil {
+		t.Fatal(err)
+	}
+	if misordered != 0 {
+		t.Errorf("%d pairs of rows ranked against their point counts", misordered)
+	}
+
+	type rank struct{ rank, prev sql.NullInt64 }
+	ranks := func(key string) rank {
+		t.Helper()
+		var r rank
+		if err := db.QueryRow(`SELECT rank_by_points, prev_rank_by_points FROM footprint_statistics
+			WHERE stat_type || '|' || stat_key || '|' || time_range = ?`, key).Scan(&r.rank, &r.prev); err != nil {
+			t.Fatalf("%s: %v", key, err)
+		}
+		return r
+	}
+	if r := ranks("COUNTRY|中国|2024-02"); r.rank.Int64 != 1 || !r.prev.Valid || r.prev.Int64 != 1 {
+		t.Errorf("COUNTRY 2024-02 rank = %v, previous %v, want 1 and 1 in 2024-01", r.rank, r.prev)
+	}
+	if r := ranks("COUNTRY|中国|2024-02-01"); !r.prev.Valid {
+		t.Errorf("COUNTRY 2024-02-01 has no previous rank, want the rank of 2024-01-31")
+	}
+	if r := ranks("COUNTRY|中国|2024-01"); r.prev.Valid {
+		t.Errorf("COUNTRY 2024-01 previous rank = %d, want none", r.prev.Int64)
+	}
+	if r := ranks("COUNTRY|中国|all"); r.rank.Int64 != 1 || r.prev.Valid {
+		t.Errorf("COUNTRY all rank = %v, previous %v, want 1 and none", r.rank, r.prev)
+	}
+}
@@ -0,0 +1,424 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+const (
+	// highAltitudeM is the altitude from which heart rate is counted as high-altitude
+	highAltitudeM = 2500.0
+	// hrMatchWindowS is the largest gap between a heart rate sample and its track point
+	hrMatchWindowS = 300
+)
+
+// HealthCorrelationAnalyzer joins imported health data with trajectory statistics
+// Skill: 健康×位置关联 (Health × Location Correlation)
+// Per day it compares recorded steps with walked distance and splits heart
+// rate by the altitude of the nearest track point. The table is small (one
+// row per day), so both modes rebuild it completely.
+type HealthCorrelationAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewHealthCorrelationAnalyzer creates a new health correlation analyzer
+func NewHealthCorrelationAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &HealthCorrelationAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "health_correlation", 1000),
+	}
+}
+
+// HealthMovementDay holds the correlation inputs of a single day
+type HealthMovementDay struct {
+	Date             string
+	RecordedSteps    int64
+	WalkDistance     float64
+	MovedDistance    float64
+	WorkoutCount     int
+	WorkoutMinutes   float64
+	WorkoutDistance  float64
+	HRSum            float64
+	HRCount          int64
+	HighAltHRSum     float64
+	HighAltHRCount   int64
+	LowAltHRSum      float64
+	LowAltHRCount    int64
+	MaxAltitude      float64
+	HasAltitudeMatch bool
+}
+
+// Analyze builds the health movement correlation table
+func (a *HealthCorrelationAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[HealthCorrelationAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	days := make(map[string]*HealthMovementDay)
+
+	if err := a.aggregateHealth(ctx, days); err != nil {
+		return err
+	}
+	if len(days) == 0 {
+		log.Printf("[HealthCorrelationAnalyzer] No health data imported, nothing to correlate")
+	}
+	if err := a.aggregateMovement(ctx, days); err != nil {
+		return err
+	}
+	matched, err := a.matchHeartRateAltitude(ctx, days)
+	if err != nil {
+		return err
+	}
+
+	if err := a.replaceCorrelations(ctx, days); err != nil {
+		return fmt.Errorf("failed to store health correlations: %w", err)
+	}
+
+	total := int64(len(days))
+	if err := a.UpdateTaskProgress(taskID, total, total, 0); err != nil {
+		log.Printf("[HealthCorrelationAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	summaryJSON, _ := json.Marshal(map[string]interface{}{
+		"days":              len(days),
+		"hr_samples_placed": matched,
+	})
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[HealthCorrelationAnalyzer] Analysis completed: %d days, %d heart rate samples placed by altitude", len(days), matched)
+	return nil
+}
+
+// healthDay returns the day row of date, creating it if needed
+func healthDay(days map[string]*HealthMovementDay, date string) *HealthMovementDay {
+	if d, ok := days[date]; ok {
+		return d
+	}
+	d := &HealthMovementDay{Date: date}
+	days[date] = d
+	return d
+}
+
+// aggregateHealth loads daily steps, workouts and heart rate from the health tables
+// Only days with health data get a row; movement is joined onto them afterwards.
+func (a *HealthCorrelationAnalyzer) aggregateHealth(ctx context.Context, days map[string]*HealthMovementDay) error {
+	// Steps of the source with the most steps, so phone and watch are not summed
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT date, MAX(total) FROM (
+			SELECT date, source_name, SUM(count) AS total
+			FROM health_steps
+			GROUP BY date, source_name
+		) GROUP BY date
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query steps: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var steps float64
+		if err := rows.Scan(&date, &steps); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan steps: %w", err)
+		}
+		healthDay(days, date).RecordedSteps = int64(steps)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating steps: %w", err)
+	}
+	rows.Close()
+
+	rows, err = a.DB.QueryContext(ctx, `
+		SELECT date, COUNT(*), SUM(duration_s), COALESCE(SUM(distance_m), 0)
+		FROM health_workouts
+		GROUP BY date
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query workouts: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var count int
+		var seconds int64
+		var distance float64
+		if err := rows.Scan(&date, &count, &seconds, &distance); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan workouts: %w", err)
+		}
+		d := healthDay(days, date)
+		d.WorkoutCount = count
+		d.WorkoutMinutes = float64(seconds) / 60
+		d.WorkoutDistance = distance
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating workouts: %w", err)
+	}
+	rows.Close()
+
+	rows, err = a.DB.QueryContext(ctx, "SELECT date, SUM(bpm), COUNT(*) FROM health_heart_rate GROUP BY date")
+	if err != nil {
+		return fmt.Errorf("failed to query heart rate: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date string
+		var sum float64
+		var count int64
+		if err := rows.Scan(&date, &sum, &count); err != nil {
+			return fmt.Errorf("failed to scan heart rate: %w", err)
+		}
+		d := healthDay(days, date)
+		d.HRSum, d.HRCount = sum, count
+	}
+
+	return rows.Err()
+}
+
+// aggregateMovement adds walked and total moved distance of the health days,
+// splitting segments that cross midnight proportionally to time
+func (a *HealthCorrelationAnalyzer) aggregateMovement(ctx context.Context, days map[string]*HealthMovementDay) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT mode, start_time, end_time, COALESCE(distance_m, 0)
+		FROM segments
+		WHERE mode != 'STAY'
+		ORDER BY start_time
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var mode string
+		var start, end int64
+		var distance float64
+		if err := rows.Scan(&mode, &start, &end, &distance); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		if end < start {
+			continue
+		}
+
+		duration := float64(end - start)
+		for cur := start; cur <= end; {
+			next := nextMidnight(cur)
+			sliceEnd := min(end, next)
+
+			if d, ok := days[time.Unix(cur, 0).Format("2006-01-02")]; ok {
+				share := 1.0
+				if duration > 0 {
+					share = float64(sliceEnd-cur) / duration
+				}
+				d.MovedDistance += distance * share
+				if mode == "WALK" {
+					d.WalkDistance += distance * share
+				}
+			}
+
+			if next > end {
+				break
+			}
+			cur = next
+		}
+	}
+
+	return rows.Err()
+}
+
+// heartRateSample is a heart rate measurement waiting for its track point
+type heartRateSample struct {
+	ts   int64
+	date string
+	bpm  float64
+}
+
+// matchHeartRateAltitude places each heart rate sample at the altitude of the
+// nearest track point within hrMatchWindowS and returns how many were placed
+// Points are streamed in time order and merged with the sorted samples, so
+// only the samples are held in memory.
+func (a *HealthCorrelationAnalyzer) matchHeartRateAltitude(ctx context.Context, days map[string]*HealthMovementDay) (int64, error) {
+	hrRows, err := a.DB.QueryContext(ctx, "SELECT time, date, bpm FROM health_heart_rate ORDER BY time")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query heart rate samples: %w", err)
+	}
+	var samples []heartRateSample
+	for hrRows.Next() {
+		var s heartRateSample
+		if err := hrRows.Scan(&s.ts, &s.date, &s.bpm); err != nil {
+			hrRows.Close()
+			return 0, fmt.Errorf("failed to scan heart rate sample: %w", err)
+		}
+		samples = append(samples, s)
+	}
+	if err := hrRows.Err(); err != nil {
+		hrRows.Close()
+		return 0, fmt.Errorf("error iterating heart rate samples: %w", err)
+	}
+	hrRows.Close()
+	if len(samples) == 0 {
+		return 0, nil
+	}
+
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, altitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND altitude IS NOT NULL
+			AND altitude > -500
+			AND altitude < 9000
+			AND (outlier_flag = 0 OR outlier_flag IS NULL)
+		ORDER BY dataTime
+	`, samples[0].ts-hrMatchWindowS, samples[len(samples)-1].ts+hrMatchWindowS)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	var matched int64
+	place := func(s heartRateSample, pointTS int64, altitude float64) {
+		gap := s.ts - pointTS
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > hrMatchWindowS {
+			return
+		}
+		d := healthDay(days, s.date)
+		if altitude >= highAltitudeM {
+			d.HighAltHRSum += s.bpm
+			d.HighAltHRCount++
+		} else {
+			d.LowAltHRSum += s.bpm
+			d.LowAltHRCount++
+		}
+		if !d.HasAltitudeMatch || altitude > d.MaxAltitude {
+			d.MaxAltitude = altitude
+			d.HasAltitudeMatch = true
+		}
+		matched++
+	}
+
+	i := 0
+	var prevTS int64
+	var prevAlt float64
+	hasPrev := false
+	for rows.Next() && i < len(samples) {
+		var ts int64
+		var altitude float64
+		if err := rows.Scan(&ts, &altitude); err != nil {
+			return matched, fmt.Errorf("failed to scan point: %w", err)
+		}
+
+		// Samples up to this point lie between the previous point and this one
+		for i < len(samples) && samples[i].ts <= ts {
+			if hasPrev && samples[i].ts-prevTS < ts-samples[i].ts {
+				place(samples[i], prevTS, prevAlt)
+			} else {
+				place(samples[i], ts, altitude)
+			}
+			i++
+		}
+		prevTS, prevAlt, hasPrev = ts, altitude, true
+	}
+	if err := rows.Err(); err != nil {
+		return matched, fmt.Errorf("error iterating points: %w", err)
+	}
+	for ; hasPrev && i < len(samples); i++ {
+		place(samples[i], prevTS, prevAlt)
+	}
+
+	return matched, nil
+}
+
+// replaceCorrelations rewrites the correlation table in one transaction
+func (a *HealthCorrelationAnalyzer) replaceCorrelations(ctx context.Context, days map[string]*HealthMovementDay) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM health_movement_correlation"); err != nil {
+		return fmt.Errorf("failed to clear health correlations: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO health_movement_correlation (
+			date, recorded_steps, walk_distance_m, moved_distance_m, expected_steps, step_ratio, meters_per_step,
+			workout_count, workout_minutes, workout_distance_m,
+			avg_heart_rate, high_altitude_hr_samples, high_altitude_avg_hr,
+			low_altitude_hr_samples, low_altitude_avg_hr, max_altitude_m,
+			algo_version, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("health_correlation")+`', ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	keys := make([]string, 0, len(days))
+	for k := range days {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	now := time.Now().Unix()
+	for _, k := range keys {
+		d := days[k]
+
+		expectedSteps := int64(d.WalkDistance / strideLengthM)
+		var stepRatio, metersPerStep, avgHR, highAvg, lowAvg, maxAlt interface{}
+		if expectedSteps > 0 && d.RecordedSteps > 0 {
+			stepRatio = roundTo(float64(d.RecordedSteps)/float64(expectedSteps), 3)
+			metersPerStep = roundTo(d.WalkDistance/float64(d.RecordedSteps), 3)
+		}
+		if d.HRCount > 0 {
+			avgHR = roundTo(d.HRSum/float64(d.HRCount), 1)
+		}
+		if d.HighAltHRCount > 0 {
+			highAvg = roundTo(d.HighAltHRSum/float64(d.HighAltHRCount), 1)
+		}
+		if d.LowAltHRCount > 0 {
+			lowAvg = roundTo(d.LowAltHRSum/float64(d.LowAltHRCount), 1)
+		}
+		if d.HasAltitudeMatch {
+			maxAlt = d.MaxAltitude
+		}
+
+		if _, err := stmt.ExecContext(ctx,
+			d.Date, d.RecordedSteps, roundTo(d.WalkDistance, 1), roundTo(d.MovedDistance, 1), expectedSteps,
+			stepRatio, metersPerStep,
+			d.WorkoutCount, roundTo(d.WorkoutMinutes, 1), roundTo(d.WorkoutDistance, 1),
+			avgHR, d.HighAltHRCount, highAvg, d.LowAltHRCount, lowAvg, maxAlt,
+			now,
+		); err != nil {
+			return fmt.Errorf("failed to insert correlation for %s: %w", d.Date, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("health_correlation", NewHealthCorrelationAnalyzer)
+	analysis.RegisterDependencies("health_correlation", "transport_mode")
+	analysis.RegisterOutputs("health_correlation", analysis.Output{Table: "health_movement_correlation", Replaced: true})
+}
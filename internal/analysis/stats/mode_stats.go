@@ -0,0 +1,197 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// ModeStatsAnalyzer implements per-mode rollups
+// Skill: 出行方式统计 (Mode Breakdown)
+// Totals distance, duration, segments and trips per transport mode by year and month
+type ModeStatsAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewModeStatsAnalyzer creates a new mode stats analyzer
+func NewModeStatsAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &ModeStatsAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "mode_stats", 10000),
+	}
+}
+
+// modeStatsKey identifies a row of mode_stats_bucketed
+type modeStatsKey struct {
+	BucketType string
+	BucketKey  string
+	Source     string
+	Mode       string
+}
+
+// modeStatsAgg accumulates the totals of a row
+type modeStatsAgg struct {
+	DistanceM    float64
+	DurationS    int64
+	SegmentCount int
+	Trips        map[int64]bool
+}
+
+// Analyze performs the per-mode rollup
+func (a *ModeStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[ModeStatsAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	factors, err := a.LoadEmissionFactors(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Each segment counts towards the trip whose time range contains its start
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			s.start_time,
+			s.mode,
+			COALESCE(s.source, ''),
+			COALESCE(s.distance_m, 0),
+			COALESCE(s.duration_s, 0),
+			(
+				SELECT t.id FROM trips t
+				WHERE t.start_time <= s.start_time AND t.end_time >= s.start_time
+				ORDER BY t.start_time DESC
+				LIMIT 1
+			)
+		FROM segments s
+		WHERE s.mode IS NOT NULL AND s.mode != 'STAY'
+		ORDER BY s.start_time
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	aggMap := make(map[modeStatsKey]*modeStatsAgg)
+	totalSegments := 0
+	for rows.Next() {
+		var startTime, durationS int64
+		var segMode, source string
+		var distanceM float64
+		var tripID sql.NullInt64
+		if err := rows.Scan(&startTime, &segMode, &source, &distanceM, &durationS, &tripID); err != nil {
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		totalSegments++
+
+		start := time.Unix(startTime, 0)
+		buckets := []struct {
+			bucketType string
+			bucketKey  string
+		}{
+			{"all", "all"},
+			{"year", start.Format("2006")},
+			{"month", start.Format("2006-01")},
+		}
+
+		for _, bt := range buckets {
+			for _, scope := range analysis.SourceScopes(source) {
+				key := modeStatsKey{BucketType: bt.bucketType, BucketKey: bt.bucketKey, Source: scope, Mode: segMode}
+				agg := aggMap[key]
+				if agg == nil {
+					agg = &modeStatsAgg{Trips: make(map[int64]bool)}
+					aggMap[key] = agg
+				}
+				agg.DistanceM += distanceM
+				agg.DurationS += durationS
+				agg.SegmentCount++
+				if tripID.Valid {
+					agg.Trips[tripID.Int64] = true
+				}
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	rows.Close()
+
+	if err := a.UpdateTaskProgress(taskID, int64(totalSegments), int64(totalSegments), 0); err != nil {
+		log.Printf("[ModeStatsAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	if err := a.insertModeStats(ctx, aggMap, factors); err != nil {
+		return fmt.Errorf("failed to insert mode stats: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_segments":   totalSegments,
+		"inserted_records": len(aggMap),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[ModeStatsAnalyzer] Analysis completed: %d segments, %d records", totalSegments, len(aggMap))
+	return nil
+}
+
+// insertModeStats upserts the aggregated rows in one transaction
+func (a *ModeStatsAnalyzer) insertModeStats(ctx context.Context, aggMap map[modeStatsKey]*modeStatsAgg, factors analysis.EmissionFactors) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO mode_stats_bucketed (
+			bucket_type, bucket_key, source, mode,
+			distance_m, duration_s, segment_count, trip_count, co2_kg,
+			algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("mode_stats")+`')
+		ON CONFLICT(bucket_type, bucket_key, source, mode)
+		DO UPDATE SET
+			distance_m = excluded.distance_m,
+			duration_s = excluded.duration_s,
+			segment_count = excluded.segment_count,
+			trip_count = excluded.trip_count,
+			co2_kg = excluded.co2_kg,
+			created_at = CAST(strftime('%s', 'now') AS INTEGER)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, agg := range aggMap {
+		co2Kg := factors.CO2Kg(key.Mode, agg.DistanceM)
+		if _, err := stmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, key.Source, key.Mode,
+			agg.DistanceM, agg.DurationS, agg.SegmentCount, len(agg.Trips), co2Kg,
+		); err != nil {
+			return fmt.Errorf("failed to insert mode stats: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("mode_stats", NewModeStatsAnalyzer)
+	analysis.RegisterDependencies("mode_stats", "transport_mode", "trip_construction")
+	analysis.RegisterOutputs("mode_stats", analysis.Output{Table: "mode_stats_bucketed"})
+}
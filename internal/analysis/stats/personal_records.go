@@ -0,0 +1,309 @@
+package stats
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// Personal record types
+const (
+	RecordLongestTrackingStreak = "LONGEST_TRACKING_STREAK"
+	RecordLongestDayDistance    = "LONGEST_DAY_DISTANCE"
+	RecordMostCitiesInDay       = "MOST_CITIES_IN_DAY"
+	RecordLongestStay           = "LONGEST_STAY"
+	RecordFastestGroundSegment  = "FASTEST_GROUND_SEGMENT"
+	RecordLongestWalk           = "LONGEST_WALK"
+	RecordLongestTrip           = "LONGEST_TRIP"
+)
+
+// minRecordSegmentS is the shortest segment that can hold the speed record,
+// so a burst of GPS noise does not beat a real train ride
+const minRecordSegmentS = 300
+
+// PersonalRecordsAnalyzer implements personal records
+// Skill: 个人纪录 (Personal Records)
+// Finds the longest run of tracked days, the longest day, the most cities in a
+// day, the longest stay, the fastest ground segment, the longest walk and the
+// longest trip. Day records come from daily_summaries, so run it after
+// daily_summary, stay_detection and trip_construction.
+type PersonalRecordsAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewPersonalRecordsAnalyzer creates a new personal records analyzer
+func NewPersonalRecordsAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &PersonalRecordsAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "personal_records", 1000),
+	}
+}
+
+// personalRecord is one row of personal_records
+type personalRecord struct {
+	RecordType string
+	Value      float64
+	Unit       string
+	StartTime  sql.NullInt64
+	EndTime    sql.NullInt64
+	Date       sql.NullString
+	EndDate    sql.NullString
+	PointID    sql.NullInt64
+	SegmentID  sql.NullInt64
+	TripID     sql.NullInt64
+	StayID     sql.NullInt64
+	Latitude   sql.NullFloat64
+	Longitude  sql.NullFloat64
+	Details    sql.NullString
+}
+
+// recordQueries select the single row holding each record, ties going to the
+// earliest. Columns: value, start_time, end_time, date, point_id, segment_id,
+// trip_id, stay_id, latitude, longitude, details.
+var recordQueries = []struct {
+	RecordType string
+	Unit       string
+	Query      string
+}{
+	{RecordLongestDayDistance, "m", `
+		SELECT d.total_distance_m, NULL, NULL, d.date, NULL, NULL,
+			(SELECT t.id FROM trips t WHERE t.date = d.date ORDER BY t.distance_m DESC LIMIT 1),
+			NULL, NULL, NULL, json_object('primary_mode', d.primary_mode)
+		FROM daily_summaries d
+		WHERE d.total_distance_m > 0
+		ORDER BY d.total_distance_m DESC, d.date
+		LIMIT 1`},
+	{RecordMostCitiesInDay, "cities", `
+		SELECT d.city_count, NULL, NULL, d.date, NULL, NULL, NULL, NULL, NULL, NULL,
+			json_object('cities', json(COALESCE(d.cities, '[]')))
+		FROM daily_summaries d
+		WHERE d.city_count > 0
+		ORDER BY d.city_count DESC, d.date
+		LIMIT 1`},
+	{RecordLongestStay, "s", `
+		SELECT s.duration_s, s.start_time, s.end_time, NULL, NULL, NULL, NULL, s.id, s.center_lat, s.center_lon,
+			json_object('province', s.province, 'city', s.city, 'county', s.county)
+		FROM stay_segments s
+		WHERE s.stay_type = 'SPATIAL'
+		ORDER BY s.duration_s DESC, s.start_time
+		LIMIT 1`},
+	{RecordFastestGroundSegment, "km/h", fmt.Sprintf(`
+		SELECT s.avg_speed_kmh, s.start_time, s.end_time, NULL, s.start_point_id, s.id,
+			(SELECT t.id FROM trips t WHERE t.start_time <= s.start_time AND t.end_time >= s.start_time
+				ORDER BY t.start_time DESC LIMIT 1),
+			NULL, p.latitude, p.longitude,
+			json_object('mode', s.mode, 'distance_m', s.distance_m, 'max_speed_kmh', s.max_speed_kmh)
+		FROM segments s
+		LEFT JOIN "一生足迹" p ON p.id = s.start_point_id
+		WHERE s.mode NOT IN ('FLIGHT', 'PLANE', 'STAY', 'UNKNOWN') AND s.duration_s >= %d
+		ORDER BY s.avg_speed_kmh DESC, s.start_time
+		LIMIT 1`, minRecordSegmentS)},
+	{RecordLongestWalk, "m", `
+		SELECT s.distance_m, s.start_time, s.end_time, NULL, s.start_point_id, s.id,
+			(SELECT t.id FROM trips t WHERE t.start_time <= s.start_time AND t.end_time >= s.start_time
+				ORDER BY t.start_time DESC LIMIT 1),
+			NULL, p.latitude, p.longitude,
+			json_object('duration_s', s.duration_s)
+		FROM segments s
+		LEFT JOIN "一生足迹" p ON p.id = s.start_point_id
+		WHERE s.mode = 'WALK' AND s.distance_m > 0
+		ORDER BY s.distance_m DESC, s.start_time
+		LIMIT 1`},
+	{RecordLongestTrip, "m", `
+		SELECT t.distance_m, t.start_time, t.end_time, NULL, NULL, NULL, t.id, t.origin_stay_id,
+			o.center_lat, o.center_lon,
+			json_object('duration_s', t.duration_s, 'modes', json(COALESCE(t.modes, '[]')))
+		FROM trips t
+		LEFT JOIN stay_segments o ON o.id = t.origin_stay_id
+		WHERE t.distance_m > 0
+		ORDER BY t.distance_m DESC, t.start_time
+		LIMIT 1`},
+}
+
+// Analyze performs the personal records analysis
+func (a *PersonalRecordsAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[PersonalRecordsAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	var records []personalRecord
+	streak, err := a.longestTrackingStreak(ctx)
+	if err != nil {
+		return err
+	}
+	if streak != nil {
+		records = append(records, *streak)
+	}
+
+	for _, rq := range recordQueries {
+		r := personalRecord{RecordType: rq.RecordType, Unit: rq.Unit}
+		err := a.DB.QueryRowContext(ctx, rq.Query).Scan(
+			&r.Value, &r.StartTime, &r.EndTime, &r.Date, &r.PointID, &r.SegmentID,
+			&r.TripID, &r.StayID, &r.Latitude, &r.Longitude, &r.Details,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query %s: %w", rq.RecordType, err)
+		}
+		if err := r.fillTimes(); err != nil {
+			return err
+		}
+		records = append(records, r)
+	}
+
+	if err := a.UpdateTaskProgress(taskID, int64(len(recordQueries)+1), int64(len(recordQueries)+1), 0); err != nil {
+		log.Printf("[PersonalRecordsAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	// Records can move to a different row or disappear, so they are replaced whatever the mode
+	if err := a.insertRecords(ctx, records); err != nil {
+		return fmt.Errorf("failed to insert personal records: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"records": len(records),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[PersonalRecordsAnalyzer] Analysis completed: %d records", len(records))
+	return nil
+}
+
+// fillTimes derives the local date of timed records and the time span of day records
+func (r *personalRecord) fillTimes() error {
+	if r.Date.Valid {
+		day, err := time.ParseInLocation("2006-01-02", r.Date.String, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid date %q of %s: %w", r.Date.String, r.RecordType, err)
+		}
+		end := day.AddDate(0, 0, 1)
+		if r.EndDate.Valid {
+			if end, err = time.ParseInLocation("2006-01-02", r.EndDate.String, time.Local); err != nil {
+				return fmt.Errorf("invalid end date %q of %s: %w", r.EndDate.String, r.RecordType, err)
+			}
+			end = end.AddDate(0, 0, 1)
+		}
+		r.StartTime = sql.NullInt64{Int64: day.Unix(), Valid: true}
+		r.EndTime = sql.NullInt64{Int64: end.Unix() - 1, Valid: true}
+		return nil
+	}
+	if r.StartTime.Valid {
+		r.Date = sql.NullString{String: time.Unix(r.StartTime.Int64, 0).Format("2006-01-02"), Valid: true}
+	}
+	if r.EndTime.Valid {
+		if end := time.Unix(r.EndTime.Int64, 0).Format("2006-01-02"); end != r.Date.String {
+			r.EndDate = sql.NullString{String: end, Valid: true}
+		}
+	}
+	return nil
+}
+
+// longestTrackingStreak finds the longest run of consecutive days with points
+func (a *PersonalRecordsAnalyzer) longestTrackingStreak(ctx context.Context) (*personalRecord, error) {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT date FROM daily_summaries WHERE point_count > 0 ORDER BY date
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var bestStart, bestEnd, runStart string
+	var best, run int
+	var prev time.Time
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("failed to scan daily summary: %w", err)
+		}
+		day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid summarized day %q: %w", date, err)
+		}
+		if run > 0 && day.Equal(prev.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run, runStart = 1, date
+		}
+		if run > best {
+			best, bestStart, bestEnd = run, runStart, date
+		}
+		prev = day
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+	if best == 0 {
+		return nil, nil
+	}
+
+	r := &personalRecord{
+		RecordType: RecordLongestTrackingStreak,
+		Value:      float64(best),
+		Unit:       "days",
+		Date:       sql.NullString{String: bestStart, Valid: true},
+		EndDate:    sql.NullString{String: bestEnd, Valid: true},
+	}
+	return r, r.fillTimes()
+}
+
+// insertRecords replaces the stored records in one transaction
+func (a *PersonalRecordsAnalyzer) insertRecords(ctx context.Context, records []personalRecord) error {
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM personal_records"); err != nil {
+		return fmt.Errorf("failed to clear personal_records: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO personal_records (
+			record_type, value, unit, start_time, end_time, date, end_date,
+			point_id, segment_id, trip_id, stay_id, latitude, longitude, details,
+			algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("personal_records")+`')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range records {
+		if _, err := stmt.ExecContext(ctx,
+			r.RecordType, r.Value, r.Unit, r.StartTime, r.EndTime, r.Date, r.EndDate,
+			r.PointID, r.SegmentID, r.TripID, r.StayID, r.Latitude, r.Longitude, r.Details,
+		); err != nil {
+			return fmt.Errorf("failed to insert %s: %w", r.RecordType, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("personal_records", NewPersonalRecordsAnalyzer)
+	analysis.RegisterDependencies("personal_records", "daily_summary", "trip_construction", "transport_mode")
+	analysis.RegisterOutputs("personal_records", analysis.Output{Table: "personal_records", Replaced: true})
+}
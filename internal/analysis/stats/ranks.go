@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+)
+
+// statRank is a rank column of a statistics table
+type statRank struct {
+	column     string // e.g. rank_by_points
+	prevColumn string // Rank of the same key in the previous period
+	orderBy    string // Ranking order, e.g. point_count DESC
+}
+
+var footprintRanks = []statRank{
+	{"rank_by_points", "prev_rank_by_points", "point_count DESC"},
+	{"rank_by_visits", "prev_rank_by_visits", "visit_count DESC"},
+	{"rank_by_duration", "prev_rank_by_duration", "total_duration_s DESC"},
+}
+
+var stayRanks = []statRank{
+	{"rank_by_count", "prev_rank_by_count", "stay_count DESC"},
+	{"rank_by_duration", "prev_rank_by_duration", "total_duration_s DESC"},
+}
+
+// nextPeriodExpr is the time_range of the period after a row's time_range
+// (the next year, month or day); NULL for "all"
+const nextPeriodExpr = `CASE length(time_range)
+	WHEN 4 THEN printf('%04d', CAST(time_range AS INTEGER) + 1)
+	WHEN 7 THEN strftime('%Y-%m', time_range || '-01', '+1 month')
+	WHEN 10 THEN date(time_range, '+1 day')
+END`
+
+// rankStatistics ranks every row of a statistics table within its stat_type
+// and time_range, ties sharing a rank, and copies each key's ranks of the
+// previous period into the prev_ columns, in one transaction
+// It runs over the whole table after aggregation, so ranks are consistent
+// however the rows were written.
+func rankStatistics(ctx context.Context, a *analysis.IncrementalAnalyzer, table string, ranks []statRank) error {
+	var windows, setRanks, clearPrev, setPrev, prevCols []string
+	for _, r := range ranks {
+		windows = append(windows, fmt.Sprintf("RANK() OVER (PARTITION BY stat_type, time_range ORDER BY %s) AS %s", r.orderBy, r.column))
+		setRanks = append(setRanks, fmt.Sprintf("%s = r.%s", r.column, r.column))
+		clearPrev = append(clearPrev, r.prevColumn+" = NULL")
+		setPrev = append(setPrev, fmt.Sprintf("%s = p.%s", r.prevColumn, r.column))
+		prevCols = append(prevCols, r.column)
+	}
+
+	queries := []string{
+		fmt.Sprintf(`UPDATE %s AS s SET %s
+			FROM (SELECT id, %s FROM %s) r
+			WHERE s.id = r.id`, table, strings.Join(setRanks, ", "), strings.Join(windows, ", "), table),
+		fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(clearPrev, ", ")),
+		fmt.Sprintf(`UPDATE %s AS s SET %s
+			FROM (SELECT stat_type, stat_key, %s AS next_range, %s FROM %s) p
+			WHERE s.stat_type = p.stat_type AND s.stat_key = p.stat_key AND s.time_range = p.next_range`,
+			table, strings.Join(setPrev, ", "), nextPeriodExpr, strings.Join(prevCols, ", "), table),
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to rank %s: %w", table, err)
+		}
+	}
+	return tx.Commit()
+}
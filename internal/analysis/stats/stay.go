@@ -12,7 +12,8 @@ import (
 
 // StayAnalyzer implements stay statistics aggregation
 // Skill: 停留层统计与排行 (Stay Analytics)
-// Aggregates stay segments by administrative areas, time ranges, and stay types
+// Aggregates stay segments by administrative areas, time ranges, and stay types,
+// then ranks the statistics
 type StayAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -180,6 +181,10 @@ func (a *StayAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) e
 		}
 	}
 
+	if err := rankStatistics(ctx, a.IncrementalAnalyzer, "stay_statistics", stayRanks); err != nil {
+		return err
+	}
+
 	// Mark task as completed
 	summary := map[string]interface{}{
 		"total_stays":      totalStays,
@@ -237,7 +242,7 @@ func (a *StayAnalyzer) aggregateStay(stats map[string]*StayStat, statType, statK
 
 // upsertStatistics inserts or updates statistics in the database
 func (a *StayAnalyzer) upsertStatistics(ctx context.Context, stats map[string]*StayStat) error {
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -0,0 +1,320 @@
+package temporal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+	_ "time/tzdata" // Named timezones resolve on hosts without a zoneinfo database
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Parts of the day, in daily order
+const (
+	DaypartMorning   = "MORNING"
+	DaypartAfternoon = "AFTERNOON"
+	DaypartEvening   = "EVENING"
+	DaypartNight     = "NIGHT"
+)
+
+// DaypartThresholds defines configurable parameters for daypart statistics
+// Loaded from the "daypart_stats" section of the active threshold profile
+type DaypartThresholds struct {
+	MorningStartHour   int     `json:"morning_start_hour"`   // 5: night ends
+	AfternoonStartHour int     `json:"afternoon_start_hour"` // 12
+	EveningStartHour   int     `json:"evening_start_hour"`   // 18
+	NightStartHour     int     `json:"night_start_hour"`     // 22: night runs until the next morning
+	Timezone           string  `json:"timezone"`             // IANA zone, e.g. Asia/Shanghai; empty for the server's zone
+	MaxGapS            int64   `json:"max_gap_s"`            // 300 s: longer gaps between points count as untracked
+	ActiveSpeedMPS     float64 `json:"active_speed_mps"`     // 0.5 m/s: slower intervals are not active time
+}
+
+// DefaultDaypartThresholds provides default daypart parameters
+var DefaultDaypartThresholds = DaypartThresholds{
+	MorningStartHour:   5,
+	AfternoonStartHour: 12,
+	EveningStartHour:   18,
+	NightStartHour:     22,
+	MaxGapS:            300,
+	ActiveSpeedMPS:     0.5,
+}
+
+// validate checks that the boundaries are increasing hours of one day
+func (t DaypartThresholds) validate() error {
+	if t.MorningStartHour < 0 || t.MorningStartHour >= t.AfternoonStartHour ||
+		t.AfternoonStartHour >= t.EveningStartHour || t.EveningStartHour >= t.NightStartHour ||
+		t.NightStartHour > 24 {
+		return fmt.Errorf("daypart boundaries must increase within 0-24, got %d/%d/%d/%d",
+			t.MorningStartHour, t.AfternoonStartHour, t.EveningStartHour, t.NightStartHour)
+	}
+	return nil
+}
+
+// location returns the timezone the day is split in
+func (t DaypartThresholds) location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", t.Timezone, err)
+	}
+	return loc, nil
+}
+
+// daypartOf returns the part of the day of local time t and the day it belongs to
+// The small hours belong to the night of the previous day, so a night out on
+// 31 December counts towards December and that year.
+func daypartOf(t time.Time, th DaypartThresholds) (string, time.Time) {
+	switch h := t.Hour(); {
+	case h < th.MorningStartHour:
+		return DaypartNight, t.AddDate(0, 0, -1)
+	case h < th.AfternoonStartHour:
+		return DaypartMorning, t
+	case h < th.EveningStartHour:
+		return DaypartAfternoon, t
+	case h < th.NightStartHour:
+		return DaypartEvening, t
+	default:
+		return DaypartNight, t
+	}
+}
+
+// DaypartStatsAnalyzer implements movement statistics by part of the day
+// Skill: 昼夜分布 (Daypart Statistics)
+// Splits distance, active time and visited areas into morning, afternoon,
+// evening and night per year and month, for chronotype-style comparisons
+type DaypartStatsAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+	Thresholds DaypartThresholds
+}
+
+// NewDaypartStatsAnalyzer creates a new daypart statistics analyzer
+func NewDaypartStatsAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &DaypartStatsAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "daypart_stats", 10000),
+		Thresholds:          DefaultDaypartThresholds,
+	}
+}
+
+// daypartKey identifies a row of daypart_stats_bucketed
+type daypartKey struct {
+	BucketType string
+	BucketKey  string
+	Source     string
+	Daypart    string
+}
+
+// daypartAgg accumulates the totals of a row
+type daypartAgg struct {
+	DistanceM  float64
+	ActiveS    int64
+	TrackedS   int64
+	PointCount int
+	Days       map[string]bool
+	Grids      map[string]bool
+	Cities     map[string]bool
+}
+
+func newDaypartAgg() *daypartAgg {
+	return &daypartAgg{Days: make(map[string]bool), Grids: make(map[string]bool), Cities: make(map[string]bool)}
+}
+
+// Analyze performs the daypart statistics
+func (a *DaypartStatsAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[DaypartStatsAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	// Load thresholds from the active threshold profile
+	a.Thresholds = DefaultDaypartThresholds
+	if err := a.LoadThresholds(ctx, taskID, &a.Thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	if err := a.Thresholds.validate(); err != nil {
+		return err
+	}
+	loc, err := a.Thresholds.location()
+	if err != nil {
+		return err
+	}
+
+	// Points of different sources are interleaved in time, so intervals are
+	// only measured between consecutive points of one source
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude, COALESCE(source, ''), COALESCE(grid_id, ''), COALESCE(city, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY source, dataTime, id
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	aggMap := make(map[daypartKey]*daypartAgg)
+	totalPoints := 0
+	var prevTime int64
+	var prevLat, prevLon float64
+	var prevSource string
+	var prevKeys []daypartKey
+	for rows.Next() {
+		var timestamp int64
+		var lat, lon float64
+		var source, gridID, city string
+		if err := rows.Scan(&timestamp, &lat, &lon, &source, &gridID, &city); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		totalPoints++
+
+		// The interval from the previous point counts towards the part of the day it started in
+		if totalPoints > 1 && source == prevSource {
+			if dt := timestamp - prevTime; dt > 0 && dt <= a.Thresholds.MaxGapS {
+				distance := spatial.HaversineDistance(prevLat, prevLon, lat, lon)
+				for _, key := range prevKeys {
+					agg := aggMap[key]
+					agg.DistanceM += distance
+					agg.TrackedS += dt
+					if distance/float64(dt) >= a.Thresholds.ActiveSpeedMPS {
+						agg.ActiveS += dt
+					}
+				}
+			}
+		}
+
+		daypart, day := daypartOf(time.Unix(timestamp, 0).In(loc), a.Thresholds)
+		prevKeys = prevKeys[:0]
+		for _, bucket := range [][2]string{
+			{"all", "all"},
+			{"year", day.Format("2006")},
+			{"month", day.Format("2006-01")},
+		} {
+			for _, scope := range analysis.SourceScopes(source) {
+				key := daypartKey{BucketType: bucket[0], BucketKey: bucket[1], Source: scope, Daypart: daypart}
+				agg := aggMap[key]
+				if agg == nil {
+					agg = newDaypartAgg()
+					aggMap[key] = agg
+				}
+				agg.PointCount++
+				agg.Days[day.Format("2006-01-02")] = true
+				if gridID != "" {
+					agg.Grids[gridID] = true
+				}
+				if city != "" {
+					agg.Cities[city] = true
+				}
+				prevKeys = append(prevKeys, key)
+			}
+		}
+		prevTime, prevLat, prevLon, prevSource = timestamp, lat, lon, source
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	rows.Close()
+
+	if err := a.UpdateTaskProgress(taskID, int64(totalPoints), int64(totalPoints), 0); err != nil {
+		log.Printf("[DaypartStatsAnalyzer] Warning: failed to update progress: %v", err)
+	}
+
+	if err := a.insertDaypartStats(ctx, aggMap); err != nil {
+		return fmt.Errorf("failed to insert daypart stats: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_points":     totalPoints,
+		"timezone":         loc.String(),
+		"inserted_records": len(aggMap),
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[DaypartStatsAnalyzer] Analysis completed: %d points, %d records", totalPoints, len(aggMap))
+	return nil
+}
+
+// insertDaypartStats upserts the aggregated rows, with each part's share of
+// its bucket's distance and active time, in one transaction
+func (a *DaypartStatsAnalyzer) insertDaypartStats(ctx context.Context, aggMap map[daypartKey]*daypartAgg) error {
+	type bucketKey struct{ BucketType, BucketKey, Source string }
+	distanceTotals := make(map[bucketKey]float64)
+	activeTotals := make(map[bucketKey]int64)
+	for key, agg := range aggMap {
+		bk := bucketKey{key.BucketType, key.BucketKey, key.Source}
+		distanceTotals[bk] += agg.DistanceM
+		activeTotals[bk] += agg.ActiveS
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO daypart_stats_bucketed (
+			bucket_type, bucket_key, source, daypart,
+			distance_m, active_s, tracked_s, point_count, day_count, grid_count, city_count,
+			distance_share, active_share, algo_version
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '`+analysis.Version("daypart_stats")+`')
+		ON CONFLICT(bucket_type, bucket_key, source, daypart)
+		DO UPDATE SET
+			distance_m = excluded.distance_m,
+			active_s = excluded.active_s,
+			tracked_s = excluded.tracked_s,
+			point_count = excluded.point_count,
+			day_count = excluded.day_count,
+			grid_count = excluded.grid_count,
+			city_count = excluded.city_count,
+			distance_share = excluded.distance_share,
+			active_share = excluded.active_share,
+			created_at = CAST(strftime('%s', 'now') AS INTEGER)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, agg := range aggMap {
+		bk := bucketKey{key.BucketType, key.BucketKey, key.Source}
+		var distanceShare, activeShare float64
+		if total := distanceTotals[bk]; total > 0 {
+			distanceShare = agg.DistanceM / total
+		}
+		if total := activeTotals[bk]; total > 0 {
+			activeShare = float64(agg.ActiveS) / float64(total)
+		}
+		if _, err := stmt.ExecContext(ctx,
+			key.BucketType, key.BucketKey, key.Source, key.Daypart,
+			agg.DistanceM, agg.ActiveS, agg.TrackedS, agg.PointCount,
+			len(agg.Days), len(agg.Grids), len(agg.Cities),
+			distanceShare, activeShare,
+		); err != nil {
+			return fmt.Errorf("failed to insert daypart stats: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("daypart_stats", NewDaypartStatsAnalyzer)
+	analysis.RegisterDependencies("daypart_stats", "outlier_detection")
+	analysis.RegisterOutputs("daypart_stats", analysis.Output{Table: "daypart_stats_bucketed"})
+}
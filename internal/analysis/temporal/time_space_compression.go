@@ -34,14 +34,6 @@ func (a *TimeSpaceCompressionAnalyzer) Analyze(ctx context.Context, taskID int64
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing compressed trajectories (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM compressed_trajectories"); err != nil {
-			return fmt.Errorf("failed to clear compressed_trajectories: %w", err)
-		}
-		log.Printf("[TimeSpaceCompressionAnalyzer] Cleared existing compressed trajectories")
-	}
-
 	// Load trajectory points
 	points, err := a.loadTrajectoryPoints(ctx)
 	if err != nil {
@@ -226,7 +218,7 @@ func (a *TimeSpaceCompressionAnalyzer) insertCompressedTrajectories(ctx context.
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -238,7 +230,7 @@ func (a *TimeSpaceCompressionAnalyzer) insertCompressedTrajectories(ctx context.
 			original_point_count, compressed_point_count, compression_ratio,
 			points_json, start_ts, end_ts,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("time_space_compression") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -269,4 +261,6 @@ func (a *TimeSpaceCompressionAnalyzer) insertCompressedTrajectories(ctx context.
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("time_space_compression", NewTimeSpaceCompressionAnalyzer)
+	analysis.RegisterDependencies("time_space_compression", "outlier_detection")
+	analysis.RegisterOutputs("time_space_compression", analysis.Output{Table: "compressed_trajectories"})
 }
\ No newline at end of file
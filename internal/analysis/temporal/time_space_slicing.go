@@ -6,13 +6,60 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
+// Slice types of time_space_slices
+const (
+	SliceHourly       = "HOURLY"        // Hour of the day, "00"-"23"
+	SliceWeeklyHourly = "WEEKLY_HOURLY" // Weekday and hour, "0-00" (Sunday) to "6-23"
+	SliceDaily        = "DAILY"         // Calendar day, "2024-03-01"
+	SliceMonthly      = "MONTHLY"       // Calendar month, "2024-03"
+)
+
+// TimeSpaceSlicingThresholds defines configurable parameters for time-space slicing
+// Loaded from the "time_space_slicing" section of the active threshold profile
+type TimeSpaceSlicingThresholds struct {
+	Timezone         string `json:"timezone"`          // IANA zone, e.g. Asia/Shanghai; empty for the server's zone
+	MaxGapS          int64  `json:"max_gap_s"`         // 300 s: longer gaps between points count as untracked
+	GeohashPrecision int    `json:"geohash_precision"` // 6: unique locations are counted in geohash cells of this precision
+}
+
+// DefaultTimeSpaceSlicingThresholds provides default time-space slicing parameters
+var DefaultTimeSpaceSlicingThresholds = TimeSpaceSlicingThresholds{
+	MaxGapS:          300,
+	GeohashPrecision: 6,
+}
+
+// location returns the timezone the slices are cut in
+func (t TimeSpaceSlicingThresholds) location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", t.Timezone, err)
+	}
+	return loc, nil
+}
+
+// sliceKeys returns the slice of every type local time t falls in
+func sliceKeys(t time.Time) [4][2]string {
+	return [4][2]string{
+		{SliceHourly, fmt.Sprintf("%02d", t.Hour())},
+		{SliceWeeklyHourly, fmt.Sprintf("%d-%02d", t.Weekday(), t.Hour())},
+		{SliceDaily, t.Format("2006-01-02")},
+		{SliceMonthly, t.Format("2006-01")},
+	}
+}
+
 // TimeSpaceSlicingAnalyzer implements time-space slicing analysis
 // Skill: 时空切片 (Time-Space Slicing)
-// Slices trajectory by time and space dimensions for aggregation
+// Slices trajectory by hour of the day, hour of the week, day and month, with
+// the points, distance, tracked time and unique locations of every slice
 type TimeSpaceSlicingAnalyzer struct {
 	*analysis.IncrementalAnalyzer
 }
@@ -33,38 +80,48 @@ func (a *TimeSpaceSlicingAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing slices (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM time_space_slices"); err != nil {
-			return fmt.Errorf("failed to clear time_space_slices: %w", err)
-		}
-		log.Printf("[TimeSpaceSlicingAnalyzer] Cleared existing time-space slices")
+	// Load thresholds from the active threshold profile
+	thresholds := DefaultTimeSpaceSlicingThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
 	}
-
-	var allSlices []TimeSpaceSlice
-
-	// 1. Hourly slices (24 slices)
-	hourlySlices, err := a.computeHourlySlices(ctx)
+	loc, err := thresholds.location()
 	if err != nil {
-		return fmt.Errorf("failed to compute hourly slices: %w", err)
+		return err
 	}
-	allSlices = append(allSlices, hourlySlices...)
 
-	// 2. Daily slices
-	dailySlices, err := a.computeDailySlices(ctx)
+	// Points of different sources are interleaved in time, so intervals are
+	// only measured between consecutive points of one source
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT dataTime, latitude, longitude, COALESCE(source, '')
+		FROM "一生足迹"
+		WHERE outlier_flag = 0
+		ORDER BY source, dataTime, id
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to compute daily slices: %w", err)
+		return fmt.Errorf("failed to query points: %w", err)
 	}
-	allSlices = append(allSlices, dailySlices...)
+	defer rows.Close()
 
-	// 3. Weekly-hourly slices (168 slices = 7 days × 24 hours)
-	weeklyHourlySlices, err := a.computeWeeklyHourlySlices(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to compute weekly-hourly slices: %w", err)
+	slicer := newTimeSpaceSlicer(thresholds)
+	totalPoints := 0
+	for rows.Next() {
+		var timestamp int64
+		var lat, lon float64
+		var source string
+		if err := rows.Scan(&timestamp, &lat, &lon, &source); err != nil {
+			return fmt.Errorf("failed to scan point: %w", err)
+		}
+		totalPoints++
+		slicer.add(source, time.Unix(timestamp, 0).In(loc), lat, lon)
 	}
-	allSlices = append(allSlices, weeklyHourlySlices...)
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	rows.Close()
 
-	log.Printf("[TimeSpaceSlicingAnalyzer] Generated %d slices", len(allSlices))
+	allSlices := slicer.slices()
+	log.Printf("[TimeSpaceSlicingAnalyzer] Generated %d slices from %d points", len(allSlices), totalPoints)
 
 	// Insert slices
 	if err := a.insertTimeSpaceSlices(ctx, allSlices); err != nil {
@@ -72,11 +129,18 @@ func (a *TimeSpaceSlicingAnalyzer) Analyze(ctx context.Context, taskID int64, mo
 	}
 
 	// Mark task as completed
+	counts := make(map[string]int)
+	for _, slice := range allSlices {
+		counts[slice.SliceType]++
+	}
 	summary := map[string]interface{}{
+		"total_points":         totalPoints,
+		"timezone":             loc.String(),
 		"total_slices":         len(allSlices),
-		"hourly_slices":        len(hourlySlices),
-		"daily_slices":         len(dailySlices),
-		"weekly_hourly_slices": len(weeklyHourlySlices),
+		"hourly_slices":        counts[SliceHourly],
+		"weekly_hourly_slices": counts[SliceWeeklyHourly],
+		"daily_slices":         counts[SliceDaily],
+		"monthly_slices":       counts[SliceMonthly],
 	}
 	summaryJSON, _ := json.Marshal(summary)
 
@@ -101,174 +165,99 @@ type TimeSpaceSlice struct {
 	UniqueLocations int64
 }
 
-// computeHourlySlices computes hourly time slices
-func (a *TimeSpaceSlicingAnalyzer) computeHourlySlices(ctx context.Context) ([]TimeSpaceSlice, error) {
-	query := `
-		SELECT
-			CAST(strftime('%H', datetime(dataTime, 'unixepoch')) AS INTEGER) as hour,
-			COUNT(*) as point_count,
-			SUM(CASE WHEN distance IS NOT NULL THEN distance ELSE 0 END) as total_distance,
-			COUNT(DISTINCT grid_id) as unique_locations
-		FROM "一生足迹"
-		WHERE outlier_flag = 0
-		GROUP BY hour
-		ORDER BY hour
-	`
+// timeSpaceSlicer accumulates points, ordered by source and time, into slices
+type timeSpaceSlicer struct {
+	th        TimeSpaceSlicingThresholds
+	slicesMap map[[2]string]*TimeSpaceSlice
+	cells     map[[2]string]map[string]bool
+
+	prevSource string
+	prevTime   time.Time
+	prevLat    float64
+	prevLon    float64
+	prevKeys   [4][2]string
+	started    bool
+}
 
-	rows, err := a.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query hourly slices: %w", err)
+func newTimeSpaceSlicer(th TimeSpaceSlicingThresholds) *timeSpaceSlicer {
+	s := &timeSpaceSlicer{
+		th:        th,
+		slicesMap: make(map[[2]string]*TimeSpaceSlice),
+		cells:     make(map[[2]string]map[string]bool),
 	}
-	defer rows.Close()
-
-	var slices []TimeSpaceSlice
-	for rows.Next() {
-		var slice TimeSpaceSlice
-		var hour int
-		var distance sql.NullFloat64
-
-		if err := rows.Scan(&hour, &slice.PointCount, &distance, &slice.UniqueLocations); err != nil {
-			return nil, fmt.Errorf("failed to scan hourly slice: %w", err)
-		}
-
-		if distance.Valid {
-			slice.Distance = distance.Float64
+	// Hour-of-day and hour-of-week patterns are complete even where nothing was tracked
+	for day := 0; day < 7; day++ {
+		for hour := 0; hour < 24; hour++ {
+			s.slice([2]string{SliceWeeklyHourly, fmt.Sprintf("%d-%02d", day, hour)})
+			s.slice([2]string{SliceHourly, fmt.Sprintf("%02d", hour)})
 		}
-
-		slice.SliceType = "HOURLY"
-		slice.SliceKey = fmt.Sprintf("%02d", hour)
-		slice.Duration = slice.PointCount * 10 // Approximate
-
-		slices = append(slices, slice)
 	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
-	}
-
-	return slices, nil
+	return s
 }
 
-// computeWeeklyHourlySlices computes weekly-hourly time slices (168 slices = 7 days × 24 hours)
-func (a *TimeSpaceSlicingAnalyzer) computeWeeklyHourlySlices(ctx context.Context) ([]TimeSpaceSlice, error) {
-	query := `
-		SELECT
-			CAST(strftime('%w', datetime(dataTime, 'unixepoch')) AS INTEGER) as day_of_week,
-			CAST(strftime('%H', datetime(dataTime, 'unixepoch')) AS INTEGER) as hour,
-			COUNT(*) as point_count,
-			SUM(CASE WHEN distance IS NOT NULL THEN distance ELSE 0 END) as total_distance,
-			COUNT(DISTINCT grid_id) as unique_locations
-		FROM "一生足迹"
-		WHERE outlier_flag = 0
-		GROUP BY day_of_week, hour
-		ORDER BY day_of_week, hour
-	`
-
-	rows, err := a.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query weekly-hourly slices: %w", err)
+// slice returns the slice of a type and key, creating it when missing
+func (s *timeSpaceSlicer) slice(key [2]string) *TimeSpaceSlice {
+	slice, ok := s.slicesMap[key]
+	if !ok {
+		slice = &TimeSpaceSlice{SliceType: key[0], SliceKey: key[1]}
+		s.slicesMap[key] = slice
+		s.cells[key] = make(map[string]bool)
 	}
-	defer rows.Close()
-
-	var slices []TimeSpaceSlice
-	for rows.Next() {
-		var slice TimeSpaceSlice
-		var dayOfWeek, hour int
-		var distance sql.NullFloat64
-
-		if err := rows.Scan(&dayOfWeek, &hour, &slice.PointCount, &distance, &slice.UniqueLocations); err != nil {
-			return nil, fmt.Errorf("failed to scan weekly-hourly slice: %w", err)
-		}
+	return slice
+}
 
-		if distance.Valid {
-			slice.Distance = distance.Float64
+// add counts a point at local time t; the interval from the previous point of
+// the same source counts towards the slices that interval started in
+func (s *timeSpaceSlicer) add(source string, t time.Time, lat, lon float64) {
+	if s.started && source == s.prevSource {
+		if dt := int64(t.Sub(s.prevTime).Seconds()); dt > 0 && dt <= s.th.MaxGapS {
+			distance := spatial.HaversineDistance(s.prevLat, s.prevLon, lat, lon)
+			for _, key := range s.prevKeys {
+				slice := s.slicesMap[key]
+				slice.Distance += distance
+				slice.Duration += dt
+			}
 		}
-
-		slice.SliceType = "WEEKLY_HOURLY"
-		slice.SliceKey = fmt.Sprintf("%d-%02d", dayOfWeek, hour) // e.g., "0-00" for Sunday 00:00
-		slice.Duration = slice.PointCount * 10 // Approximate
-
-		slices = append(slices, slice)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	keys := sliceKeys(t)
+	cell := spatial.EncodeGeohash(lat, lon, s.th.GeohashPrecision)
+	for _, key := range keys {
+		s.slice(key).PointCount++
+		s.cells[key][cell] = true
 	}
-
-	return slices, nil
+	s.prevSource, s.prevTime, s.prevLat, s.prevLon, s.prevKeys = source, t, lat, lon, keys
+	s.started = true
 }
 
-// computeDailySlices computes daily time slices
-func (a *TimeSpaceSlicingAnalyzer) computeDailySlices(ctx context.Context) ([]TimeSpaceSlice, error) {
-	query := `
-		SELECT
-			DATE(datetime(dataTime, 'unixepoch')) as date,
-			COUNT(*) as point_count,
-			SUM(CASE WHEN distance IS NOT NULL THEN distance ELSE 0 END) as total_distance,
-			COUNT(DISTINCT grid_id) as unique_locations
-		FROM "一生足迹"
-		WHERE outlier_flag = 0
-		GROUP BY date
-		ORDER BY date
-	`
-
-	rows, err := a.DB.QueryContext(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query daily slices: %w", err)
-	}
-	defer rows.Close()
-
-	var slices []TimeSpaceSlice
-	for rows.Next() {
-		var slice TimeSpaceSlice
-		var date string
-		var distance sql.NullFloat64
-
-		if err := rows.Scan(&date, &slice.PointCount, &distance, &slice.UniqueLocations); err != nil {
-			return nil, fmt.Errorf("failed to scan daily slice: %w", err)
-		}
-
-		if distance.Valid {
-			slice.Distance = distance.Float64
-		}
-
-		slice.SliceType = "DAILY"
-		slice.SliceKey = date
-		slice.Duration = slice.PointCount * 10
-
-		slices = append(slices, slice)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+// slices returns the accumulated slices
+func (s *timeSpaceSlicer) slices() []TimeSpaceSlice {
+	result := make([]TimeSpaceSlice, 0, len(s.slicesMap))
+	for key, slice := range s.slicesMap {
+		slice.UniqueLocations = int64(len(s.cells[key]))
+		result = append(result, *slice)
 	}
-
-	return slices, nil
+	return result
 }
 
-// insertTimeSpaceSlices inserts time-space slices into the database
+// insertTimeSpaceSlices replaces the time-space slices in one transaction
 func (a *TimeSpaceSlicingAnalyzer) insertTimeSpaceSlices(ctx context.Context, slices []TimeSpaceSlice) error {
-	if len(slices) == 0 {
-		return nil
-	}
-
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if _, err := tx.ExecContext(ctx, "DELETE FROM time_space_slices"); err != nil {
+		return fmt.Errorf("failed to clear time-space slices: %w", err)
+	}
+
 	insertQuery := `
 		INSERT INTO time_space_slices (
 			slice_type, slice_key, admin_level, admin_name, grid_id,
 			point_count, distance_m, duration_s, unique_locations,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
-		ON CONFLICT(slice_type, slice_key, admin_level, admin_name, grid_id) DO UPDATE SET
-			point_count = excluded.point_count,
-			distance_m = excluded.distance_m,
-			duration_s = excluded.duration_s,
-			unique_locations = excluded.unique_locations
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("time_space_slicing") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -298,4 +287,7 @@ func (a *TimeSpaceSlicingAnalyzer) insertTimeSpaceSlices(ctx context.Context, sl
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("time_space_slicing", NewTimeSpaceSlicingAnalyzer)
+	analysis.RegisterVersion("time_space_slicing", "v2")
+	analysis.RegisterDependencies("time_space_slicing", "outlier_detection")
+	analysis.RegisterOutputs("time_space_slicing", analysis.Output{Table: "time_space_slices", Replaced: true})
 }
@@ -0,0 +1,57 @@
+package temporal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeSpaceSlicer(t *testing.T) {
+	s := newTimeSpaceSlicer(DefaultTimeSpaceSlicingThresholds)
+	// Sunday 2024-03-03 08:59:00 UTC
+	start := time.Date(2024, 3, 3, 8, 59, 0, 0, time.UTC)
+	s.add("phone", start, 23.1000, 113.3000)
+	s.add("phone", start.Add(60*time.Second), 23.1009, 113.3000) // ~100 m north, now 09:00
+	s.add("phone", start.Add(2*time.Hour), 23.1009, 113.3000)    // After a gap, not tracked
+	s.add("watch", start.Add(30*time.Second), 23.2000, 113.3000) // Another source is not joined to phone
+
+	byKey := make(map[string]TimeSpaceSlice)
+	for _, slice := range s.slices() {
+		byKey[slice.SliceType+"/"+slice.SliceKey] = slice
+	}
+
+	var hourly, weekly int
+	for _, slice := range byKey {
+		switch slice.SliceType {
+		case SliceHourly:
+			hourly++
+		case SliceWeeklyHourly:
+			weekly++
+		}
+	}
+	if hourly != 24 || weekly != 168 {
+		t.Errorf("hourly, weekly-hourly slices = %d, %d, want 24, 168", hourly, weekly)
+	}
+
+	h08 := byKey["HOURLY/08"]
+	if h08.PointCount != 2 || h08.Duration != 60 || h08.Distance < 95 || h08.Distance > 105 {
+		t.Errorf("HOURLY/08 = %+v, want 2 points, 60 s, ~100 m", h08)
+	}
+	if h08.UniqueLocations != 2 {
+		t.Errorf("HOURLY/08 unique locations = %d, want 2", h08.UniqueLocations)
+	}
+	if h09 := byKey["HOURLY/09"]; h09.PointCount != 1 || h09.Duration != 0 {
+		t.Errorf("HOURLY/09 = %+v, want 1 point and no tracked time", h09)
+	}
+	if h04 := byKey["HOURLY/04"]; h04.PointCount != 0 {
+		t.Errorf("HOURLY/04 = %+v, want an empty slice", h04)
+	}
+	if w := byKey["WEEKLY_HOURLY/0-10"]; w.PointCount != 1 {
+		t.Errorf("WEEKLY_HOURLY/0-10 = %+v, want 1 point", w)
+	}
+	if m := byKey["MONTHLY/2024-03"]; m.PointCount != 4 || m.Duration != 60 {
+		t.Errorf("MONTHLY/2024-03 = %+v, want 4 points and 60 s", m)
+	}
+	if d := byKey["DAILY/2024-03-03"]; d.PointCount != 4 {
+		t.Errorf("DAILY/2024-03-03 = %+v, want 4 points", d)
+	}
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// LoadThresholds fills dst with the analyzer's section of the task's threshold profile
+// The default profile is used as the base and overridden by the profile attached to the task
+// dst must be pre-filled with built-in defaults; keys missing from the profiles keep them
+func (a *BaseAnalyzer) LoadThresholds(ctx context.Context, taskID int64, dst interface{}) error {
+	var defaultJSON sql.NullString
+	err := a.DB.QueryRowContext(ctx, `
+		SELECT params_json FROM threshold_profiles
+		WHERE is_default = 1
+		ORDER BY id
+		LIMIT 1
+	`).Scan(&defaultJSON)
+	if err != nil && err != sql.ErrNoRows {
+		// Table may not exist yet, fall back to built-in defaults
+		log.Printf("[%s] Warning: failed to load default threshold profile: %v", a.Name, err)
+		return nil
+	}
+
+	var profileJSON sql.NullString
+	err = a.DB.QueryRowContext(ctx, `
+		SELECT p.params_json
+		FROM analysis_tasks t
+		JOIN threshold_profiles p ON p.id = t.threshold_profile_id
+		WHERE t.id = ?
+	`, taskID).Scan(&profileJSON)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to load task threshold profile: %w", err)
+	}
+
+	params := map[string]interface{}{}
+	for _, raw := range []sql.NullString{defaultJSON, profileJSON} {
+		if !raw.Valid || raw.String == "" {
+			continue
+		}
+		var profile map[string]interface{}
+		if err := json.Unmarshal([]byte(raw.String), &profile); err != nil {
+			return fmt.Errorf("failed to parse threshold profile: %w", err)
+		}
+		params = MergeThresholdParams(params, profile)
+	}
+
+	section, ok := params[a.Name]
+	if !ok {
+		return nil
+	}
+
+	sectionJSON, err := json.Marshal(section)
+	if err != nil {
+		return fmt.Errorf("failed to encode thresholds: %w", err)
+	}
+	if err := json.Unmarshal(sectionJSON, dst); err != nil {
+		return fmt.Errorf("invalid thresholds for %s: %w", a.Name, err)
+	}
+
+	return nil
+}
+
+// MergeThresholdParams deep-merges override into base and returns the result
+// Nested objects are merged key by key, other values in override replace those in base
+func MergeThresholdParams(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		baseObj, baseIsObj := merged[k].(map[string]interface{})
+		overrideObj, overrideIsObj := v.(map[string]interface{})
+		if baseIsObj && overrideIsObj {
+			merged[k] = MergeThresholdParams(baseObj, overrideObj)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
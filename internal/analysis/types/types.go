@@ -0,0 +1,31 @@
+// Package types holds data structures shared by the analyzer packages
+package types
+
+// Point holds track point data loaded by analyzers
+// Fields not selected by a given query are left at their zero value
+type Point struct {
+	ID        int64
+	Timestamp int64
+	Lat       float64
+	Lon       float64
+	Alt       float64
+	Speed     float64
+	Country   string
+	Province  string
+	City      string
+	County    string
+	Town      string
+	GridID    string
+}
+
+// SegmentInfo holds segment identity, time range and location context
+type SegmentInfo struct {
+	ID       int64
+	StartTS  int64
+	EndTS    int64
+	Province string
+	City     string
+	County   string
+	Town     string
+	GridID   string
+}
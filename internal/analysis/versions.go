@@ -0,0 +1,173 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DefaultVersion is the algorithm version of analyzers that register none
+const DefaultVersion = "v1"
+
+var (
+	versions     = make(map[string]string)
+	dependencies = make(map[string][]string)
+)
+
+// RegisterVersion records the algorithm version of a skill
+// Bump it whenever a change alters what the skill derives: outputs computed
+// by another version are reported stale and recomputed in full by the next
+// analysis chain run.
+func RegisterVersion(skillName, version string) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	versions[skillName] = version
+}
+
+// Version returns the algorithm version of a skill
+func Version(skillName string) string {
+	outputsMu.RLock()
+	defer outputsMu.RUnlock()
+	if v, ok := versions[skillName]; ok {
+		return v
+	}
+	return DefaultVersion
+}
+
+// RegisterDependencies records the skills whose outputs a skill reads
+func RegisterDependencies(skillName string, deps ...string) {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	dependencies[skillName] = append(dependencies[skillName], deps...)
+}
+
+// Dependencies returns the skills whose outputs a skill reads
+func Dependencies(skillName string) []string {
+	outputsMu.RLock()
+	defer outputsMu.RUnlock()
+	return dependencies[skillName]
+}
+
+// Computed is the algorithm version a skill's outputs were last fully
+// computed with, and when
+type Computed struct {
+	Version    string
+	ComputedAt int64 // Unix seconds, 0 for outputs that predate version tracking
+}
+
+// Stale returns, for each stale skill, why its outputs are stale
+// A skill is stale when its outputs were computed by another algorithm
+// version, when a dependency was fully recomputed after it, or when a
+// dependency is stale itself. Skills without a computed version are new and
+// not stale.
+func Stale(skills []string, computed map[string]Computed) map[string][]string {
+	stale := make(map[string][]string)
+	for _, skill := range skills {
+		c, ok := computed[skill]
+		if !ok {
+			continue
+		}
+		if v := Version(skill); c.Version != v {
+			stale[skill] = append(stale[skill], fmt.Sprintf("computed by %s, now %s", c.Version, v))
+		}
+		for _, dep := range Dependencies(skill) {
+			if d, ok := computed[dep]; ok && d.ComputedAt > c.ComputedAt {
+				stale[skill] = append(stale[skill], fmt.Sprintf("%s was recomputed since", dep))
+			}
+		}
+	}
+
+	// Staleness spreads to dependents until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for _, skill := range skills {
+			if _, ok := computed[skill]; !ok {
+				continue
+			}
+			for _, dep := range Dependencies(skill) {
+				if _, depStale := stale[dep]; !depStale || hasReason(stale[skill], dep) {
+					continue
+				}
+				stale[skill] = append(stale[skill], fmt.Sprintf("%s is stale", dep))
+				changed = true
+			}
+		}
+	}
+	return stale
+}
+
+// hasReason reports whether reasons already blame dep
+func hasReason(reasons []string, dep string) bool {
+	for _, r := range reasons {
+		if r == dep+" is stale" || r == dep+" was recomputed since" {
+			return true
+		}
+	}
+	return false
+}
+
+// DependencyOrder sorts skills so each comes after the dependencies among
+// them, keeping the given order otherwise
+// Dependency cycles are broken in the given order.
+func DependencyOrder(skills []string) []string {
+	pending := append([]string(nil), skills...)
+	placed := make(map[string]bool, len(skills))
+	included := make(map[string]bool, len(skills))
+	for _, s := range skills {
+		included[s] = true
+	}
+
+	ordered := make([]string, 0, len(skills))
+	for len(pending) > 0 {
+		next := -1
+		for i, skill := range pending {
+			ready := true
+			for _, dep := range Dependencies(skill) {
+				if included[dep] && !placed[dep] && dep != skill {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+		placed[pending[next]] = true
+		ordered = append(ordered, pending[next])
+		pending = append(pending[:next], pending[next+1:]...)
+	}
+	return ordered
+}
+
+// CountByVersion counts the rows of the output by their algo_version column,
+// or returns nil when the table has no such column
+func (o Output) CountByVersion(ctx context.Context, db *sql.DB) (map[string]int64, error) {
+	var n int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'algo_version'", o.Table).Scan(&n); err != nil {
+		return nil, fmt.Errorf("failed to look up columns of %s: %w", o.Table, err)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	where, args := o.condition(nil)
+	rows, err := db.QueryContext(ctx, `SELECT COALESCE(algo_version, ''), COUNT(*) FROM "`+o.Table+`"`+where+" GROUP BY 1", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count rows of %s: %w", o.Table, err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var version string
+		var count int64
+		if err := rows.Scan(&version, &count); err != nil {
+			return nil, err
+		}
+		counts[version] = count
+	}
+	return counts, rows.Err()
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/database"
 	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
@@ -35,14 +36,6 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing render cache (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM render_segments_cache"); err != nil {
-			return fmt.Errorf("failed to clear render cache: %w", err)
-		}
-		log.Printf("[RenderingMetadataAnalyzer] Cleared existing render cache")
-	}
-
 	// Step 1: Calculate global speed percentiles for bucketing
 	speedPercentiles, err := a.calculateSpeedPercentiles(ctx)
 	if err != nil {
@@ -59,36 +52,16 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 
 	log.Printf("[RenderingMetadataAnalyzer] Calculated overlap stats for %d grid cells", len(overlapStats))
 
-	// Step 3: Get all segments
-	segmentsQuery := `
-		SELECT
-			id,
-			start_time,
-			end_time
-		FROM segments
-		ORDER BY id
-	`
-
-	rows, err := a.DB.QueryContext(ctx, segmentsQuery)
-	if err != nil {
-		return fmt.Errorf("failed to query segments: %w", err)
-	}
-
-	var segments []SegmentInfo
-	for rows.Next() {
-		var seg SegmentInfo
-		if err := rows.Scan(&seg.ID, &seg.StartTS, &seg.EndTS); err != nil {
-			rows.Close()
-			return fmt.Errorf("failed to scan segment: %w", err)
-		}
-		segments = append(segments, seg)
+	// Step 3: Count the segments
+	var total int64
+	if err := a.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM segments").Scan(&total); err != nil {
+		return fmt.Errorf("failed to count segments: %w", err)
 	}
-	rows.Close()
 
-	log.Printf("[RenderingMetadataAnalyzer] Processing %d segments", len(segments))
+	log.Printf("[RenderingMetadataAnalyzer] Processing %d segments", total)
 
 	// Update task with total count
-	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), 0, 0); err != nil {
+	if err := a.UpdateTaskProgress(taskID, total, 0, 0); err != nil {
 		return fmt.Errorf("failed to update task progress: %w", err)
 	}
 
@@ -97,43 +70,9 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 	batchSize := 100
 	var renderMetadata []RenderMetadata
 
-	for _, seg := range segments {
-		// Get points for this segment
-		pointsQuery := `
-			SELECT
-				speed,
-				grid_id
-			FROM "一生足迹"
-			WHERE dataTime BETWEEN ? AND ?
-				AND outlier_flag = 0
-			ORDER BY dataTime
-		`
-
-		pointRows, err := a.DB.QueryContext(ctx, pointsQuery, seg.StartTS, seg.EndTS)
-		if err != nil {
-			return fmt.Errorf("failed to query points for segment %d: %w", seg.ID, err)
-		}
-
-		var speeds []float64
-		var gridIDs []string
-		for pointRows.Next() {
-			var speed sql.NullFloat64
-			var gridID sql.NullString
-			if err := pointRows.Scan(&speed, &gridID); err != nil {
-				pointRows.Close()
-				return fmt.Errorf("failed to scan speed: %w", err)
-			}
-			if speed.Valid && speed.Float64 > 0 {
-				speeds = append(speeds, speed.Float64)
-			}
-			if gridID.Valid && gridID.String != "" {
-				gridIDs = append(gridIDs, gridID.String)
-			}
-		}
-		pointRows.Close()
-
+	err = a.eachSegmentPoints(ctx, func(segmentID int64, speeds []float64, gridIDs []string) error {
 		if len(speeds) == 0 {
-			continue
+			return nil
 		}
 
 		// Calculate average speed for this segment
@@ -170,7 +109,7 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 		// Create render metadata for different LODs
 		for lod := 0; lod <= 2; lod++ {
 			metadata := RenderMetadata{
-				SegmentID:   seg.ID,
+				SegmentID:   segmentID,
 				LOD:         lod,
 				SpeedBucket: speedBucket,
 				OverlapRank: overlapRank,
@@ -188,11 +127,15 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 			}
 			renderMetadata = nil
 
-			if err := a.UpdateTaskProgress(taskID, int64(len(segments)), int64(processed), 0); err != nil {
+			if err := a.UpdateTaskProgress(taskID, total, int64(processed), 0); err != nil {
 				return fmt.Errorf("failed to update progress: %w", err)
 			}
-			log.Printf("[RenderingMetadataAnalyzer] Processed %d/%d segments", processed, len(segments))
+			log.Printf("[RenderingMetadataAnalyzer] Processed %d/%d segments", processed, total)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Insert remaining metadata
@@ -204,7 +147,7 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 
 	// Mark task as completed
 	summary := map[string]interface{}{
-		"total_segments":     len(segments),
+		"total_segments":     total,
 		"processed_segments": processed,
 		"render_entries":     processed * 3, // 3 LODs per segment
 	}
@@ -218,13 +161,6 @@ func (a *RenderingMetadataAnalyzer) Analyze(ctx context.Context, taskID int64, m
 	return nil
 }
 
-// SegmentInfo holds segment information
-type SegmentInfo struct {
-	ID      int64
-	StartTS int64
-	EndTS   int64
-}
-
 // RenderMetadata holds rendering metadata
 type RenderMetadata struct {
 	SegmentID   int64
@@ -235,13 +171,68 @@ type RenderMetadata struct {
 	AlphaHint   float64
 }
 
+// eachSegmentPoints calls fn with every segment having points and the
+// positive speeds and grid IDs of its non-outlier points in time order
+// The points of all segments come from a single query joining them to the
+// segment boundaries rather than a query per segment. The unary plus keeps
+// SQLite from picking the outlier_flag index, which matches nearly every
+// point, over the dataTime range.
+func (a *RenderingMetadataAnalyzer) eachSegmentPoints(ctx context.Context, fn func(segmentID int64, speeds []float64, gridIDs []string) error) error {
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT
+			s.id,
+			`+analysis.EffectiveSpeedExpr+`,
+			p.grid_id
+		FROM segments s
+		JOIN "一生足迹" p ON p.dataTime BETWEEN s.start_time AND s.end_time
+			AND +p.outlier_flag = 0
+		ORDER BY s.id, p.dataTime
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query segment points: %w", err)
+	}
+	defer rows.Close()
+
+	var segmentID int64
+	var speeds []float64
+	var gridIDs []string
+	started := false
+	for rows.Next() {
+		var id int64
+		var speed sql.NullFloat64
+		var gridID sql.NullString
+		if err := rows.Scan(&id, &speed, &gridID); err != nil {
+			return fmt.Errorf("failed to scan speed: %w", err)
+		}
+		if started && id != segmentID {
+			if err := fn(segmentID, speeds, gridIDs); err != nil {
+				return err
+			}
+			speeds, gridIDs = nil, nil
+		}
+		segmentID, started = id, true
+		if speed.Valid && speed.Float64 > 0 {
+			speeds = append(speeds, speed.Float64)
+		}
+		if gridID.Valid && gridID.String != "" {
+			gridIDs = append(gridIDs, gridID.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read segment points: %w", err)
+	}
+	if started {
+		return fn(segmentID, speeds, gridIDs)
+	}
+	return nil
+}
+
 // calculateSpeedPercentiles calculates global speed percentiles
 func (a *RenderingMetadataAnalyzer) calculateSpeedPercentiles(ctx context.Context) ([]float64, error) {
 	query := `
-		SELECT speed
+		SELECT `+analysis.EffectiveSpeedExpr+` AS effective_speed
 		FROM "一生足迹"
-		WHERE speed IS NOT NULL
-			AND speed > 0
+		WHERE effective_speed > 0
 			AND outlier_flag = 0
 		ORDER BY RANDOM()
 		LIMIT 10000
@@ -365,26 +356,21 @@ func (a *RenderingMetadataAnalyzer) insertRenderMetadata(ctx context.Context, me
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	insertQuery := `
+	batch := database.NewBatchInserter(tx, `
 		INSERT OR REPLACE INTO render_segments_cache (
 			segment_id, lod, speed_bucket, overlap_rank, line_weight_hint, alpha_hint, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-	`
-
-	stmt, err := tx.PrepareContext(ctx, insertQuery)
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
+		)`, `(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		0)
+	defer batch.Close()
 
 	for _, m := range metadata {
-		_, err := stmt.ExecContext(ctx,
+		err := batch.Add(ctx,
 			m.SegmentID,
 			m.LOD,
 			m.SpeedBucket,
@@ -396,6 +382,9 @@ func (a *RenderingMetadataAnalyzer) insertRenderMetadata(ctx context.Context, me
 			return fmt.Errorf("failed to insert render metadata: %w", err)
 		}
 	}
+	if err := batch.Flush(ctx); err != nil {
+		return fmt.Errorf("failed to insert render metadata: %w", err)
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
@@ -407,4 +396,6 @@ func (a *RenderingMetadataAnalyzer) insertRenderMetadata(ctx context.Context, me
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("rendering_metadata", NewRenderingMetadataAnalyzer)
+	analysis.RegisterDependencies("rendering_metadata", "transport_mode")
+	analysis.RegisterOutputs("rendering_metadata", analysis.Output{Table: "render_segments_cache"})
 }
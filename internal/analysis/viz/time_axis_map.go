@@ -33,14 +33,6 @@ func (a *TimeAxisMapAnalyzer) Analyze(ctx context.Context, taskID int64, mode st
 		return fmt.Errorf("failed to mark task as running: %w", err)
 	}
 
-	// Clear existing markers (full recompute)
-	if mode == "full" {
-		if _, err := a.DB.ExecContext(ctx, "DELETE FROM time_axis_markers"); err != nil {
-			return fmt.Errorf("failed to clear time_axis_markers: %w", err)
-		}
-		log.Printf("[TimeAxisMapAnalyzer] Cleared existing time axis markers")
-	}
-
 	var markers []TimeAxisMarker
 
 	// 1. Generate markers from segments
@@ -351,7 +343,7 @@ func (a *TimeAxisMapAnalyzer) insertTimeAxisMarkers(ctx context.Context, markers
 		return nil
 	}
 
-	tx, err := a.DB.BeginTx(ctx, nil)
+	tx, err := a.BeginWriteTx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -362,7 +354,7 @@ func (a *TimeAxisMapAnalyzer) insertTimeAxisMarkers(ctx context.Context, markers
 			marker_ts, marker_type, entity_id, entity_type,
 			latitude, longitude, label, icon, color,
 			algo_version, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 'v1', CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '` + analysis.Version("time_axis_map") + `', CURRENT_TIMESTAMP)
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertQuery)
@@ -392,4 +384,6 @@ func (a *TimeAxisMapAnalyzer) insertTimeAxisMarkers(ctx context.Context, markers
 // Register the analyzer
 func init() {
 	analysis.RegisterAnalyzer("time_axis_map", NewTimeAxisMapAnalyzer)
+	analysis.RegisterDependencies("time_axis_map", "transport_mode", "speed_events", "altitude_dimension")
+	analysis.RegisterOutputs("time_axis_map", analysis.Output{Table: "time_axis_markers"})
 }
\ No newline at end of file
@@ -0,0 +1,283 @@
+package viz
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// TrajectorySimplificationAnalyzer precomputes simplified segment geometry
+// Skill: 轨迹简化 (Trajectory Simplification)
+// Each segment is simplified once per LOD (0=low, 1=medium, 2=high, as in
+// render_segments_cache) and stored as an encoded polyline, so maps can draw
+// years of tracks without loading raw points. Segments matched to the road
+// network by map_matching are simplified from their matched geometry.
+type TrajectorySimplificationAnalyzer struct {
+	*analysis.IncrementalAnalyzer
+}
+
+// NewTrajectorySimplificationAnalyzer creates a new trajectory simplification analyzer
+func NewTrajectorySimplificationAnalyzer(db *sql.DB) analysis.Analyzer {
+	return &TrajectorySimplificationAnalyzer{
+		IncrementalAnalyzer: analysis.NewIncrementalAnalyzer(db, "trajectory_simplification", 100),
+	}
+}
+
+// Simplification algorithms
+const (
+	AlgorithmDouglasPeucker = "douglas_peucker"
+	AlgorithmVisvalingam    = "visvalingam"
+)
+
+// SimplificationThresholds holds trajectory simplification parameters
+type SimplificationThresholds struct {
+	Algorithm string `json:"algorithm"` // douglas_peucker or visvalingam
+	// Tolerance per LOD in meters: the maximum deviation for Douglas-Peucker;
+	// Visvalingam drops points whose triangle area is below the tolerance squared
+	ToleranceM [3]float64 `json:"tolerance_m"`
+}
+
+// DefaultSimplificationThresholds provides default simplification thresholds
+// The tolerances are about one screen pixel at the zooms served by each LOD
+var DefaultSimplificationThresholds = SimplificationThresholds{
+	Algorithm:  AlgorithmDouglasPeucker,
+	ToleranceM: [3]float64{200, 30, 5},
+}
+
+// SegmentPolyline holds the simplified geometry of a segment at one LOD
+type SegmentPolyline struct {
+	SegmentID        int64
+	LOD              int
+	ToleranceM       float64
+	Polyline         string
+	PointCount       int
+	SourcePointCount int
+	MinLat           float64
+	MinLon           float64
+	MaxLat           float64
+	MaxLon           float64
+}
+
+// Analyze performs trajectory simplification
+func (a *TrajectorySimplificationAnalyzer) Analyze(ctx context.Context, taskID int64, mode string) error {
+	log.Printf("[TrajectorySimplificationAnalyzer] Starting analysis (task_id=%d, mode=%s)", taskID, mode)
+
+	// Mark task as running
+	if err := a.MarkTaskAsRunning(taskID); err != nil {
+		return fmt.Errorf("failed to mark task as running: %w", err)
+	}
+
+	thresholds := DefaultSimplificationThresholds
+	if err := a.LoadThresholds(ctx, taskID, &thresholds); err != nil {
+		return fmt.Errorf("failed to load thresholds: %w", err)
+	}
+	if thresholds.Algorithm != AlgorithmDouglasPeucker && thresholds.Algorithm != AlgorithmVisvalingam {
+		return fmt.Errorf("unknown simplification algorithm: %s", thresholds.Algorithm)
+	}
+
+	// Incremental mode only simplifies segments without polylines
+	segmentsQuery := `
+		SELECT s.id, s.start_time, s.end_time
+		FROM segments s
+		WHERE NOT EXISTS (SELECT 1 FROM segment_polylines p WHERE p.segment_id = s.id)
+		ORDER BY s.id
+	`
+
+	rows, err := a.DB.QueryContext(ctx, segmentsQuery)
+	if err != nil {
+		return fmt.Errorf("failed to query segments: %w", err)
+	}
+
+	type segmentRange struct {
+		id, startTS, endTS int64
+	}
+	var segments []segmentRange
+	for rows.Next() {
+		var seg segmentRange
+		if err := rows.Scan(&seg.id, &seg.startTS, &seg.endTS); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments = append(segments, seg)
+	}
+	rows.Close()
+
+	log.Printf("[TrajectorySimplificationAnalyzer] Processing %d segments", len(segments))
+
+	// Update task with total count
+	if err := a.UpdateTaskProgress(taskID, int64(len(segments)), 0, 0); err != nil {
+		return fmt.Errorf("failed to update task progress: %w", err)
+	}
+
+	var polylines []SegmentPolyline
+	var processed, skipped, sourcePoints, keptPoints int64
+	for _, seg := range segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		points, err := a.loadSegmentPoints(ctx, seg.id, seg.startTS, seg.endTS)
+		if err != nil {
+			return fmt.Errorf("failed to load points for segment %d: %w", seg.id, err)
+		}
+
+		processed++
+		if len(points) < 2 {
+			skipped++
+		} else {
+			minLat, minLon, maxLat, maxLon := spatial.BoundingBox(points)
+			for lod, tolerance := range thresholds.ToleranceM {
+				simplified := simplify(points, thresholds.Algorithm, tolerance)
+				polylines = append(polylines, SegmentPolyline{
+					SegmentID:        seg.id,
+					LOD:              lod,
+					ToleranceM:       tolerance,
+					Polyline:         spatial.EncodePolyline(simplified),
+					PointCount:       len(simplified),
+					SourcePointCount: len(points),
+					MinLat:           minLat,
+					MinLon:           minLon,
+					MaxLat:           maxLat,
+					MaxLon:           maxLon,
+				})
+				keptPoints += int64(len(simplified))
+			}
+			sourcePoints += int64(len(points))
+		}
+
+		if processed%int64(a.BatchSize) == 0 {
+			if err := a.insertPolylines(ctx, thresholds.Algorithm, polylines); err != nil {
+				return fmt.Errorf("failed to insert segment polylines: %w", err)
+			}
+			polylines = nil
+
+			if err := a.UpdateTaskProgress(taskID, int64(len(segments)), processed, 0); err != nil {
+				return fmt.Errorf("failed to update progress: %w", err)
+			}
+			log.Printf("[TrajectorySimplificationAnalyzer] Processed %d/%d segments", processed, len(segments))
+		}
+	}
+
+	// Insert remaining polylines
+	if err := a.insertPolylines(ctx, thresholds.Algorithm, polylines); err != nil {
+		return fmt.Errorf("failed to insert segment polylines: %w", err)
+	}
+
+	// Mark task as completed
+	summary := map[string]interface{}{
+		"total_segments":      len(segments),
+		"simplified_segments": processed - skipped,
+		"skipped_segments":    skipped,
+		"algorithm":           thresholds.Algorithm,
+		"source_points":       sourcePoints,
+		"kept_points":         keptPoints, // Summed over all LODs
+	}
+	summaryJSON, _ := json.Marshal(summary)
+
+	if err := a.MarkTaskAsCompleted(taskID, string(summaryJSON)); err != nil {
+		return fmt.Errorf("failed to mark task as completed: %w", err)
+	}
+
+	log.Printf("[TrajectorySimplificationAnalyzer] Analysis completed: %d segments simplified (%d skipped)",
+		processed-skipped, skipped)
+	return nil
+}
+
+// loadSegmentPoints loads the geometry of a segment: its road-snapped path when
+// map_matching has matched it, otherwise its non-outlier points in time order
+func (a *TrajectorySimplificationAnalyzer) loadSegmentPoints(ctx context.Context, segmentID, startTS, endTS int64) ([]spatial.Point, error) {
+	var matched string
+	err := a.DB.QueryRowContext(ctx, `
+		SELECT polyline FROM matched_segments WHERE segment_id = ? AND polyline != ''
+	`, segmentID).Scan(&matched)
+	if err == nil {
+		return spatial.DecodePolyline(matched)
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	rows, err := a.DB.QueryContext(ctx, `
+		SELECT latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ?
+			AND outlier_flag = 0
+		ORDER BY dataTime
+	`, startTS, endTS)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []spatial.Point
+	for rows.Next() {
+		var p spatial.Point
+		if err := rows.Scan(&p.Lat, &p.Lon); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// simplify applies the configured algorithm at a tolerance in meters
+func simplify(points []spatial.Point, algorithm string, toleranceM float64) []spatial.Point {
+	if algorithm == AlgorithmVisvalingam {
+		return spatial.SimplifyPathVisvalingam(points, toleranceM*toleranceM)
+	}
+	return spatial.SimplifyPath(points, toleranceM)
+}
+
+// insertPolylines stores simplified polylines in the database
+func (a *TrajectorySimplificationAnalyzer) insertPolylines(ctx context.Context, algorithm string, polylines []SegmentPolyline) error {
+	if len(polylines) == 0 {
+		return nil
+	}
+
+	tx, err := a.BeginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insertQuery := `
+		INSERT OR REPLACE INTO segment_polylines (
+			segment_id, lod, algorithm, tolerance_m, polyline, point_count, source_point_count,
+			min_lat, min_lon, max_lat, max_lon, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CAST(strftime('%s', 'now') AS INTEGER))
+	`
+
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range polylines {
+		_, err := stmt.ExecContext(ctx,
+			p.SegmentID, p.LOD, algorithm, p.ToleranceM, p.Polyline, p.PointCount, p.SourcePointCount,
+			p.MinLat, p.MinLon, p.MaxLat, p.MaxLon,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert polyline for segment %d: %w", p.SegmentID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Register the analyzer
+func init() {
+	analysis.RegisterAnalyzer("trajectory_simplification", NewTrajectorySimplificationAnalyzer)
+	analysis.RegisterDependencies("trajectory_simplification", "transport_mode", "map_matching")
+	analysis.RegisterOutputs("trajectory_simplification", analysis.Output{Table: "segment_polylines"})
+}
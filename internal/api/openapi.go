@@ -0,0 +1,963 @@
+package api
+
+//go:generate go run ../../cmd/openapi -out ../../docs/openapi.json -ts ../../docs/client/records-api.ts
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/config"
+	"github.com/jengzang/records-backend-go/internal/handler"
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/openapi"
+)
+
+// apiInfo describes the API in the OpenAPI document
+var apiInfo = openapi.Info{
+	Title:   "Records Backend API",
+	Version: "1.0.0",
+	Description: "Trajectory, statistics and personal data API. Responses are wrapped in " +
+		"{code, message, data}; code is 0 on success and the HTTP status otherwise.",
+}
+
+// Spec builds the OpenAPI document of the routes registered on r
+func Spec(r *gin.Engine) *openapi.Document {
+	return openapi.Build(apiInfo, r.Routes(), routeDocs)
+}
+
+// UndocumentedRoutes lists the routes of r missing from the route documentation
+func UndocumentedRoutes(r *gin.Engine) []string {
+	return openapi.Undocumented(r.Routes(), routeDocs)
+}
+
+// importFileResult is one file of the multi-file import responses
+type importFileResult[T any] struct {
+	FileName string `json:"file_name"`
+	Result   *T     `json:"result,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Query parameters shared by several routes
+var (
+	// listParams are the pagination, sorting and field selection parameters of list endpoints
+	listParams = []openapi.Param{
+		{Name: "limit", Type: "integer", Description: "Page size, at most 1000"},
+		{Name: "page", Type: "integer", Description: "1-based page number"},
+		{Name: "cursor", Description: "next_cursor of the previous page; takes precedence over page"},
+		{Name: "sort", Description: "Field to sort by"},
+		{Name: "order", Enum: []string{"asc", "desc"}},
+		{Name: "fields", Description: "Comma-separated response fields to keep"},
+	}
+	timeRangeParams = []openapi.Param{
+		{Name: "startTime", Type: "integer", Description: "Unix timestamp, 0 for no lower bound"},
+		{Name: "endTime", Type: "integer", Description: "Unix timestamp, 0 for no upper bound"},
+	}
+	bucketParam     = openapi.Param{Name: "bucket", Enum: []string{"all", "year", "month"}, Description: "Bucket type, default all"}
+	areaTypeParam   = openapi.Param{Name: "area_type", Description: "PROVINCE, CITY, COUNTY, TOWN or GRID"}
+	sourceParam     = openapi.Param{Name: "source", Description: "Track point source, default all for every source"}
+	maxPointsParam  = openapi.Param{Name: "max_points", Type: "integer", Description: "Points kept after downsampling, 2 to 5000, default 500"}
+	areaKeyParam    = openapi.Param{Name: "area_key", Description: "Area name or grid ID"}
+	dateRangeParams = []openapi.Param{
+		{Name: "from", Description: "First day, YYYY-MM-DD"},
+		{Name: "to", Description: "Last day, YYYY-MM-DD"},
+	}
+	taskListParams = []openapi.Param{
+		{Name: "status", Description: "pending, running, completed or failed"},
+		{Name: "limit", Type: "integer", Description: "Default 20"},
+		{Name: "offset", Type: "integer"},
+	}
+	// gpsloggerParams are the placeholders of a GPSLogger custom URL
+	gpsloggerParams = []openapi.Param{
+		{Name: "lat", Type: "number", Required: true},
+		{Name: "lon", Type: "number", Required: true},
+		{Name: "time", Description: "ISO 8601 time or Unix seconds", Required: true},
+		{Name: "alt", Type: "number"},
+		{Name: "acc", Type: "number", Description: "Horizontal accuracy in meters"},
+		{Name: "spd", Type: "number", Description: "Speed in m/s"},
+		{Name: "dir", Type: "number", Description: "Bearing in degrees"},
+		{Name: "batt", Type: "number", Description: "Battery percentage"},
+		{Name: "ischarging", Type: "boolean"},
+		{Name: "act", Description: "Detected activity"},
+		{Name: "aid", Description: "Device of the point; device and ser are also accepted"},
+	}
+	// directionalBucketParam is bucketParam of the directional bias endpoints, which also keep seasons
+	directionalBucketParam = openapi.Param{Name: "bucket", Enum: []string{"all", "year", "month", "season"}, Description: "Bucket type, default all"}
+)
+
+// params joins parameter lists
+func params(lists ...[]openapi.Param) []openapi.Param {
+	var all []openapi.Param
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// statsList documents a paginated statistics list of item with the given filter parameters
+func statsList(summary string, item interface{}, filters ...openapi.Param) openapi.Operation {
+	return openapi.Operation{
+		Summary:  summary,
+		Params:   params(filters, listParams),
+		Response: openapi.List{Of: item},
+	}
+}
+
+// routeDocs documents the routes registered by SetupRouter, keyed by "METHOD path"
+// Routes missing here still appear in the document, without parameters.
+var routeDocs = map[string]openapi.Operation{
+	"GET /health": {
+		Summary:  "Liveness check",
+		Response: openapi.Object{"status": "", "message": ""},
+		Bare:     true,
+	},
+	"GET /metrics": {
+		Summary:  "Prometheus metrics",
+		Response: openapi.Raw{ContentType: "text/plain"},
+	},
+	"GET /api/v1/openapi.json": {
+		Summary:  "This OpenAPI document",
+		Tag:      "system",
+		Response: openapi.Object{},
+		Bare:     true,
+	},
+	"GET /api/v1/docs": {
+		Summary:  "Swagger UI",
+		Tag:      "system",
+		Response: openapi.Raw{ContentType: "text/html"},
+	},
+
+	// Tracks
+	"GET /api/v1/tracks/points": {
+		Summary: "List track points",
+		Description: "Paged by page/pageSize by default. With after_id or limit the points are " +
+			"streamed with keyset pagination instead and the response data is " +
+			"{data, count, limit, has_more, next_after_id}.",
+		Query:    models.TrackPointFilter{},
+		Params:   params(openapi.Params(models.TrackPointCursorFilter{})),
+		Response: models.TrackPointsResponse{},
+	},
+	"GET /api/v1/tracks/points/:id": {Summary: "Get a track point", Response: models.TrackPoint{}},
+	"GET /api/v1/tracks/ungeocoded": {
+		Summary:  "List points without administrative areas",
+		Params:   []openapi.Param{{Name: "limit", Type: "integer", Description: "Default 100"}},
+		Response: openapi.Items{Of: models.TrackPoint{}},
+	},
+	"POST /api/v1/tracks/import": {
+		Summary: "Import GPX, KML, CSV or JSON track files",
+		Params: []openapi.Param{
+			{Name: "source", Type: "string", Description: "Source app or tracker of the file, recorded on every point (default the file format; \"all\" is reserved)"},
+			{Name: "device", Type: "string", Description: "Recording device or app; near-duplicates of other devices' points keep the more accurate point (default the file format)"},
+			{Name: "analyze", Type: "boolean", Description: "Queue incremental analysis after importing (default true)"},
+		},
+		Upload:   []string{"file", "files"},
+		Response: models.ImportResponse{},
+	},
+	"GET /api/v1/tracks/segments": {
+		Summary:  "List movement segments",
+		Query:    models.SegmentFilter{},
+		Response: openapi.Paged{Of: models.Segment{}},
+	},
+	"GET /api/v1/tracks/segments/:id": {Summary: "Get a movement segment", Response: models.Segment{}},
+	"GET /api/v1/tracks/stays": {
+		Summary:  "List stays",
+		Query:    models.StayFilter{},
+		Response: openapi.Paged{Of: models.StaySegment{}},
+	},
+	"GET /api/v1/tracks/stays/:id": {Summary: "Get a stay", Response: models.StaySegment{}},
+	"GET /api/v1/tracks/trips": {
+		Summary:  "List trips",
+		Query:    models.TripFilter{},
+		Response: openapi.Paged{Of: models.Trip{}},
+	},
+	"GET /api/v1/tracks/trips/:id": {Summary: "Get a trip", Response: models.Trip{}},
+	"GET /api/v1/tracks/statistics/footprint": {
+		Summary:  "Footprint statistics of a time range",
+		Params:   timeRangeParams,
+		Response: models.FootprintStatistics{},
+	},
+	"GET /api/v1/tracks/statistics/footprint/areas": {
+		Summary:     "Provinces, cities or counties visited within a time range",
+		Description: "Each area carries its point count and first and last visit within the range; sorted by point count by default.",
+		Params: params([]openapi.Param{{Name: "level", Enum: models.AreaLevels, Description: "Administrative level, default county"}},
+			timeRangeParams, listParams),
+		Response: openapi.List{Of: models.AreaVisit{}},
+	},
+	"GET /api/v1/tracks/statistics/time-distribution": {
+		Summary:  "Point counts by hour of day",
+		Params:   timeRangeParams,
+		Response: []models.TimeDistribution{},
+	},
+	"GET /api/v1/tracks/statistics/speed-distribution": {
+		Summary:  "Point counts by speed range",
+		Params:   timeRangeParams,
+		Response: []models.SpeedDistribution{},
+	},
+
+	// Statistics
+	"GET /api/v1/stats/footprint/rankings": statsList("Footprint rankings", models.FootprintStatistics{},
+		openapi.Params(models.StatsFilter{})...),
+	"GET /api/v1/stats/footprint/coverage": {
+		Summary: "Share of Chinese provinces, cities and counties visited",
+		Description: "Visited areas are counted against the division catalogue, with their first visits " +
+			"and the areas first visited in the given year.",
+		Params:   []openapi.Param{{Name: "year", Type: "integer", Description: "Year of the newly unlocked lists, default the current year"}},
+		Response: models.FootprintCoverage{},
+	},
+	"GET /api/v1/stats/countries": {
+		Summary:     "Visited countries and regions",
+		Description: "Countries are resolved by the country_backfill analyzer; Hong Kong, Macao and Taiwan have their own codes.",
+		Response:    []models.CountryVisit{},
+	},
+	"GET /api/v1/stats/countries/days-abroad": {
+		Summary:  "Days spent outside China per year",
+		Response: []models.YearAbroad{},
+	},
+	"GET /api/v1/stats/stay/rankings": statsList("Stay rankings", models.StayStatistics{},
+		openapi.Params(models.StatsFilter{})...),
+	"GET /api/v1/stats/extreme-events": statsList("Extreme events", models.ExtremeEvent{},
+		openapi.Param{Name: "eventType"},
+		openapi.Param{Name: "eventCategory"}),
+	"GET /api/v1/stats/admin-crossings": statsList("Administrative boundary crossings", models.AdminCrossing{},
+		openapi.Param{Name: "crossing_type", Description: "COUNTRY, PROVINCE, CITY, COUNTY or TOWN"},
+		openapi.Param{Name: "from", Description: "Region left"},
+		openapi.Param{Name: "to", Description: "Region entered"},
+		openapi.Param{Name: "start_time", Type: "integer", Description: "Unix timestamp"},
+		openapi.Param{Name: "end_time", Type: "integer", Description: "Unix timestamp"}),
+	"GET /api/v1/stats/admin-visits": {
+		Summary: "Visits to countries, provinces and cities",
+		Description: "Crossings paired into stays, from the first point in a region to the last one before leaving it, for a travel log. " +
+			"Re-entering a region within 10 minutes continues the visit. The latest visit has no exited_to while still there.",
+		Query:    models.AdminVisitFilter{},
+		Params:   listParams,
+		Response: openapi.List{Of: models.AdminVisit{}},
+	},
+	"GET /api/v1/stats/admin-visits/summary": {
+		Summary:  "Visit count and time spent per region",
+		Params:   []openapi.Param{{Name: "admin_level", Enum: []string{"COUNTRY", "PROVINCE", "CITY"}, Description: "Default PROVINCE"}},
+		Response: []models.AdminVisitSummary{},
+	},
+	"GET /api/v1/stats/admin-view": statsList("Statistics per administrative area", models.AdminStats{},
+		openapi.Param{Name: "admin_level", Description: "PROVINCE, CITY, COUNTY or TOWN"},
+		openapi.Param{Name: "admin_name"},
+		openapi.Param{Name: "parent_name"}),
+	"GET /api/v1/stats/admin-tree": {
+		Summary: "Administrative hierarchy with coverage",
+		Description: "Province, city, county and town tree with per-area statistics. " +
+			"Coverage counts the visited subdivisions against the division catalogue; it is null where the catalogue does not list the subdivisions.",
+		Query:    models.AdminTreeFilter{},
+		Response: models.AdminTreeNode{},
+	},
+	"GET /api/v1/stats/speed-space": statsList("Speed-space coupling per area", models.SpeedSpaceStats{},
+		bucketParam, sourceParam, areaTypeParam, openapi.Param{Name: "area_name"}),
+	"GET /api/v1/stats/speed-space/high-speed-zones": statsList("Areas crossed at high speed", models.SpeedSpaceStats{},
+		bucketParam, sourceParam, areaTypeParam),
+	"GET /api/v1/stats/speed-space/slow-life-zones": statsList("Areas where movement is slow and stays are long", models.SpeedSpaceStats{},
+		bucketParam, sourceParam, areaTypeParam),
+	"GET /api/v1/stats/directional-bias": statsList("Directional bias per area", models.DirectionalBiasStats{},
+		directionalBucketParam, sourceParam, areaTypeParam, areaKeyParam,
+		openapi.Param{Name: "mode", Description: "Transport mode, ALL (default) for every mode"}),
+	"GET /api/v1/stats/directional-bias/top-areas": statsList("Areas with the strongest directional bias", models.DirectionalBiasStats{},
+		directionalBucketParam, sourceParam),
+	"GET /api/v1/stats/directional-bias/bidirectional": statsList("Areas travelled back and forth", models.DirectionalBiasStats{},
+		directionalBucketParam, sourceParam),
+	"GET /api/v1/stats/directional-bias/rose": {
+		Summary: "Wind-rose bins of an area's travel directions",
+		Description: "Direction histogram of segments starting in an admin area or geohash cell (GEOHASH5, GEOHASH6), as bins clockwise from north with their center bearings and distance weights summing to 1. " +
+			"Grid cells are kept for all time and per year.",
+		Params: []openapi.Param{
+			{Name: "area_type", Description: "PROVINCE, CITY, COUNTY, GEOHASH5 or GEOHASH6", Required: true},
+			{Name: "area_key", Description: "Area name or geohash", Required: true},
+			{Name: "mode", Description: "Transport mode, ALL (default) for every mode"},
+			directionalBucketParam,
+			{Name: "bucket_key", Description: "Year (2025), month (2025-01) or season (2025-winter, December to February); required unless bucket is all"},
+			sourceParam,
+		},
+		Response: models.DirectionalRose{},
+	},
+	"GET /api/v1/stats/revisit-patterns": statsList("Revisit patterns of places", models.RevisitPattern{},
+		openapi.Param{Name: "min_visits", Type: "integer", Description: "Default 2"},
+		openapi.Param{Name: "habitual_only", Type: "boolean"},
+		openapi.Param{Name: "periodic_only", Type: "boolean"}),
+	"GET /api/v1/stats/revisit-patterns/top-locations": statsList("Most revisited places", models.RevisitPattern{}),
+	"GET /api/v1/stats/revisit-patterns/habitual":      statsList("Habitual places", models.RevisitPattern{}),
+	"GET /api/v1/stats/revisit-patterns/periodic":      statsList("Periodically visited places", models.RevisitPattern{}),
+	"GET /api/v1/stats/spatial-utilization": statsList("Spatial utilization per area", models.SpatialUtilization{},
+		bucketParam, sourceParam, areaTypeParam, areaKeyParam),
+	"GET /api/v1/stats/spatial-utilization/destinations": statsList("Destination areas", models.SpatialUtilization{},
+		bucketParam, sourceParam, areaTypeParam),
+	"GET /api/v1/stats/spatial-utilization/corridors": statsList("Transit corridors", models.SpatialUtilization{},
+		bucketParam, sourceParam, areaTypeParam),
+	"GET /api/v1/stats/spatial-utilization/deep-engagement": statsList("Areas of deep engagement", models.SpatialUtilization{},
+		bucketParam, sourceParam, areaTypeParam),
+	"GET /api/v1/stats/density": statsList("Density grid cells", models.SpatialDensityGrid{},
+		bucketParam, sourceParam, openapi.Param{Name: "level", Description: "core, frequent, occasional or rare"}),
+	"GET /api/v1/stats/density/core":     statsList("Core activity areas", models.SpatialDensityGrid{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/density/rare":     statsList("Rarely visited cells", models.SpatialDensityGrid{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/density/clusters": statsList("Density clusters", models.SpatialDensityGrid{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/altitude": statsList("Altitude statistics per area", models.AltitudeStats{},
+		bucketParam, sourceParam, areaTypeParam, areaKeyParam),
+	"GET /api/v1/stats/altitude/highest-spans":     statsList("Areas with the largest altitude span", models.AltitudeStats{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/altitude/highest-intensity": statsList("Areas with the highest vertical intensity", models.AltitudeStats{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/time-space-compression": statsList("Time-space compression per area", models.TimeSpaceCompression{},
+		bucketParam, sourceParam, areaTypeParam, areaKeyParam),
+	"GET /api/v1/stats/time-space-compression/highest-intensity": statsList("Areas with the highest movement intensity", models.TimeSpaceCompression{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/time-space-compression/burst-periods":     statsList("Burst periods of movement", models.TimeSpaceCompression{}, bucketParam, sourceParam),
+	"GET /api/v1/stats/mode-breakdown": statsList("Distance, duration, trips and CO2 estimate per transport mode", models.ModeStats{},
+		openapi.Param{Name: "bucket", Enum: []string{"year", "month", "all"}, Description: "Bucket type, default year"},
+		openapi.Param{Name: "bucket_key", Description: "Single bucket, e.g. 2025 or 2025-01"},
+		openapi.Param{Name: "mode", Description: "Transport mode, e.g. WALK, CAR or TRAIN"},
+		sourceParam),
+	"GET /api/v1/stats/carbon": {
+		Summary:     "Carbon footprint per year and month",
+		Description: "Each period is compared with the same period one year earlier. Estimates use the emission factors as of the last carbon_footprint run.",
+		Params:      []openapi.Param{{Name: "year", Type: "integer", Description: "Only list the months of this year"}},
+		Response:    models.CarbonSummary{},
+	},
+	"GET /api/v1/stats/carbon/trips": statsList("Carbon footprint per trip", models.TripCarbon{},
+		openapi.Param{Name: "start_time", Type: "integer", Description: "Unix timestamp, 0 for no lower bound"},
+		openapi.Param{Name: "end_time", Type: "integer", Description: "Unix timestamp, 0 for no upper bound"}),
+	"GET /api/v1/stats/weather": {
+		Summary:     "Weather of the visited days and distance travelled in the rain",
+		Description: "Historical weather looked up by the weather_enrichment analyzer, each day at the 0.25° cell with most track points. A rain day has at least 1 mm of precipitation; segments count as wet when it rained during their trip.",
+		Params:      []openapi.Param{{Name: "year", Type: "integer", Description: "Single year; all years without it"}},
+		Response:    models.WeatherSummary{},
+	},
+	"GET /api/v1/stats/weather/days": {
+		Summary:     "Daily weather at the visited locations",
+		Description: "The primary cell of each day, most recent first; all_cells=true lists every visited cell. Values are absent on days the weather provider had no data for.",
+		Query:       models.WeatherDayFilter{},
+		Params:      listParams,
+		Response:    openapi.List{Of: models.WeatherDay{}},
+	},
+	"GET /api/v1/stats/dayparts": statsList("Distance, active time and visited areas per part of the day", models.DaypartStats{},
+		openapi.Param{Name: "bucket", Enum: []string{"all", "year", "month"}, Description: "Bucket type, default all"},
+		openapi.Param{Name: "bucket_key", Description: "Single bucket, e.g. 2025 or 2025-01"},
+		openapi.Param{Name: "daypart", Enum: []string{"MORNING", "AFTERNOON", "EVENING", "NIGHT"}},
+		sourceParam),
+	"GET /api/v1/stats/day-types": statsList("Distance, trips, cities and stays on workdays, weekends and public holidays", models.DayTypeStats{},
+		openapi.Param{Name: "bucket", Enum: []string{"all", "year"}, Description: "Bucket type, default all"},
+		openapi.Param{Name: "bucket_key", Description: "Single bucket, e.g. 2025"},
+		openapi.Param{Name: "day_type", Enum: []string{"WORKDAY", "WEEKEND", "HOLIDAY"}}),
+	"GET /api/v1/stats/day-types/compare": {
+		Summary:     "Compare weekends and public holidays with workdays",
+		Description: "Day types follow the Chinese public holiday calendar; make-up workdays count as workdays.",
+		Params:      []openapi.Param{{Name: "year", Type: "integer", Description: "Single year; all years without it"}},
+		Response:    models.DayTypeComparison{},
+	},
+	"GET /api/v1/stats/anomalies": statsList("Days whose distance, range, stays or new places stand out from the preceding days", models.AnomalousDay{},
+		openapi.Param{Name: "from", Description: "First date, YYYY-MM-DD"},
+		openapi.Param{Name: "to", Description: "Last date, YYYY-MM-DD"},
+		openapi.Param{Name: "category", Enum: []string{"TRAVEL_DAY", "UNUSUAL_ROUTINE", "QUIET_DAY"}},
+		openapi.Param{Name: "reason", Description: "e.g. DISTANCE_HIGH, RADIUS_LOW, STAYS_HIGH or NOVEL_CELLS_HIGH"},
+		openapi.Param{Name: "min_score", Type: "number", Description: "Lowest robust z-score"}),
+	"GET /api/v1/stats/exploration": {
+		Summary:     "Monthly exploration curve",
+		Description: "Share of points and time in grid cells on the day they were first visited, and the cumulative number of cells ever visited.",
+		Params: []openapi.Param{
+			{Name: "from", Description: "First month, YYYY-MM"},
+			{Name: "to", Description: "Last month, YYYY-MM"},
+		},
+		Response: models.ExplorationCurve{},
+	},
+	"GET /api/v1/stats/exploration/streaks": statsList("Runs of consecutive days that each visited a new grid cell", models.DiscoveryStreak{},
+		openapi.Param{Name: "from", Description: "YYYY-MM-DD; streaks ending before it are left out"},
+		openapi.Param{Name: "to", Description: "YYYY-MM-DD; streaks starting after it are left out"}),
+	"GET /api/v1/stats/first-visits": statsList("First visit of each city or county, oldest first", models.FirstVisit{},
+		openapi.Param{Name: "level", Enum: []string{"city", "county"}, Description: "Admin level, default city"},
+		openapi.Param{Name: "from", Description: "First date, YYYY-MM-DD"},
+		openapi.Param{Name: "to", Description: "Last date, YYYY-MM-DD"}),
+	"GET /api/v1/stats/records": {
+		Summary:     "Personal records",
+		Description: "Longest run of tracked days, longest day, most cities in a day, longest trip, walk and stay, and fastest ground segment, with the day, point, segment, trip or stay that set each.",
+		Response:    []models.PersonalRecord{},
+	},
+	"GET /api/v1/stats/compare": {
+		Summary:     "Compare two months or two years",
+		Description: "Footprint, speed-space, compression and altitude metrics of both buckets side by side, with the change from A to B.",
+		Params: []openapi.Param{
+			{Name: "bucketA", Required: true, Description: "YYYY or YYYY-MM"},
+			{Name: "bucketB", Required: true, Description: "Same form as bucketA"},
+		},
+		Response: models.BucketComparison{},
+	},
+	"GET /api/v1/stats/time-space-slices": statsList("Time-space slices", models.TimeSpaceSlice{},
+		openapi.Param{Name: "slice_type", Description: "HOURLY, WEEKLY_HOURLY, DAILY or MONTHLY"}),
+	"GET /api/v1/stats/time-space-slices/weekly-pattern": {
+		Summary:  "Activity by hour of the week (168 slices)",
+		Response: []models.TimeSpaceSlice{},
+	},
+	"GET /api/v1/stats/time-space-slices/hourly-pattern": {
+		Summary:  "Activity by hour of day (24 slices)",
+		Response: []models.TimeSpaceSlice{},
+	},
+	"GET /api/v1/stats/spatial-complexity": statsList("Spatial complexity of the trajectory per day or month", models.SpatialComplexity{},
+		openapi.Param{Name: "period", Enum: []string{"day", "month", "all"}, Description: "Period of each row, default day"},
+		openapi.Param{Name: "from", Description: "First date, YYYY-MM-DD"},
+		openapi.Param{Name: "to", Description: "Last date, YYYY-MM-DD"}),
+	"GET /api/v1/stats/road-overlap":       {Summary: "Overlap of the trajectory with the road network", Response: models.RoadOverlapSummary{}},
+	"GET /api/v1/stats/rail-lines": {
+		Summary:     "Mileage on each high-speed rail line",
+		Description: "TRAIN segments matched by the rail_lines analyzer against the embedded China high-speed rail lines, e.g. 京广高铁. A segment changing lines counts toward each line it travelled.",
+		Params:      []openapi.Param{{Name: "year", Type: "integer", Description: "Single year; all years without it"}},
+		Response:    models.RailLineStats{},
+	},
+	"GET /api/v1/stats/commute": {
+		Summary:  "Commute patterns",
+		Params:   []openapi.Param{{Name: "direction", Enum: []string{"HOME_TO_WORK", "WORK_TO_HOME"}}},
+		Response: models.CommuteStats{},
+	},
+	"GET /api/v1/stats/routes": {
+		Summary:  "Frequently travelled routes",
+		Query:    models.RouteClusterFilter{},
+		Params:   listParams,
+		Response: openapi.List{Of: models.RouteCluster{}},
+	},
+	"GET /api/v1/stats/health-correlation": {
+		Summary:  "Correlation of health data with movement",
+		Params:   dateRangeParams,
+		Response: models.HealthCorrelationStats{},
+	},
+	"POST /api/v1/stats/batch": {
+		Summary: "Run several stats queries in one request",
+		Description: "Each query names a stats route below /api/v1/stats/ and its query parameters. " +
+			"Queries run concurrently; results are keyed by query name, and a failed query does not fail the batch.",
+		Body:     handler.BatchRequest{},
+		Response: openapi.Object{"results": map[string]handler.BatchResult{}},
+	},
+
+	// Visualization
+	"GET /api/v1/viz/grid-cells": {
+		Summary:  "Grid cells in a bounding box",
+		Query:    models.GridFilter{},
+		Response: openapi.Items{Of: models.GridCell{}},
+	},
+	"GET /api/v1/viz/heatmap": {
+		Summary: "Heatmap data",
+		Description: "With bbox, zoom, bucket or source the cells are aggregated dynamically for the zoom " +
+			"level and the data is a HeatmapGridResponse; otherwise grid cells of a fixed level are returned.",
+		Query:    models.GridFilter{},
+		Params:   params(openapi.Params(models.HeatmapGridFilter{}), []openapi.Param{{Name: "metric", Description: "Weighting of fixed-level cells"}}),
+		Response: models.HeatmapResponse{},
+	},
+	"GET /api/v1/viz/rendering": {
+		Summary:  "Points to render at a level of detail",
+		Query:    models.RenderFilter{},
+		Response: openapi.Items{Of: models.TrackPoint{}},
+	},
+	"GET /api/v1/viz/polylines": {
+		Summary: "Simplified segment geometry for a zoom level",
+		Description: "Segments simplified by the trajectory_simplification skill, at the level of detail " +
+			"for the zoom: LOD 0 below zoom 10, LOD 1 below zoom 14, LOD 2 from zoom 14. " +
+			"Polylines use the encoded polyline format (precision 1e5) unless format=coordinates.",
+		Query:    models.PolylineFilter{},
+		Response: models.PolylineResponse{},
+	},
+	"GET /api/v1/viz/playback": {
+		Summary: "Positions at fixed time steps for animated replay",
+		Description: "Positions are interpolated between the track points around each step, with the speed and " +
+			"heading between those points and the mode of the covering segment. No frame is produced where " +
+			"points are more than max_gap seconds apart; those ranges are listed as gaps. Ranges longer than " +
+			"5000 steps are replayed with a coarser step.",
+		Query:    models.PlaybackFilter{},
+		Response: models.PlaybackResponse{},
+	},
+	"GET /api/v1/viz/time-slices": {
+		Summary: "Point counts by time slice",
+		Params: params(timeRangeParams, []openapi.Param{
+			{Name: "granularity", Enum: []string{"day", "month", "year"}},
+		}),
+		Response: map[string]interface{}{},
+	},
+
+	// Summaries, dashboard and reports
+	"GET /api/v1/summary/daily": {
+		Summary:  "Daily summaries of a date range",
+		Query:    models.DailySummaryFilter{},
+		Response: models.DailyTimeline{},
+	},
+	"GET /api/v1/dashboard": {
+		Summary:  "Dashboard of one day",
+		Params:   []openapi.Param{{Name: "date", Description: "YYYY-MM-DD, default today"}},
+		Response: models.Dashboard{},
+	},
+	"GET /api/v1/insights": statsList("Generated findings per month and year, newest first", models.Insight{},
+		openapi.Param{Name: "bucket_type", Enum: []string{"month", "year"}},
+		openapi.Param{Name: "bucket_key", Description: "YYYY-MM or YYYY"},
+		openapi.Param{Name: "category", Enum: []string{"FOOTPRINT", "DISTANCE", "COMMUTE", "EXPLORATION", "RECORD"}},
+		openapi.Param{Name: "rule", Description: "Catalogue rule, e.g. new_counties or commute_duration"}),
+	"GET /api/v1/search": {
+		Summary:     "Search stays, trips, places and events",
+		Description: "Every word of q must match the start of a word in a label, note, admin name, route or date (YYYY-MM-DD). Best matches come first; sort=start_time orders by time instead.",
+		Params: params([]openapi.Param{
+			{Name: "q", Required: true, Description: "Words to find, e.g. 广州 or 2024-05"},
+			{Name: "type", Enum: []string{"stay", "trip", "place", "event"}},
+		}, listParams),
+		Response: openapi.List{Of: models.SearchResult{}},
+	},
+	"GET /api/v1/reports/annual/:year": {
+		Summary:  "Annual report",
+		Params:   []openapi.Param{{Name: "format", Enum: []string{"json", "html"}, Description: "html renders a printable page"}},
+		Response: models.AnnualReport{},
+	},
+
+	// Trips
+	"GET /api/v1/trips": {
+		Summary:     "List trips",
+		Description: "wet, minTemp and maxTemp match the weather looked up by weather_enrichment; trips without weather only match when none of them is given.",
+		Query:       models.TripFilter{},
+		Response:    openapi.Paged{Of: models.Trip{}},
+	},
+	"GET /api/v1/trips/od-matrix": {
+		Summary:  "Origin-destination matrix of trips",
+		Query:    models.ODMatrixFilter{},
+		Response: models.ODMatrix{},
+	},
+	"GET /api/v1/trips/:id": {
+		Summary:  "Get a trip with its segments, points, end stays and neighbouring trips",
+		Params:   []openapi.Param{maxPointsParam},
+		Response: models.TripDetail{},
+	},
+	"GET /api/v1/trips/:id/export.gpx": {Summary: "Export a trip as GPX", Response: openapi.Raw{ContentType: "application/gpx+xml"}},
+	"GET /api/v1/trips/:id/photos":     {Summary: "Photos taken during a trip, in time order", Response: models.PhotoSet{}},
+	"POST /api/v1/trips/:id/split": {
+		Summary:     "Split a trip",
+		Description: "Records a split before the trip's first segment starting at or after time and queues a full trip construction recompute. Every later run replays it; trip IDs change with the recompute. 400 when the time leaves no segment on one side.",
+		Body:        models.TripSplitRequest{},
+		Response:    models.TripEditResult{},
+	},
+	"POST /api/v1/trips/merge": {
+		Summary:     "Merge consecutive trips",
+		Description: "Records a merge of the trips, which must follow each other, and queues a full trip construction recompute. Later edits override earlier ones where they overlap.",
+		Body:        models.TripMergeRequest{},
+		Response:    models.TripEditResult{},
+	},
+	"GET /api/v1/trips/edits": {Summary: "List manual trip splits and merges", Response: []models.TripEdit{}},
+	"DELETE /api/v1/trips/edits/:id": {
+		Summary:     "Remove a trip edit",
+		Description: "Deletes the split or merge and queues a full trip construction recompute without it.",
+		Response:    models.TripEditResult{},
+	},
+	"GET /api/v1/share/trip/:id.png": {
+		Summary:     "Share card of a trip",
+		Description: "A 1200x630 PNG with the trip's track over a plain graticule and its distance, duration, cities and average speed.",
+		Response:    openapi.Raw{ContentType: "image/png"},
+	},
+	"GET /api/v1/share/day/:date.png": {
+		Summary:     "Share card of a day",
+		Description: "A 1200x630 PNG with the day's movement over a plain graticule and its distance, time on the move, cities and trips. date is YYYY-MM-DD.",
+		Response:    openapi.Raw{ContentType: "image/png"},
+	},
+	"GET /api/v1/segments/:id": {
+		Summary:  "Get a segment with its points, neighbouring segments and trip",
+		Params:   []openapi.Param{maxPointsParam},
+		Response: models.SegmentDetail{},
+	},
+	"PATCH /api/v1/segments/:id/mode": {
+		Summary:     "Correct the transport mode of a segment",
+		Description: "Sets the mode (WALK, BIKE, CAR, TRAIN or PLANE), stores the correction and queues full recomputes of speed_events, directional_bias, mode_stats and carbon_footprint. Full transport_mode runs reapply corrections to the segments covering the corrected span and calibrate their speed cutoffs with them; the transport_mode preview shows the calibrated cutoffs.",
+		Body:        models.SegmentModeRequest{},
+		Response:    models.SegmentModeResult{},
+	},
+	"GET /api/v1/segments/mode-corrections": {Summary: "List segment mode corrections, newest first", Response: []models.ModeCorrection{}},
+	"GET /api/v1/stays/:id": {
+		Summary:  "Get a stay with its points, annotation, neighbouring stays and trips",
+		Params:   []openapi.Param{maxPointsParam},
+		Response: models.StayDetail{},
+	},
+	"GET /api/v1/stays/:id/photos": {Summary: "Photos taken during a stay, in time order", Response: models.PhotoSet{}},
+
+	// Places
+	"GET /api/v1/places": {
+		Summary: "List places",
+		Description: "Stays clustered across time by the geohash neighbourhood of their center, with visit statistics. " +
+			"Most visited first; stays, annotations and trips reference them by place_id.",
+		Query:    models.PlaceFilter{},
+		Params:   listParams,
+		Response: openapi.List{Of: models.Place{}},
+	},
+	"GET /api/v1/places/:id": {Summary: "Get a place with its nearby venues", Response: models.Place{}},
+	"PUT /api/v1/places/:id": {
+		Summary:     "Name a place",
+		Description: "An empty name clears it. Named places keep their name across re-clustering and are kept without stays.",
+		Body:        models.PlaceNameRequest{},
+		Response:    models.Place{},
+	},
+
+	// Photos
+	"GET /api/v1/photos": {
+		Summary:     "List photos",
+		Description: "Photos with the time and place they were taken, in the order taken. located=false lists the photos neither geotagged nor placed on the track.",
+		Query:       models.PhotoFilter{},
+		Params:      listParams,
+		Response:    openapi.List{Of: models.Photo{}},
+	},
+	"GET /api/v1/photos/:id": {Summary: "Get a photo", Response: models.Photo{}},
+	"GET /api/v1/photos/:id/file": {
+		Summary:     "Image of a photo",
+		Description: "The original file of photos scanned from PHOTO_DIR, the preview of Immich photos. 503 when the photo's source is no longer configured.",
+		Response:    openapi.Raw{ContentType: "image/jpeg"},
+	},
+	"POST /api/v1/photos/import/scan": {
+		Summary: "Import photo geotags from PHOTO_DIR",
+		Description: "Reads the EXIF capture time, GPS position and camera of the JPEG, HEIC and raw photos under PHOTO_DIR (or its subdirectory path), " +
+			"skipping files imported before and unchanged since. Google Takeout JSON sidecars supply a missing time or location. " +
+			"Photos without a location are placed on the track, interpolated between the points within 10 minutes of the capture time. 503 when PHOTO_DIR is not set.",
+		Body:     models.PhotoScanRequest{},
+		Response: models.PhotoImportResult{},
+	},
+	"POST /api/v1/photos/import/immich": {
+		Summary:     "Import photo geotags from Immich",
+		Description: "Reads the metadata Immich keeps for the photos of its library taken after since; no file is downloaded. Photos without a location are placed on the track. 503 when IMMICH_URL or IMMICH_API_KEY is not set.",
+		Body:        models.PhotoImmichRequest{},
+		Response:    models.PhotoImportResult{},
+	},
+	"POST /api/v1/ingest/owntracks": {
+		Summary:     "Ingest live OwnTracks locations",
+		Description: "Endpoint for the OwnTracks app in HTTP mode: a message or an array of messages. Locations are validated and written at once; other message types are ignored. The device is user/device from the X-Limit-U and X-Limit-D headers (or the u and d query parameters). Requires HTTP Basic auth when OWNTRACKS_USER is set, or the INGEST_TOKEN as a bearer token or token query parameter. Incremental analysis runs every INGEST_ANALYSIS_INTERVAL while new points are pending. Responds with an empty array, as the app expects.",
+		Params: []openapi.Param{
+			{Name: "u", Type: "string", Description: "OwnTracks user, when the X-Limit-U header is not sent"},
+			{Name: "d", Type: "string", Description: "OwnTracks device, when the X-Limit-D header is not sent"},
+		},
+		Body:     importer.OwnTracksMessage{},
+		Response: []interface{}{},
+		Bare:     true,
+	},
+	"POST /api/v1/ingest/overland": {
+		Summary:     "Ingest live Overland locations",
+		Description: "Endpoint for the Overland app: a batch of GeoJSON Point features in locations. Invalid locations are dropped and counted instead of failing the batch. Motion, battery level and battery state are kept on the points. Authenticated like the OwnTracks endpoint. Responds with result ok, which the app requires before it deletes the batch.",
+		Params: []openapi.Param{
+			{Name: "device", Type: "string", Description: "Device of the points, overriding their device_id"},
+		},
+		Body:     openapi.Object{"locations": []openapi.Object{}},
+		Response: openapi.Object{"result": "", "received": 0, "rejected": 0},
+		Bare:     true,
+	},
+	"GET /api/v1/ingest/gpslogger": {
+		Summary:     "Ingest a live GPSLogger location",
+		Description: "Endpoint for the custom URL of GPSLogger for Android, e.g. ?lat=%LAT&lon=%LON&time=%TIME&acc=%ACC&batt=%BATT&ischarging=%ISCHARGING&act=%ACT&aid=%AID. Authenticated like the OwnTracks endpoint.",
+		Params:      gpsloggerParams,
+		Response:    models.IngestResult{},
+	},
+	"POST /api/v1/ingest/gpslogger": {
+		Summary:     "Ingest a live GPSLogger location from a form",
+		Description: "Same as the GET endpoint with the parameters in a form body, for GPSLogger's POST custom URL mode.",
+		Params:      gpsloggerParams,
+		Response:    models.IngestResult{},
+	},
+	"GET /api/v1/analysis/status": {
+		Summary:     "Algorithm versions and staleness of the analyzers",
+		Description: "Lists the analyzers in dependency order with their current algorithm version and the version their results were last fully computed with. An analyzer is stale when that version differs, when an analyzer it depends on was fully recomputed since, or when one is stale itself. Stale analyzers of the analysis chain run as a full recompute on the next chain run, even without new points; the others are only reported. rows_by_version counts the rows of each output table by algo_version.",
+		Response:    models.AnalysisStatus{},
+	},
+	"POST /api/v1/analysis/preview": {
+		Summary:     "Preview an analyzer on a time window without writing",
+		Description: "Runs the analyzer on the tracks between start_time and end_time (at most 31 days) and returns its results instead of writing them to derived tables, so thresholds can be tuned before a recompute. thresholds overrides the analyzer's section of the default threshold profile; unknown keys are rejected. Supported analyzers: transport_mode (segments from track points), speed_events (events of the CAR segments) and trip_construction (trips from the segments and stays).",
+		Body:        models.AnalysisPreviewRequest{},
+		Response:    models.AnalysisPreview{},
+	},
+	"GET /api/v1/ingest/status": {
+		Summary:  "Describe live ingestion",
+		Response: models.IngestStatus{},
+	},
+	"GET /api/v1/qa/outliers": {
+		Summary:     "Points flagged by outlier detection or manually reviewed",
+		Description: "Lists flagged points with their reason codes; qa_status narrows the list, e.g. to MANUAL_PASS.",
+		Query:       models.OutlierFilter{},
+		Params:      listParams,
+		Response:    openapi.List{Of: models.OutlierPoint{}},
+	},
+	"POST /api/v1/qa/outliers/confirm": {
+		Summary:     "Confirm points as outliers",
+		Description: "Sets qa_status MANUAL_FAIL; outlier detection keeps manual verdicts on later runs.",
+		Body:        models.OutlierReviewRequest{},
+		Response:    models.OutlierReviewResult{},
+	},
+	"POST /api/v1/qa/outliers/unflag": {
+		Summary:     "Clear the outlier flag of points",
+		Description: "Sets qa_status MANUAL_PASS; outlier detection keeps manual verdicts on later runs.",
+		Body:        models.OutlierReviewRequest{},
+		Response:    models.OutlierReviewResult{},
+	},
+	"POST /api/v1/qa/outliers/reset": {
+		Summary:  "Drop the manual review of points",
+		Body:     models.OutlierReviewRequest{},
+		Response: models.OutlierReviewResult{},
+	},
+	"GET /api/v1/export/anonymized": {
+		Summary: "Stream an anonymized, shareable track dataset",
+		Description: "Coordinates are rounded, timestamps shifted by one random offset, administrative names generalized to city level, " +
+			"and points in privacy zones excluded or fuzzed.",
+		Query:    models.AnonymizedExportFilter{},
+		Response: openapi.Raw{ContentType: "application/geo+json"},
+	},
+
+	// Keyboard and mouse
+	"GET /api/v1/keyboard/stats": {
+		Summary:  "Keyboard and mouse overview",
+		Query:    models.InputActivityFilter{},
+		Response: models.InputActivityStats{},
+	},
+	"GET /api/v1/keyboard/heatmap": {
+		Summary:  "Input activity by weekday and hour",
+		Query:    models.InputActivityFilter{},
+		Response: models.InputHeatmap{},
+	},
+	"GET /api/v1/keyboard/work-correlation": {
+		Summary:  "Input activity against time at work",
+		Query:    models.InputActivityFilter{},
+		Response: models.InputWorkCorrelation{},
+	},
+	"POST /api/v1/keyboard/import": {
+		Summary:  "Import keyboard and mouse logs",
+		Params:   []openapi.Param{{Name: "device"}},
+		Upload:   []string{"file", "files"},
+		Response: openapi.Object{"files": []importFileResult[models.InputActivityImportResult]{}},
+	},
+
+	// Journeys
+	"GET /api/v1/journeys": {
+		Summary:  "List flights and train journeys",
+		Query:    models.JourneyFilter{},
+		Response: openapi.Paged{Of: models.Journey{}},
+	},
+	"GET /api/v1/journeys/stats": {
+		Summary: "Journey statistics",
+		Params: []openapi.Param{
+			{Name: "type", Enum: []string{models.JourneyTypeFlight, models.JourneyTypeTrain}},
+			{Name: "limit", Type: "integer", Description: "Entries per ranking, default 10"},
+		},
+		Response: models.JourneyStats{},
+	},
+	"GET /api/v1/journeys/:id": {Summary: "Get a journey", Response: models.Journey{}},
+	"POST /api/v1/journeys/import": {
+		Summary:  "Import journey records",
+		Params:   []openapi.Param{{Name: "type", Enum: []string{models.JourneyTypeFlight, models.JourneyTypeTrain}, Description: "Type of rows without one"}},
+		Upload:   []string{"file", "files"},
+		Response: openapi.Object{"files": []importFileResult[models.JourneyImportResult]{}},
+	},
+	"POST /api/v1/journeys/link": {
+		Summary:  "Link journeys to trajectory segments",
+		Response: openapi.Object{"linked": int64(0)},
+	},
+	"GET /api/v1/flights": {
+		Summary:     "List flights",
+		Description: "Booked flights and, with source detected, flights the flight_detection analyzer found in the trajectory: long gaps or airborne-speed runs between two airports. Located flights carry their great-circle path.",
+		Query:       models.JourneyFilter{},
+		Response:    openapi.Paged{Of: models.Journey{}},
+	},
+	"GET /api/v1/trains": {
+		Summary:  "List train journeys",
+		Query:    models.JourneyFilter{},
+		Response: openapi.Paged{Of: models.Journey{}},
+	},
+
+	// Screen time
+	"GET /api/v1/screentime/stats": {
+		Summary:  "Screen time overview",
+		Query:    models.ScreenTimeFilter{},
+		Response: models.ScreenTimeSummary{},
+	},
+	"GET /api/v1/screentime/top-apps": {
+		Summary:  "Most used apps",
+		Query:    models.ScreenTimeFilter{},
+		Response: openapi.Items{Of: models.AppUsage{}},
+	},
+	"GET /api/v1/screentime/categories": {
+		Summary:  "Usage by category",
+		Query:    models.ScreenTimeFilter{},
+		Response: openapi.Items{Of: models.CategoryUsage{}},
+	},
+	"GET /api/v1/screentime/trends/weekly": {
+		Summary:  "Weekly usage",
+		Query:    models.ScreenTimeFilter{},
+		Response: openapi.Items{Of: models.WeeklyUsage{}},
+	},
+	"POST /api/v1/screentime/import": {
+		Summary:  "Import Screen Time or Digital Wellbeing CSV exports",
+		Params:   []openapi.Param{{Name: "device"}},
+		Upload:   []string{"file", "files"},
+		Response: openapi.Object{"files": []importFileResult[models.ScreenTimeImportResult]{}},
+	},
+
+	// Health data
+	"GET /api/v1/health-data/stats": {
+		Summary:  "Health overview",
+		Query:    models.HealthFilter{},
+		Response: models.HealthStats{},
+	},
+	"GET /api/v1/health-data/workouts": {
+		Summary:  "List workouts",
+		Query:    models.HealthFilter{},
+		Response: openapi.Items{Of: models.Workout{}},
+	},
+	"POST /api/v1/health-data/import": {
+		Summary:     "Import an Apple Health export",
+		Description: "Accepts export.xml or export.zip and imports it in the background; poll the returned task.",
+		Upload:      []string{"file"},
+		Response:    models.HealthImportTask{},
+	},
+	"GET /api/v1/health-data/import/tasks": {
+		Summary:  "List health import tasks",
+		Params:   []openapi.Param{{Name: "limit", Type: "integer", Description: "Default 20"}},
+		Response: openapi.Items{Of: models.HealthImportTask{}},
+	},
+	"GET /api/v1/health-data/import/tasks/:id": {Summary: "Get a health import task", Response: models.HealthImportTask{}},
+
+	// Administration
+	"POST /api/v1/admin/geocoding/tasks": {Summary: "Start a geocoding task", Response: models.GeocodingTask{}},
+	"GET /api/v1/admin/geocoding/tasks": {
+		Summary:  "List geocoding tasks",
+		Params:   taskListParams,
+		Response: openapi.Object{"tasks": []models.GeocodingTask{}, "limit": 0, "offset": 0},
+	},
+	"GET /api/v1/admin/geocoding/tasks/:id":    {Summary: "Get a geocoding task", Response: models.GeocodingTask{}},
+	"DELETE /api/v1/admin/geocoding/tasks/:id": {Summary: "Cancel a geocoding task", Response: openapi.Object{"message": ""}},
+	"POST /api/v1/admin/analysis/tasks": {
+		Summary:     "Create an analysis task",
+		Description: "The task waits in the job queue until one of ANALYSIS_WORKERS (default 2) workers is free and no other task of the same skill runs; queue_position is set while it waits. Tasks triggered through the API go before those of the scheduler, automatic import and live ingest.",
+		Body:        handler.CreateTaskRequest{},
+		Response:    models.AnalysisTask{},
+	},
+	"GET /api/v1/admin/analysis/tasks": {
+		Summary:  "List analysis tasks",
+		Params:   params([]openapi.Param{{Name: "skill_name"}}, taskListParams),
+		Response: openapi.Object{"tasks": []models.AnalysisTask{}, "limit": 0, "offset": 0},
+	},
+	"GET /api/v1/admin/analysis/tasks/:id": {Summary: "Get an analysis task", Response: models.AnalysisTask{}},
+	"GET /api/v1/admin/analysis/queue": {
+		Summary:  "List the running and queued analysis tasks",
+		Response: models.AnalysisQueue{},
+	},
+	"DELETE /api/v1/admin/analysis/tasks/:id": {
+		Summary:     "Cancel an analysis task",
+		Description: "Marks the task failed, removes it from the job queue and cancels its analyzer if it runs in this process.",
+		Response:    openapi.Object{"message": ""},
+	},
+	"POST /api/v1/admin/analysis/tasks/:id/resume": {
+		Summary:     "Resume an interrupted analysis task",
+		Description: "Tasks still running when the server shuts down are cancelled after SHUTDOWN_TIMEOUT (default 30s) and marked interrupted, as are tasks left running by a killed process. They are resumed automatically at startup, up to 3 times, unless RESUME_INTERRUPTED_TASKS is false. Incremental tasks continue with the points not analyzed yet; full recomputes start over. 409 if the task is not interrupted.",
+		Response:    models.AnalysisTask{},
+	},
+	"POST /api/v1/admin/analysis/trigger-chain": {
+		Summary:  "Queue every analyzer in dependency order",
+		Body:     handler.TriggerAnalysisChainRequest{},
+		Response: openapi.Object{"message": "", "task_ids": []int64{}},
+	},
+	"POST /api/v1/admin/recompute": {
+		Summary:     "Plan or run a full recompute of analyzers",
+		Description: "Without confirm, returns the plan: the analyzers in execution order (analysis chain order, the whole chain when none are named) and, for each, the tables it truncates, deletes rows from, updates, or replaces, with estimated rows. With confirm true, the same plan runs in the background as full-recompute tasks created by recompute, each analyzer's tables being cleared right before it runs; 409 while analysis tasks are running. With start_time and end_time, analyzers that can recompute a window (admin_crossings) only rebuild the rows of that window; the others rebuild all time and the plan warns about it.",
+		Body:        models.RecomputeRequest{},
+		Response:    models.RecomputePlan{},
+	},
+	"GET /api/v1/admin/schedules": {
+		Summary:     "List the analysis schedules",
+		Description: "The analysis chain runs incrementally every night and as a full recompute every week, per SCHEDULE_INCREMENTAL and SCHEDULE_FULL. A run is skipped when no track points were imported or deleted since the schedule last completed.",
+		Response:    openapi.Items{Of: models.Schedule{}},
+	},
+	"GET /api/v1/admin/schedules/runs": statsList("Run history of the analysis schedules, latest first", models.ScheduleRun{},
+		openapi.Param{Name: "schedule", Description: "incremental or full"},
+		openapi.Param{Name: "status", Enum: []string{"running", "completed", "failed", "skipped"}}),
+	"GET /api/v1/admin/import-watch": {
+		Summary:     "Describe the import watch directory",
+		Description: "With IMPORT_WATCH_DIR set, GPX, KML, CSV and JSON files that stop changing in that directory are imported, moved to the archive (or failed) directory, and followed by incremental analysis. A file with the same content as an imported one is archived without importing it again.",
+		Response:    models.WatchImportStatus{},
+	},
+	"GET /api/v1/admin/import-watch/files": statsList("Files processed from the import watch directory, latest first", models.WatchImport{},
+		openapi.Param{Name: "status", Enum: []string{"imported", "duplicate", "failed"}}),
+	"GET /api/v1/admin/config": {
+		Summary:     "Show the running configuration",
+		Description: "Settings by name, with passwords, keys, tokens and the URLs and user names that can carry credentials redacted. They come from the YAML file at CONFIG_FILE (default ./config.yaml, see config.example.yaml), whose nested keys are joined with underscores into the environment variable names (rate_limit: {rps: 5} is RATE_LIMIT_RPS), overridden by non-empty environment variables. 503 when no configuration was loaded.",
+		Response:    map[string]interface{}{},
+	},
+	"POST /api/v1/admin/config/reload": {
+		Summary:     "Reload the configuration file",
+		Description: "Same as sending SIGHUP. Rate limits, cache TTL and size, analysis schedules, DISABLED_ANALYZERS, THRESHOLD_PROFILE, the import watch and photo directories and the ingest credentials are applied at once; other changed settings keep their running values and are listed in restart_required. Environment variables cannot change while the server runs, so only settings from the file can be reloaded.",
+		Response:    config.ReloadResult{},
+	},
+	"POST /api/v1/admin/backup": {
+		Summary:     "Back up the database",
+		Description: "Takes a consistent snapshot with VACUUM INTO while the server keeps running, gzips it and stores it in BACKUP_DIR, or in the S3-compatible bucket BACKUP_S3_BUCKET at BACKUP_S3_ENDPOINT. Older backups beyond BACKUP_KEEP (default 7) or older than BACKUP_MAX_AGE (e.g. 720h) are then deleted; the newest is always kept. 409 while another backup runs. Restore with `server restore [-name NAME | -file PATH]` while the server is stopped.",
+		Response:    models.BackupResult{},
+	},
+	"GET /api/v1/admin/backups": {
+		Summary:  "List the backups in the backup store, newest first",
+		Response: models.BackupList{},
+	},
+	"GET /api/v1/admin/explain": {
+		Summary:     "Show the query plans of the hot queries",
+		Description: "EXPLAIN QUERY PLAN of the queries behind playback, track point lists, time distributions, the raw point heatmap and the bucketed statistics, built the way the API builds them. scans lists the steps that read a whole table or index, which is what a query losing its index looks like. Planner statistics are refreshed at startup after migrations. 404 for an unknown name.",
+		Params: []openapi.Param{
+			{Name: "name", Description: "Query to explain, e.g. playback_points; all queries when omitted"},
+		},
+		Response: []models.QueryPlan{},
+	},
+	"GET /api/v1/admin/notifications": {
+		Summary:     "Describe the notification channels",
+		Description: "Channels come from WEBHOOK_URLS, TELEGRAM_BOT_TOKEN with TELEGRAM_CHAT_ID, and BARK_URL; NOTIFY_EVENTS narrows the event types sent. Webhooks receive the event as JSON with X-Records-Event and X-Records-Delivery headers; with WEBHOOK_SECRET set, X-Records-Signature is sha256= and the hex HMAC-SHA256 of X-Records-Timestamp, a dot and the body.",
+		Response:    models.NotificationStatus{},
+	},
+	"POST /api/v1/admin/notifications/test": {
+		Summary:     "Send a test notification to every channel",
+		Description: "The event is queued and delivered in the background; check the delivery list for the outcome. 409 when no channel is configured.",
+		Response:    openapi.Object{"event_id": ""},
+	},
+	"GET /api/v1/admin/notifications/deliveries": statsList("Notification deliveries, latest first; failed ones were retried with backoff up to NOTIFY_MAX_ATTEMPTS times", models.NotificationDelivery{},
+		openapi.Param{Name: "event_type", Enum: []string{"analysis.completed", "analysis.failed", "record.new", "import.completed", "import.failed", "test"}},
+		openapi.Param{Name: "status", Enum: []string{"delivered", "failed"}}),
+	"GET /api/v1/admin/thresholds": {
+		Summary:  "List threshold profiles",
+		Response: openapi.Items{Of: models.ThresholdProfile{}},
+	},
+	"POST /api/v1/admin/thresholds": {
+		Summary:  "Create a threshold profile",
+		Body:     models.ThresholdProfileRequest{},
+		Response: models.ThresholdProfile{},
+	},
+	"GET /api/v1/admin/thresholds/:id": {Summary: "Get a threshold profile", Response: models.ThresholdProfile{}},
+	"PUT /api/v1/admin/thresholds/:id": {
+		Summary:  "Update a threshold profile",
+		Body:     models.ThresholdProfileRequest{},
+		Response: models.ThresholdProfile{},
+	},
+	"GET /api/v1/admin/thresholds/:id/effective": {
+		Summary:  "Thresholds of a profile merged with the defaults",
+		Response: models.EffectiveThresholds{},
+	},
+	"POST /api/v1/admin/thresholds/:id/default": {Summary: "Make a threshold profile the default", Response: models.ThresholdProfile{}},
+
+	// Emissions model
+	"GET /api/v1/admin/emission-factors": {
+		Summary:  "List emission factors (g CO2e per km per mode)",
+		Response: openapi.Items{Of: models.EmissionFactor{}},
+	},
+	"PUT /api/v1/admin/emission-factors/:mode": {
+		Summary:     "Override the emission factor of a mode",
+		Description: "Adds the mode when it has no factor yet. Queues full recomputes of mode_stats and carbon_footprint.",
+		Body:        models.EmissionFactorRequest{},
+		Response:    openapi.Object{"factor": models.EmissionFactor{}, "task_ids": []int64{}},
+	},
+	"POST /api/v1/admin/emission-factors/:mode/reset": {
+		Summary:  "Restore the built-in emission factor of a mode",
+		Response: openapi.Object{"factor": models.EmissionFactor{}, "task_ids": []int64{}},
+	},
+
+	// Privacy zones
+	"GET /api/v1/admin/privacy-zones": {
+		Summary:  "List privacy zones",
+		Params:   []openapi.Param{{Name: "include_deleted", Type: "boolean", Description: "Also list soft-deleted zones"}},
+		Response: openapi.Items{Of: models.PrivacyZone{}},
+	},
+	"POST /api/v1/admin/privacy-zones": {
+		Summary:     "Create a privacy zone",
+		Description: "Points inside enabled zones are excluded or fuzzed in exports and map rendering endpoints; statistics still count them.",
+		Body:        models.PrivacyZoneRequest{},
+		Response:    models.PrivacyZone{},
+	},
+	"GET /api/v1/admin/privacy-zones/:id": {Summary: "Get a privacy zone", Response: models.PrivacyZone{}},
+	"PUT /api/v1/admin/privacy-zones/:id": {
+		Summary:  "Update a privacy zone",
+		Body:     models.PrivacyZoneRequest{},
+		Response: models.PrivacyZone{},
+	},
+	"DELETE /api/v1/admin/privacy-zones/:id":       {Summary: "Soft-delete a privacy zone", Response: models.PrivacyZone{}},
+	"POST /api/v1/admin/privacy-zones/:id/restore": {Summary: "Restore a soft-deleted privacy zone", Response: models.PrivacyZone{}},
+}
@@ -1,14 +1,25 @@
 package api
 
 import (
+	"context"
+	"log"
 	"net/http"
-	"time"
+	"path/filepath"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/backup"
+	"github.com/jengzang/records-backend-go/internal/cache"
 	"github.com/jengzang/records-backend-go/internal/config"
 	"github.com/jengzang/records-backend-go/internal/database"
 	"github.com/jengzang/records-backend-go/internal/handler"
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/metrics"
 	"github.com/jengzang/records-backend-go/internal/middleware"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/mqtt"
+	"github.com/jengzang/records-backend-go/internal/notify"
+	"github.com/jengzang/records-backend-go/internal/openapi"
 	"github.com/jengzang/records-backend-go/internal/repository"
 	"github.com/jengzang/records-backend-go/internal/service"
 )
@@ -19,11 +30,20 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	r := gin.New()
 
 	// Add custom middleware
-	r.Use(middleware.Logger())
+	apiKeys := middleware.NewAPIKeys(cfg.APIKeys)
+	r.Use(middleware.IdentifyCaller(apiKeys))
+	r.Use(middleware.Logger(cfg.LogFormat))
+	r.Use(middleware.Metrics())
 	r.Use(middleware.CORS())
-	r.Use(middleware.RateLimit(3, time.Second)) // 3 requests per second
+	apiLimiter := middleware.NewRateLimiter(cfg.RateLimit.Rate, cfg.RateLimit.Burst)
+	r.Use(middleware.RateLimit(apiLimiter))
 	r.Use(gin.Recovery())
 
+	// Shared by the expensive statistics endpoints so they cannot be hammered
+	// into holding the SQLite database
+	statsLimiter := middleware.NewRateLimiter(cfg.StatsRateLimit.Rate, cfg.StatsRateLimit.Burst)
+	statsLimit := middleware.RateLimit(statsLimiter)
+
 	// Initialize database
 	db := database.GetDB()
 
@@ -37,17 +57,79 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	tripRepo := repository.NewTripRepository(db)
 	gridRepo := repository.NewGridRepository(db)
 	vizRepo := repository.NewVisualizationRepository(db)
+	thresholdRepo := repository.NewThresholdRepository(db)
+	summaryRepo := repository.NewSummaryRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	journeyRepo := repository.NewJourneyRepository(db)
+	screenTimeRepo := repository.NewScreenTimeRepository(db)
+	inputActivityRepo := repository.NewInputActivityRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
+	privacyZoneRepo := repository.NewPrivacyZoneRepository(db)
+	qaRepo := repository.NewQARepository(db)
+	carbonRepo := repository.NewCarbonRepository(db)
+	weatherRepo := repository.NewWeatherRepository(db)
+	insightRepo := repository.NewInsightRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
+	detailRepo := repository.NewDetailRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	watchImportRepo := repository.NewWatchImportRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	placeRepo := repository.NewPlaceRepository(db)
+	photoRepo := repository.NewPhotoRepository(db)
+	explainRepo := repository.NewExplainRepository(db)
 
 	// Initialize services
 	trackService := service.NewTrackService(trackRepo)
-	statsService := service.NewStatsService(statsRepo)
+	privacyService := service.NewPrivacyService(privacyZoneRepo)
+	// 统计结果缓存：分析任务完成时按 skill 失效，CACHE_TTL 为 0 时不缓存
+	statsCache := cache.New("stats", cfg.CacheTTL, cfg.CacheMaxEntries)
+	analysis.OnTaskCompleted(func(skill string) { statsCache.Invalidate(skill) })
+	statsService := service.NewStatsService(statsRepo, statsCache)
 	geocodingService := service.NewGeocodingService(geocodingRepo)
-	analysisTaskService := service.NewAnalysisTaskService(analysisTaskRepo, db)
-	segmentService := service.NewSegmentService(segmentRepo)
+	notificationService := service.NewNotificationService(notificationRepo, statsRepo, reportRepo, notify.Config{
+		Channels:    notificationChannels(cfg),
+		Events:      cfg.NotifyEvents,
+		MaxAttempts: cfg.NotifyMaxAttempts,
+	})
+	analysisTaskService := service.NewAnalysisTaskService(analysisTaskRepo, database.GetReadDB(), notificationService, cfg.AnalysisWorkers)
+	segmentService := service.NewSegmentService(segmentRepo, analysisTaskService)
 	stayService := service.NewStayService(stayRepo)
-	tripService := service.NewTripService(tripRepo)
-	gridService := service.NewGridService(gridRepo)
-	vizService := service.NewVisualizationService(vizRepo)
+	placeService := service.NewPlaceService(placeRepo)
+	// 照片来源：PHOTO_DIR 目录扫描与 Immich（都可为空）
+	var immich *importer.ImmichClient
+	if cfg.ImmichURL != "" && cfg.ImmichAPIKey != "" {
+		immich = importer.NewImmichClient(cfg.ImmichURL, cfg.ImmichAPIKey)
+	}
+	photoService := service.NewPhotoService(photoRepo, tripRepo, stayRepo, cfg.PhotoDir, immich)
+	tripService := service.NewTripService(tripRepo, privacyService, analysisTaskService)
+	gridService := service.NewGridService(gridRepo, privacyService)
+	vizService := service.NewVisualizationService(vizRepo, privacyService)
+	playbackService := service.NewPlaybackService(vizRepo, privacyService)
+	importService := service.NewImportService(trackRepo, analysisTaskService, notificationService)
+	thresholdService := service.NewThresholdService(thresholdRepo)
+	summaryService := service.NewSummaryService(summaryRepo)
+	reportService := service.NewReportService(statsRepo, summaryRepo, reportRepo)
+	journeyService := service.NewJourneyService(journeyRepo)
+	screenTimeService := service.NewScreenTimeService(screenTimeRepo)
+	inputActivityService := service.NewInputActivityService(inputActivityRepo)
+	healthService := service.NewHealthService(healthRepo)
+	exportService := service.NewExportService(trackRepo, privacyService)
+	qaService := service.NewQAService(qaRepo)
+	carbonService := service.NewCarbonService(carbonRepo, statsCache, analysisTaskService)
+	weatherService := service.NewWeatherService(weatherRepo, statsCache)
+	insightService := service.NewInsightService(insightRepo, statsCache)
+	searchService := service.NewSearchService(searchRepo, statsCache)
+	detailService := service.NewDetailService(detailRepo, segmentRepo, stayRepo, tripRepo, privacyService)
+	shareService := service.NewShareService(tripService, summaryService, vizRepo, privacyService)
+	scheduleService := service.NewScheduleService(scheduleRepo, analysisTaskService)
+	ingestService := service.NewIngestService(trackRepo, analysisTaskService, cfg.IngestAnalysisInterval)
+	watchImportService := service.NewWatchImportService(watchImportRepo, importService,
+		cfg.ImportWatchDir, cfg.ImportArchiveDir, cfg.ImportWatchInterval)
+	backupService := service.NewBackupService(db, BackupStore(cfg), filepath.Dir(cfg.DBPath),
+		backup.Retention{Keep: cfg.BackupKeep, MaxAge: cfg.BackupMaxAge})
+	recomputeService := service.NewRecomputeService(database.GetReadDB(), analysisTaskService)
+	explainService := service.NewExplainService(explainRepo)
+	dashboardService := service.NewDashboardService(summaryService, stayService, screenTimeService, inputActivityService, healthService)
 
 	// Initialize handlers
 	trackHandler := handler.NewTrackHandler(trackService)
@@ -56,9 +138,147 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	analysisTaskHandler := handler.NewAnalysisTaskHandler(analysisTaskService)
 	segmentHandler := handler.NewSegmentHandler(segmentService)
 	stayHandler := handler.NewStayHandler(stayService)
+	placeHandler := handler.NewPlaceHandler(placeService)
+	photoHandler := handler.NewPhotoHandler(photoService)
 	tripHandler := handler.NewTripHandler(tripService)
 	gridHandler := handler.NewGridHandler(gridService)
 	vizHandler := handler.NewVisualizationHandler(vizService)
+	playbackHandler := handler.NewPlaybackHandler(playbackService)
+	importHandler := handler.NewImportHandler(importService)
+	thresholdHandler := handler.NewThresholdHandler(thresholdService)
+	summaryHandler := handler.NewSummaryHandler(summaryService)
+	reportHandler := handler.NewReportHandler(reportService)
+	journeyHandler := handler.NewJourneyHandler(journeyService)
+	screenTimeHandler := handler.NewScreenTimeHandler(screenTimeService)
+	inputActivityHandler := handler.NewInputActivityHandler(inputActivityService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	privacyHandler := handler.NewPrivacyHandler(privacyService)
+	exportHandler := handler.NewExportHandler(exportService)
+	qaHandler := handler.NewQAHandler(qaService)
+	carbonHandler := handler.NewCarbonHandler(carbonService)
+	weatherHandler := handler.NewWeatherHandler(weatherService)
+	insightHandler := handler.NewInsightHandler(insightService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	detailHandler := handler.NewDetailHandler(detailService)
+	shareHandler := handler.NewShareHandler(shareService)
+	scheduleHandler := handler.NewScheduleHandler(scheduleService)
+	watchImportHandler := handler.NewWatchImportHandler(watchImportService)
+	ingestHandler := handler.NewIngestHandler(ingestService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	backupHandler := handler.NewBackupHandler(backupService)
+	explainHandler := handler.NewExplainHandler(explainService)
+	recomputeHandler := handler.NewRecomputeHandler(recomputeService)
+	configHandler := handler.NewConfigHandler()
+
+	// 事件通知（Webhook、Telegram、Bark，都未配置时关闭）
+	notificationService.Start()
+
+	// 记录分析器算法版本的基线，版本变化后其结果标记为过期
+	if err := analysisTaskService.SeedVersions(); err != nil {
+		log.Printf("Warning: failed to seed analyzer versions: %v", err)
+	}
+
+	// 上次退出时未完成的分析任务标记为 interrupted，RESUME_INTERRUPTED_TASKS 开启时重新运行
+	if err := analysisTaskService.RecoverInterrupted(cfg.ResumeInterruptedTasks); err != nil {
+		log.Printf("Warning: failed to recover interrupted analysis tasks: %v", err)
+	}
+
+	// 定时分析：每晚增量分析、每周全量重算，没有新轨迹点时跳过
+	setSchedules := func(cfg *config.Config) {
+		schedules := []struct{ name, spec, taskType string }{
+			{"incremental", cfg.ScheduleIncremental, models.TaskTypeIncremental},
+			{"full", cfg.ScheduleFull, models.TaskTypeFullRecompute},
+		}
+		for _, sc := range schedules {
+			if err := scheduleService.SetSchedule(sc.name, sc.spec, sc.taskType); err != nil {
+				log.Printf("Warning: %s analysis schedule disabled: %v", sc.name, err)
+			}
+		}
+	}
+	setSchedules(cfg)
+	if err := scheduleService.Start(); err != nil {
+		log.Printf("Warning: failed to start analysis schedules: %v", err)
+	}
+
+	// 监视目录自动导入（IMPORT_WATCH_DIR 为空时关闭）
+	if err := watchImportService.Start(); err != nil {
+		log.Printf("Warning: import watch directory disabled: %v", err)
+	}
+
+	// OwnTracks 实时接收（MQTT_URL 为空时只接受 HTTP）
+	if cfg.MQTTURL != "" {
+		err := ingestService.SubscribeMQTT(mqtt.Config{
+			URL:      cfg.MQTTURL,
+			ClientID: cfg.MQTTClientID,
+			Username: cfg.MQTTUsername,
+			Password: cfg.MQTTPassword,
+			Topics:   cfg.MQTTTopics,
+		})
+		if err != nil {
+			log.Printf("Warning: MQTT ingestion disabled: %v", err)
+		}
+	}
+	ingestService.Start()
+
+	// 手机端只支持 Basic Auth 或固定令牌
+	ingestCredentials := middleware.NewIngestCredentials(cfg.OwnTracksUser, cfg.OwnTracksPassword, cfg.IngestToken)
+	ingestAuth := middleware.IngestAuth(ingestCredentials)
+
+	// 阈值配置：THRESHOLD_PROFILE 指定的配置设为默认
+	activateThresholdProfile := func(name string) {
+		if name == "" {
+			return
+		}
+		if err := thresholdService.ActivateProfile(name); err != nil {
+			log.Printf("Warning: failed to activate threshold profile: %v", err)
+		}
+	}
+	activateThresholdProfile(cfg.ThresholdProfile)
+
+	// 优雅退出：先排空（超时后取消）分析任务，再停止后台服务
+	onShutdown(func(ctx context.Context) {
+		analysisTaskService.Shutdown(ctx)
+		scheduleService.Stop()
+		watchImportService.Stop()
+		ingestService.Stop()
+		notificationService.Stop()
+	})
+
+	// 配置热加载（SIGHUP 或 POST /admin/config/reload）：其余设置需重启
+	config.OnReload(func(next *config.Config) {
+		apiKeys.Set(next.APIKeys)
+		apiLimiter.SetLimit(next.RateLimit.Rate, next.RateLimit.Burst)
+		statsLimiter.SetLimit(next.StatsRateLimit.Rate, next.StatsRateLimit.Burst)
+		statsCache.SetLimits(next.CacheTTL, next.CacheMaxEntries)
+		setSchedules(next)
+		if err := watchImportService.Reconfigure(next.ImportWatchDir, next.ImportArchiveDir, next.ImportWatchInterval); err != nil {
+			log.Printf("Warning: import watch directory disabled: %v", err)
+		}
+		photoService.SetPhotoDir(next.PhotoDir)
+		ingestCredentials.Set(next.OwnTracksUser, next.OwnTracksPassword, next.IngestToken)
+		activateThresholdProfile(next.ThresholdProfile)
+		analysisTaskService.SetWorkers(next.AnalysisWorkers)
+	})
+
+	// Prometheus 指标（队列深度在抓取时读取）
+	metrics.NewGaugeFunc("records_db_writer_queue_depth",
+		"Callers waiting for the SQLite write transaction.",
+		func() float64 { return float64(database.GetWriter().QueueDepth()) })
+	metrics.NewGaugeVecFunc("records_analysis_tasks",
+		"Analysis tasks waiting or running, by status.", "status",
+		func() map[string]float64 {
+			counts, err := analysisTaskService.CountActiveTasks()
+			if err != nil {
+				return nil
+			}
+			values := make(map[string]float64, len(counts))
+			for status, n := range counts {
+				values[status] = float64(n)
+			}
+			return values
+		})
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
@@ -71,6 +291,10 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 	// API 路由组
 	api := r.Group("/api/v1")
 	{
+		// OpenAPI 文档与 Swagger UI
+		api.GET("/openapi.json", openapi.Handler(func() *openapi.Document { return Spec(r) }))
+		api.GET("/docs", openapi.UIHandler(apiInfo.Title, "/api/v1/openapi.json", cfg.SwaggerUIAssets))
+
 		// 轨迹相关接口
 		tracks := api.Group("/tracks")
 		{
@@ -79,6 +303,9 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			tracks.GET("/points/:id", trackHandler.GetTrackPointByID)
 			tracks.GET("/ungeocoded", trackHandler.GetUngeocodedPoints)
 
+			// Import endpoints (GPX/KML upload)
+			tracks.POST("/import", importHandler.ImportTracks)
+
 			// Segments endpoints
 			tracks.GET("/segments", segmentHandler.GetSegments)
 			tracks.GET("/segments/:id", segmentHandler.GetSegmentByID)
@@ -92,22 +319,29 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			tracks.GET("/trips/:id", tripHandler.GetTripByID)
 
 			// Statistics endpoints
-			stats := tracks.Group("/statistics")
+			stats := tracks.Group("/statistics", statsLimit)
 			{
 				stats.GET("/footprint", statsHandler.GetFootprintStatistics)
+				stats.GET("/footprint/areas", statsHandler.GetVisitedAreas)
 				stats.GET("/time-distribution", statsHandler.GetTimeDistribution)
 				stats.GET("/speed-distribution", statsHandler.GetSpeedDistribution)
 			}
 		}
 
 		// 统计排行榜接口
-		stats := api.Group("/stats")
+		stats := api.Group("/stats", statsLimit, middleware.ETag(statsHandler.DataVersion))
 		{
 			stats.GET("/footprint/rankings", statsHandler.GetFootprintRankings)
+			stats.GET("/footprint/coverage", statsHandler.GetFootprintCoverage)
+			stats.GET("/countries", statsHandler.GetCountryVisits)
+			stats.GET("/countries/days-abroad", statsHandler.GetDaysAbroad)
 			stats.GET("/stay/rankings", statsHandler.GetStayRankings)
 			stats.GET("/extreme-events", statsHandler.GetExtremeEvents)
 			stats.GET("/admin-crossings", statsHandler.GetAdminCrossings)
+			stats.GET("/admin-visits", statsHandler.GetAdminVisits)
+			stats.GET("/admin-visits/summary", statsHandler.GetAdminVisitSummaries)
 			stats.GET("/admin-view", statsHandler.GetAdminView)
+			stats.GET("/admin-tree", statsHandler.GetAdminTree)
 
 			// Speed-space coupling endpoints
 			stats.GET("/speed-space", statsHandler.GetSpeedSpaceStats)
@@ -118,6 +352,7 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			stats.GET("/directional-bias", statsHandler.GetDirectionalBiasStats)
 			stats.GET("/directional-bias/top-areas", statsHandler.GetTopDirectionalAreas)
 			stats.GET("/directional-bias/bidirectional", statsHandler.GetBidirectionalPatterns)
+			stats.GET("/directional-bias/rose", statsHandler.GetDirectionalRose)
 
 			// Revisit patterns endpoints
 			stats.GET("/revisit-patterns", statsHandler.GetRevisitPatterns)
@@ -147,6 +382,36 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			stats.GET("/time-space-compression/highest-intensity", statsHandler.GetHighestMovementIntensity)
 			stats.GET("/time-space-compression/burst-periods", statsHandler.GetBurstPeriods)
 
+			// Mode breakdown endpoint
+			stats.GET("/mode-breakdown", statsHandler.GetModeBreakdown)
+
+			// Carbon footprint endpoints
+			stats.GET("/carbon", carbonHandler.GetCarbonSummary)
+			stats.GET("/carbon/trips", carbonHandler.GetTripCarbon)
+
+			// Weather endpoints
+			stats.GET("/weather", weatherHandler.GetWeatherSummary)
+			stats.GET("/weather/days", weatherHandler.ListWeatherDays)
+
+			// Daypart endpoint
+			stats.GET("/dayparts", statsHandler.GetDaypartStats)
+
+			// Workday, weekend and holiday endpoints
+			stats.GET("/day-types", statsHandler.GetDayTypeStats)
+			stats.GET("/day-types/compare", statsHandler.CompareDayTypes)
+
+			// Anomalous day endpoints
+			stats.GET("/anomalies", statsHandler.GetAnomalousDays)
+
+			// Exploration endpoints
+			stats.GET("/exploration", statsHandler.GetExplorationCurve)
+			stats.GET("/exploration/streaks", statsHandler.GetDiscoveryStreaks)
+			stats.GET("/first-visits", statsHandler.GetFirstVisits)
+			stats.GET("/records", statsHandler.GetPersonalRecords)
+
+			// Period comparison endpoint
+			stats.GET("/compare", statsHandler.CompareBuckets)
+
 			// Time-space slicing endpoints
 			stats.GET("/time-space-slices", statsHandler.GetTimeSpaceSlices)
 			stats.GET("/time-space-slices/weekly-pattern", statsHandler.GetWeeklyPattern)
@@ -157,6 +422,21 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 
 			// Road overlap endpoint
 			stats.GET("/road-overlap", statsHandler.GetRoadOverlapSummary)
+
+			// High-speed rail line mileage endpoint
+			stats.GET("/rail-lines", statsHandler.GetRailLineStats)
+
+			// Commute patterns endpoint
+			stats.GET("/commute", statsHandler.GetCommuteStats)
+
+			// Frequent routes endpoint
+			stats.GET("/routes", statsHandler.GetRouteClusters)
+
+			// Health × movement correlation endpoint
+			stats.GET("/health-correlation", statsHandler.GetHealthCorrelation)
+
+			// Batch endpoint: runs several of the queries above in one request
+			stats.POST("/batch", handler.NewBatchHandler("/api/v1/stats/", r.Routes()).Batch)
 		}
 
 		// 可视化接口
@@ -165,39 +445,153 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			viz.GET("/grid-cells", gridHandler.GetGridCells)
 			viz.GET("/heatmap", gridHandler.GetHeatmapData)
 			viz.GET("/rendering", vizHandler.GetRenderingMetadata)
+			viz.GET("/polylines", vizHandler.GetSegmentPolylines)
 			viz.GET("/time-slices", vizHandler.GetTimeSliceData)
+			viz.GET("/playback", playbackHandler.GetPlayback)
+		}
+
+		// 每日摘要接口
+		summary := api.Group("/summary")
+		{
+			summary.GET("/daily", summaryHandler.GetDailySummaries)
+		}
+
+		// 每日看板接口
+		api.GET("/dashboard", statsLimit, dashboardHandler.GetDashboard)
+
+		// 年度报告接口
+		reports := api.Group("/reports", statsLimit)
+		{
+			reports.GET("/annual/:year", reportHandler.GetAnnualReport)
+		}
+
+		// 洞察接口
+		api.GET("/insights", statsLimit, insightHandler.GetInsights)
+
+		// 全文检索接口
+		api.GET("/search", statsLimit, searchHandler.Search)
+
+		// 段、停留详情接口
+		api.GET("/segments/:id", detailHandler.GetSegmentDetail)
+
+		// 交通方式纠正接口（纠正记录用于重算及速度阈值校准）
+		api.PATCH("/segments/:id/mode", segmentHandler.CorrectSegmentMode)
+		api.GET("/segments/mode-corrections", segmentHandler.GetModeCorrections)
+		api.GET("/stays/:id", detailHandler.GetStayDetail)
+		api.GET("/stays/:id/photos", photoHandler.GetStayPhotos)
+
+		// 地点接口（停留聚类而成，可命名）
+		places := api.Group("/places")
+		{
+			places.GET("", placeHandler.ListPlaces)
+			places.GET("/:id", placeHandler.GetPlace)
+			places.PUT("/:id", placeHandler.RenamePlace)
+		}
+
+		// 照片接口（EXIF 地理标记，按拍摄时间关联行程与停留）
+		photos := api.Group("/photos")
+		{
+			photos.GET("", photoHandler.ListPhotos)
+			photos.GET("/:id", photoHandler.GetPhoto)
+			photos.GET("/:id/file", photoHandler.GetPhotoFile)
+			photos.POST("/import/scan", photoHandler.ScanPhotos)
+			photos.POST("/import/immich", photoHandler.ImportImmichPhotos)
+		}
+
+		// 行程查询与导出接口
+		trips := api.Group("/trips")
+		{
+			trips.GET("", tripHandler.GetTrips)
+			trips.GET("/od-matrix", tripHandler.GetODMatrix)
+			trips.GET("/:id", detailHandler.GetTripDetail)
+			trips.GET("/:id/export.gpx", tripHandler.ExportTripGPX)
+			trips.GET("/:id/photos", photoHandler.GetTripPhotos)
+
+			// 手动拆分、合并行程（记录编辑并重算行程构建）
+			trips.POST("/:id/split", tripHandler.SplitTrip)
+			trips.POST("/merge", tripHandler.MergeTrips)
+			trips.GET("/edits", tripHandler.GetTripEdits)
+			trips.DELETE("/edits/:id", tripHandler.DeleteTripEdit)
+		}
+
+		// 分享卡片接口
+		share := api.Group("/share", statsLimit)
+		{
+			share.GET("/trip/:id.png", shareHandler.GetTripCard)
+			share.GET("/day/:date.png", shareHandler.GetDayCard)
+		}
+
+		// 匿名化导出接口
+		export := api.Group("/export")
+		{
+			export.GET("/anonymized", exportHandler.ExportAnonymized)
+		}
+
+		// 异常点审核接口
+		qa := api.Group("/qa")
+		{
+			qa.GET("/outliers", qaHandler.GetOutliers)
+			qa.POST("/outliers/confirm", qaHandler.ConfirmOutliers)
+			qa.POST("/outliers/unflag", qaHandler.UnflagOutliers)
+			qa.POST("/outliers/reset", qaHandler.ResetReviews)
+		}
+
+		// 分析结果状态与预览（预览只返回结果，不写入派生表）
+		analysisAPI := api.Group("/analysis")
+		{
+			analysisAPI.GET("/status", analysisTaskHandler.GetStatus)
+			analysisAPI.POST("/preview", analysisTaskHandler.Preview)
+		}
+
+		// 实时位置接收接口
+		ingest := api.Group("/ingest")
+		{
+			ingest.POST("/owntracks", ingestAuth, ingestHandler.IngestOwnTracks)
+			ingest.POST("/overland", ingestAuth, ingestHandler.IngestOverland)
+			ingest.GET("/gpslogger", ingestAuth, ingestHandler.IngestGPSLogger)
+			ingest.POST("/gpslogger", ingestAuth, ingestHandler.IngestGPSLogger)
+			ingest.GET("/status", ingestHandler.GetStatus)
 		}
 
-		// 键盘鼠标统计接口 (placeholder)
+		// 键盘鼠标统计接口
 		keyboard := api.Group("/keyboard")
 		{
-			keyboard.GET("/stats", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "keyboard stats - not implemented yet"})
-			})
+			keyboard.GET("/stats", inputActivityHandler.GetStats)
+			keyboard.GET("/heatmap", inputActivityHandler.GetHeatmap)
+			keyboard.GET("/work-correlation", inputActivityHandler.GetWorkCorrelation)
+			keyboard.POST("/import", inputActivityHandler.ImportInputActivity)
 		}
 
-		// 飞机火车路线接口 (placeholder)
-		flights := api.Group("/flights")
+		// 飞机火车行程接口
+		journeys := api.Group("/journeys")
 		{
-			flights.GET("", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "flights list - not implemented yet"})
-			})
+			journeys.GET("", journeyHandler.GetJourneys)
+			journeys.GET("/stats", journeyHandler.GetJourneyStats)
+			journeys.GET("/:id", journeyHandler.GetJourneyByID)
+			journeys.POST("/import", journeyHandler.ImportJourneys)
+			journeys.POST("/link", journeyHandler.LinkSegments)
 		}
+		api.GET("/flights", journeyHandler.GetFlights)
+		api.GET("/trains", journeyHandler.GetTrains)
 
-		// 屏幕使用时间接口 (placeholder)
+		// 屏幕使用时间接口
 		screentime := api.Group("/screentime")
 		{
-			screentime.GET("/stats", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "screentime stats - not implemented yet"})
-			})
+			screentime.GET("/stats", screenTimeHandler.GetStats)
+			screentime.GET("/top-apps", screenTimeHandler.GetTopApps)
+			screentime.GET("/categories", screenTimeHandler.GetCategories)
+			screentime.GET("/trends/weekly", screenTimeHandler.GetWeeklyTrends)
+			screentime.POST("/import", screenTimeHandler.ImportScreenTime)
 		}
 
-		// Apple健康数据接口 (placeholder)
+		// Apple健康数据接口
 		healthData := api.Group("/health-data")
 		{
-			healthData.GET("/stats", func(c *gin.Context) {
-				c.JSON(http.StatusOK, gin.H{"message": "health data stats - not implemented yet"})
-			})
+			healthData.GET("/stats", healthHandler.GetStats)
+			healthData.GET("/workouts", healthHandler.GetWorkouts)
+			healthData.POST("/import", healthHandler.ImportAppleHealth)
+			healthData.GET("/import/tasks", healthHandler.ListImportTasks)
+			healthData.GET("/import/tasks/:id", healthHandler.GetImportTask)
 		}
 
 		// 管理员接口
@@ -217,12 +611,111 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 			{
 				analysis.POST("/tasks", analysisTaskHandler.CreateTask)
 				analysis.GET("/tasks", analysisTaskHandler.ListTasks)
+				analysis.GET("/queue", analysisTaskHandler.GetQueue)
 				analysis.GET("/tasks/:id", analysisTaskHandler.GetTask)
 				analysis.DELETE("/tasks/:id", analysisTaskHandler.CancelTask)
+				analysis.POST("/tasks/:id/resume", analysisTaskHandler.ResumeTask)
 				analysis.POST("/trigger-chain", analysisTaskHandler.TriggerAnalysisChain)
 			}
+
+			// 重算编排（默认只返回计划，confirm 为 true 时执行）
+			admin.POST("/recompute", recomputeHandler.Recompute)
+
+			// Scheduled analysis
+			schedules := admin.Group("/schedules")
+			{
+				schedules.GET("", scheduleHandler.ListSchedules)
+				schedules.GET("/runs", scheduleHandler.ListRuns)
+			}
+
+			// Watch-folder imports
+			importWatch := admin.Group("/import-watch")
+			{
+				importWatch.GET("", watchImportHandler.GetStatus)
+				importWatch.GET("/files", watchImportHandler.ListFiles)
+			}
+
+			// Event notifications
+			notifications := admin.Group("/notifications")
+			{
+				notifications.GET("", notificationHandler.GetStatus)
+				notifications.POST("/test", notificationHandler.SendTest)
+				notifications.GET("/deliveries", notificationHandler.ListDeliveries)
+			}
+
+			// Running configuration and hot reload
+			admin.GET("/config", configHandler.GetConfig)
+			admin.POST("/config/reload", configHandler.ReloadConfig)
+
+			// Database backups
+			admin.POST("/backup", backupHandler.CreateBackup)
+			admin.GET("/backups", backupHandler.ListBackups)
+
+			// Query plans of the hot queries
+			admin.GET("/explain", explainHandler.Explain)
+
+			// Threshold profiles management
+			thresholds := admin.Group("/thresholds")
+			{
+				thresholds.GET("", thresholdHandler.ListProfiles)
+				thresholds.POST("", thresholdHandler.CreateProfile)
+				thresholds.GET("/:id", thresholdHandler.GetProfile)
+				thresholds.PUT("/:id", thresholdHandler.UpdateProfile)
+				thresholds.GET("/:id/effective", thresholdHandler.GetEffectiveThresholds)
+				thresholds.POST("/:id/default", thresholdHandler.SetDefaultProfile)
+			}
+
+			// Emissions model management
+			emissionFactors := admin.Group("/emission-factors")
+			{
+				emissionFactors.GET("", carbonHandler.ListFactors)
+				emissionFactors.PUT("/:mode", carbonHandler.SetFactor)
+				emissionFactors.POST("/:mode/reset", carbonHandler.ResetFactor)
+			}
+
+			// Privacy zones management
+			privacyZones := admin.Group("/privacy-zones")
+			{
+				privacyZones.GET("", privacyHandler.ListZones)
+				privacyZones.POST("", privacyHandler.CreateZone)
+				privacyZones.GET("/:id", privacyHandler.GetZone)
+				privacyZones.PUT("/:id", privacyHandler.UpdateZone)
+				privacyZones.DELETE("/:id", privacyHandler.DeleteZone)
+				privacyZones.POST("/:id/restore", privacyHandler.RestoreZone)
+			}
 		}
 	}
 
 	return r
 }
+
+// notificationChannels builds the notification channels configured in cfg
+func notificationChannels(cfg *config.Config) []notify.Channel {
+	var channels []notify.Channel
+	for _, url := range cfg.WebhookURLs {
+		channels = append(channels, &notify.Webhook{URL: url, Secret: cfg.WebhookSecret})
+	}
+	if cfg.TelegramBotToken != "" && cfg.TelegramChatID != "" {
+		channels = append(channels, &notify.Telegram{Token: cfg.TelegramBotToken, ChatID: cfg.TelegramChatID})
+	}
+	if cfg.BarkURL != "" {
+		channels = append(channels, &notify.Bark{URL: cfg.BarkURL})
+	}
+	return channels
+}
+
+// BackupStore returns the store configured in cfg for database backups: the
+// S3 bucket when one is set, else the backup directory
+func BackupStore(cfg *config.Config) backup.Store {
+	if cfg.BackupS3Endpoint != "" && cfg.BackupS3Bucket != "" {
+		return &backup.S3Store{
+			Endpoint:  cfg.BackupS3Endpoint,
+			Region:    cfg.BackupS3Region,
+			Bucket:    cfg.BackupS3Bucket,
+			Prefix:    cfg.BackupS3Prefix,
+			AccessKey: cfg.BackupS3AccessKey,
+			SecretKey: cfg.BackupS3SecretKey,
+		}
+	}
+	return &backup.DirStore{Dir: cfg.BackupDir}
+}
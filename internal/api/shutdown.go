@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func(ctx context.Context)
+)
+
+// onShutdown registers fn to stop a background service started by SetupRouter
+func onShutdown(fn func(ctx context.Context)) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+// Shutdown stops the background services started by SetupRouter, in the
+// order they were registered, letting running work finish until ctx is done
+// Call it after the HTTP server has stopped accepting requests.
+func Shutdown(ctx context.Context) {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+
+	for _, fn := range hooks {
+		fn(ctx)
+	}
+}
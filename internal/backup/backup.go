@@ -0,0 +1,261 @@
+// Package backup takes consistent snapshots of the SQLite database, keeps
+// them in a directory or an S3-compatible bucket and restores them
+// Snapshots are made with VACUUM INTO, which reads the database in one
+// transaction while the server keeps running, and stored gzip-compressed.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Backup names are namePrefix, the UTC time and nameSuffix, so they sort by time
+const (
+	namePrefix = "records-"
+	nameSuffix = ".db.gz"
+	timeLayout = "20060102-150405"
+)
+
+// Object is a backup kept in a store
+type Object struct {
+	Name      string
+	Size      int64
+	CreatedAt time.Time
+}
+
+// Store keeps backup files
+type Store interface {
+	// Location describes where the backups are kept, without credentials
+	Location() string
+	Put(ctx context.Context, name string, path string) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	List(ctx context.Context) ([]Object, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Retention says which backups to keep once a new one is stored
+type Retention struct {
+	Keep   int           // Newest backups to keep, 0 for no limit
+	MaxAge time.Duration // Backups older than this are deleted, 0 for no limit
+}
+
+// Name returns the backup name for a snapshot taken at t
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(timeLayout) + nameSuffix
+}
+
+// IsName reports whether name is a backup name, returning its time
+func IsName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return t, err == nil
+}
+
+// Create snapshots db into a compressed backup and stores it, then deletes
+// the backups the retention policy no longer keeps
+// tmpDir holds the snapshot while it is compressed; the deleted backups
+// are returned.
+func Create(ctx context.Context, db *sql.DB, store Store, tmpDir string, retention Retention) (*Object, []string, error) {
+	now := time.Now()
+	work, err := os.MkdirTemp(tmpDir, "backup-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(work)
+
+	snapshot := filepath.Join(work, "snapshot.db")
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", snapshot); err != nil {
+		return nil, nil, fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	compressed := filepath.Join(work, Name(now))
+	size, err := compress(snapshot, compressed)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj := &Object{Name: Name(now), Size: size, CreatedAt: now.UTC().Truncate(time.Second)}
+	if err := store.Put(ctx, obj.Name, compressed); err != nil {
+		return nil, nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+
+	deleted, err := Prune(ctx, store, retention, now)
+	if err != nil {
+		return obj, deleted, fmt.Errorf("backup stored but retention failed: %w", err)
+	}
+	return obj, deleted, nil
+}
+
+// Prune deletes the backups that retention no longer keeps and returns
+// their names; the newest backup is always kept
+func Prune(ctx context.Context, store Store, retention Retention, now time.Time) ([]string, error) {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].CreatedAt.After(objects[j].CreatedAt) })
+
+	var deleted []string
+	for i, obj := range objects {
+		if i == 0 {
+			continue
+		}
+		tooMany := retention.Keep > 0 && i >= retention.Keep
+		tooOld := retention.MaxAge > 0 && now.Sub(obj.CreatedAt) > retention.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := store.Delete(ctx, obj.Name); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, obj.Name)
+	}
+	return deleted, nil
+}
+
+// Latest returns the newest backup in the store
+func Latest(ctx context.Context, store Store) (*Object, error) {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, errors.New("no backups found")
+	}
+	latest := objects[0]
+	for _, obj := range objects[1:] {
+		if obj.CreatedAt.After(latest.CreatedAt) {
+			latest = obj
+		}
+	}
+	return &latest, nil
+}
+
+// Restore replaces the database at dbPath with a backup read from r,
+// compressed unless it is a plain SQLite file
+// The backup is checked with PRAGMA integrity_check before the database is
+// replaced; the replaced database is kept next to it with a .before-restore
+// suffix. The server must not be running.
+func Restore(r io.Reader, dbPath string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dbPath), ".restore-*.db")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := decompress(r, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := checkIntegrity(tmp.Name()); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbPath); err == nil {
+		if err := os.Rename(dbPath, dbPath+".before-restore"); err != nil {
+			return fmt.Errorf("failed to keep the current database: %w", err)
+		}
+	}
+	// The WAL of the old database must not be replayed into the restored one
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(tmp.Name(), dbPath); err != nil {
+		return fmt.Errorf("failed to move the restored database into place: %w", err)
+	}
+	return nil
+}
+
+// compress gzips src into dst and returns the size of dst
+func compress(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return 0, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+	info, err := out.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), out.Close()
+}
+
+// sqliteHeader starts every SQLite database file
+const sqliteHeader = "SQLite format 3\x00"
+
+// decompress copies a gzipped or plain SQLite backup from r to w
+func decompress(r io.Reader, w io.Writer) error {
+	peek := make([]byte, 2)
+	n, err := io.ReadFull(r, peek)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	r = io.MultiReader(bytes.NewReader(peek[:n]), r)
+	if n == 2 && peek[0] == 0x1f && peek[1] == 0x8b {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	header := make([]byte, len(sqliteHeader))
+	if _, err := io.ReadFull(r, header); err != nil || string(header) != sqliteHeader {
+		return errors.New("invalid backup: not a SQLite database")
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	return nil
+}
+
+// checkIntegrity runs PRAGMA integrity_check on the database at path
+func checkIntegrity(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=query_only(1)")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check backup: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed the integrity check: %s", result)
+	}
+	return nil
+}
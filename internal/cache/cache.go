@@ -0,0 +1,280 @@
+// Package cache keeps computed query results in memory until the analysis
+// tables they were read from change.
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/metrics"
+)
+
+var (
+	requests = metrics.NewCounterVec(
+		"records_cache_requests_total",
+		"Result cache lookups by cache and result (hit or miss).",
+		"cache", "result")
+	evictions = metrics.NewCounterVec(
+		"records_cache_evictions_total",
+		"Result cache entries removed, by cache and reason.",
+		"cache", "reason")
+)
+
+// entry is one cached result with the tags it depends on
+type entry struct {
+	value   interface{}
+	tags    []string
+	expires time.Time
+}
+
+// Cache is an in-memory result cache with tag-based invalidation
+// Entries are tagged with the analyzer skills whose output they were computed
+// from; Invalidate drops every entry of a skill. A TTL bounds staleness for
+// data that changes without an analyzer run.
+//
+// Cached values are shared between callers and must not be modified.
+// A nil *Cache, or one with a non-positive TTL, caches nothing.
+type Cache struct {
+	name       string
+	ttl        time.Duration
+	maxEntries int
+
+	mu         sync.Mutex
+	entries    map[string]*entry
+	byTag      map[string]map[string]struct{}
+	generation uint64 // Incremented by every invalidation
+}
+
+// New creates a cache keeping entries for ttl, holding at most maxEntries
+func New(name string, ttl time.Duration, maxEntries int) *Cache {
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	return &Cache{
+		name:       name,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*entry),
+		byTag:      make(map[string]map[string]struct{}),
+	}
+}
+
+// SetLimits changes the entry lifetime and capacity
+// Entries are shortened to the new lifetime and evicted down to the new
+// capacity; a non-positive ttl clears the cache and disables it.
+func (c *Cache) SetLimits(ttl time.Duration, maxEntries int) {
+	if c == nil {
+		return
+	}
+	if maxEntries <= 0 {
+		maxEntries = 1000
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl, c.maxEntries = ttl, maxEntries
+	if ttl <= 0 {
+		c.generation++
+		for key := range c.entries {
+			c.remove(key, "cleared")
+		}
+		return
+	}
+	latest := time.Now().Add(ttl)
+	for _, e := range c.entries {
+		if e.expires.After(latest) {
+			e.expires = latest
+		}
+	}
+	for len(c.entries) > c.maxEntries {
+		c.evictOne()
+	}
+}
+
+// Key builds a cache key from an endpoint name and its parameters
+func Key(endpoint string, params ...interface{}) string {
+	var b strings.Builder
+	b.WriteString(endpoint)
+	for _, p := range params {
+		fmt.Fprintf(&b, "|%v", p)
+	}
+	return b.String()
+}
+
+// Get returns the cached value of key
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	e, ok := c.entries[key]
+	if ok && time.Now().After(e.expires) {
+		c.remove(key, "expired")
+		ok = false
+	}
+	if !ok {
+		requests.Inc(c.name, "miss")
+		return nil, false
+	}
+	requests.Inc(c.name, "hit")
+	return e.value, true
+}
+
+// Set stores value under key, tagged with the skills it depends on
+func (c *Cache) Set(key string, value interface{}, tags ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, tags)
+}
+
+// currentGeneration returns the invalidation generation
+func (c *Cache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// setIfCurrent stores value unless an invalidation happened since generation,
+// in which case the value may have been computed from outdated tables
+func (c *Cache) setIfCurrent(generation uint64, key string, value interface{}, tags []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.set(key, value, tags)
+	}
+}
+
+// set stores an entry; callers hold mu
+func (c *Cache) set(key string, value interface{}, tags []string) {
+	if c.ttl <= 0 {
+		return
+	}
+	if _, exists := c.entries[key]; exists {
+		c.remove(key, "replaced")
+	} else if len(c.entries) >= c.maxEntries {
+		c.evictOne()
+	}
+
+	c.entries[key] = &entry{value: value, tags: tags, expires: time.Now().Add(c.ttl)}
+	for _, tag := range tags {
+		keys := c.byTag[tag]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			c.byTag[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+}
+
+// Invalidate removes the entries tagged with tag and returns their number
+func (c *Cache) Invalidate(tag string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	keys := c.byTag[tag]
+	n := len(keys)
+	for key := range keys {
+		c.remove(key, "invalidated")
+	}
+	return n
+}
+
+// Clear removes all entries
+func (c *Cache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key := range c.entries {
+		c.remove(key, "cleared")
+	}
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// remove deletes key and its tag references; callers hold mu
+func (c *Cache) remove(key, reason string) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	for _, tag := range e.tags {
+		if keys := c.byTag[tag]; keys != nil {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(c.byTag, tag)
+			}
+		}
+	}
+	evictions.Inc(c.name, reason)
+}
+
+// evictOne makes room for a new entry by dropping expired entries, or else
+// the entry closest to expiry (the oldest); callers hold mu
+func (c *Cache) evictOne() {
+	now := time.Now()
+	var oldestKey string
+	var oldest time.Time
+	for key, e := range c.entries {
+		if now.After(e.expires) {
+			c.remove(key, "expired")
+			continue
+		}
+		if oldestKey == "" || e.expires.Before(oldest) {
+			oldestKey, oldest = key, e.expires
+		}
+	}
+	if len(c.entries) >= c.maxEntries && oldestKey != "" {
+		c.remove(oldestKey, "capacity")
+	}
+}
+
+// Load returns the cached result of key, calling load and caching its result
+// on a miss. Errors are not cached.
+func Load[T any](c *Cache, key string, tags []string, load func() (T, error)) (T, error) {
+	if v, ok := c.Get(key); ok {
+		if value, ok := v.(T); ok {
+			return value, nil
+		}
+	}
+
+	generation := c.currentGeneration()
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.setIfCurrent(generation, key, value, tags)
+	return value, nil
+}
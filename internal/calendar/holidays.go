@@ -0,0 +1,144 @@
+package calendar
+
+import (
+	"time"
+)
+
+// Day types
+const (
+	DayTypeWorkday = "WORKDAY"
+	DayTypeWeekend = "WEEKEND"
+	DayTypeHoliday = "HOLIDAY"
+)
+
+// holidayPeriod is an official Chinese public holiday period (inclusive dates)
+type holidayPeriod struct {
+	Name  string
+	Start string
+	End   string
+}
+
+// chinaHolidays lists the State Council public holiday schedules
+var chinaHolidays = []holidayPeriod{
+	// 2019
+	{"元旦", "2018-12-30", "2019-01-01"},
+	{"春节", "2019-02-04", "2019-02-10"},
+	{"清明节", "2019-04-05", "2019-04-07"},
+	{"劳动节", "2019-05-01", "2019-05-04"},
+	{"端午节", "2019-06-07", "2019-06-09"},
+	{"中秋节", "2019-09-13", "2019-09-15"},
+	{"国庆节", "2019-10-01", "2019-10-07"},
+	// 2020
+	{"元旦", "2020-01-01", "2020-01-01"},
+	{"春节", "2020-01-24", "2020-02-02"},
+	{"清明节", "2020-04-04", "2020-04-06"},
+	{"劳动节", "2020-05-01", "2020-05-05"},
+	{"端午节", "2020-06-25", "2020-06-27"},
+	{"国庆节", "2020-10-01", "2020-10-08"},
+	// 2021
+	{"元旦", "2021-01-01", "2021-01-03"},
+	{"春节", "2021-02-11", "2021-02-17"},
+	{"清明节", "2021-04-03", "2021-04-05"},
+	{"劳动节", "2021-05-01", "2021-05-05"},
+	{"端午节", "2021-06-12", "2021-06-14"},
+	{"中秋节", "2021-09-19", "2021-09-21"},
+	{"国庆节", "2021-10-01", "2021-10-07"},
+	// 2022
+	{"元旦", "2022-01-01", "2022-01-03"},
+	{"春节", "2022-01-31", "2022-02-06"},
+	{"清明节", "2022-04-03", "2022-04-05"},
+	{"劳动节", "2022-04-30", "2022-05-04"},
+	{"端午节", "2022-06-03", "2022-06-05"},
+	{"中秋节", "2022-09-10", "2022-09-12"},
+	{"国庆节", "2022-10-01", "2022-10-07"},
+	// 2023
+	{"元旦", "2022-12-31", "2023-01-02"},
+	{"春节", "2023-01-21", "2023-01-27"},
+	{"清明节", "2023-04-05", "2023-04-05"},
+	{"劳动节", "2023-04-29", "2023-05-03"},
+	{"端午节", "2023-06-22", "2023-06-24"},
+	{"国庆节", "2023-09-29", "2023-10-06"},
+	// 2024
+	{"元旦", "2024-01-01", "2024-01-01"},
+	{"春节", "2024-02-10", "2024-02-17"},
+	{"清明节", "2024-04-04", "2024-04-06"},
+	{"劳动节", "2024-05-01", "2024-05-05"},
+	{"端午节", "2024-06-08", "2024-06-10"},
+	{"中秋节", "2024-09-15", "2024-09-17"},
+	{"国庆节", "2024-10-01", "2024-10-07"},
+	// 2025
+	{"元旦", "2025-01-01", "2025-01-01"},
+	{"春节", "2025-01-28", "2025-02-04"},
+	{"清明节", "2025-04-04", "2025-04-06"},
+	{"劳动节", "2025-05-01", "2025-05-05"},
+	{"端午节", "2025-05-31", "2025-06-02"},
+	{"国庆节", "2025-10-01", "2025-10-08"},
+}
+
+// chinaAdjustedWorkdays lists weekend days that are official make-up workdays (调休)
+var chinaAdjustedWorkdays = []string{
+	"2018-12-29", "2019-02-02", "2019-02-03", "2019-04-28", "2019-05-05", "2019-09-29", "2019-10-12",
+	"2020-01-19", "2020-04-26", "2020-05-09", "2020-06-28", "2020-09-27", "2020-10-10",
+	"2021-02-07", "2021-02-20", "2021-04-25", "2021-05-08", "2021-09-18", "2021-09-26", "2021-10-09",
+	"2022-01-29", "2022-01-30", "2022-04-02", "2022-04-24", "2022-05-07", "2022-10-08", "2022-10-09",
+	"2023-01-28", "2023-01-29", "2023-04-23", "2023-05-06", "2023-06-25", "2023-10-07", "2023-10-08",
+	"2024-02-04", "2024-02-18", "2024-04-07", "2024-04-28", "2024-05-11", "2024-09-14", "2024-09-29", "2024-10-12",
+	"2025-01-26", "2025-02-08", "2025-04-27", "2025-09-28", "2025-10-11",
+}
+
+var (
+	holidayNames    map[string]string
+	adjustedWorkday map[string]bool
+)
+
+func init() {
+	holidayNames = make(map[string]string)
+	for _, period := range chinaHolidays {
+		start, err := time.Parse("2006-01-02", period.Start)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse("2006-01-02", period.End)
+		if err != nil {
+			continue
+		}
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			holidayNames[d.Format("2006-01-02")] = period.Name
+		}
+	}
+
+	adjustedWorkday = make(map[string]bool, len(chinaAdjustedWorkdays))
+	for _, date := range chinaAdjustedWorkdays {
+		adjustedWorkday[date] = true
+	}
+}
+
+// HolidayName returns the public holiday name for the date, or "" if it is not a holiday
+func HolidayName(t time.Time) string {
+	return holidayNames[t.Format("2006-01-02")]
+}
+
+// IsHoliday checks if the date falls within an official public holiday period
+func IsHoliday(t time.Time) bool {
+	return HolidayName(t) != ""
+}
+
+// IsAdjustedWorkday checks if the date is a weekend day officially moved to a workday
+func IsAdjustedWorkday(t time.Time) bool {
+	return adjustedWorkday[t.Format("2006-01-02")]
+}
+
+// DayType classifies a date as WORKDAY, WEEKEND or HOLIDAY using the Chinese
+// public holiday calendar, including make-up workdays on weekends
+func DayType(t time.Time) string {
+	if IsHoliday(t) {
+		return DayTypeHoliday
+	}
+	if IsAdjustedWorkday(t) {
+		return DayTypeWorkday
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return DayTypeWeekend
+	}
+	return DayTypeWorkday
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config 应用配置
@@ -10,29 +15,326 @@ type Config struct {
 	DBPath     string
 	JWTSecret  string
 	MaxMemory  int64 // 最大内存使用（字节）
+
+	File string // 读取的配置文件，为空时只使用环境变量
+
+	ShutdownTimeout        time.Duration // 退出时等待请求和分析任务完成的时长，超时后取消分析任务
+	ResumeInterruptedTasks bool          // 启动时重新运行上次退出时中断的分析任务
+
+	DisabledAnalyzers []string // 禁用的分析器（skill 名称）
+	ThresholdProfile  string   // 设为默认的阈值配置名称，为空时不修改
+	AnalysisWorkers   int      // 同时运行的分析任务数，同一分析器的任务不会同时运行
+
+	GeocodeBoundaryPath string // 行政区边界 GeoJSON（逆地理编码回填用）
+	AdminDivisionsPath  string // 行政区划目录 JSON（补充区县、乡镇，用于覆盖率统计），可为空
+
+	MapMatchBackend string // 地图匹配后端：osrm、valhalla 或 hmm（离线），为空时有道路网文件则用 hmm
+	MapMatchURL     string // OSRM / Valhalla 服务地址
+	MapMatchProfile string // OSRM profile 或 Valhalla costing，为空时用 driving / auto
+	RoadNetworkPath string // 道路网 GeoJSON（离线 HMM 地图匹配用）
+
+	ElevationBackend string // 高程数据源：srtm（离线瓦片）或 opentopodata，为空时有 SRTM 目录则用 srtm
+	ElevationURL     string // OpenTopoData 服务地址
+	ElevationDataset string // OpenTopoData 数据集，为空时用 srtm30m
+	SRTMDir          string // SRTM .hgt 瓦片目录（高程回填用）
+
+	POIBackend      string        // POI 数据源：amap（高德）、overpass 或 snapshot（离线），为空时有快照文件则用 snapshot
+	POIURL          string        // 高德 Web 服务或 Overpass 服务地址，为空时用公共地址
+	POIAPIKey       string        // 高德 Web 服务 Key
+	POISnapshotPath string        // 离线 POI 快照 GeoJSON
+	POIRateLimit    float64       // 在线 POI 数据源每秒最多请求数，<= 0 表示不限
+	POICacheTTL     time.Duration // 在线 POI 查询结果缓存有效期，0 表示一直有效
+
+	WeatherBackend  string // 天气数据源：openmeteo（Open-Meteo 历史天气）或 era5（离线 ERA5 CSV），为空时有 ERA5 文件则用 era5
+	WeatherURL      string // Open-Meteo 历史天气服务地址，为空时用公共地址
+	WeatherERA5Path string // 离线 ERA5 逐小时数据 CSV（t2m、tp）
+
+	PhotoDir     string // 照片目录（读取 EXIF 地理标记，含 Google Takeout 的 JSON 附带文件），为空时不可扫描
+	ImmichURL    string // Immich 服务地址，与 ImmichAPIKey 都设置时可从 Immich 导入照片
+	ImmichAPIKey string
+
+	LogFormat string // 请求日志格式：text 或 json
+
+	RateLimit      RateLimitConfig // 全局限流（按 API Key 或 IP）
+	StatsRateLimit RateLimitConfig // 统计、报告等重查询接口的额外限流
+	APIKeys        []string        // 按 Key 单独限流的 API Key（X-API-Key 请求头，逗号分隔），其余请求按 IP 限流
+
+	CacheTTL        time.Duration // 统计结果缓存有效期，0 表示关闭缓存
+	CacheMaxEntries int           // 统计结果缓存最多条目数
+
+	SwaggerUIAssets string // Swagger UI 静态资源地址，为空时从 CDN 加载
+
+	ScheduleIncremental string // 增量分析链的 cron 表达式，默认每天 03:00，off 表示关闭
+	ScheduleFull        string // 全量重算的 cron 表达式，默认每周日 04:00，off 表示关闭
+
+	ImportWatchDir      string        // 自动导入的监视目录（Syncthing / Dropbox 同步的导出文件），为空时关闭
+	ImportArchiveDir    string        // 已处理文件的归档目录，为空时用监视目录下的 archive
+	ImportWatchInterval time.Duration // 监视目录的轮询间隔
+
+	OwnTracksUser          string        // 实时接收接口（OwnTracks、Overland、GPSLogger）的 Basic Auth 用户名
+	OwnTracksPassword      string        // 实时接收接口的 Basic Auth 密码
+	IngestToken            string        // 实时接收接口的访问令牌（Bearer 或 token 参数），与用户名都为空时不校验
+	IngestAnalysisInterval time.Duration // 实时接收新点后触发增量分析的间隔，0 表示不自动分析
+
+	MQTTURL      string   // MQTT broker 地址（tcp:// 或 ssl://），为空时不订阅
+	MQTTTopics   []string // 订阅的 OwnTracks 主题，默认 owntracks/+/+
+	MQTTUsername string
+	MQTTPassword string
+	MQTTClientID string // 为空时自动生成
+
+	WebhookURLs       []string // 事件通知的 Webhook 地址（逗号分隔），为空时不发送
+	WebhookSecret     string   // Webhook 签名密钥（HMAC-SHA256），为空时不签名
+	TelegramBotToken  string   // Telegram 机器人令牌，与 TelegramChatID 都设置时推送到 Telegram
+	TelegramChatID    string
+	BarkURL           string   // Bark 推送地址（含设备 key），例如 https://api.day.app/<key>
+	NotifyEvents      []string // 通知的事件类型（逗号分隔），为空时全部通知
+	NotifyMaxAttempts int      // 每次通知的最多尝试次数
+
+	BackupDir         string        // 数据库备份目录，未配置 S3 时使用
+	BackupKeep        int           // 保留最近的备份数，0 表示不限
+	BackupMaxAge      time.Duration // 超过此时长的备份会被删除，0 表示不限
+	BackupS3Endpoint  string        // S3 兼容存储地址（AWS S3、MinIO、R2），与 BackupS3Bucket 都设置时备份到 S3
+	BackupS3Region    string        // 为空时用 us-east-1
+	BackupS3Bucket    string
+	BackupS3Prefix    string // 对象键前缀，例如 records/
+	BackupS3AccessKey string
+	BackupS3SecretKey string
+}
+
+// RateLimitConfig 令牌桶限流配置
+type RateLimitConfig struct {
+	Rate  float64 // 每秒补充的令牌数，<= 0 表示不限流
+	Burst int     // 桶容量（允许的突发请求数）
+}
+
+// Load 加载配置：先读配置文件（CONFIG_FILE，默认 ./config.yaml，默认文件不存在时跳过），
+// 再用环境变量覆盖
+func Load() (*Config, error) {
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = defaultFile
+	}
+
+	file, err := readFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		path, file = "", nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	cfg := load(source{file: file})
+	cfg.File = path
+	return cfg, nil
 }
 
-// Load 加载配置
-func Load() *Config {
-	port := os.Getenv("PORT")
+// load 从配置来源构建配置，未设置的项使用默认值
+func load(s source) *Config {
+	port := s.get("PORT")
 	if port == "" {
 		port = ":8080"
 	}
 
-	dbPath := os.Getenv("DB_PATH")
+	dbPath := s.get("DB_PATH")
 	if dbPath == "" {
 		dbPath = "./data/tracks/tracks.db"
 	}
 
-	jwtSecret := os.Getenv("JWT_SECRET")
+	jwtSecret := s.get("JWT_SECRET")
 	if jwtSecret == "" {
 		jwtSecret = "your-secret-key-change-in-production"
 	}
 
+	// 逗号分隔，例如 DISABLED_ANALYZERS=road_overlap,spatial_complexity
+	disabledAnalyzers := s.list("DISABLED_ANALYZERS")
+
+	geocodeBoundaryPath := s.get("GEOCODE_BOUNDARY_PATH")
+	if geocodeBoundaryPath == "" {
+		geocodeBoundaryPath = "./data/geo/admin_boundaries.geojson"
+	}
+
+	roadNetworkPath := s.get("ROAD_NETWORK_PATH")
+	if roadNetworkPath == "" {
+		roadNetworkPath = "./data/geo/roads.geojson"
+	}
+
+	srtmDir := s.get("SRTM_DIR")
+	if srtmDir == "" {
+		srtmDir = "./data/geo/srtm"
+	}
+
+	poiSnapshotPath := s.get("POI_SNAPSHOT_PATH")
+	if poiSnapshotPath == "" {
+		poiSnapshotPath = "./data/geo/pois.geojson"
+	}
+
+	weatherERA5Path := s.get("WEATHER_ERA5_PATH")
+	if weatherERA5Path == "" {
+		weatherERA5Path = "./data/geo/era5.csv"
+	}
+
+	logFormat := strings.ToLower(s.get("LOG_FORMAT"))
+	if logFormat != "json" {
+		logFormat = "text"
+	}
+
+	backupDir := s.get("BACKUP_DIR")
+	if backupDir == "" {
+		backupDir = "./data/backups"
+	}
+
+	// 逗号分隔，例如 MQTT_TOPICS=owntracks/alice/+,owntracks/bob/phone
+	mqttTopics := s.list("MQTT_TOPICS")
+	if len(mqttTopics) == 0 {
+		mqttTopics = []string{"owntracks/+/+"}
+	}
+
 	return &Config{
-		Port:      port,
-		DBPath:    dbPath,
-		JWTSecret: jwtSecret,
-		MaxMemory: 1024 * 1024 * 800, // 800MB 最大内存使用
+		Port:              port,
+		DBPath:            dbPath,
+		JWTSecret:         jwtSecret,
+		MaxMemory:         1024 * 1024 * 800, // 800MB 最大内存使用
+		DisabledAnalyzers: disabledAnalyzers,
+		ThresholdProfile:  s.get("THRESHOLD_PROFILE"),
+		AnalysisWorkers:   s.int("ANALYSIS_WORKERS", 2),
+
+		ShutdownTimeout:        s.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		ResumeInterruptedTasks: s.bool("RESUME_INTERRUPTED_TASKS", true),
+
+		GeocodeBoundaryPath: geocodeBoundaryPath,
+		AdminDivisionsPath:  s.get("ADMIN_DIVISIONS_PATH"),
+
+		MapMatchBackend: strings.ToLower(s.get("MAP_MATCH_BACKEND")),
+		MapMatchURL:     s.get("MAP_MATCH_URL"),
+		MapMatchProfile: s.get("MAP_MATCH_PROFILE"),
+		RoadNetworkPath: roadNetworkPath,
+
+		ElevationBackend: strings.ToLower(s.get("ELEVATION_BACKEND")),
+		ElevationURL:     s.get("ELEVATION_URL"),
+		ElevationDataset: s.get("ELEVATION_DATASET"),
+		SRTMDir:          srtmDir,
+
+		POIBackend:      strings.ToLower(s.get("POI_BACKEND")),
+		POIURL:          s.get("POI_URL"),
+		POIAPIKey:       s.get("POI_API_KEY"),
+		POISnapshotPath: poiSnapshotPath,
+		POIRateLimit:    s.float("POI_RATE_LIMIT", 1),
+		POICacheTTL:     s.duration("POI_CACHE_TTL", 24*time.Hour),
+
+		WeatherBackend:  strings.ToLower(s.get("WEATHER_BACKEND")),
+		WeatherURL:      s.get("WEATHER_URL"),
+		WeatherERA5Path: weatherERA5Path,
+
+		PhotoDir:     s.get("PHOTO_DIR"),
+		ImmichURL:    s.get("IMMICH_URL"),
+		ImmichAPIKey: s.get("IMMICH_API_KEY"),
+
+		LogFormat: logFormat,
+
+		RateLimit: RateLimitConfig{
+			Rate:  s.float("RATE_LIMIT_RPS", 5),
+			Burst: s.int("RATE_LIMIT_BURST", 10),
+		},
+		StatsRateLimit: RateLimitConfig{
+			Rate:  s.float("STATS_RATE_LIMIT_RPS", 1),
+			Burst: s.int("STATS_RATE_LIMIT_BURST", 3),
+		},
+		APIKeys: s.list("API_KEYS"),
+
+		CacheTTL:        s.duration("CACHE_TTL", 10*time.Minute),
+		CacheMaxEntries: s.int("CACHE_MAX_ENTRIES", 1000),
+
+		SwaggerUIAssets: strings.TrimSuffix(s.get("SWAGGER_UI_ASSETS"), "/"),
+
+		ScheduleIncremental: s.schedule("SCHEDULE_INCREMENTAL", "0 3 * * *"),
+		ScheduleFull:        s.schedule("SCHEDULE_FULL", "0 4 * * 0"),
+
+		ImportWatchDir:      s.get("IMPORT_WATCH_DIR"),
+		ImportArchiveDir:    s.get("IMPORT_ARCHIVE_DIR"),
+		ImportWatchInterval: s.duration("IMPORT_WATCH_INTERVAL", 30*time.Second),
+
+		OwnTracksUser:          s.get("OWNTRACKS_USER"),
+		OwnTracksPassword:      s.get("OWNTRACKS_PASSWORD"),
+		IngestToken:            s.get("INGEST_TOKEN"),
+		IngestAnalysisInterval: s.duration("INGEST_ANALYSIS_INTERVAL", 15*time.Minute),
+
+		MQTTURL:      s.get("MQTT_URL"),
+		MQTTTopics:   mqttTopics,
+		MQTTUsername: s.get("MQTT_USERNAME"),
+		MQTTPassword: s.get("MQTT_PASSWORD"),
+		MQTTClientID: s.get("MQTT_CLIENT_ID"),
+
+		WebhookURLs:       s.list("WEBHOOK_URLS"),
+		WebhookSecret:     s.get("WEBHOOK_SECRET"),
+		TelegramBotToken:  s.get("TELEGRAM_BOT_TOKEN"),
+		TelegramChatID:    s.get("TELEGRAM_CHAT_ID"),
+		BarkURL:           s.get("BARK_URL"),
+		NotifyEvents:      s.list("NOTIFY_EVENTS"), // 例如 NOTIFY_EVENTS=record.new,analysis.failed
+		NotifyMaxAttempts: s.int("NOTIFY_MAX_ATTEMPTS", 5),
+
+		BackupDir:         backupDir,
+		BackupKeep:        s.int("BACKUP_KEEP", 7),
+		BackupMaxAge:      s.duration("BACKUP_MAX_AGE", 0),
+		BackupS3Endpoint:  s.get("BACKUP_S3_ENDPOINT"),
+		BackupS3Region:    s.get("BACKUP_S3_REGION"),
+		BackupS3Bucket:    s.get("BACKUP_S3_BUCKET"),
+		BackupS3Prefix:    s.get("BACKUP_S3_PREFIX"),
+		BackupS3AccessKey: s.get("BACKUP_S3_ACCESS_KEY"),
+		BackupS3SecretKey: s.get("BACKUP_S3_SECRET_KEY"),
+	}
+}
+
+// float 读取浮点配置项，未设置或格式错误时返回默认值
+func (s source) float(key string, def float64) float64 {
+	if v, err := strconv.ParseFloat(s.get(key), 64); err == nil {
+		return v
+	}
+	return def
+}
+
+// duration 读取时长配置项（如 10m、30s），未设置或格式错误时返回默认值
+func (s source) duration(key string, def time.Duration) time.Duration {
+	if v, err := time.ParseDuration(s.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// schedule 读取 cron 表达式配置项，未设置时返回默认值，off 时返回空串
+func (s source) schedule(key string, def string) string {
+	v, ok := s.lookup(key)
+	if !ok {
+		return def
+	}
+	if v = strings.TrimSpace(v); strings.EqualFold(v, "off") {
+		return ""
+	}
+	return v
+}
+
+// bool 读取布尔配置项（true/false、1/0），未设置或格式错误时返回默认值
+func (s source) bool(key string, def bool) bool {
+	if v, err := strconv.ParseBool(s.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// int 读取整数配置项，未设置或格式错误时返回默认值
+func (s source) int(key string, def int) int {
+	if v, err := strconv.Atoi(s.get(key)); err == nil {
+		return v
+	}
+	return def
+}
+
+// list 读取逗号分隔的配置项，去掉空项
+func (s source) list(key string) []string {
+	var list []string
+	for _, v := range strings.Split(s.get(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
 	}
+	return list
 }
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultFile 未设置 CONFIG_FILE 时读取的配置文件
+const defaultFile = "./config.yaml"
+
+// source 配置来源：非空的环境变量优先，其次是配置文件
+type source struct {
+	file map[string]string // 展平后的配置文件，键与环境变量同名
+}
+
+// lookup 读取配置项，ok 表示环境变量或配置文件中设置了该项
+func (s source) lookup(key string) (string, bool) {
+	env, inEnv := os.LookupEnv(key)
+	if env != "" {
+		return env, true
+	}
+	if v, ok := s.file[key]; ok {
+		return v, true
+	}
+	return env, inEnv
+}
+
+// get 读取配置项，未设置时返回空串
+func (s source) get(key string) string {
+	v, _ := s.lookup(key)
+	return v
+}
+
+// readFile 读取 YAML 配置文件，展平为与环境变量同名的键：
+// 嵌套的键用下划线连接并转为大写（rate_limit: {rps: 5} 即 RATE_LIMIT_RPS），
+// 列表用逗号连接
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	flat := make(map[string]string)
+	if err := flatten("", doc, flat); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return flat, nil
+}
+
+// flatten 把 YAML 节点 v 展平到 out，prefix 为上层的键
+func flatten(prefix string, v interface{}, out map[string]string) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			key := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(k), "-", "_"))
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+			if err := flatten(key, v[k], out); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("%s: list items must be scalars", prefix)
+			}
+			items = append(items, fmt.Sprint(item))
+		}
+		out[prefix] = strings.Join(items, ",")
+	case nil:
+		out[prefix] = ""
+	default:
+		if prefix == "" {
+			return fmt.Errorf("top level must be a mapping")
+		}
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// reloadable 可热加载的设置（Config 字段名），其余设置变化后需重启才生效
+var reloadable = map[string]bool{
+	"DisabledAnalyzers":   true,
+	"ThresholdProfile":    true,
+	"AnalysisWorkers":     true,
+	"ShutdownTimeout":     true,
+	"RateLimit":           true,
+	"StatsRateLimit":      true,
+	"APIKeys":             true,
+	"CacheTTL":            true,
+	"CacheMaxEntries":     true,
+	"ScheduleIncremental": true,
+	"ScheduleFull":        true,
+	"ImportWatchDir":      true,
+	"ImportArchiveDir":    true,
+	"ImportWatchInterval": true,
+	"OwnTracksUser":       true,
+	"OwnTracksPassword":   true,
+	"IngestToken":         true,
+	"PhotoDir":            true,
+}
+
+// secrets 含密码、密钥或令牌的设置（Config 字段名），Redacted 时隐藏
+// 地址和用户名也可能含凭据：BarkURL 含设备 key，Webhook 地址常含令牌，MQTTURL 可含 user:pass@。
+var secrets = map[string]bool{
+	"JWTSecret":         true,
+	"POIAPIKey":         true,
+	"ImmichAPIKey":      true,
+	"APIKeys":           true,
+	"OwnTracksUser":     true,
+	"OwnTracksPassword": true,
+	"IngestToken":       true,
+	"MQTTURL":           true,
+	"MQTTUsername":      true,
+	"MQTTPassword":      true,
+	"WebhookURLs":       true,
+	"WebhookSecret":     true,
+	"TelegramBotToken":  true,
+	"BarkURL":           true,
+	"BackupS3AccessKey": true,
+	"BackupS3SecretKey": true,
+}
+
+var (
+	mu      sync.RWMutex
+	current *Config
+	hooks   []func(*Config)
+
+	reloadMu sync.Mutex // 串行化 Reload
+)
+
+// ReloadResult 热加载结果
+type ReloadResult struct {
+	File            string   `json:"file,omitempty"`   // 读取的配置文件，为空时只使用环境变量
+	Applied         []string `json:"applied"`          // 已生效的设置
+	RestartRequired []string `json:"restart_required"` // 已变化但需重启才生效的设置，运行中保持原值
+}
+
+// SetCurrent 设置运行中的配置，Reload 以它为基准
+func SetCurrent(cfg *Config) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = cfg
+}
+
+// Current 返回运行中的配置，未设置时为 nil
+// 返回的配置不应修改，热加载时会整体替换。
+func Current() *Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// OnReload 注册热加载回调，可热加载的设置变化后按注册顺序调用
+func OnReload(fn func(*Config)) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// Reload 重新读取配置文件和环境变量，应用可热加载的设置
+// 环境变量在进程内不变，因此由环境变量设置的项实际上只能通过重启修改。
+func Reload() (*ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	old := Current()
+	if old == nil {
+		return nil, errors.New("configuration not loaded")
+	}
+	next, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{File: next.File, Applied: []string{}, RestartRequired: []string{}}
+	oldValue, nextValue := reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem()
+	for i := 0; i < nextValue.NumField(); i++ {
+		name := nextValue.Type().Field(i).Name
+		if name == "File" || reflect.DeepEqual(oldValue.Field(i).Interface(), nextValue.Field(i).Interface()) {
+			continue
+		}
+		if reloadable[name] {
+			result.Applied = append(result.Applied, name)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, name)
+			nextValue.Field(i).Set(oldValue.Field(i))
+		}
+	}
+	if len(result.Applied) == 0 {
+		return result, nil
+	}
+
+	mu.Lock()
+	current = next
+	fns := append([]func(*Config){}, hooks...)
+	mu.Unlock()
+
+	for _, fn := range fns {
+		fn(next)
+	}
+	return result, nil
+}
+
+// Redacted 返回配置的副本（按字段名），secrets 中的设置已隐藏
+func (c *Config) Redacted() map[string]interface{} {
+	v := reflect.ValueOf(c).Elem()
+	out := make(map[string]interface{}, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := v.Field(i).Interface()
+		if secrets[name] && !v.Field(i).IsZero() {
+			value = "***"
+		}
+		out[name] = value
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// DefaultBatchParams is the number of parameters a BatchInserter binds per
+// statement unless given a batch size
+// The SQLite driver matches each parameter to its argument with a linear
+// scan, so binding grows with the square of a statement's parameters; past a
+// few hundred that outweighs what fewer statements save (see
+// BenchmarkBatchInserter).
+const DefaultBatchParams = 240
+
+// maxBatchVariables bounds the parameters of a single statement, under
+// SQLite's SQLITE_MAX_VARIABLE_NUMBER (32766)
+const maxBatchVariables = 32000
+
+// batchTx is what a BatchInserter writes through; *sql.Tx and *Tx satisfy it
+type batchTx interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// BatchInserter buffers rows and writes them with multi-row INSERT
+// statements, which SQLite executes far faster than one statement per row.
+//
+// head is the statement up to VALUES, e.g. "INSERT OR REPLACE INTO t (a, b)",
+// and row the VALUES tuple of one row, e.g. "(?, ?, CURRENT_TIMESTAMP)"; the
+// tuple may hold constant expressions and subqueries. Full batches run
+// through a statement prepared once, the remaining rows on Flush. Callers
+// must Flush before committing and Close when done.
+type BatchInserter struct {
+	tx    batchTx
+	head  string
+	row   string
+	width int // Parameters per row
+	size  int // Rows per statement
+
+	rows  int           // Rows buffered
+	args  []interface{} // Parameters of the buffered rows
+	stmt  *sql.Stmt     // Prepared for a full batch on first use
+	count int           // Rows written
+}
+
+// NewBatchInserter creates a batch inserter writing size rows per statement
+// through tx; with size <= 0 a statement binds about DefaultBatchParams
+// parameters, and size is lowered so that it stays within SQLite's limit
+func NewBatchInserter(tx batchTx, head, row string, size int) *BatchInserter {
+	width := strings.Count(row, "?")
+	if size <= 0 {
+		size = DefaultBatchParams / max(width, 1)
+	}
+	if width > 0 && size*width > maxBatchVariables {
+		size = maxBatchVariables / width
+	}
+	size = max(size, 1)
+	return &BatchInserter{
+		tx:    tx,
+		head:  head,
+		row:   row,
+		width: width,
+		size:  size,
+		args:  make([]interface{}, 0, size*width),
+	}
+}
+
+// Add buffers a row, writing the batch once it is full
+func (b *BatchInserter) Add(ctx context.Context, args ...interface{}) error {
+	if len(args) != b.width {
+		return fmt.Errorf("batch insert: got %d values for %d parameters", len(args), b.width)
+	}
+	b.args = append(b.args, args...)
+	if b.rows++; b.rows < b.size {
+		return nil
+	}
+
+	if b.stmt == nil {
+		stmt, err := b.tx.PrepareContext(ctx, b.query(b.size))
+		if err != nil {
+			return fmt.Errorf("failed to prepare batch insert: %w", err)
+		}
+		b.stmt = stmt
+	}
+	if _, err := b.stmt.ExecContext(ctx, b.args...); err != nil {
+		return fmt.Errorf("failed to insert batch: %w", err)
+	}
+	b.count += b.size
+	b.rows, b.args = 0, b.args[:0]
+	return nil
+}
+
+// Flush writes the buffered rows
+func (b *BatchInserter) Flush(ctx context.Context) error {
+	if b.rows == 0 {
+		return nil
+	}
+	if _, err := b.tx.ExecContext(ctx, b.query(b.rows), b.args...); err != nil {
+		return fmt.Errorf("failed to insert batch: %w", err)
+	}
+	b.count += b.rows
+	b.rows, b.args = 0, b.args[:0]
+	return nil
+}
+
+// Close releases the prepared statement; buffered rows are dropped
+func (b *BatchInserter) Close() error {
+	b.rows, b.args = 0, b.args[:0]
+	if b.stmt == nil {
+		return nil
+	}
+	return b.stmt.Close()
+}
+
+// Count returns the number of rows written so far
+func (b *BatchInserter) Count() int {
+	return b.count
+}
+
+// query builds the INSERT statement for n rows
+func (b *BatchInserter) query(n int) string {
+	var sb strings.Builder
+	sb.Grow(len(b.head) + 8 + n*(len(b.row)+2))
+	sb.WriteString(b.head)
+	sb.WriteString(" VALUES ")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(b.row)
+	}
+	return sb.String()
+}
@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// benchRows is the number of rows each benchmark iteration inserts
+const benchRows = 10000
+
+// eventsHead and eventsRow insert a benchRow into the events table
+const (
+	eventsHead = `INSERT INTO events (
+		segment_id, start_ts, end_ts, max_speed, avg_speed, grid_id, created_at
+	)`
+	eventsRow = `(?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+)
+
+// benchDB opens a WAL database in a temporary directory with a table shaped
+// like speed_events
+func benchDB(tb testing.TB) *sql.DB {
+	tb.Helper()
+	db, err := sql.Open("sqlite", dsn(filepath.Join(tb.TempDir(), "bench.db"), basePragmas))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE events (
+		id INTEGER PRIMARY KEY, segment_id INTEGER, start_ts INTEGER, end_ts INTEGER,
+		max_speed REAL, avg_speed REAL, grid_id TEXT, created_at TEXT)`); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// countEvents returns the number of rows in the events table as seen by q
+func countEvents(t *testing.T, q interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}) int {
+	t.Helper()
+	var n int
+	if err := q.QueryRow("SELECT COUNT(*) FROM events").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestBatchInserterFlush(t *testing.T) {
+	db := benchDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	batch := NewBatchInserter(tx, eventsHead, eventsRow, 4)
+	add := func(from, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			if err := batch.Add(ctx, benchRow(i)...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Two full batches are written as they fill, the last two rows on Flush
+	add(0, 10)
+	if got := batch.Count(); got != 8 {
+		t.Errorf("Count() = %d before Flush, want 8", got)
+	}
+	if err := batch.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := batch.Count(); got != 10 {
+		t.Errorf("Count() = %d after Flush, want 10", got)
+	}
+
+	// Flushing again writes nothing; later rows keep counting
+	if err := batch.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	add(10, 13)
+	if err := batch.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := batch.Count(); got != 13 {
+		t.Errorf("Count() = %d after the second Flush, want 13", got)
+	}
+
+	if err := batch.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := countEvents(t, db); got != 13 {
+		t.Errorf("table holds %d rows, want 13", got)
+	}
+}
+
+func TestBatchInserterCloseWithoutFlush(t *testing.T) {
+	db := benchDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	// Close keeps the full batch already written and drops the partial one
+	batch := NewBatchInserter(tx, eventsHead, eventsRow, 4)
+	for i := 0; i < 6; i++ {
+		if err := batch.Add(ctx, benchRow(i)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := countEvents(t, tx); got != 4 || batch.Count() != 4 {
+		t.Errorf("table holds %d rows, Count() = %d, want 4", got, batch.Count())
+	}
+}
+
+func TestBatchInserterWidthMismatch(t *testing.T) {
+	db := benchDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	batch := NewBatchInserter(tx, eventsHead, eventsRow, 4)
+	defer batch.Close()
+	if err := batch.Add(ctx, benchRow(0)[:5]...); err == nil {
+		t.Error("Add with 5 values for 6 parameters succeeded")
+	}
+	if err := batch.Add(ctx, append(benchRow(0), "extra")...); err == nil {
+		t.Error("Add with 7 values for 6 parameters succeeded")
+	}
+
+	// Rejected rows are not buffered
+	if err := batch.Add(ctx, benchRow(0)...); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := countEvents(t, tx); got != 1 || batch.Count() != 1 {
+		t.Errorf("table holds %d rows, Count() = %d, want 1", got, batch.Count())
+	}
+}
+
+func TestBatchInserterSize(t *testing.T) {
+	for _, tt := range []struct {
+		row  string
+		size int
+		want int
+	}{
+		{eventsRow, 0, DefaultBatchParams / 6},
+		{eventsRow, 100, 100},
+		{eventsRow, 1 << 20, maxBatchVariables / 6},
+		{"(?, ?)", 1 << 20, maxBatchVariables / 2},
+		{"(?)", maxBatchVariables + 1, maxBatchVariables},
+		{"(1, CURRENT_TIMESTAMP)", 0, DefaultBatchParams},
+	} {
+		b := NewBatchInserter(nil, eventsHead, tt.row, tt.size)
+		if b.size != tt.want {
+			t.Errorf("NewBatchInserter(%q, %d) size = %d, want %d", tt.row, tt.size, b.size, tt.want)
+		}
+		if b.size*b.width > maxBatchVariables {
+			t.Errorf("NewBatchInserter(%q, %d) binds %d parameters, over %d", tt.row, tt.size, b.size*b.width, maxBatchVariables)
+		}
+	}
+
+	// A full batch of the capped size runs within SQLite's limit
+	db := benchDB(t)
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	batch := NewBatchInserter(tx, "INSERT INTO events (segment_id, start_ts)", "(?, ?)", 1<<20)
+	defer batch.Close()
+	for i := 0; i < batch.size; i++ {
+		if err := batch.Add(ctx, int64(i), int64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := batch.Count(); got != batch.size {
+		t.Errorf("Count() = %d, want a full batch of %d", got, batch.size)
+	}
+}
+
+// benchRow returns the values of the i-th benchmark row
+func benchRow(i int) []interface{} {
+	return []interface{}{int64(i / 10), int64(i * 60), int64(i*60 + 45), 41.5, 33.2, fmt.Sprintf("g%d", i%500)}
+}
+
+func BenchmarkInsertRowByRow(b *testing.B) {
+	db := benchDB(b)
+	ctx := context.Background()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO events (
+			segment_id, start_ts, end_ts, max_speed, avg_speed, grid_id, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < benchRows; i++ {
+			if _, err := stmt.ExecContext(ctx, benchRow(i)...); err != nil {
+				b.Fatal(err)
+			}
+		}
+		stmt.Close()
+		if err := tx.Commit(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBatchInserter(b *testing.B) {
+	// Size 0 sizes batches to DefaultBatchParams parameters, 40 rows here
+	for _, size := range []int{10, 0, 100, 500} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			db := benchDB(b)
+			ctx := context.Background()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				tx, err := db.BeginTx(ctx, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				batch := NewBatchInserter(tx, eventsHead, eventsRow, size)
+				for i := 0; i < benchRows; i++ {
+					if err := batch.Add(ctx, benchRow(i)...); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := batch.Flush(ctx); err != nil {
+					b.Fatal(err)
+				}
+				batch.Close()
+				if err := tx.Commit(); err != nil {
+					b.Fatal(err)
+				}
+				if batch.Count() != benchRows {
+					b.Fatalf("inserted %d rows, want %d", batch.Count(), benchRows)
+				}
+			}
+		})
+	}
+}
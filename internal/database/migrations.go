@@ -1,104 +1,120 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
-	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+
+	schema "github.com/jengzang/records-backend-go/scripts/tracks/migrations"
 )
 
 // Migration represents a database migration
 type Migration struct {
-	Version int
-	Name    string
-	SQL     string
+	Version  int
+	Name     string
+	SQL      string
+	Checksum string
 }
 
-// MigrationManager manages database migrations
+// MigrationManager applies the numbered SQL migrations from a file system
+// (normally the embedded scripts/tracks/migrations) and records them in the
+// schema_migrations table
 type MigrationManager struct {
-	db             *sql.DB
-	migrationsPath string
+	db   *sql.DB
+	fsys fs.FS
 }
 
 // NewMigrationManager creates a new migration manager
-func NewMigrationManager(db *sql.DB, migrationsPath string) *MigrationManager {
+func NewMigrationManager(db *sql.DB, fsys fs.FS) *MigrationManager {
 	return &MigrationManager{
-		db:             db,
-		migrationsPath: migrationsPath,
+		db:   db,
+		fsys: fsys,
 	}
 }
 
 // InitMigrationsTable creates the migrations tracking table
 func (m *MigrationManager) InitMigrationsTable() error {
 	query := `
-		CREATE TABLE IF NOT EXISTS migrations (
+		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version INTEGER PRIMARY KEY,
 			name TEXT NOT NULL,
-			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			checksum TEXT NOT NULL,
+			applied_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER))
 		)
 	`
 	_, err := m.db.Exec(query)
 	if err != nil {
-		return fmt.Errorf("failed to create migrations table: %w", err)
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
 	}
 	return nil
 }
 
-// GetAppliedMigrations returns a list of applied migration versions
-func (m *MigrationManager) GetAppliedMigrations() (map[int]bool, error) {
-	rows, err := m.db.Query("SELECT version FROM migrations ORDER BY version")
+// GetAppliedMigrations returns the checksums of applied migrations keyed by version
+func (m *MigrationManager) GetAppliedMigrations() (map[int]string, error) {
+	rows, err := m.db.Query("SELECT version, checksum FROM schema_migrations ORDER BY version")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query migrations: %w", err)
 	}
 	defer rows.Close()
 
-	applied := make(map[int]bool)
+	applied := make(map[int]string)
 	for rows.Next() {
 		var version int
-		if err := rows.Scan(&version); err != nil {
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
 			return nil, fmt.Errorf("failed to scan migration version: %w", err)
 		}
-		applied[version] = true
+		applied[version] = checksum
 	}
 
-	return applied, nil
+	return applied, rows.Err()
 }
 
-// LoadMigrations loads migration files from the migrations directory
+// LoadMigrations loads migration files from the migration file system
 func (m *MigrationManager) LoadMigrations() ([]Migration, error) {
-	files, err := ioutil.ReadDir(m.migrationsPath)
+	entries, err := fs.ReadDir(m.fsys, ".")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
 	}
 
 	var migrations []Migration
-	for _, file := range files {
-		if file.IsDir() || !strings.HasSuffix(file.Name(), ".sql") {
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
 			continue
 		}
 
 		// Parse version from filename (e.g., "001_add_admin_columns.sql")
-		var version int
-		var name string
-		_, err := fmt.Sscanf(file.Name(), "%d_%s", &version, &name)
-		if err != nil {
-			log.Printf("Warning: skipping migration file with invalid name: %s", file.Name())
+		prefix, _, ok := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil {
+			log.Printf("Warning: skipping migration file with invalid name: %s", entry.Name())
 			continue
 		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+		seen[version] = entry.Name()
 
 		// Read migration SQL
-		content, err := ioutil.ReadFile(filepath.Join(m.migrationsPath, file.Name()))
+		content, err := fs.ReadFile(m.fsys, entry.Name())
 		if err != nil {
-			return nil, fmt.Errorf("failed to read migration file %s: %w", file.Name(), err)
+			return nil, fmt.Errorf("failed to read migration file %s: %w", entry.Name(), err)
 		}
 
+		sum := sha256.Sum256(content)
 		migrations = append(migrations, Migration{
-			Version: version,
-			Name:    strings.TrimSuffix(file.Name(), ".sql"),
-			SQL:     string(content),
+			Version:  version,
+			Name:     strings.TrimSuffix(entry.Name(), ".sql"),
+			SQL:      string(content),
+			Checksum: hex.EncodeToString(sum[:]),
 		})
 	}
 
@@ -111,6 +127,11 @@ func (m *MigrationManager) LoadMigrations() ([]Migration, error) {
 }
 
 // ApplyMigration applies a single migration
+//
+// Statements run one by one in a single transaction. Databases that were
+// migrated by scripts/tracks/run_migration.py before schema_migrations existed
+// already have most of the schema, so "duplicate column" and "already exists"
+// errors are treated as the statement having been applied before.
 func (m *MigrationManager) ApplyMigration(migration Migration) error {
 	tx, err := m.db.Begin()
 	if err != nil {
@@ -125,14 +146,19 @@ func (m *MigrationManager) ApplyMigration(migration Migration) error {
 	}()
 
 	// Execute migration SQL
-	_, err = tx.Exec(migration.SQL)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("failed to execute migration %d: %w", migration.Version, err)
+	for _, stmt := range splitStatements(migration.SQL) {
+		if _, err := tx.Exec(stmt); err != nil {
+			if isAlreadyAppliedError(err) {
+				continue
+			}
+			tx.Rollback()
+			return fmt.Errorf("failed to execute migration %d (%s): %w", migration.Version, firstLine(stmt), err)
+		}
 	}
 
 	// Record migration
-	_, err = tx.Exec("INSERT INTO migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name)
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)",
+		migration.Version, migration.Name, migration.Checksum)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
@@ -166,17 +192,107 @@ func (m *MigrationManager) RunMigrations() error {
 	}
 
 	// Apply pending migrations
+	pending := 0
 	for _, migration := range migrations {
-		if applied[migration.Version] {
-			log.Printf("Skipping already applied migration %d: %s", migration.Version, migration.Name)
+		if checksum, ok := applied[migration.Version]; ok {
+			if checksum != migration.Checksum {
+				log.Printf("Warning: migration %d (%s) changed after it was applied", migration.Version, migration.Name)
+			}
 			continue
 		}
 
 		if err := m.ApplyMigration(migration); err != nil {
 			return err
 		}
+		pending++
 	}
 
-	log.Println("All migrations applied successfully")
+	if pending > 0 {
+		log.Printf("Applied %d migrations (schema version %d)", pending, migrations[len(migrations)-1].Version)
+	}
 	return nil
 }
+
+// RunMigrations applies the embedded schema migrations that db has not seen yet
+func RunMigrations(db *sql.DB) error {
+	return NewMigrationManager(db, schema.FS).RunMigrations()
+}
+
+// alreadyAppliedPatterns match errors raised when a statement's change is already present
+var alreadyAppliedPatterns = regexp.MustCompile(`duplicate column name|already exists`)
+
+func isAlreadyAppliedError(err error) bool {
+	return alreadyAppliedPatterns.MatchString(err.Error())
+}
+
+// triggerPattern matches the start of a CREATE TRIGGER statement, whose body
+// contains semicolons that do not end the statement
+var triggerPattern = regexp.MustCompile(`(?is)^CREATE\s+(TEMP\s+|TEMPORARY\s+)?TRIGGER\b`)
+
+var endPattern = regexp.MustCompile(`(?i)\bEND\s*$`)
+
+// splitStatements splits a migration script into individual statements,
+// dropping comments and skipping semicolons inside quotes and trigger bodies
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			i += end - 1
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 2
+			} else {
+				end += 2
+			}
+			current.WriteByte(' ')
+			i += 1 + end
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(script[i+1:], closing)
+			if end < 0 {
+				end = len(script) - i - 1
+			} else {
+				end++
+			}
+			current.WriteString(script[i : i+1+end])
+			i += end
+		case c == ';':
+			text := strings.TrimSpace(current.String())
+			if triggerPattern.MatchString(text) && !endPattern.MatchString(text) {
+				current.WriteByte(c)
+				continue
+			}
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
+
+func firstLine(stmt string) string {
+	line, _, _ := strings.Cut(stmt, "\n")
+	return strings.TrimSpace(line)
+}
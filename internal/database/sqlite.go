@@ -1,17 +1,21 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	_ "modernc.org/sqlite"
 )
 
 var (
-	db   *sql.DB
-	once sync.Once
+	db     *sql.DB
+	readDB *sql.DB
+	writer *Writer
+	once   sync.Once
 )
 
 // Config holds database configuration
@@ -19,11 +23,35 @@ type Config struct {
 	Path string
 }
 
-// Init initializes the database connection
+// Connection-level settings are passed as DSN pragmas so that every pooled
+// connection gets them; a plain "PRAGMA busy_timeout" via db.Exec only
+// configures whichever connection happened to run it.
+const (
+	basePragmas  = "_pragma=journal_mode(WAL)&_pragma=busy_timeout(30000)&_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)"
+	readPragmas  = basePragmas + "&_pragma=query_only(1)"
+	writePragmas = basePragmas + "&_txlock=immediate"
+)
+
+// dsn builds a modernc sqlite DSN for path with the given query parameters
+func dsn(path, params string) string {
+	if strings.HasPrefix(path, "file:") {
+		if strings.Contains(path, "?") {
+			return path + "&" + params
+		}
+		return path + "?" + params
+	}
+	return "file:" + path + "?" + params
+}
+
+// Init applies pending schema migrations and initializes the database connections:
+//   - the general pool returned by GetDB, used by the API
+//   - a read-only pool returned by GetReadDB, used by analyzers for queries
+//   - a single-connection pool owned by the Writer returned by GetWriter,
+//     through which analyzer writes are serialized
 func Init(cfg Config) error {
 	var err error
 	once.Do(func() {
-		db, err = sql.Open("sqlite", cfg.Path)
+		db, err = openTimed(dsn(cfg.Path, basePragmas), "api")
 		if err != nil {
 			return
 		}
@@ -32,29 +60,47 @@ func Init(cfg Config) error {
 		db.SetMaxOpenConns(10)
 		db.SetMaxIdleConns(5)
 
-		// Enable WAL mode for better concurrency
-		_, err = db.Exec("PRAGMA journal_mode=WAL")
+		// Test connection (also switches the file to WAL mode)
+		err = db.Ping()
 		if err != nil {
 			return
 		}
 
-		// Set busy timeout to 30 seconds to handle concurrent access
-		_, err = db.Exec("PRAGMA busy_timeout=30000")
+		readDB, err = openTimed(dsn(cfg.Path, readPragmas), "read")
 		if err != nil {
 			return
 		}
+		readDB.SetMaxOpenConns(8)
+		readDB.SetMaxIdleConns(4)
+		if err = readDB.Ping(); err != nil {
+			return
+		}
 
-		// Enable foreign keys
-		_, err = db.Exec("PRAGMA foreign_keys=ON")
+		// BEGIN IMMEDIATE takes the write lock up front, so a transaction never
+		// has to upgrade from a read lock halfway through
+		var writeDB *sql.DB
+		writeDB, err = openTimed(dsn(cfg.Path, writePragmas), "write")
 		if err != nil {
 			return
 		}
+		writeDB.SetMaxOpenConns(1)
+		writeDB.SetMaxIdleConns(1)
+		writeDB.SetConnMaxLifetime(0)
+		if err = writeDB.Ping(); err != nil {
+			writeDB.Close()
+			return
+		}
 
-		// Test connection
-		err = db.Ping()
-		if err != nil {
+		// Create or upgrade the schema before anything reads or writes it
+		if err = RunMigrations(writeDB); err != nil {
+			writeDB.Close()
+			err = fmt.Errorf("failed to run migrations: %w", err)
 			return
 		}
+		if oerr := optimize(writeDB); oerr != nil {
+			log.Printf("Warning: failed to optimize database: %v", oerr)
+		}
+		writer = NewWriter(writeDB)
 
 		log.Printf("Database initialized successfully: %s", cfg.Path)
 	})
@@ -62,6 +108,29 @@ func Init(cfg Config) error {
 	return err
 }
 
+// optimizeAnalysisLimit bounds the rows ANALYZE samples per index in optimize
+const optimizeAnalysisLimit = 1000
+
+// optimize refreshes the query planner statistics (sqlite_stat1) of the
+// tables whose indexes changed or grew noticeably since they were last
+// analyzed, which is how new indexes get picked up after a migration
+// Sampling keeps it quick on large tables.
+func optimize(db *sql.DB) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA analysis_limit = %d", optimizeAnalysisLimit)); err != nil {
+		return err
+	}
+	// 0x10002: analyze tables that need it, checking all tables rather than
+	// only those this connection queried
+	_, err = conn.ExecContext(context.Background(), "PRAGMA optimize = 0x10002")
+	return err
+}
+
 // GetDB returns the database instance
 func GetDB() *sql.DB {
 	if db == nil {
@@ -70,12 +139,42 @@ func GetDB() *sql.DB {
 	return db
 }
 
-// Close closes the database connection
+// GetReadDB returns the read-only connection pool
+func GetReadDB() *sql.DB {
+	if readDB == nil {
+		log.Fatal("Database not initialized. Call Init() first.")
+	}
+	return readDB
+}
+
+// GetWriter returns the single writer that serializes analyzer writes
+func GetWriter() *Writer {
+	if writer == nil {
+		log.Fatal("Database not initialized. Call Init() first.")
+	}
+	return writer
+}
+
+// Close closes the writer and all connection pools
 func Close() error {
+	var firstErr error
+	if writer != nil {
+		writer.Close()
+		if err := writer.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if readDB != nil {
+		if err := readDB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	if db != nil {
-		return db.Close()
+		if err := db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
-	return nil
+	return firstErr
 }
 
 // Transaction executes a function within a database transaction
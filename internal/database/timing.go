@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/metrics"
+)
+
+// openTimed opens a connection pool whose statements report their latency to
+// metrics.DBQueryDuration under the given pool name
+func openTimed(name, pool string) (*sql.DB, error) {
+	// sql.Open does not connect; it only resolves the registered driver
+	probe, err := sql.Open("sqlite", name)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	probe.Close()
+
+	return sql.OpenDB(&timedConnector{name: name, driver: drv, pool: pool}), nil
+}
+
+// timedConnector opens driver connections wrapped in timedConn
+type timedConnector struct {
+	name   string
+	driver driver.Driver
+	pool   string
+}
+
+func (t *timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := t.driver.Open(t.name)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, pool: t.pool}, nil
+}
+
+func (t *timedConnector) Driver() driver.Driver {
+	return t.driver
+}
+
+func observe(pool, op string, start time.Time) {
+	metrics.DBQueryDuration.Observe(time.Since(start).Seconds(), pool, op)
+}
+
+// timedConn times statements and commits of a driver connection
+// Query latency covers the statement up to its first row.
+type timedConn struct {
+	driver.Conn
+	pool string
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, pool: c.pool}, nil
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	pc, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := pc.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &timedStmt{Stmt: stmt, pool: c.pool}, nil
+}
+
+func (c *timedConn) Begin() (driver.Tx, error) {
+	tx, err := c.Conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &timedTx{Tx: tx, pool: c.pool}, nil
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	bt, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return c.Begin()
+	}
+	tx, err := bt.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &timedTx{Tx: tx, pool: c.pool}, nil
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ex, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observe(c.pool, "exec", time.Now())
+	return ex.ExecContext(ctx, query, args)
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	defer observe(c.pool, "query", time.Now())
+	return q.QueryContext(ctx, query, args)
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// timedTx times commits, where SQLite does most of the work of a write
+type timedTx struct {
+	driver.Tx
+	pool string
+}
+
+func (t *timedTx) Commit() error {
+	defer observe(t.pool, "commit", time.Now())
+	return t.Tx.Commit()
+}
+
+// timedStmt times the executions of a prepared statement
+type timedStmt struct {
+	driver.Stmt
+	pool string
+}
+
+func (s *timedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	defer observe(s.pool, "exec", time.Now())
+	if ex, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return ex.ExecContext(ctx, args)
+	}
+	return s.Stmt.Exec(namedToValues(args))
+}
+
+func (s *timedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	defer observe(s.pool, "query", time.Now())
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return q.QueryContext(ctx, args)
+	}
+	return s.Stmt.Query(namedToValues(args))
+}
+
+func namedToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWriterClosed is returned when a write is submitted after the writer was closed
+var ErrWriterClosed = errors.New("database writer is closed")
+
+// Writer serializes write transactions through a single goroutine.
+//
+// SQLite allows only one writer at a time. Instead of letting every analyzer
+// open its own transaction and fight over the write lock (SQLITE_BUSY, lock
+// upgrades deadlocking against readers), callers queue up and the writer
+// goroutine grants the connection to one transaction at a time. Reads never
+// go through the writer; in WAL mode they proceed concurrently on the read pool.
+type Writer struct {
+	db       *sql.DB
+	requests chan writeRequest
+	quit     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	waiting  atomic.Int64 // Callers waiting for the writer
+}
+
+type writeRequest struct {
+	ctx   context.Context
+	reply chan writeLease
+}
+
+type writeLease struct {
+	tx       *sql.Tx
+	released chan struct{}
+	err      error
+}
+
+// Tx is a write transaction leased from the Writer. The next queued write
+// starts only after Commit or Rollback, so callers must always call one of
+// them (the usual `defer tx.Rollback()` is enough).
+type Tx struct {
+	*sql.Tx
+	release func()
+}
+
+// Commit commits the transaction and hands the writer to the next caller
+func (t *Tx) Commit() error {
+	defer t.done()
+	return t.Tx.Commit()
+}
+
+// Rollback aborts the transaction and hands the writer to the next caller
+func (t *Tx) Rollback() error {
+	defer t.done()
+	return t.Tx.Rollback()
+}
+
+func (t *Tx) done() {
+	if t.release != nil {
+		t.release()
+	}
+}
+
+// WrapTx wraps a plain transaction that is not managed by a Writer
+func WrapTx(tx *sql.Tx) *Tx {
+	return &Tx{Tx: tx}
+}
+
+// NewWriter starts a writer goroutine on db. db should be limited to a single
+// open connection so nothing else can write behind the writer's back.
+// Pending callers wait on the unbuffered request channel, which hands the
+// writer out roughly in arrival order.
+func NewWriter(db *sql.DB) *Writer {
+	w := &Writer{
+		db:       db,
+		requests: make(chan writeRequest),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for {
+		select {
+		case req := <-w.requests:
+			w.serve(req)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// serve begins a transaction for one request and blocks until the caller
+// releases it, or until the caller's context is cancelled (database/sql then
+// rolls the transaction back on its own).
+func (w *Writer) serve(req writeRequest) {
+	if req.ctx.Err() != nil {
+		return
+	}
+
+	tx, err := w.db.BeginTx(req.ctx, nil)
+	lease := writeLease{tx: tx, err: err, released: make(chan struct{})}
+	req.reply <- lease
+	if err != nil {
+		return
+	}
+
+	select {
+	case <-lease.released:
+	case <-req.ctx.Done():
+		tx.Rollback()
+	}
+}
+
+// BeginTx waits for its turn in the write queue and starts a transaction
+func (w *Writer) BeginTx(ctx context.Context) (*Tx, error) {
+	req := writeRequest{ctx: ctx, reply: make(chan writeLease, 1)}
+	w.waiting.Add(1)
+	defer w.waiting.Add(-1)
+
+	select {
+	case w.requests <- req:
+	case <-w.quit:
+		return nil, ErrWriterClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case lease := <-req.reply:
+		if lease.err != nil {
+			return nil, lease.err
+		}
+		var once sync.Once
+		return &Tx{
+			Tx:      lease.tx,
+			release: func() { once.Do(func() { close(lease.released) }) },
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// QueueDepth returns the number of callers waiting for a write transaction
+func (w *Writer) QueueDepth() int {
+	return int(w.waiting.Load())
+}
+
+// ExecContext runs a single statement in its own queued transaction
+func (w *Writer) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	tx, err := w.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Close stops accepting writes and waits for the writer goroutine to exit.
+// A transaction that is in flight keeps the goroutine until it is released.
+func (w *Writer) Close() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+	})
+	<-w.done
+}
@@ -0,0 +1,45 @@
+// Package elevation looks up terrain elevation from a digital elevation model.
+//
+// Lookups go through the Provider interface so offline SRTM tiles can be used
+// where they have been downloaded, and an OpenTopoData-compatible terrain API
+// elsewhere. A process-wide default provider is set at startup and used by the
+// elevation_backfill analyzer.
+package elevation
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// ErrNoProvider is returned when no elevation provider has been configured
+var ErrNoProvider = errors.New("no elevation provider configured")
+
+// Provider returns the terrain elevation (meters above sea level) of points
+// The result has one value per point; NaN where the point lies outside the
+// provider's coverage or the model has no data.
+type Provider interface {
+	Elevations(ctx context.Context, points []spatial.Point) ([]float64, error)
+	Name() string
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultProvider Provider
+)
+
+// SetDefaultProvider sets the provider used by the backfill analyzer
+func SetDefaultProvider(p Provider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = p
+}
+
+// DefaultProvider returns the configured provider, or nil if none was set
+func DefaultProvider() Provider {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProvider
+}
@@ -0,0 +1,107 @@
+package elevation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// openTopoDataMaxPoints is the default location limit per request of OpenTopoData
+const openTopoDataMaxPoints = 100
+
+// OpenTopoDataProvider queries the elevation API of an OpenTopoData server
+// The public instance at https://api.opentopodata.org is rate limited; a
+// self-hosted server over local SRTM or ASTER files is not.
+type OpenTopoDataProvider struct {
+	baseURL   string
+	dataset   string
+	maxPoints int
+	client    *http.Client
+}
+
+// NewOpenTopoDataProvider creates a provider for the server at baseURL
+// dataset is the server's dataset name, "srtm30m" when empty.
+func NewOpenTopoDataProvider(baseURL, dataset string) *OpenTopoDataProvider {
+	if dataset == "" {
+		dataset = "srtm30m"
+	}
+	return &OpenTopoDataProvider{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		dataset:   dataset,
+		maxPoints: openTopoDataMaxPoints,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the provider name
+func (p *OpenTopoDataProvider) Name() string {
+	return "opentopodata"
+}
+
+type openTopoDataResponse struct {
+	Status  string `json:"status"`
+	Error   string `json:"error"`
+	Results []struct {
+		Elevation *float64 `json:"elevation"` // null outside the dataset's coverage
+	} `json:"results"`
+}
+
+// Elevations looks the points up in requests of at most maxPoints locations
+func (p *OpenTopoDataProvider) Elevations(ctx context.Context, points []spatial.Point) ([]float64, error) {
+	out := make([]float64, 0, len(points))
+	for start := 0; start < len(points); start += p.maxPoints {
+		part, err := p.lookup(ctx, points[start:min(start+p.maxPoints, len(points))])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, part...)
+	}
+	return out, nil
+}
+
+func (p *OpenTopoDataProvider) lookup(ctx context.Context, points []spatial.Point) ([]float64, error) {
+	locations := make([]string, len(points))
+	for i, pt := range points {
+		locations[i] = strconv.FormatFloat(pt.Lat, 'f', 6, 64) + "," + strconv.FormatFloat(pt.Lon, 'f', 6, 64)
+	}
+	endpoint := fmt.Sprintf("%s/v1/%s?%s", p.baseURL, url.PathEscape(p.dataset),
+		url.Values{"locations": {strings.Join(locations, "|")}}.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OpenTopoData request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OpenTopoData: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body openTopoDataResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenTopoData response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Status != "OK" {
+		return nil, fmt.Errorf("OpenTopoData lookup failed: %s %s", body.Status, body.Error)
+	}
+	if len(body.Results) != len(points) {
+		return nil, fmt.Errorf("OpenTopoData returned %d results for %d locations", len(body.Results), len(points))
+	}
+
+	out := make([]float64, len(points))
+	for i, r := range body.Results {
+		out[i] = math.NaN()
+		if r.Elevation != nil {
+			out[i] = *r.Elevation
+		}
+	}
+	return out, nil
+}
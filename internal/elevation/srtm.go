@@ -0,0 +1,156 @@
+package elevation
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// srtmVoid marks samples without data in SRTM tiles
+const srtmVoid = -32768
+
+// srtmMaxTiles bounds the tiles held in memory; a 1 arc-second tile takes ~26 MB
+const srtmMaxTiles = 16
+
+// SRTMProvider reads elevations from SRTM .hgt tiles in a directory
+// Tiles are named after their south-west corner (N23E113.hgt) and may have
+// 1201x1201 (3 arc-second) or 3601x3601 (1 arc-second) samples. They are
+// loaded on first use; points on missing tiles have no elevation.
+type SRTMProvider struct {
+	dir string
+
+	mu    sync.Mutex
+	tiles map[string]*srtmTile // nil for tiles that are not in the directory
+}
+
+type srtmTile struct {
+	size    int // samples per row and column
+	samples []int16
+}
+
+// NewSRTMProvider creates a provider for the tiles in dir
+func NewSRTMProvider(dir string) *SRTMProvider {
+	return &SRTMProvider{dir: dir, tiles: make(map[string]*srtmTile)}
+}
+
+// Name returns the provider name
+func (p *SRTMProvider) Name() string {
+	return "srtm"
+}
+
+// Elevations interpolates the elevation of each point bilinearly between the four nearest samples
+func (p *SRTMProvider) Elevations(ctx context.Context, points []spatial.Point) ([]float64, error) {
+	out := make([]float64, len(points))
+	for i, pt := range points {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		latFloor, lonFloor := math.Floor(pt.Lat), math.Floor(pt.Lon)
+		tile, err := p.tile(int(latFloor), int(lonFloor))
+		if err != nil {
+			return nil, err
+		}
+		if tile == nil {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = tile.interpolate(pt.Lat-latFloor, pt.Lon-lonFloor)
+	}
+	return out, nil
+}
+
+// tile returns the tile whose south-west corner is at (lat, lon), loading it on first use
+func (p *SRTMProvider) tile(lat, lon int) (*srtmTile, error) {
+	name := srtmTileName(lat, lon)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t, ok := p.tiles[name]; ok {
+		return t, nil
+	}
+
+	t, err := loadSRTMTile(filepath.Join(p.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(p.tiles) >= srtmMaxTiles {
+		for k := range p.tiles {
+			delete(p.tiles, k)
+			break
+		}
+	}
+	p.tiles[name] = t
+	return t, nil
+}
+
+// srtmTileName returns the file name of the tile with its south-west corner at (lat, lon)
+func srtmTileName(lat, lon int) string {
+	ns, ew := 'N', 'E'
+	if lat < 0 {
+		ns, lat = 'S', -lat
+	}
+	if lon < 0 {
+		ew, lon = 'W', -lon
+	}
+	return fmt.Sprintf("%c%02d%c%03d.hgt", ns, lat, ew, lon)
+}
+
+// loadSRTMTile reads a tile; nil without error when the file does not exist
+func loadSRTMTile(path string) (*srtmTile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SRTM tile: %w", err)
+	}
+
+	size := int(math.Sqrt(float64(len(data) / 2)))
+	if size < 2 || size*size*2 != len(data) {
+		return nil, fmt.Errorf("invalid SRTM tile %s: %d bytes", filepath.Base(path), len(data))
+	}
+
+	samples := make([]int16, size*size)
+	for i := range samples {
+		samples[i] = int16(binary.BigEndian.Uint16(data[2*i:]))
+	}
+	return &srtmTile{size: size, samples: samples}, nil
+}
+
+// interpolate returns the elevation at an offset (0-1 degrees) from the tile's south-west corner
+// Void samples are left out of the weighting; NaN when all four are void.
+func (t *srtmTile) interpolate(dLat, dLon float64) float64 {
+	// Rows run from the north edge southwards, columns from the west edge eastwards
+	y := (1 - dLat) * float64(t.size-1)
+	x := dLon * float64(t.size-1)
+	row, col := min(int(y), t.size-2), min(int(x), t.size-2)
+	fy, fx := y-float64(row), x-float64(col)
+
+	var sum, weights float64
+	for _, c := range [4]struct {
+		r, c int
+		w    float64
+	}{
+		{row, col, (1 - fy) * (1 - fx)},
+		{row, col + 1, (1 - fy) * fx},
+		{row + 1, col, fy * (1 - fx)},
+		{row + 1, col + 1, fy * fx},
+	} {
+		v := t.samples[c.r*t.size+c.c]
+		if v == srtmVoid || c.w == 0 {
+			continue
+		}
+		sum += float64(v) * c.w
+		weights += c.w
+	}
+	if weights == 0 {
+		return math.NaN()
+	}
+	return sum / weights
+}
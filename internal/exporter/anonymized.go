@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// PointWriter streams anonymized points to an export format
+// Close writes the trailer of the format and flushes buffered output.
+type PointWriter interface {
+	WritePoint(p *models.AnonymizedPoint) error
+	Close() error
+}
+
+// NewPointWriter creates a point writer for an export format (geojson or csv)
+func NewPointWriter(w io.Writer, format string) (PointWriter, error) {
+	switch format {
+	case models.ExportFormatGeoJSON:
+		return &geoJSONPointWriter{w: bufio.NewWriterSize(w, 32*1024)}, nil
+	case models.ExportFormatCSV:
+		return newCSVPointWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// geoJSONPointWriter writes a FeatureCollection of Point features, one per track point
+type geoJSONPointWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+type geoJSONFeature struct {
+	Type       string            `json:"type"`
+	Geometry   geoJSONPoint      `json:"geometry"`
+	Properties geoJSONProperties `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // [lon, lat]
+}
+
+type geoJSONProperties struct {
+	Time     string  `json:"time"`
+	Altitude float64 `json:"altitude,omitempty"`
+	Mode     string  `json:"mode,omitempty"`
+	Country  string  `json:"country,omitempty"`
+	Province string  `json:"province,omitempty"`
+	City     string  `json:"city,omitempty"`
+}
+
+func (g *geoJSONPointWriter) WritePoint(p *models.AnonymizedPoint) error {
+	if g.count == 0 {
+		g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	} else {
+		g.w.WriteByte(',')
+	}
+	g.count++
+
+	b, err := json.Marshal(geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONPoint{Type: "Point", Coordinates: [2]float64{p.Longitude, p.Latitude}},
+		Properties: geoJSONProperties{
+			Time:     formatGPXTime(p.DataTime),
+			Altitude: p.Altitude,
+			Mode:     p.Mode,
+			Country:  p.Country,
+			Province: p.Province,
+			City:     p.City,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode feature: %w", err)
+	}
+	_, err = g.w.Write(b)
+	return err
+}
+
+func (g *geoJSONPointWriter) Close() error {
+	if g.count == 0 {
+		g.w.WriteString(`{"type":"FeatureCollection","features":[`)
+	}
+	g.w.WriteString(`]}`)
+	return g.w.Flush()
+}
+
+// csvPointWriter writes one row per track point after a header row
+type csvPointWriter struct {
+	w *csv.Writer
+}
+
+var csvExportHeader = []string{"time", "latitude", "longitude", "altitude", "mode", "country", "province", "city"}
+
+func newCSVPointWriter(w io.Writer) *csvPointWriter {
+	c := &csvPointWriter{w: csv.NewWriter(w)}
+	c.w.Write(csvExportHeader)
+	return c
+}
+
+func (c *csvPointWriter) WritePoint(p *models.AnonymizedPoint) error {
+	return c.w.Write([]string{
+		formatGPXTime(p.DataTime),
+		strconv.FormatFloat(p.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(p.Longitude, 'f', -1, 64),
+		strconv.FormatFloat(p.Altitude, 'f', -1, 64),
+		p.Mode,
+		p.Country,
+		p.Province,
+		p.City,
+	})
+}
+
+func (c *csvPointWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,96 @@
+// Package exporter writes track data to interchange formats
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+const gpxCreator = "records-backend-go"
+
+type gpxFile struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Tracks   []gpxTrack  `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time,omitempty"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Type     string       `xml:"type,omitempty"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele,omitempty"`
+	Time string   `xml:"time"`
+}
+
+// WriteTripGPX writes a trip route as a GPX 1.1 document
+// Consecutive segments with the same transport mode share one <trk>, each segment is a <trkseg>
+func WriteTripGPX(w io.Writer, route *models.TripRoute) error {
+	name := fmt.Sprintf("Trip %s #%d", route.Date, route.TripNumber)
+	doc := gpxFile{
+		Version:  "1.1",
+		Creator:  gpxCreator,
+		Xmlns:    "http://www.topografix.com/GPX/1/1",
+		Metadata: gpxMetadata{Name: name},
+	}
+	if route.StartTime > 0 {
+		doc.Metadata.Time = formatGPXTime(route.StartTime)
+	}
+
+	for _, seg := range route.Segments {
+		trkseg := gpxSegment{Points: make([]gpxPoint, 0, len(seg.Points))}
+		for _, p := range seg.Points {
+			pt := gpxPoint{Lat: p.Latitude, Lon: p.Longitude, Time: formatGPXTime(p.DataTime)}
+			if p.Altitude != 0 {
+				ele := p.Altitude
+				pt.Ele = &ele
+			}
+			trkseg.Points = append(trkseg.Points, pt)
+		}
+
+		if n := len(doc.Tracks); n > 0 && doc.Tracks[n-1].Type == seg.Mode {
+			doc.Tracks[n-1].Segments = append(doc.Tracks[n-1].Segments, trkseg)
+			continue
+		}
+		doc.Tracks = append(doc.Tracks, gpxTrack{
+			Name:     fmt.Sprintf("%s (%s)", name, seg.Mode),
+			Type:     seg.Mode,
+			Segments: []gpxSegment{trkseg},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode GPX: %w", err)
+	}
+	return enc.Flush()
+}
+
+// formatGPXTime formats a unix timestamp as GPX (UTC ISO 8601) time
+func formatGPXTime(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"github.com/jengzang/records-backend-go/internal/staticmap"
+)
+
+// Share card layout, in pixels; 1200x630 is the usual link preview size
+const (
+	cardWidth     = 1200
+	cardHeight    = 630
+	cardMapHeight = 440
+	cardMargin    = 40
+)
+
+// ShareCard is the content of a share card: a map of the tracks above a
+// title and a row of key figures
+// Text is drawn with the built-in ASCII font; callers leave out what it
+// cannot render.
+type ShareCard struct {
+	Title    string
+	Subtitle string
+	Stats    []CardStat
+	Tracks   []CardTrack
+}
+
+// CardStat is one key figure, e.g. {"12.4 KM", "DISTANCE"}
+type CardStat struct {
+	Value string
+	Label string
+}
+
+// CardTrack is a stretch of track drawn in the colour of its mode
+type CardTrack struct {
+	Mode   string
+	Points []staticmap.Point
+}
+
+var (
+	cardBackground = color.RGBA{255, 255, 255, 255}
+	cardInk        = color.RGBA{33, 37, 41, 255}
+	cardMuted      = color.RGBA{120, 126, 133, 255}
+	cardStart      = color.RGBA{46, 160, 67, 255}
+	cardEnd        = color.RGBA{218, 54, 51, 255}
+)
+
+// modeColors are the track colours by transport mode
+var modeColors = map[string]color.RGBA{
+	"WALK":   {46, 160, 67, 255},
+	"RUN":    {26, 127, 55, 255},
+	"BIKE":   {0, 150, 136, 255},
+	"CAR":    {31, 111, 235, 255},
+	"BUS":    {130, 80, 223, 255},
+	"SUBWAY": {191, 57, 137, 255},
+	"TRAIN":  {230, 126, 34, 255},
+	"HSR":    {230, 126, 34, 255},
+	"FLIGHT": {218, 54, 51, 255},
+}
+
+// ModeColor returns the track colour of a transport mode
+func ModeColor(mode string) color.RGBA {
+	if c, ok := modeColors[mode]; ok {
+		return c
+	}
+	return color.RGBA{110, 118, 129, 255}
+}
+
+// WriteShareCard renders a share card as PNG
+func WriteShareCard(w io.Writer, card *ShareCard) error {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+
+	m := &staticmap.Map{
+		Background: color.RGBA{242, 239, 233, 255},
+		Grid:       color.RGBA{221, 216, 207, 255},
+		Padding:    36,
+	}
+	var first, last *staticmap.Point
+	for _, t := range card.Tracks {
+		if len(t.Points) == 0 {
+			continue
+		}
+		m.Lines = append(m.Lines, staticmap.Line{Points: t.Points, Color: ModeColor(t.Mode), Width: 5})
+		if first == nil {
+			first = &t.Points[0]
+		}
+		last = &t.Points[len(t.Points)-1]
+	}
+	if first != nil {
+		m.Markers = append(m.Markers,
+			staticmap.Marker{At: *first, Color: cardStart, Radius: 8},
+			staticmap.Marker{At: *last, Color: cardEnd, Radius: 8})
+	}
+	m.Draw(img, image.Rect(0, 0, cardWidth, cardMapHeight))
+
+	panel := image.Rect(0, cardMapHeight, cardWidth, cardHeight)
+	draw.Draw(img, panel, &image.Uniform{C: cardBackground}, image.Point{}, draw.Src)
+
+	y := cardMapHeight + 24
+	staticmap.DrawText(img, cardMargin, y, card.Title, 4, cardInk)
+	if card.Subtitle != "" {
+		staticmap.DrawText(img, cardMargin, y+40, card.Subtitle, 2, cardMuted)
+	}
+
+	if n := len(card.Stats); n > 0 {
+		colWidth := (cardWidth - 2*cardMargin) / n
+		for i, s := range card.Stats {
+			x := cardMargin + i*colWidth
+			staticmap.DrawText(img, x, cardHeight-88, s.Value, 5, cardInk)
+			staticmap.DrawText(img, x, cardHeight-42, s.Label, 2, cardMuted)
+		}
+	}
+
+	return png.Encode(w, img)
+}
@@ -0,0 +1,282 @@
+package geocode
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// BoundaryOptions configures which GeoJSON properties hold each level's name
+// The first non-empty property found in each list is used.
+type BoundaryOptions struct {
+	ProvinceKeys []string
+	CityKeys     []string
+	CountyKeys   []string
+	TownKeys     []string
+	CellSizeDeg  float64 // Grid index cell size in degrees (default 0.25)
+}
+
+// DefaultBoundaryOptions matches common English and Chinese column names of
+// administrative boundary datasets (e.g. the 2024 national township boundaries
+// used by scripts/geocoding, exported with `ogr2ogr -f GeoJSON`)
+var DefaultBoundaryOptions = BoundaryOptions{
+	ProvinceKeys: []string{"province", "省", "省名", "省级", "prov_name", "NAME_1"},
+	CityKeys:     []string{"city", "市", "市名", "地级", "city_name", "NAME_2"},
+	CountyKeys:   []string{"county", "县", "县名", "县级", "区县", "county_name", "NAME_3"},
+	TownKeys:     []string{"town", "乡", "乡名", "乡级", "乡镇", "镇", "town_name", "NAME_4"},
+	CellSizeDeg:  0.25,
+}
+
+// BoundaryProvider is an offline provider backed by boundary polygons
+type BoundaryProvider struct {
+	features []boundaryFeature
+	index    map[gridKey][]int
+	cellSize float64
+}
+
+type boundaryFeature struct {
+	division AdminDivision
+	polygons [][][][2]float64 // polygon -> ring -> [lon, lat]
+	minLat   float64
+	minLon   float64
+	maxLat   float64
+	maxLon   float64
+}
+
+type gridKey struct {
+	x, y int
+}
+
+type geoJSONCollection struct {
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+}
+
+// LoadBoundaryProvider loads a GeoJSON FeatureCollection of Polygon /
+// MultiPolygon features from path
+func LoadBoundaryProvider(path string, opts BoundaryOptions) (*BoundaryProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boundary file: %w", err)
+	}
+
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse boundary file: %w", err)
+	}
+
+	return newBoundaryProvider(collection.Features, opts)
+}
+
+func newBoundaryProvider(features []geoJSONFeature, opts BoundaryOptions) (*BoundaryProvider, error) {
+	if opts.CellSizeDeg <= 0 {
+		opts.CellSizeDeg = DefaultBoundaryOptions.CellSizeDeg
+	}
+
+	p := &BoundaryProvider{
+		index:    make(map[gridKey][]int),
+		cellSize: opts.CellSizeDeg,
+	}
+
+	for i, f := range features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		var polygons [][][][2]float64
+		switch f.Geometry.Type {
+		case "Polygon":
+			var rings [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &rings); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid polygon: %w", i, err)
+			}
+			polygons = append(polygons, toRings(rings))
+		case "MultiPolygon":
+			var multi [][][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &multi); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid multipolygon: %w", i, err)
+			}
+			for _, rings := range multi {
+				polygons = append(polygons, toRings(rings))
+			}
+		default:
+			continue
+		}
+
+		feature := boundaryFeature{
+			division: AdminDivision{
+				Province: property(f.Properties, opts.ProvinceKeys),
+				City:     property(f.Properties, opts.CityKeys),
+				County:   property(f.Properties, opts.CountyKeys),
+				Town:     property(f.Properties, opts.TownKeys),
+			},
+			polygons: polygons,
+			minLat:   math.Inf(1),
+			minLon:   math.Inf(1),
+			maxLat:   math.Inf(-1),
+			maxLon:   math.Inf(-1),
+		}
+		if feature.division.IsEmpty() {
+			continue
+		}
+		for _, polygon := range polygons {
+			for _, ring := range polygon {
+				for _, pt := range ring {
+					feature.minLon = math.Min(feature.minLon, pt[0])
+					feature.maxLon = math.Max(feature.maxLon, pt[0])
+					feature.minLat = math.Min(feature.minLat, pt[1])
+					feature.maxLat = math.Max(feature.maxLat, pt[1])
+				}
+			}
+		}
+		if math.IsInf(feature.minLat, 1) {
+			continue
+		}
+
+		idx := len(p.features)
+		p.features = append(p.features, feature)
+
+		minKey := p.key(feature.minLat, feature.minLon)
+		maxKey := p.key(feature.maxLat, feature.maxLon)
+		for x := minKey.x; x <= maxKey.x; x++ {
+			for y := minKey.y; y <= maxKey.y; y++ {
+				k := gridKey{x, y}
+				p.index[k] = append(p.index[k], idx)
+			}
+		}
+	}
+
+	if len(p.features) == 0 {
+		return nil, fmt.Errorf("boundary file contains no usable polygon features")
+	}
+
+	return p, nil
+}
+
+// ReverseGeocode returns the division of the most detailed feature containing the point
+func (p *BoundaryProvider) ReverseGeocode(lat, lon float64) (AdminDivision, bool, error) {
+	var best *boundaryFeature
+	bestDepth := -1
+
+	for _, idx := range p.index[p.key(lat, lon)] {
+		f := &p.features[idx]
+		if lat < f.minLat || lat > f.maxLat || lon < f.minLon || lon > f.maxLon {
+			continue
+		}
+		if !f.contains(lat, lon) {
+			continue
+		}
+		if depth := f.division.depth(); depth > bestDepth {
+			best = f
+			bestDepth = depth
+		}
+	}
+
+	if best == nil {
+		return AdminDivision{}, false, nil
+	}
+	return best.division, true, nil
+}
+
+// Name returns the provider name
+func (p *BoundaryProvider) Name() string {
+	return "boundary"
+}
+
+// FeatureCount returns the number of loaded boundary features
+func (p *BoundaryProvider) FeatureCount() int {
+	return len(p.features)
+}
+
+// Divisions returns the divisions of the loaded boundary features
+func (p *BoundaryProvider) Divisions() []AdminDivision {
+	divisions := make([]AdminDivision, len(p.features))
+	for i, f := range p.features {
+		divisions[i] = f.division
+	}
+	return divisions
+}
+
+func (p *BoundaryProvider) key(lat, lon float64) gridKey {
+	return gridKey{
+		x: int(math.Floor(lon / p.cellSize)),
+		y: int(math.Floor(lat / p.cellSize)),
+	}
+}
+
+// contains tests the point against every polygon (even-odd rule, so holes are excluded)
+func (f *boundaryFeature) contains(lat, lon float64) bool {
+	for _, polygon := range f.polygons {
+		inside := false
+		for _, ring := range polygon {
+			if ringContains(ring, lat, lon) {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+// ringContains casts a ray east from the point and counts edge crossings
+func ringContains(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+	n := len(ring)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// depth counts how many levels are filled, from province down
+func (d AdminDivision) depth() int {
+	depth := 0
+	for _, level := range []string{d.Province, d.City, d.County, d.Town} {
+		if level != "" {
+			depth++
+		}
+	}
+	return depth
+}
+
+func toRings(rings [][][]float64) [][][2]float64 {
+	out := make([][][2]float64, 0, len(rings))
+	for _, ring := range rings {
+		r := make([][2]float64, 0, len(ring))
+		for _, pt := range ring {
+			if len(pt) >= 2 {
+				r = append(r, [2]float64{pt[0], pt[1]})
+			}
+		}
+		if len(r) >= 3 {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func property(props map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if v, ok := props[key]; ok && v != nil {
+			if s := strings.TrimSpace(fmt.Sprint(v)); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,277 @@
+package geocode
+
+import "strings"
+
+// Country is a country or region with its ISO 3166-1 alpha-2 code
+type Country struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	NameEN string `json:"name_en"`
+	Region string `json:"region"` // World region, e.g. 东南亚
+}
+
+// HomeCountry is the country of the administrative divisions this package
+// resolves; other countries count as abroad
+const HomeCountry = "CN"
+
+// countries is keyed by ISO code
+var countries = map[string]Country{
+	"CN": {"CN", "中国", "China", "东亚"},
+	"HK": {"HK", "中国香港", "Hong Kong", "东亚"},
+	"MO": {"MO", "中国澳门", "Macao", "东亚"},
+	"TW": {"TW", "中国台湾", "Taiwan", "东亚"},
+	"JP": {"JP", "日本", "Japan", "东亚"},
+	"KR": {"KR", "韩国", "South Korea", "东亚"},
+	"KP": {"KP", "朝鲜", "North Korea", "东亚"},
+	"MN": {"MN", "蒙古", "Mongolia", "东亚"},
+	"VN": {"VN", "越南", "Vietnam", "东南亚"},
+	"LA": {"LA", "老挝", "Laos", "东南亚"},
+	"KH": {"KH", "柬埔寨", "Cambodia", "东南亚"},
+	"TH": {"TH", "泰国", "Thailand", "东南亚"},
+	"MM": {"MM", "缅甸", "Myanmar", "东南亚"},
+	"MY": {"MY", "马来西亚", "Malaysia", "东南亚"},
+	"SG": {"SG", "新加坡", "Singapore", "东南亚"},
+	"BN": {"BN", "文莱", "Brunei", "东南亚"},
+	"ID": {"ID", "印度尼西亚", "Indonesia", "东南亚"},
+	"PH": {"PH", "菲律宾", "Philippines", "东南亚"},
+	"IN": {"IN", "印度", "India", "南亚"},
+	"NP": {"NP", "尼泊尔", "Nepal", "南亚"},
+	"BT": {"BT", "不丹", "Bhutan", "南亚"},
+	"BD": {"BD", "孟加拉国", "Bangladesh", "南亚"},
+	"LK": {"LK", "斯里兰卡", "Sri Lanka", "南亚"},
+	"MV": {"MV", "马尔代夫", "Maldives", "南亚"},
+	"PK": {"PK", "巴基斯坦", "Pakistan", "南亚"},
+	"AF": {"AF", "阿富汗", "Afghanistan", "南亚"},
+	"KZ": {"KZ", "哈萨克斯坦", "Kazakhstan", "中亚"},
+	"KG": {"KG", "吉尔吉斯斯坦", "Kyrgyzstan", "中亚"},
+	"TJ": {"TJ", "塔吉克斯坦", "Tajikistan", "中亚"},
+	"UZ": {"UZ", "乌兹别克斯坦", "Uzbekistan", "中亚"},
+	"IR": {"IR", "伊朗", "Iran", "西亚"},
+	"TR": {"TR", "土耳其", "Turkey", "西亚"},
+	"IL": {"IL", "以色列", "Israel", "西亚"},
+	"SA": {"SA", "沙特阿拉伯", "Saudi Arabia", "西亚"},
+	"AE": {"AE", "阿联酋", "United Arab Emirates", "西亚"},
+	"QA": {"QA", "卡塔尔", "Qatar", "西亚"},
+	"RU": {"RU", "俄罗斯", "Russia", "欧洲"},
+	"UA": {"UA", "乌克兰", "Ukraine", "欧洲"},
+	"GB": {"GB", "英国", "United Kingdom", "欧洲"},
+	"IE": {"IE", "爱尔兰", "Ireland", "欧洲"},
+	"FR": {"FR", "法国", "France", "欧洲"},
+	"BE": {"BE", "比利时", "Belgium", "欧洲"},
+	"NL": {"NL", "荷兰", "Netherlands", "欧洲"},
+	"DE": {"DE", "德国", "Germany", "欧洲"},
+	"CH": {"CH", "瑞士", "Switzerland", "欧洲"},
+	"AT": {"AT", "奥地利", "Austria", "欧洲"},
+	"IT": {"IT", "意大利", "Italy", "欧洲"},
+	"ES": {"ES", "西班牙", "Spain", "欧洲"},
+	"PT": {"PT", "葡萄牙", "Portugal", "欧洲"},
+	"GR": {"GR", "希腊", "Greece", "欧洲"},
+	"CZ": {"CZ", "捷克", "Czechia", "欧洲"},
+	"PL": {"PL", "波兰", "Poland", "欧洲"},
+	"HU": {"HU", "匈牙利", "Hungary", "欧洲"},
+	"DK": {"DK", "丹麦", "Denmark", "欧洲"},
+	"NO": {"NO", "挪威", "Norway", "欧洲"},
+	"SE": {"SE", "瑞典", "Sweden", "欧洲"},
+	"FI": {"FI", "芬兰", "Finland", "欧洲"},
+	"IS": {"IS", "冰岛", "Iceland", "欧洲"},
+	"US": {"US", "美国", "United States", "北美洲"},
+	"CA": {"CA", "加拿大", "Canada", "北美洲"},
+	"MX": {"MX", "墨西哥", "Mexico", "北美洲"},
+	"BR": {"BR", "巴西", "Brazil", "南美洲"},
+	"AR": {"AR", "阿根廷", "Argentina", "南美洲"},
+	"CL": {"CL", "智利", "Chile", "南美洲"},
+	"PE": {"PE", "秘鲁", "Peru", "南美洲"},
+	"AU": {"AU", "澳大利亚", "Australia", "大洋洲"},
+	"NZ": {"NZ", "新西兰", "New Zealand", "大洋洲"},
+	"EG": {"EG", "埃及", "Egypt", "非洲"},
+	"MA": {"MA", "摩洛哥", "Morocco", "非洲"},
+	"KE": {"KE", "肯尼亚", "Kenya", "非洲"},
+	"ZA": {"ZA", "南非", "South Africa", "非洲"},
+}
+
+// countryBox is a coarse bounding box of (part of) a country
+type countryBox struct {
+	code                           string
+	minLat, minLon, maxLat, maxLon float64
+}
+
+func (b countryBox) contains(lat, lon float64) bool {
+	return lat >= b.minLat && lat <= b.maxLat && lon >= b.minLon && lon <= b.maxLon
+}
+
+func (b countryBox) area() float64 {
+	return (b.maxLat - b.minLat) * (b.maxLon - b.minLon)
+}
+
+// enclaveBoxes lie inside the mainland outline and are checked before it
+var enclaveBoxes = []countryBox{
+	{"HK", 22.15, 113.83, 22.56, 114.44},
+	{"MO", 22.10, 113.52, 22.22, 113.60},
+}
+
+// mainlandOutline is a coarse outline of mainland China and Hainan as
+// [lon, lat] vertices, accurate to tens of kilometres along the borders
+var mainlandOutline = [][2]float64{
+	{124.3, 39.8}, {126.0, 41.0}, {128.0, 42.0}, {130.6, 42.4}, {131.0, 42.9},
+	{131.2, 44.4}, {133.1, 45.1}, {134.7, 48.3}, {132.0, 47.7}, {130.9, 47.8},
+	{127.5, 50.2}, {125.0, 53.2}, {122.5, 53.4}, {121.2, 53.3}, {120.0, 52.6},
+	{117.4, 49.6}, {116.0, 49.5}, {115.6, 47.9}, {117.8, 47.6}, {119.8, 46.8},
+	{118.5, 46.6}, {116.0, 45.7}, {113.6, 44.8}, {112.0, 43.6}, {110.5, 42.6},
+	{107.0, 42.3}, {105.0, 41.6}, {100.8, 42.6}, {96.4, 42.7}, {95.3, 44.3},
+	{91.0, 45.2}, {90.8, 46.9}, {88.0, 48.5}, {87.3, 49.1}, {85.6, 48.1},
+	{82.7, 47.2}, {82.6, 45.2}, {80.2, 44.9}, {80.3, 44.2}, {80.2, 42.2},
+	{76.0, 40.4}, {73.6, 39.7}, {73.5, 39.4}, {74.9, 37.3}, {75.4, 36.8},
+	{77.8, 35.5}, {80.3, 35.3}, {79.4, 32.5}, {78.8, 31.3}, {80.1, 30.4},
+	{81.5, 30.4}, {84.0, 29.0}, {86.9, 28.0}, {88.1, 27.9}, {89.0, 28.0},
+	{91.6, 27.9}, {92.0, 27.8}, {95.0, 29.3}, {96.5, 29.0}, {97.3, 28.2},
+	{98.3, 27.6}, {98.7, 25.9}, {97.7, 24.8}, {98.0, 24.1}, {99.5, 22.1},
+	{101.2, 21.2}, {101.7, 22.0}, {102.2, 22.4}, {103.0, 22.6}, {104.0, 22.8},
+	{105.3, 23.3}, {106.7, 22.9}, {108.0, 21.5}, {108.5, 21.4}, {108.6, 18.5},
+	{109.5, 18.1}, {111.1, 19.6}, {111.0, 21.4}, {112.5, 21.7}, {113.5, 22.1},
+	{114.5, 22.4}, {116.5, 22.9}, {118.0, 24.3}, {119.6, 25.5}, {120.3, 26.9},
+	{121.9, 29.0}, {122.2, 30.0}, {122.0, 31.5}, {120.9, 33.5}, {120.2, 34.4},
+	{119.3, 35.0}, {120.3, 36.0}, {122.7, 37.4}, {121.0, 37.8}, {118.9, 37.9},
+	{117.7, 38.6}, {118.5, 39.1}, {119.6, 39.9}, {121.0, 40.8}, {121.0, 39.9},
+	{121.1, 38.7}, {121.7, 38.8}, {122.5, 39.3},
+}
+
+// countryBoxes cover other countries; where boxes overlap the smallest wins,
+// so smaller neighbours take precedence over the large country around them
+var countryBoxes = []countryBox{
+	{"TW", 21.8, 119.3, 25.4, 122.1},
+	{"JP", 33.4, 130.8, 41.6, 142.1},
+	{"JP", 41.3, 139.3, 45.6, 145.9},
+	{"JP", 31.0, 129.5, 34.0, 132.0},
+	{"JP", 24.0, 122.9, 30.0, 131.4},
+	{"KR", 33.1, 124.5, 38.7, 130.0},
+	{"KP", 37.6, 124.2, 43.0, 130.7},
+	{"MN", 41.6, 87.7, 52.2, 119.9},
+	{"VN", 20.0, 102.1, 23.4, 108.0},
+	{"VN", 11.6, 105.5, 20.0, 109.5},
+	{"VN", 8.4, 104.4, 11.6, 107.6},
+	{"LA", 13.9, 100.1, 22.5, 107.7},
+	{"KH", 10.4, 102.3, 14.7, 107.6},
+	{"TH", 5.6, 97.3, 20.5, 105.6},
+	{"MM", 9.8, 92.2, 28.5, 101.2},
+	{"MY", 1.2, 99.6, 6.8, 104.5},
+	{"MY", 0.8, 109.5, 7.4, 119.3},
+	{"SG", 1.15, 103.6, 1.48, 104.1},
+	{"BN", 4.0, 114.0, 5.1, 115.4},
+	{"ID", -11.0, 95.0, 6.1, 141.0},
+	{"PH", 4.6, 116.9, 21.2, 126.6},
+	{"IN", 6.7, 68.1, 35.5, 97.4},
+	{"NP", 26.3, 80.1, 30.4, 88.2},
+	{"BT", 26.7, 88.7, 28.3, 92.1},
+	{"BD", 20.7, 88.0, 26.6, 92.7},
+	{"LK", 5.9, 79.6, 9.9, 81.9},
+	{"MV", -0.7, 72.6, 7.1, 73.8},
+	{"PK", 23.7, 60.9, 32.5, 75.4},
+	{"PK", 32.5, 69.0, 37.1, 75.5},
+	{"AF", 29.4, 60.5, 38.5, 74.9},
+	{"KZ", 40.6, 46.5, 55.4, 87.3},
+	{"KG", 39.2, 69.3, 43.0, 80.3},
+	{"TJ", 36.7, 67.4, 41.0, 75.1},
+	{"UZ", 37.2, 56.0, 45.6, 73.1},
+	{"IR", 25.0, 44.0, 39.8, 63.4},
+	{"TR", 35.8, 25.6, 42.2, 44.8},
+	{"IL", 29.5, 34.2, 33.3, 35.9},
+	{"SA", 16.3, 34.5, 32.2, 55.7},
+	{"AE", 22.6, 51.5, 26.1, 56.4},
+	{"QA", 24.5, 50.7, 26.2, 51.7},
+	{"RU", 41.2, 27.3, 70.0, 60.0},
+	{"RU", 41.2, 60.0, 77.7, 180.0},
+	{"UA", 44.4, 22.1, 52.4, 40.2},
+	{"GB", 49.9, -8.2, 60.9, 1.8},
+	{"IE", 51.4, -10.5, 55.4, -6.0},
+	{"FR", 42.3, -4.8, 51.1, 8.2},
+	{"BE", 49.5, 2.5, 51.5, 6.4},
+	{"NL", 50.75, 3.3, 53.6, 7.2},
+	{"DE", 47.3, 5.9, 55.1, 15.0},
+	{"CH", 45.8, 5.9, 47.8, 10.5},
+	{"AT", 46.4, 9.5, 49.0, 17.2},
+	{"IT", 36.6, 6.6, 47.1, 18.5},
+	{"ES", 36.0, -9.3, 43.8, 3.3},
+	{"PT", 36.9, -9.6, 42.2, -6.2},
+	{"GR", 34.8, 19.4, 41.8, 28.3},
+	{"CZ", 48.5, 12.1, 51.1, 18.9},
+	{"PL", 49.0, 14.1, 54.9, 24.2},
+	{"HU", 45.7, 16.1, 48.6, 22.9},
+	{"DK", 54.5, 8.0, 57.8, 12.7},
+	{"NO", 57.9, 4.6, 71.2, 31.1},
+	{"SE", 55.3, 11.1, 69.1, 24.2},
+	{"FI", 59.8, 20.5, 70.1, 31.6},
+	{"IS", 63.3, -24.6, 66.6, -13.5},
+	{"US", 24.5, -124.8, 49.4, -66.9},
+	{"US", 51.2, -180.0, 71.4, -129.9},
+	{"US", 18.9, -160.3, 22.3, -154.8},
+	{"CA", 49.0, -141.0, 83.1, -52.6},
+	{"CA", 43.4, -80.6, 46.9, -71.0},
+	{"CA", 48.3, -125.0, 49.0, -122.8},
+	{"MX", 14.5, -106.0, 23.5, -86.7},
+	{"MX", 23.5, -109.5, 28.8, -99.8},
+	{"MX", 22.8, -117.1, 32.5, -111.5},
+	{"BR", -33.8, -74.0, 5.3, -34.8},
+	{"AR", -55.1, -73.6, -21.8, -53.6},
+	{"CL", -56.0, -75.7, -17.5, -66.4},
+	{"PE", -18.4, -81.4, 0.0, -68.7},
+	{"AU", -43.7, 112.9, -10.0, 153.7},
+	{"NZ", -47.3, 166.4, -34.4, 178.6},
+	{"EG", 22.0, 24.7, 31.7, 36.9},
+	{"MA", 27.6, -13.2, 35.9, -1.0},
+	{"KE", -4.7, 33.9, 5.0, 41.9},
+	{"ZA", -34.9, 16.4, -22.1, 32.9},
+}
+
+// LookupCountry resolves the country of a point offline
+// Hong Kong and Macao are matched first, then the mainland outline, then the
+// smallest box of another country containing the point. Results near borders
+// are approximate; ok is false for points in no known country.
+func LookupCountry(lat, lon float64) (country Country, ok bool) {
+	for _, b := range enclaveBoxes {
+		if b.contains(lat, lon) {
+			return countries[b.code], true
+		}
+	}
+	if ringContains(mainlandOutline, lat, lon) {
+		return countries[HomeCountry], true
+	}
+
+	var best *countryBox
+	for i := range countryBoxes {
+		b := &countryBoxes[i]
+		if b.contains(lat, lon) && (best == nil || b.area() < best.area()) {
+			best = b
+		}
+	}
+	if best == nil {
+		return Country{}, false
+	}
+	return countries[best.code], true
+}
+
+// CountryOfDivision returns the country of a resolved administrative
+// division: the special administrative regions and Taiwan by their
+// province-level name, the home country otherwise
+func CountryOfDivision(d AdminDivision) (Country, bool) {
+	if d.Province == "" {
+		return Country{}, false
+	}
+	switch {
+	case strings.HasPrefix(d.Province, "香港"):
+		return countries["HK"], true
+	case strings.HasPrefix(d.Province, "澳门"):
+		return countries["MO"], true
+	case strings.HasPrefix(d.Province, "台湾"):
+		return countries["TW"], true
+	}
+	return countries[HomeCountry], true
+}
+
+// CountryByCode returns the country with the given ISO code
+// Unknown codes yield a country carrying only the code.
+func CountryByCode(code string) Country {
+	if c, ok := countries[strings.ToUpper(code)]; ok {
+		return c
+	}
+	return Country{Code: code, Name: code, NameEN: code}
+}
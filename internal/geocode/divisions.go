@@ -0,0 +1,171 @@
+package geocode
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// divisionsCN lists the provincial and prefecture-level divisions of China
+// Names carry their full suffix (广东省 / 广州市 / 延边朝鲜族自治州), as stored in
+// the track tables; the municipalities list themselves as their only city.
+// County-level cities directly under a province are not included.
+//
+//go:embed divisions_cn.json
+var divisionsCN []byte
+
+// DivisionNode is a division with its subdivisions, the format of division
+// catalogue files
+type DivisionNode struct {
+	Name     string          `json:"name"`
+	Children []*DivisionNode `json:"children,omitempty"`
+}
+
+// Divisions is a catalogue of administrative divisions
+// It provides the denominators of coverage statistics: all subdivisions of an
+// area, visited or not. The bundled catalogue stops at prefecture level;
+// counties and towns are known once a catalogue file or boundary dataset
+// listing them has been merged in.
+type Divisions struct {
+	mu   sync.RWMutex
+	root division
+}
+
+type division struct {
+	children []*division
+	names    []string
+	index    map[string]*division
+}
+
+// child returns the subdivision called name, adding it when missing
+func (d *division) child(name string) *division {
+	if c, ok := d.index[name]; ok {
+		return c
+	}
+	if d.index == nil {
+		d.index = make(map[string]*division)
+	}
+	c := &division{}
+	d.index[name] = c
+	d.children = append(d.children, c)
+	d.names = append(d.names, name)
+	return c
+}
+
+// NewDivisions creates an empty catalogue
+func NewDivisions() *Divisions {
+	return &Divisions{}
+}
+
+var defaultDivisions = func() *Divisions {
+	d := NewDivisions()
+	var nodes []*DivisionNode
+	if err := json.Unmarshal(divisionsCN, &nodes); err != nil {
+		panic(fmt.Sprintf("invalid bundled division catalogue: %v", err))
+	}
+	d.Merge(nodes)
+	return d
+}()
+
+// DefaultDivisions returns the process-wide catalogue, preloaded with the
+// bundled provincial and prefecture-level divisions
+func DefaultDivisions() *Divisions {
+	return defaultDivisions
+}
+
+// Merge adds the divisions of a node tree to the catalogue
+func (d *Divisions) Merge(nodes []*DivisionNode) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	mergeNodes(&d.root, nodes)
+}
+
+func mergeNodes(parent *division, nodes []*DivisionNode) {
+	for _, n := range nodes {
+		if n == nil || n.Name == "" {
+			continue
+		}
+		mergeNodes(parent.child(n.Name), n.Children)
+	}
+}
+
+// LoadFile merges a JSON catalogue file: an array of DivisionNode trees
+// rooted at provinces
+func (d *Divisions) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read division catalogue: %w", err)
+	}
+	var nodes []*DivisionNode
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return fmt.Errorf("failed to parse division catalogue: %w", err)
+	}
+	d.Merge(nodes)
+	return nil
+}
+
+// Add merges the divisions of resolved locations, e.g. the features of a
+// boundary dataset
+// Each division is added down to its first empty level.
+func (d *Divisions) Add(divisions ...AdminDivision) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, div := range divisions {
+		node := &d.root
+		for _, name := range []string{div.Province, div.City, div.County, div.Town} {
+			if name == "" {
+				break
+			}
+			node = node.child(name)
+		}
+	}
+}
+
+// Children returns the subdivisions of the area at path (province, city,
+// county), or of the whole country for an empty path
+// It returns nil when the catalogue does not know the area's subdivisions.
+func (d *Divisions) Children(path ...string) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	node := &d.root
+	for _, name := range path {
+		next, ok := node.index[name]
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+	if len(node.names) == 0 {
+		return nil
+	}
+	return append([]string(nil), node.names...)
+}
+
+// Names returns the distinct names of the divisions at depth (1 for
+// provinces, 2 for cities, ...), or nil when none are catalogued
+func (d *Divisions) Names(depth int) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	level := []*division{&d.root}
+	for ; depth > 1; depth-- {
+		var next []*division
+		for _, node := range level {
+			next = append(next, node.children...)
+		}
+		level = next
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, node := range level {
+		for _, name := range node.names {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
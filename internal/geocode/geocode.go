@@ -0,0 +1,120 @@
+// Package geocode resolves coordinates to Chinese administrative divisions
+// (province / city / county / town).
+//
+// Lookups go through the Provider interface so the offline boundary dataset
+// can be swapped for another source. A process-wide default provider is set
+// at startup and used by the geocode_backfill analyzer.
+package geocode
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// ErrNoProvider is returned when no geocoding provider has been configured
+var ErrNoProvider = errors.New("no geocoding provider configured")
+
+// AdminDivision holds the administrative divisions containing a point
+// Empty fields mean the level is unknown
+type AdminDivision struct {
+	Province string `json:"province"`
+	City     string `json:"city"`
+	County   string `json:"county"`
+	Town     string `json:"town"`
+}
+
+// IsEmpty reports whether no level was resolved
+func (d AdminDivision) IsEmpty() bool {
+	return d.Province == "" && d.City == "" && d.County == "" && d.Town == ""
+}
+
+// Provider resolves a coordinate to its administrative divisions
+// ok is false when the point lies outside the provider's coverage
+type Provider interface {
+	ReverseGeocode(lat, lon float64) (division AdminDivision, ok bool, err error)
+	Name() string
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultProvider Provider
+)
+
+// SetDefaultProvider sets the provider used by the backfill analyzer
+func SetDefaultProvider(p Provider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = p
+}
+
+// DefaultProvider returns the configured provider, or nil if none was set
+func DefaultProvider() Provider {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProvider
+}
+
+// CachedProvider memoizes lookups per geohash cell
+// Precision 7 (~150m) keeps the error small next to town-level boundaries
+// while collapsing the many near-identical points of a stay into one lookup.
+type CachedProvider struct {
+	provider  Provider
+	precision int
+
+	mu    sync.RWMutex
+	cache map[string]cachedResult
+}
+
+type cachedResult struct {
+	division AdminDivision
+	ok       bool
+}
+
+// NewCachedProvider wraps p with a geohash cell cache
+func NewCachedProvider(p Provider, precision int) *CachedProvider {
+	if precision <= 0 {
+		precision = 7
+	}
+	return &CachedProvider{
+		provider:  p,
+		precision: precision,
+		cache:     make(map[string]cachedResult),
+	}
+}
+
+// ReverseGeocode resolves a point, answering from the cache when the cell was seen before
+func (c *CachedProvider) ReverseGeocode(lat, lon float64) (AdminDivision, bool, error) {
+	cell := spatial.EncodeGeohash(lat, lon, c.precision)
+
+	c.mu.RLock()
+	hit, found := c.cache[cell]
+	c.mu.RUnlock()
+	if found {
+		return hit.division, hit.ok, nil
+	}
+
+	division, ok, err := c.provider.ReverseGeocode(lat, lon)
+	if err != nil {
+		return AdminDivision{}, false, err
+	}
+
+	c.mu.Lock()
+	c.cache[cell] = cachedResult{division: division, ok: ok}
+	c.mu.Unlock()
+
+	return division, ok, nil
+}
+
+// Name returns the wrapped provider's name
+func (c *CachedProvider) Name() string {
+	return c.provider.Name()
+}
+
+// CacheSize returns the number of cached cells
+func (c *CachedProvider) CacheSize() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.cache)
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/service"
 	"github.com/jengzang/records-backend-go/pkg/response"
 )
@@ -118,6 +120,28 @@ func (h *AnalysisTaskHandler) CancelTask(c *gin.Context) {
 	response.Success(c, gin.H{"message": "Task cancelled successfully"})
 }
 
+// ResumeTask starts an interrupted task again
+// POST /api/admin/analysis/tasks/:id/resume
+func (h *AnalysisTaskHandler) ResumeTask(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID")
+		return
+	}
+
+	task, err := h.service.ResumeTask(id)
+	if err != nil {
+		if errors.Is(err, models.ErrTaskNotInterrupted) {
+			response.Error(c, http.StatusConflict, err.Error())
+			return
+		}
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.Success(c, task)
+}
+
 // TriggerAnalysisChainRequest represents the request body for triggering an analysis chain
 type TriggerAnalysisChainRequest struct {
 	TaskType string `json:"task_type" binding:"required"` // INCREMENTAL or FULL_RECOMPUTE
@@ -149,3 +173,45 @@ func (h *AnalysisTaskHandler) TriggerAnalysisChain(c *gin.Context) {
 		"task_ids": taskIDs,
 	})
 }
+
+// GetStatus reports the algorithm version of each analyzer and whether its
+// results are stale
+// GET /api/v1/analysis/status
+func (h *AnalysisTaskHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get analysis status", err)
+		return
+	}
+
+	response.Success(c, status)
+}
+
+// GetQueue returns the running and queued analysis tasks
+// GET /api/admin/analysis/queue
+func (h *AnalysisTaskHandler) GetQueue(c *gin.Context) {
+	response.Success(c, h.service.GetQueue())
+}
+
+// Preview runs an analyzer on a time window and returns its results without
+// writing them, so thresholds can be tuned before a recompute
+// POST /api/v1/analysis/preview
+func (h *AnalysisTaskHandler) Preview(c *gin.Context) {
+	var req models.AnalysisPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	preview, err := h.service.Preview(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPreview) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to preview analysis", err)
+		return
+	}
+
+	response.Success(c, preview)
+}
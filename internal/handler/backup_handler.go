@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// BackupHandler handles HTTP requests for database backups
+type BackupHandler struct {
+	service *service.BackupService
+}
+
+// NewBackupHandler creates a new backup handler
+func NewBackupHandler(service *service.BackupService) *BackupHandler {
+	return &BackupHandler{service: service}
+}
+
+// CreateBackup handles POST /api/v1/admin/backup
+// The snapshot is taken while the server keeps serving; the request returns
+// once the backup is stored
+func (h *BackupHandler) CreateBackup(c *gin.Context) {
+	result, err := h.service.CreateBackup(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, models.ErrBackupInProgress) {
+			response.Error(c, http.StatusConflict, "Backup already in progress", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to create backup", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ListBackups handles GET /api/v1/admin/backups
+func (h *BackupHandler) ListBackups(c *gin.Context) {
+	list, err := h.service.ListBackups(c.Request.Context())
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list backups", err)
+		return
+	}
+
+	response.Success(c, list)
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+const (
+	// maxBatchQueries caps the queries of one batch request
+	maxBatchQueries = 20
+	// batchConcurrency is the number of queries of a batch run at once
+	batchConcurrency = 4
+)
+
+// BatchQuery is one named query of a batch request
+type BatchQuery struct {
+	Name   string                 `json:"name" binding:"required"` // Key of the result
+	Stat   string                 `json:"stat" binding:"required"` // Stats route below the batch prefix, e.g. footprint/rankings
+	Params map[string]interface{} `json:"params"`                  // Query parameters of the route
+}
+
+// BatchRequest is the body of a batch request
+type BatchRequest struct {
+	Queries []BatchQuery `json:"queries" binding:"required"`
+}
+
+// BatchResult is the outcome of one query of a batch
+type BatchResult struct {
+	Status  int             `json:"status"`            // HTTP status the route responded with
+	Data    json.RawMessage `json:"data,omitempty"`    // Response data on success
+	Error   string          `json:"error,omitempty"`   // Error message on failure
+	Details json.RawMessage `json:"details,omitempty"` // Error details, if the route gave any
+}
+
+// BatchHandler runs several stats queries in one request
+// Queries are served by the handlers of the stats routes themselves, on a
+// private router without their middleware, so parameters are validated and
+// results cached exactly as for individual requests.
+type BatchHandler struct {
+	prefix string
+	router *gin.Engine
+	stats  map[string]bool
+}
+
+// NewBatchHandler creates a batch handler for the GET routes below prefix
+func NewBatchHandler(prefix string, routes gin.RoutesInfo) *BatchHandler {
+	h := &BatchHandler{
+		prefix: prefix,
+		router: gin.New(),
+		stats:  make(map[string]bool),
+	}
+	h.router.Use(gin.Recovery())
+	for _, route := range routes {
+		if route.Method != http.MethodGet || !strings.HasPrefix(route.Path, prefix) {
+			continue
+		}
+		h.router.GET(route.Path, route.HandlerFunc)
+		h.stats[strings.TrimPrefix(route.Path, prefix)] = true
+	}
+	return h
+}
+
+// Batch handles POST /api/v1/stats/batch
+// The response maps each query name to its result; a failed query does not
+// fail the batch.
+func (h *BatchHandler) Batch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.Queries) == 0 {
+		response.BadRequest(c, "queries must not be empty")
+		return
+	}
+	if len(req.Queries) > maxBatchQueries {
+		response.BadRequest(c, fmt.Sprintf("At most %d queries per batch", maxBatchQueries))
+		return
+	}
+
+	seen := make(map[string]bool, len(req.Queries))
+	for _, q := range req.Queries {
+		if q.Name == "" || q.Stat == "" {
+			response.BadRequest(c, "Every query needs a name and a stat")
+			return
+		}
+		if seen[q.Name] {
+			response.BadRequest(c, "Duplicate query name: "+q.Name)
+			return
+		}
+		seen[q.Name] = true
+		if !h.stats[strings.Trim(q.Stat, "/")] {
+			response.BadRequest(c, "Unknown stat: "+q.Stat+" (supported: "+strings.Join(h.statNames(), ", ")+")")
+			return
+		}
+	}
+
+	results := make([]BatchResult, len(req.Queries))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, q := range req.Queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, q BatchQuery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = h.run(c, q)
+		}(i, q)
+	}
+	wg.Wait()
+
+	byName := make(map[string]BatchResult, len(results))
+	for i, q := range req.Queries {
+		byName[q.Name] = results[i]
+	}
+	response.Success(c, gin.H{"results": byName})
+}
+
+// run serves one query on the private router
+func (h *BatchHandler) run(c *gin.Context, q BatchQuery) BatchResult {
+	query, err := encodeParams(q.Params)
+	if err != nil {
+		return BatchResult{Status: http.StatusBadRequest, Error: err.Error()}
+	}
+	target := h.prefix + strings.Trim(q.Stat, "/")
+	if query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		return BatchResult{Status: http.StatusBadRequest, Error: err.Error()}
+	}
+
+	w := newBufferedResponse()
+	h.router.ServeHTTP(w, req)
+
+	var envelope struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.body.Bytes(), &envelope); err != nil {
+		return BatchResult{Status: http.StatusInternalServerError, Error: "Invalid response of " + q.Stat}
+	}
+	if w.status != http.StatusOK {
+		return BatchResult{Status: w.status, Error: envelope.Message, Details: envelope.Data}
+	}
+	return BatchResult{Status: w.status, Data: envelope.Data}
+}
+
+// statNames returns the supported stats in order
+func (h *BatchHandler) statNames() []string {
+	names := make([]string, 0, len(h.stats))
+	for name := range h.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodeParams encodes JSON parameter values as a query string
+// Arrays become repeated parameters; objects are rejected.
+func encodeParams(params map[string]interface{}) (string, error) {
+	values := url.Values{}
+	for key, v := range params {
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			switch item := item.(type) {
+			case nil:
+			case string:
+				values.Add(key, item)
+			case float64:
+				values.Add(key, strconv.FormatFloat(item, 'f', -1, 64))
+			case bool:
+				values.Add(key, fmt.Sprint(item))
+			default:
+				return "", fmt.Errorf("unsupported value of parameter %s", key)
+			}
+		}
+	}
+	return values.Encode(), nil
+}
+
+// bufferedResponse collects a response in memory
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (w *bufferedResponse) Header() http.Header         { return w.header }
+func (w *bufferedResponse) Write(b []byte) (int, error) { return w.body.Write(b) }
+func (w *bufferedResponse) WriteHeader(status int)      { w.status = status }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// CarbonHandler handles HTTP requests for the emissions model and carbon footprints
+type CarbonHandler struct {
+	service *service.CarbonService
+}
+
+// NewCarbonHandler creates a new carbon handler
+func NewCarbonHandler(service *service.CarbonService) *CarbonHandler {
+	return &CarbonHandler{service: service}
+}
+
+// GetCarbonSummary handles GET /api/v1/stats/carbon
+// Returns the yearly footprints and the monthly footprints of year (all months
+// without it), each compared with one year earlier, and the emission factors.
+func (h *CarbonHandler) GetCarbonSummary(c *gin.Context) {
+	year := 0
+	if s := c.Query("year"); s != "" {
+		y, err := strconv.Atoi(s)
+		if err != nil || y < 1 || y > 9999 {
+			response.BadRequest(c, "year must be a number")
+			return
+		}
+		year = y
+	}
+
+	summary, err := h.service.GetSummary(year)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get carbon footprint", err)
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// GetTripCarbon handles GET /api/v1/stats/carbon/trips
+func (h *CarbonHandler) GetTripCarbon(c *gin.Context) {
+	startTime, err := strconv.ParseInt(c.DefaultQuery("start_time", "0"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid start_time parameter", err)
+		return
+	}
+	endTime, err := strconv.ParseInt(c.DefaultQuery("end_time", "0"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid end_time parameter", err)
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.service.GetTripCarbon(startTime, endTime, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get trip carbon footprints", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// ListFactors handles GET /api/v1/admin/emission-factors
+func (h *CarbonHandler) ListFactors(c *gin.Context) {
+	factors, err := h.service.ListFactors()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get emission factors", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  factors,
+		"count": len(factors),
+	})
+}
+
+// SetFactor handles PUT /api/v1/admin/emission-factors/:mode
+// Overrides (or adds) the factor of a mode and queues a recompute of the CO2 estimates.
+func (h *CarbonHandler) SetFactor(c *gin.Context) {
+	var req models.EmissionFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	factor, taskIDs, err := h.service.SetFactor(c.Param("mode"), req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidEmissionFactor) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to set emission factor", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"factor":   factor,
+		"task_ids": taskIDs,
+	})
+}
+
+// ResetFactor handles POST /api/v1/admin/emission-factors/:mode/reset
+// Restores the built-in factor of a mode and queues a recompute of the CO2 estimates.
+func (h *CarbonHandler) ResetFactor(c *gin.Context) {
+	factor, taskIDs, err := h.service.ResetFactor(c.Param("mode"))
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to reset emission factor", err)
+		return
+	}
+	if factor == nil {
+		response.NotFound(c, "Emission factor not found")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"factor":   factor,
+		"task_ids": taskIDs,
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/config"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ConfigHandler handles HTTP requests for the running configuration
+type ConfigHandler struct{}
+
+// NewConfigHandler creates a new config handler
+func NewConfigHandler() *ConfigHandler {
+	return &ConfigHandler{}
+}
+
+// GetConfig handles GET /api/v1/admin/config
+// Passwords, keys and tokens are redacted.
+func (h *ConfigHandler) GetConfig(c *gin.Context) {
+	cfg := config.Current()
+	if cfg == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Configuration not loaded")
+		return
+	}
+	response.Success(c, cfg.Redacted())
+}
+
+// ReloadConfig handles POST /api/v1/admin/config/reload
+// The settings that can change while the server runs are applied; the
+// others keep their running values and are listed as needing a restart.
+func (h *ConfigHandler) ReloadConfig(c *gin.Context) {
+	result, err := config.Reload()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to reload configuration", err)
+		return
+	}
+	response.Success(c, result)
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// DashboardHandler handles HTTP requests for the daily dashboard
+type DashboardHandler struct {
+	service *service.DashboardService
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(service *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{service: service}
+}
+
+// GetDashboard handles GET /api/v1/dashboard?date=YYYY-MM-DD
+// The date defaults to today.
+func (h *DashboardHandler) GetDashboard(c *gin.Context) {
+	date := c.Query("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		response.BadRequest(c, "date must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	dashboard, err := h.service.GetDashboard(c.Request.Context(), date)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get dashboard", err)
+		return
+	}
+
+	response.Success(c, dashboard)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// Bounds of the max_points query parameter of detail views
+const (
+	defaultDetailPoints = 500
+	maxDetailPoints     = 5000
+)
+
+// DetailHandler handles HTTP requests for segment, stay and trip details
+type DetailHandler struct {
+	service *service.DetailService
+}
+
+// NewDetailHandler creates a new detail handler
+func NewDetailHandler(service *service.DetailService) *DetailHandler {
+	return &DetailHandler{service: service}
+}
+
+// GetSegmentDetail handles GET /api/v1/segments/:id
+func (h *DetailHandler) GetSegmentDetail(c *gin.Context) {
+	id, maxPoints, ok := detailParams(c, "segment")
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.GetSegmentDetail(id, maxPoints)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get segment", err)
+		return
+	}
+	if detail == nil {
+		response.NotFound(c, "Segment not found")
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// GetStayDetail handles GET /api/v1/stays/:id
+func (h *DetailHandler) GetStayDetail(c *gin.Context) {
+	id, maxPoints, ok := detailParams(c, "stay")
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.GetStayDetail(id, maxPoints)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get stay", err)
+		return
+	}
+	if detail == nil {
+		response.NotFound(c, "Stay not found")
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// GetTripDetail handles GET /api/v1/trips/:id
+func (h *DetailHandler) GetTripDetail(c *gin.Context) {
+	id, maxPoints, ok := detailParams(c, "trip")
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.GetTripDetail(id, maxPoints)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get trip", err)
+		return
+	}
+	if detail == nil {
+		response.NotFound(c, "Trip not found")
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// detailParams parses the entity ID and max_points of a detail request,
+// responding with 400 when either is invalid
+func detailParams(c *gin.Context, entity string) (int64, int, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid "+entity+" ID", err)
+		return 0, 0, false
+	}
+
+	maxPoints, err := strconv.Atoi(c.DefaultQuery("max_points", strconv.Itoa(defaultDetailPoints)))
+	if err != nil || maxPoints < 2 || maxPoints > maxDetailPoints {
+		response.BadRequest(c, "max_points must be between 2 and "+strconv.Itoa(maxDetailPoints))
+		return 0, 0, false
+	}
+	return id, maxPoints, true
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ExplainHandler handles HTTP requests for query plans
+type ExplainHandler struct {
+	service *service.ExplainService
+}
+
+// NewExplainHandler creates a new explain handler
+func NewExplainHandler(service *service.ExplainService) *ExplainHandler {
+	return &ExplainHandler{service: service}
+}
+
+// Explain handles GET /api/v1/admin/explain
+// Query parameter name selects one query; without it every audited query is
+// explained. Steps listed under scans read a whole table or index.
+func (h *ExplainHandler) Explain(c *gin.Context) {
+	plans, err := h.service.Explain(c.Request.Context(), c.Query("name"))
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownQuery) {
+			response.Error(c, http.StatusNotFound, "Unknown query", err)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to explain queries", err)
+		return
+	}
+
+	response.Success(c, plans)
+}
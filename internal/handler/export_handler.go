@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ExportHandler handles HTTP requests for data exports
+type ExportHandler struct {
+	service *service.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(service *service.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// exportContentTypes maps export formats to their content type
+var exportContentTypes = map[string]string{
+	models.ExportFormatGeoJSON: "application/geo+json",
+	models.ExportFormatCSV:     "text/csv; charset=utf-8",
+}
+
+// ExportAnonymized handles GET /api/v1/export/anonymized
+// The dataset is written to the response as points are read instead of being buffered
+func (h *ExportHandler) ExportAnonymized(c *gin.Context) {
+	var filter models.AnonymizedExportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if err := h.service.NormalizeAnonymizedExportFilter(&filter); err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", exportContentTypes[filter.Format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tracks_anonymized.%s"`, filter.Format))
+	c.Status(http.StatusOK)
+
+	if err := h.service.ExportAnonymized(c.Request.Context(), c.Writer, filter); err != nil {
+		// Headers are already sent, so the error can only be logged
+		log.Printf("Failed to export anonymized tracks: %v", err)
+	}
+}
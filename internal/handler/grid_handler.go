@@ -2,6 +2,8 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jengzang/records-backend-go/internal/models"
@@ -45,7 +47,13 @@ func (h *GridHandler) GetGridCells(c *gin.Context) {
 }
 
 // GetHeatmapData handles GET /api/v1/viz/heatmap
+// With bbox/zoom/bucket/source parameters it returns dynamic-resolution cells, otherwise grid_cells at a fixed level
 func (h *GridHandler) GetHeatmapData(c *gin.Context) {
+	if c.Query("bbox") != "" || c.Query("zoom") != "" || c.Query("bucket") != "" || c.Query("source") != "" {
+		h.getDynamicHeatmap(c)
+		return
+	}
+
 	var filter models.GridFilter
 	if err := c.ShouldBindQuery(&filter); err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
@@ -73,3 +81,64 @@ func (h *GridHandler) GetHeatmapData(c *gin.Context) {
 
 	response.Success(c, heatmap)
 }
+
+// getDynamicHeatmap handles GET /api/v1/viz/heatmap?bbox=&zoom=&bucket=
+func (h *GridHandler) getDynamicHeatmap(c *gin.Context) {
+	var filter models.HeatmapGridFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if c.Query("zoom") == "" {
+		filter.Zoom = 10
+	}
+	if filter.Bucket != "" && filter.Bucket != "all" && filter.Bucket != "rolling_90d" {
+		if year, err := strconv.Atoi(filter.Bucket); err != nil || year < 1970 {
+			response.Error(c, http.StatusBadRequest, "Invalid bucket. Must be one of: all, rolling_90d, YYYY", nil)
+			return
+		}
+	}
+
+	// Default to the whole world if bbox is not specified
+	filter.MinLon, filter.MinLat, filter.MaxLon, filter.MaxLat = -180, -90, 180, 90
+	if filter.BBox != "" {
+		var ok bool
+		filter.MinLon, filter.MinLat, filter.MaxLon, filter.MaxLat, ok = parseBBox(c, filter.BBox)
+		if !ok {
+			return
+		}
+	}
+
+	heatmap, err := h.service.GetDynamicHeatmap(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get heatmap data", err)
+		return
+	}
+
+	response.Success(c, heatmap)
+}
+
+// parseBBox parses a minLon,minLat,maxLon,maxLat query value
+// It writes a 400 response and returns ok=false when the value is invalid.
+func parseBBox(c *gin.Context, bbox string) (minLon, minLat, maxLon, maxLat float64, ok bool) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		response.Error(c, http.StatusBadRequest, "Invalid bbox. Must be minLon,minLat,maxLon,maxLat", nil)
+		return 0, 0, 0, 0, false
+	}
+	values := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid bbox value", err)
+			return 0, 0, 0, 0, false
+		}
+		values[i] = v
+	}
+	if values[0] > values[2] || values[1] > values[3] {
+		response.Error(c, http.StatusBadRequest, "Invalid bbox. Min values must not exceed max values", nil)
+		return 0, 0, 0, 0, false
+	}
+	return values[0], values[1], values[2], values[3], true
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// HealthHandler handles HTTP requests for imported health data
+type HealthHandler struct {
+	service *service.HealthService
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(service *service.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// bindFilter parses and validates the common health query parameters
+func (h *HealthHandler) bindFilter(c *gin.Context) (models.HealthFilter, bool) {
+	var filter models.HealthFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return filter, false
+	}
+	return filter, validateDateRange(c, filter.From, filter.To, service.MaxHealthDays)
+}
+
+// GetStats handles GET /api/v1/health-data/stats?from=&to=
+func (h *HealthHandler) GetStats(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetStats(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get health statistics", err)
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// GetWorkouts handles GET /api/v1/health-data/workouts?from=&to=&type=Running&limit=
+func (h *HealthHandler) GetWorkouts(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	workouts, err := h.service.GetWorkouts(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get workouts", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  workouts,
+		"count": len(workouts),
+	})
+}
+
+// ImportAppleHealth handles POST /api/v1/health-data/import
+// Accepts an Apple Health export.xml or export.zip in the "file" field and
+// imports it in the background; poll the returned task for progress.
+func (h *HealthHandler) ImportAppleHealth(c *gin.Context) {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "No file uploaded")
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to read upload", err)
+		return
+	}
+	defer f.Close()
+
+	createdBy := c.GetString("user")
+	if createdBy == "" {
+		createdBy = "admin"
+	}
+
+	task, err := h.service.StartImport(fh.Filename, f, createdBy)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to start health import", err)
+		return
+	}
+
+	response.Success(c, task)
+}
+
+// GetImportTask handles GET /api/v1/health-data/import/tasks/:id
+func (h *HealthHandler) GetImportTask(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid task ID")
+		return
+	}
+
+	task, err := h.service.GetImportTask(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get import task", err)
+		return
+	}
+	if task == nil {
+		response.Error(c, http.StatusNotFound, "Import task not found", nil)
+		return
+	}
+
+	response.Success(c, task)
+}
+
+// ListImportTasks handles GET /api/v1/health-data/import/tasks?limit=
+func (h *HealthHandler) ListImportTasks(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	tasks, err := h.service.ListImportTasks(limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to list import tasks", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  tasks,
+		"count": len(tasks),
+	})
+}
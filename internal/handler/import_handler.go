@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ImportHandler handles HTTP requests for track file imports
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(service *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// ImportTracks handles POST /api/v1/tracks/import
+// Accepts multipart uploads with one or more GPX/KML/CSV/JSON files in the "file" or "files" fields
+// source names the app or tracker the files come from and device the recording device
+// (both default to the file format); points that duplicate another device's points are
+// merged, keeping the more accurate one
+// Set analyze=false to skip triggering incremental analysis
+func (h *ImportHandler) ImportTracks(c *gin.Context) {
+	source := c.Query("source")
+	// "all" is reserved for the stats aggregating every source
+	if source == "all" {
+		response.BadRequest(c, "source must not be \"all\"")
+		return
+	}
+	device := c.Query("device")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid multipart form", err)
+		return
+	}
+
+	var files []*multipart.FileHeader
+	files = append(files, form.File["file"]...)
+	files = append(files, form.File["files"]...)
+	if len(files) == 0 {
+		response.BadRequest(c, "No files uploaded")
+		return
+	}
+
+	resp := models.ImportResponse{}
+	for _, fh := range files {
+		result, err := h.importFile(fh, source, device)
+		if err != nil {
+			resp.Files = append(resp.Files, models.ImportResult{FileName: fh.Filename, Error: err.Error()})
+			continue
+		}
+		resp.Files = append(resp.Files, *result)
+		resp.TotalInserted += result.InsertedPoints
+		resp.TotalReplaced += result.ReplacedPoints
+		resp.TotalDuplicate += result.DuplicatePoints
+	}
+	h.service.NotifyImported("upload", resp.Files)
+
+	// Trigger incremental analysis for the newly inserted points
+	if resp.TotalInserted+resp.TotalReplaced > 0 && c.DefaultQuery("analyze", "true") == "true" {
+		createdBy := c.GetString("user")
+		if createdBy == "" {
+			createdBy = "import"
+		}
+		taskIDs, err := h.service.TriggerIncrementalAnalysis(createdBy)
+		if err != nil {
+			response.Error(c, http.StatusInternalServerError, "Points imported but failed to trigger analysis", err)
+			return
+		}
+		resp.AnalysisTasks = taskIDs
+	}
+
+	response.Success(c, resp)
+}
+
+// importFile opens an uploaded file and imports it
+func (h *ImportHandler) importFile(fh *multipart.FileHeader, source, device string) (*models.ImportResult, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return h.service.ImportFile(fh.Filename, f, source, device)
+}
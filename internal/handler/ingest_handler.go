@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// maxIngestBody bounds a live payload; the apps send one location at a time,
+// or a few hundred when they flush their queues
+const maxIngestBody = 1 << 20
+
+// IngestHandler handles HTTP requests for live location ingestion
+type IngestHandler struct {
+	service *service.IngestService
+}
+
+// NewIngestHandler creates a new ingest handler
+func NewIngestHandler(service *service.IngestService) *IngestHandler {
+	return &IngestHandler{service: service}
+}
+
+// IngestOwnTracks handles POST /api/v1/ingest/owntracks
+// Accepts the JSON payloads of the OwnTracks app in HTTP mode. The device of
+// the points is user/device from the X-Limit-U and X-Limit-D headers the app
+// sends (or the u and d query parameters), else from the message topic or
+// tracker id. Non-location messages are accepted and ignored.
+// On success the response is an empty JSON array, which is what the app
+// expects (it may list friends' locations); errors use the usual envelope.
+func (h *IngestHandler) IngestOwnTracks(c *gin.Context) {
+	payload, ok := readIngestBody(c)
+	if !ok {
+		return
+	}
+
+	user := c.GetHeader("X-Limit-U")
+	if user == "" {
+		user = c.Query("u")
+	}
+	device := c.GetHeader("X-Limit-D")
+	if device == "" {
+		device = c.Query("d")
+	}
+	var source string
+	if user != "" && device != "" {
+		source = user + "/" + device
+	}
+
+	if _, err := h.service.IngestOwnTracks(payload, source); err != nil {
+		ingestError(c, "Invalid OwnTracks payload", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, []interface{}{})
+}
+
+// IngestOverland handles POST /api/v1/ingest/overland
+// Accepts the batched GeoJSON of the Overland app. Invalid locations are
+// dropped and counted rather than failing the batch. device overrides the
+// device_id of the locations. On success the response is {"result": "ok"},
+// which the app requires before it deletes the batch from its queue.
+func (h *IngestHandler) IngestOverland(c *gin.Context) {
+	payload, ok := readIngestBody(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.IngestOverland(payload, c.Query("device"))
+	if err != nil {
+		ingestError(c, "Invalid Overland payload", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"result":   "ok",
+		"received": result.Received,
+		"rejected": result.Rejected,
+	})
+}
+
+// IngestGPSLogger handles GET and POST /api/v1/ingest/gpslogger
+// Accepts the custom URL requests of GPSLogger for Android, one location per
+// request, with the parameters in the query string or a form body. The device
+// of the point is the device, aid or ser parameter.
+func (h *IngestHandler) IngestGPSLogger(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid form", err)
+		return
+	}
+
+	result, err := h.service.IngestGPSLogger(c.Request.Form, "")
+	if err != nil {
+		ingestError(c, "Invalid GPSLogger request", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// readIngestBody reads a live payload of at most maxIngestBody bytes
+func readIngestBody(c *gin.Context) ([]byte, bool) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIngestBody+1))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to read payload", err)
+		return nil, false
+	}
+	if len(payload) > maxIngestBody {
+		response.Error(c, http.StatusRequestEntityTooLarge, "Payload too large")
+		return nil, false
+	}
+	return payload, true
+}
+
+// ingestError responds 400 for invalid payloads and 500 otherwise
+func ingestError(c *gin.Context, message string, err error) {
+	if errors.Is(err, models.ErrInvalidIngestPayload) {
+		response.Error(c, http.StatusBadRequest, message, err)
+		return
+	}
+	response.Error(c, http.StatusInternalServerError, "Failed to ingest locations", err)
+}
+
+// GetStatus handles GET /api/v1/ingest/status
+func (h *IngestHandler) GetStatus(c *gin.Context) {
+	response.Success(c, h.service.GetStatus())
+}
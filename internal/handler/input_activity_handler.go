@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// InputActivityHandler handles HTTP requests for keyboard and mouse activity
+type InputActivityHandler struct {
+	service *service.InputActivityService
+}
+
+// NewInputActivityHandler creates a new input activity handler
+func NewInputActivityHandler(service *service.InputActivityService) *InputActivityHandler {
+	return &InputActivityHandler{service: service}
+}
+
+// bindFilter parses and validates the common keyboard/mouse query parameters
+func (h *InputActivityHandler) bindFilter(c *gin.Context) (models.InputActivityFilter, bool) {
+	var filter models.InputActivityFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return filter, false
+	}
+	return filter, validateDateRange(c, filter.From, filter.To, service.MaxInputActivityDays)
+}
+
+// GetStats handles GET /api/v1/keyboard/stats?from=&to=&device=
+func (h *InputActivityHandler) GetStats(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetStats(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get keyboard statistics", err)
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// GetHeatmap handles GET /api/v1/keyboard/heatmap?from=&to=&device=&metric=keystrokes|clicks|scrolls
+func (h *InputActivityHandler) GetHeatmap(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Metric != "" && !service.IsValidInputMetric(filter.Metric) {
+		response.BadRequest(c, "metric must be keystrokes, clicks or scrolls")
+		return
+	}
+
+	heatmap, err := h.service.GetHeatmap(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get keyboard heatmap", err)
+		return
+	}
+
+	response.Success(c, heatmap)
+}
+
+// GetWorkCorrelation handles GET /api/v1/keyboard/work-correlation?from=&to=&device=
+func (h *InputActivityHandler) GetWorkCorrelation(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	correlation, err := h.service.GetWorkCorrelation(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get work correlation", err)
+		return
+	}
+
+	response.Success(c, correlation)
+}
+
+// ImportInputActivity handles POST /api/v1/keyboard/import?device=laptop
+// Accepts multipart uploads of WhatPulse style CSV or ActivityWatch JSON
+// exports in the "file" or "files" fields
+func (h *InputActivityHandler) ImportInputActivity(c *gin.Context) {
+	device := c.Query("device")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid multipart form", err)
+		return
+	}
+
+	var files []*multipart.FileHeader
+	files = append(files, form.File["file"]...)
+	files = append(files, form.File["files"]...)
+	if len(files) == 0 {
+		response.BadRequest(c, "No files uploaded")
+		return
+	}
+
+	var results []gin.H
+	for _, fh := range files {
+		result, err := h.importFile(fh, device)
+		if err != nil {
+			results = append(results, gin.H{"file_name": fh.Filename, "error": err.Error()})
+			continue
+		}
+		results = append(results, gin.H{"file_name": fh.Filename, "result": result})
+	}
+
+	response.Success(c, gin.H{"files": results})
+}
+
+// importFile opens an uploaded file and imports it
+func (h *InputActivityHandler) importFile(fh *multipart.FileHeader, device string) (*models.InputActivityImportResult, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return h.service.ImportFile(fh.Filename, f, device)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// InsightHandler handles HTTP requests for generated insights
+type InsightHandler struct {
+	service *service.InsightService
+}
+
+// NewInsightHandler creates a new insight handler
+func NewInsightHandler(service *service.InsightService) *InsightHandler {
+	return &InsightHandler{service: service}
+}
+
+// GetInsights handles GET /api/v1/insights
+// bucket_type, bucket_key, category and rule narrow the findings; newest buckets come first
+func (h *InsightHandler) GetInsights(c *gin.Context) {
+	var filter models.InsightFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	switch filter.BucketType {
+	case "", "month", "year":
+	default:
+		response.BadRequest(c, "bucket_type must be month or year")
+		return
+	}
+	filter.Category = strings.ToUpper(filter.Category)
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.service.GetInsights(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get insights", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
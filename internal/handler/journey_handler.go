@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// JourneyHandler handles HTTP requests for flight and train journeys
+type JourneyHandler struct {
+	service *service.JourneyService
+}
+
+// NewJourneyHandler creates a new journey handler
+func NewJourneyHandler(service *service.JourneyService) *JourneyHandler {
+	return &JourneyHandler{service: service}
+}
+
+// GetJourneys handles GET /api/v1/journeys
+func (h *JourneyHandler) GetJourneys(c *gin.Context) {
+	h.listJourneys(c, "")
+}
+
+// GetFlights handles GET /api/v1/flights
+func (h *JourneyHandler) GetFlights(c *gin.Context) {
+	h.listJourneys(c, models.JourneyTypeFlight)
+}
+
+// GetTrains handles GET /api/v1/trains
+func (h *JourneyHandler) GetTrains(c *gin.Context) {
+	h.listJourneys(c, models.JourneyTypeTrain)
+}
+
+// listJourneys lists journeys, restricted to journeyType when it is set
+func (h *JourneyHandler) listJourneys(c *gin.Context, journeyType string) {
+	var filter models.JourneyFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if journeyType != "" {
+		filter.JourneyType = journeyType
+	}
+	filter.JourneyType = strings.ToUpper(filter.JourneyType)
+	if !validJourneyType(filter.JourneyType, true) {
+		response.BadRequest(c, "type must be FLIGHT or TRAIN")
+		return
+	}
+
+	journeys, total, err := h.service.GetJourneys(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get journeys", err)
+		return
+	}
+
+	// Calculate pagination info
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 100
+	}
+	totalPages := int(total) / filter.PageSize
+	if int(total)%filter.PageSize > 0 {
+		totalPages++
+	}
+
+	response.Success(c, gin.H{
+		"data":       journeys,
+		"total":      total,
+		"page":       filter.Page,
+		"pageSize":   filter.PageSize,
+		"totalPages": totalPages,
+	})
+}
+
+// GetJourneyByID handles GET /api/v1/journeys/:id
+func (h *JourneyHandler) GetJourneyByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid journey ID", err)
+		return
+	}
+
+	journey, err := h.service.GetJourneyByID(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get journey", err)
+		return
+	}
+
+	if journey == nil {
+		response.Error(c, http.StatusNotFound, "Journey not found", nil)
+		return
+	}
+
+	response.Success(c, journey)
+}
+
+// GetJourneyStats handles GET /api/v1/journeys/stats?type=FLIGHT&limit=10
+func (h *JourneyHandler) GetJourneyStats(c *gin.Context) {
+	journeyType := strings.ToUpper(c.DefaultQuery("type", models.JourneyTypeFlight))
+	if !validJourneyType(journeyType, false) {
+		response.BadRequest(c, "type must be FLIGHT or TRAIN")
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	stats, err := h.service.GetJourneyStats(journeyType, limit)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get journey statistics", err)
+		return
+	}
+
+	response.Success(c, stats)
+}
+
+// ImportJourneys handles POST /api/v1/journeys/import
+// Accepts multipart uploads of App in the Air / 12306 / generic CSV files in
+// the "file" or "files" fields. type (FLIGHT or TRAIN) applies to generic
+// files without a type column.
+func (h *JourneyHandler) ImportJourneys(c *gin.Context) {
+	journeyType := strings.ToUpper(c.DefaultQuery("type", models.JourneyTypeFlight))
+	if !validJourneyType(journeyType, false) {
+		response.BadRequest(c, "type must be FLIGHT or TRAIN")
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid multipart form", err)
+		return
+	}
+
+	var files []*multipart.FileHeader
+	files = append(files, form.File["file"]...)
+	files = append(files, form.File["files"]...)
+	if len(files) == 0 {
+		response.BadRequest(c, "No files uploaded")
+		return
+	}
+
+	var results []gin.H
+	for _, fh := range files {
+		result, err := h.importFile(fh, journeyType)
+		if err != nil {
+			results = append(results, gin.H{"file_name": fh.Filename, "error": err.Error()})
+			continue
+		}
+		results = append(results, gin.H{"file_name": fh.Filename, "result": result})
+	}
+
+	response.Success(c, gin.H{"files": results})
+}
+
+// importFile opens an uploaded file and imports it
+func (h *JourneyHandler) importFile(fh *multipart.FileHeader, journeyType string) (*models.JourneyImportResult, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return h.service.ImportFile(fh.Filename, f, journeyType)
+}
+
+// LinkSegments handles POST /api/v1/journeys/link
+func (h *JourneyHandler) LinkSegments(c *gin.Context) {
+	linked, err := h.service.LinkSegments()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to link journeys", err)
+		return
+	}
+
+	response.Success(c, gin.H{"linked": linked})
+}
+
+func validJourneyType(journeyType string, allowEmpty bool) bool {
+	switch journeyType {
+	case models.JourneyTypeFlight, models.JourneyTypeTrain:
+		return true
+	case "":
+		return allowEmpty
+	default:
+		return false
+	}
+}
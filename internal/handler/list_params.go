@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// maxListLimit caps the page size of list endpoints
+const maxListLimit = 1000
+
+// listParams holds the pagination, sorting and field selection parameters of a list request
+type listParams struct {
+	models.QueryOptions
+	page   int      // 1-based page number, 0 when paging by cursor
+	fields []string // Response fields to keep; empty keeps all
+}
+
+// bindListParams parses the query parameters shared by list endpoints:
+//
+//	page or cursor  the page to return; cursor is the next_cursor of the previous page
+//	limit           page size (defaultLimit when absent, at most maxListLimit)
+//	sort, order     the field to sort by, and asc or desc
+//	fields          comma-separated response fields to keep
+//
+// legacySort names an older sort parameter of the endpoint, used when sort is
+// absent. On invalid parameters it responds 400 and returns false.
+func bindListParams(c *gin.Context, defaultLimit int, legacySort string) (listParams, bool) {
+	p := listParams{page: 1}
+	p.Limit = defaultLimit
+
+	if s := c.Query("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 {
+			response.BadRequest(c, "Invalid limit parameter")
+			return p, false
+		}
+		p.Limit = min(limit, maxListLimit)
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			response.BadRequest(c, "Invalid cursor parameter")
+			return p, false
+		}
+		p.Offset, p.page = offset, 0
+	} else if s := c.Query("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil || page < 1 {
+			response.BadRequest(c, "Invalid page parameter")
+			return p, false
+		}
+		p.page = page
+		p.Offset = (page - 1) * p.Limit
+	}
+
+	p.Sort = c.Query("sort")
+	if p.Sort == "" && legacySort != "" {
+		p.Sort = c.Query(legacySort)
+	}
+	p.Order = strings.ToLower(c.Query("order"))
+	if p.Order != "" && p.Order != "asc" && p.Order != "desc" {
+		response.BadRequest(c, "order must be asc or desc")
+		return p, false
+	}
+
+	if s := c.Query("fields"); s != "" {
+		for _, field := range strings.Split(s, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				p.fields = append(p.fields, field)
+			}
+		}
+	}
+
+	return p, true
+}
+
+// encodeCursor returns the opaque cursor of the page starting at offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o:" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	s, ok := strings.CutPrefix(string(raw), "o:")
+	if !ok {
+		return 0, errors.New("malformed cursor")
+	}
+	offset, err := strconv.Atoi(s)
+	if err != nil || offset < 0 {
+		return 0, errors.New("malformed cursor")
+	}
+	return offset, nil
+}
+
+// respondList writes a page of items in the list envelope
+// The envelope carries the total number of matching items and, when more
+// follow, the cursor of the next page. Items are reduced to the requested fields.
+func respondList[T any](c *gin.Context, items []T, total int64, p listParams) {
+	var data interface{} = items
+	if len(p.fields) > 0 {
+		projected, err := selectFields(items, p.fields)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		data = projected
+	}
+
+	body := gin.H{
+		"data":   data,
+		"count":  len(items),
+		"total":  total,
+		"limit":  p.Limit,
+		"offset": p.Offset,
+	}
+	if p.page > 0 {
+		body["page"] = p.page
+	}
+	if next := p.Offset + len(items); len(items) > 0 && int64(next) < total {
+		body["next_cursor"] = encodeCursor(next)
+	}
+	response.Success(c, body)
+}
+
+// listError responds to a failed list query: 400 for an unsupported sort field, else 500
+func listError(c *gin.Context, message string, err error) {
+	if errors.Is(err, models.ErrInvalidSort) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Error(c, http.StatusInternalServerError, message, err)
+}
+
+// selectFields reduces each item to the given JSON fields
+// Fields that T does not have are rejected.
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	known := jsonFields(reflect.TypeOf((*T)(nil)).Elem())
+	for _, field := range fields {
+		if !known[field] {
+			return nil, errors.New("unknown field: " + field)
+		}
+	}
+
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		return nil, err
+	}
+
+	projected := make([]map[string]json.RawMessage, len(objects))
+	for i, obj := range objects {
+		projected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if v, ok := obj[field]; ok {
+				projected[i][field] = v
+			}
+		}
+	}
+	return projected, nil
+}
+
+// jsonFields returns the JSON field names of a struct type
+func jsonFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := map[string]bool{}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+			continue
+		case f.Anonymous && name == "":
+			for embedded := range jsonFields(f.Type) {
+				fields[embedded] = true
+			}
+			continue
+		case name == "":
+			name = f.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
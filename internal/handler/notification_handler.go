@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// NotificationHandler handles HTTP requests for event notifications
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GetStatus handles GET /api/v1/admin/notifications
+// enabled is false when no webhook, Telegram chat or Bark device is configured
+func (h *NotificationHandler) GetStatus(c *gin.Context) {
+	response.Success(c, h.service.GetStatus())
+}
+
+// SendTest handles POST /api/v1/admin/notifications/test
+// The test event is queued; its deliveries show up in the delivery list
+func (h *NotificationHandler) SendTest(c *gin.Context) {
+	eventID, err := h.service.SendTest()
+	if err != nil {
+		if errors.Is(err, models.ErrNotificationsDisabled) {
+			response.Error(c, http.StatusConflict, "Notifications are not configured", err)
+			return
+		}
+		response.Error(c, http.StatusServiceUnavailable, "Failed to queue test notification", err)
+		return
+	}
+
+	response.Success(c, gin.H{"event_id": eventID})
+}
+
+// ListDeliveries handles GET /api/v1/admin/notifications/deliveries
+// event_type and status narrow the deliveries; the latest come first
+func (h *NotificationHandler) ListDeliveries(c *gin.Context) {
+	var filter models.NotificationFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	switch filter.Status {
+	case "", models.NotificationDelivered, models.NotificationFailed:
+	default:
+		response.BadRequest(c, "status must be delivered or failed")
+		return
+	}
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := h.service.GetDeliveries(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get notification deliveries", err)
+		return
+	}
+
+	respondList(c, deliveries, total, params)
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// PhotoHandler handles HTTP requests for geotagged photos
+type PhotoHandler struct {
+	service *service.PhotoService
+}
+
+// NewPhotoHandler creates a new photo handler
+func NewPhotoHandler(service *service.PhotoService) *PhotoHandler {
+	return &PhotoHandler{service: service}
+}
+
+// ListPhotos handles GET /api/v1/photos
+// start_time, end_time, source and located narrow the photos; they come in the order taken
+func (h *PhotoHandler) ListPhotos(c *gin.Context) {
+	var filter models.PhotoFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	photos, total, err := h.service.ListPhotos(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get photos", err)
+		return
+	}
+
+	respondList(c, photos, total, params)
+}
+
+// GetPhoto handles GET /api/v1/photos/:id
+func (h *PhotoHandler) GetPhoto(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid photo ID", err)
+		return
+	}
+
+	photo, err := h.service.GetPhoto(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get photo", err)
+		return
+	}
+	if photo == nil {
+		response.NotFound(c, "Photo not found")
+		return
+	}
+
+	response.Success(c, photo)
+}
+
+// GetPhotoFile handles GET /api/v1/photos/:id/file
+// Serves the original file of scanned photos and the preview of Immich photos.
+func (h *PhotoHandler) GetPhotoFile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid photo ID", err)
+		return
+	}
+
+	content, err := h.service.OpenPhoto(c.Request.Context(), id)
+	if errors.Is(err, models.ErrPhotoSourceNotConfigured) {
+		response.Error(c, http.StatusServiceUnavailable, "Photo source not configured", err)
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to open photo", err)
+		return
+	}
+	if content == nil {
+		response.NotFound(c, "Photo not found")
+		return
+	}
+	defer content.Body.Close()
+
+	c.Header("Cache-Control", "private, max-age=86400")
+	c.DataFromReader(http.StatusOK, content.Size, content.ContentType, content.Body, nil)
+}
+
+// GetTripPhotos handles GET /api/v1/trips/:id/photos
+func (h *PhotoHandler) GetTripPhotos(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid trip ID", err)
+		return
+	}
+
+	photos, err := h.service.GetTripPhotos(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get trip photos", err)
+		return
+	}
+	if photos == nil {
+		response.NotFound(c, "Trip not found")
+		return
+	}
+
+	response.Success(c, photos)
+}
+
+// GetStayPhotos handles GET /api/v1/stays/:id/photos
+func (h *PhotoHandler) GetStayPhotos(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid stay ID", err)
+		return
+	}
+
+	photos, err := h.service.GetStayPhotos(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get stay photos", err)
+		return
+	}
+	if photos == nil {
+		response.NotFound(c, "Stay not found")
+		return
+	}
+
+	response.Success(c, photos)
+}
+
+// ScanPhotos handles POST /api/v1/photos/import/scan
+// Scans PHOTO_DIR, or the subdirectory in path, for new and changed photos.
+func (h *PhotoHandler) ScanPhotos(c *gin.Context) {
+	var req models.PhotoScanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	result, err := h.service.ScanDirectory(req)
+	h.respondImport(c, result, err)
+}
+
+// ImportImmichPhotos handles POST /api/v1/photos/import/immich
+// Imports the photos of the Immich library taken after since (all when absent).
+func (h *PhotoHandler) ImportImmichPhotos(c *gin.Context) {
+	var req models.PhotoImmichRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	result, err := h.service.ImportImmich(c.Request.Context(), req)
+	h.respondImport(c, result, err)
+}
+
+func (h *PhotoHandler) respondImport(c *gin.Context, result *models.PhotoImportResult, err error) {
+	switch {
+	case errors.Is(err, models.ErrPhotoSourceNotConfigured):
+		response.Error(c, http.StatusServiceUnavailable, "Photo source not configured", err)
+	case errors.Is(err, models.ErrInvalidPhotoPath):
+		response.BadRequest(c, err.Error())
+	case err != nil:
+		response.Error(c, http.StatusInternalServerError, "Failed to import photos", err)
+	default:
+		response.Success(c, result)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// PlaceHandler handles HTTP requests for places clustered from stays
+type PlaceHandler struct {
+	service *service.PlaceService
+}
+
+// NewPlaceHandler creates a new place handler
+func NewPlaceHandler(service *service.PlaceService) *PlaceHandler {
+	return &PlaceHandler{service: service}
+}
+
+// ListPlaces handles GET /api/v1/places
+// name, label, city, named and min_visits narrow the places; the most visited come first
+func (h *PlaceHandler) ListPlaces(c *gin.Context) {
+	var filter models.PlaceFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	filter.Label = strings.ToUpper(filter.Label)
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	places, total, err := h.service.ListPlaces(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get places", err)
+		return
+	}
+
+	respondList(c, places, total, params)
+}
+
+// GetPlace handles GET /api/v1/places/:id
+func (h *PlaceHandler) GetPlace(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid place ID", err)
+		return
+	}
+
+	place, err := h.service.GetPlace(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get place", err)
+		return
+	}
+	if place == nil {
+		response.NotFound(c, "Place not found")
+		return
+	}
+
+	response.Success(c, place)
+}
+
+// RenamePlace handles PUT /api/v1/places/:id
+// An empty name clears the name.
+func (h *PlaceHandler) RenamePlace(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid place ID", err)
+		return
+	}
+
+	var req models.PlaceNameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	place, err := h.service.RenamePlace(id, req)
+	if errors.Is(err, models.ErrInvalidPlaceName) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to rename place", err)
+		return
+	}
+	if place == nil {
+		response.NotFound(c, "Place not found")
+		return
+	}
+
+	response.Success(c, place)
+}
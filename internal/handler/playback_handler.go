@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// Bounds of the playback query parameters, in seconds
+const (
+	maxPlaybackRange     = 7 * 24 * 3600
+	defaultPlaybackStep  = 60
+	defaultPlaybackGap   = 600
+	maxPlaybackStepOrGap = 24 * 3600
+)
+
+// PlaybackHandler handles HTTP requests for map replay
+type PlaybackHandler struct {
+	service *service.PlaybackService
+}
+
+// NewPlaybackHandler creates a new playback handler
+func NewPlaybackHandler(service *service.PlaybackService) *PlaybackHandler {
+	return &PlaybackHandler{service: service}
+}
+
+// GetPlayback handles GET /api/v1/viz/playback?from=&to=&step=
+func (h *PlaybackHandler) GetPlayback(c *gin.Context) {
+	var filter models.PlaybackFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if filter.From <= 0 || filter.To <= filter.From {
+		response.BadRequest(c, "from and to are required, with to after from")
+		return
+	}
+	if filter.To-filter.From > maxPlaybackRange {
+		response.BadRequest(c, "Range must be at most 7 days")
+		return
+	}
+	if filter.Step == 0 {
+		filter.Step = defaultPlaybackStep
+	}
+	if filter.MaxGap == 0 {
+		filter.MaxGap = defaultPlaybackGap
+	}
+	if filter.Step < 0 || filter.Step > maxPlaybackStepOrGap || filter.MaxGap < 0 || filter.MaxGap > maxPlaybackStepOrGap {
+		response.BadRequest(c, "step and max_gap must be between 1 and 86400 seconds")
+		return
+	}
+
+	playback, err := h.service.GetPlayback(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get playback", err)
+		return
+	}
+
+	response.Success(c, playback)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// PrivacyHandler handles HTTP requests for privacy zones
+type PrivacyHandler struct {
+	service *service.PrivacyService
+}
+
+// NewPrivacyHandler creates a new privacy handler
+func NewPrivacyHandler(service *service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{service: service}
+}
+
+// ListZones handles GET /api/v1/admin/privacy-zones
+// Soft-deleted zones are listed too with include_deleted=true
+func (h *PrivacyHandler) ListZones(c *gin.Context) {
+	includeDeleted := c.Query("include_deleted") == "true"
+
+	zones, err := h.service.ListZones(includeDeleted)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get privacy zones", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  zones,
+		"count": len(zones),
+	})
+}
+
+// GetZone handles GET /api/v1/admin/privacy-zones/:id
+func (h *PrivacyHandler) GetZone(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid zone ID", err)
+		return
+	}
+
+	zone, err := h.service.GetZone(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get privacy zone", err)
+		return
+	}
+	if zone == nil {
+		response.NotFound(c, "Privacy zone not found")
+		return
+	}
+
+	response.Success(c, zone)
+}
+
+// CreateZone handles POST /api/v1/admin/privacy-zones
+func (h *PrivacyHandler) CreateZone(c *gin.Context) {
+	var req models.PrivacyZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	zone, err := h.service.CreateZone(req)
+	if err != nil {
+		privacyZoneError(c, "Failed to create privacy zone", err)
+		return
+	}
+
+	response.Success(c, zone)
+}
+
+// UpdateZone handles PUT /api/v1/admin/privacy-zones/:id
+func (h *PrivacyHandler) UpdateZone(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid zone ID", err)
+		return
+	}
+
+	var req models.PrivacyZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	zone, err := h.service.UpdateZone(id, req)
+	if err != nil {
+		privacyZoneError(c, "Failed to update privacy zone", err)
+		return
+	}
+	if zone == nil {
+		response.NotFound(c, "Privacy zone not found")
+		return
+	}
+
+	response.Success(c, zone)
+}
+
+// DeleteZone handles DELETE /api/v1/admin/privacy-zones/:id
+// The zone is soft-deleted and can be restored.
+func (h *PrivacyHandler) DeleteZone(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid zone ID", err)
+		return
+	}
+
+	zone, err := h.service.DeleteZone(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete privacy zone", err)
+		return
+	}
+	if zone == nil {
+		response.NotFound(c, "Privacy zone not found")
+		return
+	}
+
+	response.Success(c, zone)
+}
+
+// RestoreZone handles POST /api/v1/admin/privacy-zones/:id/restore
+func (h *PrivacyHandler) RestoreZone(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid zone ID", err)
+		return
+	}
+
+	zone, err := h.service.RestoreZone(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to restore privacy zone", err)
+		return
+	}
+	if zone == nil {
+		response.NotFound(c, "Privacy zone not found")
+		return
+	}
+
+	response.Success(c, zone)
+}
+
+// privacyZoneError responds to a failed zone write: 400 for an invalid zone, else 500
+func privacyZoneError(c *gin.Context, message string, err error) {
+	if errors.Is(err, models.ErrInvalidPrivacyZone) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	response.Error(c, http.StatusInternalServerError, message, err)
+}
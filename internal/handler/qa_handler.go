@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// maxReviewIDs caps the points of one outlier review request
+const maxReviewIDs = 1000
+
+// QAHandler handles HTTP requests for track point quality review
+type QAHandler struct {
+	service *service.QAService
+}
+
+// NewQAHandler creates a new QA handler
+func NewQAHandler(service *service.QAService) *QAHandler {
+	return &QAHandler{service: service}
+}
+
+// GetOutliers handles GET /api/v1/qa/outliers
+func (h *QAHandler) GetOutliers(c *gin.Context) {
+	var filter models.OutlierFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	points, total, err := h.service.GetOutliers(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get outliers", err)
+		return
+	}
+
+	respondList(c, points, total, params)
+}
+
+// ConfirmOutliers handles POST /api/v1/qa/outliers/confirm
+func (h *QAHandler) ConfirmOutliers(c *gin.Context) {
+	h.review(c, h.service.ConfirmOutliers)
+}
+
+// UnflagOutliers handles POST /api/v1/qa/outliers/unflag
+func (h *QAHandler) UnflagOutliers(c *gin.Context) {
+	h.review(c, h.service.UnflagOutliers)
+}
+
+// ResetReviews handles POST /api/v1/qa/outliers/reset
+func (h *QAHandler) ResetReviews(c *gin.Context) {
+	h.review(c, h.service.ResetReviews)
+}
+
+// review binds the point IDs of a review request and applies action to them
+func (h *QAHandler) review(c *gin.Context, action func(ids []int64) (*models.OutlierReviewResult, error)) {
+	var req models.OutlierReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if len(req.IDs) == 0 {
+		response.BadRequest(c, "ids is required")
+		return
+	}
+	if len(req.IDs) > maxReviewIDs {
+		response.BadRequest(c, fmt.Sprintf("At most %d ids per request", maxReviewIDs))
+		return
+	}
+
+	result, err := action(req.IDs)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to review outliers", err)
+		return
+	}
+
+	response.Success(c, result)
+}
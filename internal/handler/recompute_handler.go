@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// RecomputeHandler handles HTTP requests for recomputing analyzers
+type RecomputeHandler struct {
+	service *service.RecomputeService
+}
+
+// NewRecomputeHandler creates a new recompute handler
+func NewRecomputeHandler(service *service.RecomputeService) *RecomputeHandler {
+	return &RecomputeHandler{service: service}
+}
+
+// Recompute handles POST /api/v1/admin/recompute
+// Without confirm the request is a dry run returning the plan; with confirm
+// the plan is executed in the background and returned with dry_run false
+func (h *RecomputeHandler) Recompute(c *gin.Context) {
+	var req models.RecomputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	var plan *models.RecomputePlan
+	var err error
+	if req.Confirm {
+		plan, err = h.service.Execute(c.Request.Context(), req)
+	} else {
+		plan, err = h.service.Plan(c.Request.Context(), req)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidRecompute):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, models.ErrRecomputeBusy):
+			response.Error(c, http.StatusConflict, "Analysis tasks are already running", err)
+		default:
+			response.Error(c, http.StatusInternalServerError, "Failed to plan recompute", err)
+		}
+		return
+	}
+
+	response.Success(c, plan)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ReportHandler handles HTTP requests for generated reports
+type ReportHandler struct {
+	service *service.ReportService
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(service *service.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetAnnualReport handles GET /api/v1/reports/annual/:year?format=json|html
+func (h *ReportHandler) GetAnnualReport(c *gin.Context) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		response.BadRequest(c, "year must be a number")
+		return
+	}
+	if current := time.Now().Year(); year < service.MinReportYear || year > current {
+		response.BadRequest(c, fmt.Sprintf("year must be between %d and %d", service.MinReportYear, current))
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		response.BadRequest(c, "format must be json or html")
+		return
+	}
+
+	report, err := h.service.GetAnnualReport(year)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to generate annual report", err)
+		return
+	}
+
+	if format == "html" {
+		var buf bytes.Buffer
+		if err := annualReportTemplate.Execute(&buf, report); err != nil {
+			response.Error(c, http.StatusInternalServerError, "Failed to render annual report", err)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	response.Success(c, report)
+}
+
+var annualReportTemplate = template.Must(template.New("annual").Funcs(template.FuncMap{
+	"km": func(m float64) string {
+		return strconv.FormatFloat(m/1000, 'f', 1, 64)
+	},
+	"pct": func(f float64) string {
+		return strconv.FormatFloat(f*100, 'f', 1, 64) + "%"
+	},
+	"date": func(ts int64) string {
+		return time.Unix(ts, 0).Format("2006-01-02")
+	},
+	"datetime": func(ts int64) string {
+		return time.Unix(ts, 0).Format("2006-01-02 15:04")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} 年度足迹报告</title>
+<style>
+body { font-family: sans-serif; max-width: 860px; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { margin-bottom: 0; }
+.muted { color: #888; font-size: 0.9em; }
+.cards { display: flex; flex-wrap: wrap; gap: 1em; margin: 1.5em 0; }
+.card { flex: 1 1 140px; border: 1px solid #ddd; border-radius: 6px; padding: 0.8em; }
+.card b { display: block; font-size: 1.6em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1.5em; }
+th, td { border-bottom: 1px solid #eee; padding: 0.4em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} 年度足迹报告</h1>
+<p class="muted">Generated {{datetime .GeneratedAt}}</p>
+
+<div class="cards">
+<div class="card"><b>{{km .TotalDistanceM}} km</b>total distance</div>
+<div class="card"><b>{{.ActiveDays}}</b>active days</div>
+<div class="card"><b>{{.TripCount}}</b>trips</div>
+<div class="card"><b>{{.TotalSteps}}</b>step equivalent</div>
+{{with .Footprint}}<div class="card"><b>{{.ProvinceCount}} / {{.CityCount}} / {{.CountyCount}}</b>provinces / cities / counties</div>{{end}}
+</div>
+
+<h2>Distance by mode</h2>
+{{if .DistanceByMode}}<table>
+<tr><th>Mode</th><th>Distance (km)</th><th>Share</th></tr>
+{{range .DistanceByMode}}<tr><td>{{.Mode}}</td><td>{{km .DistanceM}}</td><td>{{pct .Share}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No daily summaries for this year.</p>{{end}}
+{{with .BusiestDay}}<p>Busiest day: {{.Date}} ({{km .TotalDistanceM}} km)</p>{{end}}
+
+<h2>New places</h2>
+{{if .NewCities}}<table>
+<tr><th>City</th><th>Province</th><th>First visit</th></tr>
+{{range .NewCities}}<tr><td>{{.City}}</td><td>{{.Province}}</td><td>{{date .FirstVisit}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No new cities.</p>{{end}}
+{{if .NewCounties}}<table>
+<tr><th>County</th><th>City</th><th>First visit</th></tr>
+{{range .NewCounties}}<tr><td>{{.County}}</td><td>{{.City}}</td><td>{{date .FirstVisit}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No new counties.</p>{{end}}
+
+<h2>Longest trip</h2>
+{{with .LongestTrip}}<p>{{.Date}}: {{.OriginCity}} {{.OriginCounty}} → {{.DestCity}} {{.DestCounty}},
+{{km .DistanceMeters}} km{{if .PrimaryMode}} by {{.PrimaryMode}}{{end}}</p>{{else}}<p class="muted">No trips.</p>{{end}}
+
+<h2>Extremes</h2>
+{{if .ExtremeEvents}}<table>
+<tr><th>Event</th><th>Value</th><th>Time</th><th>Location</th></tr>
+{{range .ExtremeEvents}}<tr><td>{{.EventType}}</td><td>{{.EventValue}}</td><td>{{datetime .EventTime}}</td><td>{{.Province}} {{.City}} {{.County}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No extreme events.</p>{{end}}
+
+<h2>Most revisited</h2>
+{{if .TopRevisitLocations}}<table>
+<tr><th>Location</th><th>Visits</th><th>Strength</th></tr>
+{{range .TopRevisitLocations}}<tr><td>{{.City}} {{.County}} ({{.Geohash6}})</td><td>{{.VisitCount}}</td><td>{{printf "%.2f" .RevisitStrength}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No revisit patterns.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ScheduleHandler handles HTTP requests for scheduled analysis
+type ScheduleHandler struct {
+	service *service.ScheduleService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(service *service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{service: service}
+}
+
+// ListSchedules handles GET /api/v1/admin/schedules
+// Schedules disabled in the configuration are not listed
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.service.GetSchedules()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get schedules", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  schedules,
+		"count": len(schedules),
+	})
+}
+
+// ListRuns handles GET /api/v1/admin/schedules/runs
+// schedule and status narrow the runs; the latest come first
+func (h *ScheduleHandler) ListRuns(c *gin.Context) {
+	var filter models.ScheduleRunFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	switch filter.Status {
+	case "", models.ScheduleRunRunning, models.ScheduleRunCompleted, models.ScheduleRunFailed, models.ScheduleRunSkipped:
+	default:
+		response.BadRequest(c, "status must be running, completed, failed or skipped")
+		return
+	}
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
+
+	runs, total, err := h.service.GetRuns(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get schedule runs", err)
+		return
+	}
+
+	respondList(c, runs, total, params)
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"mime/multipart"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ScreenTimeHandler handles HTTP requests for screen time data
+type ScreenTimeHandler struct {
+	service *service.ScreenTimeService
+}
+
+// NewScreenTimeHandler creates a new screen time handler
+func NewScreenTimeHandler(service *service.ScreenTimeService) *ScreenTimeHandler {
+	return &ScreenTimeHandler{service: service}
+}
+
+// bindFilter parses and validates the common screen time query parameters
+func (h *ScreenTimeHandler) bindFilter(c *gin.Context) (models.ScreenTimeFilter, bool) {
+	var filter models.ScreenTimeFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return filter, false
+	}
+	return filter, validateDateRange(c, filter.From, filter.To, service.MaxScreenTimeDays)
+}
+
+// GetStats handles GET /api/v1/screentime/stats?from=&to=&device=&limit=
+func (h *ScreenTimeHandler) GetStats(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetSummary(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get screen time statistics", err)
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// GetTopApps handles GET /api/v1/screentime/top-apps?from=&to=&category=&limit=
+func (h *ScreenTimeHandler) GetTopApps(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	apps, err := h.service.GetTopApps(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get top apps", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  apps,
+		"count": len(apps),
+	})
+}
+
+// GetCategories handles GET /api/v1/screentime/categories?from=&to=
+func (h *ScreenTimeHandler) GetCategories(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	categories, err := h.service.GetCategoryTotals(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get category totals", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  categories,
+		"count": len(categories),
+	})
+}
+
+// GetWeeklyTrends handles GET /api/v1/screentime/trends/weekly?from=&to=&category=
+func (h *ScreenTimeHandler) GetWeeklyTrends(c *gin.Context) {
+	filter, ok := h.bindFilter(c)
+	if !ok {
+		return
+	}
+
+	weeks, err := h.service.GetWeeklyTrends(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get weekly trends", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  weeks,
+		"count": len(weeks),
+	})
+}
+
+// ImportScreenTime handles POST /api/v1/screentime/import?device=iPhone
+// Accepts multipart uploads of iOS Screen Time / Digital Wellbeing CSV
+// exports in the "file" or "files" fields
+func (h *ScreenTimeHandler) ImportScreenTime(c *gin.Context) {
+	device := c.Query("device")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid multipart form", err)
+		return
+	}
+
+	var files []*multipart.FileHeader
+	files = append(files, form.File["file"]...)
+	files = append(files, form.File["files"]...)
+	if len(files) == 0 {
+		response.BadRequest(c, "No files uploaded")
+		return
+	}
+
+	var results []gin.H
+	for _, fh := range files {
+		result, err := h.importFile(fh, device)
+		if err != nil {
+			results = append(results, gin.H{"file_name": fh.Filename, "error": err.Error()})
+			continue
+		}
+		results = append(results, gin.H{"file_name": fh.Filename, "result": result})
+	}
+
+	response.Success(c, gin.H{"files": results})
+}
+
+// importFile opens an uploaded file and imports it
+func (h *ScreenTimeHandler) importFile(fh *multipart.FileHeader, device string) (*models.ScreenTimeImportResult, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return h.service.ImportFile(fh.Filename, f, device)
+}
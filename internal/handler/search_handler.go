@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// maxSearchQueryLen bounds the search text, in characters
+const maxSearchQueryLen = 200
+
+// SearchHandler handles HTTP requests for full-text search
+type SearchHandler struct {
+	service *service.SearchService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(service *service.SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Search handles GET /api/v1/search
+// q holds the words to find, each matched as a prefix; type narrows the
+// results to stay, trip, place or event
+func (h *SearchHandler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		response.BadRequest(c, "q is required")
+		return
+	}
+	if utf8.RuneCountInString(text) > maxSearchQueryLen {
+		response.BadRequest(c, "q is too long")
+		return
+	}
+	kind := strings.ToLower(c.Query("type"))
+	switch kind {
+	case "", "stay", "trip", "place", "event":
+	default:
+		response.BadRequest(c, "type must be stay, trip, place or event")
+		return
+	}
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.service.Search(text, kind, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to search", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
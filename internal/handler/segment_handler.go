@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -77,3 +78,46 @@ func (h *SegmentHandler) GetSegmentByID(c *gin.Context) {
 
 	response.Success(c, segment)
 }
+
+// CorrectSegmentMode handles PATCH /api/v1/segments/:id/mode
+func (h *SegmentHandler) CorrectSegmentMode(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid segment ID", err)
+		return
+	}
+
+	var req models.SegmentModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	result, err := h.service.CorrectMode(id, req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidModeCorrection) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to correct segment mode", err)
+		return
+	}
+
+	if result == nil {
+		response.NotFound(c, "Segment not found")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetModeCorrections handles GET /api/v1/segments/mode-corrections
+func (h *SegmentHandler) GetModeCorrections(c *gin.Context) {
+	corrections, err := h.service.GetModeCorrections()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get mode corrections", err)
+		return
+	}
+
+	response.Success(c, corrections)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/exporter"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ShareHandler handles HTTP requests for share cards
+type ShareHandler struct {
+	service *service.ShareService
+}
+
+// NewShareHandler creates a new share handler
+func NewShareHandler(service *service.ShareService) *ShareHandler {
+	return &ShareHandler{service: service}
+}
+
+// GetTripCard handles GET /api/v1/share/trip/:id.png
+func (h *ShareHandler) GetTripCard(c *gin.Context) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(c.Param("id.png"), ".png"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid trip ID", err)
+		return
+	}
+
+	card, err := h.service.GetTripCard(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to build share card", err)
+		return
+	}
+	if card == nil {
+		response.NotFound(c, "Trip not found")
+		return
+	}
+
+	writeCard(c, card)
+}
+
+// GetDayCard handles GET /api/v1/share/day/:date.png
+func (h *ShareHandler) GetDayCard(c *gin.Context) {
+	date := strings.TrimSuffix(c.Param("date.png"), ".png")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		response.BadRequest(c, "date must be YYYY-MM-DD")
+		return
+	}
+
+	card, err := h.service.GetDayCard(date)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to build share card", err)
+		return
+	}
+	if card == nil {
+		response.NotFound(c, "Day not found")
+		return
+	}
+
+	writeCard(c, card)
+}
+
+// writeCard renders a share card as the PNG response
+func writeCard(c *gin.Context, card *exporter.ShareCard) {
+	var buf bytes.Buffer
+	if err := exporter.WriteShareCard(&buf, card); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to render share card", err)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", buf.Bytes())
+}
@@ -2,7 +2,10 @@ package handler
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jengzang/records-backend-go/internal/models"
@@ -22,6 +25,76 @@ func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
 	}
 }
 
+// statsTables maps each stats route to the analysis tables its response is read from
+var statsTables = map[string][]string{
+	"/api/v1/stats/footprint/rankings":                       {"footprint_statistics"},
+	"/api/v1/stats/footprint/coverage":                       {"footprint_statistics"},
+	"/api/v1/stats/countries":                                {"footprint_statistics"},
+	"/api/v1/stats/countries/days-abroad":                    {"footprint_statistics"},
+	"/api/v1/stats/stay/rankings":                            {"stay_statistics"},
+	"/api/v1/stats/extreme-events":                           {"extreme_events"},
+	"/api/v1/stats/admin-crossings":                          {"admin_crossings"},
+	"/api/v1/stats/admin-visits":                             {"admin_visits"},
+	"/api/v1/stats/admin-visits/summary":                     {"admin_visits"},
+	"/api/v1/stats/admin-view":                               {"admin_stats"},
+	"/api/v1/stats/admin-tree":                               {"admin_stats", "footprint_statistics"},
+	"/api/v1/stats/speed-space":                              {"speed_space_stats_bucketed"},
+	"/api/v1/stats/speed-space/high-speed-zones":             {"speed_space_stats_bucketed"},
+	"/api/v1/stats/speed-space/slow-life-zones":              {"speed_space_stats_bucketed"},
+	"/api/v1/stats/directional-bias":                         {"directional_stats_bucketed"},
+	"/api/v1/stats/directional-bias/top-areas":               {"directional_stats_bucketed"},
+	"/api/v1/stats/directional-bias/bidirectional":           {"directional_stats_bucketed"},
+	"/api/v1/stats/directional-bias/rose":                    {"directional_stats_bucketed"},
+	"/api/v1/stats/revisit-patterns":                         {"revisit_patterns"},
+	"/api/v1/stats/revisit-patterns/top-locations":           {"revisit_patterns"},
+	"/api/v1/stats/revisit-patterns/habitual":                {"revisit_patterns"},
+	"/api/v1/stats/revisit-patterns/periodic":                {"revisit_patterns"},
+	"/api/v1/stats/spatial-utilization":                      {"spatial_utilization_bucketed"},
+	"/api/v1/stats/spatial-utilization/destinations":         {"spatial_utilization_bucketed"},
+	"/api/v1/stats/spatial-utilization/corridors":            {"spatial_utilization_bucketed"},
+	"/api/v1/stats/spatial-utilization/deep-engagement":      {"spatial_utilization_bucketed"},
+	"/api/v1/stats/density":                                  {"spatial_density_grid_stats"},
+	"/api/v1/stats/density/core":                             {"spatial_density_grid_stats"},
+	"/api/v1/stats/density/rare":                             {"spatial_density_grid_stats"},
+	"/api/v1/stats/density/clusters":                         {"spatial_density_grid_stats"},
+	"/api/v1/stats/altitude":                                 {"altitude_stats_bucketed"},
+	"/api/v1/stats/altitude/highest-spans":                   {"altitude_stats_bucketed"},
+	"/api/v1/stats/altitude/highest-intensity":               {"altitude_stats_bucketed"},
+	"/api/v1/stats/time-space-compression":                   {"time_space_compression_bucketed"},
+	"/api/v1/stats/time-space-compression/highest-intensity": {"time_space_compression_bucketed"},
+	"/api/v1/stats/time-space-compression/burst-periods":     {"time_space_compression_bucketed"},
+	"/api/v1/stats/mode-breakdown":                           {"mode_stats_bucketed"},
+	"/api/v1/stats/carbon":                                   {"carbon_stats_bucketed", "emission_factors"},
+	"/api/v1/stats/carbon/trips":                             {"trip_carbon"},
+	"/api/v1/stats/dayparts":                                 {"daypart_stats_bucketed"},
+	"/api/v1/stats/day-types":                                {"day_type_stats_bucketed"},
+	"/api/v1/stats/day-types/compare":                        {"day_type_stats_bucketed"},
+	"/api/v1/stats/anomalies":                                {"anomalous_days"},
+	"/api/v1/stats/exploration":                              {"exploration_monthly", "discovery_streaks"},
+	"/api/v1/stats/exploration/streaks":                      {"discovery_streaks"},
+	"/api/v1/stats/first-visits":                             {"footprint_statistics", "admin_crossings", "trips"},
+	"/api/v1/stats/records":                                  {"personal_records"},
+	"/api/v1/stats/compare":                                  {"footprint_statistics", "speed_space_stats_bucketed", "time_space_compression_bucketed", "altitude_stats_bucketed"},
+	"/api/v1/stats/time-space-slices":                        {"time_space_slices"},
+	"/api/v1/stats/time-space-slices/weekly-pattern":         {"time_space_slices"},
+	"/api/v1/stats/time-space-slices/hourly-pattern":         {"time_space_slices"},
+	"/api/v1/stats/spatial-complexity":                       {"complexity_metrics"},
+	"/api/v1/stats/road-overlap":                             {"road_overlap_stats"},
+	"/api/v1/stats/commute":                                  {"commute_patterns"},
+	"/api/v1/stats/routes":                                   {"route_clusters"},
+	"/api/v1/stats/health-correlation":                       {"health_movement_correlation"},
+}
+
+// DataVersion returns the version of the tables behind the matched stats route,
+// or "" for routes without one; used to compute ETags
+func (h *StatsHandler) DataVersion(c *gin.Context) (string, error) {
+	tables, ok := statsTables[c.FullPath()]
+	if !ok {
+		return "", nil
+	}
+	return h.statsService.GetDataVersion(tables...)
+}
+
 // GetFootprintStatistics handles GET /api/v1/tracks/statistics/footprint
 func (h *StatsHandler) GetFootprintStatistics(c *gin.Context) {
 	// Parse time range
@@ -50,6 +123,39 @@ func (h *StatsHandler) GetFootprintStatistics(c *gin.Context) {
 	response.Success(c, stats)
 }
 
+// GetVisitedAreas handles GET /api/v1/tracks/statistics/footprint/areas
+func (h *StatsHandler) GetVisitedAreas(c *gin.Context) {
+	level := c.DefaultQuery("level", "county")
+	if !slices.Contains(models.AreaLevels, level) {
+		response.BadRequest(c, "level must be province, city or county")
+		return
+	}
+
+	startTime, err := strconv.ParseInt(c.DefaultQuery("startTime", "0"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid startTime parameter")
+		return
+	}
+	endTime, err := strconv.ParseInt(c.DefaultQuery("endTime", "0"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid endTime parameter")
+		return
+	}
+
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	areas, total, err := h.statsService.GetVisitedAreas(level, startTime, endTime, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get visited areas", err)
+		return
+	}
+
+	respondList(c, areas, total, params)
+}
+
 // GetTimeDistribution handles GET /api/v1/tracks/statistics/time-distribution
 func (h *StatsHandler) GetTimeDistribution(c *gin.Context) {
 	// Parse time range
@@ -113,6 +219,10 @@ func (h *StatsHandler) GetFootprintRankings(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
 		return
 	}
+	params, ok := bindListParams(c, 100, "orderBy")
+	if !ok {
+		return
+	}
 
 	// Default values
 	if filter.StatType == "" {
@@ -121,23 +231,57 @@ func (h *StatsHandler) GetFootprintRankings(c *gin.Context) {
 	if filter.TimeRange == "" {
 		filter.TimeRange = "all"
 	}
-	if filter.OrderBy == "" {
-		filter.OrderBy = "points"
+
+	rankings, total, err := h.statsService.GetFootprintRankings(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get footprint rankings", err)
+		return
 	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
+
+	respondList(c, rankings, total, params)
+}
+
+// GetFootprintCoverage handles GET /api/v1/stats/footprint/coverage?year=
+func (h *StatsHandler) GetFootprintCoverage(c *gin.Context) {
+	year := time.Now().Year()
+	if s := c.Query("year"); s != "" {
+		y, err := strconv.Atoi(s)
+		if err != nil || y < 1970 || y > 9999 {
+			response.BadRequest(c, "Invalid year parameter")
+			return
+		}
+		year = y
 	}
 
-	rankings, err := h.statsService.GetFootprintRankings(filter)
+	result, err := h.statsService.GetFootprintCoverage(year)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get footprint coverage", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetCountryVisits handles GET /api/v1/stats/countries
+func (h *StatsHandler) GetCountryVisits(c *gin.Context) {
+	visits, err := h.statsService.GetCountryVisits()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get visited countries", err)
+		return
+	}
+
+	response.Success(c, visits)
+}
+
+// GetDaysAbroad handles GET /api/v1/stats/countries/days-abroad
+func (h *StatsHandler) GetDaysAbroad(c *gin.Context) {
+	years, err := h.statsService.GetDaysAbroad()
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get footprint rankings", err)
+		response.Error(c, http.StatusInternalServerError, "Failed to get days abroad", err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"data":  rankings,
-		"count": len(rankings),
-	})
+	response.Success(c, years)
 }
 
 // GetStayRankings handles GET /api/v1/stats/stay/rankings
@@ -147,6 +291,10 @@ func (h *StatsHandler) GetStayRankings(c *gin.Context) {
 		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
 		return
 	}
+	params, ok := bindListParams(c, 100, "orderBy")
+	if !ok {
+		return
+	}
 
 	// Default values
 	if filter.StatType == "" {
@@ -155,47 +303,32 @@ func (h *StatsHandler) GetStayRankings(c *gin.Context) {
 	if filter.TimeRange == "" {
 		filter.TimeRange = "all"
 	}
-	if filter.OrderBy == "" {
-		filter.OrderBy = "count"
-	}
-	if filter.Limit == 0 {
-		filter.Limit = 100
-	}
 
-	rankings, err := h.statsService.GetStayRankings(filter)
+	rankings, total, err := h.statsService.GetStayRankings(filter, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get stay rankings", err)
+		listError(c, "Failed to get stay rankings", err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"data":  rankings,
-		"count": len(rankings),
-	})
+	respondList(c, rankings, total, params)
 }
 
 // GetExtremeEvents handles GET /api/v1/stats/extreme-events
 func (h *StatsHandler) GetExtremeEvents(c *gin.Context) {
 	eventType := c.Query("eventType")
 	eventCategory := c.Query("eventCategory")
-	limitStr := c.DefaultQuery("limit", "100")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid limit parameter", err)
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
 		return
 	}
 
-	events, err := h.statsService.GetExtremeEvents(eventType, eventCategory, limit)
+	events, total, err := h.statsService.GetExtremeEvents(eventType, eventCategory, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get extreme events", err)
+		listError(c, "Failed to get extreme events", err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"data":  events,
-		"count": len(events),
-	})
+	respondList(c, events, total, params)
 }
 
 // GetAdminCrossings handles GET /api/v1/stats/admin-crossings
@@ -205,7 +338,6 @@ func (h *StatsHandler) GetAdminCrossings(c *gin.Context) {
 	toRegion := c.Query("to")
 	startTimeStr := c.DefaultQuery("start_time", "0")
 	endTimeStr := c.DefaultQuery("end_time", "0")
-	limitStr := c.DefaultQuery("limit", "100")
 
 	startTime, err := strconv.ParseInt(startTimeStr, 10, 64)
 	if err != nil {
@@ -219,22 +351,79 @@ func (h *StatsHandler) GetAdminCrossings(c *gin.Context) {
 		return
 	}
 
-	limit, err := strconv.Atoi(limitStr)
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	crossings, total, err := h.statsService.GetAdminCrossings(crossingType, fromRegion, toRegion, startTime, endTime, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid limit parameter", err)
+		listError(c, "Failed to get admin crossings", err)
+		return
+	}
+
+	respondList(c, crossings, total, params)
+}
+
+// GetAdminVisits handles GET /api/v1/stats/admin-visits
+// start_time/end_time select the visits overlapping a time range
+func (h *StatsHandler) GetAdminVisits(c *gin.Context) {
+	var filter models.AdminVisitFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	filter.AdminLevel = strings.ToUpper(filter.AdminLevel)
+	if !validAdminVisitLevel(filter.AdminLevel, true) {
+		response.BadRequest(c, "admin_level must be COUNTRY, PROVINCE or CITY")
+		return
+	}
+	if filter.StartTime > 0 && filter.EndTime > 0 && filter.StartTime > filter.EndTime {
+		response.BadRequest(c, "start_time must be before end_time")
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
 		return
 	}
 
-	crossings, err := h.statsService.GetAdminCrossings(crossingType, fromRegion, toRegion, startTime, endTime, limit)
+	visits, total, err := h.statsService.GetAdminVisits(filter, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get admin crossings", err)
+		listError(c, "Failed to get admin visits", err)
 		return
 	}
 
-	response.Success(c, gin.H{
-		"data":  crossings,
-		"count": len(crossings),
-	})
+	respondList(c, visits, total, params)
+}
+
+// GetAdminVisitSummaries handles GET /api/v1/stats/admin-visits/summary
+// admin_level defaults to PROVINCE
+func (h *StatsHandler) GetAdminVisitSummaries(c *gin.Context) {
+	adminLevel := strings.ToUpper(c.DefaultQuery("admin_level", "PROVINCE"))
+	if !validAdminVisitLevel(adminLevel, false) {
+		response.BadRequest(c, "admin_level must be COUNTRY, PROVINCE or CITY")
+		return
+	}
+
+	result, err := h.statsService.GetAdminVisitSummaries(adminLevel)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get admin visit summaries", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// validAdminVisitLevel reports whether visits are paired at level, or level
+// is empty when allowEmpty is true
+func validAdminVisitLevel(level string, allowEmpty bool) bool {
+	switch level {
+	case "COUNTRY", "PROVINCE", "CITY":
+		return true
+	case "":
+		return allowEmpty
+	}
+	return false
 }
 
 // GetAdminView handles GET /api/v1/stats/admin-view
@@ -242,152 +431,193 @@ func (h *StatsHandler) GetAdminView(c *gin.Context) {
 	adminLevel := c.Query("admin_level")
 	adminName := c.Query("admin_name")
 	parentName := c.Query("parent_name")
-	sortBy := c.DefaultQuery("sort_by", "visit_count")
-	limitStr := c.DefaultQuery("limit", "50")
+	params, ok := bindListParams(c, 50, "sort_by")
+	if !ok {
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
+	stats, total, err := h.statsService.GetAdminStats(adminLevel, adminName, parentName, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid limit parameter", err)
+		listError(c, "Failed to get admin stats", err)
 		return
 	}
 
-	stats, err := h.statsService.GetAdminStats(adminLevel, adminName, parentName, sortBy, limit)
+	respondList(c, stats, total, params)
+}
+
+// GetAdminTree handles GET /api/v1/stats/admin-tree
+func (h *StatsHandler) GetAdminTree(c *gin.Context) {
+	var filter models.AdminTreeFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if filter.Depth < 0 || filter.Depth > 4 {
+		response.BadRequest(c, "depth must be between 1 and 4")
+		return
+	}
+	if (filter.City != "" && filter.Province == "") || (filter.County != "" && filter.City == "") {
+		response.BadRequest(c, "city requires province and county requires city")
+		return
+	}
+
+	tree, err := h.statsService.GetAdminTree(filter)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get admin stats", err)
+		response.Error(c, http.StatusInternalServerError, "Failed to get admin tree", err)
+		return
+	}
+
+	if tree == nil {
+		response.NotFound(c, "Admin area not found")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"data":  stats,
-		"count": len(stats),
-	})
+	response.Success(c, tree)
 }
 
 // GetSpeedSpaceStats handles GET /api/v1/stats/speed-space
 func (h *StatsHandler) GetSpeedSpaceStats(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.DefaultQuery("area_type", "")
 	areaName := c.DefaultQuery("area_name", "")
-	limitStr := c.DefaultQuery("limit", "100")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
 		return
 	}
 
-	stats, err := h.statsService.GetSpeedSpaceStats(bucketType, areaType, areaName, limit)
+	stats, total, err := h.statsService.GetSpeedSpaceStats(bucketType, source, areaType, areaName, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get speed-space stats", err)
 		return
 	}
 
-	response.Success(c, stats)
+	respondList(c, stats, total, params)
 }
 
 // GetHighSpeedZones handles GET /api/v1/stats/speed-space/high-speed-zones
 func (h *StatsHandler) GetHighSpeedZones(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.DefaultQuery("area_type", "")
-	limitStr := c.DefaultQuery("limit", "50")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
 		return
 	}
 
-	zones, err := h.statsService.GetHighSpeedZones(bucketType, areaType, limit)
+	zones, total, err := h.statsService.GetHighSpeedZones(bucketType, source, areaType, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get high-speed zones", err)
 		return
 	}
 
-	response.Success(c, zones)
+	respondList(c, zones, total, params)
 }
 
 // GetSlowLifeZones handles GET /api/v1/stats/speed-space/slow-life-zones
 func (h *StatsHandler) GetSlowLifeZones(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.DefaultQuery("area_type", "")
-	limitStr := c.DefaultQuery("limit", "50")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
 		return
 	}
 
-	zones, err := h.statsService.GetSlowLifeZones(bucketType, areaType, limit)
+	zones, total, err := h.statsService.GetSlowLifeZones(bucketType, source, areaType, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get slow-life zones", err)
 		return
 	}
 
-	response.Success(c, zones)
+	respondList(c, zones, total, params)
 }
 
 // GetDirectionalBiasStats handles GET /api/v1/stats/directional-bias
 func (h *StatsHandler) GetDirectionalBiasStats(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.DefaultQuery("area_type", "")
 	areaKey := c.DefaultQuery("area_key", "")
 	modeFilter := c.DefaultQuery("mode", "ALL")
-	limitStr := c.DefaultQuery("limit", "50")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
+	stats, total, err := h.statsService.GetDirectionalBiasStats(bucketType, source, areaType, areaKey, modeFilter, params.QueryOptions)
 	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+		listError(c, "Failed to get directional bias stats", err)
 		return
 	}
 
-	stats, err := h.statsService.GetDirectionalBiasStats(bucketType, areaType, areaKey, modeFilter, limit)
+	respondList(c, stats, total, params)
+}
+
+// GetDirectionalRose handles GET /api/v1/stats/directional-bias/rose
+func (h *StatsHandler) GetDirectionalRose(c *gin.Context) {
+	bucketType := c.DefaultQuery("bucket", "all")
+	bucketKey := c.DefaultQuery("bucket_key", "all")
+	source := c.DefaultQuery("source", "all")
+	areaType := c.Query("area_type")
+	areaKey := c.Query("area_key")
+	modeFilter := c.DefaultQuery("mode", "ALL")
+	if areaType == "" || areaKey == "" {
+		response.BadRequest(c, "area_type and area_key are required")
+		return
+	}
+	if bucketType != "all" && bucketKey == "all" {
+		response.BadRequest(c, "bucket_key is required for year and month buckets")
+		return
+	}
+
+	rose, err := h.statsService.GetDirectionalRose(bucketType, bucketKey, source, areaType, areaKey, modeFilter)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.Error(c, http.StatusInternalServerError, "Failed to get directional rose", err)
+		return
+	}
+	if rose == nil {
+		response.NotFound(c, "No directional stats for the area")
 		return
 	}
 
-	response.Success(c, stats)
+	response.Success(c, rose)
 }
 
 // GetTopDirectionalAreas handles GET /api/v1/stats/directional-bias/top-areas
 func (h *StatsHandler) GetTopDirectionalAreas(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limitStr := c.DefaultQuery("limit", "10")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
 		return
 	}
 
-	stats, err := h.statsService.GetTopDirectionalAreas(bucketType, limit)
+	stats, total, err := h.statsService.GetTopDirectionalAreas(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get top directional areas", err)
 		return
 	}
 
-	response.Success(c, stats)
+	respondList(c, stats, total, params)
 }
 
 // GetBidirectionalPatterns handles GET /api/v1/stats/directional-bias/bidirectional
 func (h *StatsHandler) GetBidirectionalPatterns(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limitStr := c.DefaultQuery("limit", "10")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
 		return
 	}
 
-	stats, err := h.statsService.GetBidirectionalPatterns(bucketType, limit)
+	stats, total, err := h.statsService.GetBidirectionalPatterns(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get bidirectional patterns", err)
 		return
 	}
 
-	response.Success(c, stats)
+	respondList(c, stats, total, params)
 }
 
 // GetRevisitPatterns handles GET /api/v1/stats/revisit-patterns
@@ -395,7 +625,6 @@ func (h *StatsHandler) GetRevisitPatterns(c *gin.Context) {
 	minVisitsStr := c.DefaultQuery("min_visits", "2")
 	habitualOnlyStr := c.DefaultQuery("habitual_only", "false")
 	periodicOnlyStr := c.DefaultQuery("periodic_only", "false")
-	limitStr := c.DefaultQuery("limit", "50")
 
 	minVisits, err := strconv.Atoi(minVisitsStr)
 	if err != nil {
@@ -406,296 +635,604 @@ func (h *StatsHandler) GetRevisitPatterns(c *gin.Context) {
 	habitualOnly := habitualOnlyStr == "true"
 	periodicOnly := periodicOnlyStr == "true"
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
 		return
 	}
 
-	patterns, err := h.statsService.GetRevisitPatterns(minVisits, habitualOnly, periodicOnly, limit)
+	patterns, total, err := h.statsService.GetRevisitPatterns(minVisits, habitualOnly, periodicOnly, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get revisit patterns", err)
 		return
 	}
 
-	response.Success(c, patterns)
+	respondList(c, patterns, total, params)
 }
 
 // GetTopRevisitLocations handles GET /api/v1/stats/revisit-patterns/top-locations
 func (h *StatsHandler) GetTopRevisitLocations(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
 		return
 	}
 
-	patterns, err := h.statsService.GetTopRevisitLocations(limit)
+	patterns, total, err := h.statsService.GetTopRevisitLocations(params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get top revisit locations", err)
 		return
 	}
 
-	response.Success(c, patterns)
+	respondList(c, patterns, total, params)
 }
 
 // GetHabitualLocations handles GET /api/v1/stats/revisit-patterns/habitual
 func (h *StatsHandler) GetHabitualLocations(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
 		return
 	}
 
-	patterns, err := h.statsService.GetHabitualLocations(limit)
+	patterns, total, err := h.statsService.GetHabitualLocations(params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get habitual locations", err)
 		return
 	}
 
-	response.Success(c, patterns)
+	respondList(c, patterns, total, params)
 }
 
 // GetPeriodicLocations handles GET /api/v1/stats/revisit-patterns/periodic
 func (h *StatsHandler) GetPeriodicLocations(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil {
-		response.BadRequest(c, "Invalid limit parameter")
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
 		return
 	}
 
-	patterns, err := h.statsService.GetPeriodicLocations(limit)
+	patterns, total, err := h.statsService.GetPeriodicLocations(params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get periodic locations", err)
 		return
 	}
 
-	response.Success(c, patterns)
+	respondList(c, patterns, total, params)
 }
 
 // GetSpatialUtilization handles GET /api/v1/stats/spatial-utilization
 func (h *StatsHandler) GetSpatialUtilization(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
 	areaKey := c.Query("area_key")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetSpatialUtilization(bucketType, areaType, areaKey, limit)
+	results, total, err := h.statsService.GetSpatialUtilization(bucketType, source, areaType, areaKey, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get spatial utilization", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetDestinationAreas handles GET /api/v1/stats/spatial-utilization/destinations
 func (h *StatsHandler) GetDestinationAreas(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetDestinationAreas(bucketType, areaType, limit)
+	results, total, err := h.statsService.GetDestinationAreas(bucketType, source, areaType, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get destination areas", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetTransitCorridors handles GET /api/v1/stats/spatial-utilization/corridors
 func (h *StatsHandler) GetTransitCorridors(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetTransitCorridors(bucketType, areaType, limit)
+	results, total, err := h.statsService.GetTransitCorridors(bucketType, source, areaType, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get transit corridors", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetDeepEngagementAreas handles GET /api/v1/stats/spatial-utilization/deep-engagement
 func (h *StatsHandler) GetDeepEngagementAreas(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetDeepEngagementAreas(bucketType, areaType, limit)
+	results, total, err := h.statsService.GetDeepEngagementAreas(bucketType, source, areaType, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get deep engagement areas", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetDensityGrids handles GET /api/v1/stats/density
 func (h *StatsHandler) GetDensityGrids(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	densityLevel := c.Query("level")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetDensityGrids(bucketType, densityLevel, limit)
+	results, total, err := h.statsService.GetDensityGrids(bucketType, source, densityLevel, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get density grids", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetCoreAreas handles GET /api/v1/stats/density/core
 func (h *StatsHandler) GetCoreAreas(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetCoreAreas(bucketType, limit)
+	results, total, err := h.statsService.GetCoreAreas(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get core areas", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetRareVisits handles GET /api/v1/stats/density/rare
 func (h *StatsHandler) GetRareVisits(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetRareVisits(bucketType, limit)
+	results, total, err := h.statsService.GetRareVisits(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get rare visits", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetDensityClusters handles GET /api/v1/stats/density/clusters
 func (h *StatsHandler) GetDensityClusters(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 20, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetDensityClusters(bucketType, limit)
+	results, total, err := h.statsService.GetDensityClusters(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get density clusters", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetAltitudeStats handles GET /api/v1/stats/altitude
 func (h *StatsHandler) GetAltitudeStats(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
 	areaKey := c.Query("area_key")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetAltitudeStats(bucketType, areaType, areaKey, limit)
+	results, total, err := h.statsService.GetAltitudeStats(bucketType, source, areaType, areaKey, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get altitude stats", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetHighestAltitudeSpans handles GET /api/v1/stats/altitude/highest-spans
 func (h *StatsHandler) GetHighestAltitudeSpans(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetHighestAltitudeSpans(bucketType, limit)
+	results, total, err := h.statsService.GetHighestAltitudeSpans(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get highest altitude spans", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetHighestVerticalIntensity handles GET /api/v1/stats/altitude/highest-intensity
 func (h *StatsHandler) GetHighestVerticalIntensity(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetHighestVerticalIntensity(bucketType, limit)
+	results, total, err := h.statsService.GetHighestVerticalIntensity(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get highest vertical intensity", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetTimeSpaceCompression handles GET /api/v1/stats/time-space-compression
 func (h *StatsHandler) GetTimeSpaceCompression(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
+	source := c.DefaultQuery("source", "all")
 	areaType := c.Query("area_type")
 	areaKey := c.Query("area_key")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetTimeSpaceCompression(bucketType, areaType, areaKey, limit)
+	results, total, err := h.statsService.GetTimeSpaceCompression(bucketType, source, areaType, areaKey, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get time-space compression", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetHighestMovementIntensity handles GET /api/v1/stats/time-space-compression/highest-intensity
 func (h *StatsHandler) GetHighestMovementIntensity(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetHighestMovementIntensity(bucketType, limit)
+	results, total, err := h.statsService.GetHighestMovementIntensity(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get highest movement intensity", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetBurstPeriods handles GET /api/v1/stats/time-space-compression/burst-periods
 func (h *StatsHandler) GetBurstPeriods(c *gin.Context) {
 	bucketType := c.DefaultQuery("bucket", "all")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 10, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetBurstPeriods(bucketType, limit)
+	results, total, err := h.statsService.GetBurstPeriods(bucketType, source, params.QueryOptions)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		listError(c, "Failed to get burst periods", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
+}
+
+// GetModeBreakdown handles GET /api/v1/stats/mode-breakdown
+// bucket is year (default), month or all
+func (h *StatsHandler) GetModeBreakdown(c *gin.Context) {
+	bucketType := c.DefaultQuery("bucket", "year")
+	if bucketType != "year" && bucketType != "month" && bucketType != "all" {
+		response.BadRequest(c, "bucket must be year, month or all")
+		return
+	}
+	bucketKey := c.Query("bucket_key")
+	source := c.DefaultQuery("source", "all")
+	mode := c.Query("mode")
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetModeBreakdown(bucketType, bucketKey, source, mode, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get mode breakdown", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// GetDaypartStats handles GET /api/v1/stats/dayparts
+// bucket is all (default), year or month; daypart is MORNING, AFTERNOON, EVENING or NIGHT
+func (h *StatsHandler) GetDaypartStats(c *gin.Context) {
+	bucketType := c.DefaultQuery("bucket", "all")
+	if bucketType != "year" && bucketType != "month" && bucketType != "all" {
+		response.BadRequest(c, "bucket must be all, year or month")
+		return
+	}
+	daypart := strings.ToUpper(c.Query("daypart"))
+	switch daypart {
+	case "", "MORNING", "AFTERNOON", "EVENING", "NIGHT":
+	default:
+		response.BadRequest(c, "daypart must be MORNING, AFTERNOON, EVENING or NIGHT")
+		return
+	}
+	bucketKey := c.Query("bucket_key")
+	source := c.DefaultQuery("source", "all")
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetDaypartStats(bucketType, bucketKey, source, daypart, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get daypart stats", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// GetDayTypeStats handles GET /api/v1/stats/day-types
+// bucket is all (default) or year; day_type is WORKDAY, WEEKEND or HOLIDAY
+func (h *StatsHandler) GetDayTypeStats(c *gin.Context) {
+	bucketType := c.DefaultQuery("bucket", "all")
+	if bucketType != "all" && bucketType != "year" {
+		response.BadRequest(c, "bucket must be all or year")
+		return
+	}
+	dayType := strings.ToUpper(c.Query("day_type"))
+	switch dayType {
+	case "", "WORKDAY", "WEEKEND", "HOLIDAY":
+	default:
+		response.BadRequest(c, "day_type must be WORKDAY, WEEKEND or HOLIDAY")
+		return
+	}
+	bucketKey := c.Query("bucket_key")
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetDayTypeStats(bucketType, bucketKey, dayType, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get day type stats", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// CompareDayTypes handles GET /api/v1/stats/day-types/compare
+// year selects a single year; all years are compared without it
+func (h *StatsHandler) CompareDayTypes(c *gin.Context) {
+	bucketKey := "all"
+	if year := c.Query("year"); year != "" {
+		if y, err := strconv.Atoi(year); err != nil || y < 1 || y > 9999 {
+			response.BadRequest(c, "year must be a number")
+			return
+		}
+		bucketKey = year
+	}
+
+	result, err := h.statsService.CompareDayTypes(bucketKey)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to compare day types", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetAnomalousDays handles GET /api/v1/stats/anomalies
+// from/to bound the dates; category, reason and min_score narrow the days
+func (h *StatsHandler) GetAnomalousDays(c *gin.Context) {
+	var filter models.AnomalousDayFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if !validateDateRange(c, filter.From, filter.To, 0) {
+		return
+	}
+	filter.Category = strings.ToUpper(filter.Category)
+	switch filter.Category {
+	case "", "TRAVEL_DAY", "UNUSUAL_ROUTINE", "QUIET_DAY":
+	default:
+		response.BadRequest(c, "category must be TRAVEL_DAY, UNUSUAL_ROUTINE or QUIET_DAY")
+		return
+	}
+	filter.Reason = strings.ToUpper(filter.Reason)
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetAnomalousDays(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get anomalous days", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// GetExplorationCurve handles GET /api/v1/stats/exploration
+// from/to (YYYY-MM) bound the months; the whole history is returned without them
+func (h *StatsHandler) GetExplorationCurve(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	for _, month := range []string{from, to} {
+		if month == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01", month); err != nil {
+			response.BadRequest(c, "from and to must be months in YYYY-MM format")
+			return
+		}
+	}
+	if from != "" && to != "" && from > to {
+		response.BadRequest(c, "from must not be after to")
+		return
+	}
+
+	result, err := h.statsService.GetExplorationCurve(from, to)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get exploration curve", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetDiscoveryStreaks handles GET /api/v1/stats/exploration/streaks
+// from/to (YYYY-MM-DD) keep the streaks overlapping them
+func (h *StatsHandler) GetDiscoveryStreaks(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if !validateDateRange(c, from, to, 0) {
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetDiscoveryStreaks(from, to, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get discovery streaks", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// GetFirstVisits handles GET /api/v1/stats/first-visits
+// level is city (default) or county; from/to (YYYY-MM-DD) bound the first visits
+func (h *StatsHandler) GetFirstVisits(c *gin.Context) {
+	level := strings.ToUpper(c.DefaultQuery("level", "city"))
+	if level != "CITY" && level != "COUNTY" {
+		response.BadRequest(c, "level must be city or county")
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if !validateDateRange(c, from, to, 0) {
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	results, total, err := h.statsService.GetFirstVisits(level, from, to, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get first visits", err)
+		return
+	}
+
+	respondList(c, results, total, params)
+}
+
+// GetPersonalRecords handles GET /api/v1/stats/records
+func (h *StatsHandler) GetPersonalRecords(c *gin.Context) {
+	records, err := h.statsService.GetPersonalRecords()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get personal records", err)
+		return
+	}
+
+	response.Success(c, records)
+}
+
+// CompareBuckets handles GET /api/v1/stats/compare
+// bucketA and bucketB are both years (YYYY) or both months (YYYY-MM)
+func (h *StatsHandler) CompareBuckets(c *gin.Context) {
+	bucketA, bucketB := c.Query("bucketA"), c.Query("bucketB")
+	typeA, typeB := compareBucketType(bucketA), compareBucketType(bucketB)
+	if typeA == "" || typeB == "" {
+		response.BadRequest(c, "bucketA and bucketB must be YYYY or YYYY-MM")
+		return
+	}
+	if typeA != typeB {
+		response.BadRequest(c, "bucketA and bucketB must both be years or both be months")
+		return
+	}
+
+	result, err := h.statsService.CompareBuckets(typeA, bucketA, bucketB)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to compare buckets", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// compareBucketType returns "year" or "month" for a valid bucket key, "" otherwise
+func compareBucketType(key string) string {
+	if _, err := time.Parse("2006", key); err == nil {
+		return "year"
+	}
+	if _, err := time.Parse("2006-01", key); err == nil {
+		return "month"
+	}
+	return ""
 }
 
 // GetTimeSpaceSlices handles GET /api/v1/stats/time-space-slices
 func (h *StatsHandler) GetTimeSpaceSlices(c *gin.Context) {
 	sliceType := c.Query("slice_type")
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
 
-	results, err := h.statsService.GetTimeSpaceSlices(sliceType, limit)
+	results, total, err := h.statsService.GetTimeSpaceSlices(sliceType, params.QueryOptions)
 	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get time-space slices", err)
+		listError(c, "Failed to get time-space slices", err)
 		return
 	}
 
-	response.Success(c, results)
+	respondList(c, results, total, params)
 }
 
 // GetWeeklyPattern handles GET /api/v1/stats/time-space-slices/weekly-pattern
@@ -721,19 +1258,30 @@ func (h *StatsHandler) GetHourlyPattern(c *gin.Context) {
 }
 
 // GetSpatialComplexity handles GET /api/v1/stats/spatial-complexity
+// period is day (default), month or all; from/to (YYYY-MM-DD) bound the days,
+// or the months they fall in
 func (h *StatsHandler) GetSpatialComplexity(c *gin.Context) {
-	result, err := h.statsService.GetSpatialComplexity()
-	if err != nil {
-		response.Error(c, http.StatusInternalServerError, "Failed to get spatial complexity", err)
+	period := strings.ToLower(c.DefaultQuery("period", "day"))
+	if period != "day" && period != "month" && period != "all" {
+		response.BadRequest(c, "period must be day, month or all")
+		return
+	}
+	from, to := c.Query("from"), c.Query("to")
+	if !validateDateRange(c, from, to, 0) {
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
 		return
 	}
 
-	if result == nil {
-		response.Error(c, http.StatusNotFound, "No spatial complexity data found", nil)
+	results, total, err := h.statsService.GetSpatialComplexity(period, from, to, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get spatial complexity", err)
 		return
 	}
 
-	response.Success(c, result)
+	respondList(c, results, total, params)
 }
 
 // GetRoadOverlapSummary handles GET /api/v1/stats/road-overlap
@@ -746,3 +1294,79 @@ func (h *StatsHandler) GetRoadOverlapSummary(c *gin.Context) {
 
 	response.Success(c, result)
 }
+
+// GetRailLineStats handles GET /api/v1/stats/rail-lines
+// year selects a single year; all years are summed without it
+func (h *StatsHandler) GetRailLineStats(c *gin.Context) {
+	year := 0
+	if s := c.Query("year"); s != "" {
+		y, err := strconv.Atoi(s)
+		if err != nil || y < 1 || y > 9999 {
+			response.BadRequest(c, "year must be a number")
+			return
+		}
+		year = y
+	}
+
+	result, err := h.statsService.GetRailLineStats(year)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get rail line stats", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetCommuteStats handles GET /api/v1/stats/commute
+func (h *StatsHandler) GetCommuteStats(c *gin.Context) {
+	direction := c.Query("direction")
+	if direction != "" && direction != "HOME_TO_WORK" && direction != "WORK_TO_HOME" {
+		response.BadRequest(c, "direction must be HOME_TO_WORK or WORK_TO_HOME")
+		return
+	}
+
+	result, err := h.statsService.GetCommuteStats(direction)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get commute stats", err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetRouteClusters handles GET /api/v1/stats/routes
+func (h *StatsHandler) GetRouteClusters(c *gin.Context) {
+	var filter models.RouteClusterFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
+
+	routes, total, err := h.statsService.GetRouteClusters(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get frequent routes", err)
+		return
+	}
+
+	respondList(c, routes, total, params)
+}
+
+// GetHealthCorrelation handles GET /api/v1/stats/health-correlation?from=&to=
+func (h *StatsHandler) GetHealthCorrelation(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+	if !validateDateRange(c, from, to, service.MaxHealthCorrelationDays) {
+		return
+	}
+
+	result, err := h.statsService.GetHealthCorrelation(from, to)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get health correlation", err)
+		return
+	}
+
+	response.Success(c, result)
+}
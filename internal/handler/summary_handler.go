@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// SummaryHandler handles HTTP requests for daily summaries
+type SummaryHandler struct {
+	service *service.SummaryService
+}
+
+// NewSummaryHandler creates a new summary handler
+func NewSummaryHandler(service *service.SummaryService) *SummaryHandler {
+	return &SummaryHandler{service: service}
+}
+
+// GetDailySummaries handles GET /api/v1/summary/daily?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *SummaryHandler) GetDailySummaries(c *gin.Context) {
+	var filter models.DailySummaryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if !validateDateRange(c, filter.From, filter.To, service.MaxTimelineDays) {
+		return
+	}
+
+	timeline, err := h.service.GetDailyTimeline(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get daily summaries", err)
+		return
+	}
+
+	response.Success(c, timeline)
+}
+
+// validateDateRange checks optional YYYY-MM-DD bounds, their order and the
+// span (unlimited when maxDays is 0), sending a bad request response and
+// returning false when invalid
+func validateDateRange(c *gin.Context, fromStr, toStr string, maxDays int) bool {
+	var from, to time.Time
+	var err error
+	if fromStr != "" {
+		if from, err = time.Parse("2006-01-02", fromStr); err != nil {
+			response.BadRequest(c, "from must be a date in YYYY-MM-DD format")
+			return false
+		}
+	}
+	if toStr != "" {
+		if to, err = time.Parse("2006-01-02", toStr); err != nil {
+			response.BadRequest(c, "to must be a date in YYYY-MM-DD format")
+			return false
+		}
+	}
+	if fromStr != "" && toStr != "" {
+		if to.Before(from) {
+			response.BadRequest(c, "from must not be after to")
+			return false
+		}
+		if days := int(to.Sub(from).Hours()/24) + 1; maxDays > 0 && days > maxDays {
+			response.BadRequest(c, fmt.Sprintf("date range must not exceed %d days", maxDays))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// ThresholdHandler handles HTTP requests for threshold profiles
+type ThresholdHandler struct {
+	service *service.ThresholdService
+}
+
+// NewThresholdHandler creates a new threshold handler
+func NewThresholdHandler(service *service.ThresholdService) *ThresholdHandler {
+	return &ThresholdHandler{service: service}
+}
+
+// ListProfiles handles GET /api/v1/admin/thresholds
+func (h *ThresholdHandler) ListProfiles(c *gin.Context) {
+	profiles, err := h.service.ListProfiles()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get threshold profiles", err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"data":  profiles,
+		"count": len(profiles),
+	})
+}
+
+// GetProfile handles GET /api/v1/admin/thresholds/:id
+func (h *ThresholdHandler) GetProfile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid profile ID", err)
+		return
+	}
+
+	profile, err := h.service.GetProfile(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get threshold profile", err)
+		return
+	}
+	if profile == nil {
+		response.NotFound(c, "Threshold profile not found")
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// GetEffectiveThresholds handles GET /api/v1/admin/thresholds/:id/effective
+// Use id 0 for the default profile
+func (h *ThresholdHandler) GetEffectiveThresholds(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid profile ID", err)
+		return
+	}
+
+	thresholds, err := h.service.GetEffectiveThresholds(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get effective thresholds", err)
+		return
+	}
+	if thresholds == nil {
+		response.NotFound(c, "Threshold profile not found")
+		return
+	}
+
+	response.Success(c, thresholds)
+}
+
+// CreateProfile handles POST /api/v1/admin/thresholds
+func (h *ThresholdHandler) CreateProfile(c *gin.Context) {
+	var req models.ThresholdProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Name == "" {
+		response.BadRequest(c, "Profile name is required")
+		return
+	}
+
+	profile, err := h.service.CreateProfile(req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to create threshold profile", err)
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// UpdateProfile handles PUT /api/v1/admin/thresholds/:id
+func (h *ThresholdHandler) UpdateProfile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid profile ID", err)
+		return
+	}
+
+	var req models.ThresholdProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	profile, err := h.service.UpdateProfile(id, req)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to update threshold profile", err)
+		return
+	}
+	if profile == nil {
+		response.NotFound(c, "Threshold profile not found")
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// SetDefaultProfile handles POST /api/v1/admin/thresholds/:id/default
+func (h *ThresholdHandler) SetDefaultProfile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid profile ID", err)
+		return
+	}
+
+	profile, err := h.service.SetDefaultProfile(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to set default threshold profile", err)
+		return
+	}
+	if profile == nil {
+		response.NotFound(c, "Threshold profile not found")
+		return
+	}
+
+	response.Success(c, profile)
+}
@@ -1,6 +1,10 @@
 package handler
 
 import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -22,7 +26,13 @@ func NewTrackHandler(trackService *service.TrackService) *TrackHandler {
 }
 
 // GetTrackPoints handles GET /api/v1/tracks/points
+// Uses cursor pagination when after_id or limit is given, otherwise page/pageSize
 func (h *TrackHandler) GetTrackPoints(c *gin.Context) {
+	if c.Query("after_id") != "" || c.Query("limit") != "" {
+		h.streamTrackPoints(c)
+		return
+	}
+
 	var filter models.TrackPointFilter
 
 	// Parse query parameters
@@ -83,3 +93,84 @@ func (h *TrackHandler) GetUngeocodedPoints(c *gin.Context) {
 		"count": len(points),
 	})
 }
+
+// streamTrackPoints handles GET /api/v1/tracks/points?after_id=&limit=&fields=
+// Rows are written to the response as they are read instead of being buffered
+func (h *TrackHandler) streamTrackPoints(c *gin.Context) {
+	var filter models.TrackPointCursorFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.BadRequest(c, "Invalid query parameters")
+		return
+	}
+
+	fields, err := h.trackService.ResolveTrackPointFields(&filter)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid fields parameter", err)
+		return
+	}
+
+	// Encode field names once, they prefix every value
+	keys := make([][]byte, len(fields))
+	for i, field := range fields {
+		keys[i], _ = json.Marshal(field)
+	}
+
+	// Fetch one extra row to know whether another page exists
+	pageSize := filter.Limit
+	filter.Limit++
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := bufio.NewWriterSize(c.Writer, 32*1024)
+	w.WriteString(`{"code":0,"message":"success","data":{"data":[`)
+
+	count := 0
+	hasMore := false
+	var lastID int64
+	err = h.trackService.StreamTrackPoints(c.Request.Context(), filter, fields, func(values []interface{}) error {
+		if count == pageSize {
+			hasMore = true
+			return nil
+		}
+		if count > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.Write(keys[i])
+			w.WriteByte(':')
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			w.Write(b)
+		}
+		w.WriteByte('}')
+
+		if id, ok := values[0].(int64); ok {
+			lastID = id
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		// Headers are already sent, so the error can only be logged
+		log.Printf("Failed to stream track points: %v", err)
+		w.Flush()
+		return
+	}
+
+	meta, _ := json.Marshal(gin.H{
+		"count":         count,
+		"limit":         pageSize,
+		"has_more":      hasMore,
+		"next_after_id": lastID,
+	})
+	w.WriteString(`],`)
+	w.Write(meta[1:]) // merge pagination fields into the enclosing data object
+	w.WriteString(`}`)
+	w.Flush()
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/exporter"
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/service"
 	"github.com/jengzang/records-backend-go/pkg/response"
@@ -20,7 +24,7 @@ func NewTripHandler(service *service.TripService) *TripHandler {
 	return &TripHandler{service: service}
 }
 
-// GetTrips handles GET /api/v1/tracks/trips
+// GetTrips handles GET /api/v1/tracks/trips and GET /api/v1/trips
 func (h *TripHandler) GetTrips(c *gin.Context) {
 	var filter models.TripFilter
 	if err := c.ShouldBindQuery(&filter); err != nil {
@@ -55,7 +59,29 @@ func (h *TripHandler) GetTrips(c *gin.Context) {
 	})
 }
 
-// GetTripByID handles GET /api/v1/tracks/trips/:id
+// GetODMatrix handles GET /api/v1/trips/od-matrix
+func (h *TripHandler) GetODMatrix(c *gin.Context) {
+	var filter models.ODMatrixFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if filter.Level != "" && filter.Level != models.ODLevelCity && filter.Level != models.ODLevelCounty {
+		response.BadRequest(c, "level must be city or county")
+		return
+	}
+
+	matrix, err := h.service.GetODMatrix(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get OD matrix", err)
+		return
+	}
+
+	response.Success(c, matrix)
+}
+
+// GetTripByID handles GET /api/v1/tracks/trips/:id and GET /api/v1/trips/:id
 func (h *TripHandler) GetTripByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -77,3 +103,129 @@ func (h *TripHandler) GetTripByID(c *gin.Context) {
 
 	response.Success(c, trip)
 }
+
+// ExportTripGPX handles GET /api/v1/trips/:id/export.gpx
+func (h *TripHandler) ExportTripGPX(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid trip ID", err)
+		return
+	}
+
+	route, err := h.service.GetTripRoute(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get trip route", err)
+		return
+	}
+
+	if route == nil {
+		response.Error(c, http.StatusNotFound, "Trip not found", nil)
+		return
+	}
+
+	if len(route.Segments) == 0 {
+		response.Error(c, http.StatusNotFound, "Trip has no track points", nil)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := exporter.WriteTripGPX(&buf, route); err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to export trip", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="trip_%d.gpx"`, id))
+	c.Data(http.StatusOK, "application/gpx+xml", buf.Bytes())
+}
+
+// SplitTrip handles POST /api/v1/trips/:id/split
+func (h *TripHandler) SplitTrip(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid trip ID", err)
+		return
+	}
+
+	var req models.TripSplitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	result, err := h.service.SplitTrip(id, req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTripEdit) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to split trip", err)
+		return
+	}
+
+	if result == nil {
+		response.NotFound(c, "Trip not found")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// MergeTrips handles POST /api/v1/trips/merge
+func (h *TripHandler) MergeTrips(c *gin.Context) {
+	var req models.TripMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request body")
+		return
+	}
+
+	result, err := h.service.MergeTrips(req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTripEdit) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to merge trips", err)
+		return
+	}
+
+	if result == nil {
+		response.NotFound(c, "Trip not found")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetTripEdits handles GET /api/v1/trips/edits
+func (h *TripHandler) GetTripEdits(c *gin.Context) {
+	edits, err := h.service.GetTripEdits()
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get trip edits", err)
+		return
+	}
+
+	response.Success(c, edits)
+}
+
+// DeleteTripEdit handles DELETE /api/v1/trips/edits/:id
+func (h *TripHandler) DeleteTripEdit(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid trip edit ID", err)
+		return
+	}
+
+	result, err := h.service.DeleteTripEdit(id)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to delete trip edit", err)
+		return
+	}
+
+	if result == nil {
+		response.NotFound(c, "Trip edit not found")
+		return
+	}
+
+	response.Success(c, result)
+}
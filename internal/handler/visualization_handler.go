@@ -50,6 +50,48 @@ func (h *VisualizationHandler) GetRenderingMetadata(c *gin.Context) {
 	})
 }
 
+// GetSegmentPolylines handles GET /api/v1/viz/polylines?zoom=&bbox=
+func (h *VisualizationHandler) GetSegmentPolylines(c *gin.Context) {
+	var filter models.PolylineFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	if c.Query("zoom") == "" {
+		filter.Zoom = 10
+	}
+	if filter.Zoom < 0 || filter.Zoom > 20 {
+		response.Error(c, http.StatusBadRequest, "Invalid zoom. Must be between 0 and 20", nil)
+		return
+	}
+	if filter.Format == "" {
+		filter.Format = models.PolylineFormatEncoded
+	}
+	if filter.Format != models.PolylineFormatEncoded && filter.Format != models.PolylineFormatCoordinates {
+		response.Error(c, http.StatusBadRequest, "Invalid format. Must be one of: encoded, coordinates", nil)
+		return
+	}
+
+	// Default to the whole world if bbox is not specified
+	filter.MinLon, filter.MinLat, filter.MaxLon, filter.MaxLat = -180, -90, 180, 90
+	if filter.BBox != "" {
+		var ok bool
+		filter.MinLon, filter.MinLat, filter.MaxLon, filter.MaxLat, ok = parseBBox(c, filter.BBox)
+		if !ok {
+			return
+		}
+	}
+
+	polylines, err := h.service.GetSegmentPolylines(filter)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get segment polylines", err)
+		return
+	}
+
+	response.Success(c, polylines)
+}
+
 // GetTimeSliceData handles GET /api/v1/viz/time-slices
 func (h *VisualizationHandler) GetTimeSliceData(c *gin.Context) {
 	startTimeStr := c.Query("startTime")
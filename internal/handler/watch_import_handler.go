@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// WatchImportHandler handles HTTP requests for watch-folder imports
+type WatchImportHandler struct {
+	service *service.WatchImportService
+}
+
+// NewWatchImportHandler creates a new watch import handler
+func NewWatchImportHandler(service *service.WatchImportService) *WatchImportHandler {
+	return &WatchImportHandler{service: service}
+}
+
+// GetStatus handles GET /api/v1/admin/import-watch
+// enabled is false when IMPORT_WATCH_DIR is not set
+func (h *WatchImportHandler) GetStatus(c *gin.Context) {
+	response.Success(c, h.service.GetStatus())
+}
+
+// ListFiles handles GET /api/v1/admin/import-watch/files
+// status narrows the files; the latest come first
+func (h *WatchImportHandler) ListFiles(c *gin.Context) {
+	var filter models.WatchImportFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	switch filter.Status {
+	case "", models.WatchImportImported, models.WatchImportDuplicate, models.WatchImportFailed:
+	default:
+		response.BadRequest(c, "status must be imported, duplicate or failed")
+		return
+	}
+	params, ok := bindListParams(c, 50, "")
+	if !ok {
+		return
+	}
+
+	imports, total, err := h.service.GetImports(filter, params.QueryOptions)
+	if err != nil {
+		listError(c, "Failed to get watch imports", err)
+		return
+	}
+
+	respondList(c, imports, total, params)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/service"
+	"github.com/jengzang/records-backend-go/pkg/response"
+)
+
+// WeatherHandler handles HTTP requests for the weather of visited days and trips
+type WeatherHandler struct {
+	service *service.WeatherService
+}
+
+// NewWeatherHandler creates a new weather handler
+func NewWeatherHandler(service *service.WeatherService) *WeatherHandler {
+	return &WeatherHandler{service: service}
+}
+
+// GetWeatherSummary handles GET /api/v1/stats/weather
+// Returns rain days, temperature extremes and the distance of each mode
+// travelled in the rain, for year or all years without it.
+func (h *WeatherHandler) GetWeatherSummary(c *gin.Context) {
+	year := 0
+	if s := c.Query("year"); s != "" {
+		y, err := strconv.Atoi(s)
+		if err != nil || y < 1 || y > 9999 {
+			response.BadRequest(c, "year must be a number")
+			return
+		}
+		year = y
+	}
+
+	summary, err := h.service.GetSummary(year)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to get weather stats", err)
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// ListWeatherDays handles GET /api/v1/stats/weather/days
+func (h *WeatherHandler) ListWeatherDays(c *gin.Context) {
+	var filter models.WeatherDayFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	params, ok := bindListParams(c, 100, "")
+	if !ok {
+		return
+	}
+
+	days, total, err := h.service.ListDays(filter, params.QueryOptions)
+	if errors.Is(err, models.ErrInvalidWeatherFilter) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if err != nil {
+		listError(c, "Failed to get weather days", err)
+		return
+	}
+
+	respondList(c, days, total, params)
+}
@@ -0,0 +1,303 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Apple Health record types read by ParseAppleHealth
+const (
+	hkStepCount     = "HKQuantityTypeIdentifierStepCount"
+	hkHeartRate     = "HKQuantityTypeIdentifierHeartRate"
+	hkSleepAnalysis = "HKCategoryTypeIdentifierSleepAnalysis"
+)
+
+// appleHealthTimeLayout is the date format of export.xml ("2024-01-02 08:30:00 +0800")
+const appleHealthTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// sleepStages maps HKCategoryValueSleepAnalysis values to stored stages
+var sleepStages = map[string]string{
+	"HKCategoryValueSleepAnalysisInBed":             models.SleepStageInBed,
+	"HKCategoryValueSleepAnalysisAsleep":            models.SleepStageAsleep,
+	"HKCategoryValueSleepAnalysisAsleepUnspecified": models.SleepStageAsleep,
+	"HKCategoryValueSleepAnalysisAsleepCore":        models.SleepStageCore,
+	"HKCategoryValueSleepAnalysisAsleepDeep":        models.SleepStageDeep,
+	"HKCategoryValueSleepAnalysisAsleepREM":         models.SleepStageREM,
+	"HKCategoryValueSleepAnalysisAwake":             models.SleepStageAwake,
+}
+
+// HealthBatch is a set of parsed health records handed to the caller for insertion
+type HealthBatch struct {
+	Steps      []models.HealthStepSample
+	HeartRates []models.HeartRateSample
+	Sleep      []models.SleepSample
+	Workouts   []models.Workout
+}
+
+// Len returns the number of records in the batch
+func (b *HealthBatch) Len() int {
+	return len(b.Steps) + len(b.HeartRates) + len(b.Sleep) + len(b.Workouts)
+}
+
+// AppleHealthSummary counts the records seen by ParseAppleHealth
+type AppleHealthSummary struct {
+	Parsed  int64 // Records of supported types
+	Ignored int64 // Records of other types
+	Invalid int64 // Supported records with unparsable dates or values
+}
+
+// ParseAppleHealth streams an Apple Health export.xml and hands batches of
+// steps, heart rate, sleep and workout records to flush
+// The document is read token by token so multi-gigabyte exports never have to
+// fit in memory. flush is called whenever batchSize records have accumulated
+// and once more at the end; an error from flush aborts the parse.
+func ParseAppleHealth(r io.Reader, batchSize int, flush func(*HealthBatch) error) (*AppleHealthSummary, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	decoder := xml.NewDecoder(r)
+	summary := &AppleHealthSummary{}
+	batch := &HealthBatch{}
+	sawRoot := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("invalid Apple Health export: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "HealthData":
+			sawRoot = true
+		case "Record":
+			appendHealthRecord(batch, start, summary)
+			if err := decoder.Skip(); err != nil {
+				return summary, fmt.Errorf("invalid Apple Health export: %w", err)
+			}
+		case "Workout":
+			var w appleWorkout
+			if err := decoder.DecodeElement(&w, &start); err != nil {
+				return summary, fmt.Errorf("invalid Apple Health workout: %w", err)
+			}
+			workout, err := w.toWorkout()
+			if err != nil {
+				summary.Invalid++
+				continue
+			}
+			summary.Parsed++
+			batch.Workouts = append(batch.Workouts, workout)
+		}
+
+		if batch.Len() >= batchSize {
+			if err := flush(batch); err != nil {
+				return summary, err
+			}
+			batch = &HealthBatch{}
+		}
+	}
+
+	if !sawRoot {
+		return summary, fmt.Errorf("not an Apple Health export: missing HealthData element")
+	}
+	if batch.Len() > 0 {
+		if err := flush(batch); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
+
+// appendHealthRecord adds a Record element of a supported type to the batch
+func appendHealthRecord(batch *HealthBatch, start xml.StartElement, summary *AppleHealthSummary) {
+	attrs := make(map[string]string, len(start.Attr))
+	for _, a := range start.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+
+	recordType := attrs["type"]
+	if recordType != hkStepCount && recordType != hkHeartRate && recordType != hkSleepAnalysis {
+		summary.Ignored++
+		return
+	}
+
+	startTime, err1 := time.Parse(appleHealthTimeLayout, attrs["startDate"])
+	endTime, err2 := time.Parse(appleHealthTimeLayout, attrs["endDate"])
+	if err1 != nil || err2 != nil {
+		summary.Invalid++
+		return
+	}
+	source := attrs["sourceName"]
+
+	switch recordType {
+	case hkStepCount:
+		count, err := strconv.ParseFloat(attrs["value"], 64)
+		if err != nil {
+			summary.Invalid++
+			return
+		}
+		batch.Steps = append(batch.Steps, models.HealthStepSample{
+			SourceName: source,
+			StartTime:  startTime.Unix(),
+			EndTime:    endTime.Unix(),
+			Date:       localDate(startTime),
+			Count:      count,
+		})
+	case hkHeartRate:
+		bpm, err := strconv.ParseFloat(attrs["value"], 64)
+		if err != nil {
+			summary.Invalid++
+			return
+		}
+		batch.HeartRates = append(batch.HeartRates, models.HeartRateSample{
+			SourceName: source,
+			Time:       startTime.Unix(),
+			Date:       localDate(startTime),
+			BPM:        bpm,
+		})
+	case hkSleepAnalysis:
+		stage, ok := sleepStages[attrs["value"]]
+		if !ok {
+			summary.Invalid++
+			return
+		}
+		batch.Sleep = append(batch.Sleep, models.SleepSample{
+			SourceName: source,
+			StartTime:  startTime.Unix(),
+			EndTime:    endTime.Unix(),
+			Date:       localDate(endTime),
+			Stage:      stage,
+		})
+	}
+
+	summary.Parsed++
+}
+
+// appleWorkout is a Workout element; newer exports move distance and energy
+// into WorkoutStatistics children
+type appleWorkout struct {
+	ActivityType      string  `xml:"workoutActivityType,attr"`
+	SourceName        string  `xml:"sourceName,attr"`
+	Duration          float64 `xml:"duration,attr"`
+	DurationUnit      string  `xml:"durationUnit,attr"`
+	TotalDistance     string  `xml:"totalDistance,attr"`
+	TotalDistanceUnit string  `xml:"totalDistanceUnit,attr"`
+	TotalEnergy       string  `xml:"totalEnergyBurned,attr"`
+	TotalEnergyUnit   string  `xml:"totalEnergyBurnedUnit,attr"`
+	StartDate         string  `xml:"startDate,attr"`
+	EndDate           string  `xml:"endDate,attr"`
+	Statistics        []struct {
+		Type string `xml:"type,attr"`
+		Sum  string `xml:"sum,attr"`
+		Unit string `xml:"unit,attr"`
+	} `xml:"WorkoutStatistics"`
+}
+
+func (w *appleWorkout) toWorkout() (models.Workout, error) {
+	start, err := time.Parse(appleHealthTimeLayout, w.StartDate)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	end, err := time.Parse(appleHealthTimeLayout, w.EndDate)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	workout := models.Workout{
+		ActivityType: strings.TrimPrefix(w.ActivityType, "HKWorkoutActivityType"),
+		SourceName:   w.SourceName,
+		StartTime:    start.Unix(),
+		EndTime:      end.Unix(),
+		Date:         localDate(start),
+		DurationS:    int64(end.Sub(start).Seconds()),
+	}
+	if w.Duration > 0 {
+		workout.DurationS = int64(w.Duration * durationUnitSeconds(w.DurationUnit))
+	}
+
+	workout.DistanceM = healthQuantity(w.TotalDistance, w.TotalDistanceUnit, distanceUnitMeters)
+	workout.EnergyKcal = healthQuantity(w.TotalEnergy, w.TotalEnergyUnit, energyUnitKcal)
+	for _, s := range w.Statistics {
+		switch {
+		case workout.DistanceM == nil && strings.HasPrefix(s.Type, "HKQuantityTypeIdentifierDistance"):
+			workout.DistanceM = healthQuantity(s.Sum, s.Unit, distanceUnitMeters)
+		case workout.EnergyKcal == nil && s.Type == "HKQuantityTypeIdentifierActiveEnergyBurned":
+			workout.EnergyKcal = healthQuantity(s.Sum, s.Unit, energyUnitKcal)
+		}
+	}
+
+	return workout, nil
+}
+
+// healthQuantity converts a value in unit with factor, returning nil for missing or unknown values
+func healthQuantity(value, unit string, factor func(string) float64) *float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	f := factor(unit)
+	if f == 0 {
+		return nil
+	}
+	v *= f
+	return &v
+}
+
+func durationUnitSeconds(unit string) float64 {
+	switch unit {
+	case "s", "sec":
+		return 1
+	case "hr", "h":
+		return 3600
+	default: // min
+		return 60
+	}
+}
+
+func distanceUnitMeters(unit string) float64 {
+	switch unit {
+	case "m":
+		return 1
+	case "km":
+		return 1000
+	case "mi":
+		return 1609.344
+	case "yd":
+		return 0.9144
+	case "ft":
+		return 0.3048
+	default:
+		return 0
+	}
+}
+
+func energyUnitKcal(unit string) float64 {
+	switch unit {
+	case "kcal", "Cal":
+		return 1
+	case "kJ":
+		return 1 / 4.184
+	default:
+		return 0
+	}
+}
+
+// localDate returns the local YYYY-MM-DD of t
+func localDate(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
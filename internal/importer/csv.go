@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvTable is a CSV document with a header row
+// Header names are matched case-insensitively through alias lists so that
+// exports of different apps (and languages) map onto the same fields.
+type csvTable struct {
+	header map[string]int
+	rows   [][]string
+}
+
+// readCSV reads a CSV document whose first row is the header
+// A UTF-8 byte order mark is stripped; other encodings must be converted first.
+func readCSV(r io.Reader) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV document: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV document is empty")
+	}
+
+	t := &csvTable{header: make(map[string]int)}
+	for i, name := range records[0] {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		key := normalizeHeader(name)
+		if _, dup := t.header[key]; !dup {
+			t.header[key] = i
+		}
+	}
+	t.rows = records[1:]
+
+	return t, nil
+}
+
+func normalizeHeader(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// column returns the index of the first header matching one of the aliases, or -1
+func (t *csvTable) column(aliases ...string) int {
+	for _, alias := range aliases {
+		if i, ok := t.header[normalizeHeader(alias)]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// has reports whether any alias is present in the header
+func (t *csvTable) has(aliases ...string) bool {
+	return t.column(aliases...) >= 0
+}
+
+// field returns the trimmed cell of row at column index col ("" when absent)
+func field(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+// dateTimeLayouts are tried in order for combined date and time cells
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/1/2 15:04",
+	"2006年01月02日 15:04",
+	"2006年1月2日 15:04",
+}
+
+// dateLayouts are tried in order for date-only cells
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"2006/1/2",
+	"2006.01.02",
+	"2006年01月02日",
+	"2006年1月2日",
+	"01/02/2006",
+}
+
+// parseLocalDateTime parses a date and time in local time unless the value carries an offset
+func parseLocalDateTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date time: %q", value)
+}
+
+// parseLocalDate parses a date-only value as local midnight
+func parseLocalDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %q", value)
+}
+
+// combineDateTime resolves a timestamp from a date cell and a time cell
+// The time cell may hold a full date time, in which case the date cell is ignored.
+func combineDateTime(date, clock string) (time.Time, error) {
+	if clock != "" {
+		if t, err := parseLocalDateTime(clock); err == nil {
+			return t, nil
+		}
+	}
+
+	day, err := parseLocalDate(date)
+	if err != nil {
+		if clock == "" {
+			return parseLocalDateTime(date)
+		}
+		return time.Time{}, err
+	}
+	if clock == "" {
+		return day, nil
+	}
+
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if c, err := time.Parse(layout, clock); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), c.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", clock)
+}
@@ -0,0 +1,87 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// FormatGPSLogger is the source recorded on points ingested from GPSLogger
+const FormatGPSLogger = "gpslogger"
+
+// Parameter aliases of a GPSLogger custom URL; the names are chosen by the
+// user when configuring the URL, so the placeholder names and common
+// spellings are all accepted
+var (
+	gpsloggerLatParams      = []string{"lat", "latitude"}
+	gpsloggerLonParams      = []string{"lon", "lng", "longitude"}
+	gpsloggerTimeParams     = []string{"time", "timestamp", "tst"}
+	gpsloggerAltParams      = []string{"alt", "altitude"}
+	gpsloggerAccParams      = []string{"acc", "accuracy"}
+	gpsloggerSpeedParams    = []string{"spd", "speed"}   // m/s
+	gpsloggerDirParams      = []string{"dir", "bearing"} // Degrees
+	gpsloggerBattParams     = []string{"batt", "battery"}
+	gpsloggerChargingParams = []string{"ischarging", "charging"}
+	gpsloggerActParams      = []string{"act", "activity"}
+	gpsloggerDeviceParams   = []string{"device", "aid", "ser"}
+)
+
+// ParseGPSLogger parses the parameters of a GPSLogger for Android custom URL
+// request into a validated track point
+// The URL is configured in the app with placeholders, e.g.
+// ?lat=%LAT&lon=%LON&time=%TIME&alt=%ALT&acc=%ACC&spd=%SPD&dir=%DIR&batt=%BATT&ischarging=%ISCHARGING&act=%ACT&aid=%AID
+// Only lat, lon and time (ISO 8601, or Unix seconds via %TIMESTAMP) are
+// required. The device of the point is the device, aid or ser parameter.
+func ParseGPSLogger(values url.Values, now time.Time) (models.TrackPoint, error) {
+	get := func(names []string) string {
+		for _, name := range names {
+			if v := strings.TrimSpace(values.Get(name)); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	number := func(names []string) *float64 {
+		v, err := strconv.ParseFloat(get(names), 64)
+		if err != nil {
+			return nil
+		}
+		return &v
+	}
+
+	lat, lon := number(gpsloggerLatParams), number(gpsloggerLonParams)
+	if lat == nil || lon == nil {
+		return models.TrackPoint{}, fmt.Errorf("GPSLogger request without lat/lon")
+	}
+	ts, err := parsePointTime("", get(gpsloggerTimeParams))
+	if err != nil {
+		return models.TrackPoint{}, fmt.Errorf("GPSLogger request: %w", err)
+	}
+	point, err := livePoint(*lat, *lon, ts, number(gpsloggerAccParams), now)
+	if err != nil {
+		return models.TrackPoint{}, fmt.Errorf("GPSLogger %w", err)
+	}
+
+	point.Source = FormatGPSLogger
+	point.SourceDevice = get(gpsloggerDeviceParams)
+	point.Altitude = firstOf(number(gpsloggerAltParams))
+	point.Speed = firstOf(number(gpsloggerSpeedParams))
+	point.Heading = firstOf(number(gpsloggerDirParams))
+	point.Motion = normalizeMotion(get(gpsloggerActParams))
+	if batt := number(gpsloggerBattParams); batt != nil {
+		point.BatteryLevel = batteryPercent(*batt, false)
+	}
+	switch strings.ToLower(get(gpsloggerChargingParams)) {
+	case "true", "1":
+		point.BatteryState = BatteryCharging
+	case "false", "0":
+		point.BatteryState = BatteryUnplugged
+	}
+
+	points := normalize([]models.TrackPoint{point})
+	return points[0], nil
+}
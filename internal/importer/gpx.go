@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// gpxFile mirrors the subset of the GPX 1.0/1.1 schema needed for import
+type gpxFile struct {
+	XMLName   xml.Name   `xml:"gpx"`
+	Tracks    []gpxTrack `xml:"trk"`
+	Routes    []gpxRoute `xml:"rte"`
+	Waypoints []gpxPoint `xml:"wpt"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxRoute struct {
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxPoint struct {
+	Lat       float64  `xml:"lat,attr"`
+	Lon       float64  `xml:"lon,attr"`
+	Elevation *float64 `xml:"ele"`
+	Time      string   `xml:"time"`
+	Speed     *float64 `xml:"speed"`  // GPX 1.0
+	Course    *float64 `xml:"course"` // GPX 1.0
+	HDOP      *float64 `xml:"hdop"`
+}
+
+// ParseGPX parses a GPX document into track points
+// Points without a timestamp are skipped since dataTime is required
+func ParseGPX(r io.Reader) ([]models.TrackPoint, error) {
+	var doc gpxFile
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GPX document: %w", err)
+	}
+
+	var raw []gpxPoint
+	for _, trk := range doc.Tracks {
+		for _, seg := range trk.Segments {
+			raw = append(raw, seg.Points...)
+		}
+	}
+	for _, rte := range doc.Routes {
+		raw = append(raw, rte.Points...)
+	}
+	raw = append(raw, doc.Waypoints...)
+
+	points := make([]models.TrackPoint, 0, len(raw))
+	for _, p := range raw {
+		if p.Time == "" {
+			continue
+		}
+		ts, err := parseTimestamp(p.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		point := models.TrackPoint{
+			DataTime:  ts,
+			Latitude:  p.Lat,
+			Longitude: p.Lon,
+		}
+		if p.Elevation != nil {
+			point.Altitude = *p.Elevation
+		}
+		if p.Speed != nil {
+			point.Speed = *p.Speed
+		}
+		if p.Course != nil {
+			point.Heading = *p.Course
+		}
+		if p.HDOP != nil {
+			// Approximate horizontal accuracy in meters (HDOP × nominal 5m UERE)
+			point.Accuracy = *p.HDOP * 5
+		}
+
+		points = append(points, point)
+	}
+
+	return points, nil
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// immichPageSize is the number of assets requested per search page
+const immichPageSize = 500
+
+// ImmichClient reads photo metadata and previews from an Immich server
+// Immich keeps the EXIF of each asset, so no file is downloaded to import it.
+type ImmichClient struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewImmichClient creates a client for the Immich server at baseURL using an API key
+func NewImmichClient(baseURL, apiKey string) *ImmichClient {
+	return &ImmichClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// ImmichAsset is a photo of an Immich library with its metadata
+type ImmichAsset struct {
+	ID       string
+	FileName string
+	Meta     PhotoMeta
+	FileSize int64
+}
+
+type immichSearchResponse struct {
+	Assets struct {
+		Items []struct {
+			ID               string `json:"id"`
+			OriginalFileName string `json:"originalFileName"`
+			FileCreatedAt    string `json:"fileCreatedAt"`
+			ExifInfo         *struct {
+				DateTimeOriginal string   `json:"dateTimeOriginal"`
+				Latitude         *float64 `json:"latitude"`
+				Longitude        *float64 `json:"longitude"`
+				Make             string   `json:"make"`
+				Model            string   `json:"model"`
+				ExifImageWidth   int      `json:"exifImageWidth"`
+				ExifImageHeight  int      `json:"exifImageHeight"`
+				FileSizeInByte   int64    `json:"fileSizeInByte"`
+			} `json:"exifInfo"`
+		} `json:"items"`
+		NextPage *string `json:"nextPage"`
+	} `json:"assets"`
+}
+
+// Assets lists the photos taken after since (all when 0), calling fn for each page
+func (c *ImmichClient) Assets(ctx context.Context, since int64, fn func([]ImmichAsset) error) error {
+	for page := 1; page > 0; {
+		query := map[string]interface{}{"page": page, "size": immichPageSize, "type": "IMAGE", "withExif": true}
+		if since > 0 {
+			query["takenAfter"] = time.Unix(since, 0).UTC().Format(time.RFC3339)
+		}
+		var body immichSearchResponse
+		if err := c.post(ctx, "/api/search/metadata", query, &body); err != nil {
+			return err
+		}
+
+		assets := make([]ImmichAsset, 0, len(body.Assets.Items))
+		for _, item := range body.Assets.Items {
+			asset := ImmichAsset{ID: item.ID, FileName: item.OriginalFileName}
+			taken := item.FileCreatedAt
+			if exif := item.ExifInfo; exif != nil {
+				if exif.DateTimeOriginal != "" {
+					taken = exif.DateTimeOriginal
+				}
+				if exif.Latitude != nil && exif.Longitude != nil {
+					asset.Meta.HasGPS, asset.Meta.Lat, asset.Meta.Lon = true, *exif.Latitude, *exif.Longitude
+				}
+				asset.Meta.Make, asset.Meta.Model = exif.Make, exif.Model
+				asset.Meta.Width, asset.Meta.Height = exif.ExifImageWidth, exif.ExifImageHeight
+				asset.FileSize = exif.FileSizeInByte
+			}
+			if t, err := time.Parse(time.RFC3339Nano, taken); err == nil {
+				asset.Meta.TakenAt = t.Unix()
+			}
+			assets = append(assets, asset)
+		}
+		if err := fn(assets); err != nil {
+			return err
+		}
+
+		page = 0
+		if next := body.Assets.NextPage; next != nil {
+			page, _ = strconv.Atoi(*next)
+		}
+	}
+	return nil
+}
+
+// Preview opens the preview image of an asset; the caller closes it
+// It returns the body, its content type and length (-1 when unknown).
+func (c *ImmichClient) Preview(ctx context.Context, assetID string) (io.ReadCloser, string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.baseURL+"/api/assets/"+assetID+"/thumbnail?size=preview", nil)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to create Immich request: %w", err)
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", 0, fmt.Errorf("failed to call Immich: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", 0, fmt.Errorf("Immich preview failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), resp.ContentLength, nil
+}
+
+// post sends a JSON request to the Immich API and decodes the response into out
+func (c *ImmichClient) post(ctx context.Context, path string, in, out interface{}) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create Immich request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Immich: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Immich %s failed with status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Immich response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Supported import formats
+const (
+	FormatGPX  = "gpx"
+	FormatKML  = "kml"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// DetectFormat determines the import format from a file name
+func DetectFormat(filename string) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+	switch ext {
+	case FormatGPX, FormatKML, FormatCSV, FormatJSON:
+		return ext, nil
+	case "geojson":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported file format: %s", filename)
+	}
+}
+
+// Parse parses a track file into track points ordered by time
+func Parse(filename string, r io.Reader) ([]models.TrackPoint, error) {
+	format, err := DetectFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []models.TrackPoint
+	switch format {
+	case FormatGPX:
+		points, err = ParseGPX(r)
+	case FormatKML:
+		points, err = ParseKML(r)
+	case FormatCSV:
+		points, err = ParseTrackCSV(r)
+	case FormatJSON:
+		points, err = ParseTrackJSON(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	return normalize(points), nil
+}
+
+// normalize sorts points by time, drops points sharing a timestamp and
+// fills the derived columns (distance, time strings) of the track point table
+func normalize(points []models.TrackPoint) []models.TrackPoint {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].DataTime < points[j].DataTime
+	})
+
+	result := make([]models.TrackPoint, 0, len(points))
+	for _, p := range points {
+		if len(result) > 0 {
+			prev := result[len(result)-1]
+			if p.DataTime == prev.DataTime {
+				continue
+			}
+			p.Distance = spatial.HaversineDistance(prev.Latitude, prev.Longitude, p.Latitude, p.Longitude)
+
+			// Derive speed (m/s) when the source file does not provide it
+			if p.Speed == 0 && p.DataTime > prev.DataTime {
+				p.Speed = p.Distance / float64(p.DataTime-prev.DataTime)
+			}
+		}
+
+		t := time.Unix(p.DataTime, 0)
+		p.TimeVisually = t.Format("2006/01/02 15:04:05.000")
+		p.Time = t.Format("20060102150405")
+
+		result = append(result, p)
+	}
+
+	return result
+}
+
+// parseTimestamp parses the timestamp formats used by GPX and KML files
+func parseTimestamp(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02T15:04:05Z0700", // Offset without a colon, as written by Overland
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Unix(), nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid timestamp: %q", value)
+}
@@ -0,0 +1,277 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Keyboard and mouse import formats
+const (
+	FormatWhatPulse     = "whatpulse"
+	FormatActivityWatch = "activitywatch"
+	FormatInputCSV      = "generic"
+)
+
+// Header aliases of the keyboard/mouse CSV columns
+var (
+	inputTimestampCols = []string{"timestamp", "datetime", "date time", "time stamp", "时间戳"}
+	inputDateCols      = []string{"date", "day", "日期"}
+	inputTimeCols      = []string{"time", "start", "start time", "时间", "开始时间"}
+	inputHourCols      = []string{"hour", "小时"}
+	inputKeyCols       = []string{"keys", "keystrokes", "key presses", "keypresses", "presses", "按键", "按键次数", "击键"}
+	inputClickCols     = []string{"clicks", "mouse clicks", "点击", "点击次数"}
+	inputScrollCols    = []string{"scrolls", "scroll", "wheel", "滚轮", "滚动"}
+)
+
+// InputActivityFile is the result of parsing a keyboard/mouse export
+type InputActivityFile struct {
+	Format     string
+	ParsedRows int                        // CSV rows or ActivityWatch events
+	Hours      []models.InputActivityHour // One record per device and local hour, ordered by time
+	Warnings   []string
+}
+
+// inputHourKey identifies one hourly bucket while merging
+type inputHourKey struct {
+	device string
+	start  int64
+}
+
+// ParseInputActivity parses a WhatPulse style CSV (hourly keys/clicks) or an
+// ActivityWatch JSON export into hourly keyboard and mouse activity
+// Counts falling into the same local hour are summed. Rows without an hour of
+// day (daily totals) are skipped with a warning because they cannot be placed
+// on the hourly heatmap. ActivityWatch events are attributed to the hour they
+// start in; only aw-watcher-input buckets are read, the device defaults to the
+// bucket hostname.
+func ParseInputActivity(r io.Reader, device string) (*InputActivityFile, error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(512)
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\ufeff")), " \t\r\n")
+	if len(head) > 0 && head[0] == '{' {
+		return parseActivityWatch(br, device)
+	}
+	return parseInputCSV(br, device)
+}
+
+// parseInputCSV reads hourly counts from a CSV export
+func parseInputCSV(r io.Reader, device string) (*InputActivityFile, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &InputActivityFile{Format: FormatInputCSV}
+	if table.has("keys") {
+		file.Format = FormatWhatPulse
+	}
+
+	timestampCol := table.column(inputTimestampCols...)
+	dateCol := table.column(inputDateCols...)
+	timeCol := table.column(inputTimeCols...)
+	hourCol := table.column(inputHourCols...)
+	keyCol := table.column(inputKeyCols...)
+	clickCol := table.column(inputClickCols...)
+	scrollCol := table.column(inputScrollCols...)
+	if timestampCol < 0 && dateCol < 0 {
+		return nil, fmt.Errorf("CSV header must contain a timestamp or date column")
+	}
+	if keyCol < 0 && clickCol < 0 {
+		return nil, fmt.Errorf("CSV header must contain a keys or clicks column")
+	}
+
+	merged := make(map[inputHourKey]*models.InputActivityHour)
+	for i, row := range table.rows {
+		line := i + 2
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+
+		t, err := inputRowTime(field(row, timestampCol), field(row, dateCol), field(row, timeCol), field(row, hourCol))
+		if err != nil {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+		file.ParsedRows++
+
+		h := mergeInputHour(merged, device, t, file.Format)
+		h.Keystrokes += parseCount(field(row, keyCol))
+		h.Clicks += parseCount(field(row, clickCol))
+		h.Scrolls += parseCount(field(row, scrollCol))
+	}
+
+	file.Hours = sortedInputHours(merged)
+	return file, nil
+}
+
+// inputRowTime resolves the local time of a CSV row
+// An hour column takes precedence over a time column; date-only rows are rejected.
+func inputRowTime(timestamp, date, clock, hour string) (time.Time, error) {
+	if timestamp != "" {
+		return parseLocalDateTime(timestamp)
+	}
+
+	if hour != "" {
+		day, err := parseLocalDate(date)
+		if err != nil {
+			return time.Time{}, err
+		}
+		h, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(hour), ":00"))
+		if err != nil || h < 0 || h > 23 {
+			return time.Time{}, fmt.Errorf("invalid hour: %q", hour)
+		}
+		return time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, time.Local), nil
+	}
+
+	if clock == "" {
+		if _, err := parseLocalDate(date); err == nil {
+			return time.Time{}, fmt.Errorf("no hour of day (daily totals are not supported)")
+		}
+	}
+	return combineDateTime(date, clock)
+}
+
+// activityWatchExport is the JSON document written by ActivityWatch's export
+// A single exported bucket (with "events" at the top level) is accepted too.
+type activityWatchExport struct {
+	Buckets map[string]activityWatchBucket `json:"buckets"`
+	activityWatchBucket
+}
+
+type activityWatchBucket struct {
+	ID       string               `json:"id"`
+	Type     string               `json:"type"`
+	Hostname string               `json:"hostname"`
+	Events   []activityWatchEvent `json:"events"`
+}
+
+type activityWatchEvent struct {
+	Timestamp string                 `json:"timestamp"`
+	Duration  float64                `json:"duration"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// parseActivityWatch reads the input watcher buckets of an ActivityWatch export
+func parseActivityWatch(r io.Reader, device string) (*InputActivityFile, error) {
+	var export activityWatchExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid ActivityWatch export: %w", err)
+	}
+
+	buckets := export.Buckets
+	if len(buckets) == 0 && len(export.Events) > 0 {
+		buckets = map[string]activityWatchBucket{export.ID: export.activityWatchBucket}
+	}
+
+	ids := make([]string, 0, len(buckets))
+	for id := range buckets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	file := &InputActivityFile{Format: FormatActivityWatch}
+	merged := make(map[inputHourKey]*models.InputActivityHour)
+	skipped := 0
+	for _, id := range ids {
+		bucket := buckets[id]
+		if !isInputBucket(bucket) {
+			skipped++
+			continue
+		}
+
+		bucketDevice := device
+		if bucketDevice == "" {
+			bucketDevice = bucket.Hostname
+		}
+
+		for i, event := range bucket.Events {
+			t, err := time.Parse(time.RFC3339Nano, event.Timestamp)
+			if err != nil {
+				file.Warnings = append(file.Warnings, fmt.Sprintf("bucket %s event %d: invalid timestamp %q", id, i, event.Timestamp))
+				continue
+			}
+			file.ParsedRows++
+
+			h := mergeInputHour(merged, bucketDevice, t.In(time.Local), file.Format)
+			h.Keystrokes += eventCount(event.Data, "presses")
+			h.Clicks += eventCount(event.Data, "clicks")
+			h.Scrolls += eventCount(event.Data, "scrollX") + eventCount(event.Data, "scrollY")
+		}
+	}
+
+	if skipped > 0 {
+		file.Warnings = append(file.Warnings, fmt.Sprintf("skipped %d buckets without keyboard/mouse input events", skipped))
+	}
+	if len(merged) == 0 && file.ParsedRows == 0 {
+		return nil, fmt.Errorf("export contains no aw-watcher-input events")
+	}
+
+	file.Hours = sortedInputHours(merged)
+	return file, nil
+}
+
+// isInputBucket reports whether a bucket holds aw-watcher-input events
+func isInputBucket(bucket activityWatchBucket) bool {
+	if bucket.Type == "os.hid.input" || strings.HasPrefix(bucket.ID, "aw-watcher-input") {
+		return true
+	}
+	for _, event := range bucket.Events {
+		if _, ok := event.Data["presses"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// eventCount reads a non-negative count from event data (negative scroll deltas count too)
+func eventCount(data map[string]interface{}, name string) int64 {
+	v, ok := data[name].(float64)
+	if !ok {
+		return 0
+	}
+	return int64(math.Abs(v))
+}
+
+// mergeInputHour returns the bucket of t's local hour, creating it if needed
+func mergeInputHour(merged map[inputHourKey]*models.InputActivityHour, device string, t time.Time, source string) *models.InputActivityHour {
+	start := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+	key := inputHourKey{device, start.Unix()}
+	if h, ok := merged[key]; ok {
+		return h
+	}
+
+	h := &models.InputActivityHour{
+		HourStart: start.Unix(),
+		Date:      start.Format("2006-01-02"),
+		Hour:      start.Hour(),
+		Weekday:   int(start.Weekday()),
+		Device:    device,
+		Source:    source,
+	}
+	merged[key] = h
+	return h
+}
+
+func sortedInputHours(merged map[inputHourKey]*models.InputActivityHour) []models.InputActivityHour {
+	hours := make([]models.InputActivityHour, 0, len(merged))
+	for _, h := range merged {
+		hours = append(hours, *h)
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if hours[i].HourStart != hours[j].HourStart {
+			return hours[i].HourStart < hours[j].HourStart
+		}
+		return hours[i].Device < hours[j].Device
+	})
+	return hours
+}
@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+	"github.com/jengzang/records-backend-go/internal/transit"
+)
+
+// Journey import formats
+const (
+	FormatAppInTheAir = "appintheair"
+	Format12306       = "12306"
+	FormatJourneyCSV  = "generic"
+)
+
+// Header aliases of the journey CSV columns
+var (
+	journeyNumberCols   = []string{"flight", "flight number", "flight_number", "flight no", "train", "train number", "train_number", "number", "车次", "航班号"}
+	journeyCarrierCols  = []string{"airline", "carrier", "operator", "航空公司"}
+	journeyDateCols     = []string{"date", "departure date", "dep date", "乘车日期", "出发日期", "日期"}
+	journeyDepartCols   = []string{"departure time", "dep time", "scheduled departure", "departure", "开车时间", "发车时间", "出发时间", "起飞时间"}
+	journeyArriveCols   = []string{"arrival time", "arr time", "scheduled arrival", "arrival", "到达时间", "到站时间", "降落时间"}
+	journeyOriginCols   = []string{"from", "origin", "departure airport", "dep airport", "出发站", "发站", "出发机场", "出发地"}
+	journeyDestCols     = []string{"to", "destination", "arrival airport", "arr airport", "到达站", "到站", "到达机场", "目的地"}
+	journeyClassCols    = []string{"class", "cabin", "cabin class", "booking class", "席别", "舱位"}
+	journeySeatCols     = []string{"seat", "seat number", "座位号", "座位"}
+	journeyTypeCols     = []string{"type", "journey_type", "类型"}
+	journeyNotesCols    = []string{"notes", "note", "comment", "备注"}
+	journeyOriginLatCol = []string{"origin_lat", "from_lat"}
+	journeyOriginLonCol = []string{"origin_lon", "from_lon"}
+	journeyDestLatCol   = []string{"dest_lat", "to_lat"}
+	journeyDestLonCol   = []string{"dest_lon", "to_lon"}
+)
+
+// iataPattern finds an airport code such as "CAN" or "Guangzhou (CAN)"
+var iataPattern = regexp.MustCompile(`(?:^|\()([A-Za-z]{3})(?:\)|$)`)
+
+// JourneyFile is the result of parsing a journey export
+type JourneyFile struct {
+	Format   string
+	Journeys []models.Journey
+	Warnings []string // Skipped rows and unknown airports / stations
+}
+
+// ParseJourneyCSV parses an App in the Air flight export, a 12306 order
+// export or a generic CSV with the same columns into journeys
+// The format is detected from the header. defaultType (FLIGHT or TRAIN) is
+// used for generic rows without a type column. Times without an offset are
+// read in local time. Rows that cannot be parsed are skipped with a warning.
+func ParseJourneyCSV(r io.Reader, defaultType string) (*JourneyFile, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &JourneyFile{Format: FormatJourneyCSV}
+	switch {
+	case table.has("车次", "席别", "乘车日期"):
+		file.Format = Format12306
+		defaultType = models.JourneyTypeTrain
+	case table.has("airline", "flight", "flight number"):
+		file.Format = FormatAppInTheAir
+		defaultType = models.JourneyTypeFlight
+	}
+
+	cols := journeyColumnsOf(table)
+	if cols.origin < 0 || cols.dest < 0 || (cols.date < 0 && cols.depart < 0) {
+		return nil, fmt.Errorf("CSV header must contain origin, destination and departure date columns")
+	}
+
+	for i, row := range table.rows {
+		line := i + 2
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+
+		j := models.Journey{
+			JourneyType:  strings.ToUpper(field(row, cols.kind)),
+			Number:       strings.ToUpper(strings.ReplaceAll(field(row, cols.number), " ", "")),
+			Carrier:      field(row, cols.carrier),
+			OriginName:   field(row, cols.origin),
+			DestName:     field(row, cols.dest),
+			BookingClass: field(row, cols.class),
+			Seat:         field(row, cols.seat),
+			Notes:        field(row, cols.notes),
+			Source:       file.Format,
+		}
+		if j.JourneyType == "" {
+			j.JourneyType = defaultType
+		}
+		if j.JourneyType != models.JourneyTypeFlight && j.JourneyType != models.JourneyTypeTrain {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: unknown journey type %q", line, j.JourneyType))
+			continue
+		}
+		if j.OriginName == "" || j.DestName == "" {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: missing origin or destination", line))
+			continue
+		}
+
+		date := field(row, cols.date)
+		departure, err := combineDateTime(date, field(row, cols.depart))
+		if err != nil {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+		j.DepartureTime = departure.Unix()
+
+		if arriveCell := field(row, cols.arrive); arriveCell != "" {
+			if date == "" {
+				date = departure.Format("2006-01-02")
+			}
+			arrival, err := combineDateTime(date, arriveCell)
+			if err != nil {
+				file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: %v", line, err))
+			} else {
+				// Overnight journeys list only the arrival clock time
+				for arrival.Before(departure) {
+					arrival = arrival.Add(24 * time.Hour)
+				}
+				j.ArrivalTime = arrival.Unix()
+				j.DurationS = j.ArrivalTime - j.DepartureTime
+			}
+		}
+
+		locateJourney(&j)
+		j.OriginLat, j.OriginLon = coordinateOr(row, cols.originLat, cols.originLon, j.OriginLat, j.OriginLon)
+		j.DestLat, j.DestLon = coordinateOr(row, cols.destLat, cols.destLon, j.DestLat, j.DestLon)
+		if j.HasCoordinates() {
+			j.DistanceM = spatial.HaversineDistance(j.OriginLat, j.OriginLon, j.DestLat, j.DestLon)
+		} else {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: unknown location %s - %s", line, j.OriginName, j.DestName))
+		}
+
+		file.Journeys = append(file.Journeys, j)
+	}
+
+	return file, nil
+}
+
+// journeyColumns holds the column index of each journey field (-1 when absent)
+type journeyColumns struct {
+	number, carrier, kind, notes int
+	date, depart, arrive         int
+	origin, dest, class, seat    int
+	originLat, originLon         int
+	destLat, destLon             int
+}
+
+func journeyColumnsOf(t *csvTable) journeyColumns {
+	return journeyColumns{
+		number:    t.column(journeyNumberCols...),
+		carrier:   t.column(journeyCarrierCols...),
+		kind:      t.column(journeyTypeCols...),
+		notes:     t.column(journeyNotesCols...),
+		date:      t.column(journeyDateCols...),
+		depart:    t.column(journeyDepartCols...),
+		arrive:    t.column(journeyArriveCols...),
+		origin:    t.column(journeyOriginCols...),
+		dest:      t.column(journeyDestCols...),
+		class:     t.column(journeyClassCols...),
+		seat:      t.column(journeySeatCols...),
+		originLat: t.column(journeyOriginLatCol...),
+		originLon: t.column(journeyOriginLonCol...),
+		destLat:   t.column(journeyDestLatCol...),
+		destLon:   t.column(journeyDestLonCol...),
+	}
+}
+
+// locateJourney fills codes, canonical names, cities and coordinates from the transit tables
+func locateJourney(j *models.Journey) {
+	lookup := func(name string) (transit.Place, bool) {
+		if j.JourneyType == models.JourneyTypeFlight {
+			if m := iataPattern.FindStringSubmatch(strings.TrimSpace(name)); m != nil {
+				return transit.LookupAirport(m[1])
+			}
+			return transit.Place{}, false
+		}
+		return transit.LookupStation(name)
+	}
+
+	if p, ok := lookup(j.OriginName); ok {
+		j.OriginCode, j.OriginName, j.OriginCity, j.OriginLat, j.OriginLon = p.Code, p.Name, p.City, p.Lat, p.Lon
+	}
+	if p, ok := lookup(j.DestName); ok {
+		j.DestCode, j.DestName, j.DestCity, j.DestLat, j.DestLon = p.Code, p.Name, p.City, p.Lat, p.Lon
+	}
+}
+
+// coordinateOr returns the coordinate from the given columns when both parse, else the fallback
+func coordinateOr(row []string, latCol, lonCol int, lat, lon float64) (float64, float64) {
+	rowLat, errLat := strconv.ParseFloat(field(row, latCol), 64)
+	rowLon, errLon := strconv.ParseFloat(field(row, lonCol), 64)
+	if errLat != nil || errLon != nil {
+		return lat, lon
+	}
+	return rowLat, rowLon
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ParseKML parses a KML document into track points
+// Supported geometries:
+//   - gx:Track with paired <when>/<gx:coord> elements (Google Location History, most loggers)
+//   - Placemark Point with a TimeStamp
+//
+// LineStrings carry no per-vertex time and are ignored.
+func ParseKML(r io.Reader) ([]models.TrackPoint, error) {
+	decoder := xml.NewDecoder(r)
+
+	var points []models.TrackPoint
+	var whens []string
+	var coords []string
+	var placemarkWhen string
+	var placemarkCoord string
+	var inTrack, inPoint bool
+	var text strings.Builder
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML document: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			text.Reset()
+			switch t.Name.Local {
+			case "Track":
+				inTrack = true
+				whens = whens[:0]
+				coords = coords[:0]
+			case "Placemark":
+				placemarkWhen = ""
+				placemarkCoord = ""
+			case "Point":
+				inPoint = true
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			value := strings.TrimSpace(text.String())
+			switch t.Name.Local {
+			case "when":
+				if inTrack {
+					whens = append(whens, value)
+				} else {
+					placemarkWhen = value
+				}
+			case "coord":
+				if inTrack {
+					coords = append(coords, value)
+				}
+			case "coordinates":
+				if inPoint {
+					placemarkCoord = value
+				}
+			case "Point":
+				inPoint = false
+			case "Track":
+				inTrack = false
+				if len(whens) != len(coords) {
+					return nil, fmt.Errorf("gx:Track has %d <when> but %d <gx:coord> elements", len(whens), len(coords))
+				}
+				for i := range whens {
+					point, err := kmlPoint(whens[i], strings.Fields(coords[i]))
+					if err != nil {
+						return nil, err
+					}
+					points = append(points, point)
+				}
+			case "Placemark":
+				if placemarkWhen != "" && placemarkCoord != "" {
+					point, err := kmlPoint(placemarkWhen, strings.Split(placemarkCoord, ","))
+					if err != nil {
+						return nil, err
+					}
+					points = append(points, point)
+				}
+			}
+			text.Reset()
+		}
+	}
+
+	return points, nil
+}
+
+// kmlPoint builds a track point from a timestamp and "lon lat [alt]" fields
+func kmlPoint(when string, fields []string) (models.TrackPoint, error) {
+	if len(fields) < 2 {
+		return models.TrackPoint{}, fmt.Errorf("invalid KML coordinate: %v", fields)
+	}
+
+	ts, err := parseTimestamp(when)
+	if err != nil {
+		return models.TrackPoint{}, err
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+	if err != nil {
+		return models.TrackPoint{}, fmt.Errorf("invalid longitude %q: %w", fields[0], err)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	if err != nil {
+		return models.TrackPoint{}, fmt.Errorf("invalid latitude %q: %w", fields[1], err)
+	}
+
+	point := models.TrackPoint{
+		DataTime:  ts,
+		Latitude:  lat,
+		Longitude: lon,
+	}
+	if len(fields) > 2 {
+		if alt, err := strconv.ParseFloat(strings.TrimSpace(fields[2]), 64); err == nil {
+			point.Altitude = alt
+		}
+	}
+
+	return point, nil
+}
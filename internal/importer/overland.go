@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// FormatOverland is the source recorded on points ingested from Overland
+const FormatOverland = "overland"
+
+// overlandBatch is the body the Overland app POSTs; see
+// https://github.com/aaronpk/Overland-iOS#api
+type overlandBatch struct {
+	Locations []overlandLocation `json:"locations"`
+}
+
+type overlandLocation struct {
+	Geometry struct {
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Timestamp          string   `json:"timestamp"` // ISO 8601
+		Altitude           *float64 `json:"altitude"`
+		Speed              *float64 `json:"speed"`               // m/s, -1 when unknown
+		Course             *float64 `json:"course"`              // Degrees, -1 when unknown
+		HorizontalAccuracy *float64 `json:"horizontal_accuracy"` // Meters
+		Motion             []string `json:"motion"`
+		BatteryLevel       *float64 `json:"battery_level"` // 0-1
+		BatteryState       string   `json:"battery_state"` // unknown, charging, full, unplugged
+		DeviceID           string   `json:"device_id"`
+	} `json:"properties"`
+}
+
+// ParseOverland parses an Overland batch into validated track points ordered
+// by time and returns how many locations were rejected as invalid
+// Invalid locations are dropped rather than failing the batch, since the app
+// would otherwise resend the same batch forever. The device of the points is
+// their device_id.
+func ParseOverland(payload []byte, now time.Time) ([]models.TrackPoint, int, error) {
+	var batch overlandBatch
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		return nil, 0, fmt.Errorf("invalid Overland payload: %w", err)
+	}
+
+	var points []models.TrackPoint
+	rejected := 0
+	for _, loc := range batch.Locations {
+		point, err := loc.trackPoint(now)
+		if err != nil {
+			rejected++
+			continue
+		}
+		points = append(points, point)
+	}
+	return normalize(points), rejected, nil
+}
+
+// trackPoint validates an Overland location and converts it
+func (l overlandLocation) trackPoint(now time.Time) (models.TrackPoint, error) {
+	if l.Geometry.Type != "Point" || len(l.Geometry.Coordinates) < 2 {
+		return models.TrackPoint{}, fmt.Errorf("Overland location without a Point geometry")
+	}
+	ts, err := parseTimestamp(l.Properties.Timestamp)
+	if err != nil {
+		return models.TrackPoint{}, err
+	}
+	props := l.Properties
+	point, err := livePoint(l.Geometry.Coordinates[1], l.Geometry.Coordinates[0], ts, props.HorizontalAccuracy, now)
+	if err != nil {
+		return models.TrackPoint{}, err
+	}
+
+	point.Source = FormatOverland
+	point.SourceDevice = props.DeviceID
+	point.Altitude = firstOf(props.Altitude)
+	if props.Speed != nil && *props.Speed >= 0 {
+		point.Speed = *props.Speed
+	}
+	if props.Course != nil && *props.Course >= 0 {
+		point.Heading = *props.Course
+	}
+	point.Motion = normalizeMotion(props.Motion...)
+	if props.BatteryLevel != nil {
+		point.BatteryLevel = batteryPercent(*props.BatteryLevel, true)
+	}
+	switch props.BatteryState {
+	case BatteryCharging, BatteryUnplugged, BatteryFull:
+		point.BatteryState = props.BatteryState
+	}
+	return point, nil
+}
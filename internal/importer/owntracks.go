@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// FormatOwnTracks is the source recorded on points ingested from OwnTracks
+const FormatOwnTracks = "owntracks"
+
+// OwnTracksMessage is the subset of an OwnTracks JSON message used for
+// ingestion; see https://owntracks.org/booklet/tech/json/
+type OwnTracksMessage struct {
+	Type          string   `json:"_type"`
+	Latitude      *float64 `json:"lat"`
+	Longitude     *float64 `json:"lon"`
+	Timestamp     int64    `json:"tst"`   // Unix seconds of the fix
+	Accuracy      *float64 `json:"acc"`   // Meters
+	Altitude      *float64 `json:"alt"`   // Meters
+	Velocity      *float64 `json:"vel"`   // km/h
+	Course        *float64 `json:"cog"`   // Degrees
+	Battery       *float64 `json:"batt"`  // Percent
+	BatteryStatus int      `json:"bs"`    // 0 unknown, 1 unplugged, 2 charging, 3 full
+	TrackerID     string   `json:"tid"`   // Two-character tracker id
+	Topic         string   `json:"topic"` // Set by OwnTracks in HTTP mode
+}
+
+// ParseOwnTracks parses an OwnTracks HTTP or MQTT payload, a message object
+// or an array of them, into validated track points ordered by time
+// Messages other than locations (transitions, waypoints, cards, ...) are
+// ignored; an invalid location fails the whole payload. The source of the
+// points is owntracks and their device the user/device of the message topic,
+// else its tracker id.
+func ParseOwnTracks(payload []byte, now time.Time) ([]models.TrackPoint, error) {
+	var messages []OwnTracksMessage
+	if err := json.Unmarshal(payload, &messages); err != nil {
+		var msg OwnTracksMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			return nil, fmt.Errorf("invalid OwnTracks payload: %w", err)
+		}
+		messages = []OwnTracksMessage{msg}
+	}
+
+	var points []models.TrackPoint
+	for _, msg := range messages {
+		if msg.Type != "location" {
+			continue
+		}
+		point, err := msg.trackPoint(now)
+		if err != nil {
+			return nil, err
+		}
+		point.Source = FormatOwnTracks
+		point.SourceDevice = OwnTracksDevice(msg.Topic)
+		if point.SourceDevice == "" {
+			point.SourceDevice = msg.TrackerID
+		}
+		points = append(points, point)
+	}
+	return normalize(points), nil
+}
+
+// OwnTracksDevice returns "user/device" for an OwnTracks topic of the form
+// owntracks/user/device, or "" for other topics
+func OwnTracksDevice(topic string) string {
+	parts := strings.Split(topic, "/")
+	if len(parts) < 3 || parts[1] == "" || parts[2] == "" {
+		return ""
+	}
+	return parts[1] + "/" + parts[2]
+}
+
+// trackPoint validates a location message and converts it
+func (m OwnTracksMessage) trackPoint(now time.Time) (models.TrackPoint, error) {
+	if m.Latitude == nil || m.Longitude == nil {
+		return models.TrackPoint{}, fmt.Errorf("OwnTracks location without lat/lon")
+	}
+	point, err := livePoint(*m.Latitude, *m.Longitude, m.Timestamp, m.Accuracy, now)
+	if err != nil {
+		return models.TrackPoint{}, fmt.Errorf("OwnTracks %w", err)
+	}
+	point.Altitude = firstOf(m.Altitude)
+	if m.Velocity != nil && *m.Velocity >= 0 {
+		point.Speed = *m.Velocity / 3.6
+	}
+	point.Heading = firstOf(m.Course)
+	if m.Battery != nil {
+		point.BatteryLevel = batteryPercent(*m.Battery, false)
+	}
+	switch m.BatteryStatus {
+	case 1:
+		point.BatteryState = BatteryUnplugged
+	case 2:
+		point.BatteryState = BatteryCharging
+	case 3:
+		point.BatteryState = BatteryFull
+	}
+	return point, nil
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// maxClockSkew is how far in the future the timestamp of a live location may lie
+const maxClockSkew = 5 * time.Minute
+
+// Motion activities recorded on track points, the vocabulary of Overland
+const (
+	MotionStationary = "stationary"
+	MotionWalking    = "walking"
+	MotionRunning    = "running"
+	MotionCycling    = "cycling"
+	MotionDriving    = "driving"
+)
+
+// Battery states recorded on track points
+const (
+	BatteryCharging  = "charging"
+	BatteryUnplugged = "unplugged"
+	BatteryFull      = "full"
+)
+
+// motionAliases maps the activity names of phone apps (Android activity
+// recognition, iOS Core Motion) onto the motion vocabulary
+var motionAliases = map[string]string{
+	"stationary": MotionStationary,
+	"still":      MotionStationary,
+	"walking":    MotionWalking,
+	"on_foot":    MotionWalking,
+	"running":    MotionRunning,
+	"cycling":    MotionCycling,
+	"on_bicycle": MotionCycling,
+	"driving":    MotionDriving,
+	"automotive": MotionDriving,
+	"in_vehicle": MotionDriving,
+}
+
+// normalizeMotion maps activity names onto the motion vocabulary and joins
+// them with commas, dropping unknown names and repeats
+func normalizeMotion(activities ...string) string {
+	var motions []string
+	seen := make(map[string]bool)
+	for _, activity := range activities {
+		motion, ok := motionAliases[strings.ToLower(strings.TrimSpace(activity))]
+		if ok && !seen[motion] {
+			seen[motion] = true
+			motions = append(motions, motion)
+		}
+	}
+	return strings.Join(motions, ",")
+}
+
+// batteryPercent returns a battery level in percent, converting a 0-1
+// fraction when fraction is set, or nil when the level is unknown (negative)
+func batteryPercent(level float64, fraction bool) *float64 {
+	if level < 0 {
+		return nil
+	}
+	if fraction {
+		level *= 100
+	}
+	if level > 100 {
+		level = 100
+	}
+	return &level
+}
+
+// livePoint validates a location pushed by a phone app and builds its track
+// point; accuracy is in meters and may be nil
+func livePoint(lat, lon float64, ts int64, accuracy *float64, now time.Time) (models.TrackPoint, error) {
+	if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return models.TrackPoint{}, fmt.Errorf("location out of range: %v, %v", lat, lon)
+	}
+	if lat == 0 && lon == 0 {
+		return models.TrackPoint{}, fmt.Errorf("location at 0, 0")
+	}
+	if ts <= 0 {
+		return models.TrackPoint{}, fmt.Errorf("location without a timestamp")
+	}
+	if time.Unix(ts, 0).After(now.Add(maxClockSkew)) {
+		return models.TrackPoint{}, fmt.Errorf("location timestamp %d is in the future", ts)
+	}
+	if accuracy != nil && *accuracy < 0 {
+		return models.TrackPoint{}, fmt.Errorf("location with negative accuracy")
+	}
+	return models.TrackPoint{
+		DataTime:  ts,
+		Latitude:  lat,
+		Longitude: lon,
+		Accuracy:  firstOf(accuracy),
+	}, nil
+}
@@ -0,0 +1,332 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxEXIFScan bounds how far into a photo the EXIF block is searched for
+// JPEG files carry it in the first segment; HEIC files after the metadata
+// boxes, which stay well within this for camera photos.
+const maxEXIFScan = 1 << 20
+
+// photoExtensions lists the photo file extensions scanned for geotags
+var photoExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".heic": true, ".heif": true,
+	".tif": true, ".tiff": true, ".dng": true, ".nef": true, ".arw": true, ".cr2": true,
+}
+
+// ErrNoEXIF is returned for photos without an EXIF block
+var ErrNoEXIF = errors.New("no EXIF metadata")
+
+// IsPhotoFile reports whether a file name has a photo extension
+func IsPhotoFile(name string) bool {
+	return photoExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// PhotoMeta is what the metadata of a photo tells about when and where it was taken
+type PhotoMeta struct {
+	TakenAt  int64 // Unix timestamp, 0 when unknown
+	HasGPS   bool
+	Lat      float64
+	Lon      float64
+	Altitude float64
+	Make     string
+	Model    string
+	Width    int
+	Height   int
+}
+
+// EXIF tags read from the IFDs
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+	tagOffsetOriginal   = 0x9011
+	tagPixelXDimension  = 0xA002
+	tagPixelYDimension  = 0xA003
+
+	tagGPSLatitudeRef  = 0x01
+	tagGPSLatitude     = 0x02
+	tagGPSLongitudeRef = 0x03
+	tagGPSLongitude    = 0x04
+	tagGPSAltitudeRef  = 0x05
+	tagGPSAltitude     = 0x06
+	tagGPSTimeStamp    = 0x07
+	tagGPSDateStamp    = 0x1D
+)
+
+// exifTypeSizes are the byte sizes of the TIFF field types
+var exifTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// ParseEXIF reads the capture time, GPS position and camera of a JPEG, HEIC or
+// TIFF-based raw photo from its EXIF block
+// The capture time is DateTimeOriginal with its offset, else the GPS time
+// (UTC), else DateTimeOriginal as local time.
+func ParseEXIF(r io.Reader) (*PhotoMeta, error) {
+	head, err := io.ReadAll(io.LimitReader(r, maxEXIFScan))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read photo: %w", err)
+	}
+
+	tiff := findTIFF(head)
+	if tiff == nil {
+		return nil, ErrNoEXIF
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if tiff[0] == 'M' {
+		order = binary.BigEndian
+	}
+	t := &tiffReader{data: tiff, order: order}
+
+	ifd0 := t.readIFD(order.Uint32(tiff[4:]))
+	if ifd0 == nil {
+		return nil, ErrNoEXIF
+	}
+	exif := t.readIFD(t.uint(ifd0[tagExifIFD]))
+	gps := t.readIFD(t.uint(ifd0[tagGPSIFD]))
+
+	meta := &PhotoMeta{
+		Make:   t.ascii(ifd0[tagMake]),
+		Model:  t.ascii(ifd0[tagModel]),
+		Width:  int(t.uint(exif[tagPixelXDimension])),
+		Height: int(t.uint(exif[tagPixelYDimension])),
+	}
+
+	lat, latOK := t.degrees(gps[tagGPSLatitude])
+	lon, lonOK := t.degrees(gps[tagGPSLongitude])
+	if latOK && lonOK && !(lat == 0 && lon == 0) {
+		if strings.EqualFold(t.ascii(gps[tagGPSLatitudeRef]), "S") {
+			lat = -lat
+		}
+		if strings.EqualFold(t.ascii(gps[tagGPSLongitudeRef]), "W") {
+			lon = -lon
+		}
+		meta.HasGPS, meta.Lat, meta.Lon = true, lat, lon
+		if alt, ok := t.rationals(gps[tagGPSAltitude]); ok && len(alt) > 0 {
+			meta.Altitude = alt[0]
+			if ref := gps[tagGPSAltitudeRef]; ref != nil && len(ref.value) > 0 && ref.value[0] == 1 {
+				meta.Altitude = -meta.Altitude
+			}
+		}
+	}
+
+	original := t.ascii(exif[tagDateTimeOriginal])
+	if original == "" {
+		original = t.ascii(ifd0[tagDateTime])
+	}
+	offset := t.ascii(exif[tagOffsetOriginal])
+	if original != "" && offset != "" {
+		if tm, err := time.Parse("2006:01:02 15:04:05-07:00", original+offset); err == nil {
+			meta.TakenAt = tm.Unix()
+		}
+	}
+	if meta.TakenAt == 0 {
+		meta.TakenAt = t.gpsTime(gps)
+	}
+	if meta.TakenAt == 0 && original != "" {
+		if tm, err := time.ParseInLocation("2006:01:02 15:04:05", original, time.Local); err == nil {
+			meta.TakenAt = tm.Unix()
+		}
+	}
+	return meta, nil
+}
+
+// findTIFF returns the TIFF structure holding the EXIF data, from the start of
+// a TIFF-based file or after the "Exif\0\0" marker of JPEG APP1 segments and
+// HEIC Exif items
+func findTIFF(data []byte) []byte {
+	if isTIFFHeader(data) {
+		return data
+	}
+	marker := []byte("Exif\x00\x00")
+	for start := 0; ; {
+		i := bytes.Index(data[start:], marker)
+		if i < 0 {
+			return nil
+		}
+		tiff := data[start+i+len(marker):]
+		if isTIFFHeader(tiff) {
+			return tiff
+		}
+		start += i + len(marker)
+	}
+}
+
+func isTIFFHeader(b []byte) bool {
+	return len(b) >= 8 && (bytes.HasPrefix(b, []byte("II*\x00")) || bytes.HasPrefix(b, []byte("MM\x00*")))
+}
+
+// tiffReader reads IFD entries out of a TIFF structure
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffField is a decoded IFD entry; value holds count values of the type
+type tiffField struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// readIFD reads the entries of the IFD at offset, nil if it is out of range
+func (t *tiffReader) readIFD(offset uint32) map[uint16]*tiffField {
+	if offset == 0 || int64(offset)+2 > int64(len(t.data)) {
+		return nil
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	fields := make(map[uint16]*tiffField, n)
+	for i := 0; i < n; i++ {
+		entry := int(offset) + 2 + i*12
+		if entry+12 > len(t.data) {
+			break
+		}
+		tag := t.order.Uint16(t.data[entry:])
+		typ := t.order.Uint16(t.data[entry+2:])
+		count := t.order.Uint32(t.data[entry+4:])
+		size, ok := exifTypeSizes[typ]
+		if !ok || count > uint32(len(t.data)) {
+			continue
+		}
+		total := int64(size) * int64(count)
+		var value []byte
+		if total <= 4 {
+			value = t.data[entry+8 : entry+8+int(total)]
+		} else {
+			at := int64(t.order.Uint32(t.data[entry+8:]))
+			if at+total > int64(len(t.data)) {
+				continue
+			}
+			value = t.data[at : at+total]
+		}
+		fields[tag] = &tiffField{typ: typ, count: count, value: value}
+	}
+	return fields
+}
+
+// uint returns the first value of a SHORT or LONG field, 0 if absent
+func (t *tiffReader) uint(f *tiffField) uint32 {
+	if f == nil || f.count == 0 {
+		return 0
+	}
+	switch f.typ {
+	case 3:
+		return uint32(t.order.Uint16(f.value))
+	case 4, 9:
+		return t.order.Uint32(f.value)
+	}
+	return 0
+}
+
+// ascii returns an ASCII field without its NUL terminator and padding
+func (t *tiffReader) ascii(f *tiffField) string {
+	if f == nil || f.typ != 2 {
+		return ""
+	}
+	s, _, _ := strings.Cut(string(f.value), "\x00")
+	return strings.TrimSpace(s)
+}
+
+// rationals returns the values of a RATIONAL field
+func (t *tiffReader) rationals(f *tiffField) ([]float64, bool) {
+	if f == nil || (f.typ != 5 && f.typ != 10) {
+		return nil, false
+	}
+	values := make([]float64, f.count)
+	for i := range values {
+		num, den := t.order.Uint32(f.value[i*8:]), t.order.Uint32(f.value[i*8+4:])
+		if den == 0 {
+			return nil, false
+		}
+		if f.typ == 10 {
+			values[i] = float64(int32(num)) / float64(int32(den))
+		} else {
+			values[i] = float64(num) / float64(den)
+		}
+	}
+	return values, true
+}
+
+// degrees converts a degrees, minutes, seconds GPS field to decimal degrees
+func (t *tiffReader) degrees(f *tiffField) (float64, bool) {
+	dms, ok := t.rationals(f)
+	if !ok || len(dms) < 3 {
+		return 0, false
+	}
+	deg := dms[0] + dms[1]/60 + dms[2]/3600
+	if math.IsNaN(deg) || deg > 180 {
+		return 0, false
+	}
+	return deg, true
+}
+
+// gpsTime returns the UTC time of the GPS fix, 0 if the GPS IFD has none
+func (t *tiffReader) gpsTime(gps map[uint16]*tiffField) int64 {
+	date := t.ascii(gps[tagGPSDateStamp])
+	hms, ok := t.rationals(gps[tagGPSTimeStamp])
+	if date == "" || !ok || len(hms) < 3 {
+		return 0
+	}
+	day, err := time.Parse("2006:01:02", date)
+	if err != nil {
+		return 0
+	}
+	seconds := hms[0]*3600 + hms[1]*60 + hms[2]
+	return day.Unix() + int64(seconds)
+}
+
+// takeoutSidecar is the JSON metadata Google Takeout writes next to each photo
+type takeoutSidecar struct {
+	PhotoTakenTime struct {
+		Timestamp string `json:"timestamp"` // Unix seconds as a string
+	} `json:"photoTakenTime"`
+	GeoData     takeoutGeoData `json:"geoData"`
+	GeoDataExif takeoutGeoData `json:"geoDataExif"`
+}
+
+type takeoutGeoData struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// ParseTakeoutSidecar reads the capture time and location of a Google
+// Takeout sidecar (IMG_0001.jpg.json)
+// Google Photos removes the geotag from some downloaded files but keeps it
+// here; a location of 0,0 means none.
+func ParseTakeoutSidecar(r io.Reader) (*PhotoMeta, error) {
+	var sidecar takeoutSidecar
+	if err := json.NewDecoder(r).Decode(&sidecar); err != nil {
+		return nil, fmt.Errorf("failed to parse Takeout sidecar: %w", err)
+	}
+
+	meta := &PhotoMeta{}
+	if ts, err := strconv.ParseInt(sidecar.PhotoTakenTime.Timestamp, 10, 64); err == nil {
+		meta.TakenAt = ts
+	}
+	for _, geo := range []takeoutGeoData{sidecar.GeoData, sidecar.GeoDataExif} {
+		if geo.Latitude != 0 || geo.Longitude != 0 {
+			meta.HasGPS, meta.Lat, meta.Lon, meta.Altitude = true, geo.Latitude, geo.Longitude, geo.Altitude
+			break
+		}
+	}
+	return meta, nil
+}
+
+// TakeoutSidecarNames returns the names Google Takeout gives the sidecar of a photo
+func TakeoutSidecarNames(photoPath string) []string {
+	return []string{photoPath + ".json", photoPath + ".supplemental-metadata.json"}
+}
@@ -0,0 +1,258 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Screen time import formats
+const (
+	FormatIOSScreenTime    = "ios_screentime"
+	FormatDigitalWellbeing = "digital_wellbeing"
+	FormatScreenTimeCSV    = "generic"
+)
+
+// DefaultScreenTimeCategory is used when an export has no category column
+const DefaultScreenTimeCategory = "Other"
+
+// Header aliases of the screen time CSV columns
+var (
+	screenDateCols     = []string{"date", "day", "日期"}
+	screenStartCols    = []string{"start time", "start", "timestamp", "time stamp", "开始时间"}
+	screenAppCols      = []string{"app", "app name", "application", "name", "应用", "应用名称"}
+	screenBundleCols   = []string{"bundle id", "bundle identifier", "bundle"}
+	screenPackageCols  = []string{"package", "package name", "package_name", "包名"}
+	screenCategoryCols = []string{"category", "类别", "分类"}
+	screenLaunchCols   = []string{"launches", "opens", "pickups", "times opened", "打开次数", "启动次数"}
+	screenNotifyCols   = []string{"notifications", "通知", "通知次数"}
+)
+
+// screenDurationPrefixes match duration headers such as "Usage (minutes)" or "使用时长"
+var screenDurationPrefixes = []string{"usage", "duration", "screen time", "total time", "time used", "使用时长", "时长"}
+
+// durationPartPattern matches "1h", "23 min", "4s", "1小时", "23分钟", "4秒"
+var durationPartPattern = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(h|hr|hrs|hours?|m|min|mins|minutes?|s|sec|secs|seconds?|小时|时|分钟|分|秒)`)
+
+// ScreenTimeFile is the result of parsing a screen time export
+type ScreenTimeFile struct {
+	Format     string
+	ParsedRows int
+	Usage      []models.ScreenTimeUsage // One record per app and day, ordered by date
+	Warnings   []string
+}
+
+// ParseScreenTimeCSV parses an iOS Screen Time export, an Android Digital
+// Wellbeing CSV or a generic CSV with the same columns into per-app daily usage
+// Rows of the same app and day (e.g. hourly or per-session exports) are
+// summed. Durations may be "1h 23m", "01:23:45" or plain numbers; plain
+// numbers are read in the unit named by the header ("Usage (minutes)") and as
+// seconds otherwise.
+func ParseScreenTimeCSV(r io.Reader, device string) (*ScreenTimeFile, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ScreenTimeFile{Format: FormatScreenTimeCSV}
+	platform := ""
+	appIDCol := table.column(screenPackageCols...)
+	switch {
+	case appIDCol >= 0:
+		file.Format, platform = FormatDigitalWellbeing, "android"
+	case table.has(screenBundleCols...):
+		appIDCol = table.column(screenBundleCols...)
+		file.Format, platform = FormatIOSScreenTime, "ios"
+	}
+
+	dateCol := table.column(screenDateCols...)
+	startCol := table.column(screenStartCols...)
+	appCol := table.column(screenAppCols...)
+	categoryCol := table.column(screenCategoryCols...)
+	launchCol := table.column(screenLaunchCols...)
+	notifyCol := table.column(screenNotifyCols...)
+	durationCol, unit := table.durationColumn(screenDurationPrefixes)
+	if appCol < 0 && appIDCol >= 0 {
+		appCol = appIDCol
+	}
+	if appCol < 0 || durationCol < 0 || (dateCol < 0 && startCol < 0) {
+		return nil, fmt.Errorf("CSV header must contain app, date and usage duration columns")
+	}
+
+	type usageKey struct{ date, app string }
+	merged := make(map[usageKey]*models.ScreenTimeUsage)
+
+	for i, row := range table.rows {
+		line := i + 2
+		if len(strings.Join(row, "")) == 0 {
+			continue
+		}
+
+		day, err := usageDate(field(row, dateCol), field(row, startCol))
+		if err != nil {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+		app := field(row, appCol)
+		if app == "" {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: missing app name", line))
+			continue
+		}
+		duration, err := parseUsageDuration(field(row, durationCol), unit)
+		if err != nil {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("row %d: %v", line, err))
+			continue
+		}
+		file.ParsedRows++
+
+		key := usageKey{day, app}
+		u, ok := merged[key]
+		if !ok {
+			u = &models.ScreenTimeUsage{
+				Date:     day,
+				Device:   device,
+				Platform: platform,
+				AppName:  app,
+				Category: DefaultScreenTimeCategory,
+				Source:   file.Format,
+			}
+			merged[key] = u
+		}
+		if id := field(row, appIDCol); id != "" && u.AppID == "" {
+			u.AppID = id
+		}
+		if category := field(row, categoryCol); category != "" && u.Category == DefaultScreenTimeCategory {
+			u.Category = category
+		}
+		u.DurationS += duration
+		u.Launches += parseCount(field(row, launchCol))
+		u.Notifications += parseCount(field(row, notifyCol))
+	}
+
+	file.Usage = make([]models.ScreenTimeUsage, 0, len(merged))
+	for _, u := range merged {
+		file.Usage = append(file.Usage, *u)
+	}
+	sort.Slice(file.Usage, func(i, j int) bool {
+		if file.Usage[i].Date != file.Usage[j].Date {
+			return file.Usage[i].Date < file.Usage[j].Date
+		}
+		return file.Usage[i].AppName < file.Usage[j].AppName
+	})
+
+	return file, nil
+}
+
+// durationColumn finds the usage duration column and the unit its header names
+func (t *csvTable) durationColumn(prefixes []string) (int, time.Duration) {
+	best, bestName := -1, ""
+	for name, i := range t.header {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) && (best < 0 || i < best) {
+				best, bestName = i, name
+			}
+		}
+	}
+	if best < 0 {
+		return -1, 0
+	}
+
+	switch {
+	case strings.Contains(bestName, "ms") || strings.Contains(bestName, "milli") || strings.Contains(bestName, "毫秒"):
+		return best, time.Millisecond
+	case strings.Contains(bestName, "min") || strings.Contains(bestName, "分钟"):
+		return best, time.Minute
+	case strings.Contains(bestName, "hour") || strings.Contains(bestName, "小时"):
+		return best, time.Hour
+	default:
+		return best, time.Second
+	}
+}
+
+// usageDate resolves the local day of a row from its date or start time cell
+func usageDate(date, start string) (string, error) {
+	if date != "" {
+		if t, err := parseLocalDate(date); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+		if t, err := parseLocalDateTime(date); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+		return "", fmt.Errorf("invalid date: %q", date)
+	}
+	t, err := parseLocalDateTime(start)
+	if err != nil {
+		return "", err
+	}
+	return t.Format("2006-01-02"), nil
+}
+
+// parseUsageDuration converts a duration cell to seconds
+func parseUsageDuration(value string, unit time.Duration) (int64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+
+	// Plain number in the header's unit
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return int64(n * float64(unit) / float64(time.Second)), nil
+	}
+
+	// Clock format: h:mm or h:mm:ss
+	if parts := strings.Split(value, ":"); len(parts) == 2 || len(parts) == 3 {
+		var seconds int64
+		multipliers := []int64{3600, 60, 1}
+		for i, part := range parts {
+			n, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %q", value)
+			}
+			seconds += n * multipliers[i]
+		}
+		return seconds, nil
+	}
+
+	// Unit parts: "1h 23m 4s", "1小时23分钟"
+	matches := durationPartPattern.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("invalid duration: %q", value)
+	}
+	var seconds float64
+	for _, m := range matches {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		switch m[2][0] {
+		case 'h':
+			seconds += n * 3600
+		case 'm':
+			seconds += n * 60
+		case 's':
+			seconds += n
+		default:
+			switch m[2] {
+			case "小时", "时":
+				seconds += n * 3600
+			case "分钟", "分":
+				seconds += n * 60
+			case "秒":
+				seconds += n
+			}
+		}
+	}
+	return int64(seconds), nil
+}
+
+// parseCount parses an optional integer cell, treating invalid values as zero
+func parseCount(value string) int64 {
+	n, err := strconv.ParseInt(strings.ReplaceAll(value, ",", ""), 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Header aliases of the track point CSV columns
+var (
+	trackTimeCols     = []string{"time", "timestamp", "datetime", "date time", "date_time", "datatime", "utc", "时间"}
+	trackDateCols     = []string{"date", "日期"}
+	trackLatCols      = []string{"latitude", "lat", "纬度"}
+	trackLonCols      = []string{"longitude", "lon", "lng", "long", "经度"}
+	trackAltCols      = []string{"altitude", "elevation", "ele", "alt", "海拔", "高度"}
+	trackSpeedCols    = []string{"speed", "速度"}
+	trackAccuracyCols = []string{"accuracy", "horizontal_accuracy", "horizontal accuracy", "hacc", "精度"}
+	trackHeadingCols  = []string{"heading", "bearing", "course", "方向"}
+)
+
+// ParseTrackCSV parses a CSV export with one track point per row, as written
+// by most GPS loggers and phone tracking apps
+// Columns are matched by header name; latitude, longitude and a time column
+// are required. Times may be Unix seconds or milliseconds, or date times,
+// read in local time unless they carry an offset. Speed is in m/s.
+func ParseTrackCSV(r io.Reader) ([]models.TrackPoint, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	timeCol := table.column(trackTimeCols...)
+	dateCol := table.column(trackDateCols...)
+	latCol := table.column(trackLatCols...)
+	lonCol := table.column(trackLonCols...)
+	if latCol < 0 || lonCol < 0 || (timeCol < 0 && dateCol < 0) {
+		return nil, fmt.Errorf("CSV track needs latitude, longitude and time columns")
+	}
+	altCol := table.column(trackAltCols...)
+	speedCol := table.column(trackSpeedCols...)
+	accuracyCol := table.column(trackAccuracyCols...)
+	headingCol := table.column(trackHeadingCols...)
+
+	points := make([]models.TrackPoint, 0, len(table.rows))
+	for i, row := range table.rows {
+		latValue, lonValue := field(row, latCol), field(row, lonCol)
+		if latValue == "" && lonValue == "" {
+			continue
+		}
+		lat, err := strconv.ParseFloat(latValue, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid latitude %q", i+2, latValue)
+		}
+		lon, err := strconv.ParseFloat(lonValue, 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid longitude %q", i+2, lonValue)
+		}
+
+		ts, err := parsePointTime(field(row, dateCol), field(row, timeCol))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+2, err)
+		}
+
+		point := models.TrackPoint{
+			DataTime:  ts,
+			Latitude:  lat,
+			Longitude: lon,
+		}
+		point.Altitude, _ = strconv.ParseFloat(field(row, altCol), 64)
+		point.Speed, _ = strconv.ParseFloat(field(row, speedCol), 64)
+		point.Accuracy, _ = strconv.ParseFloat(field(row, accuracyCol), 64)
+		point.Heading, _ = strconv.ParseFloat(field(row, headingCol), 64)
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// parsePointTime resolves the Unix time of a point from a date cell and a
+// time cell, either of which may be empty
+// A numeric time is Unix seconds, or milliseconds when above 1e12.
+func parsePointTime(date, clock string) (int64, error) {
+	if date == "" {
+		if ts, ok := parseEpoch(clock); ok {
+			return ts, nil
+		}
+		if t, err := time.Parse(time.RFC3339Nano, clock); err == nil {
+			return t.Unix(), nil
+		}
+		t, err := parseLocalDateTime(clock)
+		if err != nil {
+			return 0, err
+		}
+		return t.Unix(), nil
+	}
+	t, err := combineDateTime(date, clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// parseEpoch parses Unix seconds or milliseconds
+func parseEpoch(value string) (int64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	if v > 1e12 {
+		v /= 1000
+	}
+	return int64(v), true
+}
@@ -0,0 +1,258 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// jsonPoint is a track point object in one of the JSON layouts ParseTrackJSON
+// reads; the field spellings of different apps are all decoded and the
+// first present one wins
+type jsonPoint struct {
+	Latitude    *float64        `json:"latitude"`
+	Lat         *float64        `json:"lat"`
+	LatitudeE7  *int64          `json:"latitudeE7"`
+	Longitude   *float64        `json:"longitude"`
+	Lon         *float64        `json:"lon"`
+	Lng         *float64        `json:"lng"`
+	LongitudeE7 *int64          `json:"longitudeE7"`
+	Time        json.RawMessage `json:"time"`
+	Timestamp   json.RawMessage `json:"timestamp"`
+	TimestampMs string          `json:"timestampMs"`
+	Altitude    *float64        `json:"altitude"`
+	Elevation   *float64        `json:"ele"`
+	Accuracy    *float64        `json:"accuracy"`
+	Speed       *float64        `json:"speed"`
+	Velocity    *float64        `json:"velocity"`
+	Heading     *float64        `json:"heading"`
+	Course      *float64        `json:"course"`
+
+	CoordTimes []string `json:"coordTimes"` // GeoJSON LineString properties
+}
+
+// jsonTrackDoc is a JSON track object: Google Takeout location history or a
+// GeoJSON FeatureCollection
+type jsonTrackDoc struct {
+	Locations []jsonPoint      `json:"locations"`
+	Features  []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties jsonPoint `json:"properties"`
+}
+
+// ParseTrackJSON parses a JSON track export into track points
+// Supported layouts:
+//   - Google Takeout location history ({"locations": [...]}, latitudeE7/longitudeE7)
+//   - GeoJSON Point features with a time property, and LineString features
+//     with coordTimes (as written by togeojson)
+//   - An array of point objects with lat/lon (or latitude/longitude) and time
+//
+// Times may be RFC 3339 strings or Unix seconds or milliseconds. Points
+// without a time are skipped.
+func ParseTrackJSON(r io.Reader) ([]models.TrackPoint, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+
+	var raw []jsonPoint
+	if first == '[' {
+		if err := json.NewDecoder(br).Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON document: %w", err)
+		}
+		return jsonPoints(raw)
+	}
+
+	var doc jsonTrackDoc
+	if err := json.NewDecoder(br).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	points, err := jsonPoints(doc.Locations)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range doc.Features {
+		featurePoints, err := f.points()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, featurePoints...)
+	}
+	return points, nil
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n', 0xEF, 0xBB, 0xBF: // Whitespace and the UTF-8 BOM
+			br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// jsonPoints converts point objects, skipping those without a time
+func jsonPoints(raw []jsonPoint) ([]models.TrackPoint, error) {
+	points := make([]models.TrackPoint, 0, len(raw))
+	for _, p := range raw {
+		ts, ok, err := p.dataTime()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		lat, lon, ok := p.coordinate()
+		if !ok {
+			return nil, fmt.Errorf("JSON point at %d has no coordinate", ts)
+		}
+		point := p.trackPoint(ts)
+		point.Latitude, point.Longitude = lat, lon
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// points converts a GeoJSON Point or LineString feature
+func (f geoJSONFeature) points() ([]models.TrackPoint, error) {
+	switch f.Geometry.Type {
+	case "Point":
+		var coord []float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &coord); err != nil || len(coord) < 2 {
+			return nil, fmt.Errorf("invalid GeoJSON Point coordinates")
+		}
+		ts, ok, err := f.Properties.dataTime()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return []models.TrackPoint{geoJSONPoint(f.Properties.trackPoint(ts), coord)}, nil
+	case "LineString":
+		var coords [][]float64
+		if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON LineString coordinates")
+		}
+		times := f.Properties.CoordTimes
+		if len(times) == 0 {
+			return nil, nil // No per-vertex time, like KML LineStrings
+		}
+		if len(times) != len(coords) {
+			return nil, fmt.Errorf("GeoJSON LineString has %d coordTimes but %d coordinates", len(times), len(coords))
+		}
+		points := make([]models.TrackPoint, 0, len(coords))
+		for i, coord := range coords {
+			if len(coord) < 2 {
+				return nil, fmt.Errorf("invalid GeoJSON LineString coordinate")
+			}
+			ts, err := parseTimestamp(times[i])
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, geoJSONPoint(models.TrackPoint{DataTime: ts}, coord))
+		}
+		return points, nil
+	}
+	return nil, nil
+}
+
+// geoJSONPoint sets the position of point from a [lon, lat, ele] coordinate
+func geoJSONPoint(point models.TrackPoint, coord []float64) models.TrackPoint {
+	point.Longitude, point.Latitude = coord[0], coord[1]
+	if len(coord) > 2 {
+		point.Altitude = coord[2]
+	}
+	return point
+}
+
+// dataTime returns the Unix time of the point, and false if it has none
+func (p jsonPoint) dataTime() (int64, bool, error) {
+	if p.TimestampMs != "" {
+		ms, err := strconv.ParseInt(p.TimestampMs, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid timestampMs: %q", p.TimestampMs)
+		}
+		return ms / 1000, true, nil
+	}
+	for _, raw := range []json.RawMessage{p.Time, p.Timestamp} {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			s = string(raw) // Numeric timestamp
+		}
+		if ts, ok := parseEpoch(s); ok {
+			return ts, true, nil
+		}
+		ts, err := parseTimestamp(strings.TrimSpace(s))
+		if err != nil {
+			return 0, false, err
+		}
+		return ts, true, nil
+	}
+	return 0, false, nil
+}
+
+// coordinate returns the latitude and longitude of the point
+func (p jsonPoint) coordinate() (lat, lon float64, ok bool) {
+	switch {
+	case p.Latitude != nil:
+		lat = *p.Latitude
+	case p.Lat != nil:
+		lat = *p.Lat
+	case p.LatitudeE7 != nil:
+		lat = float64(*p.LatitudeE7) / 1e7
+	default:
+		return 0, 0, false
+	}
+	switch {
+	case p.Longitude != nil:
+		lon = *p.Longitude
+	case p.Lon != nil:
+		lon = *p.Lon
+	case p.Lng != nil:
+		lon = *p.Lng
+	case p.LongitudeE7 != nil:
+		lon = float64(*p.LongitudeE7) / 1e7
+	default:
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// trackPoint builds a track point at ts with the optional attributes of p
+func (p jsonPoint) trackPoint(ts int64) models.TrackPoint {
+	point := models.TrackPoint{DataTime: ts}
+	point.Altitude = firstOf(p.Altitude, p.Elevation)
+	point.Accuracy = firstOf(p.Accuracy)
+	point.Speed = firstOf(p.Speed, p.Velocity)
+	point.Heading = firstOf(p.Heading, p.Course)
+	return point
+}
+
+// firstOf returns the first non-nil value, or 0
+func firstOf(values ...*float64) float64 {
+	for _, v := range values {
+		if v != nil {
+			return *v
+		}
+	}
+	return 0
+}
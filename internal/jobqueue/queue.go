@@ -0,0 +1,172 @@
+// Package jobqueue runs jobs on a bounded number of workers, highest
+// priority first, never running two jobs with the same key at once.
+package jobqueue
+
+import (
+	"sort"
+	"sync"
+)
+
+// job is a submitted job, pending or running
+type job struct {
+	id       int64
+	key      string
+	priority int
+	run      func()
+	done     chan struct{}
+}
+
+// Entry describes a pending or running job
+type Entry struct {
+	ID       int64
+	Key      string
+	Priority int
+	Position int // 1-based position among the pending jobs; 0 while running
+}
+
+// Queue runs submitted jobs on at most a given number of goroutines
+// Pending jobs start by descending priority, then in submission order; a
+// job whose key is held by a running job waits and lets the jobs behind it
+// go first.
+type Queue struct {
+	mu      sync.Mutex
+	workers int
+	pending []*job // In start order
+	running map[int64]*job
+	keys    map[string]bool // Keys of the running jobs
+}
+
+// New creates a queue running at most workers jobs at once
+func New(workers int) *Queue {
+	return &Queue{
+		workers: max(workers, 1),
+		running: make(map[int64]*job),
+		keys:    make(map[string]bool),
+	}
+}
+
+// Submit queues run under id and key; the returned channel is closed once
+// run has returned or the job was removed
+func (q *Queue) Submit(id int64, key string, priority int, run func()) <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j := &job{id: id, key: key, priority: priority, run: run, done: make(chan struct{})}
+	i := sort.Search(len(q.pending), func(i int) bool { return q.pending[i].priority < priority })
+	q.pending = append(q.pending, nil)
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = j
+	q.dispatch()
+	return j.done
+}
+
+// dispatch starts the pending jobs that can run; callers hold mu
+func (q *Queue) dispatch() {
+	for len(q.running) < q.workers {
+		next := -1
+		for i, j := range q.pending {
+			if !q.keys[j.key] {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return
+		}
+
+		j := q.pending[next]
+		q.pending = append(q.pending[:next], q.pending[next+1:]...)
+		q.running[j.id] = j
+		q.keys[j.key] = true
+		go q.execute(j)
+	}
+}
+
+// execute runs a job, then frees its worker for the next pending job
+func (q *Queue) execute(j *job) {
+	defer func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.running, j.id)
+		delete(q.keys, j.key)
+		close(j.done)
+		q.dispatch()
+	}()
+	j.run()
+}
+
+// Remove drops the pending job id; it reports false if the job is running
+// or unknown
+func (q *Queue) Remove(id int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, j := range q.pending {
+		if j.id == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			close(j.done)
+			return true
+		}
+	}
+	return false
+}
+
+// Drain removes every pending job and returns their IDs
+func (q *Queue) Drain() []int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make([]int64, len(q.pending))
+	for i, j := range q.pending {
+		ids[i] = j.id
+		close(j.done)
+	}
+	q.pending = nil
+	return ids
+}
+
+// Position returns the 1-based position of the pending job id, or 0 if the
+// job is not pending
+func (q *Queue) Position(id int64) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, j := range q.pending {
+		if j.id == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// SetWorkers changes how many jobs run at once; running jobs beyond the new
+// limit finish
+func (q *Queue) SetWorkers(workers int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.workers = max(workers, 1)
+	q.dispatch()
+}
+
+// Workers returns how many jobs run at once
+func (q *Queue) Workers() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.workers
+}
+
+// Entries returns the running jobs by ID, then the pending jobs in start order
+func (q *Queue) Entries() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]Entry, 0, len(q.running)+len(q.pending))
+	for _, j := range q.running {
+		entries = append(entries, Entry{ID: j.id, Key: j.key, Priority: j.priority})
+	}
+	sort.Slice(entries, func(a, b int) bool { return entries[a].ID < entries[b].ID })
+	for i, j := range q.pending {
+		entries = append(entries, Entry{ID: j.id, Key: j.key, Priority: j.priority, Position: i + 1})
+	}
+	return entries
+}
@@ -0,0 +1,154 @@
+package jobqueue
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitTimeout bounds how long a test waits for a job to start or finish
+const waitTimeout = 5 * time.Second
+
+// wait fails the test unless ch is closed within waitTimeout
+func wait(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(waitTimeout):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// closed reports whether ch is closed
+func closed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// blocker returns a job that reports it has started, then runs until release
+// is closed
+func blocker() (run func(), started <-chan struct{}, release chan struct{}) {
+	s := make(chan struct{})
+	release = make(chan struct{})
+	return func() {
+		close(s)
+		<-release
+	}, s, release
+}
+
+func TestQueuePriorityOrder(t *testing.T) {
+	q := New(1)
+	run, started, release := blocker()
+	q.Submit(1, "a", 0, run)
+	wait(t, started, "job 1 to start")
+
+	var mu sync.Mutex
+	var order []int64
+	record := func(id int64) func() {
+		return func() {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, id)
+		}
+	}
+	q.Submit(2, "b", 0, record(2))
+	q.Submit(3, "c", 10, record(3))
+	q.Submit(4, "d", 0, record(4))
+	last := q.Submit(5, "e", -1, record(5))
+
+	// Higher priority first, then in submission order
+	for id, want := range map[int64]int{1: 0, 2: 2, 3: 1, 4: 3, 5: 4, 6: 0} {
+		if got := q.Position(id); got != want {
+			t.Errorf("Position(%d) = %d, want %d", id, got, want)
+		}
+	}
+
+	close(release)
+	wait(t, last, "job 5 to finish")
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []int64{3, 2, 4, 5}; !reflect.DeepEqual(order, want) {
+		t.Errorf("run order = %v, want %v", order, want)
+	}
+}
+
+func TestQueueOneRunningJobPerKey(t *testing.T) {
+	q := New(2)
+	run, started, release := blocker()
+	first := q.Submit(1, "a", 0, run)
+	wait(t, started, "job 1 to start")
+
+	// Job 2 waits for job 1 of the same key and lets job 3 go first
+	second := q.Submit(2, "a", 10, func() {})
+	third := q.Submit(3, "b", 0, func() {})
+	wait(t, third, "job 3 to finish")
+	if closed(second) {
+		t.Fatal("job 2 ran while job 1 of the same key was running")
+	}
+	if got := q.Position(2); got != 1 {
+		t.Errorf("Position(2) = %d, want 1", got)
+	}
+	want := []Entry{{ID: 1, Key: "a"}, {ID: 2, Key: "a", Priority: 10, Position: 1}}
+	if got := q.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+
+	close(release)
+	wait(t, first, "job 1 to finish")
+	wait(t, second, "job 2 to finish")
+}
+
+func TestQueueRemoveAndDrain(t *testing.T) {
+	q := New(1)
+	run, started, release := blocker()
+	first := q.Submit(1, "a", 0, run)
+	wait(t, started, "job 1 to start")
+
+	ran := make(chan int64, 3)
+	done := make(map[int64]<-chan struct{})
+	for id := int64(2); id <= 4; id++ {
+		done[id] = q.Submit(id, "b", 0, func() { ran <- id })
+	}
+
+	if !q.Remove(3) {
+		t.Error("Remove(3) = false, want true for a pending job")
+	}
+	if !closed(done[3]) {
+		t.Error("job 3 is not done after Remove")
+	}
+	if q.Remove(3) {
+		t.Error("Remove(3) = true for a job already removed")
+	}
+	if q.Remove(1) {
+		t.Error("Remove(1) = true for a running job")
+	}
+	if got := q.Position(4); got != 2 {
+		t.Errorf("Position(4) = %d after Remove(3), want 2", got)
+	}
+
+	if got, want := q.Drain(), []int64{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() = %v, want %v", got, want)
+	}
+	if !closed(done[2]) || !closed(done[4]) {
+		t.Error("drained jobs are not done")
+	}
+	if got := q.Drain(); len(got) != 0 {
+		t.Errorf("second Drain() = %v, want none", got)
+	}
+
+	close(release)
+	wait(t, first, "job 1 to finish")
+	if entries := q.Entries(); len(entries) != 0 {
+		t.Errorf("Entries() = %+v after drain, want none", entries)
+	}
+	select {
+	case id := <-ran:
+		t.Errorf("job %d ran after it was removed", id)
+	default:
+	}
+}
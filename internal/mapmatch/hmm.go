@@ -0,0 +1,195 @@
+package mapmatch
+
+import (
+	"context"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// HMMOptions configures the offline matcher
+type HMMOptions struct {
+	SearchRadiusM float64 // Roads within this distance of a fix are candidates
+	MaxCandidates int     // Candidates kept per fix, closest first
+	SigmaM        float64 // Standard deviation of GPS noise
+	BetaM         float64 // Scale of the difference between route and straight-line distance
+}
+
+// DefaultHMMOptions suits phone GPS sampled every few seconds
+var DefaultHMMOptions = HMMOptions{
+	SearchRadiusM: 50,
+	MaxCandidates: 8,
+	SigmaM:        10,
+	BetaM:         30,
+}
+
+// HMMMatcher matches traces offline against a road network with a hidden
+// Markov model (Newson & Krumm, 2009)
+// The states of each fix are nearby road positions. Emissions favour positions
+// close to the fix; transitions favour routes whose road distance is close to
+// the straight-line distance between fixes. Fixes without nearby roads, or
+// that no road route reaches, break the trace into independently matched parts.
+type HMMMatcher struct {
+	network *RoadNetwork
+	opts    HMMOptions
+}
+
+// NewHMMMatcher creates an offline matcher; zero options take their defaults
+func NewHMMMatcher(network *RoadNetwork, opts HMMOptions) *HMMMatcher {
+	if opts.SearchRadiusM <= 0 {
+		opts.SearchRadiusM = DefaultHMMOptions.SearchRadiusM
+	}
+	if opts.MaxCandidates <= 0 {
+		opts.MaxCandidates = DefaultHMMOptions.MaxCandidates
+	}
+	if opts.SigmaM <= 0 {
+		opts.SigmaM = DefaultHMMOptions.SigmaM
+	}
+	if opts.BetaM <= 0 {
+		opts.BetaM = DefaultHMMOptions.BetaM
+	}
+	return &HMMMatcher{network: network, opts: opts}
+}
+
+// Name returns the matcher name
+func (m *HMMMatcher) Name() string {
+	return "hmm"
+}
+
+// hmmStep holds the Viterbi state of one fix
+type hmmStep struct {
+	index  int               // Trace index
+	cands  []candidate       // Road positions near the fix
+	scores []float64         // Log probability of the best path ending at each candidate
+	back   []int             // Best previous candidate of each candidate
+	paths  [][]spatial.Point // Road path from that previous candidate
+}
+
+// Match snaps a trace to the road network
+func (m *HMMMatcher) Match(ctx context.Context, trace []TracePoint) (*Result, error) {
+	result := &Result{Points: make([]MatchedPoint, len(trace))}
+	for i, p := range trace {
+		result.Points[i] = MatchedPoint{Lat: p.Lat, Lon: p.Lon}
+	}
+
+	var steps []hmmStep
+	var quality float64
+	// flush backtracks the best path of the current part and emits it
+	flush := func() {
+		if len(steps) == 0 {
+			return
+		}
+		last := steps[len(steps)-1]
+		best := 0
+		for k, s := range last.scores {
+			if s > last.scores[best] {
+				best = k
+			}
+		}
+
+		parts := make([][]spatial.Point, len(steps))
+		for s := len(steps) - 1; s >= 0; s-- {
+			step := steps[s]
+			c := step.cands[best]
+			e := m.network.edges[c.edge]
+			result.Points[step.index] = MatchedPoint{
+				Matched:  true,
+				Lat:      c.pos.Lat,
+				Lon:      c.pos.Lon,
+				RoadType: e.roadType,
+				RoadName: e.name,
+			}
+			quality += m.emissionWeight(c.distance)
+			if s > 0 {
+				parts[s] = step.paths[best][1:]
+			} else {
+				parts[s] = []spatial.Point{c.pos}
+			}
+			best = step.back[best]
+		}
+		for _, part := range parts {
+			result.Geometry = append(result.Geometry, part...)
+		}
+		steps = steps[:0]
+	}
+
+	for i, p := range trace {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fix := spatial.Point{Lat: p.Lat, Lon: p.Lon}
+		cands := m.network.candidates(fix, m.opts.SearchRadiusM, m.opts.MaxCandidates)
+		if len(cands) == 0 {
+			flush()
+			result.Geometry = append(result.Geometry, fix)
+			continue
+		}
+
+		emissions := make([]float64, len(cands))
+		for k, c := range cands {
+			emissions[k] = -0.5 * (c.distance / m.opts.SigmaM) * (c.distance / m.opts.SigmaM)
+		}
+
+		step := hmmStep{
+			index:  i,
+			cands:  cands,
+			scores: emissions,
+			back:   make([]int, len(cands)),
+			paths:  make([][]spatial.Point, len(cands)),
+		}
+		if len(steps) == 0 {
+			steps = append(steps, step)
+			continue
+		}
+
+		prev := steps[len(steps)-1]
+		straight := spatial.HaversineDistance(trace[prev.index].Lat, trace[prev.index].Lon, p.Lat, p.Lon)
+		limit := 2*straight + 2*m.opts.SearchRadiusM + 100
+		scores := make([]float64, len(cands))
+		for k := range scores {
+			scores[k] = math.Inf(-1)
+		}
+		for j, a := range prev.cands {
+			distances, paths := m.network.routesFrom(a, cands, limit)
+			for k := range cands {
+				if math.IsInf(distances[k], 1) {
+					continue
+				}
+				s := prev.scores[j] - math.Abs(distances[k]-straight)/m.opts.BetaM + emissions[k]
+				if s > scores[k] {
+					scores[k] = s
+					step.back[k] = j
+					step.paths[k] = paths[k]
+				}
+			}
+		}
+
+		reachable := false
+		for _, s := range scores {
+			if !math.IsInf(s, -1) {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			// No road route from the previous fix: start a new part here
+			flush()
+			steps = append(steps, step)
+			continue
+		}
+		step.scores = scores
+		steps = append(steps, step)
+	}
+	flush()
+
+	if len(trace) > 0 {
+		result.Confidence = quality / float64(len(trace))
+	}
+	return result, nil
+}
+
+// emissionWeight rates a snap distance from 1 (on the road) towards 0
+func (m *HMMMatcher) emissionWeight(distance float64) float64 {
+	return math.Exp(-0.5 * (distance / m.opts.SigmaM) * (distance / m.opts.SigmaM))
+}
@@ -0,0 +1,151 @@
+// Package mapmatch snaps GPS traces to a road network.
+//
+// Matching goes through the Matcher interface so a routing server (OSRM or
+// Valhalla) can be used where one is available, and the offline HMM matcher
+// over a road network file elsewhere. A process-wide default matcher is set
+// at startup and used by the map_matching analyzer.
+package mapmatch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// ErrNoMatcher is returned when no map matcher has been configured
+var ErrNoMatcher = errors.New("no map matcher configured")
+
+// Road types, as stored in road_overlap_stats
+const (
+	RoadTypeHighway  = "HIGHWAY"
+	RoadTypeArterial = "ARTERIAL"
+	RoadTypeLocal    = "LOCAL"
+	RoadTypeUnknown  = "UNKNOWN"
+)
+
+// TracePoint is a GPS fix to be matched
+type TracePoint struct {
+	Lat       float64
+	Lon       float64
+	Timestamp int64 // Unix timestamp
+}
+
+// MatchedPoint is a trace point after matching
+type MatchedPoint struct {
+	Matched  bool    // false when the fix could not be snapped to a road
+	Lat      float64 // Snapped position (the raw fix when unmatched)
+	Lon      float64
+	RoadType string // HIGHWAY, ARTERIAL, LOCAL or UNKNOWN
+	RoadName string
+}
+
+// Result is the outcome of matching one trace
+type Result struct {
+	Points     []MatchedPoint  // One per trace point, in order
+	Geometry   []spatial.Point // Path along the roads; unmatched stretches keep the raw fixes
+	Confidence float64         // 0-1
+}
+
+// Matcher snaps a trace to the road network
+type Matcher interface {
+	Match(ctx context.Context, trace []TracePoint) (*Result, error)
+	Name() string
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultMatcher Matcher
+)
+
+// SetDefaultMatcher sets the matcher used by the map_matching analyzer
+func SetDefaultMatcher(m Matcher) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultMatcher = m
+}
+
+// DefaultMatcher returns the configured matcher, or nil if none was set
+func DefaultMatcher() Matcher {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultMatcher
+}
+
+// RoadTypeOf maps an OSM highway tag or Valhalla road class to a road type
+func RoadTypeOf(class string) string {
+	switch strings.TrimSuffix(strings.ToLower(class), "_link") {
+	case "motorway", "trunk":
+		return RoadTypeHighway
+	case "primary", "secondary":
+		return RoadTypeArterial
+	case "tertiary", "residential", "unclassified", "service", "service_other", "living_street", "road":
+		return RoadTypeLocal
+	default:
+		return RoadTypeUnknown
+	}
+}
+
+// MatchedCount returns the number of trace points snapped to a road
+func (r *Result) MatchedCount() int {
+	n := 0
+	for _, p := range r.Points {
+		if p.Matched {
+			n++
+		}
+	}
+	return n
+}
+
+// Distances splits the trace length into on-road distance per road type and
+// off-road distance
+// A step between two matched points is on the road of the first one and is
+// measured between the snapped positions; any other step is off-road and is
+// measured along the raw trace.
+func (r *Result) Distances(trace []TracePoint) (onRoad map[string]float64, offRoad float64) {
+	onRoad = make(map[string]float64)
+	for i := 0; i+1 < len(trace) && i+1 < len(r.Points); i++ {
+		a, b := r.Points[i], r.Points[i+1]
+		if a.Matched && b.Matched {
+			roadType := a.RoadType
+			if roadType == "" {
+				roadType = RoadTypeUnknown
+			}
+			onRoad[roadType] += spatial.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon)
+			continue
+		}
+		offRoad += spatial.HaversineDistance(trace[i].Lat, trace[i].Lon, trace[i+1].Lat, trace[i+1].Lon)
+	}
+	return onRoad, offRoad
+}
+
+// matchInChunks matches a long trace in windows of at most size points, for
+// servers that limit the points per request
+// Consecutive windows share one point so steps across a window boundary are
+// matched too; the later window's result is kept for the shared point.
+func matchInChunks(ctx context.Context, trace []TracePoint, size int, match func(context.Context, []TracePoint) (*Result, error)) (*Result, error) {
+	if len(trace) <= size {
+		return match(ctx, trace)
+	}
+
+	merged := &Result{}
+	var weighted, total float64
+	for start := 0; start < len(trace)-1; start += size - 1 {
+		end := min(start+size, len(trace))
+		part, err := match(ctx, trace[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if start > 0 {
+			merged.Points = merged.Points[:len(merged.Points)-1]
+		}
+		merged.Points = append(merged.Points, part.Points...)
+		merged.Geometry = append(merged.Geometry, part.Geometry...)
+		weighted += part.Confidence * float64(end-start)
+		total += float64(end - start)
+	}
+	merged.Confidence = weighted / total
+	return merged, nil
+}
@@ -0,0 +1,317 @@
+package mapmatch
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// roadNetworkCellDeg is the grid index cell size in degrees (~500 m)
+const roadNetworkCellDeg = 0.005
+
+// RoadNetwork is a road graph loaded from GeoJSON line features
+// Lines sharing a vertex are connected there; all roads are treated as two-way.
+type RoadNetwork struct {
+	nodes     []spatial.Point
+	edges     []roadEdge
+	adjacency [][]int           // node -> incident edges
+	index     map[cellKey][]int // grid cell -> edges whose bounding box overlaps it
+}
+
+type roadEdge struct {
+	from, to int
+	length   float64 // meters
+	roadType string
+	name     string
+}
+
+type cellKey struct {
+	x, y int
+}
+
+type geoJSONCollection struct {
+	Features []struct {
+		Properties map[string]interface{} `json:"properties"`
+		Geometry   *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// LoadRoadNetwork loads a GeoJSON FeatureCollection of LineString and
+// MultiLineString roads, e.g. an OSM extract exported with `osmium export`
+// The highway property gives each road's type, the name (or ref) property its name.
+func LoadRoadNetwork(path string) (*RoadNetwork, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read road network: %w", err)
+	}
+
+	var collection geoJSONCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse road network: %w", err)
+	}
+
+	n := &RoadNetwork{index: make(map[cellKey][]int)}
+	nodeIDs := make(map[[2]int64]int)
+	node := func(coord []float64) int {
+		key := [2]int64{int64(math.Round(coord[0] * 1e6)), int64(math.Round(coord[1] * 1e6))}
+		if id, ok := nodeIDs[key]; ok {
+			return id
+		}
+		id := len(n.nodes)
+		nodeIDs[key] = id
+		n.nodes = append(n.nodes, spatial.Point{Lat: coord[1], Lon: coord[0]})
+		n.adjacency = append(n.adjacency, nil)
+		return id
+	}
+
+	for i, f := range collection.Features {
+		if f.Geometry == nil {
+			continue
+		}
+
+		var lines [][][]float64
+		switch f.Geometry.Type {
+		case "LineString":
+			var line [][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &line); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid linestring: %w", i, err)
+			}
+			lines = append(lines, line)
+		case "MultiLineString":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &lines); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid multilinestring: %w", i, err)
+			}
+		default:
+			continue
+		}
+
+		roadType := RoadTypeOf(stringProperty(f.Properties, "highway"))
+		name := stringProperty(f.Properties, "name")
+		if name == "" {
+			name = stringProperty(f.Properties, "ref")
+		}
+
+		for _, line := range lines {
+			prev := -1
+			for _, coord := range line {
+				if len(coord) < 2 {
+					continue
+				}
+				id := node(coord)
+				if prev >= 0 && prev != id {
+					n.addEdge(prev, id, roadType, name)
+				}
+				prev = id
+			}
+		}
+	}
+
+	if len(n.edges) == 0 {
+		return nil, fmt.Errorf("road network contains no usable line features")
+	}
+	return n, nil
+}
+
+// EdgeCount returns the number of road edges (vertex-to-vertex pieces)
+func (n *RoadNetwork) EdgeCount() int {
+	return len(n.edges)
+}
+
+func (n *RoadNetwork) addEdge(from, to int, roadType, name string) {
+	a, b := n.nodes[from], n.nodes[to]
+	idx := len(n.edges)
+	n.edges = append(n.edges, roadEdge{
+		from:     from,
+		to:       to,
+		length:   spatial.HaversineDistance(a.Lat, a.Lon, b.Lat, b.Lon),
+		roadType: roadType,
+		name:     name,
+	})
+	n.adjacency[from] = append(n.adjacency[from], idx)
+	n.adjacency[to] = append(n.adjacency[to], idx)
+
+	minKey := cellOf(math.Min(a.Lat, b.Lat), math.Min(a.Lon, b.Lon))
+	maxKey := cellOf(math.Max(a.Lat, b.Lat), math.Max(a.Lon, b.Lon))
+	for x := minKey.x; x <= maxKey.x; x++ {
+		for y := minKey.y; y <= maxKey.y; y++ {
+			k := cellKey{x, y}
+			n.index[k] = append(n.index[k], idx)
+		}
+	}
+}
+
+func cellOf(lat, lon float64) cellKey {
+	return cellKey{
+		x: int(math.Floor(lon / roadNetworkCellDeg)),
+		y: int(math.Floor(lat / roadNetworkCellDeg)),
+	}
+}
+
+// candidate is a position on a road edge near a fix
+type candidate struct {
+	edge     int
+	offset   float64 // Distance from the edge's from node, meters
+	pos      spatial.Point
+	distance float64 // Distance from the fix, meters
+}
+
+// candidates returns the road positions within radius meters of p, closest first
+// Each edge contributes its closest position.
+func (n *RoadNetwork) candidates(p spatial.Point, radius float64, limit int) []candidate {
+	dLat := radius / 111320
+	dLon := dLat / math.Max(math.Cos(p.Lat*math.Pi/180), 0.01)
+	minKey := cellOf(p.Lat-dLat, p.Lon-dLon)
+	maxKey := cellOf(p.Lat+dLat, p.Lon+dLon)
+
+	var found []candidate
+	seen := make(map[int]bool)
+	for x := minKey.x; x <= maxKey.x; x++ {
+		for y := minKey.y; y <= maxKey.y; y++ {
+			for _, idx := range n.index[cellKey{x, y}] {
+				if seen[idx] {
+					continue
+				}
+				seen[idx] = true
+
+				e := n.edges[idx]
+				pos, t, distance := project(p, n.nodes[e.from], n.nodes[e.to])
+				if distance <= radius {
+					found = append(found, candidate{edge: idx, offset: t * e.length, pos: pos, distance: distance})
+				}
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// project returns the point of segment a-b closest to p, its fraction along
+// the segment and its distance from p in meters
+func project(p, a, b spatial.Point) (spatial.Point, float64, float64) {
+	k := math.Cos(p.Lat * math.Pi / 180)
+	ax, ay := (a.Lon-p.Lon)*k, a.Lat-p.Lat
+	bx, by := (b.Lon-p.Lon)*k, b.Lat-p.Lat
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if lengthSq := dx*dx + dy*dy; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lengthSq))
+	}
+	pos := spatial.Point{Lat: a.Lat + t*(b.Lat-a.Lat), Lon: a.Lon + t*(b.Lon-a.Lon)}
+	return pos, t, spatial.HaversineDistance(p.Lat, p.Lon, pos.Lat, pos.Lon)
+}
+
+// routesFrom returns the road distance and path from candidate a to each
+// target, searching at most limit meters; unreachable targets get +Inf
+func (n *RoadNetwork) routesFrom(a candidate, targets []candidate, limit float64) ([]float64, [][]spatial.Point) {
+	ea := n.edges[a.edge]
+	dist := map[int]float64{ea.from: a.offset, ea.to: ea.length - a.offset}
+	prev := map[int]int{ea.from: -1, ea.to: -1}
+	done := make(map[int]bool)
+
+	h := &nodeHeap{{ea.from, a.offset}, {ea.to, ea.length - a.offset}}
+	heap.Init(h)
+	for h.Len() > 0 {
+		item := heap.Pop(h).(nodeItem)
+		if done[item.node] || item.dist > dist[item.node] {
+			continue
+		}
+		if item.dist > limit {
+			break
+		}
+		done[item.node] = true
+		for _, idx := range n.adjacency[item.node] {
+			e := n.edges[idx]
+			next := e.to
+			if next == item.node {
+				next = e.from
+			}
+			d := item.dist + e.length
+			if old, ok := dist[next]; !ok || d < old {
+				dist[next] = d
+				prev[next] = item.node
+				heap.Push(h, nodeItem{next, d})
+			}
+		}
+	}
+
+	distances := make([]float64, len(targets))
+	paths := make([][]spatial.Point, len(targets))
+	for i, b := range targets {
+		distances[i] = math.Inf(1)
+		if b.edge == a.edge {
+			distances[i] = math.Abs(b.offset - a.offset)
+			paths[i] = []spatial.Point{a.pos, b.pos}
+			continue
+		}
+
+		eb := n.edges[b.edge]
+		for _, end := range []struct {
+			node int
+			rest float64
+		}{{eb.from, b.offset}, {eb.to, eb.length - b.offset}} {
+			if !done[end.node] {
+				continue
+			}
+			if d := dist[end.node] + end.rest; d < distances[i] {
+				distances[i] = d
+				paths[i] = n.path(a.pos, prev, end.node, b.pos)
+			}
+		}
+	}
+	return distances, paths
+}
+
+// path rebuilds the route ending at node from the predecessor map
+func (n *RoadNetwork) path(start spatial.Point, prev map[int]int, node int, end spatial.Point) []spatial.Point {
+	var nodes []int
+	for ; node >= 0; node = prev[node] {
+		nodes = append(nodes, node)
+	}
+
+	path := make([]spatial.Point, 0, len(nodes)+2)
+	path = append(path, start)
+	for i := len(nodes) - 1; i >= 0; i-- {
+		path = append(path, n.nodes[nodes[i]])
+	}
+	return append(path, end)
+}
+
+type nodeItem struct {
+	node int
+	dist float64
+}
+
+// nodeHeap is a min-heap of graph nodes by distance
+type nodeHeap []nodeItem
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(nodeItem)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+func stringProperty(props map[string]interface{}, key string) string {
+	if v, ok := props[key]; ok && v != nil {
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+	return ""
+}
@@ -0,0 +1,209 @@
+package mapmatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// osrmMaxPoints is the default coordinate limit of osrm-routed's match service
+const osrmMaxPoints = 100
+
+// OSRMMatcher matches traces with the match service of an OSRM server
+// OSRM does not report road classes, so steps on roads flagged as motorway are
+// HIGHWAY, steps on roads with a route number (G4, S15, ...) are ARTERIAL and
+// the rest are LOCAL.
+type OSRMMatcher struct {
+	baseURL   string
+	profile   string
+	radiusM   float64
+	maxPoints int
+	client    *http.Client
+}
+
+// NewOSRMMatcher creates a matcher for the OSRM server at baseURL
+// profile is the routing profile, "driving" when empty.
+func NewOSRMMatcher(baseURL, profile string) *OSRMMatcher {
+	if profile == "" {
+		profile = "driving"
+	}
+	return &OSRMMatcher{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		profile:   profile,
+		radiusM:   25,
+		maxPoints: osrmMaxPoints,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the matcher name
+func (m *OSRMMatcher) Name() string {
+	return "osrm"
+}
+
+type osrmResponse struct {
+	Code        string            `json:"code"`
+	Message     string            `json:"message"`
+	Tracepoints []*osrmTracepoint `json:"tracepoints"` // null for fixes that were not matched
+	Matchings   []struct {
+		Confidence float64 `json:"confidence"`
+		Geometry   string  `json:"geometry"`
+		Legs       []struct {
+			Steps []struct {
+				Distance      float64 `json:"distance"`
+				Ref           string  `json:"ref"`
+				Intersections []struct {
+					Classes []string `json:"classes"`
+				} `json:"intersections"`
+			} `json:"steps"`
+		} `json:"legs"`
+	} `json:"matchings"`
+}
+
+type osrmTracepoint struct {
+	MatchingsIndex int        `json:"matchings_index"`
+	WaypointIndex  int        `json:"waypoint_index"`
+	Location       [2]float64 `json:"location"` // [lon, lat]
+	Name           string     `json:"name"`
+}
+
+// Match snaps a trace to the road network
+func (m *OSRMMatcher) Match(ctx context.Context, trace []TracePoint) (*Result, error) {
+	return matchInChunks(ctx, trace, m.maxPoints, m.match)
+}
+
+func (m *OSRMMatcher) match(ctx context.Context, trace []TracePoint) (*Result, error) {
+	coords := make([]string, len(trace))
+	timestamps := make([]string, len(trace))
+	radiuses := make([]string, len(trace))
+	for i, p := range trace {
+		coords[i] = strconv.FormatFloat(p.Lon, 'f', 6, 64) + "," + strconv.FormatFloat(p.Lat, 'f', 6, 64)
+		timestamps[i] = strconv.FormatInt(p.Timestamp, 10)
+		radiuses[i] = strconv.FormatFloat(m.radiusM, 'f', 0, 64)
+	}
+
+	query := url.Values{}
+	query.Set("timestamps", strings.Join(timestamps, ";"))
+	query.Set("radiuses", strings.Join(radiuses, ";"))
+	query.Set("geometries", "polyline")
+	query.Set("overview", "full")
+	query.Set("steps", "true")
+	query.Set("gaps", "split")
+	endpoint := fmt.Sprintf("%s/match/v1/%s/%s?%s", m.baseURL, m.profile, strings.Join(coords, ";"), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OSRM request: %w", err)
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OSRM: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body osrmResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode OSRM response (status %d): %w", resp.StatusCode, err)
+	}
+
+	result := &Result{Points: make([]MatchedPoint, len(trace))}
+	// NoMatch means no fix is near a road: the whole trace is off-road
+	if body.Code == "NoMatch" {
+		for i, p := range trace {
+			result.Points[i] = MatchedPoint{Lat: p.Lat, Lon: p.Lon}
+			result.Geometry = append(result.Geometry, spatial.Point{Lat: p.Lat, Lon: p.Lon})
+		}
+		return result, nil
+	}
+	if body.Code != "Ok" {
+		return nil, fmt.Errorf("OSRM match failed: %s %s", body.Code, body.Message)
+	}
+
+	// Road type of each leg: leg j of a matching joins its waypoints j and j+1
+	legTypes := make([][]string, len(body.Matchings))
+	for mi, matching := range body.Matchings {
+		legTypes[mi] = make([]string, len(matching.Legs))
+		for li, leg := range matching.Legs {
+			byType := make(map[string]float64)
+			for _, step := range leg.Steps {
+				roadType := RoadTypeLocal
+				if step.Ref != "" {
+					roadType = RoadTypeArterial
+				}
+				for _, in := range step.Intersections {
+					for _, class := range in.Classes {
+						if class == "motorway" {
+							roadType = RoadTypeHighway
+						}
+					}
+				}
+				byType[roadType] += step.Distance
+			}
+			legTypes[mi][li] = DominantRoadType(byType)
+		}
+	}
+
+	var weighted float64
+	emitted := make(map[int]bool)
+	for i, p := range trace {
+		var tp *osrmTracepoint
+		if i < len(body.Tracepoints) {
+			tp = body.Tracepoints[i]
+		}
+		if tp == nil || tp.MatchingsIndex >= len(body.Matchings) {
+			result.Points[i] = MatchedPoint{Lat: p.Lat, Lon: p.Lon}
+			result.Geometry = append(result.Geometry, spatial.Point{Lat: p.Lat, Lon: p.Lon})
+			continue
+		}
+
+		roadType := RoadTypeUnknown
+		legs := legTypes[tp.MatchingsIndex]
+		switch {
+		case tp.WaypointIndex < len(legs):
+			roadType = legs[tp.WaypointIndex]
+		case len(legs) > 0:
+			roadType = legs[len(legs)-1]
+		}
+		result.Points[i] = MatchedPoint{
+			Matched:  true,
+			Lat:      tp.Location[1],
+			Lon:      tp.Location[0],
+			RoadType: roadType,
+			RoadName: tp.Name,
+		}
+
+		// Each matching's geometry is emitted at its first fix
+		if !emitted[tp.MatchingsIndex] {
+			emitted[tp.MatchingsIndex] = true
+			matching := body.Matchings[tp.MatchingsIndex]
+			geometry, err := spatial.DecodePolyline(matching.Geometry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid OSRM geometry: %w", err)
+			}
+			result.Geometry = append(result.Geometry, geometry...)
+			weighted += matching.Confidence
+		}
+	}
+	if len(emitted) > 0 {
+		result.Confidence = weighted / float64(len(emitted))
+	}
+	return result, nil
+}
+
+// DominantRoadType returns the road type with the longest distance
+func DominantRoadType(distances map[string]float64) string {
+	best, bestDistance := RoadTypeUnknown, 0.0
+	for _, roadType := range []string{RoadTypeHighway, RoadTypeArterial, RoadTypeLocal, RoadTypeUnknown} {
+		if distances[roadType] > bestDistance {
+			best, bestDistance = roadType, distances[roadType]
+		}
+	}
+	return best
+}
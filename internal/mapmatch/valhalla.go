@@ -0,0 +1,178 @@
+package mapmatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// valhallaMaxPoints keeps requests well below Valhalla's default shape limit
+const valhallaMaxPoints = 500
+
+// ValhallaMatcher matches traces with the trace_attributes service of a
+// Valhalla server, which reports the road class of every matched edge
+type ValhallaMatcher struct {
+	baseURL   string
+	costing   string
+	maxPoints int
+	client    *http.Client
+}
+
+// NewValhallaMatcher creates a matcher for the Valhalla server at baseURL
+// costing is the costing model, "auto" when empty.
+func NewValhallaMatcher(baseURL, costing string) *ValhallaMatcher {
+	if costing == "" {
+		costing = "auto"
+	}
+	return &ValhallaMatcher{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		costing:   costing,
+		maxPoints: valhallaMaxPoints,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the matcher name
+func (m *ValhallaMatcher) Name() string {
+	return "valhalla"
+}
+
+type valhallaShapePoint struct {
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	Time int64   `json:"time"`
+}
+
+type valhallaRequest struct {
+	Shape      []valhallaShapePoint `json:"shape"`
+	Costing    string               `json:"costing"`
+	ShapeMatch string               `json:"shape_match"`
+	Filters    struct {
+		Attributes []string `json:"attributes"`
+		Action     string   `json:"action"`
+	} `json:"filters"`
+}
+
+type valhallaResponse struct {
+	Shape string `json:"shape"` // Encoded polyline, precision 1e6
+	Edges []struct {
+		RoadClass string   `json:"road_class"`
+		Names     []string `json:"names"`
+	} `json:"edges"`
+	MatchedPoints []struct {
+		Lat       float64 `json:"lat"`
+		Lon       float64 `json:"lon"`
+		Type      string  `json:"type"` // matched, interpolated, unmatched
+		EdgeIndex *int    `json:"edge_index"`
+	} `json:"matched_points"`
+	ConfidenceScore float64 `json:"confidence_score"`
+	ErrorCode       int     `json:"error_code"`
+	Error           string  `json:"error"`
+}
+
+// valhallaNoMatchCodes are the errors Valhalla returns when no fix is near a road
+var valhallaNoMatchCodes = map[int]bool{
+	171: true, // No suitable edges near location
+	442: true, // No data found for location
+	443: true, // Map Match algorithm failed to find path
+	444: true, // Map Match algorithm failed to snap the shape points to the correct shape
+}
+
+// Match snaps a trace to the road network
+func (m *ValhallaMatcher) Match(ctx context.Context, trace []TracePoint) (*Result, error) {
+	return matchInChunks(ctx, trace, m.maxPoints, m.match)
+}
+
+func (m *ValhallaMatcher) match(ctx context.Context, trace []TracePoint) (*Result, error) {
+	request := valhallaRequest{
+		Shape:      make([]valhallaShapePoint, len(trace)),
+		Costing:    m.costing,
+		ShapeMatch: "map_snap",
+	}
+	for i, p := range trace {
+		request.Shape[i] = valhallaShapePoint{Lat: p.Lat, Lon: p.Lon, Time: p.Timestamp}
+	}
+	request.Filters.Attributes = []string{
+		"shape", "edge.road_class", "edge.names",
+		"matched.point", "matched.type", "matched.edge_index",
+	}
+	request.Filters.Action = "include"
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Valhalla request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.baseURL+"/trace_attributes", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Valhalla request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Valhalla: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Valhalla response: %w", err)
+	}
+	var body valhallaResponse
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode Valhalla response (status %d): %w", resp.StatusCode, err)
+	}
+
+	result := &Result{Points: make([]MatchedPoint, len(trace))}
+	if resp.StatusCode != http.StatusOK {
+		if !valhallaNoMatchCodes[body.ErrorCode] {
+			return nil, fmt.Errorf("Valhalla trace_attributes failed: %d %s", body.ErrorCode, body.Error)
+		}
+		// The whole trace is off-road
+		for i, p := range trace {
+			result.Points[i] = MatchedPoint{Lat: p.Lat, Lon: p.Lon}
+			result.Geometry = append(result.Geometry, spatial.Point{Lat: p.Lat, Lon: p.Lon})
+		}
+		return result, nil
+	}
+
+	for i, p := range trace {
+		result.Points[i] = MatchedPoint{Lat: p.Lat, Lon: p.Lon}
+		if i >= len(body.MatchedPoints) {
+			continue
+		}
+		mp := body.MatchedPoints[i]
+		if mp.Type == "unmatched" {
+			continue
+		}
+		point := MatchedPoint{Matched: true, Lat: mp.Lat, Lon: mp.Lon, RoadType: RoadTypeUnknown}
+		if mp.EdgeIndex != nil && *mp.EdgeIndex >= 0 && *mp.EdgeIndex < len(body.Edges) {
+			edge := body.Edges[*mp.EdgeIndex]
+			point.RoadType = RoadTypeOf(edge.RoadClass)
+			if len(edge.Names) > 0 {
+				point.RoadName = edge.Names[0]
+			}
+		}
+		result.Points[i] = point
+	}
+
+	geometry, err := spatial.DecodePolylinePrecision(body.Shape, 6)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Valhalla shape: %w", err)
+	}
+	result.Geometry = geometry
+
+	// Older servers do not report a confidence score; fall back to the matched share
+	result.Confidence = body.ConfidenceScore
+	if result.Confidence <= 0 && len(trace) > 0 {
+		result.Confidence = float64(result.MatchedCount()) / float64(len(trace))
+	}
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package metrics
+
+// Metric families instrumented throughout the application
+var (
+	// HTTPRequestDuration is observed by the request middleware per matched route
+	HTTPRequestDuration = NewHistogramVec(
+		"records_http_request_duration_seconds",
+		"HTTP request latency by method, route and status code.",
+		DefBuckets, "method", "route", "status")
+
+	// AnalyzerRunDuration is observed when an analysis task completes or fails
+	AnalyzerRunDuration = NewHistogramVec(
+		"records_analyzer_run_duration_seconds",
+		"Duration of analyzer runs by skill and final status.",
+		[]float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200}, "skill", "status")
+
+	// AnalyzerRowsProcessed counts the records analyzers reported as processed
+	AnalyzerRowsProcessed = NewCounterVec(
+		"records_analyzer_rows_processed_total",
+		"Records processed by analyzer runs.",
+		"skill")
+
+	// DBQueryDuration is observed for every statement executed on a connection pool
+	DBQueryDuration = NewHistogramVec(
+		"records_db_query_duration_seconds",
+		"SQLite statement latency by connection pool and operation.",
+		[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}, "pool", "op")
+)
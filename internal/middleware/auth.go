@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -72,3 +74,74 @@ func JWTAuth(secret string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// IngestCredentials holds the credentials accepted by IngestAuth; they can
+// be replaced while the server runs
+type IngestCredentials struct {
+	mu       sync.RWMutex
+	user     string
+	password string
+	token    string
+}
+
+// NewIngestCredentials creates the ingest credentials
+func NewIngestCredentials(user, password, token string) *IngestCredentials {
+	return &IngestCredentials{user: user, password: password, token: token}
+}
+
+// Set replaces the ingest credentials
+func (ic *IngestCredentials) Set(user, password, token string) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	ic.user, ic.password, ic.token = user, password, token
+}
+
+func (ic *IngestCredentials) get() (user, password, token string) {
+	ic.mu.RLock()
+	defer ic.mu.RUnlock()
+	return ic.user, ic.password, ic.token
+}
+
+// IngestAuth middleware guards the live ingestion endpoints, whose clients
+// (phone apps) support either HTTP Basic auth or a static token
+// The token is accepted as a Bearer token or in the token or access_token
+// query parameters. With neither user nor token configured every request passes.
+func IngestAuth(credentials *IngestCredentials) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, password, token := credentials.get()
+		if user == "" && token == "" {
+			c.Next()
+			return
+		}
+
+		if token != "" {
+			given := c.Query("token")
+			if given == "" {
+				given = c.Query("access_token")
+			}
+			if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				given = strings.TrimPrefix(auth, "Bearer ")
+			}
+			if given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		if user != "" {
+			u, p, ok := c.Request.BasicAuth()
+			if ok && subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1 {
+				c.Set("user", u)
+				c.Next()
+				return
+			}
+			c.Header("WWW-Authenticate", `Basic realm="records"`)
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    http.StatusUnauthorized,
+			"message": "Invalid ingest credentials",
+		})
+		c.Abort()
+	}
+}
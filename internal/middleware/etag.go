@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag middleware answers conditional GET requests from a version of the data
+// behind the route
+// version returns a string that changes whenever the response would; the ETag
+// hashes it together with the request URI. Requests with a matching
+// If-None-Match get 304 Not Modified without running the handler. An empty
+// version (or an error) skips ETag handling for the request.
+func ETag(version func(c *gin.Context) (string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		v, err := version(c)
+		if err != nil {
+			log.Printf("ETag version for %s failed: %v", c.Request.URL.Path, err)
+		}
+		if err != nil || v == "" {
+			c.Next()
+			return
+		}
+
+		sum := sha256.Sum256([]byte(v + "|" + c.Request.URL.RequestURI()))
+		tag := `"` + hex.EncodeToString(sum[:10]) + `"`
+
+		if etagMatches(c.GetHeader("If-None-Match"), tag) {
+			c.Header("ETag", tag)
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+
+		c.Writer = &etagWriter{ResponseWriter: c.Writer, tag: tag}
+		c.Next()
+	}
+}
+
+// etagMatches implements the weak comparison of If-None-Match
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter adds the ETag to successful responses only, so error responses
+// are never revalidated as if they were the data
+type etagWriter struct {
+	gin.ResponseWriter
+	tag string
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if code == http.StatusOK {
+		w.Header().Set("ETag", w.tag)
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
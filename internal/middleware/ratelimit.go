@@ -1,27 +1,105 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RateLimiter implements a simple rate limiter
+// callerContextKey is the gin context key of the identified caller
+const callerContextKey = "caller"
+
+// APIKeys holds the configured API keys, whose callers are rate limited per
+// key rather than per IP; they can be replaced while the server runs
+type APIKeys struct {
+	mu   sync.RWMutex
+	keys []string
+}
+
+// NewAPIKeys creates the set of configured API keys
+func NewAPIKeys(keys []string) *APIKeys {
+	ak := &APIKeys{}
+	ak.Set(keys)
+	return ak
+}
+
+// Set replaces the configured API keys
+func (ak *APIKeys) Set(keys []string) {
+	ak.mu.Lock()
+	defer ak.mu.Unlock()
+	ak.keys = append([]string(nil), keys...)
+}
+
+// valid reports whether key is a configured API key
+func (ak *APIKeys) valid(key string) bool {
+	ak.mu.RLock()
+	defer ak.mu.RUnlock()
+	for _, k := range ak.keys {
+		if k != "" && subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// IdentifyCaller middleware identifies the client of a request for rate
+// limiting and logging
+// Requests with an X-API-Key header holding a configured key are keyed by a
+// hash of the key, so the key itself never ends up in logs; other requests,
+// including those with an unknown key, are keyed by client IP, so that
+// rotating the header does not get a fresh bucket.
+func IdentifyCaller(keys *APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := "ip:" + c.ClientIP()
+		if key := c.GetHeader("X-API-Key"); key != "" && keys.valid(key) {
+			sum := sha256.Sum256([]byte(key))
+			caller = "key:" + hex.EncodeToString(sum[:])[:12]
+		}
+		c.Set(callerContextKey, caller)
+		c.Next()
+	}
+}
+
+// Caller returns the client of a request as identified by IdentifyCaller,
+// or its client IP when the request was not identified
+func Caller(c *gin.Context) string {
+	if caller := c.GetString(callerContextKey); caller != "" {
+		return caller
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// tokenBucket holds the tokens of one caller
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter implements a token bucket rate limiter per caller
 type RateLimiter struct {
-	requests map[string][]time.Time
-	mu       sync.Mutex
-	limit    int           // Maximum requests per window
-	window   time.Duration // Time window
+	buckets map[string]*tokenBucket
+	mu      sync.Mutex
+	rate    float64 // Tokens added per second
+	burst   float64 // Bucket capacity
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+// NewRateLimiter creates a new rate limiter refilling rate tokens per second
+// up to burst tokens
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
 	rl := &RateLimiter{
-		requests: make(map[string][]time.Time),
-		limit:    limit,
-		window:   window,
+		buckets: make(map[string]*tokenBucket),
+		rate:    rate,
+		burst:   float64(burst),
 	}
 
 	// Start cleanup goroutine
@@ -30,74 +108,78 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	return rl
 }
 
-// cleanup removes old entries periodically
+// SetLimit changes the refill rate and capacity of every bucket
+// A non-positive rate disables limiting.
+func (rl *RateLimiter) SetLimit(rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate, rl.burst = rate, float64(burst)
+}
+
+// idleAfter is how long a bucket takes to refill completely; a bucket idle
+// for longer is equivalent to a new one
+func (rl *RateLimiter) idleAfter() time.Duration {
+	if rl.rate <= 0 {
+		return 0
+	}
+	return time.Duration(rl.burst / rl.rate * float64(time.Second))
+}
+
+// cleanup removes full buckets periodically
 func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(rl.window)
+	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		rl.mu.Lock()
 		now := time.Now()
-		for ip, times := range rl.requests {
-			// Remove times older than window
-			var valid []time.Time
-			for _, t := range times {
-				if now.Sub(t) < rl.window {
-					valid = append(valid, t)
-				}
-			}
-			if len(valid) == 0 {
-				delete(rl.requests, ip)
-			} else {
-				rl.requests[ip] = valid
+		for caller, b := range rl.buckets {
+			if now.Sub(b.last) >= rl.idleAfter() {
+				delete(rl.buckets, caller)
 			}
 		}
 		rl.mu.Unlock()
 	}
 }
 
-// Allow checks if a request from the given IP is allowed
-func (rl *RateLimiter) Allow(ip string) bool {
+// Allow takes a token from the caller's bucket
+// When the bucket is empty it returns false and the time until the next token.
+func (rl *RateLimiter) Allow(caller string) (bool, time.Duration) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	if rl.rate <= 0 {
+		return true, 0
+	}
 
 	now := time.Now()
-
-	// Get request times for this IP
-	times, exists := rl.requests[ip]
+	b, exists := rl.buckets[caller]
 	if !exists {
-		rl.requests[ip] = []time.Time{now}
-		return true
-	}
-
-	// Remove times older than window
-	var valid []time.Time
-	for _, t := range times {
-		if now.Sub(t) < rl.window {
-			valid = append(valid, t)
-		}
+		b = &tokenBucket{tokens: rl.burst, last: now}
+		rl.buckets[caller] = b
+	} else {
+		b.tokens = math.Min(rl.burst, b.tokens+now.Sub(b.last).Seconds()*rl.rate)
+		b.last = now
 	}
 
-	// Check if limit exceeded
-	if len(valid) >= rl.limit {
-		return false
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / rl.rate * float64(time.Second))
+		return false, wait
 	}
 
-	// Add current request
-	valid = append(valid, now)
-	rl.requests[ip] = valid
-
-	return true
+	b.tokens--
+	return true, 0
 }
 
-// RateLimit middleware limits requests per IP
-func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
-	limiter := NewRateLimiter(limit, window)
-
+// RateLimit middleware limits requests per caller with the limiter's token buckets
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
-
-		if !limiter.Allow(ip) {
+		allowed, wait := limiter.Allow(Caller(c))
+		if !allowed {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"code":    http.StatusTooManyRequests,
 				"message": "Rate limit exceeded. Please try again later.",
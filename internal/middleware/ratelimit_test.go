@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// limitedRouter returns a router allowing burst requests per caller, with
+// no refill during the test
+func limitedRouter(keys *APIKeys, burst int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(IdentifyCaller(keys))
+	r.Use(RateLimit(NewRateLimiter(0.001, burst)))
+	r.GET("/stats", func(c *gin.Context) { c.String(http.StatusOK, Caller(c)) })
+	return r
+}
+
+// get sends a request from the client at 192.0.2.1 with the X-API-Key
+// header key, if not empty, and returns its status
+func get(r *gin.Engine, key string) int {
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestRateLimitUnknownAPIKeysShareTheIPBucket(t *testing.T) {
+	r := limitedRouter(NewAPIKeys([]string{"configured"}), 3)
+
+	// A new unknown key on every request is still limited by client IP
+	for i := 0; i < 3; i++ {
+		if code := get(r, fmt.Sprintf("random-%d", i)); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, code)
+		}
+	}
+	if code := get(r, "random-3"); code != http.StatusTooManyRequests {
+		t.Errorf("request with a rotated unknown key: status %d, want 429", code)
+	}
+	if code := get(r, ""); code != http.StatusTooManyRequests {
+		t.Errorf("request without a key: status %d, want 429", code)
+	}
+
+	// A configured key has a bucket of its own
+	if code := get(r, "configured"); code != http.StatusOK {
+		t.Errorf("request with a configured key: status %d, want 200", code)
+	}
+}
+
+func TestIdentifyCaller(t *testing.T) {
+	keys := NewAPIKeys([]string{"configured"})
+	r := limitedRouter(keys, 100)
+	caller := func(key string) string {
+		req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	if got := caller("unknown"); got != "ip:192.0.2.1" {
+		t.Errorf("caller with an unknown key = %q, want the client IP", got)
+	}
+	got := caller("configured")
+	if got[:4] != "key:" || got == "key:configured" {
+		t.Errorf("caller with a configured key = %q, want a hash of the key", got)
+	}
+
+	// Keys replaced on reload apply to the next request
+	keys.Set(nil)
+	if got := caller("configured"); got != "ip:192.0.2.1" {
+		t.Errorf("caller with a removed key = %q, want the client IP", got)
+	}
+}
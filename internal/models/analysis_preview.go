@@ -0,0 +1,28 @@
+package models
+
+import "errors"
+
+// ErrInvalidPreview is returned for a preview request naming an analyzer that
+// cannot preview, an invalid time window or invalid thresholds
+var ErrInvalidPreview = errors.New("invalid preview request")
+
+// AnalysisPreviewRequest asks for an analyzer to run on a time window
+// without writing its results
+type AnalysisPreviewRequest struct {
+	Analyzer   string                 `json:"analyzer" binding:"required"`
+	StartTime  int64                  `json:"start_time"`
+	EndTime    int64                  `json:"end_time"`   // Exclusive, at most 31 days after start_time
+	Thresholds map[string]interface{} `json:"thresholds"` // Overrides the analyzer's section of the default threshold profile
+}
+
+// AnalysisPreview is what an analyzer would derive from a time window
+type AnalysisPreview struct {
+	Analyzer   string      `json:"analyzer"`
+	StartTime  int64       `json:"start_time"`
+	EndTime    int64       `json:"end_time"`
+	Thresholds interface{} `json:"thresholds"` // All thresholds the preview ran with
+	Inputs     int         `json:"inputs"`     // Track points or rows read
+	Count      int         `json:"count"`
+	Results    interface{} `json:"results"` // In the analyzer's own shape
+	DurationMs int64       `json:"duration_ms"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // AnalysisTask represents an analysis task for trajectory processing
 type AnalysisTask struct {
@@ -11,9 +14,11 @@ type AnalysisTask struct {
 	TaskType  string `json:"task_type" db:"mode"`        // INCREMENTAL, FULL_RECOMPUTE
 
 	// Status
-	Status          string `json:"status" db:"status"`                       // pending, running, completed, failed
+	Status          string `json:"status" db:"status"`                       // pending, running, completed, failed, interrupted
 	ProgressPercent int    `json:"progress_percent" db:"progress_percent"`
 	ETASeconds      *int   `json:"eta_seconds,omitempty" db:"eta_seconds"`
+	Priority        int    `json:"priority" db:"priority"`             // TaskPriority*; higher priorities leave the queue first
+	QueuePosition   *int   `json:"queue_position,omitempty" db:"-"`    // 1-based position in the job queue while pending
 
 	// Input parameters
 	ParamsJSON         *string `json:"params_json,omitempty" db:"params_json"`
@@ -25,6 +30,7 @@ type AnalysisTask struct {
 	FailedPoints    int    `json:"failed_points" db:"failed_points"`
 	StartTime       *int64 `json:"start_time,omitempty" db:"start_time"`     // Unix timestamp
 	EndTime         *int64 `json:"end_time,omitempty" db:"end_time"`         // Unix timestamp
+	ResumeCount     int    `json:"resume_count" db:"resume_count"`          // Times started again after an interruption
 
 	// Results
 	ResultSummary *string `json:"result_summary,omitempty" db:"result_summary"` // JSON object with summary statistics
@@ -52,4 +58,57 @@ const (
 	TaskStatusRunning   = "running"
 	TaskStatusCompleted = "completed"
 	TaskStatusFailed    = "failed"
+	// TaskStatusInterrupted marks a task stopped by a server shutdown or
+	// restart; it can be resumed
+	TaskStatusInterrupted = "interrupted"
+)
+
+// TaskPriority constants
+const (
+	TaskPriorityBackground = 0  // Scheduler, automatic import, live ingest
+	TaskPriorityUser       = 10 // API requests and edits
 )
+
+// AnalysisQueue describes the analysis job queue
+type AnalysisQueue struct {
+	Workers int                  `json:"workers"` // Tasks run at once
+	Running []AnalysisQueueEntry `json:"running"`
+	Pending []AnalysisQueueEntry `json:"pending"` // In start order
+}
+
+// AnalysisQueueEntry is a task in the analysis job queue
+type AnalysisQueueEntry struct {
+	TaskID    int64  `json:"task_id"`
+	SkillName string `json:"skill_name"`
+	Priority  int    `json:"priority"`
+	Position  int    `json:"position,omitempty"` // 1-based, pending tasks only
+}
+
+// ErrTaskNotInterrupted is returned when resuming a task that was not interrupted
+var ErrTaskNotInterrupted = errors.New("task is not interrupted")
+
+// AnalysisStatus reports, per Go analyzer, whether its outputs are up to date
+type AnalysisStatus struct {
+	Analyzers []AnalyzerStatus `json:"analyzers"`
+	Stale     []string         `json:"stale"` // Stale analyzers, recomputed in full by the next analysis chain run when part of it
+}
+
+// AnalyzerStatus describes the outputs of an analyzer
+type AnalyzerStatus struct {
+	Analyzer        string           `json:"analyzer"`
+	Version         string           `json:"version"`                    // Current algorithm version
+	ComputedVersion string           `json:"computed_version,omitempty"` // Version the outputs were last fully computed with
+	ComputedAt      int64            `json:"computed_at,omitempty"`      // Unix seconds, 0 when only the baseline is known
+	Stale           bool             `json:"stale"`
+	Reasons         []string         `json:"reasons"`
+	InChain         bool             `json:"in_chain"` // Run by the analysis chain
+	DependsOn       []string         `json:"depends_on"`
+	Outputs         []AnalyzerOutput `json:"outputs"`
+}
+
+// AnalyzerOutput counts the rows of an analyzer's output table by the
+// algo_version they were written with
+type AnalyzerOutput struct {
+	Table         string           `json:"table"`
+	RowsByVersion map[string]int64 `json:"rows_by_version,omitempty"` // Absent for tables without an algo_version column
+}
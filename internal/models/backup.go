@@ -0,0 +1,30 @@
+package models
+
+import "errors"
+
+// ErrBackupInProgress is returned when a backup is requested while another one is running
+var ErrBackupInProgress = errors.New("a backup is already in progress")
+
+// Backup is a database snapshot kept in the backup store
+type Backup struct {
+	Name      string `json:"name"` // records-YYYYMMDD-HHMMSS.db.gz, UTC
+	Size      int64  `json:"size"` // Bytes, compressed
+	CreatedAt int64  `json:"created_at"`
+}
+
+// BackupResult describes a backup just taken
+type BackupResult struct {
+	Backup
+	Location   string   `json:"location"` // Directory or S3 bucket URL, without credentials
+	DurationMs int64    `json:"duration_ms"`
+	Deleted    []string `json:"deleted"`         // Older backups removed by the retention policy
+	Error      string   `json:"error,omitempty"` // Set when the backup was stored but the retention policy failed
+}
+
+// BackupList lists the backups in the store
+type BackupList struct {
+	Location string   `json:"location"`
+	Keep     int      `json:"keep"`            // Newest backups kept, 0 for no limit
+	MaxAge   int64    `json:"max_age_seconds"` // Older backups are deleted, 0 for no limit
+	Backups  []Backup `json:"backups"`         // Newest first
+}
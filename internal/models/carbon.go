@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// EmissionFactor is the emissions model entry of a transport mode
+type EmissionFactor struct {
+	Mode             string    `json:"mode" db:"mode"`
+	GCO2PerKm        float64   `json:"g_co2_per_km" db:"g_co2_per_km"`                 // Grams CO2-equivalent per passenger km
+	DefaultGCO2PerKm float64   `json:"default_g_co2_per_km" db:"default_g_co2_per_km"` // Built-in value restored by a reset
+	Description      string    `json:"description,omitempty" db:"description"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EmissionFactorRequest represents the request body for overriding an emission factor
+type EmissionFactorRequest struct {
+	GCO2PerKm   *float64 `json:"g_co2_per_km"`
+	Description string   `json:"description"`
+}
+
+// ErrInvalidEmissionFactor is returned for emission factor requests with a bad mode or value
+var ErrInvalidEmissionFactor = errors.New("invalid emission factor")
+
+// CarbonPeriod is the carbon footprint of a year or month
+type CarbonPeriod struct {
+	BucketType string             `json:"bucket_type" db:"bucket_type"` // year, month
+	BucketKey  string             `json:"bucket_key" db:"bucket_key"`   // 2025, 2025-01
+	DistanceM  float64            `json:"distance_m" db:"distance_m"`
+	CO2Kg      float64            `json:"co2_kg" db:"co2_kg"`
+	TripCount  int                `json:"trip_count" db:"trip_count"`
+	ModeCO2    map[string]float64 `json:"mode_co2_kg" db:"mode_co2_json"`
+	CreatedAt  int64              `json:"created_at" db:"created_at"`
+
+	// Comparison with the same period one year earlier, when it has a footprint
+	PrevYearCO2Kg *float64 `json:"prev_year_co2_kg,omitempty"`
+	ChangePct     *float64 `json:"change_pct,omitempty"`
+}
+
+// CarbonSummary is the carbon footprint overview returned by /stats/carbon
+type CarbonSummary struct {
+	TotalCO2Kg     float64          `json:"total_co2_kg"`
+	TotalDistanceM float64          `json:"total_distance_m"`
+	Years          []CarbonPeriod   `json:"years"`
+	Months         []CarbonPeriod   `json:"months"` // Months of the requested year, or all months
+	Factors        []EmissionFactor `json:"factors"`
+}
+
+// TripCarbon is the carbon footprint of a trip
+type TripCarbon struct {
+	TripID    int64              `json:"trip_id" db:"trip_id"`
+	StartTime int64              `json:"start_time" db:"start_time"`
+	DistanceM float64            `json:"distance_m" db:"distance_m"`
+	CO2Kg     float64            `json:"co2_kg" db:"co2_kg"`
+	ModeCO2   map[string]float64 `json:"mode_co2_kg" db:"mode_co2_json"`
+	CreatedAt int64              `json:"created_at" db:"created_at"`
+}
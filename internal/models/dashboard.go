@@ -0,0 +1,43 @@
+package models
+
+// Dashboard is the cross-domain overview of one day
+// Sections are nil when they have no data or failed to load; the reason of a
+// failed section is reported in Errors under the section name.
+type Dashboard struct {
+	Date      string `json:"date"`
+	StartTime int64  `json:"start_time"` // Local 00:00:00
+	EndTime   int64  `json:"end_time"`   // Next local midnight
+
+	Movement      *DailySummary       `json:"movement,omitempty"`
+	TopStays      []DashboardStay     `json:"top_stays"`
+	ScreenTime    *ScreenTimeSummary  `json:"screen_time,omitempty"`
+	InputActivity *InputActivityStats `json:"input_activity,omitempty"`
+	Health        *HealthDailyStats   `json:"health,omitempty"`
+
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// AddError records why a section is missing
+func (d *Dashboard) AddError(section, message string) {
+	if d.Errors == nil {
+		d.Errors = map[string]string{}
+	}
+	d.Errors[section] = message
+}
+
+// DashboardStay is a stay overlapping the dashboard day, with its annotation
+type DashboardStay struct {
+	ID              int64   `json:"id"`
+	StayType        string  `json:"stay_type"`
+	StartTime       int64   `json:"start_time"`
+	EndTime         int64   `json:"end_time"`
+	DurationSeconds int64   `json:"duration_seconds"` // Part of the stay within the day
+	CenterLat       float64 `json:"center_lat"`
+	CenterLon       float64 `json:"center_lon"`
+	Province        string  `json:"province,omitempty"`
+	City            string  `json:"city,omitempty"`
+	County          string  `json:"county,omitempty"`
+	Label           *string `json:"label,omitempty"`
+	SubLabel        *string `json:"sub_label,omitempty"`
+	Confirmed       bool    `json:"confirmed"`
+}
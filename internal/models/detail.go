@@ -0,0 +1,88 @@
+package models
+
+// DetailPoint is a track point of an entity detail, downsampled for display
+type DetailPoint struct {
+	ID        int64   `json:"id"`
+	DataTime  int64   `json:"data_time"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Speed     float64 `json:"speed,omitempty"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	SegmentID int64   `json:"segment_id,omitempty"` // Set in trip details
+}
+
+// EntityRef points to a segment, stay or trip related to an entity
+type EntityRef struct {
+	Type      string `json:"type"` // segment, stay, trip
+	ID        int64  `json:"id"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	Label     string `json:"label,omitempty"` // Mode of a segment, label or area of a stay, route of a trip
+}
+
+// Entity types of EntityRef
+const (
+	EntitySegment = "segment"
+	EntityStay    = "stay"
+	EntityTrip    = "trip"
+)
+
+// RenderHints tells a map how to draw an entity
+// Style hints come from rendering_metadata and are only set for segments it has processed.
+type RenderHints struct {
+	MinLat      float64  `json:"min_lat"`
+	MinLon      float64  `json:"min_lon"`
+	MaxLat      float64  `json:"max_lat"`
+	MaxLon      float64  `json:"max_lon"`
+	SpeedBucket *int     `json:"speed_bucket,omitempty"`
+	OverlapRank *float64 `json:"overlap_rank,omitempty"`
+	LineWeight  *float64 `json:"line_weight,omitempty"`
+	Alpha       *float64 `json:"alpha,omitempty"`
+}
+
+// StayAnnotation is the label given to a stay, with the suggestions it was chosen from
+type StayAnnotation struct {
+	Label       string        `json:"label,omitempty"`
+	SubLabel    string        `json:"sub_label,omitempty"`
+	Note        string        `json:"note,omitempty"`
+	Confirmed   bool          `json:"confirmed"`
+	PlaceID     int64         `json:"place_id,omitempty"`
+	Suggestions []interface{} `json:"suggestions,omitempty"`
+}
+
+// SegmentDetail is a segment with its points and neighbours
+type SegmentDetail struct {
+	Segment
+	Points           []DetailPoint `json:"points"`
+	SourcePointCount int           `json:"source_point_count"` // Points before downsampling
+	Previous         *EntityRef    `json:"previous,omitempty"`
+	Next             *EntityRef    `json:"next,omitempty"`
+	Trip             *EntityRef    `json:"trip,omitempty"` // Trip the segment belongs to
+	Render           *RenderHints  `json:"render,omitempty"`
+}
+
+// StayDetail is a stay with its points, annotation and neighbours
+type StayDetail struct {
+	StaySegment
+	Points           []DetailPoint   `json:"points"`
+	SourcePointCount int             `json:"source_point_count"`
+	Annotation       *StayAnnotation `json:"annotation,omitempty"`
+	Previous         *EntityRef      `json:"previous,omitempty"`
+	Next             *EntityRef      `json:"next,omitempty"`
+	ArrivingTrip     *EntityRef      `json:"arriving_trip,omitempty"`
+	LeavingTrip      *EntityRef      `json:"leaving_trip,omitempty"`
+	Render           *RenderHints    `json:"render,omitempty"`
+}
+
+// TripDetail is a trip with its segments, points, end stays and neighbours
+type TripDetail struct {
+	Trip
+	Segments         []EntityRef   `json:"segments"`
+	Points           []DetailPoint `json:"points"`
+	SourcePointCount int           `json:"source_point_count"`
+	OriginStay       *EntityRef    `json:"origin_stay,omitempty"`
+	DestStay         *EntityRef    `json:"dest_stay,omitempty"`
+	Previous         *EntityRef    `json:"previous,omitempty"`
+	Next             *EntityRef    `json:"next,omitempty"`
+	Render           *RenderHints  `json:"render,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "errors"
+
+// ErrUnknownQuery is returned when a query plan is requested for a name that is not registered
+var ErrUnknownQuery = errors.New("unknown query")
+
+// QueryPlan is the EXPLAIN QUERY PLAN output of a named query, run with
+// representative arguments
+type QueryPlan struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	SQL         string          `json:"sql"`
+	Steps       []QueryPlanStep `json:"steps"`
+	Scans       []string        `json:"scans"` // Steps reading a whole table or index, usually what a regression looks like
+}
+
+// QueryPlanStep is one row of EXPLAIN QUERY PLAN
+type QueryPlanStep struct {
+	ID     int    `json:"id"`
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"` // e.g. SEARCH 一生足迹 USING COVERING INDEX idx_track_time_cover (dataTime>? AND dataTime<?)
+}
@@ -0,0 +1,30 @@
+package models
+
+// AnonymizedExportFilter represents the parameters of an anonymized track export
+type AnonymizedExportFilter struct {
+	StartTime    int64  `form:"startTime"`    // Unix timestamp
+	EndTime      int64  `form:"endTime"`      // Unix timestamp
+	Format       string `form:"format"`       // geojson (default), csv
+	Precision    int    `form:"precision"`    // Coordinate decimals 1-5, default 3 (~100 m)
+	MaxShiftDays *int   `form:"maxShiftDays"` // Timestamps shift by a random offset of up to this many days, 1-3650, default 30
+}
+
+// AnonymizedPoint is a track point as written to an anonymized export
+// It has no ID, rounded coordinates, a shifted timestamp and only
+// province/city level administrative names.
+type AnonymizedPoint struct {
+	DataTime  int64   `json:"dataTime"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+	Country   string  `json:"country,omitempty"`
+	Province  string  `json:"province,omitempty"`
+	City      string  `json:"city,omitempty"`
+}
+
+// Anonymized export format constants
+const (
+	ExportFormatGeoJSON = "geojson"
+	ExportFormatCSV     = "csv"
+)
@@ -1,5 +1,18 @@
 package models
 
+import "errors"
+
+// ErrInvalidSort is returned by list queries asked to sort by a field they do not support
+var ErrInvalidSort = errors.New("invalid sort field")
+
+// QueryOptions holds the pagination and sorting parameters shared by list queries
+type QueryOptions struct {
+	Limit  int    // Rows per page
+	Offset int    // Rows to skip
+	Sort   string // Field to sort by; "" for the query's default
+	Order  string // asc, desc, or "" for the sort field's default
+}
+
 // SegmentFilter represents filter parameters for querying segments
 type SegmentFilter struct {
 	Mode         string  `form:"mode"`         // WALK, CAR, TRAIN, FLIGHT, STAY, UNKNOWN
@@ -8,6 +21,7 @@ type SegmentFilter struct {
 	Province     string  `form:"province"`
 	City         string  `form:"city"`
 	County       string  `form:"county"`
+	Source       string  `form:"source"`       // Track point source
 	MinDistance  float64 `form:"minDistance"`  // Meters
 	MinDuration  int64   `form:"minDuration"`  // Seconds
 	MinConfidence float64 `form:"minConfidence"` // 0-1
@@ -23,24 +37,35 @@ type StayFilter struct {
 	Province     string  `form:"province"`
 	City         string  `form:"city"`
 	County       string  `form:"county"`
+	Source       string  `form:"source"`       // Track point source
 	StartTime    int64   `form:"startTime"`    // Unix timestamp
 	EndTime      int64   `form:"endTime"`      // Unix timestamp
 	MinConfidence float64 `form:"minConfidence"` // 0-1
+	PlaceID      int64   `form:"placeId"`
 	Page         int     `form:"page"`
 	PageSize     int     `form:"pageSize"`
 }
 
 // TripFilter represents filter parameters for querying trips
 type TripFilter struct {
-	StartTime   int64   `form:"startTime"`   // Unix timestamp
-	EndTime     int64   `form:"endTime"`     // Unix timestamp
-	OriginCity  string  `form:"originCity"`
-	DestCity    string  `form:"destCity"`
-	MinDistance float64 `form:"minDistance"` // Meters
-	PrimaryMode string  `form:"primaryMode"` // WALK, CAR, TRAIN, FLIGHT
-	TripType    string  `form:"tripType"`    // COMMUTE, ROUND_TRIP, ONE_WAY, MULTI_STOP
-	Page        int     `form:"page"`
-	PageSize    int     `form:"pageSize"`
+	StartTime      int64    `form:"startTime"` // Unix timestamp
+	EndTime        int64    `form:"endTime"`   // Unix timestamp
+	OriginProvince string   `form:"originProvince"`
+	OriginCity     string   `form:"originCity"`
+	OriginCounty   string   `form:"originCounty"`
+	DestProvince   string   `form:"destProvince"`
+	DestCity       string   `form:"destCity"`
+	DestCounty     string   `form:"destCounty"`
+	MinDistance    float64  `form:"minDistance"` // Meters
+	PrimaryMode    string   `form:"primaryMode"` // WALK, CAR, TRAIN, FLIGHT
+	Purpose        string   `form:"purpose"`     // COMMUTE, WORK, LEISURE, SHOPPING, TRAVEL, OTHER
+	DayType        string   `form:"dayType"`     // WORKDAY, WEEKEND, HOLIDAY
+	PlaceID        int64    `form:"placeId"`     // Trips from or to the place
+	Wet            *bool    `form:"wet"`         // Trips with or without rain; trips without weather match neither
+	MinTemp        *float64 `form:"minTemp"`     // °C, mean temperature along the trip
+	MaxTemp        *float64 `form:"maxTemp"`     // °C
+	Page           int      `form:"page"`
+	PageSize       int      `form:"pageSize"`
 }
 
 // GridFilter represents filter parameters for querying grid cells
@@ -66,10 +91,33 @@ type RenderFilter struct {
 	Limit     int     `form:"limit"`     // Max points to return
 }
 
+// PolylineFilter represents filter parameters for simplified segment geometry
+type PolylineFilter struct {
+	Zoom      int    `form:"zoom"`      // Map zoom level 0-20, selects the LOD
+	BBox      string `form:"bbox"`      // minLon,minLat,maxLon,maxLat
+	StartTime int64  `form:"startTime"` // Unix timestamp
+	EndTime   int64  `form:"endTime"`   // Unix timestamp
+	Mode      string `form:"mode"`      // Filter by transport mode
+	Format    string `form:"format"`    // encoded (default) or coordinates
+	Limit     int    `form:"limit"`     // Max segments to return
+
+	// Parsed from BBox
+	MinLat float64 `form:"-"`
+	MinLon float64 `form:"-"`
+	MaxLat float64 `form:"-"`
+	MaxLon float64 `form:"-"`
+}
+
+// PlaybackFilter represents parameters of the animated map replay
+type PlaybackFilter struct {
+	From   int64 `form:"from"`    // Unix timestamp
+	To     int64 `form:"to"`      // Unix timestamp
+	Step   int64 `form:"step"`    // Seconds between frames
+	MaxGap int64 `form:"max_gap"` // Seconds between points beyond which no position is interpolated
+}
+
 // StatsFilter represents filter parameters for statistics queries
 type StatsFilter struct {
-	StatType  string `form:"statType"`  // PROVINCE, CITY, COUNTY, TOWN, GRID, ACTIVITY_TYPE
+	StatType  string `form:"statType"`  // PROVINCE, CITY, COUNTY, TOWN, COUNTRY, REGION, GRID, ACTIVITY_TYPE
 	TimeRange string `form:"timeRange"` // all, YYYY, YYYY-MM, YYYY-MM-DD
-	OrderBy   string `form:"orderBy"`   // points, visits, duration, distance, count
-	Limit     int    `form:"limit"`     // Max results
 }
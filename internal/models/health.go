@@ -0,0 +1,167 @@
+package models
+
+// Sleep stages stored in health_sleep
+const (
+	SleepStageInBed  = "IN_BED"
+	SleepStageAsleep = "ASLEEP" // Unspecified asleep (older exports)
+	SleepStageCore   = "CORE"
+	SleepStageDeep   = "DEEP"
+	SleepStageREM    = "REM"
+	SleepStageAwake  = "AWAKE"
+)
+
+// HealthStepSample is one step count sample (health_steps table)
+type HealthStepSample struct {
+	SourceName string  `json:"source_name"`
+	StartTime  int64   `json:"start_time"`
+	EndTime    int64   `json:"end_time"`
+	Date       string  `json:"date"`
+	Count      float64 `json:"count"`
+}
+
+// HeartRateSample is one heart rate measurement (health_heart_rate table)
+type HeartRateSample struct {
+	SourceName string  `json:"source_name"`
+	Time       int64   `json:"time"`
+	Date       string  `json:"date"`
+	BPM        float64 `json:"bpm"`
+}
+
+// SleepSample is one sleep analysis interval (health_sleep table)
+type SleepSample struct {
+	SourceName string `json:"source_name"`
+	StartTime  int64  `json:"start_time"`
+	EndTime    int64  `json:"end_time"`
+	Date       string `json:"date"` // Wake-up day
+	Stage      string `json:"stage"`
+}
+
+// Workout is one recorded workout (health_workouts table)
+type Workout struct {
+	ID           int64    `json:"id" db:"id"`
+	ActivityType string   `json:"activity_type" db:"activity_type"`
+	SourceName   string   `json:"source_name" db:"source_name"`
+	StartTime    int64    `json:"start_time" db:"start_time"`
+	EndTime      int64    `json:"end_time" db:"end_time"`
+	Date         string   `json:"date" db:"date"`
+	DurationS    int64    `json:"duration_s" db:"duration_s"`
+	DistanceM    *float64 `json:"distance_m,omitempty" db:"distance_m"`
+	EnergyKcal   *float64 `json:"energy_kcal,omitempty" db:"energy_kcal"`
+}
+
+// HealthFilter selects a date range (YYYY-MM-DD, inclusive) of health data
+type HealthFilter struct {
+	From  string `form:"from"`
+	To    string `form:"to"`
+	Type  string `form:"type"` // Workout activity type
+	Limit int    `form:"limit"`
+}
+
+// HealthStats is the health overview of a date range
+type HealthStats struct {
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	Days           int                `json:"days"` // Days with any health data
+	TotalSteps     int64              `json:"total_steps"`
+	AvgDailySteps  int64              `json:"avg_daily_steps"` // Per day with steps
+	AvgHeartRate   *float64           `json:"avg_heart_rate,omitempty"`
+	WorkoutCount   int                `json:"workout_count"`
+	WorkoutMinutes float64            `json:"workout_minutes"`
+	AvgSleepHours  *float64           `json:"avg_sleep_hours,omitempty"` // Per night with sleep data
+	Daily          []HealthDailyStats `json:"daily"`
+}
+
+// HealthDailyStats is the health data of one day
+// Steps and sleep take the source with the largest total, so an iPhone and an
+// Apple Watch recording the same walk are not counted twice.
+type HealthDailyStats struct {
+	Date           string   `json:"date"`
+	Steps          int64    `json:"steps"`
+	AvgHeartRate   *float64 `json:"avg_heart_rate,omitempty"`
+	MinHeartRate   *float64 `json:"min_heart_rate,omitempty"`
+	MaxHeartRate   *float64 `json:"max_heart_rate,omitempty"`
+	Workouts       int      `json:"workouts"`
+	WorkoutMinutes float64  `json:"workout_minutes"`
+	SleepHours     *float64 `json:"sleep_hours,omitempty"`
+}
+
+// HealthImportTask tracks a background Apple Health import (health_import_tasks table)
+type HealthImportTask struct {
+	ID              int64   `json:"id" db:"id"`
+	Status          string  `json:"status" db:"status"` // pending, running, completed, failed
+	FileName        string  `json:"file_name" db:"file_name"`
+	TotalBytes      int64   `json:"total_bytes" db:"total_bytes"`
+	ProcessedBytes  int64   `json:"processed_bytes" db:"processed_bytes"`
+	ProgressPercent float64 `json:"progress_percent"`
+	RecordsParsed   int64   `json:"records_parsed" db:"records_parsed"`
+	RecordsInserted int64   `json:"records_inserted" db:"records_inserted"`
+	Duplicates      int64   `json:"duplicates" db:"duplicates"`
+	IgnoredRecords  int64   `json:"ignored_records" db:"ignored_records"`
+	ErrorMessage    *string `json:"error_message,omitempty" db:"error_message"`
+	CreatedBy       string  `json:"created_by" db:"created_by"`
+	StartedAt       *int64  `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt     *int64  `json:"completed_at,omitempty" db:"completed_at"`
+	CreatedAt       int64   `json:"created_at" db:"created_at"`
+	UpdatedAt       int64   `json:"updated_at" db:"updated_at"`
+}
+
+// IsTerminal returns true if the task is in a terminal state
+func (t *HealthImportTask) IsTerminal() bool {
+	return t.Status == TaskStatusCompleted || t.Status == TaskStatusFailed
+}
+
+// Progress returns the completion percentage (0-100)
+func (t *HealthImportTask) Progress() float64 {
+	if t.Status == TaskStatusCompleted {
+		return 100
+	}
+	if t.TotalBytes == 0 {
+		return 0
+	}
+	return float64(t.ProcessedBytes) / float64(t.TotalBytes) * 100
+}
+
+// HealthMovementCorrelation is one day of the health_correlation analyzer (health_movement_correlation table)
+type HealthMovementCorrelation struct {
+	Date                  string   `json:"date" db:"date"`
+	RecordedSteps         int64    `json:"recorded_steps" db:"recorded_steps"`
+	WalkDistanceM         float64  `json:"walk_distance_m" db:"walk_distance_m"`
+	MovedDistanceM        float64  `json:"moved_distance_m" db:"moved_distance_m"`
+	ExpectedSteps         int64    `json:"expected_steps" db:"expected_steps"`   // Walked distance / average stride
+	StepRatio             *float64 `json:"step_ratio,omitempty" db:"step_ratio"` // Recorded / expected steps
+	MetersPerStep         *float64 `json:"meters_per_step,omitempty" db:"meters_per_step"`
+	WorkoutCount          int      `json:"workout_count" db:"workout_count"`
+	WorkoutMinutes        float64  `json:"workout_minutes" db:"workout_minutes"`
+	WorkoutDistanceM      float64  `json:"workout_distance_m" db:"workout_distance_m"`
+	AvgHeartRate          *float64 `json:"avg_heart_rate,omitempty" db:"avg_heart_rate"`
+	HighAltitudeHRSamples int64    `json:"high_altitude_hr_samples" db:"high_altitude_hr_samples"`
+	HighAltitudeAvgHR     *float64 `json:"high_altitude_avg_hr,omitempty" db:"high_altitude_avg_hr"`
+	LowAltitudeHRSamples  int64    `json:"low_altitude_hr_samples" db:"low_altitude_hr_samples"`
+	LowAltitudeAvgHR      *float64 `json:"low_altitude_avg_hr,omitempty" db:"low_altitude_avg_hr"`
+	MaxAltitudeM          *float64 `json:"max_altitude_m,omitempty" db:"max_altitude_m"`
+}
+
+// HealthCorrelationStats summarizes the health × movement correlation of a date range
+type HealthCorrelationStats struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+
+	// Steps vs walked distance, over days with both
+	StepDistanceCorrelation *float64 `json:"step_distance_correlation,omitempty"` // Pearson r
+	AvgMetersPerStep        *float64 `json:"avg_meters_per_step,omitempty"`
+	AvgStepRatio            *float64 `json:"avg_step_ratio,omitempty"`
+
+	// Heart rate by altitude, weighted by sample count
+	HighAltitudeHRSamples int64    `json:"high_altitude_hr_samples"`
+	HighAltitudeAvgHR     *float64 `json:"high_altitude_avg_hr,omitempty"`
+	LowAltitudeHRSamples  int64    `json:"low_altitude_hr_samples"`
+	LowAltitudeAvgHR      *float64 `json:"low_altitude_avg_hr,omitempty"`
+
+	// Movement on days with and without workouts
+	WorkoutDays            int     `json:"workout_days"`
+	WorkoutDayAvgDistanceM float64 `json:"workout_day_avg_distance_m"`
+	RestDayAvgDistanceM    float64 `json:"rest_day_avg_distance_m"`
+
+	Daily []HealthMovementCorrelation `json:"daily"`
+}
@@ -18,3 +18,44 @@ type HeatmapResponse struct {
 	Metric    string         `json:"metric"`
 	GridLevel int            `json:"grid_level"`
 }
+
+// HeatmapGridFilter represents query parameters for the dynamic-resolution heatmap
+type HeatmapGridFilter struct {
+	BBox   string `form:"bbox"`   // minLon,minLat,maxLon,maxLat
+	Zoom   int    `form:"zoom"`   // Map zoom level 0-20, controls cell size
+	Bucket string `form:"bucket"` // all, rolling_90d, or YYYY
+	Source string `form:"source"` // all, or a track point source
+
+	// Parsed from BBox
+	MinLat float64 `form:"-"`
+	MinLon float64 `form:"-"`
+	MaxLat float64 `form:"-"`
+	MaxLon float64 `form:"-"`
+}
+
+// HeatmapCell represents a single aggregated heatmap cell
+type HeatmapCell struct {
+	Lat          float64 `json:"lat"`           // Cell center latitude
+	Lng          float64 `json:"lng"`           // Cell center longitude
+	Count        int64   `json:"count"`         // Point count (raw) or stay count (density grid)
+	DwellSeconds int64   `json:"dwell_seconds"` // Total dwell time in the cell
+	Weight       float64 `json:"weight"`        // Aggregated weight used for intensity
+	Intensity    float64 `json:"intensity"`     // Normalized 0-1
+}
+
+// HeatmapGridResponse represents the dynamic-resolution heatmap API response
+type HeatmapGridResponse struct {
+	Cells       []HeatmapCell `json:"cells"`
+	Count       int           `json:"count"`
+	Zoom        int           `json:"zoom"`
+	CellSizeDeg float64       `json:"cell_size_deg"`
+	Source      string        `json:"source"` // density_grid, raw_points
+	Bucket      string        `json:"bucket"`
+	MaxWeight   float64       `json:"max_weight"`
+}
+
+// Heatmap source constants
+const (
+	HeatmapSourceDensityGrid = "density_grid"
+	HeatmapSourceRawPoints   = "raw_points"
+)
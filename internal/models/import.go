@@ -0,0 +1,25 @@
+package models
+
+// ImportResult summarizes the import of a single track file
+type ImportResult struct {
+	FileName        string `json:"file_name"`
+	Format          string `json:"format"`           // gpx, kml, csv, json
+	Source          string `json:"source"`           // App or import recorded on the imported points
+	SourceDevice    string `json:"source_device"`    // Device recorded on the imported points
+	ParsedPoints    int    `json:"parsed_points"`    // Points found in the file
+	InsertedPoints  int    `json:"inserted_points"`  // New points written to the track table
+	ReplacedPoints  int    `json:"replaced_points"`  // Less accurate points of other devices replaced by points of this file
+	DuplicatePoints int    `json:"duplicate_points"` // Points skipped as duplicates of existing, at least as accurate points
+	StartTime       int64  `json:"start_time,omitempty"`
+	EndTime         int64  `json:"end_time,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// ImportResponse represents the response of a bulk track import
+type ImportResponse struct {
+	Files          []ImportResult `json:"files"`
+	TotalInserted  int            `json:"total_inserted"`
+	TotalReplaced  int            `json:"total_replaced"`
+	TotalDuplicate int            `json:"total_duplicate"`
+	AnalysisTasks  []int64        `json:"analysis_task_ids,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "errors"
+
+// ErrInvalidIngestPayload is returned for live payloads that cannot be parsed or hold invalid locations
+var ErrInvalidIngestPayload = errors.New("invalid ingest payload")
+
+// IngestResult summarizes the points written from one live payload
+type IngestResult struct {
+	Received   int `json:"received"`   // Valid locations in the payload
+	Rejected   int `json:"rejected"`   // Invalid locations dropped (Overland batches only; others fail as a whole)
+	Inserted   int `json:"inserted"`   // New points written to the track table
+	Replaced   int `json:"replaced"`   // Less accurate points of other devices replaced
+	Duplicates int `json:"duplicates"` // Points already stored
+}
+
+// IngestStatus describes live ingestion
+type IngestStatus struct {
+	LastPointTime    int64   `json:"last_point_time,omitempty"`  // dataTime of the latest ingested point
+	LastReceivedAt   int64   `json:"last_received_at,omitempty"` // When that point arrived
+	LastDevice       string  `json:"last_device,omitempty"`      // Device of that point
+	PendingPoints    int     `json:"pending_points"`             // Points ingested since analysis was last triggered
+	AnalysisInterval int64   `json:"analysis_interval_seconds"`  // How often pending points trigger incremental analysis
+	LastAnalysisAt   int64   `json:"last_analysis_at,omitempty"` // When analysis was last triggered
+	LastAnalysisIDs  []int64 `json:"last_analysis_task_ids"`     // Tasks of that analysis
+	MQTTBroker       string  `json:"mqtt_broker,omitempty"`      // Empty when the MQTT subscriber is off
+	MQTTConnected    bool    `json:"mqtt_connected"`
+	MQTTError        string  `json:"mqtt_error,omitempty"` // Why the last MQTT connection ended
+}
@@ -0,0 +1,106 @@
+package models
+
+// InputActivityHour is the keyboard and mouse activity of one local hour (input_activity_hourly table)
+type InputActivityHour struct {
+	ID         int64  `json:"id" db:"id"`
+	HourStart  int64  `json:"hour_start" db:"hour_start"` // Unix timestamp of the local hour start
+	Date       string `json:"date" db:"date"`             // YYYY-MM-DD
+	Hour       int    `json:"hour" db:"hour"`             // 0-23
+	Weekday    int    `json:"weekday" db:"weekday"`       // 0=Sunday .. 6=Saturday
+	Device     string `json:"device,omitempty" db:"device"`
+	Keystrokes int64  `json:"keystrokes" db:"keystrokes"`
+	Clicks     int64  `json:"clicks" db:"clicks"`
+	Scrolls    int64  `json:"scrolls" db:"scrolls"`
+	Source     string `json:"source,omitempty" db:"source"`
+}
+
+// InputActivityFilter selects a date range (YYYY-MM-DD, inclusive) and device
+type InputActivityFilter struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Device string `form:"device"`
+	Metric string `form:"metric"` // keystrokes (default), clicks, scrolls
+}
+
+// InputActivityStats is the keyboard and mouse overview of a date range
+type InputActivityStats struct {
+	From                   string               `json:"from"`
+	To                     string               `json:"to"`
+	ActiveDays             int                  `json:"active_days"`
+	ActiveHours            int                  `json:"active_hours"`
+	TotalKeystrokes        int64                `json:"total_keystrokes"`
+	TotalClicks            int64                `json:"total_clicks"`
+	TotalScrolls           int64                `json:"total_scrolls"`
+	DailyAverageKeystrokes int64                `json:"daily_average_keystrokes"` // Per active day
+	DailyAverageClicks     int64                `json:"daily_average_clicks"`
+	PeakHour               *int                 `json:"peak_hour,omitempty"` // Hour of day with the most keystrokes
+	BusiestDay             *DailyInputActivity  `json:"busiest_day,omitempty"`
+	Daily                  []DailyInputActivity `json:"daily"`
+}
+
+// DailyInputActivity is the keyboard and mouse activity of one day
+type DailyInputActivity struct {
+	Date        string `json:"date"`
+	ActiveHours int    `json:"active_hours"`
+	Keystrokes  int64  `json:"keystrokes"`
+	Clicks      int64  `json:"clicks"`
+	Scrolls     int64  `json:"scrolls"`
+}
+
+// InputHeatmap is a weekday × hour grid of one activity metric
+type InputHeatmap struct {
+	From   string             `json:"from"`
+	To     string             `json:"to"`
+	Metric string             `json:"metric"`
+	Max    float64            `json:"max"` // Largest cell average, for color scaling
+	Cells  []InputHeatmapCell `json:"cells"`
+}
+
+// InputHeatmapCell is one weekday and hour of the heatmap
+type InputHeatmapCell struct {
+	Weekday int     `json:"weekday"` // 0=Sunday .. 6=Saturday
+	Hour    int     `json:"hour"`
+	Total   int64   `json:"total"`
+	Average float64 `json:"average"` // Total divided by the calendar days of this weekday in the range
+}
+
+// InputWorkCorrelation compares keyboard and mouse activity during WORK stays with the rest
+type InputWorkCorrelation struct {
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	WorkStayCount  int                `json:"work_stay_count"`
+	WorkHours      float64            `json:"work_hours"`
+	AtWork         InputActivityShare `json:"at_work"`
+	Elsewhere      InputActivityShare `json:"elsewhere"`
+	KeystrokeShare float64            `json:"keystroke_share"`       // Fraction of keystrokes typed at work (0-1)
+	Correlation    *float64           `json:"correlation,omitempty"` // Pearson r of daily work hours vs keystrokes
+	Days           []WorkInputDay     `json:"days"`
+}
+
+// InputActivityShare is the activity of the hours on one side of the work/elsewhere split
+type InputActivityShare struct {
+	ActiveHours       int     `json:"active_hours"`
+	Keystrokes        int64   `json:"keystrokes"`
+	Clicks            int64   `json:"clicks"`
+	KeystrokesPerHour float64 `json:"keystrokes_per_hour"`
+}
+
+// WorkInputDay pairs the WORK stay hours and typing of one day
+type WorkInputDay struct {
+	Date       string  `json:"date"`
+	WorkHours  float64 `json:"work_hours"`
+	Keystrokes int64   `json:"keystrokes"`
+	Clicks     int64   `json:"clicks"`
+}
+
+// InputActivityImportResult summarizes the import of a keyboard/mouse export
+type InputActivityImportResult struct {
+	FileName   string   `json:"file_name"`
+	Format     string   `json:"format"` // whatpulse, activitywatch, generic
+	ParsedRows int      `json:"parsed_rows"`
+	Hours      int      `json:"hours"`  // Hourly records after merging rows and events
+	Stored     int      `json:"stored"` // Records inserted or replaced
+	StartDate  string   `json:"start_date,omitempty"`
+	EndDate    string   `json:"end_date,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
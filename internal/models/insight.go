@@ -0,0 +1,25 @@
+package models
+
+// Insight is a human-readable finding about a month or year, generated from
+// the computed statistics by a rule of the insight catalogue
+type Insight struct {
+	ID          int64                  `json:"id" db:"id"`
+	BucketType  string                 `json:"bucket_type" db:"bucket_type"` // month, year
+	BucketKey   string                 `json:"bucket_key" db:"bucket_key"`   // 2025-05, 2025
+	Rule        string                 `json:"rule" db:"rule"`               // e.g. new_counties, commute_duration
+	Category    string                 `json:"category" db:"category"`       // FOOTPRINT, DISTANCE, COMMUTE, EXPLORATION, RECORD
+	Message     string                 `json:"message" db:"message"`
+	Value       *float64               `json:"value,omitempty" db:"value"`
+	ChangePct   *float64               `json:"change_pct,omitempty" db:"change_pct"` // Change from the previous bucket
+	Details     map[string]interface{} `json:"details,omitempty" db:"details"`
+	AlgoVersion string                 `json:"algo_version" db:"algo_version"`
+	CreatedAt   int64                  `json:"created_at" db:"created_at"`
+}
+
+// InsightFilter holds the query filters of GET /api/v1/insights
+type InsightFilter struct {
+	BucketType string `form:"bucket_type"`
+	BucketKey  string `form:"bucket_key"`
+	Category   string `form:"category"`
+	Rule       string `form:"rule"`
+}
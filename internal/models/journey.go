@@ -0,0 +1,103 @@
+package models
+
+// Journey types
+const (
+	JourneyTypeFlight = "FLIGHT"
+	JourneyTypeTrain  = "TRAIN"
+)
+
+// JourneySourceDetected is the source of flights detected in the trajectory
+// by the flight_detection analyzer
+const JourneySourceDetected = "detected"
+
+// Journey represents a booked flight or train journey (journeys table)
+type Journey struct {
+	ID          int64  `json:"id" db:"id"`
+	JourneyType string `json:"journey_type" db:"journey_type"` // FLIGHT, TRAIN
+	Number      string `json:"number" db:"number"`             // Flight / train number
+	Carrier     string `json:"carrier,omitempty" db:"carrier"`
+
+	DepartureTime int64 `json:"departure_time" db:"departure_time"` // Unix timestamp
+	ArrivalTime   int64 `json:"arrival_time,omitempty" db:"arrival_time"`
+	DurationS     int64 `json:"duration_s,omitempty" db:"duration_s"`
+
+	OriginCode string  `json:"origin_code,omitempty" db:"origin_code"` // IATA code for airports
+	OriginName string  `json:"origin_name" db:"origin_name"`
+	OriginCity string  `json:"origin_city,omitempty" db:"origin_city"`
+	OriginLat  float64 `json:"origin_lat,omitempty" db:"origin_lat"`
+	OriginLon  float64 `json:"origin_lon,omitempty" db:"origin_lon"`
+	DestCode   string  `json:"dest_code,omitempty" db:"dest_code"`
+	DestName   string  `json:"dest_name" db:"dest_name"`
+	DestCity   string  `json:"dest_city,omitempty" db:"dest_city"`
+	DestLat    float64 `json:"dest_lat,omitempty" db:"dest_lat"`
+	DestLon    float64 `json:"dest_lon,omitempty" db:"dest_lon"`
+
+	DistanceM    float64 `json:"distance_m,omitempty" db:"distance_m"` // Great-circle distance
+	BookingClass string  `json:"booking_class,omitempty" db:"booking_class"`
+	Seat         string  `json:"seat,omitempty" db:"seat"`
+
+	SegmentID int64  `json:"segment_id,omitempty" db:"segment_id"` // Linked PLANE / TRAIN segment
+	Source    string `json:"source" db:"source"`                   // appintheair, 12306, manual, detected
+	Notes     string `json:"notes,omitempty" db:"notes"`
+
+	Path [][2]float64 `json:"path,omitempty" db:"-"` // Great-circle [lon, lat] path of located flights
+
+	CreatedAt int64 `json:"created_at" db:"created_at"`
+	UpdatedAt int64 `json:"updated_at" db:"updated_at"`
+}
+
+// HasCoordinates reports whether both endpoints were located
+func (j *Journey) HasCoordinates() bool {
+	return (j.OriginLat != 0 || j.OriginLon != 0) && (j.DestLat != 0 || j.DestLon != 0)
+}
+
+// JourneyFilter represents filter parameters for querying journeys
+type JourneyFilter struct {
+	JourneyType string `form:"type"`      // FLIGHT, TRAIN
+	StartTime   int64  `form:"startTime"` // Unix timestamp
+	EndTime     int64  `form:"endTime"`   // Unix timestamp
+	Carrier     string `form:"carrier"`
+	City        string `form:"city"`   // Matches origin or destination city
+	Source      string `form:"source"` // appintheair, 12306, manual or detected
+	Page        int    `form:"page"`
+	PageSize    int    `form:"pageSize"`
+}
+
+// JourneyStats summarizes journeys of one type
+type JourneyStats struct {
+	JourneyType    string         `json:"journey_type"`
+	Count          int64          `json:"count"`
+	TotalDistanceM float64        `json:"total_distance_m"`
+	TotalDurationS int64          `json:"total_duration_s"`
+	LinkedCount    int64          `json:"linked_count"` // Journeys matched to a trajectory segment
+	TopRoutes      []JourneyRoute `json:"top_routes"`
+	TopCarriers    []JourneyCount `json:"top_carriers"`
+	ByYear         []JourneyCount `json:"by_year"`
+}
+
+// JourneyRoute is an origin-destination pair with its journey count
+type JourneyRoute struct {
+	Origin    string  `json:"origin"`
+	Dest      string  `json:"dest"`
+	Count     int64   `json:"count"`
+	DistanceM float64 `json:"distance_m"`
+}
+
+// JourneyCount is a labelled journey count
+type JourneyCount struct {
+	Key       string  `json:"key"`
+	Count     int64   `json:"count"`
+	DistanceM float64 `json:"distance_m"`
+}
+
+// JourneyImportResult summarizes the import of a journey file
+type JourneyImportResult struct {
+	FileName   string   `json:"file_name"`
+	Format     string   `json:"format"` // appintheair, 12306, generic
+	Parsed     int      `json:"parsed"`
+	Inserted   int      `json:"inserted"`
+	Duplicates int      `json:"duplicates"`
+	Unlocated  int      `json:"unlocated"` // Journeys whose airports / stations are unknown
+	Linked     int64    `json:"linked"`    // Journeys newly linked to segments
+	Warnings   []string `json:"warnings,omitempty"`
+}
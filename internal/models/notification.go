@@ -0,0 +1,40 @@
+package models
+
+import "errors"
+
+// ErrNotificationsDisabled is returned when no notification channel is configured
+var ErrNotificationsDisabled = errors.New("no notification channels configured")
+
+// NotificationDelivery is the outcome of sending one event to one
+// notification channel
+type NotificationDelivery struct {
+	ID          int64  `json:"id" db:"id"`
+	EventID     string `json:"event_id" db:"event_id"`
+	EventType   string `json:"event_type" db:"event_type"` // analysis.completed, record.new, import.completed, ...
+	Title       string `json:"title" db:"title"`
+	Channel     string `json:"channel" db:"channel"` // e.g. webhook:example.com/hook, telegram:<chat id>, bark:api.day.app
+	Status      string `json:"status" db:"status"`   // delivered, failed
+	Attempts    int    `json:"attempts" db:"attempts"`
+	Error       string `json:"error,omitempty" db:"error"`
+	EventTime   int64  `json:"event_time" db:"event_time"`
+	DeliveredAt int64  `json:"delivered_at" db:"delivered_at"` // When the last attempt finished
+}
+
+// NotificationDelivery status constants
+const (
+	NotificationDelivered = "delivered"
+	NotificationFailed    = "failed"
+)
+
+// NotificationStatus describes the configured notification channels
+type NotificationStatus struct {
+	Enabled  bool     `json:"enabled"`
+	Channels []string `json:"channels"`
+	Events   []string `json:"events"` // Event types sent
+}
+
+// NotificationFilter holds the query filters of GET /api/v1/admin/notifications/deliveries
+type NotificationFilter struct {
+	EventType string `form:"event_type"`
+	Status    string `form:"status"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import "errors"
+
+// Photo sources
+const (
+	PhotoSourceDir    = "dir"    // Scanned from PHOTO_DIR
+	PhotoSourceImmich = "immich" // Read from an Immich server
+)
+
+// How the location of a photo was found
+const (
+	PhotoLocationEXIF    = "exif"    // GPS tags of the file
+	PhotoLocationSidecar = "sidecar" // Google Takeout JSON sidecar
+	PhotoLocationImmich  = "immich"  // EXIF kept by Immich
+	PhotoLocationTrack   = "track"   // Interpolated on the track at the capture time
+)
+
+// Photo is a picture with the time and place it was taken
+// Trips and stays show the photos taken between their start and end.
+type Photo struct {
+	ID             int64   `json:"id" db:"id"`
+	Source         string  `json:"source" db:"source"`           // dir, immich
+	ExternalID     string  `json:"external_id" db:"external_id"` // Path relative to PHOTO_DIR, or the Immich asset ID
+	FileName       string  `json:"file_name" db:"file_name"`
+	TakenAt        int64   `json:"taken_at" db:"taken_at"` // Unix timestamp
+	Lat            float64 `json:"lat,omitempty" db:"lat"`
+	Lon            float64 `json:"lon,omitempty" db:"lon"`
+	Altitude       float64 `json:"altitude,omitempty" db:"altitude"`
+	LocationSource string  `json:"location_source,omitempty" db:"location_source"` // exif, sidecar, immich, track; empty when not located
+	CameraMake     string  `json:"camera_make,omitempty" db:"camera_make"`
+	CameraModel    string  `json:"camera_model,omitempty" db:"camera_model"`
+	Width          int     `json:"width,omitempty" db:"width"`
+	Height         int     `json:"height,omitempty" db:"height"`
+	FileSize       int64   `json:"file_size,omitempty" db:"file_size"`
+	FileMtime      int64   `json:"-" db:"file_mtime"`
+	ImportedAt     int64   `json:"imported_at" db:"imported_at"`
+	URL            string  `json:"url" db:"-"` // Image endpoint of the photo
+}
+
+// PhotoFilter holds the query filters of GET /api/v1/photos
+type PhotoFilter struct {
+	StartTime int64  `form:"start_time"` // Unix timestamp
+	EndTime   int64  `form:"end_time"`   // Unix timestamp
+	Source    string `form:"source"`     // dir, immich
+	Located   *bool  `form:"located"`    // Only photos with or without a location
+}
+
+// PhotoSet is the photos taken during a trip or stay, in time order
+type PhotoSet struct {
+	StartTime int64   `json:"start_time"`
+	EndTime   int64   `json:"end_time"`
+	Photos    []Photo `json:"photos"`
+}
+
+// PhotoScanRequest represents the request body of a PHOTO_DIR scan
+type PhotoScanRequest struct {
+	Path string `json:"path"` // Subdirectory of PHOTO_DIR to scan, all of it when empty
+}
+
+// PhotoImmichRequest represents the request body of an Immich import
+type PhotoImmichRequest struct {
+	Since int64 `json:"since"` // Only photos taken after this Unix timestamp, all when 0
+}
+
+// PhotoImportResult summarizes a photo import
+type PhotoImportResult struct {
+	Source    string   `json:"source"`
+	Scanned   int      `json:"scanned"`   // Photos found
+	Unchanged int      `json:"unchanged"` // Files imported before and not modified since
+	Imported  int      `json:"imported"`  // Photos inserted or updated
+	Geotagged int      `json:"geotagged"` // Imported photos with a location of their own
+	NoTime    int      `json:"no_time"`   // Skipped for lack of a capture time
+	Failed    int      `json:"failed"`    // Files that could not be read
+	Located   int      `json:"located"`   // Photos placed on the track, including earlier imports
+	Warnings  []string `json:"warnings,omitempty"`
+}
+
+var (
+	// ErrPhotoSourceNotConfigured is returned when PHOTO_DIR or the Immich server is not set
+	ErrPhotoSourceNotConfigured = errors.New("photo source not configured")
+	// ErrInvalidPhotoPath is returned for scan paths outside PHOTO_DIR
+	ErrInvalidPhotoPath = errors.New("invalid photo path")
+)
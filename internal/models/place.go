@@ -0,0 +1,71 @@
+package models
+
+import "errors"
+
+// Place is a location visited repeatedly, clustered from the stays whose
+// centers fall in the same geohash neighbourhood
+type Place struct {
+	ID      int64  `json:"id" db:"id"`
+	Geohash string `json:"geohash" db:"geohash"`       // Seed cell (geohash7)
+	Name    string `json:"name,omitempty" db:"name"`   // Given through the API
+	Label   string `json:"label,omitempty" db:"label"` // Most common confirmed stay annotation, e.g. HOME
+
+	// Extent
+	CenterLat float64 `json:"center_lat" db:"center_lat"`
+	CenterLon float64 `json:"center_lon" db:"center_lon"`
+	RadiusM   float64 `json:"radius_m" db:"radius_m"`
+
+	// Administrative divisions
+	Province string `json:"province,omitempty" db:"province"`
+	City     string `json:"city,omitempty" db:"city"`
+	County   string `json:"county,omitempty" db:"county"`
+
+	// Visit statistics
+	VisitCount     int     `json:"visit_count" db:"visit_count"`
+	TotalDurationS int64   `json:"total_duration_s" db:"total_duration_s"`
+	TotalHours     float64 `json:"total_hours" db:"-"`
+	FirstVisitTS   int64   `json:"first_visit_ts,omitempty" db:"first_visit_ts"` // Unix timestamp
+	LastVisitTS    int64   `json:"last_visit_ts,omitempty" db:"last_visit_ts"`   // Unix timestamp
+
+	// Nearby venues (POI enrichment), only on GET /api/v1/places/:id
+	POICheckedAt int64      `json:"poi_checked_at,omitempty" db:"poi_checked_at"` // Unix timestamp of the lookup
+	POIs         []PlacePOI `json:"pois,omitempty" db:"-"`
+
+	// Metadata
+	AlgoVersion string `json:"algo_version,omitempty" db:"algo_version"`
+	CreatedAt   int64  `json:"created_at" db:"created_at"`
+	UpdatedAt   int64  `json:"updated_at" db:"updated_at"`
+}
+
+// PlacePOI is a named venue near a place, looked up with a POI provider
+type PlacePOI struct {
+	Rank      int     `json:"rank" db:"rank"` // 1 for the nearest
+	Name      string  `json:"name" db:"name"`
+	Category  string  `json:"category" db:"category"`   // FOOD, SHOPPING, LEISURE, LODGING, TRANSPORT, OFFICE, ...
+	Kind      string  `json:"kind,omitempty" db:"kind"` // Provider type, e.g. amenity=cafe or an Amap type code
+	Lat       float64 `json:"lat" db:"lat"`
+	Lon       float64 `json:"lon" db:"lon"`
+	DistanceM float64 `json:"distance_m" db:"distance_m"` // From the place center
+	Provider  string  `json:"provider" db:"provider"`     // amap, overpass or snapshot
+}
+
+// PlaceFilter holds the query filters of GET /api/v1/places
+type PlaceFilter struct {
+	Name      string `form:"name"`  // Substring of the name
+	Label     string `form:"label"` // HOME, WORK, ...
+	City      string `form:"city"`
+	Named     *bool  `form:"named"` // Only named or only unnamed places
+	MinVisits int    `form:"min_visits"`
+}
+
+// PlaceNameRequest represents the request body for naming a place
+// An empty name clears it.
+type PlaceNameRequest struct {
+	Name string `json:"name"`
+}
+
+// MaxPlaceNameLength bounds the length of place names, in characters
+const MaxPlaceNameLength = 100
+
+// ErrInvalidPlaceName is returned for place names that are too long
+var ErrInvalidPlaceName = errors.New("invalid place name")
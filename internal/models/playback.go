@@ -0,0 +1,31 @@
+package models
+
+// PlaybackFrame is the interpolated position at one time step of a replay
+type PlaybackFrame struct {
+	Time      int64   `json:"time"` // Unix timestamp
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	SpeedKmh  float64 `json:"speed_kmh"`      // Between the two points around the frame
+	Heading   float64 `json:"heading"`        // Degrees from north, between the same points
+	Mode      string  `json:"mode,omitempty"` // Mode of the segment covering the frame
+	SegmentID int64   `json:"segment_id,omitempty"`
+}
+
+// PlaybackGap is a time range without positions, where tracking stopped
+// for longer than the maximum gap
+type PlaybackGap struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// PlaybackResponse is the replay of a time range at fixed steps
+// Frames fall on From + k*Step and are left out inside gaps.
+type PlaybackResponse struct {
+	From             int64           `json:"from"`
+	To               int64           `json:"to"`
+	Step             int64           `json:"step"` // Raised from the requested step when the range has too many frames
+	MaxGap           int64           `json:"max_gap"`
+	Frames           []PlaybackFrame `json:"frames"`
+	Gaps             []PlaybackGap   `json:"gaps"`
+	SourcePointCount int             `json:"source_point_count"`
+}
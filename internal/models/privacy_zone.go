@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// PrivacyZone represents a user-defined area whose track points are hidden
+// from exports and map rendering endpoints
+type PrivacyZone struct {
+	ID   int64  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+
+	// Geometry: a circle (center and radius) or a polygon
+	Shape     string       `json:"shape" db:"shape"` // circle, polygon
+	CenterLat float64      `json:"center_lat,omitempty" db:"center_lat"`
+	CenterLon float64      `json:"center_lon,omitempty" db:"center_lon"`
+	RadiusM   float64      `json:"radius_m,omitempty" db:"radius_m"`
+	Polygon   [][2]float64 `json:"polygon,omitempty" db:"polygon_json"` // [lon, lat] vertices
+
+	// Treatment of points inside the zone
+	Action  string  `json:"action" db:"action"`           // exclude, fuzz
+	FuzzM   float64 `json:"fuzz_m,omitempty" db:"fuzz_m"` // Grid size fuzzed points are snapped to
+	Enabled bool    `json:"enabled" db:"enabled"`
+
+	// Metadata
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// PrivacyZoneRequest represents the request body for creating or updating a privacy zone
+type PrivacyZoneRequest struct {
+	Name      string       `json:"name"`
+	Shape     string       `json:"shape"`
+	CenterLat float64      `json:"center_lat"`
+	CenterLon float64      `json:"center_lon"`
+	RadiusM   float64      `json:"radius_m"`
+	Polygon   [][2]float64 `json:"polygon"`
+	Action    string       `json:"action"` // Default exclude
+	FuzzM     float64      `json:"fuzz_m"`
+	Enabled   *bool        `json:"enabled"` // Default true
+}
+
+// Privacy zone shape constants
+const (
+	PrivacyShapeCircle  = "circle"
+	PrivacyShapePolygon = "polygon"
+)
+
+// Privacy zone action constants
+const (
+	PrivacyActionExclude = "exclude"
+	PrivacyActionFuzz    = "fuzz"
+)
+
+// ErrInvalidPrivacyZone is returned for privacy zone requests with a bad shape, geometry or action
+var ErrInvalidPrivacyZone = errors.New("invalid privacy zone")
@@ -0,0 +1,47 @@
+package models
+
+// OutlierPoint represents a track point flagged by outlier detection, or manually reviewed
+type OutlierPoint struct {
+	ID        int64   `json:"id"`
+	DataTime  int64   `json:"dataTime"` // Unix timestamp in seconds
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Speed     float64 `json:"speed"`
+	Accuracy  float64 `json:"accuracy"`
+	Province  string  `json:"province,omitempty"`
+	City      string  `json:"city,omitempty"`
+
+	// Outlier detection result
+	OutlierFlag bool     `json:"outlier_flag"`
+	ReasonCodes []string `json:"reason_codes"` // EXCESSIVE_SPEED, LOW_ACCURACY, JUMP, ...
+	QAStatus    string   `json:"qa_status"`    // FAIL, or MANUAL_PASS/MANUAL_FAIL after review
+}
+
+// OutlierFilter represents filter parameters for the outlier review list
+type OutlierFilter struct {
+	Reason    string `form:"reason"`     // Only points flagged with this reason code
+	QAStatus  string `form:"qa_status"`  // Default: flagged or manually reviewed points
+	StartTime int64  `form:"start_time"` // Unix timestamp
+	EndTime   int64  `form:"end_time"`   // Unix timestamp
+}
+
+// OutlierReviewRequest represents the request body of an outlier review action
+type OutlierReviewRequest struct {
+	IDs []int64 `json:"ids"` // Track point IDs
+}
+
+// OutlierReviewResult reports the outcome of an outlier review action
+type OutlierReviewResult struct {
+	QAStatus string `json:"qa_status,omitempty"` // Status the points were set to; empty after a reset
+	Updated  int64  `json:"updated"`             // Points that were updated
+}
+
+// QA status constants
+// Points with a MANUAL_* status keep it when outlier detection runs again.
+const (
+	QAStatusPass       = "PASS"
+	QAStatusWarning    = "WARNING"
+	QAStatusFail       = "FAIL"
+	QAStatusManualPass = "MANUAL_PASS" // Reviewed: not an outlier
+	QAStatusManualFail = "MANUAL_FAIL" // Reviewed: confirmed outlier
+)
@@ -0,0 +1,44 @@
+package models
+
+import "errors"
+
+var (
+	// ErrInvalidRecompute is returned for a recompute request naming unknown
+	// analyzers or an invalid time window
+	ErrInvalidRecompute = errors.New("invalid recompute request")
+	// ErrRecomputeBusy is returned when a recompute is confirmed while analysis tasks are running
+	ErrRecomputeBusy = errors.New("analysis tasks are already running")
+)
+
+// RecomputeRequest asks for analyzers to recompute their results
+type RecomputeRequest struct {
+	Analyzers []string `json:"analyzers"`  // Skill names, the analysis chain when empty
+	StartTime int64    `json:"start_time"` // Window start, Unix seconds; no window when both bounds are 0
+	EndTime   int64    `json:"end_time"`   // Window end, exclusive; 0 for no end
+	Confirm   bool     `json:"confirm"`    // Execute the plan; without it the plan is only returned
+}
+
+// RecomputePlan describes what a recompute clears and runs
+type RecomputePlan struct {
+	DryRun    bool            `json:"dry_run"`
+	StartTime int64           `json:"start_time,omitempty"`
+	EndTime   int64           `json:"end_time,omitempty"`
+	Points    int             `json:"points"`     // Track points the analyzers read
+	TotalRows int64           `json:"total_rows"` // Estimated rows cleared
+	Steps     []RecomputeStep `json:"steps"`      // In execution order
+	Warnings  []string        `json:"warnings"`
+}
+
+// RecomputeStep is one analyzer of a recompute plan
+type RecomputeStep struct {
+	Analyzer string            `json:"analyzer"`
+	Windowed bool              `json:"windowed"` // Only the window is recomputed, otherwise all time
+	Outputs  []RecomputeOutput `json:"outputs"`
+}
+
+// RecomputeOutput is a table an analyzer clears or rebuilds
+type RecomputeOutput struct {
+	Table  string `json:"table"`
+	Action string `json:"action"` // truncate, delete, update or replace
+	Rows   int64  `json:"rows"`   // Estimated rows cleared; for replace, the rows the table holds now
+}
@@ -0,0 +1,44 @@
+package models
+
+// AnnualReport is the year-in-review composed from the analysis tables
+type AnnualReport struct {
+	Year        int   `json:"year"`
+	StartTime   int64 `json:"start_time"` // Local Jan 1 00:00:00
+	EndTime     int64 `json:"end_time"`   // Local Dec 31 23:59:59
+	GeneratedAt int64 `json:"generated_at"`
+
+	// Footprint coverage within the year
+	Footprint *FootprintStatistics `json:"footprint"`
+
+	// Movement totals from daily_summaries
+	ActiveDays     int            `json:"active_days"`
+	TotalDistanceM float64        `json:"total_distance_m"`
+	TotalSteps     int64          `json:"total_steps"`
+	TripCount      int            `json:"trip_count"`
+	DistanceByMode []ModeDistance `json:"distance_by_mode"`
+	BusiestDay     *DailySummary  `json:"busiest_day,omitempty"` // Day with the largest distance
+
+	// Places visited for the first time ever during the year
+	NewCities   []NewAdminArea `json:"new_cities"`
+	NewCounties []NewAdminArea `json:"new_counties"`
+
+	// Highlights
+	LongestTrip         *Trip            `json:"longest_trip,omitempty"`
+	ExtremeEvents       []ExtremeEvent   `json:"extreme_events"`        // Most extreme event of each type within the year
+	TopRevisitLocations []RevisitPattern `json:"top_revisit_locations"` // Strongest revisit locations visited during the year
+}
+
+// ModeDistance is the distance covered with one transport mode
+type ModeDistance struct {
+	Mode      string  `json:"mode"`
+	DistanceM float64 `json:"distance_m"`
+	Share     float64 `json:"share"` // Fraction of the total distance (0-1)
+}
+
+// NewAdminArea is a city or county together with its first-ever visit
+type NewAdminArea struct {
+	Province   string `json:"province"`
+	City       string `json:"city"`
+	County     string `json:"county,omitempty"`
+	FirstVisit int64  `json:"first_visit"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// ScheduleRun is one run of an analysis schedule
+type ScheduleRun struct {
+	ID         int64   `json:"id" db:"id"`
+	Schedule   string  `json:"schedule" db:"schedule"` // incremental, full
+	Cron       string  `json:"cron" db:"cron"`
+	TaskType   string  `json:"task_type" db:"task_type"` // INCREMENTAL, FULL_RECOMPUTE
+	Status     string  `json:"status" db:"status"`       // running, completed, failed, skipped
+	Reason     string  `json:"reason,omitempty" db:"reason"`
+	PointCount int64   `json:"point_count" db:"point_count"`   // Track points when the run started
+	MaxPointID int64   `json:"max_point_id" db:"max_point_id"` // Largest track point id when the run started
+	TaskIDs    []int64 `json:"task_ids" db:"task_ids"`
+	StartedAt  int64   `json:"started_at" db:"started_at"`
+	FinishedAt *int64  `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// ScheduleRun status constants
+const (
+	ScheduleRunRunning   = "running"
+	ScheduleRunCompleted = "completed"
+	ScheduleRunFailed    = "failed"
+	ScheduleRunSkipped   = "skipped"
+)
+
+// Schedule is a configured analysis schedule with its next and latest runs
+type Schedule struct {
+	Name     string       `json:"name"`
+	Cron     string       `json:"cron"`
+	TaskType string       `json:"task_type"`
+	NextRun  int64        `json:"next_run"` // Unix timestamp, 0 when the expression never matches
+	LastRun  *ScheduleRun `json:"last_run,omitempty"`
+}
+
+// ScheduleRunFilter holds the query filters of GET /api/v1/admin/schedules/runs
+type ScheduleRunFilter struct {
+	Schedule string `form:"schedule"`
+	Status   string `form:"status"`
+}
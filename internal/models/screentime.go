@@ -0,0 +1,77 @@
+package models
+
+// ScreenTimeUsage is one app's usage on one day (screen_time_usage table)
+type ScreenTimeUsage struct {
+	ID            int64  `json:"id" db:"id"`
+	Date          string `json:"date" db:"date"` // YYYY-MM-DD
+	Device        string `json:"device,omitempty" db:"device"`
+	Platform      string `json:"platform,omitempty" db:"platform"` // ios, android
+	AppName       string `json:"app_name" db:"app_name"`
+	AppID         string `json:"app_id,omitempty" db:"app_id"` // Bundle ID / package name
+	Category      string `json:"category" db:"category"`
+	DurationS     int64  `json:"duration_s" db:"duration_s"`
+	Launches      int64  `json:"launches" db:"launches"`
+	Notifications int64  `json:"notifications" db:"notifications"`
+	Source        string `json:"source,omitempty" db:"source"`
+}
+
+// ScreenTimeFilter selects a date range (YYYY-MM-DD, inclusive) and device
+type ScreenTimeFilter struct {
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Device   string `form:"device"`
+	Category string `form:"category"`
+	Limit    int    `form:"limit"`
+}
+
+// ScreenTimeSummary is the usage overview of a date range
+type ScreenTimeSummary struct {
+	From           string          `json:"from"`
+	To             string          `json:"to"`
+	Days           int             `json:"days"` // Days with recorded usage
+	TotalDurationS int64           `json:"total_duration_s"`
+	DailyAverageS  int64           `json:"daily_average_s"`
+	TotalLaunches  int64           `json:"total_launches"`
+	TopApps        []AppUsage      `json:"top_apps"`
+	Categories     []CategoryUsage `json:"categories"`
+}
+
+// AppUsage is the total usage of one app
+type AppUsage struct {
+	AppName   string  `json:"app_name"`
+	AppID     string  `json:"app_id,omitempty"`
+	Category  string  `json:"category"`
+	DurationS int64   `json:"duration_s"`
+	Launches  int64   `json:"launches"`
+	Days      int     `json:"days"`  // Days the app was used
+	Share     float64 `json:"share"` // Fraction of the total screen time (0-1)
+}
+
+// CategoryUsage is the total usage of one app category
+type CategoryUsage struct {
+	Category  string  `json:"category"`
+	DurationS int64   `json:"duration_s"`
+	AppCount  int     `json:"app_count"`
+	Share     float64 `json:"share"` // Fraction of the total screen time (0-1)
+}
+
+// WeeklyUsage is the usage of one Monday-based week
+type WeeklyUsage struct {
+	WeekStart      string           `json:"week_start"` // Monday, YYYY-MM-DD
+	Days           int              `json:"days"`       // Days with recorded usage
+	TotalDurationS int64            `json:"total_duration_s"`
+	DailyAverageS  int64            `json:"daily_average_s"`
+	ByCategory     map[string]int64 `json:"by_category"`
+}
+
+// ScreenTimeImportResult summarizes the import of a screen time export
+type ScreenTimeImportResult struct {
+	FileName   string   `json:"file_name"`
+	Format     string   `json:"format"` // ios_screentime, digital_wellbeing, generic
+	ParsedRows int      `json:"parsed_rows"`
+	Records    int      `json:"records"` // Per-app daily records after merging rows
+	Stored     int      `json:"stored"`  // Records inserted or replaced
+	StartDate  string   `json:"start_date,omitempty"`
+	EndDate    string   `json:"end_date,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
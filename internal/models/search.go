@@ -0,0 +1,13 @@
+package models
+
+// SearchResult is one match of a full-text search
+type SearchResult struct {
+	Type      string  `json:"type"`              // stay, trip, place, event
+	Subtype   string  `json:"subtype,omitempty"` // Stay label or type, trip mode, admin level, FLIGHT, TRAIN or ANOMALOUS_DAY
+	ID        int64   `json:"id"`                // ID in the table of the type, e.g. the stay or trip ID
+	Title     string  `json:"title"`
+	Snippet   string  `json:"snippet,omitempty"` // Matching text with the terms in [brackets]
+	Date      string  `json:"date,omitempty"`    // YYYY-MM-DD
+	StartTime int64   `json:"start_time,omitempty"`
+	Score     float64 `json:"score"` // Relevance, higher is better
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // Segment represents a behavior segment (transport mode classification result)
 type Segment struct {
@@ -30,26 +33,104 @@ type Segment struct {
 	HeadingVariance float64 `json:"heading_variance,omitempty" db:"heading_variance"`
 
 	// Classification confidence
-	Confidence  float64 `json:"confidence" db:"confidence"`       // 0~1
-	ReasonCodes string  `json:"reason_codes" db:"reason_codes"`   // JSON array of reason codes
+	Confidence  float64            `json:"confidence" db:"confidence"`     // 0~1
+	ReasonCodes string             `json:"reason_codes" db:"reason_codes"` // JSON array of reason codes
+	ModeProbs   map[string]float64 `json:"mode_probs,omitempty" db:"-"`    // Probability of each mode
 
 	// Administrative divisions
 	Province string `json:"province,omitempty" db:"province"`
 	City     string `json:"city,omitempty" db:"city"`
 	County   string `json:"county,omitempty" db:"county"`
 
+	// Provenance
+	Source string `json:"source,omitempty" db:"source"` // Dominant track point source
+
+	// High-speed rail line covering most of a TRAIN segment, e.g. 京广高铁
+	RailLine string `json:"rail_line,omitempty" db:"rail_line"`
+
 	// Metadata
 	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// SegmentPolyline is the simplified geometry of a segment at one level of detail
+type SegmentPolyline struct {
+	SegmentID        int64        `json:"segment_id"`
+	Mode             string       `json:"mode"`
+	StartTime        int64        `json:"start_time"`
+	EndTime          int64        `json:"end_time"`
+	LOD              int          `json:"lod"`       // 0=low, 1=medium, 2=high
+	Algorithm        string       `json:"algorithm"` // douglas_peucker, visvalingam
+	ToleranceM       float64      `json:"tolerance_m"`
+	Polyline         string       `json:"polyline,omitempty"`    // Encoded polyline (precision 1e5)
+	Coordinates      [][2]float64 `json:"coordinates,omitempty"` // [lon, lat] pairs, with format=coordinates
+	PointCount       int          `json:"point_count"`
+	SourcePointCount int          `json:"source_point_count"`
+	MinLat           float64      `json:"min_lat"`
+	MinLon           float64      `json:"min_lon"`
+	MaxLat           float64      `json:"max_lat"`
+	MaxLon           float64      `json:"max_lon"`
+}
+
+// PolylineResponse is the simplified geometry served for a map zoom level
+type PolylineResponse struct {
+	Zoom      int               `json:"zoom"`
+	LOD       int               `json:"lod"`
+	Polylines []SegmentPolyline `json:"polylines"`
+	Count     int               `json:"count"`
+}
+
+// Polyline formats
+const (
+	PolylineFormatEncoded     = "encoded"
+	PolylineFormatCoordinates = "coordinates"
+)
+
 // TransportMode constants
 const (
 	ModeWalk    = "WALK"
+	ModeBike    = "BIKE"
 	ModeCar     = "CAR"
 	ModeTrain   = "TRAIN"
+	ModePlane   = "PLANE"
 	ModeFlight  = "FLIGHT"
 	ModeStay    = "STAY"
 	ModeUnknown = "UNKNOWN"
 )
+
+// CorrectableModes are the modes the transport mode analyzer classifies,
+// slowest first, which a segment can be corrected to
+var CorrectableModes = []string{ModeWalk, ModeBike, ModeCar, ModeTrain, ModePlane}
+
+// ErrInvalidModeCorrection is returned for a correction to an unknown mode
+var ErrInvalidModeCorrection = errors.New("invalid mode correction")
+
+// SegmentModeRequest corrects the transport mode of a segment
+type SegmentModeRequest struct {
+	Mode string `json:"mode" binding:"required"` // One of CorrectableModes
+	Note string `json:"note"`
+}
+
+// ModeCorrection is a manual correction of the mode of a segment
+// Corrections are keyed by the segment's time span, as segment IDs change
+// with every full transport mode run, which reapplies them to the segments
+// covering the span and calibrates its speed cutoffs with them.
+type ModeCorrection struct {
+	ID           int64   `json:"id"`
+	StartTime    int64   `json:"start_time"`
+	EndTime      int64   `json:"end_time"`
+	OriginalMode string  `json:"original_mode"`
+	Mode         string  `json:"mode"`
+	AvgSpeedKmh  float64 `json:"avg_speed_kmh"`
+	Note         string  `json:"note,omitempty"`
+	CreatedAt    int64   `json:"created_at"`
+}
+
+// SegmentModeResult is a corrected segment and the recomputes queued for the
+// analyzers reading segment modes
+type SegmentModeResult struct {
+	Segment    Segment        `json:"segment"`
+	Correction ModeCorrection `json:"correction"`
+	TaskIDs    []int64        `json:"task_ids"` // Empty when no recompute could be queued; stale results then wait for the next analysis chain
+}
@@ -11,7 +11,7 @@ type FootprintStatistics struct {
 	EndTime   int64 `json:"end_time,omitempty"`
 
 	// Aggregation dimensions
-	StatType  string `json:"stat_type" db:"stat_type"`   // PROVINCE, CITY, COUNTY, TOWN, GRID
+	StatType  string `json:"stat_type" db:"stat_type"`   // PROVINCE, CITY, COUNTY, TOWN, COUNTRY, REGION, GRID
 	StatKey   string `json:"stat_key" db:"stat_key"`     // Province/city/county/town name or grid_id
 	TimeRange string `json:"time_range,omitempty" db:"time_range"` // YYYY, YYYY-MM, YYYY-MM-DD, or ALL
 
@@ -30,20 +30,25 @@ type FootprintStatistics struct {
 	FirstVisitTime      int64   `json:"first_visit_time,omitempty" db:"first_visit_time"` // Unix timestamp
 	LastVisitTime       int64   `json:"last_visit_time,omitempty" db:"last_visit_time"`   // Unix timestamp
 
-	// Administrative division counts and lists
-	ProvinceCount int      `json:"province_count"`
-	Provinces     []string `json:"provinces,omitempty"`
-	CityCount     int      `json:"city_count"`
-	Cities        []string `json:"cities,omitempty"`
-	CountyCount   int      `json:"county_count"`
-	Counties      []string `json:"counties,omitempty"`
-	TownCount     int      `json:"town_count"`
-	VillageCount  int      `json:"village_count"`
-
-	// Rankings
-	RankByPoints   int `json:"rank_by_points,omitempty" db:"rank_by_points"`
-	RankByVisits   int `json:"rank_by_visits,omitempty" db:"rank_by_visits"`
-	RankByDuration int `json:"rank_by_duration,omitempty" db:"rank_by_duration"`
+	// Administrative division counts and lists, by point count
+	// Counties holds the first page only; GET /api/v1/tracks/statistics/footprint/areas pages through all.
+	ProvinceCount int         `json:"province_count"`
+	Provinces     []AreaVisit `json:"provinces,omitempty"`
+	CityCount     int         `json:"city_count"`
+	Cities        []AreaVisit `json:"cities,omitempty"`
+	CountyCount   int         `json:"county_count"`
+	Counties      []AreaVisit `json:"counties,omitempty"`
+	TownCount     int         `json:"town_count"`
+	VillageCount  int         `json:"village_count"`
+
+	// Rankings within stat_type and time_range; 0 until the analyzer has ranked the row
+	RankByPoints       int  `json:"rank_by_points,omitempty" db:"rank_by_points"`
+	RankByVisits       int  `json:"rank_by_visits,omitempty" db:"rank_by_visits"`
+	RankByDuration     int  `json:"rank_by_duration,omitempty" db:"rank_by_duration"`
+	PrevRankByPoints   int  `json:"prev_rank_by_points,omitempty" db:"prev_rank_by_points"` // Rank in the previous period; 0 if absent there
+	PrevRankByVisits   int  `json:"prev_rank_by_visits,omitempty" db:"prev_rank_by_visits"`
+	PrevRankByDuration int  `json:"prev_rank_by_duration,omitempty" db:"prev_rank_by_duration"`
+	RankMovement       *int `json:"rank_movement,omitempty"` // Places gained by points since the previous period; nil if absent there
 
 	// Metadata
 	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
@@ -52,6 +57,20 @@ type FootprintStatistics struct {
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// AreaLevels are the administrative levels of visited-area lists
+var AreaLevels = []string{"province", "city", "county"}
+
+// AreaVisit is an administrative area visited within a time range
+// Cities and counties carry their parent areas, since names repeat across provinces.
+type AreaVisit struct {
+	Province   string `json:"province,omitempty"` // Parent province of a city or county
+	City       string `json:"city,omitempty"`     // Parent city of a county
+	Name       string `json:"name"`
+	PointCount int64  `json:"point_count"`
+	FirstVisit int64  `json:"first_visit"` // Unix timestamp of the first point within the range
+	LastVisit  int64  `json:"last_visit"`
+}
+
 // StayStatistics represents aggregated stay statistics
 type StayStatistics struct {
 	ID int64 `json:"id" db:"id"`
@@ -73,9 +92,12 @@ type StayStatistics struct {
 	MaxDurationSeconds   int64   `json:"max_duration_seconds,omitempty" db:"max_duration_seconds"`
 	StayCategory         string  `json:"stay_category,omitempty" db:"stay_category"` // For CATEGORY stat_type
 
-	// Rankings
-	RankByCount    int `json:"rank_by_count,omitempty" db:"rank_by_count"`
-	RankByDuration int `json:"rank_by_duration,omitempty" db:"rank_by_duration"`
+	// Rankings within stat_type and time_range; 0 until the analyzer has ranked the row
+	RankByCount        int  `json:"rank_by_count,omitempty" db:"rank_by_count"`
+	RankByDuration     int  `json:"rank_by_duration,omitempty" db:"rank_by_duration"`
+	PrevRankByCount    int  `json:"prev_rank_by_count,omitempty" db:"prev_rank_by_count"` // Rank in the previous period; 0 if absent there
+	PrevRankByDuration int  `json:"prev_rank_by_duration,omitempty" db:"prev_rank_by_duration"`
+	RankMovement       *int `json:"rank_movement,omitempty"` // Places gained by stay count since the previous period; nil if absent there
 
 	// Metadata
 	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
@@ -123,6 +145,8 @@ const (
 	StatTypeCounty   = "COUNTY"
 	StatTypeTown     = "TOWN"
 	StatTypeGrid     = "GRID"
+	StatTypeCountry  = "COUNTRY"
+	StatTypeRegion   = "REGION"
 	StatTypeCategory = "CATEGORY"
 )
 
@@ -162,15 +186,18 @@ type SpeedDistribution struct {
 type AdminCrossing struct {
 	ID                 int64     `json:"id" db:"id"`
 	CrossingTS         int64     `json:"crossing_ts" db:"crossing_ts"`
+	FromTS             int64     `json:"from_ts,omitempty" db:"from_ts"` // Last point before the crossing
+	FromCountry        string    `json:"from_country,omitempty" db:"from_country"`
 	FromProvince       string    `json:"from_province,omitempty" db:"from_province"`
 	FromCity           string    `json:"from_city,omitempty" db:"from_city"`
 	FromCounty         string    `json:"from_county,omitempty" db:"from_county"`
 	FromTown           string    `json:"from_town,omitempty" db:"from_town"`
+	ToCountry          string    `json:"to_country,omitempty" db:"to_country"`
 	ToProvince         string    `json:"to_province,omitempty" db:"to_province"`
 	ToCity             string    `json:"to_city,omitempty" db:"to_city"`
 	ToCounty           string    `json:"to_county,omitempty" db:"to_county"`
 	ToTown             string    `json:"to_town,omitempty" db:"to_town"`
-	CrossingType       string    `json:"crossing_type" db:"crossing_type"` // PROVINCE/CITY/COUNTY/TOWN
+	CrossingType       string    `json:"crossing_type" db:"crossing_type"` // COUNTRY/PROVINCE/CITY/COUNTY/TOWN
 	Latitude           float64   `json:"latitude" db:"latitude"`
 	Longitude          float64   `json:"longitude" db:"longitude"`
 	DistanceFromPrevM  float64   `json:"distance_from_prev_m" db:"distance_from_prev_m"`
@@ -178,6 +205,46 @@ type AdminCrossing struct {
 	CreatedAt          time.Time `json:"created_at" db:"created_at"`
 }
 
+// AdminVisit represents a stay in a country, province or city, from the
+// crossing into it to the crossing out of it
+type AdminVisit struct {
+	ID          int64     `json:"id" db:"id"`
+	AdminLevel  string    `json:"admin_level" db:"admin_level"` // COUNTRY/PROVINCE/CITY
+	AdminName   string    `json:"admin_name" db:"admin_name"`
+	ParentName  string    `json:"parent_name,omitempty" db:"parent_name"`
+	ArrivalTS   int64     `json:"arrival_ts" db:"arrival_ts"`
+	DepartureTS int64     `json:"departure_ts" db:"departure_ts"`
+	DurationS   int64     `json:"duration_s" db:"duration_s"`
+	EnteredFrom string    `json:"entered_from,omitempty" db:"entered_from"` // Empty for the first visit
+	ExitedTo    string    `json:"exited_to,omitempty" db:"exited_to"`       // Empty while still there
+	ArrivalLat  float64   `json:"arrival_lat" db:"arrival_lat"`
+	ArrivalLon  float64   `json:"arrival_lon" db:"arrival_lon"`
+	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// AdminVisitFilter narrows the listed visits
+type AdminVisitFilter struct {
+	AdminLevel   string `form:"admin_level"` // COUNTRY, PROVINCE or CITY
+	AdminName    string `form:"admin_name"`
+	ParentName   string `form:"parent_name"`
+	StartTime    int64  `form:"start_time"`     // Unix timestamp; visits ending before it are left out
+	EndTime      int64  `form:"end_time"`       // Unix timestamp; visits arriving after it are left out
+	MinDurationS int64  `form:"min_duration_s"` // 0 for no lower bound
+}
+
+// AdminVisitSummary represents the visits to one region
+type AdminVisitSummary struct {
+	AdminLevel       string `json:"admin_level" db:"admin_level"`
+	AdminName        string `json:"admin_name" db:"admin_name"`
+	ParentName       string `json:"parent_name,omitempty" db:"parent_name"`
+	VisitCount       int    `json:"visit_count" db:"visit_count"`
+	TotalDurationS   int64  `json:"total_duration_s" db:"total_duration_s"`
+	LongestDurationS int64  `json:"longest_duration_s" db:"longest_duration_s"`
+	FirstArrivalTS   int64  `json:"first_arrival_ts" db:"first_arrival_ts"`
+	LastDepartureTS  int64  `json:"last_departure_ts" db:"last_departure_ts"`
+}
+
 // AdminStats represents administrative region statistics
 type AdminStats struct {
 	ID              int64     `json:"id" db:"id"`
@@ -195,6 +262,86 @@ type AdminStats struct {
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// AdminTreeFilter selects the subtree of the administrative hierarchy
+type AdminTreeFilter struct {
+	Province         string `form:"province"`
+	City             string `form:"city"`
+	County           string `form:"county"`
+	Depth            int    `form:"depth"`             // Levels below the root, 1-4 (default 2)
+	IncludeUnvisited bool   `form:"include_unvisited"` // Also list catalogued areas never visited
+}
+
+// AdminTreeNode is an area of the administrative hierarchy with its statistics
+// The COUNTRY root sums its provinces; unique days are not summed.
+type AdminTreeNode struct {
+	Level          string           `json:"level"` // COUNTRY/PROVINCE/CITY/COUNTY/TOWN
+	Name           string           `json:"name"`
+	Visited        bool             `json:"visited"`
+	VisitCount     int              `json:"visit_count"`
+	PointCount     int64            `json:"point_count"`
+	TotalDurationS int64            `json:"total_duration_s"`
+	UniqueDays     int              `json:"unique_days"`
+	FirstVisitTS   int64            `json:"first_visit_ts,omitempty"`
+	LastVisitTS    int64            `json:"last_visit_ts,omitempty"`
+	Coverage       *AdminCoverage   `json:"coverage"` // Nil when the subdivisions are not catalogued
+	Children       []*AdminTreeNode `json:"children,omitempty"`
+}
+
+// AdminCoverage is the share of an area's subdivisions that were visited
+type AdminCoverage struct {
+	VisitedChildren int     `json:"visited_children"`
+	TotalChildren   int     `json:"total_children"`
+	Ratio           float64 `json:"ratio"`
+}
+
+// FootprintCoverage is the share of the country's divisions visited per level
+type FootprintCoverage struct {
+	Year   int             `json:"year"` // Year of the newly unlocked lists
+	Levels []LevelCoverage `json:"levels"`
+}
+
+// LevelCoverage is the coverage of one administrative level
+// Areas are matched to the division catalogue by name, as the footprint
+// statistics key them; Total and Ratio are nil when the level is not catalogued.
+type LevelCoverage struct {
+	Level         string           `json:"level"`   // PROVINCE/CITY/COUNTY
+	Visited       int              `json:"visited"` // Visited areas, catalogued or not
+	Total         *int             `json:"total"`
+	Ratio         *float64         `json:"ratio"` // Visited catalogued areas / Total
+	Areas         []AreaFirstVisit `json:"areas"` // Visited areas, by first visit
+	NewlyUnlocked []AreaFirstVisit `json:"newly_unlocked"`
+}
+
+// AreaFirstVisit is a visited area with the time of its first visit
+type AreaFirstVisit struct {
+	Name       string `json:"name"`
+	FirstVisit int64  `json:"first_visit"`
+	LastVisit  int64  `json:"last_visit"`
+	PointCount int64  `json:"point_count"`
+}
+
+// CountryVisit is a visited country or region with its all-time footprint
+type CountryVisit struct {
+	Code       string `json:"code"` // ISO 3166-1 alpha-2
+	Name       string `json:"name"`
+	NameEN     string `json:"name_en"`
+	Region     string `json:"region"`
+	FirstVisit int64  `json:"first_visit"`
+	LastVisit  int64  `json:"last_visit"`
+	PointCount int64  `json:"point_count"`
+	Days       int    `json:"days"` // Days with points in the country
+}
+
+// YearAbroad counts the days of a year spent outside the home country
+type YearAbroad struct {
+	Year       string         `json:"year"`
+	DaysAbroad int            `json:"days_abroad"` // Days with points in any other country
+	Countries  map[string]int `json:"countries"`   // Days per country code
+}
+
+// AdminLevelCountry is the root level of the administrative tree
+const AdminLevelCountry = "COUNTRY"
+
 // CrossingType constants
 const (
 	CrossingTypeProvince = "PROVINCE"
@@ -242,6 +389,7 @@ type SpeedSpaceStats struct {
 	ID             int64   `json:"id" db:"id"`
 	BucketType     string  `json:"bucket_type" db:"bucket_type"`       // year, month, all
 	BucketKey      string  `json:"bucket_key" db:"bucket_key"`         // 2024, 2024-01, all
+	Source         string  `json:"source" db:"source"`                 // all, or a track point source
 	AreaType       string  `json:"area_type" db:"area_type"`           // PROVINCE, CITY, COUNTY
 	AreaKey        string  `json:"area_key" db:"area_key"`             // Area name
 	AvgSpeed       float64 `json:"avg_speed" db:"avg_speed"`           // km/h
@@ -259,17 +407,20 @@ type SpeedSpaceStats struct {
 // DirectionalBiasStats represents directional movement pattern statistics
 type DirectionalBiasStats struct {
 	ID                       int64   `json:"id" db:"id"`
-	BucketType               string  `json:"bucket_type" db:"bucket_type"`                               // year, month, all
-	BucketKey                string  `json:"bucket_key" db:"bucket_key"`                                 // 2025, 2025-01, all
-	AreaType                 string  `json:"area_type" db:"area_type"`                                   // PROVINCE, CITY, COUNTY
-	AreaKey                  string  `json:"area_key" db:"area_key"`                                     // Area name
+	BucketType               string  `json:"bucket_type" db:"bucket_type"`                               // year, month, season, all
+	BucketKey                string  `json:"bucket_key" db:"bucket_key"`                                 // 2025, 2025-01, 2025-winter, all
+	Source                   string  `json:"source" db:"source"`                                         // all, or a track point source
+	AreaType                 string  `json:"area_type" db:"area_type"`                                   // PROVINCE, CITY, COUNTY, GEOHASH5, GEOHASH6
+	AreaKey                  string  `json:"area_key" db:"area_key"`                                     // Area name or geohash
 	ModeFilter               string  `json:"mode_filter" db:"mode_filter"`                               // ALL, WALK, CAR, TRAIN, FLIGHT
 	DirectionHistogramJSON   string  `json:"direction_histogram_json" db:"direction_histogram_json"`     // JSON array of bins
-	NumBins                  int     `json:"num_bins" db:"num_bins"`                                     // 8 or 16
+	NumBins                  int     `json:"num_bins" db:"num_bins"`                                     // 8, 16 or 36
 	DominantDirectionDeg     float64 `json:"dominant_direction_deg" db:"dominant_direction_deg"`         // 0-360 degrees
 	DirectionalConcentration float64 `json:"directional_concentration" db:"directional_concentration"`   // 0-1
 	BidirectionalScore       float64 `json:"bidirectional_score" db:"bidirectional_score"`               // 0-1
 	DirectionalEntropy       float64 `json:"directional_entropy" db:"directional_entropy"`               // 0-1
+	DominantAxisDeg          float64 `json:"dominant_axis_deg" db:"dominant_axis_deg"`                   // 0-180 degrees, 0 north-south
+	AnisotropyIndex          float64 `json:"anisotropy_index" db:"anisotropy_index"`                     // 0-1, along vs across the dominant axis
 	TotalDistance            float64 `json:"total_distance" db:"total_distance"`                         // meters
 	TotalDuration            int64   `json:"total_duration" db:"total_duration"`                         // seconds
 	SegmentCount             int     `json:"segment_count" db:"segment_count"`
@@ -277,11 +428,40 @@ type DirectionalBiasStats struct {
 	CreatedAt                string  `json:"created_at" db:"created_at"`
 }
 
+// DirectionalRose is the direction histogram of an area as wind-rose bins
+type DirectionalRose struct {
+	BucketType           string    `json:"bucket_type"`
+	BucketKey            string    `json:"bucket_key"`
+	Source               string    `json:"source"`
+	AreaType             string    `json:"area_type"`
+	AreaKey              string    `json:"area_key"`
+	ModeFilter           string    `json:"mode_filter"`
+	BinWidthDeg          float64   `json:"bin_width_deg"`
+	DominantDirectionDeg float64   `json:"dominant_direction_deg"`
+	DominantAxisDeg      float64   `json:"dominant_axis_deg"`
+	AnisotropyIndex      float64   `json:"anisotropy_index"`
+	TotalDistance        float64   `json:"total_distance"` // meters
+	SegmentCount         int       `json:"segment_count"`
+	Bins                 []RoseBin `json:"bins"` // Clockwise from north
+}
+
+// RoseBin is one direction bin of a wind rose
+type RoseBin struct {
+	Bin       int     `json:"bin"`
+	CenterDeg float64 `json:"center_deg"` // Bearing the bin is centered on, 0 for north
+	FromDeg   float64 `json:"from_deg"`   // Bin covers bearings from FromDeg clockwise to ToDeg
+	ToDeg     float64 `json:"to_deg"`
+	Distance  float64 `json:"distance"` // meters
+	Count     int     `json:"count"`
+	Weight    float64 `json:"weight"` // Share of the total distance, 0-1; the weights sum to 1
+}
+
 // SpatialUtilization represents spatial utilization efficiency metrics
 type SpatialUtilization struct {
 	ID                    int64   `json:"id" db:"id"`
 	BucketType            string  `json:"bucket_type" db:"bucket_type"`
 	BucketKey             string  `json:"bucket_key,omitempty" db:"bucket_key"`
+	Source                string  `json:"source" db:"source"`
 	AreaType              string  `json:"area_type" db:"area_type"`
 	AreaKey               string  `json:"area_key" db:"area_key"`
 	TransitIntensity      int     `json:"transit_intensity" db:"transit_intensity"`
@@ -305,6 +485,7 @@ type SpatialDensityGrid struct {
 	ID             int64    `json:"id" db:"id"`
 	BucketType     string   `json:"bucket_type" db:"bucket_type"`
 	BucketKey      string   `json:"bucket_key,omitempty" db:"bucket_key"`
+	Source         string   `json:"source" db:"source"`
 	GridID         string   `json:"grid_id" db:"grid_id"`
 	CenterLat      float64  `json:"center_lat" db:"center_lat"`
 	CenterLon      float64  `json:"center_lon" db:"center_lon"`
@@ -328,6 +509,7 @@ type AltitudeStats struct {
 	ID                int64   `json:"id" db:"id"`
 	BucketType        string  `json:"bucket_type" db:"bucket_type"`
 	BucketKey         string  `json:"bucket_key,omitempty" db:"bucket_key"`
+	Source            string  `json:"source" db:"source"`
 	AreaType          string  `json:"area_type" db:"area_type"`
 	AreaKey           string  `json:"area_key,omitempty" db:"area_key"`
 	MinAltitude       float64 `json:"min_altitude" db:"min_altitude"`
@@ -354,6 +536,7 @@ type TimeSpaceCompression struct {
 	ID                     int64   `json:"id" db:"id"`
 	BucketType             string  `json:"bucket_type" db:"bucket_type"`
 	BucketKey              string  `json:"bucket_key,omitempty" db:"bucket_key"`
+	Source                 string  `json:"source" db:"source"`
 	AreaType               string  `json:"area_type" db:"area_type"`
 	AreaKey                string  `json:"area_key,omitempty" db:"area_key"`
 	MovementIntensity      float64 `json:"movement_intensity" db:"movement_intensity"`
@@ -377,6 +560,227 @@ type TimeSpaceCompression struct {
 	UpdatedAt              int64   `json:"updated_at" db:"updated_at"`
 }
 
+// ModeStats represents the totals of one transport mode in a time bucket
+type ModeStats struct {
+	ID           int64   `json:"id" db:"id"`
+	BucketType   string  `json:"bucket_type" db:"bucket_type"` // year, month, all
+	BucketKey    string  `json:"bucket_key" db:"bucket_key"`   // 2025, 2025-01, all
+	Source       string  `json:"source" db:"source"`
+	Mode         string  `json:"mode" db:"mode"`
+	DistanceM    float64 `json:"distance_m" db:"distance_m"`
+	DurationS    int64   `json:"duration_s" db:"duration_s"`
+	SegmentCount int     `json:"segment_count" db:"segment_count"`
+	TripCount    int     `json:"trip_count" db:"trip_count"`
+	CO2Kg        float64 `json:"co2_kg" db:"co2_kg"` // CO2-equivalent estimate from per-mode emission factors
+	AlgoVersion  string  `json:"algo_version" db:"algo_version"`
+	CreatedAt    int64   `json:"created_at" db:"created_at"`
+}
+
+// DaypartStats represents movement in one part of the day per time bucket
+type DaypartStats struct {
+	ID            int64   `json:"id" db:"id"`
+	BucketType    string  `json:"bucket_type" db:"bucket_type"` // year, month, all
+	BucketKey     string  `json:"bucket_key" db:"bucket_key"`   // 2025, 2025-01, all
+	Source        string  `json:"source" db:"source"`
+	Daypart       string  `json:"daypart" db:"daypart"` // MORNING, AFTERNOON, EVENING, NIGHT
+	DistanceM     float64 `json:"distance_m" db:"distance_m"`
+	ActiveS       int64   `json:"active_s" db:"active_s"`   // Time spent moving
+	TrackedS      int64   `json:"tracked_s" db:"tracked_s"` // Time covered by the track
+	PointCount    int64   `json:"point_count" db:"point_count"`
+	DayCount      int     `json:"day_count" db:"day_count"`
+	GridCount     int     `json:"grid_count" db:"grid_count"`
+	CityCount     int     `json:"city_count" db:"city_count"`
+	DistanceShare float64 `json:"distance_share" db:"distance_share"` // Share of the bucket's distance (0-1)
+	ActiveShare   float64 `json:"active_share" db:"active_share"`     // Share of the bucket's active time (0-1)
+	AlgoVersion   string  `json:"algo_version" db:"algo_version"`
+	CreatedAt     int64   `json:"created_at" db:"created_at"`
+}
+
+// DayTypeStats represents movement, places and stays on one calendar day type per time bucket
+type DayTypeStats struct {
+	ID             int64                    `json:"id" db:"id"`
+	BucketType     string                   `json:"bucket_type" db:"bucket_type"` // year, all
+	BucketKey      string                   `json:"bucket_key" db:"bucket_key"`   // 2025, all
+	DayType        string                   `json:"day_type" db:"day_type"`       // WORKDAY, WEEKEND, HOLIDAY
+	DayCount       int                      `json:"day_count" db:"day_count"`
+	TotalDistanceM float64                  `json:"total_distance_m" db:"total_distance_m"`
+	AvgDistanceM   float64                  `json:"avg_distance_m" db:"avg_distance_m"`
+	AvgActiveTimeS float64                  `json:"avg_active_time_s" db:"avg_active_time_s"`
+	TripCount      int                      `json:"trip_count" db:"trip_count"`
+	AvgTripCount   float64                  `json:"avg_trip_count" db:"avg_trip_count"`
+	DistanceByMode map[string]float64       `json:"distance_by_mode" db:"distance_by_mode"`
+	CityCount      int                      `json:"city_count" db:"city_count"`
+	AvgCityCount   float64                  `json:"avg_city_count" db:"avg_city_count"`
+	StayCount      int                      `json:"stay_count" db:"stay_count"`
+	StayDurationS  int64                    `json:"stay_duration_s" db:"stay_duration_s"`
+	StayTypes      map[string]StayTypeTotal `json:"stay_types" db:"stay_types"` // Stay label -> totals
+	AlgoVersion    string                   `json:"algo_version" db:"algo_version"`
+	CreatedAt      int64                    `json:"created_at" db:"created_at"`
+}
+
+// StayTypeTotal is the number and duration of stays with one label
+type StayTypeTotal struct {
+	Count     int   `json:"count"`
+	DurationS int64 `json:"duration_s"`
+}
+
+// DayTypeComparison compares weekends and public holidays with workdays in one time bucket
+type DayTypeComparison struct {
+	BucketType       string         `json:"bucket_type"`
+	BucketKey        string         `json:"bucket_key"`
+	Workday          *DayTypeStats  `json:"workday,omitempty"`
+	Weekend          *DayTypeStats  `json:"weekend,omitempty"`
+	Holiday          *DayTypeStats  `json:"holiday,omitempty"`
+	WeekendVsWorkday *DayTypeRatios `json:"weekend_vs_workday,omitempty"`
+	HolidayVsWorkday *DayTypeRatios `json:"holiday_vs_workday,omitempty"`
+}
+
+// DayTypeRatios are per-day averages of one day type divided by those of workdays
+// A ratio is omitted when the workday average is zero.
+type DayTypeRatios struct {
+	AvgDistance   *float64 `json:"avg_distance,omitempty"`
+	AvgActiveTime *float64 `json:"avg_active_time,omitempty"`
+	AvgTrips      *float64 `json:"avg_trips,omitempty"`
+	AvgCities     *float64 `json:"avg_cities,omitempty"`
+}
+
+// AnomalousDay represents a day whose behaviour stands out from the preceding days
+// Z-scores are robust (median / MAD); distance, radius and novel cells are compared on a log scale.
+type AnomalousDay struct {
+	ID                int64    `json:"id" db:"id"`
+	Date              string   `json:"date" db:"date"` // YYYY-MM-DD
+	DistanceM         float64  `json:"distance_m" db:"distance_m"`
+	RadiusOfGyrationM float64  `json:"radius_of_gyration_m" db:"radius_of_gyration_m"`
+	StayCount         int      `json:"stay_count" db:"stay_count"`
+	NovelGridCount    int      `json:"novel_grid_count" db:"novel_grid_count"` // Grid cells visited for the first time
+	BaselineDays      int      `json:"baseline_days" db:"baseline_days"`
+	DistanceZ         float64  `json:"distance_z" db:"distance_z"`
+	RadiusZ           float64  `json:"radius_z" db:"radius_z"`
+	StayZ             float64  `json:"stay_z" db:"stay_z"`
+	NovelZ            float64  `json:"novel_z" db:"novel_z"`
+	Score             float64  `json:"score" db:"score"`       // Largest absolute z-score
+	Category          string   `json:"category" db:"category"` // TRAVEL_DAY, UNUSUAL_ROUTINE, QUIET_DAY
+	Reasons           []string `json:"reasons" db:"reasons"`   // e.g. DISTANCE_HIGH, NOVEL_CELLS_HIGH
+	AlgoVersion       string   `json:"algo_version" db:"algo_version"`
+	CreatedAt         int64    `json:"created_at" db:"created_at"`
+}
+
+// AnomalousDayFilter narrows the anomalous day list
+type AnomalousDayFilter struct {
+	From     string  `form:"from"`      // YYYY-MM-DD, inclusive
+	To       string  `form:"to"`        // YYYY-MM-DD, inclusive
+	Category string  `form:"category"`  // TRAVEL_DAY, UNUSUAL_ROUTINE, QUIET_DAY
+	Reason   string  `form:"reason"`    // e.g. DISTANCE_HIGH
+	MinScore float64 `form:"min_score"` // 0 for no lower bound
+}
+
+// ExplorationMonth represents one month of the exploration curve
+// Points and time are novel in a grid cell on the day it was first visited.
+type ExplorationMonth struct {
+	ID                  int64   `json:"id" db:"id"`
+	Month               string  `json:"month" db:"month"` // YYYY-MM
+	PointCount          int     `json:"point_count" db:"point_count"`
+	NovelPointCount     int     `json:"novel_point_count" db:"novel_point_count"`
+	TrackedS            int64   `json:"tracked_s" db:"tracked_s"`
+	NovelTimeS          int64   `json:"novel_time_s" db:"novel_time_s"`
+	NovelPointRatio     float64 `json:"novel_point_ratio" db:"novel_point_ratio"` // 0-1
+	NovelTimeRatio      float64 `json:"novel_time_ratio" db:"novel_time_ratio"`   // 0-1
+	NewCellCount        int     `json:"new_cell_count" db:"new_cell_count"`
+	CumulativeCellCount int     `json:"cumulative_cell_count" db:"cumulative_cell_count"` // Ever visited by the end of the month
+	ActiveDays          int     `json:"active_days" db:"active_days"`
+	DiscoveryDays       int     `json:"discovery_days" db:"discovery_days"` // Days with at least one new cell
+	AlgoVersion         string  `json:"algo_version" db:"algo_version"`
+	CreatedAt           int64   `json:"created_at" db:"created_at"`
+}
+
+// DiscoveryStreak represents consecutive days that each visited a new grid cell
+type DiscoveryStreak struct {
+	ID           int64  `json:"id" db:"id"`
+	StartDate    string `json:"start_date" db:"start_date"` // YYYY-MM-DD
+	EndDate      string `json:"end_date" db:"end_date"`     // YYYY-MM-DD
+	DaysCount    int    `json:"days_count" db:"days_count"`
+	NewCellCount int    `json:"new_cell_count" db:"new_cell_count"`
+	AlgoVersion  string `json:"algo_version" db:"algo_version"`
+	CreatedAt    int64  `json:"created_at" db:"created_at"`
+}
+
+// ExplorationCurve is the monthly exploration curve over a range of months
+type ExplorationCurve struct {
+	Months        []ExplorationMonth `json:"months"`
+	TotalCells    int                `json:"total_cells"` // Ever visited by the last month listed
+	LongestStreak *DiscoveryStreak   `json:"longest_streak,omitempty"`
+}
+
+// FirstVisit represents the first time an admin area was visited
+// The entry point is the boundary crossing into the area, or the first point in
+// it when there was none (tracking started there, or a flight landed there).
+type FirstVisit struct {
+	ID             int64    `json:"id" db:"id"`
+	Level          string   `json:"level" db:"level"` // CITY, COUNTY
+	Area           string   `json:"area" db:"area"`
+	Province       string   `json:"province,omitempty" db:"province"`
+	City           string   `json:"city,omitempty" db:"city"` // Parent city of a county
+	FirstVisit     int64    `json:"first_visit" db:"first_visit"`
+	Date           string   `json:"date"` // YYYY-MM-DD (local time)
+	VisitCount     int      `json:"visit_count" db:"visit_count"`
+	PointCount     int      `json:"point_count" db:"point_count"`
+	EntryType      string   `json:"entry_type" db:"entry_type"` // CROSSING, FIRST_POINT
+	EntryLatitude  *float64 `json:"entry_latitude,omitempty" db:"entry_latitude"`
+	EntryLongitude *float64 `json:"entry_longitude,omitempty" db:"entry_longitude"`
+	FromArea       string   `json:"from_area,omitempty" db:"from_area"` // Area of the same level left at the crossing
+	CrossingID     *int64   `json:"crossing_id,omitempty" db:"crossing_id"`
+	TripID         *int64   `json:"trip_id,omitempty" db:"trip_id"` // Trip under way at the first visit
+}
+
+// PersonalRecord represents a personal best, with references to what set it
+type PersonalRecord struct {
+	ID          int64                  `json:"id" db:"id"`
+	RecordType  string                 `json:"record_type" db:"record_type"` // e.g. LONGEST_DAY_DISTANCE, FASTEST_GROUND_SEGMENT
+	Value       float64                `json:"value" db:"value"`
+	Unit        string                 `json:"unit" db:"unit"` // m, s, km/h, days, cities
+	StartTime   *int64                 `json:"start_time,omitempty" db:"start_time"`
+	EndTime     *int64                 `json:"end_time,omitempty" db:"end_time"`
+	Date        string                 `json:"date,omitempty" db:"date"`         // YYYY-MM-DD
+	EndDate     string                 `json:"end_date,omitempty" db:"end_date"` // Set when the record spans days
+	PointID     *int64                 `json:"point_id,omitempty" db:"point_id"`
+	SegmentID   *int64                 `json:"segment_id,omitempty" db:"segment_id"`
+	TripID      *int64                 `json:"trip_id,omitempty" db:"trip_id"`
+	StayID      *int64                 `json:"stay_id,omitempty" db:"stay_id"`
+	Latitude    *float64               `json:"latitude,omitempty" db:"latitude"`
+	Longitude   *float64               `json:"longitude,omitempty" db:"longitude"`
+	Details     map[string]interface{} `json:"details,omitempty" db:"details"`
+	AlgoVersion string                 `json:"algo_version" db:"algo_version"`
+	CreatedAt   int64                  `json:"created_at" db:"created_at"`
+}
+
+// MetricValue is one named metric of a time bucket; Value is nil when it is undefined
+type MetricValue struct {
+	Metric string   `json:"metric"`
+	Value  *float64 `json:"value,omitempty"`
+}
+
+// MetricComparison compares one metric between two time buckets
+// Delta is B - A; ChangePct is relative to A and omitted when A is zero.
+type MetricComparison struct {
+	Metric    string   `json:"metric"`
+	A         *float64 `json:"a,omitempty"`
+	B         *float64 `json:"b,omitempty"`
+	Delta     *float64 `json:"delta,omitempty"`
+	ChangePct *float64 `json:"change_pct,omitempty"`
+}
+
+// BucketComparison compares the main bucketed statistics of two months or two years
+// A section is empty when neither bucket has data for it.
+type BucketComparison struct {
+	BucketType  string             `json:"bucket_type"` // year, month
+	BucketA     string             `json:"bucket_a"`
+	BucketB     string             `json:"bucket_b"`
+	Footprint   []MetricComparison `json:"footprint"`
+	SpeedSpace  []MetricComparison `json:"speed_space"`
+	Compression []MetricComparison `json:"compression"`
+	Altitude    []MetricComparison `json:"altitude"`
+}
+
 // TimeSpaceSlice represents a time-space slice for spatiotemporal analysis
 type TimeSpaceSlice struct {
 	ID               int64  `json:"id" db:"id"`
@@ -393,10 +797,11 @@ type TimeSpaceSlice struct {
 	CreatedAt        string `json:"created_at" db:"created_at"`
 }
 
-// SpatialComplexity represents spatial complexity metrics
+// SpatialComplexity represents the spatial complexity metrics of a day, a month or all time
 type SpatialComplexity struct {
 	ID                   int64   `json:"id" db:"id"`
-	MetricDate           string  `json:"metric_date,omitempty" db:"metric_date"`
+	PeriodType           string  `json:"period_type" db:"period_type"`           // day, month or all
+	MetricDate           string  `json:"metric_date,omitempty" db:"metric_date"` // YYYY-MM-DD or YYYY-MM, empty for all time
 	TrajectoryComplexity float64 `json:"trajectory_complexity" db:"trajectory_complexity"`
 	DirectionChanges     int64   `json:"direction_changes" db:"direction_changes"`
 	AvgTurnAngle         float64 `json:"avg_turn_angle" db:"avg_turn_angle"`
@@ -435,3 +840,84 @@ type RoadTypeStats struct {
 	DistanceKm   float64 `json:"distance_km"`
 	AvgRatio     float64 `json:"avg_ratio"`
 }
+
+// RailLineStats represents the mileage travelled on each high-speed rail line
+type RailLineStats struct {
+	Year            int               `json:"year,omitempty"` // Single year; 0 for all years
+	TotalDistanceKm float64           `json:"total_distance_km"`
+	Lines           []RailLineMileage `json:"lines"` // Longest first
+}
+
+// RailLineMileage represents the mileage travelled on one high-speed rail line
+type RailLineMileage struct {
+	Line         string  `json:"line" db:"line"` // e.g. 京广高铁
+	DistanceKm   float64 `json:"distance_km"`
+	SegmentCount int     `json:"segment_count" db:"segment_count"`
+	FirstTime    int64   `json:"first_time" db:"first_time"` // Start of the first segment on the line
+	LastTime     int64   `json:"last_time" db:"last_time"`   // Start of the latest segment on the line
+}
+
+// CommutePattern represents a recurring HOME<->WORK pattern for one direction and weekday
+type CommutePattern struct {
+	ID                 int64              `json:"id" db:"id"`
+	Direction          string             `json:"direction" db:"direction"` // HOME_TO_WORK, WORK_TO_HOME
+	Weekday            int                `json:"weekday" db:"weekday"`     // 0=Sunday ... 6=Saturday
+	TripCount          int                `json:"trip_count" db:"trip_count"`
+	MedianDeparture    string             `json:"median_departure"` // HH:MM
+	P25Departure       string             `json:"p25_departure"`    // HH:MM
+	P75Departure       string             `json:"p75_departure"`    // HH:MM
+	MedianDepartureMin int                `json:"median_departure_min" db:"median_departure_min"`
+	MedianDurationS    int64              `json:"median_duration_s" db:"median_duration_s"`
+	AvgDurationS       float64            `json:"avg_duration_s" db:"avg_duration_s"`
+	P90DurationS       int64              `json:"p90_duration_s" db:"p90_duration_s"`
+	AvgDistanceM       float64            `json:"avg_distance_m" db:"avg_distance_m"`
+	RouteVariability   float64            `json:"route_variability" db:"route_variability"` // 0 = always the same route
+	ModeSplit          map[string]float64 `json:"mode_split"`
+	FirstTripTS        int64              `json:"first_trip_ts" db:"first_trip_ts"`
+	LastTripTS         int64              `json:"last_trip_ts" db:"last_trip_ts"`
+}
+
+// CommuteDirectionSummary aggregates commute patterns of one direction over all weekdays
+type CommuteDirectionSummary struct {
+	TripCount        int     `json:"trip_count"`
+	AvgDurationS     float64 `json:"avg_duration_s"`
+	AvgDistanceM     float64 `json:"avg_distance_m"`
+	RouteVariability float64 `json:"route_variability"`
+}
+
+// CommuteStats represents the commute patterns response
+type CommuteStats struct {
+	Patterns   []CommutePattern                   `json:"patterns"`
+	Directions map[string]CommuteDirectionSummary `json:"directions"`
+}
+
+// RouteCluster represents a frequent route discovered by route clustering
+type RouteCluster struct {
+	ID                   int64        `json:"id" db:"id"`
+	TripCount            int          `json:"trip_count" db:"trip_count"`
+	RepresentativeTripID int64        `json:"representative_trip_id" db:"representative_trip_id"`
+	Polyline             [][2]float64 `json:"polyline,omitempty"` // [lat, lon] pairs
+	StartLat             float64      `json:"start_lat" db:"start_lat"`
+	StartLon             float64      `json:"start_lon" db:"start_lon"`
+	EndLat               float64      `json:"end_lat" db:"end_lat"`
+	EndLon               float64      `json:"end_lon" db:"end_lon"`
+	PrimaryMode          string       `json:"primary_mode" db:"primary_mode"`
+	AvgDistanceM         float64      `json:"avg_distance_m" db:"avg_distance_m"`
+	AvgDurationS         float64      `json:"avg_duration_s" db:"avg_duration_s"`
+	MinDurationS         int64        `json:"min_duration_s" db:"min_duration_s"`
+	MaxDurationS         int64        `json:"max_duration_s" db:"max_duration_s"`
+	AvgSpeedKmh          float64      `json:"avg_speed_kmh" db:"avg_speed_kmh"`
+	MedianSpeedKmh       float64      `json:"median_speed_kmh" db:"median_speed_kmh"`
+	MaxSpeedKmh          float64      `json:"max_speed_kmh" db:"max_speed_kmh"`
+	MeanDTWM             float64      `json:"mean_dtw_m" db:"mean_dtw_m"`
+	FirstUsedTS          int64        `json:"first_used_ts" db:"first_used_ts"`
+	LastUsedTS           int64        `json:"last_used_ts" db:"last_used_ts"`
+	TripIDs              []int64      `json:"trip_ids,omitempty"`
+}
+
+// RouteClusterFilter represents filter parameters for frequent routes
+type RouteClusterFilter struct {
+	Mode            string `form:"mode"`
+	MinTrips        int    `form:"minTrips"`
+	IncludePolyline *bool  `form:"includePolyline"` // Defaults to true
+}
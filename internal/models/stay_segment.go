@@ -38,6 +38,13 @@ type StaySegment struct {
 	StayCategory string  `json:"stay_category,omitempty" db:"stay_category"` // HOME, WORK, TRANSIT, LEISURE, etc.
 	Confidence   float64 `json:"confidence,omitempty" db:"confidence"`       // 0~1
 
+	// Provenance
+	Source string `json:"source,omitempty" db:"source"` // Dominant track point source
+
+	// Place the stay belongs to, see Place
+	PlaceID   int64  `json:"place_id,omitempty" db:"place_id"`
+	PlaceName string `json:"place_name,omitempty" db:"place_name"`
+
 	// Metadata
 	Metadata    string    `json:"metadata,omitempty" db:"metadata"`         // JSON metadata
 	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
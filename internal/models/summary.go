@@ -0,0 +1,41 @@
+package models
+
+// DailySummary is the rollup of one local calendar day (daily_summaries table)
+type DailySummary struct {
+	Date            string             `json:"date" db:"date"`
+	Weekday         int                `json:"weekday" db:"weekday"` // 0=Sunday ... 6=Saturday
+	DayType         string             `json:"day_type" db:"day_type"`
+	HasData         bool               `json:"has_data"` // false for days without any recorded data
+	PointCount      int64              `json:"point_count" db:"point_count"`
+	TotalDistanceM  float64            `json:"total_distance_m" db:"total_distance_m"`
+	DistanceByMode  map[string]float64 `json:"distance_by_mode" db:"distance_by_mode"`
+	PrimaryMode     *string            `json:"primary_mode,omitempty" db:"primary_mode"`
+	ActiveTimeS     int64              `json:"active_time_s" db:"active_time_s"`
+	MaxSpeedKmh     float64            `json:"max_speed_kmh" db:"max_speed_kmh"`
+	StepEquivalent  int64              `json:"step_equivalent" db:"step_equivalent"`
+	TripCount       int                `json:"trip_count" db:"trip_count"`
+	StayCount       int                `json:"stay_count" db:"stay_count"`
+	FirstMovementTS *int64             `json:"first_movement_ts,omitempty" db:"first_movement_ts"`
+	LastMovementTS  *int64             `json:"last_movement_ts,omitempty" db:"last_movement_ts"`
+	Provinces       []string           `json:"provinces" db:"provinces"`
+	Cities          []string           `json:"cities" db:"cities"`
+	CityCount       int                `json:"city_count" db:"city_count"`
+	CountyCount     int                `json:"county_count" db:"county_count"`
+}
+
+// DailySummaryFilter selects a date range (YYYY-MM-DD, inclusive)
+type DailySummaryFilter struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+// DailyTimeline is a gap-free run of days for calendar views
+type DailyTimeline struct {
+	From           string         `json:"from"`
+	To             string         `json:"to"`
+	Days           []DailySummary `json:"days"`
+	ActiveDays     int            `json:"active_days"`
+	TotalDistanceM float64        `json:"total_distance_m"`
+	MaxDistanceM   float64        `json:"max_distance_m"` // Largest daily distance, for color scales
+	TotalSteps     int64          `json:"total_steps"`
+}
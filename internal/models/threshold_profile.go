@@ -11,14 +11,25 @@ type ThresholdProfile struct {
 	Description string `json:"description,omitempty" db:"description"`
 	IsDefault   bool   `json:"is_default" db:"is_default"`
 
-	// Skill name
-	SkillName string `json:"skill_name" db:"skill_name"` // e.g., "stay_detection", "transport_mode"
-
-	// Parameters (JSON)
-	ParamsJSON string `json:"params_json" db:"params_json"` // JSON object with all parameters
+	// Parameters (JSON), keyed by skill name, e.g. {"transport_mode": {...}, "speed_events": {...}}
+	ParamsJSON string `json:"params_json" db:"params_json"`
 
 	// Metadata
-	CreatedBy string    `json:"created_by,omitempty" db:"created_by"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
+
+// ThresholdProfileRequest represents the request body for creating or updating a threshold profile
+type ThresholdProfileRequest struct {
+	Name        string                 `json:"name"`
+	Description *string                `json:"description"`
+	Params      map[string]interface{} `json:"params"`
+	Replace     bool                   `json:"replace"` // Replace params instead of merging them into the existing ones
+}
+
+// EffectiveThresholds represents a profile's params merged over the default profile
+type EffectiveThresholds struct {
+	ProfileID   int64                  `json:"profile_id"`
+	ProfileName string                 `json:"profile_name"`
+	Params      map[string]interface{} `json:"params"`
+}
@@ -20,6 +20,17 @@ type TrackPoint struct {
 	County   string `json:"county,omitempty" db:"county"`         // 区县级
 	Town     string `json:"town,omitempty" db:"town"`             // 乡镇级
 	Village  string `json:"village,omitempty" db:"village"`       // 村级/街道级
+	Country  string `json:"country,omitempty" db:"country"`       // ISO 3166-1 国家/地区代码
+	Region   string `json:"region,omitempty" db:"region"`         // 世界区域（东亚、欧洲……）
+
+	// Provenance
+	Source       string `json:"source,omitempty" db:"source"`              // App or import that produced the point, legacy before provenance tracking
+	SourceDevice string `json:"sourceDevice,omitempty" db:"source_device"` // Device or app that recorded the point
+
+	// Phone state reported by live ingestion
+	Motion       string   `json:"motion,omitempty" db:"motion"`              // Comma-separated: stationary, walking, running, cycling, driving
+	BatteryLevel *float64 `json:"batteryLevel,omitempty" db:"battery_level"` // Percent
+	BatteryState string   `json:"batteryState,omitempty" db:"battery_state"` // charging, unplugged, full
 
 	// Metadata
 	CreatedAt   *string `json:"createdAt,omitempty" db:"created_at"`
@@ -38,13 +49,62 @@ type TrackPointsResponse struct {
 
 // TrackPointFilter represents filter parameters for querying track points
 type TrackPointFilter struct {
-	StartTime int64   `form:"startTime"`  // Unix timestamp
-	EndTime   int64   `form:"endTime"`    // Unix timestamp
-	Province  string  `form:"province"`
-	City      string  `form:"city"`
-	County    string  `form:"county"`
-	MinSpeed  float64 `form:"minSpeed"`
-	MaxSpeed  float64 `form:"maxSpeed"`
-	Page      int     `form:"page"`
-	PageSize  int     `form:"pageSize"`
+	StartTime    int64   `form:"startTime"`  // Unix timestamp
+	EndTime      int64   `form:"endTime"`    // Unix timestamp
+	Province     string  `form:"province"`
+	City         string  `form:"city"`
+	County       string  `form:"county"`
+	Country      string  `form:"country"`      // ISO 3166-1 alpha-2 code
+	Source       string  `form:"source"`       // App or import that produced the point
+	SourceDevice string  `form:"sourceDevice"` // Device or app that recorded the point
+	MinSpeed     float64 `form:"minSpeed"`
+	MaxSpeed     float64 `form:"maxSpeed"`
+	Page         int     `form:"page"`
+	PageSize     int     `form:"pageSize"`
+}
+
+// TrackPointCursorFilter represents filter parameters for cursor-based track point queries
+type TrackPointCursorFilter struct {
+	AfterID   int64   `form:"after_id"`  // Return points with id greater than this cursor
+	Limit     int     `form:"limit"`     // Max points per page (default 1000, max 10000)
+	Fields    string  `form:"fields"`    // Comma-separated field names, empty for all
+	StartTime int64   `form:"startTime"` // Unix timestamp
+	EndTime   int64   `form:"endTime"`   // Unix timestamp
+	MinLat    float64 `form:"minLat"`
+	MaxLat    float64 `form:"maxLat"`
+	MinLon    float64 `form:"minLon"`
+	MaxLon    float64 `form:"maxLon"`
+	Source    string  `form:"source"` // App or import that produced the point
+}
+
+// TrackPointFields maps selectable JSON field names to track point columns
+var TrackPointFields = map[string]string{
+	"id":           "id",
+	"dataTime":     "dataTime",
+	"longitude":    "longitude",
+	"latitude":     "latitude",
+	"heading":      "heading",
+	"accuracy":     "accuracy",
+	"speed":        "speed",
+	"distance":     "distance",
+	"altitude":     "altitude",
+	"timeVisually": "time_visually",
+	"time":         "time",
+	"province":     "province",
+	"city":         "city",
+	"county":       "county",
+	"town":         "town",
+	"village":      "village",
+	"country":      "country",
+	"region":       "region",
+	"source":       "source",
+	"sourceDevice": "source_device",
+	"mode":         "mode",
+	"outlierFlag":  "outlier_flag",
+}
+
+// DefaultTrackPointFields lists the fields returned when no field selection is given
+var DefaultTrackPointFields = []string{
+	"id", "dataTime", "longitude", "latitude", "heading", "accuracy", "speed", "distance", "altitude",
+	"timeVisually", "time", "province", "city", "county", "town", "village",
 }
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // Trip represents a trip construction result (origin-destination pair)
 type Trip struct {
@@ -11,17 +14,20 @@ type Trip struct {
 	TripNumber int    `json:"trip_number" db:"trip_number"` // 1st, 2nd, 3rd trip of the day
 
 	// Temporal info
-	StartTime       int64 `json:"start_time" db:"start_time"`             // Unix timestamp
-	EndTime         int64 `json:"end_time" db:"end_time"`                 // Unix timestamp
-	DurationSeconds int64 `json:"duration_seconds" db:"duration_seconds"` // Duration in seconds
+	StartTime       int64  `json:"start_time" db:"start_time"`       // Unix timestamp
+	EndTime         int64  `json:"end_time" db:"end_time"`           // Unix timestamp
+	DurationSeconds int64  `json:"duration_seconds" db:"duration_s"` // Duration in seconds
+	DayType         string `json:"day_type,omitempty" db:"day_type"` // WORKDAY, WEEKEND, HOLIDAY
 
 	// Origin and destination
-	OriginStayID int64   `json:"origin_stay_id,omitempty" db:"origin_stay_id"` // Foreign key to stay_segments
-	DestStayID   int64   `json:"dest_stay_id,omitempty" db:"dest_stay_id"`     // Foreign key to stay_segments
-	OriginLat    float64 `json:"origin_lat,omitempty" db:"origin_lat"`
-	OriginLon    float64 `json:"origin_lon,omitempty" db:"origin_lon"`
-	DestLat      float64 `json:"dest_lat,omitempty" db:"dest_lat"`
-	DestLon      float64 `json:"dest_lon,omitempty" db:"dest_lon"`
+	OriginStayID  int64   `json:"origin_stay_id,omitempty" db:"origin_stay_id"`   // Foreign key to stay_segments
+	DestStayID    int64   `json:"dest_stay_id,omitempty" db:"dest_stay_id"`       // Foreign key to stay_segments
+	OriginPlaceID int64   `json:"origin_place_id,omitempty" db:"origin_place_id"` // Place of the origin stay
+	DestPlaceID   int64   `json:"dest_place_id,omitempty" db:"dest_place_id"`     // Place of the destination stay
+	OriginLat     float64 `json:"origin_lat,omitempty" db:"origin_lat"`
+	OriginLon     float64 `json:"origin_lon,omitempty" db:"origin_lon"`
+	DestLat       float64 `json:"dest_lat,omitempty" db:"dest_lat"`
+	DestLon       float64 `json:"dest_lon,omitempty" db:"dest_lon"`
 
 	// Administrative divisions
 	OriginProvince string `json:"origin_province,omitempty" db:"origin_province"`
@@ -32,18 +38,23 @@ type Trip struct {
 	DestCounty     string `json:"dest_county,omitempty" db:"dest_county"`
 
 	// Trip characteristics
-	DistanceMeters float64 `json:"distance_meters,omitempty" db:"distance_meters"`
-	AvgSpeedKmh    float64 `json:"avg_speed_kmh,omitempty" db:"avg_speed_kmh"`
-	MaxSpeedKmh    float64 `json:"max_speed_kmh,omitempty" db:"max_speed_kmh"`
+	DistanceMeters float64 `json:"distance_meters,omitempty" db:"distance_m"`
 	PrimaryMode    string  `json:"primary_mode,omitempty" db:"primary_mode"` // Dominant transport mode
+	SegmentCount   int     `json:"segment_count" db:"segment_count"`
 
 	// Segments involved
-	ModesJSON      string `json:"modes_json,omitempty" db:"modes_json"`           // JSON array of transport modes
-	SegmentIDsJSON string `json:"segment_ids_json,omitempty" db:"segment_ids_json"` // JSON array of segment IDs
+	ModesJSON     string `json:"modes_json,omitempty" db:"modes"`           // JSON array of transport modes
+	RailLinesJSON string `json:"rail_lines_json,omitempty" db:"rail_lines"` // JSON array of high-speed rail lines, longest first
+
+	// Trip purpose
+	Purpose           string  `json:"purpose,omitempty" db:"purpose_ml"`               // COMMUTE, WORK, LEISURE, SHOPPING, TRAVEL, OTHER
+	PurposeConfidence float64 `json:"purpose_confidence,omitempty" db:"confidence_ml"` // Probability of the most likely purpose
+	PurposeProbsJSON  string  `json:"purpose_probs_json,omitempty" db:"purpose_probs"` // JSON object of purpose probabilities
 
-	// Trip type
-	TripType    string `json:"trip_type,omitempty" db:"trip_type"` // INTRA_CITY, INTER_CITY, INTER_PROVINCE
-	IsRoundTrip bool   `json:"is_round_trip" db:"is_round_trip"`
+	// Weather along the route, set by weather_enrichment
+	WeatherTempC    *float64 `json:"weather_temp_c,omitempty" db:"weather_temp_c"`       // Mean temperature
+	WeatherPrecipMM *float64 `json:"weather_precip_mm,omitempty" db:"weather_precip_mm"` // Precipitation in the hours of the trip
+	WeatherWet      *bool    `json:"weather_wet,omitempty" db:"weather_wet"`             // It rained during the trip
 
 	// Metadata
 	AlgoVersion string    `json:"algo_version,omitempty" db:"algo_version"`
@@ -68,3 +79,108 @@ type TripsResponse struct {
 }
 
 // TripFilter is defined in filters.go
+
+// ODMatrixFilter represents filter parameters for the origin-destination matrix
+type ODMatrixFilter struct {
+	StartTime    int64  `form:"startTime"`    // Unix timestamp
+	EndTime      int64  `form:"endTime"`      // Unix timestamp
+	Level        string `form:"level"`        // city (default) or county
+	Purpose      string `form:"purpose"`      // COMMUTE, WORK, LEISURE, SHOPPING, TRAVEL, OTHER
+	PrimaryMode  string `form:"primaryMode"`  // WALK, CAR, TRAIN, FLIGHT
+	IncludeIntra bool   `form:"includeIntra"` // Include trips within the same area
+	MinCount     int    `form:"minCount"`     // Minimum trips per flow
+}
+
+// OD matrix aggregation levels
+const (
+	ODLevelCity   = "city"
+	ODLevelCounty = "county"
+)
+
+// ODArea identifies an administrative area in the OD matrix
+type ODArea struct {
+	Province string  `json:"province"`
+	City     string  `json:"city"`
+	County   string  `json:"county,omitempty"`
+	Lat      float64 `json:"lat"` // Mean location of trip endpoints in the area
+	Lon      float64 `json:"lon"`
+}
+
+// ODFlow represents aggregated trips between two areas
+type ODFlow struct {
+	Origin         int     `json:"origin"` // Index into ODMatrix.Areas
+	Dest           int     `json:"dest"`   // Index into ODMatrix.Areas
+	TripCount      int64   `json:"trip_count"`
+	TotalDistanceM float64 `json:"total_distance_m"`
+	AvgDurationS   float64 `json:"avg_duration_s"`
+	FirstTime      int64   `json:"first_time"`
+	LastTime       int64   `json:"last_time"`
+}
+
+// ODMatrix represents an origin-destination matrix for flow maps
+type ODMatrix struct {
+	Level      string   `json:"level"`
+	Areas      []ODArea `json:"areas"`
+	Flows      []ODFlow `json:"flows"`
+	TotalTrips int64    `json:"total_trips"`
+}
+
+// TripRoute holds the reconstructed point sequence of a trip, grouped by segment
+type TripRoute struct {
+	TripID     int64              `json:"trip_id"`
+	Date       string             `json:"date"`
+	TripNumber int                `json:"trip_number"`
+	StartTime  int64              `json:"start_time"`
+	EndTime    int64              `json:"end_time"`
+	Segments   []TripRouteSegment `json:"segments"`
+}
+
+// TripRouteSegment holds the track points of a single segment within a trip
+type TripRouteSegment struct {
+	SegmentID int64        `json:"segment_id"`
+	Mode      string       `json:"mode"`
+	Points    []TrackPoint `json:"points"`
+	Path      [][2]float64 `json:"path,omitempty"` // Great-circle [lon, lat] path of FLIGHT segments
+}
+
+// ErrInvalidTripEdit is returned for a split or merge that does not fit the trips
+var ErrInvalidTripEdit = errors.New("invalid trip edit")
+
+// Trip edit actions
+const (
+	TripEditSplit = "split"
+	TripEditMerge = "merge"
+)
+
+// TripEdit is a manual split or merge of trips, replayed by every trip
+// construction run
+// Edits are keyed by time, as trip IDs change with every run; later edits
+// override earlier ones.
+type TripEdit struct {
+	ID        int64  `json:"id"`
+	Action    string `json:"action"`               // split or merge
+	SplitTime int64  `json:"split_time,omitempty"` // split: segments starting at or after it begin a new trip
+	FromTime  int64  `json:"from_time,omitempty"`  // merge: segments starting within [from_time, to_time] stay in one trip
+	ToTime    int64  `json:"to_time,omitempty"`
+	Note      string `json:"note,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// TripSplitRequest splits a trip in two
+type TripSplitRequest struct {
+	Time int64  `json:"time" binding:"required"` // Segments of the trip starting at or after it begin the second trip
+	Note string `json:"note"`
+}
+
+// TripMergeRequest merges trips that follow each other
+type TripMergeRequest struct {
+	TripIDs []int64 `json:"trip_ids" binding:"required"` // At least two, with no other trip between them
+	Note    string  `json:"note"`
+}
+
+// TripEditResult is a stored or removed trip edit and the trip construction
+// recompute queued to apply it
+type TripEditResult struct {
+	Edit    TripEdit `json:"edit"`
+	TaskIDs []int64  `json:"task_ids"` // Empty when no recompute could be queued; the next analysis chain applies the edit
+}
@@ -0,0 +1,44 @@
+package models
+
+// WatchImport is a track file imported from the watch directory
+type WatchImport struct {
+	ID              int64   `json:"id" db:"id"`
+	FileName        string  `json:"file_name" db:"file_name"`
+	ArchivedPath    string  `json:"archived_path,omitempty" db:"archived_path"` // Empty when the file could not be moved
+	SHA256          string  `json:"sha256" db:"sha256"`
+	Size            int64   `json:"size" db:"size"`
+	Format          string  `json:"format,omitempty" db:"format"` // gpx, kml, csv, json
+	Status          string  `json:"status" db:"status"`           // imported, duplicate, failed
+	Error           string  `json:"error,omitempty" db:"error"`
+	ParsedPoints    int     `json:"parsed_points" db:"parsed_points"`
+	InsertedPoints  int     `json:"inserted_points" db:"inserted_points"`
+	ReplacedPoints  int     `json:"replaced_points" db:"replaced_points"`
+	DuplicatePoints int     `json:"duplicate_points" db:"duplicate_points"`
+	StartTime       int64   `json:"start_time,omitempty" db:"start_time"`
+	EndTime         int64   `json:"end_time,omitempty" db:"end_time"`
+	AnalysisTasks   []int64 `json:"analysis_task_ids" db:"analysis_task_ids"`
+	ImportedAt      int64   `json:"imported_at" db:"imported_at"`
+}
+
+// WatchImport status constants
+const (
+	WatchImportImported  = "imported"
+	WatchImportDuplicate = "duplicate" // Same content as an imported file
+	WatchImportFailed    = "failed"
+)
+
+// WatchImportStatus describes the watch directory
+type WatchImportStatus struct {
+	Enabled    bool   `json:"enabled"`
+	Running    bool   `json:"running"`
+	Dir        string `json:"dir,omitempty"`
+	ArchiveDir string `json:"archive_dir,omitempty"`
+	FailedDir  string `json:"failed_dir,omitempty"`
+	Interval   int64  `json:"interval_seconds,omitempty"`
+	Error      string `json:"error,omitempty"` // Why the directory could not be read at the last poll
+}
+
+// WatchImportFilter holds the query filters of GET /api/v1/admin/import-watch/files
+type WatchImportFilter struct {
+	Status string `form:"status"`
+}
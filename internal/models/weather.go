@@ -0,0 +1,60 @@
+package models
+
+import "errors"
+
+// RainDayPrecipMM is the daily precipitation that makes a rain day, as in WMO climatology
+const RainDayPrecipMM = 1.0
+
+// WeatherDay is the weather of a visited 0.25° grid cell on a local day
+// The values are nil when the weather provider had no data for the day.
+type WeatherDay struct {
+	ID         int64    `json:"id" db:"id"`
+	Date       string   `json:"date" db:"date"` // YYYY-MM-DD
+	Cell       string   `json:"cell" db:"cell"` // Grid point, e.g. "23.00,113.25"
+	Lat        float64  `json:"lat" db:"lat"`
+	Lon        float64  `json:"lon" db:"lon"`
+	PointCount int      `json:"point_count" db:"point_count"` // Track points in the cell that day
+	IsPrimary  bool     `json:"is_primary" db:"is_primary"`   // The cell with most points that day
+	HourCount  int      `json:"hour_count" db:"hour_count"`   // Hours with data
+	TempMinC   *float64 `json:"temp_min_c,omitempty" db:"temp_min_c"`
+	TempMaxC   *float64 `json:"temp_max_c,omitempty" db:"temp_max_c"`
+	TempMeanC  *float64 `json:"temp_mean_c,omitempty" db:"temp_mean_c"`
+	PrecipMM   *float64 `json:"precip_mm,omitempty" db:"precip_mm"`
+	RainHours  *int     `json:"rain_hours,omitempty" db:"rain_hours"` // Hours with measurable rain
+	Provider   string   `json:"provider" db:"provider"`               // openmeteo, era5
+}
+
+// WeatherDayFilter represents filter parameters for querying daily weather
+type WeatherDayFilter struct {
+	StartDate string `form:"start_date"` // YYYY-MM-DD
+	EndDate   string `form:"end_date"`   // YYYY-MM-DD, inclusive
+	Rainy     *bool  `form:"rainy"`      // Days with at least RainDayPrecipMM, or without
+	AllCells  bool   `form:"all_cells"`  // Every visited cell, not only the primary cell of each day
+}
+
+// ErrInvalidWeatherFilter is returned for daily weather queries with a malformed date
+var ErrInvalidWeatherFilter = errors.New("invalid weather filter")
+
+// WeatherSummary is the weather overview returned by /stats/weather
+// Days are counted at their primary cell.
+type WeatherSummary struct {
+	Year     int                   `json:"year,omitempty"` // Single year; 0 for all years
+	Days     int                   `json:"days"`           // Days with weather
+	RainDays int                   `json:"rain_days"`      // Days with at least RainDayPrecipMM
+	AvgTempC float64               `json:"avg_temp_c"`
+	PrecipMM float64               `json:"precip_mm"`
+	Hottest  *WeatherDay           `json:"hottest,omitempty"` // Highest maximum temperature
+	Coldest  *WeatherDay           `json:"coldest,omitempty"` // Lowest minimum temperature
+	Wettest  *WeatherDay           `json:"wettest,omitempty"` // Most precipitation
+	Trips    int                   `json:"trips"`             // Trips with weather
+	WetTrips int                   `json:"wet_trips"`
+	Modes    []WeatherModeDistance `json:"modes"` // Longest total first
+}
+
+// WeatherModeDistance is the distance travelled in a transport mode on wet and dry trips
+type WeatherModeDistance struct {
+	Mode          string  `json:"mode"`
+	WetDistanceKm float64 `json:"wet_distance_km"` // On trips during which it rained
+	DryDistanceKm float64 `json:"dry_distance_km"`
+	WetShare      float64 `json:"wet_share"` // Fraction of the distance travelled in the rain
+}
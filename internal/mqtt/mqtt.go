@@ -0,0 +1,406 @@
+// Package mqtt is a minimal MQTT 3.1.1 client that subscribes to topics and
+// receives their messages, reconnecting when the connection drops
+// Only what a subscriber needs is implemented: QoS 0 and 1 deliveries,
+// keep-alive pings and username/password authentication over TCP or TLS.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Control packet types
+const (
+	packetConnect     = 1
+	packetConnAck     = 2
+	packetPublish     = 3
+	packetPubAck      = 4
+	packetPubRec      = 5
+	packetPubRel      = 6
+	packetPubComp     = 7
+	packetSubscribe   = 8
+	packetSubAck      = 9
+	packetPingReq     = 12
+	packetPingResp    = 13
+	maxRemainingBytes = 268435455
+)
+
+// Config configures a subscriber
+type Config struct {
+	URL       string // tcp://host:1883, or ssl://, tls:// or mqtts:// for TLS (default port 8883)
+	ClientID  string
+	Username  string
+	Password  string
+	Topics    []string      // Topic filters, subscribed at QoS 1
+	KeepAlive time.Duration // Default 60s
+}
+
+// Subscriber receives the messages published to its topics
+type Subscriber struct {
+	cfg    Config
+	addr   string
+	useTLS bool
+	handle func(topic string, payload []byte)
+
+	mu        sync.Mutex
+	conn      net.Conn
+	connected bool
+	lastErr   error
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewSubscriber creates a subscriber; handle is called for every message,
+// one at a time, on the receiving goroutine
+func NewSubscriber(cfg Config, handle func(topic string, payload []byte)) (*Subscriber, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MQTT URL: %w", err)
+	}
+	s := &Subscriber{cfg: cfg, handle: handle}
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "tls", "mqtts":
+		s.useTLS, port = true, "8883"
+	default:
+		return nil, fmt.Errorf("unsupported MQTT URL scheme: %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("MQTT URL has no host: %s", cfg.URL)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	s.addr = net.JoinHostPort(u.Hostname(), port)
+	if len(cfg.Topics) == 0 {
+		return nil, errors.New("no MQTT topics to subscribe to")
+	}
+	if s.cfg.KeepAlive <= 0 {
+		s.cfg.KeepAlive = 60 * time.Second
+	}
+	if s.cfg.ClientID == "" {
+		s.cfg.ClientID = fmt.Sprintf("records-%d", time.Now().UnixNano()%1e9)
+	}
+	return s, nil
+}
+
+// Addr returns the broker address
+func (s *Subscriber) Addr() string {
+	return s.addr
+}
+
+// Status reports whether the subscriber is connected, and the error that
+// ended the last connection
+func (s *Subscriber) Status() (connected bool, lastErr error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connected, s.lastErr
+}
+
+// Start connects and receives in the background until Stop is called,
+// reconnecting with backoff
+func (s *Subscriber) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.loop()
+}
+
+// Stop disconnects and waits for the receiving goroutine to return
+func (s *Subscriber) Stop() {
+	s.mu.Lock()
+	stop, done, conn := s.stop, s.done, s.conn
+	s.stop = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	if conn != nil {
+		conn.Close()
+	}
+	<-done
+}
+
+// loop runs sessions until stopped, waiting between failed ones
+func (s *Subscriber) loop() {
+	defer close(s.done)
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := s.session()
+		s.mu.Lock()
+		s.connected, s.conn, s.lastErr = false, nil, err
+		stop := s.stop
+		s.mu.Unlock()
+		if stop == nil {
+			return
+		}
+		log.Printf("MQTT %s: %v, reconnecting in %s", s.addr, err, backoff)
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second // The connection was up for a while
+		} else if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+}
+
+// session connects, subscribes and receives until the connection fails
+func (s *Subscriber) session() error {
+	conn, err := net.DialTimeout("tcp", s.addr, 30*time.Second)
+	if err != nil {
+		return err
+	}
+	if s.useTLS {
+		host, _, _ := net.SplitHostPort(s.addr)
+		conn = tls.Client(conn, &tls.Config{ServerName: host})
+	}
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		conn.Close()
+		return errors.New("stopped")
+	}
+	s.conn = conn
+	s.mu.Unlock()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := &packetWriter{conn: conn}
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := w.write(packetConnect<<4, s.connectPacket()); err != nil {
+		return err
+	}
+	header, body, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if header>>4 != packetConnAck || len(body) < 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", header>>4)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("connection refused: %s", connAckReason(body[1]))
+	}
+
+	var sub []byte
+	sub = binary.BigEndian.AppendUint16(sub, 1)
+	for _, topic := range s.cfg.Topics {
+		sub = appendString(sub, topic)
+		sub = append(sub, 1)
+	}
+	if err := w.write(packetSubscribe<<4|0x02, sub); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.mu.Lock()
+	s.connected, s.lastErr = true, nil
+	s.mu.Unlock()
+	log.Printf("MQTT %s: subscribed to %v", s.addr, s.cfg.Topics)
+
+	pingDone := make(chan struct{})
+	defer close(pingDone)
+	go s.ping(w, pingDone)
+
+	for {
+		// The broker answers pings, so silence for 1.5 keep-alives is a dead link
+		conn.SetReadDeadline(time.Now().Add(s.cfg.KeepAlive * 3 / 2))
+		header, body, err := readPacket(r)
+		if err != nil {
+			return err
+		}
+		switch header >> 4 {
+		case packetPublish:
+			if err := s.receive(w, header, body); err != nil {
+				return err
+			}
+		case packetPubRel:
+			if len(body) >= 2 {
+				w.write(packetPubComp<<4, body[:2])
+			}
+		case packetSubAck:
+			if len(body) < 2 {
+				return errors.New("malformed SUBACK")
+			}
+			for _, code := range body[2:] {
+				if code == 0x80 {
+					return errors.New("subscription refused by the broker")
+				}
+			}
+		case packetPingResp:
+		}
+	}
+}
+
+// receive handles a PUBLISH packet, acknowledging it per its QoS
+func (s *Subscriber) receive(w *packetWriter, header byte, body []byte) error {
+	qos := (header >> 1) & 0x03
+	topic, rest, err := readString(body)
+	if err != nil {
+		return err
+	}
+	var id []byte
+	if qos > 0 {
+		if len(rest) < 2 {
+			return errors.New("PUBLISH without packet id")
+		}
+		id, rest = rest[:2], rest[2:]
+	}
+	s.handle(topic, rest)
+
+	switch qos {
+	case 1:
+		return w.write(packetPubAck<<4, id)
+	case 2:
+		return w.write(packetPubRec<<4, id)
+	}
+	return nil
+}
+
+// ping sends PINGREQ every half keep-alive until done is closed
+func (s *Subscriber) ping(w *packetWriter, done chan struct{}) {
+	ticker := time.NewTicker(s.cfg.KeepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if w.write(packetPingReq<<4, nil) != nil {
+				return
+			}
+		}
+	}
+}
+
+// connectPacket builds the variable header and payload of CONNECT
+func (s *Subscriber) connectPacket() []byte {
+	flags := byte(0x02) // Clean session
+	if s.cfg.Username != "" {
+		flags |= 0x80
+		if s.cfg.Password != "" {
+			flags |= 0x40
+		}
+	}
+	b := appendString(nil, "MQTT")
+	b = append(b, 4, flags) // Protocol level 4 is MQTT 3.1.1
+	b = binary.BigEndian.AppendUint16(b, uint16(s.cfg.KeepAlive/time.Second))
+	b = appendString(b, s.cfg.ClientID)
+	if s.cfg.Username != "" {
+		b = appendString(b, s.cfg.Username)
+		if s.cfg.Password != "" {
+			b = appendString(b, s.cfg.Password)
+		}
+	}
+	return b
+}
+
+// connAckReason describes a CONNACK return code
+func connAckReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client id rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad username or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("return code %d", code)
+}
+
+// packetWriter serializes packet writes of the receiving and ping goroutines
+type packetWriter struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// write sends a packet with the given first header byte and body
+func (w *packetWriter) write(header byte, body []byte) error {
+	packet := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.conn.Write(packet)
+	return err
+}
+
+// readPacket reads one packet and returns its first header byte and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7F) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if multiplier *= 128; i == 3 || length > maxRemainingBytes {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// readString reads a length-prefixed string and returns the rest
+func readString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("truncated string")
+	}
+	n := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+n {
+		return "", nil, errors.New("truncated string")
+	}
+	return string(b[2 : 2+n]), b[2+n:], nil
+}
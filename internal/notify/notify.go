@@ -0,0 +1,404 @@
+// Package notify delivers events to webhooks and push services in the
+// background, retrying failed deliveries with exponential backoff
+// Webhook bodies are signed with HMAC-SHA256 when a secret is configured, so
+// receivers can check that a call came from this server.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	EventAnalysisCompleted = "analysis.completed"
+	EventAnalysisFailed    = "analysis.failed"
+	EventRecordNew         = "record.new" // A personal record or extreme event was beaten
+	EventImportCompleted   = "import.completed"
+	EventImportFailed      = "import.failed"
+	EventTest              = "test"
+)
+
+// EventTypes lists the event types that can be subscribed to
+var EventTypes = []string{
+	EventAnalysisCompleted, EventAnalysisFailed, EventRecordNew,
+	EventImportCompleted, EventImportFailed,
+}
+
+// Event is something that happened, as posted to webhooks
+type Event struct {
+	ID      string      `json:"id"`
+	Type    string      `json:"type"`
+	Time    int64       `json:"time"` // Unix seconds
+	Title   string      `json:"title"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Channel sends events to one destination
+type Channel interface {
+	// Name identifies the channel in delivery records, without secrets
+	Name() string
+	Send(ctx context.Context, client *http.Client, event Event) error
+}
+
+// Delivery is the outcome of sending one event to one channel
+type Delivery struct {
+	Event    Event
+	Channel  string
+	Attempts int
+	Err      error // nil when delivered
+}
+
+// Config configures a notifier
+type Config struct {
+	Channels    []Channel
+	Events      []string      // Event types to send, all when empty; test events are always sent
+	MaxAttempts int           // Attempts per delivery, default 5
+	RetryDelay  time.Duration // Delay before the first retry, doubling after each, default 10s
+	QueueSize   int           // Events waiting to be sent before new ones are dropped, default 100
+}
+
+// Notifier sends events to its channels in the background
+type Notifier struct {
+	cfg    Config
+	events map[string]bool // nil when all events are sent
+	client *http.Client
+	record func(Delivery)
+
+	mu    sync.Mutex
+	queue chan Event
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New creates a notifier; record, when not nil, is called with the outcome
+// of every delivery
+func New(cfg Config, record func(Delivery)) *Notifier {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 10 * time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	n := &Notifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 15 * time.Second},
+		record: record,
+		queue:  make(chan Event, cfg.QueueSize),
+	}
+	if len(cfg.Events) > 0 {
+		n.events = make(map[string]bool, len(cfg.Events))
+		for _, t := range cfg.Events {
+			n.events[t] = true
+		}
+	}
+	return n
+}
+
+// Enabled reports whether any channel is configured
+func (n *Notifier) Enabled() bool {
+	return len(n.cfg.Channels) > 0
+}
+
+// Channels returns the names of the channels
+func (n *Notifier) Channels() []string {
+	names := make([]string, len(n.cfg.Channels))
+	for i, ch := range n.cfg.Channels {
+		names[i] = ch.Name()
+	}
+	return names
+}
+
+// Events returns the event types sent
+func (n *Notifier) Events() []string {
+	if n.events == nil {
+		return EventTypes
+	}
+	return n.cfg.Events
+}
+
+// Wants reports whether events of the type are sent
+func (n *Notifier) Wants(eventType string) bool {
+	if !n.Enabled() {
+		return false
+	}
+	return n.events == nil || n.events[eventType] || eventType == EventTest
+}
+
+// Notify queues an event for delivery and returns it, or returns nil if
+// events of its type are not sent or the queue is full
+func (n *Notifier) Notify(eventType, title, message string, data interface{}) *Event {
+	if !n.Wants(eventType) {
+		return nil
+	}
+	event := Event{
+		ID:      newEventID(),
+		Type:    eventType,
+		Time:    time.Now().Unix(),
+		Title:   title,
+		Message: message,
+		Data:    data,
+	}
+	select {
+	case n.queue <- event:
+		return &event
+	default:
+		log.Printf("Notify: queue full, dropped %s event %q", eventType, title)
+		return nil
+	}
+}
+
+// Start delivers queued events in the background until Stop is called
+func (n *Notifier) Start() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stop != nil || !n.Enabled() {
+		return
+	}
+	n.stop, n.done = make(chan struct{}), make(chan struct{})
+	go n.loop()
+}
+
+// Stop stops delivering, abandoning retries in progress
+func (n *Notifier) Stop() {
+	n.mu.Lock()
+	stop, done := n.stop, n.done
+	n.stop = nil
+	n.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// loop sends queued events one at a time
+func (n *Notifier) loop() {
+	defer close(n.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-n.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-n.queue:
+			var wg sync.WaitGroup
+			for _, ch := range n.cfg.Channels {
+				wg.Add(1)
+				go func(ch Channel) {
+					defer wg.Done()
+					n.deliver(ctx, ch, event)
+				}(ch)
+			}
+			wg.Wait()
+		}
+	}
+}
+
+// deliver sends an event to a channel, retrying with backoff until it
+// succeeds, fails permanently or runs out of attempts
+func (n *Notifier) deliver(ctx context.Context, ch Channel, event Event) {
+	d := Delivery{Event: event, Channel: ch.Name()}
+	delay := n.cfg.RetryDelay
+	for d.Attempts < n.cfg.MaxAttempts {
+		d.Attempts++
+		if d.Err = ch.Send(ctx, n.client, event); d.Err == nil {
+			break
+		}
+		var perm *permanentError
+		if errors.As(d.Err, &perm) || d.Attempts == n.cfg.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			d.Err = fmt.Errorf("%v; retry abandoned at shutdown", d.Err)
+			n.finish(d)
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	n.finish(d)
+}
+
+// finish logs and records the outcome of a delivery
+func (n *Notifier) finish(d Delivery) {
+	if d.Err != nil {
+		log.Printf("Notify %s: %s event %s failed after %d attempts: %v", d.Channel, d.Event.Type, d.Event.ID, d.Attempts, d.Err)
+	}
+	if n.record != nil {
+		n.record(d)
+	}
+}
+
+// permanentError is a failure that retrying will not fix, such as a
+// rejected request
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// post sends a JSON body and checks the response status; 4xx responses other
+// than 408 and 429 are permanent failures
+func post(ctx context.Context, client *http.Client, target string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "records-backend")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return &permanentError{err}
+	}
+	return err
+}
+
+// Webhook posts events as JSON to a URL
+// With a secret, the X-Records-Signature header is sha256= followed by the
+// hex HMAC-SHA256 of the X-Records-Timestamp header, a dot and the body.
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+// Name returns the webhook host and path, leaving out any credentials or query
+func (w *Webhook) Name() string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return "webhook"
+	}
+	return "webhook:" + u.Host + u.Path
+}
+
+// Send posts the event
+func (w *Webhook) Send(ctx context.Context, client *http.Client, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return &permanentError{err}
+	}
+	header := http.Header{}
+	header.Set("X-Records-Event", event.Type)
+	header.Set("X-Records-Delivery", event.ID)
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set("X-Records-Timestamp", timestamp)
+		header.Set("X-Records-Signature", "sha256="+Sign(w.Secret, timestamp, body))
+	}
+	return post(ctx, client, w.URL, body, header)
+}
+
+// Sign returns the hex HMAC-SHA256 of timestamp.body with secret, as sent
+// in the X-Records-Signature header
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Telegram sends events as messages from a bot to a chat
+type Telegram struct {
+	Token  string
+	ChatID string
+	APIURL string // Default https://api.telegram.org
+}
+
+// Name identifies the chat
+func (t *Telegram) Name() string {
+	return "telegram:" + t.ChatID
+}
+
+// Send sends the event title and message
+func (t *Telegram) Send(ctx context.Context, client *http.Client, event Event) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id": t.ChatID,
+		"text":    event.Title + "\n" + event.Message,
+	})
+	if err != nil {
+		return &permanentError{err}
+	}
+	base := t.APIURL
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	return post(ctx, client, strings.TrimSuffix(base, "/")+"/bot"+t.Token+"/sendMessage", body, nil)
+}
+
+// Bark pushes events to an iOS device through a Bark server
+type Bark struct {
+	URL string // Server and device key, e.g. https://api.day.app/<key>
+}
+
+// Name returns the Bark server, leaving out the device key
+func (b *Bark) Name() string {
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return "bark"
+	}
+	return "bark:" + u.Host
+}
+
+// Send pushes the event title and message, grouped as records
+func (b *Bark) Send(ctx context.Context, client *http.Client, event Event) error {
+	body, err := json.Marshal(map[string]string{
+		"title": event.Title,
+		"body":  event.Message,
+		"group": "records",
+	})
+	if err != nil {
+		return &permanentError{err}
+	}
+	return post(ctx, client, b.URL, body, nil)
+}
+
+// newEventID returns a random event id
+func newEventID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
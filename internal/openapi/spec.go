@@ -0,0 +1,416 @@
+// Package openapi builds an OpenAPI 3 description of the API from the routes
+// registered on the Gin engine and a table documenting them.
+//
+// Paths come from the engine, so every route appears in the document even
+// before it is documented; schemas are derived from the Go types the
+// handlers respond with, so the document follows model changes.
+package openapi
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.0.3"
+
+// Param documents a query parameter
+type Param struct {
+	Name        string
+	Type        string // string (default), integer, number or boolean
+	Description string
+	Enum        []string
+	Required    bool
+}
+
+// Operation documents one route
+type Operation struct {
+	Summary     string
+	Description string
+	Tag         string      // Defaults to the first path segment after the API version
+	Query       interface{} // Filter struct whose form-tagged fields are query parameters
+	Params      []Param     // Further query parameters; override Query fields of the same name
+	Body        interface{} // JSON request body
+	Upload      []string    // Multipart file fields of an upload
+	Response    interface{} // Response data: a value of the returned type, or Items, Paged, List, Object or Raw
+	Bare        bool        // Response is not wrapped in the {code, message, data} envelope
+}
+
+// Items is a response listing values of Of as {data, count}
+type Items struct{ Of interface{} }
+
+// Paged is a response listing values of Of as {data, total, page, pageSize, totalPages}
+type Paged struct{ Of interface{} }
+
+// List is a response listing values of Of in the envelope of the paginated
+// list endpoints: {data, count, total, limit, offset, page, next_cursor}
+type List struct{ Of interface{} }
+
+// Object is a response object with the given properties
+// Values are examples of the property types.
+type Object map[string]interface{}
+
+// Raw is a response that is not JSON
+type Raw struct {
+	ContentType string
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                               `json:"openapi"`
+	Info       Info                                 `json:"info"`
+	Servers    []Server                             `json:"servers,omitempty"`
+	Tags       []Tag                                `json:"tags,omitempty"`
+	Paths      map[string]map[string]*PathOperation `json:"paths"`
+	Components Components                           `json:"components"`
+}
+
+// Server is a base URL of the API
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Components holds the shared schemas and responses of a document
+type Components struct {
+	Schemas   map[string]*Schema         `json:"schemas"`
+	Responses map[string]*ResponseObject `json:"responses,omitempty"`
+}
+
+// PathOperation is an operation object of a document
+type PathOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []ParameterObject          `json:"parameters,omitempty"`
+	RequestBody *RequestBody               `json:"requestBody,omitempty"`
+	Responses   map[string]*ResponseObject `json:"responses"`
+
+	// Used by client generators
+	method string
+	path   string
+	data   *Schema // Response data inside the envelope, nil for bare and raw responses
+	raw    string  // Content type of a non-JSON response
+}
+
+// ParameterObject is a path or query parameter
+type ParameterObject struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// ResponseObject is a response of an operation
+type ResponseObject struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+var pathParam = regexp.MustCompile(`[:*](\w+)`)
+
+// Build generates the document of the given routes
+// ops documents routes by "METHOD /path", using Gin path syntax.
+func Build(info Info, routes gin.RoutesInfo, ops map[string]Operation) *Document {
+	g := newRegistry()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*PathOperation),
+	}
+
+	errorSchema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "integer", Format: "int32"},
+			"message": {Type: "string"},
+			"data": {
+				Type:       "object",
+				Properties: map[string]*Schema{"error": {Type: "string"}},
+			},
+		},
+		Required: []string{"code", "message"},
+	}
+	doc.Components.Responses = map[string]*ResponseObject{
+		"Error": {
+			Description: "Error response",
+			Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+		},
+	}
+
+	// Stable order so that duplicate operation IDs are numbered consistently
+	routes = append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	ids := make(map[string]int)
+	tags := make(map[string]bool)
+	for _, route := range routes {
+		op := ops[route.Method+" "+route.Path]
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+
+		id := operationID(route)
+		if ids[id]++; ids[id] > 1 {
+			id += strconv.Itoa(ids[id])
+		}
+		tag := op.Tag
+		if tag == "" {
+			tag = routeTag(route.Path)
+		}
+		tags[tag] = true
+
+		po := &PathOperation{
+			OperationID: id,
+			Summary:     op.Summary,
+			Description: op.Description,
+			Tags:        []string{tag},
+			Responses:   map[string]*ResponseObject{"default": {Ref: "#/components/responses/Error"}},
+			method:      route.Method,
+			path:        path,
+		}
+
+		for _, m := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			schema := &Schema{Type: "string"}
+			if m[1] == "id" || m[1] == "year" {
+				schema = &Schema{Type: "integer", Format: "int64"}
+			}
+			po.Parameters = append(po.Parameters, ParameterObject{Name: m[1], In: "path", Required: true, Schema: schema})
+		}
+		for _, p := range mergeParams(Params(op.Query), op.Params) {
+			typ := p.Type
+			if typ == "" {
+				typ = "string"
+			}
+			po.Parameters = append(po.Parameters, ParameterObject{
+				Name:        p.Name,
+				In:          "query",
+				Description: p.Description,
+				Required:    p.Required,
+				Schema:      &Schema{Type: typ, Enum: p.Enum},
+			})
+		}
+
+		switch {
+		case len(op.Upload) > 0:
+			form := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			for _, field := range op.Upload {
+				form.Properties[field] = &Schema{Type: "array", Items: &Schema{Type: "string", Format: "binary"}}
+			}
+			po.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"multipart/form-data": {Schema: form}}}
+		case op.Body != nil:
+			po.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: g.schemaOfValue(op.Body)}}}
+		}
+
+		ok := &ResponseObject{Description: "Success"}
+		if raw, isRaw := op.Response.(Raw); isRaw {
+			po.raw = raw.ContentType
+			ok.Content = map[string]MediaType{raw.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		} else {
+			data := g.responseSchema(op.Response)
+			body := data
+			if !op.Bare {
+				po.data = data
+				body = &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"code":    {Type: "integer", Format: "int32"},
+						"message": {Type: "string"},
+						"data":    data,
+					},
+					Required: []string{"code", "message", "data"},
+				}
+			}
+			ok.Content = map[string]MediaType{"application/json": {Schema: body}}
+		}
+		po.Responses["200"] = ok
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*PathOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = po
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	doc.Components.Schemas = g.schemas
+	return doc
+}
+
+// Undocumented returns the routes that ops has no entry for
+func Undocumented(routes gin.RoutesInfo, ops map[string]Operation) []string {
+	var missing []string
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		if _, ok := ops[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Operations returns the operations of a document ordered by path and method
+func (d *Document) Operations() []*PathOperation {
+	var ops []*PathOperation
+	for _, methods := range d.Paths {
+		for _, op := range methods {
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].path != ops[j].path {
+			return ops[i].path < ops[j].path
+		}
+		return ops[i].method < ops[j].method
+	})
+	return ops
+}
+
+// responseSchema describes the data of a response
+func (g *registry) responseSchema(v interface{}) *Schema {
+	integer := func() *Schema { return &Schema{Type: "integer", Format: "int64"} }
+	switch r := v.(type) {
+	case Items:
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data":  {Type: "array", Items: g.schemaOfValue(r.Of)},
+				"count": integer(),
+			},
+			Required: []string{"data", "count"},
+		}
+	case Paged:
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data":       {Type: "array", Items: g.schemaOfValue(r.Of)},
+				"total":      integer(),
+				"page":       integer(),
+				"pageSize":   integer(),
+				"totalPages": integer(),
+			},
+			Required: []string{"data", "total", "page", "pageSize", "totalPages"},
+		}
+	case List:
+		return &Schema{
+			Type: "object",
+			Properties: map[string]*Schema{
+				"data":        {Type: "array", Items: g.schemaOfValue(r.Of)},
+				"count":       integer(),
+				"total":       integer(),
+				"limit":       integer(),
+				"offset":      integer(),
+				"page":        {Type: "integer", Format: "int64", Description: "Present when paging by page number"},
+				"next_cursor": {Type: "string", Description: "Cursor of the next page, absent on the last page"},
+			},
+			Required: []string{"data", "count", "total", "limit", "offset"},
+		}
+	case Object:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, example := range r {
+			s.Properties[name] = g.schemaOfValue(example)
+			s.Required = append(s.Required, name)
+		}
+		sort.Strings(s.Required)
+		return s
+	}
+	return g.schemaOfValue(v)
+}
+
+// mergeParams appends extra to params, replacing parameters of the same name
+func mergeParams(params, extra []Param) []Param {
+	index := make(map[string]int, len(params))
+	for i, p := range params {
+		index[p.Name] = i
+	}
+	for _, p := range extra {
+		if i, ok := index[p.Name]; ok {
+			if p.Type == "" {
+				p.Type = params[i].Type
+			}
+			params[i] = p
+			continue
+		}
+		index[p.Name] = len(params)
+		params = append(params, p)
+	}
+	return params
+}
+
+// operationID names an operation after its handler, e.g. statsGetDensityGrids
+// for (*StatsHandler).GetDensityGrids; anonymous handlers are named after the path
+func operationID(route gin.RouteInfo) string {
+	name := strings.TrimSuffix(route.Handler, "-fm")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	parts := strings.Split(name, ".")
+	if len(parts) == 3 && !strings.HasPrefix(parts[2], "func") {
+		receiver := strings.Trim(parts[1], "(*)")
+		receiver = strings.TrimSuffix(receiver, "Handler")
+		return lowerFirst(receiver) + parts[2]
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, word := range strings.FieldsFunc(route.Path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(exportedName(word))
+	}
+	return b.String()
+}
+
+// routeTag groups a route by its first path segment after the API version
+func routeTag(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) >= 3 && segments[0] == "api" {
+		return segments[2]
+	}
+	return "system"
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
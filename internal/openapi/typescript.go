@@ -0,0 +1,243 @@
+package openapi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// tsRuntime is the request helper of generated TypeScript clients
+const tsRuntime = `export interface Envelope<T> {
+  code: number;
+  message: string;
+  data: T;
+}
+
+export class ApiError extends Error {
+  constructor(public status: number, public code: number, message: string, public body?: unknown) {
+    super(message);
+  }
+}
+
+type Query = Record<string, string | number | boolean | undefined | null>;
+
+export class RecordsClient {
+  constructor(
+    private baseUrl: string = "",
+    private fetchImpl: typeof fetch = (input, init) => fetch(input, init),
+    private headers: Record<string, string> = {},
+  ) {}
+
+  private async send(method: string, path: string, query?: Query, body?: unknown): Promise<Response> {
+    const params = new URLSearchParams();
+    for (const [key, value] of Object.entries(query ?? {})) {
+      if (value !== undefined && value !== null) params.set(key, String(value));
+    }
+    const qs = params.toString();
+    const init: RequestInit = { method, headers: { ...this.headers } };
+    if (body instanceof FormData) {
+      init.body = body;
+    } else if (body !== undefined) {
+      init.body = JSON.stringify(body);
+      (init.headers as Record<string, string>)["Content-Type"] = "application/json";
+    }
+    const res = await this.fetchImpl(this.baseUrl + path + (qs ? "?" + qs : ""), init);
+    if (!res.ok) {
+      const err = await res.json().catch(() => undefined);
+      throw new ApiError(res.status, err?.code ?? res.status, err?.message ?? res.statusText, err);
+    }
+    return res;
+  }
+
+  private async json<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    const res = await this.send(method, path, query, body);
+    return (await res.json()) as T;
+  }
+
+  private async data<T>(method: string, path: string, query?: Query, body?: unknown): Promise<T> {
+    return (await this.json<Envelope<T>>(method, path, query, body)).data;
+  }
+`
+
+// WriteTypeScript writes a typed TypeScript client of the document: an
+// interface per component schema and a RecordsClient method per operation.
+// Enveloped responses resolve to their data.
+func WriteTypeScript(w io.Writer, doc *Document) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// Code generated from the OpenAPI document of %s %s. DO NOT EDIT.\n\n", doc.Info.Title, doc.Info.Version)
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := doc.Components.Schemas[name]
+		if s.Type == "object" && s.AdditionalProperties == nil {
+			fmt.Fprintf(bw, "export interface %s %s\n\n", name, tsObject(s, ""))
+		} else {
+			fmt.Fprintf(bw, "export type %s = %s;\n\n", name, tsType(s, ""))
+		}
+	}
+
+	// Inline response types are named after their operation
+	ops := doc.Operations()
+	results := make(map[*PathOperation]string, len(ops))
+	for _, op := range ops {
+		result := tsResult(op)
+		if strings.Contains(result, "{") {
+			name := exportedName(op.OperationID) + "Result"
+			fmt.Fprintf(bw, "export type %s = %s;\n\n", name, result)
+			result = name
+		}
+		results[op] = result
+	}
+
+	bw.WriteString(tsRuntime)
+	for _, op := range ops {
+		writeTSMethod(bw, op, results[op])
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// tsResult returns the type an operation's client method resolves to
+func tsResult(op *PathOperation) string {
+	switch {
+	case op.raw != "":
+		return "Response"
+	case op.data != nil:
+		return tsType(op.data, "")
+	}
+	return tsType(op.Responses["200"].Content["application/json"].Schema, "")
+}
+
+func writeTSMethod(w *bufio.Writer, op *PathOperation, result string) {
+	var args, query []string
+	path := op.path
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			args = append(args, fmt.Sprintf("%s: %s", p.Name, tsType(p.Schema, "  ")))
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", "${encodeURIComponent(String("+p.Name+"))}")
+		case "query":
+			query = append(query, fmt.Sprintf("%s?: %s", tsKey(p.Name), tsType(p.Schema, "  ")))
+		}
+	}
+
+	body := "undefined"
+	if op.RequestBody != nil {
+		if _, ok := op.RequestBody.Content["multipart/form-data"]; ok {
+			args = append(args, "form: FormData")
+			body = "form"
+		} else {
+			args = append(args, "body: "+tsType(op.RequestBody.Content["application/json"].Schema, "  "))
+			body = "body"
+		}
+	}
+	queryArg := "undefined"
+	if len(query) > 0 {
+		args = append(args, "query: { "+strings.Join(query, "; ")+" } = {}")
+		queryArg = "query"
+	}
+
+	call := "json"
+	switch {
+	case op.raw != "":
+		call = "send"
+	case op.data != nil:
+		call = "data"
+	}
+
+	if op.Summary != "" {
+		fmt.Fprintf(w, "\n  /** %s */\n", op.Summary)
+	} else {
+		w.WriteString("\n")
+	}
+	fmt.Fprintf(w, "  %s(%s): Promise<%s> {\n", op.OperationID, strings.Join(args, ", "), result)
+	if call == "send" {
+		fmt.Fprintf(w, "    return this.send(%q, `%s`, %s, %s);\n  }\n", op.method, path, queryArg, body)
+		return
+	}
+	fmt.Fprintf(w, "    return this.%s<%s>(%q, `%s`, %s, %s);\n  }\n", call, result, op.method, path, queryArg, body)
+}
+
+// tsType returns the TypeScript type of a schema; indent is the indentation of the line it appears on
+func tsType(s *Schema, indent string) string {
+	var t string
+	switch {
+	case s == nil:
+		return "unknown"
+	case s.Ref != "":
+		t = strings.TrimPrefix(s.Ref, componentPrefix)
+	case len(s.AllOf) == 1:
+		t = tsType(s.AllOf[0], indent)
+	case len(s.Enum) > 0:
+		quoted := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			quoted[i] = fmt.Sprintf("%q", v)
+		}
+		t = strings.Join(quoted, " | ")
+	case s.Type == "string":
+		t = "string"
+	case s.Type == "integer" || s.Type == "number":
+		t = "number"
+	case s.Type == "boolean":
+		t = "boolean"
+	case s.Type == "array":
+		t = tsType(s.Items, indent)
+		if strings.ContainsAny(t, " |") {
+			t = "(" + t + ")"
+		}
+		t += "[]"
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		t = "Record<string, " + tsType(s.AdditionalProperties, indent) + ">"
+	case s.Type == "object" && len(s.Properties) > 0:
+		t = tsObject(s, indent)
+	case s.Type == "object":
+		t = "Record<string, unknown>"
+	default:
+		t = "unknown"
+	}
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// tsObject returns the TypeScript object type of an object schema
+func tsObject(s *Schema, indent string) string {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsKey(name), optional, tsType(s.Properties[name], indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// tsKey quotes property names that are not identifiers
+func tsKey(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SwaggerUIVersion is the Swagger UI release loaded by the documentation page
+const SwaggerUIVersion = "5.17.14"
+
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui", deepLinking: true});
+</script>
+</body>
+</html>
+`))
+
+// Handler serves the document built by build as JSON
+// The document is built on the first request, after all routes are registered.
+func Handler(build func() *Document) gin.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			body, err = json.MarshalIndent(build(), "", "  ")
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": http.StatusInternalServerError, "message": "Failed to build OpenAPI document"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
+
+// UIHandler serves a Swagger UI page for the document at specURL
+// assets is the base URL of the swagger-ui-dist files; empty loads them
+// from the jsDelivr CDN.
+func UIHandler(title, specURL, assets string) gin.HandlerFunc {
+	if assets == "" {
+		assets = "https://cdn.jsdelivr.net/npm/swagger-ui-dist@" + SwaggerUIVersion
+	}
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		uiTemplate.Execute(c.Writer, map[string]string{
+			"Title":   title,
+			"SpecURL": specURL,
+			"Assets":  assets,
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package poi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// amapDefaultURL is the Amap web service API
+const amapDefaultURL = "https://restapi.amap.com"
+
+// amapPageSize is the number of venues requested per lookup (Amap allows 25)
+const amapPageSize = 25
+
+// AmapProvider searches venues with the place search around API of Amap (高德)
+// Amap works in GCJ-02 coordinates; locations are converted on the way in and
+// out, so callers use WGS-84 like the tracks. The free quota is a few
+// thousand lookups per day, so wrap it with a cache and a rate limit.
+type AmapProvider struct {
+	baseURL string
+	key     string
+	client  *http.Client
+}
+
+// NewAmapProvider creates a provider calling Amap with the web service key
+// baseURL is "https://restapi.amap.com" when empty.
+func NewAmapProvider(baseURL, key string) *AmapProvider {
+	if baseURL == "" {
+		baseURL = amapDefaultURL
+	}
+	return &AmapProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		key:     key,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the provider name
+func (p *AmapProvider) Name() string {
+	return "amap"
+}
+
+type amapResponse struct {
+	Status string `json:"status"` // "1" on success
+	Info   string `json:"info"`
+	POIs   []struct {
+		Name     string `json:"name"`
+		TypeCode string `json:"typecode"` // e.g. 050100; several joined by | for some venues
+		Location string `json:"location"` // "lon,lat" in GCJ-02
+	} `json:"pois"`
+}
+
+// Nearby searches the venues around a location, nearest first
+func (p *AmapProvider) Nearby(ctx context.Context, lat, lon, radiusM float64) ([]POI, error) {
+	gLat, gLon := wgsToGCJ(lat, lon)
+	endpoint := p.baseURL + "/v3/place/around?" + url.Values{
+		"key":        {p.key},
+		"location":   {strconv.FormatFloat(gLon, 'f', 6, 64) + "," + strconv.FormatFloat(gLat, 'f', 6, 64)},
+		"radius":     {strconv.Itoa(int(math.Ceil(radiusM)))},
+		"sortrule":   {"distance"},
+		"offset":     {strconv.Itoa(amapPageSize)},
+		"page":       {"1"},
+		"extensions": {"base"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Amap request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Amap: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body amapResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Amap response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Status != "1" {
+		return nil, fmt.Errorf("Amap place search failed: %s", body.Info)
+	}
+
+	pois := make([]POI, 0, len(body.POIs))
+	for _, v := range body.POIs {
+		parts := strings.Split(v.Location, ",")
+		if v.Name == "" || len(parts) != 2 {
+			continue
+		}
+		vLon, errLon := strconv.ParseFloat(parts[0], 64)
+		vLat, errLat := strconv.ParseFloat(parts[1], 64)
+		if errLon != nil || errLat != nil {
+			continue
+		}
+		code, _, _ := strings.Cut(v.TypeCode, "|")
+		vLat, vLon = gcjToWGS(vLat, vLon)
+		pois = append(pois, POI{Name: v.Name, Category: amapCategory(code), Kind: code, Lat: vLat, Lon: vLon})
+	}
+	return nearest(pois, lat, lon, radiusM), nil
+}
+
+// amapCategory maps an Amap POI type code to a category
+// The first two digits are the major class, the next two the medium class.
+func amapCategory(code string) string {
+	if len(code) < 4 {
+		return CategoryOther
+	}
+	switch code[:2] {
+	case "05":
+		return CategoryFood
+	case "06":
+		return CategoryShopping
+	case "08", "11":
+		return CategoryLeisure
+	case "10":
+		return CategoryLodging
+	case "15":
+		return CategoryTransport
+	case "17":
+		return CategoryOffice
+	case "09":
+		return CategoryHealth
+	case "14":
+		if code[:4] == "1412" {
+			return CategoryEducation
+		}
+		return CategoryLeisure
+	case "12":
+		if code[:4] == "1203" {
+			return CategoryResidential
+		}
+		return CategoryOffice
+	}
+	return CategoryOther
+}
+
+// GCJ-02 offset parameters (Krasovsky 1940 ellipsoid)
+const (
+	gcjSemiMajor = 6378245.0
+	gcjEccSq     = 0.00669342162296594323
+)
+
+// outsideChina reports whether a location lies outside the area where GCJ-02 is offset
+func outsideChina(lat, lon float64) bool {
+	return lon < 72.004 || lon > 137.8347 || lat < 0.8293 || lat > 55.8271
+}
+
+// wgsToGCJ converts WGS-84 coordinates to GCJ-02
+func wgsToGCJ(lat, lon float64) (float64, float64) {
+	if outsideChina(lat, lon) {
+		return lat, lon
+	}
+	x, y := lon-105.0, lat-35.0
+	dLat := -100.0 + 2.0*x + 3.0*y + 0.2*y*y + 0.1*x*y + 0.2*math.Sqrt(math.Abs(x)) +
+		(20.0*math.Sin(6.0*x*math.Pi)+20.0*math.Sin(2.0*x*math.Pi))*2.0/3.0 +
+		(20.0*math.Sin(y*math.Pi)+40.0*math.Sin(y/3.0*math.Pi))*2.0/3.0 +
+		(160.0*math.Sin(y/12.0*math.Pi)+320*math.Sin(y*math.Pi/30.0))*2.0/3.0
+	dLon := 300.0 + x + 2.0*y + 0.1*x*x + 0.1*x*y + 0.1*math.Sqrt(math.Abs(x)) +
+		(20.0*math.Sin(6.0*x*math.Pi)+20.0*math.Sin(2.0*x*math.Pi))*2.0/3.0 +
+		(20.0*math.Sin(x*math.Pi)+40.0*math.Sin(x/3.0*math.Pi))*2.0/3.0 +
+		(150.0*math.Sin(x/12.0*math.Pi)+300.0*math.Sin(x/30.0*math.Pi))*2.0/3.0
+
+	radLat := lat / 180.0 * math.Pi
+	magic := 1 - gcjEccSq*math.Sin(radLat)*math.Sin(radLat)
+	sqrtMagic := math.Sqrt(magic)
+	dLat = (dLat * 180.0) / ((gcjSemiMajor * (1 - gcjEccSq)) / (magic * sqrtMagic) * math.Pi)
+	dLon = (dLon * 180.0) / (gcjSemiMajor / sqrtMagic * math.Cos(radLat) * math.Pi)
+	return lat + dLat, lon + dLon
+}
+
+// gcjToWGS converts GCJ-02 coordinates back to WGS-84
+// The offset is applied in reverse, which is accurate to about a meter.
+func gcjToWGS(lat, lon float64) (float64, float64) {
+	gLat, gLon := wgsToGCJ(lat, lon)
+	return 2*lat - gLat, 2*lon - gLon
+}
@@ -0,0 +1,140 @@
+package poi
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// CachedProvider memoizes lookups per geohash cell and radius
+// Precision 7 (~150m) is below the usual search radius, so the stays of one
+// place share a lookup. Entries expire after ttl, as venues open and close.
+type CachedProvider struct {
+	provider  Provider
+	precision int
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedResult
+}
+
+type cachedResult struct {
+	pois    []POI
+	expires time.Time
+}
+
+// NewCachedProvider wraps p with a geohash cell cache
+// A ttl of 0 keeps entries until restart.
+func NewCachedProvider(p Provider, precision int, ttl time.Duration) *CachedProvider {
+	if precision <= 0 {
+		precision = 7
+	}
+	return &CachedProvider{
+		provider:  p,
+		precision: precision,
+		ttl:       ttl,
+		cache:     make(map[string]cachedResult),
+	}
+}
+
+// Nearby looks a location up, answering from the cache when the cell was seen before
+// Distances are those from the first location looked up in the cell.
+func (c *CachedProvider) Nearby(ctx context.Context, lat, lon, radiusM float64) ([]POI, error) {
+	key := spatial.EncodeGeohash(lat, lon, c.precision) + "/" + strconv.FormatFloat(radiusM, 'f', 0, 64)
+
+	c.mu.Lock()
+	hit, found := c.cache[key]
+	c.mu.Unlock()
+	if found && (c.ttl == 0 || time.Now().Before(hit.expires)) {
+		return hit.pois, nil
+	}
+
+	pois, err := c.provider.Nearby(ctx, lat, lon, radiusM)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[key] = cachedResult{pois: pois, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return pois, nil
+}
+
+// Name returns the wrapped provider's name
+func (c *CachedProvider) Name() string {
+	return c.provider.Name()
+}
+
+// CacheSize returns the number of cached lookups
+func (c *CachedProvider) CacheSize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.cache)
+}
+
+// RateLimitedProvider spaces out the calls to an external provider
+// Callers wait for their turn, so a batch of lookups stays within the
+// provider's quota instead of failing part way.
+type RateLimitedProvider struct {
+	provider Provider
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest time of the next call
+}
+
+// NewRateLimitedProvider wraps p to make at most rate calls per second
+// A rate <= 0 does not limit.
+func NewRateLimitedProvider(p Provider, rate float64) *RateLimitedProvider {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	return &RateLimitedProvider{provider: p, interval: interval}
+}
+
+// Nearby waits for the next free slot, then looks the location up
+func (r *RateLimitedProvider) Nearby(ctx context.Context, lat, lon, radiusM float64) ([]POI, error) {
+	if err := r.wait(ctx); err != nil {
+		return nil, err
+	}
+	return r.provider.Nearby(ctx, lat, lon, radiusM)
+}
+
+// wait reserves the next slot and sleeps until it, or until ctx is done
+func (r *RateLimitedProvider) wait(ctx context.Context) error {
+	if r.interval == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.interval)
+	r.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Name returns the wrapped provider's name
+func (r *RateLimitedProvider) Name() string {
+	return r.provider.Name()
+}
@@ -0,0 +1,154 @@
+package poi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// overpassDefaultURL is the public Overpass API instance
+const overpassDefaultURL = "https://overpass-api.de/api/interpreter"
+
+// overpassKeys are the OSM tags whose named features are venues
+var overpassKeys = []string{"amenity", "shop", "tourism", "leisure", "office", "railway", "public_transport", "aeroway"}
+
+// OverpassProvider searches named OSM features with an Overpass API server
+// The public instances ask for at most one request per second or so; a
+// self-hosted server over a regional extract has no limit.
+type OverpassProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewOverpassProvider creates a provider for the Overpass interpreter endpoint
+// at endpoint, the public instance when empty
+func NewOverpassProvider(endpoint string) *OverpassProvider {
+	if endpoint == "" {
+		endpoint = overpassDefaultURL
+	}
+	return &OverpassProvider{
+		url:    endpoint,
+		client: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name returns the provider name
+func (p *OverpassProvider) Name() string {
+	return "overpass"
+}
+
+type overpassResponse struct {
+	Remark   string `json:"remark"` // Set when the query timed out or ran out of memory
+	Elements []struct {
+		Lat    float64 `json:"lat"`
+		Lon    float64 `json:"lon"`
+		Center *struct {
+			Lat float64 `json:"lat"`
+			Lon float64 `json:"lon"`
+		} `json:"center"` // Ways and relations
+		Tags map[string]string `json:"tags"`
+	} `json:"elements"`
+}
+
+// Nearby searches the named venues around a location, nearest first
+func (p *OverpassProvider) Nearby(ctx context.Context, lat, lon, radiusM float64) ([]POI, error) {
+	around := fmt.Sprintf("around:%d,%.6f,%.6f", int(math.Ceil(radiusM)), lat, lon)
+	var query strings.Builder
+	query.WriteString("[out:json][timeout:25];(")
+	for _, key := range overpassKeys {
+		fmt.Fprintf(&query, `nwr(%s)["name"]["%s"];`, around, key)
+	}
+	query.WriteString(");out tags center;")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url,
+		strings.NewReader(url.Values{"data": {query.String()}}.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Overpass request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Overpass: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Overpass query failed with status %d", resp.StatusCode)
+	}
+
+	var body overpassResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Overpass response: %w", err)
+	}
+	if body.Remark != "" && len(body.Elements) == 0 {
+		return nil, fmt.Errorf("Overpass query failed: %s", body.Remark)
+	}
+
+	pois := make([]POI, 0, len(body.Elements))
+	for _, e := range body.Elements {
+		vLat, vLon := e.Lat, e.Lon
+		if e.Center != nil {
+			vLat, vLon = e.Center.Lat, e.Center.Lon
+		}
+		category, kind := osmCategory(e.Tags)
+		pois = append(pois, POI{Name: osmName(e.Tags), Category: category, Kind: kind, Lat: vLat, Lon: vLon})
+	}
+	return nearest(pois, lat, lon, radiusM), nil
+}
+
+// osmName prefers the Chinese name of a feature
+func osmName(tags map[string]string) string {
+	if name := tags["name:zh"]; name != "" {
+		return name
+	}
+	return tags["name"]
+}
+
+// osmCategory maps the tags of an OSM feature to a category and the key=value
+// tag it was derived from
+func osmCategory(tags map[string]string) (string, string) {
+	if v := tags["amenity"]; v != "" {
+		kind := "amenity=" + v
+		switch v {
+		case "restaurant", "cafe", "fast_food", "food_court", "bar", "pub", "ice_cream", "biergarten":
+			return CategoryFood, kind
+		case "cinema", "theatre", "arts_centre", "nightclub", "library", "community_centre":
+			return CategoryLeisure, kind
+		case "school", "university", "college", "kindergarten":
+			return CategoryEducation, kind
+		case "hospital", "clinic", "doctors", "dentist", "pharmacy":
+			return CategoryHealth, kind
+		case "bus_station", "ferry_terminal", "fuel", "parking", "charging_station":
+			return CategoryTransport, kind
+		case "marketplace":
+			return CategoryShopping, kind
+		}
+		return CategoryOther, kind
+	}
+	if v := tags["shop"]; v != "" {
+		return CategoryShopping, "shop=" + v
+	}
+	if v := tags["tourism"]; v != "" {
+		switch v {
+		case "hotel", "hostel", "guest_house", "motel", "apartment":
+			return CategoryLodging, "tourism=" + v
+		}
+		return CategoryLeisure, "tourism=" + v
+	}
+	if v := tags["leisure"]; v != "" {
+		return CategoryLeisure, "leisure=" + v
+	}
+	if v := tags["office"]; v != "" {
+		return CategoryOffice, "office=" + v
+	}
+	for _, key := range []string{"railway", "public_transport", "aeroway"} {
+		if v := tags[key]; v != "" {
+			return CategoryTransport, key + "=" + v
+		}
+	}
+	return CategoryOther, ""
+}
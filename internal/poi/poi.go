@@ -0,0 +1,84 @@
+// Package poi looks up named venues (points of interest) near a location.
+//
+// Lookups go through the Provider interface so the Amap place search or an
+// OSM Overpass server can be used online, and a POI snapshot file offline.
+// External providers are wrapped with a cache and a rate limit. A
+// process-wide default provider is set at startup and used by the
+// poi_enrichment analyzer.
+package poi
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// ErrNoProvider is returned when no POI provider has been configured
+var ErrNoProvider = errors.New("no POI provider configured")
+
+// POI categories, normalized across providers
+const (
+	CategoryFood        = "FOOD"
+	CategoryShopping    = "SHOPPING"
+	CategoryLeisure     = "LEISURE"
+	CategoryLodging     = "LODGING"
+	CategoryTransport   = "TRANSPORT"
+	CategoryOffice      = "OFFICE"
+	CategoryEducation   = "EDUCATION"
+	CategoryHealth      = "HEALTH"
+	CategoryResidential = "RESIDENTIAL"
+	CategoryOther       = "OTHER"
+)
+
+// POI is a named venue
+type POI struct {
+	Name      string  `json:"name"`
+	Category  string  `json:"category"` // FOOD, SHOPPING, ...
+	Kind      string  `json:"kind"`     // Provider type, e.g. amenity=cafe or 050500
+	Lat       float64 `json:"lat"`      // WGS-84
+	Lon       float64 `json:"lon"`
+	DistanceM float64 `json:"distance_m"` // From the looked-up location
+}
+
+// Provider returns the venues within radiusM of a location, nearest first
+// An empty result means no venue was found.
+type Provider interface {
+	Nearby(ctx context.Context, lat, lon, radiusM float64) ([]POI, error)
+	Name() string
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultProvider Provider
+)
+
+// SetDefaultProvider sets the provider used by the enrichment analyzer
+func SetDefaultProvider(p Provider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = p
+}
+
+// DefaultProvider returns the configured provider, or nil if none was set
+func DefaultProvider() Provider {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProvider
+}
+
+// nearest sets the distance of each venue from (lat, lon), drops those
+// farther than radiusM and sorts the rest nearest first
+func nearest(pois []POI, lat, lon, radiusM float64) []POI {
+	out := pois[:0]
+	for _, p := range pois {
+		p.DistanceM = spatial.HaversineDistance(lat, lon, p.Lat, p.Lon)
+		if p.DistanceM <= radiusM {
+			out = append(out, p)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DistanceM < out[j].DistanceM })
+	return out
+}
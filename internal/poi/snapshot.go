@@ -0,0 +1,118 @@
+package poi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// snapshotCellDeg is the grid index cell size of snapshots, about 1 km
+const snapshotCellDeg = 0.01
+
+// SnapshotProvider is an offline provider backed by a POI snapshot file
+type SnapshotProvider struct {
+	pois  []POI
+	index map[[2]int][]int
+}
+
+type snapshotCollection struct {
+	Features []struct {
+		Properties map[string]interface{} `json:"properties"`
+		Geometry   *struct {
+			Type        string    `json:"type"`
+			Coordinates []float64 `json:"coordinates"` // [lon, lat]
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// LoadSnapshotProvider loads a GeoJSON FeatureCollection of named Point
+// features from path, e.g. an OSM extract exported with `osmium export` or
+// `ogr2ogr -f GeoJSON`
+// A "category" property (FOOD, SHOPPING, ...) is used as is; otherwise the
+// category is derived from OSM tag properties (amenity, shop, tourism, ...).
+// Features without a name or a point geometry are skipped.
+func LoadSnapshotProvider(path string) (*SnapshotProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read POI snapshot: %w", err)
+	}
+
+	var collection snapshotCollection
+	if err := json.Unmarshal(data, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse POI snapshot: %w", err)
+	}
+
+	var pois []POI
+	for _, f := range collection.Features {
+		if f.Geometry == nil || f.Geometry.Type != "Point" || len(f.Geometry.Coordinates) < 2 {
+			continue
+		}
+		tags := make(map[string]string, len(f.Properties))
+		for key, value := range f.Properties {
+			if s, ok := value.(string); ok {
+				tags[key] = s
+			}
+		}
+		name := osmName(tags)
+		if name == "" {
+			continue
+		}
+		category, kind := osmCategory(tags)
+		if c := strings.ToUpper(tags["category"]); c != "" {
+			category = c
+		}
+		pois = append(pois, POI{
+			Name:     name,
+			Category: category,
+			Kind:     kind,
+			Lat:      f.Geometry.Coordinates[1],
+			Lon:      f.Geometry.Coordinates[0],
+		})
+	}
+	return NewSnapshotProvider(pois), nil
+}
+
+// NewSnapshotProvider creates a provider over venues held in memory
+func NewSnapshotProvider(pois []POI) *SnapshotProvider {
+	p := &SnapshotProvider{pois: pois, index: make(map[[2]int][]int)}
+	for i, v := range pois {
+		cell := snapshotCell(v.Lat, v.Lon)
+		p.index[cell] = append(p.index[cell], i)
+	}
+	return p
+}
+
+// Name returns the provider name
+func (p *SnapshotProvider) Name() string {
+	return "snapshot"
+}
+
+// Count returns the number of venues in the snapshot
+func (p *SnapshotProvider) Count() int {
+	return len(p.pois)
+}
+
+// Nearby returns the snapshot venues around a location, nearest first
+func (p *SnapshotProvider) Nearby(_ context.Context, lat, lon, radiusM float64) ([]POI, error) {
+	dLat := radiusM / 111320
+	dLon := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+	lo, hi := snapshotCell(lat-dLat, lon-dLon), snapshotCell(lat+dLat, lon+dLon)
+
+	var candidates []POI
+	for x := lo[0]; x <= hi[0]; x++ {
+		for y := lo[1]; y <= hi[1]; y++ {
+			for _, i := range p.index[[2]int{x, y}] {
+				candidates = append(candidates, p.pois[i])
+			}
+		}
+	}
+	return nearest(candidates, lat, lon, radiusM), nil
+}
+
+// snapshotCell returns the grid index cell of a location
+func snapshotCell(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lon / snapshotCellDeg)), int(math.Floor(lat / snapshotCellDeg))}
+}
@@ -0,0 +1,138 @@
+package privacy
+
+import (
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// defaultFuzzM is the fuzz grid size of polygon zones without fuzz_m
+const defaultFuzzM = 500
+
+// metersPerDegree is the length of a degree of latitude
+const metersPerDegree = 111320
+
+// Filter hides track points inside privacy zones
+// Points in an exclude zone are dropped; points in a fuzz zone are snapped to
+// the center of a grid cell of the zone's fuzz size, so nearby fixes collapse
+// onto the same coarse location. The first zone containing a point applies.
+// A nil Filter keeps every point.
+type Filter struct {
+	zones []zone
+}
+
+type zone struct {
+	shape   string
+	center  spatial.Point
+	radiusM float64
+	polygon []spatial.Point
+	exclude bool
+	fuzzM   float64
+
+	// Bounding box, to skip the exact test for points far away
+	minLat, minLon, maxLat, maxLon float64
+}
+
+// NewFilter creates a filter from privacy zones; disabled and deleted zones are ignored
+func NewFilter(zones []models.PrivacyZone) *Filter {
+	f := &Filter{}
+	for _, z := range zones {
+		if !z.Enabled || z.DeletedAt != nil {
+			continue
+		}
+
+		fz := zone{
+			shape:   z.Shape,
+			exclude: z.Action != models.PrivacyActionFuzz,
+			fuzzM:   z.FuzzM,
+		}
+		switch z.Shape {
+		case models.PrivacyShapeCircle:
+			if z.RadiusM <= 0 {
+				continue
+			}
+			fz.center = spatial.Point{Lat: z.CenterLat, Lon: z.CenterLon}
+			fz.radiusM = z.RadiusM
+			dLat := z.RadiusM / metersPerDegree
+			dLon := dLat / math.Max(math.Cos(z.CenterLat*math.Pi/180), 0.01)
+			fz.minLat, fz.maxLat = z.CenterLat-dLat, z.CenterLat+dLat
+			fz.minLon, fz.maxLon = z.CenterLon-dLon, z.CenterLon+dLon
+			if fz.fuzzM <= 0 {
+				fz.fuzzM = z.RadiusM
+			}
+		case models.PrivacyShapePolygon:
+			if len(z.Polygon) < 3 {
+				continue
+			}
+			fz.polygon = make([]spatial.Point, len(z.Polygon))
+			for i, v := range z.Polygon {
+				fz.polygon[i] = spatial.Point{Lat: v[1], Lon: v[0]}
+			}
+			fz.minLat, fz.minLon, fz.maxLat, fz.maxLon = spatial.BoundingBox(fz.polygon)
+			if fz.fuzzM <= 0 {
+				fz.fuzzM = defaultFuzzM
+			}
+		default:
+			continue
+		}
+		f.zones = append(f.zones, fz)
+	}
+	return f
+}
+
+// Empty reports whether the filter has no active zones
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.zones) == 0
+}
+
+// Apply returns the location a point is shown at, and false when it must be dropped
+func (f *Filter) Apply(lat, lon float64) (float64, float64, bool) {
+	if f == nil {
+		return lat, lon, true
+	}
+	for _, z := range f.zones {
+		if !z.contains(lat, lon) {
+			continue
+		}
+		if z.exclude {
+			return 0, 0, false
+		}
+		lat, lon = fuzz(lat, lon, z.fuzzM)
+		return lat, lon, true
+	}
+	return lat, lon, true
+}
+
+// ApplyPath filters a sequence of points, dropping excluded ones
+func (f *Filter) ApplyPath(points []spatial.Point) []spatial.Point {
+	if f.Empty() {
+		return points
+	}
+	kept := make([]spatial.Point, 0, len(points))
+	for _, p := range points {
+		if lat, lon, ok := f.Apply(p.Lat, p.Lon); ok {
+			kept = append(kept, spatial.Point{Lat: lat, Lon: lon})
+		}
+	}
+	return kept
+}
+
+func (z zone) contains(lat, lon float64) bool {
+	if lat < z.minLat || lat > z.maxLat || lon < z.minLon || lon > z.maxLon {
+		return false
+	}
+	if z.shape == models.PrivacyShapeCircle {
+		return spatial.HaversineDistance(z.center.Lat, z.center.Lon, lat, lon) <= z.radiusM
+	}
+	return spatial.PointInPolygon(spatial.Point{Lat: lat, Lon: lon}, z.polygon)
+}
+
+// fuzz snaps a location to the center of its grid cell of about cellM meters
+func fuzz(lat, lon, cellM float64) (float64, float64) {
+	latStep := cellM / metersPerDegree
+	lat = (math.Floor(lat/latStep) + 0.5) * latStep
+	lonStep := latStep / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+	lon = (math.Floor(lon/lonStep) + 0.5) * lonStep
+	return lat, lon
+}
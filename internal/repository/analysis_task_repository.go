@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/jengzang/records-backend-go/internal/analysis"
 	"github.com/jengzang/records-backend-go/internal/models"
 )
 
@@ -25,8 +26,8 @@ func (r *AnalysisTaskRepository) Create(task *models.AnalysisTask) error {
 			skill_name, mode, status, progress_percent, eta_seconds,
 			params_json, threshold_profile_id, total_points, processed_points,
 			failed_points, start_time, end_time, result_summary, error_message,
-			depends_on_task_ids, blocks_task_ids, created_by
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			depends_on_task_ids, blocks_task_ids, created_by, priority
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.Exec(query,
@@ -47,6 +48,7 @@ func (r *AnalysisTaskRepository) Create(task *models.AnalysisTask) error {
 		task.DependsOnTaskIDs,
 		task.BlocksTaskIDs,
 		task.CreatedBy,
+		task.Priority,
 	)
 
 	if err != nil {
@@ -65,9 +67,9 @@ func (r *AnalysisTaskRepository) Create(task *models.AnalysisTask) error {
 // GetByID retrieves an analysis task by ID
 func (r *AnalysisTaskRepository) GetByID(id int64) (*models.AnalysisTask, error) {
 	query := `
-		SELECT id, skill_name, mode, status, progress_percent, eta_seconds,
+		SELECT id, skill_name, mode, status, progress_percent, eta_seconds, priority,
 			   params_json, threshold_profile_id, total_points, processed_points,
-			   failed_points, start_time, end_time, result_summary, error_message,
+			   failed_points, start_time, end_time, resume_count, result_summary, error_message,
 			   depends_on_task_ids, blocks_task_ids, created_by, created_at, updated_at
 		FROM analysis_tasks
 		WHERE id = ?
@@ -81,6 +83,7 @@ func (r *AnalysisTaskRepository) GetByID(id int64) (*models.AnalysisTask, error)
 		&task.Status,
 		&task.ProgressPercent,
 		&task.ETASeconds,
+		&task.Priority,
 		&task.ParamsJSON,
 		&task.ThresholdProfileID,
 		&task.TotalPoints,
@@ -88,6 +91,7 @@ func (r *AnalysisTaskRepository) GetByID(id int64) (*models.AnalysisTask, error)
 		&task.FailedPoints,
 		&task.StartTime,
 		&task.EndTime,
+		&task.ResumeCount,
 		&task.ResultSummary,
 		&task.ErrorMessage,
 		&task.DependsOnTaskIDs,
@@ -110,9 +114,9 @@ func (r *AnalysisTaskRepository) GetByID(id int64) (*models.AnalysisTask, error)
 // List retrieves analysis tasks with optional filters
 func (r *AnalysisTaskRepository) List(skillName string, status string, limit int, offset int) ([]*models.AnalysisTask, error) {
 	query := `
-		SELECT id, skill_name, mode, status, progress_percent, eta_seconds,
+		SELECT id, skill_name, mode, status, progress_percent, eta_seconds, priority,
 			   params_json, threshold_profile_id, total_points, processed_points,
-			   failed_points, start_time, end_time, result_summary, error_message,
+			   failed_points, start_time, end_time, resume_count, result_summary, error_message,
 			   depends_on_task_ids, blocks_task_ids, created_by, created_at, updated_at
 		FROM analysis_tasks
 		WHERE 1=1
@@ -147,6 +151,7 @@ func (r *AnalysisTaskRepository) List(skillName string, status string, limit int
 			&task.Status,
 			&task.ProgressPercent,
 			&task.ETASeconds,
+			&task.Priority,
 			&task.ParamsJSON,
 			&task.ThresholdProfileID,
 			&task.TotalPoints,
@@ -154,6 +159,7 @@ func (r *AnalysisTaskRepository) List(skillName string, status string, limit int
 			&task.FailedPoints,
 			&task.StartTime,
 			&task.EndTime,
+			&task.ResumeCount,
 			&task.ResultSummary,
 			&task.ErrorMessage,
 			&task.DependsOnTaskIDs,
@@ -272,6 +278,75 @@ func (r *AnalysisTaskRepository) MarkAsFailed(id int64, errorMessage string) err
 	return nil
 }
 
+// MarkAsInterrupted marks a pending or running task as interrupted
+func (r *AnalysisTaskRepository) MarkAsInterrupted(id int64, message string) error {
+	_, err := r.db.Exec(`
+		UPDATE analysis_tasks
+		SET status = ?, end_time = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status IN (?, ?)`,
+		models.TaskStatusInterrupted, time.Now().Unix(), message, id,
+		models.TaskStatusPending, models.TaskStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to mark task as interrupted: %w", err)
+	}
+	return nil
+}
+
+// InterruptUnfinished marks every pending or running task as interrupted
+// and returns their number
+func (r *AnalysisTaskRepository) InterruptUnfinished(message string) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE analysis_tasks
+		SET status = ?, end_time = ?, error_message = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE status IN (?, ?)`,
+		models.TaskStatusInterrupted, time.Now().Unix(), message,
+		models.TaskStatusPending, models.TaskStatusRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to interrupt unfinished tasks: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ListInterrupted retrieves the interrupted tasks resumed fewer than
+// maxResumes times, oldest first
+func (r *AnalysisTaskRepository) ListInterrupted(maxResumes int) ([]*models.AnalysisTask, error) {
+	rows, err := r.db.Query(`
+		SELECT id, skill_name, mode, priority, resume_count
+		FROM analysis_tasks
+		WHERE status = ? AND resume_count < ?
+		ORDER BY id`, models.TaskStatusInterrupted, maxResumes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interrupted tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []*models.AnalysisTask
+	for rows.Next() {
+		task := &models.AnalysisTask{Status: models.TaskStatusInterrupted}
+		if err := rows.Scan(&task.ID, &task.SkillName, &task.TaskType, &task.Priority, &task.ResumeCount); err != nil {
+			return nil, fmt.Errorf("failed to scan interrupted task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// MarkAsResumed puts an interrupted task back to pending and counts the
+// resume; it reports false when the task was not interrupted
+func (r *AnalysisTaskRepository) MarkAsResumed(id int64) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE analysis_tasks
+		SET status = ?, end_time = NULL, error_message = NULL,
+			resume_count = resume_count + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?`,
+		models.TaskStatusPending, id, models.TaskStatusInterrupted)
+	if err != nil {
+		return false, fmt.Errorf("failed to resume task: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // CountUnanalyzedPoints counts the number of points without analysis data
 func (r *AnalysisTaskRepository) CountUnanalyzedPoints() (int, error) {
 	query := `SELECT COUNT(*) FROM "一生足迹" WHERE segment_id IS NULL`
@@ -297,3 +372,76 @@ func (r *AnalysisTaskRepository) CountAllPoints() (int, error) {
 
 	return count, nil
 }
+
+// CountActiveByStatus counts pending and running tasks by status
+func (r *AnalysisTaskRepository) CountActiveByStatus() (map[string]int, error) {
+	rows, err := r.db.Query(`
+		SELECT status, COUNT(*) FROM analysis_tasks
+		WHERE status IN ('pending', 'running')
+		GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active tasks: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{models.TaskStatusPending: 0, models.TaskStatusRunning: 0}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan task count: %w", err)
+		}
+		counts[status] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// GetComputedVersions returns the algorithm version each skill's outputs
+// were last fully computed with
+func (r *AnalysisTaskRepository) GetComputedVersions() (map[string]analysis.Computed, error) {
+	rows, err := r.db.Query("SELECT skill_name, algo_version, computed_at FROM analyzer_versions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analyzer versions: %w", err)
+	}
+	defer rows.Close()
+
+	computed := make(map[string]analysis.Computed)
+	for rows.Next() {
+		var skill string
+		var c analysis.Computed
+		if err := rows.Scan(&skill, &c.Version, &c.ComputedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan analyzer version: %w", err)
+		}
+		computed[skill] = c
+	}
+	return computed, rows.Err()
+}
+
+// SetComputedVersion records that a skill's outputs were fully computed with version
+func (r *AnalysisTaskRepository) SetComputedVersion(skillName, version string, computedAt int64) error {
+	_, err := r.db.Exec(`
+		INSERT INTO analyzer_versions (skill_name, algo_version, computed_at) VALUES (?, ?, ?)
+		ON CONFLICT(skill_name) DO UPDATE SET algo_version = excluded.algo_version, computed_at = excluded.computed_at`,
+		skillName, version, computedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record analyzer version: %w", err)
+	}
+	return nil
+}
+
+// SeedComputedVersions records the versions of the skills that have none yet
+// as their baseline, computed at 0
+func (r *AnalysisTaskRepository) SeedComputedVersions(versions map[string]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for skill, version := range versions {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO analyzer_versions (skill_name, algo_version, computed_at) VALUES (?, ?, 0)", skill, version); err != nil {
+			return fmt.Errorf("failed to seed analyzer version: %w", err)
+		}
+	}
+	return tx.Commit()
+}
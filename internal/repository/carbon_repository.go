@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// CarbonRepository handles database operations for the emissions model and carbon footprints
+type CarbonRepository struct {
+	db *sql.DB
+}
+
+// NewCarbonRepository creates a new carbon repository
+func NewCarbonRepository(db *sql.DB) *CarbonRepository {
+	return &CarbonRepository{db: db}
+}
+
+const emissionFactorColumns = `mode, g_co2_per_km, default_g_co2_per_km, COALESCE(description, ''), updated_at`
+
+// scanEmissionFactor scans an emission factor row
+func scanEmissionFactor(scanner interface{ Scan(...interface{}) error }) (*models.EmissionFactor, error) {
+	var f models.EmissionFactor
+	if err := scanner.Scan(&f.Mode, &f.GCO2PerKm, &f.DefaultGCO2PerKm, &f.Description, &f.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ListFactors retrieves the emission factors of all modes
+func (r *CarbonRepository) ListFactors() ([]models.EmissionFactor, error) {
+	rows, err := r.db.Query(`SELECT ` + emissionFactorColumns + ` FROM emission_factors ORDER BY mode`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emission factors: %w", err)
+	}
+	defer rows.Close()
+
+	factors := []models.EmissionFactor{}
+	for rows.Next() {
+		f, err := scanEmissionFactor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan emission factor: %w", err)
+		}
+		factors = append(factors, *f)
+	}
+	return factors, rows.Err()
+}
+
+// GetFactor retrieves the emission factor of a mode; nil if it has none
+func (r *CarbonRepository) GetFactor(mode string) (*models.EmissionFactor, error) {
+	f, err := scanEmissionFactor(r.db.QueryRow(`SELECT `+emissionFactorColumns+` FROM emission_factors WHERE mode = ?`, mode))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emission factor: %w", err)
+	}
+	return f, nil
+}
+
+// SetFactor overrides the emission factor of a mode
+// A mode without a factor yet is added with the value as its default.
+func (r *CarbonRepository) SetFactor(mode string, gCO2PerKm float64, description string) error {
+	_, err := r.db.Exec(`INSERT INTO emission_factors (mode, g_co2_per_km, default_g_co2_per_km, description)
+		VALUES (?, ?, ?, NULLIF(?, ''))
+		ON CONFLICT(mode) DO UPDATE SET
+			g_co2_per_km = excluded.g_co2_per_km,
+			description = COALESCE(excluded.description, emission_factors.description),
+			updated_at = CURRENT_TIMESTAMP`,
+		mode, gCO2PerKm, gCO2PerKm, description)
+	if err != nil {
+		return fmt.Errorf("failed to set emission factor: %w", err)
+	}
+	return nil
+}
+
+// ResetFactor restores the built-in emission factor of a mode
+func (r *CarbonRepository) ResetFactor(mode string) error {
+	_, err := r.db.Exec(`UPDATE emission_factors
+		SET g_co2_per_km = default_g_co2_per_km, updated_at = CURRENT_TIMESTAMP
+		WHERE mode = ?`, mode)
+	if err != nil {
+		return fmt.Errorf("failed to reset emission factor: %w", err)
+	}
+	return nil
+}
+
+// unmarshalModeCO2 decodes a mode_co2_json column; empty for NULL
+func unmarshalModeCO2(modeJSON sql.NullString) (map[string]float64, error) {
+	modeCO2 := map[string]float64{}
+	if modeJSON.Valid && modeJSON.String != "" {
+		if err := json.Unmarshal([]byte(modeJSON.String), &modeCO2); err != nil {
+			return nil, fmt.Errorf("invalid mode_co2_json: %w", err)
+		}
+	}
+	return modeCO2, nil
+}
+
+// GetCarbonPeriods retrieves the footprints of a bucket type in bucket key order
+// keyPrefix narrows the rows, e.g. "2025-" for the months of 2025.
+func (r *CarbonRepository) GetCarbonPeriods(bucketType, keyPrefix string) ([]models.CarbonPeriod, error) {
+	rows, err := r.db.Query(`SELECT bucket_type, bucket_key, distance_m, co2_kg, trip_count, mode_co2_json, created_at
+		FROM carbon_stats_bucketed
+		WHERE bucket_type = ? AND bucket_key LIKE ? || '%'
+		ORDER BY bucket_key`, bucketType, keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query carbon footprints: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []models.CarbonPeriod{}
+	for rows.Next() {
+		var p models.CarbonPeriod
+		var modeJSON sql.NullString
+		if err := rows.Scan(&p.BucketType, &p.BucketKey, &p.DistanceM, &p.CO2Kg, &p.TripCount, &modeJSON, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan carbon footprint: %w", err)
+		}
+		if p.ModeCO2, err = unmarshalModeCO2(modeJSON); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, rows.Err()
+}
+
+const tripCarbonColumns = `trip_id, start_time, distance_m, co2_kg, mode_co2_json, created_at`
+
+var tripCarbonSort = sortSpec{
+	fields:       sortFields("start_time", "distance_m", "co2_kg"),
+	defaultField: "co2_kg",
+	defaultOrder: "DESC",
+}
+
+func scanTripCarbon(rows *sql.Rows) (models.TripCarbon, error) {
+	var t models.TripCarbon
+	var modeJSON sql.NullString
+	if err := rows.Scan(&t.TripID, &t.StartTime, &t.DistanceM, &t.CO2Kg, &modeJSON, &t.CreatedAt); err != nil {
+		return t, err
+	}
+	var err error
+	t.ModeCO2, err = unmarshalModeCO2(modeJSON)
+	return t, err
+}
+
+// GetTripCarbon retrieves a page of trip footprints starting within [startTime, endTime]
+// Zero bounds are open.
+func (r *CarbonRepository) GetTripCarbon(startTime, endTime int64, opts models.QueryOptions) ([]models.TripCarbon, int64, error) {
+	q := newListQuery(tripCarbonColumns, "trip_carbon").
+		whereIf(startTime > 0, "start_time >= ?", startTime).
+		whereIf(endTime > 0, "start_time <= ?", endTime)
+	return queryList(r.db, q, tripCarbonSort, opts, "trip carbon footprints", scanTripCarbon)
+}
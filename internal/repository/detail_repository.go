@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// DetailRepository loads the context shown with a single segment, stay or trip
+type DetailRepository struct {
+	db *sql.DB
+}
+
+// NewDetailRepository creates a new detail repository
+func NewDetailRepository(db *sql.DB) *DetailRepository {
+	return &DetailRepository{db: db}
+}
+
+// entityRefQueries select type-specific refs as (id, start_time, end_time, label)
+var entityRefQueries = map[string]struct{ columns, from string }{
+	models.EntitySegment: {
+		columns: `id, start_time, end_time, mode`,
+		from:    `segments`,
+	},
+	models.EntityStay: {
+		columns: `s.id, s.start_time, s.end_time, COALESCE(a.label, s.county, s.city, s.province, '')`,
+		from:    `stay_segments s LEFT JOIN stay_annotations a ON a.stay_id = s.id`,
+	},
+	models.EntityTrip: {
+		columns: `id, start_time, end_time,
+			COALESCE(origin_county, origin_city, '?') || ' → ' || COALESCE(dest_county, dest_city, '?')`,
+		from: `trips`,
+	},
+}
+
+// queryRef returns the first ref of an entity type matching the condition, or nil
+func (r *DetailRepository) queryRef(entity, condition, order string, args ...interface{}) (*models.EntityRef, error) {
+	q := entityRefQueries[entity]
+	query := `SELECT ` + q.columns + ` FROM ` + q.from + ` WHERE ` + condition
+	if order != "" {
+		query += ` ORDER BY ` + order
+	}
+	query += ` LIMIT 1`
+
+	ref := models.EntityRef{Type: entity}
+	err := r.db.QueryRow(query, args...).Scan(&ref.ID, &ref.StartTime, &ref.EndTime, &ref.Label)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", entity, err)
+	}
+	return &ref, nil
+}
+
+// GetRef retrieves the ref of an entity by ID
+func (r *DetailRepository) GetRef(entity string, id int64) (*models.EntityRef, error) {
+	return r.queryRef(entity, entityColumn(entity, "id")+" = ?", "", id)
+}
+
+// GetNeighbours retrieves the entities of the same type just before and
+// after the given one, ordered by start time
+func (r *DetailRepository) GetNeighbours(entity string, id, startTime int64) (prev, next *models.EntityRef, err error) {
+	idCol := entityColumn(entity, "id")
+	startCol := entityColumn(entity, "start_time")
+
+	prev, err = r.queryRef(entity,
+		"("+startCol+" < ? OR ("+startCol+" = ? AND "+idCol+" < ?))",
+		startCol+" DESC, "+idCol+" DESC", startTime, startTime, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	next, err = r.queryRef(entity,
+		"("+startCol+" > ? OR ("+startCol+" = ? AND "+idCol+" > ?))",
+		startCol+" ASC, "+idCol+" ASC", startTime, startTime, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prev, next, nil
+}
+
+// GetTripCovering retrieves the trip whose time range covers [start, end]
+func (r *DetailRepository) GetTripCovering(start, end int64) (*models.EntityRef, error) {
+	return r.queryRef(models.EntityTrip, "start_time <= ? AND end_time >= ?", "start_time DESC, id DESC", start, end)
+}
+
+// GetStayTrips retrieves the trips arriving at and leaving from a stay
+func (r *DetailRepository) GetStayTrips(stayID int64) (arriving, leaving *models.EntityRef, err error) {
+	arriving, err = r.queryRef(models.EntityTrip, "dest_stay_id = ?", "start_time DESC, id DESC", stayID)
+	if err != nil {
+		return nil, nil, err
+	}
+	leaving, err = r.queryRef(models.EntityTrip, "origin_stay_id = ?", "start_time ASC, id ASC", stayID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return arriving, leaving, nil
+}
+
+// GetTripSegments retrieves the refs of a trip's segments in time order
+func (r *DetailRepository) GetTripSegments(trip *models.Trip) ([]models.EntityRef, error) {
+	var metadata sql.NullString
+	if err := r.db.QueryRow(`SELECT metadata FROM trips WHERE id = ?`, trip.ID).Scan(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to get trip metadata: %w", err)
+	}
+
+	query, args, err := tripSegmentsQuery("id, start_time, end_time, mode", metadata, trip.StartTime, trip.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip segments: %w", err)
+	}
+	defer rows.Close()
+
+	refs := []models.EntityRef{}
+	for rows.Next() {
+		ref := models.EntityRef{Type: models.EntitySegment}
+		if err := rows.Scan(&ref.ID, &ref.StartTime, &ref.EndTime, &ref.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating segments: %w", err)
+	}
+	return refs, nil
+}
+
+// GetPoints retrieves the non-outlier track points in [start, end] in time order
+func (r *DetailRepository) GetPoints(start, end int64) ([]models.DetailPoint, error) {
+	rows, err := r.db.Query(`
+		SELECT id, dataTime, latitude, longitude, speed, altitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ? AND outlier_flag = 0
+		ORDER BY dataTime, id
+	`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	var points []models.DetailPoint
+	for rows.Next() {
+		var p models.DetailPoint
+		var speed, altitude sql.NullFloat64
+		if err := rows.Scan(&p.ID, &p.DataTime, &p.Latitude, &p.Longitude, &speed, &altitude); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		p.Speed = speed.Float64
+		p.Altitude = altitude.Float64
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating points: %w", err)
+	}
+	return points, nil
+}
+
+// GetStayAnnotation retrieves the label of a stay and its label suggestions,
+// or nil when the stay has neither
+func (r *DetailRepository) GetStayAnnotation(stayID int64) (*models.StayAnnotation, error) {
+	var label, subLabel, note, suggestions sql.NullString
+	var confirmed, placeID sql.NullInt64
+	err := r.db.QueryRow(`
+		SELECT a.label, a.sub_label, a.note, a.confirmed, a.place_id, c.suggestions_json
+		FROM (SELECT ? AS stay_id) s
+		LEFT JOIN stay_annotations a ON a.stay_id = s.stay_id
+		LEFT JOIN stay_context_cache c ON c.stay_id = s.stay_id
+	`, stayID).Scan(&label, &subLabel, &note, &confirmed, &placeID, &suggestions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stay annotation: %w", err)
+	}
+	if !label.Valid && !suggestions.Valid {
+		return nil, nil
+	}
+
+	a := &models.StayAnnotation{
+		Label:     label.String,
+		SubLabel:  subLabel.String,
+		Note:      note.String,
+		Confirmed: confirmed.Int64 == 1,
+		PlaceID:   placeID.Int64,
+	}
+	if suggestions.Valid && suggestions.String != "" {
+		if err := json.Unmarshal([]byte(suggestions.String), &a.Suggestions); err != nil {
+			return nil, fmt.Errorf("invalid suggestions of stay %d: %w", stayID, err)
+		}
+	}
+	return a, nil
+}
+
+// GetRenderHints retrieves the style hints computed for a segment by
+// rendering_metadata, from its lowest level of detail, or nil when none exist
+func (r *DetailRepository) GetRenderHints(segmentID int64) (*models.RenderHints, error) {
+	var speedBucket sql.NullInt64
+	var overlapRank, lineWeight, alpha sql.NullFloat64
+	err := r.db.QueryRow(`
+		SELECT speed_bucket, overlap_rank, line_weight_hint, alpha_hint
+		FROM render_segments_cache
+		WHERE segment_id = ?
+		ORDER BY lod
+		LIMIT 1
+	`, segmentID).Scan(&speedBucket, &overlapRank, &lineWeight, &alpha)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get render hints: %w", err)
+	}
+
+	h := &models.RenderHints{}
+	if speedBucket.Valid {
+		v := int(speedBucket.Int64)
+		h.SpeedBucket = &v
+	}
+	if overlapRank.Valid {
+		h.OverlapRank = &overlapRank.Float64
+	}
+	if lineWeight.Valid {
+		h.LineWeight = &lineWeight.Float64
+	}
+	if alpha.Valid {
+		h.Alpha = &alpha.Float64
+	}
+	return h, nil
+}
+
+// entityColumn qualifies a column of the entity table in its ref query
+func entityColumn(entity, column string) string {
+	if entity == models.EntityStay {
+		return "s." + column
+	}
+	return column
+}
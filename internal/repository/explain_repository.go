@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ExplainRepository reports the query plans of the hot repository queries
+type ExplainRepository struct {
+	db *sql.DB
+}
+
+// NewExplainRepository creates a new explain repository
+func NewExplainRepository(db *sql.DB) *ExplainRepository {
+	return &ExplainRepository{db: db}
+}
+
+// namedQuery is a hot query built the way its repository method builds it,
+// with representative arguments
+type namedQuery struct {
+	description string
+	sql         string
+	args        []interface{}
+}
+
+// namedQueries returns the queries whose plans are audited, by name
+// Plans do not depend on argument values (SQLite is built without STAT4), so
+// the arguments only need the right types.
+func namedQueries() map[string]namedQuery {
+	end := time.Now().Unix()
+	start := end - 30*86400
+	page := models.QueryOptions{}
+
+	queries := map[string]namedQuery{
+		"playback_points": {
+			description: "Non-outlier points of a time range in time order (playback, share links)",
+			sql:         playbackPointsQuery,
+			args:        []interface{}{start, end},
+		},
+		"time_distribution": {
+			description: "Points of a time range by hour of day",
+			sql:         timeDistributionQuery,
+			args:        []interface{}{start, end},
+		},
+	}
+
+	add := func(name, description, query string, args []interface{}) {
+		queries[name] = namedQuery{description: description, sql: query, args: args}
+	}
+	addList := func(name, description string, q *listQuery, spec sortSpec) {
+		query, args, _ := q.pageQuery(spec, page) // The default sort is always valid
+		add(name, description, query, args)
+	}
+
+	query, _, args := trackPointsQuery(models.TrackPointFilter{StartTime: start, EndTime: end})
+	add("track_points_by_time", "Track point list filtered by time range", query, append(args, 100, 0))
+	query, _, args = trackPointsQuery(models.TrackPointFilter{Province: "广东省", City: "广州市"})
+	add("track_points_by_area", "Track point list filtered by province and city", query, append(args, 100, 0))
+
+	query, args = rawHeatmapQuery(models.HeatmapGridFilter{MinLat: 22, MaxLat: 24, MinLon: 112, MaxLon: 115},
+		0.01, start, end, 300)
+	add("raw_point_heatmap", "Zoomed-in heatmap aggregated from raw points", query, args)
+
+	addList("speed_space_stats", "Speed-space stats of a source, bucket type and area type",
+		speedSpaceQuery("year", "all", "province"), speedSpaceSort)
+	addList("spatial_utilization", "Spatial utilization of a source, bucket type and area",
+		utilizationQuery("year", "all", "city").where("area_key = ?", "广州市"), utilizationSort)
+	addList("mode_breakdown", "Mode breakdown of a bucket type and source",
+		modeBreakdownQuery("month", "", "all", ""), modeStatsSort)
+	return queries
+}
+
+// QueryNames returns the names of the audited queries in order
+func (r *ExplainRepository) QueryNames() []string {
+	queries := namedQueries()
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Explain returns the query plan of a named query
+func (r *ExplainRepository) Explain(ctx context.Context, name string) (*models.QueryPlan, error) {
+	q, ok := namedQueries()[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", models.ErrUnknownQuery, name)
+	}
+
+	rows, err := r.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+q.sql, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain %s: %w", name, err)
+	}
+	defer rows.Close()
+
+	plan := &models.QueryPlan{
+		Name:        name,
+		Description: q.description,
+		SQL:         strings.Join(strings.Fields(q.sql), " "),
+		Steps:       []models.QueryPlanStep{},
+		Scans:       []string{},
+	}
+	for rows.Next() {
+		var step models.QueryPlanStep
+		var unused int
+		if err := rows.Scan(&step.ID, &step.Parent, &unused, &step.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan plan of %s: %w", name, err)
+		}
+		plan.Steps = append(plan.Steps, step)
+		if isFullScan(step.Detail) {
+			plan.Scans = append(plan.Scans, step.Detail)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating plan of %s: %w", name, err)
+	}
+	return plan, nil
+}
+
+// isFullScan reports whether a plan step reads a whole table or index rather
+// than a subquery's result
+func isFullScan(detail string) bool {
+	return strings.HasPrefix(detail, "SCAN ") && !strings.HasPrefix(detail, "SCAN (")
+}
@@ -126,3 +126,104 @@ func (r *GridRepository) GetGridCellByID(id int64) (*models.GridCell, error) {
 
 	return &c, nil
 }
+
+// GetDensityHeatmapCells aggregates spatial_density_grid_stats into cells of cellDeg degrees
+func (r *GridRepository) GetDensityHeatmapCells(filter models.HeatmapGridFilter, cellDeg float64, bucketType, bucketKey string) ([]models.HeatmapCell, error) {
+	conditions := []string{
+		"bucket_type = ?",
+		"source = ?",
+		"center_lat BETWEEN ? AND ?",
+		"center_lon BETWEEN ? AND ?",
+	}
+	args := []interface{}{cellDeg, cellDeg, bucketType, filter.Source, filter.MinLat, filter.MaxLat, filter.MinLon, filter.MaxLon}
+	if bucketKey != "" {
+		conditions = append(conditions, "bucket_key = ?")
+		args = append(args, bucketKey)
+	}
+
+	query := `SELECT
+			CAST((center_lat + 90) / ? AS INTEGER) AS cy,
+			CAST((center_lon + 180) / ? AS INTEGER) AS cx,
+			SUM(stay_count), SUM(stay_duration_s), SUM(density_score)
+		FROM spatial_density_grid_stats
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY cy, cx
+		LIMIT 20000`
+
+	return r.queryHeatmapCells(query, args, cellDeg)
+}
+
+// GetRawPointHeatmapCells aggregates raw track points into cells of cellDeg degrees
+// Dwell time per point is the gap to the next point, capped at maxGapSeconds
+func (r *GridRepository) GetRawPointHeatmapCells(filter models.HeatmapGridFilter, cellDeg float64, startTime, endTime int64, maxGapSeconds int64) ([]models.HeatmapCell, error) {
+	query, args := rawHeatmapQuery(filter, cellDeg, startTime, endTime, maxGapSeconds)
+	return r.queryHeatmapCells(query, args, cellDeg)
+}
+
+// rawHeatmapQuery builds the aggregation of GetRawPointHeatmapCells
+func rawHeatmapQuery(filter models.HeatmapGridFilter, cellDeg float64, startTime, endTime int64, maxGapSeconds int64) (string, []interface{}) {
+	conditions := []string{
+		"outlier_flag = 0",
+		"latitude BETWEEN ? AND ?",
+		"longitude BETWEEN ? AND ?",
+	}
+	args := []interface{}{cellDeg, cellDeg, maxGapSeconds, filter.MinLat, filter.MaxLat, filter.MinLon, filter.MaxLon}
+	if filter.Source != "" && filter.Source != "all" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+	if startTime > 0 {
+		conditions = append(conditions, "dataTime >= ?")
+		args = append(args, startTime)
+	}
+	if endTime > 0 {
+		conditions = append(conditions, "dataTime <= ?")
+		args = append(args, endTime)
+	}
+
+	query := `SELECT
+			CAST((latitude + 90) / ? AS INTEGER) AS cy,
+			CAST((longitude + 180) / ? AS INTEGER) AS cx,
+			COUNT(*), SUM(dwell), COUNT(*)
+		FROM (
+			SELECT latitude, longitude,
+				MIN(COALESCE(LEAD(dataTime) OVER (ORDER BY dataTime) - dataTime, 0), ?) AS dwell
+			FROM "一生足迹"
+			WHERE ` + strings.Join(conditions, " AND ") + `
+		)
+		GROUP BY cy, cx
+		LIMIT 20000`
+	return query, args
+}
+
+// queryHeatmapCells runs a heatmap aggregation query returning (cy, cx, count, dwell, weight) rows
+func (r *GridRepository) queryHeatmapCells(query string, args []interface{}, cellDeg float64) ([]models.HeatmapCell, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query heatmap cells: %w", err)
+	}
+	defer rows.Close()
+
+	var cells []models.HeatmapCell
+	for rows.Next() {
+		var cy, cx int64
+		var count, dwell sql.NullInt64
+		var weight sql.NullFloat64
+		if err := rows.Scan(&cy, &cx, &count, &dwell, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan heatmap cell: %w", err)
+		}
+		cells = append(cells, models.HeatmapCell{
+			Lat:          (float64(cy)+0.5)*cellDeg - 90,
+			Lng:          (float64(cx)+0.5)*cellDeg - 180,
+			Count:        count.Int64,
+			DwellSeconds: dwell.Int64,
+			Weight:       weight.Float64,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating heatmap cells: %w", err)
+	}
+
+	return cells, nil
+}
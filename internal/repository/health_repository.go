@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// HealthRepository handles database operations for imported health data
+type HealthRepository struct {
+	db *sql.DB
+}
+
+// NewHealthRepository creates a new health repository
+func NewHealthRepository(db *sql.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// InsertRecords stores a batch of health records in one transaction, skipping duplicates
+// Records are unique per source and start time, so re-importing a newer
+// export only adds what is new.
+func (r *HealthRepository) InsertRecords(steps []models.HealthStepSample, heartRates []models.HeartRateSample,
+	sleep []models.SleepSample, workouts []models.Workout) (inserted, duplicates int64, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert := func(query string, count int, args func(i int) []interface{}) error {
+		if count == 0 {
+			return nil
+		}
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer stmt.Close()
+
+		for i := 0; i < count; i++ {
+			result, err := stmt.Exec(args(i)...)
+			if err != nil {
+				return err
+			}
+			n, _ := result.RowsAffected()
+			inserted += n
+			duplicates += 1 - n
+		}
+		return nil
+	}
+
+	if err := insert(`INSERT OR IGNORE INTO health_steps (source_name, start_time, end_time, date, count)
+		VALUES (?, ?, ?, ?, ?)`, len(steps), func(i int) []interface{} {
+		s := steps[i]
+		return []interface{}{s.SourceName, s.StartTime, s.EndTime, s.Date, s.Count}
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to insert steps: %w", err)
+	}
+
+	if err := insert(`INSERT OR IGNORE INTO health_heart_rate (source_name, time, date, bpm)
+		VALUES (?, ?, ?, ?)`, len(heartRates), func(i int) []interface{} {
+		h := heartRates[i]
+		return []interface{}{h.SourceName, h.Time, h.Date, h.BPM}
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to insert heart rate: %w", err)
+	}
+
+	if err := insert(`INSERT OR IGNORE INTO health_sleep (source_name, start_time, end_time, date, stage)
+		VALUES (?, ?, ?, ?, ?)`, len(sleep), func(i int) []interface{} {
+		s := sleep[i]
+		return []interface{}{s.SourceName, s.StartTime, s.EndTime, s.Date, s.Stage}
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to insert sleep: %w", err)
+	}
+
+	if err := insert(`INSERT OR IGNORE INTO health_workouts (
+			activity_type, source_name, start_time, end_time, date, duration_s, distance_m, energy_kcal
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, len(workouts), func(i int) []interface{} {
+		w := workouts[i]
+		return []interface{}{w.ActivityType, w.SourceName, w.StartTime, w.EndTime, w.Date, w.DurationS, w.DistanceM, w.EnergyKcal}
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to insert workouts: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, duplicates, nil
+}
+
+// GetDailyStats returns the health data per day of a resolved date range, ordered by date
+func (r *HealthRepository) GetDailyStats(from, to string) ([]models.HealthDailyStats, error) {
+	days := make(map[string]*models.HealthDailyStats)
+	day := func(date string) *models.HealthDailyStats {
+		d, ok := days[date]
+		if !ok {
+			d = &models.HealthDailyStats{Date: date}
+			days[date] = d
+		}
+		return d
+	}
+
+	// Steps: the source with the most steps per day
+	rows, err := r.db.Query(`
+		SELECT date, MAX(total) FROM (
+			SELECT date, source_name, SUM(count) AS total
+			FROM health_steps
+			WHERE date BETWEEN ? AND ?
+			GROUP BY date, source_name
+		) GROUP BY date`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily steps: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var steps float64
+		if err := rows.Scan(&date, &steps); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan daily steps: %w", err)
+		}
+		day(date).Steps = int64(steps)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT date, AVG(bpm), MIN(bpm), MAX(bpm)
+		FROM health_heart_rate
+		WHERE date BETWEEN ? AND ?
+		GROUP BY date`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily heart rate: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var avg, minBPM, maxBPM float64
+		if err := rows.Scan(&date, &avg, &minBPM, &maxBPM); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan daily heart rate: %w", err)
+		}
+		d := day(date)
+		d.AvgHeartRate, d.MinHeartRate, d.MaxHeartRate = &avg, &minBPM, &maxBPM
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(`
+		SELECT date, COUNT(*), SUM(duration_s)
+		FROM health_workouts
+		WHERE date BETWEEN ? AND ?
+		GROUP BY date`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily workouts: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var count int
+		var seconds int64
+		if err := rows.Scan(&date, &count, &seconds); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan daily workouts: %w", err)
+		}
+		d := day(date)
+		d.Workouts, d.WorkoutMinutes = count, float64(seconds)/60
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Sleep: asleep stages of the source with the most sleep per night
+	rows, err = r.db.Query(`
+		SELECT date, MAX(total) FROM (
+			SELECT date, source_name, SUM(end_time - start_time) AS total
+			FROM health_sleep
+			WHERE date BETWEEN ? AND ? AND stage NOT IN (?, ?)
+			GROUP BY date, source_name
+		) GROUP BY date`, from, to, models.SleepStageInBed, models.SleepStageAwake)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily sleep: %w", err)
+	}
+	for rows.Next() {
+		var date string
+		var seconds int64
+		if err := rows.Scan(&date, &seconds); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan daily sleep: %w", err)
+		}
+		hours := float64(seconds) / 3600
+		day(date).SleepHours = &hours
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]models.HealthDailyStats, 0, len(days))
+	for _, d := range days {
+		result = append(result, *d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+
+	return result, nil
+}
+
+// GetWorkouts returns the workouts of a resolved date range, newest first
+func (r *HealthRepository) GetWorkouts(filter models.HealthFilter, limit int) ([]models.Workout, error) {
+	query := `
+		SELECT id, activity_type, source_name, start_time, end_time, date, duration_s, distance_m, energy_kcal
+		FROM health_workouts
+		WHERE date BETWEEN ? AND ?`
+	args := []interface{}{filter.From, filter.To}
+	if filter.Type != "" {
+		query += " AND activity_type = ?"
+		args = append(args, filter.Type)
+	}
+	query += " ORDER BY start_time DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workouts: %w", err)
+	}
+	defer rows.Close()
+
+	workouts := []models.Workout{}
+	for rows.Next() {
+		var w models.Workout
+		var distance, energy sql.NullFloat64
+		if err := rows.Scan(&w.ID, &w.ActivityType, &w.SourceName, &w.StartTime, &w.EndTime,
+			&w.Date, &w.DurationS, &distance, &energy); err != nil {
+			return nil, fmt.Errorf("failed to scan workout: %w", err)
+		}
+		if distance.Valid {
+			w.DistanceM = &distance.Float64
+		}
+		if energy.Valid {
+			w.EnergyKcal = &energy.Float64
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
+}
+
+// GetLatestDate returns the most recent day with steps or workouts, or "" if there is none
+func (r *HealthRepository) GetLatestDate() (string, error) {
+	var date sql.NullString
+	err := r.db.QueryRow(`
+		SELECT MAX(date) FROM (
+			SELECT MAX(date) AS date FROM health_steps
+			UNION ALL SELECT MAX(date) FROM health_workouts
+		)`).Scan(&date)
+	if err != nil {
+		return "", fmt.Errorf("failed to query latest health date: %w", err)
+	}
+	return date.String, nil
+}
+
+// CreateImportTask creates a pending import task
+func (r *HealthRepository) CreateImportTask(task *models.HealthImportTask) error {
+	result, err := r.db.Exec(`
+		INSERT INTO health_import_tasks (status, file_name, total_bytes, created_by)
+		VALUES (?, ?, ?, ?)`, task.Status, task.FileName, task.TotalBytes, task.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to create health import task: %w", err)
+	}
+
+	if task.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// MarkImportRunning marks a task as running with the size of the document being read
+func (r *HealthRepository) MarkImportRunning(id, totalBytes int64) error {
+	_, err := r.db.Exec(`
+		UPDATE health_import_tasks
+		SET status = ?, total_bytes = ?, started_at = CAST(strftime('%s', 'now') AS INTEGER),
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		WHERE id = ?`, models.TaskStatusRunning, totalBytes, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark health import task as running: %w", err)
+	}
+	return nil
+}
+
+// UpdateImportProgress stores the progress counters of a running task
+func (r *HealthRepository) UpdateImportProgress(task *models.HealthImportTask) error {
+	_, err := r.db.Exec(`
+		UPDATE health_import_tasks
+		SET processed_bytes = ?, records_parsed = ?, records_inserted = ?, duplicates = ?, ignored_records = ?,
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		WHERE id = ?`,
+		task.ProcessedBytes, task.RecordsParsed, task.RecordsInserted, task.Duplicates, task.IgnoredRecords, task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update health import progress: %w", err)
+	}
+	return nil
+}
+
+// FinishImportTask stores the final counters and marks the task completed, or failed when errMsg is set
+func (r *HealthRepository) FinishImportTask(task *models.HealthImportTask, errMsg string) error {
+	status := models.TaskStatusCompleted
+	if errMsg != "" {
+		status = models.TaskStatusFailed
+	}
+
+	_, err := r.db.Exec(`
+		UPDATE health_import_tasks
+		SET status = ?, processed_bytes = ?, records_parsed = ?, records_inserted = ?, duplicates = ?,
+			ignored_records = ?, error_message = ?,
+			completed_at = CAST(strftime('%s', 'now') AS INTEGER),
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		WHERE id = ?`,
+		status, task.ProcessedBytes, task.RecordsParsed, task.RecordsInserted, task.Duplicates,
+		task.IgnoredRecords, nullString(errMsg), task.ID)
+	if err != nil {
+		return fmt.Errorf("failed to finish health import task: %w", err)
+	}
+	return nil
+}
+
+const healthImportTaskColumns = `id, status, file_name, total_bytes, processed_bytes, records_parsed,
+	records_inserted, duplicates, ignored_records, error_message, COALESCE(created_by, ''),
+	started_at, completed_at, created_at, updated_at`
+
+func scanHealthImportTask(row rowScanner) (*models.HealthImportTask, error) {
+	task := &models.HealthImportTask{}
+	var errMsg sql.NullString
+	var startedAt, completedAt sql.NullInt64
+	if err := row.Scan(&task.ID, &task.Status, &task.FileName, &task.TotalBytes, &task.ProcessedBytes,
+		&task.RecordsParsed, &task.RecordsInserted, &task.Duplicates, &task.IgnoredRecords, &errMsg,
+		&task.CreatedBy, &startedAt, &completedAt, &task.CreatedAt, &task.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if errMsg.Valid {
+		task.ErrorMessage = &errMsg.String
+	}
+	if startedAt.Valid {
+		task.StartedAt = &startedAt.Int64
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Int64
+	}
+	task.ProgressPercent = task.Progress()
+	return task, nil
+}
+
+// GetImportTask retrieves an import task by ID, or nil if it does not exist
+func (r *HealthRepository) GetImportTask(id int64) (*models.HealthImportTask, error) {
+	task, err := scanHealthImportTask(r.db.QueryRow(
+		"SELECT "+healthImportTaskColumns+" FROM health_import_tasks WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health import task: %w", err)
+	}
+	return task, nil
+}
+
+// ListImportTasks returns the most recent import tasks
+func (r *HealthRepository) ListImportTasks(limit int) ([]*models.HealthImportTask, error) {
+	rows, err := r.db.Query(
+		"SELECT "+healthImportTaskColumns+" FROM health_import_tasks ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list health import tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*models.HealthImportTask{}
+	for rows.Next() {
+		task, err := scanHealthImportTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan health import task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
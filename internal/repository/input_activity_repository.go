@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// InputActivityRepository handles database operations for keyboard and mouse activity
+type InputActivityRepository struct {
+	db *sql.DB
+}
+
+// NewInputActivityRepository creates a new input activity repository
+func NewInputActivityRepository(db *sql.DB) *InputActivityRepository {
+	return &InputActivityRepository{db: db}
+}
+
+// UpsertHours stores hourly activity, replacing records of the same hour and device
+func (r *InputActivityRepository) UpsertHours(hours []models.InputActivityHour) (int, error) {
+	if len(hours) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO input_activity_hourly (
+			hour_start, date, hour, weekday, device, keystrokes, clicks, scrolls, source
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hour_start, device) DO UPDATE SET
+			keystrokes = excluded.keystrokes,
+			clicks = excluded.clicks,
+			scrolls = excluded.scrolls,
+			source = excluded.source,
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, h := range hours {
+		if _, err := stmt.Exec(
+			h.HourStart, h.Date, h.Hour, h.Weekday, h.Device,
+			h.Keystrokes, h.Clicks, h.Scrolls, nullString(h.Source),
+		); err != nil {
+			return 0, fmt.Errorf("failed to store input activity of %s %02d:00: %w", h.Date, h.Hour, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(hours), nil
+}
+
+// inputActivityWhere builds the WHERE clause for a filter whose dates are already resolved
+func inputActivityWhere(filter models.InputActivityFilter) (string, []interface{}) {
+	conditions := []string{"date BETWEEN ? AND ?"}
+	args := []interface{}{filter.From, filter.To}
+
+	if filter.Device != "" {
+		conditions = append(conditions, "device = ?")
+		args = append(args, filter.Device)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetDailyActivity returns the activity per day of the range, ordered by date
+func (r *InputActivityRepository) GetDailyActivity(filter models.InputActivityFilter) ([]models.DailyInputActivity, error) {
+	where, args := inputActivityWhere(filter)
+
+	rows, err := r.db.Query(`
+		SELECT date, COUNT(DISTINCT hour_start), SUM(keystrokes), SUM(clicks), SUM(scrolls)
+		FROM input_activity_hourly`+where+`
+		GROUP BY date
+		ORDER BY date`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily input activity: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.DailyInputActivity{}
+	for rows.Next() {
+		var d models.DailyInputActivity
+		if err := rows.Scan(&d.Date, &d.ActiveHours, &d.Keystrokes, &d.Clicks, &d.Scrolls); err != nil {
+			return nil, fmt.Errorf("failed to scan daily input activity: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	return days, rows.Err()
+}
+
+// GetHourOfDayKeystrokes returns total keystrokes per hour of day (0-23)
+func (r *InputActivityRepository) GetHourOfDayKeystrokes(filter models.InputActivityFilter) ([24]int64, error) {
+	var totals [24]int64
+	where, args := inputActivityWhere(filter)
+
+	rows, err := r.db.Query(`
+		SELECT hour, SUM(keystrokes)
+		FROM input_activity_hourly`+where+`
+		GROUP BY hour`, args...)
+	if err != nil {
+		return totals, fmt.Errorf("failed to query hourly keystrokes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour int
+		var total int64
+		if err := rows.Scan(&hour, &total); err != nil {
+			return totals, fmt.Errorf("failed to scan hourly keystrokes: %w", err)
+		}
+		if hour >= 0 && hour < 24 {
+			totals[hour] = total
+		}
+	}
+
+	return totals, rows.Err()
+}
+
+// GetWeekdayHourTotals returns the 7×24 totals of a metric column, indexed [weekday][hour]
+// metric must be one of keystrokes, clicks or scrolls (validated by the caller).
+func (r *InputActivityRepository) GetWeekdayHourTotals(filter models.InputActivityFilter, metric string) ([7][24]int64, error) {
+	var grid [7][24]int64
+	where, args := inputActivityWhere(filter)
+
+	rows, err := r.db.Query(`
+		SELECT weekday, hour, SUM(`+metric+`)
+		FROM input_activity_hourly`+where+`
+		GROUP BY weekday, hour`, args...)
+	if err != nil {
+		return grid, fmt.Errorf("failed to query input heatmap: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var weekday, hour int
+		var total int64
+		if err := rows.Scan(&weekday, &hour, &total); err != nil {
+			return grid, fmt.Errorf("failed to scan input heatmap: %w", err)
+		}
+		if weekday >= 0 && weekday < 7 && hour >= 0 && hour < 24 {
+			grid[weekday][hour] = total
+		}
+	}
+
+	return grid, rows.Err()
+}
+
+// GetHoursWithWorkOverlap returns the activity hours of the range (devices
+// merged) with the seconds of each hour covered by stays annotated as WORK
+func (r *InputActivityRepository) GetHoursWithWorkOverlap(filter models.InputActivityFilter) ([]models.InputActivityHour, []int64, error) {
+	where, args := inputActivityWhere(filter)
+
+	rows, err := r.db.Query(`
+		SELECT h.hour_start, h.date, h.hour, h.weekday, h.keystrokes, h.clicks, h.scrolls,
+			COALESCE((
+				SELECT SUM(MIN(s.end_time, h.hour_start + 3600) - MAX(s.start_time, h.hour_start))
+				FROM stay_segments s
+				JOIN stay_annotations a ON a.stay_id = s.id
+				WHERE a.label = 'WORK'
+					AND s.start_time < h.hour_start + 3600
+					AND s.end_time > h.hour_start
+			), 0) AS work_s
+		FROM (
+			SELECT hour_start, MIN(date) AS date, MIN(hour) AS hour, MIN(weekday) AS weekday,
+				SUM(keystrokes) AS keystrokes, SUM(clicks) AS clicks, SUM(scrolls) AS scrolls
+			FROM input_activity_hourly`+where+`
+			GROUP BY hour_start
+		) h
+		ORDER BY h.hour_start`, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query input activity at work: %w", err)
+	}
+	defer rows.Close()
+
+	var hours []models.InputActivityHour
+	var workSeconds []int64
+	for rows.Next() {
+		var h models.InputActivityHour
+		var workS int64
+		if err := rows.Scan(&h.HourStart, &h.Date, &h.Hour, &h.Weekday,
+			&h.Keystrokes, &h.Clicks, &h.Scrolls, &workS); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan input activity at work: %w", err)
+		}
+		if workS > 3600 {
+			workS = 3600
+		}
+		hours = append(hours, h)
+		workSeconds = append(workSeconds, workS)
+	}
+
+	return hours, workSeconds, rows.Err()
+}
+
+// GetWorkStayIntervals returns the [start, end) unix intervals of WORK stays overlapping [start, end)
+func (r *InputActivityRepository) GetWorkStayIntervals(start, end int64) ([][2]int64, error) {
+	rows, err := r.db.Query(`
+		SELECT s.start_time, s.end_time
+		FROM stay_segments s
+		JOIN stay_annotations a ON a.stay_id = s.id
+		WHERE a.label = 'WORK' AND s.start_time < ? AND s.end_time > ?
+		ORDER BY s.start_time`, end, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query work stays: %w", err)
+	}
+	defer rows.Close()
+
+	var intervals [][2]int64
+	for rows.Next() {
+		var iv [2]int64
+		if err := rows.Scan(&iv[0], &iv[1]); err != nil {
+			return nil, fmt.Errorf("failed to scan work stay: %w", err)
+		}
+		intervals = append(intervals, iv)
+	}
+
+	return intervals, rows.Err()
+}
+
+// GetLatestDate returns the most recent day with activity, or "" if there is none
+func (r *InputActivityRepository) GetLatestDate() (string, error) {
+	var date sql.NullString
+	if err := r.db.QueryRow("SELECT MAX(date) FROM input_activity_hourly").Scan(&date); err != nil {
+		return "", fmt.Errorf("failed to query latest input activity date: %w", err)
+	}
+	return date.String, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// InsightRepository handles database operations for generated insights
+type InsightRepository struct {
+	db *sql.DB
+}
+
+// NewInsightRepository creates a new insight repository
+func NewInsightRepository(db *sql.DB) *InsightRepository {
+	return &InsightRepository{db: db}
+}
+
+const insightColumns = `id, bucket_type, bucket_key, rule, category, message, value, change_pct, details,
+		algo_version, created_at`
+
+// insightSort lists the newest buckets first, each in catalogue order
+var insightSort = sortSpec{
+	fields:       sortFields("bucket_key", "value", "change_pct"),
+	defaultField: "bucket_key",
+	defaultOrder: "DESC",
+	then:         "position",
+}
+
+func scanInsight(rows *sql.Rows) (models.Insight, error) {
+	var in models.Insight
+	var value, changePct sql.NullFloat64
+	var details sql.NullString
+	if err := rows.Scan(
+		&in.ID, &in.BucketType, &in.BucketKey, &in.Rule, &in.Category, &in.Message,
+		&value, &changePct, &details, &in.AlgoVersion, &in.CreatedAt,
+	); err != nil {
+		return in, err
+	}
+	if value.Valid {
+		in.Value = &value.Float64
+	}
+	if changePct.Valid {
+		in.ChangePct = &changePct.Float64
+	}
+	if details.Valid && details.String != "" {
+		if err := json.Unmarshal([]byte(details.String), &in.Details); err != nil {
+			return in, fmt.Errorf("invalid details of insight %d: %w", in.ID, err)
+		}
+	}
+	return in, nil
+}
+
+// GetInsights retrieves a page of insights matching the filter
+func (r *InsightRepository) GetInsights(filter models.InsightFilter, opts models.QueryOptions) ([]models.Insight, int64, error) {
+	q := newListQuery(insightColumns, "insights").
+		whereIf(filter.BucketType != "", "bucket_type = ?", filter.BucketType).
+		whereIf(filter.BucketKey != "", "bucket_key = ?", filter.BucketKey).
+		whereIf(filter.Category != "", "category = ?", filter.Category).
+		whereIf(filter.Rule != "", "rule = ?", filter.Rule)
+	return queryList(r.db, q, insightSort, opts, "insights", scanInsight)
+}
@@ -0,0 +1,377 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// JourneyRepository handles database operations for flight and train journeys
+type JourneyRepository struct {
+	db *sql.DB
+}
+
+// NewJourneyRepository creates a new journey repository
+func NewJourneyRepository(db *sql.DB) *JourneyRepository {
+	return &JourneyRepository{db: db}
+}
+
+// journeyColumns lists the journey columns scanned by scanJourney
+const journeyColumns = `id, journey_type, number, carrier,
+		departure_time, arrival_time, duration_s,
+		origin_code, origin_name, origin_city, origin_lat, origin_lon,
+		dest_code, dest_name, dest_city, dest_lat, dest_lon,
+		distance_m, booking_class, seat, segment_id, source, notes,
+		created_at, updated_at`
+
+// segmentModes maps journey types to the segment modes they can be linked to
+var segmentModes = map[string][]string{
+	models.JourneyTypeFlight: {"PLANE", "FLIGHT"},
+	models.JourneyTypeTrain:  {"TRAIN"},
+}
+
+// scanJourney scans a row selected with journeyColumns
+func scanJourney(row rowScanner) (models.Journey, error) {
+	var j models.Journey
+	var carrier, originCode, originCity, destCode, destCity, class, seat, notes sql.NullString
+	var arrival, duration, segmentID sql.NullInt64
+	var originLat, originLon, destLat, destLon, distance sql.NullFloat64
+
+	err := row.Scan(
+		&j.ID, &j.JourneyType, &j.Number, &carrier,
+		&j.DepartureTime, &arrival, &duration,
+		&originCode, &j.OriginName, &originCity, &originLat, &originLon,
+		&destCode, &j.DestName, &destCity, &destLat, &destLon,
+		&distance, &class, &seat, &segmentID, &j.Source, &notes,
+		&j.CreatedAt, &j.UpdatedAt,
+	)
+	if err != nil {
+		return j, err
+	}
+
+	j.Carrier = carrier.String
+	j.ArrivalTime, j.DurationS = arrival.Int64, duration.Int64
+	j.OriginCode, j.OriginCity = originCode.String, originCity.String
+	j.OriginLat, j.OriginLon = originLat.Float64, originLon.Float64
+	j.DestCode, j.DestCity = destCode.String, destCity.String
+	j.DestLat, j.DestLon = destLat.Float64, destLon.Float64
+	j.DistanceM = distance.Float64
+	j.BookingClass, j.Seat, j.Notes = class.String, seat.String, notes.String
+	j.SegmentID = segmentID.Int64
+
+	return j, nil
+}
+
+// InsertJourneys inserts journeys, skipping those with the same type, number and departure time
+// Returns the number of inserted and skipped (duplicate) journeys
+func (r *JourneyRepository) InsertJourneys(journeys []models.Journey) (int, int, error) {
+	if len(journeys) == 0 {
+		return 0, 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO journeys (
+			journey_type, number, carrier, departure_time, arrival_time, duration_s,
+			origin_code, origin_name, origin_city, origin_lat, origin_lon,
+			dest_code, dest_name, dest_city, dest_lat, dest_lon,
+			distance_m, booking_class, seat, source, notes
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, j := range journeys {
+		result, err := stmt.Exec(
+			j.JourneyType, j.Number, nullString(j.Carrier), j.DepartureTime, nullInt(j.ArrivalTime), nullInt(j.DurationS),
+			nullString(j.OriginCode), j.OriginName, nullString(j.OriginCity), nullCoord(j.OriginLat, j.OriginLon), nullCoord(j.OriginLon, j.OriginLat),
+			nullString(j.DestCode), j.DestName, nullString(j.DestCity), nullCoord(j.DestLat, j.DestLon), nullCoord(j.DestLon, j.DestLat),
+			nullFloat(j.DistanceM), nullString(j.BookingClass), nullString(j.Seat), j.Source, nullString(j.Notes),
+		)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to insert journey %s at %d: %w", j.Number, j.DepartureTime, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		inserted += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, len(journeys) - inserted, nil
+}
+
+// GetJourneys retrieves journeys with filtering and pagination
+func (r *JourneyRepository) GetJourneys(filter models.JourneyFilter) ([]models.Journey, int64, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.JourneyType != "" {
+		conditions = append(conditions, "journey_type = ?")
+		args = append(args, filter.JourneyType)
+	}
+	if filter.StartTime > 0 {
+		conditions = append(conditions, "departure_time >= ?")
+		args = append(args, filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		conditions = append(conditions, "departure_time <= ?")
+		args = append(args, filter.EndTime)
+	}
+	if filter.Carrier != "" {
+		conditions = append(conditions, "carrier = ?")
+		args = append(args, filter.Carrier)
+	}
+	if filter.City != "" {
+		conditions = append(conditions, "(origin_city = ? OR dest_city = ?)")
+		args = append(args, filter.City, filter.City)
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM journeys"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count journeys: %w", err)
+	}
+
+	// Add pagination
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 100
+	}
+	if filter.PageSize > 1000 {
+		filter.PageSize = 1000
+	}
+
+	query := `SELECT ` + journeyColumns + ` FROM journeys` + whereClause +
+		" ORDER BY departure_time DESC LIMIT ? OFFSET ?"
+	args = append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query journeys: %w", err)
+	}
+	defer rows.Close()
+
+	journeys := []models.Journey{}
+	for rows.Next() {
+		j, err := scanJourney(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan journey: %w", err)
+		}
+		journeys = append(journeys, j)
+	}
+
+	return journeys, total, rows.Err()
+}
+
+// GetJourneyByID retrieves a single journey by ID
+func (r *JourneyRepository) GetJourneyByID(id int64) (*models.Journey, error) {
+	j, err := scanJourney(r.db.QueryRow(`SELECT `+journeyColumns+` FROM journeys WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journey: %w", err)
+	}
+	return &j, nil
+}
+
+// GetJourneyStats aggregates the journeys of one type
+func (r *JourneyRepository) GetJourneyStats(journeyType string, limit int) (*models.JourneyStats, error) {
+	stats := &models.JourneyStats{
+		JourneyType: journeyType,
+		TopRoutes:   []models.JourneyRoute{},
+		TopCarriers: []models.JourneyCount{},
+		ByYear:      []models.JourneyCount{},
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(distance_m), 0), COALESCE(SUM(duration_s), 0),
+			COUNT(segment_id)
+		FROM journeys WHERE journey_type = ?
+	`, journeyType).Scan(&stats.Count, &stats.TotalDistanceM, &stats.TotalDurationS, &stats.LinkedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journey totals: %w", err)
+	}
+
+	// Routes are undirected: A-B and B-A count together
+	rows, err := r.db.Query(`
+		SELECT MIN(origin_name, dest_name) AS a, MAX(origin_name, dest_name) AS b,
+			COUNT(*), COALESCE(AVG(distance_m), 0)
+		FROM journeys WHERE journey_type = ?
+		GROUP BY a, b
+		ORDER BY COUNT(*) DESC, a
+		LIMIT ?
+	`, journeyType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query journey routes: %w", err)
+	}
+	for rows.Next() {
+		var route models.JourneyRoute
+		if err := rows.Scan(&route.Origin, &route.Dest, &route.Count, &route.DistanceM); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan journey route: %w", err)
+		}
+		stats.TopRoutes = append(stats.TopRoutes, route)
+	}
+	rows.Close()
+
+	if stats.TopCarriers, err = r.countJourneys(journeyType, "carrier", "COUNT(*) DESC", limit); err != nil {
+		return nil, err
+	}
+	if stats.ByYear, err = r.countJourneys(journeyType, "strftime('%Y', departure_time, 'unixepoch', 'localtime')", "group_key", 0); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// countJourneys groups journeys of one type by a key expression
+func (r *JourneyRepository) countJourneys(journeyType, keyExpr, orderBy string, limit int) ([]models.JourneyCount, error) {
+	query := fmt.Sprintf(`
+		SELECT %s AS group_key, COUNT(*), COALESCE(SUM(distance_m), 0)
+		FROM journeys
+		WHERE journey_type = ? AND %s IS NOT NULL AND %s != ''
+		GROUP BY group_key
+		ORDER BY %s
+	`, keyExpr, keyExpr, keyExpr, orderBy)
+	args := []interface{}{journeyType}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count journeys: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.JourneyCount{}
+	for rows.Next() {
+		var c models.JourneyCount
+		if err := rows.Scan(&c.Key, &c.Count, &c.DistanceM); err != nil {
+			return nil, fmt.Errorf("failed to scan journey count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// LinkSegments links unlinked journeys to the PLANE / TRAIN segment that
+// overlaps their scheduled time the most
+// Journeys without an arrival time are matched with a window of slack
+// seconds after departure. slack also widens the window on both sides to
+// absorb delays. Returns the number of journeys linked.
+func (r *JourneyRepository) LinkSegments(slack int64) (int64, error) {
+	var linked int64
+	for journeyType, modes := range segmentModes {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(modes)), ", ")
+		query := fmt.Sprintf(`
+			UPDATE journeys
+			SET segment_id = (
+				SELECT id FROM (
+					SELECT s.id,
+						MIN(s.end_time, COALESCE(journeys.arrival_time, journeys.departure_time + ?))
+							- MAX(s.start_time, journeys.departure_time) AS overlap
+					FROM segments s
+					WHERE s.mode IN (%s)
+					  AND s.start_time <= COALESCE(journeys.arrival_time, journeys.departure_time + ?) + ?
+					  AND s.end_time >= journeys.departure_time - ?
+				)
+				ORDER BY overlap DESC
+				LIMIT 1
+			),
+			updated_at = CAST(strftime('%%s', 'now') AS INTEGER)
+			WHERE journey_type = ? AND segment_id IS NULL
+			  AND EXISTS (
+				SELECT 1 FROM segments s
+				WHERE s.mode IN (%s)
+				  AND s.start_time <= COALESCE(journeys.arrival_time, journeys.departure_time + ?) + ?
+				  AND s.end_time >= journeys.departure_time - ?
+			  )
+		`, placeholders, placeholders)
+
+		args := []interface{}{slack}
+		for _, m := range modes {
+			args = append(args, m)
+		}
+		args = append(args, slack, slack, slack, journeyType)
+		for _, m := range modes {
+			args = append(args, m)
+		}
+		args = append(args, slack, slack, slack)
+
+		result, err := r.db.Exec(query, args...)
+		if err != nil {
+			return linked, fmt.Errorf("failed to link %s journeys: %w", strings.ToLower(journeyType), err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return linked, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		linked += affected
+	}
+
+	return linked, nil
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func nullInt(v int64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func nullFloat(v float64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullInt64Ptr returns a pointer to a scanned integer, or nil when it was NULL
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// nullCoord returns v unless both components of the coordinate are zero
+func nullCoord(v, other float64) interface{} {
+	if v == 0 && other == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Bounds of the page size of list queries
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// sortSpec describes how a list query can be sorted
+type sortSpec struct {
+	fields       map[string]string // Sort field -> SQL expression
+	defaultField string
+	defaultOrder string // ASC or DESC
+	then         string // Fixed ORDER BY terms between the sort field and the id tiebreaker
+}
+
+// orderBy returns the ORDER BY clause for the requested sort
+// Rows are finally ordered by id so that pages do not overlap.
+func (s sortSpec) orderBy(opts models.QueryOptions) (string, error) {
+	field := opts.Sort
+	if field == "" {
+		field = s.defaultField
+	}
+	expr, ok := s.fields[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", models.ErrInvalidSort, field)
+	}
+
+	order := s.defaultOrder
+	switch strings.ToLower(opts.Order) {
+	case "asc":
+		order = "ASC"
+	case "desc":
+		order = "DESC"
+	}
+
+	terms := []string{expr + " " + order}
+	if s.then != "" {
+		terms = append(terms, s.then)
+	}
+	terms = append(terms, "id "+order)
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// conditions composes the conditions of a WHERE clause with the arguments of
+// their placeholders
+// It is a value: and returns a copy, so conditions shared by several queries
+// can be extended by each without affecting the others.
+type conditions struct {
+	exprs []string
+	args  []interface{}
+}
+
+// and returns c with condition added, its placeholders bound to args
+func (c conditions) and(condition string, args ...interface{}) conditions {
+	return conditions{
+		exprs: append(c.exprs[:len(c.exprs):len(c.exprs)], condition),
+		args:  append(c.args[:len(c.args):len(c.args)], args...),
+	}
+}
+
+// andIf returns c with condition added only when ok, typically when a filter was given
+func (c conditions) andIf(ok bool, condition string, args ...interface{}) conditions {
+	if !ok {
+		return c
+	}
+	return c.and(condition, args...)
+}
+
+// where returns the WHERE clause of the conditions, or "" when there are none
+func (c conditions) where() string {
+	if len(c.exprs) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.exprs, " AND ")
+}
+
+// listQuery builds a filtered SELECT that can be counted and read page by page
+type listQuery struct {
+	columns   string
+	table     string
+	tableArgs []interface{} // Bound to placeholders in table, e.g. of a grouped subquery
+	cond      conditions
+}
+
+// newListQuery starts a list query selecting columns from table
+func newListQuery(columns, table string) *listQuery {
+	return &listQuery{columns: columns, table: table}
+}
+
+// fromArgs binds the placeholders of the table expression
+func (q *listQuery) fromArgs(args ...interface{}) *listQuery {
+	q.tableArgs = append(q.tableArgs, args...)
+	return q
+}
+
+// queryArgs returns the arguments of the table expression followed by those of the conditions
+func (q *listQuery) queryArgs() []interface{} {
+	return append(append([]interface{}(nil), q.tableArgs...), q.cond.args...)
+}
+
+// where adds a condition whose placeholders are bound to args
+func (q *listQuery) where(condition string, args ...interface{}) *listQuery {
+	q.cond = q.cond.and(condition, args...)
+	return q
+}
+
+// whereIf adds the condition only when ok, typically when a filter was given
+func (q *listQuery) whereIf(ok bool, condition string, args ...interface{}) *listQuery {
+	q.cond = q.cond.andIf(ok, condition, args...)
+	return q
+}
+
+func (q *listQuery) whereClause() string {
+	return q.cond.where()
+}
+
+// count returns the number of rows matching the conditions
+func (q *listQuery) count(db *sql.DB) (int64, error) {
+	var total int64
+	err := db.QueryRow("SELECT COUNT(*) FROM "+q.table+q.whereClause(), q.queryArgs()...).Scan(&total)
+	return total, err
+}
+
+// pageQuery returns the SELECT of one page, sorted per sort and opts, and its arguments
+func (q *listQuery) pageQuery(sort sortSpec, opts models.QueryOptions) (string, []interface{}, error) {
+	orderBy, err := sort.orderBy(opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := "SELECT " + q.columns + " FROM " + q.table + q.whereClause() + orderBy + " LIMIT ? OFFSET ?"
+	args := append(q.queryArgs(), pageLimit(opts.Limit), max(opts.Offset, 0))
+	return query, args, nil
+}
+
+// page returns the rows of one page, sorted per sort and opts
+func (q *listQuery) page(db *sql.DB, sort sortSpec, opts models.QueryOptions) (*sql.Rows, error) {
+	query, args, err := q.pageQuery(sort, opts)
+	if err != nil {
+		return nil, err
+	}
+	return db.Query(query, args...)
+}
+
+// pageLimit bounds a requested page size
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return defaultListLimit
+	}
+	return min(limit, maxListLimit)
+}
+
+// queryList reads one page of a list query and the total number of matching
+// rows, scanning each row with scan
+// what names the rows in error messages.
+func queryList[T any](db *sql.DB, q *listQuery, sort sortSpec, opts models.QueryOptions, what string, scan func(*sql.Rows) (T, error)) ([]T, int64, error) {
+	rows, err := q.page(db, sort, opts)
+	if errors.Is(err, models.ErrInvalidSort) {
+		return nil, 0, err
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	items := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan %s: %w", what, err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating %s: %w", what, err)
+	}
+
+	// A short page ends the result, which makes counting unnecessary
+	offset := max(opts.Offset, 0)
+	if len(items) < pageLimit(opts.Limit) && (len(items) > 0 || offset == 0) {
+		return items, int64(offset + len(items)), nil
+	}
+	total, err := q.count(db)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count %s: %w", what, err)
+	}
+	return items, total, nil
+}
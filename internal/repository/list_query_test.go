@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+func TestConditionsWhere(t *testing.T) {
+	tests := []struct {
+		name      string
+		cond      conditions
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"none", conditions{}, "", nil},
+		{"skipped filters", conditions{}.andIf(false, "a = ?", 1).andIf(false, "b = ?", 2), "", nil},
+		{"one", conditions{}.and("a = ?", 1), " WHERE a = ?", []interface{}{1}},
+		{"filters and fixed", conditions{}.andIf(true, "a >= ?", 1).andIf(false, "b = ?", 2).and("c IS NOT NULL"),
+			" WHERE a >= ? AND c IS NOT NULL", []interface{}{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cond.where(); got != tt.wantWhere {
+				t.Errorf("where() = %q, want %q", got, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(tt.cond.args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", tt.cond.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestConditionsAndCopies(t *testing.T) {
+	base := conditions{}.and("a = ?", 1).and("b = ?", 2)
+	town := base.and("town != ''")
+	village := base.and("village != ?", "")
+	if got := base.where(); got != " WHERE a = ? AND b = ?" {
+		t.Errorf("base changed to %q", got)
+	}
+	if got := town.where(); got != " WHERE a = ? AND b = ? AND town != ''" {
+		t.Errorf("town where() = %q", got)
+	}
+	if got, want := village.args, []interface{}{1, 2, ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("village args = %v, want %v", got, want)
+	}
+	if len(town.args) != 2 {
+		t.Errorf("town args = %v, want the base args only", town.args)
+	}
+}
+
+func TestListQueryEmptyFilters(t *testing.T) {
+	byName := sortSpec{fields: sortFields("name"), defaultField: "name", defaultOrder: "ASC"}
+	q := newListQuery("id", "t").whereIf(false, "a = ?", 1)
+	query, args, err := q.pageQuery(byName, models.QueryOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "SELECT id FROM t ORDER BY name ASC, id ASC LIMIT ? OFFSET ?"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if want := []interface{}{10, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+
+	q = newListQuery("id", "(SELECT id FROM u WHERE x > ?)").fromArgs(5).where("id < ?", 9)
+	query, args, _ = q.pageQuery(byName, models.QueryOptions{Limit: 10, Offset: 20})
+	if want := "SELECT id FROM (SELECT id FROM u WHERE x > ?) WHERE id < ? ORDER BY name ASC, id ASC LIMIT ? OFFSET ?"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	if want := []interface{}{5, 9, 10, 20}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// NotificationRepository handles database operations for notification deliveries
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationColumns = `id, event_id, event_type, title, channel, status, attempts,
+		COALESCE(error, ''), event_time, delivered_at`
+
+// notificationSort lists the latest deliveries first
+var notificationSort = sortSpec{
+	fields:       sortFields("event_time", "delivered_at", "attempts"),
+	defaultField: "event_time",
+	defaultOrder: "DESC",
+}
+
+// CreateDelivery records a delivery and sets its ID
+func (r *NotificationRepository) CreateDelivery(d *models.NotificationDelivery) error {
+	result, err := r.db.Exec(`INSERT INTO notification_deliveries (
+			event_id, event_type, title, channel, status, attempts, error, event_time, delivered_at
+		) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		d.EventID, d.EventType, d.Title, d.Channel, d.Status, d.Attempts, d.Error, d.EventTime, d.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to create notification delivery: %w", err)
+	}
+	if d.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries retrieves a page of deliveries matching the filter, latest first
+func (r *NotificationRepository) GetDeliveries(filter models.NotificationFilter, opts models.QueryOptions) ([]models.NotificationDelivery, int64, error) {
+	q := newListQuery(notificationColumns, "notification_deliveries").
+		whereIf(filter.EventType != "", "event_type = ?", filter.EventType).
+		whereIf(filter.Status != "", "status = ?", filter.Status)
+	return queryList(r.db, q, notificationSort, opts, "notification deliveries", func(rows *sql.Rows) (models.NotificationDelivery, error) {
+		var d models.NotificationDelivery
+		err := rows.Scan(&d.ID, &d.EventID, &d.EventType, &d.Title, &d.Channel, &d.Status, &d.Attempts,
+			&d.Error, &d.EventTime, &d.DeliveredAt)
+		return d, err
+	})
+}
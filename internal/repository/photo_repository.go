@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// PhotoRepository handles database operations for photos and their locations
+type PhotoRepository struct {
+	db *sql.DB
+}
+
+// NewPhotoRepository creates a new photo repository
+func NewPhotoRepository(db *sql.DB) *PhotoRepository {
+	return &PhotoRepository{db: db}
+}
+
+// photoColumns lists the photo columns scanned by scanPhoto
+const photoColumns = `id, source, external_id, file_name, taken_at, lat, lon, altitude, location_source,
+		camera_make, camera_model, width, height, file_size, file_mtime, imported_at`
+
+// photoSort lists photos in the order they were taken
+var photoSort = sortSpec{
+	fields:       sortFields("taken_at", "imported_at", "file_name"),
+	defaultField: "taken_at",
+	defaultOrder: "ASC",
+}
+
+// scanPhoto scans a row selected with photoColumns
+func scanPhoto(row rowScanner) (models.Photo, error) {
+	var p models.Photo
+	var locationSource, cameraMake, cameraModel sql.NullString
+	var lat, lon, altitude sql.NullFloat64
+	var width, height, fileSize, fileMtime sql.NullInt64
+
+	err := row.Scan(
+		&p.ID, &p.Source, &p.ExternalID, &p.FileName, &p.TakenAt, &lat, &lon, &altitude, &locationSource,
+		&cameraMake, &cameraModel, &width, &height, &fileSize, &fileMtime, &p.ImportedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	p.Lat, p.Lon, p.Altitude = lat.Float64, lon.Float64, altitude.Float64
+	p.LocationSource = locationSource.String
+	p.CameraMake, p.CameraModel = cameraMake.String, cameraModel.String
+	p.Width, p.Height = int(width.Int64), int(height.Int64)
+	p.FileSize, p.FileMtime = fileSize.Int64, fileMtime.Int64
+
+	return p, nil
+}
+
+// List retrieves a page of photos matching the filter
+func (r *PhotoRepository) List(filter models.PhotoFilter, opts models.QueryOptions) ([]models.Photo, int64, error) {
+	q := newListQuery(photoColumns, "photos").
+		whereIf(filter.StartTime > 0, "taken_at >= ?", filter.StartTime).
+		whereIf(filter.EndTime > 0, "taken_at <= ?", filter.EndTime).
+		whereIf(filter.Source != "", "source = ?", filter.Source).
+		whereIf(filter.Located != nil && *filter.Located, "location_source IS NOT NULL").
+		whereIf(filter.Located != nil && !*filter.Located, "location_source IS NULL")
+	return queryList(r.db, q, photoSort, opts, "photos", func(rows *sql.Rows) (models.Photo, error) {
+		return scanPhoto(rows)
+	})
+}
+
+// GetByID retrieves a photo by ID; nil if it does not exist
+func (r *PhotoRepository) GetByID(id int64) (*models.Photo, error) {
+	p, err := scanPhoto(r.db.QueryRow(`SELECT `+photoColumns+` FROM photos WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get photo: %w", err)
+	}
+	return &p, nil
+}
+
+// ListTakenBetween retrieves the photos taken in [start, end] in time order
+func (r *PhotoRepository) ListTakenBetween(start, end int64) ([]models.Photo, error) {
+	rows, err := r.db.Query(`SELECT `+photoColumns+` FROM photos
+		WHERE taken_at BETWEEN ? AND ? ORDER BY taken_at, id`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query photos: %w", err)
+	}
+	defer rows.Close()
+
+	photos := []models.Photo{}
+	for rows.Next() {
+		p, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photos = append(photos, p)
+	}
+	return photos, rows.Err()
+}
+
+// GetFileVersions returns the size and modification time of the imported
+// files of a source, keyed by external ID
+func (r *PhotoRepository) GetFileVersions(source string) (map[string][2]int64, error) {
+	rows, err := r.db.Query(`SELECT external_id, COALESCE(file_size, 0), COALESCE(file_mtime, 0)
+		FROM photos WHERE source = ?`, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query photo files: %w", err)
+	}
+	defer rows.Close()
+
+	versions := make(map[string][2]int64)
+	for rows.Next() {
+		var id string
+		var size, mtime int64
+		if err := rows.Scan(&id, &size, &mtime); err != nil {
+			return nil, fmt.Errorf("failed to scan photo file: %w", err)
+		}
+		versions[id] = [2]int64{size, mtime}
+	}
+	return versions, rows.Err()
+}
+
+// Upsert stores photos in one transaction, replacing the metadata of photos
+// imported before from the same source
+func (r *PhotoRepository) Upsert(photos []models.Photo) error {
+	if len(photos) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO photos (
+			source, external_id, file_name, taken_at, lat, lon, altitude, location_source,
+			camera_make, camera_model, width, height, file_size, file_mtime, imported_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CAST(strftime('%s', 'now') AS INTEGER))
+		ON CONFLICT (source, external_id) DO UPDATE SET
+			file_name = excluded.file_name, taken_at = excluded.taken_at,
+			lat = excluded.lat, lon = excluded.lon, altitude = excluded.altitude,
+			location_source = excluded.location_source,
+			camera_make = excluded.camera_make, camera_model = excluded.camera_model,
+			width = excluded.width, height = excluded.height,
+			file_size = excluded.file_size, file_mtime = excluded.file_mtime,
+			imported_at = excluded.imported_at`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, p := range photos {
+		var lat, lon interface{}
+		if p.LocationSource != "" {
+			lat, lon = p.Lat, p.Lon
+		}
+		if _, err := stmt.Exec(p.Source, p.ExternalID, p.FileName, p.TakenAt, lat, lon, nullFloat(p.Altitude),
+			nullString(p.LocationSource), nullString(p.CameraMake), nullString(p.CameraModel),
+			nullInt(int64(p.Width)), nullInt(int64(p.Height)), nullInt(p.FileSize), nullInt(p.FileMtime)); err != nil {
+			return fmt.Errorf("failed to store photo %s: %w", p.ExternalID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListUnlocated retrieves the photos without a location
+func (r *PhotoRepository) ListUnlocated() ([]models.Photo, error) {
+	rows, err := r.db.Query(`SELECT ` + photoColumns + ` FROM photos WHERE location_source IS NULL ORDER BY taken_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unlocated photos: %w", err)
+	}
+	defer rows.Close()
+
+	var photos []models.Photo
+	for rows.Next() {
+		p, err := scanPhoto(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan photo: %w", err)
+		}
+		photos = append(photos, p)
+	}
+	return photos, rows.Err()
+}
+
+// SetTrackLocation places a photo on the track
+func (r *PhotoRepository) SetTrackLocation(id int64, lat, lon, altitude float64) error {
+	_, err := r.db.Exec(`UPDATE photos SET lat = ?, lon = ?, altitude = ?, location_source = ? WHERE id = ?`,
+		lat, lon, nullFloat(altitude), models.PhotoLocationTrack, id)
+	if err != nil {
+		return fmt.Errorf("failed to locate photo: %w", err)
+	}
+	return nil
+}
+
+// TrackFix is a track point used to place a photo
+type TrackFix struct {
+	Time      int64
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+}
+
+// GetTrackFixesAround returns the last non-outlier track point at or before t
+// and the first after it, each nil when none lies within maxGap seconds
+func (r *PhotoRepository) GetTrackFixesAround(t, maxGap int64) (*TrackFix, *TrackFix, error) {
+	fix := func(query string, args ...interface{}) (*TrackFix, error) {
+		var f TrackFix
+		var altitude sql.NullFloat64
+		err := r.db.QueryRow(query, args...).Scan(&f.Time, &f.Latitude, &f.Longitude, &altitude)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query track point: %w", err)
+		}
+		f.Altitude = altitude.Float64
+		return &f, nil
+	}
+
+	before, err := fix(`SELECT dataTime, latitude, longitude, altitude FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ? AND outlier_flag = 0
+		ORDER BY dataTime DESC LIMIT 1`, t-maxGap, t)
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := fix(`SELECT dataTime, latitude, longitude, altitude FROM "一生足迹"
+		WHERE dataTime > ? AND dataTime <= ? AND outlier_flag = 0
+		ORDER BY dataTime LIMIT 1`, t, t+maxGap)
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
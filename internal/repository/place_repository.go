@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// PlaceRepository handles database operations for places clustered from stays
+type PlaceRepository struct {
+	db *sql.DB
+}
+
+// NewPlaceRepository creates a new place repository
+func NewPlaceRepository(db *sql.DB) *PlaceRepository {
+	return &PlaceRepository{db: db}
+}
+
+// placeColumns lists the place columns scanned by scanPlace
+const placeColumns = `id, geohash, name, label, center_lat, center_lon, radius_m,
+		province, city, county, visit_count, total_duration_s, first_visit_ts, last_visit_ts,
+		poi_checked_at, algo_version, created_at, updated_at`
+
+// placeSort lists the most visited places first
+var placeSort = sortSpec{
+	fields:       sortFields("visit_count", "total_duration_s", "first_visit_ts", "last_visit_ts", "name"),
+	defaultField: "visit_count",
+	defaultOrder: "DESC",
+}
+
+// scanPlace scans a row selected with placeColumns
+func scanPlace(row rowScanner) (models.Place, error) {
+	var p models.Place
+	var name, label, province, city, county, algoVersion sql.NullString
+	var radius sql.NullFloat64
+	var firstVisit, lastVisit, poiChecked sql.NullInt64
+
+	err := row.Scan(
+		&p.ID, &p.Geohash, &name, &label, &p.CenterLat, &p.CenterLon, &radius,
+		&province, &city, &county, &p.VisitCount, &p.TotalDurationS, &firstVisit, &lastVisit,
+		&poiChecked, &algoVersion, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+
+	p.Name, p.Label = name.String, label.String
+	p.RadiusM = radius.Float64
+	p.Province, p.City, p.County = province.String, city.String, county.String
+	p.TotalHours = float64(p.TotalDurationS) / 3600
+	p.FirstVisitTS, p.LastVisitTS = firstVisit.Int64, lastVisit.Int64
+	p.POICheckedAt = poiChecked.Int64
+	p.AlgoVersion = algoVersion.String
+
+	return p, nil
+}
+
+// List retrieves a page of places matching the filter
+func (r *PlaceRepository) List(filter models.PlaceFilter, opts models.QueryOptions) ([]models.Place, int64, error) {
+	q := newListQuery(placeColumns, "places").
+		whereIf(filter.Name != "", "name LIKE ?", "%"+filter.Name+"%").
+		whereIf(filter.Label != "", "label = ?", filter.Label).
+		whereIf(filter.City != "", "city = ?", filter.City).
+		whereIf(filter.Named != nil && *filter.Named, "name IS NOT NULL").
+		whereIf(filter.Named != nil && !*filter.Named, "name IS NULL").
+		whereIf(filter.MinVisits > 0, "visit_count >= ?", filter.MinVisits)
+	return queryList(r.db, q, placeSort, opts, "places", func(rows *sql.Rows) (models.Place, error) {
+		return scanPlace(rows)
+	})
+}
+
+// GetByID retrieves a place by ID with its nearby venues; nil if it does not exist
+func (r *PlaceRepository) GetByID(id int64) (*models.Place, error) {
+	p, err := scanPlace(r.db.QueryRow(`SELECT `+placeColumns+` FROM places WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get place: %w", err)
+	}
+
+	if p.POIs, err = r.listPOIs(id); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// listPOIs retrieves the venues near a place, nearest first
+func (r *PlaceRepository) listPOIs(placeID int64) ([]models.PlacePOI, error) {
+	rows, err := r.db.Query(`SELECT rank, name, category, kind, lat, lon, distance_m, provider
+		FROM place_pois WHERE place_id = ? ORDER BY rank`, placeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query place POIs: %w", err)
+	}
+	defer rows.Close()
+
+	var pois []models.PlacePOI
+	for rows.Next() {
+		var v models.PlacePOI
+		var kind sql.NullString
+		if err := rows.Scan(&v.Rank, &v.Name, &v.Category, &kind, &v.Lat, &v.Lon, &v.DistanceM, &v.Provider); err != nil {
+			return nil, fmt.Errorf("failed to scan place POI: %w", err)
+		}
+		v.Kind = kind.String
+		pois = append(pois, v)
+	}
+	return pois, rows.Err()
+}
+
+// UpdateName names a place, or clears its name when name is empty
+// Returns false if the place does not exist.
+func (r *PlaceRepository) UpdateName(id int64, name string) (bool, error) {
+	result, err := r.db.Exec(`UPDATE places
+		SET name = ?, updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		WHERE id = ?`, nullString(name), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update place name: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected > 0, nil
+}
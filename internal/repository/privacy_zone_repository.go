@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// PrivacyZoneRepository handles database operations for privacy zones
+type PrivacyZoneRepository struct {
+	db *sql.DB
+}
+
+// NewPrivacyZoneRepository creates a new privacy zone repository
+func NewPrivacyZoneRepository(db *sql.DB) *PrivacyZoneRepository {
+	return &PrivacyZoneRepository{db: db}
+}
+
+const privacyZoneColumns = `id, name, shape,
+	COALESCE(center_lat, 0), COALESCE(center_lon, 0), COALESCE(radius_m, 0), COALESCE(polygon_json, ''),
+	action, COALESCE(fuzz_m, 0), enabled, created_at, updated_at, deleted_at`
+
+// scanPrivacyZone scans a privacy zone row
+func scanPrivacyZone(scanner interface{ Scan(...interface{}) error }) (*models.PrivacyZone, error) {
+	var z models.PrivacyZone
+	var polygonJSON string
+	var deletedAt sql.NullTime
+	if err := scanner.Scan(&z.ID, &z.Name, &z.Shape, &z.CenterLat, &z.CenterLon, &z.RadiusM, &polygonJSON,
+		&z.Action, &z.FuzzM, &z.Enabled, &z.CreatedAt, &z.UpdatedAt, &deletedAt); err != nil {
+		return nil, err
+	}
+	if polygonJSON != "" {
+		if err := json.Unmarshal([]byte(polygonJSON), &z.Polygon); err != nil {
+			return nil, fmt.Errorf("invalid polygon of privacy zone %d: %w", z.ID, err)
+		}
+	}
+	if deletedAt.Valid {
+		z.DeletedAt = &deletedAt.Time
+	}
+	return &z, nil
+}
+
+// List retrieves privacy zones, including soft-deleted ones when includeDeleted is set
+func (r *PrivacyZoneRepository) List(includeDeleted bool) ([]models.PrivacyZone, error) {
+	query := `SELECT ` + privacyZoneColumns + ` FROM privacy_zones`
+	if !includeDeleted {
+		query += ` WHERE deleted_at IS NULL`
+	}
+	query += ` ORDER BY id`
+
+	return r.query(query)
+}
+
+// ListActive retrieves the enabled privacy zones that are not deleted
+func (r *PrivacyZoneRepository) ListActive() ([]models.PrivacyZone, error) {
+	return r.query(`SELECT ` + privacyZoneColumns + ` FROM privacy_zones WHERE deleted_at IS NULL AND enabled = 1 ORDER BY id`)
+}
+
+func (r *PrivacyZoneRepository) query(query string, args ...interface{}) ([]models.PrivacyZone, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query privacy zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := []models.PrivacyZone{}
+	for rows.Next() {
+		z, err := scanPrivacyZone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan privacy zone: %w", err)
+		}
+		zones = append(zones, *z)
+	}
+
+	return zones, rows.Err()
+}
+
+// GetByID retrieves a privacy zone by ID, including a soft-deleted one
+func (r *PrivacyZoneRepository) GetByID(id int64) (*models.PrivacyZone, error) {
+	z, err := scanPrivacyZone(r.db.QueryRow(`SELECT `+privacyZoneColumns+` FROM privacy_zones WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get privacy zone: %w", err)
+	}
+	return z, nil
+}
+
+// Create creates a new privacy zone
+func (r *PrivacyZoneRepository) Create(z *models.PrivacyZone) (int64, error) {
+	polygonJSON, err := marshalPolygon(z.Polygon)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Exec(`INSERT INTO privacy_zones (
+			name, shape, center_lat, center_lon, radius_m, polygon_json, action, fuzz_m, enabled
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		z.Name, z.Shape, z.CenterLat, z.CenterLon, z.RadiusM, polygonJSON, z.Action, z.FuzzM, z.Enabled)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create privacy zone: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// Update replaces the definition of a privacy zone
+func (r *PrivacyZoneRepository) Update(z *models.PrivacyZone) error {
+	polygonJSON, err := marshalPolygon(z.Polygon)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`UPDATE privacy_zones
+		SET name = ?, shape = ?, center_lat = ?, center_lon = ?, radius_m = ?, polygon_json = ?,
+			action = ?, fuzz_m = ?, enabled = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`,
+		z.Name, z.Shape, z.CenterLat, z.CenterLon, z.RadiusM, polygonJSON, z.Action, z.FuzzM, z.Enabled, z.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update privacy zone: %w", err)
+	}
+	return nil
+}
+
+// SoftDelete marks a privacy zone as deleted
+func (r *PrivacyZoneRepository) SoftDelete(id int64) error {
+	_, err := r.db.Exec(`UPDATE privacy_zones
+		SET deleted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND deleted_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete privacy zone: %w", err)
+	}
+	return nil
+}
+
+// Restore clears the deleted mark of a privacy zone
+func (r *PrivacyZoneRepository) Restore(id int64) error {
+	_, err := r.db.Exec(`UPDATE privacy_zones
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore privacy zone: %w", err)
+	}
+	return nil
+}
+
+// marshalPolygon encodes polygon vertices for polygon_json; nil for circles
+func marshalPolygon(polygon [][2]float64) (interface{}, error) {
+	if len(polygon) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(polygon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode polygon: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// QARepository handles database operations for track point quality review
+type QARepository struct {
+	db *sql.DB
+}
+
+// NewQARepository creates a new QA repository
+func NewQARepository(db *sql.DB) *QARepository {
+	return &QARepository{db: db}
+}
+
+const outlierPointColumns = `id, dataTime, latitude, longitude, COALESCE(speed, 0), COALESCE(accuracy, 0),
+		COALESCE(province, ''), COALESCE(city, ''),
+		COALESCE(outlier_flag, 0), COALESCE(outlier_reason_codes, ''), COALESCE(qa_status, '')`
+
+var outlierPointSort = sortSpec{
+	fields:       sortFields("dataTime", "speed", "accuracy"),
+	defaultField: "dataTime",
+	defaultOrder: "DESC",
+}
+
+func scanOutlierPoint(rows *sql.Rows) (models.OutlierPoint, error) {
+	var p models.OutlierPoint
+	var reasons string
+	err := rows.Scan(&p.ID, &p.DataTime, &p.Latitude, &p.Longitude, &p.Speed, &p.Accuracy,
+		&p.Province, &p.City, &p.OutlierFlag, &reasons, &p.QAStatus)
+	if err != nil {
+		return p, err
+	}
+	p.ReasonCodes = []string{}
+	if reasons != "" {
+		if err := json.Unmarshal([]byte(reasons), &p.ReasonCodes); err != nil {
+			return p, fmt.Errorf("invalid reason codes of point %d: %w", p.ID, err)
+		}
+	}
+	return p, nil
+}
+
+// GetOutliers retrieves a page of flagged or manually reviewed track points
+func (r *QARepository) GetOutliers(filter models.OutlierFilter, opts models.QueryOptions) ([]models.OutlierPoint, int64, error) {
+	q := newListQuery(outlierPointColumns, `"一生足迹"`).
+		whereIf(filter.QAStatus == "", "(outlier_flag = 1 OR qa_status IN (?, ?))",
+			models.QAStatusManualPass, models.QAStatusManualFail).
+		whereIf(filter.QAStatus != "", "qa_status = ?", filter.QAStatus).
+		// Reason codes are stored as a JSON array of strings
+		whereIf(filter.Reason != "", "outlier_reason_codes LIKE ?", `%"`+filter.Reason+`"%`).
+		whereIf(filter.StartTime > 0, "dataTime >= ?", filter.StartTime).
+		whereIf(filter.EndTime > 0, "dataTime <= ?", filter.EndTime)
+	return queryList(r.db, q, outlierPointSort, opts, "outlier points", scanOutlierPoint)
+}
+
+// SetManualStatus records a manual review of track points
+// MANUAL_FAIL flags the points as outliers, MANUAL_PASS clears the flag; the
+// detected reason codes are kept so the review can be revisited.
+func (r *QARepository) SetManualStatus(ids []int64, qaStatus string) (int64, error) {
+	outlierFlag := 0
+	if qaStatus == models.QAStatusManualFail {
+		outlierFlag = 1
+	}
+
+	args := []interface{}{outlierFlag, qaStatus}
+	result, err := r.db.Exec(`UPDATE "一生足迹" SET outlier_flag = ?, qa_status = ?
+		WHERE id IN (`+inPlaceholders(len(ids))+`)`, append(args, int64Args(ids)...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update qa status: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ResetManualStatus drops the manual review of track points
+// The flag and status return to the verdict of the detected reason codes
+// until outlier detection runs again.
+func (r *QARepository) ResetManualStatus(ids []int64) (int64, error) {
+	result, err := r.db.Exec(`UPDATE "一生足迹"
+		SET outlier_flag = CASE WHEN COALESCE(outlier_reason_codes, '') != '' THEN 1 ELSE 0 END,
+			qa_status = CASE WHEN COALESCE(outlier_reason_codes, '') != '' THEN ? ELSE ? END
+		WHERE qa_status IN (?, ?) AND id IN (`+inPlaceholders(len(ids))+`)`,
+		append([]interface{}{models.QAStatusFail, models.QAStatusPass, models.QAStatusManualPass, models.QAStatusManualFail},
+			int64Args(ids)...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset qa status: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// inPlaceholders returns n comma-separated SQL placeholders
+func inPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func int64Args(values []int64) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ReportRepository handles the time-scoped queries behind generated reports
+type ReportRepository struct {
+	db *sql.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *sql.DB) *ReportRepository {
+	return &ReportRepository{db: db}
+}
+
+// GetNewAdminAreas returns the cities ("city") or counties ("county") whose
+// first-ever recorded point falls within the time range
+func (r *ReportRepository) GetNewAdminAreas(level string, startTime, endTime int64) ([]models.NewAdminArea, error) {
+	var columns, where string
+	switch level {
+	case "city":
+		columns = "COALESCE(province, ''), city, ''"
+		where = "city IS NOT NULL AND city != ''"
+	case "county":
+		columns = "COALESCE(province, ''), COALESCE(city, ''), county"
+		where = "county IS NOT NULL AND county != ''"
+	default:
+		return nil, fmt.Errorf("unsupported admin level: %s", level)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, MIN(dataTime) AS first_visit
+		FROM "一生足迹"
+		WHERE %s
+		GROUP BY 1, 2, 3
+		HAVING first_visit BETWEEN ? AND ?
+		ORDER BY first_visit
+	`, columns, where)
+
+	rows, err := r.db.Query(query, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new %s areas: %w", level, err)
+	}
+	defer rows.Close()
+
+	areas := []models.NewAdminArea{}
+	for rows.Next() {
+		var a models.NewAdminArea
+		if err := rows.Scan(&a.Province, &a.City, &a.County, &a.FirstVisit); err != nil {
+			return nil, fmt.Errorf("failed to scan new %s area: %w", level, err)
+		}
+		areas = append(areas, a)
+	}
+
+	return areas, rows.Err()
+}
+
+// GetExtremeEventsBetween returns the most extreme event of each type that
+// happened within the time range (lowest value for SOUTHMOST / WESTMOST,
+// highest otherwise)
+func (r *ReportRepository) GetExtremeEventsBetween(startTime, endTime int64) ([]models.ExtremeEvent, error) {
+	query := `
+		SELECT id, event_type,
+			COALESCE(event_category, ''),
+			point_id, timestamp, value,
+			latitude, longitude,
+			COALESCE(province, ''), COALESCE(city, ''), COALESCE(county, ''),
+			COALESCE(mode, ''), COALESCE(segment_id, 0), COALESCE(rank, 0),
+			COALESCE(algo_version, 'v1'),
+			created_at, updated_at
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (
+				PARTITION BY event_type
+				ORDER BY CASE WHEN event_type IN ('SOUTHMOST', 'WESTMOST') THEN value ELSE -value END
+			) AS pos
+			FROM extreme_events
+			WHERE timestamp BETWEEN ? AND ?
+		)
+		WHERE pos = 1
+		ORDER BY event_type
+	`
+
+	rows, err := r.db.Query(query, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extreme events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.ExtremeEvent{}
+	for rows.Next() {
+		var e models.ExtremeEvent
+		if err := rows.Scan(
+			&e.ID, &e.EventType, &e.EventCategory, &e.PointID, &e.EventTime, &e.EventValue,
+			&e.Latitude, &e.Longitude, &e.Province, &e.City, &e.County,
+			&e.Mode, &e.SegmentID, &e.Rank,
+			&e.AlgoVersion, &e.CreatedAt, &e.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan extreme event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// GetLongestTrip returns the trip with the largest distance starting within
+// the time range, or nil if there is none
+func (r *ReportRepository) GetLongestTrip(startTime, endTime int64) (*models.Trip, error) {
+	query := `SELECT ` + tripColumns + ` FROM trips
+		WHERE start_time BETWEEN ? AND ? AND distance_m IS NOT NULL
+		ORDER BY distance_m DESC
+		LIMIT 1`
+
+	trip, err := scanTrip(r.db.QueryRow(query, startTime, endTime))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query longest trip: %w", err)
+	}
+
+	return &trip, nil
+}
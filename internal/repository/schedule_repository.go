@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ScheduleRepository handles database operations for scheduled analysis runs
+type ScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewScheduleRepository creates a new schedule repository
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+const scheduleRunColumns = `id, schedule, cron, task_type, status, reason, point_count, max_point_id,
+		task_ids, started_at, finished_at`
+
+// scheduleRunSort lists the latest runs first
+var scheduleRunSort = sortSpec{
+	fields:       sortFields("started_at"),
+	defaultField: "started_at",
+	defaultOrder: "DESC",
+}
+
+// scanScheduleRun scans a row selected with scheduleRunColumns
+func scanScheduleRun(row rowScanner) (models.ScheduleRun, error) {
+	var run models.ScheduleRun
+	var reason, taskIDs sql.NullString
+	var finishedAt sql.NullInt64
+	if err := row.Scan(
+		&run.ID, &run.Schedule, &run.Cron, &run.TaskType, &run.Status, &reason,
+		&run.PointCount, &run.MaxPointID, &taskIDs, &run.StartedAt, &finishedAt,
+	); err != nil {
+		return run, err
+	}
+	run.Reason = reason.String
+	run.TaskIDs = []int64{}
+	if taskIDs.Valid && taskIDs.String != "" {
+		if err := json.Unmarshal([]byte(taskIDs.String), &run.TaskIDs); err != nil {
+			return run, fmt.Errorf("invalid task ids of schedule run %d: %w", run.ID, err)
+		}
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Int64
+	}
+	return run, nil
+}
+
+// GetPointWatermark returns the number of track points and the largest
+// point id, which change whenever points are imported or deleted
+func (r *ScheduleRepository) GetPointWatermark() (count, maxID int64, err error) {
+	err = r.db.QueryRow(`SELECT COUNT(*), COALESCE(MAX(id), 0) FROM "一生足迹"`).Scan(&count, &maxID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get point watermark: %w", err)
+	}
+	return count, maxID, nil
+}
+
+// CreateRun records the start of a run and sets its ID
+// Skipped runs are recorded finished.
+func (r *ScheduleRepository) CreateRun(run *models.ScheduleRun) error {
+	taskIDs, err := json.Marshal(run.TaskIDs)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task ids: %w", err)
+	}
+	result, err := r.db.Exec(`INSERT INTO schedule_runs (
+			schedule, cron, task_type, status, reason, point_count, max_point_id,
+			task_ids, started_at, finished_at
+		) VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)`,
+		run.Schedule, run.Cron, run.TaskType, run.Status, run.Reason, run.PointCount, run.MaxPointID,
+		string(taskIDs), run.StartedAt, run.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create schedule run: %w", err)
+	}
+	if run.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// FinishRun records the outcome and analysis tasks of a run
+func (r *ScheduleRepository) FinishRun(run *models.ScheduleRun) error {
+	taskIDs, err := json.Marshal(run.TaskIDs)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task ids: %w", err)
+	}
+	now := time.Now().Unix()
+	_, err = r.db.Exec(`UPDATE schedule_runs
+		SET status = ?, reason = NULLIF(?, ''), task_ids = ?, finished_at = ?
+		WHERE id = ?`,
+		run.Status, run.Reason, string(taskIDs), now, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to finish schedule run %d: %w", run.ID, err)
+	}
+	run.FinishedAt = &now
+	return nil
+}
+
+// GetLastRun returns the latest run of a schedule, only counting runs with
+// the given status unless it is empty, or nil if there is none
+func (r *ScheduleRepository) GetLastRun(schedule, status string) (*models.ScheduleRun, error) {
+	query := "SELECT " + scheduleRunColumns + " FROM schedule_runs WHERE schedule = ?"
+	args := []interface{}{schedule}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	run, err := scanScheduleRun(r.db.QueryRow(query+" ORDER BY started_at DESC, id DESC LIMIT 1", args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last run of schedule %s: %w", schedule, err)
+	}
+	return &run, nil
+}
+
+// GetRuns retrieves a page of runs matching the filter, latest first
+func (r *ScheduleRepository) GetRuns(filter models.ScheduleRunFilter, opts models.QueryOptions) ([]models.ScheduleRun, int64, error) {
+	q := newListQuery(scheduleRunColumns, "schedule_runs").
+		whereIf(filter.Schedule != "", "schedule = ?", filter.Schedule).
+		whereIf(filter.Status != "", "status = ?", filter.Status)
+	return queryList(r.db, q, scheduleRunSort, opts, "schedule runs", func(rows *sql.Rows) (models.ScheduleRun, error) {
+		return scanScheduleRun(rows)
+	})
+}
+
+// FailUnfinishedRuns marks runs still recorded as running failed, with reason
+func (r *ScheduleRepository) FailUnfinishedRuns(reason string) error {
+	_, err := r.db.Exec(`UPDATE schedule_runs SET status = ?, reason = ?, finished_at = ?
+		WHERE status = ?`,
+		models.ScheduleRunFailed, reason, time.Now().Unix(), models.ScheduleRunRunning)
+	if err != nil {
+		return fmt.Errorf("failed to fail unfinished schedule runs: %w", err)
+	}
+	return nil
+}
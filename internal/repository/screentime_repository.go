@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ScreenTimeRepository handles database operations for screen time usage
+type ScreenTimeRepository struct {
+	db *sql.DB
+}
+
+// NewScreenTimeRepository creates a new screen time repository
+func NewScreenTimeRepository(db *sql.DB) *ScreenTimeRepository {
+	return &ScreenTimeRepository{db: db}
+}
+
+// UpsertUsage stores per-app daily usage, replacing records of the same day, device and app
+// Re-importing an export therefore updates days that were still in progress.
+func (r *ScreenTimeRepository) UpsertUsage(usage []models.ScreenTimeUsage) (int, error) {
+	if len(usage) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO screen_time_usage (
+			date, device, platform, app_name, app_id, category,
+			duration_s, launches, notifications, source
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (date, device, app_name) DO UPDATE SET
+			platform = excluded.platform,
+			app_id = COALESCE(excluded.app_id, app_id),
+			category = excluded.category,
+			duration_s = excluded.duration_s,
+			launches = excluded.launches,
+			notifications = excluded.notifications,
+			source = excluded.source,
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, u := range usage {
+		if _, err := stmt.Exec(
+			u.Date, u.Device, nullString(u.Platform), u.AppName, nullString(u.AppID), u.Category,
+			u.DurationS, u.Launches, u.Notifications, nullString(u.Source),
+		); err != nil {
+			return 0, fmt.Errorf("failed to store usage of %s on %s: %w", u.AppName, u.Date, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(usage), nil
+}
+
+// screenTimeWhere builds the WHERE clause for a filter whose dates are already resolved
+func screenTimeWhere(filter models.ScreenTimeFilter) (string, []interface{}) {
+	conditions := []string{"date BETWEEN ? AND ?"}
+	args := []interface{}{filter.From, filter.To}
+
+	if filter.Device != "" {
+		conditions = append(conditions, "device = ?")
+		args = append(args, filter.Device)
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, filter.Category)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetTotals returns the number of days with usage, total seconds and total launches
+func (r *ScreenTimeRepository) GetTotals(filter models.ScreenTimeFilter) (int, int64, int64, error) {
+	where, args := screenTimeWhere(filter)
+
+	var days int
+	var total, launches int64
+	err := r.db.QueryRow(`
+		SELECT COUNT(DISTINCT date), COALESCE(SUM(duration_s), 0), COALESCE(SUM(launches), 0)
+		FROM screen_time_usage`+where, args...).Scan(&days, &total, &launches)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to get screen time totals: %w", err)
+	}
+
+	return days, total, launches, nil
+}
+
+// GetAppUsage returns the most used apps of the range
+func (r *ScreenTimeRepository) GetAppUsage(filter models.ScreenTimeFilter, limit int) ([]models.AppUsage, error) {
+	where, args := screenTimeWhere(filter)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(`
+		SELECT app_name, COALESCE(MAX(app_id), ''), MAX(category),
+			SUM(duration_s), SUM(launches), COUNT(DISTINCT date)
+		FROM screen_time_usage`+where+`
+		GROUP BY app_name
+		ORDER BY SUM(duration_s) DESC, app_name
+		LIMIT ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query app usage: %w", err)
+	}
+	defer rows.Close()
+
+	apps := []models.AppUsage{}
+	for rows.Next() {
+		var a models.AppUsage
+		if err := rows.Scan(&a.AppName, &a.AppID, &a.Category, &a.DurationS, &a.Launches, &a.Days); err != nil {
+			return nil, fmt.Errorf("failed to scan app usage: %w", err)
+		}
+		apps = append(apps, a)
+	}
+
+	return apps, rows.Err()
+}
+
+// GetCategoryUsage returns the usage per category of the range
+func (r *ScreenTimeRepository) GetCategoryUsage(filter models.ScreenTimeFilter) ([]models.CategoryUsage, error) {
+	where, args := screenTimeWhere(filter)
+
+	rows, err := r.db.Query(`
+		SELECT category, SUM(duration_s), COUNT(DISTINCT app_name)
+		FROM screen_time_usage`+where+`
+		GROUP BY category
+		ORDER BY SUM(duration_s) DESC, category`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query category usage: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.CategoryUsage{}
+	for rows.Next() {
+		var c models.CategoryUsage
+		if err := rows.Scan(&c.Category, &c.DurationS, &c.AppCount); err != nil {
+			return nil, fmt.Errorf("failed to scan category usage: %w", err)
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+// GetWeeklyUsage returns usage grouped by Monday-based week and category
+func (r *ScreenTimeRepository) GetWeeklyUsage(filter models.ScreenTimeFilter) ([]models.WeeklyUsage, error) {
+	where, args := screenTimeWhere(filter)
+
+	// date(d, 'weekday 0', '-6 days') is the Monday of d's week
+	rows, err := r.db.Query(`
+		SELECT date(date, 'weekday 0', '-6 days') AS week_start, category, SUM(duration_s)
+		FROM screen_time_usage`+where+`
+		GROUP BY week_start, category
+		ORDER BY week_start`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query weekly usage: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := []models.WeeklyUsage{}
+	for rows.Next() {
+		var weekStart, category string
+		var duration int64
+		if err := rows.Scan(&weekStart, &category, &duration); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly usage: %w", err)
+		}
+
+		if len(weeks) == 0 || weeks[len(weeks)-1].WeekStart != weekStart {
+			weeks = append(weeks, models.WeeklyUsage{WeekStart: weekStart, ByCategory: map[string]int64{}})
+		}
+		w := &weeks[len(weeks)-1]
+		w.TotalDurationS += duration
+		w.ByCategory[category] += duration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Days are counted per week, not per category
+	dayRows, err := r.db.Query(`
+		SELECT date(date, 'weekday 0', '-6 days') AS week_start, COUNT(DISTINCT date)
+		FROM screen_time_usage`+where+`
+		GROUP BY week_start`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count weekly usage days: %w", err)
+	}
+	defer dayRows.Close()
+
+	dayCounts := make(map[string]int)
+	for dayRows.Next() {
+		var weekStart string
+		var days int
+		if err := dayRows.Scan(&weekStart, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly usage days: %w", err)
+		}
+		dayCounts[weekStart] = days
+	}
+
+	for i := range weeks {
+		weeks[i].Days = dayCounts[weeks[i].WeekStart]
+		if weeks[i].Days > 0 {
+			weeks[i].DailyAverageS = weeks[i].TotalDurationS / int64(weeks[i].Days)
+		}
+	}
+
+	return weeks, dayRows.Err()
+}
+
+// GetLatestDate returns the most recent day with usage, or "" if there is none
+func (r *ScreenTimeRepository) GetLatestDate() (string, error) {
+	var date sql.NullString
+	if err := r.db.QueryRow("SELECT MAX(date) FROM screen_time_usage").Scan(&date); err != nil {
+		return "", fmt.Errorf("failed to query latest screen time date: %w", err)
+	}
+	return date.String, nil
+}
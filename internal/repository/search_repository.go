@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// SearchRepository handles full-text queries of the search index
+type SearchRepository struct {
+	db *sql.DB
+}
+
+// NewSearchRepository creates a new search repository
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// The rowid is selected as id for the tiebreaker of sortSpec; rank is the
+// bm25 score, lower for better matches
+const searchColumns = `rowid AS id, kind, subtype, ref_id, title,
+		snippet(search_index, 4, '[', ']', '…', 12), date, start_time, rank`
+
+var searchSort = sortSpec{
+	fields:       map[string]string{"relevance": "rank", "start_time": "start_time"},
+	defaultField: "relevance",
+	defaultOrder: "ASC",
+}
+
+func scanSearchResult(rows *sql.Rows) (models.SearchResult, error) {
+	var r models.SearchResult
+	var rowID int64
+	var date sql.NullString
+	var startTime sql.NullInt64
+	if err := rows.Scan(&rowID, &r.Type, &r.Subtype, &r.ID, &r.Title, &r.Snippet, &date, &startTime, &r.Score); err != nil {
+		return r, err
+	}
+	r.Date, r.StartTime = date.String, startTime.Int64
+	r.Score = -r.Score
+	return r, nil
+}
+
+// ftsQuery turns free text into an FTS5 query matching documents that contain
+// every word, each as a prefix; "" when there are no words
+func ftsQuery(text string) string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+// Search retrieves a page of the documents matching text, optionally of one type
+func (r *SearchRepository) Search(text, kind string, opts models.QueryOptions) ([]models.SearchResult, int64, error) {
+	q := newListQuery(searchColumns, "search_index").
+		where("search_index MATCH ?", ftsQuery(text)).
+		whereIf(kind != "", "kind = ?", kind)
+	return queryList(r.db, q, searchSort, opts, "search results", scanSearchResult)
+}
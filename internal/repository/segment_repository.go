@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -18,54 +19,121 @@ func NewSegmentRepository(db *sql.DB) *SegmentRepository {
 	return &SegmentRepository{db: db}
 }
 
+// segmentTables joins segments (s) with their start (sp) and end (ep) points,
+// which give the segment its coordinates, and its area while the segment has
+// none: segments built before their points were geocoded keep empty areas
+// until geocode_backfill or transport_mode runs again
+const segmentTables = `segments s
+		LEFT JOIN "一生足迹" sp ON sp.id = s.start_point_id
+		LEFT JOIN "一生足迹" ep ON ep.id = s.end_point_id`
+
+// segmentArea selects an area column of a segment, or of its start point
+// while the segment has no area
+func segmentArea(column string) string {
+	return "CASE WHEN s.province IS NULL THEN sp." + column + " ELSE s." + column + " END"
+}
+
+// segmentColumns lists the segment columns scanned by scanSegment
+var segmentColumns = `s.id, s.mode, s.start_point_id, s.end_point_id, s.start_time, s.end_time, s.duration_s,
+		s.distance_m, sp.latitude, sp.longitude, ep.latitude, ep.longitude,
+		s.avg_speed_kmh, s.max_speed_kmh, s.confidence, s.reason_codes,
+		` + segmentArea("province") + `, ` + segmentArea("city") + `, ` + segmentArea("county") + `, s.source,
+		s.algo_version, s.created_at, s.updated_at, s.metadata, s.rail_line`
+
+// scanSegment scans a row selected with segmentColumns from segmentTables
+func scanSegment(row rowScanner) (models.Segment, error) {
+	var s models.Segment
+	var startPointID, endPointID, duration sql.NullInt64
+	var distance, startLat, startLon, endLat, endLon, avgSpeed, maxSpeed, confidence sql.NullFloat64
+	var reasonCodes, province, city, county, source, algoVersion, metadata, railLine sql.NullString
+	var createdAt, updatedAt interface{}
+
+	err := row.Scan(
+		&s.ID, &s.Mode, &startPointID, &endPointID, &s.StartTime, &s.EndTime, &duration,
+		&distance, &startLat, &startLon, &endLat, &endLon,
+		&avgSpeed, &maxSpeed, &confidence, &reasonCodes,
+		&province, &city, &county, &source,
+		&algoVersion, &createdAt, &updatedAt, &metadata, &railLine,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	s.StartPointID, s.EndPointID = startPointID.Int64, endPointID.Int64
+	s.DurationSeconds = duration.Int64
+	s.DistanceMeters = distance.Float64
+	s.StartLat, s.StartLon = startLat.Float64, startLon.Float64
+	s.EndLat, s.EndLon = endLat.Float64, endLon.Float64
+	s.AvgSpeedKmh, s.MaxSpeedKmh = avgSpeed.Float64, maxSpeed.Float64
+	s.Confidence = confidence.Float64
+	s.ReasonCodes = reasonCodes.String
+	s.Province, s.City, s.County = province.String, city.String, county.String
+	s.Source = source.String
+	s.RailLine = railLine.String
+	s.AlgoVersion = algoVersion.String
+	s.CreatedAt = parseDBTime(createdAt)
+	s.UpdatedAt = parseDBTime(updatedAt)
+
+	// Mode probabilities, for segments classified from several signals
+	if metadata.Valid && metadata.String != "" {
+		var meta struct {
+			ModeProbs map[string]float64 `json:"mode_probs"`
+		}
+		if json.Unmarshal([]byte(metadata.String), &meta) == nil {
+			s.ModeProbs = meta.ModeProbs
+		}
+	}
+
+	return s, nil
+}
+
 // GetSegments retrieves segments with filtering and pagination
 func (r *SegmentRepository) GetSegments(filter models.SegmentFilter) ([]models.Segment, int64, error) {
 	// Build query
-	query := `SELECT id, mode, start_point_id, end_point_id, start_time, end_time, duration_seconds,
-		distance_meters, start_lat, start_lon, end_lat, end_lon,
-		avg_speed_kmh, max_speed_kmh, avg_heading, heading_variance,
-		confidence, reason_codes, province, city, county,
-		algo_version, created_at, updated_at
-		FROM segments`
+	query := `SELECT ` + segmentColumns + ` FROM ` + segmentTables
 
 	var conditions []string
 	var args []interface{}
 
 	// Add filters
 	if filter.Mode != "" {
-		conditions = append(conditions, "mode = ?")
+		conditions = append(conditions, "s.mode = ?")
 		args = append(args, filter.Mode)
 	}
 	if filter.StartTime > 0 {
-		conditions = append(conditions, "start_time >= ?")
+		conditions = append(conditions, "s.start_time >= ?")
 		args = append(args, filter.StartTime)
 	}
 	if filter.EndTime > 0 {
-		conditions = append(conditions, "end_time <= ?")
+		conditions = append(conditions, "s.end_time <= ?")
 		args = append(args, filter.EndTime)
 	}
 	if filter.Province != "" {
-		conditions = append(conditions, "province = ?")
+		conditions = append(conditions, segmentArea("province")+" = ?")
 		args = append(args, filter.Province)
 	}
 	if filter.City != "" {
-		conditions = append(conditions, "city = ?")
+		conditions = append(conditions, segmentArea("city")+" = ?")
 		args = append(args, filter.City)
 	}
 	if filter.County != "" {
-		conditions = append(conditions, "county = ?")
+		conditions = append(conditions, segmentArea("county")+" = ?")
 		args = append(args, filter.County)
 	}
+	if filter.Source != "" {
+		conditions = append(conditions, "s.source = ?")
+		args = append(args, filter.Source)
+	}
 	if filter.MinDistance > 0 {
-		conditions = append(conditions, "distance_meters >= ?")
+		conditions = append(conditions, "s.distance_m >= ?")
 		args = append(args, filter.MinDistance)
 	}
 	if filter.MinDuration > 0 {
-		conditions = append(conditions, "duration_seconds >= ?")
+		conditions = append(conditions, "s.duration_s >= ?")
 		args = append(args, filter.MinDuration)
 	}
 	if filter.MinConfidence > 0 {
-		conditions = append(conditions, "confidence >= ?")
+		conditions = append(conditions, "s.confidence >= ?")
 		args = append(args, filter.MinConfidence)
 	}
 
@@ -74,7 +142,7 @@ func (r *SegmentRepository) GetSegments(filter models.SegmentFilter) ([]models.S
 	}
 
 	// Get total count
-	countQuery := "SELECT COUNT(*) FROM segments"
+	countQuery := "SELECT COUNT(*) FROM " + segmentTables
 	if len(conditions) > 0 {
 		countQuery += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -97,7 +165,7 @@ func (r *SegmentRepository) GetSegments(filter models.SegmentFilter) ([]models.S
 	}
 
 	offset := (filter.Page - 1) * filter.PageSize
-	query += " ORDER BY start_time DESC LIMIT ? OFFSET ?"
+	query += " ORDER BY s.start_time DESC LIMIT ? OFFSET ?"
 	args = append(args, filter.PageSize, offset)
 
 	// Execute query
@@ -109,14 +177,7 @@ func (r *SegmentRepository) GetSegments(filter models.SegmentFilter) ([]models.S
 
 	var segments []models.Segment
 	for rows.Next() {
-		var s models.Segment
-		err := rows.Scan(
-			&s.ID, &s.Mode, &s.StartPointID, &s.EndPointID, &s.StartTime, &s.EndTime, &s.DurationSeconds,
-			&s.DistanceMeters, &s.StartLat, &s.StartLon, &s.EndLat, &s.EndLon,
-			&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.AvgHeading, &s.HeadingVariance,
-			&s.Confidence, &s.ReasonCodes, &s.Province, &s.City, &s.County,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanSegment(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan segment: %w", err)
 		}
@@ -128,21 +189,9 @@ func (r *SegmentRepository) GetSegments(filter models.SegmentFilter) ([]models.S
 
 // GetSegmentByID retrieves a single segment by ID
 func (r *SegmentRepository) GetSegmentByID(id int64) (*models.Segment, error) {
-	query := `SELECT id, mode, start_point_id, end_point_id, start_time, end_time, duration_seconds,
-		distance_meters, start_lat, start_lon, end_lat, end_lon,
-		avg_speed_kmh, max_speed_kmh, avg_heading, heading_variance,
-		confidence, reason_codes, province, city, county,
-		algo_version, created_at, updated_at
-		FROM segments WHERE id = ?`
+	query := `SELECT ` + segmentColumns + ` FROM ` + segmentTables + ` WHERE s.id = ?`
 
-	var s models.Segment
-	err := r.db.QueryRow(query, id).Scan(
-		&s.ID, &s.Mode, &s.StartPointID, &s.EndPointID, &s.StartTime, &s.EndTime, &s.DurationSeconds,
-		&s.DistanceMeters, &s.StartLat, &s.StartLon, &s.EndLat, &s.EndLon,
-		&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.AvgHeading, &s.HeadingVariance,
-		&s.Confidence, &s.ReasonCodes, &s.Province, &s.City, &s.County,
-		&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-	)
+	seg, err := scanSegment(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -150,5 +199,62 @@ func (r *SegmentRepository) GetSegmentByID(id int64) (*models.Segment, error) {
 		return nil, fmt.Errorf("failed to get segment: %w", err)
 	}
 
-	return &s, nil
+	return &seg, nil
+}
+
+// CorrectSegmentMode sets the mode of a segment and stores its correction,
+// setting its ID
+func (r *SegmentRepository) CorrectSegmentMode(segmentID int64, c *models.ModeCorrection) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE segments
+		SET mode = ?, confidence = 1, reason_codes = '["USER_CORRECTED"]',
+			updated_at = CAST(strftime('%s', 'now') AS INTEGER)
+		WHERE id = ?`, c.Mode, segmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update segment mode: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO segment_mode_corrections (start_time, end_time, original_mode, mode, avg_speed_kmh, note, created_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?)`,
+		c.StartTime, c.EndTime, c.OriginalMode, c.Mode, c.AvgSpeedKmh, c.Note, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create mode correction: %w", err)
+	}
+	if c.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// GetModeCorrections retrieves the mode corrections, newest first
+func (r *SegmentRepository) GetModeCorrections() ([]models.ModeCorrection, error) {
+	rows, err := r.db.Query(`
+		SELECT id, start_time, end_time, original_mode, mode, avg_speed_kmh, COALESCE(note, ''), created_at
+		FROM segment_mode_corrections
+		ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mode corrections: %w", err)
+	}
+	defer rows.Close()
+
+	corrections := []models.ModeCorrection{}
+	for rows.Next() {
+		var c models.ModeCorrection
+		if err := rows.Scan(&c.ID, &c.StartTime, &c.EndTime, &c.OriginalMode, &c.Mode, &c.AvgSpeedKmh, &c.Note, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan mode correction: %w", err)
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections, rows.Err()
 }
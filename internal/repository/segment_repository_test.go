@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// Segments built before their points were geocoded have no area of their own
+// and are listed by the area of their start point
+func TestGetSegmentsAreaFallback(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "segments.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range []string{
+		`INSERT INTO "一生足迹" (id, dataTime, longitude, latitude, province, city, county)
+			VALUES (1, 1700000000, 125.3, 43.9, '吉林省', '长春市', '朝阳区'),
+			       (2, 1700000600, 125.4, 43.9, '吉林省', '长春市', '朝阳区')`,
+		// Built before the points were geocoded
+		`INSERT INTO segments (id, mode, start_time, end_time, start_point_id, end_point_id)
+			VALUES (1, 'WALK', 1700000000, 1700000600, 1, 2)`,
+		// Built with an area of its own, which wins over the start point's
+		`INSERT INTO segments (id, mode, start_time, end_time, start_point_id, end_point_id, province, city, county)
+			VALUES (2, 'WALK', 1700000000, 1700000600, 1, 2, '北京市', '北京市', '东城区')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewSegmentRepository(db)
+
+	segments, total, err := r.GetSegments(models.SegmentFilter{Province: "吉林省", City: "长春市", County: "朝阳区"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(segments) != 1 || segments[0].ID != 1 {
+		t.Fatalf("GetSegments(吉林省) = %d of %d, want segment 1", len(segments), total)
+	}
+	if s := segments[0]; s.Province != "吉林省" || s.City != "长春市" || s.County != "朝阳区" {
+		t.Errorf("segment 1 area = %s/%s/%s, want its start point's", s.Province, s.City, s.County)
+	}
+
+	segments, total, err = r.GetSegments(models.SegmentFilter{Province: "北京市"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(segments) != 1 || segments[0].ID != 2 || segments[0].County != "东城区" {
+		t.Errorf("GetSegments(北京市) = %+v, want segment 2", segments)
+	}
+}
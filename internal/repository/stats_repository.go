@@ -2,8 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/models"
 )
@@ -18,6 +21,16 @@ func NewStatsRepository(db *sql.DB) *StatsRepository {
 	return &StatsRepository{db: db}
 }
 
+// footprintCountyLimit is the number of counties GetFootprintStatistics lists
+const footprintCountyLimit = 100
+
+// pointTimeConditions returns the conditions bounding dataTime to a time range, 0 leaving a side open
+func pointTimeConditions(startTime, endTime int64) conditions {
+	return conditions{}.
+		andIf(startTime > 0, "dataTime >= ?", startTime).
+		andIf(endTime > 0, "dataTime <= ?", endTime)
+}
+
 // GetFootprintStatistics retrieves footprint statistics for a time range
 func (r *StatsRepository) GetFootprintStatistics(startTime, endTime int64) (*models.FootprintStatistics, error) {
 	stats := &models.FootprintStatistics{
@@ -25,87 +38,89 @@ func (r *StatsRepository) GetFootprintStatistics(startTime, endTime int64) (*mod
 		EndTime:   endTime,
 	}
 
-	// Build WHERE clause
-	var conditions []string
-	var args []interface{}
-
-	if startTime > 0 {
-		conditions = append(conditions, "dataTime >= ?")
-		args = append(args, startTime)
-	}
-	if endTime > 0 {
-		conditions = append(conditions, "dataTime <= ?")
-		args = append(args, endTime)
-	}
-
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = " WHERE " + strings.Join(conditions, " AND ")
-	}
+	inRange := pointTimeConditions(startTime, endTime)
 
 	// Get total points
-	query := `SELECT COUNT(*) FROM "一生足迹"` + whereClause
-	err := r.db.QueryRow(query, args...).Scan(&stats.TotalPoints)
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM "一生足迹"`+inRange.where(), inRange.args...).Scan(&stats.TotalPoints)
 	if err != nil {
 		return nil, fmt.Errorf("failed to count total points: %w", err)
 	}
 
-	// Get province count and list
-	query = `SELECT COUNT(DISTINCT province), GROUP_CONCAT(DISTINCT province)
-		FROM "一生足迹"` + whereClause + ` AND province IS NOT NULL AND province != ''`
-	var provinceList sql.NullString
-	err = r.db.QueryRow(query, args...).Scan(&stats.ProvinceCount, &provinceList)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get province stats: %w", err)
-	}
-	if provinceList.Valid {
-		stats.Provinces = strings.Split(provinceList.String, ",")
+	// Provinces and cities are few enough to list in full
+	all := models.QueryOptions{Limit: maxListLimit}
+	provinces, provinceCount, err := r.GetVisitedAreas("province", startTime, endTime, all)
+	if err != nil {
+		return nil, err
 	}
+	stats.Provinces, stats.ProvinceCount = provinces, int(provinceCount)
 
-	// Get city count and list
-	query = `SELECT COUNT(DISTINCT city), GROUP_CONCAT(DISTINCT city)
-		FROM "一生足迹"` + whereClause + ` AND city IS NOT NULL AND city != ''`
-	var cityList sql.NullString
-	err = r.db.QueryRow(query, args...).Scan(&stats.CityCount, &cityList)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get city stats: %w", err)
-	}
-	if cityList.Valid {
-		stats.Cities = strings.Split(cityList.String, ",")
+	cities, cityCount, err := r.GetVisitedAreas("city", startTime, endTime, all)
+	if err != nil {
+		return nil, err
 	}
+	stats.Cities, stats.CityCount = cities, int(cityCount)
 
-	// Get county count and list
-	query = `SELECT COUNT(DISTINCT county), GROUP_CONCAT(DISTINCT county)
-		FROM "一生足迹"` + whereClause + ` AND county IS NOT NULL AND county != ''`
-	var countyList sql.NullString
-	err = r.db.QueryRow(query, args...).Scan(&stats.CountyCount, &countyList)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get county stats: %w", err)
-	}
-	if countyList.Valid {
-		stats.Counties = strings.Split(countyList.String, ",")
+	counties, countyCount, err := r.GetVisitedAreas("county", startTime, endTime, models.QueryOptions{Limit: footprintCountyLimit})
+	if err != nil {
+		return nil, err
 	}
+	stats.Counties, stats.CountyCount = counties, int(countyCount)
 
-	// Get town count
-	query = `SELECT COUNT(DISTINCT town) FROM "一生足迹"` + whereClause + ` AND town IS NOT NULL AND town != ''`
-	err = r.db.QueryRow(query, args...).Scan(&stats.TownCount)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get town count: %w", err)
+	// Get town and village counts
+	for _, c := range []struct {
+		column string
+		count  *int
+	}{{"town", &stats.TownCount}, {"village", &stats.VillageCount}} {
+		cond := inRange.and(c.column + " IS NOT NULL").and(c.column + " != ''")
+		query := fmt.Sprintf(`SELECT COUNT(DISTINCT %s) FROM "一生足迹"`, c.column) + cond.where()
+		if err := r.db.QueryRow(query, cond.args...).Scan(c.count); err != nil {
+			return nil, fmt.Errorf("failed to get %s count: %w", c.column, err)
+		}
 	}
 
-	// Get village count
-	query = `SELECT COUNT(DISTINCT village) FROM "一生足迹"` + whereClause + ` AND village IS NOT NULL AND village != ''`
-	err = r.db.QueryRow(query, args...).Scan(&stats.VillageCount)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get village count: %w", err)
+	return stats, nil
+}
+
+// visitedAreaColumns are the parent and name columns of each area level
+var visitedAreaColumns = map[string]string{
+	"province": "'' AS province, '' AS city, province AS name",
+	"city":     "COALESCE(province, '') AS province, '' AS city, city AS name",
+	"county":   "COALESCE(province, '') AS province, COALESCE(city, '') AS city, county AS name",
+}
+
+// visitedAreaSort orders areas by point count by default; an area's id is that of its first point
+var visitedAreaSort = sortSpec{
+	fields:       sortFields("name", "point_count", "first_visit", "last_visit"),
+	defaultField: "point_count",
+	defaultOrder: "DESC",
+}
+
+func scanAreaVisit(rows *sql.Rows) (models.AreaVisit, error) {
+	var a models.AreaVisit
+	err := rows.Scan(&a.Province, &a.City, &a.Name, &a.PointCount, &a.FirstVisit, &a.LastVisit)
+	return a, err
+}
+
+// GetVisitedAreas retrieves a page of the provinces, cities or counties
+// visited within a time range, with their point counts and first and last visits
+func (r *StatsRepository) GetVisitedAreas(level string, startTime, endTime int64, opts models.QueryOptions) ([]models.AreaVisit, int64, error) {
+	columns, ok := visitedAreaColumns[level]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported admin level: %s", level)
 	}
 
-	return stats, nil
+	cond := pointTimeConditions(startTime, endTime).and(level + " IS NOT NULL").and(level + " != ''")
+	areas := fmt.Sprintf(`(SELECT %s, MIN(id) AS id,
+			COUNT(*) AS point_count, MIN(dataTime) AS first_visit, MAX(dataTime) AS last_visit
+		FROM "一生足迹"%s
+		GROUP BY 1, 2, 3)`, columns, cond.where())
+
+	q := newListQuery("province, city, name, point_count, first_visit, last_visit", areas).fromArgs(cond.args...)
+	return queryList(r.db, q, visitedAreaSort, opts, level+" visits", scanAreaVisit)
 }
 
-// GetTimeDistribution retrieves time distribution statistics
-func (r *StatsRepository) GetTimeDistribution(startTime, endTime int64) ([]models.TimeDistribution, error) {
-	query := `SELECT
+// timeDistributionQuery counts the points of a time range by hour of day
+const timeDistributionQuery = `SELECT
 		CAST(strftime('%H', datetime(dataTime, 'unixepoch')) AS INTEGER) as hour,
 		COUNT(*) as count
 		FROM "一生足迹"
@@ -113,7 +128,9 @@ func (r *StatsRepository) GetTimeDistribution(startTime, endTime int64) ([]model
 		GROUP BY hour
 		ORDER BY hour`
 
-	rows, err := r.db.Query(query, startTime, endTime)
+// GetTimeDistribution retrieves time distribution statistics
+func (r *StatsRepository) GetTimeDistribution(startTime, endTime int64) ([]models.TimeDistribution, error) {
+	rows, err := r.db.Query(timeDistributionQuery, startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query time distribution: %w", err)
 	}
@@ -131,19 +148,27 @@ func (r *StatsRepository) GetTimeDistribution(startTime, endTime int64) ([]model
 	return distribution, nil
 }
 
+// effectiveSpeed is the speed of a track point, falling back to computed_speed
+// where the device reported none (same as analysis.EffectiveSpeedExpr)
+const effectiveSpeed = "COALESCE(NULLIF(speed, 0), computed_speed)"
+
 // GetSpeedDistribution retrieves speed distribution statistics
 func (r *StatsRepository) GetSpeedDistribution(startTime, endTime int64) ([]models.SpeedDistribution, error) {
 	query := `SELECT
 		CASE
-			WHEN speed < 10 THEN '0-10'
-			WHEN speed < 30 THEN '10-30'
-			WHEN speed < 60 THEN '30-60'
-			WHEN speed < 120 THEN '60-120'
+			WHEN effective_speed < 10 THEN '0-10'
+			WHEN effective_speed < 30 THEN '10-30'
+			WHEN effective_speed < 60 THEN '30-60'
+			WHEN effective_speed < 120 THEN '60-120'
 			ELSE '120+'
 		END as speed_range,
 		COUNT(*) as count
-		FROM "一生足迹"
-		WHERE dataTime >= ? AND dataTime <= ? AND speed > 0
+		FROM (
+			SELECT ` + effectiveSpeed + ` AS effective_speed
+			FROM "一生足迹"
+			WHERE dataTime >= ? AND dataTime <= ?
+		)
+		WHERE effective_speed > 0
 		GROUP BY speed_range
 		ORDER BY
 			CASE speed_range
@@ -172,152 +197,129 @@ func (r *StatsRepository) GetSpeedDistribution(startTime, endTime int64) ([]mode
 	return distribution, nil
 }
 
-// GetFootprintRankings retrieves footprint statistics with rankings
-func (r *StatsRepository) GetFootprintRankings(filter models.StatsFilter) ([]models.FootprintStatistics, error) {
-	// Build query
-	query := `SELECT id, stat_type, stat_key, time_range,
-		province, city, county, town,
-		point_count, visit_count, total_distance_meters, total_duration_seconds,
-		first_visit_time, last_visit_time,
-		rank_by_points, rank_by_visits, rank_by_duration,
-		algo_version, created_at, updated_at
-		FROM footprint_statistics`
-
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if filter.StatType != "" {
-		conditions = append(conditions, "stat_type = ?")
-		args = append(args, filter.StatType)
-	}
-	if filter.TimeRange != "" {
-		conditions = append(conditions, "time_range = ?")
-		args = append(args, filter.TimeRange)
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	// Order by
-	orderBy := "point_count DESC"
-	if filter.OrderBy == "visits" {
-		orderBy = "visit_count DESC"
-	} else if filter.OrderBy == "duration" {
-		orderBy = "total_duration_seconds DESC"
-	} else if filter.OrderBy == "distance" {
-		orderBy = "total_distance_meters DESC"
-	}
-	query += " ORDER BY " + orderBy
-
-	// Limit
-	limit := 100
-	if filter.Limit > 0 && filter.Limit <= 1000 {
-		limit = filter.Limit
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
-
-	// Execute query
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query footprint rankings: %w", err)
-	}
-	defer rows.Close()
-
-	var stats []models.FootprintStatistics
-	for rows.Next() {
-		var s models.FootprintStatistics
-		err := rows.Scan(
-			&s.ID, &s.StatType, &s.StatKey, &s.TimeRange,
-			&s.Province, &s.City, &s.County, &s.Town,
-			&s.PointCount, &s.VisitCount, &s.TotalDistanceMeters, &s.TotalDurationSeconds,
-			&s.FirstVisitTime, &s.LastVisitTime,
-			&s.RankByPoints, &s.RankByVisits, &s.RankByDuration,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan footprint statistics: %w", err)
-		}
-		stats = append(stats, s)
+// sortFields maps each of names, which are both response fields and columns, to itself
+func sortFields(names ...string) map[string]string {
+	fields := make(map[string]string, len(names)+1)
+	fields["id"] = "id"
+	for _, name := range names {
+		fields[name] = name
 	}
-
-	return stats, nil
+	return fields
 }
 
-// GetStayRankings retrieves stay statistics with rankings
-func (r *StatsRepository) GetStayRankings(filter models.StatsFilter) ([]models.StayStatistics, error) {
-	// Build query
-	query := `SELECT id, stat_type, stat_key, time_range,
-		province, city, county,
-		stay_count, total_duration_seconds, avg_duration_seconds, max_duration_seconds,
-		stay_category, rank_by_count, rank_by_duration,
-		algo_version, created_at, updated_at
-		FROM stay_statistics`
-
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if filter.StatType != "" {
-		conditions = append(conditions, "stat_type = ?")
-		args = append(args, filter.StatType)
-	}
-	if filter.TimeRange != "" {
-		conditions = append(conditions, "time_range = ?")
-		args = append(args, filter.TimeRange)
-	}
+// withDefault returns a copy of s sorting by field in order when the request does not say
+func (s sortSpec) withDefault(field, order string) sortSpec {
+	s.defaultField, s.defaultOrder = field, order
+	return s
+}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
+// footprintRankingColumns reads footprint_statistics under the response's field names
+// The table keeps no area columns: a row of an area level fills the field of
+// its own level from stat_key.
+const footprintRankingColumns = `id, stat_type, stat_key, time_range,
+		CASE stat_type WHEN 'PROVINCE' THEN stat_key ELSE '' END,
+		CASE stat_type WHEN 'CITY' THEN stat_key ELSE '' END,
+		CASE stat_type WHEN 'COUNTY' THEN stat_key ELSE '' END,
+		CASE stat_type WHEN 'TOWN' THEN stat_key ELSE '' END,
+		point_count, visit_count, total_distance_m, total_duration_s,
+		COALESCE(first_visit, 0), COALESCE(last_visit, 0),
+		COALESCE(rank_by_points, 0), COALESCE(rank_by_visits, 0), COALESCE(rank_by_duration, 0),
+		COALESCE(prev_rank_by_points, 0), COALESCE(prev_rank_by_visits, 0), COALESCE(prev_rank_by_duration, 0),
+		prev_rank_by_points - rank_by_points,
+		created_at, updated_at`
+
+// footprintRankingSort also accepts the short names of the former orderBy parameter
+var footprintRankingSort = sortSpec{
+	fields: func() map[string]string {
+		f := sortFields("stat_key", "point_count", "visit_count", "rank_by_points", "rank_by_visits", "rank_by_duration")
+		f["total_distance_meters"], f["total_duration_seconds"] = "total_distance_m", "total_duration_s"
+		f["first_visit_time"], f["last_visit_time"] = "first_visit", "last_visit"
+		f["rank_movement"] = "prev_rank_by_points - rank_by_points"
+		f["points"], f["visits"] = "point_count", "visit_count"
+		f["duration"], f["distance"] = "total_duration_s", "total_distance_m"
+		f["rank"] = "rank_by_points"
+		return f
+	}(),
+	defaultField: "point_count",
+	defaultOrder: "DESC",
+}
 
-	// Order by
-	orderBy := "stay_count DESC"
-	if filter.OrderBy == "duration" {
-		orderBy = "total_duration_seconds DESC"
-	}
-	query += " ORDER BY " + orderBy
+func scanFootprintRanking(rows *sql.Rows) (models.FootprintStatistics, error) {
+	var s models.FootprintStatistics
+	err := rows.Scan(
+		&s.ID, &s.StatType, &s.StatKey, &s.TimeRange,
+		&s.Province, &s.City, &s.County, &s.Town,
+		&s.PointCount, &s.VisitCount, &s.TotalDistanceMeters, &s.TotalDurationSeconds,
+		&s.FirstVisitTime, &s.LastVisitTime,
+		&s.RankByPoints, &s.RankByVisits, &s.RankByDuration,
+		&s.PrevRankByPoints, &s.PrevRankByVisits, &s.PrevRankByDuration,
+		&s.RankMovement,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	return s, err
+}
 
-	// Limit
-	limit := 100
-	if filter.Limit > 0 && filter.Limit <= 1000 {
-		limit = filter.Limit
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
+// GetFootprintRankings retrieves a page of footprint statistics with rankings
+func (r *StatsRepository) GetFootprintRankings(filter models.StatsFilter, opts models.QueryOptions) ([]models.FootprintStatistics, int64, error) {
+	q := newListQuery(footprintRankingColumns, "footprint_statistics").
+		whereIf(filter.StatType != "", "stat_type = ?", filter.StatType).
+		whereIf(filter.TimeRange != "", "time_range = ?", filter.TimeRange)
+	return queryList(r.db, q, footprintRankingSort, opts, "footprint rankings", scanFootprintRanking)
+}
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query stay rankings: %w", err)
-	}
-	defer rows.Close()
+// stayRankingColumns reads stay_statistics under the response's field names
+// As with footprintRankingColumns, the area fields come from stat_key.
+const stayRankingColumns = `id, stat_type, stat_key, time_range,
+		CASE stat_type WHEN 'PROVINCE' THEN stat_key ELSE '' END,
+		CASE stat_type WHEN 'CITY' THEN stat_key ELSE '' END,
+		CASE stat_type WHEN 'COUNTY' THEN stat_key ELSE '' END,
+		stay_count, total_duration_s, avg_duration_s, max_duration_s,
+		CASE stat_type WHEN 'ACTIVITY_TYPE' THEN stat_key ELSE '' END,
+		COALESCE(rank_by_count, 0), COALESCE(rank_by_duration, 0),
+		COALESCE(prev_rank_by_count, 0), COALESCE(prev_rank_by_duration, 0),
+		prev_rank_by_count - rank_by_count,
+		created_at, updated_at`
+
+// stayRankingSort also accepts the short names of the former orderBy parameter
+var stayRankingSort = sortSpec{
+	fields: func() map[string]string {
+		f := sortFields("stat_key", "stay_count", "rank_by_count", "rank_by_duration")
+		f["total_duration_seconds"], f["avg_duration_seconds"] = "total_duration_s", "avg_duration_s"
+		f["max_duration_seconds"] = "max_duration_s"
+		f["rank_movement"] = "prev_rank_by_count - rank_by_count"
+		f["count"], f["duration"] = "stay_count", "total_duration_s"
+		f["rank"] = "rank_by_count"
+		return f
+	}(),
+	defaultField: "stay_count",
+	defaultOrder: "DESC",
+}
 
-	var stats []models.StayStatistics
-	for rows.Next() {
-		var s models.StayStatistics
-		err := rows.Scan(
-			&s.ID, &s.StatType, &s.StatKey, &s.TimeRange,
-			&s.Province, &s.City, &s.County,
-			&s.StayCount, &s.TotalDurationSeconds, &s.AvgDurationSeconds, &s.MaxDurationSeconds,
-			&s.StayCategory, &s.RankByCount, &s.RankByDuration,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan stay statistics: %w", err)
-		}
-		stats = append(stats, s)
-	}
+func scanStayRanking(rows *sql.Rows) (models.StayStatistics, error) {
+	var s models.StayStatistics
+	err := rows.Scan(
+		&s.ID, &s.StatType, &s.StatKey, &s.TimeRange,
+		&s.Province, &s.City, &s.County,
+		&s.StayCount, &s.TotalDurationSeconds, &s.AvgDurationSeconds, &s.MaxDurationSeconds,
+		&s.StayCategory,
+		&s.RankByCount, &s.RankByDuration,
+		&s.PrevRankByCount, &s.PrevRankByDuration,
+		&s.RankMovement,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	return s, err
+}
 
-	return stats, nil
+// GetStayRankings retrieves a page of stay statistics with rankings
+func (r *StatsRepository) GetStayRankings(filter models.StatsFilter, opts models.QueryOptions) ([]models.StayStatistics, int64, error) {
+	q := newListQuery(stayRankingColumns, "stay_statistics").
+		whereIf(filter.StatType != "", "stat_type = ?", filter.StatType).
+		whereIf(filter.TimeRange != "", "time_range = ?", filter.TimeRange)
+	return queryList(r.db, q, stayRankingSort, opts, "stay rankings", scanStayRanking)
 }
 
-// GetExtremeEvents retrieves extreme events
-func (r *StatsRepository) GetExtremeEvents(eventType, eventCategory string, limit int) ([]models.ExtremeEvent, error) {
-	// Build query - use actual column names from database
-	query := `SELECT id, event_type,
+// extremeEventColumns uses the actual column names from the database
+const extremeEventColumns = `id, event_type,
 		COALESCE(event_category, '') as event_category,
 		point_id,
 		timestamp as event_time,
@@ -330,181 +332,180 @@ func (r *StatsRepository) GetExtremeEvents(eventType, eventCategory string, limi
 		COALESCE(segment_id, 0) as segment_id,
 		COALESCE(rank, 0) as rank,
 		COALESCE(algo_version, 'v1') as algo_version,
-		created_at, updated_at
-		FROM extreme_events`
-
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if eventType != "" {
-		conditions = append(conditions, "event_type = ?")
-		args = append(args, eventType)
-	}
-	if eventCategory != "" {
-		conditions = append(conditions, "event_category = ?")
-		args = append(args, eventCategory)
-	}
+		created_at, updated_at`
+
+// extremeEventSort orders by rank (or value if rank is not set) by default
+var extremeEventSort = sortSpec{
+	fields: map[string]string{
+		"id":          "id",
+		"rank":        "COALESCE(rank, 999999)",
+		"event_value": "value",
+		"event_time":  "timestamp",
+	},
+	defaultField: "rank",
+	defaultOrder: "ASC",
+	then:         "value DESC",
+}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
+func scanExtremeEvent(rows *sql.Rows) (models.ExtremeEvent, error) {
+	var e models.ExtremeEvent
+	err := rows.Scan(
+		&e.ID, &e.EventType, &e.EventCategory, &e.PointID, &e.EventTime, &e.EventValue,
+		&e.Latitude, &e.Longitude, &e.Province, &e.City, &e.County,
+		&e.Mode, &e.SegmentID, &e.Rank,
+		&e.AlgoVersion, &e.CreatedAt, &e.UpdatedAt,
+	)
+	return e, err
+}
 
-	// Order by rank (or value if rank is not set)
-	query += " ORDER BY COALESCE(rank, 999999) ASC, value DESC"
+// GetExtremeEvents retrieves a page of extreme events
+func (r *StatsRepository) GetExtremeEvents(eventType, eventCategory string, opts models.QueryOptions) ([]models.ExtremeEvent, int64, error) {
+	q := newListQuery(extremeEventColumns, "extreme_events").
+		whereIf(eventType != "", "event_type = ?", eventType).
+		whereIf(eventCategory != "", "event_category = ?", eventCategory)
+	return queryList(r.db, q, extremeEventSort, opts, "extreme events", scanExtremeEvent)
+}
 
-	// Limit
-	if limit <= 0 || limit > 100 {
-		limit = 100
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
+const adminCrossingColumns = `id, crossing_ts, COALESCE(from_ts, 0), COALESCE(from_country, ''),
+		from_province, from_city, from_county, from_town,
+		COALESCE(to_country, ''), to_province, to_city, to_county, to_town, crossing_type,
+		latitude, longitude, distance_from_prev_m, algo_version, created_at`
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query extreme events: %w", err)
-	}
-	defer rows.Close()
+var adminCrossingSort = sortSpec{
+	fields:       sortFields("crossing_ts", "distance_from_prev_m"),
+	defaultField: "crossing_ts",
+	defaultOrder: "DESC",
+}
 
-	var events []models.ExtremeEvent
-	for rows.Next() {
-		var e models.ExtremeEvent
-		err := rows.Scan(
-			&e.ID, &e.EventType, &e.EventCategory, &e.PointID, &e.EventTime, &e.EventValue,
-			&e.Latitude, &e.Longitude, &e.Province, &e.City, &e.County,
-			&e.Mode, &e.SegmentID, &e.Rank,
-			&e.AlgoVersion, &e.CreatedAt, &e.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan extreme event: %w", err)
-		}
-		events = append(events, e)
-	}
+func scanAdminCrossing(rows *sql.Rows) (models.AdminCrossing, error) {
+	var c models.AdminCrossing
+	err := rows.Scan(
+		&c.ID, &c.CrossingTS, &c.FromTS, &c.FromCountry,
+		&c.FromProvince, &c.FromCity, &c.FromCounty, &c.FromTown,
+		&c.ToCountry, &c.ToProvince, &c.ToCity, &c.ToCounty, &c.ToTown, &c.CrossingType,
+		&c.Latitude, &c.Longitude, &c.DistanceFromPrevM, &c.AlgoVersion, &c.CreatedAt,
+	)
+	return c, err
+}
 
-	return events, nil
+// GetAdminCrossings retrieves a page of administrative boundary crossing events
+func (r *StatsRepository) GetAdminCrossings(crossingType, fromRegion, toRegion string, startTime, endTime int64, opts models.QueryOptions) ([]models.AdminCrossing, int64, error) {
+	q := newListQuery(adminCrossingColumns, "admin_crossings").
+		whereIf(crossingType != "", "crossing_type = ?", crossingType).
+		whereIf(startTime > 0, "crossing_ts >= ?", startTime).
+		whereIf(endTime > 0, "crossing_ts <= ?", endTime).
+		whereIf(fromRegion != "", "(from_country = ? OR from_province = ? OR from_city = ? OR from_county = ? OR from_town = ?)",
+			fromRegion, fromRegion, fromRegion, fromRegion, fromRegion).
+		whereIf(toRegion != "", "(to_country = ? OR to_province = ? OR to_city = ? OR to_county = ? OR to_town = ?)",
+			toRegion, toRegion, toRegion, toRegion, toRegion)
+	return queryList(r.db, q, adminCrossingSort, opts, "admin crossings", scanAdminCrossing)
 }
 
-// GetAdminCrossings retrieves administrative boundary crossing events
-func (r *StatsRepository) GetAdminCrossings(crossingType, fromRegion, toRegion string, startTime, endTime int64, limit int) ([]models.AdminCrossing, error) {
-	// Build query
-	query := `SELECT id, crossing_ts, from_province, from_city, from_county, from_town,
-		to_province, to_city, to_county, to_town, crossing_type,
-		latitude, longitude, distance_from_prev_m, algo_version, created_at
-		FROM admin_crossings`
+const adminStatsColumns = `id, admin_level, admin_name, parent_name, visit_count,
+		total_duration_s, unique_days, first_visit_ts, last_visit_ts,
+		total_distance_m, algo_version, created_at, updated_at`
+
+// adminStatsSort also accepts the short names of the former sort_by parameter
+var adminStatsSort = sortSpec{
+	fields: func() map[string]string {
+		f := sortFields("admin_name", "visit_count", "total_duration_s", "unique_days",
+			"first_visit_ts", "last_visit_ts", "total_distance_m")
+		f["duration"], f["distance"] = "total_duration_s", "total_distance_m"
+		return f
+	}(),
+	defaultField: "visit_count",
+	defaultOrder: "DESC",
+}
 
-	var conditions []string
-	var args []interface{}
+func scanAdminStats(rows *sql.Rows) (models.AdminStats, error) {
+	var s models.AdminStats
+	err := rows.Scan(
+		&s.ID, &s.AdminLevel, &s.AdminName, &s.ParentName, &s.VisitCount,
+		&s.TotalDurationS, &s.UniqueDays, &s.FirstVisitTS, &s.LastVisitTS,
+		&s.TotalDistanceM, &s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
+	)
+	return s, err
+}
 
-	// Add filters
-	if crossingType != "" {
-		conditions = append(conditions, "crossing_type = ?")
-		args = append(args, crossingType)
-	}
-	if startTime > 0 {
-		conditions = append(conditions, "crossing_ts >= ?")
-		args = append(args, startTime)
-	}
-	if endTime > 0 {
-		conditions = append(conditions, "crossing_ts <= ?")
-		args = append(args, endTime)
-	}
-	if fromRegion != "" {
-		conditions = append(conditions, "(from_province = ? OR from_city = ? OR from_county = ? OR from_town = ?)")
-		args = append(args, fromRegion, fromRegion, fromRegion, fromRegion)
-	}
-	if toRegion != "" {
-		conditions = append(conditions, "(to_province = ? OR to_city = ? OR to_county = ? OR to_town = ?)")
-		args = append(args, toRegion, toRegion, toRegion, toRegion)
-	}
+const adminVisitColumns = `id, admin_level, admin_name, COALESCE(parent_name, ''), arrival_ts, departure_ts,
+		duration_s, COALESCE(entered_from, ''), COALESCE(exited_to, ''),
+		COALESCE(arrival_lat, 0), COALESCE(arrival_lon, 0), algo_version, created_at`
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
+var adminVisitSort = sortSpec{
+	fields:       sortFields("arrival_ts", "departure_ts", "duration_s"),
+	defaultField: "arrival_ts",
+	defaultOrder: "DESC",
+}
 
-	// Order by timestamp
-	query += " ORDER BY crossing_ts DESC"
+func scanAdminVisit(rows *sql.Rows) (models.AdminVisit, error) {
+	var v models.AdminVisit
+	err := rows.Scan(
+		&v.ID, &v.AdminLevel, &v.AdminName, &v.ParentName, &v.ArrivalTS, &v.DepartureTS,
+		&v.DurationS, &v.EnteredFrom, &v.ExitedTo,
+		&v.ArrivalLat, &v.ArrivalLon, &v.AlgoVersion, &v.CreatedAt,
+	)
+	return v, err
+}
 
-	// Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 100
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
+// GetAdminVisits retrieves a page of visits to countries, provinces and cities
+func (r *StatsRepository) GetAdminVisits(filter models.AdminVisitFilter, opts models.QueryOptions) ([]models.AdminVisit, int64, error) {
+	q := newListQuery(adminVisitColumns, "admin_visits").
+		whereIf(filter.AdminLevel != "", "admin_level = ?", filter.AdminLevel).
+		whereIf(filter.AdminName != "", "admin_name = ?", filter.AdminName).
+		whereIf(filter.ParentName != "", "parent_name = ?", filter.ParentName).
+		whereIf(filter.StartTime > 0, "departure_ts >= ?", filter.StartTime).
+		whereIf(filter.EndTime > 0, "arrival_ts <= ?", filter.EndTime).
+		whereIf(filter.MinDurationS > 0, "duration_s >= ?", filter.MinDurationS)
+	return queryList(r.db, q, adminVisitSort, opts, "admin visits", scanAdminVisit)
+}
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
+// GetAdminVisitSummaries retrieves the visit count and time spent per region
+// of a level, most visited first
+func (r *StatsRepository) GetAdminVisitSummaries(adminLevel string) ([]models.AdminVisitSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT admin_level, admin_name, COALESCE(MAX(parent_name), ''), COUNT(*),
+			SUM(duration_s), MAX(duration_s), MIN(arrival_ts), MAX(departure_ts)
+		FROM admin_visits
+		WHERE admin_level = ?
+		GROUP BY admin_level, admin_name
+		ORDER BY COUNT(*) DESC, SUM(duration_s) DESC, admin_name
+	`, adminLevel)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query admin crossings: %w", err)
+		return nil, fmt.Errorf("failed to query admin visit summaries: %w", err)
 	}
 	defer rows.Close()
 
-	var crossings []models.AdminCrossing
+	summaries := []models.AdminVisitSummary{}
 	for rows.Next() {
-		var c models.AdminCrossing
-		err := rows.Scan(
-			&c.ID, &c.CrossingTS, &c.FromProvince, &c.FromCity, &c.FromCounty, &c.FromTown,
-			&c.ToProvince, &c.ToCity, &c.ToCounty, &c.ToTown, &c.CrossingType,
-			&c.Latitude, &c.Longitude, &c.DistanceFromPrevM, &c.AlgoVersion, &c.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan admin crossing: %w", err)
+		var s models.AdminVisitSummary
+		if err := rows.Scan(
+			&s.AdminLevel, &s.AdminName, &s.ParentName, &s.VisitCount,
+			&s.TotalDurationS, &s.LongestDurationS, &s.FirstArrivalTS, &s.LastDepartureTS,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan admin visit summary: %w", err)
 		}
-		crossings = append(crossings, c)
+		summaries = append(summaries, s)
 	}
+	return summaries, rows.Err()
+}
 
-	return crossings, nil
+// GetAdminStats retrieves a page of administrative region statistics
+func (r *StatsRepository) GetAdminStats(adminLevel, adminName, parentName string, opts models.QueryOptions) ([]models.AdminStats, int64, error) {
+	q := newListQuery(adminStatsColumns, "admin_stats").
+		whereIf(adminLevel != "", "admin_level = ?", adminLevel).
+		whereIf(adminName != "", "admin_name = ?", adminName).
+		whereIf(parentName != "", "parent_name = ?", parentName)
+	return queryList(r.db, q, adminStatsSort, opts, "admin stats", scanAdminStats)
 }
 
-// GetAdminStats retrieves administrative region statistics
-func (r *StatsRepository) GetAdminStats(adminLevel, adminName, parentName, sortBy string, limit int) ([]models.AdminStats, error) {
-	// Build query
-	query := `SELECT id, admin_level, admin_name, parent_name, visit_count,
+// GetAllAdminStats retrieves the statistics of every administrative area,
+// most visited first
+func (r *StatsRepository) GetAllAdminStats() ([]models.AdminStats, error) {
+	rows, err := r.db.Query(`SELECT id, admin_level, admin_name, COALESCE(parent_name, ''), visit_count,
 		total_duration_s, unique_days, first_visit_ts, last_visit_ts,
 		total_distance_m, algo_version, created_at, updated_at
-		FROM admin_stats`
-
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if adminLevel != "" {
-		conditions = append(conditions, "admin_level = ?")
-		args = append(args, adminLevel)
-	}
-	if adminName != "" {
-		conditions = append(conditions, "admin_name = ?")
-		args = append(args, adminName)
-	}
-	if parentName != "" {
-		conditions = append(conditions, "parent_name = ?")
-		args = append(args, parentName)
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	// Order by
-	orderBy := "visit_count DESC"
-	if sortBy == "duration" {
-		orderBy = "total_duration_s DESC"
-	} else if sortBy == "unique_days" {
-		orderBy = "unique_days DESC"
-	} else if sortBy == "distance" {
-		orderBy = "total_distance_m DESC"
-	}
-	query += " ORDER BY " + orderBy
-
-	// Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 50
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
-
-	// Execute query
-	rows, err := r.db.Query(query, args...)
+		FROM admin_stats
+		ORDER BY visit_count DESC, admin_name`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query admin stats: %w", err)
 	}
@@ -512,1399 +513,1323 @@ func (r *StatsRepository) GetAdminStats(adminLevel, adminName, parentName, sortB
 
 	var stats []models.AdminStats
 	for rows.Next() {
-		var s models.AdminStats
-		err := rows.Scan(
-			&s.ID, &s.AdminLevel, &s.AdminName, &s.ParentName, &s.VisitCount,
-			&s.TotalDurationS, &s.UniqueDays, &s.FirstVisitTS, &s.LastVisitTS,
-			&s.TotalDistanceM, &s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanAdminStats(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan admin stats: %w", err)
 		}
 		stats = append(stats, s)
 	}
-
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate admin stats: %w", err)
+	}
 	return stats, nil
 }
-// GetSpeedSpaceStats retrieves speed-space coupling statistics
-func (r *StatsRepository) GetSpeedSpaceStats(bucketType, areaType, areaName string, limit int) ([]models.SpeedSpaceStats, error) {
-	query := `SELECT id, bucket_type, bucket_key, area_type, area_key,
-		avg_speed, speed_variance, speed_entropy, total_distance, segment_count,
-		is_high_speed_zone, is_slow_life_zone, stay_intensity,
-		algo_version, created_at
-		FROM speed_space_stats_bucketed`
 
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if bucketType != "" {
-		conditions = append(conditions, "bucket_type = ?")
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		conditions = append(conditions, "area_type = ?")
-		args = append(args, areaType)
-	}
-	if areaName != "" {
-		conditions = append(conditions, "area_key = ?")
-		args = append(args, areaName)
+// GetAdminPointCounts retrieves the all-time point count of every
+// administrative area from the footprint statistics, by level and name
+func (r *StatsRepository) GetAdminPointCounts() (map[string]map[string]int64, error) {
+	rows, err := r.db.Query(`SELECT stat_type, stat_key, point_count
+		FROM footprint_statistics
+		WHERE time_range = 'all' AND stat_type IN ('PROVINCE', 'CITY', 'COUNTY', 'TOWN')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query admin point counts: %w", err)
 	}
+	defer rows.Close()
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	counts := make(map[string]map[string]int64)
+	for rows.Next() {
+		var level, name string
+		var count int64
+		if err := rows.Scan(&level, &name, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan admin point count: %w", err)
+		}
+		if counts[level] == nil {
+			counts[level] = make(map[string]int64)
+		}
+		counts[level][name] = count
 	}
-
-	// Order by average speed descending
-	query += " ORDER BY avg_speed DESC"
-
-	// Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 100
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate admin point counts: %w", err)
 	}
-	query += " LIMIT ?"
-	args = append(args, limit)
+	return counts, nil
+}
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
+// GetAreaFirstVisits retrieves every visited area of the given levels from
+// the all-time footprint statistics, by level and in order of first visit
+func (r *StatsRepository) GetAreaFirstVisits(levels ...string) (map[string][]models.AreaFirstVisit, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(levels)), ", ")
+	args := make([]interface{}, len(levels))
+	for i, level := range levels {
+		args[i] = level
+	}
+	rows, err := r.db.Query(`SELECT stat_type, stat_key, COALESCE(first_visit, 0), COALESCE(last_visit, 0), point_count
+		FROM footprint_statistics
+		WHERE time_range = 'all' AND stat_type IN (`+placeholders+`)
+		ORDER BY first_visit, stat_key`, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query speed-space stats: %w", err)
+		return nil, fmt.Errorf("failed to query area first visits: %w", err)
 	}
 	defer rows.Close()
 
-	var stats []models.SpeedSpaceStats
+	visits := make(map[string][]models.AreaFirstVisit)
 	for rows.Next() {
-		var s models.SpeedSpaceStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey,
-			&s.AvgSpeed, &s.SpeedVariance, &s.SpeedEntropy, &s.TotalDistance, &s.SegmentCount,
-			&s.IsHighSpeedZone, &s.IsSlowLifeZone, &s.StayIntensity,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan speed-space stats: %w", err)
+		var level string
+		var v models.AreaFirstVisit
+		if err := rows.Scan(&level, &v.Name, &v.FirstVisit, &v.LastVisit, &v.PointCount); err != nil {
+			return nil, fmt.Errorf("failed to scan area first visit: %w", err)
 		}
-		stats = append(stats, s)
+		visits[level] = append(visits[level], v)
 	}
-
-	return stats, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate area first visits: %w", err)
+	}
+	return visits, nil
 }
 
-// GetHighSpeedZones retrieves high-speed zones
-func (r *StatsRepository) GetHighSpeedZones(bucketType, areaType string, limit int) ([]models.SpeedSpaceStats, error) {
-	query := `SELECT id, bucket_type, bucket_key, area_type, area_key,
-		avg_speed, speed_variance, speed_entropy, total_distance, segment_count,
-		is_high_speed_zone, is_slow_life_zone, stay_intensity,
-		algo_version, created_at
-		FROM speed_space_stats_bucketed
-		WHERE is_high_speed_zone = 1`
-
-	var args []interface{}
+// GetCountryVisits retrieves every visited country from the footprint
+// statistics, in order of first visit
+func (r *StatsRepository) GetCountryVisits() ([]models.CountryVisit, error) {
+	rows, err := r.db.Query(`SELECT a.stat_key, COALESCE(a.first_visit, 0), COALESCE(a.last_visit, 0), a.point_count,
+			(SELECT COUNT(*) FROM footprint_statistics d
+			 WHERE d.stat_type = 'COUNTRY' AND d.stat_key = a.stat_key AND length(d.time_range) = 10)
+		FROM footprint_statistics a
+		WHERE a.stat_type = 'COUNTRY' AND a.time_range = 'all'
+		ORDER BY a.first_visit, a.stat_key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query country visits: %w", err)
+	}
+	defer rows.Close()
 
-	// Add filters
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
+	var visits []models.CountryVisit
+	for rows.Next() {
+		var v models.CountryVisit
+		if err := rows.Scan(&v.Code, &v.FirstVisit, &v.LastVisit, &v.PointCount, &v.Days); err != nil {
+			return nil, fmt.Errorf("failed to scan country visit: %w", err)
+		}
+		visits = append(visits, v)
 	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate country visits: %w", err)
 	}
+	return visits, nil
+}
 
-	// Order by average speed descending
-	query += " ORDER BY avg_speed DESC"
+// GetDaysAbroad counts per year the days with points outside the home
+// country, in total and per country
+func (r *StatsRepository) GetDaysAbroad(home string) ([]models.YearAbroad, error) {
+	rows, err := r.db.Query(`SELECT substr(time_range, 1, 4) AS year, COUNT(DISTINCT time_range)
+		FROM footprint_statistics
+		WHERE stat_type = 'COUNTRY' AND stat_key != ? AND length(time_range) = 10
+		GROUP BY year
+		ORDER BY year`, home)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query days abroad: %w", err)
+	}
+	defer rows.Close()
 
-	// Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 50
+	var years []models.YearAbroad
+	index := make(map[string]int)
+	for rows.Next() {
+		y := models.YearAbroad{Countries: make(map[string]int)}
+		if err := rows.Scan(&y.Year, &y.DaysAbroad); err != nil {
+			return nil, fmt.Errorf("failed to scan days abroad: %w", err)
+		}
+		index[y.Year] = len(years)
+		years = append(years, y)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate days abroad: %w", err)
 	}
-	query += " LIMIT ?"
-	args = append(args, limit)
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
+	rows, err = r.db.Query(`SELECT substr(time_range, 1, 4) AS year, stat_key, COUNT(*)
+		FROM footprint_statistics
+		WHERE stat_type = 'COUNTRY' AND stat_key != ? AND length(time_range) = 10
+		GROUP BY year, stat_key`, home)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query high-speed zones: %w", err)
+		return nil, fmt.Errorf("failed to query days per country: %w", err)
 	}
 	defer rows.Close()
 
-	var zones []models.SpeedSpaceStats
 	for rows.Next() {
-		var s models.SpeedSpaceStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey,
-			&s.AvgSpeed, &s.SpeedVariance, &s.SpeedEntropy, &s.TotalDistance, &s.SegmentCount,
-			&s.IsHighSpeedZone, &s.IsSlowLifeZone, &s.StayIntensity,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan high-speed zone: %w", err)
+		var year, code string
+		var days int
+		if err := rows.Scan(&year, &code, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan days per country: %w", err)
+		}
+		if i, ok := index[year]; ok {
+			years[i].Countries[code] = days
 		}
-		zones = append(zones, s)
 	}
-
-	return zones, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate days per country: %w", err)
+	}
+	return years, nil
 }
 
-// GetSlowLifeZones retrieves slow-life zones
-func (r *StatsRepository) GetSlowLifeZones(bucketType, areaType string, limit int) ([]models.SpeedSpaceStats, error) {
-	query := `SELECT id, bucket_type, bucket_key, area_type, area_key,
+const speedSpaceColumns = `id, bucket_type, bucket_key, source, area_type, area_key,
 		avg_speed, speed_variance, speed_entropy, total_distance, segment_count,
 		is_high_speed_zone, is_slow_life_zone, stay_intensity,
-		algo_version, created_at
-		FROM speed_space_stats_bucketed
-		WHERE is_slow_life_zone = 1`
+		algo_version, created_at`
 
-	var args []interface{}
-
-	// Add filters
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
+var speedSpaceSort = sortSpec{
+	fields:       sortFields("area_key", "avg_speed", "speed_variance", "speed_entropy", "total_distance", "segment_count", "stay_intensity"),
+	defaultField: "avg_speed",
+	defaultOrder: "DESC",
+}
 
-	// Order by average speed ascending (slowest first)
-	query += " ORDER BY avg_speed ASC"
+func scanSpeedSpace(rows *sql.Rows) (models.SpeedSpaceStats, error) {
+	var s models.SpeedSpaceStats
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &s.BucketKey, &s.Source, &s.AreaType, &s.AreaKey,
+		&s.AvgSpeed, &s.SpeedVariance, &s.SpeedEntropy, &s.TotalDistance, &s.SegmentCount,
+		&s.IsHighSpeedZone, &s.IsSlowLifeZone, &s.StayIntensity,
+		&s.AlgoVersion, &s.CreatedAt,
+	)
+	return s, err
+}
 
-	// Limit
-	if limit <= 0 || limit > 1000 {
-		limit = 50
-	}
-	query += " LIMIT ?"
-	args = append(args, limit)
+// speedSpaceQuery selects speed-space rows of a source, bucket type and area type (the latter two may be "")
+func speedSpaceQuery(bucketType, source, areaType string) *listQuery {
+	return newListQuery(speedSpaceColumns, "speed_space_stats_bucketed").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(areaType != "", "area_type = ?", areaType)
+}
 
-	// Execute query
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query slow-life zones: %w", err)
-	}
-	defer rows.Close()
+// GetSpeedSpaceStats retrieves a page of speed-space coupling statistics
+func (r *StatsRepository) GetSpeedSpaceStats(bucketType, source, areaType, areaName string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	q := speedSpaceQuery(bucketType, source, areaType).
+		whereIf(areaName != "", "area_key = ?", areaName)
+	return queryList(r.db, q, speedSpaceSort, opts, "speed-space stats", scanSpeedSpace)
+}
 
-	var zones []models.SpeedSpaceStats
-	for rows.Next() {
-		var s models.SpeedSpaceStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey,
-			&s.AvgSpeed, &s.SpeedVariance, &s.SpeedEntropy, &s.TotalDistance, &s.SegmentCount,
-			&s.IsHighSpeedZone, &s.IsSlowLifeZone, &s.StayIntensity,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan slow-life zone: %w", err)
-		}
-		zones = append(zones, s)
-	}
+// GetHighSpeedZones retrieves a page of high-speed zones
+func (r *StatsRepository) GetHighSpeedZones(bucketType, source, areaType string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	q := speedSpaceQuery(bucketType, source, areaType).where("is_high_speed_zone = 1")
+	return queryList(r.db, q, speedSpaceSort, opts, "high-speed zones", scanSpeedSpace)
+}
 
-	return zones, nil
+// GetSlowLifeZones retrieves a page of slow-life zones, slowest first
+func (r *StatsRepository) GetSlowLifeZones(bucketType, source, areaType string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	q := speedSpaceQuery(bucketType, source, areaType).where("is_slow_life_zone = 1")
+	return queryList(r.db, q, speedSpaceSort.withDefault("avg_speed", "ASC"), opts, "slow-life zones", scanSpeedSpace)
 }
 
-// GetDirectionalBiasStats retrieves directional bias statistics
-func (r *StatsRepository) GetDirectionalBiasStats(
-	bucketType, areaType, areaKey, modeFilter string,
-	limit int,
-) ([]models.DirectionalBiasStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key, mode_filter,
-			direction_histogram_json, num_bins,
-			dominant_direction_deg, directional_concentration,
-			bidirectional_score, directional_entropy,
-			total_distance, total_duration, segment_count,
-			algo_version, created_at
-		FROM directional_stats_bucketed
-		WHERE 1=1
-	`
+const directionalColumns = `id, bucket_type, bucket_key, source, area_type, area_key, mode_filter,
+		direction_histogram_json, num_bins,
+		dominant_direction_deg, directional_concentration,
+		bidirectional_score, directional_entropy,
+		COALESCE(dominant_axis_deg, 0), COALESCE(anisotropy_index, 0),
+		total_distance, total_duration, segment_count,
+		algo_version, created_at`
+
+var directionalSort = sortSpec{
+	fields: sortFields("area_key", "dominant_direction_deg", "directional_concentration", "bidirectional_score",
+		"directional_entropy", "anisotropy_index", "total_distance", "total_duration", "segment_count"),
+	defaultField: "total_distance",
+	defaultOrder: "DESC",
+}
 
-	var args []interface{}
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
-	if areaKey != "" {
-		query += " AND area_key = ?"
-		args = append(args, areaKey)
-	}
-	if modeFilter != "" {
-		query += " AND mode_filter = ?"
-		args = append(args, modeFilter)
-	}
+func scanDirectional(rows *sql.Rows) (models.DirectionalBiasStats, error) {
+	var s models.DirectionalBiasStats
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &s.BucketKey, &s.Source, &s.AreaType, &s.AreaKey, &s.ModeFilter,
+		&s.DirectionHistogramJSON, &s.NumBins,
+		&s.DominantDirectionDeg, &s.DirectionalConcentration,
+		&s.BidirectionalScore, &s.DirectionalEntropy,
+		&s.DominantAxisDeg, &s.AnisotropyIndex,
+		&s.TotalDistance, &s.TotalDuration, &s.SegmentCount,
+		&s.AlgoVersion, &s.CreatedAt,
+	)
+	return s, err
+}
 
-	query += " ORDER BY total_distance DESC"
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
-	}
+// GetDirectionalBiasStats retrieves a page of directional bias statistics
+func (r *StatsRepository) GetDirectionalBiasStats(
+	bucketType, source, areaType, areaKey, modeFilter string,
+	opts models.QueryOptions,
+) ([]models.DirectionalBiasStats, int64, error) {
+	q := newListQuery(directionalColumns, "directional_stats_bucketed").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(areaType != "", "area_type = ?", areaType).
+		whereIf(areaKey != "", "area_key = ?", areaKey).
+		whereIf(modeFilter != "", "mode_filter = ?", modeFilter)
+	return queryList(r.db, q, directionalSort, opts, "directional bias stats", scanDirectional)
+}
 
-	rows, err := r.db.Query(query, args...)
+// GetDirectionalHistogram retrieves the directional stats of one area, bucket
+// and mode, or nil when there are none
+func (r *StatsRepository) GetDirectionalHistogram(
+	bucketType, bucketKey, source, areaType, areaKey, modeFilter string,
+) (*models.DirectionalBiasStats, error) {
+	cond := conditions{}.
+		and("source = ?", source).
+		and("bucket_type = ?", bucketType).
+		and("mode_filter = ?", modeFilter).
+		and("area_type = ?", areaType).
+		and("area_key = ?", areaKey).
+		and("bucket_key = ?", bucketKey)
+
+	rows, err := r.db.Query(`SELECT `+directionalColumns+` FROM directional_stats_bucketed`+cond.where()+` LIMIT 1`, cond.args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query directional bias stats: %w", err)
+		return nil, fmt.Errorf("failed to query directional histogram: %w", err)
 	}
 	defer rows.Close()
 
-	var stats []models.DirectionalBiasStats
-	for rows.Next() {
-		var s models.DirectionalBiasStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey, &s.ModeFilter,
-			&s.DirectionHistogramJSON, &s.NumBins,
-			&s.DominantDirectionDeg, &s.DirectionalConcentration,
-			&s.BidirectionalScore, &s.DirectionalEntropy,
-			&s.TotalDistance, &s.TotalDuration, &s.SegmentCount,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan directional bias stat: %w", err)
-		}
-		stats = append(stats, s)
+	if !rows.Next() {
+		return nil, rows.Err()
 	}
-
-	return stats, nil
+	stats, err := scanDirectional(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan directional histogram: %w", err)
+	}
+	return &stats, nil
 }
 
 // GetTopDirectionalAreas retrieves areas with highest directional concentration
+// Grid cells are left out, only admin areas are ranked.
 func (r *StatsRepository) GetTopDirectionalAreas(
 	bucketType string,
-	limit int,
-) ([]models.DirectionalBiasStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key, mode_filter,
-			direction_histogram_json, num_bins,
-			dominant_direction_deg, directional_concentration,
-			bidirectional_score, directional_entropy,
-			total_distance, total_duration, segment_count,
-			algo_version, created_at
-		FROM directional_stats_bucketed
-		WHERE bucket_type = ?
-			AND mode_filter = 'ALL'
-		ORDER BY directional_concentration DESC
-		LIMIT ?
-	`
-
-	rows, err := r.db.Query(query, bucketType, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query top directional areas: %w", err)
-	}
-	defer rows.Close()
-
-	var stats []models.DirectionalBiasStats
-	for rows.Next() {
-		var s models.DirectionalBiasStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey, &s.ModeFilter,
-			&s.DirectionHistogramJSON, &s.NumBins,
-			&s.DominantDirectionDeg, &s.DirectionalConcentration,
-			&s.BidirectionalScore, &s.DirectionalEntropy,
-			&s.TotalDistance, &s.TotalDuration, &s.SegmentCount,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan top directional area: %w", err)
-		}
-		stats = append(stats, s)
-	}
-
-	return stats, nil
+	source string,
+	opts models.QueryOptions,
+) ([]models.DirectionalBiasStats, int64, error) {
+	q := newListQuery(directionalColumns, "directional_stats_bucketed").
+		where("bucket_type = ?", bucketType).
+		where("source = ?", source).
+		where("mode_filter = 'ALL'").
+		where("area_type IN ('PROVINCE', 'CITY', 'COUNTY')")
+	sort := directionalSort.withDefault("directional_concentration", "DESC")
+	return queryList(r.db, q, sort, opts, "top directional areas", scanDirectional)
 }
 
 // GetBidirectionalPatterns retrieves areas with strong bidirectional patterns
+// Grid cells are left out, only admin areas are ranked.
 func (r *StatsRepository) GetBidirectionalPatterns(
 	bucketType string,
-	limit int,
-) ([]models.DirectionalBiasStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key, mode_filter,
-			direction_histogram_json, num_bins,
-			dominant_direction_deg, directional_concentration,
-			bidirectional_score, directional_entropy,
-			total_distance, total_duration, segment_count,
-			algo_version, created_at
-		FROM directional_stats_bucketed
-		WHERE bucket_type = ?
-			AND mode_filter = 'ALL'
-		ORDER BY bidirectional_score DESC
-		LIMIT ?
-	`
+	source string,
+	opts models.QueryOptions,
+) ([]models.DirectionalBiasStats, int64, error) {
+	q := newListQuery(directionalColumns, "directional_stats_bucketed").
+		where("bucket_type = ?", bucketType).
+		where("source = ?", source).
+		where("mode_filter = 'ALL'").
+		where("area_type IN ('PROVINCE', 'CITY', 'COUNTY')")
+	sort := directionalSort.withDefault("bidirectional_score", "DESC")
+	return queryList(r.db, q, sort, opts, "bidirectional patterns", scanDirectional)
+}
 
-	rows, err := r.db.Query(query, bucketType, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query bidirectional patterns: %w", err)
-	}
-	defer rows.Close()
+const revisitColumns = `id, geohash6, center_lat, center_lon,
+		province, city, county,
+		visit_count, first_visit, last_visit, total_duration_seconds,
+		avg_interval_days, std_interval_days, min_interval_days, max_interval_days,
+		regularity_score, is_periodic, is_habitual, revisit_strength,
+		algo_version, created_at, updated_at`
+
+var revisitSort = sortSpec{
+	fields: sortFields("visit_count", "first_visit", "last_visit", "total_duration_seconds",
+		"avg_interval_days", "regularity_score", "revisit_strength"),
+	defaultField: "revisit_strength",
+	defaultOrder: "DESC",
+}
 
-	var stats []models.DirectionalBiasStats
-	for rows.Next() {
-		var s models.DirectionalBiasStats
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &s.BucketKey, &s.AreaType, &s.AreaKey, &s.ModeFilter,
-			&s.DirectionHistogramJSON, &s.NumBins,
-			&s.DominantDirectionDeg, &s.DirectionalConcentration,
-			&s.BidirectionalScore, &s.DirectionalEntropy,
-			&s.TotalDistance, &s.TotalDuration, &s.SegmentCount,
-			&s.AlgoVersion, &s.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan bidirectional pattern: %w", err)
-		}
-		stats = append(stats, s)
-	}
-
-	return stats, nil
+func scanRevisit(rows *sql.Rows) (models.RevisitPattern, error) {
+	var p models.RevisitPattern
+	var isPeriodic, isHabitual int
+	err := rows.Scan(
+		&p.ID, &p.Geohash6, &p.CenterLat, &p.CenterLon,
+		&p.Province, &p.City, &p.County,
+		&p.VisitCount, &p.FirstVisit, &p.LastVisit, &p.TotalDurationSeconds,
+		&p.AvgIntervalDays, &p.StdIntervalDays, &p.MinIntervalDays, &p.MaxIntervalDays,
+		&p.RegularityScore, &isPeriodic, &isHabitual, &p.RevisitStrength,
+		&p.AlgoVersion, &p.CreatedAt, &p.UpdatedAt,
+	)
+	p.IsPeriodic = isPeriodic == 1
+	p.IsHabitual = isHabitual == 1
+	return p, err
 }
 
-// GetRevisitPatterns retrieves revisit patterns with filters
+// GetRevisitPatterns retrieves a page of revisit patterns with filters
 func (r *StatsRepository) GetRevisitPatterns(
 	minVisits int,
 	habitualOnly bool,
 	periodicOnly bool,
-	limit int,
-) ([]models.RevisitPattern, error) {
-	query := `
-		SELECT
-			id, geohash6, center_lat, center_lon,
-			province, city, county,
-			visit_count, first_visit, last_visit, total_duration_seconds,
-			avg_interval_days, std_interval_days, min_interval_days, max_interval_days,
-			regularity_score, is_periodic, is_habitual, revisit_strength,
-			algo_version, created_at, updated_at
-		FROM revisit_patterns
-		WHERE visit_count >= ?
-	`
-
-	var args []interface{}
-	args = append(args, minVisits)
-
-	if habitualOnly {
-		query += " AND is_habitual = 1"
-	}
-	if periodicOnly {
-		query += " AND is_periodic = 1"
-	}
-
-	query += " ORDER BY revisit_strength DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query revisit patterns: %w", err)
-	}
-	defer rows.Close()
-
-	var patterns []models.RevisitPattern
-	for rows.Next() {
-		var p models.RevisitPattern
-		var isPeriodic, isHabitual int
-		err := rows.Scan(
-			&p.ID, &p.Geohash6, &p.CenterLat, &p.CenterLon,
-			&p.Province, &p.City, &p.County,
-			&p.VisitCount, &p.FirstVisit, &p.LastVisit, &p.TotalDurationSeconds,
-			&p.AvgIntervalDays, &p.StdIntervalDays, &p.MinIntervalDays, &p.MaxIntervalDays,
-			&p.RegularityScore, &isPeriodic, &isHabitual, &p.RevisitStrength,
-			&p.AlgoVersion, &p.CreatedAt, &p.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan revisit pattern: %w", err)
-		}
-		p.IsPeriodic = isPeriodic == 1
-		p.IsHabitual = isHabitual == 1
-		patterns = append(patterns, p)
-	}
-
-	return patterns, nil
+	opts models.QueryOptions,
+) ([]models.RevisitPattern, int64, error) {
+	q := newListQuery(revisitColumns, "revisit_patterns").
+		where("visit_count >= ?", minVisits).
+		whereIf(habitualOnly, "is_habitual = 1").
+		whereIf(periodicOnly, "is_periodic = 1")
+	return queryList(r.db, q, revisitSort, opts, "revisit patterns", scanRevisit)
 }
 
 // GetTopRevisitLocations retrieves locations with highest revisit strength
-func (r *StatsRepository) GetTopRevisitLocations(limit int) ([]models.RevisitPattern, error) {
-	return r.GetRevisitPatterns(2, false, false, limit)
+func (r *StatsRepository) GetTopRevisitLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return r.GetRevisitPatterns(2, false, false, opts)
 }
 
 // GetHabitualLocations retrieves habitual locations (≥5 visits + high regularity)
-func (r *StatsRepository) GetHabitualLocations(limit int) ([]models.RevisitPattern, error) {
-	return r.GetRevisitPatterns(5, true, false, limit)
+func (r *StatsRepository) GetHabitualLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return r.GetRevisitPatterns(5, true, false, opts)
 }
 
 // GetPeriodicLocations retrieves locations with periodic visit patterns
-func (r *StatsRepository) GetPeriodicLocations(limit int) ([]models.RevisitPattern, error) {
-	return r.GetRevisitPatterns(3, false, true, limit)
+func (r *StatsRepository) GetPeriodicLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return r.GetRevisitPatterns(3, false, true, opts)
 }
 
-// GetSpatialUtilization retrieves utilization stats with filters
-func (r *StatsRepository) GetSpatialUtilization(
-	bucketType string,
-	areaType string,
-	areaKey string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			transit_intensity, stay_duration_s,
-			utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
-			distinct_visit_days, distinct_grids, total_grids,
-			first_visit, last_visit,
-			algo_version, created_at, updated_at
-		FROM spatial_utilization_bucketed
-		WHERE 1=1
-	`
-	args := []interface{}{}
+const utilizationColumns = `id, bucket_type, bucket_key, source, area_type, area_key,
+		transit_intensity, stay_duration_s,
+		utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
+		distinct_visit_days, distinct_grids, total_grids,
+		first_visit, last_visit,
+		algo_version, created_at, updated_at`
+
+var utilizationSort = sortSpec{
+	fields: sortFields("area_key", "transit_intensity", "stay_duration_s", "utilization_efficiency", "transit_dominance",
+		"area_depth", "coverage_efficiency", "distinct_visit_days", "distinct_grids", "first_visit", "last_visit"),
+	defaultField: "utilization_efficiency",
+	defaultOrder: "DESC",
+}
+
+func scanUtilization(rows *sql.Rows) (models.SpatialUtilization, error) {
+	var s models.SpatialUtilization
+	var bucketKey sql.NullString
+	var firstVisit, lastVisit sql.NullInt64
+
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &bucketKey, &s.Source, &s.AreaType, &s.AreaKey,
+		&s.TransitIntensity, &s.StayDurationS,
+		&s.UtilizationEfficiency, &s.TransitDominance, &s.AreaDepth, &s.CoverageEfficiency,
+		&s.DistinctVisitDays, &s.DistinctGrids, &s.TotalGrids,
+		&firstVisit, &lastVisit,
+		&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
+	)
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
+	if bucketKey.Valid {
+		s.BucketKey = bucketKey.String
 	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
+	if firstVisit.Valid {
+		s.FirstVisit = firstVisit.Int64
 	}
-	if areaKey != "" {
-		query += " AND area_key = ?"
-		args = append(args, areaKey)
+	if lastVisit.Valid {
+		s.LastVisit = lastVisit.Int64
 	}
+	return s, err
+}
 
-	query += " ORDER BY utilization_efficiency DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query spatial utilization: %w", err)
-	}
-	defer rows.Close()
-
-	var results []models.SpatialUtilization
-	for rows.Next() {
-		var s models.SpatialUtilization
-		var bucketKey sql.NullString
-		var firstVisit, lastVisit sql.NullInt64
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &s.AreaKey,
-			&s.TransitIntensity, &s.StayDurationS,
-			&s.UtilizationEfficiency, &s.TransitDominance, &s.AreaDepth, &s.CoverageEfficiency,
-			&s.DistinctVisitDays, &s.DistinctGrids, &s.TotalGrids,
-			&firstVisit, &lastVisit,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan spatial utilization: %w", err)
-		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if firstVisit.Valid {
-			s.FirstVisit = firstVisit.Int64
-		}
-		if lastVisit.Valid {
-			s.LastVisit = lastVisit.Int64
-		}
-
-		results = append(results, s)
-	}
+// utilizationQuery selects utilization rows of a source, bucket type and area type (the latter two may be "")
+func utilizationQuery(bucketType, source, areaType string) *listQuery {
+	return newListQuery(utilizationColumns, "spatial_utilization_bucketed").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(areaType != "", "area_type = ?", areaType)
+}
 
-	return results, nil
+// GetSpatialUtilization retrieves a page of utilization stats with filters
+func (r *StatsRepository) GetSpatialUtilization(
+	bucketType string,
+	source string,
+	areaType string,
+	areaKey string,
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	q := utilizationQuery(bucketType, source, areaType).whereIf(areaKey != "", "area_key = ?", areaKey)
+	return queryList(r.db, q, utilizationSort, opts, "spatial utilization", scanUtilization)
 }
 
 // GetDestinationAreas retrieves areas with high utilization efficiency (destinations)
 func (r *StatsRepository) GetDestinationAreas(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			transit_intensity, stay_duration_s,
-			utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
-			distinct_visit_days, distinct_grids, total_grids,
-			first_visit, last_visit,
-			algo_version, created_at, updated_at
-		FROM spatial_utilization_bucketed
-		WHERE utilization_efficiency > 10
-		  AND transit_dominance < 0.3
-	`
-	args := []interface{}{}
-
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
-
-	query += " ORDER BY utilization_efficiency DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query destination areas: %w", err)
-	}
-	defer rows.Close()
-
-	var results []models.SpatialUtilization
-	for rows.Next() {
-		var s models.SpatialUtilization
-		var bucketKey sql.NullString
-		var firstVisit, lastVisit sql.NullInt64
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &s.AreaKey,
-			&s.TransitIntensity, &s.StayDurationS,
-			&s.UtilizationEfficiency, &s.TransitDominance, &s.AreaDepth, &s.CoverageEfficiency,
-			&s.DistinctVisitDays, &s.DistinctGrids, &s.TotalGrids,
-			&firstVisit, &lastVisit,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan destination area: %w", err)
-		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if firstVisit.Valid {
-			s.FirstVisit = firstVisit.Int64
-		}
-		if lastVisit.Valid {
-			s.LastVisit = lastVisit.Int64
-		}
-
-		results = append(results, s)
-	}
-
-	return results, nil
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	q := utilizationQuery(bucketType, source, areaType).
+		where("utilization_efficiency > 10").
+		where("transit_dominance < 0.3")
+	return queryList(r.db, q, utilizationSort, opts, "destination areas", scanUtilization)
 }
 
 // GetTransitCorridors retrieves areas with high transit dominance (corridors)
 func (r *StatsRepository) GetTransitCorridors(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			transit_intensity, stay_duration_s,
-			utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
-			distinct_visit_days, distinct_grids, total_grids,
-			first_visit, last_visit,
-			algo_version, created_at, updated_at
-		FROM spatial_utilization_bucketed
-		WHERE transit_dominance > 0.7
-		  AND utilization_efficiency < 1
-	`
-	args := []interface{}{}
-
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
-
-	query += " ORDER BY transit_dominance DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query transit corridors: %w", err)
-	}
-	defer rows.Close()
-
-	var results []models.SpatialUtilization
-	for rows.Next() {
-		var s models.SpatialUtilization
-		var bucketKey sql.NullString
-		var firstVisit, lastVisit sql.NullInt64
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &s.AreaKey,
-			&s.TransitIntensity, &s.StayDurationS,
-			&s.UtilizationEfficiency, &s.TransitDominance, &s.AreaDepth, &s.CoverageEfficiency,
-			&s.DistinctVisitDays, &s.DistinctGrids, &s.TotalGrids,
-			&firstVisit, &lastVisit,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan transit corridor: %w", err)
-		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if firstVisit.Valid {
-			s.FirstVisit = firstVisit.Int64
-		}
-		if lastVisit.Valid {
-			s.LastVisit = lastVisit.Int64
-		}
-
-		results = append(results, s)
-	}
-
-	return results, nil
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	q := utilizationQuery(bucketType, source, areaType).
+		where("transit_dominance > 0.7").
+		where("utilization_efficiency < 1")
+	sort := utilizationSort.withDefault("transit_dominance", "DESC")
+	return queryList(r.db, q, sort, opts, "transit corridors", scanUtilization)
 }
 
 // GetDeepEngagementAreas retrieves areas with high area depth
 func (r *StatsRepository) GetDeepEngagementAreas(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			transit_intensity, stay_duration_s,
-			utilization_efficiency, transit_dominance, area_depth, coverage_efficiency,
-			distinct_visit_days, distinct_grids, total_grids,
-			first_visit, last_visit,
-			algo_version, created_at, updated_at
-		FROM spatial_utilization_bucketed
-		WHERE area_depth > 20
-	`
-	args := []interface{}{}
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	q := utilizationQuery(bucketType, source, areaType).where("area_depth > 20")
+	sort := utilizationSort.withDefault("area_depth", "DESC")
+	return queryList(r.db, q, sort, opts, "deep engagement areas", scanUtilization)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
+const densityGridColumns = `id, bucket_type, bucket_key, source, grid_id,
+		center_lat, center_lon, province, city, county,
+		density_score, density_level,
+		stay_duration_s, stay_count, visit_days,
+		cluster_id, cluster_area_km2,
+		algo_version, created_at, updated_at`
+
+var densityGridSort = sortSpec{
+	fields: sortFields("grid_id", "density_score", "stay_duration_s", "stay_count", "visit_days",
+		"cluster_id", "cluster_area_km2"),
+	defaultField: "density_score",
+	defaultOrder: "DESC",
+}
 
-	query += " ORDER BY area_depth DESC LIMIT ?"
-	args = append(args, limit)
+func scanDensityGrid(rows *sql.Rows) (models.SpatialDensityGrid, error) {
+	var g models.SpatialDensityGrid
+	var bucketKey, province, city, county sql.NullString
+	var clusterID sql.NullInt64
+	var clusterAreaKm2 sql.NullFloat64
+
+	err := rows.Scan(
+		&g.ID, &g.BucketType, &bucketKey, &g.Source, &g.GridID,
+		&g.CenterLat, &g.CenterLon, &province, &city, &county,
+		&g.DensityScore, &g.DensityLevel,
+		&g.StayDurationS, &g.StayCount, &g.VisitDays,
+		&clusterID, &clusterAreaKm2,
+		&g.AlgoVersion, &g.CreatedAt, &g.UpdatedAt,
+	)
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query deep engagement areas: %w", err)
+	if bucketKey.Valid {
+		g.BucketKey = bucketKey.String
 	}
-	defer rows.Close()
-
-	var results []models.SpatialUtilization
-	for rows.Next() {
-		var s models.SpatialUtilization
-		var bucketKey sql.NullString
-		var firstVisit, lastVisit sql.NullInt64
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &s.AreaKey,
-			&s.TransitIntensity, &s.StayDurationS,
-			&s.UtilizationEfficiency, &s.TransitDominance, &s.AreaDepth, &s.CoverageEfficiency,
-			&s.DistinctVisitDays, &s.DistinctGrids, &s.TotalGrids,
-			&firstVisit, &lastVisit,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan deep engagement area: %w", err)
-		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if firstVisit.Valid {
-			s.FirstVisit = firstVisit.Int64
-		}
-		if lastVisit.Valid {
-			s.LastVisit = lastVisit.Int64
-		}
-
-		results = append(results, s)
+	if province.Valid {
+		g.Province = province.String
 	}
-
-	return results, nil
-}
-
-// GetDensityGrids retrieves density grids with filters
-func (r *StatsRepository) GetDensityGrids(
-	bucketType string,
-	densityLevel string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, grid_id,
-			center_lat, center_lon, province, city, county,
-			density_score, density_level,
-			stay_duration_s, stay_count, visit_days,
-			cluster_id, cluster_area_km2,
-			algo_version, created_at, updated_at
-		FROM spatial_density_grid_stats
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
+	if city.Valid {
+		g.City = city.String
 	}
-
-	if densityLevel != "" {
-		query += " AND density_level = ?"
-		args = append(args, densityLevel)
+	if county.Valid {
+		g.County = county.String
 	}
-
-	query += " ORDER BY density_score DESC LIMIT ?"
-	args = append(args, limit)
-
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query density grids: %w", err)
+	if clusterID.Valid {
+		id := int(clusterID.Int64)
+		g.ClusterID = &id
 	}
-	defer rows.Close()
-
-	var results []models.SpatialDensityGrid
-	for rows.Next() {
-		var g models.SpatialDensityGrid
-		var bucketKey, province, city, county sql.NullString
-		var clusterID sql.NullInt64
-		var clusterAreaKm2 sql.NullFloat64
-
-		err := rows.Scan(
-			&g.ID, &g.BucketType, &bucketKey, &g.GridID,
-			&g.CenterLat, &g.CenterLon, &province, &city, &county,
-			&g.DensityScore, &g.DensityLevel,
-			&g.StayDurationS, &g.StayCount, &g.VisitDays,
-			&clusterID, &clusterAreaKm2,
-			&g.AlgoVersion, &g.CreatedAt, &g.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan density grid: %w", err)
-		}
-
-		if bucketKey.Valid {
-			g.BucketKey = bucketKey.String
-		}
-		if province.Valid {
-			g.Province = province.String
-		}
-		if city.Valid {
-			g.City = city.String
-		}
-		if county.Valid {
-			g.County = county.String
-		}
-		if clusterID.Valid {
-			id := int(clusterID.Int64)
-			g.ClusterID = &id
-		}
-		if clusterAreaKm2.Valid {
-			g.ClusterAreaKm2 = &clusterAreaKm2.Float64
-		}
-
-		results = append(results, g)
+	if clusterAreaKm2.Valid {
+		g.ClusterAreaKm2 = &clusterAreaKm2.Float64
 	}
+	return g, err
+}
 
-	return results, nil
+// GetDensityGrids retrieves a page of density grids with filters
+func (r *StatsRepository) GetDensityGrids(
+	bucketType string,
+	source string,
+	densityLevel string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	q := newListQuery(densityGridColumns, "spatial_density_grid_stats").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(densityLevel != "", "density_level = ?", densityLevel)
+	return queryList(r.db, q, densityGridSort, opts, "density grids", scanDensityGrid)
 }
 
 // GetCoreAreas retrieves core density areas
 func (r *StatsRepository) GetCoreAreas(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return r.GetDensityGrids(bucketType, "core", limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return r.GetDensityGrids(bucketType, source, "core", opts)
 }
 
 // GetRareVisits retrieves rare visit locations
 func (r *StatsRepository) GetRareVisits(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return r.GetDensityGrids(bucketType, "rare", limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return r.GetDensityGrids(bucketType, source, "rare", opts)
 }
 
 // GetDensityClusters retrieves density clusters (if implemented)
 func (r *StatsRepository) GetDensityClusters(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, grid_id,
-			center_lat, center_lon, province, city, county,
-			density_score, density_level,
-			stay_duration_s, stay_count, visit_days,
-			cluster_id, cluster_area_km2,
-			algo_version, created_at, updated_at
-		FROM spatial_density_grid_stats
-		WHERE cluster_id IS NOT NULL
-	`
-	args := []interface{}{}
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	q := newListQuery(densityGridColumns, "spatial_density_grid_stats").
+		where("cluster_id IS NOT NULL").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType)
+	sort := densityGridSort.withDefault("cluster_area_km2", "DESC")
+	return queryList(r.db, q, sort, opts, "density clusters", scanDensityGrid)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
+const altitudeColumns = `id, bucket_type, bucket_key, source, area_type, area_key,
+		min_altitude, max_altitude, avg_altitude, altitude_span,
+		p25_altitude, p50_altitude, p75_altitude, p90_altitude,
+		total_ascent, total_descent, vertical_intensity,
+		point_count, segment_count, total_distance,
+		algo_version, created_at, updated_at`
+
+var altitudeSort = sortSpec{
+	fields: sortFields("area_key", "min_altitude", "max_altitude", "avg_altitude", "altitude_span",
+		"total_ascent", "total_descent", "vertical_intensity", "point_count", "segment_count", "total_distance"),
+	defaultField: "altitude_span",
+	defaultOrder: "DESC",
+}
 
-	query += " ORDER BY cluster_area_km2 DESC LIMIT ?"
-	args = append(args, limit)
+func scanAltitude(rows *sql.Rows) (models.AltitudeStats, error) {
+	var s models.AltitudeStats
+	var bucketKey, areaKey sql.NullString
+
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &bucketKey, &s.Source, &s.AreaType, &areaKey,
+		&s.MinAltitude, &s.MaxAltitude, &s.AvgAltitude, &s.AltitudeSpan,
+		&s.P25Altitude, &s.P50Altitude, &s.P75Altitude, &s.P90Altitude,
+		&s.TotalAscent, &s.TotalDescent, &s.VerticalIntensity,
+		&s.PointCount, &s.SegmentCount, &s.TotalDistance,
+		&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
+	)
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query density clusters: %w", err)
+	if bucketKey.Valid {
+		s.BucketKey = bucketKey.String
 	}
-	defer rows.Close()
-
-	var results []models.SpatialDensityGrid
-	for rows.Next() {
-		var g models.SpatialDensityGrid
-		var bucketKey, province, city, county sql.NullString
-		var clusterID sql.NullInt64
-		var clusterAreaKm2 sql.NullFloat64
-
-		err := rows.Scan(
-			&g.ID, &g.BucketType, &bucketKey, &g.GridID,
-			&g.CenterLat, &g.CenterLon, &province, &city, &county,
-			&g.DensityScore, &g.DensityLevel,
-			&g.StayDurationS, &g.StayCount, &g.VisitDays,
-			&clusterID, &clusterAreaKm2,
-			&g.AlgoVersion, &g.CreatedAt, &g.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan density cluster: %w", err)
-		}
-
-		if bucketKey.Valid {
-			g.BucketKey = bucketKey.String
-		}
-		if province.Valid {
-			g.Province = province.String
-		}
-		if city.Valid {
-			g.City = city.String
-		}
-		if county.Valid {
-			g.County = county.String
-		}
-		if clusterID.Valid {
-			id := int(clusterID.Int64)
-			g.ClusterID = &id
-		}
-		if clusterAreaKm2.Valid {
-			g.ClusterAreaKm2 = &clusterAreaKm2.Float64
-		}
-
-		results = append(results, g)
+	if areaKey.Valid {
+		s.AreaKey = areaKey.String
 	}
-
-	return results, nil
+	return s, err
 }
 
-// GetAltitudeStats retrieves altitude statistics with filters
+// GetAltitudeStats retrieves a page of altitude statistics with filters
 func (r *StatsRepository) GetAltitudeStats(
 	bucketType string,
+	source string,
 	areaType string,
 	areaKey string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			min_altitude, max_altitude, avg_altitude, altitude_span,
-			p25_altitude, p50_altitude, p75_altitude, p90_altitude,
-			total_ascent, total_descent, vertical_intensity,
-			point_count, segment_count, total_distance,
-			algo_version, created_at, updated_at
-		FROM altitude_stats_bucketed
-		WHERE 1=1
-	`
-	args := []interface{}{}
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	q := newListQuery(altitudeColumns, "altitude_stats_bucketed").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(areaType != "", "area_type = ?", areaType).
+		whereIf(areaKey != "", "area_key = ?", areaKey)
+	return queryList(r.db, q, altitudeSort, opts, "altitude stats", scanAltitude)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
+// GetHighestAltitudeSpans retrieves areas with highest altitude spans
+func (r *StatsRepository) GetHighestAltitudeSpans(
+	bucketType string,
+	source string,
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	q := newListQuery(altitudeColumns, "altitude_stats_bucketed").
+		where("altitude_span > 0").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType)
+	return queryList(r.db, q, altitudeSort, opts, "highest altitude spans", scanAltitude)
+}
 
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
+// GetHighestVerticalIntensity retrieves areas with highest vertical intensity
+func (r *StatsRepository) GetHighestVerticalIntensity(
+	bucketType string,
+	source string,
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	q := newListQuery(altitudeColumns, "altitude_stats_bucketed").
+		where("vertical_intensity > 0").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType)
+	sort := altitudeSort.withDefault("vertical_intensity", "DESC")
+	return queryList(r.db, q, sort, opts, "highest vertical intensity", scanAltitude)
+}
 
-	if areaKey != "" {
-		query += " AND area_key = ?"
-		args = append(args, areaKey)
-	}
+const compressionColumns = `id, bucket_type, bucket_key, source, area_type, area_key,
+		movement_intensity, burst_intensity, burst_count, burst_duration_s,
+		active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio,
+		avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index,
+		total_distance_m, total_duration_s, trip_count, distinct_days,
+		algo_version, created_at, updated_at`
+
+var compressionSort = sortSpec{
+	fields: sortFields("area_key", "movement_intensity", "burst_intensity", "burst_count", "burst_duration_s",
+		"active_time_s", "activity_ratio", "effective_movement_ratio", "avg_speed_kmh", "max_speed_kmh",
+		"distance_per_day", "time_compression_index", "total_distance_m", "total_duration_s", "trip_count", "distinct_days"),
+	defaultField: "time_compression_index",
+	defaultOrder: "DESC",
+}
 
-	query += " ORDER BY altitude_span DESC LIMIT ?"
-	args = append(args, limit)
+func scanCompression(rows *sql.Rows) (models.TimeSpaceCompression, error) {
+	var s models.TimeSpaceCompression
+	var bucketKey, areaKey sql.NullString
+
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &bucketKey, &s.Source, &s.AreaType, &areaKey,
+		&s.MovementIntensity, &s.BurstIntensity, &s.BurstCount, &s.BurstDurationS,
+		&s.ActiveTimeS, &s.InactiveTimeS, &s.ActivityRatio, &s.EffectiveMovementRatio,
+		&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.DistancePerDay, &s.TimeCompressionIndex,
+		&s.TotalDistanceM, &s.TotalDurationS, &s.TripCount, &s.DistinctDays,
+		&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
+	)
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query altitude stats: %w", err)
+	if bucketKey.Valid {
+		s.BucketKey = bucketKey.String
 	}
-	defer rows.Close()
-
-	var results []models.AltitudeStats
-	for rows.Next() {
-		var s models.AltitudeStats
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MinAltitude, &s.MaxAltitude, &s.AvgAltitude, &s.AltitudeSpan,
-			&s.P25Altitude, &s.P50Altitude, &s.P75Altitude, &s.P90Altitude,
-			&s.TotalAscent, &s.TotalDescent, &s.VerticalIntensity,
-			&s.PointCount, &s.SegmentCount, &s.TotalDistance,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan altitude stats: %w", err)
-		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
-		}
-
-		results = append(results, s)
+	if areaKey.Valid {
+		s.AreaKey = areaKey.String
 	}
+	return s, err
+}
 
-	return results, nil
+// GetTimeSpaceCompression retrieves a page of time-space compression stats with filters
+func (r *StatsRepository) GetTimeSpaceCompression(
+	bucketType string,
+	source string,
+	areaType string,
+	areaKey string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	q := newListQuery(compressionColumns, "time_space_compression_bucketed").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType).
+		whereIf(areaType != "", "area_type = ?", areaType).
+		whereIf(areaKey != "", "area_key = ?", areaKey)
+	return queryList(r.db, q, compressionSort, opts, "time-space compression", scanCompression)
 }
 
-// GetHighestAltitudeSpans retrieves areas with highest altitude spans
-func (r *StatsRepository) GetHighestAltitudeSpans(
+// GetHighestMovementIntensity retrieves areas with highest movement intensity
+func (r *StatsRepository) GetHighestMovementIntensity(
 	bucketType string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			min_altitude, max_altitude, avg_altitude, altitude_span,
-			p25_altitude, p50_altitude, p75_altitude, p90_altitude,
-			total_ascent, total_descent, vertical_intensity,
-			point_count, segment_count, total_distance,
-			algo_version, created_at, updated_at
-		FROM altitude_stats_bucketed
-		WHERE altitude_span > 0
-	`
-	args := []interface{}{}
+	source string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	q := newListQuery(compressionColumns, "time_space_compression_bucketed").
+		where("movement_intensity > 0").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType)
+	sort := compressionSort.withDefault("movement_intensity", "DESC")
+	return queryList(r.db, q, sort, opts, "highest movement intensity", scanCompression)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
+// GetBurstPeriods retrieves areas with most burst periods
+func (r *StatsRepository) GetBurstPeriods(
+	bucketType string,
+	source string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	q := newListQuery(compressionColumns, "time_space_compression_bucketed").
+		where("burst_count > 0").
+		where("source = ?", source).
+		whereIf(bucketType != "", "bucket_type = ?", bucketType)
+	sort := compressionSort.withDefault("burst_count", "DESC")
+	sort.then = "burst_intensity DESC"
+	return queryList(r.db, q, sort, opts, "burst periods", scanCompression)
+}
 
-	query += " ORDER BY altitude_span DESC LIMIT ?"
-	args = append(args, limit)
+const modeStatsColumns = `id, bucket_type, bucket_key, source, mode,
+		distance_m, duration_s, segment_count, trip_count, co2_kg,
+		algo_version, created_at`
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query highest altitude spans: %w", err)
-	}
-	defer rows.Close()
+var modeStatsSort = sortSpec{
+	fields:       sortFields("bucket_key", "mode", "distance_m", "duration_s", "segment_count", "trip_count", "co2_kg"),
+	defaultField: "bucket_key",
+	defaultOrder: "DESC",
+	then:         "distance_m DESC",
+}
 
-	var results []models.AltitudeStats
-	for rows.Next() {
-		var s models.AltitudeStats
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MinAltitude, &s.MaxAltitude, &s.AvgAltitude, &s.AltitudeSpan,
-			&s.P25Altitude, &s.P50Altitude, &s.P75Altitude, &s.P90Altitude,
-			&s.TotalAscent, &s.TotalDescent, &s.VerticalIntensity,
-			&s.PointCount, &s.SegmentCount, &s.TotalDistance,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan altitude stats: %w", err)
-		}
+func scanModeStats(rows *sql.Rows) (models.ModeStats, error) {
+	var s models.ModeStats
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &s.BucketKey, &s.Source, &s.Mode,
+		&s.DistanceM, &s.DurationS, &s.SegmentCount, &s.TripCount, &s.CO2Kg,
+		&s.AlgoVersion, &s.CreatedAt,
+	)
+	return s, err
+}
 
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
-		}
+// GetModeBreakdown retrieves a page of per-mode totals of a bucket type and source
+// bucketKey and mode narrow the rows when given.
+func (r *StatsRepository) GetModeBreakdown(
+	bucketType string,
+	bucketKey string,
+	source string,
+	mode string,
+	opts models.QueryOptions,
+) ([]models.ModeStats, int64, error) {
+	q := modeBreakdownQuery(bucketType, bucketKey, source, mode)
+	return queryList(r.db, q, modeStatsSort, opts, "mode breakdown", scanModeStats)
+}
 
-		results = append(results, s)
-	}
+// modeBreakdownQuery selects mode rows of a bucket type and source, and of a bucket key and mode when given
+func modeBreakdownQuery(bucketType, bucketKey, source, mode string) *listQuery {
+	return newListQuery(modeStatsColumns, "mode_stats_bucketed").
+		where("bucket_type = ?", bucketType).
+		where("source = ?", source).
+		whereIf(bucketKey != "", "bucket_key = ?", bucketKey).
+		whereIf(mode != "", "mode = ?", mode)
+}
 
-	return results, nil
+const daypartStatsColumns = `id, bucket_type, bucket_key, source, daypart,
+		distance_m, active_s, tracked_s, point_count, day_count, grid_count, city_count,
+		distance_share, active_share, algo_version, created_at`
+
+// daypartOrder sorts parts of the day in daily order rather than by name
+const daypartOrder = `CASE daypart WHEN 'MORNING' THEN 0 WHEN 'AFTERNOON' THEN 1 WHEN 'EVENING' THEN 2 ELSE 3 END`
+
+var daypartStatsSort = sortSpec{
+	fields: func() map[string]string {
+		fields := sortFields("bucket_key", "distance_m", "active_s", "point_count", "grid_count", "city_count", "distance_share", "active_share")
+		fields["daypart"] = daypartOrder
+		return fields
+	}(),
+	defaultField: "bucket_key",
+	defaultOrder: "DESC",
+	then:         daypartOrder,
 }
 
-// GetHighestVerticalIntensity retrieves areas with highest vertical intensity
-func (r *StatsRepository) GetHighestVerticalIntensity(
-	bucketType string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			min_altitude, max_altitude, avg_altitude, altitude_span,
-			p25_altitude, p50_altitude, p75_altitude, p90_altitude,
-			total_ascent, total_descent, vertical_intensity,
-			point_count, segment_count, total_distance,
-			algo_version, created_at, updated_at
-		FROM altitude_stats_bucketed
-		WHERE vertical_intensity > 0
-	`
-	args := []interface{}{}
+func scanDaypartStats(rows *sql.Rows) (models.DaypartStats, error) {
+	var s models.DaypartStats
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &s.BucketKey, &s.Source, &s.Daypart,
+		&s.DistanceM, &s.ActiveS, &s.TrackedS, &s.PointCount, &s.DayCount, &s.GridCount, &s.CityCount,
+		&s.DistanceShare, &s.ActiveShare, &s.AlgoVersion, &s.CreatedAt,
+	)
+	return s, err
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
+// GetDaypartStats retrieves a page of per-daypart totals of a bucket type and source
+// bucketKey and daypart narrow the rows when given.
+func (r *StatsRepository) GetDaypartStats(
+	bucketType string,
+	bucketKey string,
+	source string,
+	daypart string,
+	opts models.QueryOptions,
+) ([]models.DaypartStats, int64, error) {
+	q := newListQuery(daypartStatsColumns, "daypart_stats_bucketed").
+		where("bucket_type = ?", bucketType).
+		where("source = ?", source).
+		whereIf(bucketKey != "", "bucket_key = ?", bucketKey).
+		whereIf(daypart != "", "daypart = ?", daypart)
+	return queryList(r.db, q, daypartStatsSort, opts, "daypart stats", scanDaypartStats)
+}
 
-	query += " ORDER BY vertical_intensity DESC LIMIT ?"
-	args = append(args, limit)
+const dayTypeStatsColumns = `id, bucket_type, bucket_key, day_type, day_count,
+		total_distance_m, avg_distance_m, avg_active_time_s, trip_count, avg_trip_count, distance_by_mode,
+		city_count, avg_city_count, stay_count, stay_duration_s, stay_types, algo_version, created_at`
+
+// dayTypeOrder sorts day types as workday, weekend, holiday rather than by name
+const dayTypeOrder = `CASE day_type WHEN 'WORKDAY' THEN 0 WHEN 'WEEKEND' THEN 1 ELSE 2 END`
+
+var dayTypeStatsSort = sortSpec{
+	fields: func() map[string]string {
+		fields := sortFields("bucket_key", "day_count", "total_distance_m", "avg_distance_m", "avg_active_time_s",
+			"avg_trip_count", "city_count", "avg_city_count", "stay_count")
+		fields["day_type"] = dayTypeOrder
+		return fields
+	}(),
+	defaultField: "bucket_key",
+	defaultOrder: "DESC",
+	then:         dayTypeOrder,
+}
 
-	rows, err := r.db.Query(query, args...)
+func scanDayTypeStats(rows *sql.Rows) (models.DayTypeStats, error) {
+	var s models.DayTypeStats
+	var modeJSON, stayTypesJSON sql.NullString
+	err := rows.Scan(
+		&s.ID, &s.BucketType, &s.BucketKey, &s.DayType, &s.DayCount,
+		&s.TotalDistanceM, &s.AvgDistanceM, &s.AvgActiveTimeS, &s.TripCount, &s.AvgTripCount, &modeJSON,
+		&s.CityCount, &s.AvgCityCount, &s.StayCount, &s.StayDurationS, &stayTypesJSON,
+		&s.AlgoVersion, &s.CreatedAt,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query highest vertical intensity: %w", err)
+		return s, err
 	}
-	defer rows.Close()
 
-	var results []models.AltitudeStats
-	for rows.Next() {
-		var s models.AltitudeStats
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MinAltitude, &s.MaxAltitude, &s.AvgAltitude, &s.AltitudeSpan,
-			&s.P25Altitude, &s.P50Altitude, &s.P75Altitude, &s.P90Altitude,
-			&s.TotalAscent, &s.TotalDescent, &s.VerticalIntensity,
-			&s.PointCount, &s.SegmentCount, &s.TotalDistance,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan altitude stats: %w", err)
+	s.DistanceByMode = map[string]float64{}
+	if modeJSON.Valid && modeJSON.String != "" {
+		if err := json.Unmarshal([]byte(modeJSON.String), &s.DistanceByMode); err != nil {
+			return s, fmt.Errorf("invalid distance_by_mode: %w", err)
 		}
-
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
+	}
+	s.StayTypes = map[string]models.StayTypeTotal{}
+	if stayTypesJSON.Valid && stayTypesJSON.String != "" {
+		if err := json.Unmarshal([]byte(stayTypesJSON.String), &s.StayTypes); err != nil {
+			return s, fmt.Errorf("invalid stay_types: %w", err)
 		}
-
-		results = append(results, s)
 	}
-
-	return results, nil
+	return s, nil
 }
 
-// GetTimeSpaceCompression retrieves time-space compression stats with filters
-func (r *StatsRepository) GetTimeSpaceCompression(
+// GetDayTypeStats retrieves a page of workday, weekend and holiday totals of a bucket type
+// bucketKey and dayType narrow the rows when given.
+func (r *StatsRepository) GetDayTypeStats(
 	bucketType string,
-	areaType string,
-	areaKey string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			movement_intensity, burst_intensity, burst_count, burst_duration_s,
-			active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio,
-			avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index,
-			total_distance_m, total_duration_s, trip_count, distinct_days,
-			algo_version, created_at, updated_at
-		FROM time_space_compression_bucketed
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
-
-	if areaType != "" {
-		query += " AND area_type = ?"
-		args = append(args, areaType)
-	}
+	bucketKey string,
+	dayType string,
+	opts models.QueryOptions,
+) ([]models.DayTypeStats, int64, error) {
+	q := newListQuery(dayTypeStatsColumns, "day_type_stats_bucketed").
+		where("bucket_type = ?", bucketType).
+		whereIf(bucketKey != "", "bucket_key = ?", bucketKey).
+		whereIf(dayType != "", "day_type = ?", dayType)
+	return queryList(r.db, q, dayTypeStatsSort, opts, "day type stats", scanDayTypeStats)
+}
 
-	if areaKey != "" {
-		query += " AND area_key = ?"
-		args = append(args, areaKey)
-	}
+const anomalousDayColumns = `id, date, distance_m, radius_of_gyration_m, stay_count, novel_grid_count,
+		baseline_days, distance_z, radius_z, stay_z, novel_z, score, category, reasons,
+		algo_version, created_at`
 
-	query += " ORDER BY time_compression_index DESC LIMIT ?"
-	args = append(args, limit)
+var anomalousDaySort = sortSpec{
+	fields:       sortFields("date", "score", "distance_m", "radius_of_gyration_m", "stay_count", "novel_grid_count"),
+	defaultField: "date",
+	defaultOrder: "DESC",
+}
 
-	rows, err := r.db.Query(query, args...)
+func scanAnomalousDay(rows *sql.Rows) (models.AnomalousDay, error) {
+	var d models.AnomalousDay
+	var reasonsJSON sql.NullString
+	err := rows.Scan(
+		&d.ID, &d.Date, &d.DistanceM, &d.RadiusOfGyrationM, &d.StayCount, &d.NovelGridCount,
+		&d.BaselineDays, &d.DistanceZ, &d.RadiusZ, &d.StayZ, &d.NovelZ, &d.Score, &d.Category, &reasonsJSON,
+		&d.AlgoVersion, &d.CreatedAt,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query time-space compression: %w", err)
+		return d, err
 	}
-	defer rows.Close()
-
-	var results []models.TimeSpaceCompression
-	for rows.Next() {
-		var s models.TimeSpaceCompression
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MovementIntensity, &s.BurstIntensity, &s.BurstCount, &s.BurstDurationS,
-			&s.ActiveTimeS, &s.InactiveTimeS, &s.ActivityRatio, &s.EffectiveMovementRatio,
-			&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.DistancePerDay, &s.TimeCompressionIndex,
-			&s.TotalDistanceM, &s.TotalDurationS, &s.TripCount, &s.DistinctDays,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan time-space compression: %w", err)
-		}
 
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
+	d.Reasons = []string{}
+	if reasonsJSON.Valid && reasonsJSON.String != "" {
+		if err := json.Unmarshal([]byte(reasonsJSON.String), &d.Reasons); err != nil {
+			return d, fmt.Errorf("invalid reasons: %w", err)
 		}
-
-		results = append(results, s)
 	}
-
-	return results, nil
+	return d, nil
 }
 
-// GetHighestMovementIntensity retrieves areas with highest movement intensity
-func (r *StatsRepository) GetHighestMovementIntensity(
-	bucketType string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			movement_intensity, burst_intensity, burst_count, burst_duration_s,
-			active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio,
-			avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index,
-			total_distance_m, total_duration_s, trip_count, distinct_days,
-			algo_version, created_at, updated_at
-		FROM time_space_compression_bucketed
-		WHERE movement_intensity > 0
-	`
-	args := []interface{}{}
+// GetAnomalousDays retrieves a page of anomalous days matching the filter
+func (r *StatsRepository) GetAnomalousDays(
+	filter models.AnomalousDayFilter,
+	opts models.QueryOptions,
+) ([]models.AnomalousDay, int64, error) {
+	q := newListQuery(anomalousDayColumns, "anomalous_days").
+		whereIf(filter.From != "", "date >= ?", filter.From).
+		whereIf(filter.To != "", "date <= ?", filter.To).
+		whereIf(filter.Category != "", "category = ?", filter.Category).
+		whereIf(filter.Reason != "", "EXISTS (SELECT 1 FROM json_each(reasons) WHERE value = ?)", filter.Reason).
+		whereIf(filter.MinScore > 0, "score >= ?", filter.MinScore)
+	return queryList(r.db, q, anomalousDaySort, opts, "anomalous days", scanAnomalousDay)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
-	}
+const explorationMonthColumns = `id, month, point_count, novel_point_count, tracked_s, novel_time_s,
+		novel_point_ratio, novel_time_ratio, new_cell_count, cumulative_cell_count,
+		active_days, discovery_days, algo_version, created_at`
 
-	query += " ORDER BY movement_intensity DESC LIMIT ?"
-	args = append(args, limit)
+// GetExplorationMonths retrieves the exploration curve in month order
+// from and to (YYYY-MM, inclusive) bound the months when given.
+func (r *StatsRepository) GetExplorationMonths(from, to string) ([]models.ExplorationMonth, error) {
+	cond := conditions{}.
+		andIf(from != "", "month >= ?", from).
+		andIf(to != "", "month <= ?", to)
+	query := "SELECT " + explorationMonthColumns + " FROM exploration_monthly" + cond.where() + " ORDER BY month"
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.Query(query, cond.args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query highest movement intensity: %w", err)
+		return nil, fmt.Errorf("failed to query exploration months: %w", err)
 	}
 	defer rows.Close()
 
-	var results []models.TimeSpaceCompression
+	months := []models.ExplorationMonth{}
 	for rows.Next() {
-		var s models.TimeSpaceCompression
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MovementIntensity, &s.BurstIntensity, &s.BurstCount, &s.BurstDurationS,
-			&s.ActiveTimeS, &s.InactiveTimeS, &s.ActivityRatio, &s.EffectiveMovementRatio,
-			&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.DistancePerDay, &s.TimeCompressionIndex,
-			&s.TotalDistanceM, &s.TotalDurationS, &s.TripCount, &s.DistinctDays,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan time-space compression: %w", err)
+		var m models.ExplorationMonth
+		if err := rows.Scan(
+			&m.ID, &m.Month, &m.PointCount, &m.NovelPointCount, &m.TrackedS, &m.NovelTimeS,
+			&m.NovelPointRatio, &m.NovelTimeRatio, &m.NewCellCount, &m.CumulativeCellCount,
+			&m.ActiveDays, &m.DiscoveryDays, &m.AlgoVersion, &m.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan exploration month: %w", err)
 		}
+		months = append(months, m)
+	}
+	return months, rows.Err()
+}
 
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
-		}
+const discoveryStreakColumns = `id, start_date, end_date, days_count, new_cell_count, algo_version, created_at`
 
-		results = append(results, s)
-	}
+var discoveryStreakSort = sortSpec{
+	fields:       sortFields("days_count", "start_date", "new_cell_count"),
+	defaultField: "days_count",
+	defaultOrder: "DESC",
+}
 
-	return results, nil
+func scanDiscoveryStreak(rows *sql.Rows) (models.DiscoveryStreak, error) {
+	var s models.DiscoveryStreak
+	err := rows.Scan(&s.ID, &s.StartDate, &s.EndDate, &s.DaysCount, &s.NewCellCount, &s.AlgoVersion, &s.CreatedAt)
+	return s, err
 }
 
-// GetBurstPeriods retrieves areas with most burst periods
-func (r *StatsRepository) GetBurstPeriods(
-	bucketType string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	query := `
-		SELECT
-			id, bucket_type, bucket_key, area_type, area_key,
-			movement_intensity, burst_intensity, burst_count, burst_duration_s,
-			active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio,
-			avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index,
-			total_distance_m, total_duration_s, trip_count, distinct_days,
-			algo_version, created_at, updated_at
-		FROM time_space_compression_bucketed
-		WHERE burst_count > 0
-	`
-	args := []interface{}{}
+// GetDiscoveryStreaks retrieves a page of discovery streaks
+// from and to (YYYY-MM-DD, inclusive) keep the streaks that overlap them when given.
+func (r *StatsRepository) GetDiscoveryStreaks(from, to string, opts models.QueryOptions) ([]models.DiscoveryStreak, int64, error) {
+	q := newListQuery(discoveryStreakColumns, "discovery_streaks").
+		whereIf(from != "", "end_date >= ?", from).
+		whereIf(to != "", "start_date <= ?", to)
+	return queryList(r.db, q, discoveryStreakSort, opts, "discovery streaks", scanDiscoveryStreak)
+}
 
-	if bucketType != "" {
-		query += " AND bucket_type = ?"
-		args = append(args, bucketType)
+// firstVisitCrossingWindowS is how long after the first point in an area a
+// crossing into it still counts as the entry; crossings are detected on the
+// first point past the boundary, so they normally coincide
+const firstVisitCrossingWindowS = 3600
+
+// firstVisitsTable joins the all-time footprint of each city and county with
+// the crossing into it and the trip under way at its first visit
+var firstVisitsTable = fmt.Sprintf(`(
+		SELECT f.id, f.stat_type AS level, f.stat_key AS area, f.first_visit,
+			COALESCE(f.visit_count, 0) AS visit_count, COALESCE(f.point_count, 0) AS point_count,
+			COALESCE(c.to_province, p.province, '') AS province,
+			CASE f.stat_type WHEN 'COUNTY' THEN COALESCE(c.to_city, p.city, '') ELSE '' END AS city,
+			CASE WHEN c.id IS NULL THEN 'FIRST_POINT' ELSE 'CROSSING' END AS entry_type,
+			COALESCE(c.latitude, p.latitude) AS entry_latitude,
+			COALESCE(c.longitude, p.longitude) AS entry_longitude,
+			COALESCE(CASE f.stat_type WHEN 'CITY' THEN c.from_city ELSE c.from_county END, '') AS from_area,
+			c.id AS crossing_id,
+			(SELECT t.id FROM trips t
+				WHERE t.start_time <= f.first_visit AND t.end_time >= f.first_visit
+				ORDER BY t.start_time DESC LIMIT 1) AS trip_id
+		FROM footprint_statistics f
+		LEFT JOIN admin_crossings c ON c.id = (
+			SELECT ac.id FROM admin_crossings ac
+			WHERE ac.crossing_ts BETWEEN f.first_visit AND f.first_visit + %d
+				AND CASE f.stat_type WHEN 'CITY' THEN ac.to_city ELSE ac.to_county END = f.stat_key
+				AND CASE f.stat_type WHEN 'CITY' THEN ac.from_city ELSE ac.from_county END IS NOT f.stat_key
+			ORDER BY ac.crossing_ts LIMIT 1)
+		LEFT JOIN "一生足迹" p ON p.id = (
+			SELECT tp.id FROM "一生足迹" tp
+			WHERE tp.dataTime = f.first_visit
+				AND CASE f.stat_type WHEN 'CITY' THEN tp.city ELSE tp.county END = f.stat_key
+			ORDER BY tp.id LIMIT 1)
+		WHERE f.stat_type IN ('CITY', 'COUNTY') AND f.time_range = 'all' AND f.first_visit IS NOT NULL
+	) first_visits`, firstVisitCrossingWindowS)
+
+const firstVisitColumns = `id, level, area, province, city, first_visit, visit_count, point_count,
+		entry_type, entry_latitude, entry_longitude, from_area, crossing_id, trip_id`
+
+var firstVisitSort = sortSpec{
+	fields:       sortFields("first_visit", "area", "visit_count", "point_count"),
+	defaultField: "first_visit",
+	defaultOrder: "ASC",
+}
+
+func scanFirstVisit(rows *sql.Rows) (models.FirstVisit, error) {
+	var v models.FirstVisit
+	var lat, lon sql.NullFloat64
+	var crossingID, tripID sql.NullInt64
+	err := rows.Scan(
+		&v.ID, &v.Level, &v.Area, &v.Province, &v.City, &v.FirstVisit, &v.VisitCount, &v.PointCount,
+		&v.EntryType, &lat, &lon, &v.FromArea, &crossingID, &tripID,
+	)
+	if err != nil {
+		return v, err
+	}
+
+	v.Date = time.Unix(v.FirstVisit, 0).Format("2006-01-02")
+	if lat.Valid && lon.Valid {
+		v.EntryLatitude, v.EntryLongitude = &lat.Float64, &lon.Float64
+	}
+	if crossingID.Valid {
+		v.CrossingID = &crossingID.Int64
 	}
+	if tripID.Valid {
+		v.TripID = &tripID.Int64
+	}
+	return v, nil
+}
 
-	query += " ORDER BY burst_count DESC, burst_intensity DESC LIMIT ?"
-	args = append(args, limit)
+// GetFirstVisits retrieves a page of the first visits of the cities or counties
+// startTime and endTime (Unix, 0 for open) bound the first visits.
+func (r *StatsRepository) GetFirstVisits(level string, startTime, endTime int64, opts models.QueryOptions) ([]models.FirstVisit, int64, error) {
+	q := newListQuery(firstVisitColumns, firstVisitsTable).
+		where("level = ?", level).
+		whereIf(startTime > 0, "first_visit >= ?", startTime).
+		whereIf(endTime > 0, "first_visit < ?", endTime)
+	return queryList(r.db, q, firstVisitSort, opts, "first visits", scanFirstVisit)
+}
 
-	rows, err := r.db.Query(query, args...)
+// GetPersonalRecords retrieves all personal records in a fixed, display friendly order
+func (r *StatsRepository) GetPersonalRecords() ([]models.PersonalRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT id, record_type, value, unit, start_time, end_time, date, end_date,
+			point_id, segment_id, trip_id, stay_id, latitude, longitude, details,
+			algo_version, created_at
+		FROM personal_records
+		ORDER BY CASE record_type
+			WHEN 'LONGEST_TRACKING_STREAK' THEN 0
+			WHEN 'LONGEST_DAY_DISTANCE' THEN 1
+			WHEN 'MOST_CITIES_IN_DAY' THEN 2
+			WHEN 'LONGEST_TRIP' THEN 3
+			WHEN 'LONGEST_WALK' THEN 4
+			WHEN 'FASTEST_GROUND_SEGMENT' THEN 5
+			WHEN 'LONGEST_STAY' THEN 6
+			ELSE 7 END, record_type`)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query burst periods: %w", err)
+		return nil, fmt.Errorf("failed to query personal records: %w", err)
 	}
 	defer rows.Close()
 
-	var results []models.TimeSpaceCompression
+	records := []models.PersonalRecord{}
 	for rows.Next() {
-		var s models.TimeSpaceCompression
-		var bucketKey, areaKey sql.NullString
-
-		err := rows.Scan(
-			&s.ID, &s.BucketType, &bucketKey, &s.AreaType, &areaKey,
-			&s.MovementIntensity, &s.BurstIntensity, &s.BurstCount, &s.BurstDurationS,
-			&s.ActiveTimeS, &s.InactiveTimeS, &s.ActivityRatio, &s.EffectiveMovementRatio,
-			&s.AvgSpeedKmh, &s.MaxSpeedKmh, &s.DistancePerDay, &s.TimeCompressionIndex,
-			&s.TotalDistanceM, &s.TotalDurationS, &s.TripCount, &s.DistinctDays,
-			&s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan time-space compression: %w", err)
-		}
+		var rec models.PersonalRecord
+		var startTime, endTime, pointID, segmentID, tripID, stayID sql.NullInt64
+		var date, endDate, details sql.NullString
+		var lat, lon sql.NullFloat64
+		if err := rows.Scan(
+			&rec.ID, &rec.RecordType, &rec.Value, &rec.Unit, &startTime, &endTime, &date, &endDate,
+			&pointID, &segmentID, &tripID, &stayID, &lat, &lon, &details,
+			&rec.AlgoVersion, &rec.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan personal record: %w", err)
+		}
+
+		rec.StartTime = nullInt64Ptr(startTime)
+		rec.EndTime = nullInt64Ptr(endTime)
+		rec.Date, rec.EndDate = date.String, endDate.String
+		rec.PointID = nullInt64Ptr(pointID)
+		rec.SegmentID = nullInt64Ptr(segmentID)
+		rec.TripID = nullInt64Ptr(tripID)
+		rec.StayID = nullInt64Ptr(stayID)
+		if lat.Valid && lon.Valid {
+			rec.Latitude, rec.Longitude = &lat.Float64, &lon.Float64
+		}
+		if details.Valid && details.String != "" {
+			if err := json.Unmarshal([]byte(details.String), &rec.Details); err != nil {
+				return nil, fmt.Errorf("invalid details of %s: %w", rec.RecordType, err)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
 
-		if bucketKey.Valid {
-			s.BucketKey = bucketKey.String
-		}
-		if areaKey.Valid {
-			s.AreaKey = areaKey.String
-		}
+// queryBucketMetrics reads one row holding the number of source rows followed
+// by the named metrics, and returns nil when there were no source rows
+func (r *StatsRepository) queryBucketMetrics(what string, names []string, query string, args ...interface{}) ([]models.MetricValue, error) {
+	var n int64
+	values := make([]sql.NullFloat64, len(names))
+	dest := []interface{}{&n}
+	for i := range values {
+		dest = append(dest, &values[i])
+	}
+	if err := r.db.QueryRow(query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	if n == 0 {
+		return nil, nil
+	}
 
-		results = append(results, s)
+	metrics := make([]models.MetricValue, len(names))
+	for i, name := range names {
+		metrics[i].Metric = name
+		if values[i].Valid {
+			v := values[i].Float64
+			metrics[i].Value = &v
+		}
 	}
+	return metrics, nil
+}
 
-	return results, nil
+// GetFootprintBucketMetrics retrieves the admin areas visited and the totals of
+// a year or month; totals are summed over provinces so nothing counts twice
+func (r *StatsRepository) GetFootprintBucketMetrics(bucketKey string) ([]models.MetricValue, error) {
+	return r.queryBucketMetrics("footprint bucket",
+		[]string{"province_count", "city_count", "county_count", "town_count", "point_count", "distance_m", "duration_s"}, `
+		SELECT COUNT(*),
+			SUM(stat_type = 'PROVINCE'), SUM(stat_type = 'CITY'), SUM(stat_type = 'COUNTY'), SUM(stat_type = 'TOWN'),
+			SUM(CASE WHEN stat_type = 'PROVINCE' THEN point_count END),
+			SUM(CASE WHEN stat_type = 'PROVINCE' THEN total_distance_m END),
+			SUM(CASE WHEN stat_type = 'PROVINCE' THEN total_duration_s END)
+		FROM footprint_statistics
+		WHERE time_range = ?`, bucketKey)
 }
 
-// GetTimeSpaceSlices retrieves time-space slices with filters
-func (r *StatsRepository) GetTimeSpaceSlices(
-	sliceType string,
-	limit int,
-) ([]models.TimeSpaceSlice, error) {
-	query := `
-		SELECT id, slice_type, slice_key, admin_level, admin_name, grid_id,
-		       point_count, distance_m, duration_s, unique_locations,
-		       algo_version, created_at
-		FROM time_space_slices
-		WHERE 1=1
-	`
-	args := []interface{}{}
+// GetSpeedSpaceBucketMetrics retrieves the distance-weighted speed and the
+// high-speed and slow-life cities of a bucket
+func (r *StatsRepository) GetSpeedSpaceBucketMetrics(bucketType, bucketKey string) ([]models.MetricValue, error) {
+	return r.queryBucketMetrics("speed-space bucket",
+		[]string{"avg_speed_kmh", "distance_m", "segment_count", "high_speed_city_count", "slow_life_city_count"}, `
+		SELECT COUNT(*),
+			SUM(CASE WHEN area_type = 'PROVINCE' THEN avg_speed * total_distance END)
+				/ NULLIF(SUM(CASE WHEN area_type = 'PROVINCE' THEN total_distance END), 0),
+			SUM(CASE WHEN area_type = 'PROVINCE' THEN total_distance END),
+			SUM(CASE WHEN area_type = 'PROVINCE' THEN segment_count END),
+			SUM(area_type = 'CITY' AND is_high_speed_zone = 1),
+			SUM(area_type = 'CITY' AND is_slow_life_zone = 1)
+		FROM speed_space_stats_bucketed
+		WHERE bucket_type = ? AND bucket_key = ? AND source = 'all'`, bucketType, bucketKey)
+}
 
-	if sliceType != "" {
-		query += " AND slice_type = ?"
-		args = append(args, sliceType)
-	}
+// GetCompressionBucketMetrics retrieves the overall time-space compression of a bucket
+func (r *StatsRepository) GetCompressionBucketMetrics(bucketType, bucketKey string) ([]models.MetricValue, error) {
+	return r.queryBucketMetrics("compression bucket",
+		[]string{"movement_intensity", "activity_ratio", "avg_speed_kmh", "max_speed_kmh", "distance_per_day",
+			"time_compression_index", "burst_count", "distance_m", "trip_count", "distinct_days"}, `
+		SELECT COUNT(*), MAX(movement_intensity), MAX(activity_ratio), MAX(avg_speed_kmh), MAX(max_speed_kmh),
+			MAX(distance_per_day), MAX(time_compression_index), MAX(burst_count), MAX(total_distance_m),
+			MAX(trip_count), MAX(distinct_days)
+		FROM time_space_compression_bucketed
+		WHERE bucket_type = ? AND bucket_key = ? AND area_type = 'ALL' AND source = 'all'`, bucketType, bucketKey)
+}
 
-	query += " ORDER BY slice_key LIMIT ?"
-	args = append(args, limit)
+// GetAltitudeBucketMetrics retrieves the overall altitude statistics of a bucket
+func (r *StatsRepository) GetAltitudeBucketMetrics(bucketType, bucketKey string) ([]models.MetricValue, error) {
+	return r.queryBucketMetrics("altitude bucket",
+		[]string{"min_altitude", "max_altitude", "avg_altitude", "altitude_span",
+			"total_ascent", "total_descent", "vertical_intensity"}, `
+		SELECT COUNT(*), MAX(min_altitude), MAX(max_altitude), MAX(avg_altitude), MAX(altitude_span),
+			MAX(total_ascent), MAX(total_descent), MAX(vertical_intensity)
+		FROM altitude_stats_bucketed
+		WHERE bucket_type = ? AND bucket_key = ? AND area_type = 'ALL' AND source = 'all'`, bucketType, bucketKey)
+}
 
-	rows, err := r.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query time-space slices: %w", err)
-	}
-	defer rows.Close()
+const sliceColumns = `id, slice_type, slice_key, admin_level, admin_name, grid_id,
+		point_count, distance_m, duration_s, unique_locations,
+		algo_version, created_at`
 
-	var results []models.TimeSpaceSlice
-	for rows.Next() {
-		var slice models.TimeSpaceSlice
-		var adminLevel, adminName, gridID sql.NullString
-
-		err := rows.Scan(
-			&slice.ID, &slice.SliceType, &slice.SliceKey,
-			&adminLevel, &adminName, &gridID,
-			&slice.PointCount, &slice.DistanceM, &slice.DurationS, &slice.UniqueLocations,
-			&slice.AlgoVersion, &slice.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan time-space slice: %w", err)
-		}
+var sliceSort = sortSpec{
+	fields:       sortFields("slice_key", "point_count", "distance_m", "duration_s", "unique_locations"),
+	defaultField: "slice_key",
+	defaultOrder: "ASC",
+}
 
-		if adminLevel.Valid {
-			slice.AdminLevel = adminLevel.String
-		}
-		if adminName.Valid {
-			slice.AdminName = adminName.String
-		}
-		if gridID.Valid {
-			slice.GridID = gridID.String
-		}
+func scanSlice(rows *sql.Rows) (models.TimeSpaceSlice, error) {
+	var slice models.TimeSpaceSlice
+	var adminLevel, adminName, gridID sql.NullString
 
-		results = append(results, slice)
-	}
+	err := rows.Scan(
+		&slice.ID, &slice.SliceType, &slice.SliceKey,
+		&adminLevel, &adminName, &gridID,
+		&slice.PointCount, &slice.DistanceM, &slice.DurationS, &slice.UniqueLocations,
+		&slice.AlgoVersion, &slice.CreatedAt,
+	)
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating rows: %w", err)
+	if adminLevel.Valid {
+		slice.AdminLevel = adminLevel.String
 	}
+	if adminName.Valid {
+		slice.AdminName = adminName.String
+	}
+	if gridID.Valid {
+		slice.GridID = gridID.String
+	}
+	return slice, err
+}
 
-	return results, nil
+// GetTimeSpaceSlices retrieves a page of time-space slices with filters
+func (r *StatsRepository) GetTimeSpaceSlices(
+	sliceType string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceSlice, int64, error) {
+	q := newListQuery(sliceColumns, "time_space_slices").
+		whereIf(sliceType != "", "slice_type = ?", sliceType)
+	return queryList(r.db, q, sliceSort, opts, "time-space slices", scanSlice)
 }
 
 // GetWeeklyPattern retrieves weekly-hourly pattern (168 slices)
 func (r *StatsRepository) GetWeeklyPattern() ([]models.TimeSpaceSlice, error) {
-	return r.GetTimeSpaceSlices("WEEKLY_HOURLY", 168)
+	slices, _, err := r.GetTimeSpaceSlices("WEEKLY_HOURLY", models.QueryOptions{Limit: 168})
+	return slices, err
 }
 
 // GetHourlyPattern retrieves hourly pattern (24 slices)
 func (r *StatsRepository) GetHourlyPattern() ([]models.TimeSpaceSlice, error) {
-	return r.GetTimeSpaceSlices("HOURLY", 24)
+	slices, _, err := r.GetTimeSpaceSlices("HOURLY", models.QueryOptions{Limit: 24})
+	return slices, err
 }
 
-// GetSpatialComplexity retrieves spatial complexity metrics
-func (r *StatsRepository) GetSpatialComplexity() (*models.SpatialComplexity, error) {
-	query := `
-		SELECT id, metric_date, trajectory_complexity, direction_changes,
-		       avg_turn_angle, spatial_entropy, path_efficiency, tortuosity,
-		       algo_version, created_at
-		FROM complexity_metrics
-		ORDER BY created_at DESC
-		LIMIT 1
-	`
+const complexityColumns = `id, period_type, metric_date, trajectory_complexity, direction_changes,
+		avg_turn_angle, spatial_entropy, path_efficiency, tortuosity,
+		algo_version, created_at`
 
+var complexitySort = sortSpec{
+	fields: sortFields("metric_date", "trajectory_complexity", "direction_changes", "avg_turn_angle",
+		"spatial_entropy", "path_efficiency", "tortuosity"),
+	defaultField: "metric_date",
+	defaultOrder: "ASC",
+}
+
+func scanComplexity(rows *sql.Rows) (models.SpatialComplexity, error) {
 	var complexity models.SpatialComplexity
 	var metricDate sql.NullString
 
-	err := r.db.QueryRow(query).Scan(
-		&complexity.ID, &metricDate, &complexity.TrajectoryComplexity,
+	err := rows.Scan(
+		&complexity.ID, &complexity.PeriodType, &metricDate, &complexity.TrajectoryComplexity,
 		&complexity.DirectionChanges, &complexity.AvgTurnAngle,
 		&complexity.SpatialEntropy, &complexity.PathEfficiency,
 		&complexity.Tortuosity, &complexity.AlgoVersion, &complexity.CreatedAt,
 	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to query spatial complexity: %w", err)
-	}
 
 	if metricDate.Valid {
 		complexity.MetricDate = metricDate.String
 	}
+	return complexity, err
+}
 
-	return &complexity, nil
+// GetSpatialComplexity retrieves a page of the spatial complexity metrics of a period type
+// from and to (YYYY-MM-DD, inclusive) bound the days, or the months they fall in.
+func (r *StatsRepository) GetSpatialComplexity(periodType, from, to string, opts models.QueryOptions) ([]models.SpatialComplexity, int64, error) {
+	if periodType == "month" {
+		from, to = truncateDate(from, 7), truncateDate(to, 7)
+	}
+	q := newListQuery(complexityColumns, "complexity_metrics").
+		where("period_type = ?", periodType).
+		whereIf(from != "", "metric_date >= ?", from).
+		whereIf(to != "", "metric_date <= ?", to)
+	return queryList(r.db, q, complexitySort, opts, "spatial complexity", scanComplexity)
+}
+
+// truncateDate shortens a date to n characters, e.g. YYYY-MM-DD to its month YYYY-MM
+func truncateDate(date string, n int) string {
+	if len(date) > n {
+		return date[:n]
+	}
+	return date
+}
+
+// GetRailLineMileage retrieves the distance travelled on each high-speed rail
+// line, longest first, for one year or all years when year is 0
+func (r *StatsRepository) GetRailLineMileage(year int) ([]models.RailLineMileage, error) {
+	cond := conditions{}.andIf(year > 0, "strftime('%Y', start_time, 'unixepoch', 'localtime') = ?", fmt.Sprintf("%04d", year))
+	query := `
+		SELECT line, SUM(distance_m), COUNT(*), MIN(start_time), MAX(start_time)
+		FROM segment_rail_lines` + cond.where() + `
+		GROUP BY line ORDER BY SUM(distance_m) DESC, line`
+
+	rows, err := r.db.Query(query, cond.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rail line mileage: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []models.RailLineMileage{}
+	for rows.Next() {
+		var line models.RailLineMileage
+		var distance float64
+		if err := rows.Scan(&line.Line, &distance, &line.SegmentCount, &line.FirstTime, &line.LastTime); err != nil {
+			return nil, fmt.Errorf("failed to scan rail line mileage: %w", err)
+		}
+		line.DistanceKm = distance / 1000.0
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
 }
 
 // GetRoadOverlapSummary retrieves aggregated road overlap statistics
@@ -1979,3 +1904,227 @@ func (r *StatsRepository) GetRoadOverlapSummary() (*models.RoadOverlapSummary, e
 
 	return &summary, nil
 }
+
+// GetCommutePatterns retrieves commute patterns, optionally filtered by direction
+func (r *StatsRepository) GetCommutePatterns(direction string) ([]models.CommutePattern, error) {
+	query := `
+		SELECT id, direction, weekday, trip_count,
+		       median_departure_min, p25_departure_min, p75_departure_min,
+		       median_duration_s, avg_duration_s, p90_duration_s, avg_distance_m,
+		       route_variability, mode_split, first_trip_ts, last_trip_ts
+		FROM commute_patterns
+	`
+
+	cond := conditions{}.andIf(direction != "", "direction = ?", direction)
+	query += cond.where() + " ORDER BY direction, weekday"
+
+	rows, err := r.db.Query(query, cond.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query commute patterns: %w", err)
+	}
+	defer rows.Close()
+
+	formatMinutes := func(m int) string {
+		return fmt.Sprintf("%02d:%02d", m/60, m%60)
+	}
+
+	var patterns []models.CommutePattern
+	for rows.Next() {
+		var p models.CommutePattern
+		var p25, p75 int
+		var modeSplit sql.NullString
+
+		if err := rows.Scan(
+			&p.ID, &p.Direction, &p.Weekday, &p.TripCount,
+			&p.MedianDepartureMin, &p25, &p75,
+			&p.MedianDurationS, &p.AvgDurationS, &p.P90DurationS, &p.AvgDistanceM,
+			&p.RouteVariability, &modeSplit, &p.FirstTripTS, &p.LastTripTS,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan commute pattern: %w", err)
+		}
+
+		p.MedianDeparture = formatMinutes(p.MedianDepartureMin)
+		p.P25Departure = formatMinutes(p25)
+		p.P75Departure = formatMinutes(p75)
+
+		p.ModeSplit = make(map[string]float64)
+		if modeSplit.Valid && modeSplit.String != "" {
+			if err := json.Unmarshal([]byte(modeSplit.String), &p.ModeSplit); err != nil {
+				return nil, fmt.Errorf("failed to parse mode split: %w", err)
+			}
+		}
+
+		patterns = append(patterns, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating commute patterns: %w", err)
+	}
+
+	return patterns, nil
+}
+
+const routeClusterColumns = `id, trip_count, representative_trip_id, polyline,
+		start_lat, start_lon, end_lat, end_lon, COALESCE(primary_mode, ''),
+		avg_distance_m, avg_duration_s, min_duration_s, max_duration_s,
+		avg_speed_kmh, median_speed_kmh, max_speed_kmh, mean_dtw_m,
+		first_used_ts, last_used_ts, trip_ids`
+
+var routeClusterSort = sortSpec{
+	fields: sortFields("trip_count", "avg_distance_m", "avg_duration_s", "avg_speed_kmh", "median_speed_kmh",
+		"max_speed_kmh", "first_used_ts", "last_used_ts"),
+	defaultField: "trip_count",
+	defaultOrder: "DESC",
+}
+
+// GetRouteClusters retrieves a page of frequent routes, ordered by usage by default
+func (r *StatsRepository) GetRouteClusters(filter models.RouteClusterFilter, opts models.QueryOptions) ([]models.RouteCluster, int64, error) {
+	q := newListQuery(routeClusterColumns, "route_clusters").
+		whereIf(filter.Mode != "", "primary_mode = ?", filter.Mode).
+		whereIf(filter.MinTrips > 0, "trip_count >= ?", filter.MinTrips)
+
+	includePolyline := filter.IncludePolyline == nil || *filter.IncludePolyline
+
+	return queryList(r.db, q, routeClusterSort, opts, "route clusters", func(rows *sql.Rows) (models.RouteCluster, error) {
+		var route models.RouteCluster
+		var polyline, tripIDs sql.NullString
+
+		if err := rows.Scan(
+			&route.ID, &route.TripCount, &route.RepresentativeTripID, &polyline,
+			&route.StartLat, &route.StartLon, &route.EndLat, &route.EndLon, &route.PrimaryMode,
+			&route.AvgDistanceM, &route.AvgDurationS, &route.MinDurationS, &route.MaxDurationS,
+			&route.AvgSpeedKmh, &route.MedianSpeedKmh, &route.MaxSpeedKmh, &route.MeanDTWM,
+			&route.FirstUsedTS, &route.LastUsedTS, &tripIDs,
+		); err != nil {
+			return route, err
+		}
+
+		if includePolyline && polyline.Valid {
+			if err := json.Unmarshal([]byte(polyline.String), &route.Polyline); err != nil {
+				return route, fmt.Errorf("invalid polyline: %w", err)
+			}
+		}
+		if tripIDs.Valid {
+			if err := json.Unmarshal([]byte(tripIDs.String), &route.TripIDs); err != nil {
+				return route, fmt.Errorf("invalid trip IDs: %w", err)
+			}
+		}
+		return route, nil
+	})
+}
+
+// GetHealthCorrelations retrieves the per-day health × movement rows between two dates (inclusive)
+func (r *StatsRepository) GetHealthCorrelations(from, to string) ([]models.HealthMovementCorrelation, error) {
+	rows, err := r.db.Query(`
+		SELECT date, recorded_steps, walk_distance_m, moved_distance_m, expected_steps, step_ratio, meters_per_step,
+		       workout_count, workout_minutes, workout_distance_m,
+		       avg_heart_rate, high_altitude_hr_samples, high_altitude_avg_hr,
+		       low_altitude_hr_samples, low_altitude_avg_hr, max_altitude_m
+		FROM health_movement_correlation
+		WHERE date BETWEEN ? AND ?
+		ORDER BY date
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health correlations: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.HealthMovementCorrelation{}
+	for rows.Next() {
+		var d models.HealthMovementCorrelation
+		if err := rows.Scan(
+			&d.Date, &d.RecordedSteps, &d.WalkDistanceM, &d.MovedDistanceM, &d.ExpectedSteps, &d.StepRatio, &d.MetersPerStep,
+			&d.WorkoutCount, &d.WorkoutMinutes, &d.WorkoutDistanceM,
+			&d.AvgHeartRate, &d.HighAltitudeHRSamples, &d.HighAltitudeAvgHR,
+			&d.LowAltitudeHRSamples, &d.LowAltitudeAvgHR, &d.MaxAltitudeM,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan health correlation: %w", err)
+		}
+		days = append(days, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating health correlations: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetLatestHealthCorrelationDate returns the most recent correlated day, or "" if there is none
+func (r *StatsRepository) GetLatestHealthCorrelationDate() (string, error) {
+	var date sql.NullString
+	if err := r.db.QueryRow("SELECT MAX(date) FROM health_movement_correlation").Scan(&date); err != nil {
+		return "", fmt.Errorf("failed to query latest health correlation date: %w", err)
+	}
+	return date.String, nil
+}
+
+// versionColumns caches, per table, the columns GetTableVersion can read
+var versionColumns sync.Map
+
+// GetTableVersion returns a string that changes whenever rows of the given
+// tables are added, removed, updated or recomputed by a new algorithm version
+// It combines the row count with MAX(updated_at/created_at) and MAX(algo_version),
+// using whichever of those columns each table has.
+func (r *StatsRepository) GetTableVersion(tables ...string) (string, error) {
+	parts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		cols, err := r.versionColumnsOf(table)
+		if err != nil {
+			return "", err
+		}
+
+		var count int64
+		var changed, algo sql.NullString
+		query := fmt.Sprintf(`SELECT COUNT(*), %s, %s FROM "%s"`, cols[0], cols[1], table)
+		if err := r.db.QueryRow(query).Scan(&count, &changed, &algo); err != nil {
+			return "", fmt.Errorf("failed to query version of %s: %w", table, err)
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%s:%s", table, count, changed.String, algo.String))
+	}
+	return strings.Join(parts, ";"), nil
+}
+
+// versionColumnsOf returns the change-time and algorithm-version expressions of a table
+func (r *StatsRepository) versionColumnsOf(table string) ([2]string, error) {
+	if cols, ok := versionColumns.Load(table); ok {
+		return cols.([2]string), nil
+	}
+
+	rows, err := r.db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return [2]string{}, fmt.Errorf("failed to query columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	has := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return [2]string{}, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		has[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return [2]string{}, fmt.Errorf("error iterating columns of %s: %w", table, err)
+	}
+	if len(has) == 0 {
+		return [2]string{}, fmt.Errorf("table %s does not exist", table)
+	}
+
+	cols := [2]string{"NULL", "NULL"}
+	switch {
+	case has["updated_at"] && has["created_at"]:
+		cols[0] = "MAX(COALESCE(updated_at, created_at))"
+	case has["updated_at"]:
+		cols[0] = "MAX(updated_at)"
+	case has["created_at"]:
+		cols[0] = "MAX(created_at)"
+	}
+	if has["algo_version"] {
+		cols[1] = "MAX(algo_version)"
+	}
+
+	versionColumns.Store(table, cols)
+	return cols, nil
+}
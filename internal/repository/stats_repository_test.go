@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/database"
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// statsTestRepo opens a migrated database in a temporary directory with a
+// few geocoded points
+func statsTestRepo(t *testing.T) *StatsRepository {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatal(err)
+	}
+
+	points := []struct {
+		ts                   int64
+		province, city, town string
+	}{
+		{1700000000, "北京市", "北京市", "建外街道"},
+		{1700000600, "北京市", "北京市", "建外街道"},
+		{1700086400, "吉林省", "长春市", ""},
+	}
+	for _, p := range points {
+		if _, err := db.Exec(`INSERT INTO "一生足迹" (dataTime, longitude, latitude, province, city, county, town)
+			VALUES (?, 116.4, 39.9, ?, ?, '朝阳区', ?)`, p.ts, p.province, p.city, p.town); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewStatsRepository(db)
+}
+
+// Methods whose filters are all optional must build valid SQL when none is given
+func TestStatsRepositoryEmptyFilters(t *testing.T) {
+	r := statsTestRepo(t)
+
+	stats, err := r.GetFootprintStatistics(0, 0)
+	if err != nil {
+		t.Fatalf("GetFootprintStatistics without a time range: %v", err)
+	}
+	if stats.TotalPoints != 3 || stats.ProvinceCount != 2 || stats.CountyCount != 2 || stats.TownCount != 1 {
+		t.Errorf("GetFootprintStatistics = %d points, %d provinces, %d counties, %d towns, want 3, 2, 2, 1",
+			stats.TotalPoints, stats.ProvinceCount, stats.CountyCount, stats.TownCount)
+	}
+
+	areas, total, err := r.GetVisitedAreas("county", 0, 0, models.QueryOptions{})
+	if err != nil {
+		t.Fatalf("GetVisitedAreas without a time range: %v", err)
+	}
+	if total != 2 || len(areas) != 2 || areas[0].Province != "北京市" || areas[0].PointCount != 2 {
+		t.Errorf("GetVisitedAreas = %+v (total %d), want 北京市 朝阳区 with 2 points first of 2", areas, total)
+	}
+
+	if _, err := r.GetExplorationMonths("", ""); err != nil {
+		t.Errorf("GetExplorationMonths without bounds: %v", err)
+	}
+	if _, err := r.GetRailLineMileage(0); err != nil {
+		t.Errorf("GetRailLineMileage for all years: %v", err)
+	}
+	if _, err := r.GetCommutePatterns(""); err != nil {
+		t.Errorf("GetCommutePatterns without a direction: %v", err)
+	}
+}
+
+func TestStatsRepositoryFilters(t *testing.T) {
+	r := statsTestRepo(t)
+
+	stats, err := r.GetFootprintStatistics(1700000300, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalPoints != 2 || stats.ProvinceCount != 2 || stats.TownCount != 1 {
+		t.Errorf("from 1700000300: %d points, %d provinces, %d towns, want 2, 2, 1", stats.TotalPoints, stats.ProvinceCount, stats.TownCount)
+	}
+
+	stats, err = r.GetFootprintStatistics(0, 1700000300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalPoints != 1 || stats.ProvinceCount != 1 || stats.Provinces[0].Name != "北京市" {
+		t.Errorf("until 1700000300: %d points, provinces %+v, want 1 point in 北京市", stats.TotalPoints, stats.Provinces)
+	}
+
+	areas, total, err := r.GetVisitedAreas("city", 1700000000, 1700000600, models.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || areas[0].Name != "北京市" || areas[0].FirstVisit != 1700000000 || areas[0].LastVisit != 1700000600 {
+		t.Errorf("GetVisitedAreas = %+v (total %d), want 北京市 visited from 1700000000 to 1700000600", areas, total)
+	}
+
+	for _, args := range [][2]string{{"2023-01", ""}, {"", "2023-12"}, {"2023-01", "2023-12"}} {
+		if _, err := r.GetExplorationMonths(args[0], args[1]); err != nil {
+			t.Errorf("GetExplorationMonths(%q, %q): %v", args[0], args[1], err)
+		}
+	}
+	if _, err := r.GetRailLineMileage(2023); err != nil {
+		t.Errorf("GetRailLineMileage(2023): %v", err)
+	}
+	if _, err := r.GetCommutePatterns("to_work"); err != nil {
+		t.Errorf("GetCommutePatterns(to_work): %v", err)
+	}
+}
+
+// Ranking rows carry their area in the field of their own level
+func TestStatsRepositoryRankingAreas(t *testing.T) {
+	r := statsTestRepo(t)
+	for _, stmt := range []string{
+		`INSERT INTO footprint_statistics (stat_type, stat_key, time_range, point_count)
+			VALUES ('PROVINCE', '吉林省', 'all', 3), ('CITY', '长春市', 'all', 2), ('TOWN', '建外街道', 'all', 1)`,
+		`INSERT INTO stay_statistics (stat_type, stat_key, time_range, stay_count)
+			VALUES ('COUNTY', '朝阳区', 'all', 2), ('ACTIVITY_TYPE', 'HOME', 'all', 1)`,
+	} {
+		if _, err := r.db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	footprints, _, err := r.GetFootprintRankings(models.StatsFilter{}, models.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(footprints) != 3 {
+		t.Fatalf("GetFootprintRankings = %d rows, want 3", len(footprints))
+	}
+	for _, f := range footprints {
+		areas := [4]string{f.Province, f.City, f.County, f.Town}
+		var want [4]string
+		switch f.StatType {
+		case "PROVINCE":
+			want[0] = f.StatKey
+		case "CITY":
+			want[1] = f.StatKey
+		case "TOWN":
+			want[3] = f.StatKey
+		}
+		if areas != want {
+			t.Errorf("%s %s areas = %v, want %v", f.StatType, f.StatKey, areas, want)
+		}
+	}
+
+	stays, _, err := r.GetStayRankings(models.StatsFilter{}, models.QueryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range stays {
+		want := ""
+		if s.StatType == "COUNTY" {
+			want = s.StatKey
+		}
+		if s.County != want || s.Province != "" || s.City != "" {
+			t.Errorf("%s %s areas = %q/%q/%q, want county %q", s.StatType, s.StatKey, s.Province, s.City, s.County, want)
+		}
+	}
+}
@@ -18,62 +18,113 @@ func NewStayRepository(db *sql.DB) *StayRepository {
 	return &StayRepository{db: db}
 }
 
+// stayTables joins stay segments (s) with their annotation (a), which gives
+// the stay its category and label, and their place (p)
+const stayTables = `stay_segments s LEFT JOIN stay_annotations a ON a.stay_id = s.id
+		LEFT JOIN places p ON p.id = s.place_id`
+
+// stayColumns lists the stay columns scanned by scanStay
+const stayColumns = `s.id, s.stay_type, a.label, a.sub_label, s.start_time, s.end_time, s.duration_s,
+		s.center_lat, s.center_lon, s.radius_m, s.point_count,
+		s.province, s.city, s.county, s.town, s.village,
+		s.confidence, s.source, s.metadata, s.algo_version, s.created_at, s.updated_at,
+		s.place_id, p.name`
+
+// scanStay scans a row selected with stayColumns from stayTables
+func scanStay(row rowScanner) (models.StaySegment, error) {
+	var s models.StaySegment
+	var category, label, province, city, county, town, village, source, metadata, algoVersion, placeName sql.NullString
+	var centerLat, centerLon, radius, confidence sql.NullFloat64
+	var pointCount, placeID sql.NullInt64
+	var createdAt, updatedAt interface{}
+
+	err := row.Scan(
+		&s.ID, &s.StayType, &category, &label, &s.StartTime, &s.EndTime, &s.DurationSeconds,
+		&centerLat, &centerLon, &radius, &pointCount,
+		&province, &city, &county, &town, &village,
+		&confidence, &source, &metadata, &algoVersion, &createdAt, &updatedAt,
+		&placeID, &placeName,
+	)
+	if err != nil {
+		return s, err
+	}
+
+	s.StayCategory, s.StayLabel = category.String, label.String
+	s.CenterLat, s.CenterLon = centerLat.Float64, centerLon.Float64
+	s.RadiusMeters = radius.Float64
+	s.PointCount = int(pointCount.Int64)
+	s.Province, s.City, s.County, s.Town, s.Village = province.String, city.String, county.String, town.String, village.String
+	s.Confidence = confidence.Float64
+	s.Source = source.String
+	s.Metadata = metadata.String
+	s.AlgoVersion = algoVersion.String
+	s.CreatedAt = parseDBTime(createdAt)
+	s.UpdatedAt = parseDBTime(updatedAt)
+	s.PlaceID, s.PlaceName = placeID.Int64, placeName.String
+
+	return s, nil
+}
+
 // GetStays retrieves stay segments with filtering and pagination
 func (r *StayRepository) GetStays(filter models.StayFilter) ([]models.StaySegment, int64, error) {
 	// Build query
-	query := `SELECT id, stay_type, stay_category, start_time, end_time, duration_seconds,
-		center_lat, center_lon, radius_meters, point_count,
-		province, city, county, town, village,
-		confidence, metadata, algo_version, created_at, updated_at
-		FROM stay_segments`
+	query := `SELECT ` + stayColumns + ` FROM ` + stayTables
 
 	var conditions []string
 	var args []interface{}
 
 	// Add filters
 	if filter.StayType != "" {
-		conditions = append(conditions, "stay_type = ?")
+		conditions = append(conditions, "s.stay_type = ?")
 		args = append(args, filter.StayType)
 	}
 	if filter.StayCategory != "" {
-		conditions = append(conditions, "stay_category = ?")
+		conditions = append(conditions, "a.label = ?")
 		args = append(args, filter.StayCategory)
 	}
 	if filter.MinDuration > 0 {
-		conditions = append(conditions, "duration_seconds >= ?")
+		conditions = append(conditions, "s.duration_s >= ?")
 		args = append(args, filter.MinDuration)
 	}
 	if filter.Province != "" {
-		conditions = append(conditions, "province = ?")
+		conditions = append(conditions, "s.province = ?")
 		args = append(args, filter.Province)
 	}
 	if filter.City != "" {
-		conditions = append(conditions, "city = ?")
+		conditions = append(conditions, "s.city = ?")
 		args = append(args, filter.City)
 	}
 	if filter.County != "" {
-		conditions = append(conditions, "county = ?")
+		conditions = append(conditions, "s.county = ?")
 		args = append(args, filter.County)
 	}
+	if filter.Source != "" {
+		conditions = append(conditions, "s.source = ?")
+		args = append(args, filter.Source)
+	}
 	if filter.StartTime > 0 {
-		conditions = append(conditions, "start_time >= ?")
+		conditions = append(conditions, "s.start_time >= ?")
 		args = append(args, filter.StartTime)
 	}
 	if filter.EndTime > 0 {
-		conditions = append(conditions, "end_time <= ?")
+		conditions = append(conditions, "s.end_time <= ?")
 		args = append(args, filter.EndTime)
 	}
 	if filter.MinConfidence > 0 {
-		conditions = append(conditions, "confidence >= ?")
+		conditions = append(conditions, "s.confidence >= ?")
 		args = append(args, filter.MinConfidence)
 	}
+	if filter.PlaceID > 0 {
+		conditions = append(conditions, "s.place_id = ?")
+		args = append(args, filter.PlaceID)
+	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Get total count
-	countQuery := "SELECT COUNT(*) FROM stay_segments"
+	countQuery := "SELECT COUNT(*) FROM " + stayTables
 	if len(conditions) > 0 {
 		countQuery += " WHERE " + strings.Join(conditions, " AND ")
 	}
@@ -96,7 +147,7 @@ func (r *StayRepository) GetStays(filter models.StayFilter) ([]models.StaySegmen
 	}
 
 	offset := (filter.Page - 1) * filter.PageSize
-	query += " ORDER BY start_time DESC LIMIT ? OFFSET ?"
+	query += " ORDER BY s.start_time DESC LIMIT ? OFFSET ?"
 	args = append(args, filter.PageSize, offset)
 
 	// Execute query
@@ -108,13 +159,7 @@ func (r *StayRepository) GetStays(filter models.StayFilter) ([]models.StaySegmen
 
 	var stays []models.StaySegment
 	for rows.Next() {
-		var s models.StaySegment
-		err := rows.Scan(
-			&s.ID, &s.StayType, &s.StayCategory, &s.StartTime, &s.EndTime, &s.DurationSeconds,
-			&s.CenterLat, &s.CenterLon, &s.RadiusMeters, &s.PointCount,
-			&s.Province, &s.City, &s.County, &s.Town, &s.Village,
-			&s.Confidence, &s.Metadata, &s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanStay(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan stay segment: %w", err)
 		}
@@ -126,19 +171,9 @@ func (r *StayRepository) GetStays(filter models.StayFilter) ([]models.StaySegmen
 
 // GetStayByID retrieves a single stay segment by ID
 func (r *StayRepository) GetStayByID(id int64) (*models.StaySegment, error) {
-	query := `SELECT id, stay_type, stay_category, start_time, end_time, duration_seconds,
-		center_lat, center_lon, radius_meters, point_count,
-		province, city, county, town, village,
-		confidence, metadata, algo_version, created_at, updated_at
-		FROM stay_segments WHERE id = ?`
+	query := `SELECT ` + stayColumns + ` FROM ` + stayTables + ` WHERE s.id = ?`
 
-	var s models.StaySegment
-	err := r.db.QueryRow(query, id).Scan(
-		&s.ID, &s.StayType, &s.StayCategory, &s.StartTime, &s.EndTime, &s.DurationSeconds,
-		&s.CenterLat, &s.CenterLon, &s.RadiusMeters, &s.PointCount,
-		&s.Province, &s.City, &s.County, &s.Town, &s.Village,
-		&s.Confidence, &s.Metadata, &s.AlgoVersion, &s.CreatedAt, &s.UpdatedAt,
-	)
+	stay, err := scanStay(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -146,5 +181,49 @@ func (r *StayRepository) GetStayByID(id int64) (*models.StaySegment, error) {
 		return nil, fmt.Errorf("failed to get stay segment: %w", err)
 	}
 
-	return &s, nil
+	return &stay, nil
+}
+
+// GetLabeledStaysInRange returns the stays overlapping [start, end) with their
+// annotation, longest overlap first
+func (r *StayRepository) GetLabeledStaysInRange(start, end int64, limit int) ([]models.DashboardStay, error) {
+	rows, err := r.db.Query(`
+		SELECT s.id, s.stay_type, s.start_time, s.end_time,
+			MIN(s.end_time, ?) - MAX(s.start_time, ?) AS overlap_s,
+			COALESCE(s.center_lat, 0), COALESCE(s.center_lon, 0),
+			COALESCE(s.province, ''), COALESCE(s.city, ''), COALESCE(s.county, ''),
+			a.label, a.sub_label, COALESCE(a.confirmed, 0)
+		FROM stay_segments s
+		LEFT JOIN stay_annotations a ON a.stay_id = s.id
+		WHERE s.start_time < ? AND s.end_time > ?
+		ORDER BY overlap_s DESC, s.start_time
+		LIMIT ?`, end, start, end, start, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stays in range: %w", err)
+	}
+	defer rows.Close()
+
+	stays := []models.DashboardStay{}
+	for rows.Next() {
+		var s models.DashboardStay
+		var label, subLabel sql.NullString
+		var confirmed int
+		if err := rows.Scan(
+			&s.ID, &s.StayType, &s.StartTime, &s.EndTime, &s.DurationSeconds,
+			&s.CenterLat, &s.CenterLon, &s.Province, &s.City, &s.County,
+			&label, &subLabel, &confirmed,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stay: %w", err)
+		}
+		if label.Valid {
+			s.Label = &label.String
+		}
+		if subLabel.Valid {
+			s.SubLabel = &subLabel.String
+		}
+		s.Confirmed = confirmed == 1
+		stays = append(stays, s)
+	}
+
+	return stays, rows.Err()
 }
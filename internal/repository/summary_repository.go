@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// SummaryRepository handles database operations for daily summaries
+type SummaryRepository struct {
+	db *sql.DB
+}
+
+// NewSummaryRepository creates a new summary repository
+func NewSummaryRepository(db *sql.DB) *SummaryRepository {
+	return &SummaryRepository{db: db}
+}
+
+// GetDailySummaries retrieves the stored summaries between two dates (inclusive)
+func (r *SummaryRepository) GetDailySummaries(from, to string) ([]models.DailySummary, error) {
+	query := `
+		SELECT date, weekday, COALESCE(day_type, ''), point_count, total_distance_m,
+			distance_by_mode, primary_mode, active_time_s, max_speed_kmh, step_equivalent,
+			trip_count, stay_count, first_movement_ts, last_movement_ts,
+			provinces, cities, city_count, county_count
+		FROM daily_summaries
+		WHERE date BETWEEN ? AND ?
+		ORDER BY date
+	`
+
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily summaries: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []models.DailySummary
+	for rows.Next() {
+		var s models.DailySummary
+		var byMode, provinces, cities sql.NullString
+		var primaryMode sql.NullString
+		var firstTS, lastTS sql.NullInt64
+
+		if err := rows.Scan(
+			&s.Date, &s.Weekday, &s.DayType, &s.PointCount, &s.TotalDistanceM,
+			&byMode, &primaryMode, &s.ActiveTimeS, &s.MaxSpeedKmh, &s.StepEquivalent,
+			&s.TripCount, &s.StayCount, &firstTS, &lastTS,
+			&provinces, &cities, &s.CityCount, &s.CountyCount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan daily summary: %w", err)
+		}
+
+		s.HasData = true
+		if byMode.Valid {
+			json.Unmarshal([]byte(byMode.String), &s.DistanceByMode)
+		}
+		if provinces.Valid {
+			json.Unmarshal([]byte(provinces.String), &s.Provinces)
+		}
+		if cities.Valid {
+			json.Unmarshal([]byte(cities.String), &s.Cities)
+		}
+		if primaryMode.Valid {
+			s.PrimaryMode = &primaryMode.String
+		}
+		if firstTS.Valid {
+			s.FirstMovementTS = &firstTS.Int64
+		}
+		if lastTS.Valid {
+			s.LastMovementTS = &lastTS.Int64
+		}
+
+		summaries = append(summaries, s)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetLatestSummaryDate returns the most recent summarized day, or "" if there is none
+func (r *SummaryRepository) GetLatestSummaryDate() (string, error) {
+	var date sql.NullString
+	if err := r.db.QueryRow("SELECT MAX(date) FROM daily_summaries").Scan(&date); err != nil {
+		return "", fmt.Errorf("failed to query latest summary date: %w", err)
+	}
+	return date.String, nil
+}
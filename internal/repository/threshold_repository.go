@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// ThresholdRepository handles database operations for threshold profiles
+type ThresholdRepository struct {
+	db *sql.DB
+}
+
+// NewThresholdRepository creates a new threshold repository
+func NewThresholdRepository(db *sql.DB) *ThresholdRepository {
+	return &ThresholdRepository{db: db}
+}
+
+const thresholdProfileColumns = `id, name, COALESCE(description, ''), params_json, is_default, created_at, updated_at`
+
+// scanThresholdProfile scans a threshold profile row
+func scanThresholdProfile(scanner interface{ Scan(...interface{}) error }) (*models.ThresholdProfile, error) {
+	var p models.ThresholdProfile
+	if err := scanner.Scan(&p.ID, &p.Name, &p.Description, &p.ParamsJSON, &p.IsDefault, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List retrieves all threshold profiles
+func (r *ThresholdRepository) List() ([]models.ThresholdProfile, error) {
+	rows, err := r.db.Query(`SELECT ` + thresholdProfileColumns + ` FROM threshold_profiles ORDER BY is_default DESC, name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query threshold profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []models.ThresholdProfile
+	for rows.Next() {
+		p, err := scanThresholdProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan threshold profile: %w", err)
+		}
+		profiles = append(profiles, *p)
+	}
+
+	return profiles, nil
+}
+
+// GetByID retrieves a threshold profile by ID
+func (r *ThresholdRepository) GetByID(id int64) (*models.ThresholdProfile, error) {
+	p, err := scanThresholdProfile(r.db.QueryRow(`SELECT `+thresholdProfileColumns+` FROM threshold_profiles WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get threshold profile: %w", err)
+	}
+	return p, nil
+}
+
+// GetDefault retrieves the default threshold profile
+func (r *ThresholdRepository) GetDefault() (*models.ThresholdProfile, error) {
+	p, err := scanThresholdProfile(r.db.QueryRow(`SELECT ` + thresholdProfileColumns + ` FROM threshold_profiles WHERE is_default = 1 ORDER BY id LIMIT 1`))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get default threshold profile: %w", err)
+	}
+	return p, nil
+}
+
+// Create creates a new threshold profile
+func (r *ThresholdRepository) Create(name, description, paramsJSON string) (int64, error) {
+	result, err := r.db.Exec(`INSERT INTO threshold_profiles (name, description, params_json, is_default) VALUES (?, ?, ?, 0)`,
+		name, description, paramsJSON)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create threshold profile: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// Update updates the description and params of a threshold profile
+func (r *ThresholdRepository) Update(id int64, description, paramsJSON string) error {
+	_, err := r.db.Exec(`UPDATE threshold_profiles
+		SET description = ?, params_json = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, description, paramsJSON, id)
+	if err != nil {
+		return fmt.Errorf("failed to update threshold profile: %w", err)
+	}
+	return nil
+}
+
+// SetDefault makes a threshold profile the default (active) profile
+func (r *ThresholdRepository) SetDefault(id int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE threshold_profiles SET is_default = 0 WHERE is_default = 1 AND id != ?`, id); err != nil {
+		return fmt.Errorf("failed to clear default profile: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE threshold_profiles SET is_default = 1, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to set default profile: %w", err)
+	}
+
+	return tx.Commit()
+}
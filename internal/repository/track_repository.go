@@ -1,11 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
 	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // TrackRepository handles database operations for track points
@@ -20,53 +22,7 @@ func NewTrackRepository(db *sql.DB) *TrackRepository {
 
 // GetTrackPoints retrieves track points with filtering and pagination
 func (r *TrackRepository) GetTrackPoints(filter models.TrackPointFilter) ([]models.TrackPoint, int64, error) {
-	// Build query
-	query := `SELECT id, dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude,
-		time_visually, time, province, city, county, town, village, created_at, updated_at, algo_version
-		FROM "一生足迹"`
-
-	var conditions []string
-	var args []interface{}
-
-	// Add filters
-	if filter.StartTime > 0 {
-		conditions = append(conditions, "dataTime >= ?")
-		args = append(args, filter.StartTime)
-	}
-	if filter.EndTime > 0 {
-		conditions = append(conditions, "dataTime <= ?")
-		args = append(args, filter.EndTime)
-	}
-	if filter.Province != "" {
-		conditions = append(conditions, "province = ?")
-		args = append(args, filter.Province)
-	}
-	if filter.City != "" {
-		conditions = append(conditions, "city = ?")
-		args = append(args, filter.City)
-	}
-	if filter.County != "" {
-		conditions = append(conditions, "county = ?")
-		args = append(args, filter.County)
-	}
-	if filter.MinSpeed > 0 {
-		conditions = append(conditions, "speed >= ?")
-		args = append(args, filter.MinSpeed)
-	}
-	if filter.MaxSpeed > 0 {
-		conditions = append(conditions, "speed <= ?")
-		args = append(args, filter.MaxSpeed)
-	}
-
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
-	}
-
-	// Get total count
-	countQuery := "SELECT COUNT(*) FROM \"一生足迹\""
-	if len(conditions) > 0 {
-		countQuery += " WHERE " + strings.Join(conditions, " AND ")
-	}
+	query, countQuery, args := trackPointsQuery(filter)
 
 	var total int64
 	err := r.db.QueryRow(countQuery, args...).Scan(&total)
@@ -86,7 +42,6 @@ func (r *TrackRepository) GetTrackPoints(filter models.TrackPointFilter) ([]mode
 	}
 
 	offset := (filter.Page - 1) * filter.PageSize
-	query += " ORDER BY dataTime DESC LIMIT ? OFFSET ?"
 	args = append(args, filter.PageSize, offset)
 
 	// Execute query
@@ -102,7 +57,8 @@ func (r *TrackRepository) GetTrackPoints(filter models.TrackPointFilter) ([]mode
 		err := rows.Scan(
 			&p.ID, &p.DataTime, &p.Longitude, &p.Latitude, &p.Heading, &p.Accuracy,
 			&p.Speed, &p.Distance, &p.Altitude, &p.TimeVisually, &p.Time,
-			&p.Province, &p.City, &p.County, &p.Town, &p.Village,
+			&p.Province, &p.City, &p.County, &p.Town, &p.Village, &p.Country, &p.Region,
+			&p.Source, &p.SourceDevice, &p.Motion, &p.BatteryLevel, &p.BatteryState,
 			&p.CreatedAt, &p.UpdatedAt, &p.AlgoVersion,
 		)
 		if err != nil {
@@ -114,17 +70,82 @@ func (r *TrackRepository) GetTrackPoints(filter models.TrackPointFilter) ([]mode
 	return points, total, nil
 }
 
+// trackPointsQuery builds the page and count queries of a track point filter
+// The page query ends in LIMIT ? OFFSET ?, whose arguments are not included.
+func trackPointsQuery(filter models.TrackPointFilter) (query, countQuery string, args []interface{}) {
+	query = `SELECT id, dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude,
+		time_visually, time, province, city, county, town, village, COALESCE(country, ''), COALESCE(region, ''),
+		source, COALESCE(source_device, ''), COALESCE(motion, ''), battery_level, COALESCE(battery_state, ''),
+		created_at, updated_at, algo_version
+		FROM "一生足迹"`
+
+	var conditions []string
+
+	// Add filters
+	if filter.StartTime > 0 {
+		conditions = append(conditions, "dataTime >= ?")
+		args = append(args, filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		conditions = append(conditions, "dataTime <= ?")
+		args = append(args, filter.EndTime)
+	}
+	if filter.Province != "" {
+		conditions = append(conditions, "province = ?")
+		args = append(args, filter.Province)
+	}
+	if filter.City != "" {
+		conditions = append(conditions, "city = ?")
+		args = append(args, filter.City)
+	}
+	if filter.County != "" {
+		conditions = append(conditions, "county = ?")
+		args = append(args, filter.County)
+	}
+	if filter.Country != "" {
+		conditions = append(conditions, "country = ?")
+		args = append(args, filter.Country)
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+	if filter.SourceDevice != "" {
+		conditions = append(conditions, "source_device = ?")
+		args = append(args, filter.SourceDevice)
+	}
+	if filter.MinSpeed > 0 {
+		conditions = append(conditions, "speed >= ?")
+		args = append(args, filter.MinSpeed)
+	}
+	if filter.MaxSpeed > 0 {
+		conditions = append(conditions, "speed <= ?")
+		args = append(args, filter.MaxSpeed)
+	}
+
+	countQuery = "SELECT COUNT(*) FROM \"一生足迹\""
+	if len(conditions) > 0 {
+		where := " WHERE " + strings.Join(conditions, " AND ")
+		query += where
+		countQuery += where
+	}
+	return query + " ORDER BY dataTime DESC LIMIT ? OFFSET ?", countQuery, args
+}
+
 // GetTrackPointByID retrieves a single track point by ID
 func (r *TrackRepository) GetTrackPointByID(id int64) (*models.TrackPoint, error) {
 	query := `SELECT id, dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude,
-		time_visually, time, province, city, county, town, village, created_at, updated_at, algo_version
+		time_visually, time, province, city, county, town, village, COALESCE(country, ''), COALESCE(region, ''),
+		source, COALESCE(source_device, ''), COALESCE(motion, ''), battery_level, COALESCE(battery_state, ''),
+		created_at, updated_at, algo_version
 		FROM "一生足迹" WHERE id = ?`
 
 	var p models.TrackPoint
 	err := r.db.QueryRow(query, id).Scan(
 		&p.ID, &p.DataTime, &p.Longitude, &p.Latitude, &p.Heading, &p.Accuracy,
 		&p.Speed, &p.Distance, &p.Altitude, &p.TimeVisually, &p.Time,
-		&p.Province, &p.City, &p.County, &p.Town, &p.Village,
+		&p.Province, &p.City, &p.County, &p.Town, &p.Village, &p.Country, &p.Region,
+		&p.Source, &p.SourceDevice, &p.Motion, &p.BatteryLevel, &p.BatteryState,
 		&p.CreatedAt, &p.UpdatedAt, &p.AlgoVersion,
 	)
 	if err == sql.ErrNoRows {
@@ -199,7 +220,9 @@ func (r *TrackRepository) BatchUpdateAdminDivisions(updates []struct {
 // GetUngeocodedPoints retrieves track points without administrative divisions
 func (r *TrackRepository) GetUngeocodedPoints(limit int) ([]models.TrackPoint, error) {
 	query := `SELECT id, dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude,
-		time_visually, time, province, city, county, town, village, created_at, updated_at, algo_version
+		time_visually, time, province, city, county, town, village, COALESCE(country, ''), COALESCE(region, ''),
+		source, COALESCE(source_device, ''), COALESCE(motion, ''), battery_level, COALESCE(battery_state, ''),
+		created_at, updated_at, algo_version
 		FROM "一生足迹"
 		WHERE province IS NULL OR province = ''
 		ORDER BY dataTime ASC
@@ -217,7 +240,8 @@ func (r *TrackRepository) GetUngeocodedPoints(limit int) ([]models.TrackPoint, e
 		err := rows.Scan(
 			&p.ID, &p.DataTime, &p.Longitude, &p.Latitude, &p.Heading, &p.Accuracy,
 			&p.Speed, &p.Distance, &p.Altitude, &p.TimeVisually, &p.Time,
-			&p.Province, &p.City, &p.County, &p.Town, &p.Village,
+			&p.Province, &p.City, &p.County, &p.Town, &p.Village, &p.Country, &p.Region,
+			&p.Source, &p.SourceDevice, &p.Motion, &p.BatteryLevel, &p.BatteryState,
 			&p.CreatedAt, &p.UpdatedAt, &p.AlgoVersion,
 		)
 		if err != nil {
@@ -228,3 +252,257 @@ func (r *TrackRepository) GetUngeocodedPoints(limit int) ([]models.TrackPoint, e
 
 	return points, nil
 }
+
+// Points from different devices in the same minute and closer than
+// mergeDistanceM are treated as recordings of the same position
+const mergeDistanceM = 50
+
+// mergeCandidate is an existing point that an imported point may duplicate
+type mergeCandidate struct {
+	id       int64
+	dataTime int64
+	lat, lon float64
+	accuracy float64
+	device   string
+}
+
+// InsertTrackPoints inserts track points, reconciling them with points already
+// recorded by other devices (SourceDevice)
+// A point whose dataTime already exists, or that lies within mergeDistanceM of
+// another device's point in the same minute, is a near-duplicate: the more
+// accurate of the two is kept (a lower accuracy value is better, 0 is unknown).
+// Re-imports from the same device are skipped.
+// Returns the number of inserted, replaced (existing point swapped for the
+// imported one) and skipped (duplicate) points.
+func (r *TrackRepository) InsertTrackPoints(points []models.TrackPoint) (inserted, replaced, duplicates int, err error) {
+	if len(points) == 0 {
+		return 0, 0, 0, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	candidateStmt, err := tx.Prepare(`SELECT id, dataTime, latitude, longitude, COALESCE(accuracy, 0), COALESCE(source_device, '')
+		FROM "一生足迹"
+		WHERE dataTime >= ? AND dataTime < ?`)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare candidate statement: %w", err)
+	}
+	defer candidateStmt.Close()
+
+	insertStmt, err := tx.Prepare(`INSERT INTO "一生足迹" (
+			dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude,
+			time_visually, time, source, source_device, motion, battery_level, battery_state
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''))`)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare insert statement: %w", err)
+	}
+	defer insertStmt.Close()
+
+	deleteStmt, err := tx.Prepare(`DELETE FROM "一生足迹" WHERE id = ?`)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to prepare delete statement: %w", err)
+	}
+	defer deleteStmt.Close()
+
+	for _, p := range points {
+		minute := p.DataTime - p.DataTime%60
+		candidates, err := queryMergeCandidates(candidateStmt, minute, minute+60)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to query points near %d: %w", p.DataTime, err)
+		}
+
+		match := findDuplicate(p, candidates)
+		if match != nil {
+			if match.device == p.SourceDevice || !moreAccurate(p.Accuracy, match.accuracy) {
+				duplicates++
+				continue
+			}
+			if _, err := deleteStmt.Exec(match.id); err != nil {
+				return 0, 0, 0, fmt.Errorf("failed to delete replaced track point %d: %w", match.id, err)
+			}
+		}
+
+		_, err = insertStmt.Exec(
+			p.DataTime, p.Longitude, p.Latitude, p.Heading, p.Accuracy, p.Speed, p.Distance, p.Altitude,
+			p.TimeVisually, p.Time, p.Source, p.SourceDevice, p.Motion, p.BatteryLevel, p.BatteryState,
+		)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("failed to insert track point at %d: %w", p.DataTime, err)
+		}
+		if match != nil {
+			replaced++
+		} else {
+			inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return inserted, replaced, duplicates, nil
+}
+
+// queryMergeCandidates loads the points recorded in [start, end)
+func queryMergeCandidates(stmt *sql.Stmt, start, end int64) ([]mergeCandidate, error) {
+	rows, err := stmt.Query(start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []mergeCandidate
+	for rows.Next() {
+		var c mergeCandidate
+		if err := rows.Scan(&c.id, &c.dataTime, &c.lat, &c.lon, &c.accuracy, &c.device); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// findDuplicate returns the existing point that p duplicates, if any
+// A point with the same dataTime always matches; otherwise the closest point
+// of another device within mergeDistanceM matches.
+func findDuplicate(p models.TrackPoint, candidates []mergeCandidate) *mergeCandidate {
+	var best *mergeCandidate
+	bestDistance := float64(mergeDistanceM)
+	for i := range candidates {
+		c := &candidates[i]
+		if c.dataTime == p.DataTime {
+			return c
+		}
+		if c.device == p.SourceDevice {
+			continue
+		}
+		if d := spatial.HaversineDistance(p.Latitude, p.Longitude, c.lat, c.lon); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// moreAccurate reports whether accuracy a is better than b
+// Accuracy is an error radius in meters; 0 means the recorder did not report one.
+func moreAccurate(a, b float64) bool {
+	return a > 0 && (b <= 0 || a < b)
+}
+
+// StreamTrackPoints streams track points after the cursor in id order, calling fn for each row
+// columns must be validated column names; values are passed in the same order
+// Rows are read one at a time so memory use does not depend on the result size
+func (r *TrackRepository) StreamTrackPoints(ctx context.Context, filter models.TrackPointCursorFilter, columns []string, fn func(values []interface{}) error) error {
+	conditions := []string{"id > ?"}
+	args := []interface{}{filter.AfterID}
+
+	if filter.StartTime > 0 {
+		conditions = append(conditions, "dataTime >= ?")
+		args = append(args, filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		conditions = append(conditions, "dataTime <= ?")
+		args = append(args, filter.EndTime)
+	}
+	if filter.MinLat != 0 {
+		conditions = append(conditions, "latitude >= ?")
+		args = append(args, filter.MinLat)
+	}
+	if filter.MaxLat != 0 {
+		conditions = append(conditions, "latitude <= ?")
+		args = append(args, filter.MaxLat)
+	}
+	if filter.MinLon != 0 {
+		conditions = append(conditions, "longitude >= ?")
+		args = append(args, filter.MinLon)
+	}
+	if filter.MaxLon != 0 {
+		conditions = append(conditions, "longitude <= ?")
+		args = append(args, filter.MaxLon)
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, "source = ?")
+		args = append(args, filter.Source)
+	}
+
+	query := `SELECT ` + strings.Join(columns, ", ") + ` FROM "一生足迹"
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY id LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query track points: %w", err)
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return fmt.Errorf("failed to scan track point: %w", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating track points: %w", err)
+	}
+
+	return nil
+}
+
+// StreamExportPoints streams the non-outlier track points of a time range in time order
+// Only the fields an export may contain are read: time, coordinates, altitude,
+// mode and the country/province/city names. The values are not anonymized yet.
+// An end time of 0 means no upper bound.
+func (r *TrackRepository) StreamExportPoints(ctx context.Context, startTime, endTime int64, fn func(p *models.AnonymizedPoint) error) error {
+	query := `SELECT dataTime, latitude, longitude, COALESCE(altitude, 0), COALESCE(mode, ''),
+			COALESCE(country, ''), COALESCE(province, ''), COALESCE(city, '')
+		FROM "一生足迹"
+		WHERE dataTime >= ? AND (outlier_flag IS NULL OR outlier_flag = 0)`
+	args := []interface{}{startTime}
+	if endTime > 0 {
+		query += ` AND dataTime <= ?`
+		args = append(args, endTime)
+	}
+	query += ` ORDER BY dataTime`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query track points: %w", err)
+	}
+	defer rows.Close()
+
+	var p models.AnonymizedPoint
+	for rows.Next() {
+		if err := rows.Scan(&p.DataTime, &p.Latitude, &p.Longitude, &p.Altitude, &p.Mode,
+			&p.Country, &p.Province, &p.City); err != nil {
+			return fmt.Errorf("failed to scan track point: %w", err)
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating track points: %w", err)
+	}
+
+	return nil
+}
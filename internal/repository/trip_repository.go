@@ -2,8 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/models"
 )
@@ -18,15 +20,96 @@ func NewTripRepository(db *sql.DB) *TripRepository {
 	return &TripRepository{db: db}
 }
 
+// tripColumns lists the trip columns scanned by scanTrip
+const tripColumns = `id, date, trip_number, start_time, end_time, duration_s, day_type,
+		origin_stay_id, dest_stay_id,
+		origin_lat, origin_lon, dest_lat, dest_lon,
+		origin_province, origin_city, origin_county,
+		dest_province, dest_city, dest_county,
+		distance_m, primary_mode, segment_count, modes,
+		purpose_ml, confidence_ml, purpose_probs,
+		algo_version, created_at, updated_at, rail_lines,
+		origin_place_id, dest_place_id, weather_temp_c, weather_precip_mm, weather_wet`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTrip scans a row selected with tripColumns
+func scanTrip(row rowScanner) (models.Trip, error) {
+	var t models.Trip
+	var dayType, originProvince, originCity, originCounty, destProvince, destCity, destCounty sql.NullString
+	var primaryMode, modes, railLines, purpose, purposeProbs, algoVersion sql.NullString
+	var originStayID, destStayID, originPlaceID, destPlaceID sql.NullInt64
+	var originLat, originLon, destLat, destLon, distance, confidence sql.NullFloat64
+	var weatherTemp, weatherPrecip sql.NullFloat64
+	var weatherWet sql.NullBool
+	var createdAt, updatedAt interface{}
+
+	err := row.Scan(
+		&t.ID, &t.Date, &t.TripNumber, &t.StartTime, &t.EndTime, &t.DurationSeconds, &dayType,
+		&originStayID, &destStayID,
+		&originLat, &originLon, &destLat, &destLon,
+		&originProvince, &originCity, &originCounty,
+		&destProvince, &destCity, &destCounty,
+		&distance, &primaryMode, &t.SegmentCount, &modes,
+		&purpose, &confidence, &purposeProbs,
+		&algoVersion, &createdAt, &updatedAt, &railLines,
+		&originPlaceID, &destPlaceID, &weatherTemp, &weatherPrecip, &weatherWet,
+	)
+	if err != nil {
+		return t, err
+	}
+
+	t.DayType = dayType.String
+	t.OriginStayID = originStayID.Int64
+	t.DestStayID = destStayID.Int64
+	t.OriginPlaceID, t.DestPlaceID = originPlaceID.Int64, destPlaceID.Int64
+	t.OriginLat, t.OriginLon = originLat.Float64, originLon.Float64
+	t.DestLat, t.DestLon = destLat.Float64, destLon.Float64
+	t.OriginProvince, t.OriginCity, t.OriginCounty = originProvince.String, originCity.String, originCounty.String
+	t.DestProvince, t.DestCity, t.DestCounty = destProvince.String, destCity.String, destCounty.String
+	t.DistanceMeters = distance.Float64
+	t.PrimaryMode = primaryMode.String
+	t.ModesJSON = modes.String
+	t.RailLinesJSON = railLines.String
+	t.Purpose = purpose.String
+	t.PurposeConfidence = confidence.Float64
+	t.PurposeProbsJSON = purposeProbs.String
+	t.AlgoVersion = algoVersion.String
+	t.CreatedAt = parseDBTime(createdAt)
+	t.UpdatedAt = parseDBTime(updatedAt)
+	if weatherWet.Valid {
+		t.WeatherTempC, t.WeatherPrecipMM = &weatherTemp.Float64, &weatherPrecip.Float64
+		t.WeatherWet = &weatherWet.Bool
+	}
+
+	return t, nil
+}
+
+// parseDBTime converts a timestamp column that may hold Unix seconds or a SQLite datetime
+func parseDBTime(v interface{}) time.Time {
+	switch value := v.(type) {
+	case int64:
+		return time.Unix(value, 0)
+	case time.Time:
+		return value
+	case string:
+		if t, err := time.Parse("2006-01-02 15:04:05", value); err == nil {
+			return t
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
 // GetTrips retrieves trips with filtering and pagination
 func (r *TripRepository) GetTrips(filter models.TripFilter) ([]models.Trip, int64, error) {
 	// Build query
-	query := `SELECT id, date, start_time, end_time, duration_seconds,
-		origin_stay_id, dest_stay_id, distance_meters,
-		primary_mode, modes_json, segment_ids_json, trip_type,
-		origin_province, origin_city, dest_province, dest_city,
-		algo_version, created_at, updated_at
-		FROM trips`
+	query := `SELECT ` + tripColumns + ` FROM trips`
 
 	var conditions []string
 	var args []interface{}
@@ -40,25 +123,61 @@ func (r *TripRepository) GetTrips(filter models.TripFilter) ([]models.Trip, int6
 		conditions = append(conditions, "end_time <= ?")
 		args = append(args, filter.EndTime)
 	}
+	if filter.OriginProvince != "" {
+		conditions = append(conditions, "origin_province = ?")
+		args = append(args, filter.OriginProvince)
+	}
 	if filter.OriginCity != "" {
 		conditions = append(conditions, "origin_city = ?")
 		args = append(args, filter.OriginCity)
 	}
+	if filter.OriginCounty != "" {
+		conditions = append(conditions, "origin_county = ?")
+		args = append(args, filter.OriginCounty)
+	}
+	if filter.DestProvince != "" {
+		conditions = append(conditions, "dest_province = ?")
+		args = append(args, filter.DestProvince)
+	}
 	if filter.DestCity != "" {
 		conditions = append(conditions, "dest_city = ?")
 		args = append(args, filter.DestCity)
 	}
+	if filter.DestCounty != "" {
+		conditions = append(conditions, "dest_county = ?")
+		args = append(args, filter.DestCounty)
+	}
 	if filter.MinDistance > 0 {
-		conditions = append(conditions, "distance_meters >= ?")
+		conditions = append(conditions, "distance_m >= ?")
 		args = append(args, filter.MinDistance)
 	}
 	if filter.PrimaryMode != "" {
 		conditions = append(conditions, "primary_mode = ?")
 		args = append(args, filter.PrimaryMode)
 	}
-	if filter.TripType != "" {
-		conditions = append(conditions, "trip_type = ?")
-		args = append(args, filter.TripType)
+	if filter.Purpose != "" {
+		conditions = append(conditions, "purpose_ml = ?")
+		args = append(args, filter.Purpose)
+	}
+	if filter.DayType != "" {
+		conditions = append(conditions, "day_type = ?")
+		args = append(args, filter.DayType)
+	}
+	if filter.PlaceID > 0 {
+		conditions = append(conditions, "(origin_place_id = ? OR dest_place_id = ?)")
+		args = append(args, filter.PlaceID, filter.PlaceID)
+	}
+	if filter.Wet != nil {
+		conditions = append(conditions, "weather_wet = ?")
+		args = append(args, *filter.Wet)
+	}
+	if filter.MinTemp != nil {
+		conditions = append(conditions, "weather_temp_c >= ?")
+		args = append(args, *filter.MinTemp)
+	}
+	if filter.MaxTemp != nil {
+		conditions = append(conditions, "weather_temp_c <= ?")
+		args = append(args, *filter.MaxTemp)
 	}
 
 	if len(conditions) > 0 {
@@ -101,14 +220,7 @@ func (r *TripRepository) GetTrips(filter models.TripFilter) ([]models.Trip, int6
 
 	var trips []models.Trip
 	for rows.Next() {
-		var t models.Trip
-		err := rows.Scan(
-			&t.ID, &t.Date, &t.StartTime, &t.EndTime, &t.DurationSeconds,
-			&t.OriginStayID, &t.DestStayID, &t.DistanceMeters,
-			&t.PrimaryMode, &t.ModesJSON, &t.SegmentIDsJSON, &t.TripType,
-			&t.OriginProvince, &t.OriginCity, &t.DestProvince, &t.DestCity,
-			&t.AlgoVersion, &t.CreatedAt, &t.UpdatedAt,
-		)
+		t, err := scanTrip(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan trip: %w", err)
 		}
@@ -120,21 +232,9 @@ func (r *TripRepository) GetTrips(filter models.TripFilter) ([]models.Trip, int6
 
 // GetTripByID retrieves a single trip by ID
 func (r *TripRepository) GetTripByID(id int64) (*models.Trip, error) {
-	query := `SELECT id, date, start_time, end_time, duration_seconds,
-		origin_stay_id, dest_stay_id, distance_meters,
-		primary_mode, modes_json, segment_ids_json, trip_type,
-		origin_province, origin_city, dest_province, dest_city,
-		algo_version, created_at, updated_at
-		FROM trips WHERE id = ?`
+	query := `SELECT ` + tripColumns + ` FROM trips WHERE id = ?`
 
-	var t models.Trip
-	err := r.db.QueryRow(query, id).Scan(
-		&t.ID, &t.Date, &t.StartTime, &t.EndTime, &t.DurationSeconds,
-		&t.OriginStayID, &t.DestStayID, &t.DistanceMeters,
-		&t.PrimaryMode, &t.ModesJSON, &t.SegmentIDsJSON, &t.TripType,
-		&t.OriginProvince, &t.OriginCity, &t.DestProvince, &t.DestCity,
-		&t.AlgoVersion, &t.CreatedAt, &t.UpdatedAt,
-	)
+	t, err := scanTrip(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -144,3 +244,353 @@ func (r *TripRepository) GetTripByID(id int64) (*models.Trip, error) {
 
 	return &t, nil
 }
+
+// GetODMatrix aggregates trips into origin-destination flows between admin areas
+func (r *TripRepository) GetODMatrix(filter models.ODMatrixFilter) (*models.ODMatrix, error) {
+	areaColumns := []string{"province", "city"}
+	if filter.Level == models.ODLevelCounty {
+		areaColumns = append(areaColumns, "county")
+	}
+
+	var originCols, destCols, groupCols []string
+	conditions := []string{}
+	var args []interface{}
+	for _, col := range areaColumns {
+		originCols = append(originCols, "COALESCE(origin_"+col+", '')")
+		destCols = append(destCols, "COALESCE(dest_"+col+", '')")
+		groupCols = append(groupCols, "origin_"+col, "dest_"+col)
+		conditions = append(conditions, "origin_"+col+" IS NOT NULL", "dest_"+col+" IS NOT NULL")
+	}
+
+	if filter.StartTime > 0 {
+		conditions = append(conditions, "start_time >= ?")
+		args = append(args, filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		conditions = append(conditions, "end_time <= ?")
+		args = append(args, filter.EndTime)
+	}
+	if filter.Purpose != "" {
+		conditions = append(conditions, "purpose_ml = ?")
+		args = append(args, filter.Purpose)
+	}
+	if filter.PrimaryMode != "" {
+		conditions = append(conditions, "primary_mode = ?")
+		args = append(args, filter.PrimaryMode)
+	}
+	if !filter.IncludeIntra {
+		var same []string
+		for _, col := range areaColumns {
+			same = append(same, "origin_"+col+" = dest_"+col)
+		}
+		conditions = append(conditions, "NOT ("+strings.Join(same, " AND ")+")")
+	}
+
+	query := `SELECT ` + strings.Join(originCols, ", ") + `, ` + strings.Join(destCols, ", ") + `,
+			COUNT(*), COALESCE(SUM(distance_m), 0), COALESCE(AVG(duration_s), 0),
+			MIN(start_time), MAX(start_time),
+			AVG(origin_lat), AVG(origin_lon), AVG(dest_lat), AVG(dest_lon)
+		FROM trips
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY ` + strings.Join(groupCols, ", ")
+	if filter.MinCount > 1 {
+		query += " HAVING COUNT(*) >= ?"
+		args = append(args, filter.MinCount)
+	}
+	query += " ORDER BY COUNT(*) DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OD matrix: %w", err)
+	}
+	defer rows.Close()
+
+	matrix := &models.ODMatrix{
+		Level: models.ODLevelCity,
+		Areas: []models.ODArea{},
+		Flows: []models.ODFlow{},
+	}
+	if filter.Level == models.ODLevelCounty {
+		matrix.Level = models.ODLevelCounty
+	}
+
+	// Areas are deduplicated; their location is the trip-weighted mean of all endpoints
+	type areaAccumulator struct {
+		index          int
+		latSum, lonSum float64
+		weight         float64
+	}
+	areaIndex := make(map[string]*areaAccumulator)
+	addArea := func(names []string, lat, lon sql.NullFloat64, count int64) int {
+		key := strings.Join(names, "|")
+		acc, ok := areaIndex[key]
+		if !ok {
+			area := models.ODArea{Province: names[0], City: names[1]}
+			if len(names) > 2 {
+				area.County = names[2]
+			}
+			acc = &areaAccumulator{index: len(matrix.Areas)}
+			areaIndex[key] = acc
+			matrix.Areas = append(matrix.Areas, area)
+		}
+		if lat.Valid && lon.Valid {
+			acc.latSum += lat.Float64 * float64(count)
+			acc.lonSum += lon.Float64 * float64(count)
+			acc.weight += float64(count)
+		}
+		return acc.index
+	}
+
+	for rows.Next() {
+		originNames := make([]string, len(areaColumns))
+		destNames := make([]string, len(areaColumns))
+		var flow models.ODFlow
+		var originLat, originLon, destLat, destLon sql.NullFloat64
+
+		dest := make([]interface{}, 0, len(areaColumns)*2+9)
+		for i := range originNames {
+			dest = append(dest, &originNames[i])
+		}
+		for i := range destNames {
+			dest = append(dest, &destNames[i])
+		}
+		dest = append(dest, &flow.TripCount, &flow.TotalDistanceM, &flow.AvgDurationS,
+			&flow.FirstTime, &flow.LastTime, &originLat, &originLon, &destLat, &destLon)
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan OD flow: %w", err)
+		}
+
+		flow.Origin = addArea(originNames, originLat, originLon, flow.TripCount)
+		flow.Dest = addArea(destNames, destLat, destLon, flow.TripCount)
+		matrix.Flows = append(matrix.Flows, flow)
+		matrix.TotalTrips += flow.TripCount
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating OD flows: %w", err)
+	}
+
+	for _, acc := range areaIndex {
+		if acc.weight > 0 {
+			matrix.Areas[acc.index].Lat = acc.latSum / acc.weight
+			matrix.Areas[acc.index].Lon = acc.lonSum / acc.weight
+		}
+	}
+
+	return matrix, nil
+}
+
+// tripSegmentsQuery builds the query selecting columns of the segments of a
+// trip in time order: those listed in the trip metadata, or else the segments
+// within the trip time range
+func tripSegmentsQuery(columns string, metadata sql.NullString, startTime, endTime int64) (string, []interface{}, error) {
+	var meta struct {
+		SegmentIDs []int64 `json:"segment_ids"`
+	}
+	if metadata.Valid && metadata.String != "" {
+		if err := json.Unmarshal([]byte(metadata.String), &meta); err != nil {
+			return "", nil, fmt.Errorf("failed to parse trip metadata: %w", err)
+		}
+	}
+
+	if len(meta.SegmentIDs) == 0 {
+		return `SELECT ` + columns + ` FROM segments
+			WHERE start_time >= ? AND end_time <= ? ORDER BY start_time`, []interface{}{startTime, endTime}, nil
+	}
+	placeholders := make([]string, len(meta.SegmentIDs))
+	args := make([]interface{}, len(meta.SegmentIDs))
+	for i, id := range meta.SegmentIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return `SELECT ` + columns + ` FROM segments
+			WHERE id IN (` + strings.Join(placeholders, ", ") + `) ORDER BY start_time`, args, nil
+}
+
+// GetTripRoute reconstructs the point sequence of a trip from its segments' start/end point IDs
+// Segments are taken from the trip metadata, falling back to segments within the trip time range
+func (r *TripRepository) GetTripRoute(tripID int64) (*models.TripRoute, error) {
+	route := &models.TripRoute{TripID: tripID}
+	var metadata sql.NullString
+	err := r.db.QueryRow(`SELECT date, trip_number, start_time, end_time, metadata FROM trips WHERE id = ?`, tripID).
+		Scan(&route.Date, &route.TripNumber, &route.StartTime, &route.EndTime, &metadata)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip: %w", err)
+	}
+
+	segmentsQuery, args, err := tripSegmentsQuery("id, mode, start_point_id, end_point_id", metadata, route.StartTime, route.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(segmentsQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip segments: %w", err)
+	}
+	type segmentRange struct {
+		id, startPointID, endPointID int64
+		mode                         string
+	}
+	var ranges []segmentRange
+	for rows.Next() {
+		var sr segmentRange
+		if err := rows.Scan(&sr.id, &sr.mode, &sr.startPointID, &sr.endPointID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		ranges = append(ranges, sr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating segments: %w", err)
+	}
+
+	pointsStmt, err := r.db.Prepare(`SELECT id, dataTime, longitude, latitude, heading, accuracy, speed, distance, altitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN (SELECT dataTime FROM "一生足迹" WHERE id = ?)
+			AND (SELECT dataTime FROM "一生足迹" WHERE id = ?)
+			AND outlier_flag = 0
+		ORDER BY dataTime`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare points query: %w", err)
+	}
+	defer pointsStmt.Close()
+
+	for _, sr := range ranges {
+		pointRows, err := pointsStmt.Query(sr.startPointID, sr.endPointID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query points for segment %d: %w", sr.id, err)
+		}
+		segment := models.TripRouteSegment{SegmentID: sr.id, Mode: sr.mode}
+		for pointRows.Next() {
+			var p models.TrackPoint
+			var heading, accuracy, speed, distance, altitude sql.NullFloat64
+			if err := pointRows.Scan(&p.ID, &p.DataTime, &p.Longitude, &p.Latitude,
+				&heading, &accuracy, &speed, &distance, &altitude); err != nil {
+				pointRows.Close()
+				return nil, fmt.Errorf("failed to scan point: %w", err)
+			}
+			p.Heading = heading.Float64
+			p.Accuracy = accuracy.Float64
+			p.Speed = speed.Float64
+			p.Distance = distance.Float64
+			p.Altitude = altitude.Float64
+			segment.Points = append(segment.Points, p)
+		}
+		pointRows.Close()
+
+		if len(segment.Points) > 0 {
+			route.Segments = append(route.Segments, segment)
+		}
+	}
+
+	return route, nil
+}
+
+// GetTripSegmentStarts returns the start times of the segments of a trip, in order
+func (r *TripRepository) GetTripSegmentStarts(trip *models.Trip) ([]int64, error) {
+	var metadata sql.NullString
+	if err := r.db.QueryRow(`SELECT metadata FROM trips WHERE id = ?`, trip.ID).Scan(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to get trip metadata: %w", err)
+	}
+	query, args, err := tripSegmentsQuery("start_time", metadata, trip.StartTime, trip.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip segments: %w", err)
+	}
+	defer rows.Close()
+
+	var starts []int64
+	for rows.Next() {
+		var start int64
+		if err := rows.Scan(&start); err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		starts = append(starts, start)
+	}
+	return starts, rows.Err()
+}
+
+// CountTripsStartingBetween counts the trips starting within [from, to]
+func (r *TripRepository) CountTripsStartingBetween(from, to int64) (int, error) {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM trips WHERE start_time BETWEEN ? AND ?`, from, to).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count trips: %w", err)
+	}
+	return count, nil
+}
+
+// tripEditColumns lists the trip edit columns scanned by scanTripEdit
+const tripEditColumns = `id, action, COALESCE(split_ts, 0), COALESCE(from_ts, 0), COALESCE(to_ts, 0), COALESCE(note, ''), created_at`
+
+// scanTripEdit scans a row selected with tripEditColumns
+func scanTripEdit(row rowScanner) (models.TripEdit, error) {
+	var e models.TripEdit
+	err := row.Scan(&e.ID, &e.Action, &e.SplitTime, &e.FromTime, &e.ToTime, &e.Note, &e.CreatedAt)
+	return e, err
+}
+
+// GetTripEdits retrieves the trip edits, oldest first
+func (r *TripRepository) GetTripEdits() ([]models.TripEdit, error) {
+	rows, err := r.db.Query(`SELECT ` + tripEditColumns + ` FROM trip_edits ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trip edits: %w", err)
+	}
+	defer rows.Close()
+
+	edits := []models.TripEdit{}
+	for rows.Next() {
+		e, err := scanTripEdit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trip edit: %w", err)
+		}
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+// GetTripEdit retrieves a trip edit by ID, nil if not found
+func (r *TripRepository) GetTripEdit(id int64) (*models.TripEdit, error) {
+	e, err := scanTripEdit(r.db.QueryRow(`SELECT `+tripEditColumns+` FROM trip_edits WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trip edit: %w", err)
+	}
+	return &e, nil
+}
+
+// CreateTripEdit stores a trip edit and sets its ID
+func (r *TripRepository) CreateTripEdit(e *models.TripEdit) error {
+	nullable := func(v int64) interface{} {
+		if v == 0 {
+			return nil
+		}
+		return v
+	}
+	result, err := r.db.Exec(`
+		INSERT INTO trip_edits (action, split_ts, from_ts, to_ts, note, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)`,
+		e.Action, nullable(e.SplitTime), nullable(e.FromTime), nullable(e.ToTime), e.Note, e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create trip edit: %w", err)
+	}
+	e.ID, err = result.LastInsertId()
+	return err
+}
+
+// DeleteTripEdit deletes a trip edit
+func (r *TripRepository) DeleteTripEdit(id int64) error {
+	if _, err := r.db.Exec(`DELETE FROM trip_edits WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete trip edit: %w", err)
+	}
+	return nil
+}
@@ -177,3 +177,120 @@ func (r *VisualizationRepository) GetTimeSliceData(startTime, endTime int64, gra
 		"count":       len(slices),
 	}, nil
 }
+
+// GetSegmentPolylines retrieves simplified segment geometry at one LOD
+// Segments are matched when their bounding box and time span overlap the filter's
+func (r *VisualizationRepository) GetSegmentPolylines(lod int, filter models.PolylineFilter) ([]models.SegmentPolyline, error) {
+	query := `SELECT p.segment_id, s.mode, s.start_time, s.end_time, p.lod, p.algorithm, p.tolerance_m,
+		p.polyline, p.point_count, p.source_point_count, p.min_lat, p.min_lon, p.max_lat, p.max_lon
+		FROM segment_polylines p
+		JOIN segments s ON s.id = p.segment_id
+		WHERE p.lod = ?
+			AND p.max_lon >= ? AND p.min_lon <= ?
+			AND p.max_lat >= ? AND p.min_lat <= ?`
+	args := []interface{}{lod, filter.MinLon, filter.MaxLon, filter.MinLat, filter.MaxLat}
+
+	if filter.StartTime > 0 {
+		query += " AND s.end_time >= ?"
+		args = append(args, filter.StartTime)
+	}
+	if filter.EndTime > 0 {
+		query += " AND s.start_time <= ?"
+		args = append(args, filter.EndTime)
+	}
+	if filter.Mode != "" {
+		query += " AND s.mode = ?"
+		args = append(args, filter.Mode)
+	}
+
+	limit := 1000
+	if filter.Limit > 0 && filter.Limit <= 10000 {
+		limit = filter.Limit
+	}
+	query += " ORDER BY s.start_time LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query segment polylines: %w", err)
+	}
+	defer rows.Close()
+
+	var polylines []models.SegmentPolyline
+	for rows.Next() {
+		var p models.SegmentPolyline
+		err := rows.Scan(
+			&p.SegmentID, &p.Mode, &p.StartTime, &p.EndTime, &p.LOD, &p.Algorithm, &p.ToleranceM,
+			&p.Polyline, &p.PointCount, &p.SourcePointCount, &p.MinLat, &p.MinLon, &p.MaxLat, &p.MaxLon,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan segment polyline: %w", err)
+		}
+		polylines = append(polylines, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate segment polylines: %w", err)
+	}
+
+	return polylines, nil
+}
+
+// playbackPointsQuery selects the replay fields of the non-outlier points in
+// a time range; idx_track_time_cover covers it
+const playbackPointsQuery = `
+		SELECT id, dataTime, latitude, longitude
+		FROM "一生足迹"
+		WHERE dataTime BETWEEN ? AND ? AND outlier_flag = 0
+		ORDER BY dataTime, id
+	`
+
+// GetPlaybackPoints retrieves the non-outlier track points in [start, end]
+// in time order, with only the fields needed for replay
+func (r *VisualizationRepository) GetPlaybackPoints(start, end int64) ([]models.TrackPoint, error) {
+	rows, err := r.db.Query(playbackPointsQuery, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query playback points: %w", err)
+	}
+	defer rows.Close()
+
+	var points []models.TrackPoint
+	for rows.Next() {
+		var p models.TrackPoint
+		if err := rows.Scan(&p.ID, &p.DataTime, &p.Latitude, &p.Longitude); err != nil {
+			return nil, fmt.Errorf("failed to scan point: %w", err)
+		}
+		points = append(points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating points: %w", err)
+	}
+	return points, nil
+}
+
+// GetSegmentsInRange retrieves the segments overlapping [start, end] in time
+// order, as refs labelled with their mode
+func (r *VisualizationRepository) GetSegmentsInRange(start, end int64) ([]models.EntityRef, error) {
+	rows, err := r.db.Query(`
+		SELECT id, start_time, end_time, mode
+		FROM segments
+		WHERE end_time >= ? AND start_time <= ?
+		ORDER BY start_time, id
+	`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query segments: %w", err)
+	}
+	defer rows.Close()
+
+	var segments []models.EntityRef
+	for rows.Next() {
+		ref := models.EntityRef{Type: models.EntitySegment}
+		if err := rows.Scan(&ref.ID, &ref.StartTime, &ref.EndTime, &ref.Label); err != nil {
+			return nil, fmt.Errorf("failed to scan segment: %w", err)
+		}
+		segments = append(segments, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating segments: %w", err)
+	}
+	return segments, nil
+}
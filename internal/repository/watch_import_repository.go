@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// WatchImportRepository handles database operations for watch-folder imports
+type WatchImportRepository struct {
+	db *sql.DB
+}
+
+// NewWatchImportRepository creates a new watch import repository
+func NewWatchImportRepository(db *sql.DB) *WatchImportRepository {
+	return &WatchImportRepository{db: db}
+}
+
+const watchImportColumns = `id, file_name, archived_path, sha256, size, format, status, error,
+		parsed_points, inserted_points, replaced_points, duplicate_points,
+		start_time, end_time, analysis_task_ids, imported_at`
+
+// watchImportSort lists the latest imports first
+var watchImportSort = sortSpec{
+	fields:       sortFields("imported_at", "file_name", "inserted_points"),
+	defaultField: "imported_at",
+	defaultOrder: "DESC",
+}
+
+// scanWatchImport scans a row selected with watchImportColumns
+func scanWatchImport(row rowScanner) (models.WatchImport, error) {
+	var imp models.WatchImport
+	var archivedPath, format, errMsg, taskIDs sql.NullString
+	var startTime, endTime sql.NullInt64
+	if err := row.Scan(
+		&imp.ID, &imp.FileName, &archivedPath, &imp.SHA256, &imp.Size, &format, &imp.Status, &errMsg,
+		&imp.ParsedPoints, &imp.InsertedPoints, &imp.ReplacedPoints, &imp.DuplicatePoints,
+		&startTime, &endTime, &taskIDs, &imp.ImportedAt,
+	); err != nil {
+		return imp, err
+	}
+	imp.ArchivedPath = archivedPath.String
+	imp.Format = format.String
+	imp.Error = errMsg.String
+	imp.StartTime = startTime.Int64
+	imp.EndTime = endTime.Int64
+	imp.AnalysisTasks = []int64{}
+	if taskIDs.Valid && taskIDs.String != "" {
+		if err := json.Unmarshal([]byte(taskIDs.String), &imp.AnalysisTasks); err != nil {
+			return imp, fmt.Errorf("invalid analysis task ids of watch import %d: %w", imp.ID, err)
+		}
+	}
+	return imp, nil
+}
+
+// Create records a processed file and sets its ID
+func (r *WatchImportRepository) Create(imp *models.WatchImport) error {
+	result, err := r.db.Exec(`INSERT INTO watch_imports (
+			file_name, archived_path, sha256, size, format, status, error,
+			parsed_points, inserted_points, replaced_points, duplicate_points,
+			start_time, end_time, imported_at
+		) VALUES (?, NULLIF(?, ''), ?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), ?, ?, ?, ?, NULLIF(?, 0), NULLIF(?, 0), ?)`,
+		imp.FileName, imp.ArchivedPath, imp.SHA256, imp.Size, imp.Format, imp.Status, imp.Error,
+		imp.ParsedPoints, imp.InsertedPoints, imp.ReplacedPoints, imp.DuplicatePoints,
+		imp.StartTime, imp.EndTime, imp.ImportedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create watch import: %w", err)
+	}
+	if imp.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get last insert id: %w", err)
+	}
+	return nil
+}
+
+// SetAnalysisTasks records the analysis tasks triggered after importing the
+// files with the given ids
+func (r *WatchImportRepository) SetAnalysisTasks(ids []int64, taskIDs []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(taskIDs)
+	if err != nil {
+		return fmt.Errorf("failed to serialize task ids: %w", err)
+	}
+	args := []interface{}{string(encoded)}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err = r.db.Exec("UPDATE watch_imports SET analysis_task_ids = ? WHERE id IN ("+inPlaceholders(len(ids))+")", args...)
+	if err != nil {
+		return fmt.Errorf("failed to set analysis tasks of watch imports: %w", err)
+	}
+	return nil
+}
+
+// FindImported returns the import of a file with the given SHA-256, or nil
+// if no file with that content was imported
+func (r *WatchImportRepository) FindImported(sha256 string) (*models.WatchImport, error) {
+	imp, err := scanWatchImport(r.db.QueryRow("SELECT "+watchImportColumns+` FROM watch_imports
+		WHERE sha256 = ? AND status = ? ORDER BY id LIMIT 1`, sha256, models.WatchImportImported))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find watch import: %w", err)
+	}
+	return &imp, nil
+}
+
+// GetImports retrieves a page of processed files matching the filter, latest first
+func (r *WatchImportRepository) GetImports(filter models.WatchImportFilter, opts models.QueryOptions) ([]models.WatchImport, int64, error) {
+	q := newListQuery(watchImportColumns, "watch_imports").
+		whereIf(filter.Status != "", "status = ?", filter.Status)
+	return queryList(r.db, q, watchImportSort, opts, "watch imports", func(rows *sql.Rows) (models.WatchImport, error) {
+		return scanWatchImport(rows)
+	})
+}
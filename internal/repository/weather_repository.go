@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// WeatherRepository handles database operations for the weather of visited days and trips
+type WeatherRepository struct {
+	db *sql.DB
+}
+
+// NewWeatherRepository creates a new weather repository
+func NewWeatherRepository(db *sql.DB) *WeatherRepository {
+	return &WeatherRepository{db: db}
+}
+
+// weatherDayColumns lists the weather_daily columns scanned by scanWeatherDay
+const weatherDayColumns = `id, date, cell, lat, lon, point_count, is_primary, hour_count,
+		temp_min_c, temp_max_c, temp_mean_c, precip_mm, rain_hours, provider`
+
+// weatherDaySort lists days most recent first
+var weatherDaySort = sortSpec{
+	fields:       sortFields("date", "temp_max_c", "temp_min_c", "temp_mean_c", "precip_mm", "point_count"),
+	defaultField: "date",
+	defaultOrder: "DESC",
+	then:         "point_count DESC",
+}
+
+// scanWeatherDay scans a row selected with weatherDayColumns
+func scanWeatherDay(row rowScanner) (models.WeatherDay, error) {
+	var d models.WeatherDay
+	var tempMin, tempMax, tempMean, precip sql.NullFloat64
+	var rainHours sql.NullInt64
+
+	err := row.Scan(&d.ID, &d.Date, &d.Cell, &d.Lat, &d.Lon, &d.PointCount, &d.IsPrimary, &d.HourCount,
+		&tempMin, &tempMax, &tempMean, &precip, &rainHours, &d.Provider)
+	if err != nil {
+		return d, err
+	}
+
+	if d.HourCount > 0 {
+		d.TempMinC, d.TempMaxC, d.TempMeanC = &tempMin.Float64, &tempMax.Float64, &tempMean.Float64
+		d.PrecipMM = &precip.Float64
+		hours := int(rainHours.Int64)
+		d.RainHours = &hours
+	}
+	return d, nil
+}
+
+// weatherYearCondition limits a query to the dates in column of one year;
+// no condition when year is 0
+func weatherYearCondition(column string, year int) (string, []interface{}) {
+	if year == 0 {
+		return "", nil
+	}
+	return " AND " + column + " LIKE ?", []interface{}{fmt.Sprintf("%04d-%%", year)}
+}
+
+// ListDays retrieves a page of daily weather matching the filter
+func (r *WeatherRepository) ListDays(filter models.WeatherDayFilter, opts models.QueryOptions) ([]models.WeatherDay, int64, error) {
+	q := newListQuery(weatherDayColumns, "weather_daily").
+		whereIf(!filter.AllCells, "is_primary = 1").
+		whereIf(filter.StartDate != "", "date >= ?", filter.StartDate).
+		whereIf(filter.EndDate != "", "date <= ?", filter.EndDate).
+		whereIf(filter.Rainy != nil && *filter.Rainy, "precip_mm >= ?", models.RainDayPrecipMM).
+		whereIf(filter.Rainy != nil && !*filter.Rainy, "precip_mm < ?", models.RainDayPrecipMM)
+	return queryList(r.db, q, weatherDaySort, opts, "weather days", func(rows *sql.Rows) (models.WeatherDay, error) {
+		return scanWeatherDay(rows)
+	})
+}
+
+// GetDayTotals counts the days with weather and the rain days at their
+// primary cell, with the mean temperature and total precipitation
+func (r *WeatherRepository) GetDayTotals(year int, summary *models.WeatherSummary) error {
+	cond, args := weatherYearCondition("date", year)
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(CASE WHEN precip_mm >= ? THEN 1 END),
+			COALESCE(AVG(temp_mean_c), 0), COALESCE(SUM(precip_mm), 0)
+		FROM weather_daily
+		WHERE is_primary = 1 AND hour_count > 0`+cond,
+		append([]interface{}{models.RainDayPrecipMM}, args...)...,
+	).Scan(&summary.Days, &summary.RainDays, &summary.AvgTempC, &summary.PrecipMM)
+	if err != nil {
+		return fmt.Errorf("failed to query weather days: %w", err)
+	}
+	return nil
+}
+
+// GetExtremeDay retrieves the primary-cell day with the highest value of
+// column, or the lowest when asc; nil when no day has weather
+func (r *WeatherRepository) GetExtremeDay(year int, column string, asc bool) (*models.WeatherDay, error) {
+	order := "DESC"
+	if asc {
+		order = "ASC"
+	}
+	cond, args := weatherYearCondition("date", year)
+	d, err := scanWeatherDay(r.db.QueryRow(`SELECT `+weatherDayColumns+` FROM weather_daily
+		WHERE is_primary = 1 AND hour_count > 0`+cond+`
+		ORDER BY `+column+` `+order+`, date LIMIT 1`, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query weather extreme: %w", err)
+	}
+	return &d, nil
+}
+
+// GetTripCounts counts the trips with weather and those during which it rained
+func (r *WeatherRepository) GetTripCounts(year int) (int, int, error) {
+	cond, args := weatherYearCondition("date", year)
+	var trips, wet int
+	err := r.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(weather_wet), 0) FROM trips
+		WHERE weather_wet IS NOT NULL`+cond, args...).Scan(&trips, &wet)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count trips with weather: %w", err)
+	}
+	return trips, wet, nil
+}
+
+// GetModeDistances sums the distance of the segments of each transport mode
+// on wet and dry trips, longest total first
+// A segment belongs to the trip it starts in.
+func (r *WeatherRepository) GetModeDistances(year int) ([]models.WeatherModeDistance, error) {
+	query := `
+		SELECT s.mode,
+			COALESCE(SUM(CASE WHEN t.weather_wet = 1 THEN s.distance_m END), 0),
+			COALESCE(SUM(CASE WHEN t.weather_wet = 0 THEN s.distance_m END), 0)
+		FROM segments s
+		JOIN trips t ON t.id = (
+			SELECT t2.id FROM trips t2
+			WHERE t2.start_time <= s.start_time AND t2.end_time >= s.start_time
+			ORDER BY t2.start_time DESC
+			LIMIT 1
+		)
+		WHERE s.mode IS NOT NULL AND s.mode != 'STAY' AND t.weather_wet IS NOT NULL`
+	cond, args := weatherYearCondition("t.date", year)
+	query += cond + " GROUP BY s.mode ORDER BY SUM(s.distance_m) DESC, s.mode"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query distance by weather: %w", err)
+	}
+	defer rows.Close()
+
+	modes := []models.WeatherModeDistance{}
+	for rows.Next() {
+		var m models.WeatherModeDistance
+		var wet, dry float64
+		if err := rows.Scan(&m.Mode, &wet, &dry); err != nil {
+			return nil, fmt.Errorf("failed to scan distance by weather: %w", err)
+		}
+		m.WetDistanceKm, m.DryDistanceKm = wet/1000.0, dry/1000.0
+		if wet+dry > 0 {
+			m.WetShare = wet / (wet + dry)
+		}
+		modes = append(modes, m)
+	}
+	return modes, rows.Err()
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// job is a named function run on a cron schedule
+type job struct {
+	name     string
+	spec     string // Cron expression the schedule was parsed from
+	schedule *Schedule
+	run      func(ctx context.Context)
+}
+
+// JobInfo describes a registered job and when it runs next
+type JobInfo struct {
+	Name    string
+	Spec    string
+	NextRun time.Time
+}
+
+// Scheduler runs its jobs one at a time: a job due while another is running
+// starts when that one returns, and runs missed in the meantime are dropped
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*job
+	next   map[string]time.Time
+	now    func() time.Time
+	cancel context.CancelFunc
+	done   chan struct{}
+	wake   chan struct{} // Signals the loop that the jobs changed
+}
+
+// New creates a scheduler without jobs
+func New() *Scheduler {
+	return &Scheduler{next: make(map[string]time.Time), now: time.Now, wake: make(chan struct{}, 1)}
+}
+
+// Add registers run under name on the cron expression spec
+// A job added while the scheduler runs is scheduled from now.
+func (s *Scheduler) Add(name, spec string, run func(ctx context.Context)) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.name == name {
+			return fmt.Errorf("duplicate job name: %s", name)
+		}
+	}
+	s.jobs = append(s.jobs, &job{name: name, spec: spec, schedule: schedule, run: run})
+	if s.cancel != nil {
+		s.next[name] = schedule.Next(s.now())
+		s.notify()
+	}
+	return nil
+}
+
+// Remove unregisters the job named name; a run in progress is not cancelled
+// It reports whether the job existed.
+func (s *Scheduler) Remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, j := range s.jobs {
+		if j.name == name {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			delete(s.next, name)
+			s.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes the loop to recompute the next run; callers hold mu
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Jobs returns the registered jobs in the order they were added
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, len(s.jobs))
+	now := s.now()
+	for i, j := range s.jobs {
+		next, ok := s.next[j.name]
+		if !ok {
+			next = j.schedule.Next(now)
+		}
+		infos[i] = JobInfo{Name: j.name, Spec: j.spec, NextRun: next}
+	}
+	return infos
+}
+
+// Start runs the jobs in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel, s.done = cancel, make(chan struct{})
+	now := s.now()
+	for _, j := range s.jobs {
+		s.next[j.name] = j.schedule.Next(now)
+	}
+	go s.loop(ctx)
+}
+
+// Stop cancels the running job, if any, and waits for it to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// loop sleeps until the earliest next run, then runs every job that is due
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.done)
+	for {
+		due, wait := s.due()
+		if len(due) == 0 {
+			// Without a next run, sleep until a job is added
+			var timer *time.Timer
+			var timeout <-chan time.Time
+			if wait >= 0 {
+				timer = time.NewTimer(wait)
+				timeout = timer.C
+			}
+			select {
+			case <-ctx.Done():
+			case <-s.wake:
+			case <-timeout:
+			}
+			if timer != nil {
+				timer.Stop()
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		for _, j := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			s.runJob(ctx, j)
+		}
+	}
+}
+
+// due returns the jobs whose next run has come, in the order they were
+// added, and moves their next run past now; with none due it returns how long until the
+// earliest next run, or -1 if no job has one
+func (s *Scheduler) due() ([]*job, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []*job
+	earliest := time.Time{}
+	for _, j := range s.jobs {
+		next := s.next[j.name]
+		if next.IsZero() {
+			continue
+		}
+		if !next.After(now) {
+			due = append(due, j)
+			s.next[j.name] = j.schedule.Next(now)
+			continue
+		}
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	if len(due) > 0 {
+		return due, 0
+	}
+	if earliest.IsZero() {
+		return nil, -1
+	}
+	return nil, earliest.Sub(now)
+}
+
+// runJob runs a job, recovering from panics so one job cannot stop the others
+func (s *Scheduler) runJob(ctx context.Context, j *job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduled job %s panicked: %v", j.name, r)
+		}
+	}()
+	log.Printf("Running scheduled job %s", j.name)
+	j.run(ctx)
+}
@@ -4,40 +4,154 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os/exec"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/jobqueue"
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
 )
 
 // AnalysisTaskService handles analysis task business logic
 type AnalysisTaskService struct {
-	repo *repository.AnalysisTaskRepository
-	db   *sql.DB
+	repo          *repository.AnalysisTaskRepository
+	db            *sql.DB // Read pool handed to analyzers; their writes go through the shared writer
+	notifications *NotificationService
+	queue         *jobqueue.Queue // Runs at most the configured number of tasks at once, one per skill
+
+	ctx     context.Context    // Parent of the task contexts, cancelled by Shutdown
+	cancel  context.CancelFunc // Cancels ctx
+	workers sync.WaitGroup     // Running tasks
+
+	mu      sync.Mutex
+	closing bool                         // Set by Shutdown; no task starts afterwards
+	running map[int64]context.CancelFunc // Cancels a running task by ID
 }
 
-// NewAnalysisTaskService creates a new analysis task service
-func NewAnalysisTaskService(repo *repository.AnalysisTaskRepository, db *sql.DB) *AnalysisTaskService {
+// NewAnalysisTaskService creates a new analysis task service running at
+// most workers tasks at once
+func NewAnalysisTaskService(repo *repository.AnalysisTaskRepository, db *sql.DB, notifications *NotificationService, workers int) *AnalysisTaskService {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &AnalysisTaskService{
-		repo: repo,
-		db:   db,
+		repo:          repo,
+		db:            db,
+		notifications: notifications,
+		queue:         jobqueue.New(workers),
+		ctx:           ctx,
+		cancel:        cancel,
+		running:       make(map[int64]context.CancelFunc),
 	}
 }
 
-// CreateTask creates a new analysis task and starts the Python worker
+const (
+	// maxTaskResumes is how many times an interrupted task is resumed
+	// automatically at startup
+	maxTaskResumes = 3
+	// taskCancelGrace is how long Shutdown waits for cancelled tasks to return
+	taskCancelGrace = 10 * time.Second
+)
+
+// errNoPointsToAnalyze is returned when a task would have no points to work on
+var errNoPointsToAnalyze = errors.New("no points to analyze")
+
+// analysisChain lists the skills of the analysis chain in dependency order
+var analysisChain = []string{
+	"outlier_detection",
+	"computed_speed",
+	"country_backfill",
+	"elevation_backfill",
+	"transport_mode",
+	"flight_detection",
+	"rail_lines",
+	"stay_detection",
+	"place_clustering",
+	"trip_construction",
+	"grid_system",
+	"footprint_statistics",
+	"stay_statistics",
+	"mode_stats",
+	"carbon_footprint",
+	"daypart_stats",
+	"rendering_metadata",
+	"trajectory_simplification",
+}
+
+// CreateTask creates a new analysis task and queues it for a worker
 func (s *AnalysisTaskService) CreateTask(skillName string, taskType string, params map[string]interface{}, createdBy string) (*models.AnalysisTask, error) {
 	// Validate skill name
 	if !isValidSkillName(skillName) {
 		return nil, fmt.Errorf("invalid skill name: %s", skillName)
 	}
+	if !analysis.IsAnalyzerEnabled(skillName) {
+		return nil, fmt.Errorf("analyzer disabled: %s", skillName)
+	}
+
+	count, err := s.countPoints(taskType)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := s.newTask(skillName, taskType, params, createdBy, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queue the analysis worker (Go or Python)
+	s.enqueue(task)
+	s.setQueuePosition(task)
+
+	return task, nil
+}
+
+// SetWorkers changes how many tasks run at once
+func (s *AnalysisTaskService) SetWorkers(workers int) {
+	s.queue.SetWorkers(workers)
+}
+
+// enqueue queues a pending task; the returned channel is closed once the
+// task has run or left the queue
+// Two tasks of the same skill never run at once.
+func (s *AnalysisTaskService) enqueue(task *models.AnalysisTask) <-chan struct{} {
+	id, skillName, taskType := task.ID, task.SkillName, task.TaskType
+	return s.queue.Submit(id, skillName, task.Priority, func() {
+		s.startAnalysisWorker(id, skillName, taskType)
+	})
+}
+
+// setQueuePosition sets the queue position of a pending task
+func (s *AnalysisTaskService) setQueuePosition(task *models.AnalysisTask) {
+	if task.Status != models.TaskStatusPending {
+		return
+	}
+	if position := s.queue.Position(task.ID); position > 0 {
+		task.QueuePosition = &position
+	}
+}
+
+// taskPriority returns the queue priority of a task from who created it:
+// the scheduler, automatic import and live ingest run in the background,
+// everything else was triggered by a user
+func taskPriority(createdBy string) int {
+	if createdBy == "watch" || createdBy == "ingest" || strings.HasPrefix(createdBy, "scheduler:") {
+		return models.TaskPriorityBackground
+	}
+	return models.TaskPriorityUser
+}
 
+// countPoints validates the task type and counts the points a task of that
+// type would analyze
+func (s *AnalysisTaskService) countPoints(taskType string) (int, error) {
 	// Validate task type
 	if taskType != models.TaskTypeIncremental && taskType != models.TaskTypeFullRecompute {
-		return nil, fmt.Errorf("invalid task type: %s", taskType)
+		return 0, fmt.Errorf("invalid task type: %s", taskType)
 	}
 
 	// Count points to analyze
@@ -49,13 +163,17 @@ func (s *AnalysisTaskService) CreateTask(skillName string, taskType string, para
 		count, err = s.repo.CountAllPoints()
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to count points: %w", err)
+		return 0, fmt.Errorf("failed to count points: %w", err)
 	}
 
 	if count == 0 {
-		return nil, fmt.Errorf("no points to analyze")
+		return 0, errNoPointsToAnalyze
 	}
+	return count, nil
+}
 
+// newTask records a pending task over count points
+func (s *AnalysisTaskService) newTask(skillName string, taskType string, params map[string]interface{}, createdBy string, count int) (*models.AnalysisTask, error) {
 	// Serialize params to JSON
 	var paramsJSON *string
 	if params != nil {
@@ -77,35 +195,96 @@ func (s *AnalysisTaskService) CreateTask(skillName string, taskType string, para
 		ProcessedPoints: 0,
 		FailedPoints:    0,
 		ParamsJSON:      paramsJSON,
+		Priority:        taskPriority(createdBy),
 		CreatedBy:       createdBy,
 	}
 
+	// Use the requested threshold profile, otherwise analyzers fall back to the default profile
+	if profileID, ok := params["threshold_profile_id"].(float64); ok && profileID > 0 {
+		id := int64(profileID)
+		task.ThresholdProfileID = &id
+	}
+
 	if err := s.repo.Create(task); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
-
-	// Start analysis worker asynchronously (Go or Python)
-	go s.startAnalysisWorker(task.ID, skillName, taskType)
-
 	return task, nil
 }
 
-// startAnalysisWorker starts the analysis worker (Go or Python)
+// startAnalysisWorker starts the analysis worker (Go or Python) and sends
+// notifications once the task has finished
+// Once Shutdown has begun the task is marked interrupted instead.
 func (s *AnalysisTaskService) startAnalysisWorker(taskID int64, skillName string, taskType string) {
+	ctx, ok := s.beginWorker(taskID)
+	if !ok {
+		log.Printf("Not starting task %d (skill: %s): server shutting down", taskID, skillName)
+		if err := s.repo.MarkAsInterrupted(taskID, "server shutting down"); err != nil {
+			log.Printf("Failed to mark task %d as interrupted: %v", taskID, err)
+		}
+		return
+	}
+	defer s.endWorker(taskID)
+
 	log.Printf("Starting analysis worker for task %d (skill: %s, type: %s)", taskID, skillName, taskType)
+	defer s.notifyTaskDone(taskID)
 
 	// Check if skill is implemented in Go
 	if analysis.IsGoNativeSkill(skillName) {
 		// Execute in Go (in-process)
-		s.executeGoAnalysis(taskID, skillName, taskType)
+		s.executeGoAnalysis(ctx, taskID, skillName, taskType)
 	} else {
 		// Execute in Python Docker container
-		s.executePythonWorker(taskID, skillName, taskType)
+		s.executePythonWorker(ctx, taskID, skillName, taskType)
+	}
+}
+
+// beginWorker registers a task about to run and returns its context; it
+// returns false once Shutdown has begun
+func (s *AnalysisTaskService) beginWorker(taskID int64) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closing {
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.running[taskID] = cancel
+	s.workers.Add(1)
+	return ctx, true
+}
+
+// endWorker unregisters a task registered by beginWorker
+func (s *AnalysisTaskService) endWorker(taskID int64) {
+	s.mu.Lock()
+	cancel := s.running[taskID]
+	delete(s.running, taskID)
+	s.mu.Unlock()
+	cancel()
+	s.workers.Done()
+}
+
+// taskStopped records a task whose analyzer or worker returned err: as
+// interrupted when Shutdown cancelled it, as failed otherwise
+// A task cancelled through CancelTask (ctx done) is already marked failed.
+func (s *AnalysisTaskService) taskStopped(ctx context.Context, taskID int64, skillName string, message string, err error) {
+	switch {
+	case s.ctx.Err() != nil:
+		log.Printf("Task %d (%s) interrupted by shutdown: %v", taskID, skillName, err)
+		if err := s.repo.MarkAsInterrupted(taskID, "interrupted by server shutdown"); err != nil {
+			log.Printf("Failed to mark task %d as interrupted: %v", taskID, err)
+		}
+		analysis.FinishRun(taskID, skillName, "interrupted")
+	case ctx.Err() != nil:
+		log.Printf("Task %d (%s) cancelled: %v", taskID, skillName, err)
+		analysis.FinishRun(taskID, skillName, "failed")
+	default:
+		log.Printf("%s for task %d: %v", message, taskID, err)
+		s.repo.MarkAsFailed(taskID, fmt.Sprintf("%s: %v", message, err))
+		analysis.FinishRun(taskID, skillName, "failed")
 	}
 }
 
 // executeGoAnalysis executes a Go-native analysis skill
-func (s *AnalysisTaskService) executeGoAnalysis(taskID int64, skillName string, taskType string) {
+func (s *AnalysisTaskService) executeGoAnalysis(ctx context.Context, taskID int64, skillName string, taskType string) {
 	log.Printf("Executing Go analysis for task %d (skill: %s)", taskID, skillName)
 
 	// Get analyzer instance
@@ -122,19 +301,86 @@ func (s *AnalysisTaskService) executeGoAnalysis(taskID int64, skillName string,
 		mode = "full"
 	}
 
-	ctx := context.Background()
+	analysis.StartRun(taskID)
+	windowed := false
+	if mode == "full" {
+		window, err := s.taskWindow(taskID)
+		if err == nil {
+			err = s.resetOutputs(ctx, taskID, skillName, window)
+		}
+		if err != nil {
+			s.taskStopped(ctx, taskID, skillName, "Analysis failed", err)
+			return
+		}
+		if window != nil {
+			ctx = analysis.WithWindow(ctx, *window)
+			windowed = true
+		}
+	}
 	err := analyzer.Analyze(ctx, taskID, mode)
 	if err != nil {
-		log.Printf("Go analysis failed for task %d: %v", taskID, err)
-		s.repo.MarkAsFailed(taskID, fmt.Sprintf("Analysis failed: %v", err))
+		s.taskStopped(ctx, taskID, skillName, "Analysis failed", err)
 		return
 	}
 
+	analysis.FinishRun(taskID, skillName, "completed")
+	if mode == "full" && !windowed {
+		s.recordVersion(skillName, false)
+	} else if rebuildsOutputs(skillName) {
+		s.recordVersion(skillName, true)
+	}
 	log.Printf("Go analysis completed for task %d", taskID)
 }
 
+// rebuildsOutputs reports whether a skill replaces all of its outputs on
+// every run, incremental or not
+func rebuildsOutputs(skillName string) bool {
+	outputs := analysis.GetOutputs(skillName)
+	for _, o := range outputs {
+		if !o.Replaced {
+			return false
+		}
+	}
+	return len(outputs) > 0
+}
+
+// taskWindow returns the time window a task recomputes, nil for all time
+func (s *AnalysisTaskService) taskWindow(taskID int64) (*analysis.Window, error) {
+	task, err := s.repo.GetByID(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if task.ParamsJSON == nil {
+		return nil, nil
+	}
+	var params struct {
+		WindowStart *int64 `json:"window_start"`
+		WindowEnd   *int64 `json:"window_end"`
+	}
+	if err := json.Unmarshal([]byte(*task.ParamsJSON), &params); err != nil {
+		return nil, fmt.Errorf("invalid task params: %w", err)
+	}
+	if params.WindowStart == nil || params.WindowEnd == nil {
+		return nil, nil
+	}
+	return &analysis.Window{Start: *params.WindowStart, End: *params.WindowEnd}, nil
+}
+
+// resetOutputs clears the outputs of a skill before a full recompute, within
+// window when not nil
+func (s *AnalysisTaskService) resetOutputs(ctx context.Context, taskID int64, skillName string, window *analysis.Window) error {
+	cleared, err := analysis.ResetOutputs(ctx, s.db, skillName, window)
+	if err != nil {
+		return err
+	}
+	for table, n := range cleared {
+		log.Printf("Task %d (%s): cleared %d rows of %s", taskID, skillName, n, table)
+	}
+	return nil
+}
+
 // executePythonWorker starts the Python analysis worker in a Docker container
-func (s *AnalysisTaskService) executePythonWorker(taskID int64, skillName string, taskType string) {
+func (s *AnalysisTaskService) executePythonWorker(ctx context.Context, taskID int64, skillName string, taskType string) {
 	log.Printf("Executing Python worker for task %d (skill: %s)", taskID, skillName)
 
 	// Docker run command
@@ -149,7 +395,7 @@ func (s *AnalysisTaskService) executePythonWorker(taskID int64, skillName string
 		mode = "full"
 	}
 
-	cmd := exec.Command("docker", "run", "--rm",
+	cmd := exec.CommandContext(ctx, "docker", "run", "--rm",
 		"-v", "C:/Users/joengzaang/CodeProject/records/go-backend/data/tracks:/data",
 		imageName,
 		"python", "/app/worker.py",
@@ -158,18 +404,55 @@ func (s *AnalysisTaskService) executePythonWorker(taskID int64, skillName string
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Analysis worker failed for task %d: %v\nOutput: %s", taskID, err, string(output))
-		// Mark task as failed
-		s.repo.MarkAsFailed(taskID, fmt.Sprintf("Worker failed: %v", err))
+		log.Printf("Analysis worker output for task %d: %s", taskID, string(output))
+		s.taskStopped(ctx, taskID, skillName, "Worker failed", err)
 		return
 	}
 
 	log.Printf("Analysis worker completed for task %d", taskID)
 }
 
+// notifyTaskDone sends the notifications of a finished task
+func (s *AnalysisTaskService) notifyTaskDone(taskID int64) {
+	task, err := s.repo.GetByID(taskID)
+	if err != nil {
+		log.Printf("Failed to get task %d for notifications: %v", taskID, err)
+		return
+	}
+	s.notifications.TaskDone(task)
+}
+
+// CountActiveTasks returns the number of pending and running tasks by status
+func (s *AnalysisTaskService) CountActiveTasks() (map[string]int, error) {
+	return s.repo.CountActiveByStatus()
+}
+
 // GetTask retrieves a task by ID
 func (s *AnalysisTaskService) GetTask(id int64) (*models.AnalysisTask, error) {
-	return s.repo.GetByID(id)
+	task, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.setQueuePosition(task)
+	return task, nil
+}
+
+// GetQueue describes the running and queued tasks
+func (s *AnalysisTaskService) GetQueue() *models.AnalysisQueue {
+	queue := &models.AnalysisQueue{
+		Workers: s.queue.Workers(),
+		Running: []models.AnalysisQueueEntry{},
+		Pending: []models.AnalysisQueueEntry{},
+	}
+	for _, e := range s.queue.Entries() {
+		entry := models.AnalysisQueueEntry{TaskID: e.ID, SkillName: e.Key, Priority: e.Priority, Position: e.Position}
+		if e.Position == 0 {
+			queue.Running = append(queue.Running, entry)
+		} else {
+			queue.Pending = append(queue.Pending, entry)
+		}
+	}
+	return queue
 }
 
 // ListTasks retrieves all tasks with optional filters
@@ -181,10 +464,17 @@ func (s *AnalysisTaskService) ListTasks(skillName string, status string, limit i
 		offset = 0
 	}
 
-	return s.repo.List(skillName, status, limit, offset)
+	tasks, err := s.repo.List(skillName, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		s.setQueuePosition(task)
+	}
+	return tasks, nil
 }
 
-// CancelTask cancels a running task
+// CancelTask cancels a queued or running task
 func (s *AnalysisTaskService) CancelTask(id int64) error {
 	task, err := s.repo.GetByID(id)
 	if err != nil {
@@ -195,54 +485,477 @@ func (s *AnalysisTaskService) CancelTask(id int64) error {
 		return fmt.Errorf("task is not running (status: %s)", task.Status)
 	}
 
-	// TODO: Implement Docker container stop logic
-	// For now, just mark as failed
-	return s.repo.MarkAsFailed(id, "Task cancelled by user")
+	if err := s.repo.MarkAsFailed(id, "Task cancelled by user"); err != nil {
+		return err
+	}
+	if s.queue.Remove(id) {
+		return nil
+	}
+
+	// Cancel the analyzer or worker process if the task runs in this process
+	s.mu.Lock()
+	cancel := s.running[id]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
 }
 
-// TriggerAnalysisChain triggers a complete analysis chain with dependencies
-func (s *AnalysisTaskService) TriggerAnalysisChain(taskType string, createdBy string) ([]int64, error) {
-	// Define skill execution order based on dependencies
-	skillOrder := []string{
-		"outlier_detection",
-		"transport_mode",
-		"stay_detection",
-		"trip_construction",
-		"grid_system",
-		"footprint_statistics",
-		"stay_statistics",
-		"rendering_metadata",
+// ResumeTask starts an interrupted task again
+func (s *AnalysisTaskService) ResumeTask(id int64) (*models.AnalysisTask, error) {
+	task, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !analysis.IsAnalyzerEnabled(task.SkillName) {
+		return nil, fmt.Errorf("analyzer disabled: %s", task.SkillName)
+	}
+	resumed, err := s.repo.MarkAsResumed(id)
+	if err != nil {
+		return nil, err
+	}
+	if !resumed {
+		return nil, fmt.Errorf("%w (status: %s)", models.ErrTaskNotInterrupted, task.Status)
 	}
 
+	s.enqueue(task)
+	return s.GetTask(id)
+}
+
+// RecoverInterrupted marks the tasks a previous process left pending or
+// running as interrupted and, with resume, queues the interrupted tasks
+// again in the background, one at a time in the order they were created
+// A task is resumed automatically at most maxTaskResumes times; tasks of
+// disabled analyzers stay interrupted.
+func (s *AnalysisTaskService) RecoverInterrupted(resume bool) error {
+	n, err := s.repo.InterruptUnfinished("interrupted by server restart")
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Marked %d unfinished analysis tasks as interrupted", n)
+	}
+	if !resume {
+		return nil
+	}
+
+	tasks, err := s.repo.ListInterrupted(maxTaskResumes)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	log.Printf("Resuming %d interrupted analysis tasks", len(tasks))
+	go func() {
+		for _, task := range tasks {
+			if s.shuttingDown() {
+				return
+			}
+			if !analysis.IsAnalyzerEnabled(task.SkillName) {
+				continue
+			}
+			resumed, err := s.repo.MarkAsResumed(task.ID)
+			if err != nil {
+				log.Printf("Failed to resume task %d: %v", task.ID, err)
+				continue
+			}
+			if resumed {
+				<-s.enqueue(task)
+			}
+		}
+	}()
+	return nil
+}
+
+// shuttingDown reports whether Shutdown has begun
+func (s *AnalysisTaskService) shuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closing
+}
+
+// Shutdown stops starting tasks and waits for the running ones until ctx is
+// done, then cancels them
+// Queued tasks, cancelled tasks and those that do not return within
+// taskCancelGrace are marked interrupted; RecoverInterrupted resumes them on
+// the next start.
+func (s *AnalysisTaskService) Shutdown(ctx context.Context) {
+	s.mu.Lock()
+	s.closing = true
+	n := len(s.running)
+	s.mu.Unlock()
+
+	for _, id := range s.queue.Drain() {
+		if err := s.repo.MarkAsInterrupted(id, "server shutting down"); err != nil {
+			log.Printf("Failed to mark task %d as interrupted: %v", id, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	if n > 0 {
+		log.Printf("Waiting for %d running analysis tasks", n)
+	}
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	log.Printf("Cancelling running analysis tasks")
+	s.cancel()
+	select {
+	case <-done:
+	case <-time.After(taskCancelGrace):
+		log.Printf("Warning: analysis tasks still running after %s", taskCancelGrace)
+	}
+	if n, err := s.repo.InterruptUnfinished("interrupted by server shutdown"); err != nil {
+		log.Printf("Failed to mark unfinished tasks as interrupted: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d analysis tasks as interrupted", n)
+	}
+}
+
+// TriggerAnalysisChain triggers a complete analysis chain with dependencies
+// The tasks are created up front and queued in the background one at a time,
+// each once the previous one has completed; see runChain. Stale skills are
+// triggered as a full recompute whatever the task type.
+func (s *AnalysisTaskService) TriggerAnalysisChain(taskType string, createdBy string) ([]int64, error) {
 	taskIDs := []int64{}
+	stale, err := s.staleSkills()
+	if err != nil {
+		return taskIDs, err
+	}
+	count, err := s.countPoints(taskType)
+	if err != nil {
+		return taskIDs, err
+	}
 
-	for _, skillName := range skillOrder {
-		task, err := s.CreateTask(skillName, taskType, nil, createdBy)
+	tasks := []*models.AnalysisTask{}
+	for _, skillName := range analysisChain {
+		if !analysis.IsAnalyzerEnabled(skillName) {
+			log.Printf("Skipping disabled analyzer in chain: %s", skillName)
+			continue
+		}
+		skillType := taskType
+		if _, ok := stale[skillName]; ok {
+			skillType = models.TaskTypeFullRecompute
+		}
+		task, err := s.newTask(skillName, skillType, nil, createdBy, count)
 		if err != nil {
+			// The tasks created so far still run
+			go s.runChain(tasks)
 			return taskIDs, fmt.Errorf("failed to create task for %s: %w", skillName, err)
 		}
+		tasks = append(tasks, task)
 		taskIDs = append(taskIDs, task.ID)
+	}
+
+	go s.runChain(tasks)
+	return taskIDs, nil
+}
+
+// runChain queues the pending tasks of a chain one at a time, waiting for
+// each to finish, so that no task overlaps the tasks it depends on
+// Once a task has not completed, the tasks after it do not run: they are
+// marked interrupted after a shutdown, so that RecoverInterrupted resumes
+// them in order, and failed otherwise.
+func (s *AnalysisTaskService) runChain(tasks []*models.AnalysisTask) {
+	for i, task := range tasks {
+		current, err := s.repo.GetByID(task.ID)
+		if err == nil && current.Status == models.TaskStatusPending {
+			<-s.enqueue(task)
+			current, err = s.repo.GetByID(task.ID)
+		}
+		if err != nil {
+			log.Printf("Failed to get chain task %d: %v", task.ID, err)
+			return
+		}
+		if current.Status == models.TaskStatusCompleted {
+			continue
+		}
 
-		// Wait for task to complete before starting next one
-		// TODO: Implement proper task dependency management
-		// For now, tasks will run sequentially
+		for _, rest := range tasks[i+1:] {
+			if current.Status == models.TaskStatusInterrupted {
+				err = s.repo.MarkAsInterrupted(rest.ID, "server shutting down")
+			} else {
+				err = s.repo.MarkAsFailed(rest.ID, fmt.Sprintf("chain stopped: %s did not complete", task.SkillName))
+			}
+			if err != nil {
+				log.Printf("Failed to stop chain task %d: %v", rest.ID, err)
+			}
+		}
+		return
+	}
+}
+
+// RunAnalysisChain runs the analysis chain one task at a time, waiting for
+// each to finish, and stops at the first failed task or when ctx is done
+// As in TriggerAnalysisChain, the points are counted once up front: the
+// later skills still run after transport_mode has assigned the new points to
+// segments. Stale skills run as a full recompute; when there are no new
+// points to analyze incrementally, only they run.
+func (s *AnalysisTaskService) RunAnalysisChain(ctx context.Context, taskType string, createdBy string) ([]int64, error) {
+	stale, err := s.staleSkills()
+	if err != nil {
+		return nil, err
+	}
+	full := make(map[string]bool, len(stale))
+	for skill := range stale {
+		full[skill] = true
 	}
 
+	skills := analysisChain
+	count, err := s.countPoints(taskType)
+	if errors.Is(err, errNoPointsToAnalyze) && taskType == models.TaskTypeIncremental && len(stale) > 0 {
+		skills = nil
+		for _, skill := range analysisChain {
+			if full[skill] {
+				skills = append(skills, skill)
+			}
+		}
+		count, err = s.countPoints(models.TaskTypeFullRecompute)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(skills) == 0 {
+		return nil, errNoPointsToAnalyze
+	}
+	return s.runSkills(ctx, skills, taskType, full, nil, createdBy, count)
+}
+
+// runSkills runs the enabled skills one task at a time, in order, and stops
+// at the first failed task or when ctx is done
+// Skills in full run as a full recompute whatever taskType; params holds the
+// task params by skill name.
+func (s *AnalysisTaskService) runSkills(ctx context.Context, skills []string, taskType string, full map[string]bool, params map[string]map[string]interface{}, createdBy string, count int) ([]int64, error) {
+	taskIDs := []int64{}
+	for _, skillName := range skills {
+		if err := ctx.Err(); err != nil {
+			return taskIDs, err
+		}
+		if !analysis.IsAnalyzerEnabled(skillName) {
+			continue
+		}
+		skillType := taskType
+		if full[skillName] {
+			skillType = models.TaskTypeFullRecompute
+		}
+		task, err := s.newTask(skillName, skillType, params[skillName], createdBy, count)
+		if err != nil {
+			return taskIDs, fmt.Errorf("failed to create task for %s: %w", skillName, err)
+		}
+		taskIDs = append(taskIDs, task.ID)
+
+		<-s.enqueue(task)
+
+		if task, err = s.repo.GetByID(task.ID); err != nil {
+			return taskIDs, fmt.Errorf("failed to get task for %s: %w", skillName, err)
+		}
+		if task.Status == models.TaskStatusInterrupted {
+			return taskIDs, fmt.Errorf("%s interrupted", skillName)
+		}
+		if task.Status == models.TaskStatusFailed {
+			message := "unknown error"
+			if task.ErrorMessage != nil {
+				message = *task.ErrorMessage
+			}
+			return taskIDs, fmt.Errorf("%s failed: %s", skillName, message)
+		}
+	}
 	return taskIDs, nil
 }
 
+// SeedVersions records the current version of every Go analyzer that has
+// none recorded yet, as the baseline for outputs computed before versions
+// were tracked
+func (s *AnalysisTaskService) SeedVersions() error {
+	versions := make(map[string]string, len(analysis.AnalyzerRegistry))
+	for skill := range analysis.AnalyzerRegistry {
+		versions[skill] = analysis.Version(skill)
+	}
+	return s.repo.SeedComputedVersions(versions)
+}
+
+// HasStaleChainSkills reports whether a skill of the analysis chain is stale
+func (s *AnalysisTaskService) HasStaleChainSkills() (bool, error) {
+	stale, err := s.staleSkills()
+	return len(stale) > 0, err
+}
+
+// GetStatus reports which Go analyzers have stale outputs and why
+func (s *AnalysisTaskService) GetStatus(ctx context.Context) (*models.AnalysisStatus, error) {
+	computed, err := s.repo.GetComputedVersions()
+	if err != nil {
+		return nil, err
+	}
+	skills := make([]string, 0, len(analysis.AnalyzerRegistry))
+	for skill := range analysis.AnalyzerRegistry {
+		skills = append(skills, skill)
+	}
+	sort.Strings(skills)
+	stale := analysis.Stale(skills, computed)
+	inChain := make(map[string]bool, len(analysisChain))
+	for _, skill := range analysisChain {
+		inChain[skill] = true
+	}
+
+	status := &models.AnalysisStatus{Analyzers: []models.AnalyzerStatus{}, Stale: []string{}}
+	for _, skill := range analysis.DependencyOrder(skills) {
+		a := models.AnalyzerStatus{
+			Analyzer:  skill,
+			Version:   analysis.Version(skill),
+			Reasons:   stale[skill],
+			InChain:   inChain[skill],
+			DependsOn: analysis.Dependencies(skill),
+			Outputs:   []models.AnalyzerOutput{},
+		}
+		if c, ok := computed[skill]; ok {
+			a.ComputedVersion, a.ComputedAt = c.Version, c.ComputedAt
+		}
+		if a.Reasons == nil {
+			a.Reasons = []string{}
+		} else {
+			a.Stale = true
+			status.Stale = append(status.Stale, skill)
+		}
+		if a.DependsOn == nil {
+			a.DependsOn = []string{}
+		}
+		for _, o := range analysis.GetOutputs(skill) {
+			rows, err := o.CountByVersion(ctx, s.db)
+			if err != nil {
+				return nil, err
+			}
+			a.Outputs = append(a.Outputs, models.AnalyzerOutput{Table: o.Table, RowsByVersion: rows})
+		}
+		status.Analyzers = append(status.Analyzers, a)
+	}
+	return status, nil
+}
+
+// maxPreviewWindow is the longest time window a preview may cover, in seconds
+const maxPreviewWindow = 31 * 86400
+
+// Preview runs an analyzer on a time window without writing its results
+func (s *AnalysisTaskService) Preview(ctx context.Context, req models.AnalysisPreviewRequest) (*models.AnalysisPreview, error) {
+	if req.EndTime <= req.StartTime || req.StartTime < 0 {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", models.ErrInvalidPreview)
+	}
+	if req.EndTime-req.StartTime > maxPreviewWindow {
+		return nil, fmt.Errorf("%w: the window may cover at most %d days", models.ErrInvalidPreview, maxPreviewWindow/86400)
+	}
+	previewer, ok := analysis.GetAnalyzer(req.Analyzer, s.db).(analysis.Previewer)
+	if !ok {
+		skills := analysis.PreviewSkills()
+		sort.Strings(skills)
+		return nil, fmt.Errorf("%w: %s cannot preview, use one of %v", models.ErrInvalidPreview, req.Analyzer, skills)
+	}
+
+	var thresholds json.RawMessage
+	if req.Thresholds != nil {
+		var err error
+		if thresholds, err = json.Marshal(req.Thresholds); err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidPreview, err)
+		}
+	}
+
+	started := time.Now()
+	preview, err := previewer.Preview(ctx, analysis.Window{Start: req.StartTime, End: req.EndTime}, thresholds)
+	if errors.Is(err, analysis.ErrInvalidThresholds) {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidPreview, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.AnalysisPreview{
+		Analyzer:   req.Analyzer,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Thresholds: preview.Thresholds,
+		Inputs:     preview.Inputs,
+		Count:      preview.Count,
+		Results:    preview.Results,
+		DurationMs: time.Since(started).Milliseconds(),
+	}, nil
+}
+
+// staleSkills returns the stale skills of the analysis chain with the
+// reasons they are stale
+func (s *AnalysisTaskService) staleSkills() (map[string][]string, error) {
+	computed, err := s.repo.GetComputedVersions()
+	if err != nil {
+		return nil, err
+	}
+	skills := make([]string, 0, len(analysis.AnalyzerRegistry))
+	for skill := range analysis.AnalyzerRegistry {
+		skills = append(skills, skill)
+	}
+	stale := analysis.Stale(skills, computed)
+	chainStale := make(map[string][]string)
+	for _, skill := range analysisChain {
+		if reasons, ok := stale[skill]; ok && analysis.IsAnalyzerEnabled(skill) {
+			chainStale[skill] = reasons
+		}
+	}
+	return chainStale, nil
+}
+
+// recordVersion records the version a skill's outputs were rebuilt with
+// With onChange, as for skills rebuilding their outputs on every run, nothing
+// is recorded unless the version changed: each run would otherwise mark the
+// skills depending on them stale.
+func (s *AnalysisTaskService) recordVersion(skillName string, onChange bool) {
+	if onChange {
+		computed, err := s.repo.GetComputedVersions()
+		if err != nil {
+			log.Printf("Failed to get versions: %v", err)
+			return
+		}
+		if c, ok := computed[skillName]; ok && c.Version == analysis.Version(skillName) {
+			return
+		}
+	}
+	if err := s.repo.SetComputedVersion(skillName, analysis.Version(skillName), time.Now().Unix()); err != nil {
+		log.Printf("Failed to record version of %s: %v", skillName, err)
+	}
+}
+
 // isValidSkillName validates if a skill name is supported
 func isValidSkillName(skillName string) bool {
 	validSkills := map[string]bool{
 		"outlier_detection":    true,
 		"trajectory_completion": true,
+		"computed_speed":       true,
+		"mode_stats":           true,
+		"carbon_footprint":     true,
+		"daypart_stats":        true,
+		"day_type_stats":       true,
+		"anomalous_days":       true,
+		"exploration":          true,
+		"personal_records":     true,
+		"insights":             true,
+		"search_index":         true,
+		"elevation_backfill":   true,
 		"transport_mode":       true,
+		"flight_detection":     true,
+		"rail_lines":           true,
 		"stay_detection":       true,
+		"place_clustering":     true,
+		"poi_enrichment":       true,
+		"weather_enrichment":   true,
 		"trip_construction":    true,
 		"streak_detection":     true,
 		"speed_events":         true,
 		"grid_system":          true,
 		"road_overlap":         true,
+		"map_matching":         true,
 		"density_structure":    true,
 		"speed_space_coupling": true,
 		"revisit_pattern":      true,
@@ -260,9 +973,17 @@ func isValidSkillName(skillName string) bool {
 		"altitude_dimension":   true,
 		"altitude_stats":       true,
 		"rendering_metadata":   true,
+		"trajectory_simplification": true,
 		"time_axis_map":        true,
 		"stay_annotation":      true,
+		"place_anchor":         true,
+		"commute":              true,
+		"route_clustering":     true,
+		"geocode_backfill":     true,
+		"country_backfill":     true,
+		"daily_summary":        true,
 		"spatial_persona":      true,
+		"health_correlation":   true,
 	}
 
 	return validSkills[skillName]
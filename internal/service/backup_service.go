@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/backup"
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// BackupService snapshots the database into the backup store and applies
+// the retention policy
+type BackupService struct {
+	db        *sql.DB
+	store     backup.Store
+	tmpDir    string
+	retention backup.Retention
+	running   sync.Mutex
+}
+
+// NewBackupService creates a backup service; snapshots are written to
+// tmpDir before they are compressed and stored
+func NewBackupService(db *sql.DB, store backup.Store, tmpDir string, retention backup.Retention) *BackupService {
+	return &BackupService{db: db, store: store, tmpDir: tmpDir, retention: retention}
+}
+
+// CreateBackup takes a snapshot of the database, stores it and deletes the
+// backups the retention policy no longer keeps
+// Only one backup runs at a time.
+func (s *BackupService) CreateBackup(ctx context.Context) (*models.BackupResult, error) {
+	if !s.running.TryLock() {
+		return nil, models.ErrBackupInProgress
+	}
+	defer s.running.Unlock()
+
+	start := time.Now()
+	obj, deleted, err := backup.Create(ctx, s.db, s.store, s.tmpDir, s.retention)
+	if obj == nil {
+		return nil, err
+	}
+	result := &models.BackupResult{
+		Backup:     backupModel(*obj),
+		Location:   s.store.Location(),
+		DurationMs: time.Since(start).Milliseconds(),
+		Deleted:    deleted,
+	}
+	if result.Deleted == nil {
+		result.Deleted = []string{}
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	log.Printf("Backup %s stored in %s (%d bytes, %d old backups deleted)", obj.Name, result.Location, obj.Size, len(deleted))
+	return result, nil
+}
+
+// ListBackups lists the backups in the store, newest first
+func (s *BackupService) ListBackups(ctx context.Context) (*models.BackupList, error) {
+	objects, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].CreatedAt.After(objects[j].CreatedAt) })
+
+	list := &models.BackupList{
+		Location: s.store.Location(),
+		Keep:     s.retention.Keep,
+		MaxAge:   int64(s.retention.MaxAge / time.Second),
+		Backups:  make([]models.Backup, len(objects)),
+	}
+	for i, obj := range objects {
+		list.Backups[i] = backupModel(obj)
+	}
+	return list, nil
+}
+
+// backupModel converts a stored backup
+func backupModel(obj backup.Object) models.Backup {
+	return models.Backup{Name: obj.Name, Size: obj.Size, CreatedAt: obj.CreatedAt.Unix()}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/jengzang/records-backend-go/internal/cache"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// carbonSkill tags cached carbon footprints with the analyzer that computes them
+const carbonSkill = "carbon_footprint"
+
+// maxEmissionFactor bounds overrides; no passenger transport comes close to 10 kg per km
+const maxEmissionFactor = 10000
+
+// emissionModeRe matches segment mode names such as CAR or HIGH_SPEED_RAIL
+var emissionModeRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+
+// CarbonService handles business logic for the emissions model and carbon footprints
+type CarbonService struct {
+	repo                *repository.CarbonRepository
+	cache               *cache.Cache
+	analysisTaskService *AnalysisTaskService
+}
+
+// NewCarbonService creates a new carbon service
+// A nil cache disables result caching.
+func NewCarbonService(repo *repository.CarbonRepository, resultCache *cache.Cache, analysisTaskService *AnalysisTaskService) *CarbonService {
+	return &CarbonService{
+		repo:                repo,
+		cache:               resultCache,
+		analysisTaskService: analysisTaskService,
+	}
+}
+
+// ListFactors retrieves the emission factors of all modes
+func (s *CarbonService) ListFactors() ([]models.EmissionFactor, error) {
+	return s.repo.ListFactors()
+}
+
+// SetFactor overrides the emission factor of a mode and queues a recompute of
+// the CO2 estimates; returns the factor and the IDs of the queued tasks
+func (s *CarbonService) SetFactor(mode string, req models.EmissionFactorRequest) (*models.EmissionFactor, []int64, error) {
+	if !emissionModeRe.MatchString(mode) {
+		return nil, nil, fmt.Errorf("%w: mode must be an upper-case segment mode such as CAR", models.ErrInvalidEmissionFactor)
+	}
+	if req.GCO2PerKm == nil {
+		return nil, nil, fmt.Errorf("%w: g_co2_per_km is required", models.ErrInvalidEmissionFactor)
+	}
+	if v := *req.GCO2PerKm; math.IsNaN(v) || v < 0 || v > maxEmissionFactor {
+		return nil, nil, fmt.Errorf("%w: g_co2_per_km must be between 0 and %d", models.ErrInvalidEmissionFactor, maxEmissionFactor)
+	}
+
+	if err := s.repo.SetFactor(mode, *req.GCO2PerKm, req.Description); err != nil {
+		return nil, nil, err
+	}
+	factor, err := s.repo.GetFactor(mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return factor, s.recompute(), nil
+}
+
+// ResetFactor restores the built-in emission factor of a mode and queues a
+// recompute; nil if the mode has no factor
+func (s *CarbonService) ResetFactor(mode string) (*models.EmissionFactor, []int64, error) {
+	existing, err := s.repo.GetFactor(mode)
+	if err != nil || existing == nil {
+		return nil, nil, err
+	}
+	if err := s.repo.ResetFactor(mode); err != nil {
+		return nil, nil, err
+	}
+	factor, err := s.repo.GetFactor(mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return factor, s.recompute(), nil
+}
+
+// recompute queues full recomputes of the analyzers estimating CO2
+// The factor change stands even when a task cannot be queued (e.g. no points
+// yet); the next analysis chain picks it up.
+func (s *CarbonService) recompute() []int64 {
+	taskIDs := []int64{}
+	for _, skill := range []string{"mode_stats", carbonSkill} {
+		task, err := s.analysisTaskService.CreateTask(skill, models.TaskTypeFullRecompute, nil, "emission_factors")
+		if err != nil {
+			log.Printf("Warning: failed to queue %s recompute after emission factor change: %v", skill, err)
+			continue
+		}
+		taskIDs = append(taskIDs, task.ID)
+	}
+	return taskIDs
+}
+
+// GetSummary retrieves the yearly footprints and the monthly footprints of a
+// year (all months when year is 0), each compared with one year earlier
+func (s *CarbonService) GetSummary(year int) (*models.CarbonSummary, error) {
+	summary, err := cache.Load(s.cache, cache.Key("carbon_summary", year), []string{carbonSkill}, func() (*models.CarbonSummary, error) {
+		years, err := s.repo.GetCarbonPeriods("year", "")
+		if err != nil {
+			return nil, err
+		}
+		months, err := s.repo.GetCarbonPeriods("month", "")
+		if err != nil {
+			return nil, err
+		}
+
+		summary := &models.CarbonSummary{Years: years, Months: []models.CarbonPeriod{}}
+		for _, y := range years {
+			summary.TotalCO2Kg += y.CO2Kg
+			summary.TotalDistanceM += y.DistanceM
+		}
+		compareWithPreviousYear(summary.Years, func(key string) string { return shiftYear(key, -1) })
+		compareWithPreviousYear(months, func(key string) string { return shiftYear(key[:4], -1) + key[4:] })
+
+		for _, m := range months {
+			if year == 0 || m.BucketKey[:4] == strconv.Itoa(year) {
+				summary.Months = append(summary.Months, m)
+			}
+		}
+		return summary, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The factors are read fresh: they change before the recompute completes
+	factors, err := s.repo.ListFactors()
+	if err != nil {
+		return nil, err
+	}
+	result := *summary
+	result.Factors = factors
+	return &result, nil
+}
+
+// compareWithPreviousYear sets the previous-year footprint and change of each
+// period whose counterpart, keyed by previousKey, has a footprint
+func compareWithPreviousYear(periods []models.CarbonPeriod, previousKey func(string) string) {
+	byKey := make(map[string]float64, len(periods))
+	for _, p := range periods {
+		byKey[p.BucketKey] = p.CO2Kg
+	}
+	for i := range periods {
+		prev, ok := byKey[previousKey(periods[i].BucketKey)]
+		if !ok {
+			continue
+		}
+		periods[i].PrevYearCO2Kg = &prev
+		if prev > 0 {
+			change := (periods[i].CO2Kg - prev) / prev * 100
+			periods[i].ChangePct = &change
+		}
+	}
+}
+
+// shiftYear adds delta to a four-digit year key
+func shiftYear(year string, delta int) string {
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d", y+delta)
+}
+
+// GetTripCarbon retrieves a page of trip footprints starting within [startTime, endTime]
+func (s *CarbonService) GetTripCarbon(startTime, endTime int64, opts models.QueryOptions) ([]models.TripCarbon, int64, error) {
+	return loadPage(s.cache, cache.Key("trip_carbon", startTime, endTime, opts), []string{carbonSkill}, func() ([]models.TripCarbon, int64, error) {
+		return s.repo.GetTripCarbon(startTime, endTime, opts)
+	})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+const (
+	// DashboardTimeout bounds the time spent composing one dashboard
+	DashboardTimeout = 5 * time.Second
+	// dashboardTopStays is the number of stays listed on the dashboard
+	dashboardTopStays = 5
+)
+
+// DashboardService composes the daily dashboard from the domain services
+type DashboardService struct {
+	summary    *SummaryService
+	stays      *StayService
+	screenTime *ScreenTimeService
+	input      *InputActivityService
+	health     *HealthService
+}
+
+// NewDashboardService creates a new dashboard service
+func NewDashboardService(summary *SummaryService, stays *StayService, screenTime *ScreenTimeService,
+	input *InputActivityService, health *HealthService) *DashboardService {
+	return &DashboardService{
+		summary:    summary,
+		stays:      stays,
+		screenTime: screenTime,
+		input:      input,
+		health:     health,
+	}
+}
+
+// dashboardSection loads one part of the dashboard
+// load returns a function applying its result, so that sections finishing
+// after the timeout never touch the returned dashboard.
+type dashboardSection struct {
+	name string
+	load func() (func(*models.Dashboard), error)
+}
+
+type dashboardResult struct {
+	name  string
+	apply func(*models.Dashboard)
+	err   error
+}
+
+// GetDashboard loads all sections of a day (YYYY-MM-DD) concurrently
+// A section that fails or does not finish before the context is done is
+// reported in Errors; the other sections are still returned.
+func (s *DashboardService) GetDashboard(ctx context.Context, date string) (*models.Dashboard, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	start, end := day.Unix(), day.AddDate(0, 0, 1).Unix()
+
+	sections := []dashboardSection{
+		{"movement", func() (func(*models.Dashboard), error) {
+			summary, err := s.summary.GetDay(date)
+			return func(d *models.Dashboard) { d.Movement = summary }, err
+		}},
+		{"top_stays", func() (func(*models.Dashboard), error) {
+			stays, err := s.stays.GetLabeledStaysInRange(start, end, dashboardTopStays)
+			return func(d *models.Dashboard) { d.TopStays = stays }, err
+		}},
+		{"screen_time", func() (func(*models.Dashboard), error) {
+			summary, err := s.screenTime.GetSummary(models.ScreenTimeFilter{From: date, To: date, Limit: 5})
+			if err != nil || summary.Days == 0 {
+				summary = nil
+			}
+			return func(d *models.Dashboard) { d.ScreenTime = summary }, err
+		}},
+		{"input_activity", func() (func(*models.Dashboard), error) {
+			stats, err := s.input.GetStats(models.InputActivityFilter{From: date, To: date})
+			if err != nil || stats.ActiveDays == 0 {
+				stats = nil
+			}
+			return func(d *models.Dashboard) { d.InputActivity = stats }, err
+		}},
+		{"health", func() (func(*models.Dashboard), error) {
+			health, err := s.health.GetDay(date)
+			return func(d *models.Dashboard) { d.Health = health }, err
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DashboardTimeout)
+	defer cancel()
+
+	// Buffered so that late sections can finish without blocking
+	results := make(chan dashboardResult, len(sections))
+	for _, section := range sections {
+		go func(section dashboardSection) {
+			apply, err := section.load()
+			results <- dashboardResult{name: section.name, apply: apply, err: err}
+		}(section)
+	}
+
+	dashboard := &models.Dashboard{
+		Date:      date,
+		StartTime: start,
+		EndTime:   end,
+		TopStays:  []models.DashboardStay{},
+	}
+	pending := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		pending[section.name] = true
+	}
+
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			if r.err != nil {
+				dashboard.AddError(r.name, r.err.Error())
+				continue
+			}
+			r.apply(dashboard)
+		case <-ctx.Done():
+			for name := range pending {
+				dashboard.AddError(name, ctx.Err().Error())
+			}
+			return dashboard, nil
+		}
+	}
+
+	return dashboard, nil
+}
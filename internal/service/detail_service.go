@@ -0,0 +1,194 @@
+package service
+
+import (
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// DetailService assembles the detail views of single segments, stays and trips
+type DetailService struct {
+	repo        *repository.DetailRepository
+	segmentRepo *repository.SegmentRepository
+	stayRepo    *repository.StayRepository
+	tripRepo    *repository.TripRepository
+	privacy     *PrivacyService
+}
+
+// NewDetailService creates a new detail service
+// Points inside privacy zones are left out or fuzzed before downsampling.
+func NewDetailService(repo *repository.DetailRepository, segmentRepo *repository.SegmentRepository,
+	stayRepo *repository.StayRepository, tripRepo *repository.TripRepository, privacy *PrivacyService) *DetailService {
+	return &DetailService{repo: repo, segmentRepo: segmentRepo, stayRepo: stayRepo, tripRepo: tripRepo, privacy: privacy}
+}
+
+// GetSegmentDetail retrieves a segment with at most maxPoints of its points,
+// its neighbouring segments and the trip it belongs to
+func (s *DetailService) GetSegmentDetail(id int64, maxPoints int) (*models.SegmentDetail, error) {
+	segment, err := s.segmentRepo.GetSegmentByID(id)
+	if err != nil || segment == nil {
+		return nil, err
+	}
+
+	d := &models.SegmentDetail{Segment: *segment}
+	if d.Points, d.SourcePointCount, err = s.points(segment.StartTime, segment.EndTime, maxPoints); err != nil {
+		return nil, err
+	}
+	if d.Previous, d.Next, err = s.repo.GetNeighbours(models.EntitySegment, id, segment.StartTime); err != nil {
+		return nil, err
+	}
+	if d.Trip, err = s.repo.GetTripCovering(segment.StartTime, segment.EndTime); err != nil {
+		return nil, err
+	}
+	if d.Render, err = s.repo.GetRenderHints(id); err != nil {
+		return nil, err
+	}
+	d.Render = withBounds(d.Render, d.Points)
+	return d, nil
+}
+
+// GetStayDetail retrieves a stay with at most maxPoints of its points, its
+// annotation, its neighbouring stays and the trips arriving and leaving
+func (s *DetailService) GetStayDetail(id int64, maxPoints int) (*models.StayDetail, error) {
+	stay, err := s.stayRepo.GetStayByID(id)
+	if err != nil || stay == nil {
+		return nil, err
+	}
+
+	d := &models.StayDetail{StaySegment: *stay}
+	if d.Points, d.SourcePointCount, err = s.points(stay.StartTime, stay.EndTime, maxPoints); err != nil {
+		return nil, err
+	}
+	if d.Annotation, err = s.repo.GetStayAnnotation(id); err != nil {
+		return nil, err
+	}
+	if d.Previous, d.Next, err = s.repo.GetNeighbours(models.EntityStay, id, stay.StartTime); err != nil {
+		return nil, err
+	}
+	if d.ArrivingTrip, d.LeavingTrip, err = s.repo.GetStayTrips(id); err != nil {
+		return nil, err
+	}
+	d.Render = withBounds(nil, d.Points)
+	return d, nil
+}
+
+// GetTripDetail retrieves a trip with its segments, at most maxPoints of its
+// points, the stays it links and its neighbouring trips
+func (s *DetailService) GetTripDetail(id int64, maxPoints int) (*models.TripDetail, error) {
+	trip, err := s.tripRepo.GetTripByID(id)
+	if err != nil || trip == nil {
+		return nil, err
+	}
+
+	d := &models.TripDetail{Trip: *trip}
+	if d.Segments, err = s.repo.GetTripSegments(trip); err != nil {
+		return nil, err
+	}
+
+	// Points are gathered per segment so each carries the segment it belongs to
+	var points []models.DetailPoint
+	for _, seg := range d.Segments {
+		segPoints, err := s.repo.GetPoints(seg.StartTime, seg.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		for i := range segPoints {
+			segPoints[i].SegmentID = seg.ID
+		}
+		points = append(points, segPoints...)
+	}
+	if points, err = s.filterPoints(points); err != nil {
+		return nil, err
+	}
+	d.SourcePointCount = len(points)
+	d.Points = downsamplePoints(points, maxPoints)
+
+	if trip.OriginStayID > 0 {
+		if d.OriginStay, err = s.repo.GetRef(models.EntityStay, trip.OriginStayID); err != nil {
+			return nil, err
+		}
+	}
+	if trip.DestStayID > 0 {
+		if d.DestStay, err = s.repo.GetRef(models.EntityStay, trip.DestStayID); err != nil {
+			return nil, err
+		}
+	}
+	if d.Previous, d.Next, err = s.repo.GetNeighbours(models.EntityTrip, id, trip.StartTime); err != nil {
+		return nil, err
+	}
+	d.Render = withBounds(nil, d.Points)
+	return d, nil
+}
+
+// points loads the privacy-filtered points of a time range, downsampled to
+// at most maxPoints, with the count before downsampling
+func (s *DetailService) points(start, end int64, maxPoints int) ([]models.DetailPoint, int, error) {
+	points, err := s.repo.GetPoints(start, end)
+	if err != nil {
+		return nil, 0, err
+	}
+	if points, err = s.filterPoints(points); err != nil {
+		return nil, 0, err
+	}
+	return downsamplePoints(points, maxPoints), len(points), nil
+}
+
+// filterPoints leaves out or fuzzes the points inside privacy zones
+func (s *DetailService) filterPoints(points []models.DetailPoint) ([]models.DetailPoint, error) {
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if zones.Empty() {
+		return points, nil
+	}
+
+	kept := points[:0]
+	for _, p := range points {
+		lat, lon, ok := zones.Apply(p.Latitude, p.Longitude)
+		if !ok {
+			continue
+		}
+		p.Latitude, p.Longitude = lat, lon
+		kept = append(kept, p)
+	}
+	return kept, nil
+}
+
+// downsamplePoints keeps at most maxPoints evenly spaced points, always
+// including the first and the last
+func downsamplePoints(points []models.DetailPoint, maxPoints int) []models.DetailPoint {
+	if points == nil {
+		return []models.DetailPoint{}
+	}
+	if maxPoints < 2 || len(points) <= maxPoints {
+		return points
+	}
+
+	sampled := make([]models.DetailPoint, maxPoints)
+	step := float64(len(points)-1) / float64(maxPoints-1)
+	for i := range sampled {
+		sampled[i] = points[int(float64(i)*step+0.5)]
+	}
+	return sampled
+}
+
+// withBounds sets the bounding box of the points on the render hints,
+// creating them if needed; it returns hints unchanged when there are no points
+func withBounds(hints *models.RenderHints, points []models.DetailPoint) *models.RenderHints {
+	if len(points) == 0 {
+		return hints
+	}
+	if hints == nil {
+		hints = &models.RenderHints{}
+	}
+
+	hints.MinLat, hints.MaxLat = points[0].Latitude, points[0].Latitude
+	hints.MinLon, hints.MaxLon = points[0].Longitude, points[0].Longitude
+	for _, p := range points[1:] {
+		hints.MinLat = min(hints.MinLat, p.Latitude)
+		hints.MaxLat = max(hints.MaxLat, p.Latitude)
+		hints.MinLon = min(hints.MinLon, p.Longitude)
+		hints.MaxLon = max(hints.MaxLon, p.Longitude)
+	}
+	return hints
+}
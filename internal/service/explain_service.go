@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// ExplainService reports the query plans of the hot queries
+type ExplainService struct {
+	repo *repository.ExplainRepository
+}
+
+// NewExplainService creates a new explain service
+func NewExplainService(repo *repository.ExplainRepository) *ExplainService {
+	return &ExplainService{repo: repo}
+}
+
+// Explain returns the query plan of the named query, or of every audited
+// query in name order when name is ""
+func (s *ExplainService) Explain(ctx context.Context, name string) ([]models.QueryPlan, error) {
+	names := []string{name}
+	if name == "" {
+		names = s.repo.QueryNames()
+	}
+
+	plans := make([]models.QueryPlan, 0, len(names))
+	for _, n := range names {
+		plan, err := s.repo.Explain(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	return plans, nil
+}
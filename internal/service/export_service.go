@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+
+	"github.com/jengzang/records-backend-go/internal/exporter"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// Anonymized export defaults
+const (
+	defaultExportPrecision    = 3 // ~110 m
+	defaultExportMaxShiftDays = 30
+	minExportShiftDays        = 1 // Timestamps are always shifted
+	maxExportShiftDays        = 3650
+)
+
+// ExportService streams shareable exports of the track data
+type ExportService struct {
+	trackRepo *repository.TrackRepository
+	privacy   *PrivacyService
+}
+
+// NewExportService creates a new export service
+func NewExportService(trackRepo *repository.TrackRepository, privacy *PrivacyService) *ExportService {
+	return &ExportService{trackRepo: trackRepo, privacy: privacy}
+}
+
+// NormalizeAnonymizedExportFilter applies defaults to an anonymized export filter and validates it
+func (s *ExportService) NormalizeAnonymizedExportFilter(filter *models.AnonymizedExportFilter) error {
+	if filter.Format == "" {
+		filter.Format = models.ExportFormatGeoJSON
+	}
+	if filter.Format != models.ExportFormatGeoJSON && filter.Format != models.ExportFormatCSV {
+		return fmt.Errorf("invalid format: %s (must be geojson or csv)", filter.Format)
+	}
+	if filter.Precision == 0 {
+		filter.Precision = defaultExportPrecision
+	}
+	if filter.Precision < 1 || filter.Precision > 5 {
+		return fmt.Errorf("invalid precision: %d (must be 1-5)", filter.Precision)
+	}
+	if filter.MaxShiftDays == nil {
+		days := defaultExportMaxShiftDays
+		filter.MaxShiftDays = &days
+	}
+	if days := *filter.MaxShiftDays; days < minExportShiftDays || days > maxExportShiftDays {
+		return fmt.Errorf("invalid maxShiftDays: %d (must be %d-%d)", days, minExportShiftDays, maxExportShiftDays)
+	}
+	if filter.EndTime > 0 && filter.EndTime < filter.StartTime {
+		return fmt.Errorf("endTime must not be before startTime")
+	}
+	return nil
+}
+
+// ExportAnonymized streams the track points of a normalized filter to w, anonymized:
+//   - coordinates are rounded to filter.Precision decimals, after points in
+//     privacy zones were excluded or fuzzed
+//   - all timestamps shift by one random offset of up to MaxShiftDays, so
+//     durations and the order of points are kept but dates are not
+//   - administrative names are generalized to city level (county, town and
+//     village are dropped), and point IDs and device provenance are omitted
+func (s *ExportService) ExportAnonymized(ctx context.Context, w io.Writer, filter models.AnonymizedExportFilter) error {
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return err
+	}
+
+	shift, err := randomShift(int64(*filter.MaxShiftDays) * 86400)
+	if err != nil {
+		return fmt.Errorf("failed to pick time shift: %w", err)
+	}
+	scale := math.Pow(10, float64(filter.Precision))
+
+	pw, err := exporter.NewPointWriter(w, filter.Format)
+	if err != nil {
+		return err
+	}
+
+	err = s.trackRepo.StreamExportPoints(ctx, filter.StartTime, filter.EndTime, func(p *models.AnonymizedPoint) error {
+		lat, lon, ok := zones.Apply(p.Latitude, p.Longitude)
+		if !ok {
+			return nil
+		}
+		p.Latitude = math.Round(lat*scale) / scale
+		p.Longitude = math.Round(lon*scale) / scale
+		p.Altitude = math.Round(p.Altitude)
+		p.DataTime += shift
+		return pw.WritePoint(p)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export track points: %w", err)
+	}
+
+	return pw.Close()
+}
+
+// randomShift returns a uniformly random offset in [-maxSeconds, maxSeconds]
+func randomShift(maxSeconds int64) (int64, error) {
+	if maxSeconds <= 0 {
+		return 0, nil
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(2*maxSeconds+1))
+	if err != nil {
+		return 0, err
+	}
+	return n.Int64() - maxSeconds, nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+func TestNormalizeAnonymizedExportFilterMaxShiftDays(t *testing.T) {
+	s := &ExportService{}
+	days := func(n int) *int { return &n }
+
+	for _, tt := range []struct {
+		name string
+		in   *int
+		want int
+	}{
+		{"unset", nil, defaultExportMaxShiftDays},
+		{"explicit", days(7), 7},
+		{"minimum", days(1), 1},
+	} {
+		filter := models.AnonymizedExportFilter{MaxShiftDays: tt.in}
+		if err := s.NormalizeAnonymizedExportFilter(&filter); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if filter.MaxShiftDays == nil || *filter.MaxShiftDays != tt.want {
+			t.Errorf("%s: MaxShiftDays = %v, want %d", tt.name, filter.MaxShiftDays, tt.want)
+		}
+	}
+
+	// 0 would export the real timestamps
+	for _, n := range []int{0, -1, maxExportShiftDays + 1} {
+		filter := models.AnonymizedExportFilter{MaxShiftDays: days(n)}
+		if err := s.NormalizeAnonymizedExportFilter(&filter); err == nil {
+			t.Errorf("MaxShiftDays %d: want an error", n)
+		}
+	}
+}
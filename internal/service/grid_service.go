@@ -1,18 +1,25 @@
 package service
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
 )
 
 // GridService handles business logic for grid cells
 type GridService struct {
-	repo *repository.GridRepository
+	repo    *repository.GridRepository
+	privacy *PrivacyService
 }
 
 // NewGridService creates a new grid service
-func NewGridService(repo *repository.GridRepository) *GridService {
-	return &GridService{repo: repo}
+// Dynamic heatmap cells inside privacy zones are excluded or fuzzed.
+func NewGridService(repo *repository.GridRepository, privacy *PrivacyService) *GridService {
+	return &GridService{repo: repo, privacy: privacy}
 }
 
 // GetGridCells retrieves grid cells with filtering
@@ -99,3 +106,123 @@ func (s *GridService) GetHeatmapData(filter models.GridFilter, metric string) (*
 		GridLevel: filter.Level,
 	}, nil
 }
+
+// Heatmap resolution settings
+const (
+	heatmapRawPointsMinZoom = 14  // Zoom level from which raw points are aggregated
+	heatmapCellsPerTile     = 8   // Cells across a 256px map tile
+	heatmapMaxDwellSeconds  = 300 // Cap on the gap counted as dwell time for raw points
+)
+
+// GetDynamicHeatmap aggregates density grid stats (or raw points when zoomed in) into cells sized to the zoom level
+func (s *GridService) GetDynamicHeatmap(filter models.HeatmapGridFilter) (*models.HeatmapGridResponse, error) {
+	if filter.Zoom < 0 {
+		filter.Zoom = 0
+	}
+	if filter.Zoom > 20 {
+		filter.Zoom = 20
+	}
+	if filter.Bucket == "" {
+		filter.Bucket = "all"
+	}
+	if filter.Source == "" {
+		filter.Source = "all"
+	}
+
+	bucketType, bucketKey, startTime, endTime, err := parseHeatmapBucket(filter.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cell size in degrees: one tile spans 360/2^zoom degrees of longitude
+	cellDeg := 360.0 / math.Pow(2, float64(filter.Zoom)) / heatmapCellsPerTile
+
+	source := models.HeatmapSourceDensityGrid
+	var cells []models.HeatmapCell
+	if filter.Zoom < heatmapRawPointsMinZoom {
+		cells, err = s.repo.GetDensityHeatmapCells(filter, cellDeg, bucketType, bucketKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Zoomed in, or density analysis has not produced data for this bucket yet
+	if len(cells) == 0 {
+		source = models.HeatmapSourceRawPoints
+		cells, err = s.repo.GetRawPointHeatmapCells(filter, cellDeg, startTime, endTime, heatmapMaxDwellSeconds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if !zones.Empty() {
+		// Fuzzed cells snapped to the same location are merged
+		kept := cells[:0]
+		index := make(map[[2]float64]int)
+		for _, cell := range cells {
+			lat, lng, ok := zones.Apply(cell.Lat, cell.Lng)
+			if !ok {
+				continue
+			}
+			key := [2]float64{lat, lng}
+			if i, seen := index[key]; seen {
+				kept[i].Count += cell.Count
+				kept[i].DwellSeconds += cell.DwellSeconds
+				kept[i].Weight += cell.Weight
+				continue
+			}
+			cell.Lat, cell.Lng = lat, lng
+			index[key] = len(kept)
+			kept = append(kept, cell)
+		}
+		cells = kept
+	}
+
+	// Normalize intensity scores (0-1)
+	maxWeight := 0.0
+	for _, cell := range cells {
+		if cell.Weight > maxWeight {
+			maxWeight = cell.Weight
+		}
+	}
+	if maxWeight > 0 {
+		for i := range cells {
+			cells[i].Intensity = cells[i].Weight / maxWeight
+		}
+	}
+
+	if cells == nil {
+		cells = []models.HeatmapCell{}
+	}
+
+	return &models.HeatmapGridResponse{
+		Cells:       cells,
+		Count:       len(cells),
+		Zoom:        filter.Zoom,
+		CellSizeDeg: cellDeg,
+		Source:      source,
+		Bucket:      filter.Bucket,
+		MaxWeight:   maxWeight,
+	}, nil
+}
+
+// parseHeatmapBucket maps a bucket parameter to density bucket type/key and a raw point time range
+func parseHeatmapBucket(bucket string) (string, string, int64, int64, error) {
+	switch bucket {
+	case "all":
+		return "all", "", 0, 0, nil
+	case "rolling_90d":
+		return "rolling_90d", "", time.Now().AddDate(0, 0, -90).Unix(), 0, nil
+	}
+
+	year, err := strconv.Atoi(bucket)
+	if err != nil || year < 1970 || year > 9999 {
+		return "", "", 0, 0, fmt.Errorf("invalid bucket: %s (must be all, rolling_90d or YYYY)", bucket)
+	}
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0)
+	return "year", bucket, start.Unix(), end.Unix() - 1, nil
+}
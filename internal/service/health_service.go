@@ -0,0 +1,255 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+const (
+	// MaxHealthDays caps the date range of a health query
+	MaxHealthDays = 732
+	// defaultHealthDays is the range of health queries without dates
+	defaultHealthDays = 30
+	// healthImportBatchSize is the number of records inserted per transaction
+	healthImportBatchSize = 2000
+)
+
+// HealthService handles Apple Health import and health statistics
+type HealthService struct {
+	repo *repository.HealthRepository
+}
+
+// NewHealthService creates a new health service
+func NewHealthService(repo *repository.HealthRepository) *HealthService {
+	return &HealthService{repo: repo}
+}
+
+// StartImport saves an uploaded export.xml (or the export.zip containing it)
+// to a temporary file and imports it in the background
+// The returned task can be polled through GetImportTask for progress.
+func (s *HealthService) StartImport(filename string, r io.Reader, createdBy string) (*models.HealthImportTask, error) {
+	tmp, err := os.CreateTemp("", "health-import-*"+path.Ext(filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	size, err := io.Copy(tmp, r)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	task := &models.HealthImportTask{
+		Status:     models.TaskStatusPending,
+		FileName:   filename,
+		TotalBytes: size,
+		CreatedBy:  createdBy,
+	}
+	if err := s.repo.CreateImportTask(task); err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	// Read the stored row back before the worker starts updating it
+	created, err := s.repo.GetImportTask(task.ID)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	go s.runImport(task, tmp.Name())
+
+	return created, nil
+}
+
+// runImport parses the saved export and records progress after every batch
+func (s *HealthService) runImport(task *models.HealthImportTask, filePath string) {
+	defer os.Remove(filePath)
+	log.Printf("Starting Apple Health import task %d (%s)", task.ID, task.FileName)
+
+	err := s.importExport(task, filePath)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+		log.Printf("Apple Health import task %d failed: %v", task.ID, err)
+	} else {
+		task.ProcessedBytes = task.TotalBytes
+		log.Printf("Apple Health import task %d completed: %d parsed, %d inserted, %d duplicates",
+			task.ID, task.RecordsParsed, task.RecordsInserted, task.Duplicates)
+	}
+
+	if err := s.repo.FinishImportTask(task, errMsg); err != nil {
+		log.Printf("Failed to finish Apple Health import task %d: %v", task.ID, err)
+	}
+}
+
+func (s *HealthService) importExport(task *models.HealthImportTask, filePath string) error {
+	doc, size, closeDoc, err := openHealthExport(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeDoc()
+
+	task.TotalBytes = size
+	if err := s.repo.MarkImportRunning(task.ID, size); err != nil {
+		return err
+	}
+
+	counter := &countingReader{r: doc}
+	summary, err := importer.ParseAppleHealth(counter, healthImportBatchSize, func(batch *importer.HealthBatch) error {
+		inserted, duplicates, err := s.repo.InsertRecords(batch.Steps, batch.HeartRates, batch.Sleep, batch.Workouts)
+		if err != nil {
+			return err
+		}
+		task.RecordsParsed += int64(batch.Len())
+		task.RecordsInserted += inserted
+		task.Duplicates += duplicates
+		task.ProcessedBytes = counter.n
+		return s.repo.UpdateImportProgress(task)
+	})
+	if summary != nil {
+		task.IgnoredRecords = summary.Ignored
+	}
+	return err
+}
+
+// openHealthExport opens export.xml directly or from inside an export.zip
+// It returns the document, its uncompressed size and a close function.
+func openHealthExport(filePath string) (io.Reader, int64, func(), error) {
+	if zr, err := zip.OpenReader(filePath); err == nil {
+		for _, f := range zr.File {
+			if path.Base(f.Name) != "export.xml" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				zr.Close()
+				return nil, 0, nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+			}
+			return rc, int64(f.UncompressedSize64), func() { rc.Close(); zr.Close() }, nil
+		}
+		zr.Close()
+		return nil, 0, nil, fmt.Errorf("archive does not contain export.xml")
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to open export: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, nil, fmt.Errorf("failed to stat export: %w", err)
+	}
+	return f, info.Size(), func() { f.Close() }, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// GetImportTask retrieves an import task by ID, or nil if it does not exist
+func (s *HealthService) GetImportTask(id int64) (*models.HealthImportTask, error) {
+	return s.repo.GetImportTask(id)
+}
+
+// ListImportTasks returns the most recent import tasks
+func (s *HealthService) ListImportTasks(limit int) ([]*models.HealthImportTask, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.ListImportTasks(limit)
+}
+
+// GetStats returns daily steps, heart rate, workouts and sleep with range totals
+func (s *HealthService) GetStats(filter models.HealthFilter) (*models.HealthStats, error) {
+	from, to, err := resolveDayRange(filter.From, filter.To, defaultHealthDays, s.repo.GetLatestDate)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := s.repo.GetDailyStats(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.HealthStats{From: from, To: to, Days: len(daily), Daily: daily}
+	var stepDays, hrDays, sleepDays int
+	var hrSum, sleepSum float64
+	for _, d := range daily {
+		if d.Steps > 0 {
+			stats.TotalSteps += d.Steps
+			stepDays++
+		}
+		if d.AvgHeartRate != nil {
+			hrSum += *d.AvgHeartRate
+			hrDays++
+		}
+		if d.SleepHours != nil {
+			sleepSum += *d.SleepHours
+			sleepDays++
+		}
+		stats.WorkoutCount += d.Workouts
+		stats.WorkoutMinutes += d.WorkoutMinutes
+	}
+	if stepDays > 0 {
+		stats.AvgDailySteps = stats.TotalSteps / int64(stepDays)
+	}
+	if hrDays > 0 {
+		avg := hrSum / float64(hrDays)
+		stats.AvgHeartRate = &avg
+	}
+	if sleepDays > 0 {
+		avg := sleepSum / float64(sleepDays)
+		stats.AvgSleepHours = &avg
+	}
+
+	return stats, nil
+}
+
+// GetDay returns the health data of one day, or nil if nothing was recorded
+func (s *HealthService) GetDay(date string) (*models.HealthDailyStats, error) {
+	daily, err := s.repo.GetDailyStats(date, date)
+	if err != nil || len(daily) == 0 {
+		return nil, err
+	}
+	return &daily[0], nil
+}
+
+// GetWorkouts returns the workouts of a date range, newest first
+func (s *HealthService) GetWorkouts(filter models.HealthFilter) ([]models.Workout, error) {
+	var err error
+	filter.From, filter.To, err = resolveDayRange(filter.From, filter.To, defaultHealthDays, s.repo.GetLatestDate)
+	if err != nil {
+		return nil, err
+	}
+	filter.Type = normalizeWorkoutType(filter.Type)
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.GetWorkouts(filter, limit)
+}
+
+// normalizeWorkoutType strips the HealthKit prefix from a workout type filter
+func normalizeWorkoutType(activityType string) string {
+	return strings.TrimPrefix(activityType, "HKWorkoutActivityType")
+}
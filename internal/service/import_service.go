@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// ImportService handles importing track files into the track point table
+type ImportService struct {
+	trackRepo           *repository.TrackRepository
+	analysisTaskService *AnalysisTaskService
+	notifications       *NotificationService
+}
+
+// NewImportService creates a new import service
+func NewImportService(trackRepo *repository.TrackRepository, analysisTaskService *AnalysisTaskService, notifications *NotificationService) *ImportService {
+	return &ImportService{
+		trackRepo:           trackRepo,
+		analysisTaskService: analysisTaskService,
+		notifications:       notifications,
+	}
+}
+
+// ImportFile parses a single GPX/KML/CSV/JSON file from source, recorded by device, and merges
+// its points into the track point table; source and device default to the file format
+func (s *ImportService) ImportFile(filename string, r io.Reader, source, device string) (*models.ImportResult, error) {
+	format, err := importer.DetectFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := importer.Parse(filename, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if source == "" {
+		source = format
+	}
+	if device == "" {
+		device = format
+	}
+	for i := range points {
+		points[i].Source = source
+		points[i].SourceDevice = device
+	}
+
+	result := &models.ImportResult{
+		FileName:     filename,
+		Format:       format,
+		Source:       source,
+		SourceDevice: device,
+		ParsedPoints: len(points),
+	}
+	if len(points) == 0 {
+		return result, nil
+	}
+	result.StartTime = points[0].DataTime
+	result.EndTime = points[len(points)-1].DataTime
+
+	inserted, replaced, duplicates, err := s.trackRepo.InsertTrackPoints(points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert points: %w", err)
+	}
+	result.InsertedPoints = inserted
+	result.ReplacedPoints = replaced
+	result.DuplicatePoints = duplicates
+
+	log.Printf("Imported %s from %s/%s: %d parsed, %d inserted, %d replaced, %d duplicates",
+		filename, source, device, len(points), inserted, replaced, duplicates)
+	return result, nil
+}
+
+// TriggerIncrementalAnalysis starts the incremental analysis chain after new points arrive
+func (s *ImportService) TriggerIncrementalAnalysis(createdBy string) ([]int64, error) {
+	return s.analysisTaskService.TriggerAnalysisChain(models.TaskTypeIncremental, createdBy)
+}
+
+// NotifyImported sends the notifications of the files of one upload or watch
+// directory poll; via says where they came from
+func (s *ImportService) NotifyImported(via string, files []models.ImportResult) {
+	s.notifications.ImportsDone(via, files)
+}
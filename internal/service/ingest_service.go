@@ -0,0 +1,224 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/mqtt"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// IngestService writes live locations pushed by phone apps (OwnTracks over
+// HTTP or MQTT, Overland, GPSLogger) to the track point table and
+// periodically runs incremental analysis over the points that arrived since
+// the last run
+type IngestService struct {
+	trackRepo           *repository.TrackRepository
+	analysisTaskService *AnalysisTaskService
+	interval            time.Duration
+	subscriber          *mqtt.Subscriber // nil when MQTT is off
+
+	mu     sync.Mutex
+	status models.IngestStatus
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewIngestService creates an ingest service triggering analysis every
+// interval while new points are pending
+func NewIngestService(trackRepo *repository.TrackRepository, analysisTaskService *AnalysisTaskService, interval time.Duration) *IngestService {
+	return &IngestService{
+		trackRepo:           trackRepo,
+		analysisTaskService: analysisTaskService,
+		interval:            interval,
+		status: models.IngestStatus{
+			AnalysisInterval: int64(interval / time.Second),
+			LastAnalysisIDs:  []int64{},
+		},
+	}
+}
+
+// SubscribeMQTT ingests the OwnTracks messages published to the topics of
+// cfg once the service is started
+// Points are recorded with the user/device of the topic as their device.
+func (s *IngestService) SubscribeMQTT(cfg mqtt.Config) error {
+	subscriber, err := mqtt.NewSubscriber(cfg, func(topic string, payload []byte) {
+		if _, err := s.IngestOwnTracks(payload, importer.OwnTracksDevice(topic)); err != nil {
+			log.Printf("MQTT %s: %v", topic, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	s.subscriber = subscriber
+	s.status.MQTTBroker = subscriber.Addr()
+	return nil
+}
+
+// Start starts the MQTT subscriber, if any, and the periodic analysis unless
+// the interval is 0
+func (s *IngestService) Start() {
+	if s.subscriber != nil {
+		s.subscriber.Start()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil || s.interval <= 0 {
+		return
+	}
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	go s.loop()
+}
+
+// Stop stops the MQTT subscriber and the periodic analysis
+func (s *IngestService) Stop() {
+	if s.subscriber != nil {
+		s.subscriber.Stop()
+	}
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop = nil
+	s.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// IngestOwnTracks validates an OwnTracks payload and writes its locations
+// device, when not empty, overrides the device named in the messages.
+func (s *IngestService) IngestOwnTracks(payload []byte, device string) (*models.IngestResult, error) {
+	points, err := importer.ParseOwnTracks(payload, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidIngestPayload, err)
+	}
+	return s.write(points, device, importer.FormatOwnTracks)
+}
+
+// IngestOverland writes the valid locations of an Overland batch
+// device, when not empty, overrides the device_id of the locations.
+func (s *IngestService) IngestOverland(payload []byte, device string) (*models.IngestResult, error) {
+	points, rejected, err := importer.ParseOverland(payload, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidIngestPayload, err)
+	}
+	result, err := s.write(points, device, importer.FormatOverland)
+	if err != nil {
+		return nil, err
+	}
+	result.Rejected = rejected
+	return result, nil
+}
+
+// IngestGPSLogger validates the parameters of a GPSLogger request and writes
+// its location
+// device, when not empty, overrides the device parameters of the request.
+func (s *IngestService) IngestGPSLogger(values url.Values, device string) (*models.IngestResult, error) {
+	point, err := importer.ParseGPSLogger(values, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidIngestPayload, err)
+	}
+	return s.write([]models.TrackPoint{point}, device, importer.FormatGPSLogger)
+}
+
+// write stores live points, setting their device to device when not empty
+// and to fallback when they name none, and counts them as pending analysis
+func (s *IngestService) write(points []models.TrackPoint, device, fallback string) (*models.IngestResult, error) {
+	result := &models.IngestResult{Received: len(points)}
+	if len(points) == 0 {
+		return result, nil
+	}
+	for i := range points {
+		if device != "" {
+			points[i].SourceDevice = device
+		}
+		if points[i].SourceDevice == "" {
+			points[i].SourceDevice = fallback
+		}
+	}
+
+	var err error
+	result.Inserted, result.Replaced, result.Duplicates, err = s.trackRepo.InsertTrackPoints(points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert points: %w", err)
+	}
+
+	last := points[len(points)-1]
+	s.mu.Lock()
+	s.status.PendingPoints += result.Inserted + result.Replaced
+	if last.DataTime >= s.status.LastPointTime {
+		s.status.LastPointTime = last.DataTime
+		s.status.LastReceivedAt = time.Now().Unix()
+		s.status.LastDevice = last.SourceDevice
+	}
+	s.mu.Unlock()
+	return result, nil
+}
+
+// GetStatus describes live ingestion
+func (s *IngestService) GetStatus() models.IngestStatus {
+	s.mu.Lock()
+	status := s.status
+	s.mu.Unlock()
+	if s.subscriber != nil {
+		var err error
+		status.MQTTConnected, err = s.subscriber.Status()
+		if err != nil {
+			status.MQTTError = err.Error()
+		}
+	}
+	return status
+}
+
+// loop triggers analysis every interval until stopped
+func (s *IngestService) loop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.analyzePending()
+		}
+	}
+}
+
+// analyzePending triggers the incremental analysis chain when points are
+// pending and the chain of an earlier trigger is no longer running
+func (s *IngestService) analyzePending() {
+	s.mu.Lock()
+	pending := s.status.PendingPoints
+	s.mu.Unlock()
+	if pending == 0 {
+		return
+	}
+
+	active, err := s.analysisTaskService.CountActiveTasks()
+	if err != nil {
+		log.Printf("Ingest: %v", err)
+		return
+	}
+	if active[models.TaskStatusPending]+active[models.TaskStatusRunning] > 0 {
+		return // Try again at the next tick
+	}
+
+	taskIDs, err := s.analysisTaskService.TriggerAnalysisChain(models.TaskTypeIncremental, "ingest")
+	if err != nil {
+		log.Printf("Ingest: failed to trigger analysis: %v", err)
+		return
+	}
+	log.Printf("Ingest: triggered incremental analysis of %d new points (%d tasks)", pending, len(taskIDs))
+
+	s.mu.Lock()
+	s.status.PendingPoints -= pending
+	s.status.LastAnalysisAt = time.Now().Unix()
+	s.status.LastAnalysisIDs = taskIDs
+	s.mu.Unlock()
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/stats"
+)
+
+const (
+	// MaxInputActivityDays caps the date range of a keyboard/mouse query
+	MaxInputActivityDays = 732
+	// defaultInputActivityDays is the range of stats queries without dates
+	defaultInputActivityDays = 30
+	// defaultInputHeatmapDays is the range of heatmap and work queries without dates
+	defaultInputHeatmapDays = 90
+	// workHourMinSeconds is the WORK stay coverage that makes an activity hour count as at work
+	workHourMinSeconds = 1800
+	// minCorrelationDays is the number of days needed to report a correlation
+	minCorrelationDays = 3
+)
+
+// inputMetrics are the activity columns a heatmap can show
+var inputMetrics = map[string]bool{
+	"keystrokes": true,
+	"clicks":     true,
+	"scrolls":    true,
+}
+
+// IsValidInputMetric reports whether metric names a keyboard/mouse activity column
+func IsValidInputMetric(metric string) bool {
+	return inputMetrics[metric]
+}
+
+// InputActivityService handles keyboard and mouse activity import and statistics
+type InputActivityService struct {
+	repo *repository.InputActivityRepository
+}
+
+// NewInputActivityService creates a new input activity service
+func NewInputActivityService(repo *repository.InputActivityRepository) *InputActivityService {
+	return &InputActivityService{repo: repo}
+}
+
+// ImportFile parses a keyboard/mouse export and stores its hourly activity
+func (s *InputActivityService) ImportFile(filename string, r io.Reader, device string) (*models.InputActivityImportResult, error) {
+	file, err := importer.ParseInputActivity(r, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	result := &models.InputActivityImportResult{
+		FileName:   filename,
+		Format:     file.Format,
+		ParsedRows: file.ParsedRows,
+		Hours:      len(file.Hours),
+		Warnings:   file.Warnings,
+	}
+	if len(file.Hours) > 0 {
+		result.StartDate = file.Hours[0].Date
+		result.EndDate = file.Hours[len(file.Hours)-1].Date
+	}
+
+	if result.Stored, err = s.repo.UpsertHours(file.Hours); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Imported input activity from %s (%s): %d rows, %d hours stored", filename, file.Format, result.ParsedRows, result.Stored)
+	return result, nil
+}
+
+// GetStats returns totals, daily activity and the peak typing hour of a date range
+func (s *InputActivityService) GetStats(filter models.InputActivityFilter) (*models.InputActivityStats, error) {
+	filter, err := s.resolveRange(filter, defaultInputActivityDays)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := s.repo.GetDailyActivity(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.InputActivityStats{From: filter.From, To: filter.To, ActiveDays: len(daily), Daily: daily}
+	for i, d := range daily {
+		result.ActiveHours += d.ActiveHours
+		result.TotalKeystrokes += d.Keystrokes
+		result.TotalClicks += d.Clicks
+		result.TotalScrolls += d.Scrolls
+		if result.BusiestDay == nil || d.Keystrokes > result.BusiestDay.Keystrokes {
+			result.BusiestDay = &daily[i]
+		}
+	}
+	if result.ActiveDays > 0 {
+		result.DailyAverageKeystrokes = result.TotalKeystrokes / int64(result.ActiveDays)
+		result.DailyAverageClicks = result.TotalClicks / int64(result.ActiveDays)
+	}
+
+	byHour, err := s.repo.GetHourOfDayKeystrokes(filter)
+	if err != nil {
+		return nil, err
+	}
+	for hour, total := range byHour {
+		if total > 0 && (result.PeakHour == nil || total > byHour[*result.PeakHour]) {
+			h := hour
+			result.PeakHour = &h
+		}
+	}
+
+	return result, nil
+}
+
+// GetHeatmap returns the weekday × hour grid of a metric
+// Cell averages divide by the calendar days of each weekday in the range, so
+// days without recorded activity count as zero.
+func (s *InputActivityService) GetHeatmap(filter models.InputActivityFilter) (*models.InputHeatmap, error) {
+	if filter.Metric == "" {
+		filter.Metric = "keystrokes"
+	}
+	if !IsValidInputMetric(filter.Metric) {
+		return nil, fmt.Errorf("invalid metric: %s", filter.Metric)
+	}
+
+	filter, err := s.resolveRange(filter, defaultInputHeatmapDays)
+	if err != nil {
+		return nil, err
+	}
+
+	grid, err := s.repo.GetWeekdayHourTotals(filter, filter.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	weekdayCounts, err := countWeekdays(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := &models.InputHeatmap{From: filter.From, To: filter.To, Metric: filter.Metric}
+	heatmap.Cells = make([]models.InputHeatmapCell, 0, 7*24)
+	for weekday := 0; weekday < 7; weekday++ {
+		for hour := 0; hour < 24; hour++ {
+			cell := models.InputHeatmapCell{Weekday: weekday, Hour: hour, Total: grid[weekday][hour]}
+			if weekdayCounts[weekday] > 0 {
+				cell.Average = float64(cell.Total) / float64(weekdayCounts[weekday])
+			}
+			if cell.Average > heatmap.Max {
+				heatmap.Max = cell.Average
+			}
+			heatmap.Cells = append(heatmap.Cells, cell)
+		}
+	}
+
+	return heatmap, nil
+}
+
+// GetWorkCorrelation compares activity during WORK stays with activity elsewhere
+// An activity hour counts as at work when WORK stays cover at least half of
+// it. The correlation pairs the WORK stay hours and keystrokes of each day with
+// recorded activity.
+func (s *InputActivityService) GetWorkCorrelation(filter models.InputActivityFilter) (*models.InputWorkCorrelation, error) {
+	filter, err := s.resolveRange(filter, defaultInputHeatmapDays)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.InputWorkCorrelation{From: filter.From, To: filter.To, Days: []models.WorkInputDay{}}
+
+	hours, workSeconds, err := s.repo.GetHoursWithWorkOverlap(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i, h := range hours {
+		side := &result.Elsewhere
+		if workSeconds[i] >= workHourMinSeconds {
+			side = &result.AtWork
+		}
+		side.ActiveHours++
+		side.Keystrokes += h.Keystrokes
+		side.Clicks += h.Clicks
+	}
+	for _, side := range []*models.InputActivityShare{&result.AtWork, &result.Elsewhere} {
+		if side.ActiveHours > 0 {
+			side.KeystrokesPerHour = float64(side.Keystrokes) / float64(side.ActiveHours)
+		}
+	}
+	if total := result.AtWork.Keystrokes + result.Elsewhere.Keystrokes; total > 0 {
+		result.KeystrokeShare = float64(result.AtWork.Keystrokes) / float64(total)
+	}
+
+	// Clip WORK stays to each local day of the range
+	from, _ := time.ParseInLocation("2006-01-02", filter.From, time.Local)
+	to, _ := time.ParseInLocation("2006-01-02", filter.To, time.Local)
+	end := to.AddDate(0, 0, 1)
+	intervals, err := s.repo.GetWorkStayIntervals(from.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	result.WorkStayCount = len(intervals)
+
+	workByDay := make(map[string]float64)
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		dayStart, dayEnd := day.Unix(), day.AddDate(0, 0, 1).Unix()
+		for _, iv := range intervals {
+			overlap := min(iv[1], dayEnd) - max(iv[0], dayStart)
+			if overlap > 0 {
+				workByDay[day.Format("2006-01-02")] += float64(overlap) / 3600
+			}
+		}
+	}
+	for _, hours := range workByDay {
+		result.WorkHours += hours
+	}
+
+	daily, err := s.repo.GetDailyActivity(filter)
+	if err != nil {
+		return nil, err
+	}
+	x := make([]float64, 0, len(daily))
+	y := make([]float64, 0, len(daily))
+	for _, d := range daily {
+		result.Days = append(result.Days, models.WorkInputDay{
+			Date:       d.Date,
+			WorkHours:  workByDay[d.Date],
+			Keystrokes: d.Keystrokes,
+			Clicks:     d.Clicks,
+		})
+		x = append(x, workByDay[d.Date])
+		y = append(y, float64(d.Keystrokes))
+	}
+	if len(x) >= minCorrelationDays && varies(x) && varies(y) {
+		r := stats.PearsonCorrelation(x, y)
+		result.Correlation = &r
+	}
+
+	return result, nil
+}
+
+// resolveRange fills missing bounds: the range ends at the last imported day
+// (or today) and spans defaultDays
+func (s *InputActivityService) resolveRange(filter models.InputActivityFilter, defaultDays int) (models.InputActivityFilter, error) {
+	var err error
+	filter.From, filter.To, err = resolveDayRange(filter.From, filter.To, defaultDays, s.repo.GetLatestDate)
+	return filter, err
+}
+
+// countWeekdays returns how often each weekday (0=Sunday) occurs in [from, to]
+func countWeekdays(from, to string) ([7]int, error) {
+	var counts [7]int
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return counts, err
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return counts, err
+	}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		counts[day.Weekday()]++
+	}
+	return counts, nil
+}
+
+// varies reports whether values are not all equal (a correlation is undefined otherwise)
+func varies(values []float64) bool {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return true
+		}
+	}
+	return false
+}
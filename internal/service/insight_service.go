@@ -0,0 +1,29 @@
+package service
+
+import (
+	"github.com/jengzang/records-backend-go/internal/cache"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// insightsSkill tags cached insights with the analyzer that generates them
+const insightsSkill = "insights"
+
+// InsightService handles business logic for generated insights
+type InsightService struct {
+	repo  *repository.InsightRepository
+	cache *cache.Cache
+}
+
+// NewInsightService creates a new insight service
+// A nil cache disables result caching.
+func NewInsightService(repo *repository.InsightRepository, resultCache *cache.Cache) *InsightService {
+	return &InsightService{repo: repo, cache: resultCache}
+}
+
+// GetInsights retrieves a page of insights matching the filter
+func (s *InsightService) GetInsights(filter models.InsightFilter, opts models.QueryOptions) ([]models.Insight, int64, error) {
+	return loadPage(s.cache, cache.Key("insights", filter, opts), []string{insightsSkill}, func() ([]models.Insight, int64, error) {
+		return s.repo.GetInsights(filter, opts)
+	})
+}
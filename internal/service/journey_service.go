@@ -0,0 +1,105 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// journeyLinkSlack widens the scheduled journey window when matching
+// segments, absorbing delays and the walk to / from the gate or platform
+const journeyLinkSlack = 2 * 3600
+
+// JourneyService handles business logic for flight and train journeys
+type JourneyService struct {
+	repo *repository.JourneyRepository
+}
+
+// NewJourneyService creates a new journey service
+func NewJourneyService(repo *repository.JourneyRepository) *JourneyService {
+	return &JourneyService{repo: repo}
+}
+
+// GetJourneys retrieves journeys with filtering and pagination
+func (s *JourneyService) GetJourneys(filter models.JourneyFilter) ([]models.Journey, int64, error) {
+	journeys, total, err := s.repo.GetJourneys(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range journeys {
+		setFlightPath(&journeys[i])
+	}
+	return journeys, total, nil
+}
+
+// GetJourneyByID retrieves a single journey by ID
+func (s *JourneyService) GetJourneyByID(id int64) (*models.Journey, error) {
+	journey, err := s.repo.GetJourneyByID(id)
+	if err != nil || journey == nil {
+		return journey, err
+	}
+	setFlightPath(journey)
+	return journey, nil
+}
+
+// setFlightPath sets the great-circle path of a located flight
+func setFlightPath(j *models.Journey) {
+	if j.JourneyType != models.JourneyTypeFlight || !j.HasCoordinates() {
+		return
+	}
+	j.Path = spatial.GreatCirclePath(j.OriginLat, j.OriginLon, j.DestLat, j.DestLon, flightPathStepM)
+}
+
+// GetJourneyStats aggregates the journeys of one type
+func (s *JourneyService) GetJourneyStats(journeyType string, limit int) (*models.JourneyStats, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.GetJourneyStats(journeyType, limit)
+}
+
+// ImportFile parses a journey CSV, stores new journeys and links them to segments
+func (s *JourneyService) ImportFile(filename string, r io.Reader, defaultType string) (*models.JourneyImportResult, error) {
+	file, err := importer.ParseJourneyCSV(r, defaultType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	result := &models.JourneyImportResult{
+		FileName: filename,
+		Format:   file.Format,
+		Parsed:   len(file.Journeys),
+		Warnings: file.Warnings,
+	}
+	for i := range file.Journeys {
+		if !file.Journeys[i].HasCoordinates() {
+			result.Unlocated++
+		}
+	}
+
+	result.Inserted, result.Duplicates, err = s.repo.InsertJourneys(file.Journeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Inserted > 0 {
+		if result.Linked, err = s.repo.LinkSegments(journeyLinkSlack); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("Imported journeys from %s (%s): %d parsed, %d inserted, %d duplicates, %d linked",
+		filename, file.Format, result.Parsed, result.Inserted, result.Duplicates, result.Linked)
+	return result, nil
+}
+
+// LinkSegments links unlinked journeys to detected PLANE / TRAIN segments
+// Run it again after transport mode detection has processed new points.
+func (s *JourneyService) LinkSegments() (int64, error) {
+	return s.repo.LinkSegments(journeyLinkSlack)
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/notify"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// NotificationService sends events to the configured webhooks and push
+// services: analysis tasks finishing, personal records and extreme events
+// being beaten, and track files being imported
+type NotificationService struct {
+	repo       *repository.NotificationRepository
+	statsRepo  *repository.StatsRepository
+	reportRepo *repository.ReportRepository
+	notifier   *notify.Notifier
+
+	mu       sync.Mutex
+	records  map[string]float64 // Best value by personal record type, as last seen
+	extremes map[string]float64 // Most extreme value by extreme event type
+}
+
+// NewNotificationService creates a notification service sending to the
+// channels of cfg; without channels every notification is a no-op
+func NewNotificationService(repo *repository.NotificationRepository, statsRepo *repository.StatsRepository, reportRepo *repository.ReportRepository, cfg notify.Config) *NotificationService {
+	s := &NotificationService{repo: repo, statsRepo: statsRepo, reportRepo: reportRepo}
+	s.notifier = notify.New(cfg, s.recordDelivery)
+	return s
+}
+
+// Start loads the current records, which later ones are compared against,
+// and starts delivering
+func (s *NotificationService) Start() {
+	if !s.notifier.Enabled() {
+		return
+	}
+	s.loadRecords()
+	s.notifier.Start()
+	log.Printf("Notifications enabled: %s", strings.Join(s.notifier.Channels(), ", "))
+}
+
+// Stop stops delivering; events still queued are dropped
+func (s *NotificationService) Stop() {
+	s.notifier.Stop()
+}
+
+// GetStatus describes the configured channels
+func (s *NotificationService) GetStatus() models.NotificationStatus {
+	return models.NotificationStatus{
+		Enabled:  s.notifier.Enabled(),
+		Channels: s.notifier.Channels(),
+		Events:   s.notifier.Events(),
+	}
+}
+
+// GetDeliveries retrieves a page of deliveries, latest first
+func (s *NotificationService) GetDeliveries(filter models.NotificationFilter, opts models.QueryOptions) ([]models.NotificationDelivery, int64, error) {
+	return s.repo.GetDeliveries(filter, opts)
+}
+
+// SendTest queues a test event to every channel and returns its id
+func (s *NotificationService) SendTest() (string, error) {
+	if !s.notifier.Enabled() {
+		return "", models.ErrNotificationsDisabled
+	}
+	event := s.notifier.Notify(notify.EventTest, "Records test notification",
+		"Notifications from the records backend are working.", nil)
+	if event == nil {
+		return "", fmt.Errorf("notification queue is full")
+	}
+	return event.ID, nil
+}
+
+// TaskDone notifies that an analysis task finished and, after the
+// personal_records and extreme_events skills, about any record beaten
+func (s *NotificationService) TaskDone(task *models.AnalysisTask) {
+	if !s.notifier.Enabled() {
+		return
+	}
+	data := map[string]interface{}{
+		"task_id":    task.ID,
+		"skill_name": task.SkillName,
+		"task_type":  task.TaskType,
+		"status":     task.Status,
+	}
+	if task.StartTime != nil && task.EndTime != nil {
+		data["duration_s"] = *task.EndTime - *task.StartTime
+	}
+
+	switch task.Status {
+	case models.TaskStatusCompleted:
+		if task.ResultSummary != nil {
+			data["result_summary"] = *task.ResultSummary
+		}
+		s.notifier.Notify(notify.EventAnalysisCompleted,
+			fmt.Sprintf("Analysis %s completed", task.SkillName),
+			fmt.Sprintf("Task %d (%s) over %d points completed.", task.ID, task.TaskType, task.TotalPoints), data)
+		switch task.SkillName {
+		case "personal_records", "extreme_events":
+			s.checkRecords()
+		}
+	case models.TaskStatusFailed:
+		message := "unknown error"
+		if task.ErrorMessage != nil {
+			message = *task.ErrorMessage
+		}
+		data["error"] = message
+		s.notifier.Notify(notify.EventAnalysisFailed,
+			fmt.Sprintf("Analysis %s failed", task.SkillName),
+			fmt.Sprintf("Task %d (%s) failed: %s", task.ID, task.TaskType, message), data)
+	}
+}
+
+// ImportsDone notifies about the files of one upload or watch directory
+// poll; via says where they came from
+// Files with an Error failed to import.
+func (s *NotificationService) ImportsDone(via string, files []models.ImportResult) {
+	if !s.notifier.Enabled() || len(files) == 0 {
+		return
+	}
+	var imported, failed []models.ImportResult
+	inserted, replaced := 0, 0
+	for _, f := range files {
+		if f.Error != "" {
+			failed = append(failed, f)
+			continue
+		}
+		imported = append(imported, f)
+		inserted += f.InsertedPoints
+		replaced += f.ReplacedPoints
+	}
+
+	if len(imported) > 0 {
+		s.notifier.Notify(notify.EventImportCompleted,
+			fmt.Sprintf("Imported %d track files", len(imported)),
+			fmt.Sprintf("%d new points, %d replaced (%s).", inserted, replaced, via),
+			map[string]interface{}{"via": via, "files": imported, "inserted_points": inserted, "replaced_points": replaced})
+	}
+	if len(failed) > 0 {
+		names := make([]string, len(failed))
+		for i, f := range failed {
+			names[i] = f.FileName
+		}
+		s.notifier.Notify(notify.EventImportFailed,
+			fmt.Sprintf("%d track files failed to import", len(failed)),
+			fmt.Sprintf("%s (%s): %s", strings.Join(names, ", "), via, failed[0].Error),
+			map[string]interface{}{"via": via, "files": failed})
+	}
+}
+
+// checkRecords notifies about the personal records and extreme events that
+// beat the values last seen
+// Nothing is sent for a kind of record that had no values before, so the
+// first analysis does not announce every record at once.
+func (s *NotificationService) checkRecords() {
+	if !s.notifier.Wants(notify.EventRecordNew) {
+		return
+	}
+
+	if records, err := s.statsRepo.GetPersonalRecords(); err != nil {
+		log.Printf("Notify: %v", err)
+	} else {
+		s.mu.Lock()
+		prev := s.records
+		s.records = recordValues(records)
+		s.mu.Unlock()
+		for _, rec := range records {
+			old, ok := prev[rec.RecordType]
+			if len(prev) == 0 || ok && rec.Value <= old {
+				continue
+			}
+			message := fmt.Sprintf("%s: %s", rec.RecordType, formatRecordValue(rec.Value, rec.Unit))
+			if ok {
+				message += fmt.Sprintf(", up from %s", formatRecordValue(old, rec.Unit))
+			}
+			if rec.Date != "" {
+				message += " on " + rec.Date
+			}
+			s.notifier.Notify(notify.EventRecordNew, "New personal record", message,
+				map[string]interface{}{"kind": "personal_record", "record": rec, "previous_value": prevValue(old, ok)})
+		}
+	}
+
+	if events, err := s.reportRepo.GetExtremeEventsBetween(0, math.MaxInt64); err != nil {
+		log.Printf("Notify: %v", err)
+	} else {
+		s.mu.Lock()
+		prev := s.extremes
+		s.extremes = extremeValues(events)
+		s.mu.Unlock()
+		for _, e := range events {
+			old, ok := prev[e.EventType]
+			if len(prev) == 0 || ok && !beatsExtreme(e.EventType, e.EventValue, old) {
+				continue
+			}
+			message := fmt.Sprintf("%s: %.6g", e.EventType, e.EventValue)
+			if place := strings.TrimSpace(e.Province + " " + e.City); place != "" {
+				message += " in " + place
+			}
+			message += " on " + time.Unix(e.EventTime, 0).Format("2006-01-02")
+			s.notifier.Notify(notify.EventRecordNew, "New extreme event", message,
+				map[string]interface{}{"kind": "extreme_event", "event": e, "previous_value": prevValue(old, ok)})
+		}
+	}
+}
+
+// loadRecords loads the current personal records and extreme events as the
+// values later ones are compared against
+func (s *NotificationService) loadRecords() {
+	records, err := s.statsRepo.GetPersonalRecords()
+	if err != nil {
+		log.Printf("Notify: %v", err)
+	}
+	events, err := s.reportRepo.GetExtremeEventsBetween(0, math.MaxInt64)
+	if err != nil {
+		log.Printf("Notify: %v", err)
+	}
+	s.mu.Lock()
+	s.records, s.extremes = recordValues(records), extremeValues(events)
+	s.mu.Unlock()
+}
+
+// recordValues maps personal record types to their values
+func recordValues(records []models.PersonalRecord) map[string]float64 {
+	values := make(map[string]float64, len(records))
+	for _, rec := range records {
+		values[rec.RecordType] = rec.Value
+	}
+	return values
+}
+
+// extremeValues maps extreme event types to their most extreme values
+func extremeValues(events []models.ExtremeEvent) map[string]float64 {
+	values := make(map[string]float64, len(events))
+	for _, e := range events {
+		values[e.EventType] = e.EventValue
+	}
+	return values
+}
+
+// recordDelivery stores the outcome of a delivery
+func (s *NotificationService) recordDelivery(d notify.Delivery) {
+	delivery := &models.NotificationDelivery{
+		EventID:     d.Event.ID,
+		EventType:   d.Event.Type,
+		Title:       d.Event.Title,
+		Channel:     d.Channel,
+		Status:      models.NotificationDelivered,
+		Attempts:    d.Attempts,
+		EventTime:   d.Event.Time,
+		DeliveredAt: time.Now().Unix(),
+	}
+	if d.Err != nil {
+		delivery.Status = models.NotificationFailed
+		delivery.Error = d.Err.Error()
+	}
+	if err := s.repo.CreateDelivery(delivery); err != nil {
+		log.Printf("Notify: %v", err)
+	}
+}
+
+// beatsExtreme reports whether value is more extreme than prev for the event
+// type; the southmost and westmost points have the lowest values
+func beatsExtreme(eventType string, value, prev float64) bool {
+	if eventType == "SOUTHMOST" || eventType == "WESTMOST" {
+		return value < prev
+	}
+	return value > prev
+}
+
+// formatRecordValue formats a record value with its unit for a message
+func formatRecordValue(value float64, unit string) string {
+	switch unit {
+	case "m":
+		if value >= 1000 {
+			return fmt.Sprintf("%.1f km", value/1000)
+		}
+		return fmt.Sprintf("%.0f m", value)
+	case "s":
+		return (time.Duration(value) * time.Second).String()
+	case "km/h":
+		return fmt.Sprintf("%.1f km/h", value)
+	}
+	return fmt.Sprintf("%g %s", value, unit)
+}
+
+// prevValue returns the previous value of a record, or nil for a new record type
+func prevValue(prev float64, ok bool) interface{} {
+	if !ok {
+		return nil
+	}
+	return prev
+}
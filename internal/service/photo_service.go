@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+const (
+	// photoImportBatchSize is the number of photos stored per transaction
+	photoImportBatchSize = 200
+	// photoTrackMaxGapS is how far in time a track point may be from a photo
+	// without a geotag to place it
+	photoTrackMaxGapS = 600
+	// maxPhotoWarnings caps the warnings returned by an import
+	maxPhotoWarnings = 100
+)
+
+// PhotoService imports photo geotags and finds the photos of trips and stays
+// Photos are linked to trips and stays by capture time, so the links follow
+// recomputed trips and stays without being rewritten.
+type PhotoService struct {
+	repo     *repository.PhotoRepository
+	tripRepo *repository.TripRepository
+	stayRepo *repository.StayRepository
+	immich   *importer.ImmichClient // nil when no Immich server is configured
+
+	mu       sync.RWMutex
+	photoDir string
+}
+
+// NewPhotoService creates a new photo service
+// photoDir is the directory scanned for photos, empty to disable scans.
+func NewPhotoService(repo *repository.PhotoRepository, tripRepo *repository.TripRepository,
+	stayRepo *repository.StayRepository, photoDir string, immich *importer.ImmichClient) *PhotoService {
+	return &PhotoService{repo: repo, tripRepo: tripRepo, stayRepo: stayRepo, photoDir: photoDir, immich: immich}
+}
+
+// SetPhotoDir changes the directory scanned for photos
+// Photos imported from the previous directory are served from the new one
+// by their relative path.
+func (s *PhotoService) SetPhotoDir(photoDir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.photoDir = photoDir
+}
+
+// getPhotoDir returns the directory scanned for photos
+func (s *PhotoService) getPhotoDir() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.photoDir
+}
+
+// PhotoContent is the opened image of a photo; the caller closes Body
+type PhotoContent struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64 // -1 when unknown
+}
+
+// ListPhotos retrieves a page of photos matching the filter
+func (s *PhotoService) ListPhotos(filter models.PhotoFilter, opts models.QueryOptions) ([]models.Photo, int64, error) {
+	photos, total, err := s.repo.List(filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	setPhotoURLs(photos)
+	return photos, total, nil
+}
+
+// GetPhoto retrieves a photo by ID; nil if it does not exist
+func (s *PhotoService) GetPhoto(id int64) (*models.Photo, error) {
+	photo, err := s.repo.GetByID(id)
+	if err != nil || photo == nil {
+		return photo, err
+	}
+	photo.URL = photoURL(photo.ID)
+	return photo, nil
+}
+
+// GetTripPhotos retrieves the photos taken during a trip; nil if the trip does not exist
+func (s *PhotoService) GetTripPhotos(id int64) (*models.PhotoSet, error) {
+	trip, err := s.tripRepo.GetTripByID(id)
+	if err != nil || trip == nil {
+		return nil, err
+	}
+	return s.photosBetween(trip.StartTime, trip.EndTime)
+}
+
+// GetStayPhotos retrieves the photos taken during a stay; nil if the stay does not exist
+func (s *PhotoService) GetStayPhotos(id int64) (*models.PhotoSet, error) {
+	stay, err := s.stayRepo.GetStayByID(id)
+	if err != nil || stay == nil {
+		return nil, err
+	}
+	return s.photosBetween(stay.StartTime, stay.EndTime)
+}
+
+func (s *PhotoService) photosBetween(start, end int64) (*models.PhotoSet, error) {
+	photos, err := s.repo.ListTakenBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	setPhotoURLs(photos)
+	return &models.PhotoSet{StartTime: start, EndTime: end, Photos: photos}, nil
+}
+
+// OpenPhoto opens the image of a photo: the file under PHOTO_DIR, or the
+// Immich preview; nil if the photo does not exist
+func (s *PhotoService) OpenPhoto(ctx context.Context, id int64) (*PhotoContent, error) {
+	photo, err := s.repo.GetByID(id)
+	if err != nil || photo == nil {
+		return nil, err
+	}
+
+	switch photo.Source {
+	case models.PhotoSourceImmich:
+		if s.immich == nil {
+			return nil, fmt.Errorf("%w: IMMICH_URL", models.ErrPhotoSourceNotConfigured)
+		}
+		body, contentType, size, err := s.immich.Preview(ctx, photo.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		return &PhotoContent{Body: body, ContentType: contentType, Size: size}, nil
+	default:
+		path, err := resolvePhotoPath(s.getPhotoDir(), photo.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open photo: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to stat photo: %w", err)
+		}
+		contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return &PhotoContent{Body: f, ContentType: contentType, Size: info.Size()}, nil
+	}
+}
+
+// ScanDirectory imports the geotags of the photos under PHOTO_DIR, or under
+// one of its subdirectories
+// Files imported before are skipped unless their size or modification time
+// changed. A Google Takeout sidecar supplies the time or location a file lacks.
+func (s *PhotoService) ScanDirectory(req models.PhotoScanRequest) (*models.PhotoImportResult, error) {
+	photoDir := s.getPhotoDir()
+	if photoDir == "" {
+		return nil, fmt.Errorf("%w: PHOTO_DIR", models.ErrPhotoSourceNotConfigured)
+	}
+	root, err := resolvePhotoPath(photoDir, req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%w: %q is not a directory", models.ErrInvalidPhotoPath, req.Path)
+	}
+	versions, err := s.repo.GetFileVersions(models.PhotoSourceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PhotoImportResult{Source: models.PhotoSourceDir}
+	var batch []models.Photo
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			addPhotoWarning(result, "%s: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !importer.IsPhotoFile(d.Name()) {
+			return nil
+		}
+		result.Scanned++
+
+		rel, err := filepath.Rel(photoDir, path)
+		if err != nil {
+			return err
+		}
+		id := filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			result.Failed++
+			addPhotoWarning(result, "%s: %v", id, err)
+			return nil
+		}
+		if v, ok := versions[id]; ok && v == [2]int64{info.Size(), info.ModTime().Unix()} {
+			result.Unchanged++
+			return nil
+		}
+
+		photo, err := readPhotoFile(path)
+		if err != nil {
+			result.Failed++
+			addPhotoWarning(result, "%s: %v", id, err)
+			return nil
+		}
+		if photo.TakenAt == 0 {
+			result.NoTime++
+			addPhotoWarning(result, "%s: no capture time", id)
+			return nil
+		}
+		photo.Source, photo.ExternalID, photo.FileName = models.PhotoSourceDir, id, d.Name()
+		photo.FileSize, photo.FileMtime = info.Size(), info.ModTime().Unix()
+		batch = append(batch, *photo)
+
+		if len(batch) == photoImportBatchSize {
+			if err := s.store(batch, result); err != nil {
+				return err
+			}
+			batch = nil
+		}
+		return nil
+	})
+	if err == nil {
+		err = s.store(batch, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.locateOnTrack(result); err != nil {
+		return nil, err
+	}
+	log.Printf("Photo scan of %s: %d scanned, %d imported, %d unchanged, %d located on the track",
+		root, result.Scanned, result.Imported, result.Unchanged, result.Located)
+	return result, nil
+}
+
+// ImportImmich imports the metadata of the photos of the Immich library taken
+// after req.Since
+func (s *PhotoService) ImportImmich(ctx context.Context, req models.PhotoImmichRequest) (*models.PhotoImportResult, error) {
+	if s.immich == nil {
+		return nil, fmt.Errorf("%w: IMMICH_URL and IMMICH_API_KEY", models.ErrPhotoSourceNotConfigured)
+	}
+
+	result := &models.PhotoImportResult{Source: models.PhotoSourceImmich}
+	err := s.immich.Assets(ctx, req.Since, func(assets []importer.ImmichAsset) error {
+		batch := make([]models.Photo, 0, len(assets))
+		for _, a := range assets {
+			result.Scanned++
+			if a.Meta.TakenAt == 0 {
+				result.NoTime++
+				addPhotoWarning(result, "%s: no capture time", a.FileName)
+				continue
+			}
+			photo := photoFromMeta(&a.Meta, models.PhotoLocationImmich)
+			photo.Source, photo.ExternalID, photo.FileName = models.PhotoSourceImmich, a.ID, a.FileName
+			photo.FileSize = a.FileSize
+			batch = append(batch, *photo)
+		}
+		return s.store(batch, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.locateOnTrack(result); err != nil {
+		return nil, err
+	}
+	log.Printf("Immich photo import: %d scanned, %d imported, %d located on the track",
+		result.Scanned, result.Imported, result.Located)
+	return result, nil
+}
+
+// store saves a batch of imported photos and counts them
+func (s *PhotoService) store(batch []models.Photo, result *models.PhotoImportResult) error {
+	if err := s.repo.Upsert(batch); err != nil {
+		return err
+	}
+	result.Imported += len(batch)
+	for _, p := range batch {
+		if p.LocationSource != "" {
+			result.Geotagged++
+		}
+	}
+	return nil
+}
+
+// locateOnTrack places the photos without a location where the track was
+// when they were taken, interpolating between the surrounding points
+// Photos imported before the track covered their time are retried.
+func (s *PhotoService) locateOnTrack(result *models.PhotoImportResult) error {
+	photos, err := s.repo.ListUnlocated()
+	if err != nil {
+		return err
+	}
+	for _, p := range photos {
+		before, after, err := s.repo.GetTrackFixesAround(p.TakenAt, photoTrackMaxGapS)
+		if err != nil {
+			return err
+		}
+		var lat, lon, altitude float64
+		switch {
+		case before != nil && after != nil:
+			f := 0.0
+			if after.Time > before.Time {
+				f = float64(p.TakenAt-before.Time) / float64(after.Time-before.Time)
+			}
+			lat = before.Latitude + f*(after.Latitude-before.Latitude)
+			lon = before.Longitude + f*(after.Longitude-before.Longitude)
+			altitude = before.Altitude + f*(after.Altitude-before.Altitude)
+		case before != nil:
+			lat, lon, altitude = before.Latitude, before.Longitude, before.Altitude
+		case after != nil:
+			lat, lon, altitude = after.Latitude, after.Longitude, after.Altitude
+		default:
+			continue
+		}
+		if err := s.repo.SetTrackLocation(p.ID, lat, lon, altitude); err != nil {
+			return err
+		}
+		result.Located++
+	}
+	return nil
+}
+
+// resolvePhotoPath resolves a path relative to photoDir (PHOTO_DIR),
+// rejecting paths that leave it
+func resolvePhotoPath(photoDir, rel string) (string, error) {
+	if photoDir == "" {
+		return "", fmt.Errorf("%w: PHOTO_DIR", models.ErrPhotoSourceNotConfigured)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: %q", models.ErrInvalidPhotoPath, rel)
+		}
+	}
+	return filepath.Join(photoDir, filepath.FromSlash(rel)), nil
+}
+
+// addPhotoWarning records a warning of an import, up to maxPhotoWarnings
+func addPhotoWarning(result *models.PhotoImportResult, format string, args ...interface{}) {
+	if len(result.Warnings) < maxPhotoWarnings {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// readPhotoFile reads the EXIF of a photo file, completed by its Google
+// Takeout sidecar when there is one
+func readPhotoFile(path string) (*models.Photo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := importer.ParseEXIF(f)
+	f.Close()
+	if errors.Is(err, importer.ErrNoEXIF) {
+		meta, err = &importer.PhotoMeta{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	photo := photoFromMeta(meta, models.PhotoLocationEXIF)
+
+	if photo.TakenAt != 0 && photo.LocationSource != "" {
+		return photo, nil
+	}
+	for _, name := range importer.TakeoutSidecarNames(path) {
+		sf, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		sidecar, err := importer.ParseTakeoutSidecar(sf)
+		sf.Close()
+		if err != nil {
+			return nil, err
+		}
+		if photo.TakenAt == 0 {
+			photo.TakenAt = sidecar.TakenAt
+		}
+		if photo.LocationSource == "" && sidecar.HasGPS {
+			photo.Lat, photo.Lon, photo.Altitude = sidecar.Lat, sidecar.Lon, sidecar.Altitude
+			photo.LocationSource = models.PhotoLocationSidecar
+		}
+		break
+	}
+	return photo, nil
+}
+
+// photoFromMeta converts photo metadata, recording locationSource when it has a position
+func photoFromMeta(meta *importer.PhotoMeta, locationSource string) *models.Photo {
+	photo := &models.Photo{
+		TakenAt:     meta.TakenAt,
+		CameraMake:  meta.Make,
+		CameraModel: meta.Model,
+		Width:       meta.Width,
+		Height:      meta.Height,
+	}
+	if meta.HasGPS {
+		photo.Lat, photo.Lon, photo.Altitude = meta.Lat, meta.Lon, meta.Altitude
+		photo.LocationSource = locationSource
+	}
+	return photo
+}
+
+// setPhotoURLs sets the image endpoint of each photo
+func setPhotoURLs(photos []models.Photo) {
+	for i := range photos {
+		photos[i].URL = photoURL(photos[i].ID)
+	}
+}
+
+func photoURL(id int64) string {
+	return "/api/v1/photos/" + strconv.FormatInt(id, 10) + "/file"
+}
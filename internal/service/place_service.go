@@ -0,0 +1,47 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// PlaceService handles business logic for places clustered from stays
+// Places are renamed through the API, so results are not cached.
+type PlaceService struct {
+	repo *repository.PlaceRepository
+}
+
+// NewPlaceService creates a new place service
+func NewPlaceService(repo *repository.PlaceRepository) *PlaceService {
+	return &PlaceService{repo: repo}
+}
+
+// ListPlaces retrieves a page of places matching the filter
+func (s *PlaceService) ListPlaces(filter models.PlaceFilter, opts models.QueryOptions) ([]models.Place, int64, error) {
+	return s.repo.List(filter, opts)
+}
+
+// GetPlace retrieves a place by ID; nil if it does not exist
+func (s *PlaceService) GetPlace(id int64) (*models.Place, error) {
+	return s.repo.GetByID(id)
+}
+
+// RenamePlace names a place, or clears its name when the name is blank; nil
+// if the place does not exist
+// A named place is kept by place clustering even when its stays are gone.
+func (s *PlaceService) RenamePlace(id int64, req models.PlaceNameRequest) (*models.Place, error) {
+	name := strings.TrimSpace(req.Name)
+	if utf8.RuneCountInString(name) > models.MaxPlaceNameLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", models.ErrInvalidPlaceName, models.MaxPlaceNameLength)
+	}
+
+	found, err := s.repo.UpdateName(id, name)
+	if err != nil || !found {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
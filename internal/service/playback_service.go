@@ -0,0 +1,123 @@
+package service
+
+import (
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// maxPlaybackFrames bounds the frames of one replay; longer ranges get a
+// coarser step
+const maxPlaybackFrames = 5000
+
+// PlaybackService replays a time range as positions at fixed time steps
+type PlaybackService struct {
+	repo    *repository.VisualizationRepository
+	privacy *PrivacyService
+}
+
+// NewPlaybackService creates a new playback service
+// Points inside privacy zones are left out or fuzzed before interpolation,
+// so a hidden stretch shows up as a gap.
+func NewPlaybackService(repo *repository.VisualizationRepository, privacy *PrivacyService) *PlaybackService {
+	return &PlaybackService{repo: repo, privacy: privacy}
+}
+
+// GetPlayback interpolates positions every filter.Step seconds from
+// filter.From to filter.To, leaving out frames where consecutive points are
+// more than filter.MaxGap seconds apart
+func (s *PlaybackService) GetPlayback(filter models.PlaybackFilter) (*models.PlaybackResponse, error) {
+	step := filter.Step
+	if span := filter.To - filter.From; span/step+1 > maxPlaybackFrames {
+		step = (span + maxPlaybackFrames - 2) / (maxPlaybackFrames - 1)
+	}
+
+	// Points up to MaxGap outside the range let the first and last frames be interpolated
+	points, err := s.repo.GetPlaybackPoints(filter.From-filter.MaxGap, filter.To+filter.MaxGap)
+	if err != nil {
+		return nil, err
+	}
+	if points, err = s.privacy.FilterPoints(points); err != nil {
+		return nil, err
+	}
+
+	segments, err := s.repo.GetSegmentsInRange(filter.From, filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PlaybackResponse{
+		From:             filter.From,
+		To:               filter.To,
+		Step:             step,
+		MaxGap:           filter.MaxGap,
+		Frames:           playbackFrames(points, segments, filter.From, filter.To, step, filter.MaxGap),
+		Gaps:             playbackGaps(points, filter.From, filter.To, filter.MaxGap),
+		SourcePointCount: len(points),
+	}, nil
+}
+
+// playbackFrames interpolates the position at each step between the points
+// around it; points and segments must be in time order
+func playbackFrames(points []models.TrackPoint, segments []models.EntityRef, from, to, step, maxGap int64) []models.PlaybackFrame {
+	frames := []models.PlaybackFrame{}
+	if len(points) == 0 {
+		return frames
+	}
+
+	i, k := 0, 0
+	for t := from; t <= to; t += step {
+		for i+1 < len(points) && points[i+1].DataTime <= t {
+			i++
+		}
+		a := points[i]
+		if a.DataTime > t {
+			continue // Before the first point
+		}
+
+		frame := models.PlaybackFrame{Time: t, Latitude: a.Latitude, Longitude: a.Longitude}
+		if i+1 < len(points) && points[i+1].DataTime-a.DataTime <= maxGap {
+			b := points[i+1]
+			dt := float64(b.DataTime - a.DataTime)
+			f := float64(t-a.DataTime) / dt
+			frame.Latitude = a.Latitude + f*(b.Latitude-a.Latitude)
+			frame.Longitude = a.Longitude + f*(b.Longitude-a.Longitude)
+			frame.SpeedKmh = spatial.HaversineDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) / dt * 3.6
+			frame.Heading = spatial.Bearing(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+		} else if a.DataTime != t {
+			continue // Inside a gap, or after the last point
+		}
+
+		for k < len(segments) && segments[k].EndTime < t {
+			k++
+		}
+		if k < len(segments) && segments[k].StartTime <= t {
+			frame.Mode = segments[k].Label
+			frame.SegmentID = segments[k].ID
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// playbackGaps returns the parts of [from, to] not covered by runs of points
+// at most maxGap seconds apart; points must be in time order
+func playbackGaps(points []models.TrackPoint, from, to, maxGap int64) []models.PlaybackGap {
+	gaps := []models.PlaybackGap{}
+	addGap := func(start, end int64) {
+		start, end = max(start, from), min(end, to)
+		if start < end {
+			gaps = append(gaps, models.PlaybackGap{Start: start, End: end})
+		}
+	}
+
+	covered := from // End of the latest run of points
+	for i, p := range points {
+		if i == 0 || p.DataTime-points[i-1].DataTime > maxGap {
+			addGap(covered, p.DataTime)
+		}
+		covered = p.DataTime
+	}
+	addGap(covered, to)
+	return gaps
+}
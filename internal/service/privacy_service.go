@@ -0,0 +1,175 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/privacy"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// PrivacyService handles business logic for privacy zones
+type PrivacyService struct {
+	repo *repository.PrivacyZoneRepository
+}
+
+// NewPrivacyService creates a new privacy service
+func NewPrivacyService(repo *repository.PrivacyZoneRepository) *PrivacyService {
+	return &PrivacyService{repo: repo}
+}
+
+// ListZones retrieves privacy zones, optionally including soft-deleted ones
+func (s *PrivacyService) ListZones(includeDeleted bool) ([]models.PrivacyZone, error) {
+	return s.repo.List(includeDeleted)
+}
+
+// GetZone retrieves a privacy zone by ID
+func (s *PrivacyService) GetZone(id int64) (*models.PrivacyZone, error) {
+	return s.repo.GetByID(id)
+}
+
+// CreateZone creates a new privacy zone
+func (s *PrivacyService) CreateZone(req models.PrivacyZoneRequest) (*models.PrivacyZone, error) {
+	zone, err := privacyZoneFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(zone)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// UpdateZone replaces the definition of a privacy zone; nil if it does not exist
+func (s *PrivacyService) UpdateZone(id int64, req models.PrivacyZoneRequest) (*models.PrivacyZone, error) {
+	existing, err := s.repo.GetByID(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	zone, err := privacyZoneFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	zone.ID = id
+	if err := s.repo.Update(zone); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// DeleteZone soft-deletes a privacy zone; nil if it does not exist
+func (s *PrivacyService) DeleteZone(id int64) (*models.PrivacyZone, error) {
+	existing, err := s.repo.GetByID(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if err := s.repo.SoftDelete(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// RestoreZone restores a soft-deleted privacy zone; nil if it does not exist
+func (s *PrivacyService) RestoreZone(id int64) (*models.PrivacyZone, error) {
+	existing, err := s.repo.GetByID(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Filter returns the privacy filter of the active zones
+// Exports and rendering endpoints pass their points through it; statistics do not.
+func (s *PrivacyService) Filter() (*privacy.Filter, error) {
+	if s == nil {
+		return nil, nil
+	}
+	zones, err := s.repo.ListActive()
+	if err != nil {
+		return nil, err
+	}
+	return privacy.NewFilter(zones), nil
+}
+
+// FilterPoints leaves out or fuzzes the track points inside active zones
+func (s *PrivacyService) FilterPoints(points []models.TrackPoint) ([]models.TrackPoint, error) {
+	zones, err := s.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if zones.Empty() {
+		return points, nil
+	}
+
+	kept := points[:0]
+	for _, p := range points {
+		lat, lon, ok := zones.Apply(p.Latitude, p.Longitude)
+		if !ok {
+			continue
+		}
+		p.Latitude, p.Longitude = lat, lon
+		kept = append(kept, p)
+	}
+	return kept, nil
+}
+
+// privacyZoneFromRequest validates a privacy zone request
+func privacyZoneFromRequest(req models.PrivacyZoneRequest) (*models.PrivacyZone, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", models.ErrInvalidPrivacyZone)
+	}
+	if req.Action == "" {
+		req.Action = models.PrivacyActionExclude
+	}
+	if req.Action != models.PrivacyActionExclude && req.Action != models.PrivacyActionFuzz {
+		return nil, fmt.Errorf("%w: action must be exclude or fuzz", models.ErrInvalidPrivacyZone)
+	}
+	if req.FuzzM < 0 {
+		return nil, fmt.Errorf("%w: fuzz_m must not be negative", models.ErrInvalidPrivacyZone)
+	}
+
+	zone := &models.PrivacyZone{
+		Name:    req.Name,
+		Shape:   req.Shape,
+		Action:  req.Action,
+		FuzzM:   req.FuzzM,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+
+	switch req.Shape {
+	case models.PrivacyShapeCircle:
+		if req.RadiusM <= 0 {
+			return nil, fmt.Errorf("%w: radius_m must be positive", models.ErrInvalidPrivacyZone)
+		}
+		if !validLatLon(req.CenterLat, req.CenterLon) {
+			return nil, fmt.Errorf("%w: center is out of range", models.ErrInvalidPrivacyZone)
+		}
+		zone.CenterLat = req.CenterLat
+		zone.CenterLon = req.CenterLon
+		zone.RadiusM = req.RadiusM
+	case models.PrivacyShapePolygon:
+		if len(req.Polygon) < 3 {
+			return nil, fmt.Errorf("%w: polygon needs at least 3 vertices", models.ErrInvalidPrivacyZone)
+		}
+		for _, v := range req.Polygon {
+			if !validLatLon(v[1], v[0]) {
+				return nil, fmt.Errorf("%w: polygon vertex is out of range", models.ErrInvalidPrivacyZone)
+			}
+		}
+		zone.Polygon = req.Polygon
+	default:
+		return nil, fmt.Errorf("%w: shape must be circle or polygon", models.ErrInvalidPrivacyZone)
+	}
+
+	return zone, nil
+}
+
+func validLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// QAService handles business logic for track point quality review
+type QAService struct {
+	repo *repository.QARepository
+}
+
+// NewQAService creates a new QA service
+func NewQAService(repo *repository.QARepository) *QAService {
+	return &QAService{repo: repo}
+}
+
+// GetOutliers retrieves a page of flagged or manually reviewed track points
+func (s *QAService) GetOutliers(filter models.OutlierFilter, opts models.QueryOptions) ([]models.OutlierPoint, int64, error) {
+	return s.repo.GetOutliers(filter, opts)
+}
+
+// ConfirmOutliers marks track points as reviewed outliers (MANUAL_FAIL)
+func (s *QAService) ConfirmOutliers(ids []int64) (*models.OutlierReviewResult, error) {
+	return s.setManualStatus(ids, models.QAStatusManualFail)
+}
+
+// UnflagOutliers marks track points as reviewed non-outliers (MANUAL_PASS)
+func (s *QAService) UnflagOutliers(ids []int64) (*models.OutlierReviewResult, error) {
+	return s.setManualStatus(ids, models.QAStatusManualPass)
+}
+
+// ResetReviews drops the manual review of track points so outlier detection decides again
+func (s *QAService) ResetReviews(ids []int64) (*models.OutlierReviewResult, error) {
+	updated, err := s.repo.ResetManualStatus(ids)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutlierReviewResult{Updated: updated}, nil
+}
+
+func (s *QAService) setManualStatus(ids []int64, qaStatus string) (*models.OutlierReviewResult, error) {
+	updated, err := s.repo.SetManualStatus(ids, qaStatus)
+	if err != nil {
+		return nil, err
+	}
+	return &models.OutlierReviewResult{QAStatus: qaStatus, Updated: updated}, nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/models"
+)
+
+// RecomputeService plans and runs full recomputes of chosen analyzers,
+// optionally limited to a time window
+// Each analyzer's outputs are cleared right before it runs, as listed in the
+// plan, instead of every analyzer deleting its tables on its own.
+type RecomputeService struct {
+	db                  *sql.DB
+	analysisTaskService *AnalysisTaskService
+	running             sync.Mutex
+}
+
+// NewRecomputeService creates a recompute service
+func NewRecomputeService(db *sql.DB, analysisTaskService *AnalysisTaskService) *RecomputeService {
+	return &RecomputeService{db: db, analysisTaskService: analysisTaskService}
+}
+
+// Plan describes what recomputing the requested analyzers would clear,
+// without changing anything
+func (s *RecomputeService) Plan(ctx context.Context, req models.RecomputeRequest) (*models.RecomputePlan, error) {
+	skills, window, err := parseRecompute(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.plan(ctx, skills, window)
+}
+
+// Execute plans the recompute and runs it in the background, one analyzer at
+// a time in plan order; it stops at the first failed analyzer
+// The tasks are created as they run, by "recompute".
+func (s *RecomputeService) Execute(ctx context.Context, req models.RecomputeRequest) (*models.RecomputePlan, error) {
+	skills, window, err := parseRecompute(req)
+	if err != nil {
+		return nil, err
+	}
+	if !s.running.TryLock() {
+		return nil, models.ErrRecomputeBusy
+	}
+	started := false
+	defer func() {
+		if !started {
+			s.running.Unlock()
+		}
+	}()
+
+	active, err := s.analysisTaskService.CountActiveTasks()
+	if err != nil {
+		return nil, err
+	}
+	if active[models.TaskStatusPending]+active[models.TaskStatusRunning] > 0 {
+		return nil, models.ErrRecomputeBusy
+	}
+
+	plan, err := s.plan(ctx, skills, window)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Points == 0 {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidRecompute, errNoPointsToAnalyze)
+	}
+	plan.DryRun = false
+
+	params := make(map[string]map[string]interface{})
+	for _, step := range plan.Steps {
+		if step.Windowed {
+			params[step.Analyzer] = map[string]interface{}{"window_start": window.Start, "window_end": window.End}
+		}
+	}
+
+	started = true
+	go func() {
+		defer s.running.Unlock()
+		taskIDs, err := s.analysisTaskService.runSkills(context.Background(), skills, models.TaskTypeFullRecompute, nil, params, "recompute", plan.Points)
+		if err != nil {
+			log.Printf("Recompute stopped after %d tasks: %v", len(taskIDs), err)
+			return
+		}
+		log.Printf("Recompute completed: %d tasks", len(taskIDs))
+	}()
+	return plan, nil
+}
+
+// plan counts the rows each analyzer's outputs would lose
+func (s *RecomputeService) plan(ctx context.Context, skills []string, window *analysis.Window) (*models.RecomputePlan, error) {
+	plan := &models.RecomputePlan{DryRun: true, Steps: []models.RecomputeStep{}, Warnings: []string{}}
+	if window != nil {
+		plan.StartTime = window.Start
+		if window.End != math.MaxInt64 {
+			plan.EndTime = window.End
+		}
+	}
+
+	points, err := s.analysisTaskService.countPoints(models.TaskTypeFullRecompute)
+	if err != nil && !errors.Is(err, errNoPointsToAnalyze) {
+		return nil, err
+	}
+	plan.Points = points
+
+	planned := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		planned[skill] = true
+	}
+
+	for _, skill := range skills {
+		step := models.RecomputeStep{Analyzer: skill, Outputs: []models.RecomputeOutput{}}
+		stepWindow := window
+		if window != nil {
+			if analysis.SupportsWindow(skill) {
+				step.Windowed = true
+			} else {
+				stepWindow = nil
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s cannot recompute a time window; all of its results are rebuilt", skill))
+			}
+		}
+
+		outputs := analysis.GetOutputs(skill)
+		if len(outputs) == 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s has no registered outputs; its results are updated in place", skill))
+		}
+		for _, o := range outputs {
+			rows, err := o.CountRows(ctx, s.db, stepWindow)
+			if err != nil {
+				return nil, err
+			}
+			action := o.Action(stepWindow)
+			step.Outputs = append(step.Outputs, models.RecomputeOutput{Table: o.Table, Action: action, Rows: rows})
+			if action == analysis.OutputReplace {
+				continue
+			}
+			plan.TotalRows += rows
+			if action != analysis.OutputTruncate {
+				continue
+			}
+			for _, other := range derivingSkills(o.Table) {
+				if other != skill && !planned[other] {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s clears %s, which %s also derives; add %s to rebuild it", skill, o.Table, other, other))
+				}
+			}
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+// parseRecompute validates a recompute request and returns its analyzers in
+// execution order and its window, nil for all time
+// Analyzers of the analysis chain run in chain order, the others after them
+// in the order requested.
+func parseRecompute(req models.RecomputeRequest) ([]string, *analysis.Window, error) {
+	var window *analysis.Window
+	if req.StartTime != 0 || req.EndTime != 0 {
+		window = &analysis.Window{Start: req.StartTime, End: req.EndTime}
+		if window.End == 0 {
+			window.End = math.MaxInt64
+		}
+		if window.Start < 0 || window.End <= window.Start {
+			return nil, nil, fmt.Errorf("%w: end_time must be after start_time", models.ErrInvalidRecompute)
+		}
+	}
+
+	if len(req.Analyzers) == 0 {
+		skills := []string{}
+		for _, skill := range analysisChain {
+			if analysis.IsAnalyzerEnabled(skill) {
+				skills = append(skills, skill)
+			}
+		}
+		return skills, window, nil
+	}
+
+	requested := make(map[string]bool, len(req.Analyzers))
+	var unknown []string
+	for _, skill := range req.Analyzers {
+		if !analysis.IsGoNativeSkill(skill) || !analysis.IsAnalyzerEnabled(skill) {
+			unknown = append(unknown, skill)
+		}
+		requested[skill] = true
+	}
+	if len(unknown) > 0 {
+		return nil, nil, fmt.Errorf("%w: unknown or disabled analyzers: %s", models.ErrInvalidRecompute, strings.Join(unknown, ", "))
+	}
+
+	skills := []string{}
+	for _, skill := range analysisChain {
+		if requested[skill] {
+			skills = append(skills, skill)
+			delete(requested, skill)
+		}
+	}
+	for _, skill := range req.Analyzers {
+		if requested[skill] {
+			skills = append(skills, skill)
+			delete(requested, skill)
+		}
+	}
+	return skills, window, nil
+}
+
+// derivingSkills returns the skills, sorted, whose cleared outputs include table
+func derivingSkills(table string) []string {
+	var skills []string
+	for skill := range analysis.AnalyzerRegistry {
+		for _, o := range analysis.GetOutputs(skill) {
+			if o.Table == table && !o.Replaced {
+				skills = append(skills, skill)
+				break
+			}
+		}
+	}
+	sort.Strings(skills)
+	return skills
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+const (
+	// MinReportYear is the earliest year a report can be generated for
+	MinReportYear = 1970
+	// reportTopRevisits is the number of revisit locations in a report
+	reportTopRevisits = 10
+	// reportRevisitCandidates is how many of the strongest lifetime revisit
+	// locations are checked for visits within the report year
+	reportRevisitCandidates = 100
+)
+
+// ReportService composes reports from the stats, summary and trip data
+type ReportService struct {
+	statsRepo   *repository.StatsRepository
+	summaryRepo *repository.SummaryRepository
+	reportRepo  *repository.ReportRepository
+}
+
+// NewReportService creates a new report service
+func NewReportService(
+	statsRepo *repository.StatsRepository,
+	summaryRepo *repository.SummaryRepository,
+	reportRepo *repository.ReportRepository,
+) *ReportService {
+	return &ReportService{
+		statsRepo:   statsRepo,
+		summaryRepo: summaryRepo,
+		reportRepo:  reportRepo,
+	}
+}
+
+// GetAnnualReport builds the year-in-review for a local calendar year
+// Movement totals come from daily_summaries, so the daily_summary analyzer
+// must have run for them to be filled.
+func (s *ReportService) GetAnnualReport(year int) (*models.AnnualReport, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0)
+
+	report := &models.AnnualReport{
+		Year:        year,
+		StartTime:   start.Unix(),
+		EndTime:     end.Unix() - 1,
+		GeneratedAt: time.Now().Unix(),
+	}
+
+	footprint, err := s.statsRepo.GetFootprintStatistics(report.StartTime, report.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get footprint statistics: %w", err)
+	}
+	report.Footprint = footprint
+
+	days, err := s.summaryRepo.GetDailySummaries(start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily summaries: %w", err)
+	}
+	s.addMovementTotals(report, days)
+
+	if report.NewCities, err = s.reportRepo.GetNewAdminAreas("city", report.StartTime, report.EndTime); err != nil {
+		return nil, err
+	}
+	if report.NewCounties, err = s.reportRepo.GetNewAdminAreas("county", report.StartTime, report.EndTime); err != nil {
+		return nil, err
+	}
+
+	if report.LongestTrip, err = s.reportRepo.GetLongestTrip(report.StartTime, report.EndTime); err != nil {
+		return nil, err
+	}
+	if report.ExtremeEvents, err = s.reportRepo.GetExtremeEventsBetween(report.StartTime, report.EndTime); err != nil {
+		return nil, err
+	}
+
+	revisits, _, err := s.statsRepo.GetTopRevisitLocations(models.QueryOptions{Limit: reportRevisitCandidates})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisit locations: %w", err)
+	}
+	report.TopRevisitLocations = []models.RevisitPattern{}
+	for _, p := range revisits {
+		if p.LastVisit < report.StartTime || p.FirstVisit > report.EndTime {
+			continue
+		}
+		report.TopRevisitLocations = append(report.TopRevisitLocations, p)
+		if len(report.TopRevisitLocations) == reportTopRevisits {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// addMovementTotals sums the daily summaries of the year into the report
+func (s *ReportService) addMovementTotals(report *models.AnnualReport, days []models.DailySummary) {
+	byMode := make(map[string]float64)
+	for i := range days {
+		d := &days[i]
+		if d.PointCount > 0 || d.TotalDistanceM > 0 {
+			report.ActiveDays++
+		}
+		report.TotalDistanceM += d.TotalDistanceM
+		report.TotalSteps += d.StepEquivalent
+		report.TripCount += d.TripCount
+		for mode, distance := range d.DistanceByMode {
+			byMode[mode] += distance
+		}
+		if d.TotalDistanceM > 0 && (report.BusiestDay == nil || d.TotalDistanceM > report.BusiestDay.TotalDistanceM) {
+			report.BusiestDay = d
+		}
+	}
+
+	var modeTotal float64
+	for _, distance := range byMode {
+		modeTotal += distance
+	}
+
+	report.DistanceByMode = make([]models.ModeDistance, 0, len(byMode))
+	for mode, distance := range byMode {
+		md := models.ModeDistance{Mode: mode, DistanceM: distance}
+		if modeTotal > 0 {
+			md.Share = distance / modeTotal
+		}
+		report.DistanceByMode = append(report.DistanceByMode, md)
+	}
+	sort.Slice(report.DistanceByMode, func(i, j int) bool {
+		if report.DistanceByMode[i].DistanceM != report.DistanceByMode[j].DistanceM {
+			return report.DistanceByMode[i].DistanceM > report.DistanceByMode[j].DistanceM
+		}
+		return report.DistanceByMode[i].Mode < report.DistanceByMode[j].Mode
+	})
+}
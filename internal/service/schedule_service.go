@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/scheduler"
+)
+
+// ScheduleService runs the analysis chain on cron schedules and records
+// each run
+type ScheduleService struct {
+	repo      *repository.ScheduleRepository
+	tasks     *AnalysisTaskService
+	scheduler *scheduler.Scheduler
+
+	mu        sync.Mutex
+	taskTypes map[string]string // Schedule name -> task type
+}
+
+// NewScheduleService creates a new schedule service without schedules
+func NewScheduleService(repo *repository.ScheduleRepository, tasks *AnalysisTaskService) *ScheduleService {
+	return &ScheduleService{
+		repo:      repo,
+		tasks:     tasks,
+		scheduler: scheduler.New(),
+		taskTypes: make(map[string]string),
+	}
+}
+
+// AddSchedule runs the analysis chain with tasks of taskType whenever the
+// cron expression spec matches
+// A schedule added after Start is scheduled from now.
+func (s *ScheduleService) AddSchedule(name, spec, taskType string) error {
+	if taskType != models.TaskTypeIncremental && taskType != models.TaskTypeFullRecompute {
+		return fmt.Errorf("invalid task type: %s", taskType)
+	}
+	err := s.scheduler.Add(name, spec, func(ctx context.Context) {
+		s.run(ctx, name, spec, taskType)
+	})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.taskTypes[name] = taskType
+	s.mu.Unlock()
+	return nil
+}
+
+// SetSchedule changes the cron expression of the schedule name, adding it
+// if missing; an empty spec removes the schedule
+// A run in progress finishes under the previous expression.
+func (s *ScheduleService) SetSchedule(name, spec, taskType string) error {
+	for _, job := range s.scheduler.Jobs() {
+		if job.Name == name && job.Spec == spec {
+			return nil
+		}
+	}
+	if spec != "" {
+		if _, err := scheduler.Parse(spec); err != nil {
+			return err
+		}
+	}
+
+	s.scheduler.Remove(name)
+	s.mu.Lock()
+	delete(s.taskTypes, name)
+	s.mu.Unlock()
+	if spec == "" {
+		return nil
+	}
+	return s.AddSchedule(name, spec, taskType)
+}
+
+// Start starts running the schedules in the background
+// Runs left unfinished by a previous process are marked failed first.
+func (s *ScheduleService) Start() error {
+	if err := s.repo.FailUnfinishedRuns("interrupted by server restart"); err != nil {
+		return err
+	}
+	s.scheduler.Start()
+	return nil
+}
+
+// Stop stops the schedules, cancelling the running chain between tasks
+func (s *ScheduleService) Stop() {
+	s.scheduler.Stop()
+}
+
+// GetSchedules returns the configured schedules with their next and latest runs
+func (s *ScheduleService) GetSchedules() ([]models.Schedule, error) {
+	jobs := s.scheduler.Jobs()
+	s.mu.Lock()
+	taskTypes := make(map[string]string, len(s.taskTypes))
+	for name, taskType := range s.taskTypes {
+		taskTypes[name] = taskType
+	}
+	s.mu.Unlock()
+
+	schedules := make([]models.Schedule, len(jobs))
+	for i, job := range jobs {
+		last, err := s.repo.GetLastRun(job.Name, "")
+		if err != nil {
+			return nil, err
+		}
+		schedules[i] = models.Schedule{
+			Name:     job.Name,
+			Cron:     job.Spec,
+			TaskType: taskTypes[job.Name],
+			LastRun:  last,
+		}
+		if !job.NextRun.IsZero() {
+			schedules[i].NextRun = job.NextRun.Unix()
+		}
+	}
+	return schedules, nil
+}
+
+// GetRuns retrieves a page of schedule runs, latest first
+func (s *ScheduleService) GetRuns(filter models.ScheduleRunFilter, opts models.QueryOptions) ([]models.ScheduleRun, int64, error) {
+	return s.repo.GetRuns(filter, opts)
+}
+
+// run runs the analysis chain for a schedule, unless no track points were
+// imported or deleted since the schedule last completed and no analyzer of
+// the chain is stale
+func (s *ScheduleService) run(ctx context.Context, name, spec, taskType string) {
+	count, maxID, err := s.repo.GetPointWatermark()
+	if err != nil {
+		log.Printf("Schedule %s: %v", name, err)
+		return
+	}
+	run := &models.ScheduleRun{
+		Schedule:   name,
+		Cron:       spec,
+		TaskType:   taskType,
+		Status:     models.ScheduleRunRunning,
+		PointCount: count,
+		MaxPointID: maxID,
+		TaskIDs:    []int64{},
+		StartedAt:  time.Now().Unix(),
+	}
+
+	last, err := s.repo.GetLastRun(name, models.ScheduleRunCompleted)
+	if err != nil {
+		log.Printf("Schedule %s: %v", name, err)
+		return
+	}
+	stale, err := s.tasks.HasStaleChainSkills()
+	if err != nil {
+		log.Printf("Schedule %s: %v", name, err)
+		return
+	}
+	if last != nil && last.PointCount == count && last.MaxPointID == maxID && !stale {
+		run.Status = models.ScheduleRunSkipped
+		run.Reason = fmt.Sprintf("no new points since run %d", last.ID)
+		run.FinishedAt = &run.StartedAt
+		if err := s.repo.CreateRun(run); err != nil {
+			log.Printf("Schedule %s: %v", name, err)
+		}
+		log.Printf("Schedule %s skipped: %s", name, run.Reason)
+		return
+	}
+
+	if err := s.repo.CreateRun(run); err != nil {
+		log.Printf("Schedule %s: %v", name, err)
+		return
+	}
+	taskIDs, err := s.tasks.RunAnalysisChain(ctx, taskType, "scheduler:"+name)
+	run.TaskIDs = taskIDs
+	switch {
+	case errors.Is(err, errNoPointsToAnalyze):
+		run.Status, run.Reason = models.ScheduleRunSkipped, err.Error()
+	case err != nil:
+		run.Status, run.Reason = models.ScheduleRunFailed, err.Error()
+	default:
+		run.Status = models.ScheduleRunCompleted
+	}
+	if err := s.repo.FinishRun(run); err != nil {
+		log.Printf("Schedule %s: %v", name, err)
+	}
+	log.Printf("Schedule %s %s with %d tasks (run %d)", name, run.Status, len(taskIDs), run.ID)
+}
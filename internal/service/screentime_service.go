@@ -0,0 +1,191 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+const (
+	// MaxScreenTimeDays caps the date range of a screen time query
+	MaxScreenTimeDays = 732
+	// defaultScreenTimeDays is the range of stats queries without dates
+	defaultScreenTimeDays = 30
+	// defaultScreenTimeWeeks is the range of weekly trends without dates
+	defaultScreenTimeWeeks = 12
+)
+
+// ScreenTimeService handles screen time import and statistics
+type ScreenTimeService struct {
+	repo *repository.ScreenTimeRepository
+}
+
+// NewScreenTimeService creates a new screen time service
+func NewScreenTimeService(repo *repository.ScreenTimeRepository) *ScreenTimeService {
+	return &ScreenTimeService{repo: repo}
+}
+
+// ImportFile parses a screen time export and stores its per-app daily usage
+func (s *ScreenTimeService) ImportFile(filename string, r io.Reader, device string) (*models.ScreenTimeImportResult, error) {
+	file, err := importer.ParseScreenTimeCSV(r, device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	result := &models.ScreenTimeImportResult{
+		FileName:   filename,
+		Format:     file.Format,
+		ParsedRows: file.ParsedRows,
+		Records:    len(file.Usage),
+		Warnings:   file.Warnings,
+	}
+	if len(file.Usage) > 0 {
+		result.StartDate = file.Usage[0].Date
+		result.EndDate = file.Usage[len(file.Usage)-1].Date
+	}
+
+	if result.Stored, err = s.repo.UpsertUsage(file.Usage); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Imported screen time from %s (%s): %d rows, %d app-days stored", filename, file.Format, result.ParsedRows, result.Stored)
+	return result, nil
+}
+
+// GetSummary returns totals, top apps and category totals of a date range
+func (s *ScreenTimeService) GetSummary(filter models.ScreenTimeFilter) (*models.ScreenTimeSummary, error) {
+	filter, err := s.resolveRange(filter, defaultScreenTimeDays)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ScreenTimeSummary{From: filter.From, To: filter.To}
+	summary.Days, summary.TotalDurationS, summary.TotalLaunches, err = s.repo.GetTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+	if summary.Days > 0 {
+		summary.DailyAverageS = summary.TotalDurationS / int64(summary.Days)
+	}
+
+	if summary.TopApps, err = s.topApps(filter, summary.TotalDurationS); err != nil {
+		return nil, err
+	}
+	if summary.Categories, err = s.categories(filter, summary.TotalDurationS); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// GetTopApps returns the most used apps of a date range
+func (s *ScreenTimeService) GetTopApps(filter models.ScreenTimeFilter) ([]models.AppUsage, error) {
+	filter, err := s.resolveRange(filter, defaultScreenTimeDays)
+	if err != nil {
+		return nil, err
+	}
+	_, total, _, err := s.repo.GetTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.topApps(filter, total)
+}
+
+// GetCategoryTotals returns the usage per category of a date range
+func (s *ScreenTimeService) GetCategoryTotals(filter models.ScreenTimeFilter) ([]models.CategoryUsage, error) {
+	filter, err := s.resolveRange(filter, defaultScreenTimeDays)
+	if err != nil {
+		return nil, err
+	}
+	_, total, _, err := s.repo.GetTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.categories(filter, total)
+}
+
+// GetWeeklyTrends returns usage per Monday-based week
+func (s *ScreenTimeService) GetWeeklyTrends(filter models.ScreenTimeFilter) ([]models.WeeklyUsage, error) {
+	filter, err := s.resolveRange(filter, defaultScreenTimeWeeks*7)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetWeeklyUsage(filter)
+}
+
+func (s *ScreenTimeService) topApps(filter models.ScreenTimeFilter, total int64) ([]models.AppUsage, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	apps, err := s.repo.GetAppUsage(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		for i := range apps {
+			apps[i].Share = float64(apps[i].DurationS) / float64(total)
+		}
+	}
+	return apps, nil
+}
+
+func (s *ScreenTimeService) categories(filter models.ScreenTimeFilter, total int64) ([]models.CategoryUsage, error) {
+	categories, err := s.repo.GetCategoryUsage(filter)
+	if err != nil {
+		return nil, err
+	}
+	if total > 0 {
+		for i := range categories {
+			categories[i].Share = float64(categories[i].DurationS) / float64(total)
+		}
+	}
+	return categories, nil
+}
+
+// resolveRange fills missing bounds: the range ends at the last imported day
+// (or today) and spans defaultDays
+func (s *ScreenTimeService) resolveRange(filter models.ScreenTimeFilter, defaultDays int) (models.ScreenTimeFilter, error) {
+	var err error
+	filter.From, filter.To, err = resolveDayRange(filter.From, filter.To, defaultDays, s.repo.GetLatestDate)
+	return filter, err
+}
+
+// resolveDayRange fills missing YYYY-MM-DD bounds of an imported-data query
+// Without an end date the range ends at the day returned by latest (or today
+// when nothing is imported); without a start date it spans defaultDays.
+func resolveDayRange(from, to string, defaultDays int, latest func() (string, error)) (string, string, error) {
+	var end time.Time
+	var err error
+	if to != "" {
+		end, err = time.ParseInLocation("2006-01-02", to, time.Local)
+	} else if from != "" {
+		var start time.Time
+		start, err = time.ParseInLocation("2006-01-02", from, time.Local)
+		end = start.AddDate(0, 0, defaultDays-1)
+	} else {
+		var last string
+		if last, err = latest(); err == nil {
+			if last == "" {
+				now := time.Now()
+				end = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+			} else {
+				end, err = time.ParseInLocation("2006-01-02", last, time.Local)
+			}
+		}
+	}
+	if err != nil {
+		return from, to, err
+	}
+
+	if from == "" {
+		from = end.AddDate(0, 0, -(defaultDays - 1)).Format("2006-01-02")
+	}
+	return from, end.Format("2006-01-02"), nil
+}
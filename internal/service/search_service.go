@@ -0,0 +1,30 @@
+package service
+
+import (
+	"github.com/jengzang/records-backend-go/internal/cache"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// searchSkill tags cached search results with the analyzer that builds the index
+const searchSkill = "search_index"
+
+// SearchService handles business logic for full-text search
+type SearchService struct {
+	repo  *repository.SearchRepository
+	cache *cache.Cache
+}
+
+// NewSearchService creates a new search service
+// A nil cache disables result caching.
+func NewSearchService(repo *repository.SearchRepository, resultCache *cache.Cache) *SearchService {
+	return &SearchService{repo: repo, cache: resultCache}
+}
+
+// Search retrieves a page of stays, trips, places and events matching text,
+// best matches first; kind restricts the results to one type
+func (s *SearchService) Search(text, kind string, opts models.QueryOptions) ([]models.SearchResult, int64, error) {
+	return loadPage(s.cache, cache.Key("search", text, kind, opts), []string{searchSkill}, func() ([]models.SearchResult, int64, error) {
+		return s.repo.Search(text, kind, opts)
+	})
+}
@@ -1,18 +1,30 @@
 package service
 
 import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
 )
 
+// modeCorrectionSkills are the analyzers reading segment modes, recomputed
+// after a mode correction
+// Transport mode itself picks up the correction, and the speed cutoffs it
+// calibrates, on its next full run.
+var modeCorrectionSkills = []string{"speed_events", "directional_bias", "mode_stats", "carbon_footprint"}
+
 // SegmentService handles business logic for segments
 type SegmentService struct {
-	repo *repository.SegmentRepository
+	repo                *repository.SegmentRepository
+	analysisTaskService *AnalysisTaskService
 }
 
 // NewSegmentService creates a new segment service
-func NewSegmentService(repo *repository.SegmentRepository) *SegmentService {
-	return &SegmentService{repo: repo}
+func NewSegmentService(repo *repository.SegmentRepository, analysisTaskService *AnalysisTaskService) *SegmentService {
+	return &SegmentService{repo: repo, analysisTaskService: analysisTaskService}
 }
 
 // GetSegments retrieves segments with filtering and pagination
@@ -24,3 +36,61 @@ func (s *SegmentService) GetSegments(filter models.SegmentFilter) ([]models.Segm
 func (s *SegmentService) GetSegmentByID(id int64) (*models.Segment, error) {
 	return s.repo.GetSegmentByID(id)
 }
+
+// CorrectMode corrects the transport mode of a segment and queues recomputes
+// of the analyzers reading it, returning nil if the segment does not exist
+func (s *SegmentService) CorrectMode(id int64, req models.SegmentModeRequest) (*models.SegmentModeResult, error) {
+	mode := strings.ToUpper(strings.TrimSpace(req.Mode))
+	valid := false
+	for _, m := range models.CorrectableModes {
+		valid = valid || m == mode
+	}
+	if !valid {
+		return nil, fmt.Errorf("%w: mode must be one of %s", models.ErrInvalidModeCorrection, strings.Join(models.CorrectableModes, ", "))
+	}
+
+	seg, err := s.repo.GetSegmentByID(id)
+	if err != nil || seg == nil {
+		return nil, err
+	}
+
+	correction := models.ModeCorrection{
+		StartTime:    seg.StartTime,
+		EndTime:      seg.EndTime,
+		OriginalMode: seg.Mode,
+		Mode:         mode,
+		AvgSpeedKmh:  seg.AvgSpeedKmh,
+		Note:         req.Note,
+		CreatedAt:    time.Now().Unix(),
+	}
+	if err := s.repo.CorrectSegmentMode(id, &correction); err != nil {
+		return nil, err
+	}
+
+	seg, err = s.repo.GetSegmentByID(id)
+	if err != nil || seg == nil {
+		return nil, err
+	}
+	return &models.SegmentModeResult{Segment: *seg, Correction: correction, TaskIDs: s.recompute()}, nil
+}
+
+// GetModeCorrections retrieves the mode corrections, newest first
+func (s *SegmentService) GetModeCorrections() ([]models.ModeCorrection, error) {
+	return s.repo.GetModeCorrections()
+}
+
+// recompute queues full recomputes of the analyzers reading segment modes
+// The correction stands even when a task cannot be queued (e.g. no points
+// yet); the next analysis chain picks it up.
+func (s *SegmentService) recompute() []int64 {
+	taskIDs := []int64{}
+	for _, skill := range modeCorrectionSkills {
+		task, err := s.analysisTaskService.CreateTask(skill, models.TaskTypeFullRecompute, nil, "mode_corrections")
+		if err != nil {
+			log.Printf("Warning: failed to queue %s recompute after mode correction: %v", skill, err)
+			continue
+		}
+		taskIDs = append(taskIDs, task.ID)
+	}
+	return taskIDs
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/exporter"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/staticmap"
+)
+
+// ShareService builds the share cards of trips and days
+type ShareService struct {
+	trips     *TripService
+	summaries *SummaryService
+	viz       *repository.VisualizationRepository
+	privacy   *PrivacyService
+}
+
+// NewShareService creates a new share service
+// Cards show only the privacy-filtered track and a few headline figures.
+func NewShareService(trips *TripService, summaries *SummaryService, viz *repository.VisualizationRepository, privacy *PrivacyService) *ShareService {
+	return &ShareService{trips: trips, summaries: summaries, viz: viz, privacy: privacy}
+}
+
+// GetTripCard builds the share card of a trip, or returns nil if it does not exist
+func (s *ShareService) GetTripCard(id int64) (*exporter.ShareCard, error) {
+	trip, err := s.trips.GetTripByID(id)
+	if err != nil || trip == nil {
+		return nil, err
+	}
+	route, err := s.trips.GetTripRoute(id)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &exporter.ShareCard{Title: "Trip · " + trip.Date}
+	var modes []string
+	if route != nil {
+		for _, seg := range route.Segments {
+			track := exporter.CardTrack{Mode: seg.Mode, Points: make([]staticmap.Point, len(seg.Points))}
+			for i, p := range seg.Points {
+				track.Points[i] = staticmap.Point{Lat: p.Latitude, Lon: p.Longitude}
+			}
+			if len(seg.Path) > 0 {
+				// Flights are drawn along their great circle
+				track.Points = track.Points[:0]
+				for _, v := range seg.Path {
+					track.Points = append(track.Points, staticmap.Point{Lat: v[1], Lon: v[0]})
+				}
+			}
+			card.Tracks = append(card.Tracks, track)
+			modes = appendUnique(modes, seg.Mode)
+		}
+	}
+
+	origin := firstNonEmpty(trip.OriginCounty, trip.OriginCity, trip.OriginProvince)
+	dest := firstNonEmpty(trip.DestCounty, trip.DestCity, trip.DestProvince)
+	if routeName := origin + " → " + dest; origin != "" && dest != "" && staticmap.CanRender(routeName) {
+		card.Subtitle = routeName
+	} else {
+		card.Subtitle = strings.Join(modes, " · ")
+	}
+
+	cities := appendUnique(appendUnique(nil, trip.OriginCity), trip.DestCity)
+	card.Stats = []exporter.CardStat{
+		{Value: formatCardDistance(trip.DistanceMeters), Label: "Distance"},
+		{Value: formatCardDuration(trip.DurationSeconds), Label: "Duration"},
+		{Value: fmt.Sprintf("%d", len(cities)), Label: plural(len(cities), "City", "Cities")},
+	}
+	if trip.DurationSeconds > 0 {
+		card.Stats = append(card.Stats, exporter.CardStat{
+			Value: fmt.Sprintf("%.0f KM/H", trip.DistanceMeters/float64(trip.DurationSeconds)*3.6),
+			Label: "Average speed",
+		})
+	}
+	return card, nil
+}
+
+// GetDayCard builds the share card of a day (YYYY-MM-DD), or returns nil if
+// the day was not summarized
+func (s *ShareService) GetDayCard(date string) (*exporter.ShareCard, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", date, err)
+	}
+	summary, err := s.summaries.GetDay(date)
+	if err != nil || summary == nil {
+		return nil, err
+	}
+
+	start, end := day.Unix(), day.AddDate(0, 0, 1).Unix()-1
+	points, err := s.viz.GetPlaybackPoints(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if points, err = s.privacy.FilterPoints(points); err != nil {
+		return nil, err
+	}
+	segments, err := s.viz.GetSegmentsInRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &exporter.ShareCard{Title: "Day · " + date}
+	if cities := strings.Join(summary.Cities, " · "); cities != "" && staticmap.CanRender(cities) {
+		card.Subtitle = cities
+	} else {
+		card.Subtitle = fmt.Sprintf("%d %s · %d %s",
+			summary.TripCount, plural(summary.TripCount, "trip", "trips"),
+			summary.StayCount, plural(summary.StayCount, "stay", "stays"))
+	}
+
+	// Only points within movement segments are drawn, leaving out the jitter of stays
+	i := 0
+	for _, seg := range segments {
+		track := exporter.CardTrack{Mode: seg.Label}
+		for ; i < len(points) && points[i].DataTime <= seg.EndTime; i++ {
+			if points[i].DataTime >= seg.StartTime {
+				track.Points = append(track.Points, staticmap.Point{Lat: points[i].Latitude, Lon: points[i].Longitude})
+			}
+		}
+		if len(track.Points) > 0 {
+			card.Tracks = append(card.Tracks, track)
+		}
+	}
+
+	card.Stats = []exporter.CardStat{
+		{Value: formatCardDistance(summary.TotalDistanceM), Label: "Distance"},
+		{Value: formatCardDuration(summary.ActiveTimeS), Label: "On the move"},
+		{Value: fmt.Sprintf("%d", summary.CityCount), Label: plural(summary.CityCount, "City", "Cities")},
+		{Value: fmt.Sprintf("%d", summary.TripCount), Label: plural(summary.TripCount, "Trip", "Trips")},
+	}
+	return card, nil
+}
+
+// formatCardDistance formats metres as "850 M", "12.4 KM" or "1234 KM"
+func formatCardDistance(m float64) string {
+	switch {
+	case m < 1000:
+		return fmt.Sprintf("%.0f M", m)
+	case m < 100000:
+		return fmt.Sprintf("%.1f KM", m/1000)
+	default:
+		return fmt.Sprintf("%.0f KM", m/1000)
+	}
+}
+
+// formatCardDuration formats seconds as "19 MIN" or "3H 05M"
+func formatCardDuration(s int64) string {
+	if s < 3600 {
+		return fmt.Sprintf("%d MIN", (s+30)/60)
+	}
+	return fmt.Sprintf("%dH %02dM", s/3600, s%3600/60)
+}
+
+// appendUnique appends v to values unless it is empty or already present
+func appendUnique(values []string, v string) []string {
+	if v == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == v {
+			return values
+		}
+	}
+	return append(values, v)
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// plural returns one or many depending on n
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
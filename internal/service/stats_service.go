@@ -1,25 +1,57 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
+	"github.com/jengzang/records-backend-go/internal/cache"
+	"github.com/jengzang/records-backend-go/internal/calendar"
+	"github.com/jengzang/records-backend-go/internal/geocode"
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/stats"
 )
 
+// rawPointsSkill tags cached results computed directly from track points
+// Every import triggers the analysis chain, which starts with this skill, so
+// its completion marks new points.
+const rawPointsSkill = "outlier_detection"
+
 // StatsService handles business logic for statistics
+// Results are cached per endpoint and parameters, tagged with the analyzer
+// skills whose tables they read, and dropped when one of those analyzers
+// completes a task.
 type StatsService struct {
 	statsRepo *repository.StatsRepository
+	cache     *cache.Cache
 }
 
 // NewStatsService creates a new stats service
-func NewStatsService(statsRepo *repository.StatsRepository) *StatsService {
+// A nil cache disables result caching.
+func NewStatsService(statsRepo *repository.StatsRepository, resultCache *cache.Cache) *StatsService {
 	return &StatsService{
 		statsRepo: statsRepo,
+		cache:     resultCache,
 	}
 }
 
+// page is a cached page of list results with the total number of matching rows
+type page[T any] struct {
+	items []T
+	total int64
+}
+
+// loadPage caches a page of list results together with its total
+func loadPage[T any](c *cache.Cache, key string, tags []string, load func() ([]T, int64, error)) ([]T, int64, error) {
+	p, err := cache.Load(c, key, tags, func() (page[T], error) {
+		items, total, err := load()
+		return page[T]{items: items, total: total}, err
+	})
+	return p.items, p.total, err
+}
+
 // GetFootprintStatistics retrieves footprint statistics for a time range
 func (s *StatsService) GetFootprintStatistics(startTime, endTime int64) (*models.FootprintStatistics, error) {
 	// Validate time range
@@ -33,13 +65,34 @@ func (s *StatsService) GetFootprintStatistics(startTime, endTime int64) (*models
 		return nil, fmt.Errorf("start time must be before end time")
 	}
 
-	stats, err := s.statsRepo.GetFootprintStatistics(startTime, endTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get footprint statistics: %w", err)
+	key := cache.Key("footprint_statistics", startTime, endTime)
+	return cache.Load(s.cache, key, []string{rawPointsSkill}, func() (*models.FootprintStatistics, error) {
+		stats, err := s.statsRepo.GetFootprintStatistics(startTime, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get footprint statistics: %w", err)
+		}
+
+		stats.GeneratedAt = time.Now().Format(time.RFC3339)
+		return stats, nil
+	})
+}
+
+// GetVisitedAreas retrieves a page of the areas of a level visited within a time range
+func (s *StatsService) GetVisitedAreas(level string, startTime, endTime int64, opts models.QueryOptions) ([]models.AreaVisit, int64, error) {
+	if startTime < 0 {
+		startTime = 0
+	}
+	if endTime < 0 {
+		endTime = time.Now().Unix()
+	}
+	if endTime > 0 && startTime > endTime {
+		return nil, 0, fmt.Errorf("start time must be before end time")
 	}
 
-	stats.GeneratedAt = time.Now().Format(time.RFC3339)
-	return stats, nil
+	key := cache.Key("visited_areas", level, startTime, endTime, opts)
+	return loadPage(s.cache, key, []string{rawPointsSkill}, func() ([]models.AreaVisit, int64, error) {
+		return s.statsRepo.GetVisitedAreas(level, startTime, endTime, opts)
+	})
 }
 
 // GetTimeDistribution retrieves time distribution statistics
@@ -55,12 +108,14 @@ func (s *StatsService) GetTimeDistribution(startTime, endTime int64) ([]models.T
 		return nil, fmt.Errorf("start time must be before end time")
 	}
 
-	distribution, err := s.statsRepo.GetTimeDistribution(startTime, endTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get time distribution: %w", err)
-	}
-
-	return distribution, nil
+	key := cache.Key("time_distribution", startTime, endTime)
+	return cache.Load(s.cache, key, []string{rawPointsSkill}, func() ([]models.TimeDistribution, error) {
+		distribution, err := s.statsRepo.GetTimeDistribution(startTime, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get time distribution: %w", err)
+		}
+		return distribution, nil
+	})
 }
 
 // GetSpeedDistribution retrieves speed distribution statistics
@@ -76,31 +131,293 @@ func (s *StatsService) GetSpeedDistribution(startTime, endTime int64) ([]models.
 		return nil, fmt.Errorf("start time must be before end time")
 	}
 
-	distribution, err := s.statsRepo.GetSpeedDistribution(startTime, endTime)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get speed distribution: %w", err)
+	key := cache.Key("speed_distribution", startTime, endTime)
+	return cache.Load(s.cache, key, []string{rawPointsSkill}, func() ([]models.SpeedDistribution, error) {
+		distribution, err := s.statsRepo.GetSpeedDistribution(startTime, endTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get speed distribution: %w", err)
+		}
+		return distribution, nil
+	})
+}
+
+// adminTreeLevels are the levels of the administrative tree, from its root down
+var adminTreeLevels = []string{
+	models.AdminLevelCountry, models.AdminLevelProvince, models.AdminLevelCity,
+	models.AdminLevelCounty, models.AdminLevelTown,
+}
+
+// GetAdminTree builds the administrative hierarchy below the area selected by
+// filter, with per-area statistics and the coverage of each area's
+// subdivisions in the division catalogue
+// It returns nil when the area is neither visited nor catalogued.
+func (s *StatsService) GetAdminTree(filter models.AdminTreeFilter) (*models.AdminTreeNode, error) {
+	if filter.Depth <= 0 {
+		filter.Depth = 2
+	}
+	filter.Depth = min(filter.Depth, len(adminTreeLevels)-1)
+
+	key := cache.Key("admin_tree", filter)
+	return cache.Load(s.cache, key, []string{"admin_view_engine", "footprint_statistics"}, func() (*models.AdminTreeNode, error) {
+		stats, err := s.statsRepo.GetAllAdminStats()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get admin stats: %w", err)
+		}
+		points, err := s.statsRepo.GetAdminPointCounts()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get admin point counts: %w", err)
+		}
+
+		tree := newAdminTree(stats, points, geocode.DefaultDivisions(), filter.IncludeUnvisited)
+		var path []string
+		for _, name := range []string{filter.Province, filter.City, filter.County} {
+			if name == "" {
+				break
+			}
+			path = append(path, name)
+		}
+		if len(path) > 0 && !tree.exists(path) {
+			return nil, nil
+		}
+		return tree.build(path, filter.Depth), nil
+	})
+}
+
+// coverageLevels are the levels of footprint coverage with their catalogue depth
+var coverageLevels = []struct {
+	level string
+	depth int
+}{
+	{models.AdminLevelProvince, 1},
+	{models.AdminLevelCity, 2},
+	{models.AdminLevelCounty, 3},
+}
+
+// GetFootprintCoverage computes the share of the catalogued provinces, cities
+// and counties that were visited, and the areas first visited in year
+func (s *StatsService) GetFootprintCoverage(year int) (*models.FootprintCoverage, error) {
+	key := cache.Key("footprint_coverage", year)
+	return cache.Load(s.cache, key, []string{"footprint_statistics"}, func() (*models.FootprintCoverage, error) {
+		levels := make([]string, len(coverageLevels))
+		for i, l := range coverageLevels {
+			levels[i] = l.level
+		}
+		visits, err := s.statsRepo.GetAreaFirstVisits(levels...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get area first visits: %w", err)
+		}
+
+		yearStart := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+		yearEnd := time.Date(year+1, 1, 1, 0, 0, 0, 0, time.Local).Unix()
+		divisions := geocode.DefaultDivisions()
+
+		result := &models.FootprintCoverage{Year: year}
+		for _, l := range coverageLevels {
+			areas := visits[l.level]
+			if areas == nil {
+				areas = []models.AreaFirstVisit{}
+			}
+			lc := models.LevelCoverage{
+				Level:         l.level,
+				Visited:       len(areas),
+				Areas:         areas,
+				NewlyUnlocked: []models.AreaFirstVisit{},
+			}
+			for _, a := range areas {
+				if a.FirstVisit >= yearStart && a.FirstVisit < yearEnd {
+					lc.NewlyUnlocked = append(lc.NewlyUnlocked, a)
+				}
+			}
+
+			if names := divisions.Names(l.depth); len(names) > 0 {
+				catalogued := make(map[string]bool, len(names))
+				for _, name := range names {
+					catalogued[name] = true
+				}
+				covered := 0
+				for _, a := range areas {
+					if catalogued[a.Name] {
+						covered++
+					}
+				}
+				total := len(names)
+				ratio := float64(covered) / float64(total)
+				lc.Total, lc.Ratio = &total, &ratio
+			}
+			result.Levels = append(result.Levels, lc)
+		}
+		return result, nil
+	})
+}
+
+// GetCountryVisits retrieves the visited countries and regions with their names
+func (s *StatsService) GetCountryVisits() ([]models.CountryVisit, error) {
+	return cache.Load(s.cache, cache.Key("country_visits"), []string{"footprint_statistics"}, func() ([]models.CountryVisit, error) {
+		visits, err := s.statsRepo.GetCountryVisits()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get country visits: %w", err)
+		}
+		for i := range visits {
+			country := geocode.CountryByCode(visits[i].Code)
+			visits[i].Name, visits[i].NameEN, visits[i].Region = country.Name, country.NameEN, country.Region
+		}
+		return visits, nil
+	})
+}
+
+// GetDaysAbroad counts per year the days spent outside China
+func (s *StatsService) GetDaysAbroad() ([]models.YearAbroad, error) {
+	return cache.Load(s.cache, cache.Key("days_abroad"), []string{"footprint_statistics"}, func() ([]models.YearAbroad, error) {
+		years, err := s.statsRepo.GetDaysAbroad(geocode.HomeCountry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get days abroad: %w", err)
+		}
+		return years, nil
+	})
+}
+
+// adminTree assembles tree nodes from the flat admin_stats rows
+// Rows are linked to their parents by name, as the analyzer stores them.
+type adminTree struct {
+	stats            map[string]map[string]models.AdminStats   // level -> name
+	children         map[string]map[string][]models.AdminStats // level -> parent name
+	points           map[string]map[string]int64
+	divisions        *geocode.Divisions
+	includeUnvisited bool
+}
+
+func newAdminTree(stats []models.AdminStats, points map[string]map[string]int64, divisions *geocode.Divisions, includeUnvisited bool) *adminTree {
+	t := &adminTree{
+		stats:            make(map[string]map[string]models.AdminStats),
+		children:         make(map[string]map[string][]models.AdminStats),
+		points:           points,
+		divisions:        divisions,
+		includeUnvisited: includeUnvisited,
+	}
+	for _, st := range stats {
+		if t.stats[st.AdminLevel] == nil {
+			t.stats[st.AdminLevel] = make(map[string]models.AdminStats)
+			t.children[st.AdminLevel] = make(map[string][]models.AdminStats)
+		}
+		t.stats[st.AdminLevel][st.AdminName] = st
+		t.children[st.AdminLevel][st.ParentName] = append(t.children[st.AdminLevel][st.ParentName], st)
 	}
+	return t
+}
 
-	return distribution, nil
+// exists reports whether the area at path was visited or is catalogued
+func (t *adminTree) exists(path []string) bool {
+	name := path[len(path)-1]
+	if _, ok := t.stats[adminTreeLevels[len(path)]][name]; ok {
+		return true
+	}
+	for _, child := range t.divisions.Children(path[:len(path)-1]...) {
+		if child == name {
+			return true
+		}
+	}
+	return false
+}
+
+// build returns the node of the area at path with depth levels of descendants
+func (t *adminTree) build(path []string, depth int) *models.AdminTreeNode {
+	level := adminTreeLevels[len(path)]
+	node := &models.AdminTreeNode{Level: level, Name: "中国"}
+	if len(path) > 0 {
+		node.Name = path[len(path)-1]
+		if st, ok := t.stats[level][node.Name]; ok {
+			node.Visited = true
+			node.VisitCount = st.VisitCount
+			node.TotalDurationS = st.TotalDurationS
+			node.UniqueDays = st.UniqueDays
+			node.FirstVisitTS = st.FirstVisitTS
+			node.LastVisitTS = st.LastVisitTS
+		}
+		node.PointCount = t.points[level][node.Name]
+	}
+
+	if len(path) == len(adminTreeLevels)-1 {
+		return node
+	}
+	childLevel := adminTreeLevels[len(path)+1]
+	parent := ""
+	if len(path) > 0 {
+		parent = node.Name
+	}
+	visited := t.children[childLevel][parent]
+
+	var unvisited []string
+	if catalogued := t.divisions.Children(path...); catalogued != nil {
+		seen := make(map[string]bool, len(visited))
+		for _, st := range visited {
+			seen[st.AdminName] = true
+		}
+		coverage := &models.AdminCoverage{TotalChildren: len(catalogued)}
+		for _, name := range catalogued {
+			if seen[name] {
+				coverage.VisitedChildren++
+			} else {
+				unvisited = append(unvisited, name)
+			}
+		}
+		coverage.Ratio = float64(coverage.VisitedChildren) / float64(coverage.TotalChildren)
+		node.Coverage = coverage
+	}
+
+	if node.Level == models.AdminLevelCountry {
+		for _, st := range visited {
+			node.Visited = true
+			node.VisitCount += st.VisitCount
+			node.TotalDurationS += st.TotalDurationS
+			node.PointCount += t.points[childLevel][st.AdminName]
+			if node.FirstVisitTS == 0 || st.FirstVisitTS < node.FirstVisitTS {
+				node.FirstVisitTS = st.FirstVisitTS
+			}
+			node.LastVisitTS = max(node.LastVisitTS, st.LastVisitTS)
+		}
+	}
+
+	if depth > 0 {
+		for _, st := range visited {
+			node.Children = append(node.Children, t.build(childPath(path, st.AdminName), depth-1))
+		}
+		if t.includeUnvisited {
+			for _, name := range unvisited {
+				node.Children = append(node.Children, t.build(childPath(path, name), depth-1))
+			}
+		}
+	}
+	return node
+}
+
+// childPath returns path extended by name without sharing its backing array
+func childPath(path []string, name string) []string {
+	return append(append(make([]string, 0, len(path)+1), path...), name)
 }
 
 // GetFootprintRankings retrieves footprint statistics with rankings
-func (s *StatsService) GetFootprintRankings(filter models.StatsFilter) ([]models.FootprintStatistics, error) {
-	return s.statsRepo.GetFootprintRankings(filter)
+func (s *StatsService) GetFootprintRankings(filter models.StatsFilter, opts models.QueryOptions) ([]models.FootprintStatistics, int64, error) {
+	return loadPage(s.cache, cache.Key("footprint_rankings", filter, opts), []string{"footprint_statistics"}, func() ([]models.FootprintStatistics, int64, error) {
+		return s.statsRepo.GetFootprintRankings(filter, opts)
+	})
 }
 
 // GetStayRankings retrieves stay statistics with rankings
-func (s *StatsService) GetStayRankings(filter models.StatsFilter) ([]models.StayStatistics, error) {
-	return s.statsRepo.GetStayRankings(filter)
+func (s *StatsService) GetStayRankings(filter models.StatsFilter, opts models.QueryOptions) ([]models.StayStatistics, int64, error) {
+	return loadPage(s.cache, cache.Key("stay_rankings", filter, opts), []string{"stay_statistics"}, func() ([]models.StayStatistics, int64, error) {
+		return s.statsRepo.GetStayRankings(filter, opts)
+	})
 }
 
 // GetExtremeEvents retrieves extreme events
-func (s *StatsService) GetExtremeEvents(eventType, eventCategory string, limit int) ([]models.ExtremeEvent, error) {
-	return s.statsRepo.GetExtremeEvents(eventType, eventCategory, limit)
+func (s *StatsService) GetExtremeEvents(eventType, eventCategory string, opts models.QueryOptions) ([]models.ExtremeEvent, int64, error) {
+	return loadPage(s.cache, cache.Key("extreme_events", eventType, eventCategory, opts), []string{"extreme_events"}, func() ([]models.ExtremeEvent, int64, error) {
+		return s.statsRepo.GetExtremeEvents(eventType, eventCategory, opts)
+	})
 }
 
 // GetAdminCrossings retrieves administrative boundary crossing events
-func (s *StatsService) GetAdminCrossings(crossingType, fromRegion, toRegion string, startTime, endTime int64, limit int) ([]models.AdminCrossing, error) {
+func (s *StatsService) GetAdminCrossings(crossingType, fromRegion, toRegion string, startTime, endTime int64, opts models.QueryOptions) ([]models.AdminCrossing, int64, error) {
 	// Validate time range
 	if startTime < 0 {
 		startTime = 0
@@ -109,212 +426,787 @@ func (s *StatsService) GetAdminCrossings(crossingType, fromRegion, toRegion stri
 		endTime = time.Now().Unix()
 	}
 	if startTime > 0 && endTime > 0 && startTime > endTime {
-		return nil, fmt.Errorf("start time must be before end time")
+		return nil, 0, fmt.Errorf("start time must be before end time")
 	}
 
-	return s.statsRepo.GetAdminCrossings(crossingType, fromRegion, toRegion, startTime, endTime, limit)
+	key := cache.Key("admin_crossings", crossingType, fromRegion, toRegion, startTime, endTime, opts)
+	return loadPage(s.cache, key, []string{"admin_crossings"}, func() ([]models.AdminCrossing, int64, error) {
+		return s.statsRepo.GetAdminCrossings(crossingType, fromRegion, toRegion, startTime, endTime, opts)
+	})
+}
+
+// GetAdminVisits retrieves the visits to countries, provinces and cities
+func (s *StatsService) GetAdminVisits(filter models.AdminVisitFilter, opts models.QueryOptions) ([]models.AdminVisit, int64, error) {
+	return loadPage(s.cache, cache.Key("admin_visits", filter, opts), []string{"admin_crossings"}, func() ([]models.AdminVisit, int64, error) {
+		return s.statsRepo.GetAdminVisits(filter, opts)
+	})
+}
+
+// GetAdminVisitSummaries retrieves the visit count and time spent per region of a level
+func (s *StatsService) GetAdminVisitSummaries(adminLevel string) ([]models.AdminVisitSummary, error) {
+	return cache.Load(s.cache, cache.Key("admin_visit_summaries", adminLevel), []string{"admin_crossings"}, func() ([]models.AdminVisitSummary, error) {
+		return s.statsRepo.GetAdminVisitSummaries(adminLevel)
+	})
 }
 
 // GetAdminStats retrieves administrative region statistics
-func (s *StatsService) GetAdminStats(adminLevel, adminName, parentName, sortBy string, limit int) ([]models.AdminStats, error) {
-	return s.statsRepo.GetAdminStats(adminLevel, adminName, parentName, sortBy, limit)
+func (s *StatsService) GetAdminStats(adminLevel, adminName, parentName string, opts models.QueryOptions) ([]models.AdminStats, int64, error) {
+	return loadPage(s.cache, cache.Key("admin_stats", adminLevel, adminName, parentName, opts), []string{"admin_view_engine"}, func() ([]models.AdminStats, int64, error) {
+		return s.statsRepo.GetAdminStats(adminLevel, adminName, parentName, opts)
+	})
 }
 // GetSpeedSpaceStats retrieves speed-space coupling statistics
-func (s *StatsService) GetSpeedSpaceStats(bucketType, areaType, areaName string, limit int) ([]models.SpeedSpaceStats, error) {
-	return s.statsRepo.GetSpeedSpaceStats(bucketType, areaType, areaName, limit)
+func (s *StatsService) GetSpeedSpaceStats(bucketType, source, areaType, areaName string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	return loadPage(s.cache, cache.Key("speed_space_stats", bucketType, source, areaType, areaName, opts), []string{"speed_space_coupling"}, func() ([]models.SpeedSpaceStats, int64, error) {
+		return s.statsRepo.GetSpeedSpaceStats(bucketType, source, areaType, areaName, opts)
+	})
 }
 
 // GetHighSpeedZones retrieves high-speed zones
-func (s *StatsService) GetHighSpeedZones(bucketType, areaType string, limit int) ([]models.SpeedSpaceStats, error) {
-	return s.statsRepo.GetHighSpeedZones(bucketType, areaType, limit)
+func (s *StatsService) GetHighSpeedZones(bucketType, source, areaType string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	return loadPage(s.cache, cache.Key("high_speed_zones", bucketType, source, areaType, opts), []string{"speed_space_coupling"}, func() ([]models.SpeedSpaceStats, int64, error) {
+		return s.statsRepo.GetHighSpeedZones(bucketType, source, areaType, opts)
+	})
 }
 
 // GetSlowLifeZones retrieves slow-life zones
-func (s *StatsService) GetSlowLifeZones(bucketType, areaType string, limit int) ([]models.SpeedSpaceStats, error) {
-	return s.statsRepo.GetSlowLifeZones(bucketType, areaType, limit)
+func (s *StatsService) GetSlowLifeZones(bucketType, source, areaType string, opts models.QueryOptions) ([]models.SpeedSpaceStats, int64, error) {
+	return loadPage(s.cache, cache.Key("slow_life_zones", bucketType, source, areaType, opts), []string{"speed_space_coupling"}, func() ([]models.SpeedSpaceStats, int64, error) {
+		return s.statsRepo.GetSlowLifeZones(bucketType, source, areaType, opts)
+	})
 }
 
 // GetDirectionalBiasStats retrieves directional bias statistics
-func (s *StatsService) GetDirectionalBiasStats(bucketType, areaType, areaKey, modeFilter string, limit int) ([]models.DirectionalBiasStats, error) {
-	return s.statsRepo.GetDirectionalBiasStats(bucketType, areaType, areaKey, modeFilter, limit)
+func (s *StatsService) GetDirectionalBiasStats(bucketType, source, areaType, areaKey, modeFilter string, opts models.QueryOptions) ([]models.DirectionalBiasStats, int64, error) {
+	return loadPage(s.cache, cache.Key("directional_bias_stats", bucketType, source, areaType, areaKey, modeFilter, opts), []string{"directional_bias"}, func() ([]models.DirectionalBiasStats, int64, error) {
+		return s.statsRepo.GetDirectionalBiasStats(bucketType, source, areaType, areaKey, modeFilter, opts)
+	})
+}
+
+// GetDirectionalRose retrieves the direction histogram of an area, bucket and
+// mode as wind-rose bins, or nil when the area has none
+func (s *StatsService) GetDirectionalRose(bucketType, bucketKey, source, areaType, areaKey, modeFilter string) (*models.DirectionalRose, error) {
+	return cache.Load(s.cache, cache.Key("directional_rose", bucketType, bucketKey, source, areaType, areaKey, modeFilter), []string{"directional_bias"}, func() (*models.DirectionalRose, error) {
+		bias, err := s.statsRepo.GetDirectionalHistogram(bucketType, bucketKey, source, areaType, areaKey, modeFilter)
+		if err != nil || bias == nil {
+			return nil, err
+		}
+		return directionalRose(bias)
+	})
+}
+
+// directionalRose converts directional stats to wind-rose bins
+// Bin i of n is centered on bearing i*360/n, so bin 0 straddles north.
+func directionalRose(bias *models.DirectionalBiasStats) (*models.DirectionalRose, error) {
+	var histogram []struct {
+		Bin      int     `json:"bin"`
+		Distance float64 `json:"distance"`
+		Count    int     `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(bias.DirectionHistogramJSON), &histogram); err != nil {
+		return nil, fmt.Errorf("failed to parse direction histogram: %w", err)
+	}
+
+	numBins := bias.NumBins
+	if numBins <= 0 {
+		numBins = len(histogram)
+	}
+	width := 360.0 / float64(numBins)
+
+	rose := &models.DirectionalRose{
+		BucketType:           bias.BucketType,
+		BucketKey:            bias.BucketKey,
+		Source:               bias.Source,
+		AreaType:             bias.AreaType,
+		AreaKey:              bias.AreaKey,
+		ModeFilter:           bias.ModeFilter,
+		BinWidthDeg:          width,
+		DominantDirectionDeg: bias.DominantDirectionDeg,
+		DominantAxisDeg:      bias.DominantAxisDeg,
+		AnisotropyIndex:      bias.AnisotropyIndex,
+		TotalDistance:        bias.TotalDistance,
+		SegmentCount:         bias.SegmentCount,
+		Bins:                 make([]models.RoseBin, numBins),
+	}
+	var total float64
+	for i := range rose.Bins {
+		center := float64(i) * width
+		rose.Bins[i] = models.RoseBin{
+			Bin:       i,
+			CenterDeg: center,
+			FromDeg:   math.Mod(center-width/2+360, 360),
+			ToDeg:     math.Mod(center+width/2, 360),
+		}
+	}
+	for _, h := range histogram {
+		if h.Bin < 0 || h.Bin >= numBins {
+			continue
+		}
+		rose.Bins[h.Bin].Distance += h.Distance
+		rose.Bins[h.Bin].Count += h.Count
+		total += h.Distance
+	}
+	if total > 0 {
+		for i := range rose.Bins {
+			rose.Bins[i].Weight = rose.Bins[i].Distance / total
+		}
+	}
+	return rose, nil
 }
 
 // GetTopDirectionalAreas retrieves areas with highest directional concentration
-func (s *StatsService) GetTopDirectionalAreas(bucketType string, limit int) ([]models.DirectionalBiasStats, error) {
-	return s.statsRepo.GetTopDirectionalAreas(bucketType, limit)
+func (s *StatsService) GetTopDirectionalAreas(bucketType, source string, opts models.QueryOptions) ([]models.DirectionalBiasStats, int64, error) {
+	return loadPage(s.cache, cache.Key("top_directional_areas", bucketType, source, opts), []string{"directional_bias"}, func() ([]models.DirectionalBiasStats, int64, error) {
+		return s.statsRepo.GetTopDirectionalAreas(bucketType, source, opts)
+	})
 }
 
 // GetBidirectionalPatterns retrieves areas with strong bidirectional patterns
-func (s *StatsService) GetBidirectionalPatterns(bucketType string, limit int) ([]models.DirectionalBiasStats, error) {
-	return s.statsRepo.GetBidirectionalPatterns(bucketType, limit)
+func (s *StatsService) GetBidirectionalPatterns(bucketType, source string, opts models.QueryOptions) ([]models.DirectionalBiasStats, int64, error) {
+	return loadPage(s.cache, cache.Key("bidirectional_patterns", bucketType, source, opts), []string{"directional_bias"}, func() ([]models.DirectionalBiasStats, int64, error) {
+		return s.statsRepo.GetBidirectionalPatterns(bucketType, source, opts)
+	})
 }
 
 // GetRevisitPatterns retrieves revisit patterns with filters
-func (s *StatsService) GetRevisitPatterns(minVisits int, habitualOnly, periodicOnly bool, limit int) ([]models.RevisitPattern, error) {
-	return s.statsRepo.GetRevisitPatterns(minVisits, habitualOnly, periodicOnly, limit)
+func (s *StatsService) GetRevisitPatterns(minVisits int, habitualOnly, periodicOnly bool, opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return loadPage(s.cache, cache.Key("revisit_patterns", minVisits, habitualOnly, periodicOnly, opts), []string{"revisit_pattern"}, func() ([]models.RevisitPattern, int64, error) {
+		return s.statsRepo.GetRevisitPatterns(minVisits, habitualOnly, periodicOnly, opts)
+	})
 }
 
 // GetTopRevisitLocations retrieves locations with highest revisit strength
-func (s *StatsService) GetTopRevisitLocations(limit int) ([]models.RevisitPattern, error) {
-	return s.statsRepo.GetTopRevisitLocations(limit)
+func (s *StatsService) GetTopRevisitLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return loadPage(s.cache, cache.Key("top_revisit_locations", opts), []string{"revisit_pattern"}, func() ([]models.RevisitPattern, int64, error) {
+		return s.statsRepo.GetTopRevisitLocations(opts)
+	})
 }
 
 // GetHabitualLocations retrieves habitual locations
-func (s *StatsService) GetHabitualLocations(limit int) ([]models.RevisitPattern, error) {
-	return s.statsRepo.GetHabitualLocations(limit)
+func (s *StatsService) GetHabitualLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return loadPage(s.cache, cache.Key("habitual_locations", opts), []string{"revisit_pattern"}, func() ([]models.RevisitPattern, int64, error) {
+		return s.statsRepo.GetHabitualLocations(opts)
+	})
 }
 
 // GetPeriodicLocations retrieves locations with periodic visit patterns
-func (s *StatsService) GetPeriodicLocations(limit int) ([]models.RevisitPattern, error) {
-	return s.statsRepo.GetPeriodicLocations(limit)
+func (s *StatsService) GetPeriodicLocations(opts models.QueryOptions) ([]models.RevisitPattern, int64, error) {
+	return loadPage(s.cache, cache.Key("periodic_locations", opts), []string{"revisit_pattern"}, func() ([]models.RevisitPattern, int64, error) {
+		return s.statsRepo.GetPeriodicLocations(opts)
+	})
 }
 
 // GetSpatialUtilization retrieves utilization stats with filters
 func (s *StatsService) GetSpatialUtilization(
 	bucketType string,
+	source string,
 	areaType string,
 	areaKey string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	return s.statsRepo.GetSpatialUtilization(bucketType, areaType, areaKey, limit)
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	return loadPage(s.cache, cache.Key("spatial_utilization", bucketType, source, areaType, areaKey, opts), []string{"utilization_efficiency"}, func() ([]models.SpatialUtilization, int64, error) {
+		return s.statsRepo.GetSpatialUtilization(bucketType, source, areaType, areaKey, opts)
+	})
 }
 
 // GetDestinationAreas retrieves areas with high utilization efficiency
 func (s *StatsService) GetDestinationAreas(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	return s.statsRepo.GetDestinationAreas(bucketType, areaType, limit)
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	return loadPage(s.cache, cache.Key("destination_areas", bucketType, source, areaType, opts), []string{"utilization_efficiency"}, func() ([]models.SpatialUtilization, int64, error) {
+		return s.statsRepo.GetDestinationAreas(bucketType, source, areaType, opts)
+	})
 }
 
 // GetTransitCorridors retrieves areas with high transit dominance
 func (s *StatsService) GetTransitCorridors(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	return s.statsRepo.GetTransitCorridors(bucketType, areaType, limit)
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	return loadPage(s.cache, cache.Key("transit_corridors", bucketType, source, areaType, opts), []string{"utilization_efficiency"}, func() ([]models.SpatialUtilization, int64, error) {
+		return s.statsRepo.GetTransitCorridors(bucketType, source, areaType, opts)
+	})
 }
 
 // GetDeepEngagementAreas retrieves areas with high area depth
 func (s *StatsService) GetDeepEngagementAreas(
 	bucketType string,
+	source string,
 	areaType string,
-	limit int,
-) ([]models.SpatialUtilization, error) {
-	return s.statsRepo.GetDeepEngagementAreas(bucketType, areaType, limit)
+	opts models.QueryOptions,
+) ([]models.SpatialUtilization, int64, error) {
+	return loadPage(s.cache, cache.Key("deep_engagement_areas", bucketType, source, areaType, opts), []string{"utilization_efficiency"}, func() ([]models.SpatialUtilization, int64, error) {
+		return s.statsRepo.GetDeepEngagementAreas(bucketType, source, areaType, opts)
+	})
 }
 
 // GetDensityGrids retrieves density grids with filters
 func (s *StatsService) GetDensityGrids(
 	bucketType string,
+	source string,
 	densityLevel string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return s.statsRepo.GetDensityGrids(bucketType, densityLevel, limit)
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return loadPage(s.cache, cache.Key("density_grids", bucketType, source, densityLevel, opts), []string{"density_structure"}, func() ([]models.SpatialDensityGrid, int64, error) {
+		return s.statsRepo.GetDensityGrids(bucketType, source, densityLevel, opts)
+	})
 }
 
 // GetCoreAreas retrieves core density areas
 func (s *StatsService) GetCoreAreas(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return s.statsRepo.GetCoreAreas(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return loadPage(s.cache, cache.Key("core_areas", bucketType, source, opts), []string{"density_structure"}, func() ([]models.SpatialDensityGrid, int64, error) {
+		return s.statsRepo.GetCoreAreas(bucketType, source, opts)
+	})
 }
 
 // GetRareVisits retrieves rare visit locations
 func (s *StatsService) GetRareVisits(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return s.statsRepo.GetRareVisits(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return loadPage(s.cache, cache.Key("rare_visits", bucketType, source, opts), []string{"density_structure"}, func() ([]models.SpatialDensityGrid, int64, error) {
+		return s.statsRepo.GetRareVisits(bucketType, source, opts)
+	})
 }
 
 // GetDensityClusters retrieves density clusters
 func (s *StatsService) GetDensityClusters(
 	bucketType string,
-	limit int,
-) ([]models.SpatialDensityGrid, error) {
-	return s.statsRepo.GetDensityClusters(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.SpatialDensityGrid, int64, error) {
+	return loadPage(s.cache, cache.Key("density_clusters", bucketType, source, opts), []string{"density_structure"}, func() ([]models.SpatialDensityGrid, int64, error) {
+		return s.statsRepo.GetDensityClusters(bucketType, source, opts)
+	})
 }
 
 // GetAltitudeStats retrieves altitude statistics with filters
 func (s *StatsService) GetAltitudeStats(
 	bucketType string,
+	source string,
 	areaType string,
 	areaKey string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	return s.statsRepo.GetAltitudeStats(bucketType, areaType, areaKey, limit)
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	return loadPage(s.cache, cache.Key("altitude_stats", bucketType, source, areaType, areaKey, opts), []string{"altitude_stats"}, func() ([]models.AltitudeStats, int64, error) {
+		return s.statsRepo.GetAltitudeStats(bucketType, source, areaType, areaKey, opts)
+	})
 }
 
 // GetHighestAltitudeSpans retrieves areas with highest altitude spans
 func (s *StatsService) GetHighestAltitudeSpans(
 	bucketType string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	return s.statsRepo.GetHighestAltitudeSpans(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	return loadPage(s.cache, cache.Key("highest_altitude_spans", bucketType, source, opts), []string{"altitude_stats"}, func() ([]models.AltitudeStats, int64, error) {
+		return s.statsRepo.GetHighestAltitudeSpans(bucketType, source, opts)
+	})
 }
 
 // GetHighestVerticalIntensity retrieves areas with highest vertical intensity
 func (s *StatsService) GetHighestVerticalIntensity(
 	bucketType string,
-	limit int,
-) ([]models.AltitudeStats, error) {
-	return s.statsRepo.GetHighestVerticalIntensity(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.AltitudeStats, int64, error) {
+	return loadPage(s.cache, cache.Key("highest_vertical_intensity", bucketType, source, opts), []string{"altitude_stats"}, func() ([]models.AltitudeStats, int64, error) {
+		return s.statsRepo.GetHighestVerticalIntensity(bucketType, source, opts)
+	})
 }
 
 // GetTimeSpaceCompression retrieves time-space compression stats with filters
 func (s *StatsService) GetTimeSpaceCompression(
 	bucketType string,
+	source string,
 	areaType string,
 	areaKey string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	return s.statsRepo.GetTimeSpaceCompression(bucketType, areaType, areaKey, limit)
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	return loadPage(s.cache, cache.Key("time_space_compression", bucketType, source, areaType, areaKey, opts), []string{"movement_intensity"}, func() ([]models.TimeSpaceCompression, int64, error) {
+		return s.statsRepo.GetTimeSpaceCompression(bucketType, source, areaType, areaKey, opts)
+	})
 }
 
 // GetHighestMovementIntensity retrieves areas with highest movement intensity
 func (s *StatsService) GetHighestMovementIntensity(
 	bucketType string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	return s.statsRepo.GetHighestMovementIntensity(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	return loadPage(s.cache, cache.Key("highest_movement_intensity", bucketType, source, opts), []string{"movement_intensity"}, func() ([]models.TimeSpaceCompression, int64, error) {
+		return s.statsRepo.GetHighestMovementIntensity(bucketType, source, opts)
+	})
 }
 
 // GetBurstPeriods retrieves areas with most burst periods
 func (s *StatsService) GetBurstPeriods(
 	bucketType string,
-	limit int,
-) ([]models.TimeSpaceCompression, error) {
-	return s.statsRepo.GetBurstPeriods(bucketType, limit)
+	source string,
+	opts models.QueryOptions,
+) ([]models.TimeSpaceCompression, int64, error) {
+	return loadPage(s.cache, cache.Key("burst_periods", bucketType, source, opts), []string{"movement_intensity"}, func() ([]models.TimeSpaceCompression, int64, error) {
+		return s.statsRepo.GetBurstPeriods(bucketType, source, opts)
+	})
+}
+
+// GetModeBreakdown retrieves per-mode distance, duration, trip and CO2 totals per time bucket
+func (s *StatsService) GetModeBreakdown(
+	bucketType string,
+	bucketKey string,
+	source string,
+	mode string,
+	opts models.QueryOptions,
+) ([]models.ModeStats, int64, error) {
+	return loadPage(s.cache, cache.Key("mode_breakdown", bucketType, bucketKey, source, mode, opts), []string{"mode_stats"}, func() ([]models.ModeStats, int64, error) {
+		return s.statsRepo.GetModeBreakdown(bucketType, bucketKey, source, mode, opts)
+	})
+}
+
+// GetDaypartStats retrieves distance, active time and visited areas per part of the day
+func (s *StatsService) GetDaypartStats(
+	bucketType string,
+	bucketKey string,
+	source string,
+	daypart string,
+	opts models.QueryOptions,
+) ([]models.DaypartStats, int64, error) {
+	return loadPage(s.cache, cache.Key("daypart_stats", bucketType, bucketKey, source, daypart, opts), []string{"daypart_stats"}, func() ([]models.DaypartStats, int64, error) {
+		return s.statsRepo.GetDaypartStats(bucketType, bucketKey, source, daypart, opts)
+	})
+}
+
+// GetDayTypeStats retrieves movement, place and stay totals of workdays, weekends and public holidays
+func (s *StatsService) GetDayTypeStats(
+	bucketType string,
+	bucketKey string,
+	dayType string,
+	opts models.QueryOptions,
+) ([]models.DayTypeStats, int64, error) {
+	return loadPage(s.cache, cache.Key("day_type_stats", bucketType, bucketKey, dayType, opts), []string{"day_type_stats"}, func() ([]models.DayTypeStats, int64, error) {
+		return s.statsRepo.GetDayTypeStats(bucketType, bucketKey, dayType, opts)
+	})
+}
+
+// CompareDayTypes compares the per-day averages of weekends and holidays with
+// those of workdays in one bucket ("all" or a year)
+func (s *StatsService) CompareDayTypes(bucketKey string) (*models.DayTypeComparison, error) {
+	bucketType := "year"
+	if bucketKey == "all" {
+		bucketType = "all"
+	}
+	return cache.Load(s.cache, cache.Key("day_type_comparison", bucketKey), []string{"day_type_stats"}, func() (*models.DayTypeComparison, error) {
+		rows, _, err := s.statsRepo.GetDayTypeStats(bucketType, bucketKey, "", models.QueryOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		result := &models.DayTypeComparison{BucketType: bucketType, BucketKey: bucketKey}
+		for i := range rows {
+			switch rows[i].DayType {
+			case calendar.DayTypeWorkday:
+				result.Workday = &rows[i]
+			case calendar.DayTypeWeekend:
+				result.Weekend = &rows[i]
+			case calendar.DayTypeHoliday:
+				result.Holiday = &rows[i]
+			}
+		}
+		if result.Workday != nil {
+			result.WeekendVsWorkday = dayTypeRatios(result.Weekend, result.Workday)
+			result.HolidayVsWorkday = dayTypeRatios(result.Holiday, result.Workday)
+		}
+		return result, nil
+	})
+}
+
+// dayTypeRatios divides the per-day averages of s by those of base; nil without s
+func dayTypeRatios(s, base *models.DayTypeStats) *models.DayTypeRatios {
+	if s == nil {
+		return nil
+	}
+	ratio := func(v, b float64) *float64 {
+		if b == 0 {
+			return nil
+		}
+		r := v / b
+		return &r
+	}
+	return &models.DayTypeRatios{
+		AvgDistance:   ratio(s.AvgDistanceM, base.AvgDistanceM),
+		AvgActiveTime: ratio(s.AvgActiveTimeS, base.AvgActiveTimeS),
+		AvgTrips:      ratio(s.AvgTripCount, base.AvgTripCount),
+		AvgCities:     ratio(s.AvgCityCount, base.AvgCityCount),
+	}
+}
+
+// GetAnomalousDays retrieves the days that stand out from their baseline
+func (s *StatsService) GetAnomalousDays(
+	filter models.AnomalousDayFilter,
+	opts models.QueryOptions,
+) ([]models.AnomalousDay, int64, error) {
+	return loadPage(s.cache, cache.Key("anomalous_days", filter, opts), []string{"anomalous_days"}, func() ([]models.AnomalousDay, int64, error) {
+		return s.statsRepo.GetAnomalousDays(filter, opts)
+	})
+}
+
+// GetExplorationCurve retrieves the monthly exploration curve between two
+// months (YYYY-MM, inclusive, open when empty) and its longest discovery streak
+func (s *StatsService) GetExplorationCurve(from, to string) (*models.ExplorationCurve, error) {
+	return cache.Load(s.cache, cache.Key("exploration_curve", from, to), []string{"exploration"}, func() (*models.ExplorationCurve, error) {
+		months, err := s.statsRepo.GetExplorationMonths(from, to)
+		if err != nil {
+			return nil, err
+		}
+		curve := &models.ExplorationCurve{Months: months}
+		if len(months) > 0 {
+			curve.TotalCells = months[len(months)-1].CumulativeCellCount
+		}
+
+		// Month bounds as day bounds; "-31" sorts after every day of the month
+		fromDay, toDay := "", ""
+		if from != "" {
+			fromDay = from + "-01"
+		}
+		if to != "" {
+			toDay = to + "-31"
+		}
+		streaks, _, err := s.statsRepo.GetDiscoveryStreaks(fromDay, toDay, models.QueryOptions{Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(streaks) > 0 {
+			curve.LongestStreak = &streaks[0]
+		}
+		return curve, nil
+	})
+}
+
+// GetDiscoveryStreaks retrieves runs of consecutive days that each visited a new grid cell
+func (s *StatsService) GetDiscoveryStreaks(from, to string, opts models.QueryOptions) ([]models.DiscoveryStreak, int64, error) {
+	return loadPage(s.cache, cache.Key("discovery_streaks", from, to, opts), []string{"exploration"}, func() ([]models.DiscoveryStreak, int64, error) {
+		return s.statsRepo.GetDiscoveryStreaks(from, to, opts)
+	})
+}
+
+// GetFirstVisits retrieves the first visits of each city or county in
+// chronological order; from and to (YYYY-MM-DD, local, inclusive) bound them when given
+func (s *StatsService) GetFirstVisits(level, from, to string, opts models.QueryOptions) ([]models.FirstVisit, int64, error) {
+	var startTime, endTime int64
+	if from != "" {
+		day, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid from date: %w", err)
+		}
+		startTime = day.Unix()
+	}
+	if to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid to date: %w", err)
+		}
+		endTime = day.AddDate(0, 0, 1).Unix()
+	}
+
+	return loadPage(s.cache, cache.Key("first_visits", level, from, to, opts), []string{"footprint_statistics", "admin_crossings", "trip_construction"}, func() ([]models.FirstVisit, int64, error) {
+		return s.statsRepo.GetFirstVisits(level, startTime, endTime, opts)
+	})
+}
+
+// GetPersonalRecords retrieves the personal records
+func (s *StatsService) GetPersonalRecords() ([]models.PersonalRecord, error) {
+	return cache.Load(s.cache, cache.Key("personal_records"), []string{"personal_records"}, func() ([]models.PersonalRecord, error) {
+		return s.statsRepo.GetPersonalRecords()
+	})
+}
+
+// CompareBuckets compares the footprint, speed-space, compression and
+// altitude statistics of two buckets of the same type (year or month)
+func (s *StatsService) CompareBuckets(bucketType, bucketA, bucketB string) (*models.BucketComparison, error) {
+	tags := []string{"footprint_statistics", "speed_space_coupling", "movement_intensity", "altitude_stats"}
+	return cache.Load(s.cache, cache.Key("bucket_comparison", bucketType, bucketA, bucketB), tags, func() (*models.BucketComparison, error) {
+		sections := []func(key string) ([]models.MetricValue, error){
+			s.statsRepo.GetFootprintBucketMetrics,
+			func(key string) ([]models.MetricValue, error) {
+				return s.statsRepo.GetSpeedSpaceBucketMetrics(bucketType, key)
+			},
+			func(key string) ([]models.MetricValue, error) {
+				return s.statsRepo.GetCompressionBucketMetrics(bucketType, key)
+			},
+			func(key string) ([]models.MetricValue, error) {
+				return s.statsRepo.GetAltitudeBucketMetrics(bucketType, key)
+			},
+		}
+
+		compared := make([][]models.MetricComparison, len(sections))
+		for i, get := range sections {
+			a, err := get(bucketA)
+			if err != nil {
+				return nil, err
+			}
+			b, err := get(bucketB)
+			if err != nil {
+				return nil, err
+			}
+			compared[i] = compareMetrics(a, b)
+		}
+
+		return &models.BucketComparison{
+			BucketType:  bucketType,
+			BucketA:     bucketA,
+			BucketB:     bucketB,
+			Footprint:   compared[0],
+			SpeedSpace:  compared[1],
+			Compression: compared[2],
+			Altitude:    compared[3],
+		}, nil
+	})
+}
+
+// compareMetrics pairs the metrics of two buckets by name; either side may be
+// nil when its bucket has no data
+func compareMetrics(a, b []models.MetricValue) []models.MetricComparison {
+	names := a
+	if names == nil {
+		names = b
+	}
+	result := make([]models.MetricComparison, 0, len(names))
+	for i, m := range names {
+		c := models.MetricComparison{Metric: m.Metric}
+		if a != nil {
+			c.A = a[i].Value
+		}
+		if b != nil {
+			c.B = b[i].Value
+		}
+		if c.A != nil && c.B != nil {
+			delta := *c.B - *c.A
+			c.Delta = &delta
+			if *c.A != 0 {
+				pct := delta / math.Abs(*c.A) * 100
+				c.ChangePct = &pct
+			}
+		}
+		result = append(result, c)
+	}
+	return result
 }
 
 // GetTimeSpaceSlices retrieves time-space slices with filters
 func (s *StatsService) GetTimeSpaceSlices(
 	sliceType string,
-	limit int,
-) ([]models.TimeSpaceSlice, error) {
-	return s.statsRepo.GetTimeSpaceSlices(sliceType, limit)
+	opts models.QueryOptions,
+) ([]models.TimeSpaceSlice, int64, error) {
+	return loadPage(s.cache, cache.Key("time_space_slices", sliceType, opts), []string{"time_space_slicing"}, func() ([]models.TimeSpaceSlice, int64, error) {
+		return s.statsRepo.GetTimeSpaceSlices(sliceType, opts)
+	})
 }
 
 // GetWeeklyPattern retrieves weekly-hourly pattern
 func (s *StatsService) GetWeeklyPattern() ([]models.TimeSpaceSlice, error) {
-	return s.statsRepo.GetWeeklyPattern()
+	return cache.Load(s.cache, cache.Key("weekly_pattern"), []string{"time_space_slicing"}, func() ([]models.TimeSpaceSlice, error) {
+		return s.statsRepo.GetWeeklyPattern()
+	})
 }
 
 // GetHourlyPattern retrieves hourly pattern
 func (s *StatsService) GetHourlyPattern() ([]models.TimeSpaceSlice, error) {
-	return s.statsRepo.GetHourlyPattern()
+	return cache.Load(s.cache, cache.Key("hourly_pattern"), []string{"time_space_slicing"}, func() ([]models.TimeSpaceSlice, error) {
+		return s.statsRepo.GetHourlyPattern()
+	})
 }
 
-// GetSpatialComplexity retrieves spatial complexity metrics
-func (s *StatsService) GetSpatialComplexity() (*models.SpatialComplexity, error) {
-	return s.statsRepo.GetSpatialComplexity()
+// GetSpatialComplexity retrieves a page of the spatial complexity time series of a period type
+func (s *StatsService) GetSpatialComplexity(periodType, from, to string, opts models.QueryOptions) ([]models.SpatialComplexity, int64, error) {
+	return loadPage(s.cache, cache.Key("spatial_complexity", periodType, from, to, opts), []string{"spatial_complexity"}, func() ([]models.SpatialComplexity, int64, error) {
+		return s.statsRepo.GetSpatialComplexity(periodType, from, to, opts)
+	})
+}
+
+// GetRailLineStats retrieves the mileage of each high-speed rail line, for
+// one year or all years when year is 0
+func (s *StatsService) GetRailLineStats(year int) (*models.RailLineStats, error) {
+	lines, err := cache.Load(s.cache, cache.Key("rail_line_mileage", year), []string{"rail_lines"}, func() ([]models.RailLineMileage, error) {
+		return s.statsRepo.GetRailLineMileage(year)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.RailLineStats{Year: year, Lines: lines}
+	for _, line := range lines {
+		stats.TotalDistanceKm += line.DistanceKm
+	}
+	return stats, nil
 }
 
 // GetRoadOverlapSummary retrieves road overlap summary
 func (s *StatsService) GetRoadOverlapSummary() (*models.RoadOverlapSummary, error) {
-	return s.statsRepo.GetRoadOverlapSummary()
+	return cache.Load(s.cache, cache.Key("road_overlap_summary"), []string{"road_overlap"}, func() (*models.RoadOverlapSummary, error) {
+		return s.statsRepo.GetRoadOverlapSummary()
+	})
+}
+
+// GetCommuteStats retrieves commute patterns with per-direction totals
+func (s *StatsService) GetCommuteStats(direction string) (*models.CommuteStats, error) {
+	if direction != "" && direction != "HOME_TO_WORK" && direction != "WORK_TO_HOME" {
+		return nil, fmt.Errorf("invalid direction: %s (must be HOME_TO_WORK or WORK_TO_HOME)", direction)
+	}
+
+	patterns, err := cache.Load(s.cache, cache.Key("commute_patterns", direction), []string{"commute"}, func() ([]models.CommutePattern, error) {
+		return s.statsRepo.GetCommutePatterns(direction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Trip-weighted averages per direction
+	directions := make(map[string]models.CommuteDirectionSummary)
+	for _, p := range patterns {
+		summary := directions[p.Direction]
+		weight := float64(p.TripCount)
+		summary.AvgDurationS += p.AvgDurationS * weight
+		summary.AvgDistanceM += p.AvgDistanceM * weight
+		summary.RouteVariability += p.RouteVariability * weight
+		summary.TripCount += p.TripCount
+		directions[p.Direction] = summary
+	}
+	for direction, summary := range directions {
+		if summary.TripCount > 0 {
+			n := float64(summary.TripCount)
+			summary.AvgDurationS /= n
+			summary.AvgDistanceM /= n
+			summary.RouteVariability /= n
+		}
+		directions[direction] = summary
+	}
+
+	if patterns == nil {
+		patterns = []models.CommutePattern{}
+	}
+
+	return &models.CommuteStats{
+		Patterns:   patterns,
+		Directions: directions,
+	}, nil
+}
+
+// maxRouteClusters caps the page size of frequent routes
+const maxRouteClusters = 500
+
+// GetRouteClusters retrieves a page of frequent routes
+// Pages hold at most maxRouteClusters routes, whose polylines are large.
+func (s *StatsService) GetRouteClusters(filter models.RouteClusterFilter, opts models.QueryOptions) ([]models.RouteCluster, int64, error) {
+	opts.Limit = min(opts.Limit, maxRouteClusters)
+	includePolyline := filter.IncludePolyline == nil || *filter.IncludePolyline
+	key := cache.Key("route_clusters", filter.Mode, filter.MinTrips, includePolyline, opts)
+	return loadPage(s.cache, key, []string{"route_clustering"}, func() ([]models.RouteCluster, int64, error) {
+		return s.statsRepo.GetRouteClusters(filter, opts)
+	})
+}
+
+const (
+	// MaxHealthCorrelationDays caps the date range of a health correlation query
+	MaxHealthCorrelationDays = 732
+	// defaultHealthCorrelationDays is the range of health correlation queries without dates
+	defaultHealthCorrelationDays = 90
+)
+
+// GetHealthCorrelation summarizes the health × movement rows of a date range
+// Without dates the range ends at the last correlated day.
+func (s *StatsService) GetHealthCorrelation(from, to string) (*models.HealthCorrelationStats, error) {
+	from, to, err := resolveDayRange(from, to, defaultHealthCorrelationDays, s.statsRepo.GetLatestHealthCorrelationDate)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := cache.Load(s.cache, cache.Key("health_correlations", from, to), []string{"health_correlation"}, func() ([]models.HealthMovementCorrelation, error) {
+		return s.statsRepo.GetHealthCorrelations(from, to)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.HealthCorrelationStats{From: from, To: to, Days: len(days), Daily: days}
+
+	var steps, walked []float64
+	var stepRatioSum float64
+	var stepRatioDays int
+	var highSum, lowSum float64
+	var workoutDistance, restDistance float64
+	for _, d := range days {
+		if d.RecordedSteps > 0 && d.WalkDistanceM > 0 {
+			steps = append(steps, float64(d.RecordedSteps))
+			walked = append(walked, d.WalkDistanceM)
+		}
+		if d.StepRatio != nil {
+			stepRatioSum += *d.StepRatio
+			stepRatioDays++
+		}
+		if d.HighAltitudeAvgHR != nil {
+			highSum += *d.HighAltitudeAvgHR * float64(d.HighAltitudeHRSamples)
+			result.HighAltitudeHRSamples += d.HighAltitudeHRSamples
+		}
+		if d.LowAltitudeAvgHR != nil {
+			lowSum += *d.LowAltitudeAvgHR * float64(d.LowAltitudeHRSamples)
+			result.LowAltitudeHRSamples += d.LowAltitudeHRSamples
+		}
+		if d.WorkoutCount > 0 {
+			result.WorkoutDays++
+			workoutDistance += d.MovedDistanceM
+		} else {
+			restDistance += d.MovedDistanceM
+		}
+	}
+
+	if len(steps) > 0 {
+		var totalSteps, totalWalked float64
+		for i := range steps {
+			totalSteps += steps[i]
+			totalWalked += walked[i]
+		}
+		metersPerStep := totalWalked / totalSteps
+		result.AvgMetersPerStep = &metersPerStep
+	}
+	if stepRatioDays > 0 {
+		stepRatio := stepRatioSum / float64(stepRatioDays)
+		result.AvgStepRatio = &stepRatio
+	}
+	// Pearson r is undefined when either series is constant
+	if len(steps) >= 3 && stats.Variance(steps) > 0 && stats.Variance(walked) > 0 {
+		r := stats.PearsonCorrelation(walked, steps)
+		result.StepDistanceCorrelation = &r
+	}
+	if result.HighAltitudeHRSamples > 0 {
+		avg := highSum / float64(result.HighAltitudeHRSamples)
+		result.HighAltitudeAvgHR = &avg
+	}
+	if result.LowAltitudeHRSamples > 0 {
+		avg := lowSum / float64(result.LowAltitudeHRSamples)
+		result.LowAltitudeAvgHR = &avg
+	}
+	if result.WorkoutDays > 0 {
+		result.WorkoutDayAvgDistanceM = workoutDistance / float64(result.WorkoutDays)
+	}
+	if restDays := len(days) - result.WorkoutDays; restDays > 0 {
+		result.RestDayAvgDistanceM = restDistance / float64(restDays)
+	}
+
+	return result, nil
+}
+
+// GetDataVersion returns the current version of the given statistics tables,
+// for conditional requests (not cached, so it reflects the tables as they are)
+func (s *StatsService) GetDataVersion(tables ...string) (string, error) {
+	return s.statsRepo.GetTableVersion(tables...)
 }
@@ -24,3 +24,8 @@ func (s *StayService) GetStays(filter models.StayFilter) ([]models.StaySegment,
 func (s *StayService) GetStayByID(id int64) (*models.StaySegment, error) {
 	return s.repo.GetStayByID(id)
 }
+
+// GetLabeledStaysInRange returns the longest stays overlapping [start, end) with their labels
+func (s *StayService) GetLabeledStaysInRange(start, end int64, limit int) ([]models.DashboardStay, error) {
+	return s.repo.GetLabeledStaysInRange(start, end, limit)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/calendar"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+const (
+	// MaxTimelineDays caps a single timeline request (two leap years)
+	MaxTimelineDays = 732
+	// defaultTimelineDays is the range returned when no dates are given
+	defaultTimelineDays = 365
+)
+
+// SummaryService handles daily summary business logic
+type SummaryService struct {
+	repo *repository.SummaryRepository
+}
+
+// NewSummaryService creates a new summary service
+func NewSummaryService(repo *repository.SummaryRepository) *SummaryService {
+	return &SummaryService{repo: repo}
+}
+
+// GetDailyTimeline returns one entry per day in the range, filling days
+// without data with empty entries so the result maps directly onto a calendar
+// Missing bounds default to the year ending at the last summarized day.
+func (s *SummaryService) GetDailyTimeline(filter models.DailySummaryFilter) (*models.DailyTimeline, error) {
+	to, err := s.resolveEnd(filter.To)
+	if err != nil {
+		return nil, err
+	}
+
+	var from time.Time
+	if filter.From != "" {
+		from, err = time.ParseInLocation("2006-01-02", filter.From, time.Local)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		from = to.AddDate(0, 0, -(defaultTimelineDays - 1))
+	}
+	if filter.To == "" && from.After(to) {
+		to = from.AddDate(0, 0, defaultTimelineDays-1)
+	}
+	if last := from.AddDate(0, 0, MaxTimelineDays-1); to.After(last) {
+		to = last
+	}
+
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+	stored, err := s.repo.GetDailySummaries(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]models.DailySummary, len(stored))
+	for _, d := range stored {
+		byDate[d.Date] = d
+	}
+
+	timeline := &models.DailyTimeline{
+		From: fromStr,
+		To:   toStr,
+		Days: []models.DailySummary{},
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		d, ok := byDate[date]
+		if !ok {
+			d = models.DailySummary{
+				Date:           date,
+				Weekday:        int(day.Weekday()),
+				DayType:        calendar.DayType(day),
+				DistanceByMode: map[string]float64{},
+				Provinces:      []string{},
+				Cities:         []string{},
+			}
+		} else if d.PointCount > 0 || d.TotalDistanceM > 0 {
+			timeline.ActiveDays++
+		}
+
+		timeline.TotalDistanceM += d.TotalDistanceM
+		timeline.TotalSteps += d.StepEquivalent
+		if d.TotalDistanceM > timeline.MaxDistanceM {
+			timeline.MaxDistanceM = d.TotalDistanceM
+		}
+		timeline.Days = append(timeline.Days, d)
+	}
+
+	return timeline, nil
+}
+
+// resolveEnd parses the end date, defaulting to the last summarized day (or today)
+func (s *SummaryService) resolveEnd(to string) (time.Time, error) {
+	if to == "" {
+		latest, err := s.repo.GetLatestSummaryDate()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if latest == "" {
+			now := time.Now()
+			return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+		}
+		to = latest
+	}
+	return time.ParseInLocation("2006-01-02", to, time.Local)
+}
+
+// GetDay returns the stored summary of one day, or nil if it was not summarized
+func (s *SummaryService) GetDay(date string) (*models.DailySummary, error) {
+	days, err := s.repo.GetDailySummaries(date, date)
+	if err != nil || len(days) == 0 {
+		return nil, err
+	}
+	return &days[0], nil
+}
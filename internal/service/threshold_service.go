@@ -0,0 +1,180 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jengzang/records-backend-go/internal/analysis"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// ThresholdService handles business logic for threshold profiles
+type ThresholdService struct {
+	repo *repository.ThresholdRepository
+}
+
+// NewThresholdService creates a new threshold service
+func NewThresholdService(repo *repository.ThresholdRepository) *ThresholdService {
+	return &ThresholdService{repo: repo}
+}
+
+// ListProfiles retrieves all threshold profiles
+func (s *ThresholdService) ListProfiles() ([]models.ThresholdProfile, error) {
+	return s.repo.List()
+}
+
+// GetProfile retrieves a threshold profile by ID
+func (s *ThresholdService) GetProfile(id int64) (*models.ThresholdProfile, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetEffectiveThresholds returns a profile's params merged over the default profile
+// This is what analyzers see when a task uses the profile; id 0 means the default profile
+func (s *ThresholdService) GetEffectiveThresholds(id int64) (*models.EffectiveThresholds, error) {
+	defaultProfile, err := s.repo.GetDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{}
+	result := &models.EffectiveThresholds{}
+	if defaultProfile != nil {
+		if params, err = parseThresholdParams(defaultProfile.ParamsJSON); err != nil {
+			return nil, err
+		}
+		result.ProfileID = defaultProfile.ID
+		result.ProfileName = defaultProfile.Name
+	}
+
+	if id > 0 && (defaultProfile == nil || id != defaultProfile.ID) {
+		profile, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if profile == nil {
+			return nil, nil
+		}
+		override, err := parseThresholdParams(profile.ParamsJSON)
+		if err != nil {
+			return nil, err
+		}
+		params = analysis.MergeThresholdParams(params, override)
+		result.ProfileID = profile.ID
+		result.ProfileName = profile.Name
+	}
+
+	result.Params = params
+	return result, nil
+}
+
+// CreateProfile creates a new threshold profile
+func (s *ThresholdService) CreateProfile(req models.ThresholdProfileRequest) (*models.ThresholdProfile, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if req.Params == nil {
+		req.Params = map[string]interface{}{}
+	}
+
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize params: %w", err)
+	}
+
+	description := ""
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	id, err := s.repo.Create(req.Name, description, string(paramsJSON))
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// UpdateProfile updates a threshold profile, merging params unless req.Replace is set
+func (s *ThresholdService) UpdateProfile(id int64, req models.ThresholdProfileRequest) (*models.ThresholdProfile, error) {
+	profile, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	params := req.Params
+	if !req.Replace {
+		existing, err := parseThresholdParams(profile.ParamsJSON)
+		if err != nil {
+			return nil, err
+		}
+		params = analysis.MergeThresholdParams(existing, req.Params)
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize params: %w", err)
+	}
+
+	description := profile.Description
+	if req.Description != nil {
+		description = *req.Description
+	}
+
+	if err := s.repo.Update(id, description, string(paramsJSON)); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// SetDefaultProfile makes a threshold profile the active default profile
+func (s *ThresholdService) SetDefaultProfile(id int64) (*models.ThresholdProfile, error) {
+	profile, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return nil, nil
+	}
+
+	if err := s.repo.SetDefault(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// ActivateProfile makes the threshold profile named name the default
+// profile, unless it already is
+func (s *ThresholdService) ActivateProfile(name string) error {
+	profiles, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		if profile.Name != name {
+			continue
+		}
+		if profile.IsDefault {
+			return nil
+		}
+		return s.repo.SetDefault(profile.ID)
+	}
+	return fmt.Errorf("threshold profile not found: %s", name)
+}
+
+// parseThresholdParams parses a profile's params JSON object
+func parseThresholdParams(paramsJSON string) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	if paramsJSON == "" {
+		return params, nil
+	}
+	if err := json.Unmarshal([]byte(paramsJSON), &params); err != nil {
+		return nil, fmt.Errorf("failed to parse threshold params: %w", err)
+	}
+	return params, nil
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"strings"
 
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
@@ -79,3 +81,49 @@ func (s *TrackService) GetUngeocodedPoints(limit int) ([]models.TrackPoint, erro
 
 	return points, nil
 }
+
+// ResolveTrackPointFields normalizes a cursor filter and resolves its field selection
+// The id field is always returned first since it is the pagination cursor
+func (s *TrackService) ResolveTrackPointFields(filter *models.TrackPointCursorFilter) ([]string, error) {
+	if filter.AfterID < 0 {
+		filter.AfterID = 0
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 1000
+	}
+	if filter.Limit > 10000 {
+		filter.Limit = 10000
+	}
+
+	fields := []string{"id"}
+	requested := models.DefaultTrackPointFields
+	if filter.Fields != "" {
+		requested = strings.Split(filter.Fields, ",")
+	}
+	for _, field := range requested {
+		field = strings.TrimSpace(field)
+		if field == "" || field == "id" {
+			continue
+		}
+		if _, ok := models.TrackPointFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// StreamTrackPoints streams track points matching a cursor filter, calling fn for each row
+// fields must come from ResolveTrackPointFields
+func (s *TrackService) StreamTrackPoints(ctx context.Context, filter models.TrackPointCursorFilter, fields []string, fn func(values []interface{}) error) error {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = models.TrackPointFields[field]
+	}
+
+	if err := s.trackRepo.StreamTrackPoints(ctx, filter, columns, fn); err != nil {
+		return fmt.Errorf("failed to stream track points: %w", err)
+	}
+	return nil
+}
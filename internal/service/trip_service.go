@@ -1,18 +1,30 @@
 package service
 
 import (
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
+// tripConstructionSkill is the analyzer building trips, recomputed after trip edits
+const tripConstructionSkill = "trip_construction"
+
 // TripService handles business logic for trips
 type TripService struct {
-	repo *repository.TripRepository
+	repo                *repository.TripRepository
+	privacy             *PrivacyService
+	analysisTaskService *AnalysisTaskService
 }
 
 // NewTripService creates a new trip service
-func NewTripService(repo *repository.TripRepository) *TripService {
-	return &TripService{repo: repo}
+// Trip routes (and their GPX exports) leave out or fuzz points inside privacy
+// zones; trip edits queue a trip construction recompute to apply them.
+func NewTripService(repo *repository.TripRepository, privacy *PrivacyService, analysisTaskService *AnalysisTaskService) *TripService {
+	return &TripService{repo: repo, privacy: privacy, analysisTaskService: analysisTaskService}
 }
 
 // GetTrips retrieves trips with filtering and pagination
@@ -24,3 +36,180 @@ func (s *TripService) GetTrips(filter models.TripFilter) ([]models.Trip, int64,
 func (s *TripService) GetTripByID(id int64) (*models.Trip, error) {
 	return s.repo.GetTripByID(id)
 }
+
+// GetODMatrix aggregates trips into an origin-destination matrix by city or county
+func (s *TripService) GetODMatrix(filter models.ODMatrixFilter) (*models.ODMatrix, error) {
+	if filter.Level == "" {
+		filter.Level = models.ODLevelCity
+	}
+	if filter.Level != models.ODLevelCity && filter.Level != models.ODLevelCounty {
+		return nil, fmt.Errorf("invalid level: %s (must be city or county)", filter.Level)
+	}
+	return s.repo.GetODMatrix(filter)
+}
+
+// GetTripRoute retrieves the reconstructed point sequence of a trip
+func (s *TripService) GetTripRoute(id int64) (*models.TripRoute, error) {
+	route, err := s.repo.GetTripRoute(id)
+	if err != nil || route == nil {
+		return route, err
+	}
+
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if !zones.Empty() {
+		segments := route.Segments[:0]
+		for _, seg := range route.Segments {
+			points := seg.Points[:0]
+			for _, p := range seg.Points {
+				lat, lon, ok := zones.Apply(p.Latitude, p.Longitude)
+				if !ok {
+					continue
+				}
+				p.Latitude, p.Longitude = lat, lon
+				points = append(points, p)
+			}
+			if len(points) == 0 {
+				continue
+			}
+			seg.Points = points
+			segments = append(segments, seg)
+		}
+		route.Segments = segments
+	}
+
+	for i := range route.Segments {
+		if route.Segments[i].Mode == models.ModeFlight {
+			route.Segments[i].Path = flightPath(route.Segments[i].Points)
+		}
+	}
+	return route, nil
+}
+
+// flightPathStepM is the vertex spacing of great-circle flight paths
+const flightPathStepM = 50000
+
+// flightPath joins the points of a flight along great circles, so that gaps
+// without fixes are drawn as the arc flown instead of a straight line
+func flightPath(points []models.TrackPoint) [][2]float64 {
+	var path [][2]float64
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		arc := spatial.GreatCirclePath(a.Latitude, a.Longitude, b.Latitude, b.Longitude, flightPathStepM)
+		if i > 1 {
+			arc = arc[1:]
+		}
+		path = append(path, arc...)
+	}
+	return path
+}
+
+// SplitTrip splits a trip in two before its first segment starting at or
+// after req.Time, returning nil if the trip does not exist
+func (s *TripService) SplitTrip(id int64, req models.TripSplitRequest) (*models.TripEditResult, error) {
+	trip, err := s.repo.GetTripByID(id)
+	if err != nil || trip == nil {
+		return nil, err
+	}
+	starts, err := s.repo.GetTripSegmentStarts(trip)
+	if err != nil {
+		return nil, err
+	}
+
+	// The split takes effect at the first segment starting at or after the
+	// time, which must leave segments on both sides
+	splitAt := int64(0)
+	for i, start := range starts {
+		if start >= req.Time {
+			if i > 0 {
+				splitAt = start
+			}
+			break
+		}
+	}
+	if splitAt == 0 {
+		return nil, fmt.Errorf("%w: time must fall after the first segment and no later than the last segment of trip %d", models.ErrInvalidTripEdit, id)
+	}
+
+	edit := &models.TripEdit{Action: models.TripEditSplit, SplitTime: splitAt, Note: req.Note}
+	return s.createEdit(edit)
+}
+
+// MergeTrips merges consecutive trips into one, returning nil if a trip does
+// not exist
+func (s *TripService) MergeTrips(req models.TripMergeRequest) (*models.TripEditResult, error) {
+	seen := make(map[int64]bool, len(req.TripIDs))
+	var first, last *models.Trip
+	for _, id := range req.TripIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		trip, err := s.repo.GetTripByID(id)
+		if err != nil || trip == nil {
+			return nil, err
+		}
+		if first == nil || trip.StartTime < first.StartTime {
+			first = trip
+		}
+		if last == nil || trip.StartTime > last.StartTime {
+			last = trip
+		}
+	}
+	if len(seen) < 2 {
+		return nil, fmt.Errorf("%w: at least two trips are needed", models.ErrInvalidTripEdit)
+	}
+
+	// Another trip between the first and the last would be swallowed too
+	count, err := s.repo.CountTripsStartingBetween(first.StartTime, last.StartTime)
+	if err != nil {
+		return nil, err
+	}
+	if count != len(seen) {
+		return nil, fmt.Errorf("%w: trips must follow each other, %d other trips lie between them", models.ErrInvalidTripEdit, count-len(seen))
+	}
+
+	edit := &models.TripEdit{Action: models.TripEditMerge, FromTime: first.StartTime, ToTime: last.StartTime, Note: req.Note}
+	return s.createEdit(edit)
+}
+
+// GetTripEdits retrieves the trip edits, oldest first
+func (s *TripService) GetTripEdits() ([]models.TripEdit, error) {
+	return s.repo.GetTripEdits()
+}
+
+// DeleteTripEdit removes a trip edit so trips are constructed without it,
+// returning nil if the edit does not exist
+func (s *TripService) DeleteTripEdit(id int64) (*models.TripEditResult, error) {
+	edit, err := s.repo.GetTripEdit(id)
+	if err != nil || edit == nil {
+		return nil, err
+	}
+	if err := s.repo.DeleteTripEdit(id); err != nil {
+		return nil, err
+	}
+	return &models.TripEditResult{Edit: *edit, TaskIDs: s.recompute()}, nil
+}
+
+// createEdit stores a trip edit and queues the recompute applying it
+func (s *TripService) createEdit(edit *models.TripEdit) (*models.TripEditResult, error) {
+	edit.CreatedAt = time.Now().Unix()
+	if err := s.repo.CreateTripEdit(edit); err != nil {
+		return nil, err
+	}
+	return &models.TripEditResult{Edit: *edit, TaskIDs: s.recompute()}, nil
+}
+
+// recompute queues a full recompute of trip construction
+// The edit stands even when the task cannot be queued (e.g. no points yet);
+// the next analysis chain picks it up.
+func (s *TripService) recompute() []int64 {
+	task, err := s.analysisTaskService.CreateTask(tripConstructionSkill, models.TaskTypeFullRecompute, nil, "trip_edits")
+	if err != nil {
+		log.Printf("Warning: failed to queue %s recompute after trip edit: %v", tripConstructionSkill, err)
+		return []int64{}
+	}
+	return []int64{task.ID}
+}
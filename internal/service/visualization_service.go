@@ -1,26 +1,127 @@
 package service
 
 import (
+	"fmt"
+
 	"github.com/jengzang/records-backend-go/internal/models"
 	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/spatial"
 )
 
 // VisualizationService handles business logic for visualization data
 type VisualizationService struct {
-	repo *repository.VisualizationRepository
+	repo    *repository.VisualizationRepository
+	privacy *PrivacyService
 }
 
 // NewVisualizationService creates a new visualization service
-func NewVisualizationService(repo *repository.VisualizationRepository) *VisualizationService {
-	return &VisualizationService{repo: repo}
+// Points inside privacy zones are excluded or fuzzed in everything it renders.
+func NewVisualizationService(repo *repository.VisualizationRepository, privacy *PrivacyService) *VisualizationService {
+	return &VisualizationService{repo: repo, privacy: privacy}
 }
 
 // GetRenderingMetadata retrieves track points with rendering properties
 func (s *VisualizationService) GetRenderingMetadata(filter models.RenderFilter) ([]models.TrackPoint, error) {
-	return s.repo.GetRenderingMetadata(filter)
+	points, err := s.repo.GetRenderingMetadata(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if zones.Empty() {
+		return points, nil
+	}
+	kept := points[:0]
+	for _, p := range points {
+		lat, lon, ok := zones.Apply(p.Latitude, p.Longitude)
+		if !ok {
+			continue
+		}
+		p.Latitude, p.Longitude = lat, lon
+		kept = append(kept, p)
+	}
+	return kept, nil
 }
 
 // GetTimeSliceData retrieves aggregated data for time axis
 func (s *VisualizationService) GetTimeSliceData(startTime, endTime int64, granularity string) (map[string]interface{}, error) {
 	return s.repo.GetTimeSliceData(startTime, endTime, granularity)
 }
+
+// Zoom levels from which the medium and high LOD polylines are served
+// The LOD tolerances (200 m, 30 m, 5 m) are about a pixel at these zooms.
+const (
+	polylineMediumLODZoom = 10
+	polylineHighLODZoom   = 14
+)
+
+// polylineLOD returns the polyline LOD drawn at a map zoom level
+func polylineLOD(zoom int) int {
+	switch {
+	case zoom >= polylineHighLODZoom:
+		return 2
+	case zoom >= polylineMediumLODZoom:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GetSegmentPolylines retrieves simplified segment geometry at the LOD for the filter's zoom
+func (s *VisualizationService) GetSegmentPolylines(filter models.PolylineFilter) (*models.PolylineResponse, error) {
+	lod := polylineLOD(filter.Zoom)
+	polylines, err := s.repo.GetSegmentPolylines(lod, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	zones, err := s.privacy.Filter()
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.Format == models.PolylineFormatCoordinates || !zones.Empty() {
+		kept := polylines[:0]
+		for _, polyline := range polylines {
+			points, err := spatial.DecodePolyline(polyline.Polyline)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode polyline of segment %d: %w", polyline.SegmentID, err)
+			}
+			if !zones.Empty() {
+				points = zones.ApplyPath(points)
+				// Nothing left to draw outside the zones
+				if len(points) < 2 {
+					continue
+				}
+				polyline.PointCount = len(points)
+				polyline.MinLat, polyline.MinLon, polyline.MaxLat, polyline.MaxLon = spatial.BoundingBox(points)
+			}
+
+			if filter.Format == models.PolylineFormatCoordinates {
+				coordinates := make([][2]float64, len(points))
+				for j, p := range points {
+					coordinates[j] = [2]float64{p.Lon, p.Lat}
+				}
+				polyline.Coordinates = coordinates
+				polyline.Polyline = ""
+			} else {
+				polyline.Polyline = spatial.EncodePolyline(points)
+			}
+			kept = append(kept, polyline)
+		}
+		polylines = kept
+	}
+
+	if polylines == nil {
+		polylines = []models.SegmentPolyline{}
+	}
+	return &models.PolylineResponse{
+		Zoom:      filter.Zoom,
+		LOD:       lod,
+		Polylines: polylines,
+		Count:     len(polylines),
+	}, nil
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/importer"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+	"github.com/jengzang/records-backend-go/internal/watcher"
+)
+
+// WatchImportService imports the track files that appear in a watch
+// directory, moves them to an archive or failed directory and triggers
+// incremental analysis for the new points
+type WatchImportService struct {
+	repo          *repository.WatchImportRepository
+	importService *ImportService
+
+	mu         sync.Mutex // Guards the watcher and directories against Reconfigure
+	archiveDir string
+	failedDir  string
+	watcher    *watcher.Watcher // nil when no watch directory is configured
+}
+
+// NewWatchImportService creates a watch import service for dir, polled every
+// interval; an empty dir disables watching
+// Processed files are moved to archiveDir (default dir/archive) under
+// year/month subdirectories, files that fail to import to dir/failed.
+func NewWatchImportService(repo *repository.WatchImportRepository, importService *ImportService, dir, archiveDir string, interval time.Duration) *WatchImportService {
+	s := &WatchImportService{repo: repo, importService: importService}
+	s.configure(dir, archiveDir, interval)
+	return s
+}
+
+// configure sets up the watcher for dir without starting it; callers hold mu
+// unless s is not shared yet
+func (s *WatchImportService) configure(dir, archiveDir string, interval time.Duration) {
+	s.watcher, s.archiveDir, s.failedDir = nil, "", ""
+	if dir == "" {
+		return
+	}
+	if archiveDir == "" {
+		archiveDir = filepath.Join(dir, "archive")
+	}
+	s.archiveDir, s.failedDir = archiveDir, filepath.Join(dir, "failed")
+	s.watcher = watcher.New(dir, interval, isTrackFile, s.importFiles)
+}
+
+// Reconfigure switches to watching dir every interval, after the files being
+// imported from the current directory; an empty dir stops watching
+// Nothing changes when the settings are the current ones.
+func (s *WatchImportService) Reconfigure(dir, archiveDir string, interval time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dir != "" && archiveDir == "" {
+		archiveDir = filepath.Join(dir, "archive")
+	}
+	if s.watcher == nil && dir == "" {
+		return nil
+	}
+	if s.watcher != nil && s.watcher.Dir() == dir && s.archiveDir == archiveDir && s.watcher.Interval() == interval {
+		return nil
+	}
+
+	if s.watcher != nil {
+		s.watcher.Stop()
+		log.Printf("Stopped watching %s", s.watcher.Dir())
+	}
+	s.configure(dir, archiveDir, interval)
+	return s.start()
+}
+
+// isTrackFile reports whether name has an importable track file extension
+func isTrackFile(name string) bool {
+	_, err := importer.DetectFormat(name)
+	return err == nil
+}
+
+// Start starts watching the directory, creating it and the archive and
+// failed directories if needed
+func (s *WatchImportService) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.start()
+}
+
+// start starts the watcher; callers hold mu
+func (s *WatchImportService) start() error {
+	if s.watcher == nil {
+		return nil
+	}
+	for _, dir := range []string{s.watcher.Dir(), s.archiveDir, s.failedDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create import directory: %w", err)
+		}
+	}
+	s.watcher.Start()
+	log.Printf("Watching %s for track files every %s", s.watcher.Dir(), s.watcher.Interval())
+	return nil
+}
+
+// Stop stops watching, waiting for the files being imported
+func (s *WatchImportService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher != nil {
+		s.watcher.Stop()
+	}
+}
+
+// GetStatus describes the watch directory
+func (s *WatchImportService) GetStatus() models.WatchImportStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watcher == nil {
+		return models.WatchImportStatus{}
+	}
+	status := models.WatchImportStatus{
+		Enabled:    true,
+		Running:    s.watcher.Running(),
+		Dir:        s.watcher.Dir(),
+		ArchiveDir: s.archiveDir,
+		FailedDir:  s.failedDir,
+		Interval:   int64(s.watcher.Interval() / time.Second),
+	}
+	if err := s.watcher.Err(); err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// GetImports retrieves a page of processed files, latest first
+func (s *WatchImportService) GetImports(filter models.WatchImportFilter, opts models.QueryOptions) ([]models.WatchImport, int64, error) {
+	return s.repo.GetImports(filter, opts)
+}
+
+// importFiles imports the files of one poll, then triggers incremental
+// analysis once if any of them added points and notifies about the files
+// that were not duplicates
+func (s *WatchImportService) importFiles(ctx context.Context, paths []string) {
+	var changed []int64
+	var results []models.ImportResult
+	defer func() { s.importService.NotifyImported("watch", results) }()
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return
+		}
+		imp := s.importFile(path)
+		if err := s.repo.Create(imp); err != nil {
+			log.Printf("Watch import %s: %v", imp.FileName, err)
+			continue
+		}
+		if imp.Status != models.WatchImportDuplicate {
+			results = append(results, models.ImportResult{
+				FileName:        imp.FileName,
+				Format:          imp.Format,
+				ParsedPoints:    imp.ParsedPoints,
+				InsertedPoints:  imp.InsertedPoints,
+				ReplacedPoints:  imp.ReplacedPoints,
+				DuplicatePoints: imp.DuplicatePoints,
+				StartTime:       imp.StartTime,
+				EndTime:         imp.EndTime,
+				Error:           imp.Error,
+			})
+		}
+		if imp.InsertedPoints+imp.ReplacedPoints > 0 {
+			changed = append(changed, imp.ID)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	taskIDs, err := s.importService.TriggerIncrementalAnalysis("watch")
+	if err != nil {
+		log.Printf("Watch import: failed to trigger analysis: %v", err)
+		return
+	}
+	if err := s.repo.SetAnalysisTasks(changed, taskIDs); err != nil {
+		log.Printf("Watch import: %v", err)
+	}
+}
+
+// importFile imports one file unless a file with the same content was
+// imported before, and moves it out of the watch directory
+func (s *WatchImportService) importFile(path string) *models.WatchImport {
+	imp := &models.WatchImport{
+		FileName:   filepath.Base(path),
+		Status:     models.WatchImportFailed,
+		ImportedAt: time.Now().Unix(),
+	}
+	imp.Format, _ = importer.DetectFormat(imp.FileName)
+
+	if err := s.process(path, imp); err != nil {
+		imp.Error = err.Error()
+	}
+
+	destDir := s.failedDir
+	if imp.Status != models.WatchImportFailed {
+		destDir = filepath.Join(s.archiveDir, time.Unix(imp.ImportedAt, 0).Format("2006/01"))
+	}
+	archived, err := moveFile(path, destDir)
+	if err != nil {
+		log.Printf("Watch import %s: %v", imp.FileName, err)
+	}
+	imp.ArchivedPath = archived
+
+	if imp.Error != "" {
+		log.Printf("Watch import %s: %s: %s", imp.FileName, imp.Status, imp.Error)
+	} else {
+		log.Printf("Watch import %s: %d inserted, %d replaced, %d duplicates",
+			imp.FileName, imp.InsertedPoints, imp.ReplacedPoints, imp.DuplicatePoints)
+	}
+	return imp
+}
+
+// process hashes and imports the file at path into imp
+func (s *WatchImportService) process(path string, imp *models.WatchImport) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if imp.Size, err = io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	imp.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	prev, err := s.repo.FindImported(imp.SHA256)
+	if err != nil {
+		return err
+	}
+	if prev != nil {
+		imp.Status = models.WatchImportDuplicate
+		imp.Error = fmt.Sprintf("same content as %s (import %d)", prev.FileName, prev.ID)
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	result, err := s.importService.ImportFile(imp.FileName, f, "", "")
+	if err != nil {
+		return err
+	}
+	imp.Status = models.WatchImportImported
+	imp.Format = result.Format
+	imp.ParsedPoints = result.ParsedPoints
+	imp.InsertedPoints = result.InsertedPoints
+	imp.ReplacedPoints = result.ReplacedPoints
+	imp.DuplicatePoints = result.DuplicatePoints
+	imp.StartTime = result.StartTime
+	imp.EndTime = result.EndTime
+	return nil
+}
+
+// moveFile moves path into dir, adding a numeric suffix to the name if a
+// file of that name is already there, and returns the new path
+func moveFile(path, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	dest := filepath.Join(dir, name)
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(dest); os.IsNotExist(err) {
+			break
+		}
+		dest = filepath.Join(dir, fmt.Sprintf("%s.%d%s", base, i, ext))
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", fmt.Errorf("failed to move file: %w", err)
+	}
+	return dest, nil
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jengzang/records-backend-go/internal/cache"
+	"github.com/jengzang/records-backend-go/internal/models"
+	"github.com/jengzang/records-backend-go/internal/repository"
+)
+
+// weatherSkill tags cached weather stats with the analyzer that looks the weather up
+const weatherSkill = "weather_enrichment"
+
+// WeatherService handles business logic for the weather of visited days and trips
+type WeatherService struct {
+	repo  *repository.WeatherRepository
+	cache *cache.Cache
+}
+
+// NewWeatherService creates a new weather service
+// A nil cache disables result caching.
+func NewWeatherService(repo *repository.WeatherRepository, resultCache *cache.Cache) *WeatherService {
+	return &WeatherService{repo: repo, cache: resultCache}
+}
+
+// ListDays retrieves a page of daily weather, the primary cell of each day
+// unless the filter asks for all cells
+func (s *WeatherService) ListDays(filter models.WeatherDayFilter, opts models.QueryOptions) ([]models.WeatherDay, int64, error) {
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, 0, fmt.Errorf("%w: dates must be YYYY-MM-DD, got %q", models.ErrInvalidWeatherFilter, date)
+		}
+	}
+	return s.repo.ListDays(filter, opts)
+}
+
+// GetSummary retrieves the weather overview of one year, or all years when year is 0
+func (s *WeatherService) GetSummary(year int) (*models.WeatherSummary, error) {
+	return cache.Load(s.cache, cache.Key("weather_summary", year), []string{weatherSkill}, func() (*models.WeatherSummary, error) {
+		summary := &models.WeatherSummary{Year: year}
+		if err := s.repo.GetDayTotals(year, summary); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if summary.Hottest, err = s.repo.GetExtremeDay(year, "temp_max_c", false); err != nil {
+			return nil, err
+		}
+		if summary.Coldest, err = s.repo.GetExtremeDay(year, "temp_min_c", true); err != nil {
+			return nil, err
+		}
+		if summary.Wettest, err = s.repo.GetExtremeDay(year, "precip_mm", false); err != nil {
+			return nil, err
+		}
+		if summary.Trips, summary.WetTrips, err = s.repo.GetTripCounts(year); err != nil {
+			return nil, err
+		}
+		if summary.Modes, err = s.repo.GetModeDistances(year); err != nil {
+			return nil, err
+		}
+		return summary, nil
+	})
+}
@@ -79,6 +79,25 @@ func Midpoint(lat1, lon1, lat2, lon2 float64) (float64, float64) {
 	return midLatLng.Lat.Degrees(), midLatLng.Lng.Degrees()
 }
 
+// GreatCirclePath interpolates the great circle between two points with
+// vertices at most stepM meters apart
+// Returns [lon, lat] pairs, both ends included.
+func GreatCirclePath(lat1, lon1, lat2, lon2, stepM float64) [][2]float64 {
+	p1 := s2.PointFromLatLng(s2.LatLngFromDegrees(lat1, lon1))
+	p2 := s2.PointFromLatLng(s2.LatLngFromDegrees(lat2, lon2))
+
+	n := 1
+	if stepM > 0 {
+		n = max(1, int(math.Ceil(HaversineDistance(lat1, lon1, lat2, lon2)/stepM)))
+	}
+	path := make([][2]float64, 0, n+1)
+	for i := 0; i <= n; i++ {
+		ll := s2.LatLngFromPoint(s2.Interpolate(float64(i)/float64(n), p1, p2))
+		path = append(path, [2]float64{ll.Lng.Degrees(), ll.Lat.Degrees()})
+	}
+	return path
+}
+
 // Constants
 const (
 	EarthRadiusMeters = 6371000.0 // Earth's mean radius in meters
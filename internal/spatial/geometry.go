@@ -1,6 +1,7 @@
 package spatial
 
 import (
+	"container/heap"
 	"math"
 )
 
@@ -258,21 +259,125 @@ func SimplifyPath(points []Point, epsilon float64) []Point {
 	return []Point{points[0], points[len(points)-1]}
 }
 
-// perpendicularDistance calculates the perpendicular distance from a point to a line segment
-func perpendicularDistance(point, lineStart, lineEnd Point) float64 {
-	// Calculate the perpendicular distance using cross product
-	x0, y0 := point.Lat, point.Lon
-	x1, y1 := lineStart.Lat, lineStart.Lon
-	x2, y2 := lineEnd.Lat, lineEnd.Lon
+// SimplifyPathVisvalingam simplifies a path using the Visvalingam-Whyatt algorithm
+// minArea: points whose triangle with their neighbours is smaller than this area
+// (square meters) are removed, smallest first
+func SimplifyPathVisvalingam(points []Point, minArea float64) []Point {
+	n := len(points)
+	if n < 3 {
+		return points
+	}
+
+	prev := make([]int, n)
+	next := make([]int, n)
+	area := make([]float64, n)
+	removed := make([]bool, n)
+	h := &areaHeap{}
+	for i := range points {
+		prev[i], next[i] = i-1, i+1
+		if i > 0 && i < n-1 {
+			area[i] = triangleArea(points[i-1], points[i], points[i+1])
+			heap.Push(h, areaItem{index: i, area: area[i]})
+		}
+	}
+
+	for h.Len() > 0 {
+		item := heap.Pop(h).(areaItem)
+		i := item.index
+		// Skip entries superseded by a recomputed area
+		if removed[i] || item.area != area[i] {
+			continue
+		}
+		if item.area >= minArea {
+			break
+		}
 
-	num := math.Abs((y2-y1)*x0 - (x2-x1)*y0 + x2*y1 - y2*x1)
-	den := math.Sqrt((y2-y1)*(y2-y1) + (x2-x1)*(x2-x1))
+		removed[i] = true
+		p, q := prev[i], next[i]
+		next[p], prev[q] = q, p
+
+		// Recompute the neighbours; an area never drops below that of a point
+		// already removed, so removal order follows effective area
+		for _, j := range []int{p, q} {
+			if j == 0 || j == n-1 {
+				continue
+			}
+			area[j] = math.Max(triangleArea(points[prev[j]], points[j], points[next[j]]), item.area)
+			heap.Push(h, areaItem{index: j, area: area[j]})
+		}
+	}
 
-	if den == 0 {
-		return HaversineDistance(point.Lat, point.Lon, lineStart.Lat, lineStart.Lon)
+	result := make([]Point, 0, n)
+	for i := 0; i < n; i = next[i] {
+		result = append(result, points[i])
 	}
+	return result
+}
+
+// triangleArea calculates the area in square meters of the triangle formed by three points
+func triangleArea(a, b, c Point) float64 {
+	ax, ay := localXY(a, b.Lat)
+	bx, by := localXY(b, b.Lat)
+	cx, cy := localXY(c, b.Lat)
+	return math.Abs((bx-ax)*(cy-ay)-(cx-ax)*(by-ay)) / 2
+}
+
+type areaItem struct {
+	index int
+	area  float64
+}
+
+// areaHeap is a min-heap of points by triangle area
+type areaHeap []areaItem
+
+func (h areaHeap) Len() int            { return len(h) }
+func (h areaHeap) Less(i, j int) bool  { return h[i].area < h[j].area }
+func (h areaHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *areaHeap) Push(x interface{}) { *h = append(*h, x.(areaItem)) }
+func (h *areaHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// DistanceToPath calculates the distance in meters from a point to the closest
+// segment of a path
+func DistanceToPath(point Point, path []Point) float64 {
+	switch len(path) {
+	case 0:
+		return math.Inf(1)
+	case 1:
+		return HaversineDistance(point.Lat, point.Lon, path[0].Lat, path[0].Lon)
+	}
+	best := math.Inf(1)
+	for i := 1; i < len(path); i++ {
+		best = math.Min(best, perpendicularDistance(point, path[i-1], path[i]))
+	}
+	return best
+}
+
+// perpendicularDistance calculates the distance in meters from a point to a line segment
+// The points are projected onto a local equirectangular plane, which is accurate for
+// the short segments of a track
+func perpendicularDistance(point, lineStart, lineEnd Point) float64 {
+	x0, y0 := localXY(point, lineStart.Lat)
+	x1, y1 := localXY(lineStart, lineStart.Lat)
+	x2, y2 := localXY(lineEnd, lineStart.Lat)
+
+	dx, dy := x2-x1, y2-y1
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return math.Hypot(x0-x1, y0-y1)
+	}
+
+	// Clamp the projection to the segment so points beyond an endpoint keep their distance to it
+	t := math.Max(0, math.Min(1, ((x0-x1)*dx+(y0-y1)*dy)/lengthSq))
+	return math.Hypot(x0-(x1+t*dx), y0-(y1+t*dy))
+}
 
-	// Convert to meters (approximate)
-	metersPerDegree := 111320.0
-	return (num / den) * metersPerDegree
+// localXY projects a point to meters on an equirectangular plane centered on refLat
+func localXY(p Point, refLat float64) (float64, float64) {
+	const metersPerDegree = EarthRadiusMeters * math.Pi / 180
+	return p.Lon * metersPerDegree * math.Cos(refLat*math.Pi/180), p.Lat * metersPerDegree
 }
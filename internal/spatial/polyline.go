@@ -0,0 +1,93 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// polylinePrecision is the coordinate scale of encoded polylines (5 decimal places)
+const polylinePrecision = 1e5
+
+// EncodePolyline encodes a path in the encoded polyline format used by Google
+// Maps, Mapbox and Leaflet plugins (precision 1e5)
+func EncodePolyline(points []Point) string {
+	var b strings.Builder
+	var lastLat, lastLon int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Lat * polylinePrecision))
+		lon := int64(math.Round(p.Lon * polylinePrecision))
+		encodePolylineValue(&b, lat-lastLat)
+		encodePolylineValue(&b, lon-lastLon)
+		lastLat, lastLon = lat, lon
+	}
+	return b.String()
+}
+
+// DecodePolyline decodes an encoded polyline (precision 1e5)
+func DecodePolyline(encoded string) ([]Point, error) {
+	return DecodePolylinePrecision(encoded, 5)
+}
+
+// DecodePolylinePrecision decodes an encoded polyline whose coordinates have the
+// given number of decimal places (e.g. 6 for Valhalla and OSRM polyline6)
+func DecodePolylinePrecision(encoded string, digits int) ([]Point, error) {
+	scale := math.Pow(10, float64(digits))
+	var points []Point
+	var lat, lon int64
+	for i := 0; i < len(encoded); {
+		dLat, n, err := decodePolylineValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		dLon, n, err := decodePolylineValue(encoded[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+
+		lat += dLat
+		lon += dLon
+		points = append(points, Point{
+			Lat: float64(lat) / scale,
+			Lon: float64(lon) / scale,
+		})
+	}
+	return points, nil
+}
+
+// encodePolylineValue writes a signed delta as 5-bit chunks offset by 63
+func encodePolylineValue(b *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte(0x20|(u&0x1f)) + 63)
+		u >>= 5
+	}
+	b.WriteByte(byte(u) + 63)
+}
+
+// decodePolylineValue reads one signed delta and returns it with the number of bytes consumed
+func decodePolylineValue(s string) (int64, int, error) {
+	var result uint64
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		chunk := int(s[i]) - 63
+		if chunk < 0 || chunk > 0x3f {
+			return 0, 0, fmt.Errorf("invalid polyline character %q", s[i])
+		}
+		result |= uint64(chunk&0x1f) << shift
+		shift += 5
+		if chunk < 0x20 {
+			v := int64(result >> 1)
+			if result&1 != 0 {
+				v = ^v
+			}
+			return v, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("truncated polyline")
+}
@@ -0,0 +1,39 @@
+package staticmap
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// fillRect fills r, clipped to dst, with c
+func fillRect(dst *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(dst, r.Intersect(dst.Rect), &image.Uniform{C: c}, image.Point{}, draw.Over)
+}
+
+// fillDisc fills the disc of radius r centred on (cx, cy) with c
+func fillDisc(dst *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	r = math.Max(r, 0.5)
+	x0, x1 := int(math.Floor(cx-r)), int(math.Ceil(cx+r))
+	y0, y1 := int(math.Floor(cy-r)), int(math.Ceil(cy+r))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			if dx*dx+dy*dy <= r*r && (image.Point{X: x, Y: y}).In(dst.Rect) {
+				dst.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// strokeLine draws the segment from (x0, y0) to (x1, y1) with a round pen
+// of the given width
+func strokeLine(dst *image.RGBA, x0, y0, x1, y1, width float64, c color.RGBA) {
+	length := math.Hypot(x1-x0, y1-y0)
+	steps := max(int(math.Ceil(length)), 1)
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		fillDisc(dst, x0+t*(x1-x0), y0+t*(y1-y0), width/2, c)
+	}
+}
@@ -0,0 +1,196 @@
+// Package staticmap renders tracks onto a plain image with a graticule and a
+// scale bar, without fetching map tiles
+package staticmap
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// minSpanDegrees is the smallest extent shown, so a single point or a
+// short walk is not zoomed in to street-corner level
+const minSpanDegrees = 0.005
+
+// Point is a WGS84 position
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Line is a polyline drawn with a round pen of Width pixels
+type Line struct {
+	Points []Point
+	Color  color.RGBA
+	Width  float64
+}
+
+// Marker is a filled disc with a white outline
+type Marker struct {
+	At     Point
+	Color  color.RGBA
+	Radius float64
+}
+
+// Map is a set of lines and markers drawn in Web Mercator, fitted to their
+// bounding box
+type Map struct {
+	Lines      []Line
+	Markers    []Marker
+	Background color.RGBA
+	Grid       color.RGBA // Graticule lines; the scale bar uses a darker shade
+	Padding    int        // Pixels kept clear around the fitted bounding box
+}
+
+// projection maps Web Mercator coordinates (radians) to pixels of an area
+type projection struct {
+	area   image.Rectangle
+	cx, cy float64 // Mercator centre of the area
+	scale  float64 // Pixels per radian
+}
+
+func mercatorY(lat float64) float64 {
+	lat = math.Max(-85, math.Min(85, lat))
+	return math.Log(math.Tan(math.Pi/4 + lat*math.Pi/360))
+}
+
+func (p projection) pixel(pt Point) (float64, float64) {
+	x := float64(p.area.Min.X) + float64(p.area.Dx())/2 + (pt.Lon*math.Pi/180-p.cx)*p.scale
+	y := float64(p.area.Min.Y) + float64(p.area.Dy())/2 - (mercatorY(pt.Lat)-p.cy)*p.scale
+	return x, y
+}
+
+// bounds returns the latitude and longitude range visible in the area
+func (p projection) bounds() (minLat, minLon, maxLat, maxLon float64) {
+	halfW := float64(p.area.Dx()) / 2 / p.scale
+	halfH := float64(p.area.Dy()) / 2 / p.scale
+	lat := func(y float64) float64 { return (2*math.Atan(math.Exp(y)) - math.Pi/2) * 180 / math.Pi }
+	return lat(p.cy - halfH), (p.cx - halfW) * 180 / math.Pi, lat(p.cy + halfH), (p.cx + halfW) * 180 / math.Pi
+}
+
+// fit returns the projection showing every point of the map in area
+func (m *Map) fit(area image.Rectangle) projection {
+	minLat, minLon, maxLat, maxLon := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	extend := func(pt Point) {
+		minLat, maxLat = math.Min(minLat, pt.Lat), math.Max(maxLat, pt.Lat)
+		minLon, maxLon = math.Min(minLon, pt.Lon), math.Max(maxLon, pt.Lon)
+	}
+	for _, l := range m.Lines {
+		for _, pt := range l.Points {
+			extend(pt)
+		}
+	}
+	for _, mk := range m.Markers {
+		extend(mk.At)
+	}
+	if math.IsInf(minLat, 1) {
+		minLat, minLon, maxLat, maxLon = 0, 0, 0, 0
+	}
+	if span := maxLat - minLat; span < minSpanDegrees {
+		minLat, maxLat = minLat-(minSpanDegrees-span)/2, maxLat+(minSpanDegrees-span)/2
+	}
+	if span := maxLon - minLon; span < minSpanDegrees {
+		minLon, maxLon = minLon-(minSpanDegrees-span)/2, maxLon+(minSpanDegrees-span)/2
+	}
+
+	x0, x1 := minLon*math.Pi/180, maxLon*math.Pi/180
+	y0, y1 := mercatorY(minLat), mercatorY(maxLat)
+	w := float64(max(area.Dx()-2*m.Padding, 1))
+	h := float64(max(area.Dy()-2*m.Padding, 1))
+	return projection{
+		area:  area,
+		cx:    (x0 + x1) / 2,
+		cy:    (y0 + y1) / 2,
+		scale: math.Min(w/(x1-x0), h/(y1-y0)),
+	}
+}
+
+// Draw renders the map into area of dst
+func (m *Map) Draw(dst *image.RGBA, area image.Rectangle) {
+	fillRect(dst, area, m.Background)
+	proj := m.fit(area)
+	clip := dst.SubImage(area).(*image.RGBA)
+
+	m.drawGraticule(clip, proj)
+	for _, l := range m.Lines {
+		for i := 1; i < len(l.Points); i++ {
+			x0, y0 := proj.pixel(l.Points[i-1])
+			x1, y1 := proj.pixel(l.Points[i])
+			strokeLine(clip, x0, y0, x1, y1, l.Width, l.Color)
+		}
+		if len(l.Points) == 1 {
+			x, y := proj.pixel(l.Points[0])
+			fillDisc(clip, x, y, l.Width/2, l.Color)
+		}
+	}
+	for _, mk := range m.Markers {
+		x, y := proj.pixel(mk.At)
+		fillDisc(clip, x, y, mk.Radius+2, color.RGBA{255, 255, 255, 255})
+		fillDisc(clip, x, y, mk.Radius, mk.Color)
+	}
+	m.drawScaleBar(clip, proj)
+}
+
+// gridSteps are the graticule spacings tried, in degrees
+var gridSteps = []float64{0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 20, 45}
+
+// drawGraticule draws meridians and parallels at the finest step that
+// leaves at most six lines across the wider side
+func (m *Map) drawGraticule(dst *image.RGBA, proj projection) {
+	minLat, minLon, maxLat, maxLon := proj.bounds()
+	span := math.Max(maxLat-minLat, maxLon-minLon)
+	step := gridSteps[len(gridSteps)-1]
+	for _, s := range gridSteps {
+		if span/s <= 6 {
+			step = s
+			break
+		}
+	}
+
+	for lon := math.Ceil(minLon/step) * step; lon <= maxLon; lon += step {
+		x, _ := proj.pixel(Point{Lon: lon})
+		fillRect(dst, image.Rect(int(x), dst.Rect.Min.Y, int(x)+1, dst.Rect.Max.Y), m.Grid)
+	}
+	for lat := math.Ceil(minLat/step) * step; lat <= maxLat; lat += step {
+		_, y := proj.pixel(Point{Lat: lat})
+		fillRect(dst, image.Rect(dst.Rect.Min.X, int(y), dst.Rect.Max.X, int(y)+1), m.Grid)
+	}
+}
+
+// scaleSteps are the scale bar lengths tried, in metres
+var scaleSteps = []float64{10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000, 100000, 200000, 500000, 1000000}
+
+// drawScaleBar draws the longest scale bar up to a quarter of the width in
+// the bottom-left corner, measured at the centre latitude
+func (m *Map) drawScaleBar(dst *image.RGBA, proj projection) {
+	minLat, minLon, maxLat, _ := proj.bounds()
+	midLat := (minLat + maxLat) / 2
+	metresPerPixel := spatial.HaversineDistance(midLat, minLon, midLat, minLon+180/math.Pi/proj.scale)
+	if metresPerPixel <= 0 {
+		return
+	}
+
+	length := scaleSteps[0]
+	for _, s := range scaleSteps {
+		if s/metresPerPixel > float64(dst.Rect.Dx())/4 {
+			break
+		}
+		length = s
+	}
+	label := fmt.Sprintf("%.0f M", length)
+	if length >= 1000 {
+		label = fmt.Sprintf("%.0f KM", length/1000)
+	}
+
+	ink := color.RGBA{m.Grid.R / 2, m.Grid.G / 2, m.Grid.B / 2, 255}
+	x := dst.Rect.Min.X + 16
+	y := dst.Rect.Max.Y - 16
+	w := int(length / metresPerPixel)
+	fillRect(dst, image.Rect(x, y-3, x+w, y), ink)
+	fillRect(dst, image.Rect(x, y-9, x+2, y), ink)
+	fillRect(dst, image.Rect(x+w-2, y-9, x+w, y), ink)
+	DrawText(dst, x, y-9-glyphHeight*2-4, label, 2, ink)
+}
@@ -231,6 +231,39 @@ func AutoCorrelation(x []float64, maxLag int) []float64 {
 	return CrossCorrelation(x, x, maxLag)
 }
 
+// ACF calculates the sample autocorrelation function for lags 0 to maxLag
+// Unlike AutoCorrelation, every lag is normalized by the variance of the whole
+// series, so correlations shrink with the overlap and stay within [-1, 1],
+// and a period outranks its multiples. It returns nil for constant series.
+func ACF(x []float64, maxLag int) []float64 {
+	n := len(x)
+	if n < 2 {
+		return nil
+	}
+	if maxLag > n-1 {
+		maxLag = n - 1
+	}
+
+	mean := Mean(x)
+	var variance float64
+	for _, v := range x {
+		variance += (v - mean) * (v - mean)
+	}
+	if variance == 0 {
+		return nil
+	}
+
+	result := make([]float64, maxLag+1)
+	for lag := 0; lag <= maxLag; lag++ {
+		var sum float64
+		for i := 0; i+lag < n; i++ {
+			sum += (x[i] - mean) * (x[i+lag] - mean)
+		}
+		result[lag] = sum / variance
+	}
+	return result
+}
+
 // PartialCorrelation calculates the partial correlation between x and y controlling for z
 func PartialCorrelation(x, y, z []float64) float64 {
 	if len(x) != len(y) || len(x) != len(z) || len(x) < 3 {
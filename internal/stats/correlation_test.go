@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestACF(t *testing.T) {
+	// A visit every third day: the period outranks its multiple
+	x := []float64{1, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0}
+	acf := ACF(x, 6)
+	if len(acf) != 7 || math.Abs(acf[0]-1) > 1e-9 {
+		t.Fatalf("acf = %v, want 7 lags starting at 1", acf)
+	}
+	if !(acf[3] > acf[6] && acf[3] > acf[1] && acf[3] > acf[2]) {
+		t.Errorf("acf = %v, want the peak at lag 3", acf)
+	}
+	for lag, r := range acf {
+		if r < -1 || r > 1 {
+			t.Errorf("acf[%d] = %v, outside [-1, 1]", lag, r)
+		}
+	}
+
+	if ACF([]float64{1, 1, 1}, 2) != nil {
+		t.Error("constant series has an autocorrelation")
+	}
+}
@@ -0,0 +1,41 @@
+package stats
+
+// WeightedRunning accumulates the weighted mean and variance of a stream of
+// values in one pass (West's weighted form of Welford's algorithm), without
+// keeping the values; the zero value is empty
+// The results match WeightedMean and WeightedVariance over the same values,
+// and stay accurate when the variance is small next to the mean.
+type WeightedRunning struct {
+	weight float64 // Sum of weights
+	mean   float64
+	m2     float64 // Weighted sum of squared deviations from the mean
+}
+
+// Add adds a value with a weight; non-positive weights are ignored
+func (r *WeightedRunning) Add(value, weight float64) {
+	if weight <= 0 {
+		return
+	}
+	r.weight += weight
+	delta := value - r.mean
+	r.mean += delta * weight / r.weight
+	r.m2 += weight * delta * (value - r.mean)
+}
+
+// Weight returns the sum of the weights added
+func (r *WeightedRunning) Weight() float64 {
+	return r.weight
+}
+
+// Mean returns the weighted mean, 0 when nothing was added
+func (r *WeightedRunning) Mean() float64 {
+	return r.mean
+}
+
+// Variance returns the weighted (population) variance, 0 when nothing was added
+func (r *WeightedRunning) Variance() float64 {
+	if r.weight == 0 {
+		return 0
+	}
+	return r.m2 / r.weight
+}
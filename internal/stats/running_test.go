@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedRunning(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		weights  []float64
+		mean     float64
+		variance float64
+	}{
+		{name: "empty"},
+		{name: "single value", values: []float64{42}, weights: []float64{3}, mean: 42},
+		{
+			name:     "uniform weights",
+			values:   []float64{2, 4, 4, 4, 5, 5, 7, 9},
+			weights:  []float64{1, 1, 1, 1, 1, 1, 1, 1},
+			mean:     5,
+			variance: 4,
+		},
+		{
+			// Weights act like repeated values: 2 three times and 8 once
+			name:     "integer weights",
+			values:   []float64{2, 8},
+			weights:  []float64{3, 1},
+			mean:     3.5,
+			variance: 6.75,
+		},
+		{
+			name:     "two-point distribution",
+			values:   []float64{10, 20},
+			weights:  []float64{0.25, 0.75},
+			mean:     17.5,
+			variance: 18.75,
+		},
+		{
+			name:     "non-positive weights are ignored",
+			values:   []float64{1, 100, 3, 50},
+			weights:  []float64{1, 0, 1, -2},
+			mean:     2,
+			variance: 1,
+		},
+		{
+			// Naive sum-of-squares loses the variance to cancellation here
+			name:     "large offset",
+			values:   []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16},
+			weights:  []float64{1, 1, 1, 1},
+			mean:     1e9 + 10,
+			variance: 22.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r WeightedRunning
+			for i, v := range tt.values {
+				r.Add(v, tt.weights[i])
+			}
+			if math.Abs(r.Mean()-tt.mean) > 1e-9 || math.Abs(r.Variance()-tt.variance) > 1e-9 {
+				t.Errorf("mean/variance = %v/%v, want %v/%v", r.Mean(), r.Variance(), tt.mean, tt.variance)
+			}
+		})
+	}
+
+	t.Run("matches two-pass weighted variance", func(t *testing.T) {
+		values := []float64{3.5, 60, 12, 95.2, 40, 40, 7}
+		weights := []float64{120, 5000, 800, 20000, 3000, 10, 450}
+		var r WeightedRunning
+		for i, v := range values {
+			r.Add(v, weights[i])
+		}
+		if want := WeightedMean(values, weights); math.Abs(r.Mean()-want) > 1e-9 {
+			t.Errorf("mean = %v, want %v", r.Mean(), want)
+		}
+		if want := WeightedVariance(values, weights); math.Abs(r.Variance()-want) > 1e-6 {
+			t.Errorf("variance = %v, want %v", r.Variance(), want)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+// Package transit holds reference locations of airports and railway
+// stations, used to place imported flight and train journeys on the map, and
+// traces of the main high-speed rail lines, used to name the lines travelled.
+//
+// The tables cover the main Chinese hubs plus common international airports.
+// Coordinates are approximate (terminal / station building) and are only used
+// for great-circle distances and for matching journeys to trajectories.
+package transit
+
+import (
+	"math"
+	"strings"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// Place is an airport or railway station
+type Place struct {
+	Code string  `json:"code,omitempty"` // IATA code for airports, empty for stations
+	Name string  `json:"name"`
+	City string  `json:"city"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+// airports is keyed by IATA code
+var airports = map[string]Place{
+	"PEK": {"PEK", "北京首都国际机场", "北京市", 40.0801, 116.5846},
+	"PKX": {"PKX", "北京大兴国际机场", "北京市", 39.5098, 116.4105},
+	"PVG": {"PVG", "上海浦东国际机场", "上海市", 31.1443, 121.8083},
+	"SHA": {"SHA", "上海虹桥国际机场", "上海市", 31.1979, 121.3363},
+	"CAN": {"CAN", "广州白云国际机场", "广州市", 23.3924, 113.2988},
+	"SZX": {"SZX", "深圳宝安国际机场", "深圳市", 22.6393, 113.8107},
+	"CTU": {"CTU", "成都双流国际机场", "成都市", 30.5785, 103.9471},
+	"TFU": {"TFU", "成都天府国际机场", "成都市", 30.3125, 104.4411},
+	"CKG": {"CKG", "重庆江北国际机场", "重庆市", 29.7192, 106.6417},
+	"KMG": {"KMG", "昆明长水国际机场", "昆明市", 25.1019, 102.9292},
+	"XIY": {"XIY", "西安咸阳国际机场", "西安市", 34.4471, 108.7516},
+	"HGH": {"HGH", "杭州萧山国际机场", "杭州市", 30.2295, 120.4344},
+	"NKG": {"NKG", "南京禄口国际机场", "南京市", 31.7420, 118.8620},
+	"WUH": {"WUH", "武汉天河国际机场", "武汉市", 30.7838, 114.2081},
+	"CSX": {"CSX", "长沙黄花国际机场", "长沙市", 28.1892, 113.2196},
+	"XMN": {"XMN", "厦门高崎国际机场", "厦门市", 24.5440, 118.1277},
+	"FOC": {"FOC", "福州长乐国际机场", "福州市", 25.9351, 119.6633},
+	"TAO": {"TAO", "青岛胶东国际机场", "青岛市", 36.3617, 120.0880},
+	"TSN": {"TSN", "天津滨海国际机场", "天津市", 39.1244, 117.3462},
+	"SHE": {"SHE", "沈阳桃仙国际机场", "沈阳市", 41.6398, 123.4834},
+	"DLC": {"DLC", "大连周水子国际机场", "大连市", 38.9657, 121.5386},
+	"HRB": {"HRB", "哈尔滨太平国际机场", "哈尔滨市", 45.6234, 126.2503},
+	"CGQ": {"CGQ", "长春龙嘉国际机场", "长春市", 43.9962, 125.6850},
+	"CGO": {"CGO", "郑州新郑国际机场", "郑州市", 34.5197, 113.8409},
+	"TNA": {"TNA", "济南遥墙国际机场", "济南市", 36.8572, 117.2158},
+	"HFE": {"HFE", "合肥新桥国际机场", "合肥市", 31.9884, 116.9769},
+	"KHN": {"KHN", "南昌昌北国际机场", "南昌市", 28.8650, 115.9000},
+	"NNG": {"NNG", "南宁吴圩国际机场", "南宁市", 22.6083, 108.1722},
+	"KWL": {"KWL", "桂林两江国际机场", "桂林市", 25.2181, 110.0392},
+	"KWE": {"KWE", "贵阳龙洞堡国际机场", "贵阳市", 26.5385, 106.8008},
+	"HAK": {"HAK", "海口美兰国际机场", "海口市", 19.9349, 110.4590},
+	"SYX": {"SYX", "三亚凤凰国际机场", "三亚市", 18.3029, 109.4122},
+	"URC": {"URC", "乌鲁木齐地窝堡国际机场", "乌鲁木齐市", 43.9071, 87.4742},
+	"LHW": {"LHW", "兰州中川国际机场", "兰州市", 36.5152, 103.6204},
+	"LXA": {"LXA", "拉萨贡嘎国际机场", "拉萨市", 29.2978, 90.9119},
+	"XNN": {"XNN", "西宁曹家堡国际机场", "西宁市", 36.5275, 102.0430},
+	"INC": {"INC", "银川河东国际机场", "银川市", 38.3228, 106.3931},
+	"HET": {"HET", "呼和浩特白塔国际机场", "呼和浩特市", 40.8514, 111.8241},
+	"TYN": {"TYN", "太原武宿国际机场", "太原市", 37.7469, 112.6283},
+	"SJW": {"SJW", "石家庄正定国际机场", "石家庄市", 38.2807, 114.6973},
+	"NGB": {"NGB", "宁波栎社国际机场", "宁波市", 29.8267, 121.4619},
+	"WNZ": {"WNZ", "温州龙湾国际机场", "温州市", 27.9122, 120.8522},
+	"ZUH": {"ZUH", "珠海金湾机场", "珠海市", 22.0064, 113.3760},
+	"SWA": {"SWA", "揭阳潮汕国际机场", "揭阳市", 23.5520, 116.5033},
+	"HKG": {"HKG", "香港国际机场", "香港", 22.3080, 113.9185},
+	"MFM": {"MFM", "澳门国际机场", "澳门", 22.1496, 113.5915},
+	"TPE": {"TPE", "桃园国际机场", "台北", 25.0777, 121.2328},
+	"NRT": {"NRT", "Narita International Airport", "Tokyo", 35.7720, 140.3929},
+	"HND": {"HND", "Haneda Airport", "Tokyo", 35.5494, 139.7798},
+	"KIX": {"KIX", "Kansai International Airport", "Osaka", 34.4347, 135.2440},
+	"ICN": {"ICN", "Incheon International Airport", "Seoul", 37.4602, 126.4407},
+	"SIN": {"SIN", "Singapore Changi Airport", "Singapore", 1.3644, 103.9915},
+	"BKK": {"BKK", "Suvarnabhumi Airport", "Bangkok", 13.6900, 100.7501},
+	"KUL": {"KUL", "Kuala Lumpur International Airport", "Kuala Lumpur", 2.7456, 101.7072},
+	"DXB": {"DXB", "Dubai International Airport", "Dubai", 25.2532, 55.3657},
+	"LHR": {"LHR", "Heathrow Airport", "London", 51.4700, -0.4543},
+	"CDG": {"CDG", "Charles de Gaulle Airport", "Paris", 49.0097, 2.5479},
+	"FRA": {"FRA", "Frankfurt Airport", "Frankfurt", 50.0379, 8.5622},
+	"JFK": {"JFK", "John F. Kennedy International Airport", "New York", 40.6413, -73.7781},
+	"LAX": {"LAX", "Los Angeles International Airport", "Los Angeles", 33.9416, -118.4085},
+	"SFO": {"SFO", "San Francisco International Airport", "San Francisco", 37.6213, -122.3790},
+	"SYD": {"SYD", "Sydney Airport", "Sydney", -33.9399, 151.1753},
+}
+
+// stations is keyed by the station name without the trailing "站"
+var stations = map[string]Place{
+	"北京":    {"", "北京站", "北京市", 39.9029, 116.4272},
+	"北京南":   {"", "北京南站", "北京市", 39.8652, 116.3786},
+	"北京西":   {"", "北京西站", "北京市", 39.8949, 116.3213},
+	"上海":    {"", "上海站", "上海市", 31.2495, 121.4558},
+	"上海虹桥":  {"", "上海虹桥站", "上海市", 31.1942, 121.3201},
+	"天津":    {"", "天津站", "天津市", 39.1359, 117.2057},
+	"南京南":   {"", "南京南站", "南京市", 31.9686, 118.7975},
+	"杭州东":   {"", "杭州东站", "杭州市", 30.2906, 120.2127},
+	"合肥南":   {"", "合肥南站", "合肥市", 31.7983, 117.2897},
+	"济南西":   {"", "济南西站", "济南市", 36.6697, 116.8905},
+	"青岛北":   {"", "青岛北站", "青岛市", 36.1676, 120.3736},
+	"石家庄":   {"", "石家庄站", "石家庄市", 38.0117, 114.4861},
+	"郑州东":   {"", "郑州东站", "郑州市", 34.7581, 113.7791},
+	"西安北":   {"", "西安北站", "西安市", 34.3770, 108.9393},
+	"武汉":    {"", "武汉站", "武汉市", 30.6075, 114.4243},
+	"汉口":    {"", "汉口站", "武汉市", 30.6178, 114.2543},
+	"长沙南":   {"", "长沙南站", "长沙市", 28.1500, 113.0647},
+	"成都东":   {"", "成都东站", "成都市", 30.6299, 104.1413},
+	"重庆北":   {"", "重庆北站", "重庆市", 29.6079, 106.5508},
+	"重庆西":   {"", "重庆西站", "重庆市", 29.4948, 106.4355},
+	"贵阳北":   {"", "贵阳北站", "贵阳市", 26.6188, 106.6795},
+	"昆明南":   {"", "昆明南站", "昆明市", 24.8757, 102.8743},
+	"南宁东":   {"", "南宁东站", "南宁市", 22.8340, 108.4090},
+	"厦门北":   {"", "厦门北站", "厦门市", 24.6377, 118.0747},
+	"福州南":   {"", "福州南站", "福州市", 25.9894, 119.3794},
+	"沈阳北":   {"", "沈阳北站", "沈阳市", 41.8213, 123.4353},
+	"长春西":   {"", "长春西站", "长春市", 43.8318, 125.2311},
+	"哈尔滨西":  {"", "哈尔滨西站", "哈尔滨市", 45.7067, 126.5716},
+	"广州":    {"", "广州站", "广州市", 23.1494, 113.2571},
+	"广州东":   {"", "广州东站", "广州市", 23.1510, 113.3250},
+	"广州南":   {"", "广州南站", "广州市", 22.9890, 113.2693},
+	"佛山西":   {"", "佛山西站", "佛山市", 23.0532, 113.0537},
+	"虎门":    {"", "虎门站", "东莞市", 22.8653, 113.6726},
+	"深圳":    {"", "深圳站", "深圳市", 22.5316, 114.1176},
+	"深圳北":   {"", "深圳北站", "深圳市", 22.6096, 114.0292},
+	"福田":    {"", "福田站", "深圳市", 22.5395, 114.0549},
+	"珠海":    {"", "珠海站", "珠海市", 22.2166, 113.5464},
+	"潮汕":    {"", "潮汕站", "潮州市", 23.5361, 116.6181},
+	"香港西九龙": {"", "香港西九龙站", "香港", 22.3038, 114.1661},
+}
+
+// LookupAirport finds an airport by IATA code (case-insensitive)
+func LookupAirport(code string) (Place, bool) {
+	p, ok := airports[strings.ToUpper(strings.TrimSpace(code))]
+	return p, ok
+}
+
+// LookupStation finds a railway station by name, with or without the "站" suffix
+func LookupStation(name string) (Place, bool) {
+	p, ok := stations[StationKey(name)]
+	return p, ok
+}
+
+// StationKey normalizes a station name to its lookup key
+func StationKey(name string) string {
+	return strings.TrimSuffix(strings.TrimSpace(name), "站")
+}
+
+// NearestAirport returns the airport closest to a location and its distance in meters
+func NearestAirport(lat, lon float64) (Place, float64) {
+	return nearest(airports, lat, lon)
+}
+
+// NearestStation returns the railway station closest to a location and its
+// distance in meters
+func NearestStation(lat, lon float64) (Place, float64) {
+	return nearest(stations, lat, lon)
+}
+
+// nearest returns the place of a table closest to a location, ties going to
+// the smallest key so results do not depend on map order
+func nearest(places map[string]Place, lat, lon float64) (Place, float64) {
+	var best Place
+	var bestKey string
+	bestDist := math.Inf(1)
+	for key, p := range places {
+		d := spatial.HaversineDistance(lat, lon, p.Lat, p.Lon)
+		if d < bestDist || d == bestDist && key < bestKey {
+			best, bestKey, bestDist = p, key, d
+		}
+	}
+	return best, bestDist
+}
@@ -0,0 +1,163 @@
+package transit
+
+import (
+	"math"
+
+	"github.com/jengzang/records-backend-go/internal/spatial"
+)
+
+// RailLine is a high-speed rail line, traced through its main stations
+// The trace joins the stations with straight lines, so it strays from the
+// track by a few kilometers where the line curves.
+type RailLine struct {
+	Name     string
+	Stations []string
+	Path     []spatial.Point // One vertex per station
+
+	minLat, minLon, maxLat, maxLon float64
+}
+
+// railLines holds the main lines of the China high-speed rail network
+var railLines = []RailLine{
+	railLine("京沪高铁", []railStop{
+		{"北京南", 39.8652, 116.3786}, {"廊坊", 39.5058, 116.6921}, {"天津西", 39.1592, 117.1637},
+		{"沧州西", 38.2999, 116.7874}, {"德州东", 37.4466, 116.3604}, {"济南西", 36.6697, 116.8905},
+		{"泰安", 36.1750, 117.0735}, {"曲阜东", 35.5637, 117.0290}, {"滕州东", 35.0843, 117.1907},
+		{"枣庄", 34.8477, 117.5535}, {"徐州东", 34.2818, 117.2852}, {"宿州东", 33.6069, 117.0180},
+		{"蚌埠南", 32.8826, 117.3816}, {"定远", 32.5211, 117.6818}, {"滁州", 32.2955, 118.2854},
+		{"南京南", 31.9686, 118.7975}, {"镇江南", 32.1305, 119.4315}, {"常州北", 31.8328, 119.9696},
+		{"无锡东", 31.5896, 120.4225}, {"苏州北", 31.4185, 120.6399}, {"昆山南", 31.3701, 120.9578},
+		{"上海虹桥", 31.1942, 121.3201},
+	}),
+	railLine("京广高铁", []railStop{
+		{"北京西", 39.8949, 116.3213}, {"涿州东", 39.4835, 115.9821}, {"保定东", 38.8611, 115.5693},
+		{"定州东", 38.5190, 115.0590}, {"石家庄", 38.0117, 114.4861}, {"邢台东", 37.0617, 114.5790},
+		{"邯郸东", 36.6018, 114.5513}, {"安阳东", 36.1007, 114.4340}, {"鹤壁东", 35.7230, 114.2825},
+		{"新乡东", 35.3013, 113.9541}, {"郑州东", 34.7581, 113.7791}, {"许昌东", 34.0409, 113.8693},
+		{"漯河西", 33.5705, 113.9820}, {"驻马店西", 33.0040, 113.9930}, {"信阳东", 32.1703, 114.1348},
+		{"孝感北", 30.9893, 114.0300}, {"武汉", 30.6075, 114.4243}, {"咸宁北", 29.8788, 114.3305},
+		{"岳阳东", 29.3692, 113.1790}, {"长沙南", 28.1500, 113.0647}, {"株洲西", 27.8044, 113.0606},
+		{"衡阳东", 26.9240, 112.6720}, {"郴州西", 25.8007, 113.0033}, {"韶关", 24.7926, 113.6020},
+		{"清远", 23.6818, 113.0610}, {"广州南", 22.9890, 113.2693},
+	}),
+	railLine("广深港高铁", []railStop{
+		{"广州南", 22.9890, 113.2693}, {"虎门", 22.8653, 113.6726}, {"深圳北", 22.6096, 114.0292},
+		{"福田", 22.5395, 114.0549}, {"香港西九龙", 22.3038, 114.1661},
+	}),
+	railLine("沪昆高铁", []railStop{
+		{"上海虹桥", 31.1942, 121.3201}, {"松江南", 30.9826, 121.2304}, {"嘉兴南", 30.7206, 120.7720},
+		{"杭州东", 30.2906, 120.2127}, {"义乌", 29.3145, 120.0622}, {"金华", 29.1030, 119.6340},
+		{"衢州", 28.9419, 118.8827}, {"上饶", 28.4413, 117.9707}, {"鹰潭北", 28.2446, 117.0342},
+		{"南昌西", 28.6249, 115.7996}, {"新余北", 27.8539, 114.9212}, {"萍乡北", 27.6327, 113.8511},
+		{"长沙南", 28.1500, 113.0647}, {"娄底南", 27.7040, 112.0178}, {"邵阳北", 27.2618, 111.4530},
+		{"怀化南", 27.5394, 109.9898}, {"凯里南", 26.5517, 107.9718}, {"贵阳北", 26.6188, 106.6795},
+		{"安顺西", 26.2620, 105.9310}, {"盘州", 25.7110, 104.5780}, {"曲靖北", 25.5230, 103.8110},
+		{"昆明南", 24.8757, 102.8743},
+	}),
+	railLine("京哈高铁", []railStop{
+		{"北京朝阳", 39.9474, 116.5053}, {"承德南", 40.8820, 117.9090}, {"朝阳", 41.5700, 120.4500},
+		{"阜新", 42.0170, 121.6700}, {"沈阳北", 41.8213, 123.4353}, {"铁岭西", 42.2800, 123.8000},
+		{"四平东", 43.1700, 124.3800}, {"长春西", 43.8318, 125.2311}, {"德惠西", 44.5200, 125.6400},
+		{"哈尔滨西", 45.7067, 126.5716},
+	}),
+	railLine("徐兰高铁", []railStop{
+		{"徐州东", 34.2818, 117.2852}, {"商丘", 34.4300, 115.6600}, {"开封北", 34.8000, 114.3000},
+		{"郑州东", 34.7581, 113.7791}, {"洛阳龙门", 34.5930, 112.4580}, {"三门峡南", 34.7310, 111.1840},
+		{"华山北", 34.5700, 110.0800}, {"渭南北", 34.5200, 109.5000}, {"西安北", 34.3770, 108.9393},
+		{"宝鸡南", 34.3510, 107.1400}, {"天水南", 34.5500, 105.7200}, {"定西北", 35.5800, 104.6200},
+		{"兰州西", 36.0700, 103.7600},
+	}),
+	railLine("沪汉蓉高铁", []railStop{
+		{"南京南", 31.9686, 118.7975}, {"合肥南", 31.7983, 117.2897}, {"六安", 31.7600, 116.5000},
+		{"麻城北", 31.1800, 115.0000}, {"汉口", 30.6178, 114.2543}, {"宜昌东", 30.7200, 111.3500},
+		{"恩施", 30.3000, 109.4900}, {"万州北", 30.7900, 108.3800}, {"重庆北", 29.6079, 106.5508},
+		{"遂宁", 30.5300, 105.5900}, {"成都东", 30.6299, 104.1413},
+	}),
+	railLine("杭深高铁", []railStop{
+		{"杭州东", 30.2906, 120.2127}, {"宁波", 29.8640, 121.5290}, {"台州", 28.7300, 121.2500},
+		{"温州南", 27.9700, 120.5700}, {"福鼎", 27.2400, 120.2000}, {"福州南", 25.9894, 119.3794},
+		{"莆田", 25.4500, 119.0000}, {"泉州", 24.9500, 118.6000}, {"厦门北", 24.6377, 118.0747},
+		{"漳州", 24.5000, 117.7000}, {"潮汕", 23.5361, 116.6181}, {"汕尾", 22.8000, 115.3000},
+		{"深圳北", 22.6096, 114.0292},
+	}),
+	railLine("成渝高铁", []railStop{
+		{"成都东", 30.6299, 104.1413}, {"简阳南", 30.3800, 104.5500}, {"资阳北", 30.1500, 104.6300},
+		{"内江北", 29.6200, 105.0500}, {"隆昌北", 29.3500, 105.3000}, {"永川东", 29.3800, 105.9300},
+		{"重庆西", 29.4948, 106.4355},
+	}),
+	railLine("京津城际", []railStop{
+		{"北京南", 39.8652, 116.3786}, {"亦庄", 39.8000, 116.5100}, {"武清", 39.3800, 117.0300},
+		{"天津", 39.1359, 117.2057}, {"于家堡", 39.0000, 117.7000},
+	}),
+	railLine("京张高铁", []railStop{
+		{"北京北", 39.9440, 116.3530}, {"清河", 40.0400, 116.3200}, {"昌平", 40.2200, 116.2300},
+		{"八达岭长城", 40.3600, 116.0000}, {"怀来", 40.4000, 115.5200}, {"下花园北", 40.5000, 115.2800},
+		{"宣化北", 40.6200, 115.0500}, {"张家口", 40.7700, 114.8900},
+	}),
+	railLine("西成高铁", []railStop{
+		{"西安北", 34.3770, 108.9393}, {"鄠邑", 34.1000, 108.6000}, {"汉中", 33.0700, 107.0300},
+		{"广元", 32.4300, 105.8400}, {"江油", 31.7700, 104.7400}, {"绵阳", 31.4700, 104.6900},
+		{"德阳", 31.1300, 104.4000}, {"成都东", 30.6299, 104.1413},
+	}),
+	railLine("贵广高铁", []railStop{
+		{"贵阳北", 26.6188, 106.6795}, {"都匀东", 26.2800, 107.5200}, {"三都县", 25.9900, 107.8700},
+		{"从江", 25.7500, 108.9000}, {"桂林北", 25.3300, 110.3000}, {"贺州", 24.4000, 111.5600},
+		{"肇庆东", 23.1300, 112.5700}, {"佛山西", 23.0532, 113.0537}, {"广州南", 22.9890, 113.2693},
+	}),
+	railLine("南广高铁", []railStop{
+		{"南宁东", 22.8340, 108.4090}, {"贵港", 23.1000, 109.6000}, {"梧州南", 23.4500, 111.2800},
+		{"肇庆东", 23.1300, 112.5700}, {"佛山西", 23.0532, 113.0537}, {"广州南", 22.9890, 113.2693},
+	}),
+	railLine("宁杭高铁", []railStop{
+		{"南京南", 31.9686, 118.7975}, {"溧水", 31.6500, 119.0300}, {"宜兴", 31.3400, 119.8200},
+		{"湖州", 30.8700, 120.1000}, {"杭州东", 30.2906, 120.2127},
+	}),
+	railLine("济青高铁", []railStop{
+		{"济南东", 36.7400, 117.2000}, {"淄博北", 36.8700, 118.0000}, {"潍坊北", 36.8000, 119.1000},
+		{"青岛北", 36.1676, 120.3736},
+	}),
+}
+
+// railStop is a station of a rail line
+type railStop struct {
+	name     string
+	lat, lon float64
+}
+
+// railLine builds a rail line through stops, in order
+func railLine(name string, stops []railStop) RailLine {
+	line := RailLine{Name: name, minLat: math.Inf(1), minLon: math.Inf(1), maxLat: math.Inf(-1), maxLon: math.Inf(-1)}
+	for _, s := range stops {
+		line.Stations = append(line.Stations, s.name)
+		line.Path = append(line.Path, spatial.Point{Lat: s.lat, Lon: s.lon})
+		line.minLat, line.maxLat = math.Min(line.minLat, s.lat), math.Max(line.maxLat, s.lat)
+		line.minLon, line.maxLon = math.Min(line.minLon, s.lon), math.Max(line.maxLon, s.lon)
+	}
+	return line
+}
+
+// RailLines returns the known high-speed rail lines
+func RailLines() []RailLine {
+	return railLines
+}
+
+// NearestRailLine returns the rail line closest to a location within
+// maxDistM meters and its distance, or false if none is that close
+// Where lines share track, the line listed first wins.
+func NearestRailLine(lat, lon, maxDistM float64) (RailLine, float64, bool) {
+	// Degrees of latitude / longitude spanning maxDistM, to skip distant lines
+	margin := maxDistM / (spatial.EarthRadiusMeters * math.Pi / 180)
+	lonMargin := margin / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+
+	var best RailLine
+	bestDist := math.Inf(1)
+	for _, line := range railLines {
+		if lat < line.minLat-margin || lat > line.maxLat+margin || lon < line.minLon-lonMargin || lon > line.maxLon+lonMargin {
+			continue
+		}
+		if d := spatial.DistanceToPath(spatial.Point{Lat: lat, Lon: lon}, line.Path); d < bestDist {
+			best, bestDist = line, d
+		}
+	}
+	return best, bestDist, bestDist <= maxDistM
+}
@@ -0,0 +1,184 @@
+// Package watcher polls a directory for files that have finished arriving
+package watcher
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileState is the size and modification time of a file at a poll
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher polls the top level of a directory and hands over the files that
+// did not change between two polls, so files still being written or synced
+// are left alone
+// Hidden files and the temporary files of sync tools and browsers are
+// ignored. A handed over file is expected to be moved away; one that stays
+// is handed over again only once it changes.
+type Watcher struct {
+	dir      string
+	interval time.Duration
+	accept   func(name string) bool
+	handle   func(ctx context.Context, paths []string)
+
+	mu      sync.Mutex
+	pending map[string]fileState // Files seen at the last poll
+	handled map[string]fileState // Handed over files still in the directory
+	lastErr error
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New creates a watcher of dir polling every interval
+// accept filters file names; handle receives the paths of the files that
+// are ready, in name order, and runs on the polling goroutine.
+func New(dir string, interval time.Duration, accept func(name string) bool, handle func(ctx context.Context, paths []string)) *Watcher {
+	return &Watcher{
+		dir:      dir,
+		interval: interval,
+		accept:   accept,
+		handle:   handle,
+		pending:  make(map[string]fileState),
+		handled:  make(map[string]fileState),
+	}
+}
+
+// Dir returns the watched directory
+func (w *Watcher) Dir() string {
+	return w.dir
+}
+
+// Interval returns the polling interval
+func (w *Watcher) Interval() time.Duration {
+	return w.interval
+}
+
+// Err returns the error of the last poll, or nil if it succeeded
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastErr
+}
+
+// Running reports whether the watcher has been started and not stopped
+func (w *Watcher) Running() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cancel != nil
+}
+
+// Start polls the directory in the background until Stop is called
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel, w.done = cancel, make(chan struct{})
+	go w.loop(ctx)
+}
+
+// Stop stops polling and waits for a running handler to return
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// loop polls until ctx is done
+func (w *Watcher) loop(ctx context.Context) {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if ready := w.poll(); len(ready) > 0 {
+			w.handle(ctx, ready)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll lists the directory and returns the files that are ready
+func (w *Watcher) poll() []string {
+	entries, err := os.ReadDir(w.dir)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err != nil {
+		if w.lastErr == nil || w.lastErr.Error() != err.Error() {
+			log.Printf("Watcher %s: %v", w.dir, err)
+		}
+		w.lastErr = err
+		return nil
+	}
+	w.lastErr = nil
+
+	current := make(map[string]fileState)
+	var ready []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || isTemporary(name) || !w.accept(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since the listing
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		current[name] = state
+
+		if handled, ok := w.handled[name]; ok && handled == state {
+			continue
+		}
+		delete(w.handled, name)
+		if prev, ok := w.pending[name]; ok && prev == state {
+			ready = append(ready, name)
+		}
+	}
+	w.pending = current
+	for name := range w.handled {
+		if _, ok := current[name]; !ok {
+			delete(w.handled, name)
+		}
+	}
+
+	sort.Strings(ready)
+	paths := make([]string, len(ready))
+	for i, name := range ready {
+		w.handled[name] = current[name]
+		paths[i] = filepath.Join(w.dir, name)
+	}
+	return paths
+}
+
+// isTemporary reports whether name is a hidden file or a partial download
+// (Syncthing writes .syncthing.*.tmp, Dropbox and browsers use these suffixes)
+func isTemporary(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") || strings.HasSuffix(name, "~") {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".tmp", ".part", ".partial", ".crdownload", ".download":
+		return true
+	}
+	return false
+}
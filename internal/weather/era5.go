@@ -0,0 +1,123 @@
+package weather
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ERA5Provider serves hourly weather from ERA5 extracts held in memory
+// The extract is a CSV file as exported by the Copernicus Climate Data Store
+// for "ERA5 hourly data on single levels", with a header naming the columns
+// valid_time (or time), latitude, longitude, t2m (K) and tp (m). Times are
+// UTC, either Unix seconds or "2006-01-02 15:04:05". Locations are answered
+// from the nearest grid point within one cell.
+type ERA5Provider struct {
+	cells map[string][]Hour // sorted by time
+}
+
+// LoadERA5Provider reads an ERA5 CSV extract
+func LoadERA5Provider(path string) (*ERA5Provider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ERA5 extract: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ERA5 header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := col["time"]; !ok {
+		if i, ok := col["valid_time"]; ok {
+			col["time"] = i
+		}
+	}
+	for _, name := range []string{"time", "latitude", "longitude", "t2m", "tp"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("ERA5 extract has no %s column", name)
+		}
+	}
+
+	p := &ERA5Provider{cells: make(map[string][]Hour)}
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ERA5 extract: %w", err)
+		}
+
+		t, err := parseERA5Time(rec[col["time"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.TrimSpace(rec[col["t2m"]]) == "" || strings.TrimSpace(rec[col["tp"]]) == "" {
+			continue // missing values over the requested area
+		}
+		var v [4]float64
+		for i, name := range []string{"latitude", "longitude", "t2m", "tp"} {
+			s := strings.TrimSpace(rec[col[name]])
+			if v[i], err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s %q", line, name, s)
+			}
+		}
+
+		key := Cell(v[0], v[1])
+		p.cells[key] = append(p.cells[key], Hour{Time: t, TempC: v[2] - 273.15, PrecipMM: v[3] * 1000})
+	}
+
+	for _, hours := range p.cells {
+		sort.Slice(hours, func(i, j int) bool { return hours[i].Time < hours[j].Time })
+	}
+	return p, nil
+}
+
+func parseERA5Time(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ts, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time %q", s)
+}
+
+// Name returns the provider name
+func (p *ERA5Provider) Name() string {
+	return "era5"
+}
+
+// Cells returns the number of grid points in the extract
+func (p *ERA5Provider) Cells() int {
+	return len(p.cells)
+}
+
+// Hourly returns the extract's hours at the grid point of the location
+// within the UTC days from start to end
+func (p *ERA5Provider) Hourly(ctx context.Context, lat, lon float64, start, end time.Time) ([]Hour, error) {
+	hours := p.cells[Cell(lat, lon)]
+	from := time.Date(start.UTC().Year(), start.UTC().Month(), start.UTC().Day(), 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(end.UTC().Year(), end.UTC().Month(), end.UTC().Day()+1, 0, 0, 0, 0, time.UTC).Unix()
+
+	i := sort.Search(len(hours), func(i int) bool { return hours[i].Time >= from })
+	j := sort.Search(len(hours), func(i int) bool { return hours[i].Time >= to })
+	return append([]Hour(nil), hours[i:j]...), nil
+}
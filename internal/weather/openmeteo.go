@@ -0,0 +1,93 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openMeteoDefaultURL is the public historical weather API of Open-Meteo
+const openMeteoDefaultURL = "https://archive-api.open-meteo.com"
+
+// OpenMeteoProvider queries the historical weather API of Open-Meteo
+// The API serves ERA5 reanalysis from 1940 with a delay of about five days.
+// The free tier allows 10,000 requests per day; each request covers one
+// location over any date range.
+type OpenMeteoProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenMeteoProvider creates a provider for the Open-Meteo archive API at
+// baseURL, the public instance when empty
+func NewOpenMeteoProvider(baseURL string) *OpenMeteoProvider {
+	if baseURL == "" {
+		baseURL = openMeteoDefaultURL
+	}
+	return &OpenMeteoProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Name returns the provider name
+func (p *OpenMeteoProvider) Name() string {
+	return "openmeteo"
+}
+
+type openMeteoResponse struct {
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+	Hourly struct {
+		Time          []int64    `json:"time"`
+		Temperature   []*float64 `json:"temperature_2m"` // null where there is no data yet
+		Precipitation []*float64 `json:"precipitation"`
+	} `json:"hourly"`
+}
+
+// Hourly looks up the hourly temperature and precipitation of the UTC days
+// from start to end
+func (p *OpenMeteoProvider) Hourly(ctx context.Context, lat, lon float64, start, end time.Time) ([]Hour, error) {
+	endpoint := p.baseURL + "/v1/archive?" + url.Values{
+		"latitude":   {strconv.FormatFloat(lat, 'f', 4, 64)},
+		"longitude":  {strconv.FormatFloat(lon, 'f', 4, 64)},
+		"start_date": {start.UTC().Format("2006-01-02")},
+		"end_date":   {end.UTC().Format("2006-01-02")},
+		"hourly":     {"temperature_2m,precipitation"},
+		"timezone":   {"GMT"},
+		"timeformat": {"unixtime"},
+	}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Open-Meteo request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Open-Meteo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode Open-Meteo response (status %d): %w", resp.StatusCode, err)
+	}
+	if body.Error || resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Open-Meteo query failed with status %d: %s", resp.StatusCode, body.Reason)
+	}
+
+	h := body.Hourly
+	hours := make([]Hour, 0, len(h.Time))
+	for i, t := range h.Time {
+		if i >= len(h.Temperature) || i >= len(h.Precipitation) || h.Temperature[i] == nil || h.Precipitation[i] == nil {
+			continue
+		}
+		hours = append(hours, Hour{Time: t, TempC: *h.Temperature[i], PrecipMM: *h.Precipitation[i]})
+	}
+	return hours, nil
+}
@@ -0,0 +1,69 @@
+// Package weather looks up historical hourly weather at visited locations.
+//
+// Lookups go through the Provider interface so the Open-Meteo historical
+// weather API (ERA5 reanalysis) can be used online, and ERA5 extracts
+// downloaded from the Copernicus Climate Data Store offline. A process-wide
+// default provider is set at startup and used by the weather_enrichment
+// analyzer, which aggregates the hours into days and trips.
+package weather
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrNoProvider is returned when no weather provider has been configured
+var ErrNoProvider = errors.New("no weather provider configured")
+
+// CellDeg is the size of the grid cells weather is looked up for, the ERA5
+// grid spacing
+const CellDeg = 0.25
+
+// Hour is the weather of one hour at a location
+type Hour struct {
+	Time     int64   // Unix timestamp, on the hour
+	TempC    float64 // 2 m air temperature at Time
+	PrecipMM float64 // Precipitation in the hour ending at Time
+}
+
+// Provider returns the hourly weather at a location between two times
+// Hours the provider has no data for (e.g. the last days, not yet in the
+// reanalysis) are left out.
+type Provider interface {
+	Hourly(ctx context.Context, lat, lon float64, start, end time.Time) ([]Hour, error)
+	Name() string
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultProvider Provider
+)
+
+// SetDefaultProvider sets the provider used by the weather analyzer
+func SetDefaultProvider(p Provider) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultProvider = p
+}
+
+// DefaultProvider returns the configured provider, or nil if none was set
+func DefaultProvider() Provider {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultProvider
+}
+
+// Cell returns the key of the grid cell containing a location, e.g. "23.00,113.25"
+func Cell(lat, lon float64) string {
+	cLat, cLon := CellCenter(lat, lon)
+	return strconv.FormatFloat(cLat, 'f', 2, 64) + "," + strconv.FormatFloat(cLon, 'f', 2, 64)
+}
+
+// CellCenter returns the grid point nearest to a location
+func CellCenter(lat, lon float64) (float64, float64) {
+	return math.Round(lat/CellDeg) * CellDeg, math.Round(lon/CellDeg) * CellDeg
+}
@@ -342,6 +342,19 @@ class StayDetectionWorker(IncrementalAnalyzer):
                     WHERE dataTime >= ? AND dataTime <= ?
                 """, (stay_id, stay['start_time'], stay['end_time']))
 
+            # A stay belongs to the source that recorded most of its points
+            self.conn.execute("""
+                UPDATE stay_segments
+                SET source = (
+                    SELECT p.source FROM "一生足迹" p
+                    WHERE p.dataTime BETWEEN stay_segments.start_time AND stay_segments.end_time
+                    GROUP BY p.source
+                    ORDER BY COUNT(*) DESC, p.source
+                    LIMIT 1
+                )
+                WHERE source IS NULL
+            """)
+
             self.conn.commit()
 
         except Exception as e:
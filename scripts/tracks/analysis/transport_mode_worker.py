@@ -29,6 +29,21 @@ sys.path.append('/app/scripts/common')
 from incremental_analyzer import IncrementalAnalyzer
 
 
+# Admin area of a segment: a column of the earlier geocoded of its start and
+# end points, as SegmentAreaValues in internal/analysis/segments.go; takes both
+# point ids as parameters
+SEGMENT_AREA_EXPR = """(
+    SELECT {column} FROM "一生足迹"
+    WHERE id IN (?, ?) AND province != ''
+    ORDER BY dataTime
+    LIMIT 1
+)"""
+
+SEGMENT_AREA_VALUES = ", ".join(
+    SEGMENT_AREA_EXPR.format(column=column) for column in ("province", "city", "county")
+)
+
+
 class TransportModeWorker(IncrementalAnalyzer):
     """Worker for transport mode classification"""
 
@@ -306,14 +321,16 @@ class TransportModeWorker(IncrementalAnalyzer):
                     INSERT INTO segments (
                         mode, start_time, end_time, start_point_id, end_point_id,
                         point_count, distance_m, duration_s, avg_speed_kmh,
-                        max_speed_kmh, confidence, reason_codes, metadata
-                    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+                        max_speed_kmh, confidence, reason_codes, metadata,
+                        province, city, county
+                    ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, """ + SEGMENT_AREA_VALUES + """)
                 """, (
                     segment['mode'], segment['start_time'], segment['end_time'],
                     segment['start_point_id'], segment['end_point_id'],
                     segment['point_count'], segment['distance_m'], segment['duration_s'],
                     segment['avg_speed_kmh'], segment['max_speed_kmh'],
-                    segment['confidence'], segment['reason_codes'], segment['metadata']
+                    segment['confidence'], segment['reason_codes'], segment['metadata'],
+                    *(segment['start_point_id'], segment['end_point_id']) * 3
                 ))
                 segment_id = cursor.lastrowid
 
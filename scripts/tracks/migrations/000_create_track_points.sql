@@ -0,0 +1,18 @@
+-- Migration 000: Create the base track point table
+-- Purpose: Let a fresh database start from an empty file. The table is normally
+--          created by the import script (scripts/tracks/import/write2sql.py);
+--          later migrations add the admin, QA and metadata columns.
+
+CREATE TABLE IF NOT EXISTS "一生足迹" (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    dataTime INTEGER,                    -- Unix timestamp (seconds)
+    longitude REAL,
+    latitude REAL,
+    heading REAL,
+    accuracy REAL,
+    speed REAL,
+    distance REAL,
+    altitude REAL,
+    time_visually TEXT,                  -- Format: 2025/01/22 21:42:18.000
+    time TEXT                            -- Format: 20250122214218
+);
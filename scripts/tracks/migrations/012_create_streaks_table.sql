@@ -0,0 +1,17 @@
+-- Migration 012: Create streaks table
+-- Skill: streak_detection
+-- Purpose: Store runs of consecutive active days
+
+CREATE TABLE IF NOT EXISTS streaks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_date TEXT NOT NULL,            -- YYYY-MM-DD
+    end_date TEXT NOT NULL,              -- YYYY-MM-DD
+    days_count INTEGER NOT NULL,
+    total_distance_m REAL,
+    total_duration_s INTEGER,
+    algo_version TEXT DEFAULT 'v1',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_streaks_days ON streaks(days_count DESC);
+CREATE INDEX IF NOT EXISTS idx_streaks_start ON streaks(start_date);
@@ -214,38 +214,9 @@ CREATE TABLE IF NOT EXISTS compressed_trajectories (
 CREATE INDEX IF NOT EXISTS idx_compressed_trajectories_ts ON compressed_trajectories(start_ts);
 CREATE INDEX IF NOT EXISTS idx_compressed_trajectories_ratio ON compressed_trajectories(compression_ratio);
 
--- 11. Trips Table (Enhanced version from Phase 4)
--- Trip records constructed from segments and stays
-CREATE TABLE IF NOT EXISTS trips (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    start_ts INTEGER NOT NULL,
-    end_ts INTEGER NOT NULL,
-    origin_lat REAL,
-    origin_lon REAL,
-    origin_province TEXT,
-    origin_city TEXT,
-    origin_county TEXT,
-    dest_lat REAL,
-    dest_lon REAL,
-    dest_province TEXT,
-    dest_city TEXT,
-    dest_county TEXT,
-    total_distance_m REAL,
-    duration_s INTEGER,
-    primary_mode TEXT, -- WALK/BIKE/CAR/TRAIN/PLANE
-    segment_count INTEGER DEFAULT 0,
-    stay_count INTEGER DEFAULT 0,
-    purpose TEXT, -- COMMUTE/LEISURE/TRAVEL/UNKNOWN
-    confidence REAL,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    algo_version TEXT DEFAULT 'v1'
-);
-
-CREATE INDEX IF NOT EXISTS idx_trips_start_ts ON trips(start_ts);
-CREATE INDEX IF NOT EXISTS idx_trips_end_ts ON trips(end_ts);
-CREATE INDEX IF NOT EXISTS idx_trips_origin_city ON trips(origin_city);
-CREATE INDEX IF NOT EXISTS idx_trips_dest_city ON trips(dest_city);
-CREATE INDEX IF NOT EXISTS idx_trips_mode ON trips(primary_mode);
+-- 11. Trips Table
+-- Defined in 007_create_trips_table.sql; origin/destination and primary mode
+-- columns are added by 030_trip_endpoints.sql
 
 -- 12. Time Axis Markers Table
 -- Timeline visualization metadata
@@ -0,0 +1,28 @@
+-- Migration 026: Add analyzer threshold parameters to the default threshold profile
+-- Purpose: TransportModeAnalyzer, SpeedEventsAnalyzer and OutlierDetectionAnalyzer read
+--          their thresholds from the active profile instead of hard-coded values
+-- Keys carry explicit units; existing keys are kept for compatibility
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.transport_mode.walk_max_speed_mps', 2.0,
+        '$.transport_mode.bike_max_speed_mps', 8.0,
+        '$.transport_mode.car_max_speed_mps', 40.0,
+        '$.transport_mode.train_max_speed_mps', 60.0,
+        '$.transport_mode.min_segment_duration_s', 10,
+        '$.speed_events', json('{
+            "min_event_speed_mps": 33.33,
+            "min_event_duration_s": 60,
+            "allowed_gap_s": 10
+        }'),
+        '$.outlier_detection.max_speed_mps', 277.78,
+        '$.outlier_detection.max_accuracy_m', 100.0,
+        '$.outlier_detection.jump_distance_m', 1000.0,
+        '$.outlier_detection.jump_time_s', 10,
+        '$.outlier_detection.backtrack_radius_m', 20.0,
+        '$.outlier_detection.static_drift_radius_m', 50.0
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
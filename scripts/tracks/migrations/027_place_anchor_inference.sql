@@ -0,0 +1,10 @@
+-- Migration 027: Support automatically inferred place anchors
+-- Skill: place_anchor (Home/Work anchor inference)
+-- Purpose: Distinguish inferred anchors from manually entered ones so that
+--          re-running inference never overwrites manual anchors
+
+ALTER TABLE place_anchors ADD COLUMN source TEXT DEFAULT 'manual';  -- manual, inferred
+ALTER TABLE place_anchors ADD COLUMN confidence REAL;
+ALTER TABLE place_anchors ADD COLUMN metadata TEXT;                 -- JSON object with inference evidence
+
+CREATE INDEX IF NOT EXISTS idx_place_anchors_source ON place_anchors(source);
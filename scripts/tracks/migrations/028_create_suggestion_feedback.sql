@@ -0,0 +1,16 @@
+-- Migration 028: Suggestion feedback for stay label suggestions
+-- Skill: stay_annotation (Stay Annotation feedback loop)
+-- Purpose: Learn per-rule confidence weights from confirmed/rejected annotations
+--
+-- Feedback source is stay_annotations.confirmed:
+--   1  = label confirmed by the user
+--   0  = suggested, no feedback yet
+--   -1 = label rejected by the user
+
+CREATE TABLE IF NOT EXISTS suggestion_feedback (
+    rule TEXT PRIMARY KEY,               -- Rule ID, e.g. HOME_OVERNIGHT, WORK_WEEKDAY_DAYTIME
+    confirmed_count INTEGER DEFAULT 0,   -- Suggestions from this rule that matched a confirmed label
+    rejected_count INTEGER DEFAULT 0,    -- Suggestions from this rule that contradicted user feedback
+    weight REAL DEFAULT 1.0,             -- Multiplier applied to the rule's base confidence
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,9 @@
+-- Migration 029: Trip purpose probabilities
+-- Skill: trip_construction (Trip purpose inference)
+-- Purpose: Store a probability distribution over trip purposes instead of a
+--          single label; purpose_ml/confidence_ml keep the most likely purpose
+
+ALTER TABLE trips ADD COLUMN purpose_probs TEXT;  -- JSON object, e.g. {"COMMUTE":0.72,"LEISURE":0.1,...}
+ALTER TABLE trips ADD COLUMN day_type TEXT;       -- WORKDAY, WEEKEND, HOLIDAY (Chinese public holiday calendar)
+
+CREATE INDEX IF NOT EXISTS idx_trips_day_type ON trips(day_type);
@@ -0,0 +1,20 @@
+-- Migration 030: Trip origin/destination and primary mode
+-- Skill: trip_construction
+-- Purpose: Denormalize trip endpoints (from linked stays, or the first/last track point)
+--          so trips can be filtered by admin area and aggregated into OD matrices
+
+ALTER TABLE trips ADD COLUMN primary_mode TEXT;  -- Mode covering the longest distance
+ALTER TABLE trips ADD COLUMN origin_lat REAL;
+ALTER TABLE trips ADD COLUMN origin_lon REAL;
+ALTER TABLE trips ADD COLUMN origin_province TEXT;
+ALTER TABLE trips ADD COLUMN origin_city TEXT;
+ALTER TABLE trips ADD COLUMN origin_county TEXT;
+ALTER TABLE trips ADD COLUMN dest_lat REAL;
+ALTER TABLE trips ADD COLUMN dest_lon REAL;
+ALTER TABLE trips ADD COLUMN dest_province TEXT;
+ALTER TABLE trips ADD COLUMN dest_city TEXT;
+ALTER TABLE trips ADD COLUMN dest_county TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_trips_primary_mode ON trips(primary_mode);
+CREATE INDEX IF NOT EXISTS idx_trips_origin_city ON trips(origin_province, origin_city);
+CREATE INDEX IF NOT EXISTS idx_trips_dest_city ON trips(dest_province, dest_city);
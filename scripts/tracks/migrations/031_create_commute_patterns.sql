@@ -0,0 +1,35 @@
+-- Migration 031: Create commute_patterns table
+-- Skill: commute (Commute Pattern Detection)
+-- Purpose: Store recurring HOME<->WORK trip patterns per direction and weekday
+
+CREATE TABLE IF NOT EXISTS commute_patterns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    direction TEXT NOT NULL,             -- HOME_TO_WORK, WORK_TO_HOME
+    weekday INTEGER NOT NULL,            -- 0=Sunday ... 6=Saturday
+    trip_count INTEGER NOT NULL,
+
+    -- Departure time (minutes since local midnight)
+    median_departure_min INTEGER,
+    p25_departure_min INTEGER,
+    p75_departure_min INTEGER,
+
+    -- Duration and distance
+    median_duration_s INTEGER,
+    avg_duration_s REAL,
+    p90_duration_s INTEGER,
+    avg_distance_m REAL,
+
+    -- Route variability: 1 - mean Jaccard similarity of visited cells to the typical route (0 = always the same route)
+    route_variability REAL,
+
+    mode_split TEXT,                     -- JSON object, primary mode -> share of trips
+    first_trip_ts INTEGER,
+    last_trip_ts INTEGER,
+
+    algo_version TEXT DEFAULT 'v1',
+    created_at INTEGER,
+
+    UNIQUE(direction, weekday)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commute_patterns_direction ON commute_patterns(direction);
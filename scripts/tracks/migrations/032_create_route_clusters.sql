@@ -0,0 +1,31 @@
+-- Migration 032: Create route_clusters table
+-- Skill: route_clustering (Frequent Route Mining)
+-- Purpose: Store clusters of trips with similar geometry ("frequent routes")
+
+CREATE TABLE IF NOT EXISTS route_clusters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trip_count INTEGER NOT NULL,
+    representative_trip_id INTEGER,      -- Medoid trip of the cluster
+    polyline TEXT NOT NULL,              -- JSON array of [lat, lon] (resampled representative route)
+    start_lat REAL,
+    start_lon REAL,
+    end_lat REAL,
+    end_lon REAL,
+    primary_mode TEXT,                   -- Most common primary mode of member trips
+    avg_distance_m REAL,
+    avg_duration_s REAL,
+    min_duration_s INTEGER,
+    max_duration_s INTEGER,
+    avg_speed_kmh REAL,
+    median_speed_kmh REAL,
+    max_speed_kmh REAL,                  -- Fastest member trip (average speed)
+    mean_dtw_m REAL,                     -- Mean DTW distance of members to the representative
+    first_used_ts INTEGER,
+    last_used_ts INTEGER,
+    trip_ids TEXT,                       -- JSON array of member trip IDs
+    algo_version TEXT DEFAULT 'v1',
+    created_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_route_clusters_trip_count ON route_clusters(trip_count DESC);
+CREATE INDEX IF NOT EXISTS idx_route_clusters_mode ON route_clusters(primary_mode);
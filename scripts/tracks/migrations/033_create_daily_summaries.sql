@@ -0,0 +1,33 @@
+-- Migration 033: Create daily_summaries table
+-- Skill: daily_summary (Daily Summary Rollup)
+-- Purpose: One row per local calendar day so timeline / "year in pixels" views
+--          need a single range query instead of many stats queries
+
+CREATE TABLE IF NOT EXISTS daily_summaries (
+    date TEXT PRIMARY KEY,               -- YYYY-MM-DD (local time)
+    weekday INTEGER NOT NULL,            -- 0=Sunday ... 6=Saturday
+    day_type TEXT,                       -- WORKDAY, WEEKEND, HOLIDAY
+
+    point_count INTEGER DEFAULT 0,
+    total_distance_m REAL DEFAULT 0,
+    distance_by_mode TEXT,               -- JSON object, mode -> meters
+    primary_mode TEXT,                   -- Mode covering the longest distance
+    active_time_s INTEGER DEFAULT 0,     -- Time spent in movement segments
+    max_speed_kmh REAL DEFAULT 0,
+    step_equivalent INTEGER DEFAULT 0,   -- Walking distance / average stride length
+
+    trip_count INTEGER DEFAULT 0,
+    stay_count INTEGER DEFAULT 0,
+    first_movement_ts INTEGER,           -- Start of the first movement segment
+    last_movement_ts INTEGER,            -- End of the last movement segment
+
+    provinces TEXT,                      -- JSON array of provinces visited
+    cities TEXT,                         -- JSON array of cities visited
+    city_count INTEGER DEFAULT 0,
+    county_count INTEGER DEFAULT 0,
+
+    algo_version TEXT DEFAULT 'v1',
+    created_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_summaries_distance ON daily_summaries(total_distance_m DESC);
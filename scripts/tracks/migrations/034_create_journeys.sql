@@ -0,0 +1,44 @@
+-- Migration 034: Create journeys table
+-- Module: Flights and train journeys
+-- Purpose: Booked flight / train journeys imported from App in the Air CSV,
+--          12306 order exports or entered manually, linked to the PLANE /
+--          TRAIN segments detected in the trajectory
+
+CREATE TABLE IF NOT EXISTS journeys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    journey_type TEXT NOT NULL,          -- FLIGHT, TRAIN
+    number TEXT NOT NULL DEFAULT '',     -- Flight number (CZ3101) or train number (G79)
+    carrier TEXT,                        -- Airline / railway operator
+
+    departure_time INTEGER NOT NULL,     -- Unix timestamp
+    arrival_time INTEGER,                -- Unix timestamp
+    duration_s INTEGER,
+
+    origin_code TEXT,                    -- IATA code for airports
+    origin_name TEXT NOT NULL,
+    origin_city TEXT,
+    origin_lat REAL,
+    origin_lon REAL,
+    dest_code TEXT,
+    dest_name TEXT NOT NULL,
+    dest_city TEXT,
+    dest_lat REAL,
+    dest_lon REAL,
+
+    distance_m REAL,                     -- Great-circle distance between origin and destination
+    booking_class TEXT,                  -- Economy / 二等座 ...
+    seat TEXT,
+
+    segment_id INTEGER,                  -- Matching PLANE / TRAIN segment
+    source TEXT NOT NULL DEFAULT 'manual', -- appintheair, 12306, manual
+    notes TEXT,
+
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+
+    FOREIGN KEY (segment_id) REFERENCES segments(id) ON DELETE SET NULL,
+    UNIQUE (journey_type, number, departure_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_journeys_type_time ON journeys(journey_type, departure_time);
+CREATE INDEX IF NOT EXISTS idx_journeys_segment ON journeys(segment_id);
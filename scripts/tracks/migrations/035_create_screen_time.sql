@@ -0,0 +1,26 @@
+-- Migration 035: Create screen_time_usage table
+-- Module: Screen time
+-- Purpose: Per-app daily phone usage imported from iOS Screen Time exports
+--          and Android Digital Wellbeing CSV files
+
+CREATE TABLE IF NOT EXISTS screen_time_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date TEXT NOT NULL,                  -- YYYY-MM-DD (local time)
+    device TEXT NOT NULL DEFAULT '',     -- Device label given at import
+    platform TEXT,                       -- ios, android
+    app_name TEXT NOT NULL,
+    app_id TEXT,                         -- Bundle ID / package name
+    category TEXT NOT NULL DEFAULT 'Other',
+    duration_s INTEGER NOT NULL DEFAULT 0,
+    launches INTEGER DEFAULT 0,          -- Launches / pickups attributed to the app
+    notifications INTEGER DEFAULT 0,
+    source TEXT,                         -- ios_screentime, digital_wellbeing, generic
+
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+
+    UNIQUE (date, device, app_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_screen_time_date ON screen_time_usage(date);
+CREATE INDEX IF NOT EXISTS idx_screen_time_category ON screen_time_usage(category, date);
@@ -0,0 +1,25 @@
+-- Migration 036: Create input_activity_hourly table
+-- Module: Keyboard & mouse
+-- Purpose: Hourly keystroke, click and scroll counts imported from WhatPulse
+--          style CSV exports and ActivityWatch input watcher buckets
+
+CREATE TABLE IF NOT EXISTS input_activity_hourly (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    hour_start INTEGER NOT NULL,          -- Unix timestamp of the local hour start
+    date TEXT NOT NULL,                   -- YYYY-MM-DD (local time)
+    hour INTEGER NOT NULL,                -- 0-23 (local time)
+    weekday INTEGER NOT NULL,             -- 0=Sunday .. 6=Saturday
+    device TEXT NOT NULL DEFAULT '',      -- Device label given at import (or the ActivityWatch hostname)
+    keystrokes INTEGER NOT NULL DEFAULT 0,
+    clicks INTEGER NOT NULL DEFAULT 0,
+    scrolls INTEGER NOT NULL DEFAULT 0,
+    source TEXT,                          -- whatpulse, activitywatch, generic
+
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+
+    UNIQUE (hour_start, device)
+);
+
+CREATE INDEX IF NOT EXISTS idx_input_activity_date ON input_activity_hourly(date);
+CREATE INDEX IF NOT EXISTS idx_input_activity_weekday_hour ON input_activity_hourly(weekday, hour);
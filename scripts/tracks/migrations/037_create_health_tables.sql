@@ -0,0 +1,74 @@
+-- Migration 037: Create Apple Health tables
+-- Module: Health data
+-- Purpose: Steps, heart rate, workouts and sleep imported from Apple Health
+--          export.xml, plus the background import tasks that load them
+
+CREATE TABLE IF NOT EXISTS health_steps (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_name TEXT NOT NULL DEFAULT '',  -- Recording device/app, e.g. "Apple Watch"
+    start_time INTEGER NOT NULL,           -- Unix timestamp
+    end_time INTEGER NOT NULL,
+    date TEXT NOT NULL,                    -- YYYY-MM-DD of start_time (local time)
+    count REAL NOT NULL,
+    UNIQUE (source_name, start_time, end_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_steps_date ON health_steps(date);
+
+CREATE TABLE IF NOT EXISTS health_heart_rate (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_name TEXT NOT NULL DEFAULT '',
+    time INTEGER NOT NULL,                 -- Unix timestamp of the sample
+    date TEXT NOT NULL,                    -- YYYY-MM-DD (local time)
+    bpm REAL NOT NULL,
+    UNIQUE (source_name, time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_heart_rate_time ON health_heart_rate(time);
+CREATE INDEX IF NOT EXISTS idx_health_heart_rate_date ON health_heart_rate(date);
+
+CREATE TABLE IF NOT EXISTS health_workouts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    activity_type TEXT NOT NULL,           -- Running, Walking, Cycling, ... (HKWorkoutActivityType prefix removed)
+    source_name TEXT NOT NULL DEFAULT '',
+    start_time INTEGER NOT NULL,
+    end_time INTEGER NOT NULL,
+    date TEXT NOT NULL,                    -- YYYY-MM-DD of start_time (local time)
+    duration_s INTEGER NOT NULL DEFAULT 0,
+    distance_m REAL,
+    energy_kcal REAL,
+    UNIQUE (source_name, start_time, activity_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_workouts_date ON health_workouts(date);
+CREATE INDEX IF NOT EXISTS idx_health_workouts_time ON health_workouts(start_time, end_time);
+
+CREATE TABLE IF NOT EXISTS health_sleep (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_name TEXT NOT NULL DEFAULT '',
+    start_time INTEGER NOT NULL,
+    end_time INTEGER NOT NULL,
+    date TEXT NOT NULL,                    -- YYYY-MM-DD of end_time, i.e. the wake-up day (local time)
+    stage TEXT NOT NULL,                   -- IN_BED, ASLEEP, CORE, DEEP, REM, AWAKE
+    UNIQUE (source_name, start_time, stage)
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_sleep_date ON health_sleep(date);
+
+CREATE TABLE IF NOT EXISTS health_import_tasks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    status TEXT NOT NULL DEFAULT 'pending',  -- pending, running, completed, failed
+    file_name TEXT NOT NULL,
+    total_bytes INTEGER DEFAULT 0,           -- Size of export.xml (uncompressed)
+    processed_bytes INTEGER DEFAULT 0,
+    records_parsed INTEGER DEFAULT 0,        -- Records of supported types
+    records_inserted INTEGER DEFAULT 0,
+    duplicates INTEGER DEFAULT 0,
+    ignored_records INTEGER DEFAULT 0,       -- Records of other types
+    error_message TEXT,
+    created_by TEXT,
+    started_at INTEGER,
+    completed_at INTEGER,
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER))
+);
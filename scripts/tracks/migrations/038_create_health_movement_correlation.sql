@@ -0,0 +1,29 @@
+-- Migration 038: Create health_movement_correlation table
+-- Skill: health_correlation (Health × Location Correlation)
+-- Purpose: One row per local day joining imported health data (steps,
+--          workouts, heart rate) with trajectory-derived movement
+
+CREATE TABLE IF NOT EXISTS health_movement_correlation (
+    date TEXT PRIMARY KEY,               -- YYYY-MM-DD (local time)
+
+    recorded_steps INTEGER DEFAULT 0,    -- Steps of the source with the most steps that day
+    walk_distance_m REAL DEFAULT 0,      -- WALK segment distance (split at midnight)
+    moved_distance_m REAL DEFAULT 0,     -- Distance of all movement segments
+    expected_steps INTEGER DEFAULT 0,    -- walk_distance_m / average stride length
+    step_ratio REAL,                     -- recorded_steps / expected_steps
+    meters_per_step REAL,                -- walk_distance_m / recorded_steps
+
+    workout_count INTEGER DEFAULT 0,
+    workout_minutes REAL DEFAULT 0,
+    workout_distance_m REAL DEFAULT 0,
+
+    avg_heart_rate REAL,
+    high_altitude_hr_samples INTEGER DEFAULT 0,  -- Heart rate samples matched to points at high altitude
+    high_altitude_avg_hr REAL,
+    low_altitude_hr_samples INTEGER DEFAULT 0,   -- Heart rate samples matched to points below it
+    low_altitude_avg_hr REAL,
+    max_altitude_m REAL,                 -- Highest altitude of the matched points
+
+    algo_version TEXT DEFAULT 'v1',
+    created_at INTEGER
+);
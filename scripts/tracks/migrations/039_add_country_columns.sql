@@ -0,0 +1,11 @@
+-- Migration 039: Add country and world region columns to track points
+-- Skill: country_backfill (Country Lookup)
+-- Purpose: Track footprints outside China. country holds the ISO 3166-1
+--          alpha-2 code (Hong Kong, Macao and Taiwan have their own codes),
+--          region the world region (东亚, 欧洲, ...). footprint_statistics
+--          aggregates them as stat_type COUNTRY and REGION.
+
+ALTER TABLE "一生足迹" ADD COLUMN country TEXT;
+ALTER TABLE "一生足迹" ADD COLUMN region TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_admin_country ON "一生足迹"(country);
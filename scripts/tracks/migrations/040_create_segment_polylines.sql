@@ -0,0 +1,26 @@
+-- Migration 040: Create segment_polylines table
+-- Module: Visualization
+-- Purpose: Simplified segment geometry precomputed by the trajectory_simplification
+--          skill, one encoded polyline per segment and LOD (same LOD levels as
+--          render_segments_cache)
+
+CREATE TABLE IF NOT EXISTS segment_polylines (
+    segment_id INTEGER NOT NULL,
+    lod INTEGER NOT NULL,                 -- 0=low, 1=medium, 2=high
+    algorithm TEXT NOT NULL,              -- douglas_peucker, visvalingam
+    tolerance_m REAL NOT NULL,            -- Simplification tolerance in meters
+    polyline TEXT NOT NULL,               -- Encoded polyline (precision 1e5)
+    point_count INTEGER NOT NULL,         -- Points kept
+    source_point_count INTEGER NOT NULL,  -- Points before simplification
+    min_lat REAL NOT NULL,
+    min_lon REAL NOT NULL,
+    max_lat REAL NOT NULL,
+    max_lon REAL NOT NULL,
+
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+
+    PRIMARY KEY (segment_id, lod),
+    FOREIGN KEY (segment_id) REFERENCES segments(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_segment_polylines_lod_bbox ON segment_polylines(lod, min_lon, max_lon);
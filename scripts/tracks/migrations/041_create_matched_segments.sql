@@ -0,0 +1,21 @@
+-- Migration 041: Create matched_segments table
+-- Module: Spatial analysis
+-- Purpose: Road-snapped geometry of CAR segments produced by the map_matching
+--          skill. The same run replaces the segment's road_overlap_stats row
+--          (algo_version 'map_match_v1') with measured on/off-road distances.
+
+CREATE TABLE IF NOT EXISTS matched_segments (
+    segment_id INTEGER PRIMARY KEY,
+    matcher TEXT NOT NULL,                -- osrm, valhalla, hmm
+    polyline TEXT NOT NULL,               -- Encoded polyline (precision 1e5), empty when nothing matched
+    point_count INTEGER NOT NULL,         -- Trace points sent to the matcher
+    matched_point_count INTEGER NOT NULL, -- Trace points snapped to a road
+    on_road_distance_m REAL NOT NULL DEFAULT 0,
+    off_road_distance_m REAL NOT NULL DEFAULT 0,
+    road_type TEXT,                       -- Dominant road type: HIGHWAY/ARTERIAL/LOCAL/UNKNOWN
+    confidence REAL,                      -- 0~1, as reported by the matcher
+
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+
+    FOREIGN KEY (segment_id) REFERENCES segments(id)
+);
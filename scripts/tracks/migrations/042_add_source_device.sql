@@ -0,0 +1,10 @@
+-- Migration 042: Add source device provenance to track points
+-- Purpose: Merge tracks recorded by several devices/apps over the same period.
+--          source_device records which device or app each point came from;
+--          import reconciles near-duplicate points (same minute, <50m apart)
+--          from different devices and keeps the more accurate one.
+--          Points that predate provenance tracking have NULL source_device.
+
+ALTER TABLE "一生足迹" ADD COLUMN source_device TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_track_source_device ON "一生足迹"(source_device);
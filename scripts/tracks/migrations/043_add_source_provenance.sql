@@ -0,0 +1,322 @@
+-- Migration 043: Per-source data provenance
+-- Purpose: Users who combine several trackers (app A, app B, imported GPX)
+--          can view statistics per source. source records the app or import
+--          that produced each track point; points that predate provenance
+--          tracking belong to 'legacy'. Segments and stays take the source
+--          contributing most of their points.
+--          The bucketed stat tables gain a source dimension: rows with
+--          source 'all' aggregate every source, the others one source each.
+--          SQLite cannot alter UNIQUE constraints, so those tables are rebuilt
+--          with source added to their keys.
+
+ALTER TABLE "一生足迹" ADD COLUMN source TEXT NOT NULL DEFAULT 'legacy';
+CREATE INDEX IF NOT EXISTS idx_track_source ON "一生足迹"(source);
+
+ALTER TABLE segments ADD COLUMN source TEXT;
+UPDATE segments SET source = 'legacy' WHERE source IS NULL;
+CREATE INDEX IF NOT EXISTS idx_segments_source ON segments(source);
+
+ALTER TABLE stay_segments ADD COLUMN source TEXT;
+UPDATE stay_segments SET source = 'legacy' WHERE source IS NULL;
+CREATE INDEX IF NOT EXISTS idx_stay_segments_source ON stay_segments(source);
+
+-- speed_space_stats_bucketed
+CREATE TABLE speed_space_stats_bucketed_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_type TEXT NOT NULL,  -- 'year', 'month', 'all'
+    bucket_key TEXT NOT NULL,   -- '2024', '2024-01', 'all'
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+    area_type TEXT NOT NULL,    -- 'PROVINCE', 'CITY', 'COUNTY'
+    area_key TEXT NOT NULL,     -- Area name
+    avg_speed REAL NOT NULL,    -- Distance-weighted average speed (km/h)
+    speed_variance REAL,        -- Distance-weighted speed variance
+    speed_entropy REAL,         -- Shannon entropy of speed distribution
+    total_distance REAL,        -- Total distance in this area (meters)
+    segment_count INTEGER,      -- Number of segments
+    is_high_speed_zone BOOLEAN DEFAULT 0,  -- >90th percentile
+    is_slow_life_zone BOOLEAN DEFAULT 0,   -- <25th percentile
+    stay_intensity REAL,        -- Stay intensity (for correlation analysis)
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    algo_version INTEGER DEFAULT 1,
+    UNIQUE(bucket_type, bucket_key, area_type, area_key, source)
+);
+
+INSERT INTO speed_space_stats_bucketed_new (id, bucket_type, bucket_key, area_type, area_key, avg_speed, speed_variance, speed_entropy, total_distance, segment_count, is_high_speed_zone, is_slow_life_zone, stay_intensity, created_at, algo_version)
+SELECT id, bucket_type, bucket_key, area_type, area_key, avg_speed, speed_variance, speed_entropy, total_distance, segment_count, is_high_speed_zone, is_slow_life_zone, stay_intensity, created_at, algo_version
+FROM speed_space_stats_bucketed;
+
+DROP TABLE speed_space_stats_bucketed;
+ALTER TABLE speed_space_stats_bucketed_new RENAME TO speed_space_stats_bucketed;
+
+CREATE INDEX IF NOT EXISTS idx_speed_space_bucket ON speed_space_stats_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_speed_space_area ON speed_space_stats_bucketed(area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_speed_space_high_speed ON speed_space_stats_bucketed(is_high_speed_zone) WHERE is_high_speed_zone = 1;
+CREATE INDEX IF NOT EXISTS idx_speed_space_slow_life ON speed_space_stats_bucketed(is_slow_life_zone) WHERE is_slow_life_zone = 1;
+CREATE INDEX IF NOT EXISTS idx_speed_space_avg_speed ON speed_space_stats_bucketed(avg_speed DESC);
+
+-- directional_stats_bucketed
+CREATE TABLE directional_stats_bucketed_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Bucketing dimensions
+    bucket_type TEXT NOT NULL,           -- 'year', 'month', 'all'
+    bucket_key TEXT NOT NULL,            -- '2025', '2025-01', 'all'
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+    area_type TEXT NOT NULL,             -- 'PROVINCE', 'CITY', 'COUNTY'
+    area_key TEXT NOT NULL,              -- Area name (e.g., '广东省', '深圳市')
+    mode_filter TEXT DEFAULT 'ALL',      -- 'ALL', 'WALK', 'CAR', 'TRAIN', 'FLIGHT'
+
+    -- Directional histogram (JSON array of 8 bins)
+    -- Format: [{"bin": 0, "distance": 1234.5, "count": 10}, ...]
+    direction_histogram_json TEXT,
+    num_bins INTEGER DEFAULT 8,          -- Number of bins (8 or 16)
+
+    -- Key metrics
+    dominant_direction_deg REAL,         -- 0-360 degrees (weighted average of dominant bin)
+    directional_concentration REAL,      -- 0-1 (vector synthesis magnitude)
+    bidirectional_score REAL,            -- 0-1 (strength of back-and-forth pattern)
+    directional_entropy REAL,            -- 0-1 (Shannon entropy, normalized)
+
+    -- Aggregated statistics
+    total_distance REAL,                 -- Total distance in meters
+    total_duration INTEGER,              -- Total duration in seconds
+    segment_count INTEGER,               -- Number of segments
+
+    -- Metadata
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    algo_version INTEGER DEFAULT 1,
+
+    -- Ensure uniqueness per bucket combination
+    UNIQUE(bucket_type, bucket_key, area_type, area_key, mode_filter, source)
+);
+
+INSERT INTO directional_stats_bucketed_new (id, bucket_type, bucket_key, area_type, area_key, mode_filter, direction_histogram_json, num_bins, dominant_direction_deg, directional_concentration, bidirectional_score, directional_entropy, total_distance, total_duration, segment_count, created_at, algo_version)
+SELECT id, bucket_type, bucket_key, area_type, area_key, mode_filter, direction_histogram_json, num_bins, dominant_direction_deg, directional_concentration, bidirectional_score, directional_entropy, total_distance, total_duration, segment_count, created_at, algo_version
+FROM directional_stats_bucketed;
+
+DROP TABLE directional_stats_bucketed;
+ALTER TABLE directional_stats_bucketed_new RENAME TO directional_stats_bucketed;
+
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_bucket
+    ON directional_stats_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_area
+    ON directional_stats_bucketed(area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_mode
+    ON directional_stats_bucketed(mode_filter);
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_concentration
+    ON directional_stats_bucketed(directional_concentration DESC);
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_bidirectional
+    ON directional_stats_bucketed(bidirectional_score DESC);
+
+-- spatial_utilization_bucketed
+CREATE TABLE spatial_utilization_bucketed_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Time bucketing
+    bucket_type TEXT NOT NULL,  -- 'month', 'year', 'all'
+    bucket_key TEXT,            -- 'YYYY-MM', 'YYYY', NULL
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+
+    -- Spatial bucketing
+    area_type TEXT NOT NULL,    -- 'province', 'city', 'county', 'town', 'grid'
+    area_key TEXT NOT NULL,     -- Province name, city name, or grid_id
+
+    -- Core metrics from skill spec
+    transit_intensity INTEGER DEFAULT 0,      -- Distinct trip count passing through
+    stay_duration_s INTEGER DEFAULT 0,        -- Total stay duration in seconds
+    utilization_efficiency REAL DEFAULT 0,    -- stay / (transit + ε)
+    transit_dominance REAL DEFAULT 0,         -- transit / (transit + stay)
+    area_depth REAL DEFAULT 0,                -- log(1+stay) * log(1+days)
+    coverage_efficiency REAL DEFAULT 0,       -- Distinct grids / Total grids
+
+    -- Supporting data
+    distinct_visit_days INTEGER DEFAULT 0,
+    distinct_grids INTEGER DEFAULT 0,
+    total_grids INTEGER DEFAULT 0,
+    first_visit INTEGER,
+    last_visit INTEGER,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, area_type, area_key, source)
+);
+
+INSERT INTO spatial_utilization_bucketed_new (id, bucket_type, bucket_key, area_type, area_key, transit_intensity, stay_duration_s, utilization_efficiency, transit_dominance, area_depth, coverage_efficiency, distinct_visit_days, distinct_grids, total_grids, first_visit, last_visit, created_at, updated_at, algo_version)
+SELECT id, bucket_type, bucket_key, area_type, area_key, transit_intensity, stay_duration_s, utilization_efficiency, transit_dominance, area_depth, coverage_efficiency, distinct_visit_days, distinct_grids, total_grids, first_visit, last_visit, created_at, updated_at, algo_version
+FROM spatial_utilization_bucketed;
+
+DROP TABLE spatial_utilization_bucketed;
+ALTER TABLE spatial_utilization_bucketed_new RENAME TO spatial_utilization_bucketed;
+
+CREATE INDEX IF NOT EXISTS idx_util_bucket ON spatial_utilization_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_util_area ON spatial_utilization_bucketed(area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_util_efficiency ON spatial_utilization_bucketed(utilization_efficiency DESC);
+CREATE INDEX IF NOT EXISTS idx_util_dominance ON spatial_utilization_bucketed(transit_dominance DESC);
+CREATE INDEX IF NOT EXISTS idx_util_depth ON spatial_utilization_bucketed(area_depth DESC);
+
+-- spatial_density_grid_stats
+CREATE TABLE spatial_density_grid_stats_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Time bucketing
+    bucket_type TEXT NOT NULL,  -- 'year', 'rolling_90d', 'all'
+    bucket_key TEXT,            -- 'YYYY', NULL
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+
+    -- Grid identification
+    grid_id TEXT NOT NULL,
+    center_lat REAL,
+    center_lon REAL,
+
+    -- Administrative context
+    province TEXT,
+    city TEXT,
+    county TEXT,
+
+    -- Density metrics
+    density_score REAL NOT NULL,
+    density_level TEXT NOT NULL,  -- 'core', 'secondary', 'active', 'peripheral', 'rare'
+    stay_duration_s INTEGER DEFAULT 0,
+    stay_count INTEGER DEFAULT 0,
+    visit_days INTEGER DEFAULT 0,
+
+    -- Cluster information
+    cluster_id INTEGER,
+    cluster_area_km2 REAL,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, grid_id, source)
+);
+
+INSERT INTO spatial_density_grid_stats_new (id, bucket_type, bucket_key, grid_id, center_lat, center_lon, province, city, county, density_score, density_level, stay_duration_s, stay_count, visit_days, cluster_id, cluster_area_km2, created_at, updated_at, algo_version)
+SELECT id, bucket_type, bucket_key, grid_id, center_lat, center_lon, province, city, county, density_score, density_level, stay_duration_s, stay_count, visit_days, cluster_id, cluster_area_km2, created_at, updated_at, algo_version
+FROM spatial_density_grid_stats;
+
+DROP TABLE spatial_density_grid_stats;
+ALTER TABLE spatial_density_grid_stats_new RENAME TO spatial_density_grid_stats;
+
+CREATE INDEX IF NOT EXISTS idx_density_bucket ON spatial_density_grid_stats(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_density_grid ON spatial_density_grid_stats(grid_id);
+CREATE INDEX IF NOT EXISTS idx_density_score ON spatial_density_grid_stats(density_score DESC);
+CREATE INDEX IF NOT EXISTS idx_density_level ON spatial_density_grid_stats(density_level);
+CREATE INDEX IF NOT EXISTS idx_density_cluster ON spatial_density_grid_stats(cluster_id);
+
+-- altitude_stats_bucketed
+CREATE TABLE altitude_stats_bucketed_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Time bucketing
+    bucket_type TEXT NOT NULL,  -- 'year', 'month', 'all'
+    bucket_key TEXT,            -- 'YYYY', 'YYYY-MM', NULL
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+
+    -- Spatial bucketing
+    area_type TEXT NOT NULL,    -- 'PROVINCE', 'CITY', 'COUNTY', 'ALL'
+    area_key TEXT,              -- Province/city/county name, or NULL for ALL
+
+    -- Altitude statistics
+    min_altitude REAL,
+    max_altitude REAL,
+    avg_altitude REAL,
+    altitude_span REAL,         -- max - min
+
+    -- Distribution percentiles
+    p25_altitude REAL,
+    p50_altitude REAL,
+    p75_altitude REAL,
+    p90_altitude REAL,
+
+    -- Vertical movement metrics
+    total_ascent REAL DEFAULT 0,      -- Cumulative elevation gain (meters)
+    total_descent REAL DEFAULT 0,     -- Cumulative elevation loss (meters)
+    vertical_intensity REAL DEFAULT 0, -- (ascent + descent) / distance
+
+    -- Supporting data
+    point_count INTEGER DEFAULT 0,
+    segment_count INTEGER DEFAULT 0,
+    total_distance REAL DEFAULT 0,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, area_type, area_key, source)
+);
+
+INSERT INTO altitude_stats_bucketed_new (id, bucket_type, bucket_key, area_type, area_key, min_altitude, max_altitude, avg_altitude, altitude_span, p25_altitude, p50_altitude, p75_altitude, p90_altitude, total_ascent, total_descent, vertical_intensity, point_count, segment_count, total_distance, created_at, updated_at, algo_version)
+SELECT id, bucket_type, bucket_key, area_type, area_key, min_altitude, max_altitude, avg_altitude, altitude_span, p25_altitude, p50_altitude, p75_altitude, p90_altitude, total_ascent, total_descent, vertical_intensity, point_count, segment_count, total_distance, created_at, updated_at, algo_version
+FROM altitude_stats_bucketed;
+
+DROP TABLE altitude_stats_bucketed;
+ALTER TABLE altitude_stats_bucketed_new RENAME TO altitude_stats_bucketed;
+
+CREATE INDEX IF NOT EXISTS idx_altitude_bucket ON altitude_stats_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_altitude_area ON altitude_stats_bucketed(area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_altitude_span ON altitude_stats_bucketed(altitude_span DESC);
+CREATE INDEX IF NOT EXISTS idx_altitude_intensity ON altitude_stats_bucketed(vertical_intensity DESC);
+
+-- time_space_compression_bucketed
+CREATE TABLE time_space_compression_bucketed_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Time bucketing
+    bucket_type TEXT NOT NULL,  -- 'year', 'month', 'all'
+    bucket_key TEXT,            -- 'YYYY', 'YYYY-MM', NULL
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+
+    -- Spatial bucketing
+    area_type TEXT NOT NULL,    -- 'PROVINCE', 'CITY', 'COUNTY', 'ALL'
+    area_key TEXT,              -- Province/city/county name, or NULL for ALL
+
+    -- Movement intensity metrics
+    movement_intensity REAL DEFAULT 0,     -- Total distance / Total time (km/h)
+    burst_intensity REAL DEFAULT 0,        -- Max movement intensity in burst periods
+    burst_count INTEGER DEFAULT 0,         -- Number of burst periods detected
+    burst_duration_s INTEGER DEFAULT 0,    -- Total duration of burst periods
+
+    -- Activity density metrics
+    active_time_s INTEGER DEFAULT 0,       -- Time spent moving (speed > threshold)
+    inactive_time_s INTEGER DEFAULT 0,     -- Time spent stationary
+    activity_ratio REAL DEFAULT 0,         -- active_time / total_time
+    effective_movement_ratio REAL DEFAULT 0, -- Distance in active time / Total distance
+
+    -- Time-space efficiency
+    avg_speed_kmh REAL DEFAULT 0,          -- Average speed during active periods
+    max_speed_kmh REAL DEFAULT 0,          -- Maximum speed recorded
+    distance_per_day REAL DEFAULT 0,       -- Average daily distance
+    time_compression_index REAL DEFAULT 0, -- Composite efficiency metric
+
+    -- Supporting data
+    total_distance_m REAL DEFAULT 0,
+    total_duration_s INTEGER DEFAULT 0,
+    trip_count INTEGER DEFAULT 0,
+    distinct_days INTEGER DEFAULT 0,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, area_type, area_key, source)
+);
+
+INSERT INTO time_space_compression_bucketed_new (id, bucket_type, bucket_key, area_type, area_key, movement_intensity, burst_intensity, burst_count, burst_duration_s, active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio, avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index, total_distance_m, total_duration_s, trip_count, distinct_days, created_at, updated_at, algo_version)
+SELECT id, bucket_type, bucket_key, area_type, area_key, movement_intensity, burst_intensity, burst_count, burst_duration_s, active_time_s, inactive_time_s, activity_ratio, effective_movement_ratio, avg_speed_kmh, max_speed_kmh, distance_per_day, time_compression_index, total_distance_m, total_duration_s, trip_count, distinct_days, created_at, updated_at, algo_version
+FROM time_space_compression_bucketed;
+
+DROP TABLE time_space_compression_bucketed;
+ALTER TABLE time_space_compression_bucketed_new RENAME TO time_space_compression_bucketed;
+
+CREATE INDEX IF NOT EXISTS idx_tsc_bucket ON time_space_compression_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_tsc_area ON time_space_compression_bucketed(area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_tsc_intensity ON time_space_compression_bucketed(movement_intensity DESC);
+CREATE INDEX IF NOT EXISTS idx_tsc_burst ON time_space_compression_bucketed(burst_intensity DESC);
+CREATE INDEX IF NOT EXISTS idx_tsc_efficiency ON time_space_compression_bucketed(time_compression_index DESC);
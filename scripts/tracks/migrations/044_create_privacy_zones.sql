@@ -0,0 +1,24 @@
+-- Migration 044: Create privacy_zones table
+-- Purpose: User-defined areas (e.g. around home) whose track points are
+--          excluded or fuzzed in exports and map rendering endpoints.
+--          Points stay untouched in the database, so private statistics
+--          still count them. Zones are soft-deleted: deleted_at hides a zone
+--          without losing it, and restoring clears it again.
+
+CREATE TABLE IF NOT EXISTS privacy_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    shape TEXT NOT NULL,              -- 'circle' or 'polygon'
+    center_lat REAL,                  -- circle only
+    center_lon REAL,                  -- circle only
+    radius_m REAL,                    -- circle only
+    polygon_json TEXT,                -- polygon only: JSON array of [lon, lat] vertices
+    action TEXT NOT NULL DEFAULT 'exclude',  -- 'exclude' drops points, 'fuzz' snaps them to a coarse grid
+    fuzz_m REAL,                      -- Fuzz grid size in meters (default: zone radius, or 500 m)
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP              -- Soft delete
+);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_zones_deleted ON privacy_zones(deleted_at);
@@ -0,0 +1,19 @@
+-- Migration 045: Add the adaptive outlier rule parameters to the default threshold profile
+-- Purpose: OutlierDetectionAnalyzer can run the BACKTRACK and STATIC_DRIFT rules
+--          again, with radii adapted to each device's accuracy baseline and
+--          corroborated by reported speed and accuracy. Both rules stay off
+--          until enable_backtrack / enable_static_drift are set in a profile.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.outlier_detection.enable_backtrack', json('false'),
+        '$.outlier_detection.enable_static_drift', json('false'),
+        '$.outlier_detection.backtrack_window_s', 120,
+        '$.outlier_detection.stationary_speed_mps', 0.5,
+        '$.outlier_detection.accuracy_factor', 2.0,
+        '$.outlier_detection.drift_window_size', 10
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
@@ -0,0 +1,21 @@
+-- Migration 046: Add the Hampel outlier pass parameters to the default threshold profile
+-- Purpose: OutlierDetectionAnalyzer can complement its fixed thresholds with a
+--          rolling median absolute deviation test on reported and implied
+--          speed. k is set per transport mode; the pass stays off until
+--          enable_hampel is set in a profile.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.outlier_detection.enable_hampel', json('false'),
+        '$.outlier_detection.hampel_window', 7,
+        '$.outlier_detection.hampel_min_mad_mps', 0.5,
+        '$.outlier_detection.hampel_k', 3.5,
+        '$.outlier_detection.hampel_k_walk', 3.0,
+        '$.outlier_detection.hampel_k_car', 4.0,
+        '$.outlier_detection.hampel_k_train', 5.0,
+        '$.outlier_detection.hampel_k_flight', 6.0
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
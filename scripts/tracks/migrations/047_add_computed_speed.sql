@@ -0,0 +1,19 @@
+-- Migration 047: Speed computed from positions
+-- Purpose: Many datasets have speed NULL or 0. ComputedSpeedAnalyzer fills
+--          computed_speed (m/s) from the distance and time between
+--          consecutive points, smoothed with a rolling median. Transport mode
+--          classification, speed events and speed statistics fall back to it
+--          where the device reported no speed.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+ALTER TABLE "一生足迹" ADD COLUMN computed_speed REAL;
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.computed_speed.smoothing_window', 2,
+        '$.computed_speed.max_gap_s', 300,
+        '$.computed_speed.max_speed_mps', 277.78
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
@@ -0,0 +1,24 @@
+-- Migration 048: Elevation enrichment from a DEM
+-- Purpose: Altitude is often missing or barometrically noisy.
+--          ElevationBackfillAnalyzer looks up the terrain elevation of each
+--          point (offline SRTM tiles or an OpenTopoData server), fills missing
+--          altitudes and replaces implausible ones. dem_elevation marks the
+--          points already checked; replaced device readings are kept in
+--          altitude_raw so a full recompute can restore them.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+ALTER TABLE "一生足迹" ADD COLUMN dem_elevation REAL;
+ALTER TABLE "一生足迹" ADD COLUMN altitude_raw REAL;
+ALTER TABLE "一生足迹" ADD COLUMN altitude_source TEXT;  -- NULL (device), 'DEM_FILLED', 'DEM_CORRECTED'
+
+CREATE INDEX IF NOT EXISTS idx_track_dem_unchecked ON "一生足迹"(dataTime) WHERE dem_elevation IS NULL;
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.elevation_backfill.max_deviation_m', 50,
+        '$.elevation_backfill.max_offset_m', 100,
+        '$.elevation_backfill.min_offset_points', 5
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
@@ -0,0 +1,45 @@
+-- Migration 049: Per-mode distance and duration rollups
+-- Purpose: ModeStatsAnalyzer totals distance, duration, segments and trips per
+--          transport mode by year and month, with a CO2-equivalent estimate
+--          from per-mode emission factors (g CO2e per km) kept in the
+--          threshold profile.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+CREATE TABLE IF NOT EXISTS mode_stats_bucketed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Bucketing dimensions
+    bucket_type TEXT NOT NULL,           -- 'year', 'month', 'all'
+    bucket_key TEXT NOT NULL,            -- '2025', '2025-01', 'all'
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+    mode TEXT NOT NULL,                  -- Segment mode: 'WALK', 'BIKE', 'CAR', 'TRAIN', 'PLANE', ...
+
+    -- Totals
+    distance_m REAL DEFAULT 0,
+    duration_s INTEGER DEFAULT 0,
+    segment_count INTEGER DEFAULT 0,
+    trip_count INTEGER DEFAULT 0,        -- Distinct trips with a segment of this mode
+    co2_kg REAL DEFAULT 0,               -- distance_km * emission factor of the mode
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, source, mode)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mode_stats_bucket ON mode_stats_bucketed(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_mode_stats_mode ON mode_stats_bucketed(mode);
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.mode_stats.co2_g_per_km_walk', 0,
+        '$.mode_stats.co2_g_per_km_bike', 0,
+        '$.mode_stats.co2_g_per_km_car', 170,
+        '$.mode_stats.co2_g_per_km_train', 35,
+        '$.mode_stats.co2_g_per_km_plane', 250,
+        '$.mode_stats.co2_g_per_km_other', 0
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
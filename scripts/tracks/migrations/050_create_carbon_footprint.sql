@@ -0,0 +1,56 @@
+-- Migration 050: Carbon footprint estimation
+-- Purpose: emission_factors is the emissions model shared by every CO2
+--          estimate (g CO2e per km per mode), editable through the admin API.
+--          CarbonFootprintAnalyzer stores the footprint of each trip and the
+--          monthly and yearly totals. ModeStatsAnalyzer reads its factors
+--          from emission_factors too, so they are dropped from the threshold
+--          profile.
+
+CREATE TABLE IF NOT EXISTS emission_factors (
+    mode TEXT PRIMARY KEY,               -- Segment mode: 'WALK', 'CAR', 'TRAIN', ...
+    g_co2_per_km REAL NOT NULL,          -- Grams CO2-equivalent per passenger km
+    default_g_co2_per_km REAL NOT NULL,  -- Built-in value, restored by a reset
+    description TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT OR IGNORE INTO emission_factors (mode, g_co2_per_km, default_g_co2_per_km, description) VALUES
+    ('WALK', 0, 0, 'Walking'),
+    ('BIKE', 0, 0, 'Cycling'),
+    ('CAR', 170, 170, 'Average petrol car, single occupant'),
+    ('TRAIN', 35, 35, 'Rail, per passenger'),
+    ('PLANE', 250, 250, 'Short-haul flight, per passenger'),
+    ('FLIGHT', 250, 250, 'Short-haul flight, per passenger');
+
+CREATE TABLE IF NOT EXISTS trip_carbon (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    trip_id INTEGER NOT NULL UNIQUE,
+    start_time INTEGER NOT NULL,
+    distance_m REAL DEFAULT 0,
+    co2_kg REAL DEFAULT 0,
+    mode_co2_json TEXT,                  -- {"CAR": 1.2, "WALK": 0}
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    FOREIGN KEY (trip_id) REFERENCES trips(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_carbon_start ON trip_carbon(start_time);
+CREATE INDEX IF NOT EXISTS idx_trip_carbon_co2 ON trip_carbon(co2_kg DESC);
+
+CREATE TABLE IF NOT EXISTS carbon_stats_bucketed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_type TEXT NOT NULL,           -- 'year', 'month'
+    bucket_key TEXT NOT NULL,            -- '2025', '2025-01'
+    distance_m REAL DEFAULT 0,
+    co2_kg REAL DEFAULT 0,
+    trip_count INTEGER DEFAULT 0,
+    mode_co2_json TEXT,                  -- {"CAR": 120.5, "TRAIN": 8.1}
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    UNIQUE(bucket_type, bucket_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_carbon_bucket ON carbon_stats_bucketed(bucket_type, bucket_key);
+
+UPDATE threshold_profiles
+SET params_json = json_remove(params_json, '$.mode_stats'),
+    updated_at = CURRENT_TIMESTAMP
+WHERE json_extract(params_json, '$.mode_stats') IS NOT NULL;
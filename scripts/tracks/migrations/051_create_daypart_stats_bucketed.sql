@@ -0,0 +1,50 @@
+-- Migration 051: Movement by part of the day
+-- Purpose: DaypartStatsAnalyzer splits distance, active time and visited areas
+--          into morning, afternoon, evening and night, by year and month, in
+--          the timezone and with the boundaries set in the threshold profile.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+CREATE TABLE IF NOT EXISTS daypart_stats_bucketed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Bucketing dimensions
+    bucket_type TEXT NOT NULL,           -- 'year', 'month', 'all'
+    bucket_key TEXT NOT NULL,            -- '2025', '2025-01', 'all'
+    source TEXT NOT NULL DEFAULT 'all',  -- 'all' or a track point source
+    daypart TEXT NOT NULL,               -- 'MORNING', 'AFTERNOON', 'EVENING', 'NIGHT'
+
+    -- Totals
+    distance_m REAL DEFAULT 0,
+    active_s INTEGER DEFAULT 0,          -- Time spent moving
+    tracked_s INTEGER DEFAULT 0,         -- Time covered by the track, moving or not
+    point_count INTEGER DEFAULT 0,
+    day_count INTEGER DEFAULT 0,         -- Days with points in this part of the day
+    grid_count INTEGER DEFAULT 0,        -- Distinct grid cells visited
+    city_count INTEGER DEFAULT 0,        -- Distinct cities visited
+
+    -- Share of the bucket's total across the four parts of the day (0-1)
+    distance_share REAL DEFAULT 0,
+    active_share REAL DEFAULT 0,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, source, daypart)
+);
+
+CREATE INDEX IF NOT EXISTS idx_daypart_stats_bucket ON daypart_stats_bucketed(bucket_type, bucket_key);
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.daypart_stats.morning_start_hour', 5,
+        '$.daypart_stats.afternoon_start_hour', 12,
+        '$.daypart_stats.evening_start_hour', 18,
+        '$.daypart_stats.night_start_hour', 22,
+        '$.daypart_stats.timezone', '',
+        '$.daypart_stats.max_gap_s', 300,
+        '$.daypart_stats.active_speed_mps', 0.5
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
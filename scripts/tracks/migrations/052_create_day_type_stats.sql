@@ -0,0 +1,42 @@
+-- Migration 052: Workday, weekend and public holiday comparison
+-- Purpose: DayTypeStatsAnalyzer splits the daily summaries and the stays into
+--          workdays, weekends and Chinese public holidays (make-up workdays
+--          count as workdays), overall and per year, so behaviour can be
+--          compared across calendar categories.
+
+CREATE TABLE IF NOT EXISTS day_type_stats_bucketed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Bucketing dimensions
+    bucket_type TEXT NOT NULL,           -- 'year', 'all'
+    bucket_key TEXT NOT NULL,            -- '2025', 'all'
+    day_type TEXT NOT NULL,              -- 'WORKDAY', 'WEEKEND', 'HOLIDAY'
+
+    -- Days with data
+    day_count INTEGER DEFAULT 0,
+
+    -- Movement
+    total_distance_m REAL DEFAULT 0,
+    avg_distance_m REAL DEFAULT 0,       -- Per day
+    avg_active_time_s REAL DEFAULT 0,    -- Per day
+    trip_count INTEGER DEFAULT 0,
+    avg_trip_count REAL DEFAULT 0,       -- Per day
+    distance_by_mode TEXT,               -- JSON object, mode -> meters
+
+    -- Places
+    city_count INTEGER DEFAULT 0,        -- Distinct cities visited
+    avg_city_count REAL DEFAULT 0,       -- Cities visited per day
+
+    -- Stays by label: stay annotation, else place cluster type, else UNLABELED
+    stay_count INTEGER DEFAULT 0,
+    stay_duration_s INTEGER DEFAULT 0,
+    stay_types TEXT,                     -- JSON object, label -> {count, duration_s}
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1',
+
+    UNIQUE(bucket_type, bucket_key, day_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_day_type_stats_bucket ON day_type_stats_bucketed(bucket_type, bucket_key);
@@ -0,0 +1,45 @@
+-- Migration 053: Anomalous day detection
+-- Purpose: AnomalousDaysAnalyzer scores each day's distance, radius of
+--          gyration, number of stays and newly visited grid cells against the
+--          preceding days, and keeps the days that stand out (travel days,
+--          unusual routines, unusually quiet days) with the reasons why.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+CREATE TABLE IF NOT EXISTS anomalous_days (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date TEXT NOT NULL UNIQUE,           -- YYYY-MM-DD (local time)
+
+    -- The day's behaviour
+    distance_m REAL DEFAULT 0,
+    radius_of_gyration_m REAL DEFAULT 0,
+    stay_count INTEGER DEFAULT 0,
+    novel_grid_count INTEGER DEFAULT 0,  -- Grid cells visited for the first time
+
+    -- Robust z-scores against the baseline (median / MAD of the preceding days)
+    baseline_days INTEGER DEFAULT 0,     -- Days the baseline was built from
+    distance_z REAL DEFAULT 0,
+    radius_z REAL DEFAULT 0,
+    stay_z REAL DEFAULT 0,
+    novel_z REAL DEFAULT 0,
+    score REAL DEFAULT 0,                -- Largest absolute z-score
+
+    category TEXT NOT NULL,              -- 'TRAVEL_DAY', 'UNUSUAL_ROUTINE', 'QUIET_DAY'
+    reasons TEXT,                        -- JSON array, e.g. ["DISTANCE_HIGH","NOVEL_CELLS_HIGH"]
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1'
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomalous_days_score ON anomalous_days(score DESC);
+CREATE INDEX IF NOT EXISTS idx_anomalous_days_category ON anomalous_days(category, date);
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.anomalous_days.baseline_days', 90,
+        '$.anomalous_days.min_baseline_days', 14,
+        '$.anomalous_days.z_threshold', 3.5
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
@@ -0,0 +1,56 @@
+-- Migration 054: Exploration vs exploitation tracker
+-- Purpose: ExplorationAnalyzer splits each month's points and tracked time
+--          into grid cells visited for the first time (exploration) and cells
+--          visited before (exploitation), keeps the cumulative number of cells
+--          ever visited, and records runs of consecutive days that each
+--          discovered at least one new cell.
+-- json_insert leaves keys that are already set untouched, so re-running keeps tuned values
+
+CREATE TABLE IF NOT EXISTS exploration_monthly (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    month TEXT NOT NULL UNIQUE,          -- YYYY-MM (local time)
+
+    -- Points with a grid cell, and the time the track covers
+    point_count INTEGER DEFAULT 0,
+    novel_point_count INTEGER DEFAULT 0, -- In a cell on the day it was first visited
+    tracked_s INTEGER DEFAULT 0,
+    novel_time_s INTEGER DEFAULT 0,
+    novel_point_ratio REAL DEFAULT 0,    -- 0-1
+    novel_time_ratio REAL DEFAULT 0,     -- 0-1
+
+    -- Cells
+    new_cell_count INTEGER DEFAULT 0,    -- First visited this month
+    cumulative_cell_count INTEGER DEFAULT 0, -- Ever visited by the end of the month
+
+    -- Days
+    active_days INTEGER DEFAULT 0,       -- Days with points
+    discovery_days INTEGER DEFAULT 0,    -- Days with at least one new cell
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1'
+);
+
+CREATE TABLE IF NOT EXISTS discovery_streaks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_date TEXT NOT NULL,            -- YYYY-MM-DD
+    end_date TEXT NOT NULL,              -- YYYY-MM-DD
+    days_count INTEGER NOT NULL,
+    new_cell_count INTEGER DEFAULT 0,
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1'
+);
+
+CREATE INDEX IF NOT EXISTS idx_discovery_streaks_days ON discovery_streaks(days_count DESC);
+CREATE INDEX IF NOT EXISTS idx_discovery_streaks_start ON discovery_streaks(start_date);
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.exploration.max_gap_s', 300,
+        '$.exploration.min_streak_days', 2
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
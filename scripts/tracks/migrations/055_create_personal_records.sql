@@ -0,0 +1,31 @@
+-- Migration 055: Personal records
+-- Purpose: PersonalRecordsAnalyzer keeps one row per record (longest run of
+--          tracked days, longest day, most cities in a day, longest stay,
+--          fastest ground segment, ...) with references to the day, point,
+--          segment, trip or stay that set it.
+
+CREATE TABLE IF NOT EXISTS personal_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    record_type TEXT NOT NULL UNIQUE,    -- e.g. 'LONGEST_DAY_DISTANCE'
+    value REAL NOT NULL,
+    unit TEXT NOT NULL,                  -- 'm', 's', 'km/h', 'days', 'cities'
+
+    -- When the record was set
+    start_time INTEGER,
+    end_time INTEGER,
+    date TEXT,                           -- YYYY-MM-DD (local time) of the start
+    end_date TEXT,                       -- YYYY-MM-DD of the end, for multi-day records
+
+    -- What set it
+    point_id INTEGER,
+    segment_id INTEGER,
+    trip_id INTEGER,
+    stay_id INTEGER,
+    latitude REAL,
+    longitude REAL,
+    details TEXT,                        -- JSON object, record specific
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1'
+);
@@ -0,0 +1,36 @@
+-- Migration 056: Insights
+-- Purpose: InsightsAnalyzer runs the rule catalogue of internal/analysis/insights
+--          over every month and year and stores each finding as a rendered,
+--          human-readable sentence ("You visited 3 new counties in May 2025")
+--          with the numbers behind it.
+
+CREATE TABLE IF NOT EXISTS insights (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_type TEXT NOT NULL,           -- 'month', 'year'
+    bucket_key TEXT NOT NULL,            -- '2025-05', '2025'
+    rule TEXT NOT NULL,                  -- Catalogue rule, e.g. 'new_counties'
+    category TEXT NOT NULL,              -- FOOTPRINT, DISTANCE, COMMUTE, EXPLORATION, RECORD
+    position INTEGER NOT NULL,           -- Order of the rule in the catalogue
+    message TEXT NOT NULL,
+    value REAL,                          -- Headline number of the finding
+    change_pct REAL,                     -- Change from the previous bucket, when compared
+    details TEXT,                        -- JSON object, the template data
+
+    -- Metadata
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    algo_version TEXT DEFAULT 'v1'
+);
+
+CREATE INDEX IF NOT EXISTS idx_insights_bucket ON insights(bucket_type, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_insights_rule ON insights(rule);
+
+UPDATE threshold_profiles
+SET params_json = json_insert(
+        params_json,
+        '$.insights.min_change_pct', 10,
+        '$.insights.min_commute_trips', 3,
+        '$.insights.min_novel_ratio', 0.2,
+        '$.insights.max_examples', 3
+    ),
+    updated_at = CURRENT_TIMESTAMP
+WHERE name = 'default';
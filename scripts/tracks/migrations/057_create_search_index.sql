@@ -0,0 +1,17 @@
+-- Migration 057: Full-text search index
+-- Purpose: SearchIndexAnalyzer fills an FTS5 table with one document per stay
+--          (label, note, admin names), trip (origin and destination), place
+--          (visited admin area) and event (flight, train or anomalous day),
+--          so GET /api/v1/search can serve a universal search box.
+-- Dates are indexed as YYYY-MM-DD text, so "2024-05" matches every day of May 2024.
+
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+    kind UNINDEXED,                      -- stay, trip, place, event
+    subtype UNINDEXED,                   -- e.g. HOME, CAR, CITY, FLIGHT, ANOMALOUS_DAY
+    ref_id UNINDEXED,                    -- id in stay_segments, trips, footprint_statistics, journeys or anomalous_days
+    title,
+    body,
+    date,                                -- YYYY-MM-DD (local time)
+    start_time UNINDEXED,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
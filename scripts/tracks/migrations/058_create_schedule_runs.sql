@@ -0,0 +1,24 @@
+-- Migration 058: Scheduled analysis runs
+-- Purpose: The built-in scheduler (internal/scheduler) runs the analysis chain
+--          on cron schedules, incrementally every night and as a full
+--          recompute every week. Each run is recorded here, including those
+--          skipped because no track points arrived since the schedule last
+--          completed.
+-- The point watermark (count and largest id of "一生足迹") is what "new data"
+-- is measured against.
+
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule TEXT NOT NULL,              -- Schedule name, e.g. 'incremental', 'full'
+    cron TEXT NOT NULL,                  -- Cron expression at the time of the run
+    task_type TEXT NOT NULL,             -- INCREMENTAL, FULL_RECOMPUTE
+    status TEXT NOT NULL,                -- running, completed, failed, skipped
+    reason TEXT,                         -- Why the run was skipped or failed
+    point_count INTEGER NOT NULL DEFAULT 0,
+    max_point_id INTEGER NOT NULL DEFAULT 0,
+    task_ids TEXT,                       -- JSON array of the analysis tasks run
+    started_at INTEGER NOT NULL,
+    finished_at INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule, started_at);
@@ -0,0 +1,28 @@
+-- Migration 059: Watch-folder imports
+-- Purpose: With IMPORT_WATCH_DIR set, track files dropped into that directory
+--          (e.g. phone exports synced by Syncthing or Dropbox) are imported
+--          automatically and moved to the archive or failed directory. Each
+--          file is recorded here; its SHA-256 lets a re-synced copy of an
+--          imported file be archived without importing it again.
+
+CREATE TABLE IF NOT EXISTS watch_imports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    file_name TEXT NOT NULL,             -- Name of the file in the watch directory
+    archived_path TEXT,                  -- Where the file was moved after processing
+    sha256 TEXT NOT NULL,
+    size INTEGER NOT NULL DEFAULT 0,     -- Bytes
+    format TEXT,                         -- gpx, kml, csv, json
+    status TEXT NOT NULL,                -- imported, duplicate, failed
+    error TEXT,
+    parsed_points INTEGER NOT NULL DEFAULT 0,
+    inserted_points INTEGER NOT NULL DEFAULT 0,
+    replaced_points INTEGER NOT NULL DEFAULT 0,
+    duplicate_points INTEGER NOT NULL DEFAULT 0,
+    start_time INTEGER,                  -- Time range of the imported points
+    end_time INTEGER,
+    analysis_task_ids TEXT,              -- JSON array of the incremental analysis tasks triggered
+    imported_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_imports_sha256 ON watch_imports(sha256, status);
+CREATE INDEX IF NOT EXISTS idx_watch_imports_imported_at ON watch_imports(imported_at);
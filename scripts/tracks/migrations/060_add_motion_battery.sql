@@ -0,0 +1,11 @@
+-- Migration 060: Phone motion and battery on track points
+-- Purpose: Phone apps pushing live locations (Overland, GPSLogger, OwnTracks)
+--          report the motion activity detected by the phone and its battery.
+--          motion is a comma-separated list of stationary, walking, running,
+--          cycling and driving; battery_level is a percentage and
+--          battery_state one of charging, unplugged and full. All are NULL for
+--          points that did not carry them.
+
+ALTER TABLE "一生足迹" ADD COLUMN motion TEXT;
+ALTER TABLE "一生足迹" ADD COLUMN battery_level REAL;
+ALTER TABLE "一生足迹" ADD COLUMN battery_state TEXT;
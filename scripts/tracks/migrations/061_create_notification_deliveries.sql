@@ -0,0 +1,21 @@
+-- Migration 061: Notification deliveries
+-- Purpose: Events (analysis task finished, personal record or extreme event
+--          beaten, track file imported) are sent to the configured webhooks,
+--          Telegram chat and Bark device. Each delivery is recorded here after
+--          its last attempt, so failed ones can be seen from the admin API.
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id TEXT NOT NULL,              -- Shared by the deliveries of one event to several channels
+    event_type TEXT NOT NULL,            -- analysis.completed, analysis.failed, record.new, import.completed, import.failed, test
+    title TEXT NOT NULL,
+    channel TEXT NOT NULL,               -- Channel name without secrets
+    status TEXT NOT NULL,                -- delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 1,
+    error TEXT,                          -- Error of the last attempt
+    event_time INTEGER NOT NULL,
+    delivered_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_event_time ON notification_deliveries(event_time);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_type ON notification_deliveries(event_type, status);
@@ -0,0 +1,14 @@
+-- Migration 062: Analyzer algorithm versions
+-- Purpose: Each Go analyzer registers the version of its algorithm
+--          (analysis.RegisterVersion). When a task rebuilds all of an
+--          analyzer's outputs, the version it ran with is recorded here, so
+--          outputs computed by an older version, or before a dependency was
+--          rebuilt, can be reported stale and recomputed.
+-- Analyzers are seeded with their current version at startup, with
+-- computed_at 0, as the baseline for data computed before versions were tracked.
+
+CREATE TABLE IF NOT EXISTS analyzer_versions (
+    skill_name TEXT PRIMARY KEY,
+    algo_version TEXT NOT NULL,          -- Version the outputs were last fully computed with
+    computed_at INTEGER NOT NULL         -- Unix seconds, 0 for the baseline
+);
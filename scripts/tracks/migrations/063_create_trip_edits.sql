@@ -0,0 +1,16 @@
+-- Migration 063: Create trip_edits table
+-- Purpose: Manual splits and merges of trips. Trips are rebuilt from the
+--          segments on every trip_construction run, so edits are kept here,
+--          keyed by time rather than trip ID, and replayed by the analyzer
+--          when it decides where one trip ends and the next begins. Later
+--          edits override earlier ones.
+
+CREATE TABLE IF NOT EXISTS trip_edits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    action TEXT NOT NULL,             -- 'split' or 'merge'
+    split_ts INTEGER,                 -- split: segments starting at or after it begin a new trip
+    from_ts INTEGER,                  -- merge: segments starting within [from_ts, to_ts] stay in one trip
+    to_ts INTEGER,
+    note TEXT,
+    created_at INTEGER NOT NULL
+);
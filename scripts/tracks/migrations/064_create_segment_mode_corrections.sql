@@ -0,0 +1,19 @@
+-- Migration 064: Create segment_mode_corrections table
+-- Purpose: Manual corrections of the transport mode of segments. Segments
+--          are rebuilt on every full transport_mode run, so corrections are
+--          kept here, keyed by the corrected time span rather than segment
+--          ID, and reapplied to the segments covering that span. Their speeds
+--          also calibrate the analyzer's speed cutoffs.
+
+CREATE TABLE IF NOT EXISTS segment_mode_corrections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_time INTEGER NOT NULL,      -- Span of the corrected segment
+    end_time INTEGER NOT NULL,
+    original_mode TEXT NOT NULL,      -- Mode before the correction
+    mode TEXT NOT NULL,               -- Corrected mode
+    avg_speed_kmh REAL NOT NULL DEFAULT 0,
+    note TEXT,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_segment_mode_corrections_time ON segment_mode_corrections(start_time, end_time);
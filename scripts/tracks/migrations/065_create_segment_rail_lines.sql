@@ -0,0 +1,24 @@
+-- Migration 065: Create segment_rail_lines table
+-- Skill: rail_lines (High-speed rail line recognition)
+-- Purpose: The high-speed rail lines TRAIN segments travel, matched against
+--          the embedded line traces. A segment running along several lines
+--          has one row per line with the distance travelled on it; the line
+--          covering most of it labels the segment, and trip construction
+--          copies the labels of a trip's segments to the trip.
+
+CREATE TABLE IF NOT EXISTS segment_rail_lines (
+    segment_id INTEGER NOT NULL,
+    line TEXT NOT NULL,                  -- Line name, e.g. 京广高铁
+    distance_m REAL NOT NULL,            -- Distance travelled along the line
+    share REAL NOT NULL,                 -- Fraction of the segment's distance
+    start_time INTEGER NOT NULL,         -- Start of the segment, for date filters
+    algo_version TEXT,
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    PRIMARY KEY (segment_id, line),
+    FOREIGN KEY (segment_id) REFERENCES segments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_segment_rail_lines_line ON segment_rail_lines(line, start_time);
+
+ALTER TABLE segments ADD COLUMN rail_line TEXT;   -- Line covering most of the segment
+ALTER TABLE trips ADD COLUMN rail_lines TEXT;     -- JSON array of the lines of the trip's segments
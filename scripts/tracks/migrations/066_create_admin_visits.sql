@@ -0,0 +1,30 @@
+-- Migration 066: Create admin_visits table
+-- Skill: admin_crossings (Admin Crossings Detection)
+-- Purpose: Pair the boundary crossings into visits: one row per stay in a
+--          country, province or city, from the crossing into it to the
+--          crossing out of it, for a travel log. Crossings now also record
+--          country changes and the time of the point before the boundary,
+--          which is when the region left was last seen.
+
+ALTER TABLE admin_crossings ADD COLUMN from_country TEXT;
+ALTER TABLE admin_crossings ADD COLUMN to_country TEXT;
+ALTER TABLE admin_crossings ADD COLUMN from_ts INTEGER;   -- Last point before the crossing
+
+CREATE TABLE IF NOT EXISTS admin_visits (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    admin_level TEXT NOT NULL,          -- COUNTRY/PROVINCE/CITY
+    admin_name TEXT NOT NULL,
+    parent_name TEXT,                   -- Province of a city, country of a province
+    arrival_ts INTEGER NOT NULL,        -- First point in the region
+    departure_ts INTEGER NOT NULL,      -- Last point in the region
+    duration_s INTEGER NOT NULL,
+    entered_from TEXT,                  -- Region left on arrival; NULL for the first visit
+    exited_to TEXT,                     -- Region entered on departure; NULL while still there
+    arrival_lat REAL,
+    arrival_lon REAL,
+    algo_version TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_visits_level ON admin_visits(admin_level, arrival_ts);
+CREATE INDEX IF NOT EXISTS idx_admin_visits_name ON admin_visits(admin_name);
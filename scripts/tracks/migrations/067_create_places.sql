@@ -0,0 +1,39 @@
+-- Migration 067: Create places table
+-- Skill: place_clustering (Place Clustering)
+-- Purpose: Persistent places clustered from stay segments across time: the
+--          stays whose centers fall in the same geohash neighbourhood are
+--          one place, with visit statistics. Places keep their id across
+--          re-clustering so a name given through the API sticks; stays,
+--          their annotations and trip endpoints reference the place.
+
+CREATE TABLE IF NOT EXISTS places (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    geohash TEXT NOT NULL UNIQUE,       -- Seed cell (geohash7) the place grows from
+    name TEXT,                          -- Given through the API; NULL until named
+    label TEXT,                         -- Most common confirmed stay annotation, e.g. HOME
+    center_lat REAL NOT NULL,
+    center_lon REAL NOT NULL,
+    radius_m REAL DEFAULT 0,            -- Farthest stay center from the place center
+    province TEXT,
+    city TEXT,
+    county TEXT,
+    visit_count INTEGER DEFAULT 0,
+    total_duration_s INTEGER DEFAULT 0,
+    first_visit_ts INTEGER,
+    last_visit_ts INTEGER,
+    algo_version TEXT,
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    updated_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER))
+);
+
+CREATE INDEX IF NOT EXISTS idx_places_visits ON places(visit_count DESC);
+CREATE INDEX IF NOT EXISTS idx_places_name ON places(name);
+
+ALTER TABLE stay_segments ADD COLUMN place_id INTEGER;
+ALTER TABLE stay_annotations ADD COLUMN place_id INTEGER;
+ALTER TABLE trips ADD COLUMN origin_place_id INTEGER;
+ALTER TABLE trips ADD COLUMN dest_place_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_stay_segments_place ON stay_segments(place_id);
+CREATE INDEX IF NOT EXISTS idx_trips_origin_place ON trips(origin_place_id);
+CREATE INDEX IF NOT EXISTS idx_trips_dest_place ON trips(dest_place_id);
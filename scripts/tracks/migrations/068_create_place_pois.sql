@@ -0,0 +1,25 @@
+-- Migration 068: Create place_pois table
+-- Skill: poi_enrichment (POI Enrichment)
+-- Purpose: Named venues near each place, nearest first, looked up from the
+--          configured POI provider (Amap, OSM Overpass or an offline
+--          snapshot). Stay label suggestions use the venue categories to go
+--          beyond time-of-day heuristics.
+
+CREATE TABLE IF NOT EXISTS place_pois (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    place_id INTEGER NOT NULL REFERENCES places(id) ON DELETE CASCADE,
+    rank INTEGER NOT NULL,              -- 1 for the nearest venue
+    name TEXT NOT NULL,
+    category TEXT NOT NULL,             -- FOOD/SHOPPING/LEISURE/LODGING/TRANSPORT/OFFICE/EDUCATION/HEALTH/RESIDENTIAL/OTHER
+    kind TEXT,                          -- Provider type, e.g. amenity=cafe or an Amap type code
+    lat REAL NOT NULL,
+    lon REAL NOT NULL,
+    distance_m REAL NOT NULL,           -- From the place center
+    provider TEXT NOT NULL,             -- amap/overpass/snapshot
+    algo_version TEXT,
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER))
+);
+
+CREATE INDEX IF NOT EXISTS idx_place_pois_place ON place_pois(place_id, rank);
+
+ALTER TABLE places ADD COLUMN poi_checked_at INTEGER;   -- Last venue lookup; NULL until looked up
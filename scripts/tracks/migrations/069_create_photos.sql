@@ -0,0 +1,28 @@
+-- Migration 069: Create photos table
+-- Purpose: Where and when photos were taken, read from their EXIF geotags
+--          (a scanned PHOTO_DIR, including Google Takeout sidecars) or from
+--          an Immich server. Photos without a geotag are placed on the track
+--          at the time they were taken. Trips and stays find their photos by
+--          time range, so the links survive recomputing them.
+
+CREATE TABLE IF NOT EXISTS photos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,                -- dir, immich
+    external_id TEXT NOT NULL,           -- Path relative to PHOTO_DIR, or the Immich asset ID
+    file_name TEXT NOT NULL,
+    taken_at INTEGER NOT NULL,           -- Unix timestamp
+    lat REAL,
+    lon REAL,
+    altitude REAL,
+    location_source TEXT,                -- exif, sidecar, immich, track; NULL when not located
+    camera_make TEXT,
+    camera_model TEXT,
+    width INTEGER,
+    height INTEGER,
+    file_size INTEGER,                   -- Bytes, with file_mtime used to skip unchanged files on rescans
+    file_mtime INTEGER,
+    imported_at INTEGER NOT NULL,
+    UNIQUE (source, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_photos_taken_at ON photos(taken_at);
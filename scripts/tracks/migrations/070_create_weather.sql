@@ -0,0 +1,43 @@
+-- Migration 070: Create weather tables
+-- Skill: weather_enrichment (Weather Enrichment)
+-- Purpose: Historical weather at the visited locations, looked up from the
+--          configured weather provider (Open-Meteo archive or offline ERA5
+--          extracts) on a 0.25° grid. weather_hourly keeps the hours of each
+--          visited day and cell; weather_daily aggregates them per local day
+--          and cell, and trips get the weather along their route, so trips
+--          can be filtered by weather and distance split by rain.
+
+CREATE TABLE IF NOT EXISTS weather_hourly (
+    cell TEXT NOT NULL,                 -- Grid point, e.g. "23.00,113.25"
+    ts INTEGER NOT NULL,                -- Unix timestamp of the hour
+    temp_c REAL NOT NULL,               -- 2 m temperature at ts
+    precip_mm REAL NOT NULL,            -- Precipitation in the hour ending at ts
+    provider TEXT NOT NULL,             -- openmeteo/era5
+    PRIMARY KEY (cell, ts)
+);
+
+CREATE TABLE IF NOT EXISTS weather_daily (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    date TEXT NOT NULL,                 -- Local date, YYYY-MM-DD
+    cell TEXT NOT NULL,
+    lat REAL NOT NULL,                  -- Grid point
+    lon REAL NOT NULL,
+    point_count INTEGER NOT NULL,       -- Track points in the cell that day
+    is_primary INTEGER NOT NULL DEFAULT 0, -- 1 for the cell with most points that day
+    hour_count INTEGER NOT NULL,        -- Hours with data; 0 when the provider had none
+    temp_min_c REAL,
+    temp_max_c REAL,
+    temp_mean_c REAL,
+    precip_mm REAL,
+    rain_hours INTEGER,                 -- Hours with at least the wet threshold of precipitation
+    provider TEXT NOT NULL,
+    algo_version TEXT,
+    created_at INTEGER DEFAULT (CAST(strftime('%s', 'now') AS INTEGER)),
+    UNIQUE (date, cell)
+);
+
+CREATE INDEX IF NOT EXISTS idx_weather_daily_primary ON weather_daily(is_primary, date);
+
+ALTER TABLE trips ADD COLUMN weather_temp_c REAL;      -- Mean temperature along the trip
+ALTER TABLE trips ADD COLUMN weather_precip_mm REAL;   -- Precipitation along the trip
+ALTER TABLE trips ADD COLUMN weather_wet INTEGER;      -- 1 if it rained during the trip; NULL until looked up
@@ -0,0 +1,8 @@
+-- Migration 071: Resume interrupted analysis tasks
+-- Purpose: Tasks still running when the server shuts down are cancelled and
+--          marked 'interrupted' instead of being left 'running' forever.
+--          Interrupted tasks are started again on the next startup (or on
+--          request); resume_count bounds the automatic retries of a task
+--          that keeps being interrupted, e.g. by crashing the server.
+
+ALTER TABLE analysis_tasks ADD COLUMN resume_count INTEGER NOT NULL DEFAULT 0;  -- Times the task was started again after an interruption
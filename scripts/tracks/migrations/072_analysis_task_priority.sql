@@ -0,0 +1,7 @@
+-- Migration 072: Analysis task priority
+-- Purpose: Analysis tasks wait in a job queue for one of a bounded number of
+--          workers. Tasks started by a user (API requests, edits) go before
+--          background tasks (scheduler, automatic import, live ingest); the
+--          priority is kept so that a resumed task queues as it did before.
+
+ALTER TABLE analysis_tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;  -- 0 background, 10 user-triggered
@@ -0,0 +1,49 @@
+-- Migration 073: Covering indexes for the hot queries
+-- Purpose: Time-range reads (playback, segment points, distributions) filter
+--          on outlier_flag, which nearly every point shares; the planner
+--          still preferred idx_outlier_flag over idx_datatime and scanned the
+--          whole table. The time index now carries outlier_flag and the
+--          coordinates, so those reads never touch the table, and outliers
+--          keep a partial index of their own. Admin and grid indexes gain
+--          dataTime for filtered, time-ordered reads, and the bucketed stats
+--          tables get source-first keys matching how they are queried (their
+--          UNIQUE keys start with bucket_type, bucket_key and cover the rest).
+--          GET /api/v1/admin/explain shows the resulting plans.
+-- Building the indexes rewrites the point table's indexes once and can take a
+-- minute on large databases.
+
+-- Track points: time ranges
+DROP INDEX IF EXISTS idx_outlier_flag;
+DROP INDEX IF EXISTS idx_datatime;
+CREATE INDEX IF NOT EXISTS idx_track_time_cover ON "一生足迹"(dataTime, outlier_flag, latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_track_outliers ON "一生足迹"(outlier_flag) WHERE outlier_flag = 1;
+
+-- Track points: admin areas
+DROP INDEX IF EXISTS idx_admin_full;
+DROP INDEX IF EXISTS idx_admin_province;
+CREATE INDEX IF NOT EXISTS idx_track_admin_time ON "一生足迹"(province, city, county, dataTime);
+CREATE INDEX IF NOT EXISTS idx_track_time_admin ON "一生足迹"(dataTime, province, city, county);
+
+-- Track points: grid cells
+DROP INDEX IF EXISTS idx_grid_id;
+CREATE INDEX IF NOT EXISTS idx_track_grid ON "一生足迹"(grid_id, outlier_flag, dataTime);
+
+-- Bucketed stats: the (bucket_type, bucket_key) indexes duplicate a prefix of the UNIQUE keys
+DROP INDEX IF EXISTS idx_speed_space_bucket;
+DROP INDEX IF EXISTS idx_util_bucket;
+DROP INDEX IF EXISTS idx_altitude_bucket;
+DROP INDEX IF EXISTS idx_tsc_bucket;
+DROP INDEX IF EXISTS idx_directional_bucketed_bucket;
+DROP INDEX IF EXISTS idx_density_bucket;
+DROP INDEX IF EXISTS idx_mode_stats_bucket;
+DROP INDEX IF EXISTS idx_daypart_stats_bucket;
+
+CREATE INDEX IF NOT EXISTS idx_speed_space_source ON speed_space_stats_bucketed(source, bucket_type, area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_util_source ON spatial_utilization_bucketed(source, bucket_type, area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_altitude_source ON altitude_stats_bucketed(source, bucket_type, area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_tsc_source ON time_space_compression_bucketed(source, bucket_type, area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_directional_bucketed_source
+    ON directional_stats_bucketed(source, bucket_type, mode_filter, area_type, area_key);
+CREATE INDEX IF NOT EXISTS idx_density_source ON spatial_density_grid_stats(source, bucket_type, density_level);
+CREATE INDEX IF NOT EXISTS idx_mode_stats_source ON mode_stats_bucketed(bucket_type, source, bucket_key);
+CREATE INDEX IF NOT EXISTS idx_daypart_stats_source ON daypart_stats_bucketed(bucket_type, source, bucket_key);
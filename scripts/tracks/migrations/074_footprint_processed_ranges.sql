@@ -0,0 +1,21 @@
+-- Migration 074: Footprint processed point ranges
+-- Purpose: footprint_statistics were upserted by adding counts, so every full
+--          run counted all points again on top of the previous totals. The
+--          analyzer now truncates the statistics before a full run and records
+--          the point id ranges it has counted, each in the same transaction as
+--          its counts; incremental runs resume after the last recorded range.
+--          Month, year and "all" rows are rolled up from day rows, so they no
+--          longer depend on how points were batched.
+-- Existing statistics have no recorded ranges, so the next footprint run,
+-- full or incremental, rebuilds them.
+
+CREATE TABLE IF NOT EXISTS footprint_processed_ranges (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    start_id INTEGER NOT NULL,  -- First point id of the range
+    end_id INTEGER NOT NULL,  -- Last point id of the range (inclusive)
+    point_count INTEGER NOT NULL,  -- Non-outlier points counted from the range
+    task_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_footprint_ranges_end ON footprint_processed_ranges(end_id);
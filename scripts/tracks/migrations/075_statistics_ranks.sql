@@ -0,0 +1,24 @@
+-- Migration 075: Persisted ranks of footprint and stay statistics
+-- Purpose: The rankings endpoints selected rank columns that were never
+--          created or computed. Footprint and stay statistics are now ranked
+--          after each run within their stat_type and time_range, and keep the
+--          rank the same key held in the previous period (year, month or day
+--          before) so rankings can show movement. A NULL previous rank means
+--          the key did not appear in the previous period, or the row is "all".
+-- Existing rows are ranked by the next run of their analyzer.
+
+ALTER TABLE footprint_statistics ADD COLUMN rank_by_points INTEGER;
+ALTER TABLE footprint_statistics ADD COLUMN rank_by_visits INTEGER;
+ALTER TABLE footprint_statistics ADD COLUMN rank_by_duration INTEGER;
+ALTER TABLE footprint_statistics ADD COLUMN prev_rank_by_points INTEGER;
+ALTER TABLE footprint_statistics ADD COLUMN prev_rank_by_visits INTEGER;
+ALTER TABLE footprint_statistics ADD COLUMN prev_rank_by_duration INTEGER;
+
+ALTER TABLE stay_statistics ADD COLUMN rank_by_count INTEGER;
+ALTER TABLE stay_statistics ADD COLUMN rank_by_duration INTEGER;
+ALTER TABLE stay_statistics ADD COLUMN prev_rank_by_count INTEGER;
+ALTER TABLE stay_statistics ADD COLUMN prev_rank_by_duration INTEGER;
+
+-- Rankings list one stat_type and time_range in rank order
+CREATE INDEX IF NOT EXISTS idx_footprint_rank ON footprint_statistics(stat_type, time_range, rank_by_points);
+CREATE INDEX IF NOT EXISTS idx_stay_rank ON stay_statistics(stat_type, time_range, rank_by_count);
@@ -0,0 +1,27 @@
+-- Migration 076: Administrative areas of segments
+-- Purpose: Segments had no area of their own, so segment stats could only be
+--          kept for everywhere (movement_intensity wrote ALL rows only) and
+--          segment lists joined the start point for it. A segment now carries
+--          the province, city and county of its start point, or of its end
+--          point when the start point is not geocoded, set when the segment
+--          is constructed.
+-- Segments built before their points were geocoded keep empty areas until
+-- transport_mode runs again.
+
+ALTER TABLE segments ADD COLUMN province TEXT;
+ALTER TABLE segments ADD COLUMN city TEXT;
+ALTER TABLE segments ADD COLUMN county TEXT;
+
+UPDATE segments SET
+    province = (SELECT p.province FROM "一生足迹" p
+        WHERE p.id IN (segments.start_point_id, segments.end_point_id) AND p.province != ''
+        ORDER BY p.dataTime LIMIT 1),
+    city = (SELECT p.city FROM "一生足迹" p
+        WHERE p.id IN (segments.start_point_id, segments.end_point_id) AND p.province != ''
+        ORDER BY p.dataTime LIMIT 1),
+    county = (SELECT p.county FROM "一生足迹" p
+        WHERE p.id IN (segments.start_point_id, segments.end_point_id) AND p.province != ''
+        ORDER BY p.dataTime LIMIT 1);
+
+CREATE INDEX IF NOT EXISTS idx_segments_province ON segments(province, start_time);
+CREATE INDEX IF NOT EXISTS idx_segments_city ON segments(city, start_time);
@@ -0,0 +1,10 @@
+-- Migration 077: Directional anisotropy
+-- Purpose: Directional histograms can have 8, 16 or 36 bins (num_bins, set
+--          per mode in the directional_bias thresholds) and are also kept per
+--          season (bucket_type 'season', e.g. '2024-winter'). Each histogram
+--          now records its dominant axis, travelled either way, and an
+--          anisotropy index comparing the distance along that axis with the
+--          distance across it.
+
+ALTER TABLE directional_stats_bucketed ADD COLUMN dominant_axis_deg REAL;   -- 0-180 degrees, 0 north-south
+ALTER TABLE directional_stats_bucketed ADD COLUMN anisotropy_index REAL;    -- 0-1, 0 when both axes are travelled alike
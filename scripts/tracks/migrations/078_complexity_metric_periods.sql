@@ -0,0 +1,9 @@
+-- Migration 078: Complexity metric periods
+-- Purpose: Spatial complexity is kept per day, per month and for all time, so
+--          the endpoint can return a time series. period_type tells the
+--          periods apart; metric_date is YYYY-MM-DD for days, YYYY-MM for
+--          months and NULL for all time.
+
+ALTER TABLE complexity_metrics ADD COLUMN period_type TEXT NOT NULL DEFAULT 'all';  -- 'day', 'month' or 'all'
+
+CREATE INDEX IF NOT EXISTS idx_complexity_metrics_period ON complexity_metrics(period_type, metric_date);
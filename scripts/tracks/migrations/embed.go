@@ -0,0 +1,11 @@
+// Package migrations embeds the SQL schema migrations so the server can apply
+// them on startup (see database.RunMigrations).
+package migrations
+
+import "embed"
+
+// FS holds the migration files, named NNN_description.sql and applied in
+// version order
+//
+//go:embed *.sql
+var FS embed.FS
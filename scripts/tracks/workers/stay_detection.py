@@ -247,6 +247,19 @@ class StayDetectionWorker:
                 stay['reason_codes'], stay['metadata']
             ))
 
+        # A stay belongs to the source that recorded most of its points
+        cursor.execute("""
+            UPDATE stay_segments
+            SET source = (
+                SELECT p.source FROM "一生足迹" p
+                WHERE p.dataTime BETWEEN stay_segments.start_time AND stay_segments.end_time
+                GROUP BY p.source
+                ORDER BY COUNT(*) DESC, p.source
+                LIMIT 1
+            )
+            WHERE source IS NULL
+        """)
+
         self.conn.commit()
 
     def mark_completed(self, summary):